@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,25 +20,46 @@ import (
 
 	_ "github.com/gjovanovicst/auth_api/docs" // docs is generated by Swag CLI
 	"github.com/gjovanovicst/auth_api/internal/admin"
+	"github.com/gjovanovicst/auth_api/internal/botdetect"
 	"github.com/gjovanovicst/auth_api/internal/bruteforce"
+	"github.com/gjovanovicst/auth_api/internal/config"
 	"github.com/gjovanovicst/auth_api/internal/database"
+	"github.com/gjovanovicst/auth_api/internal/disposable"
 	"github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/internal/environment"
 	"github.com/gjovanovicst/auth_api/internal/geoip"
 	"github.com/gjovanovicst/auth_api/internal/health"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/jwtkeys"
+	"github.com/gjovanovicst/auth_api/internal/livefeed"
 	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/metricshistory"
 	"github.com/gjovanovicst/auth_api/internal/middleware"
+	"github.com/gjovanovicst/auth_api/internal/notification"
 	"github.com/gjovanovicst/auth_api/internal/oidc"
+	"github.com/gjovanovicst/auth_api/internal/passhash"
 	"github.com/gjovanovicst/auth_api/internal/rbac"
+	"github.com/gjovanovicst/auth_api/internal/reauth"
 	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/reload"
+	"github.com/gjovanovicst/auth_api/internal/revocation"
+	"github.com/gjovanovicst/auth_api/internal/scheduler"
+	"github.com/gjovanovicst/auth_api/internal/secretaudit"
+	"github.com/gjovanovicst/auth_api/internal/server"
 	"github.com/gjovanovicst/auth_api/internal/session"
 	sessiongroup "github.com/gjovanovicst/auth_api/internal/sessiongroup"
 	"github.com/gjovanovicst/auth_api/internal/sms"
 	"github.com/gjovanovicst/auth_api/internal/social"
 	ssopkg "github.com/gjovanovicst/auth_api/internal/sso"
+	"github.com/gjovanovicst/auth_api/internal/storage"
 	"github.com/gjovanovicst/auth_api/internal/twofa"
+	"github.com/gjovanovicst/auth_api/internal/usage"
 	"github.com/gjovanovicst/auth_api/internal/user"
+	"github.com/gjovanovicst/auth_api/internal/usersync"
 	passkey "github.com/gjovanovicst/auth_api/internal/webauthn"
 	"github.com/gjovanovicst/auth_api/internal/webhook"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/gjovanovicst/auth_api/web"
 	"github.com/gjovanovicst/auth_api/web/static"
 	swaggerFiles "github.com/swaggo/files"
@@ -78,11 +107,50 @@ func main() {
 		log.Println("No .env file found, relying on environment variables")
 	}
 
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (default: config.yaml, config.yml or config.toml if present)")
+	printConfig := flag.Bool("print-effective-config", false, "Print the effective configuration with secrets redacted and exit")
+	auditSecrets := flag.Bool("audit-secrets", false, "Audit the strength of the configured secrets, print the findings and exit (status 1 on critical or high findings)")
+	flag.Parse()
+
+	// Optional config file; environment variables (and .env) take precedence
+	configPath := config.FindConfigFile(*configFile)
+	if configPath != "" {
+		if err := config.LoadConfigFile(configPath); err != nil {
+			log.Fatalf("Config file: %v", err)
+		}
+		log.Printf("Loaded config file %s", configPath)
+	}
+
 	// Initialize Viper for configuration management
 	viper.AutomaticEnv() // Read environment variables
 	viper.SetDefault("PORT", "8080")
 	viper.SetDefault("ACCESS_TOKEN_EXPIRATION_MINUTES", 15)
 	viper.SetDefault("REFRESH_TOKEN_EXPIRATION_HOURS", 720)
+	// Single-use email verification and password reset links
+	viper.SetDefault("EMAIL_VERIFICATION_TOKEN_TTL_MINUTES", 1440)
+	viper.SetDefault("PASSWORD_RESET_TOKEN_TTL_MINUTES", 60)
+	// 6-digit email verification codes (applications with email_verification_code set)
+	viper.SetDefault("EMAIL_VERIFICATION_CODE_TTL_MINUTES", 15)
+	viper.SetDefault("EMAIL_VERIFICATION_CODE_MAX_ATTEMPTS", 5)
+	// Lifetime of re-authentication proofs (POST /auth/challenge/verify)
+	viper.SetDefault("REAUTH_PROOF_TTL_SECONDS", 300)
+	// Longest lifetime of impersonation tokens (POST /app/:id/users/:user_id/impersonate)
+	viper.SetDefault("IMPERSONATION_TOKEN_MAX_TTL_MINUTES", 15)
+	// Longest lifetime of service account tokens (POST /admin/apps/:id/service-accounts/:account_id/tokens)
+	viper.SetDefault("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS", 365)
+	// In-memory bloom filter of revoked access token IDs (skips most blacklist lookups)
+	viper.SetDefault("REVOCATION_FILTER_ENABLED", true)
+	viper.SetDefault("REVOCATION_FILTER_REFRESH_INTERVAL", "1m")
+	// In-process cache of verified access tokens (0 disables)
+	viper.SetDefault("JWT_CACHE_SIZE", 10000)
+	// Token signing: HS256 (JWT_SECRET) or RS256/ES256 with keys published at /.well-known/jwks.json
+	viper.SetDefault("JWT_SIGNING_ALGORITHM", "HS256")
+	viper.SetDefault("JWT_ACCEPT_HS256", true)
+	viper.SetDefault("JWT_KEY_ROTATION_DAYS", 90)
+	// Every application signs with RS256/ES256 keys of its own (otherwise only those rotated under /admin/apps/:id/keys)
+	viper.SetDefault("JWT_PER_APP_KEYS", false)
+	// POST /admin/users/generate (synthetic load-test users); never enable in production
+	viper.SetDefault("LOAD_TEST_USER_GENERATION_ENABLED", false)
 	// OIDC provider configuration
 	viper.SetDefault("OIDC_ENABLED", false)
 	viper.SetDefault("OIDC_DEFAULT_APP_ID", "00000000-0000-0000-0000-000000000001")
@@ -97,6 +165,86 @@ func main() {
 	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
 	viper.SetDefault("OIDC_ID_TOKEN_EXPIRATION_MINUTES", 60)
 	viper.SetDefault("OIDC_AUTH_CODE_EXPIRATION_MINUTES", 10)
+	// Per-app statistics report cache (GET /admin/apps/:id/stats); 0 disables caching
+	viper.SetDefault("APP_STATS_CACHE_TTL_SECONDS", 300)
+	// Usage metering for billing (GET /admin/tenants/:id/usage)
+	viper.SetDefault("USAGE_METERING_ENABLED", true)
+	viper.SetDefault("USAGE_FLUSH_INTERVAL_SECONDS", 60)
+	viper.SetDefault("USAGE_REPORT_INTERVAL_MINUTES", 60)
+	// SMTP connection reuse per server (0 connections = dial per message)
+	viper.SetDefault("SMTP_POOL_MAX_CONNECTIONS", 4)
+	viper.SetDefault("SMTP_POOL_IDLE_TIMEOUT_SECONDS", 30)
+	// Batch email sends (POST /admin/apps/:id/send-email-batch)
+	viper.SetDefault("EMAIL_BATCH_MAX_RECIPIENTS", 1000)
+	viper.SetDefault("EMAIL_BATCH_MAX_RATE_PER_SECOND", 10)
+	// Hours an email idempotency key prevents sending the same email again
+	viper.SetDefault("EMAIL_IDEMPOTENCY_WINDOW_HOURS", 24)
+	// Disposable email domains rejected at registration and email change; the bundled
+	// list is extended by DISPOSABLE_EMAIL_LIST_URL (refreshed every N hours) if set
+	viper.SetDefault("DISPOSABLE_EMAIL_BLOCKING_ENABLED", true)
+	viper.SetDefault("DISPOSABLE_EMAIL_LIST_URL", "")
+	viper.SetDefault("DISPOSABLE_EMAIL_REFRESH_HOURS", 24)
+	// Registration bot detection: honeypot, time-to-submit, velocity per IP/ASN and an
+	// optional external fraud score; suspicious sign-ups get a CAPTCHA or are rejected
+	viper.SetDefault("REGISTRATION_BOT_DETECTION_ENABLED", false)
+	viper.SetDefault("REGISTRATION_MIN_SUBMIT_SECONDS", 0)
+	viper.SetDefault("REGISTRATION_MAX_PER_IP", 10)
+	viper.SetDefault("REGISTRATION_MAX_PER_ASN", 0)
+	viper.SetDefault("REGISTRATION_VELOCITY_WINDOW_MINUTES", 60)
+	viper.SetDefault("REGISTRATION_FRAUD_SCORE_URL", "")
+	viper.SetDefault("REGISTRATION_FRAUD_SCORE_TOKEN", "")
+	viper.SetDefault("REGISTRATION_FRAUD_SCORE_TIMEOUT_MS", 2000)
+	viper.SetDefault("REGISTRATION_FRAUD_CHALLENGE_SCORE", 70)
+	viper.SetDefault("REGISTRATION_FRAUD_DENY_SCORE", 90)
+	// Background job scheduler (GUI: /gui/scheduled-jobs)
+	viper.SetDefault("SCHEDULER_ENABLED", true)
+	// DB-backed job queue for long-running admin operations (GUI: /gui/jobs)
+	viper.SetDefault("JOB_QUEUE_ENABLED", true)
+	// Admin API audit capture: requests and responses with secrets redacted (GET /admin/audit-logs)
+	viper.SetDefault("ADMIN_AUDIT_CAPTURE_ENABLED", false)
+	viper.SetDefault("ADMIN_AUDIT_MAX_BODY_BYTES", 16384)
+	viper.SetDefault("ADMIN_AUDIT_SKIP_ROUTES", "")
+	viper.SetDefault("ADMIN_AUDIT_RETENTION_DAYS", 90)
+	// Error budget on the admin dashboard: availability target in percent (5xx = unavailable)
+	viper.SetDefault("SLO_AVAILABILITY_TARGET", 99.9)
+	// Metrics history behind the dashboard charts, kept in the database (no Prometheus needed)
+	viper.SetDefault("METRICS_HISTORY_ENABLED", true)
+	viper.SetDefault("METRICS_HISTORY_RETENTION_DAYS", 30)
+	// User sync: user changes relayed as user.* webhooks and to an optional Redis stream
+	viper.SetDefault("USER_SYNC_ENABLED", false)
+	viper.SetDefault("USER_SYNC_STREAM", "")
+	viper.SetDefault("USER_SYNC_STREAM_MAXLEN", 100000)
+	viper.SetDefault("USER_SYNC_POLL_INTERVAL_SECONDS", 5)
+	// Live admin dashboard: new activity events streamed over server-sent events
+	viper.SetDefault("ADMIN_DASHBOARD_LIVE_ENABLED", true)
+	// Scripted installs: bootstrap the first admin API key (POST /admin/bootstrap/api-key)
+	// with ADMIN_BOOTSTRAP_TOKEN, or over mutual TLS on ADMIN_BOOTSTRAP_MTLS_ADDR
+	viper.SetDefault("ADMIN_BOOTSTRAP_TOKEN", "")
+	viper.SetDefault("ADMIN_BOOTSTRAP_MTLS_ADDR", "")
+	// Separate listener for the admin GUI and admin API (/gui, /admin): a TCP address
+	// such as "127.0.0.1:9090" or "unix:/run/auth_api/admin.sock"; empty = serve them on PORT
+	viper.SetDefault("ADMIN_LISTEN_ADDR", "")
+	// Request limits applied before every handler (0 disables a limit): body bytes,
+	// multipart upload bytes, header values, and characters per string value
+	viper.SetDefault("REQUEST_MAX_BODY_BYTES", 2<<20)
+	viper.SetDefault("REQUEST_MAX_UPLOAD_BYTES", 16<<20)
+	viper.SetDefault("REQUEST_MAX_HEADERS", 100)
+	viper.SetDefault("REQUEST_MAX_FIELD_LENGTH", 262144)
+	viper.SetDefault("REQUEST_MAX_NAME_LENGTH", 255)
+	viper.SetDefault("REQUEST_MAX_DESCRIPTION_LENGTH", 4096)
+	// Built-in TLS on PORT: certificate files, or Let's Encrypt certificates for
+	// TLS_AUTOCERT_DOMAINS; TLS_REDIRECT_ADDR (e.g. ":80") redirects HTTP to HTTPS
+	viper.SetDefault("TLS_CERT_FILE", "")
+	viper.SetDefault("TLS_KEY_FILE", "")
+	viper.SetDefault("TLS_AUTOCERT_DOMAINS", "")
+	viper.SetDefault("TLS_AUTOCERT_EMAIL", "")
+	viper.SetDefault("TLS_AUTOCERT_CACHE_DIR", "autocert-cache")
+	viper.SetDefault("TLS_REDIRECT_ADDR", "")
+	viper.SetDefault("HTTP2_ENABLED", true)
+	// Admin GUI development: serve templates and static assets from GUI_WEB_DIR
+	// instead of the copies embedded in the binary, reloading templates on change
+	viper.SetDefault("GUI_DEV_MODE", false)
+	viper.SetDefault("GUI_WEB_DIR", "web")
 	// Trusted device cookie SameSite policy.
 	// "none"   = cross-origin deployments (Auth API and frontend on different domains — e.g. Planora).
 	//            SameSite=None requires Secure=true, which is enforced automatically.
@@ -104,10 +252,33 @@ func main() {
 	// "strict" = same-origin only (Auth API and frontend on the identical domain).
 	// Default is "none" to support cross-origin setups out of the box.
 	viper.SetDefault("TRUSTED_DEVICE_COOKIE_SAMESITE", "none")
+	// Key encrypting secrets stored in the settings table (falls back to JWT_SECRET)
+	viper.SetDefault("SETTINGS_ENCRYPTION_KEY", "")
+
+	if *printConfig {
+		if err := config.PrintEffectiveConfig(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
 
 	// Connect to database
 	database.ConnectDatabase()
 
+	if *auditSecrets {
+		report, err := secretaudit.Run(database.DB, secretaudit.Options{SensitiveSettingKeys: admin.SensitiveSettingKeys()})
+		if err != nil {
+			log.Fatalf("Secrets audit: %v", err)
+		}
+		if err := secretaudit.WriteText(os.Stdout, report); err != nil {
+			log.Fatalf("Failed to print the secrets audit: %v", err)
+		}
+		if report.Failed() {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Connect to Redis
 	redis.ConnectRedis()
 
@@ -116,6 +287,7 @@ func main() {
 
 	// Initialize GeoIP service (graceful degradation if not configured)
 	geoIPService := geoip.NewService(viper.GetString("GEOIP_DB_PATH"))
+	geoIPService.LoadASNDatabase(viper.GetString("GEOIP_ASN_DB_PATH"))
 
 	// Initialize Anomaly Detector (uses GeoIP if available)
 	anomalyDetector := logService.NewAnomalyDetector(database.DB, geoIPService)
@@ -143,17 +315,37 @@ func main() {
 	logRepo := logService.NewRepository(database.DB)
 	emailRepo := email.NewRepository(database.DB)
 	emailService := email.NewService(emailRepo, database.DB)
+	if maxConns := viper.GetInt("SMTP_POOL_MAX_CONNECTIONS"); maxConns > 0 {
+		emailService.EnableConnectionPool(maxConns, time.Duration(viper.GetInt("SMTP_POOL_IDLE_TIMEOUT_SECONDS"))*time.Second)
+		defer emailService.Shutdown()
+	}
+
+	// Usage metering (API calls, email sends, MAU per app) with optional billing reporter
+	var usageService *usage.Service
+	if viper.GetBool("USAGE_METERING_ENABLED") {
+		usageService = usage.NewService(usage.NewRepository(database.DB), usage.NewReporterFromConfig())
+		usageService.Start()
+		defer usageService.Shutdown()
+		emailService.SetSentCallback(usageService.RecordEmailSent)
+	}
 	rbacRepo := rbac.NewRepository(database.DB)
 	rbacService := rbac.NewService(rbacRepo)
 	rbacHandler := rbac.NewHandler(rbacService)
 	userService := user.NewService(userRepo, emailService, database.DB)
 	userService.LookupRoles = rbacService.GetUserRoleNames
 	userService.AssignDefaultRole = rbacService.AssignDefaultRole
+	// Disposable email blocklist (bundled list, optional remote list, per-app additions)
+	disposableEmails := disposable.NewBlocklist()
+	disposableEmails.Start()
+	defer disposableEmails.Shutdown()
+	userService.DisposableEmails = disposableEmails
 	sessionService := session.NewService()
+	sessionService.Limits = session.DBLimits(database.DB)
 	userService.SessionService = sessionService
 	socialService := social.NewService(userRepo, socialRepo)
 	socialService.LookupRoles = rbacService.GetUserRoleNames
 	socialService.AssignDefaultRole = rbacService.AssignDefaultRole
+	socialService.AssignRoleByName = rbacService.AssignRoleByName
 	socialService.SessionService = sessionService
 	twofaService := twofa.NewService(userRepo, database.DB, emailService)
 	logQueryService := logService.NewQueryService(logRepo)
@@ -208,8 +400,21 @@ func main() {
 		return device.UserID, device.AppID, true
 	}
 	logHandler := logService.NewHandler(logQueryService)
+	// Cold archive of activity logs older than LOG_ARCHIVE_HOT_DAYS on the file storage backend
+	var logArchiver *logService.Archiver
+	if config.GetLoggingConfig().ArchiveEnabled {
+		logArchiver = logService.NewArchiver(database.DB)
+		logHandler.Archiver = logArchiver
+	}
 	sessionHandler := session.NewHandler(sessionService)
+	emailHandler := email.NewHandler(emailService)
 	adminRepo := admin.NewRepository(database.DB)
+	if err := adminRepo.RestoreAppAuthEpochs(); err != nil {
+		log.Printf("Warning: Failed to restore application auth epochs to Redis: %v\n", err)
+	}
+	if err := adminRepo.RestoreServiceAccountTokenRevocations(); err != nil {
+		log.Printf("Warning: Failed to restore service account token revocations to Redis: %v\n", err)
+	}
 	adminHandler := admin.NewHandler(adminRepo, emailService)
 
 	// Initialize Health & Metrics Handler
@@ -223,9 +428,14 @@ func main() {
 	webauthnHandler.LookupRoles = rbacService.GetUserRoleNames
 	webauthnHandler.SessionService = sessionService
 	webauthnHandler.AssignDefaultRole = rbacService.AssignDefaultRole
+	webauthnHandler.UserService = userService
 	// Wire DB for per-app token TTL overrides
 	webauthnHandler.DB = database.DB
 
+	// Re-authentication challenges for sensitive actions
+	reauthService := reauth.NewService(userRepo, twofaService, webauthnService, emailService)
+	reauthHandler := reauth.NewHandler(reauthService)
+
 	// Initialize Admin GUI Services and Handler
 	accountRepo := admin.NewAccountRepository(database.DB)
 	accountService := admin.NewAccountService(accountRepo, emailService)
@@ -252,6 +462,42 @@ func main() {
 	// to control it without a process restart.
 	twofaHandler.SettingResolver = settingsService.GetResolvedValue
 
+	// Password hashing algorithm and cost are read from the settings as well, so
+	// changing them in the admin GUI applies to new hashes within a minute.
+	passhash.SetSettingResolver(settingsService.GetResolvedValue)
+
+	// The file storage backend (local, S3 or GCS) and its credentials are
+	// configured in the settings too; the backend is rebuilt when they change.
+	storage.SetSettingResolver(settingsService.GetResolvedValue)
+
+	// Token lifetimes, issuer and audience are security settings too; changes
+	// apply to new tokens within a minute. COOKIE_FORCE_SECURE is read whenever
+	// the admin session cookie is set.
+	jwt.SetSettingResolver(settingsService.GetResolvedValue)
+
+	// Activity logging too, so the Log Behavior settings saved in the admin
+	// GUI apply; variables without a setting are read from the environment.
+	logSettings := func(key string) string {
+		if admin.GetSettingDefinition(key) == nil {
+			return os.Getenv(key)
+		}
+		return settingsService.GetResolvedValue(key)
+	}
+	config.ReloadLoggingConfig(logSettings)
+
+	// RS256/ES256 signing keys (JWT_SIGNING_ALGORITHM); the first key is
+	// generated on startup, later ones by the jwt_key_rotation job.
+	jwtKeys := jwtkeys.NewService(database.DB)
+	if err := jwtKeys.Start(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	defer jwtKeys.Shutdown()
+	adminHandler.JWTKeys = jwtKeys
+	web.ForceSecureCookies = func() bool {
+		force, _ := strconv.ParseBool(settingsService.GetResolvedValue("COOKIE_FORCE_SECURE"))
+		return force
+	}
+
 	// Wire admin lookup for passkey discoverable login
 	webauthnService.AdminLookup = accountRepo.GetByID
 
@@ -287,6 +533,10 @@ func main() {
 		}()
 	}
 
+	// Application environments (development/staging/production child apps)
+	envResolver := environment.NewResolver(database.DB)
+	socialHandler.Environments = envResolver
+
 	// Wire IP rule evaluator and anomaly detector on login handlers
 	userHandler.IPRuleEvaluator = ipRuleEvaluator
 	userHandler.AnomalyDetector = anomalyDetector
@@ -299,20 +549,122 @@ func main() {
 
 	// Wire brute-force protection service on login handlers
 	userHandler.BruteForceService = bruteForceService
+
+	// Wire registration bot detection
+	botDetector := botdetect.NewDetector(botdetect.LoadConfig(), viper.GetString("JWT_SECRET"))
+	botDetector.Scorer = botdetect.NewHTTPScorerFromConfig()
+	botDetector.ASN = geoIPService.LookupASN
+	userHandler.BotDetector = botDetector
 	guiHandler.BruteForceService = bruteForceService
+	adminHandler.BruteForceService = bruteForceService
+	adminHandler.DisposableEmails = disposableEmails
 
 	// Wire IP rule management on admin handlers
 	adminHandler.IPRuleRepo = ipRuleRepo
 	adminHandler.IPRuleEvaluator = ipRuleEvaluator
 	adminHandler.GeoIPService = geoIPService
 	adminHandler.TrustedDeviceRepo = trustedDeviceRepo
+	statsService := admin.NewStatsService(database.DB)
+	adminHandler.StatsService = statsService
+	adminHandler.DashboardService = dashboardService
 	guiHandler.IPRuleRepo = ipRuleRepo
 	guiHandler.IPRuleEvaluator = ipRuleEvaluator
 	guiHandler.GeoIPService = geoIPService
 	guiHandler.TrustedDeviceRepo = trustedDeviceRepo
+	viewPrefRepo := admin.NewViewPreferenceRepository(database.DB)
+	guiHandler.ViewPrefRepo = viewPrefRepo
 
 	// Wire health handler into admin GUI for the monitoring page
 	guiHandler.HealthHandler = healthHandler
+	adminHandler.HealthHandler = healthHandler
+
+	// Live configuration reload (SIGHUP, POST /admin/config/reload): re-reads
+	// the config file, then drops what was resolved from it or from the
+	// settings, so the next request resolves it again
+	reloader := reload.New(configPath, redis.Rdb)
+	reloader.Register("logging", func() error {
+		config.ReloadLoggingConfig(logSettings)
+		switch mode := viper.GetString("GIN_MODE"); mode {
+		case "":
+		case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+			gin.SetMode(mode)
+		default:
+			return fmt.Errorf("invalid GIN_MODE %q", mode)
+		}
+		return nil
+	})
+	reloader.Register("settings", func() error {
+		jwt.ReloadSettings()
+		passhash.ReloadSettings()
+		storage.ReloadSettings()
+		return nil
+	})
+	reloader.Register("jwt_keys", func() error {
+		return jwtKeys.Load(context.Background())
+	})
+	reloader.Register("app_configs", func() error {
+		socialRepo.OAuthConfigs.Purge()
+		envResolver.Purge()
+		ipRuleEvaluator.InvalidateAll()
+		return nil
+	})
+	reloader.Start()
+	defer reloader.Shutdown()
+	adminHandler.Reloader = reloader
+
+	// Metrics history for the dashboard charts: every instance samples its
+	// counters each minute; the gauges and the hourly rollup are scheduled jobs
+	var metricsHistory *metricshistory.Service
+	if viper.GetBool("METRICS_HISTORY_ENABLED") {
+		metricsHistory = metricshistory.NewService(database.DB, func(ctx context.Context) (map[string]float64, error) {
+			stats, err := dashboardService.GetStats()
+			if err != nil {
+				return nil, err
+			}
+			return map[string]float64{
+				"active_sessions": float64(stats.ActiveSessions),
+				"users":           float64(stats.TotalUsers),
+			}, nil
+		})
+		metricsHistory.Start()
+		defer metricsHistory.Shutdown()
+		adminHandler.MetricsHistory = metricsHistory
+		guiHandler.MetricsHistory = metricsHistory
+	}
+
+	// User sync for CRMs and data warehouses: a trigger records user changes in
+	// an outbox that every instance relays to webhooks and the Redis stream
+	if viper.GetBool("USER_SYNC_ENABLED") {
+		userSync := usersync.NewService(database.DB, webhookService, redis.Rdb)
+		userSync.Start()
+		defer userSync.Shutdown()
+		adminHandler.UserSync = userSync
+	} else if err := usersync.RemoveTrigger(context.Background(), database.DB); err != nil {
+		log.Printf("Warning: failed to remove the user sync trigger: %v", err)
+	}
+
+	// Initialize admin notification center: tenant creation, SMTP failures,
+	// anomaly spikes and API key expiry raise notifications shown in the GUI
+	notificationService := notification.NewService(notification.NewRepository(database.DB))
+	adminHandler.Notifications = notificationService
+	guiHandler.Notifications = notificationService
+	emailService.SetFailedCallback(notificationService.SMTPFailure)
+	emailService.SetUserSentCallback(logService.LogEmailSent)
+	logSvc.SetAnomalyObserver(func(appID uuid.UUID, _ logService.AnomalyResult) {
+		notificationService.RecordAnomaly(appID)
+	})
+
+	// Stream new activity logs to the admin dashboard, relayed through Redis so
+	// dashboards on every instance see all events
+	if viper.GetBool("ADMIN_DASHBOARD_LIVE_ENABLED") {
+		liveFeed := livefeed.NewHub(redis.Rdb)
+		liveFeed.Start()
+		defer liveFeed.Shutdown()
+		guiHandler.LiveFeed = liveFeed
+		logSvc.SetActivityObserver(func(activityLog models.ActivityLog) {
+			liveFeed.Publish(livefeed.EventFromLog(activityLog))
+		})
+	}
 
 	// Wire anomaly notification callback: sends emails when anomalies are detected
 	logSvc.SetAnomalyCallback(func(appID, userID uuid.UUID, userEmail string, result logService.AnomalyResult) {
@@ -323,27 +675,128 @@ func main() {
 		switch result.NotificationType {
 		case "new_device_login":
 			if err := emailService.SendNewDeviceLoginEmail(appID, userEmail, &userID,
-				nd.IPAddress, nd.Location, nd.Device, nd.LoginTime); err != nil {
+				nd.IPAddress, nd.Location, nd.Device, nd.LoginTime); err != nil && !errors.Is(err, email.ErrUnsubscribed) {
 				log.Printf("Anomaly notification (new_device_login) failed for user %s: %v", userEmail, err)
 			}
 		case "suspicious_activity":
 			if err := emailService.SendSuspiciousActivityEmail(appID, userEmail, &userID,
-				nd.IPAddress, nd.Location, nd.Device, nd.LoginTime, nd.AlertType, nd.Details); err != nil {
+				nd.IPAddress, nd.Location, nd.Device, nd.LoginTime, nd.AlertType, nd.Details); err != nil && !errors.Is(err, email.ErrUnsubscribed) {
 				log.Printf("Anomaly notification (suspicious_activity) failed for user %s: %v", userEmail, err)
 			}
 		}
 	})
 
-	// Initialize and start the API key expiry notification service
+	// API key expiry reminders (7 and 1 days before expiry), run by the job scheduler
 	apiKeyNotificationSvc := admin.NewApiKeyNotificationService(adminRepo, emailService)
-	apiKeyNotificationSvc.Start()
-	defer apiKeyNotificationSvc.Shutdown()
+	apiKeyNotificationSvc.Notifications = notificationService
+
+	// Job queue for long-running admin operations (bulk user imports, batch email sends)
+	// and emails deferred by quiet hours
+	var jobQueue *jobqueue.Queue
+	if viper.GetBool("JOB_QUEUE_ENABLED") {
+		jobQueue = jobqueue.NewQueue(jobqueue.NewRepository(database.DB))
+		jobQueue.Register(admin.JobTypeUserImport, 1, adminRepo.RunUserImportJob)
+		jobQueue.Register(admin.JobTypeUserGenerate, 1, adminRepo.RunUserGenerateJob)
+		jobQueue.Register(admin.JobTypeUserExport, 2, adminRepo.RunUserExportJob)
+		jobQueue.Register(email.JobTypeEmailBatch, 1, emailService.RunBatchJob)
+		jobQueue.Register(email.JobTypeEmailDeferred, 3, emailService.RunDeferredEmailJob)
+		if logArchiver != nil {
+			jobQueue.Register(logService.JobTypeArchiveRestore, 1, logArchiver.RunRestoreJob)
+		}
+		jobQueue.Start()
+		defer jobQueue.Shutdown()
+		logHandler.JobQueue = jobQueue
+		adminHandler.JobQueue = jobQueue
+		guiHandler.JobQueue = jobQueue
+		// Product emails sent during an app's quiet hours wait in the queue
+		emailService.SetJobQueue(jobQueue)
+	}
+
+	// Job scheduler for recurring background jobs (cron schedules, Redis lock per run)
+	if viper.GetBool("SCHEDULER_ENABLED") {
+		jobScheduler := scheduler.NewScheduler(scheduler.NewRepository(database.DB))
+		if err := jobScheduler.Register("api_key_expiry_reminders",
+			"Emails admins and raises GUI notifications for API keys expiring in 7 days or 1 day",
+			admin.ReminderSchedule(), apiKeyNotificationSvc.Run); err != nil {
+			log.Fatalf("Failed to register scheduled job: %v", err)
+		}
+		if err := jobScheduler.Register("dashboard_stats_refresh",
+			"Pre-aggregates the admin dashboard stat cards into a Redis snapshot",
+			"* * * * *", dashboardService.RefreshStats); err != nil {
+			log.Fatalf("Failed to register scheduled job: %v", err)
+		}
+		if err := jobScheduler.Register("daily_metrics_rollup",
+			"Rolls up yesterday's per-app signups, logins, emails and active users into daily_app_metrics",
+			"20 0 * * *", statsService.RunDailyRollup); err != nil {
+			log.Fatalf("Failed to register scheduled job: %v", err)
+		}
+		if err := jobScheduler.Register("social_profile_sync",
+			"Refreshes name and avatar of linked social accounts in apps with the scheduled profile sync policy, while the stored provider tokens are valid",
+			"30 * * * *", socialService.RunScheduledProfileSync); err != nil {
+			log.Fatalf("Failed to register scheduled job: %v", err)
+		}
+		if jobQueue != nil {
+			if err := jobScheduler.Register("background_job_cleanup",
+				"Deletes finished background jobs older than JOB_QUEUE_RETENTION_DAYS (default 30)",
+				"45 3 * * *", jobQueue.Cleanup); err != nil {
+				log.Fatalf("Failed to register scheduled job: %v", err)
+			}
+		}
+		if logArchiver != nil {
+			if err := jobScheduler.Register("activity_log_archive",
+				"Moves activity logs older than LOG_ARCHIVE_HOT_DAYS (default 30) to the file storage backend as gzipped NDJSON per app and day",
+				"40 2 * * *", logArchiver.RunArchive); err != nil {
+				log.Fatalf("Failed to register scheduled job: %v", err)
+			}
+		}
+		if err := jobScheduler.Register("jwt_key_rotation",
+			"Rotates the shared and per-application RS256/ES256 token signing keys every JWT_KEY_ROTATION_DAYS (default 90) and deletes keys whose tokens have all expired",
+			"10 3 * * *", jwtKeys.RunRotation); err != nil {
+			log.Fatalf("Failed to register scheduled job: %v", err)
+		}
+		if metricsHistory != nil {
+			if err := jobScheduler.Register("metrics_history_gauges",
+				"Records active sessions and users in the metrics history behind the dashboard charts",
+				"* * * * *", metricsHistory.RecordGauges); err != nil {
+				log.Fatalf("Failed to register scheduled job: %v", err)
+			}
+			if err := jobScheduler.Register("metrics_history_rollup",
+				"Downsamples the metrics history into hourly buckets, deletes minute buckets after 48 hours and hourly buckets after METRICS_HISTORY_RETENTION_DAYS (default 30)",
+				"5 * * * *", metricsHistory.RunRollup); err != nil {
+				log.Fatalf("Failed to register scheduled job: %v", err)
+			}
+		}
+		if viper.GetBool("ADMIN_AUDIT_CAPTURE_ENABLED") {
+			if err := jobScheduler.Register("admin_audit_cleanup",
+				"Deletes captured Admin API requests older than ADMIN_AUDIT_RETENTION_DAYS (default 90)",
+				"15 4 * * *", adminRepo.CleanupAdminAuditLogs); err != nil {
+				log.Fatalf("Failed to register scheduled job: %v", err)
+			}
+		}
+		jobScheduler.Start()
+		defer jobScheduler.Shutdown()
+		guiHandler.Scheduler = jobScheduler
+	}
 
 	// Setup Gin Router
-	r := gin.Default()
+	r := gin.New()
+	// Request logging, then panics and unwritten error statuses (e.g. unknown
+	// routes) answered with an HTML error page or problem+json
+	r.Use(gin.Logger(), middleware.ErrorPagesMiddleware())
 
 	// Initialize template renderer for GUI
-	renderer, err := web.NewRenderer()
+	guiDevMode := viper.GetBool("GUI_DEV_MODE")
+	var renderer *web.Renderer
+	var err error
+	if guiDevMode {
+		renderer, err = web.NewDevRenderer(viper.GetString("GUI_WEB_DIR"))
+		if err == nil {
+			defer renderer.Close()
+			log.Printf("GUI dev mode: templates and static assets are served from %s, templates reload on change", viper.GetString("GUI_WEB_DIR"))
+		}
+	} else {
+		renderer, err = web.NewRenderer()
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize template renderer: %v", err)
 	}
@@ -352,22 +805,42 @@ func main() {
 	// Add security headers middleware (before CORS so headers are always set)
 	r.Use(middleware.SecurityHeadersMiddleware())
 
+	// Serve /gui and /admin only on the admin listener when one is configured
+	adminListenAddr := viper.GetString("ADMIN_LISTEN_ADDR")
+	if adminListenAddr != "" {
+		r.Use(middleware.AdminListenerMiddleware())
+	}
+
 	// Add CORS middleware
-	r.Use(middleware.CORSMiddleware())
+	r.Use(middleware.CORSMiddleware(settingsService.GetResolvedValue))
+
+	// Reject oversized headers, bodies and strings and invalid UTF-8 before any handler
+	r.Use(middleware.RequestLimitsMiddleware())
+
 	r.Use(middleware.AppIDMiddleware())
+	r.Use(middleware.EnvironmentMiddleware(adminRepo, envResolver))
 
 	// Instrument all requests with Prometheus metrics
 	r.Use(health.PrometheusMiddleware())
 
+	// Meter per-app API calls for billing
+	if usageService != nil {
+		r.Use(usageService.APICallMiddleware())
+	}
+
 	// Public routes (with rate limiting)
 	public := r.Group("/")
+	public.Use(middleware.PolicyRateLimit(middleware.PolicyPublicAuth))
 	{
 		public.POST("/register", middleware.APIRegisterRateLimit(), userHandler.Register)
+		public.GET("/register/form-token", userHandler.RegistrationFormToken)
 		public.POST("/login", middleware.APILoginRateLimit(), userHandler.Login)
 		public.POST("/refresh-token", middleware.APIRefreshTokenRateLimit(), userHandler.RefreshToken)
 		public.POST("/forgot-password", middleware.APIForgotPasswordRateLimit(), userHandler.ForgotPassword)
 		public.POST("/reset-password", middleware.APIResetPasswordRateLimit(), userHandler.ResetPassword)
+		public.POST("/change-expired-password", middleware.APIResetPasswordRateLimit(), userHandler.ChangeExpiredPassword)
 		public.GET("/verify-email", userHandler.VerifyEmail)
+		public.POST("/verify-email/code", middleware.APIVerifyEmailCodeRateLimit(), userHandler.VerifyEmailCode)
 		public.POST("/resend-verification", middleware.APIResendVerificationRateLimit(), userHandler.ResendVerification)
 		// 2FA login verification (public because it needs temp token)
 		public.POST("/2fa/login-verify", middleware.API2FAVerifyRateLimit(), twofaHandler.VerifyLogin)
@@ -390,6 +863,10 @@ func main() {
 		public.POST("/passkey/login/begin", middleware.APIPasskeyLoginRateLimit(), webauthnHandler.BeginPasswordlessLogin)
 		public.POST("/passkey/login/finish", middleware.APIPasskeyLoginRateLimit(), webauthnHandler.FinishPasswordlessLogin)
 
+		// Passkey-only account registration (public; passkey-only applications)
+		public.POST("/passkey/register-account/begin", middleware.APIRegisterRateLimit(), webauthnHandler.BeginAccountRegistration)
+		public.POST("/passkey/register-account/finish", middleware.APIPasskeyLoginRateLimit(), webauthnHandler.FinishAccountRegistration)
+
 		// Magic link passwordless login (public)
 		public.POST("/magic-link/request", middleware.APIMagicLinkRateLimit(), userHandler.RequestMagicLink)
 		public.POST("/magic-link/verify", middleware.APIMagicLinkRateLimit(), userHandler.VerifyMagicLink)
@@ -397,10 +874,19 @@ func main() {
 		// Public app login configuration (no auth required — used by login/register UI)
 		public.GET("/app-config/:app_id", adminHandler.GetAppLoginConfig)
 
+		// Unsubscribe links from non-transactional emails (public — signed token;
+		// POST also serves RFC 8058 one-click unsubscribe from mail clients)
+		public.GET("/unsubscribe", emailHandler.UnsubscribePage)
+		public.POST("/unsubscribe", emailHandler.Unsubscribe)
+
 		// Health check (public — used by load balancers and Kubernetes probes)
 		public.GET("/health", healthHandler.Health)
 	}
 
+	// Uploaded profile pictures (public — referenced from <img> tags, so not
+	// subject to the auth rate limits)
+	r.GET("/avatars/:app_id/:file", userHandler.ServeAvatar)
+
 	// Metrics endpoint (Admin API Key required — prevents exposing internal telemetry)
 	metricsGroup := r.Group("", middleware.AdminAuthMiddleware(adminRepo))
 	{
@@ -422,6 +908,10 @@ func main() {
 		auth.GET("/github/login", socialHandler.GithubLogin)
 		auth.GET("/github/callback", socialHandler.GithubCallback)
 
+		// Generic OpenID Connect providers (Okta, Auth0, Azure AD, Keycloak, ...)
+		auth.GET("/oidc/:provider/login", socialHandler.OIDCLogin)
+		auth.GET("/oidc/:provider/callback", socialHandler.OIDCCallback)
+
 		// Account merge confirmation (public — requires merge_token + existing password)
 		auth.POST("/merge/confirm", socialHandler.MergeConfirm)
 
@@ -434,74 +924,103 @@ func main() {
 	// Account linking initiation routes (require JWT authentication)
 	authLink := r.Group("/auth")
 	authLink.Use(middleware.AuthMiddleware())
+	authLink.Use(middleware.RejectServiceAccount())
 	{
-		authLink.GET("/google/link", socialHandler.GoogleLink)
-		authLink.GET("/facebook/link", socialHandler.FacebookLink)
-		authLink.GET("/github/link", socialHandler.GithubLink)
+		authLink.GET("/google/link", middleware.RejectImpersonation(), socialHandler.GoogleLink)
+		authLink.GET("/facebook/link", middleware.RejectImpersonation(), socialHandler.FacebookLink)
+		authLink.GET("/github/link", middleware.RejectImpersonation(), socialHandler.GithubLink)
 	}
 
-	// Protected routes (require JWT authentication)
+	// Routes that also accept service account tokens. A service account token
+	// reaches a route guarded by RequireTokenScope only when its scope names it
+	tokenRoutes := r.Group("/")
+	tokenRoutes.Use(middleware.AuthMiddleware())
+	tokenRoutes.Use(middleware.PolicyRateLimit(middleware.PolicyUserAPI))
+	{
+		tokenRoutes.GET("/auth/validate", userHandler.ValidateToken)
+
+		// Activity log routes (require log:read)
+		tokenRoutes.GET("/activity-logs", middleware.RequireTokenScope("log:read"), middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.GetUserActivityLogs)
+		tokenRoutes.GET("/activity-logs/event-types", middleware.RequireTokenScope("log:read"), middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.GetEventTypes)
+		tokenRoutes.GET("/activity-logs/export", middleware.RequireTokenScope("log:read"), middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.ExportUserActivityLogs)
+		tokenRoutes.GET("/activity-logs/:id", middleware.RequireTokenScope("log:read"), middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.GetActivityLogByID)
+	}
+
+	// Protected routes (require JWT authentication of a user session)
 	protected := r.Group("/")
 	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.RejectServiceAccount())
+	protected.Use(middleware.PolicyRateLimit(middleware.PolicyUserAPI))
 	{
 		// User profile routes (require user:read / user:write / user:delete)
 		protected.GET("/profile", middleware.AuthorizePermission(rbacService, "user", "read"), userHandler.GetProfile)
 		protected.PUT("/profile", middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.UpdateProfile)
-		protected.DELETE("/profile", middleware.AuthorizePermission(rbacService, "user", "delete"), userHandler.DeleteAccount)
-		protected.PUT("/profile/email", middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.UpdateEmail)
-		protected.PUT("/profile/password", middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.UpdatePassword)
-		protected.POST("/profile/set-password", middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.SetPassword)
+		protected.DELETE("/profile", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "user", "delete"), userHandler.DeleteAccount)
+		protected.PUT("/profile/email", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.UpdateEmail)
+		protected.POST("/profile/avatar", middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.UploadAvatar)
+		protected.DELETE("/profile/avatar", middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.DeleteAvatar)
+		protected.PUT("/profile/password", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.UpdatePassword)
+		protected.POST("/profile/set-password", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.SetPassword)
+		protected.GET("/profile/notification-preferences", middleware.AuthorizePermission(rbacService, "user", "read"), userHandler.GetNotificationPreferences)
+		protected.PUT("/profile/notification-preferences", middleware.AuthorizePermission(rbacService, "user", "write"), userHandler.UpdateNotificationPreferences)
+		protected.GET("/profile/security-events", middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.GetSecurityEvents)
 
 		// Social account management routes
 		protected.GET("/profile/social-accounts", middleware.AuthorizePermission(rbacService, "user", "read"), socialHandler.ListSocialAccounts)
-		protected.DELETE("/profile/social-accounts/:id", middleware.AuthorizePermission(rbacService, "user", "write"), socialHandler.UnlinkSocialAccount)
+		protected.DELETE("/profile/social-accounts/:id", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "user", "write"), socialHandler.UnlinkSocialAccount)
+		protected.POST("/profile/social-accounts/:provider/sync", middleware.AuthorizePermission(rbacService, "user", "write"), socialHandler.SyncSocialAccount)
+
+		// Third-party applications authorized through the OIDC provider
+		if oidcHandler != nil {
+			protected.GET("/profile/authorizations", middleware.AuthorizePermission(rbacService, "user", "read"), oidcHandler.ListAuthorizations)
+			protected.DELETE("/profile/authorizations/:client_id", middleware.AuthorizePermission(rbacService, "user", "write"), oidcHandler.RevokeAuthorization)
+		}
 
 		// Auth routes (no extra permission needed — auth is inherent)
-		protected.GET("/auth/validate", userHandler.ValidateToken)
 		protected.POST("/logout", userHandler.Logout)
 
+		// Re-authentication challenges (confirm identity before a sensitive action)
+		protected.GET("/auth/challenge/methods", reauthHandler.Methods)
+		protected.POST("/auth/challenge", middleware.RejectImpersonation(), middleware.APIReauthRateLimit(), reauthHandler.Begin)
+		protected.POST("/auth/challenge/verify", middleware.RejectImpersonation(), middleware.APIReauthRateLimit(), reauthHandler.Verify)
+		protected.POST("/auth/challenge/proof", reauthHandler.CheckProof)
+
 		// 2FA management routes (require settings:write — managing own security settings)
-		protected.POST("/2fa/generate", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.Generate2FA)
-		protected.POST("/2fa/verify-setup", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.VerifySetup)
-		protected.POST("/2fa/enable", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.Enable2FA)
-		protected.POST("/2fa/disable", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.Disable2FA)
-		protected.POST("/2fa/recovery-codes", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.GenerateRecoveryCodes)
+		protected.POST("/2fa/generate", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.Generate2FA)
+		protected.POST("/2fa/verify-setup", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.VerifySetup)
+		protected.POST("/2fa/enable", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.Enable2FA)
+		protected.POST("/2fa/disable", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.Disable2FA)
+		protected.POST("/2fa/recovery-codes", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.GenerateRecoveryCodes)
 		// Email 2FA routes
-		protected.POST("/2fa/email/enable", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.EnableEmail2FA)
+		protected.POST("/2fa/email/enable", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.EnableEmail2FA)
 
 		// SMS 2FA routes
-		protected.POST("/2fa/sms/enable", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.EnableSMS2FA)
+		protected.POST("/2fa/sms/enable", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.EnableSMS2FA)
 
 		// Backup email 2FA routes
-		protected.POST("/2fa/backup-email", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.AddBackupEmail)
-		protected.DELETE("/2fa/backup-email", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RemoveBackupEmail)
+		protected.POST("/2fa/backup-email", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.AddBackupEmail)
+		protected.DELETE("/2fa/backup-email", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RemoveBackupEmail)
 		protected.GET("/2fa/backup-email/status", middleware.AuthorizePermission(rbacService, "settings", "read"), twofaHandler.BackupEmailStatus)
-		protected.POST("/2fa/backup-email/enable", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.EnableBackupEmail2FA)
-		protected.POST("/2fa/backup-email/disable", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.DisableBackupEmail2FA)
+		protected.POST("/2fa/backup-email/enable", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.EnableBackupEmail2FA)
+		protected.POST("/2fa/backup-email/disable", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.DisableBackupEmail2FA)
 
 		// Phone management routes
-		protected.POST("/phone", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.AddPhone)
-		protected.POST("/phone/verify", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.VerifyPhone)
-		protected.DELETE("/phone", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RemovePhone)
+		protected.POST("/phone", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.AddPhone)
+		protected.POST("/phone/verify", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.VerifyPhone)
+		protected.DELETE("/phone", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RemovePhone)
 		protected.GET("/phone/status", middleware.AuthorizePermission(rbacService, "settings", "read"), twofaHandler.PhoneStatus)
 
 		// Trusted device management routes
 		protected.GET("/2fa/trusted-devices", middleware.AuthorizePermission(rbacService, "settings", "read"), twofaHandler.ListTrustedDevices)
-		protected.DELETE("/2fa/trusted-devices/:id", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RevokeTrustedDevice)
-		protected.DELETE("/2fa/trusted-devices", middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RevokeAllTrustedDevices)
+		protected.DELETE("/2fa/trusted-devices/:id", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RevokeTrustedDevice)
+		protected.DELETE("/2fa/trusted-devices", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), twofaHandler.RevokeAllTrustedDevices)
 
 		// Passkey management routes (require settings:write)
-		protected.POST("/passkey/register/begin", middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.BeginRegistration)
-		protected.POST("/passkey/register/finish", middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.FinishRegistration)
+		protected.POST("/passkey/register/begin", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.BeginRegistration)
+		protected.POST("/passkey/register/finish", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.FinishRegistration)
 		protected.GET("/passkeys", middleware.AuthorizePermission(rbacService, "settings", "read"), webauthnHandler.ListCredentials)
-		protected.PUT("/passkeys/:id", middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.RenameCredential)
-		protected.DELETE("/passkeys/:id", middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.DeleteCredential)
-
-		// Activity log routes (require log:read)
-		protected.GET("/activity-logs", middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.GetUserActivityLogs)
-		protected.GET("/activity-logs/event-types", middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.GetEventTypes)
-		protected.GET("/activity-logs/export", middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.ExportUserActivityLogs)
-		protected.GET("/activity-logs/:id", middleware.AuthorizePermission(rbacService, "log", "read"), logHandler.GetActivityLogByID)
+		protected.PUT("/passkeys/:id", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.RenameCredential)
+		protected.DELETE("/passkeys/:id", middleware.RejectImpersonation(), middleware.AuthorizePermission(rbacService, "settings", "write"), webauthnHandler.DeleteCredential)
 
 		// Session management routes
 		protected.GET("/sessions", sessionHandler.ListSessions)
@@ -519,31 +1038,108 @@ func main() {
 	// Protected token issuance endpoint — requires JWT auth.
 	ssoProtected := r.Group("/sso")
 	ssoProtected.Use(middleware.AuthMiddleware())
+	ssoProtected.Use(middleware.RejectServiceAccount())
 	{
-		ssoProtected.POST("/token", middleware.APISSORateLimit(), ssoHandler.IssueToken)
+		ssoProtected.POST("/token", middleware.RejectImpersonation(), middleware.APISSORateLimit(), ssoHandler.IssueToken)
 	}
 
+	// First admin API key for scripted installs: authorized by ADMIN_BOOTSTRAP_TOKEN
+	// here, or by a client certificate on the mutual-TLS listener below
+	r.POST("/admin/bootstrap/api-key", middleware.PolicyRateLimit(middleware.PolicyPublicAuth), adminHandler.BootstrapApiKey)
+
 	// Admin routes (protected by Admin API Key)
 	adminRoutes := r.Group("/admin")
 	// Remove the general AuthMiddleware and replace with AdminAuthMiddleware
 	// Admin routes shouldn't require user tokens, but a specific admin key
 	adminRoutes.Use(middleware.AdminAuthMiddleware(adminRepo))
+	adminRoutes.Use(middleware.PolicyRateLimit(middleware.PolicyAdminAPI))
+	adminAuditEnabled := viper.GetBool("ADMIN_AUDIT_CAPTURE_ENABLED")
+	if adminAuditEnabled {
+		adminRoutes.Use(middleware.AdminAuditMiddleware(adminRepo))
+	}
 	{
 		adminRoutes.GET("/activity-logs", logHandler.GetAllActivityLogs)
-		adminRoutes.GET("/activity-logs/export", logHandler.ExportAllActivityLogs)
+		adminRoutes.GET("/activity-logs/export", middleware.SkipAdminAudit(), logHandler.ExportAllActivityLogs)
+		adminRoutes.GET("/activity-logs/event-catalog", logHandler.GetEventCatalog)
+		adminRoutes.GET("/activity-logs/archives", logHandler.ListActivityLogArchives)
+		adminRoutes.GET("/activity-logs/archives/query", middleware.SkipAdminAudit(), logHandler.QueryActivityLogArchives)
+		adminRoutes.POST("/activity-logs/archives/restore", logHandler.RestoreActivityLogArchives)
+
+		// Captured Admin API requests (not captured themselves)
+		adminRoutes.GET("/audit-logs", middleware.SkipAdminAudit(), adminHandler.ListAdminAuditLogs)
+		adminRoutes.GET("/audit-logs/:id", middleware.SkipAdminAudit(), adminHandler.GetAdminAuditLog)
 
 		// Multi-tenancy Management
 		adminRoutes.POST("/tenants", adminHandler.CreateTenant)
 		adminRoutes.GET("/tenants", adminHandler.ListTenants)
+		adminRoutes.GET("/tenants/by-external-id/:external_id", adminHandler.GetTenantByExternalID)
+		adminRoutes.PUT("/tenants/by-external-id/:external_id", adminHandler.UpsertTenantByExternalID)
+		adminRoutes.GET("/tenants/:id", adminHandler.GetTenant)
+		adminRoutes.DELETE("/tenants/:id", adminHandler.DeleteTenant)
+		adminRoutes.GET("/tenants/:id/usage", usage.NewHandler(usageService).GetTenantUsage)
+		adminRoutes.GET("/tenants/:id/email-domains", adminHandler.ListTenantEmailDomains)
+		adminRoutes.POST("/tenants/:id/email-domains", adminHandler.AddTenantEmailDomain)
+		adminRoutes.POST("/tenants/:id/email-domains/:domain_id/verify", adminHandler.VerifyTenantEmailDomain)
+		adminRoutes.DELETE("/tenants/:id/email-domains/:domain_id", adminHandler.DeleteTenantEmailDomain)
+		adminRoutes.GET("/notifications", notification.NewHandler(notificationService).ListFeed)
+		if jobQueue != nil {
+			jobHandler := jobqueue.NewHandler(jobQueue)
+			adminRoutes.GET("/jobs", jobHandler.ListJobs)
+			adminRoutes.GET("/jobs/:id", jobHandler.GetJob)
+			adminRoutes.POST("/jobs/:id/cancel", jobHandler.CancelJob)
+			adminRoutes.POST("/jobs/:id/retry", jobHandler.RetryJob)
+		}
 		adminRoutes.POST("/apps", adminHandler.CreateApp)
+		adminRoutes.GET("/apps/by-external-id/:external_id", adminHandler.GetAppByExternalID)
+		adminRoutes.PUT("/apps/by-external-id/:external_id", adminHandler.UpsertAppByExternalID)
 		adminRoutes.GET("/apps/:id", adminHandler.GetAppDetails)
+		adminRoutes.DELETE("/apps/:id", adminHandler.DeleteApp)
+		adminRoutes.GET("/apps/:id/stats", adminHandler.GetAppStats)
+		adminRoutes.POST("/apps/:id/clone", adminHandler.CloneApp)
+		adminRoutes.POST("/apps/:id/force-password-rotation", adminHandler.ForcePasswordRotation)
+		adminRoutes.POST("/apps/:id/revoke-all-sessions", adminHandler.RevokeAllAppSessions)
+		adminRoutes.GET("/apps/:id/keys", adminHandler.ListAppJWTKeys)
+		adminRoutes.POST("/apps/:id/keys/rotate", adminHandler.RotateAppJWTKey)
+		adminRoutes.DELETE("/apps/:id/keys/:kid", adminHandler.DeleteAppJWTKey)
+		adminRoutes.GET("/apps/:id/environments", adminHandler.ListEnvironments)
+		adminRoutes.POST("/apps/:id/environments", adminHandler.CreateEnvironment)
 		adminRoutes.POST("/apps/:id/oauth-config", adminHandler.UpsertOAuthConfig)
+		adminRoutes.GET("/oauth-configs", adminHandler.ListOAuthConfigs)
+		adminRoutes.POST("/oauth-configs/export", adminHandler.ExportOAuthConfigs)
+		adminRoutes.POST("/oauth-configs/import", adminHandler.ImportOAuthConfigs)
+		adminRoutes.GET("/oauth-configs/by-external-id/:external_id", adminHandler.GetOAuthConfigByExternalID)
+		adminRoutes.PUT("/oauth-configs/by-external-id/:external_id", adminHandler.UpsertOAuthConfigByExternalID)
+		adminRoutes.GET("/oauth-configs/:id", adminHandler.GetOAuthConfig)
+		adminRoutes.PUT("/oauth-configs/:id/toggle", adminHandler.ToggleOAuthConfig)
+		adminRoutes.DELETE("/oauth-configs/:id", adminHandler.DeleteOAuthConfig)
+
+		// Dashboard (same data as the admin GUI dashboard)
+		adminRoutes.GET("/dashboard/stats", adminHandler.GetDashboardStats)
+		adminRoutes.GET("/dashboard/activity", adminHandler.GetDashboardActivity)
+		adminRoutes.GET("/metrics/history", adminHandler.GetMetricsHistory)
+		adminRoutes.GET("/apps/:id/user-sync", adminHandler.GetUserSyncStatus)
+		adminRoutes.POST("/apps/:id/user-sync/backfill", adminHandler.BackfillUserSync)
+		adminRoutes.POST("/apps/:id/social-raw-data/redact", adminHandler.RedactSocialRawData)
+
+		// Diagnostics
+		adminRoutes.GET("/diagnostics", middleware.SkipAdminAudit(), adminHandler.GetSupportBundle)
+		adminRoutes.GET("/diagnostics/secrets", adminHandler.AuditSecrets)
+		adminRoutes.POST("/config/reload", adminHandler.ReloadConfig)
+
+		// Token signing keys (JWT_SIGNING_ALGORITHM=RS256 or ES256)
+		adminRoutes.GET("/jwt-keys", adminHandler.ListJWTKeys)
+		adminRoutes.POST("/jwt-keys/rotate", adminHandler.RotateJWTKey)
+		adminRoutes.DELETE("/jwt-keys/:id", adminHandler.DeleteJWTKey)
 
 		// Email management API
 		adminRoutes.GET("/email-types", adminHandler.ListEmailTypes)
 		adminRoutes.GET("/email-types/:code", adminHandler.GetEmailType)
+		adminRoutes.GET("/email-types/:code/variables", adminHandler.GetEmailTypeVariables)
 		adminRoutes.GET("/email-variables", adminHandler.ListWellKnownVariables)
 		adminRoutes.GET("/email-templates", adminHandler.ListEmailTemplates)
+		adminRoutes.GET("/email-templates/validate", adminHandler.ValidateEmailTemplates)
+		adminRoutes.GET("/email-templates/by-external-id/:external_id", adminHandler.GetEmailTemplateByExternalID)
+		adminRoutes.PUT("/email-templates/by-external-id/:external_id", adminHandler.UpsertEmailTemplateByExternalID)
 		adminRoutes.GET("/email-templates/:id", adminHandler.GetEmailTemplate)
 		adminRoutes.POST("/email-templates", adminHandler.SaveEmailTemplate)
 		adminRoutes.DELETE("/email-templates/:id", adminHandler.DeleteEmailTemplate)
@@ -551,7 +1147,7 @@ func main() {
 		adminRoutes.GET("/apps/:id/email-config", adminHandler.GetEmailServerConfig)
 		adminRoutes.PUT("/apps/:id/email-config", adminHandler.SaveEmailServerConfig)
 		adminRoutes.DELETE("/apps/:id/email-config", adminHandler.DeleteEmailServerConfig)
-		adminRoutes.POST("/apps/:id/email-test", adminHandler.SendTestEmail)
+		adminRoutes.POST("/apps/:id/email-test", middleware.PolicyRateLimit(middleware.PolicyEmailSend), adminHandler.SendTestEmail)
 		adminRoutes.GET("/apps/:id/email-servers", adminHandler.ListEmailServerConfigsByApp)
 
 		// Email server config CRUD (config-level, multi-config)
@@ -566,7 +1162,18 @@ func main() {
 		adminRoutes.POST("/email-types", adminHandler.CreateEmailType)
 		adminRoutes.PUT("/email-types/:id", adminHandler.UpdateEmailType)
 		adminRoutes.DELETE("/email-types/:id", adminHandler.DeleteEmailType)
-		adminRoutes.POST("/apps/:id/send-email", adminHandler.SendCustomEmail)
+		adminRoutes.POST("/apps/:id/send-email", middleware.PolicyRateLimit(middleware.PolicyEmailSend), adminHandler.SendCustomEmail)
+		adminRoutes.POST("/apps/:id/send-email-batch", middleware.PolicyRateLimit(middleware.PolicyEmailSend), adminHandler.SendEmailBatch)
+		adminRoutes.GET("/disposable-emails/check", adminHandler.CheckDisposableEmail)
+		adminRoutes.GET("/apps/:id/email-suppressions", adminHandler.ListEmailSuppressions)
+		adminRoutes.POST("/apps/:id/email-suppressions", adminHandler.AddEmailSuppression)
+		adminRoutes.DELETE("/apps/:id/email-suppressions/:email", adminHandler.DeleteEmailSuppression)
+		adminRoutes.GET("/apps/:id/email-templates/test-matrix", adminHandler.GetEmailTemplateTestMatrix)
+		adminRoutes.GET("/apps/:id/email-variants", adminHandler.ListEmailVariants)
+		adminRoutes.POST("/apps/:id/email-variants", adminHandler.CreateEmailVariant)
+		adminRoutes.PUT("/apps/:id/email-variants/:variant_id", adminHandler.UpdateEmailVariant)
+		adminRoutes.DELETE("/apps/:id/email-variants/:variant_id", adminHandler.DeleteEmailVariant)
+		adminRoutes.GET("/apps/:id/email-variant-stats", adminHandler.GetEmailVariantStats)
 
 		// RBAC Management
 		adminRoutes.GET("/rbac/roles", rbacHandler.ListRoles)
@@ -599,9 +1206,31 @@ func main() {
 		adminRoutes.GET("/webhooks/:id/deliveries", webhookHandler.AdminListDeliveriesByEndpoint)
 		adminRoutes.GET("/webhooks/apps/:app_id/deliveries", webhookHandler.AdminListDeliveriesByApp)
 
-		// User Import/Export (Admin)
-		adminRoutes.GET("/users/export", adminHandler.ExportUsers)
+		// User listing (cursor-paginated) and Import/Export (Admin)
+		adminRoutes.GET("/users", adminHandler.ListUsers)
+		adminRoutes.GET("/users/export", middleware.SkipAdminAudit(), adminHandler.ExportUsers)
+		adminRoutes.GET("/exports/:job_id", middleware.SkipAdminAudit(), adminHandler.DownloadExport)
 		adminRoutes.POST("/users/import", adminHandler.ImportUsers)
+		// Synthetic users for load testing; only routed when explicitly enabled
+		if viper.GetBool("LOAD_TEST_USER_GENERATION_ENABLED") {
+			adminRoutes.POST("/users/generate", adminHandler.GenerateUsers)
+		}
+		adminRoutes.GET("/users/:id", adminHandler.GetUserDetail)
+		adminRoutes.PUT("/users/:id/toggle", adminHandler.ToggleUserActive)
+		adminRoutes.PUT("/users/:id/unlock", adminHandler.UnlockUser)
+
+		// Right-to-erasure (GDPR): anonymize or delete a user's personal data, with a signed certificate
+		adminRoutes.POST("/users/:id/erase", adminHandler.EraseUser)
+
+		// Service accounts: non-human users with long-lived, scoped tokens
+		adminRoutes.GET("/apps/:id/service-accounts", adminHandler.ListServiceAccounts)
+		adminRoutes.POST("/apps/:id/service-accounts", adminHandler.CreateServiceAccount)
+		adminRoutes.DELETE("/apps/:id/service-accounts/:account_id", adminHandler.DeleteServiceAccount)
+		adminRoutes.GET("/apps/:id/service-accounts/:account_id/tokens", adminHandler.ListServiceAccountTokens)
+		adminRoutes.POST("/apps/:id/service-accounts/:account_id/tokens", adminHandler.CreateServiceAccountToken)
+		adminRoutes.DELETE("/apps/:id/service-accounts/:account_id/tokens/:token_id", adminHandler.RevokeServiceAccountToken)
+		adminRoutes.GET("/erasure-certificates", adminHandler.ListErasureCertificates)
+		adminRoutes.GET("/erasure-certificates/:id", adminHandler.GetErasureCertificate)
 
 		// Trusted Device Management (Admin)
 		adminRoutes.GET("/users/:id/trusted-devices", adminHandler.AdminListTrustedDevices)
@@ -617,14 +1246,15 @@ func main() {
 	appRoutes := r.Group("/app/:id")
 	appRoutes.Use(middleware.AppApiKeyMiddleware(adminRepo))
 	appRoutes.Use(middleware.AppRouteGuardMiddleware())
+	appRoutes.Use(middleware.PolicyRateLimit(middleware.PolicyAppAPI))
 	{
 		// Read-only: SMTP configuration
 		appRoutes.GET("/email-config", adminHandler.GetEmailServerConfig)
 		appRoutes.GET("/email-servers", adminHandler.ListEmailServerConfigsByApp)
 
 		// Send emails
-		appRoutes.POST("/email-test", adminHandler.SendTestEmail)
-		appRoutes.POST("/send-email", adminHandler.SendCustomEmail)
+		appRoutes.POST("/email-test", middleware.PolicyRateLimit(middleware.PolicyEmailSend), adminHandler.SendTestEmail)
+		appRoutes.POST("/send-email", middleware.PolicyRateLimit(middleware.PolicyEmailSend), adminHandler.SendCustomEmail)
 
 		// Webhook Management (App-scoped)
 		appRoutes.GET("/webhooks", webhookHandler.AppListEndpoints)
@@ -632,13 +1262,20 @@ func main() {
 		appRoutes.PUT("/webhooks/:webhook_id/toggle", webhookHandler.AppToggleEndpoint)
 		appRoutes.DELETE("/webhooks/:webhook_id", webhookHandler.AppDeleteEndpoint)
 		appRoutes.GET("/webhooks/deliveries", webhookHandler.AppListDeliveries)
+
+		// Support impersonation: short-lived tokens for signing in as a user
+		appRoutes.POST("/users/:user_id/impersonate", middleware.RequireExplicitScope(admin.ScopeUsersImpersonate), adminHandler.ImpersonateUser)
 	}
 
 	// GUI routes (Admin web interface)
 	gui := r.Group("/gui")
 	{
-		// Static assets (no auth required)
-		gui.StaticFS("/static", static.HTTPFileSystem())
+		// Static assets (no auth required); embedded unless GUI_DEV_MODE serves them from disk
+		if guiDevMode {
+			gui.StaticFS("/static", static.DirHTTPFileSystem(filepath.Join(viper.GetString("GUI_WEB_DIR"), "static")))
+		} else {
+			gui.StaticFS("/static", static.HTTPFileSystem())
+		}
 
 		// Login page and form submission (no auth required)
 		gui.GET("/login", guiHandler.LoginPage)
@@ -661,12 +1298,22 @@ func main() {
 		guiAuth := gui.Group("/")
 		guiAuth.Use(middleware.GUIAuthMiddleware(accountService))
 		guiAuth.Use(middleware.CSRFMiddleware(accountService))
+		guiAuth.Use(middleware.GUIPreferencesMiddleware(viewPrefRepo))
 		{
 			guiAuth.GET("/", guiHandler.Dashboard)
 			guiAuth.GET("/dashboard/stats", guiHandler.DashboardStats)
 			guiAuth.GET("/dashboard/activity", guiHandler.DashboardActivity)
+			guiAuth.GET("/dashboard/service-health", guiHandler.DashboardServiceHealth)
+			guiAuth.GET("/dashboard/metrics-history", guiHandler.DashboardMetricsHistory)
+			guiAuth.GET("/dashboard/live-controls", guiHandler.DashboardLiveControls)
+			guiAuth.GET("/dashboard/stream", guiHandler.DashboardStream)
 			guiAuth.GET("/logout", guiHandler.Logout)
 
+			// Notification center
+			guiAuth.GET("/notifications/bell", guiHandler.NotificationBell)
+			guiAuth.POST("/notifications/read-all", guiHandler.NotificationMarkAllRead)
+			guiAuth.POST("/notifications/:id/read", guiHandler.NotificationMarkRead)
+
 			// Tenant management
 			guiAuth.GET("/tenants", guiHandler.TenantPage)
 			guiAuth.GET("/tenants/list", guiHandler.TenantList)
@@ -686,6 +1333,10 @@ func main() {
 			guiAuth.GET("/applications/form-cancel", guiHandler.AppFormCancel)
 			guiAuth.GET("/applications/:id/edit", guiHandler.AppEditForm)
 			guiAuth.PUT("/applications/:id", guiHandler.AppUpdate)
+			guiAuth.GET("/applications/:id/clone", guiHandler.AppCloneForm)
+			guiAuth.POST("/applications/:id/clone", guiHandler.AppClone)
+			guiAuth.GET("/applications/:id/kill-sessions", guiHandler.AppKillSessionsConfirm)
+			guiAuth.POST("/applications/:id/kill-sessions", guiHandler.AppKillSessions)
 			guiAuth.GET("/applications/:id/delete", guiHandler.AppDeleteConfirm)
 			guiAuth.DELETE("/applications/:id", guiHandler.AppDelete)
 
@@ -695,7 +1346,9 @@ func main() {
 			guiAuth.GET("/oauth/new", guiHandler.OAuthCreateForm)
 			guiAuth.POST("/oauth", guiHandler.OAuthCreate)
 			guiAuth.GET("/oauth/form-cancel", guiHandler.OAuthFormCancel)
+			guiAuth.GET("/oauth/effective", guiHandler.OAuthEffectiveConfig)
 			guiAuth.GET("/oauth/:id/edit", guiHandler.OAuthEditForm)
+			guiAuth.GET("/oauth/:id/test", guiHandler.OAuthTestConfig)
 			guiAuth.PUT("/oauth/:id", guiHandler.OAuthUpdate)
 			guiAuth.GET("/oauth/:id/delete", guiHandler.OAuthDeleteConfirm)
 			guiAuth.DELETE("/oauth/:id", guiHandler.OAuthDelete)
@@ -707,9 +1360,12 @@ func main() {
 			guiAuth.GET("/users/export", guiHandler.UserExport)
 			guiAuth.GET("/users/import/modal", guiHandler.UserImportModal)
 			guiAuth.POST("/users/import", guiHandler.UserImport)
+			guiAuth.GET("/users/import/jobs/:id", guiHandler.UserImportJobStatus)
 			guiAuth.GET("/users/:id", guiHandler.UserDetail)
 			guiAuth.PUT("/users/:id/toggle", guiHandler.UserToggleActive)
 			guiAuth.PUT("/users/:id/unlock", guiHandler.UserUnlock)
+			guiAuth.GET("/users/:id/erase", guiHandler.UserEraseConfirm)
+			guiAuth.POST("/users/:id/erase", guiHandler.UserErase)
 			guiAuth.GET("/users/social-accounts/:id/unlink", guiHandler.SocialAccountUnlinkConfirm)
 			guiAuth.DELETE("/users/social-accounts/:id", guiHandler.SocialAccountUnlink)
 			guiAuth.GET("/users/passkeys/:id/delete", guiHandler.PasskeyDeleteConfirm)
@@ -717,12 +1373,24 @@ func main() {
 			guiAuth.DELETE("/users/:id/trusted-devices/:device_id", guiHandler.UserRevokeTrustedDevice)
 			guiAuth.DELETE("/users/:id/trusted-devices", guiHandler.UserRevokeAllTrustedDevices)
 
+			// Sign-up approval queue (applications with registration_approval_required)
+			guiAuth.GET("/user-approvals", guiHandler.UserApprovalsPage)
+			guiAuth.GET("/user-approvals/list", guiHandler.UserApprovalList)
+			guiAuth.POST("/user-approvals/approve", guiHandler.UserApprovalApprove)
+			guiAuth.POST("/user-approvals/reject", guiHandler.UserApprovalReject)
+
 			// Activity logs viewer
 			guiAuth.GET("/logs", guiHandler.LogsPage)
 			guiAuth.GET("/logs/list", guiHandler.LogList)
 			guiAuth.GET("/logs/export", guiHandler.LogExport)
 			guiAuth.GET("/logs/:id", guiHandler.LogDetail)
 
+			// Saved filters & column preferences (users / logs list views)
+			guiAuth.POST("/view-prefs/:page/filters", guiHandler.SavedFilterCreate)
+			guiAuth.PUT("/view-prefs/:page/filters/:id/default", guiHandler.SavedFilterSetDefault)
+			guiAuth.DELETE("/view-prefs/:page/filters/:id", guiHandler.SavedFilterDelete)
+			guiAuth.PUT("/view-prefs/:page/columns", guiHandler.ColumnPreferencesSave)
+
 			// API key management
 			guiAuth.GET("/api-keys", guiHandler.ApiKeysPage)
 			guiAuth.GET("/api-keys/list", guiHandler.ApiKeyList)
@@ -741,6 +1409,7 @@ func main() {
 			guiAuth.GET("/settings", guiHandler.SettingsPage)
 			guiAuth.GET("/settings/info", guiHandler.SettingsInfo)
 			guiAuth.GET("/settings/section/:category", guiHandler.SettingsSection)
+			guiAuth.POST("/settings/:key/preview", guiHandler.SettingImpactPreview)
 			guiAuth.PUT("/settings/:key", guiHandler.SettingUpdate)
 			guiAuth.DELETE("/settings/:key", guiHandler.SettingReset)
 
@@ -748,6 +1417,19 @@ func main() {
 			guiAuth.GET("/monitoring", guiHandler.MonitoringPage)
 			guiAuth.GET("/monitoring/health", guiHandler.MonitoringHealth)
 			guiAuth.GET("/monitoring/metrics", guiHandler.MonitoringMetrics)
+			guiAuth.GET("/monitoring/sms-check", guiHandler.MonitoringSMSCheck)
+
+			// Scheduled background jobs
+			guiAuth.GET("/scheduled-jobs", guiHandler.ScheduledJobsPage)
+			guiAuth.GET("/scheduled-jobs/list", guiHandler.ScheduledJobList)
+			guiAuth.GET("/scheduled-jobs/runs", guiHandler.ScheduledJobRuns)
+			guiAuth.POST("/scheduled-jobs/:name/run", guiHandler.ScheduledJobRunNow)
+
+			// Background Jobs
+			guiAuth.GET("/jobs", guiHandler.JobsPage)
+			guiAuth.GET("/jobs/list", guiHandler.JobList)
+			guiAuth.POST("/jobs/:id/cancel", guiHandler.JobCancel)
+			guiAuth.POST("/jobs/:id/retry", guiHandler.JobRetry)
 
 			// Email server management
 			guiAuth.GET("/email-servers", guiHandler.EmailServersPage)
@@ -760,6 +1442,7 @@ func main() {
 			guiAuth.GET("/email-servers/:id/delete", guiHandler.EmailServerDeleteConfirm)
 			guiAuth.DELETE("/email-servers/:id", guiHandler.EmailServerDelete)
 			guiAuth.POST("/email-servers/:id/test", guiHandler.EmailServerSendTest)
+			guiAuth.GET("/email-servers/:id/dns-check", guiHandler.EmailServerDNSCheck)
 
 			// Email template management
 			guiAuth.GET("/email-templates", guiHandler.EmailTemplatesPage)
@@ -773,6 +1456,7 @@ func main() {
 			guiAuth.DELETE("/email-templates/:id", guiHandler.EmailTemplateDelete)
 			guiAuth.POST("/email-templates/preview", guiHandler.EmailTemplatePreview)
 			guiAuth.POST("/email-templates/editor-window", guiHandler.EmailTemplateEditorWindow)
+			guiAuth.GET("/email-templates/variables", guiHandler.EmailTemplateVariables)
 			guiAuth.GET("/email-templates/:id/reset", guiHandler.EmailTemplateResetConfirm)
 			guiAuth.POST("/email-templates/:id/reset", guiHandler.EmailTemplateReset)
 
@@ -829,6 +1513,8 @@ func main() {
 			guiAuth.DELETE("/sessions/:app_id/:session_id", guiHandler.SessionRevoke)
 			guiAuth.DELETE("/sessions/revoke-all-user", guiHandler.SessionRevokeAllForUser)
 			guiAuth.GET("/users/:id/sessions", guiHandler.UserSessions)
+			guiAuth.GET("/users/:id/timeline", guiHandler.UserTimelinePage)
+			guiAuth.GET("/users/:id/timeline/list", guiHandler.UserTimelineList)
 
 			// IP Rule management
 			guiAuth.GET("/ip-rules", guiHandler.IPRulePage)
@@ -869,6 +1555,11 @@ func main() {
 			guiAuth.POST("/my-account/backup-email", guiHandler.MyAccountSetBackupEmail)
 			guiAuth.DELETE("/my-account/backup-email", guiHandler.MyAccountRemoveBackupEmail)
 
+			// Display preferences (theme, page size, timezone, date format)
+			guiAuth.GET("/my-account/preferences", guiHandler.MyAccountPreferences)
+			guiAuth.POST("/my-account/preferences", guiHandler.MyAccountSavePreferences)
+			guiAuth.POST("/preferences/theme", guiHandler.PreferencesSaveTheme)
+
 			// Trusted device management (admin self-service)
 			guiAuth.GET("/my-account/trusted-devices", guiHandler.MyAccountTrustedDevices)
 			guiAuth.DELETE("/my-account/trusted-devices/:device_id", guiHandler.MyAccountRevokeTrustedDevice)
@@ -912,6 +1603,13 @@ func main() {
 		}
 	}
 
+	// Keys for verifying access tokens offline; without the OIDC provider the
+	// discovery document only points at them.
+	r.GET("/.well-known/jwks.json", jwtkeys.JWKS)
+	if oidcHandler == nil {
+		r.GET("/.well-known/openid-configuration", jwtkeys.Discovery)
+	}
+
 	// OIDC Provider routes (enabled only when OIDC_ENABLED=true)
 	if oidcHandler != nil {
 		// Global OIDC discovery redirect to default app
@@ -934,11 +1632,16 @@ func main() {
 			oidcGroup.POST("/revoke", middleware.OIDCRevokeRateLimit(), oidcHandler.Revoke)
 			oidcGroup.GET("/end_session", oidcHandler.EndSession)
 			oidcGroup.POST("/end_session", oidcHandler.EndSession)
+			oidcGroup.GET("/authorizations", oidcHandler.AuthorizationsPage)
+			oidcGroup.POST("/authorizations/revoke", middleware.OIDCRevokeRateLimit(), oidcHandler.AuthorizationsRevoke)
 		}
 
 		// Admin OIDC client management (JSON API, protected by Admin API key)
 		adminOIDC := r.Group("/admin/oidc/apps/:id/clients")
 		adminOIDC.Use(middleware.AdminAuthMiddleware(adminRepo))
+		if adminAuditEnabled {
+			adminOIDC.Use(middleware.AdminAuditMiddleware(adminRepo))
+		}
 		{
 			adminOIDC.POST("", oidcHandler.AdminCreateClient)
 			adminOIDC.GET("", oidcHandler.AdminListClients)
@@ -952,15 +1655,102 @@ func main() {
 	// Add Swagger UI endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Start the access token revocation filter used by the auth middleware
+	if viper.GetBool("REVOCATION_FILTER_ENABLED") {
+		revocationCtx, stopRevocationFilter := context.WithCancel(context.Background())
+		defer stopRevocationFilter()
+		revocation.Start(revocationCtx, viper.GetDuration("REVOCATION_FILTER_REFRESH_INTERVAL"))
+	}
+
+	// Start the worker upgrading outdated password hashes after logins
+	rehashCtx, stopRehash := context.WithCancel(context.Background())
+	defer stopRehash()
+	passhash.Start(rehashCtx, database.DB)
+
 	// Start session group expiry detection service
 	expiryService := sessiongroup.NewExpiryService(sessionGroupRevoker)
 	expiryService.Start()
 	defer expiryService.Stop()
 
+	// Optional mutual-TLS listener that only serves the admin API key bootstrap
+	if addr := viper.GetString("ADMIN_BOOTSTRAP_MTLS_ADDR"); addr != "" {
+		bootstrapServer, err := admin.NewBootstrapMTLSServer(addr,
+			viper.GetString("ADMIN_BOOTSTRAP_TLS_CERT"), viper.GetString("ADMIN_BOOTSTRAP_TLS_KEY"),
+			viper.GetString("ADMIN_BOOTSTRAP_CLIENT_CA"), adminHandler.BootstrapApiKey)
+		if err != nil {
+			log.Fatalf("Failed to configure the admin bootstrap listener: %v", err)
+		}
+		go func() {
+			log.Printf("Admin bootstrap (mutual TLS) listening on %s", addr)
+			if err := bootstrapServer.ListenAndServeTLS("", ""); err != nil {
+				log.Printf("Admin bootstrap listener stopped: %v", err)
+			}
+		}()
+	}
+
+	// Optional admin listener: same router, restricted to the admin surface
+	if adminListenAddr != "" {
+		adminListener, err := server.Listen(adminListenAddr)
+		if err != nil {
+			log.Fatalf("Failed to open the admin listener on %s: %v", adminListenAddr, err)
+		}
+		adminServer := &http.Server{
+			Handler:           r,
+			ConnContext:       middleware.MarkAdminListener,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			log.Printf("Admin GUI and admin API listening on %s", adminListenAddr)
+			if err := adminServer.Serve(adminListener); err != nil {
+				log.Printf("Admin listener stopped: %v", err)
+			}
+		}()
+	}
+
 	// Start the server
 	port := viper.GetString("PORT")
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(fmt.Sprintf(":%s", port)); err != nil {
+	tlsOptions := server.TLSOptions{
+		CertFile:         viper.GetString("TLS_CERT_FILE"),
+		KeyFile:          viper.GetString("TLS_KEY_FILE"),
+		AutocertDomains:  splitList(viper.GetString("TLS_AUTOCERT_DOMAINS")),
+		AutocertEmail:    viper.GetString("TLS_AUTOCERT_EMAIL"),
+		AutocertCacheDir: viper.GetString("TLS_AUTOCERT_CACHE_DIR"),
+		DisableHTTP2:     !viper.GetBool("HTTP2_ENABLED"),
+	}
+	if !tlsOptions.Enabled() {
+		log.Printf("Server starting on port %s", port)
+		if err := r.Run(fmt.Sprintf(":%s", port)); err != nil {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+		return
+	}
+
+	tlsServer, certManager, err := server.NewTLSServer(fmt.Sprintf(":%s", port), r, tlsOptions)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	if addr := viper.GetString("TLS_REDIRECT_ADDR"); addr != "" {
+		redirectServer := server.NewRedirectServer(addr, port, certManager)
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", addr)
+			if err := redirectServer.ListenAndServe(); err != nil {
+				log.Printf("HTTP redirect listener stopped: %v", err)
+			}
+		}()
+	}
+	log.Printf("Server starting on port %s with TLS (HTTP/2: %v)", port, !tlsOptions.DisableHTTP2)
+	if err := tlsServer.ListenAndServeTLS("", ""); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
+
+// splitList splits a comma-separated setting, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/admin"
+	"github.com/gjovanovicst/auth_api/internal/database"
+	"github.com/gjovanovicst/auth_api/internal/migrationguard"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultID is the ID of the default tenant and application, as created by
+// migrations/20260105_add_multi_tenancy.sql and expected by OIDC_DEFAULT_APP_ID.
+var defaultID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// bootstrapOptions configure the non-interactive setup.
+type bootstrapOptions struct {
+	Username      string
+	Password      string
+	Email         string
+	TenantName    string
+	AppName       string
+	MigrationsDir string // Empty = skip SQL migrations
+	APIKeyFile    string // Empty = print the key
+	Guard         migrationGuardOptions
+}
+
+// migrationGuardOptions configure the checks run on each pending SQL
+// migration before it is applied.
+type migrationGuardOptions struct {
+	AllowUnsafe    bool  // Apply migrations with guard errors anyway
+	LargeTableRows int64 // Estimated row count from which a locked table is large
+}
+
+// runNonInteractive performs a full bootstrap without prompts: migrations, the
+// default tenant and app, the admin account and an admin API key. Every step
+// is skipped when already done, so it can run on every start of an init
+// container.
+func runNonInteractive(opts bootstrapOptions) error {
+	if opts.Username == "" || opts.Password == "" {
+		return fmt.Errorf("--username and --password (or SETUP_ADMIN_USERNAME and SETUP_ADMIN_PASSWORD) are required")
+	}
+	if err := validateUsername(opts.Username); err != nil {
+		return fmt.Errorf("invalid username: %w", err)
+	}
+	if opts.Email != "" {
+		if err := validateEmail(opts.Email); err != nil {
+			return fmt.Errorf("invalid email: %w", err)
+		}
+	}
+
+	database.ConnectDatabase()
+	database.MigrateDatabase()
+	db := database.DB
+
+	if opts.MigrationsDir != "" {
+		applied, err := applySQLMigrations(db, opts.MigrationsDir, opts.Guard)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("SQL migrations: %d applied\n", applied)
+	}
+
+	if err := ensureDefaultTenantApp(db, opts.TenantName, opts.AppName); err != nil {
+		return err
+	}
+
+	// Admin account: an existing one is left unchanged
+	repo := admin.NewAccountRepository(db)
+	if existing, _ := repo.GetByUsername(opts.Username); existing != nil {
+		fmt.Printf("Admin account '%s' already exists, leaving it unchanged\n", opts.Username)
+	} else {
+		if err := validatePassword(opts.Password); err != nil {
+			return fmt.Errorf("invalid password: %w", err)
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(opts.Password), bcryptCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		account := &models.AdminAccount{Username: opts.Username, Email: opts.Email, PasswordHash: string(hashedPassword)}
+		if err := repo.Create(account); err != nil {
+			return fmt.Errorf("failed to create admin account: %w", err)
+		}
+		fmt.Printf("Admin account '%s' created\n", opts.Username)
+	}
+
+	// Admin API key: only the first one is created here
+	rawKey, apiKey, err := admin.NewRepository(db).BootstrapAdminApiKey("Setup key", "Created by cmd/setup --non-interactive")
+	if errors.Is(err, admin.ErrAlreadyBootstrapped) {
+		fmt.Println("An admin API key already exists, not creating another one")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create admin API key: %w", err)
+	}
+	if opts.APIKeyFile != "" {
+		if err := writeSecretFile(opts.APIKeyFile, rawKey+"\n"); err != nil {
+			return err
+		}
+		fmt.Printf("Admin API key %s... written to %s\n", apiKey.KeyPrefix, opts.APIKeyFile)
+	} else {
+		fmt.Println("Admin API key (shown only once, use it in the X-Admin-API-Key header):")
+		fmt.Println(rawKey)
+	}
+	return nil
+}
+
+// pendingMigrationFiles returns the forward SQL migrations in dir, sorted by
+// name, that are not in applied.
+func pendingMigrationFiles(dir string, applied map[string]bool) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var pending []string
+	for _, p := range paths {
+		version := strings.TrimSuffix(filepath.Base(p), ".sql")
+		if strings.HasSuffix(version, "_rollback") || applied[version] {
+			continue
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// applySQLMigrations applies the pending SQL migrations in dir and records
+// them in schema_migrations, like scripts/apply_pending_migrations.sh. It
+// stops at the first failure, and before a migration the guard reports as
+// unsafe for the current table sizes unless guard.AllowUnsafe is set.
+func applySQLMigrations(db *gorm.DB, dir string, guard migrationGuardOptions) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, fmt.Errorf("migrations directory: %w", err)
+	}
+	var versions []string
+	if err := db.Model(&models.SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return 0, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	applied := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+	pending, err := pendingMigrationFiles(dir, applied)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, path := range pending {
+		version := strings.TrimSuffix(filepath.Base(path), ".sql")
+		sql, err := os.ReadFile(path) // #nosec G304 -- migration files from the configured directory
+		if err != nil {
+			return i, err
+		}
+		findings := migrationguard.Lint(string(sql), largeTableFunc(db, guard.LargeTableRows))
+		for _, f := range findings {
+			fmt.Printf("  %s: %s\n", version, f)
+		}
+		if migrationguard.HasErrors(findings) && !guard.AllowUnsafe {
+			return i, fmt.Errorf("migration %s would lock large tables; rewrite it or rerun with --allow-unsafe-migrations", version)
+		}
+
+		fmt.Printf("Applying migration: %s\n", version)
+		start := time.Now()
+		if err := execMigration(db, string(sql)); err != nil {
+			return i, fmt.Errorf("migration %s failed: %w", version, err)
+		}
+		sum := sha256.Sum256(sql)
+		record := models.SchemaMigration{
+			Version:         version,
+			Name:            version,
+			AppliedAt:       time.Now().UTC(),
+			ExecutionTimeMs: int(time.Since(start).Milliseconds()),
+			Success:         true,
+			Checksum:        hex.EncodeToString(sum[:]),
+		}
+		// Some migrations record themselves
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+			return i, fmt.Errorf("failed to record migration %s: %w", version, err)
+		}
+	}
+	return len(pending), nil
+}
+
+// execMigration runs a migration as one query string, or statement by
+// statement when it builds indexes CONCURRENTLY, which cannot run inside the
+// implicit transaction of a multi-statement query.
+func execMigration(db *gorm.DB, sql string) error {
+	if !migrationguard.NeedsStatementExecution(sql) {
+		return db.Exec(sql).Error
+	}
+	for _, stmt := range migrationguard.Split(sql) {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// largeTableFunc reports a table as large when the planner's row estimate
+// reaches rows. Tables that do not exist yet, or were never analyzed, are
+// unknown.
+func largeTableFunc(db *gorm.DB, rows int64) migrationguard.LargeTableFunc {
+	return func(table string) (bool, bool) {
+		var estimate *int64
+		if err := db.Raw("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)", table).Scan(&estimate).Error; err != nil || estimate == nil || *estimate < 0 {
+			return false, false
+		}
+		return *estimate >= rows, true
+	}
+}
+
+// lintMigrations checks migration files without a database. Locking
+// operations on the comma-separated largeTables are errors; other table sizes
+// are unknown, so only size-independent problems are errors there. With no
+// paths, every forward migration in dir is checked. It reports whether no file
+// has errors.
+func lintMigrations(dir string, paths []string, largeTables string) (bool, error) {
+	if len(paths) == 0 {
+		var err error
+		if paths, err = pendingMigrationFiles(dir, nil); err != nil {
+			return false, err
+		}
+	}
+	ok := true
+	for _, path := range paths {
+		sql, err := os.ReadFile(path) // #nosec G304 -- migration files named by the operator
+		if err != nil {
+			return false, err
+		}
+		findings := migrationguard.Lint(string(sql), listedTableFunc(largeTables))
+		for _, f := range findings {
+			fmt.Printf("%s: %s\n", filepath.Base(path), f)
+		}
+		if migrationguard.HasErrors(findings) {
+			ok = false
+		}
+	}
+	return ok, nil
+}
+
+// listedTableFunc reports the tables in the comma-separated list as large and
+// the size of any other table as unknown. An empty list yields nil.
+func listedTableFunc(list string) migrationguard.LargeTableFunc {
+	large := map[string]bool{}
+	for _, table := range strings.Split(list, ",") {
+		if table = strings.ToLower(strings.TrimSpace(table)); table != "" {
+			large[strings.TrimPrefix(table, "public.")] = true
+		}
+	}
+	if len(large) == 0 {
+		return nil
+	}
+	return func(table string) (bool, bool) {
+		return large[table], large[table]
+	}
+}
+
+// ensureDefaultTenantApp creates the default tenant and application if the
+// migrations did not.
+func ensureDefaultTenantApp(db *gorm.DB, tenantName, appName string) error {
+	tenant := models.Tenant{ID: defaultID, Name: tenantName}
+	if err := db.Where(models.Tenant{ID: defaultID}).FirstOrCreate(&tenant).Error; err != nil {
+		return fmt.Errorf("failed to create default tenant: %w", err)
+	}
+	app := models.Application{ID: defaultID, TenantID: defaultID, Name: appName}
+	if err := db.Where(models.Application{ID: defaultID}).FirstOrCreate(&app).Error; err != nil {
+		return fmt.Errorf("failed to create default application: %w", err)
+	}
+	fmt.Printf("Default tenant '%s' and application '%s' (%s) ready\n", tenant.Name, app.Name, app.ID)
+	return nil
+}
+
+// writeSecretFile writes content to path, readable by the owner only.
+func writeSecretFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) // #nosec G304 -- path from the operator
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer f.Close()
+	// O_CREATE does not change the mode of an existing file
+	if err := f.Chmod(0o600); err != nil {
+		return fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// envOr returns the environment variable key when set, else def.
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+// envInt64 returns the environment variable key as a number when set and
+// valid, else def.
+func envInt64(key string, def int64) int64 {
+	if v, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/internal/migrationguard"
+)
+
+func TestPendingMigrationFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"20260105_b.sql", "20260105_b_rollback.sql", "00_init.sql", "20240103_a.sql", "20240103_a.md", "20261016_c.sql",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := pendingMigrationFiles(dir, map[string]bool{"20240103_a": true})
+	if err != nil {
+		t.Fatalf("pendingMigrationFiles: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "00_init.sql"),
+		filepath.Join(dir, "20260105_b.sql"),
+		filepath.Join(dir, "20261016_c.sql"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pending = %v, want %v (sorted, without rollbacks and applied ones)", got, want)
+	}
+}
+
+// TestMigrationOrder checks that the repository's migrations, applied in file
+// name order, alter and index tables only after the migration creating them.
+func TestMigrationOrder(t *testing.T) {
+	paths, err := pendingMigrationFiles(filepath.Join("..", "..", "migrations"), nil)
+	if err != nil {
+		t.Fatalf("pendingMigrationFiles: %v", err)
+	}
+	createRe := regexp.MustCompile(`(?i)CREATE TABLE (?:IF NOT EXISTS )?(\w+)`)
+	useRe := regexp.MustCompile(`(?i)(?:ALTER TABLE (?:IF EXISTS )?|INDEX (?:CONCURRENTLY )?(?:IF NOT EXISTS )?\w+ ON )(\w+)`)
+
+	createdIn := map[string]int{}
+	sqls := make([]string, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqls[i] = string(data)
+		for _, m := range createRe.FindAllStringSubmatch(sqls[i], -1) {
+			if _, ok := createdIn[m[1]]; !ok {
+				createdIn[m[1]] = i
+			}
+		}
+	}
+	for i, sql := range sqls {
+		for _, m := range useRe.FindAllStringSubmatch(sql, -1) {
+			if j, ok := createdIn[m[1]]; ok && j > i {
+				t.Errorf("%s uses %s before %s creates it", filepath.Base(paths[i]), m[1], filepath.Base(paths[j]))
+			}
+		}
+	}
+}
+
+func TestWriteSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin-api-key")
+	// An existing, world-readable file is restricted too
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSecretFile(path, "ak_secret\n"); err != nil {
+		t.Fatalf("writeSecretFile: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "ak_secret\n" {
+		t.Errorf("content = %q", data)
+	}
+}
+
+func TestLintMigrations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20261016_a.sql": "CREATE INDEX idx_users_x ON users(x);",
+		"20261016_b.sql": "BEGIN;\nCREATE INDEX CONCURRENTLY idx_users_y ON users(y);\nCOMMIT;",
+	}
+	for name, sql := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(sql), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without table sizes a blocking index is only a warning
+	ok, err := lintMigrations(dir, []string{filepath.Join(dir, "20261016_a.sql")}, "")
+	if err != nil || !ok {
+		t.Errorf("lintMigrations(a) = %v, %v, want true, nil", ok, err)
+	}
+	// On a table listed as large it is an error
+	ok, err = lintMigrations(dir, []string{filepath.Join(dir, "20261016_a.sql")}, "sessions, public.users")
+	if err != nil || ok {
+		t.Errorf("lintMigrations(a, users large) = %v, %v, want false, nil", ok, err)
+	}
+	ok, err = lintMigrations(dir, []string{filepath.Join(dir, "20261016_a.sql")}, "sessions")
+	if err != nil || !ok {
+		t.Errorf("lintMigrations(a, sessions large) = %v, %v, want true, nil", ok, err)
+	}
+	ok, err = lintMigrations(dir, nil, "")
+	if err != nil || ok {
+		t.Errorf("lintMigrations(all) = %v, %v, want false, nil (CONCURRENTLY inside a transaction)", ok, err)
+	}
+}
+
+// guardIntroduced is the version prefix of the first migrations written
+// against the migration guard; older ones predate it and are not linted.
+const guardIntroduced = "20261016"
+
+// TestRepoMigrationsPassGuard keeps every migration since the guard was
+// introduced free of findings, so they apply on large tables without
+// --allow-unsafe-migrations.
+func TestRepoMigrationsPassGuard(t *testing.T) {
+	paths, err := pendingMigrationFiles(filepath.Join("..", "..", "migrations"), nil)
+	if err != nil {
+		t.Fatalf("pendingMigrationFiles: %v", err)
+	}
+	for _, path := range paths {
+		if version, _, _ := strings.Cut(filepath.Base(path), "_"); version < guardIntroduced {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range migrationguard.Lint(string(data), nil) {
+			t.Errorf("%s: %s", filepath.Base(path), f)
+		}
+	}
+}
@@ -25,20 +25,63 @@ const (
 )
 
 func main() {
+	// Load environment variables (flags default to the SETUP_* variables)
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, relying on environment variables")
+	}
+
 	// Parse command-line flags for non-interactive mode
-	username := flag.String("username", "", "Admin username")
-	password := flag.String("password", "", "Admin password")
-	emailFlag := flag.String("email", "", "Admin email (optional)")
+	username := flag.String("username", os.Getenv("SETUP_ADMIN_USERNAME"), "Admin username (env SETUP_ADMIN_USERNAME)")
+	password := flag.String("password", os.Getenv("SETUP_ADMIN_PASSWORD"), "Admin password (env SETUP_ADMIN_PASSWORD)")
+	emailFlag := flag.String("email", os.Getenv("SETUP_ADMIN_EMAIL"), "Admin email (optional, env SETUP_ADMIN_EMAIL)")
+	nonInteractive := flag.Bool("non-interactive", envOr("SETUP_NON_INTERACTIVE", "") == "true",
+		"Full bootstrap without prompts: migrations, default tenant/app, admin account and admin API key (env SETUP_NON_INTERACTIVE=true)")
+	migrationsDir := flag.String("migrations-dir", envOr("SETUP_MIGRATIONS_DIR", "migrations"), "SQL migrations applied in non-interactive mode (env SETUP_MIGRATIONS_DIR)")
+	skipMigrations := flag.Bool("skip-sql-migrations", envOr("SETUP_SKIP_SQL_MIGRATIONS", "") == "true", "Only run GORM AutoMigrate in non-interactive mode (env SETUP_SKIP_SQL_MIGRATIONS=true)")
+	tenantName := flag.String("tenant-name", envOr("SETUP_TENANT_NAME", "Default Tenant"), "Name of the default tenant, if created (env SETUP_TENANT_NAME)")
+	appName := flag.String("app-name", envOr("SETUP_APP_NAME", "Default App"), "Name of the default application, if created (env SETUP_APP_NAME)")
+	apiKeyFile := flag.String("api-key-file", os.Getenv("SETUP_API_KEY_FILE"), "Write the admin API key to this file (mode 0600) instead of printing it (env SETUP_API_KEY_FILE)")
+	allowUnsafe := flag.Bool("allow-unsafe-migrations", envOr("SETUP_ALLOW_UNSAFE_MIGRATIONS", "") == "true", "Apply SQL migrations that would lock large tables (env SETUP_ALLOW_UNSAFE_MIGRATIONS=true)")
+	largeTableRows := flag.Int64("large-table-rows", envInt64("SETUP_LARGE_TABLE_ROWS", 100000), "Estimated row count from which the migration guard treats a table as large (env SETUP_LARGE_TABLE_ROWS)")
+	lintOnly := flag.Bool("lint-migrations", false, "Check the SQL migrations given as arguments (default: all in --migrations-dir) for locking operations and exit")
+	largeTables := flag.String("large-tables", os.Getenv("SETUP_LARGE_TABLES"), "Comma-separated tables --lint-migrations treats as large, turning locking operations on them into errors (env SETUP_LARGE_TABLES)")
 	flag.Parse()
 
+	if *lintOnly {
+		ok, err := lintMigrations(*migrationsDir, flag.Args(), *largeTables)
+		if err != nil {
+			log.Fatalf("Migration lint failed: %v", err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Println("===========================================")
 	fmt.Println("  Auth API - Admin Account Setup")
 	fmt.Println("===========================================")
 	fmt.Println()
 
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, relying on environment variables")
+	if *nonInteractive {
+		opts := bootstrapOptions{
+			Username:      *username,
+			Password:      *password,
+			Email:         *emailFlag,
+			TenantName:    *tenantName,
+			AppName:       *appName,
+			MigrationsDir: *migrationsDir,
+			APIKeyFile:    *apiKeyFile,
+			Guard:         migrationGuardOptions{AllowUnsafe: *allowUnsafe, LargeTableRows: *largeTableRows},
+		}
+		if *skipMigrations {
+			opts.MigrationsDir = ""
+		}
+		if err := runNonInteractive(opts); err != nil {
+			log.Fatalf("Setup failed: %v", err)
+		}
+		fmt.Println("Setup complete.")
+		return
 	}
 
 	// Connect to database
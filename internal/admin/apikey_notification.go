@@ -4,134 +4,161 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/internal/notification"
+	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
 // ApiKeyNotificationService sends expiry-warning emails for API keys that are
-// about to expire. It runs as an in-process background goroutine, checking once
-// per day (same pattern as internal/log/cleanup.go).
+// about to expire. Run scans the api_keys table; it is registered as a daily
+// scheduler job (see ReminderSchedule and internal/scheduler).
 //
 // Notifications are sent:
 //   - 7 days before expiry  (deduplicated via notified_7_days_at column)
 //   - 1 day  before expiry  (deduplicated via notified_1_day_at  column)
 //
-// The recipient is the system admin email configured via the ADMIN_EMAIL
-// environment variable. If the variable is empty, notifications are skipped.
+// Recipients are the admin addresses configured via the ADMIN_EMAIL environment
+// variable (comma separated). If the variable is empty, no email is sent. Each
+// warning is also raised in the admin GUI notification center when
+// Notifications is set.
 type ApiKeyNotificationService struct {
-	repo         *Repository
-	emailService *email.Service
-	ctx          context.Context
-	cancel       context.CancelFunc
-	ticker       *time.Ticker
+	repo          *Repository
+	emailService  *email.Service
+	Notifications *notification.Service // Optional: GUI notification center (nil = email only)
 }
 
-// NewApiKeyNotificationService creates the service but does not start it.
+// expiryReminder identifies which expiry warning is due for an API key.
+type expiryReminder int
+
+const (
+	reminderNone expiryReminder = iota
+	reminder7Days
+	reminder1Day
+)
+
+// NewApiKeyNotificationService creates the service.
 func NewApiKeyNotificationService(repo *Repository, emailSvc *email.Service) *ApiKeyNotificationService {
-	ctx, cancel := context.WithCancel(context.Background())
 	return &ApiKeyNotificationService{
 		repo:         repo,
 		emailService: emailSvc,
-		ctx:          ctx,
-		cancel:       cancel,
-		ticker:       time.NewTicker(24 * time.Hour),
 	}
 }
 
-// Start launches the background worker goroutine.
-func (s *ApiKeyNotificationService) Start() {
-	go s.worker()
-	log.Println("API key expiry notification service started (interval: 24h)")
+// ReminderSchedule returns the cron expression of the daily reminder job:
+// every day at API_KEY_REMINDER_HOUR (UTC, default 9).
+func ReminderSchedule() string {
+	hour := 9
+	if viper.IsSet("API_KEY_REMINDER_HOUR") {
+		if h := viper.GetInt("API_KEY_REMINDER_HOUR"); h >= 0 && h <= 23 {
+			hour = h
+		}
+	}
+	return fmt.Sprintf("0 %d * * *", hour)
 }
 
-// Shutdown stops the background worker.
-func (s *ApiKeyNotificationService) Shutdown() {
-	if s == nil {
-		return
-	}
-	log.Println("Shutting down API key notification service...")
-	if s.cancel != nil {
-		s.cancel()
-	}
-	if s.ticker != nil {
-		s.ticker.Stop()
+// adminEmailRecipients returns the admin addresses configured in ADMIN_EMAIL.
+func adminEmailRecipients() []string {
+	var recipients []string
+	for _, addr := range strings.Split(viper.GetString("ADMIN_EMAIL"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
 	}
+	return recipients
 }
 
-// worker runs the notification check on a 24-hour schedule.
-func (s *ApiKeyNotificationService) worker() {
-	// Run an initial check shortly after startup.
-	time.Sleep(2 * time.Minute)
-	s.runCheck()
-
-	for {
-		select {
-		case <-s.ctx.Done():
-			log.Println("API key notification service shutting down...")
-			return
-		case <-s.ticker.C:
-			s.runCheck()
-		}
+// dueReminder reports which warning is outstanding for a key and how many days
+// (rounded up) remain until it expires. Only the most urgent warning is due:
+// a key first seen inside the 1-day window gets the 1-day warning alone.
+func dueReminder(key models.ApiKey, now time.Time) (expiryReminder, int) {
+	if key.ExpiresAt == nil {
+		return reminderNone, 0
 	}
+	daysLeft := int(math.Ceil(key.ExpiresAt.Sub(now).Hours() / 24))
+	switch {
+	case daysLeft <= 1 && key.Notified1DayAt == nil:
+		return reminder1Day, daysLeft
+	case daysLeft <= 7 && key.Notified7DaysAt == nil && key.Notified1DayAt == nil:
+		return reminder7Days, daysLeft
+	}
+	return reminderNone, daysLeft
 }
 
-// runCheck queries for keys expiring within 7 days and sends any outstanding
-// notification emails.
-func (s *ApiKeyNotificationService) runCheck() {
-	adminEmail := viper.GetString("ADMIN_EMAIL")
-	if adminEmail == "" {
+// Run queries for keys expiring within 7 days and sends any outstanding
+// expiry warnings. Its signature matches scheduler.JobFunc.
+func (s *ApiKeyNotificationService) Run(ctx context.Context) error {
+	recipients := adminEmailRecipients()
+	if len(recipients) == 0 && s.Notifications == nil {
 		// No recipient configured — nothing to do.
-		return
+		return nil
 	}
 
-	log.Println("Running API key expiry notification check...")
-
 	keys, err := s.repo.GetKeysExpiringWithin(7)
 	if err != nil {
-		log.Printf("API key notification: failed to query expiring keys: %v", err)
-		return
+		return fmt.Errorf("failed to query expiring keys: %w", err)
 	}
 
 	now := time.Now().UTC()
 	sent := 0
 
 	for _, key := range keys {
-		if key.ExpiresAt == nil {
-			continue
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-		daysLeft := int(key.ExpiresAt.UTC().Sub(now).Hours() / 24)
-
-		// 7-day warning
-		if daysLeft <= 7 && key.Notified7DaysAt == nil {
-			if err := s.sendNotification(adminEmail, key.ID, key.Name, key.KeyPrefix, string(key.KeyType), *key.ExpiresAt, daysLeft); err != nil {
+		reminder, daysLeft := dueReminder(key, now)
+		switch reminder {
+		case reminder7Days:
+			if err := s.remind(recipients, key, daysLeft); err != nil {
 				log.Printf("API key notification: failed to send 7-day warning for key %s: %v", key.ID, err)
-			} else {
-				if markErr := s.repo.MarkApiKeyNotified7Days(key.ID); markErr != nil {
-					log.Printf("API key notification: failed to mark 7-day notified for key %s: %v", key.ID, markErr)
-				}
-				sent++
+				continue
 			}
-		}
-
-		// 1-day warning
-		if daysLeft <= 1 && key.Notified1DayAt == nil {
-			if err := s.sendNotification(adminEmail, key.ID, key.Name, key.KeyPrefix, string(key.KeyType), *key.ExpiresAt, daysLeft); err != nil {
+			if markErr := s.repo.MarkApiKeyNotified7Days(key.ID); markErr != nil {
+				log.Printf("API key notification: failed to mark 7-day notified for key %s: %v", key.ID, markErr)
+			}
+			sent++
+		case reminder1Day:
+			if err := s.remind(recipients, key, daysLeft); err != nil {
 				log.Printf("API key notification: failed to send 1-day warning for key %s: %v", key.ID, err)
-			} else {
-				if markErr := s.repo.MarkApiKeyNotified1Day(key.ID); markErr != nil {
-					log.Printf("API key notification: failed to mark 1-day notified for key %s: %v", key.ID, markErr)
-				}
-				sent++
+				continue
+			}
+			if markErr := s.repo.MarkApiKeyNotified1Day(key.ID); markErr != nil {
+				log.Printf("API key notification: failed to mark 1-day notified for key %s: %v", key.ID, markErr)
 			}
+			sent++
 		}
 	}
 
 	if sent > 0 {
-		log.Printf("API key notification: sent %d expiry warning email(s)", sent)
+		log.Printf("API key notification: sent %d expiry warning(s)", sent)
+	}
+	return nil
+}
+
+// remind sends one expiry warning: an email to each recipient and a GUI
+// notification. It fails only when every email failed, so one bad address does
+// not cause the others to be warned again on the next run.
+func (s *ApiKeyNotificationService) remind(recipients []string, key models.ApiKey, daysLeft int) error {
+	var lastErr error
+	delivered := 0
+	for _, to := range recipients {
+		if err := s.sendNotification(to, key.ID, key.Name, key.KeyPrefix, string(key.KeyType), *key.ExpiresAt, daysLeft); err != nil {
+			log.Printf("API key notification: failed to email %s about key %s: %v", to, key.ID, err)
+			lastErr = err
+			continue
+		}
+		delivered++
+	}
+	if len(recipients) > 0 && delivered == 0 {
+		return lastErr
 	}
+	s.Notifications.ApiKeyExpiring(key.ID, key.Name, key.KeyPrefix, *key.ExpiresAt, daysLeft)
+	return nil
 }
 
 // sendNotification sends a single api_key_expiring_soon email.
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/spf13/viper"
+)
+
+func TestDueReminder(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { v := now.Add(d); return &v }
+	sent := at(-time.Hour)
+
+	tests := []struct {
+		name     string
+		key      models.ApiKey
+		want     expiryReminder
+		wantDays int
+	}{
+		{"no expiry", models.ApiKey{}, reminderNone, 0},
+		{"6.5 days out", models.ApiKey{ExpiresAt: at(156 * time.Hour)}, reminder7Days, 7},
+		{"7-day already sent", models.ApiKey{ExpiresAt: at(156 * time.Hour), Notified7DaysAt: sent}, reminderNone, 7},
+		{"20 hours out", models.ApiKey{ExpiresAt: at(20 * time.Hour), Notified7DaysAt: sent}, reminder1Day, 1},
+		{"first seen inside 1-day window", models.ApiKey{ExpiresAt: at(20 * time.Hour)}, reminder1Day, 1},
+		{"1-day already sent", models.ApiKey{ExpiresAt: at(20 * time.Hour), Notified1DayAt: sent}, reminderNone, 1},
+		{"30 hours out", models.ApiKey{ExpiresAt: at(30 * time.Hour), Notified7DaysAt: sent}, reminderNone, 2},
+	}
+	for _, tt := range tests {
+		got, days := dueReminder(tt.key, now)
+		if got != tt.want || days != tt.wantDays {
+			t.Errorf("%s: got (%v, %d), want (%v, %d)", tt.name, got, days, tt.want, tt.wantDays)
+		}
+	}
+}
+
+func TestReminderSchedule(t *testing.T) {
+	defer viper.Set("API_KEY_REMINDER_HOUR", nil)
+
+	viper.Set("API_KEY_REMINDER_HOUR", nil)
+	if got := ReminderSchedule(); got != "0 9 * * *" {
+		t.Errorf("default = %q", got)
+	}
+	viper.Set("API_KEY_REMINDER_HOUR", 0)
+	if got := ReminderSchedule(); got != "0 0 * * *" {
+		t.Errorf("hour 0 = %q", got)
+	}
+	viper.Set("API_KEY_REMINDER_HOUR", 25)
+	if got := ReminderSchedule(); got != "0 9 * * *" {
+		t.Errorf("out of range = %q", got)
+	}
+}
+
+func TestAdminEmailRecipients(t *testing.T) {
+	viper.Set("ADMIN_EMAIL", " ops@example.com, ,security@example.com ")
+	defer viper.Set("ADMIN_EMAIL", "")
+
+	got := adminEmailRecipients()
+	if len(got) != 2 || got[0] != "ops@example.com" || got[1] != "security@example.com" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,206 @@
+package admin
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// AdminAuditFilter narrows ListAdminAuditLogs to a time range; zero values match everything.
+type AdminAuditFilter struct {
+	Since *time.Time
+	Until *time.Time
+}
+
+// adminAuditListSpec is the sorting and filtering of the admin audit log list.
+var adminAuditListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"created_at":  "created_at",
+		"status_code": "status_code",
+		"duration_ms": "duration_ms",
+		"route":       "route",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"method":      {Column: "method", Kind: pagination.FilterExact},
+		"route":       {Column: "route", Kind: pagination.FilterExact},
+		"status_code": {Column: "status_code", Kind: pagination.FilterInt},
+		"api_key_id":  {Column: "api_key_id", Kind: pagination.FilterUUID},
+	},
+}
+
+// CreateAdminAuditLog stores a captured Admin API request (middleware.AdminAuditRecorder).
+func (r *Repository) CreateAdminAuditLog(entry *models.AdminAuditLog) error {
+	return r.DB.Create(entry).Error
+}
+
+// ListAdminAuditLogs returns a page of captured Admin API requests, newest
+// first unless sorted otherwise, without their bodies.
+func (r *Repository) ListAdminAuditLogs(lq pagination.ListQuery, filter AdminAuditFilter) ([]models.AdminAuditLog, int64, error) {
+	q := lq.ApplyFilters(r.DB.Model(&models.AdminAuditLog{}))
+	if filter.Since != nil {
+		q = q.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		q = q.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.AdminAuditLog
+	err := lq.ApplyPage(q.Omit("request_body", "response_body")).Find(&entries).Error
+	return entries, total, err
+}
+
+// GetAdminAuditLog returns a captured Admin API request with its bodies.
+func (r *Repository) GetAdminAuditLog(id uuid.UUID) (*models.AdminAuditLog, error) {
+	var entry models.AdminAuditLog
+	if err := r.DB.First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CleanupAdminAuditLogs deletes captured requests older than
+// ADMIN_AUDIT_RETENTION_DAYS (default 90). Its signature matches scheduler.JobFunc.
+func (r *Repository) CleanupAdminAuditLogs(ctx context.Context) error {
+	days := viper.GetInt("ADMIN_AUDIT_RETENTION_DAYS")
+	if days <= 0 {
+		days = 90
+	}
+	result := r.DB.WithContext(ctx).
+		Where("created_at < ?", time.Now().UTC().AddDate(0, 0, -days)).
+		Delete(&models.AdminAuditLog{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Admin audit: deleted %d captured request(s) older than %d days", result.RowsAffected, days)
+	}
+	return nil
+}
+
+func toAdminAuditLogResponse(e *models.AdminAuditLog) dto.AdminAuditLogResponse {
+	return dto.AdminAuditLogResponse{
+		ID:                e.ID,
+		Method:            e.Method,
+		Route:             e.Route,
+		Path:              e.Path,
+		StatusCode:        e.StatusCode,
+		ApiKeyID:          e.ApiKeyID,
+		IPAddress:         e.IPAddress,
+		UserAgent:         e.UserAgent,
+		RequestBody:       e.RequestBody,
+		ResponseBody:      e.ResponseBody,
+		RequestTruncated:  e.RequestTruncated,
+		ResponseTruncated: e.ResponseTruncated,
+		DurationMs:        e.DurationMs,
+		CreatedAt:         e.CreatedAt,
+	}
+}
+
+// ListAdminAuditLogs lists captured Admin API requests
+// @Summary List admin audit log entries
+// @Description Returns Admin API requests captured while ADMIN_AUDIT_CAPTURE_ENABLED is set, newest first, without bodies.
+// @Tags Admin
+// @Produce json
+// @Param   page                 query  int     false  "Page number" default(1)
+// @Param   page_size            query  int     false  "Page size (max 100)" default(20)
+// @Param   sort                 query  string  false  "Sort field: created_at, status_code, duration_ms or route (prefix with - for descending)" default(created_at)
+// @Param   order                query  string  false  "Sort order: asc or desc" default(desc)
+// @Param   filter[method]       query  string  false  "HTTP method (also accepted as method)"
+// @Param   filter[route]        query  string  false  "Route pattern, e.g. /admin/apps/:id (also accepted as route)"
+// @Param   filter[status_code]  query  int     false  "Response status code (also accepted as status_code)"
+// @Param   filter[api_key_id]   query  string  false  "Admin API key ID (also accepted as api_key_id)"
+// @Param   since                query  string  false  "Only entries at or after this time (RFC 3339)"
+// @Param   until                query  string  false  "Only entries before this time (RFC 3339)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/audit-logs [get]
+func (h *Handler) ListAdminAuditLogs(c *gin.Context) {
+	lq, err := pagination.ParseListQuery(c.Request.URL.Query(), adminAuditListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var filter AdminAuditFilter
+	var ok bool
+	if filter.Since, ok = timeQuery(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = timeQuery(c, "until"); !ok {
+		return
+	}
+
+	entries, total, err := h.Repo.ListAdminAuditLogs(lq, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list audit log entries"})
+		return
+	}
+	response := make([]dto.AdminAuditLogResponse, 0, len(entries))
+	for i := range entries {
+		response = append(response, toAdminAuditLogResponse(&entries[i]))
+	}
+
+	c.JSON(http.StatusOK, lq.Response(response, total))
+}
+
+// timeQuery parses an optional RFC 3339 query parameter. It writes a 400
+// response and returns false when the value is invalid.
+func timeQuery(c *gin.Context, param string) (*time.Time, bool) {
+	raw := c.Query(param)
+	if raw == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid " + param + ": expected RFC 3339 timestamp"})
+		return nil, false
+	}
+	return &t, true
+}
+
+// GetAdminAuditLog returns one captured Admin API request
+// @Summary Get an admin audit log entry
+// @Description Returns a captured Admin API request with its redacted request and response bodies.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "Audit log entry ID"
+// @Success 200 {object} dto.AdminAuditLogResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/audit-logs/{id} [get]
+func (h *Handler) GetAdminAuditLog(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid audit log entry ID"})
+		return
+	}
+	entry, err := h.Repo.GetAdminAuditLog(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Audit log entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load audit log entry"})
+		return
+	}
+	c.JSON(http.StatusOK, toAdminAuditLogResponse(entry))
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"fmt"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// authMethodLabels are the app form labels of the auth methods.
+var authMethodLabels = map[string]string{
+	models.AuthMethodPassword:          "Password",
+	models.AuthMethodGoogle:            "Google",
+	models.AuthMethodFacebook:          "Facebook",
+	models.AuthMethodGithub:            "GitHub",
+	models.AuthMethodOIDC:              "OpenID Connect providers",
+	models.AuthMethodMagicLink:         "Magic link",
+	models.AuthMethodPasskey:           "Passkey",
+	models.AuthMethodClientCredentials: "Client credentials (OIDC)",
+}
+
+// authMethodOption is one checkbox of the app form's sign-in method policy.
+type authMethodOption struct {
+	Value   string
+	Label   string
+	Allowed bool
+}
+
+// authMethodOptions returns the app form checkboxes for an auth method policy.
+func authMethodOptions(app *models.Application) []authMethodOption {
+	opts := make([]authMethodOption, 0, len(models.AuthMethods))
+	for _, m := range models.AuthMethods {
+		opts = append(opts, authMethodOption{Value: m, Label: authMethodLabels[m], Allowed: app.AuthMethodAllowed(m)})
+	}
+	return opts
+}
+
+// parseAuthMethodPolicy validates the sign-in methods checked in the app form
+// and returns the policy to store (see models.NormalizeAuthMethods).
+// Passkey-only mode disables passwords, so it needs passkey or magic link
+// sign-in to remain allowed.
+func parseAuthMethodPolicy(methods []string, passwordlessOnly bool) (string, error) {
+	policy, err := models.NormalizeAuthMethods(methods)
+	if err != nil {
+		return "", err
+	}
+	if passwordlessOnly {
+		app := models.Application{AllowedAuthMethods: policy}
+		if !app.AuthMethodAllowed(models.AuthMethodPasskey) && !app.AuthMethodAllowed(models.AuthMethodMagicLink) {
+			return "", fmt.Errorf("passkey-only mode requires passkey or magic link sign-in to be allowed")
+		}
+	}
+	return policy, nil
+}
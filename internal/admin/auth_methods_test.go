@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestParseAuthMethodPolicy(t *testing.T) {
+	tests := []struct {
+		name             string
+		methods          []string
+		passwordlessOnly bool
+		want             string
+		wantErr          bool
+	}{
+		{"subset in canonical order", []string{"passkey", "password", "google"}, false, "password,google,passkey", false},
+		{"duplicates collapse", []string{"github", "github"}, false, "github", false},
+		{"every method is unrestricted", models.AuthMethods, false, "", false},
+		{"none checked", nil, false, "", true},
+		{"unknown method", []string{"password", "saml"}, false, "", true},
+		{"passkey-only with magic link", []string{"magic_link"}, true, "magic_link", false},
+		{"passkey-only without passkey or magic link", []string{"password", "google"}, true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAuthMethodPolicy(tt.methods, tt.passwordlessOnly)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAuthMethodPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAuthMethodPolicy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthMethodAllowed(t *testing.T) {
+	unrestricted := &models.Application{}
+	for _, m := range models.AuthMethods {
+		if !unrestricted.AuthMethodAllowed(m) {
+			t.Errorf("empty policy does not allow %q", m)
+		}
+	}
+
+	app := &models.Application{AllowedAuthMethods: "password, passkey"}
+	if !app.AuthMethodAllowed(models.AuthMethodPasskey) {
+		t.Error("policy does not allow a listed method")
+	}
+	if app.AuthMethodAllowed(models.AuthMethodGoogle) || app.AuthMethodAllowed(models.AuthMethodClientCredentials) {
+		t.Error("policy allows an unlisted method")
+	}
+
+	opts := authMethodOptions(app)
+	if len(opts) != len(models.AuthMethods) || !opts[0].Allowed || opts[1].Allowed || opts[0].Label != "Password" {
+		t.Errorf("authMethodOptions() = %+v", opts)
+	}
+}
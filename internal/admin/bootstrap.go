@@ -0,0 +1,182 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// ErrAlreadyBootstrapped is returned by BootstrapAdminApiKey once any admin API
+// key (active or not) exists.
+var ErrAlreadyBootstrapped = errors.New("an admin API key already exists")
+
+// bootstrapLockID serializes concurrent bootstrap requests (pg_advisory_xact_lock).
+const bootstrapLockID = 7_320_411_905
+
+// BootstrapAdminApiKey creates the first admin API key and returns its raw
+// value (shown once). It fails with ErrAlreadyBootstrapped when an admin key
+// was created before, so the bootstrap can succeed only once per installation.
+func (r *Repository) BootstrapAdminApiKey(name, description string) (string, *models.ApiKey, error) {
+	rawKey, keyHash, keyPrefix, keySuffix, err := GenerateApiKey(KeyTypeAdmin)
+	if err != nil {
+		return "", nil, err
+	}
+	apiKey := &models.ApiKey{
+		KeyType:     KeyTypeAdmin,
+		Name:        name,
+		Description: description,
+		KeyHash:     keyHash,
+		KeyPrefix:   keyPrefix,
+		KeySuffix:   keySuffix,
+	}
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", bootstrapLockID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.ApiKey{}).Where("key_type = ?", KeyTypeAdmin).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAlreadyBootstrapped
+		}
+		return tx.Create(apiKey).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return rawKey, apiKey, nil
+}
+
+// Bootstrap methods, reported in the response and the log.
+const (
+	bootstrapMethodToken = "bootstrap_token"
+	bootstrapMethodMTLS  = "mtls"
+)
+
+// bootstrapMethod authorizes a bootstrap request: either a client certificate
+// verified by the mutual-TLS bootstrap listener, or the X-Bootstrap-Token header
+// matching ADMIN_BOOTSTRAP_TOKEN. It writes the error response and returns ""
+// when the request is not authorized.
+func bootstrapMethod(c *gin.Context) string {
+	if c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0 {
+		return bootstrapMethodMTLS
+	}
+
+	required := viper.GetString("ADMIN_BOOTSTRAP_TOKEN")
+	if required == "" {
+		// Token bootstrap disabled: behave like an unknown route
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Admin bootstrap is not enabled"})
+		return ""
+	}
+	token := c.GetHeader("X-Bootstrap-Token")
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(required)) != 1 {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid bootstrap token"})
+		return ""
+	}
+	return bootstrapMethodToken
+}
+
+// @Summary Bootstrap the first admin API key
+// @Description Creates the first admin API key for scripted installs, instead of the interactive cmd/setup.
+// @Description Authorized by the X-Bootstrap-Token header (ADMIN_BOOTSTRAP_TOKEN) or by a client certificate on the
+// @Description mutual-TLS bootstrap listener (ADMIN_BOOTSTRAP_MTLS_ADDR). Works only while no admin API key exists;
+// @Description the raw key is returned once.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param X-Bootstrap-Token header string false "One-time bootstrap token (not needed over mutual TLS)"
+// @Param request body dto.BootstrapApiKeyRequest false "Key name and description"
+// @Success 201 {object} dto.BootstrapApiKeyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse "Bootstrap not enabled"
+// @Failure 409 {object} dto.ErrorResponse "An admin API key already exists"
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/bootstrap/api-key [post]
+func (h *Handler) BootstrapApiKey(c *gin.Context) {
+	method := bootstrapMethod(c)
+	if method == "" {
+		return
+	}
+
+	var req dto.BootstrapApiKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Bootstrap key"
+	}
+
+	rawKey, apiKey, err := h.Repo.BootstrapAdminApiKey(name, strings.TrimSpace(req.Description))
+	if err != nil {
+		if errors.Is(err, ErrAlreadyBootstrapped) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Admin API already bootstrapped; create further keys with an admin API key"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create admin API key"})
+		return
+	}
+
+	log.Printf("Admin API key %s (%s...) bootstrapped via %s from %s", apiKey.ID, apiKey.KeyPrefix, method, c.ClientIP())
+	c.JSON(http.StatusCreated, dto.BootstrapApiKeyResponse{
+		ID:        apiKey.ID.String(),
+		Name:      apiKey.Name,
+		Key:       rawKey,
+		KeyPrefix: apiKey.KeyPrefix,
+		Method:    method,
+		CreatedAt: apiKey.CreatedAt,
+	})
+}
+
+// NewBootstrapMTLSServer returns a server for addr that only serves the
+// bootstrap endpoint and requires a client certificate signed by the CA in
+// clientCAFile.
+func NewBootstrapMTLSServer(addr, certFile, keyFile, clientCAFile string, handler gin.HandlerFunc) (*http.Server, error) {
+	caPEM, err := os.ReadFile(clientCAFile) // #nosec G304 -- path comes from server configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bootstrap client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bootstrap server certificate: %w", err)
+	}
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.POST("/admin/bootstrap/api-key", handler)
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: r,
+		TLSConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func TestBootstrapMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer viper.Set("ADMIN_BOOTSTRAP_TOKEN", "")
+
+	tests := []struct {
+		name       string
+		configured string
+		header     string
+		verified   bool
+		wantMethod string
+		wantStatus int
+	}{
+		{"disabled", "", "anything", false, "", http.StatusNotFound},
+		{"missing token", "s3cret-bootstrap", "", false, "", http.StatusUnauthorized},
+		{"wrong token", "s3cret-bootstrap", "s3cret-bootstrap-x", false, "", http.StatusUnauthorized},
+		{"valid token", "s3cret-bootstrap", "s3cret-bootstrap", false, bootstrapMethodToken, http.StatusOK},
+		{"verified client certificate", "", "", true, bootstrapMethodMTLS, http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			viper.Set("ADMIN_BOOTSTRAP_TOKEN", tc.configured)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/bootstrap/api-key", nil)
+			if tc.header != "" {
+				c.Request.Header.Set("X-Bootstrap-Token", tc.header)
+			}
+			if tc.verified {
+				c.Request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+			}
+
+			if got := bootstrapMethod(c); got != tc.wantMethod {
+				t.Errorf("bootstrapMethod() = %q, want %q", got, tc.wantMethod)
+			}
+			if w.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tc.wantStatus)
+			}
+		})
+	}
+}
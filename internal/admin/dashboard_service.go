@@ -1,6 +1,9 @@
 package admin
 
 import (
+	"context"
+	"encoding/json"
+	"log"
 	"time"
 
 	appRedis "github.com/gjovanovicst/auth_api/internal/redis"
@@ -19,6 +22,9 @@ type DashboardStats struct {
 	ActiveSessions     int64 // active user sessions across all apps
 	TrustedDeviceCount int64 // active (non-expired) trusted devices
 	VerifiedPhoneCount int64 // users with phone_verified = true
+	// GeneratedAt is when the counts were computed; they are served from a
+	// snapshot refreshed in the background (see RefreshStats)
+	GeneratedAt time.Time
 }
 
 // DashboardService provides aggregated data for the admin dashboard.
@@ -34,36 +40,82 @@ func NewDashboardService(db *gorm.DB) *DashboardService {
 	return &DashboardService{db: db}
 }
 
-// GetStats returns aggregate counts for the dashboard stat cards.
+// statsMaxAge is how long a dashboard stats snapshot is served before a
+// dashboard load recomputes it. The dashboard_stats_refresh scheduled job
+// rewrites the snapshot every minute, so while the scheduler runs dashboard
+// loads never run the aggregate queries themselves.
+const statsMaxAge = 2 * time.Minute
+
+// GetStats returns aggregate counts for the dashboard stat cards from the
+// snapshot in Redis, computing and storing a fresh one when there is none.
 func (s *DashboardService) GetStats() (*DashboardStats, error) {
-	stats := &DashboardStats{}
+	if data, err := appRedis.GetDashboardStats(); err == nil && data != nil {
+		var stats DashboardStats
+		if err := json.Unmarshal(data, &stats); err == nil {
+			return &stats, nil
+		}
+	}
+	return s.refreshStats(context.Background())
+}
 
-	// Count total users
-	if err := s.db.Model(&models.User{}).Count(&stats.TotalUsers).Error; err != nil {
+// RefreshStats recomputes and stores the dashboard stats snapshot. Its
+// signature matches scheduler.JobFunc.
+func (s *DashboardService) RefreshStats(ctx context.Context) error {
+	_, err := s.refreshStats(ctx)
+	return err
+}
+
+func (s *DashboardService) refreshStats(ctx context.Context) (*DashboardStats, error) {
+	stats, err := s.computeStats(ctx)
+	if err != nil {
 		return nil, err
 	}
+	data, err := json.Marshal(stats)
+	if err == nil {
+		err = appRedis.SetDashboardStats(data, statsMaxAge)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to store dashboard stats snapshot: %v\n", err)
+	}
+	return stats, nil
+}
 
-	// Count active users
-	if err := s.db.Model(&models.User{}).Where("is_active = ?", true).Count(&stats.ActiveUsers).Error; err != nil {
+// computeStats runs the aggregate queries behind the dashboard stat cards.
+func (s *DashboardService) computeStats(ctx context.Context) (*DashboardStats, error) {
+	stats := &DashboardStats{GeneratedAt: time.Now().UTC()}
+	db := s.db.WithContext(ctx)
+
+	// Count total, active and phone-verified users in a single scan
+	var users struct {
+		Total         int64
+		Active        int64
+		VerifiedPhone int64
+	}
+	if err := db.Model(&models.User{}).
+		Select("COUNT(*) AS total, " +
+			"COUNT(*) FILTER (WHERE is_active) AS active, " +
+			"COUNT(*) FILTER (WHERE phone_verified) AS verified_phone").
+		Scan(&users).Error; err != nil {
 		return nil, err
 	}
-
-	// Count inactive users
-	stats.InactiveUsers = stats.TotalUsers - stats.ActiveUsers
+	stats.TotalUsers = users.Total
+	stats.ActiveUsers = users.Active
+	stats.InactiveUsers = users.Total - users.Active
+	stats.VerifiedPhoneCount = users.VerifiedPhone
 
 	// Count total tenants
-	if err := s.db.Model(&models.Tenant{}).Count(&stats.TotalTenants).Error; err != nil {
+	if err := db.Model(&models.Tenant{}).Count(&stats.TotalTenants).Error; err != nil {
 		return nil, err
 	}
 
 	// Count total applications
-	if err := s.db.Model(&models.Application{}).Count(&stats.TotalApps).Error; err != nil {
+	if err := db.Model(&models.Application{}).Count(&stats.TotalApps).Error; err != nil {
 		return nil, err
 	}
 
 	// Count activity logs in the last 24 hours
 	since := time.Now().Add(-24 * time.Hour)
-	if err := s.db.Model(&models.ActivityLog{}).
+	if err := db.Model(&models.ActivityLog{}).
 		Where("timestamp >= ?", since).
 		Count(&stats.RecentEventsCount).Error; err != nil {
 		return nil, err
@@ -71,7 +123,7 @@ func (s *DashboardService) GetStats() (*DashboardStats, error) {
 
 	// Count active sessions across all apps (from Redis)
 	var appIDs []string
-	if err := s.db.Model(&models.Application{}).Pluck("id", &appIDs).Error; err != nil {
+	if err := db.Model(&models.Application{}).Pluck("id", &appIDs).Error; err != nil {
 		return nil, err
 	}
 	for _, appID := range appIDs {
@@ -83,20 +135,13 @@ func (s *DashboardService) GetStats() (*DashboardStats, error) {
 	}
 
 	// Count active (non-expired) trusted devices
-	if err := s.db.Table("trusted_devices").
+	if err := db.Table("trusted_devices").
 		Where("expires_at > ?", time.Now().UTC()).
 		Count(&stats.TrustedDeviceCount).Error; err != nil {
 		// Non-fatal: table may not exist yet on first startup
 		stats.TrustedDeviceCount = 0
 	}
 
-	// Count users with verified phone numbers
-	if err := s.db.Model(&models.User{}).
-		Where("phone_verified = ?", true).
-		Count(&stats.VerifiedPhoneCount).Error; err != nil {
-		stats.VerifiedPhoneCount = 0
-	}
-
 	return stats, nil
 }
 
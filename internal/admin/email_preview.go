@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/email"
+	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/util"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// previewEmailAsUser renders tmpl with the variables a real email to the user
+// would get and records the access to the user's data in their activity log.
+// Nothing is recorded when the user could not be loaded.
+func previewEmailAsUser(c *gin.Context, svc *email.Service, tmpl *models.EmailTemplate, userID uuid.UUID, vars map[string]string, previewedBy string) (dto.EmailPreviewResponse, error) {
+	appID, subject, htmlBody, textBody, err := svc.PreviewTemplateForUser(tmpl, userID, vars)
+	if appID == uuid.Nil {
+		return dto.EmailPreviewResponse{}, err
+	}
+
+	ip, userAgent := util.GetClientInfo(c)
+	logService.LogEmailPreview(appID, userID, ip, userAgent, map[string]interface{}{
+		"previewed_by": previewedBy,
+	})
+	log.Printf("Email preview: template rendered with the data of user %s of app %s by %s\n", userID, appID, previewedBy)
+	if err != nil {
+		return dto.EmailPreviewResponse{}, err
+	}
+	return dto.EmailPreviewResponse{Subject: subject, BodyHTML: htmlBody, BodyText: textBody}, nil
+}
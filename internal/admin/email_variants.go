@@ -0,0 +1,297 @@
+package admin
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+)
+
+// ListEmailVariants lists the A/B template variants of an application
+// @Summary List email template variants
+// @Description A/B variants of the application's email templates, oldest first by default.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, name, weight (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(asc)
+// @Param filter[email_type_id] query string false "Email type ID (also accepted as email_type_id)"
+// @Param filter[is_active] query bool false "Filter by active state"
+// @Success 200 {object} dto.EmailTemplateVariantListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-variants [get]
+func (h *Handler) ListEmailVariants(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), email.VariantListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	q = q.WithFilter("app_id", appID.String())
+	variants, total, err := h.EmailService.ListVariants(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list variants: " + err.Error()})
+		return
+	}
+	resp := dto.EmailTemplateVariantListResponse{
+		Variants:   make([]dto.EmailTemplateVariantResponse, len(variants)),
+		Total:      total,
+		Page:       q.Page,
+		PageSize:   q.PageSize,
+		TotalPages: q.TotalPages(total),
+		Sort:       q.Sort,
+		Order:      q.Order,
+	}
+	for i := range variants {
+		resp.Variants[i] = toEmailVariantResponse(&variants[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateEmailVariant adds an A/B template variant to an application
+// @Summary Create an email template variant
+// @Description Adds an alternative subject and body for an email type of the application, sent for weight percent of
+// @Description the type's emails. The active variants of a type may weigh at most 100 together; the remaining emails
+// @Description use the regular template ("control"). Lint warnings do not prevent the save.
+// @Tags Admin - Email
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body dto.EmailTemplateVariantRequest true "Variant"
+// @Success 201 {object} dto.EmailTemplateVariantSaveResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-variants [post]
+func (h *Handler) CreateEmailVariant(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	var req dto.EmailTemplateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	emailTypeID, err := uuid.Parse(req.EmailTypeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "A valid email_type_id is required"})
+		return
+	}
+
+	variant := &models.EmailTemplateVariant{AppID: appID, EmailTypeID: emailTypeID, IsActive: true}
+	applyEmailVariantRequest(variant, &req)
+	h.saveEmailVariant(c, variant, http.StatusCreated)
+}
+
+// UpdateEmailVariant changes an A/B template variant of an application
+// @Summary Update an email template variant
+// @Description Replaces the name, subject, bodies, engine, weight and state of a variant. Its email type cannot change.
+// @Tags Admin - Email
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param variant_id path string true "Variant ID"
+// @Param request body dto.EmailTemplateVariantRequest true "Variant"
+// @Success 200 {object} dto.EmailTemplateVariantSaveResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-variants/{variant_id} [put]
+func (h *Handler) UpdateEmailVariant(c *gin.Context) {
+	variant, ok := h.loadEmailVariant(c)
+	if !ok {
+		return
+	}
+	var req dto.EmailTemplateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.EmailTypeID != "" && req.EmailTypeID != variant.EmailTypeID.String() {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "The email type of a variant cannot change"})
+		return
+	}
+	applyEmailVariantRequest(variant, &req)
+	h.saveEmailVariant(c, variant, http.StatusOK)
+}
+
+// DeleteEmailVariant deletes an A/B template variant of an application
+// @Summary Delete an email template variant
+// @Description Deletes the variant and its send statistics.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param variant_id path string true "Variant ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-variants/{variant_id} [delete]
+func (h *Handler) DeleteEmailVariant(c *gin.Context) {
+	variant, ok := h.loadEmailVariant(c)
+	if !ok {
+		return
+	}
+	if err := h.EmailService.DeleteVariant(variant.AppID, variant.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete variant: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email template variant deleted"})
+}
+
+// GetEmailVariantStats reports the send statistics of an email type's variants
+// @Summary Email template variant statistics
+// @Description Emails sent and failed per variant of an email type of the application, with the regular template as
+// @Description "control", so the variants can be compared. share is the variant's part of all sent emails of the type.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param email_type_id query string true "Email type ID"
+// @Success 200 {object} dto.EmailVariantStatsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-variant-stats [get]
+func (h *Handler) GetEmailVariantStats(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	emailTypeID, err := uuid.Parse(c.Query("email_type_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "A valid email_type_id is required"})
+		return
+	}
+	stats, err := h.EmailService.GetVariantStats(appID, emailTypeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load variant statistics: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toEmailVariantStatsResponse(emailTypeID, stats))
+}
+
+// loadEmailVariant loads the variant named by the route, answering 400 or 404
+// when it cannot.
+func (h *Handler) loadEmailVariant(c *gin.Context) (*models.EmailTemplateVariant, bool) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return nil, false
+	}
+	id, err := uuid.Parse(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid variant ID"})
+		return nil, false
+	}
+	variant, err := h.EmailService.GetVariant(appID, id)
+	if errors.Is(err, email.ErrVariantNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Email template variant not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load variant: " + err.Error()})
+		return nil, false
+	}
+	return variant, true
+}
+
+// saveEmailVariant stores a variant and answers with it and its lint warnings.
+func (h *Handler) saveEmailVariant(c *gin.Context, variant *models.EmailTemplateVariant, status int) {
+	warnings, err := h.EmailService.SaveVariant(variant)
+	switch {
+	case errors.Is(err, email.ErrInvalidVariant):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, email.ErrVariantNameTaken):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save variant: " + err.Error()})
+		return
+	}
+	c.JSON(status, dto.EmailTemplateVariantSaveResponse{
+		Variant:  toEmailVariantResponse(variant),
+		Warnings: emailTemplateWarnings(warnings),
+	})
+}
+
+func applyEmailVariantRequest(variant *models.EmailTemplateVariant, req *dto.EmailTemplateVariantRequest) {
+	variant.Name = req.Name
+	variant.Subject = req.Subject
+	variant.BodyHTML = req.BodyHTML
+	variant.BodyText = req.BodyText
+	variant.TemplateEngine = req.TemplateEngine
+	variant.Weight = req.Weight
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+}
+
+func toEmailVariantResponse(v *models.EmailTemplateVariant) dto.EmailTemplateVariantResponse {
+	return dto.EmailTemplateVariantResponse{
+		ID:             v.ID.String(),
+		EmailTypeID:    v.EmailTypeID.String(),
+		Name:           v.Name,
+		Subject:        v.Subject,
+		BodyHTML:       v.BodyHTML,
+		BodyText:       v.BodyText,
+		TemplateEngine: v.TemplateEngine,
+		Weight:         v.Weight,
+		IsActive:       v.IsActive,
+		CreatedAt:      v.CreatedAt,
+		UpdatedAt:      v.UpdatedAt,
+	}
+}
+
+// toEmailVariantStatsResponse adds failure rates and shares of the sent
+// emails, rounded to four decimals.
+func toEmailVariantStatsResponse(emailTypeID uuid.UUID, stats []email.VariantStats) dto.EmailVariantStatsResponse {
+	resp := dto.EmailVariantStatsResponse{EmailTypeID: emailTypeID.String(), Variants: make([]dto.EmailVariantStatsEntry, len(stats))}
+	for _, st := range stats {
+		resp.TotalSent += st.Sent
+	}
+	ratio := func(n, d int64) float64 {
+		if d == 0 {
+			return 0
+		}
+		return math.Round(float64(n)/float64(d)*10000) / 10000
+	}
+	for i, st := range stats {
+		entry := dto.EmailVariantStatsEntry{
+			Name:        st.Name,
+			Weight:      st.Weight,
+			IsActive:    st.IsActive,
+			Sent:        st.Sent,
+			Failed:      st.Failed,
+			FailureRate: ratio(st.Failed, st.Sent+st.Failed),
+			Share:       ratio(st.Sent, resp.TotalSent),
+			LastSentAt:  st.LastSentAt,
+		}
+		if st.VariantID != uuid.Nil {
+			entry.VariantID = st.VariantID.String()
+		}
+		resp.Variants[i] = entry
+	}
+	return resp
+}
@@ -0,0 +1,509 @@
+package admin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/user"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// erasedEmailDomain is the domain of the placeholder address an anonymized
+// user keeps, so that the (app_id, email) unique index still holds.
+const erasedEmailDomain = "erased.invalid"
+
+// erasureCertificateListSpec is the sorting and filtering of the erasure certificate list.
+var erasureCertificateListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"erased_at": "erased_at",
+		"mode":      "mode",
+	},
+	DefaultSort:  "erased_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"app_id":  {Column: "app_id", Kind: pagination.FilterUUID},
+		"user_id": {Column: "user_id", Kind: pagination.FilterUUID},
+		"mode":    {Column: "mode", Kind: pagination.FilterExact},
+	},
+}
+
+// EraseUser carries out a right-to-erasure request for a user and returns the
+// signed certificate recording it.
+//
+// In both modes the user's sessions are revoked and their linked social
+// accounts, passkeys, trusted devices, pending OIDC authorization codes and
+// suppression list entries are deleted, as are the webhook deliveries of the
+// application whose payload names the user's ID or email address; the bodies
+// of captured Admin API requests on the user, or whose bodies name the user's
+// ID or email address, are cleared, and an uploaded
+// profile picture is removed from file storage. Activity logs recorded before the user was
+// identified (failed logins by email) are anonymized.
+//
+// ErasureModeAnonymize keeps the user row, its role assignments and its
+// activity logs (event type and time) for referential integrity and
+// statistics, with every personal field cleared and the account disabled.
+// ErasureModeDelete deletes the user, its role assignments and its activity
+// logs. Archived activity logs on file storage are not rewritten.
+func (r *Repository) EraseUser(userID uuid.UUID, mode, reason, erasedBy string) (*models.ErasureCertificate, error) {
+	if mode != models.ErasureModeAnonymize && mode != models.ErasureModeDelete {
+		return nil, fmt.Errorf("invalid erasure mode %q", mode)
+	}
+	var u models.User
+	if err := r.DB.First(&u, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var counts dto.ErasureCounts
+	// Sessions are revoked first: a failed erasure leaves the user signed
+	// out, which is harmless, while an erased user must never stay signed in
+	if redis.Rdb != nil {
+		if ids, err := redis.GetUserSessionIDs(u.AppID.String(), u.ID.String()); err == nil {
+			counts.Sessions = int64(len(ids))
+		}
+		if err := redis.DeleteAllUserSessions(u.AppID.String(), u.ID.String(), ""); err != nil {
+			log.Printf("Warning: Failed to revoke sessions of user %s before erasure: %v\n", u.ID, err)
+		}
+	}
+
+	cert := &models.ErasureCertificate{
+		ID:          uuid.New(),
+		AppID:       u.AppID,
+		UserID:      u.ID,
+		Mode:        mode,
+		SubjectHash: erasureSubjectHash(u.AppID, u.Email),
+		Reason:      reason,
+		ErasedBy:    erasedBy,
+		// Postgres keeps microseconds; the signature must survive the round trip
+		ErasedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		del := func(n *int64, q *gorm.DB, model interface{}) error {
+			res := q.Delete(model)
+			*n = res.RowsAffected
+			return res.Error
+		}
+		if err := del(&counts.SocialAccounts, tx.Where("user_id = ?", u.ID), &models.SocialAccount{}); err != nil {
+			return err
+		}
+		if err := del(&counts.Passkeys, tx.Where("user_id = ?", u.ID), &models.WebAuthnCredential{}); err != nil {
+			return err
+		}
+		if err := del(&counts.TrustedDevices, tx.Where("user_id = ?", u.ID), &models.TrustedDevice{}); err != nil {
+			return err
+		}
+		if err := del(&counts.OIDCAuthCodes, tx.Where("user_id = ?", u.ID), &models.OIDCAuthCode{}); err != nil {
+			return err
+		}
+		if err := del(&counts.EmailSuppressions, tx.Where("app_id = ? AND email = ?", u.AppID, strings.ToLower(u.Email)), &models.EmailSuppression{}); err != nil {
+			return err
+		}
+
+		// Payloads sent to webhook endpoints (e.g. user.registered, user sync
+		// events) carry the email address and profile
+		if err := del(&counts.WebhookDeliveries, tx.Where("app_id = ? AND (strpos(payload, ?) > 0 OR strpos(lower(payload), ?) > 0)",
+			u.AppID, u.ID.String(), strings.ToLower(u.Email)), &models.WebhookDelivery{}); err != nil {
+			return err
+		}
+
+		audit := adminAuditLogsNaming(tx, &u).
+			Updates(map[string]interface{}{"request_body": "", "response_body": ""})
+		if audit.Error != nil {
+			return audit.Error
+		}
+		counts.AdminAuditLogs = audit.RowsAffected
+
+		// Logs recorded by email before the user was identified
+		anonymized := map[string]interface{}{"ip_address": "", "user_agent": "", "details": gorm.Expr("NULL")}
+		byEmail := tx.Model(&models.ActivityLog{}).
+			Where("(user_id IS NULL OR user_id = ?) AND app_id = ? AND lower(details->>'email') = ?", uuid.Nil, u.AppID, strings.ToLower(u.Email)).
+			Updates(anonymized)
+		if byEmail.Error != nil {
+			return byEmail.Error
+		}
+		counts.ActivityLogs = byEmail.RowsAffected
+
+		if mode == models.ErasureModeDelete {
+			var logs int64
+			if err := del(&logs, tx.Where("user_id = ?", u.ID), &models.ActivityLog{}); err != nil {
+				return err
+			}
+			counts.ActivityLogs += logs
+			if err := del(&counts.UserRoles, tx.Where("user_id = ?", u.ID), &models.UserRole{}); err != nil {
+				return err
+			}
+			if err := del(&counts.Users, tx.Where("id = ?", u.ID), &models.User{}); err != nil {
+				return err
+			}
+		} else {
+			logs := tx.Model(&models.ActivityLog{}).Where("user_id = ?", u.ID).Updates(anonymized)
+			if logs.Error != nil {
+				return logs.Error
+			}
+			counts.ActivityLogs += logs.RowsAffected
+			users := tx.Model(&models.User{}).Where("id = ?", u.ID).Updates(anonymizedUserColumns(u.ID))
+			if users.Error != nil {
+				return users.Error
+			}
+			counts.Users = users.RowsAffected
+		}
+
+		data, err := json.Marshal(counts)
+		if err != nil {
+			return err
+		}
+		cert.Counts = datatypes.JSON(data)
+		if cert.Signature, err = signErasureCertificate(cert); err != nil {
+			return err
+		}
+		return tx.Create(cert).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.DeleteUploadedAvatar(context.Background(), u.ProfilePicture)
+	log.Printf("Erasure: %s user %s of app %s by %s (certificate %s)\n", mode, u.ID, u.AppID, erasedBy, cert.ID)
+	return cert, nil
+}
+
+// adminAuditLogsNaming scopes q to the captured Admin API requests with a body
+// that are on the user, or whose request or response body names the user's ID
+// or email address (e.g. user creation, user lists and searches).
+func adminAuditLogsNaming(q *gorm.DB, u *models.User) *gorm.DB {
+	id, email := u.ID.String(), strings.ToLower(u.Email)
+	return q.Model(&models.AdminAuditLog{}).
+		Where("request_body <> '' OR response_body <> ''").
+		Where("path LIKE ? OR strpos(request_body, ?) > 0 OR strpos(response_body, ?) > 0 OR "+
+			"strpos(lower(request_body), ?) > 0 OR strpos(lower(response_body), ?) > 0",
+			"%"+id+"%", id, id, email, email)
+}
+
+// anonymizedUserColumns clears every personal and credential field of a user
+// and disables the account. The email becomes a unique placeholder.
+func anonymizedUserColumns(userID uuid.UUID) map[string]interface{} {
+	return map[string]interface{}{
+		"email":                  fmt.Sprintf("erased-%s@%s", userID, erasedEmailDomain),
+		"password_hash":          "",
+		"email_verified":         false,
+		"is_active":              false,
+		"name":                   "",
+		"first_name":             "",
+		"last_name":              "",
+		"profile_picture":        "",
+		"locale":                 "",
+		"two_fa_enabled":         false,
+		"two_fa_method":          "",
+		"two_fa_secret":          "",
+		"two_fa_recovery_codes":  gorm.Expr("NULL"),
+		"two_fa_previous_method": "",
+		"two_fa_previous_secret": "",
+		"backup_email":           "",
+		"backup_email_verified":  false,
+		"phone_number":           "",
+		"phone_verified":         false,
+		"lock_reason":            "",
+		"password_history":       datatypes.JSON("[]"),
+		"groups":                 datatypes.JSON("[]"),
+		"notify_security_alerts": false,
+		"notify_product_emails":  false,
+	}
+}
+
+// erasureSubjectHash is the keyed hash of an erased address stored on the
+// certificate instead of the address itself.
+func erasureSubjectHash(appID uuid.UUID, email string) string {
+	mac := hmac.New(sha256.New, []byte(viper.GetString("JWT_SECRET")))
+	mac.Write([]byte("erasure-subject:" + appID.String() + ":" + strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signErasureCertificate returns the HMAC-SHA256 signature, keyed with
+// JWT_SECRET, of every field of the certificate but the signature.
+func signErasureCertificate(cert *models.ErasureCertificate) (string, error) {
+	var counts dto.ErasureCounts
+	if err := json.Unmarshal(cert.Counts, &counts); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(struct {
+		ID          string            `json:"id"`
+		AppID       string            `json:"app_id"`
+		UserID      string            `json:"user_id"`
+		Mode        string            `json:"mode"`
+		SubjectHash string            `json:"subject_hash"`
+		Reason      string            `json:"reason"`
+		ErasedBy    string            `json:"erased_by"`
+		Counts      dto.ErasureCounts `json:"counts"`
+		ErasedAt    string            `json:"erased_at"`
+	}{
+		ID:          cert.ID.String(),
+		AppID:       cert.AppID.String(),
+		UserID:      cert.UserID.String(),
+		Mode:        cert.Mode,
+		SubjectHash: cert.SubjectHash,
+		Reason:      cert.Reason,
+		ErasedBy:    cert.ErasedBy,
+		Counts:      counts,
+		ErasedAt:    cert.ErasedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(viper.GetString("JWT_SECRET")))
+	mac.Write([]byte("erasure-certificate:"))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyErasureCertificate reports whether the certificate's signature
+// matches its content.
+func VerifyErasureCertificate(cert *models.ErasureCertificate) bool {
+	want, err := signErasureCertificate(cert)
+	return err == nil && hmac.Equal([]byte(cert.Signature), []byte(want))
+}
+
+// ListErasureCertificates returns a page of erasure certificates, newest
+// first unless sorted otherwise. A non-empty subjectHash selects the
+// certificates of an erased email address.
+func (r *Repository) ListErasureCertificates(lq pagination.ListQuery, subjectHash string) ([]models.ErasureCertificate, int64, error) {
+	q := lq.ApplyFilters(r.DB.Model(&models.ErasureCertificate{}))
+	if subjectHash != "" {
+		q = q.Where("subject_hash = ?", subjectHash)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var certs []models.ErasureCertificate
+	err := lq.ApplyPage(q).Find(&certs).Error
+	return certs, total, err
+}
+
+// GetErasureCertificate returns an erasure certificate.
+func (r *Repository) GetErasureCertificate(id uuid.UUID) (*models.ErasureCertificate, error) {
+	var cert models.ErasureCertificate
+	if err := r.DB.First(&cert, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+func toErasureCertificateResponse(cert *models.ErasureCertificate) dto.ErasureCertificateResponse {
+	resp := dto.ErasureCertificateResponse{
+		ID:             cert.ID,
+		AppID:          cert.AppID,
+		UserID:         cert.UserID,
+		Mode:           cert.Mode,
+		SubjectHash:    cert.SubjectHash,
+		Reason:         cert.Reason,
+		ErasedBy:       cert.ErasedBy,
+		ErasedAt:       cert.ErasedAt,
+		Signature:      cert.Signature,
+		SignatureValid: VerifyErasureCertificate(cert),
+	}
+	_ = json.Unmarshal(cert.Counts, &resp.Counts)
+	return resp
+}
+
+// EraseUser carries out a right-to-erasure (GDPR) request
+// @Summary Erase a user's personal data (Admin)
+// @Description Anonymizes (mode=anonymize) or deletes (mode=delete) a user's personal data across users, social accounts,
+// @Description passkeys, trusted devices, activity logs, email suppressions, webhook deliveries whose payload names the
+// @Description user and captured Admin API request bodies, revokes
+// @Description their sessions and returns a signed erasure certificate. With anonymize the user row, role assignments and
+// @Description activity log events are kept without personal data. The request must set confirm=true; it cannot be undone.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param   id       path  string                 true  "User ID"
+// @Param   request  body  dto.EraseUserRequest   true  "Erasure mode and reason"
+// @Success 200 {object} dto.ErasureCertificateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/users/{id}/erase [post]
+func (h *Handler) EraseUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	var req dto.EraseUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !req.Confirm {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Erasure must be confirmed"})
+		return
+	}
+
+	erasedBy := "admin_api"
+	if v, ok := c.Get(web.ApiKeyIDKey); ok {
+		if id, ok := v.(uuid.UUID); ok {
+			erasedBy = "admin_api_key:" + id.String()
+		}
+	}
+	cert, err := h.Repo.EraseUser(userID, req.Mode, req.Reason, erasedBy)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "User not found"})
+			return
+		}
+		log.Printf("Erasure of user %s failed: %v\n", userID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to erase user"})
+		return
+	}
+	c.JSON(http.StatusOK, toErasureCertificateResponse(cert))
+}
+
+// ListErasureCertificates lists erasure certificates
+// @Summary List erasure certificates (Admin)
+// @Description Returns the certificates of right-to-erasure requests, newest first, with their signature checked.
+// @Description Filter by email to find the erasure of an address: it is matched by its keyed hash and requires app_id.
+// @Tags Users
+// @Produce json
+// @Param   page               query  int     false  "Page number" default(1)
+// @Param   page_size          query  int     false  "Page size (max 100)" default(20)
+// @Param   sort               query  string  false  "Sort field: erased_at or mode (prefix with - for descending)" default(erased_at)
+// @Param   order              query  string  false  "Sort order: asc or desc" default(desc)
+// @Param   filter[app_id]     query  string  false  "Application ID (also accepted as app_id)"
+// @Param   filter[user_id]    query  string  false  "Erased user ID (also accepted as user_id)"
+// @Param   filter[mode]       query  string  false  "Erasure mode: delete or anonymize"
+// @Param   email              query  string  false  "Erased email address (requires app_id)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/erasure-certificates [get]
+func (h *Handler) ListErasureCertificates(c *gin.Context) {
+	lq, err := pagination.ParseListQuery(c.Request.URL.Query(), erasureCertificateListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	var subjectHash string
+	if email := c.Query("email"); email != "" {
+		appID := lq.Filter("app_id")
+		if appID == "" {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Filtering by email requires app_id"})
+			return
+		}
+		subjectHash = erasureSubjectHash(uuid.MustParse(appID), email)
+	}
+
+	certs, total, err := h.Repo.ListErasureCertificates(lq, subjectHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list erasure certificates"})
+		return
+	}
+	response := make([]dto.ErasureCertificateResponse, 0, len(certs))
+	for i := range certs {
+		response = append(response, toErasureCertificateResponse(&certs[i]))
+	}
+
+	c.JSON(http.StatusOK, lq.Response(response, total))
+}
+
+// GetErasureCertificate returns an erasure certificate
+// @Summary Get an erasure certificate (Admin)
+// @Description Returns a right-to-erasure certificate with its signature checked.
+// @Tags Users
+// @Produce json
+// @Param   id  path  string  true  "Certificate ID"
+// @Success 200 {object} dto.ErasureCertificateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/erasure-certificates/{id} [get]
+func (h *Handler) GetErasureCertificate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid certificate ID"})
+		return
+	}
+	cert, err := h.Repo.GetErasureCertificate(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Erasure certificate not found"})
+		return
+	}
+	c.JSON(http.StatusOK, toErasureCertificateResponse(cert))
+}
+
+// UserEraseConfirm returns the erasure confirmation form for HTMX.
+// GET /gui/users/:id/erase
+func (h *GUIHandler) UserEraseConfirm(c *gin.Context) {
+	detail, err := h.Repo.GetUserDetailByID(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusNotFound,
+			`<div class="modal-body"><div class="alert alert-danger">User not found.</div></div>`)
+		return
+	}
+	c.HTML(http.StatusOK, "user_erase_confirm", gin.H{
+		"User": detail,
+		"Mode": models.ErasureModeAnonymize,
+	})
+}
+
+// UserErase erases a user's personal data once the admin typed their email
+// address, and shows the erasure certificate.
+// POST /gui/users/:id/erase
+func (h *GUIHandler) UserErase(c *gin.Context) {
+	detail, err := h.Repo.GetUserDetailByID(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusNotFound,
+			`<div class="modal-body"><div class="alert alert-danger">User not found.</div></div>`)
+		return
+	}
+	mode := c.PostForm("mode")
+	reason := strings.TrimSpace(c.PostForm("reason"))
+	formData := gin.H{"User": detail, "Mode": mode, "Reason": reason}
+
+	if mode != models.ErasureModeAnonymize && mode != models.ErasureModeDelete {
+		formData["Error"] = "Choose whether to anonymize or delete the user."
+		c.HTML(http.StatusOK, "user_erase_confirm", formData)
+		return
+	}
+	if len(reason) > 255 {
+		formData["Error"] = "The reason must be at most 255 characters."
+		c.HTML(http.StatusOK, "user_erase_confirm", formData)
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(c.PostForm("confirm_email")), detail.Email) {
+		formData["Error"] = "The email address does not match the user's."
+		c.HTML(http.StatusOK, "user_erase_confirm", formData)
+		return
+	}
+
+	cert, err := h.Repo.EraseUser(detail.ID, mode, reason, getAdminUsername(c))
+	if err != nil {
+		log.Printf("Erasure of user %s failed: %v\n", detail.ID, err)
+		formData["Error"] = "Failed to erase the user."
+		c.HTML(http.StatusOK, "user_erase_confirm", formData)
+		return
+	}
+
+	c.Header("HX-Trigger", "userErased")
+	c.HTML(http.StatusOK, "user_erase_result", toErasureCertificateResponse(cert))
+}
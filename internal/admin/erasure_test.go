@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func newTestErasureCertificate(t *testing.T) *models.ErasureCertificate {
+	t.Helper()
+	appID := uuid.New()
+	cert := &models.ErasureCertificate{
+		ID:          uuid.New(),
+		AppID:       appID,
+		UserID:      uuid.New(),
+		Mode:        models.ErasureModeAnonymize,
+		SubjectHash: erasureSubjectHash(appID, "user@example.com"),
+		Reason:      "ticket 42",
+		ErasedBy:    "admin",
+		Counts:      datatypes.JSON(`{"users":1,"activity_logs":12,"sessions":2}`),
+		ErasedAt:    time.Date(2026, 10, 16, 12, 0, 0, 123456000, time.UTC),
+	}
+	sig, err := signErasureCertificate(cert)
+	if err != nil {
+		t.Fatalf("signErasureCertificate: %v", err)
+	}
+	cert.Signature = sig
+	return cert
+}
+
+func TestErasureCertificateSignature(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+
+	cert := newTestErasureCertificate(t)
+	if !VerifyErasureCertificate(cert) {
+		t.Fatal("freshly signed certificate does not verify")
+	}
+
+	// Postgres returns the counts reformatted and the time in local time
+	reloaded := *cert
+	reloaded.Counts = datatypes.JSON(`{"sessions": 2, "users": 1, "activity_logs": 12}`)
+	reloaded.ErasedAt = cert.ErasedAt.In(time.FixedZone("CEST", 2*3600))
+	if !VerifyErasureCertificate(&reloaded) {
+		t.Error("certificate does not verify after a database round trip")
+	}
+
+	tampered := *cert
+	tampered.Mode = models.ErasureModeDelete
+	if VerifyErasureCertificate(&tampered) {
+		t.Error("certificate with a changed mode verifies")
+	}
+	tampered = *cert
+	tampered.Counts = datatypes.JSON(`{"users":1,"activity_logs":0,"sessions":2}`)
+	if VerifyErasureCertificate(&tampered) {
+		t.Error("certificate with changed counts verifies")
+	}
+
+	viper.Set("JWT_SECRET", "rotated")
+	if VerifyErasureCertificate(cert) {
+		t.Error("certificate verifies with a different key")
+	}
+}
+
+func TestErasureSubjectHash(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+
+	appID := uuid.New()
+	hash := erasureSubjectHash(appID, "User@Example.com")
+	if len(hash) != 64 {
+		t.Errorf("hash length = %d, want 64", len(hash))
+	}
+	if erasureSubjectHash(appID, " user@example.com ") != hash {
+		t.Error("hash depends on case or surrounding spaces")
+	}
+	if erasureSubjectHash(uuid.New(), "user@example.com") == hash {
+		t.Error("hash does not depend on the application")
+	}
+	if strings.Contains(hash, "example") {
+		t.Error("hash contains the address")
+	}
+}
+
+func TestAnonymizedUserColumns(t *testing.T) {
+	id := uuid.New()
+	cols := anonymizedUserColumns(id)
+	if cols["email"] != "erased-"+id.String()+"@erased.invalid" {
+		t.Errorf("email = %v", cols["email"])
+	}
+	if cols["is_active"] != false || cols["password_hash"] != "" || cols["phone_number"] != "" {
+		t.Errorf("account is not disabled and cleared: %v", cols)
+	}
+}
+
+func TestAdminAuditLogsNaming(t *testing.T) {
+	u := &models.User{ID: uuid.New(), Email: "Jane.Doe@Example.com"}
+	sql := dryRunDB(t).ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return adminAuditLogsNaming(tx, u).Updates(map[string]interface{}{"request_body": "", "response_body": ""})
+	})
+	// A user created or found by email is captured under a path without its ID
+	for _, want := range []string{
+		"path LIKE '%" + u.ID.String() + "%'",
+		"strpos(request_body, '" + u.ID.String() + "') > 0",
+		"strpos(lower(request_body), 'jane.doe@example.com') > 0",
+		"strpos(lower(response_body), 'jane.doe@example.com') > 0",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("audit scrub misses %s: %s", want, sql)
+		}
+	}
+	if !strings.Contains(sql, `"request_body"=''`) || !strings.Contains(sql, `"response_body"=''`) {
+		t.Errorf("audit scrub does not clear the bodies: %s", sql)
+	}
+}
+
+func TestRenderUserErase(t *testing.T) {
+	c, w := newFragmentContext(t)
+	c.HTML(http.StatusOK, "user_erase_confirm", gin.H{
+		"User":  &UserDetail{ID: uuid.New(), Email: "user@example.com", AppName: "Shop"},
+		"Mode":  models.ErasureModeDelete,
+		"Error": "The email address does not match the user's.",
+	})
+	body := w.Body.String()
+	for _, want := range []string{"user@example.com", "Shop", "does not match", `value="delete" checked`, `name="confirm_email"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("confirmation is missing %q: %s", want, body)
+		}
+	}
+
+	c, w = newFragmentContext(t)
+	c.HTML(http.StatusOK, "user_erase_result", dto.ErasureCertificateResponse{
+		ID:        uuid.New(),
+		Mode:      models.ErasureModeAnonymize,
+		Counts:    dto.ErasureCounts{Users: 1, ActivityLogs: 7},
+		ErasedAt:  time.Now(),
+		ErasedBy:  "admin",
+		Signature: "abc123",
+	})
+	body = w.Body.String()
+	for _, want := range []string{"anonymized", "7 activity logs", "abc123"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("result is missing %q: %s", want, body)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+	"github.com/google/uuid"
+)
+
+// errPreconditionFailed is returned when a conditional write's If-Match or
+// If-None-Match header does not match the current state of the resource.
+var errPreconditionFailed = errors.New("precondition failed: the resource was modified or does not match If-Match/If-None-Match")
+
+// resourceETag derives a strong ETag from a resource's ID and last update time.
+// The timestamp is truncated to microseconds because that is what Postgres
+// stores, so the tag computed right after a write matches the one computed
+// from a later read.
+func resourceETag(id uuid.UUID, updatedAt time.Time) string {
+	sum := sha256.Sum256([]byte(id.String() + "|" + updatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// preconditions holds the conditional headers of a write request.
+type preconditions struct {
+	ifMatch     string
+	ifNoneMatch string
+}
+
+// readPreconditions reads If-Match and If-None-Match from the request.
+func readPreconditions(c *gin.Context) preconditions {
+	return preconditions{
+		ifMatch:     strings.TrimSpace(c.GetHeader("If-Match")),
+		ifNoneMatch: strings.TrimSpace(c.GetHeader("If-None-Match")),
+	}
+}
+
+// check validates the headers against the current ETag of the resource
+// (empty when it does not exist yet):
+//   - If-Match: * requires the resource to exist; a list of tags requires one to match.
+//   - If-None-Match: * requires the resource not to exist (create only); a list
+//     of tags requires none to match.
+func (p preconditions) check(currentETag string) error {
+	if p.ifMatch != "" {
+		if currentETag == "" {
+			return errPreconditionFailed
+		}
+		if p.ifMatch != "*" && !etagListContains(p.ifMatch, currentETag) {
+			return errPreconditionFailed
+		}
+	}
+	if p.ifNoneMatch != "" && currentETag != "" {
+		if p.ifNoneMatch == "*" || etagListContains(p.ifNoneMatch, currentETag) {
+			return errPreconditionFailed
+		}
+	}
+	return nil
+}
+
+// etagListContains reports whether a comma-separated If-Match/If-None-Match
+// header value contains etag. Weak tags (W/"...") compare by their opaque value.
+func etagListContains(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeETag sets the ETag header of a read. It answers 304 Not Modified and
+// returns true when the request's If-None-Match already holds that tag, in
+// which case the caller must not write a body.
+func writeETag(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && (inm == "*" || etagListContains(inm, etag)) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// readUnmodifiedSince turns the request's If-Unmodified-Since header into an
+// optimistic-locking guard (the zero guard when the header is absent).
+func readUnmodifiedSince(c *gin.Context) (optlock.Guard, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Unmodified-Since"))
+	if header == "" {
+		return optlock.Guard{}, nil
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return optlock.Guard{}, errors.New("invalid If-Unmodified-Since header: expected an HTTP date")
+	}
+	return optlock.Guard{UnmodifiedSince: &since}, nil
+}
+
+// abortEditConflict writes the 409 response for a conditional save that lost
+// to a concurrent edit, with the current state of the resource.
+func abortEditConflict(c *gin.Context, updatedAt time.Time, current interface{}) {
+	c.Header("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	c.JSON(http.StatusConflict, dto.EditConflictResponse{
+		Error:            optlock.ErrConflict.Error(),
+		CurrentUpdatedAt: updatedAt,
+		Current:          current,
+	})
+}
+
+// abortPreconditionFailed writes the 412 response for a failed precondition,
+// including the resource's current ETag when it exists so the client can re-read.
+func abortPreconditionFailed(c *gin.Context, currentETag string) {
+	if currentETag != "" {
+		c.Header("ETag", currentETag)
+	}
+	c.JSON(http.StatusPreconditionFailed, dto.ErrorResponse{Error: errPreconditionFailed.Error()})
+}
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestResourceETag(t *testing.T) {
+	id := uuid.New()
+	at := time.Date(2026, 10, 16, 12, 0, 0, 123456789, time.UTC)
+
+	etag := resourceETag(id, at)
+	if etag[0] != '"' || etag[len(etag)-1] != '"' {
+		t.Fatalf("etag %s is not a quoted strong tag", etag)
+	}
+	// Postgres keeps microseconds, so sub-microsecond differences must not change the tag
+	if got := resourceETag(id, at.Truncate(time.Microsecond)); got != etag {
+		t.Errorf("truncated timestamp etag = %s, want %s", got, etag)
+	}
+	if got := resourceETag(id, at.In(time.FixedZone("CEST", 2*3600))); got != etag {
+		t.Errorf("etag depends on the time zone: %s vs %s", got, etag)
+	}
+	if resourceETag(id, at.Add(time.Microsecond)) == etag {
+		t.Error("etag did not change with updated_at")
+	}
+	if resourceETag(uuid.New(), at) == etag {
+		t.Error("etag did not change with the ID")
+	}
+}
+
+func TestPreconditionsCheck(t *testing.T) {
+	const etag = `"abc"`
+	tests := []struct {
+		name    string
+		pre     preconditions
+		current string
+		wantErr bool
+	}{
+		{"no headers, exists", preconditions{}, etag, false},
+		{"no headers, missing", preconditions{}, "", false},
+		{"if-match equal", preconditions{ifMatch: etag}, etag, false},
+		{"if-match in list", preconditions{ifMatch: `"x", "abc"`}, etag, false},
+		{"if-match weak", preconditions{ifMatch: `W/"abc"`}, etag, false},
+		{"if-match stale", preconditions{ifMatch: `"old"`}, etag, true},
+		{"if-match missing resource", preconditions{ifMatch: etag}, "", true},
+		{"if-match star, exists", preconditions{ifMatch: "*"}, etag, false},
+		{"if-match star, missing", preconditions{ifMatch: "*"}, "", true},
+		{"if-none-match star, missing", preconditions{ifNoneMatch: "*"}, "", false},
+		{"if-none-match star, exists", preconditions{ifNoneMatch: "*"}, etag, true},
+		{"if-none-match other tag", preconditions{ifNoneMatch: `"old"`}, etag, false},
+		{"if-none-match same tag", preconditions{ifNoneMatch: etag}, etag, true},
+	}
+	for _, tc := range tests {
+		err := tc.pre.check(tc.current)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestWriteETagNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const etag = `"abc"`
+	router := gin.New()
+	router.GET("/r", func(c *gin.Context) {
+		if writeETag(c, etag) {
+			return
+		}
+		c.String(http.StatusOK, "body")
+	})
+
+	for _, tc := range []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{"", http.StatusOK},
+		{`"old"`, http.StatusOK},
+		{etag, http.StatusNotModified},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/r", nil)
+		if tc.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("If-None-Match %q: status = %d, want %d", tc.ifNoneMatch, w.Code, tc.want)
+		}
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %q: ETag header = %q", tc.ifNoneMatch, w.Header().Get("ETag"))
+		}
+	}
+}
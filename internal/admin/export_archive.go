@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/storage"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobTypeUserExport is the background job type for user exports written to
+// the file storage backend.
+const JobTypeUserExport = "user_export"
+
+// exportArchivePrefix is the storage key prefix of export archives.
+const exportArchivePrefix = "exports/"
+
+// RunUserExportJob exports users for a user_export job (payload
+// dto.UserExportRequest), stores the file and returns its
+// dto.ExportArchiveResult. Its signature matches jobqueue.HandlerFunc.
+func (r *Repository) RunUserExportJob(ctx context.Context, task *jobqueue.Task) (interface{}, error) {
+	var req dto.UserExportRequest
+	if err := task.Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	if req.Format != "json" {
+		req.Format = "csv"
+	}
+
+	items, truncated, err := r.ExportUsers(req.AppID, req.Search)
+	if err != nil {
+		return nil, err
+	}
+	task.SetProgress(50, fmt.Sprintf("%d users exported, storing the file", len(items)))
+
+	var buf bytes.Buffer
+	writeUserExport(&buf, req.Format, items, truncated)
+	key := fmt.Sprintf("%susers/%s.%s", exportArchivePrefix, task.Job.ID, req.Format)
+	backend, err := storage.Current()
+	if err != nil {
+		return nil, fmt.Errorf("file storage is unavailable: %w", err)
+	}
+	size := int64(buf.Len())
+	if err := backend.Put(ctx, key, &buf, size, exportContentTypes[req.Format]); err != nil {
+		return nil, fmt.Errorf("failed to store export: %w", err)
+	}
+
+	task.SetSummary(fmt.Sprintf("%d users exported to %s", len(items), key))
+	return dto.ExportArchiveResult{
+		Key:         key,
+		Format:      req.Format,
+		Count:       len(items),
+		Truncated:   truncated,
+		Size:        size,
+		DownloadURL: "/admin/exports/" + task.Job.ID.String(),
+	}, nil
+}
+
+// DownloadExport serves the file written by a succeeded export job.
+//
+// @Summary Download an export archive (Admin)
+// @Description Download the file written to the file storage backend by a background export job (e.g. GET /admin/users/export?async=true).
+// @Tags Users
+// @Security AdminApiKey
+// @Produce octet-stream
+// @Param job_id path string true "Export job ID"
+// @Success 200 {file} binary
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 502 {object} dto.ErrorResponse
+// @Router /admin/exports/{job_id} [get]
+func (h *Handler) DownloadExport(c *gin.Context) {
+	if h.JobQueue == nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Background jobs are disabled on this server"})
+		return
+	}
+	id, err := uuid.Parse(c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid job ID"})
+		return
+	}
+	job, err := h.JobQueue.Get(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Background job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load background job"})
+		return
+	}
+	if job.Type != JobTypeUserExport {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Background job is not an export"})
+		return
+	}
+	if job.Status != jobqueue.StatusSucceeded {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Export is not finished (status: " + job.Status + ")"})
+		return
+	}
+	var result dto.ExportArchiveResult
+	if err := json.Unmarshal([]byte(job.Result), &result); err != nil || !strings.HasPrefix(result.Key, exportArchivePrefix) {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Export job has no archive"})
+		return
+	}
+
+	backend, err := storage.Current()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "File storage is unavailable"})
+		return
+	}
+	obj, err := backend.Get(c.Request.Context(), result.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Export file no longer exists"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, dto.ErrorResponse{Error: "Failed to read export file"})
+		return
+	}
+	defer obj.Body.Close()
+
+	c.DataFromReader(http.StatusOK, obj.Size, exportContentTypes[result.Format], obj.Body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, path.Base(result.Key)),
+	})
+}
@@ -0,0 +1,319 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ============================================================
+// External IDs — idempotent upserts for declarative clients
+// ============================================================
+//
+// Tenants, applications, OAuth configs and email templates can carry a
+// caller-assigned external_id (e.g. a Terraform resource ID). PUT by external
+// ID creates the resource the first time and replaces its managed fields on
+// every later call. The row is locked for the duration of the upsert, and the
+// caller's check runs under that lock so If-Match/If-None-Match preconditions
+// cannot race with a concurrent write.
+
+var (
+	// ErrExternalIDConflict is returned when the natural key of an upsert
+	// (app + provider, app + email type) already belongs to a resource managed
+	// under a different external ID.
+	ErrExternalIDConflict = errors.New("another resource with the same key is already managed under a different external_id")
+
+	// ErrImmutableField is returned when an upsert tries to move an existing
+	// resource to a different parent or key; such resources must be recreated.
+	ErrImmutableField = errors.New("field cannot be changed on an existing resource; delete and recreate it instead")
+
+	// ErrClientSecretRequired is returned when an OAuth config is created by
+	// external ID without a client secret.
+	ErrClientSecretRequired = errors.New("client_secret is required when creating an OAuth config")
+)
+
+// GetTenantByExternalID returns the tenant with the given external ID.
+func (r *Repository) GetTenantByExternalID(externalID string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := r.DB.First(&tenant, "external_id = ?", externalID).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// UpsertTenantByExternalID creates the tenant with the given external ID from
+// in, or replaces the name, billing customer and app quota of the existing one.
+// check receives the current tenant (nil when it does not exist yet) before
+// anything is written; a non-nil error aborts the upsert and is returned as is.
+func (r *Repository) UpsertTenantByExternalID(externalID string, in *models.Tenant, check func(current *models.Tenant) error) (*models.Tenant, bool, error) {
+	created := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Tenant
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "external_id = ?", externalID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := check(nil); err != nil {
+				return err
+			}
+			in.ExternalID = &externalID
+			created = true
+			return tx.Create(in).Error
+		}
+		if err != nil {
+			return err
+		}
+		if err := check(&current); err != nil {
+			return err
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"name":                in.Name,
+			"billing_customer_id": in.BillingCustomerID,
+			"max_apps":            in.MaxApps,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	tenant, err := r.GetTenantByExternalID(externalID)
+	return tenant, created, err
+}
+
+// GetAppByExternalID returns the application with the given external ID,
+// including its OAuth configs.
+func (r *Repository) GetAppByExternalID(externalID string) (*models.Application, error) {
+	var app models.Application
+	if err := r.DB.Preload("OAuthProviderConfigs").First(&app, "external_id = ?", externalID).Error; err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// UpsertAppByExternalID creates the application with the given external ID
+// from in, or replaces the fields settable through POST /admin/apps on the
+// existing one. The tenant of an existing application cannot be changed.
+// check behaves as in UpsertTenantByExternalID.
+func (r *Repository) UpsertAppByExternalID(externalID string, in *models.Application, check func(current *models.Application) error) (*models.Application, bool, error) {
+	created := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Application
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "external_id = ?", externalID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := check(nil); err != nil {
+				return err
+			}
+			in.ExternalID = &externalID
+			created = true
+			return tx.Create(in).Error
+		}
+		if err != nil {
+			return err
+		}
+		if err := check(&current); err != nil {
+			return err
+		}
+		if current.TenantID != in.TenantID {
+			return fmt.Errorf("tenant_id: %w", ErrImmutableField)
+		}
+		callbackMode := in.SocialCallbackMode
+		if callbackMode == "" {
+			callbackMode = "query"
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"name":                  in.Name,
+			"description":           in.Description,
+			"frontend_url":          in.FrontendURL,
+			"magic_link_enabled":    in.MagicLinkEnabled,
+			"passwordless_only":     in.PasswordlessOnly,
+			"reset_password_path":   in.ResetPasswordPath,
+			"magic_link_path":       in.MagicLinkPath,
+			"verify_email_path":     in.VerifyEmailPath,
+			"allowed_redirect_urls": in.AllowedRedirectURLs,
+			"blocked_email_domains": in.BlockedEmailDomains,
+			"social_callback_mode":  callbackMode,
+			// Email verification by code
+			"email_verification_code": in.EmailVerificationCode,
+			// Registration approval
+			"registration_approval_required": in.RegistrationApprovalRequired,
+			// Concurrent session limit
+			"max_sessions_per_user": in.MaxSessionsPerUser,
+			"session_limit_policy":  in.SessionLimitPolicy,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	app, err := r.GetAppByExternalID(externalID)
+	return app, created, err
+}
+
+// GetOAuthConfigByExternalID returns the OAuth config with the given external ID.
+func (r *Repository) GetOAuthConfigByExternalID(externalID string) (*models.OAuthProviderConfig, error) {
+	var config models.OAuthProviderConfig
+	if err := r.DB.First(&config, "external_id = ?", externalID).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpsertOAuthConfigByExternalID creates the OAuth config with the given
+// external ID from in, or replaces the client ID, redirect URL, enabled flag,
+// claim mappings and provisioning controls of the existing one. An empty client secret keeps the stored secret. A config
+// that already exists for the same app and provider without an external ID is
+// adopted; one with a different external ID is an ErrExternalIDConflict.
+// check behaves as in UpsertTenantByExternalID.
+func (r *Repository) UpsertOAuthConfigByExternalID(externalID string, in *models.OAuthProviderConfig, check func(current *models.OAuthProviderConfig) error) (*models.OAuthProviderConfig, bool, error) {
+	created := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.OAuthProviderConfig
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "external_id = ?", externalID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Adopt an unmanaged config for the same app and provider
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&current, "app_id = ? AND provider = ?", in.AppID, in.Provider).Error
+			if err == nil && current.ExternalID != nil {
+				return ErrExternalIDConflict
+			}
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := check(nil); err != nil {
+				return err
+			}
+			if in.ClientSecret == "" {
+				return ErrClientSecretRequired
+			}
+			in.ExternalID = &externalID
+			created = true
+			return tx.Create(in).Error
+		}
+		if err != nil {
+			return err
+		}
+		if current.ExternalID != nil {
+			// Only a config found by its external ID is its current self; an
+			// adopted one had no ETag the caller could have matched against.
+			if err := check(&current); err != nil {
+				return err
+			}
+		} else if err := check(nil); err != nil {
+			return err
+		}
+		if current.AppID != in.AppID {
+			return fmt.Errorf("app_id: %w", ErrImmutableField)
+		}
+		if current.Provider != in.Provider {
+			return fmt.Errorf("provider: %w", ErrImmutableField)
+		}
+		updates := map[string]interface{}{
+			"external_id":  externalID,
+			"client_id":    in.ClientID,
+			"redirect_url": in.RedirectURL,
+			"is_enabled":   in.IsEnabled,
+			// Replaced as a whole; nil removes the mappings
+			"claim_mappings": in.ClaimMappings,
+			// Just-in-time provisioning
+			"provisioning_mode":     in.ProvisioningMode,
+			"allowed_email_domains": in.AllowedEmailDomains,
+			// Generic OpenID Connect providers
+			"issuer_url": in.IssuerURL,
+			"scopes":     in.Scopes,
+		}
+		if in.ClientSecret != "" {
+			updates["client_secret"] = in.ClientSecret
+		}
+		return tx.Model(&current).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	config, err := r.GetOAuthConfigByExternalID(externalID)
+	return config, created, err
+}
+
+// GetEmailTemplateByExternalID returns the email template with the given
+// external ID, including its email type.
+func (r *Repository) GetEmailTemplateByExternalID(externalID string) (*models.EmailTemplate, error) {
+	var tmpl models.EmailTemplate
+	if err := r.DB.Preload("EmailType").First(&tmpl, "external_id = ?", externalID).Error; err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// UpsertEmailTemplateByExternalID creates the email template with the given
+// external ID from in (a global default when in.AppID is nil), or replaces the
+// content fields of the existing one. A template that already exists for the
+// same app and email type without an external ID is adopted; one with a
+// different external ID is an ErrExternalIDConflict. check behaves as in
+// UpsertTenantByExternalID.
+func (r *Repository) UpsertEmailTemplateByExternalID(externalID string, in *models.EmailTemplate, check func(current *models.EmailTemplate) error) (*models.EmailTemplate, bool, error) {
+	created := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.EmailTemplate
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "external_id = ?", externalID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Adopt an unmanaged template for the same app (or global scope) and email type
+			query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("email_type_id = ?", in.EmailTypeID)
+			if in.AppID == nil {
+				query = query.Where("app_id IS NULL")
+			} else {
+				query = query.Where("app_id = ?", *in.AppID)
+			}
+			err = query.First(&current).Error
+			if err == nil && current.ExternalID != nil {
+				return ErrExternalIDConflict
+			}
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := check(nil); err != nil {
+				return err
+			}
+			in.ExternalID = &externalID
+			created = true
+			return tx.Create(in).Error
+		}
+		if err != nil {
+			return err
+		}
+		if current.ExternalID != nil {
+			if err := check(&current); err != nil {
+				return err
+			}
+		} else if err := check(nil); err != nil {
+			return err
+		}
+		if !sameAppID(current.AppID, in.AppID) {
+			return fmt.Errorf("app_id: %w", ErrImmutableField)
+		}
+		if current.EmailTypeID != in.EmailTypeID {
+			return fmt.Errorf("email_type_id: %w", ErrImmutableField)
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"external_id":      externalID,
+			"name":             in.Name,
+			"subject":          in.Subject,
+			"body_html":        in.BodyHTML,
+			"body_text":        in.BodyText,
+			"template_engine":  in.TemplateEngine,
+			"from_email":       in.FromEmail,
+			"from_name":        in.FromName,
+			"server_config_id": in.ServerConfigID,
+			"is_active":        in.IsActive,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	tmpl, err := r.GetEmailTemplateByExternalID(externalID)
+	return tmpl, created, err
+}
+
+// sameAppID reports whether two optional application IDs are equal (nil = global).
+func sameAppID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+	"github.com/google/uuid"
+)
+
+// ============================================================
+// Optimistic locking for GUI edit forms
+// ============================================================
+//
+// Edit forms carry the record's version (its updated_at) in a hidden "version"
+// field. Saving a form whose version is stale fails with optlock.ErrConflict;
+// the handler then answers 409 with a warning rendered into the form's
+// conflict slot, listing the fields where the stored record differs from the
+// submitted one. The warning also refreshes the hidden version, so saving
+// again deliberately overwrites the other admin's changes.
+
+// formGuard reads the version field of an edit form. Forms without one (e.g.
+// opened before an upgrade) update unconditionally; a malformed version is
+// rejected with a 400 alert and ok = false.
+func formGuard(c *gin.Context) (optlock.Guard, bool) {
+	version, err := optlock.ParseVersion(c.PostForm("version"))
+	if err != nil {
+		c.String(http.StatusBadRequest,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Invalid form version. Please reload the form.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return optlock.Guard{}, false
+	}
+	return optlock.Guard{Version: version}, true
+}
+
+// editField is one field of an edit form with its stored and submitted values.
+type editField struct {
+	Label     string
+	Stored    interface{}
+	Submitted interface{}
+}
+
+// differingFields returns the labels of the fields whose stored value differs
+// from the submitted one.
+func differingFields(fields []editField) []string {
+	var labels []string
+	for _, f := range fields {
+		if fmt.Sprint(f.Stored) != fmt.Sprint(f.Submitted) {
+			labels = append(labels, f.Label)
+		}
+	}
+	return labels
+}
+
+// editConflict describes a rejected edit for the edit_conflict partial.
+type editConflict struct {
+	What         string    // Record kind shown to the admin, e.g. "application"
+	Prefix       string    // Form element ID prefix: the slot is <prefix>-form-conflict, the version input <prefix>-form-version
+	ReloadURL    string    // Edit form URL
+	ReloadTarget string    // Selector the reloaded form is swapped into
+	UpdatedAt    time.Time // Current version of the record
+	Fields       []string  // Labels of the fields that differ from the submission
+}
+
+// renderEditConflict answers a stale edit with 409 and the conflict warning,
+// retargeted into the form's conflict slot so the admin's input is kept.
+func renderEditConflict(c *gin.Context, conflict editConflict) {
+	c.Header("HX-Retarget", "#"+conflict.Prefix+"-form-conflict")
+	c.Header("HX-Reswap", "innerHTML")
+	c.HTML(http.StatusConflict, "edit_conflict", gin.H{
+		"What":         conflict.What,
+		"VersionID":    conflict.Prefix + "-form-version",
+		"Version":      optlock.FormatVersion(conflict.UpdatedAt),
+		"ReloadURL":    conflict.ReloadURL,
+		"ReloadTarget": conflict.ReloadTarget,
+		"UpdatedAt":    conflict.UpdatedAt,
+		"Fields":       conflict.Fields,
+	})
+}
+
+// optionalUUID formats an optional ID for differingFields (nil = "").
+func optionalUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+)
+
+func TestDifferingFields(t *testing.T) {
+	got := differingFields([]editField{
+		{"Name", "App", "App"},
+		{"Description", "old", "new"},
+		{"Enabled", true, false},
+		{"Min length", 8, 8},
+		{"Server", optionalUUID(nil), ""},
+	})
+	want := []string{"Description", "Enabled"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("differingFields = %v, want %v", got, want)
+	}
+}
+
+func TestFormGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	version := time.Date(2026, 10, 16, 12, 0, 0, 123456000, time.UTC)
+
+	tests := []struct {
+		name        string
+		version     string
+		wantOK      bool
+		wantVersion *time.Time
+	}{
+		{"missing version updates unconditionally", "", true, nil},
+		{"valid version", optlock.FormatVersion(version), true, &version},
+		{"malformed version", "yesterday", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			form := url.Values{"version": {tt.version}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+			c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			guard, ok := formGuard(c)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", w.Code)
+				}
+				return
+			}
+			if (guard.Version == nil) != (tt.wantVersion == nil) ||
+				(guard.Version != nil && !guard.Version.Equal(*tt.wantVersion)) {
+				t.Errorf("guard.Version = %v, want %v", guard.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestReadUnmodifiedSince(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(header string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		if header != "" {
+			c.Request.Header.Set("If-Unmodified-Since", header)
+		}
+		return c
+	}
+
+	guard, err := readUnmodifiedSince(newContext(""))
+	if err != nil || !guard.IsZero() {
+		t.Errorf("no header: guard = %+v, err = %v; want zero guard", guard, err)
+	}
+
+	guard, err = readUnmodifiedSince(newContext("Fri, 16 Oct 2026 12:00:00 GMT"))
+	if err != nil || guard.UnmodifiedSince == nil ||
+		!guard.UnmodifiedSince.Equal(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("valid header: guard = %+v, err = %v", guard, err)
+	}
+
+	if _, err := readUnmodifiedSince(newContext("last tuesday")); err == nil {
+		t.Error("malformed header: expected an error")
+	}
+}
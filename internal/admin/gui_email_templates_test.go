@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/internal/email"
+)
+
+func TestEmailTemplateVariablesPanelUsesEngineSyntax(t *testing.T) {
+	c, w := newFragmentContext(t)
+	c.Request = httptest.NewRequest(http.MethodGet, "/gui/email-templates/variables?template_engine=placeholder", nil)
+
+	(&GUIHandler{}).EmailTemplateVariables(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`data-var-name="app_name"`, `data-var-syntax="{app_name}"`, `data-var-go="{{.AppName}}"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("panel missing %s", want)
+		}
+	}
+}
+
+func TestEmailTemplateVariablesPanelRejectsInvalidType(t *testing.T) {
+	c, w := newFragmentContext(t)
+	c.Request = httptest.NewRequest(http.MethodGet, "/gui/email-templates/variables?email_type_id=not-a-uuid", nil)
+
+	(&GUIHandler{}).EmailTemplateVariables(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestRenderEmailTemplateSavedListsWarnings(t *testing.T) {
+	c, w := newFragmentContext(t)
+	renderEmailTemplateSaved(c, "Email template updated successfully.", []email.LintWarning{
+		{Field: "body_html", Code: email.LintUnknownVariable, Variable: "Eta", Message: `Unknown variable "<Eta>"`},
+	})
+
+	body := w.Body.String()
+	if !strings.Contains(body, "alert-warning") || !strings.Contains(body, "<code>body_html</code>") {
+		t.Errorf("warnings not listed: %s", body)
+	}
+	if strings.Contains(body, "<Eta>") {
+		t.Errorf("warning message not escaped: %s", body)
+	}
+	if got := w.Header().Get("HX-Trigger"); got != "emailTemplateListRefresh" {
+		t.Errorf("HX-Trigger = %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================
+// HTMX fragment helpers
+// ============================================================
+//
+// Fragments that interpolate values (names, IDs, error messages, anything an
+// admin or end user typed) are rendered from partial templates, so html/template
+// escapes them for their context. Only constant markup is written with c.String.
+
+// alertFragment is the data of the "alert" partial. Every field is escaped.
+type alertFragment struct {
+	Kind        string // Bootstrap contextual class: success, danger, warning or info
+	Icon        string // Optional Bootstrap icon class, e.g. "bi-check-circle"
+	Title       string // Optional bold lead-in before the message
+	Message     string
+	Detail      string // Optional muted second line
+	Class       string // Extra classes, e.g. "py-2 small" or "mb-0"
+	Dismissible bool
+}
+
+// renderAlert writes an alert fragment with the given status.
+func renderAlert(c *gin.Context, status int, alert alertFragment) {
+	c.HTML(status, "alert", alert)
+}
+
+// renderErrorAlert writes a dismissible danger alert with message.
+func renderErrorAlert(c *gin.Context, status int, message string) {
+	renderAlert(c, status, alertFragment{Kind: "danger", Message: message, Dismissible: true})
+}
+
+// renderSuccessAlert writes a dismissible success alert with message.
+func renderSuccessAlert(c *gin.Context, message string) {
+	renderAlert(c, http.StatusOK, alertFragment{Kind: "success", Message: message, Dismissible: true})
+}
+
+// setHXTriggerEvent sets an HX-Trigger header that fires event with detail,
+// JSON-encoding the detail so messages cannot break out of the header value.
+func setHXTriggerEvent(c *gin.Context, event string, detail interface{}) {
+	payload, err := json.Marshal(map[string]interface{}{event: detail})
+	if err != nil {
+		c.Header("HX-Trigger", event)
+		return
+	}
+	c.Header("HX-Trigger", string(payload))
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+)
+
+// newFragmentContext returns a test context that renders with the GUI templates.
+func newFragmentContext(t *testing.T) (*gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	renderer, err := web.NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	w := httptest.NewRecorder()
+	c, engine := gin.CreateTestContext(w)
+	engine.HTMLRender = renderer
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	return c, w
+}
+
+func TestRenderAlertEscapes(t *testing.T) {
+	c, w := newFragmentContext(t)
+	renderAlert(c, http.StatusBadRequest, alertFragment{
+		Kind:        "danger",
+		Icon:        "bi-x-circle",
+		Title:       `<b>1.2.3.4</b>`,
+		Message:     `App "<script>alert(1)</script>" already exists`,
+		Detail:      "Reason: <img src=x>",
+		Dismissible: true,
+	})
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+	body := w.Body.String()
+	for _, raw := range []string{"<script>", "<b>", "<img"} {
+		if strings.Contains(body, raw) {
+			t.Errorf("alert contains unescaped %q: %s", raw, body)
+		}
+	}
+	for _, want := range []string{
+		`class="alert alert-danger alert-dismissible fade show"`,
+		`&lt;script&gt;alert(1)&lt;/script&gt;`,
+		`<i class="bi bi-x-circle me-2"></i>`,
+		`data-bs-dismiss="alert"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("alert is missing %q: %s", want, body)
+		}
+	}
+}
+
+func TestUserRoleSearchResultsEscapeScriptContext(t *testing.T) {
+	c, w := newFragmentContext(t)
+	c.HTML(http.StatusOK, "user_role_search_results", []UserListItem{
+		{ID: uuid.New(), Email: `x');alert(1);//@example.com`, Name: "<i>Eve</i>"},
+	})
+
+	body := w.Body.String()
+	if strings.Contains(body, `x');alert(1)`) {
+		t.Errorf("email broke out of the onclick string: %s", body)
+	}
+	if strings.Contains(body, "<i>Eve</i>") {
+		t.Errorf("name is not escaped: %s", body)
+	}
+}
+
+func TestRoleOptionsEscape(t *testing.T) {
+	c, w := newFragmentContext(t)
+	c.HTML(http.StatusOK, "role_options", []models.Role{{ID: uuid.New(), Name: `"><script>x</script>`}})
+
+	body := w.Body.String()
+	if strings.Contains(body, "<script>") {
+		t.Errorf("role name is not escaped: %s", body)
+	}
+	if !strings.HasPrefix(body, `<option value="">-- Select Role --</option>`) {
+		t.Errorf("placeholder option missing: %s", body)
+	}
+}
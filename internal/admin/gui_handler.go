@@ -4,10 +4,13 @@ import (
 	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"math"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -15,19 +18,32 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/internal/bruteforce"
+	"github.com/gjovanovicst/auth_api/internal/dnscheck"
 	"github.com/gjovanovicst/auth_api/internal/email"
 	"github.com/gjovanovicst/auth_api/internal/geoip"
 	healthpkg "github.com/gjovanovicst/auth_api/internal/health"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/livefeed"
 	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/metricshistory"
+	"github.com/gjovanovicst/auth_api/internal/notification"
 	oidcpkg "github.com/gjovanovicst/auth_api/internal/oidc"
+	"github.com/gjovanovicst/auth_api/internal/providercheck"
+	"github.com/gjovanovicst/auth_api/internal/quota"
 	"github.com/gjovanovicst/auth_api/internal/rbac"
 	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/scheduler"
+	"github.com/gjovanovicst/auth_api/internal/session"
+	"github.com/gjovanovicst/auth_api/internal/social"
 	"github.com/gjovanovicst/auth_api/internal/twofa"
 	userimport "github.com/gjovanovicst/auth_api/internal/user"
 	passkeypkg "github.com/gjovanovicst/auth_api/internal/webauthn"
 	"github.com/gjovanovicst/auth_api/internal/webhook"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/gjovanovicst/auth_api/web"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
@@ -70,6 +86,14 @@ type GUIHandler struct {
 	OIDCService       *oidcpkg.Service               // OIDC provider service (nil = OIDC disabled)
 	TrustedDeviceRepo *twofa.TrustedDeviceRepository // Trusted device repository (nil = feature disabled)
 	HealthHandler     *healthpkg.Handler             // System health + metrics (nil = monitoring disabled)
+	ViewPrefRepo      *ViewPreferenceRepository      // Saved list filters + column preferences (nil = disabled)
+	Notifications     *notification.Service          // Admin notification center (nil = disabled)
+	Scheduler         *scheduler.Scheduler           // Background job scheduler (nil = scheduler disabled)
+	JobQueue          *jobqueue.Queue                // Background job queue (nil = long operations run inline)
+	DNSChecker        *dnscheck.Checker              // Sender domain DNS checks (nil = system resolver)
+	ProviderChecker   *providercheck.Checker         // OAuth/SMS configuration tests (nil = public provider endpoints)
+	LiveFeed          *livefeed.Hub                  // Live dashboard updates (nil = disabled)
+	MetricsHistory    *metricshistory.Service        // Dashboard history charts (nil = disabled)
 }
 
 // NewGUIHandler creates a new GUIHandler
@@ -115,7 +139,7 @@ func (h *GUIHandler) LoginSubmit(c *gin.Context) {
 
 	username := c.PostForm("username")
 	password := c.PostForm("password")
-	redirect := c.PostForm("redirect")
+	redirect := safeGUIRedirect(c.PostForm("redirect"))
 
 	// Validate input
 	if username == "" || password == "" {
@@ -174,7 +198,7 @@ func (h *GUIHandler) LoginSubmit(c *gin.Context) {
 		// Build redirect URL to 2FA verification page
 		redirectURL := fmt.Sprintf("/gui/2fa-verify?token=%s&method=%s", tempToken, account.TwoFAMethod)
 		if redirect != "" && redirect != "/gui/login" {
-			redirectURL += "&redirect=" + redirect
+			redirectURL += "&redirect=" + url.QueryEscape(redirect)
 		}
 		c.Redirect(http.StatusFound, redirectURL)
 		return
@@ -240,6 +264,8 @@ func (h *GUIHandler) Dashboard(c *gin.Context) {
 }
 
 // DashboardStats returns the stats cards HTML fragment for HTMX.
+// The counts come from a periodically refreshed snapshot, so the browser may
+// reuse the fragment for a short while (statsFragmentMaxAge).
 // GET /gui/dashboard/stats
 func (h *GUIHandler) DashboardStats(c *gin.Context) {
 	stats, err := h.DashboardService.GetStats()
@@ -248,9 +274,13 @@ func (h *GUIHandler) DashboardStats(c *gin.Context) {
 			`<div class="alert alert-danger">Failed to load dashboard stats.</div>`)
 		return
 	}
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(statsFragmentMaxAge.Seconds())))
 	c.HTML(http.StatusOK, "dashboard_stats", stats)
 }
 
+// statsFragmentMaxAge is how long browsers may cache the dashboard stats fragment.
+const statsFragmentMaxAge = 30 * time.Second
+
 // DashboardActivity returns the recent activity table HTML fragment for HTMX.
 // GET /gui/dashboard/activity
 func (h *GUIHandler) DashboardActivity(c *gin.Context) {
@@ -285,7 +315,7 @@ func (h *GUIHandler) TenantList(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 
 	tenants, total, err := h.Repo.ListTenantsWithAppCount(page, pageSize)
 	if err != nil {
@@ -315,8 +345,9 @@ func (h *GUIHandler) TenantList(c *gin.Context) {
 // GET /gui/tenants/new
 func (h *GUIHandler) TenantCreateForm(c *gin.Context) {
 	type formData struct {
-		ID   string
-		Name string
+		ID      string
+		Name    string
+		MaxApps int
 	}
 	c.HTML(http.StatusOK, "tenant_form", formData{})
 }
@@ -331,14 +362,15 @@ func (h *GUIHandler) TenantCreate(c *gin.Context) {
 		return
 	}
 
-	tenant := &models.Tenant{Name: name}
+	tenant := &models.Tenant{Name: name, MaxApps: parseQuotaField(c.PostForm("max_apps"))}
 	if err := h.Repo.CreateTenant(tenant); err != nil {
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to create tenant. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
 	}
+	h.Notifications.TenantCreated(tenant.ID, tenant.Name)
 
-	c.Header("HX-Trigger", "tenantListRefresh")
+	c.Header("HX-Trigger", "tenantListRefresh, notificationsChanged")
 	c.String(http.StatusOK,
 		`<div class="alert alert-success alert-dismissible fade show" role="alert">Tenant created successfully.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 }
@@ -355,12 +387,14 @@ func (h *GUIHandler) TenantEditForm(c *gin.Context) {
 	}
 
 	type formData struct {
-		ID   string
-		Name string
+		ID      string
+		Name    string
+		MaxApps int
 	}
 	c.HTML(http.StatusOK, "tenant_form", formData{
-		ID:   tenant.ID.String(),
-		Name: tenant.Name,
+		ID:      tenant.ID.String(),
+		Name:    tenant.Name,
+		MaxApps: tenant.MaxApps,
 	})
 }
 
@@ -375,7 +409,7 @@ func (h *GUIHandler) TenantUpdate(c *gin.Context) {
 		return
 	}
 
-	if err := h.Repo.UpdateTenant(id, name); err != nil {
+	if err := h.Repo.UpdateTenant(id, name, parseQuotaField(c.PostForm("max_apps"))); err != nil {
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update tenant. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
@@ -422,7 +456,7 @@ func (h *GUIHandler) TenantDelete(c *gin.Context) {
 
 	// Re-fetch and render the updated tenant list
 	page := 1
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 	tenants, total, err := h.Repo.ListTenantsWithAppCount(page, pageSize)
 	if err != nil {
 		c.String(http.StatusInternalServerError,
@@ -482,7 +516,7 @@ func (h *GUIHandler) AppList(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 	tenantID := c.Query("tenant_id")
 
 	apps, total, err := h.Repo.ListAppsWithDetails(page, pageSize, tenantID)
@@ -533,6 +567,7 @@ func (h *GUIHandler) AppCreateForm(c *gin.Context) {
 		Passkey2FAEnabled    bool
 		PasskeyLoginEnabled  bool
 		MagicLinkEnabled     bool
+		PasswordlessOnly     bool
 		OIDCEnabled          bool
 		SMS2FAEnabled        bool
 		TrustedDeviceEnabled bool
@@ -561,6 +596,16 @@ func (h *GUIHandler) AppCreateForm(c *gin.Context) {
 		LoginPrimaryColor   string
 		LoginSecondaryColor string
 		LoginDisplayName    string
+		// Email Branding
+		EmailLogoURL       string
+		EmailFooterText    string
+		EmailPostalAddress string
+		EmailSocialLinks   string
+		// Email Quiet Hours
+		EmailQuietHoursStart string
+		EmailQuietHoursEnd   string
+		EmailTimeZone        string
+		Timezones            []string
 		// Password Policy
 		PwMinLength     int
 		PwMaxLength     int
@@ -573,10 +618,35 @@ func (h *GUIHandler) AppCreateForm(c *gin.Context) {
 		// Token TTL overrides
 		AccessTokenTTLMinutes int
 		RefreshTokenTTLHours  int
+		// Concurrent session limit (0 = unlimited)
+		MaxSessionsPerUser int
+		SessionLimitPolicy string
 		// Email Action Link Paths
 		ResetPasswordPath string
 		MagicLinkPath     string
 		VerifyEmailPath   string
+		// Redirect allowlist (empty = use ALLOWED_REDIRECT_DOMAINS)
+		AllowedRedirectURLs string
+		// Social login callback delivery ("query", "fragment", "json", "post_message")
+		SocialCallbackMode string
+		// Social profile sync policy ("login", "on_demand", "scheduled") and interval
+		SocialProfileSync              string
+		SocialProfileSyncIntervalHours int
+		// Social raw data retention policy ("full", "allowlist", "none") and allowlisted fields
+		SocialRawData       string
+		SocialRawDataFields string
+		// Per-app additions to the disposable email blocklist
+		BlockedEmailDomains string
+		// Verify email addresses with a 6-digit code instead of a link
+		EmailVerificationCode bool
+		// Hold new registrations for admin approval
+		RegistrationApprovalRequired bool
+		// Sign-in methods permitted by the auth method policy
+		AuthMethods []authMethodOption
+		// Quotas (0 = use global default)
+		MaxUsers        int
+		MaxApiKeys      int
+		MaxEmailsPerDay int
 	}
 	c.HTML(http.StatusOK, "app_form", formData{
 		TwoFAEnabled: true, // Default: 2FA enabled for new apps
@@ -598,15 +668,60 @@ func (h *GUIHandler) AppCreateForm(c *gin.Context) {
 		// Password Policy defaults
 		PwMinLength: 8,
 		PwMaxLength: 128,
+		// Session limit default
+		SessionLimitPolicy: session.LimitPolicyEvictOldest,
+		Timezones:          web.Timezones,
+		AuthMethods:        authMethodOptions(&models.Application{}),
+		// Social profile sync defaults
+		SocialProfileSync:              social.ProfileSyncLogin,
+		SocialProfileSyncIntervalHours: 24,
+		SocialRawData:                  social.RawDataFull,
 	})
 }
 
+// parseSocialRawData reads the social raw data retention policy and
+// allowlisted fields of the app form. The allowlist policy needs a field.
+func parseSocialRawData(c *gin.Context) (policy, fields string, err error) {
+	policy = c.PostForm("social_raw_data")
+	if !social.IsValidRawDataPolicy(policy) {
+		policy = social.RawDataFull
+	}
+	fields = strings.Join(social.ParseRawDataFields(c.PostForm("social_raw_data_fields")), ", ")
+	if policy == social.RawDataAllowlist && fields == "" {
+		return "", "", errors.New("list the fields to keep")
+	}
+	return policy, fields, nil
+}
+
+// parseSocialProfileSync reads the social profile sync policy and interval of
+// the app form. An unknown policy falls back to sync on login only.
+func parseSocialProfileSync(c *gin.Context) (policy string, intervalHours int, err error) {
+	policy = c.PostForm("social_profile_sync")
+	if !social.IsValidProfileSync(policy) {
+		policy = social.ProfileSyncLogin
+	}
+	intervalHours = 24
+	if v := strings.TrimSpace(c.PostForm("social_profile_sync_interval_hours")); v != "" {
+		intervalHours, err = strconv.Atoi(v)
+		if err != nil || intervalHours < 1 || intervalHours > 720 {
+			return "", 0, errors.New("it must be between 1 and 720 hours")
+		}
+	}
+	return policy, intervalHours, nil
+}
+
 // AppCreate handles creating a new application.
 // POST /gui/applications
 func (h *GUIHandler) AppCreate(c *gin.Context) {
 	name := strings.TrimSpace(c.PostForm("name"))
 	description := strings.TrimSpace(c.PostForm("description"))
 	frontendURL := strings.TrimSpace(c.PostForm("frontend_url"))
+	allowedRedirectURLs := strings.TrimSpace(c.PostForm("allowed_redirect_urls"))
+	blockedEmailDomains := strings.TrimSpace(c.PostForm("blocked_email_domains"))
+	socialCallbackMode := c.PostForm("social_callback_mode")
+	if !social.IsValidCallbackMode(socialCallbackMode) {
+		socialCallbackMode = social.CallbackModeQuery
+	}
 	tenantID := c.PostForm("tenant_id")
 	twoFAIssuerName := strings.TrimSpace(c.PostForm("two_fa_issuer_name"))
 	twoFAEnabled := c.PostForm("two_fa_enabled") == "on"
@@ -614,6 +729,9 @@ func (h *GUIHandler) AppCreate(c *gin.Context) {
 	passkey2FAEnabled := c.PostForm("passkey_2fa_enabled") == "on"
 	passkeyLoginEnabled := c.PostForm("passkey_login_enabled") == "on"
 	magicLinkEnabled := c.PostForm("magic_link_enabled") == "on"
+	passwordlessOnly := c.PostForm("passwordless_only") == "on"
+	emailVerificationCode := c.PostForm("email_verification_code") == "on"
+	registrationApprovalRequired := c.PostForm("registration_approval_required") == "on"
 	sms2FAEnabled := c.PostForm("sms_2fa_enabled") == "on"
 	trustedDeviceEnabled := c.PostForm("trusted_device_enabled") == "on"
 	trustedDeviceMaxDays := 30
@@ -621,6 +739,22 @@ func (h *GUIHandler) AppCreate(c *gin.Context) {
 		trustedDeviceMaxDays = v
 	}
 
+	allowedAuthMethods, err := parseAuthMethodPolicy(c.PostFormArray("allowed_auth_methods"), passwordlessOnly)
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid sign-in methods: "+err.Error()+".")
+		return
+	}
+	socialProfileSync, socialProfileSyncHours, err := parseSocialProfileSync(c)
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid social profile sync interval: "+err.Error()+".")
+		return
+	}
+	socialRawData, socialRawDataFields, err := parseSocialRawData(c)
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid social raw data retention: "+err.Error()+".")
+		return
+	}
+
 	if name == "" {
 		c.String(http.StatusBadRequest,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Application name is required.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
@@ -639,20 +773,45 @@ func (h *GUIHandler) AppCreate(c *gin.Context) {
 		return
 	}
 
+	if err := quota.CheckTenantApps(h.Repo.DB, parsedTenantID); err != nil {
+		msg := "Failed to check the tenant's application quota."
+		if errors.Is(err, quota.ErrExceeded) {
+			msg = "Cannot create application: " + err.Error() + "."
+		}
+		renderErrorAlert(c, http.StatusForbidden, msg)
+		return
+	}
+
 	app := &models.Application{
 		TenantID:             parsedTenantID,
 		Name:                 name,
 		Description:          description,
 		FrontendURL:          frontendURL,
+		AllowedRedirectURLs:  allowedRedirectURLs,
+		BlockedEmailDomains:  blockedEmailDomains,
+		SocialCallbackMode:   socialCallbackMode,
 		TwoFAIssuerName:      twoFAIssuerName,
 		TwoFAEnabled:         twoFAEnabled,
 		TwoFARequired:        twoFARequired,
 		Passkey2FAEnabled:    passkey2FAEnabled,
 		PasskeyLoginEnabled:  passkeyLoginEnabled,
 		MagicLinkEnabled:     magicLinkEnabled,
+		PasswordlessOnly:     passwordlessOnly,
 		SMS2FAEnabled:        sms2FAEnabled,
 		TrustedDeviceEnabled: trustedDeviceEnabled,
 		TrustedDeviceMaxDays: trustedDeviceMaxDays,
+		// Email verification by code
+		EmailVerificationCode: emailVerificationCode,
+		// Registration approval
+		RegistrationApprovalRequired: registrationApprovalRequired,
+		// Auth method policy
+		AllowedAuthMethods: allowedAuthMethods,
+		// Social profile sync
+		SocialProfileSync:              socialProfileSync,
+		SocialProfileSyncIntervalHours: socialProfileSyncHours,
+		// Social raw data retention
+		SocialRawData:       socialRawData,
+		SocialRawDataFields: socialRawDataFields,
 	}
 
 	// Brute-force lockout overrides
@@ -709,6 +868,21 @@ func (h *GUIHandler) AppCreate(c *gin.Context) {
 	app.LoginSecondaryColor = strings.TrimSpace(c.PostForm("login_secondary_color"))
 	app.LoginDisplayName = strings.TrimSpace(c.PostForm("login_display_name"))
 
+	// Email Branding
+	app.EmailLogoURL = strings.TrimSpace(c.PostForm("email_logo_url"))
+	app.EmailFooterText = strings.TrimSpace(c.PostForm("email_footer_text"))
+	app.EmailPostalAddress = strings.TrimSpace(c.PostForm("email_postal_address"))
+	app.EmailSocialLinks = strings.TrimSpace(c.PostForm("email_social_links"))
+
+	// Email Quiet Hours
+	app.EmailQuietHoursStart = strings.TrimSpace(c.PostForm("email_quiet_hours_start"))
+	app.EmailQuietHoursEnd = strings.TrimSpace(c.PostForm("email_quiet_hours_end"))
+	app.EmailTimeZone = strings.TrimSpace(c.PostForm("email_time_zone"))
+	if _, err := email.ParseQuietHours(app.EmailQuietHoursStart, app.EmailQuietHoursEnd, app.EmailTimeZone); err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid email quiet hours: "+err.Error()+".")
+		return
+	}
+
 	// Password Policy
 	app.PwMinLength = 8
 	if v, err := strconv.Atoi(c.PostForm("pw_min_length")); err == nil && v > 0 {
@@ -722,8 +896,8 @@ func (h *GUIHandler) AppCreate(c *gin.Context) {
 	app.PwRequireLower = c.PostForm("pw_require_lower") == "on"
 	app.PwRequireDigit = c.PostForm("pw_require_digit") == "on"
 	app.PwRequireSymbol = c.PostForm("pw_require_symbol") == "on"
-	if v, err := strconv.Atoi(c.PostForm("pw_history_count")); err == nil && v >= 0 {
-		app.PwHistoryCount = v
+	if v, err := strconv.Atoi(c.PostForm("pw_history_count")); err == nil {
+		app.PwHistoryCount = userimport.ClampPasswordHistoryCount(v)
 	}
 	if v, err := strconv.Atoi(c.PostForm("pw_max_age_days")); err == nil && v >= 0 {
 		app.PwMaxAgeDays = v
@@ -737,6 +911,20 @@ func (h *GUIHandler) AppCreate(c *gin.Context) {
 		app.RefreshTokenTTLHours = v
 	}
 
+	// Concurrent session limit
+	if v, err := strconv.Atoi(c.PostForm("max_sessions_per_user")); err == nil && v >= 0 {
+		app.MaxSessionsPerUser = v
+	}
+	app.SessionLimitPolicy = c.PostForm("session_limit_policy")
+	if !session.IsValidLimitPolicy(app.SessionLimitPolicy) {
+		app.SessionLimitPolicy = session.LimitPolicyEvictOldest
+	}
+
+	// Quotas
+	app.MaxUsers = parseQuotaField(c.PostForm("max_users"))
+	app.MaxApiKeys = parseQuotaField(c.PostForm("max_api_keys"))
+	app.MaxEmailsPerDay = parseQuotaField(c.PostForm("max_emails_per_day"))
+
 	if err := h.Repo.CreateApp(app); err != nil {
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to create application. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
@@ -781,12 +969,14 @@ func (h *GUIHandler) AppEditForm(c *gin.Context) {
 		Passkey2FAEnabled    bool
 		PasskeyLoginEnabled  bool
 		MagicLinkEnabled     bool
+		PasswordlessOnly     bool
 		OIDCEnabled          bool
 		SMS2FAEnabled        bool
 		TrustedDeviceEnabled bool
 		TrustedDeviceMaxDays int
 		Tenants              []models.Tenant
 		IsEdit               bool
+		Version              string // Row version for optimistic locking (see optlock)
 		// Brute-force overrides
 		BfLockoutOverride  bool
 		BfLockoutEnabled   bool
@@ -809,6 +999,16 @@ func (h *GUIHandler) AppEditForm(c *gin.Context) {
 		LoginPrimaryColor   string
 		LoginSecondaryColor string
 		LoginDisplayName    string
+		// Email Branding
+		EmailLogoURL       string
+		EmailFooterText    string
+		EmailPostalAddress string
+		EmailSocialLinks   string
+		// Email Quiet Hours
+		EmailQuietHoursStart string
+		EmailQuietHoursEnd   string
+		EmailTimeZone        string
+		Timezones            []string
 		// Password Policy
 		PwMinLength     int
 		PwMaxLength     int
@@ -821,10 +1021,35 @@ func (h *GUIHandler) AppEditForm(c *gin.Context) {
 		// Token TTL overrides
 		AccessTokenTTLMinutes int
 		RefreshTokenTTLHours  int
+		// Concurrent session limit (0 = unlimited)
+		MaxSessionsPerUser int
+		SessionLimitPolicy string
 		// Email Action Link Paths
 		ResetPasswordPath string
 		MagicLinkPath     string
 		VerifyEmailPath   string
+		// Redirect allowlist (empty = use ALLOWED_REDIRECT_DOMAINS)
+		AllowedRedirectURLs string
+		// Social login callback delivery ("query", "fragment", "json", "post_message")
+		SocialCallbackMode string
+		// Social profile sync policy ("login", "on_demand", "scheduled") and interval
+		SocialProfileSync              string
+		SocialProfileSyncIntervalHours int
+		// Social raw data retention policy ("full", "allowlist", "none") and allowlisted fields
+		SocialRawData       string
+		SocialRawDataFields string
+		// Per-app additions to the disposable email blocklist
+		BlockedEmailDomains string
+		// Verify email addresses with a 6-digit code instead of a link
+		EmailVerificationCode bool
+		// Hold new registrations for admin approval
+		RegistrationApprovalRequired bool
+		// Sign-in methods permitted by the auth method policy
+		AuthMethods []authMethodOption
+		// Quotas (0 = use global default)
+		MaxUsers        int
+		MaxApiKeys      int
+		MaxEmailsPerDay int
 	}
 
 	fd := formData{
@@ -839,17 +1064,29 @@ func (h *GUIHandler) AppEditForm(c *gin.Context) {
 		Passkey2FAEnabled:    app.Passkey2FAEnabled,
 		PasskeyLoginEnabled:  app.PasskeyLoginEnabled,
 		MagicLinkEnabled:     app.MagicLinkEnabled,
+		PasswordlessOnly:     app.PasswordlessOnly,
 		OIDCEnabled:          app.OIDCEnabled,
 		SMS2FAEnabled:        app.SMS2FAEnabled,
 		TrustedDeviceEnabled: app.TrustedDeviceEnabled,
 		TrustedDeviceMaxDays: app.TrustedDeviceMaxDays,
 		Tenants:              tenants,
 		IsEdit:               true,
+		Version:              optlock.FormatVersion(app.UpdatedAt),
 		// Login Page Branding
 		LoginLogoURL:        app.LoginLogoURL,
 		LoginPrimaryColor:   app.LoginPrimaryColor,
 		LoginSecondaryColor: app.LoginSecondaryColor,
 		LoginDisplayName:    app.LoginDisplayName,
+		// Email Branding
+		EmailLogoURL:       app.EmailLogoURL,
+		EmailFooterText:    app.EmailFooterText,
+		EmailPostalAddress: app.EmailPostalAddress,
+		EmailSocialLinks:   app.EmailSocialLinks,
+		// Email Quiet Hours
+		EmailQuietHoursStart: app.EmailQuietHoursStart,
+		EmailQuietHoursEnd:   app.EmailQuietHoursEnd,
+		EmailTimeZone:        app.EmailTimeZone,
+		Timezones:            web.Timezones,
 		// Password Policy
 		PwMinLength:     app.PwMinLength,
 		PwMaxLength:     app.PwMaxLength,
@@ -862,10 +1099,34 @@ func (h *GUIHandler) AppEditForm(c *gin.Context) {
 		// Token TTL overrides
 		AccessTokenTTLMinutes: app.AccessTokenTTLMinutes,
 		RefreshTokenTTLHours:  app.RefreshTokenTTLHours,
+		// Concurrent session limit
+		MaxSessionsPerUser: app.MaxSessionsPerUser,
+		SessionLimitPolicy: app.SessionLimitPolicy,
 		// Email Action Link Paths
 		ResetPasswordPath: app.ResetPasswordPath,
 		MagicLinkPath:     app.MagicLinkPath,
 		VerifyEmailPath:   app.VerifyEmailPath,
+		// Redirect allowlist
+		AllowedRedirectURLs: app.AllowedRedirectURLs,
+		SocialCallbackMode:  app.SocialCallbackMode,
+		// Social profile sync
+		SocialProfileSync:              app.SocialProfileSync,
+		SocialProfileSyncIntervalHours: app.SocialProfileSyncIntervalHours,
+		// Social raw data retention
+		SocialRawData:       app.SocialRawData,
+		SocialRawDataFields: app.SocialRawDataFields,
+		// Blocked email domains
+		BlockedEmailDomains: app.BlockedEmailDomains,
+		// Email verification by code
+		EmailVerificationCode: app.EmailVerificationCode,
+		// Registration approval
+		RegistrationApprovalRequired: app.RegistrationApprovalRequired,
+		// Auth method policy
+		AuthMethods: authMethodOptions(app),
+		// Quotas
+		MaxUsers:        app.MaxUsers,
+		MaxApiKeys:      app.MaxApiKeys,
+		MaxEmailsPerDay: app.MaxEmailsPerDay,
 	}
 
 	// Pre-fill brute-force defaults so fields are never blank
@@ -966,6 +1227,10 @@ func (h *GUIHandler) AppUpdate(c *gin.Context) {
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Application name is required.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
 	}
+	guard, ok := formGuard(c)
+	if !ok {
+		return
+	}
 
 	// Build brute-force settings
 	var bf BruteForceAppSettings
@@ -1028,6 +1293,15 @@ func (h *GUIHandler) AppUpdate(c *gin.Context) {
 		LoginPrimaryColor:   strings.TrimSpace(c.PostForm("login_primary_color")),
 		LoginSecondaryColor: strings.TrimSpace(c.PostForm("login_secondary_color")),
 		LoginDisplayName:    strings.TrimSpace(c.PostForm("login_display_name")),
+		// Email Branding
+		EmailLogoURL:       strings.TrimSpace(c.PostForm("email_logo_url")),
+		EmailFooterText:    strings.TrimSpace(c.PostForm("email_footer_text")),
+		EmailPostalAddress: strings.TrimSpace(c.PostForm("email_postal_address")),
+		EmailSocialLinks:   strings.TrimSpace(c.PostForm("email_social_links")),
+		// Email Quiet Hours
+		EmailQuietHoursStart: strings.TrimSpace(c.PostForm("email_quiet_hours_start")),
+		EmailQuietHoursEnd:   strings.TrimSpace(c.PostForm("email_quiet_hours_end")),
+		EmailTimeZone:        strings.TrimSpace(c.PostForm("email_time_zone")),
 		// Password Policy
 		PwMinLength:     8,
 		PwMaxLength:     128,
@@ -1039,6 +1313,20 @@ func (h *GUIHandler) AppUpdate(c *gin.Context) {
 		ResetPasswordPath: strings.TrimSpace(c.PostForm("reset_password_path")),
 		MagicLinkPath:     strings.TrimSpace(c.PostForm("magic_link_path")),
 		VerifyEmailPath:   strings.TrimSpace(c.PostForm("verify_email_path")),
+		// Redirect allowlist
+		AllowedRedirectURLs: strings.TrimSpace(c.PostForm("allowed_redirect_urls")),
+		SocialCallbackMode:  c.PostForm("social_callback_mode"),
+		// Passkey-only mode
+		PasswordlessOnly: c.PostForm("passwordless_only") == "on",
+		// Blocked email domains
+		BlockedEmailDomains: strings.TrimSpace(c.PostForm("blocked_email_domains")),
+		// Email verification by code
+		EmailVerificationCode: c.PostForm("email_verification_code") == "on",
+		// Registration approval
+		RegistrationApprovalRequired: c.PostForm("registration_approval_required") == "on",
+	}
+	if !social.IsValidCallbackMode(custom.SocialCallbackMode) {
+		custom.SocialCallbackMode = social.CallbackModeQuery
 	}
 	if v, err := strconv.Atoi(c.PostForm("pw_min_length")); err == nil && v > 0 {
 		custom.PwMinLength = v
@@ -1046,8 +1334,8 @@ func (h *GUIHandler) AppUpdate(c *gin.Context) {
 	if v, err := strconv.Atoi(c.PostForm("pw_max_length")); err == nil && v > 0 {
 		custom.PwMaxLength = v
 	}
-	if v, err := strconv.Atoi(c.PostForm("pw_history_count")); err == nil && v >= 0 {
-		custom.PwHistoryCount = v
+	if v, err := strconv.Atoi(c.PostForm("pw_history_count")); err == nil {
+		custom.PwHistoryCount = userimport.ClampPasswordHistoryCount(v)
 	}
 	if v, err := strconv.Atoi(c.PostForm("pw_max_age_days")); err == nil && v >= 0 {
 		custom.PwMaxAgeDays = v
@@ -1058,13 +1346,108 @@ func (h *GUIHandler) AppUpdate(c *gin.Context) {
 	if v, err := strconv.Atoi(c.PostForm("refresh_token_ttl_hours")); err == nil && v >= 0 {
 		custom.RefreshTokenTTLHours = v
 	}
-
-	if err := h.Repo.UpdateApp(id, name, description, frontendURL, twoFAIssuerName, twoFAEnabled, twoFARequired, passkey2FAEnabled, passkeyLoginEnabled, magicLinkEnabled, oidcEnabled, bf, custom); err != nil {
+	if v, err := strconv.Atoi(c.PostForm("max_sessions_per_user")); err == nil && v >= 0 {
+		custom.MaxSessionsPerUser = v
+	}
+	custom.SessionLimitPolicy = c.PostForm("session_limit_policy")
+	if !session.IsValidLimitPolicy(custom.SessionLimitPolicy) {
+		custom.SessionLimitPolicy = session.LimitPolicyEvictOldest
+	}
+	if _, err := email.ParseQuietHours(custom.EmailQuietHoursStart, custom.EmailQuietHoursEnd, custom.EmailTimeZone); err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid email quiet hours: "+err.Error()+".")
+		return
+	}
+	allowedAuthMethods, err := parseAuthMethodPolicy(c.PostFormArray("allowed_auth_methods"), custom.PasswordlessOnly)
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid sign-in methods: "+err.Error()+".")
+		return
+	}
+	custom.AllowedAuthMethods = allowedAuthMethods
+	custom.SocialProfileSync, custom.SocialProfileSyncIntervalHours, err = parseSocialProfileSync(c)
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid social profile sync interval: "+err.Error()+".")
+		return
+	}
+	custom.SocialRawData, custom.SocialRawDataFields, err = parseSocialRawData(c)
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid social raw data retention: "+err.Error()+".")
+		return
+	}
+
+	// Remembered so that histories can be pruned when the count is lowered
+	previousHistoryCount := -1
+	if app, err := h.Repo.GetAppByID(id); err == nil {
+		previousHistoryCount = app.PwHistoryCount
+	}
+
+	if err := h.Repo.UpdateApp(id, name, description, frontendURL, twoFAIssuerName, twoFAEnabled, twoFARequired, passkey2FAEnabled, passkeyLoginEnabled, magicLinkEnabled, oidcEnabled, bf, custom, guard); err != nil {
+		if errors.Is(err, optlock.ErrConflict) {
+			if app, loadErr := h.Repo.GetAppByID(id); loadErr == nil {
+				renderEditConflict(c, editConflict{
+					What:         "application",
+					Prefix:       "app",
+					ReloadURL:    "/gui/applications/" + id + "/edit",
+					ReloadTarget: "#app-form-container",
+					UpdatedAt:    app.UpdatedAt,
+					Fields: differingFields([]editField{
+						{"Name", app.Name, name},
+						{"Description", app.Description, description},
+						{"Frontend URL", app.FrontendURL, frontendURL},
+						{"2FA issuer name", app.TwoFAIssuerName, twoFAIssuerName},
+						{"2FA enabled", app.TwoFAEnabled, twoFAEnabled},
+						{"2FA required", app.TwoFARequired, twoFARequired},
+						{"Passkey 2FA", app.Passkey2FAEnabled, passkey2FAEnabled},
+						{"Passkey login", app.PasskeyLoginEnabled, passkeyLoginEnabled},
+						{"Magic link", app.MagicLinkEnabled, magicLinkEnabled},
+						{"Passkey-only mode", app.PasswordlessOnly, custom.PasswordlessOnly},
+						{"Email verification code", app.EmailVerificationCode, custom.EmailVerificationCode},
+						{"Registration approval", app.RegistrationApprovalRequired, custom.RegistrationApprovalRequired},
+						{"OIDC", app.OIDCEnabled, oidcEnabled},
+						{"SMS 2FA", app.SMS2FAEnabled, sms2FAEnabled},
+						{"Trusted devices", app.TrustedDeviceEnabled, trustedDeviceEnabled},
+						{"Login logo URL", app.LoginLogoURL, custom.LoginLogoURL},
+						{"Login display name", app.LoginDisplayName, custom.LoginDisplayName},
+						{"Email logo URL", app.EmailLogoURL, custom.EmailLogoURL},
+						{"Email footer text", app.EmailFooterText, custom.EmailFooterText},
+						{"Email postal address", app.EmailPostalAddress, custom.EmailPostalAddress},
+						{"Email social links", app.EmailSocialLinks, custom.EmailSocialLinks},
+						{"Email quiet hours start", app.EmailQuietHoursStart, custom.EmailQuietHoursStart},
+						{"Email quiet hours end", app.EmailQuietHoursEnd, custom.EmailQuietHoursEnd},
+						{"Email time zone", app.EmailTimeZone, custom.EmailTimeZone},
+						{"Password minimum length", app.PwMinLength, custom.PwMinLength},
+						{"Password maximum length", app.PwMaxLength, custom.PwMaxLength},
+						{"Password history", app.PwHistoryCount, custom.PwHistoryCount},
+						{"Access token TTL", app.AccessTokenTTLMinutes, custom.AccessTokenTTLMinutes},
+						{"Refresh token TTL", app.RefreshTokenTTLHours, custom.RefreshTokenTTLHours},
+						{"Max sessions per user", app.MaxSessionsPerUser, custom.MaxSessionsPerUser},
+						{"Session limit policy", app.SessionLimitPolicy, custom.SessionLimitPolicy},
+						{"Allowed redirect URLs", app.AllowedRedirectURLs, custom.AllowedRedirectURLs},
+						{"Social callback mode", app.SocialCallbackMode, custom.SocialCallbackMode},
+						{"Social profile sync", app.SocialProfileSync, custom.SocialProfileSync},
+						{"Social profile sync interval", app.SocialProfileSyncIntervalHours, custom.SocialProfileSyncIntervalHours},
+						{"Social raw data retention", app.SocialRawData, custom.SocialRawData},
+						{"Social raw data fields", app.SocialRawDataFields, custom.SocialRawDataFields},
+						{"Blocked email domains", app.BlockedEmailDomains, custom.BlockedEmailDomains},
+						{"Allowed sign-in methods", app.AllowedAuthMethods, custom.AllowedAuthMethods},
+					}),
+				})
+				return
+			}
+		}
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update application. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
 	}
 
+	// Drop the remembered password hashes the new policy no longer checks
+	if previousHistoryCount > custom.PwHistoryCount {
+		if pruned, err := h.Repo.PrunePasswordHistory(id, custom.PwHistoryCount); err != nil {
+			log.Printf("Warning: Failed to prune password history of app %s: %v\n", id, err)
+		} else if pruned > 0 {
+			log.Printf("Pruned password history of %d users of app %s to %d entries\n", pruned, id, custom.PwHistoryCount)
+		}
+	}
+
 	// Update SMS and trusted device settings
 	if err := h.Repo.UpdateAppSMSTrustedDevice(id, sms2FAEnabled, trustedDeviceEnabled, trustedDeviceMaxDays); err != nil {
 		c.String(http.StatusInternalServerError,
@@ -1072,11 +1455,107 @@ func (h *GUIHandler) AppUpdate(c *gin.Context) {
 		return
 	}
 
+	// Update quota overrides
+	if err := h.Repo.UpdateAppQuotas(id, parseQuotaField(c.PostForm("max_users")), parseQuotaField(c.PostForm("max_api_keys")), parseQuotaField(c.PostForm("max_emails_per_day"))); err != nil {
+		c.String(http.StatusInternalServerError,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update quota settings.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
+
 	c.Header("HX-Trigger", "appListRefresh")
 	c.String(http.StatusOK,
 		`<div class="alert alert-success alert-dismissible fade show" role="alert">Application updated successfully.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 }
 
+// parseQuotaField parses a quota form value. Empty, invalid or negative
+// values mean "use the global default" and are stored as 0.
+func parseQuotaField(v string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// AppCloneForm returns the clone form HTML fragment for HTMX.
+// GET /gui/applications/:id/clone
+func (h *GUIHandler) AppCloneForm(c *gin.Context) {
+	app, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusNotFound,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Application not found.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
+	tenants, err := h.Repo.ListAllTenants()
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to load tenants.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
+
+	type cloneFormData struct {
+		ID       string
+		Name     string
+		TenantID string
+		Tenants  []models.Tenant
+	}
+	c.HTML(http.StatusOK, "app_clone_form", cloneFormData{
+		ID:       app.ID.String(),
+		Name:     app.Name,
+		TenantID: app.TenantID.String(),
+		Tenants:  tenants,
+	})
+}
+
+// AppClone handles cloning an application's configuration into a new application.
+// POST /gui/applications/:id/clone
+func (h *GUIHandler) AppClone(c *gin.Context) {
+	name := strings.TrimSpace(c.PostForm("name"))
+	description := strings.TrimSpace(c.PostForm("description"))
+	if name == "" {
+		c.String(http.StatusBadRequest,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Application name is required.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
+
+	source, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusNotFound,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Application not found.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
+
+	tenantID, err := uuid.Parse(c.PostForm("tenant_id"))
+	if err != nil {
+		c.String(http.StatusBadRequest,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Invalid tenant ID.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
+
+	if err := quota.CheckTenantApps(h.Repo.DB, tenantID); err != nil {
+		msg := "Failed to check the tenant's application quota."
+		if errors.Is(err, quota.ErrExceeded) {
+			msg = "Cannot clone application: " + err.Error() + "."
+		}
+		renderErrorAlert(c, http.StatusForbidden, msg)
+		return
+	}
+
+	result, err := h.Repo.CloneApp(source, tenantID, name, description)
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to clone application. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
+
+	// Seed default RBAC roles for the new application (non-fatal on error)
+	_ = h.Repo.SeedDefaultRolesForApp(result.App.ID)
+
+	c.Header("HX-Trigger", "appListRefresh")
+	renderSuccessAlert(c, fmt.Sprintf("Application cloned successfully (%d OAuth configs, %d SMTP configs, %d email templates). Re-enter OAuth client secrets and SMTP passwords before enabling them.",
+		result.OAuthConfigs, result.SMTPConfigs, result.EmailTemplates))
+}
+
 // AppDeleteConfirm returns the delete confirmation modal body for HTMX.
 // GET /gui/applications/:id/delete
 func (h *GUIHandler) AppDeleteConfirm(c *gin.Context) {
@@ -1098,6 +1577,50 @@ func (h *GUIHandler) AppDeleteConfirm(c *gin.Context) {
 	})
 }
 
+// AppKillSessionsConfirm returns the session kill switch confirmation for HTMX.
+// GET /gui/applications/:id/kill-sessions
+func (h *GUIHandler) AppKillSessionsConfirm(c *gin.Context) {
+	app, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusNotFound,
+			`<div class="modal-body"><div class="alert alert-danger">Application not found.</div></div>`)
+		return
+	}
+
+	var sessions int64
+	if redis.Rdb != nil {
+		sessions, _ = redis.CountAppSessions(app.ID.String())
+	}
+	c.HTML(http.StatusOK, "app_kill_sessions_confirm", gin.H{
+		"ID":          app.ID.String(),
+		"Name":        app.Name,
+		"Sessions":    sessions,
+		"AuthEpochAt": app.AuthEpochAt,
+	})
+}
+
+// AppKillSessions revokes every session, token and trusted device of an application.
+// POST /gui/applications/:id/kill-sessions
+func (h *GUIHandler) AppKillSessions(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, `<div class="modal-body"><div class="alert alert-danger">Invalid application ID.</div></div>`)
+		return
+	}
+	result, err := h.Repo.RevokeAllAppSessions(appID)
+	if result == nil {
+		c.String(http.StatusInternalServerError, `<div class="modal-body"><div class="alert alert-danger">Failed to revoke application sessions.</div></div>`)
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Kill switch for app %s incomplete: %v\n", appID, err)
+	}
+	c.String(http.StatusOK, fmt.Sprintf(
+		`<div class="modal-body"><div class="alert alert-success mb-0"><i class="bi bi-check-circle me-1"></i>All sessions revoked: every token issued before %s UTC is rejected. %d sessions and %d trusted devices deleted.</div></div>`+
+			`<div class="modal-footer border-0"><button type="button" class="btn btn-outline-secondary btn-sm" data-bs-dismiss="modal">Close</button></div>`,
+		result.Epoch.Format("2006-01-02 15:04:05"), result.Sessions, result.TrustedDevices))
+}
+
 // AppDelete handles deleting an application.
 // DELETE /gui/applications/:id
 func (h *GUIHandler) AppDelete(c *gin.Context) {
@@ -1113,7 +1636,7 @@ func (h *GUIHandler) AppDelete(c *gin.Context) {
 
 	// Re-fetch and render the updated application list
 	page := 1
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 	apps, total, err := h.Repo.ListAppsWithDetails(page, pageSize, "")
 	if err != nil {
 		c.String(http.StatusInternalServerError,
@@ -1174,7 +1697,7 @@ func (h *GUIHandler) OAuthList(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 	appID := c.Query("app_id")
 
 	configs, total, err := h.Repo.ListOAuthConfigsWithDetails(page, pageSize, appID)
@@ -1222,10 +1745,20 @@ func (h *GUIHandler) OAuthCreateForm(c *gin.Context) {
 		IsEnabled   bool
 		Apps        []AppWithTenant
 		IsEdit      bool
+		// Claim mapping rules as JSON
+		ClaimMappings string
+		// Just-in-time provisioning
+		ProvisioningMode    string
+		AllowedEmailDomains string
+		// Generic OpenID Connect providers
+		IsOIDC    bool
+		IssuerURL string
+		Scopes    string
 	}
 	c.HTML(http.StatusOK, "oauth_form", formData{
-		IsEnabled: true, // Default to enabled for new configs
-		Apps:      apps,
+		IsEnabled:        true, // Default to enabled for new configs
+		Apps:             apps,
+		ProvisioningMode: models.ProvisioningModeAuto,
 	})
 }
 
@@ -1238,6 +1771,27 @@ func (h *GUIHandler) OAuthCreate(c *gin.Context) {
 	clientSecret := strings.TrimSpace(c.PostForm("client_secret"))
 	redirectURL := strings.TrimSpace(c.PostForm("redirect_url"))
 	isEnabled := c.PostForm("is_enabled") == "true"
+	claimMappings, err := parseClaimMappings([]byte(c.PostForm("claim_mappings")))
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid claim mappings: "+err.Error()+".")
+		return
+	}
+	provisioningMode := provisioningModeOrDefault(c.PostForm("provisioning_mode"))
+	if provisioningMode != models.ProvisioningModeAuto && provisioningMode != models.ProvisioningModeExistingOnly {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid provisioning mode.")
+		return
+	}
+	allowedEmailDomains := strings.TrimSpace(c.PostForm("allowed_email_domains"))
+	// The "oidc" option stands for a generic OpenID Connect provider named by provider_name
+	if provider == "oidc" {
+		provider = strings.ToLower(strings.TrimSpace(c.PostForm("provider_name")))
+		if provider == "" {
+			renderErrorAlert(c, http.StatusBadRequest, "Provider name is required for OpenID Connect providers.")
+			return
+		}
+	}
+	issuerURL := strings.TrimSpace(c.PostForm("issuer_url"))
+	scopes := strings.Join(strings.Fields(c.PostForm("scopes")), " ")
 
 	if appID == "" {
 		c.String(http.StatusBadRequest,
@@ -1279,8 +1833,19 @@ func (h *GUIHandler) OAuthCreate(c *gin.Context) {
 		ClientSecret: clientSecret,
 		RedirectURL:  redirectURL,
 		IsEnabled:    isEnabled,
+		// Claim mappings and just-in-time provisioning
+		ClaimMappings:       claimMappings,
+		ProvisioningMode:    provisioningMode,
+		AllowedEmailDomains: allowedEmailDomains,
+		// Generic OpenID Connect providers
+		IssuerURL: issuerURL,
+		Scopes:    scopes,
+	}
+	if err := config.ValidateProvider(); err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid provider: "+err.Error()+".")
+		return
 	}
-	if err := h.Repo.UpsertOAuthConfig(config); err != nil {
+	if err := h.Repo.UpsertOAuthConfig(config, optlock.Guard{}); err != nil {
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to create OAuth config. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
@@ -1318,16 +1883,35 @@ func (h *GUIHandler) OAuthEditForm(c *gin.Context) {
 		IsEnabled   bool
 		Apps        []AppWithTenant
 		IsEdit      bool
+		Version     string // Row version for optimistic locking (see optlock)
+		// Claim mapping rules as JSON
+		ClaimMappings string
+		// Just-in-time provisioning
+		ProvisioningMode    string
+		AllowedEmailDomains string
+		// Generic OpenID Connect providers
+		IsOIDC    bool
+		IssuerURL string
+		Scopes    string
 	}
 	c.HTML(http.StatusOK, "oauth_form", formData{
-		ID:          config.ID.String(),
-		AppID:       config.AppID.String(),
-		Provider:    config.Provider,
-		ClientID:    config.ClientID,
-		RedirectURL: config.RedirectURL,
-		IsEnabled:   config.IsEnabled,
-		Apps:        apps,
-		IsEdit:      true,
+		ID:            config.ID.String(),
+		AppID:         config.AppID.String(),
+		Provider:      config.Provider,
+		ClientID:      config.ClientID,
+		RedirectURL:   config.RedirectURL,
+		IsEnabled:     config.IsEnabled,
+		Apps:          apps,
+		IsEdit:        true,
+		Version:       optlock.FormatVersion(config.UpdatedAt),
+		ClaimMappings: formatClaimMappings(config.ClaimMappings),
+		// Just-in-time provisioning
+		ProvisioningMode:    config.ProvisioningMode,
+		AllowedEmailDomains: config.AllowedEmailDomains,
+		// Generic OpenID Connect providers
+		IsOIDC:    config.IsGenericOIDC(),
+		IssuerURL: config.IssuerURL,
+		Scopes:    config.Scopes,
 	})
 }
 
@@ -1339,6 +1923,17 @@ func (h *GUIHandler) OAuthUpdate(c *gin.Context) {
 	clientSecret := strings.TrimSpace(c.PostForm("client_secret"))
 	redirectURL := strings.TrimSpace(c.PostForm("redirect_url"))
 	isEnabled := c.PostForm("is_enabled") == "true"
+	claimMappings, err := parseClaimMappings([]byte(c.PostForm("claim_mappings")))
+	if err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid claim mappings: "+err.Error()+".")
+		return
+	}
+	provisioningMode := provisioningModeOrDefault(c.PostForm("provisioning_mode"))
+	if provisioningMode != models.ProvisioningModeAuto && provisioningMode != models.ProvisioningModeExistingOnly {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid provisioning mode.")
+		return
+	}
+	allowedEmailDomains := strings.TrimSpace(c.PostForm("allowed_email_domains"))
 
 	if clientID == "" {
 		c.String(http.StatusBadRequest,
@@ -1350,8 +1945,56 @@ func (h *GUIHandler) OAuthUpdate(c *gin.Context) {
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Redirect URL is required.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
 	}
-
-	if err := h.Repo.UpdateOAuthConfigByID(id, clientID, clientSecret, redirectURL, isEnabled); err != nil {
+	guard, ok := formGuard(c)
+	if !ok {
+		return
+	}
+	current, err := h.Repo.GetOAuthConfigByID(id)
+	if err != nil {
+		renderErrorAlert(c, http.StatusNotFound, "OAuth config not found.")
+		return
+	}
+	update := &models.OAuthProviderConfig{
+		Provider:            current.Provider,
+		ClientID:            clientID,
+		ClientSecret:        clientSecret,
+		RedirectURL:         redirectURL,
+		IsEnabled:           isEnabled,
+		ClaimMappings:       claimMappings,
+		ProvisioningMode:    provisioningMode,
+		AllowedEmailDomains: allowedEmailDomains,
+		// Generic OpenID Connect providers
+		IssuerURL: strings.TrimSpace(c.PostForm("issuer_url")),
+		Scopes:    strings.Join(strings.Fields(c.PostForm("scopes")), " "),
+	}
+	if err := update.ValidateProvider(); err != nil {
+		renderErrorAlert(c, http.StatusBadRequest, "Invalid provider: "+err.Error()+".")
+		return
+	}
+
+	if err := h.Repo.UpdateOAuthConfigByID(id, update, guard); err != nil {
+		if errors.Is(err, optlock.ErrConflict) {
+			if config, loadErr := h.Repo.GetOAuthConfigByID(id); loadErr == nil {
+				renderEditConflict(c, editConflict{
+					What:         "OAuth config",
+					Prefix:       "oauth",
+					ReloadURL:    "/gui/oauth/" + id + "/edit",
+					ReloadTarget: "#oauth-form-container",
+					UpdatedAt:    config.UpdatedAt,
+					Fields: differingFields([]editField{
+						{"Client ID", config.ClientID, clientID},
+						{"Redirect URL", config.RedirectURL, redirectURL},
+						{"Enabled", config.IsEnabled, isEnabled},
+						{"Claim Mappings", formatClaimMappings(config.ClaimMappings), formatClaimMappings(claimMappings)},
+						{"Provisioning", config.ProvisioningMode, provisioningMode},
+						{"Allowed email domains", config.AllowedEmailDomains, allowedEmailDomains},
+						{"Issuer URL", config.IssuerURL, update.IssuerURL},
+						{"Scopes", config.Scopes, update.Scopes},
+					}),
+				})
+				return
+			}
+		}
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update OAuth config. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
@@ -1406,7 +2049,7 @@ func (h *GUIHandler) OAuthDelete(c *gin.Context) {
 	c.Header("HX-Trigger", "oauthDeleted")
 
 	page := 1
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 	configs, total, err := h.Repo.ListOAuthConfigsWithDetails(page, pageSize, "")
 	if err != nil {
 		c.String(http.StatusInternalServerError,
@@ -1450,13 +2093,7 @@ func (h *GUIHandler) OAuthToggleEnabled(c *gin.Context) {
 	}
 
 	// Return the updated toggle HTML fragment
-	if config.IsEnabled {
-		c.String(http.StatusOK,
-			`<div id="toggle-`+id+`" hx-put="/gui/oauth/`+id+`/toggle" hx-target="#toggle-`+id+`" hx-swap="outerHTML" style="cursor: pointer;"><span class="badge bg-success bg-opacity-10 text-success"><i class="bi bi-check-circle-fill me-1"></i>On</span></div>`)
-	} else {
-		c.String(http.StatusOK,
-			`<div id="toggle-`+id+`" hx-put="/gui/oauth/`+id+`/toggle" hx-target="#toggle-`+id+`" hx-swap="outerHTML" style="cursor: pointer;"><span class="badge bg-danger bg-opacity-10 text-danger"><i class="bi bi-x-circle-fill me-1"></i>Off</span></div>`)
-	}
+	c.HTML(http.StatusOK, "oauth_toggle", config)
 }
 
 // --- Helpers ---
@@ -1498,26 +2135,56 @@ func (h *GUIHandler) UserPage(c *gin.Context) {
 		return
 	}
 
+	pendingApprovals, err := h.Repo.CountPendingUsers()
+	if err != nil {
+		pendingApprovals = 0 // Non-critical, only hides the approval queue shortcut
+	}
+
 	c.HTML(http.StatusOK, "users", gin.H{
-		"ActivePage": "users",
-		"AdminUser":  getAdminUsername(c),
-		"CSRFToken":  getCSRFToken(c),
-		"Data":       apps,
+		"ActivePage":       "users",
+		"AdminUser":        getAdminUsername(c),
+		"CSRFToken":        getCSRFToken(c),
+		"Data":             apps,
+		"ViewPrefs":        h.loadListViewPrefs(c, "users"),
+		"PendingApprovals": pendingApprovals,
 	})
 }
 
-// UserList returns the paginated user list partial (HTMX fragment)
+// UserList returns the paginated user list partial (HTMX fragment).
+// Pages are fetched with keyset pagination via the opaque "cursor" param;
+// "page" is only carried along for the "page X of Y" display. A search is
+// ranked by relevance and paginated by "page" instead.
 func (h *GUIHandler) UserList(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
-	}
-	pageSize := 15
+	pageSize := guiPageSize(c, 15)
 
 	appID := c.Query("app_id")
-	search := c.Query("search")
+	search := strings.TrimSpace(c.Query("search"))
+	if search != "" {
+		page := guiListPage(c)
+		users, total, err := h.Repo.SearchUsers(page, pageSize, appUserListQuery(appID), search)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "user_list", gin.H{
+				"Users": nil,
+				"Error": "Failed to search users",
+			})
+			return
+		}
+		totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+		c.HTML(http.StatusOK, "user_list", gin.H{
+			"Users":      users,
+			"Page":       page,
+			"TotalPages": totalPages,
+			"Total":      total,
+			"Cursor":     rankedPage(page, totalPages),
+			"Ranked":     true,
+			"AppID":      appID,
+			"Search":     search,
+		})
+		return
+	}
+	cursor, page := guiListCursor(c)
 
-	users, total, err := h.Repo.ListUsersWithDetails(page, pageSize, appID, search)
+	users, pageInfo, err := h.Repo.ListUsersKeyset(pageSize, appUserListQuery(appID), search, cursor)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "user_list", gin.H{
 			"Users": nil,
@@ -1526,6 +2193,10 @@ func (h *GUIHandler) UserList(c *gin.Context) {
 		return
 	}
 
+	total, err := h.Repo.CountUsers(appUserListQuery(appID), search)
+	if err != nil {
+		total = int64(len(users)) // Non-critical, the list itself loaded
+	}
 	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
 
 	c.HTML(http.StatusOK, "user_list", gin.H{
@@ -1533,11 +2204,37 @@ func (h *GUIHandler) UserList(c *gin.Context) {
 		"Page":       page,
 		"TotalPages": totalPages,
 		"Total":      total,
+		"Cursor":     pageInfo,
 		"AppID":      appID,
 		"Search":     search,
 	})
 }
 
+// guiListCursor reads the keyset cursor and display page number of a GUI list
+// request. An invalid or missing cursor restarts from the first page.
+func guiListCursor(c *gin.Context) (*pagination.Cursor, int) {
+	cursor, err := pagination.Decode(c.Query("cursor"))
+	if err != nil || cursor == nil {
+		return nil, 1
+	}
+	return cursor, guiListPage(c)
+}
+
+// guiListPage reads the page number of an offset-paginated GUI list request.
+func guiListPage(c *gin.Context) int {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	return page
+}
+
+// rankedPage describes the position of an offset-paginated page for the
+// list templates, which render it like a keyset page without cursors.
+func rankedPage(page, totalPages int) pagination.Page {
+	return pagination.Page{HasNext: page < totalPages, HasPrevious: page > 1}
+}
+
 // UserDetail returns the user detail partial (HTMX fragment)
 func (h *GUIHandler) UserDetail(c *gin.Context) {
 	id := c.Param("id")
@@ -1561,6 +2258,13 @@ func (h *GUIHandler) UserDetail(c *gin.Context) {
 		}
 	}
 
+	// Load the per-account and per-IP failed login counters
+	if h.BruteForceService != nil {
+		if status, bfErr := h.BruteForceService.GetThrottleStatus(detail.AppID, detail.Email); bfErr == nil {
+			detail.LoginThrottle = status
+		}
+	}
+
 	c.HTML(http.StatusOK, "user_detail", detail)
 }
 
@@ -1623,54 +2327,62 @@ func (h *GUIHandler) UserToggleActive(c *gin.Context) {
 
 	// If user was deactivated, revoke all their tokens immediately
 	if !newActive {
-		// Blacklist all tokens for this user for 30 days
-		maxTokenLifetime := 30 * 24 * time.Hour
-		if rErr := redis.BlacklistAllUserTokens(appID, id, maxTokenLifetime); rErr != nil {
-			// Log but don't fail the toggle
-			fmt.Printf("Warning: Failed to blacklist tokens for deactivated user %s: %v\n", id, rErr)
-		}
-		// Revoke their current refresh token
-		currentRefreshToken, rErr := redis.GetRefreshToken(appID, id)
-		if rErr == nil && currentRefreshToken != "" {
-			if rErr := redis.RevokeRefreshToken(appID, id, currentRefreshToken); rErr != nil {
-				fmt.Printf("Warning: Failed to revoke refresh token for deactivated user %s: %v\n", id, rErr)
-			}
-		}
+		revokeDeactivatedUserTokens(appID, id)
 	}
 
+	// The HX-Trigger response header refreshes the list so both views stay in sync
+	c.Header("HX-Trigger", "userListRefresh")
+
 	// Return the toggle badge HTML fragment.
 	// HTMX outerHTML swap with hx-target="this" replaces whichever element was clicked.
-	confirmMsg := "Reactivate this user?"
-	toggleLabel := "Inactive"
-	if newActive {
-		confirmMsg = "Deactivate this user? Their sessions will be revoked immediately."
-		toggleLabel = "Active"
+	c.HTML(http.StatusOK, "user_toggle", gin.H{"ID": id, "IsActive": newActive})
+}
+
+// revokeDeactivatedUserTokens blacklists all access tokens of a deactivated user
+// and revokes their current refresh token. Failures are logged only.
+func revokeDeactivatedUserTokens(appID, userID string) {
+	// Blacklist all tokens for this user for 30 days
+	maxTokenLifetime := 30 * 24 * time.Hour
+	if rErr := redis.BlacklistAllUserTokens(appID, userID, maxTokenLifetime); rErr != nil {
+		// Log but don't fail the toggle
+		fmt.Printf("Warning: Failed to blacklist tokens for deactivated user %s: %v\n", userID, rErr)
+	}
+	// Revoke their current refresh token
+	currentRefreshToken, rErr := redis.GetRefreshToken(appID, userID)
+	if rErr == nil && currentRefreshToken != "" {
+		if rErr := redis.RevokeRefreshToken(appID, userID, currentRefreshToken); rErr != nil {
+			fmt.Printf("Warning: Failed to revoke refresh token for deactivated user %s: %v\n", userID, rErr)
+		}
 	}
+}
 
-	// The HX-Trigger response header refreshes the list so both views stay in sync
-	c.Header("HX-Trigger", "userListRefresh")
+// unlockUserAccount clears a user's lockout in the database, resets the Redis
+// brute-force counters (bf may be nil) and logs the unlock event.
+func unlockUserAccount(repo *Repository, bf *bruteforce.Service, id, unlockedBy, method string) error {
+	userEmail, appIDStr, err := repo.UnlockUser(id)
+	if err != nil {
+		return err
+	}
 
-	if newActive {
-		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(
-			`<div hx-put="/gui/users/`+id+`/toggle"`+
-				` hx-target="this"`+
-				` hx-swap="outerHTML"`+
-				` hx-confirm="`+confirmMsg+`"`+
-				` style="cursor: pointer;"`+
-				` title="Click to deactivate">`+
-				`<span class="badge bg-success bg-opacity-10 text-success"><i class="bi bi-check-circle-fill me-1"></i>`+toggleLabel+`</span>`+
-				`</div>`))
-	} else {
-		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(
-			`<div hx-put="/gui/users/`+id+`/toggle"`+
-				` hx-target="this"`+
-				` hx-swap="outerHTML"`+
-				` hx-confirm="`+confirmMsg+`"`+
-				` style="cursor: pointer;"`+
-				` title="Click to activate">`+
-				`<span class="badge bg-danger bg-opacity-10 text-danger"><i class="bi bi-x-circle-fill me-1"></i>`+toggleLabel+`</span>`+
-				`</div>`))
+	appID, parseErr := uuid.Parse(appIDStr)
+	if parseErr != nil {
+		return nil
+	}
+
+	// Reset Redis brute-force counters (lockout tier, delay tier, failure counter)
+	if bf != nil {
+		if userID, userParseErr := uuid.Parse(id); userParseErr == nil {
+			_ = bf.UnlockAccount(appID, userID, userEmail)
+		}
 	}
+
+	// Log the unlock event
+	logService.LogAccountUnlocked(appID, uuid.Nil, "", "", map[string]interface{}{
+		"email":         userEmail,
+		"unlocked_by":   unlockedBy,
+		"unlock_method": method,
+	})
+	return nil
 }
 
 // UserUnlock unlocks a locked user account (HTMX fragment).
@@ -1679,34 +2391,11 @@ func (h *GUIHandler) UserToggleActive(c *gin.Context) {
 func (h *GUIHandler) UserUnlock(c *gin.Context) {
 	id := c.Param("id")
 
-	userEmail, appIDStr, err := h.Repo.UnlockUser(id)
-	if err != nil {
+	if err := unlockUserAccount(h.Repo, h.BruteForceService, id, getAdminUsername(c), "admin_gui"); err != nil {
 		c.String(http.StatusInternalServerError, "Failed to unlock user account")
 		return
 	}
 
-	// Reset Redis brute-force counters (lockout tier, delay tier, failure counter)
-	if h.BruteForceService != nil {
-		appID, parseErr := uuid.Parse(appIDStr)
-		if parseErr == nil {
-			userID, userParseErr := uuid.Parse(id)
-			if userParseErr == nil {
-				_ = h.BruteForceService.UnlockAccount(appID, userID, userEmail)
-			}
-		}
-	}
-
-	// Log the unlock event
-	appID, parseErr := uuid.Parse(appIDStr)
-	if parseErr == nil {
-		adminUser := getAdminUsername(c)
-		logService.LogAccountUnlocked(appID, uuid.Nil, "", "", map[string]interface{}{
-			"email":         userEmail,
-			"unlocked_by":   adminUser,
-			"unlock_method": "admin_gui",
-		})
-	}
-
 	// Return an inline success message — the HX-Trigger will refresh the detail view
 	c.Header("HX-Trigger", "userDetailRefresh")
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(
@@ -1749,26 +2438,56 @@ func (h *GUIHandler) LogsPage(c *gin.Context) {
 		"Apps":       apps,
 		"EventTypes": eventTypes,
 		"Severities": severities,
+		"ViewPrefs":  h.loadListViewPrefs(c, "logs"),
 	})
 }
 
 // LogList returns the paginated activity log list partial (HTMX fragment).
+// Uses keyset pagination like UserList, and ranked results for a search.
 // GET /gui/logs/list
 func (h *GUIHandler) LogList(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
-	}
-	pageSize := 20
+	pageSize := guiPageSize(c, 20)
 
 	eventType := c.Query("event_type")
 	severity := c.Query("severity")
 	appID := c.Query("app_id")
-	search := c.Query("search")
+	search := strings.TrimSpace(c.Query("search"))
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
+	since := c.Query("since")
+	fromDate := resolveLogSince(since, startDate)
+
+	if search != "" {
+		page := guiListPage(c)
+		logs, total, err := h.Repo.SearchActivityLogs(page, pageSize, eventType, severity, appID, search, fromDate, endDate)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "activity_log_list", gin.H{
+				"Logs":  nil,
+				"Error": "Failed to search activity logs",
+			})
+			return
+		}
+		totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+		c.HTML(http.StatusOK, "activity_log_list", gin.H{
+			"Logs":       logs,
+			"Page":       page,
+			"TotalPages": totalPages,
+			"Total":      total,
+			"Cursor":     rankedPage(page, totalPages),
+			"Ranked":     true,
+			"EventType":  eventType,
+			"Severity":   severity,
+			"AppID":      appID,
+			"Search":     search,
+			"StartDate":  startDate,
+			"EndDate":    endDate,
+			"Since":      since,
+		})
+		return
+	}
+	cursor, page := guiListCursor(c)
 
-	logs, total, err := h.Repo.ListActivityLogs(page, pageSize, eventType, severity, appID, search, startDate, endDate)
+	logs, pageInfo, err := h.Repo.ListActivityLogsKeyset(pageSize, eventType, severity, appID, search, fromDate, endDate, cursor)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "activity_log_list", gin.H{
 			"Logs":  nil,
@@ -1777,6 +2496,10 @@ func (h *GUIHandler) LogList(c *gin.Context) {
 		return
 	}
 
+	total, err := h.Repo.CountActivityLogs(eventType, severity, appID, search, fromDate, endDate)
+	if err != nil {
+		total = int64(len(logs)) // Non-critical, the list itself loaded
+	}
 	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
 
 	c.HTML(http.StatusOK, "activity_log_list", gin.H{
@@ -1784,15 +2507,36 @@ func (h *GUIHandler) LogList(c *gin.Context) {
 		"Page":       page,
 		"TotalPages": totalPages,
 		"Total":      total,
+		"Cursor":     pageInfo,
 		"EventType":  eventType,
 		"Severity":   severity,
 		"AppID":      appID,
 		"Search":     search,
 		"StartDate":  startDate,
 		"EndDate":    endDate,
+		"Since":      since,
 	})
 }
 
+// logSinceWindows maps the relative "since" filter values accepted by the log
+// list and export endpoints to their durations.
+var logSinceWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// resolveLogSince returns the effective lower time bound for a log query.
+// A recognised relative window ("24h", "7d", ...) takes precedence over the
+// start_date filter so saved filters like "last 24h" stay relative to now.
+func resolveLogSince(since, startDate string) string {
+	if d, ok := logSinceWindows[since]; ok {
+		return time.Now().UTC().Add(-d).Format(time.RFC3339)
+	}
+	return startDate
+}
+
 // LogDetail returns the activity log detail partial (HTMX fragment).
 // GET /gui/logs/:id
 func (h *GUIHandler) LogDetail(c *gin.Context) {
@@ -1824,7 +2568,7 @@ func (h *GUIHandler) LogExport(c *gin.Context) {
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 
-	items, truncated, err := h.Repo.ExportActivityLogs(eventType, severity, appID, search, startDate, endDate)
+	items, truncated, err := h.Repo.ExportActivityLogs(eventType, severity, appID, search, resolveLogSince(c.Query("since"), startDate), endDate)
 	if err != nil {
 		c.String(http.StatusInternalServerError, "Failed to export activity logs")
 		return
@@ -1908,6 +2652,18 @@ func getCSRFToken(c *gin.Context) string {
 	return ""
 }
 
+// safeGUIRedirect returns redirect if it is a local path (e.g. "/gui/users"),
+// or "" otherwise, so the login flow cannot be used as an open redirect.
+func safeGUIRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return ""
+	}
+	if u, err := url.Parse(redirect); err != nil || u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+	return redirect
+}
+
 // sessionMaxAgeSeconds returns the session cookie max age in seconds
 func sessionMaxAgeSeconds() int {
 	hours := viper.GetInt("ADMIN_SESSION_EXPIRATION_HOURS")
@@ -1938,7 +2694,7 @@ func (h *GUIHandler) ApiKeyList(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 20
+	pageSize := guiPageSize(c, 20)
 
 	keyType := c.Query("key_type")
 
@@ -2024,6 +2780,15 @@ func (h *GUIHandler) ApiKeyCreate(c *gin.Context) {
 			return
 		}
 		appName = app.Name
+
+		if err := quota.CheckAppApiKeys(h.Repo.DB, parsedID); err != nil {
+			msg := "Failed to check the application's API key quota."
+			if errors.Is(err, quota.ErrExceeded) {
+				msg = "Cannot create API key: " + err.Error() + ". Revoke an unused key or raise the quota."
+			}
+			renderErrorAlert(c, http.StatusForbidden, msg)
+			return
+		}
 	}
 
 	// Parse optional expiration
@@ -2122,7 +2887,7 @@ func (h *GUIHandler) ApiKeyRevoke(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 20
+	pageSize := guiPageSize(c, 20)
 	keyType := c.Query("key_type")
 
 	keys, total, err := h.Repo.ListApiKeys(page, pageSize, keyType)
@@ -2181,7 +2946,7 @@ func (h *GUIHandler) ApiKeyDelete(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 20
+	pageSize := guiPageSize(c, 20)
 	keyType := c.Query("key_type")
 
 	keys, total, err := h.Repo.ListApiKeys(page, pageSize, keyType)
@@ -2343,8 +3108,7 @@ func (h *GUIHandler) SettingsSection(c *gin.Context) {
 	categorySlug := c.Param("category")
 	category, err := h.SettingsService.ResolveCategorySettings(categorySlug)
 	if err != nil {
-		c.String(http.StatusBadRequest,
-			`<div class="alert alert-danger m-3 small">Failed to load settings: %s</div>`, err.Error())
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: "Failed to load settings: " + err.Error(), Class: "m-3 small"})
 		return
 	}
 	c.HTML(http.StatusOK, "settings_section", category)
@@ -2375,9 +3139,8 @@ func (h *GUIHandler) SettingUpdate(c *gin.Context) {
 
 	// Save
 	if err := h.SettingsService.UpdateSetting(key, value); err != nil {
-		c.Header("HX-Trigger", fmt.Sprintf(`{"settingError": {"message": "%s"}}`, err.Error()))
-		c.String(http.StatusBadRequest,
-			`<div class="alert alert-danger small py-2 mb-0">%s</div>`, err.Error())
+		setHXTriggerEvent(c, "settingError", gin.H{"message": err.Error()})
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error(), Class: "small py-2 mb-0"})
 		return
 	}
 
@@ -2403,6 +3166,18 @@ func (h *GUIHandler) SettingUpdate(c *gin.Context) {
 	c.String(http.StatusOK, `<div class="alert alert-success small py-2 mb-0">Setting saved.</div>`)
 }
 
+// SettingImpactPreview validates a new value for a setting and shows what
+// saving it would do, before it is applied.
+// POST /gui/settings/:key/preview
+func (h *GUIHandler) SettingImpactPreview(c *gin.Context) {
+	preview, err := h.SettingsService.PreviewSetting(c.Param("key"), c.PostForm("value"))
+	if err != nil {
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error(), Class: "small py-2 mb-0 mt-2"})
+		return
+	}
+	c.HTML(http.StatusOK, "settings_preview", preview)
+}
+
 // SettingReset removes the DB override for a setting (reverts to env/default).
 // DELETE /gui/settings/:key
 func (h *GUIHandler) SettingReset(c *gin.Context) {
@@ -2417,7 +3192,7 @@ func (h *GUIHandler) SettingReset(c *gin.Context) {
 	}
 
 	if err := h.SettingsService.ResetSetting(key); err != nil {
-		c.Header("HX-Trigger", fmt.Sprintf(`{"settingError": {"message": "%s"}}`, err.Error()))
+		setHXTriggerEvent(c, "settingError", gin.H{"message": err.Error()})
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger small py-2 mb-0">Failed to reset setting.</div>`)
 		return
@@ -2610,6 +3385,10 @@ func (h *GUIHandler) EmailServerCreate(c *gin.Context) {
 	}
 
 	if err := h.EmailService.SaveServerConfig(config); err != nil {
+		if errors.Is(err, email.ErrUnverifiedSenderDomain) {
+			renderErrorAlert(c, http.StatusBadRequest, err.Error()+". Register and verify the domain with the tenant's sending domains (/admin/tenants/{id}/email-domains) first.")
+			return
+		}
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to save SMTP config. Please try again.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
@@ -2741,6 +3520,10 @@ func (h *GUIHandler) EmailServerUpdate(c *gin.Context) {
 	config.ID = id
 
 	if err := h.EmailService.SaveServerConfig(config); err != nil {
+		if errors.Is(err, email.ErrUnverifiedSenderDomain) {
+			renderErrorAlert(c, http.StatusBadRequest, err.Error()+". Register and verify the domain with the tenant's sending domains (/admin/tenants/{id}/email-domains) first.")
+			return
+		}
 		c.String(http.StatusInternalServerError,
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update SMTP config.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
@@ -2814,13 +3597,15 @@ func (h *GUIHandler) EmailServerSendTest(c *gin.Context) {
 
 	if err := h.EmailService.SendTestEmailWithConfigID(configID, toEmail); err != nil {
 		friendlyMsg := formatSMTPError(err.Error())
-		c.String(http.StatusOK,
-			fmt.Sprintf(`<div class="alert alert-danger alert-dismissible fade show mb-0" role="alert"><i class="bi bi-exclamation-triangle me-2"></i><strong>Send failed:</strong> %s<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`, friendlyMsg))
+		renderAlert(c, http.StatusOK, alertFragment{
+			Kind: "danger", Icon: "bi-exclamation-triangle", Title: "Send failed:", Message: friendlyMsg, Class: "mb-0", Dismissible: true,
+		})
 		return
 	}
 
-	c.String(http.StatusOK,
-		fmt.Sprintf(`<div class="alert alert-success alert-dismissible fade show mb-0" role="alert"><i class="bi bi-check-circle me-2"></i>Test email sent to <strong>%s</strong> successfully!</div>`, toEmail))
+	renderAlert(c, http.StatusOK, alertFragment{
+		Kind: "success", Icon: "bi-check-circle", Message: "Test email sent to " + toEmail + " successfully!", Class: "mb-0", Dismissible: true,
+	})
 }
 
 // resolveServerConfigDisplay resolves a server config ID to its display string and name.
@@ -3087,9 +3872,12 @@ func (h *GUIHandler) EmailTemplateCreate(c *gin.Context) {
 		IsActive:       isActive,
 	}
 
+	// Lint problems are reported after saving; they do not block the save
+	warnings, _ := h.EmailService.LintTemplate(tmpl, emailTypeID)
+
 	if appIDStr == "" {
 		// Global default
-		if err := h.EmailService.SaveGlobalTemplate(emailTypeID, tmpl); err != nil {
+		if err := h.EmailService.SaveGlobalTemplate(emailTypeID, tmpl, optlock.Guard{}); err != nil {
 			c.String(http.StatusInternalServerError,
 				`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to save template.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 			return
@@ -3101,16 +3889,28 @@ func (h *GUIHandler) EmailTemplateCreate(c *gin.Context) {
 				`<div class="alert alert-danger alert-dismissible fade show" role="alert">Invalid application ID.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 			return
 		}
-		if err := h.EmailService.SaveAppTemplate(appID, emailTypeID, tmpl); err != nil {
+		if err := h.EmailService.SaveAppTemplate(appID, emailTypeID, tmpl, optlock.Guard{}); err != nil {
 			c.String(http.StatusInternalServerError,
 				`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to save template.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 			return
 		}
 	}
 
+	renderEmailTemplateSaved(c, "Email template created successfully.", warnings)
+}
+
+// renderEmailTemplateSaved answers a successful template save and lists the
+// template's lint warnings, if any.
+func renderEmailTemplateSaved(c *gin.Context, message string, warnings []email.LintWarning) {
 	c.Header("HX-Trigger", "emailTemplateListRefresh")
-	c.String(http.StatusOK,
-		`<div class="alert alert-success alert-dismissible fade show" role="alert">Email template created successfully.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+	if len(warnings) == 0 {
+		renderAlert(c, http.StatusOK, alertFragment{Kind: "success", Message: message, Dismissible: true})
+		return
+	}
+	c.HTML(http.StatusOK, "email_template_warnings", gin.H{
+		"Message":  message,
+		"Warnings": warnings,
+	})
 }
 
 // EmailTemplateEditForm returns the pre-filled edit form for an email template.
@@ -3159,6 +3959,7 @@ func (h *GUIHandler) EmailTemplateEditForm(c *gin.Context) {
 		"FromName":       tmpl.FromName,
 		"ServerConfigID": serverConfigIDStr,
 		"IsActive":       tmpl.IsActive,
+		"Version":        optlock.FormatVersion(tmpl.UpdatedAt),
 		"Apps":           apps,
 		"EmailTypes":     emailTypes,
 		"ServerConfigs":  serverConfigs,
@@ -3198,6 +3999,10 @@ func (h *GUIHandler) EmailTemplateUpdate(c *gin.Context) {
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Name and subject are required.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
 	}
+	guard, ok := formGuard(c)
+	if !ok {
+		return
+	}
 
 	tmpl.Name = name
 	tmpl.Subject = subject
@@ -3218,23 +4023,39 @@ func (h *GUIHandler) EmailTemplateUpdate(c *gin.Context) {
 	}
 	tmpl.IsActive = isActive
 
-	if tmpl.AppID == nil {
-		if err := h.EmailService.SaveGlobalTemplate(tmpl.EmailTypeID, tmpl); err != nil {
-			c.String(http.StatusInternalServerError,
-				`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update template.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
-			return
-		}
-	} else {
-		if err := h.EmailService.SaveAppTemplate(*tmpl.AppID, tmpl.EmailTypeID, tmpl); err != nil {
-			c.String(http.StatusInternalServerError,
-				`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update template.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
-			return
+	// Lint problems are reported after saving; they do not block the save
+	warnings, _ := h.EmailService.LintTemplate(tmpl, tmpl.EmailTypeID)
+
+	if err := h.EmailService.UpdateTemplate(tmpl, guard); err != nil {
+		if errors.Is(err, optlock.ErrConflict) {
+			if stored, loadErr := h.EmailService.GetTemplateByID(id); loadErr == nil && stored != nil {
+				renderEditConflict(c, editConflict{
+					What:         "email template",
+					Prefix:       "email-template",
+					ReloadURL:    "/gui/email-templates/" + id.String() + "/edit",
+					ReloadTarget: "#email-template-form-container",
+					UpdatedAt:    stored.UpdatedAt,
+					Fields: differingFields([]editField{
+						{"Name", stored.Name, tmpl.Name},
+						{"Subject", stored.Subject, tmpl.Subject},
+						{"HTML body", stored.BodyHTML, tmpl.BodyHTML},
+						{"Text body", stored.BodyText, tmpl.BodyText},
+						{"Template engine", stored.TemplateEngine, tmpl.TemplateEngine},
+						{"From email", stored.FromEmail, tmpl.FromEmail},
+						{"From name", stored.FromName, tmpl.FromName},
+						{"Email server", optionalUUID(stored.ServerConfigID), optionalUUID(tmpl.ServerConfigID)},
+						{"Active", stored.IsActive, tmpl.IsActive},
+					}),
+				})
+				return
+			}
 		}
+		c.String(http.StatusInternalServerError,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update template.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
 	}
 
-	c.Header("HX-Trigger", "emailTemplateListRefresh")
-	c.String(http.StatusOK,
-		`<div class="alert alert-success alert-dismissible fade show" role="alert">Email template updated successfully.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+	renderEmailTemplateSaved(c, "Email template updated successfully.", warnings)
 }
 
 // EmailTemplateDeleteConfirm returns the delete confirmation modal body.
@@ -3328,8 +4149,7 @@ func (h *GUIHandler) EmailTemplateReset(c *gin.Context) {
 	}
 
 	if err := h.EmailService.ResetTemplateToDefault(id); err != nil {
-		c.String(http.StatusInternalServerError,
-			fmt.Sprintf(`<div class="alert alert-danger alert-dismissible fade show" role="alert">%s<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`, err.Error()))
+		renderErrorAlert(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -3348,6 +4168,45 @@ func (h *GUIHandler) EmailVariablesList(c *gin.Context) {
 	c.JSON(http.StatusOK, variables)
 }
 
+// EmailTemplateVariables renders the insert-variable panel of the template form:
+// the selected email type's variables and the well-known ones, in the syntax of
+// the selected template engine.
+// GET /gui/email-templates/variables
+func (h *GUIHandler) EmailTemplateVariables(c *gin.Context) {
+	var emailType *models.EmailType
+	if idStr := c.Query("email_type_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err == nil {
+			emailType, err = h.EmailService.GetEmailTypeByID(id)
+		}
+		if err != nil || emailType == nil {
+			renderAlert(c, http.StatusNotFound, alertFragment{Kind: "warning", Message: "Email type not found.", Class: "py-2 small"})
+			return
+		}
+	}
+
+	vars, err := email.TypeVariables(emailType)
+	if err != nil {
+		renderAlert(c, http.StatusInternalServerError, alertFragment{Kind: "danger", Message: "Failed to read the variables of this email type.", Detail: err.Error(), Class: "py-2 small"})
+		return
+	}
+
+	engine := c.Query("template_engine")
+	if engine == "" {
+		engine = models.TemplateEngineGoTemplate
+	}
+
+	typeName := ""
+	if emailType != nil {
+		typeName = emailType.Name
+	}
+	c.HTML(http.StatusOK, "email_template_variables", gin.H{
+		"Variables": vars,
+		"Engine":    engine,
+		"TypeName":  typeName,
+	})
+}
+
 // EmailTemplateFormCancel clears the form container.
 // Also clears the preview container via HTMX out-of-band swap.
 // GET /gui/email-templates/form-cancel
@@ -3371,78 +4230,52 @@ func (h *GUIHandler) EmailTemplatePreview(c *gin.Context) {
 		TemplateEngine: templateEngine,
 	}
 
-	// Use sample variables for preview
-	sampleVars := map[string]string{
-		"app_name":           "My Application",
-		"user_email":         "user@example.com",
-		"user_name":          "John Doe",
-		"verification_link":  "https://example.com/verify?token=abc123",
-		"verification_token": "abc123",
-		"reset_link":         "https://example.com/reset?token=xyz789",
-		"code":               "123456",
-		"expiration_minutes": "5",
-		"change_time":        "2026-02-22 10:30:00 UTC",
-	}
-
-	renderedSubject, renderedHTML, _, err := h.EmailService.PreviewTemplate(tmpl, sampleVars)
-	if err != nil {
-		c.String(http.StatusOK,
-			fmt.Sprintf(`<div class="alert alert-danger">Preview error: %s</div>`, err.Error()))
-		return
+	// Use sample variables for preview, or a real user's data when requested
+	var renderedSubject, renderedHTML string
+	if userIDStr := strings.TrimSpace(c.PostForm("preview_user_id")); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			renderAlert(c, http.StatusOK, alertFragment{Kind: "danger", Message: "Invalid user ID"})
+			return
+		}
+		resp, err := previewEmailAsUser(c, h.EmailService, tmpl, userID, email.SampleExplicitVariables(), getAdminUsername(c))
+		if errors.Is(err, email.ErrPreviewUserNotFound) {
+			renderAlert(c, http.StatusOK, alertFragment{Kind: "danger", Message: "User not found"})
+			return
+		}
+		if err != nil {
+			renderAlert(c, http.StatusOK, alertFragment{Kind: "danger", Message: "Preview error: " + err.Error()})
+			return
+		}
+		renderedSubject, renderedHTML = resp.Subject, resp.BodyHTML
+	} else {
+		var err error
+		renderedSubject, renderedHTML, _, err = h.EmailService.PreviewTemplate(tmpl, email.SampleVariables())
+		if err != nil {
+			renderAlert(c, http.StatusOK, alertFragment{Kind: "danger", Message: "Preview error: " + err.Error()})
+			return
+		}
 	}
 
 	// Check if full standalone HTML page is requested (for new window preview)
 	full := c.Query("full")
 	if full == "1" {
 		// Return complete standalone HTML page for new window preview
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.String(http.StatusOK, fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Email Preview: %s</title>
-    <style>
-        body { margin: 0; padding: 20px; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif; }
-        .preview-header { background: #f8f9fa; padding: 15px; border-bottom: 1px solid #dee2e6; margin: -20px -20px 20px -20px; }
-        .preview-subject { font-size: 1.2em; font-weight: bold; color: #495057; }
-        .preview-label { font-size: 0.9em; color: #6c757d; margin-right: 5px; }
-        .preview-content { max-width: 800px; margin: 0 auto; }
-    </style>
-</head>
-<body>
-    <div class="preview-header">
-        <div class="preview-content">
-            <span class="preview-label">Subject:</span>
-            <span class="preview-subject">%s</span>
-        </div>
-    </div>
-    <div class="preview-content">
-        %s
-    </div>
-</body>
-</html>`, renderedSubject, renderedSubject, renderedHTML))
-		return
-	}
-
-	// For split view preview: escape only quotes for srcdoc attribute
-	// HTML entities in the content should remain as-is
-	escapedHTML := strings.ReplaceAll(renderedHTML, `"`, `&quot;`)
-
-	c.String(http.StatusOK, fmt.Sprintf(`
-<div class="card border-0 shadow-sm">
-    <div class="card-header bg-body-tertiary d-flex align-items-center justify-content-between">
-        <span><small class="text-muted">Subject:</small> <strong>%s</strong></span>
-        <button type="button" class="btn btn-sm btn-outline-secondary"
-                onclick="document.getElementById('email-template-preview-container').innerHTML=''"
-                aria-label="Close preview">
-            <i class="bi bi-x-lg me-1"></i>Close Preview
-        </button>
-    </div>
-    <div class="card-body p-0">
-        <iframe srcdoc="%s" style="width:100%%;min-height:400px;border:none;" sandbox="allow-same-origin allow-scripts allow-forms allow-popups"></iframe>
-    </div>
-</div>`, renderedSubject, escapedHTML))
+		// The rendered body is the admin's own template output and is shown as is;
+		// only the subject is escaped.
+		c.HTML(http.StatusOK, "email_template_preview_window", gin.H{
+			"Subject":  renderedSubject,
+			"BodyHTML": renderedHTML,
+		})
+		return
+	}
+
+	// For split view preview: the body goes into the iframe's srcdoc attribute,
+	// where the template's attribute escaping is undone by the browser
+	c.HTML(http.StatusOK, "email_template_preview", gin.H{
+		"Subject":  renderedSubject,
+		"BodyHTML": renderedHTML,
+	})
 }
 
 // EmailTemplateEditorWindow renders a standalone editor window with split editor/preview.
@@ -3509,6 +4342,7 @@ func (h *GUIHandler) EmailTypeList(c *gin.Context) {
 		Name           string
 		Description    string
 		DefaultSubject string
+		Category       string
 		IsSystem       bool
 		IsActive       bool
 		VarCount       int
@@ -3529,6 +4363,7 @@ func (h *GUIHandler) EmailTypeList(c *gin.Context) {
 			Name:           t.Name,
 			Description:    t.Description,
 			DefaultSubject: t.DefaultSubject,
+			Category:       t.Category,
 			IsSystem:       t.IsSystem,
 			IsActive:       t.IsActive,
 			VarCount:       varCount,
@@ -3546,6 +4381,7 @@ func (h *GUIHandler) EmailTypeCreateForm(c *gin.Context) {
 	c.HTML(http.StatusOK, "email_type_form", gin.H{
 		"IsEdit":             false,
 		"IsActive":           true,
+		"Category":           models.EmailCategoryTransactional,
 		"WellKnownVariables": email.WellKnownVariables,
 	})
 }
@@ -3557,6 +4393,7 @@ func (h *GUIHandler) EmailTypeCreate(c *gin.Context) {
 	name := strings.TrimSpace(c.PostForm("name"))
 	description := strings.TrimSpace(c.PostForm("description"))
 	defaultSubject := strings.TrimSpace(c.PostForm("default_subject"))
+	category := c.DefaultPostForm("category", models.EmailCategoryTransactional)
 	isActive := c.PostForm("is_active") == "true"
 
 	if code == "" || name == "" {
@@ -3564,6 +4401,11 @@ func (h *GUIHandler) EmailTypeCreate(c *gin.Context) {
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Code and Name are required.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
 	}
+	if !models.IsValidEmailCategory(category) {
+		c.String(http.StatusBadRequest,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Invalid category.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
 
 	// Check for duplicate code
 	existing, _ := h.EmailService.GetEmailTypeByCode(code)
@@ -3581,6 +4423,7 @@ func (h *GUIHandler) EmailTypeCreate(c *gin.Context) {
 		Name:           name,
 		Description:    description,
 		DefaultSubject: defaultSubject,
+		Category:       category,
 		Variables:      varsJSON,
 		IsSystem:       false,
 		IsActive:       isActive,
@@ -3628,6 +4471,7 @@ func (h *GUIHandler) EmailTypeEditForm(c *gin.Context) {
 		"Name":               emailType.Name,
 		"Description":        emailType.Description,
 		"DefaultSubject":     emailType.DefaultSubject,
+		"Category":           emailType.Category,
 		"IsSystem":           emailType.IsSystem,
 		"IsActive":           emailType.IsActive,
 		"Variables":          vars,
@@ -3656,6 +4500,7 @@ func (h *GUIHandler) EmailTypeUpdate(c *gin.Context) {
 	name := strings.TrimSpace(c.PostForm("name"))
 	description := strings.TrimSpace(c.PostForm("description"))
 	defaultSubject := strings.TrimSpace(c.PostForm("default_subject"))
+	category := c.DefaultPostForm("category", emailType.Category)
 	isActive := c.PostForm("is_active") == "true"
 
 	if name == "" {
@@ -3663,10 +4508,16 @@ func (h *GUIHandler) EmailTypeUpdate(c *gin.Context) {
 			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Name is required.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 		return
 	}
+	if !models.IsValidEmailCategory(category) {
+		c.String(http.StatusBadRequest,
+			`<div class="alert alert-danger alert-dismissible fade show" role="alert">Invalid category.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+		return
+	}
 
 	emailType.Name = name
 	emailType.Description = description
 	emailType.DefaultSubject = defaultSubject
+	emailType.Category = category
 	emailType.IsActive = isActive
 	emailType.Variables = parseVariablesFromForm(c)
 
@@ -3724,8 +4575,7 @@ func (h *GUIHandler) EmailTypeDelete(c *gin.Context) {
 	}
 
 	if err := h.EmailService.DeleteEmailType(id); err != nil {
-		c.String(http.StatusBadRequest,
-			fmt.Sprintf(`<div class="alert alert-danger">%s</div>`, err.Error()))
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error()})
 		return
 	}
 
@@ -3779,7 +4629,7 @@ func (h *GUIHandler) TwoFAVerifySubmit(c *gin.Context) {
 	tempToken := c.PostForm("temp_token")
 	code := c.PostForm("code")
 	method := c.PostForm("method")
-	redirect := c.PostForm("redirect")
+	redirect := safeGUIRedirect(c.PostForm("redirect"))
 	useRecovery := c.PostForm("use_recovery") == "true"
 
 	if tempToken == "" || code == "" {
@@ -3941,13 +4791,11 @@ func (h *GUIHandler) MyAccountUpdateEmail(c *gin.Context) {
 		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
 			msg = "This email address is already in use."
 		}
-		c.String(http.StatusBadRequest,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, msg))
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: msg, Class: "py-2 small"})
 		return
 	}
 
-	c.String(http.StatusOK,
-		fmt.Sprintf(`<div class="alert alert-success py-2"><small>Email updated to %s.</small></div>`, email))
+	renderAlert(c, http.StatusOK, alertFragment{Kind: "success", Message: "Email updated to " + email + ".", Class: "py-2 small"})
 }
 
 // MyAccountChangePassword handles password changes.
@@ -3977,8 +4825,7 @@ func (h *GUIHandler) MyAccountChangePassword(c *gin.Context) {
 	}
 
 	if err := h.AccountService.ChangePassword(adminID, currentPassword, newPassword); err != nil {
-		c.String(http.StatusBadRequest,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, err.Error()))
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error(), Class: "py-2 small"})
 		return
 	}
 
@@ -3995,8 +4842,7 @@ func (h *GUIHandler) MyAccount2FAGenerateTOTP(c *gin.Context) {
 
 	setup, err := h.AccountService.GenerateTOTPSecret(adminID, username)
 	if err != nil {
-		c.String(http.StatusInternalServerError,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, err.Error()))
+		renderAlert(c, http.StatusInternalServerError, alertFragment{Kind: "danger", Message: err.Error(), Class: "py-2 small"})
 		return
 	}
 
@@ -4026,16 +4872,14 @@ func (h *GUIHandler) MyAccount2FAVerifyTOTP(c *gin.Context) {
 	}
 
 	if err := h.AccountService.VerifyTOTPSetup(adminID, code); err != nil {
-		c.String(http.StatusBadRequest,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, err.Error()))
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error(), Class: "py-2 small"})
 		return
 	}
 
 	// Verification succeeded — enable TOTP and return recovery codes
 	recoveryCodes, err := h.AccountService.EnableTOTP(adminID)
 	if err != nil {
-		c.String(http.StatusInternalServerError,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, err.Error()))
+		renderAlert(c, http.StatusInternalServerError, alertFragment{Kind: "danger", Message: err.Error(), Class: "py-2 small"})
 		return
 	}
 
@@ -4059,8 +4903,7 @@ func (h *GUIHandler) MyAccount2FAEnableEmail(c *gin.Context) {
 
 	recoveryCodes, err := h.AccountService.EnableEmail2FA(adminID)
 	if err != nil {
-		c.String(http.StatusBadRequest,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, err.Error()))
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error(), Class: "py-2 small"})
 		return
 	}
 
@@ -4089,8 +4932,7 @@ func (h *GUIHandler) MyAccount2FADisable(c *gin.Context) {
 	}
 
 	if err := h.AccountService.Disable2FA(adminID, password); err != nil {
-		c.String(http.StatusBadRequest,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, err.Error()))
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error(), Class: "py-2 small"})
 		return
 	}
 
@@ -4145,8 +4987,7 @@ func (h *GUIHandler) MyAccount2FARegenerateCodes(c *gin.Context) {
 
 	codes, err := h.AccountService.RegenerateRecoveryCodes(adminID, password)
 	if err != nil {
-		c.String(http.StatusBadRequest,
-			fmt.Sprintf(`<div class="alert alert-danger py-2"><small>%s</small></div>`, err.Error()))
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: err.Error(), Class: "py-2 small"})
 		return
 	}
 
@@ -4272,8 +5113,7 @@ func (h *GUIHandler) MyAccountPasskeyDelete(c *gin.Context) {
 
 	appErr := h.PasskeyService.DeleteAdminCredential(adminUUID, credUUID)
 	if appErr != nil {
-		c.String(appErr.Code, fmt.Sprintf(
-			`<div class="alert alert-danger py-2"><small>%s</small></div>`, appErr.Message))
+		renderAlert(c, appErr.Code, alertFragment{Kind: "danger", Message: appErr.Message, Class: "py-2 small"})
 		return
 	}
 
@@ -4457,8 +5297,7 @@ func (h *GUIHandler) RoleUpdate(c *gin.Context) {
 	}
 
 	if err := h.RBACService.UpdateRole(id, name, description); err != nil {
-		c.String(http.StatusInternalServerError,
-			fmt.Sprintf(`<div class="alert alert-danger alert-dismissible fade show" role="alert">Failed to update role: %s<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`, err.Error()))
+		renderErrorAlert(c, http.StatusInternalServerError, "Failed to update role: "+err.Error())
 		return
 	}
 
@@ -4502,8 +5341,7 @@ func (h *GUIHandler) RoleDelete(c *gin.Context) {
 	appID := role.AppID.String()
 
 	if err := h.RBACService.DeleteRole(id); err != nil {
-		c.String(http.StatusInternalServerError,
-			fmt.Sprintf(`<div class="alert alert-danger">Failed to delete role: %s</div>`, err.Error()))
+		renderAlert(c, http.StatusInternalServerError, alertFragment{Kind: "danger", Message: "Failed to delete role: " + err.Error()})
 		return
 	}
 
@@ -4771,7 +5609,7 @@ func (h *GUIHandler) UserRoleCreate(c *gin.Context) {
 	}
 
 	// Signal the page to refresh the user-role table for the assigned app.
-	c.Header("HX-Trigger", fmt.Sprintf(`{"userRoleAssigned":{"appID":"%s"}}`, appID))
+	setHXTriggerEvent(c, "userRoleAssigned", gin.H{"appID": appID})
 	c.String(http.StatusOK,
 		`<div class="alert alert-success alert-dismissible fade show" role="alert">Role assigned successfully.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
 }
@@ -4820,7 +5658,7 @@ func (h *GUIHandler) renderUserRoleList(c *gin.Context, appID string, page int)
 	if page < 1 {
 		page = 1
 	}
-	const pageSize = 20
+	pageSize := guiPageSize(c, 20)
 
 	items, total, err := h.RBACService.Repo.GetUsersWithRoleInApp(appID, page, pageSize)
 	if err != nil {
@@ -4870,12 +5708,7 @@ func (h *GUIHandler) UserRoleRolesForApp(c *gin.Context) {
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString(`<option value="">-- Select Role --</option>`)
-	for _, r := range roles {
-		sb.WriteString(fmt.Sprintf(`<option value="%s">%s</option>`, r.ID.String(), r.Name))
-	}
-	c.String(http.StatusOK, sb.String())
+	c.HTML(http.StatusOK, "role_options", roles)
 }
 
 // UserRoleSearchUsers returns a list of matching users as clickable HTML items.
@@ -4904,31 +5737,7 @@ func (h *GUIHandler) UserRoleSearchUsers(c *gin.Context) {
 		return
 	}
 
-	var sb strings.Builder
-	for _, u := range users {
-		name := u.Email
-		if u.Name != "" {
-			name = u.Name + " &mdash; " + u.Email
-		}
-		sb.WriteString(fmt.Sprintf(
-			`<a href="#" class="list-group-item list-group-item-action py-2 px-3" onclick="selectUser('%s','%s'); return false;">
-				<div class="fw-semibold small">%s</div>
-				<div class="text-muted font-monospace" style="font-size:.7rem">%s</div>
-			</a>`,
-			u.ID.String(), escapeHTML(u.Email), name, u.ID.String(),
-		))
-	}
-	c.String(http.StatusOK, sb.String())
-}
-
-// escapeHTML is a minimal HTML entity escaper for dynamic string insertion.
-func escapeHTML(s string) string {
-	s = strings.ReplaceAll(s, "&", "&amp;")
-	s = strings.ReplaceAll(s, "<", "&lt;")
-	s = strings.ReplaceAll(s, ">", "&gt;")
-	s = strings.ReplaceAll(s, `"`, "&quot;")
-	s = strings.ReplaceAll(s, "'", "&#39;")
-	return s
+	c.HTML(http.StatusOK, "user_role_search_results", users)
 }
 
 // UserRoleRevokeConfirm returns the revoke confirmation modal body for HTMX.
@@ -5521,7 +6330,7 @@ func (h *GUIHandler) SessionList(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 20
+	pageSize := guiPageSize(c, 20)
 
 	filterAppID := c.Query("app_id")
 	search := strings.ToLower(c.Query("search"))
@@ -5576,10 +6385,7 @@ func (h *GUIHandler) SessionList(c *gin.Context) {
 	userEmails, _ := h.Repo.GetUserEmailsByIDs(userIDs)
 
 	// Active threshold: treat sessions with last_active within this window as "active"
-	activeThresholdMinutes := viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES")
-	if activeThresholdMinutes <= 0 {
-		activeThresholdMinutes = 15
-	}
+	activeThresholdMinutes := int(jwt.DefaultAccessTokenTTL().Minutes())
 
 	// Build flattened items and apply in-memory filters
 	var items []sessionItem
@@ -5612,12 +6418,12 @@ func (h *GUIHandler) SessionList(c *gin.Context) {
 
 		// Format timestamps for display
 		if t, err := time.Parse(time.RFC3339, s["created_at"]); err == nil {
-			item.CreatedAtFormatted = formatTimeAgo(t)
+			item.CreatedAtFormatted = formatTimeAgo(t, web.GetPreferences(c).Location())
 		} else {
 			item.CreatedAtFormatted = s["created_at"]
 		}
 		if t, err := time.Parse(time.RFC3339, s["last_active"]); err == nil {
-			item.LastActiveFormatted = formatTimeAgo(t)
+			item.LastActiveFormatted = formatTimeAgo(t, web.GetPreferences(c).Location())
 			// Compute session status based on idle time
 			idle := int(time.Since(t).Minutes())
 			item.IdleMinutes = idle
@@ -5684,10 +6490,7 @@ func (h *GUIHandler) SessionDetail(c *gin.Context) {
 	appNames, _ := h.Repo.GetAppNamesByIDs([]string{appID})
 
 	// Compute session status
-	activeThresholdMinutes := viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES")
-	if activeThresholdMinutes <= 0 {
-		activeThresholdMinutes = 15
-	}
+	activeThresholdMinutes := int(jwt.DefaultAccessTokenTTL().Minutes())
 	detailStatus := "stale"
 	detailIdleMinutes := 0
 	if t, err := time.Parse(time.RFC3339, data["last_active"]); err == nil {
@@ -5742,7 +6545,7 @@ func (h *GUIHandler) SessionRevoke(c *gin.Context) {
 	// We use a user-wide blacklist keyed to the access-token TTL; the middleware
 	// checks this flag on every authenticated request and returns 401 when set.
 	if userID != "" {
-		accessTokenTTL := time.Duration(viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES")) * time.Minute
+		accessTokenTTL := jwt.DefaultAccessTokenTTL()
 		if err := redis.BlacklistAllUserTokens(appID, userID, accessTokenTTL); err != nil {
 			// Non-fatal: log the error but continue — the session hash is already gone so the
 			// next request will still get a 401 via the SessionExists check.
@@ -5781,7 +6584,7 @@ func (h *GUIHandler) SessionRevokeAllForUser(c *gin.Context) {
 	}
 
 	// Blacklist all live access tokens for the user so they are forced out immediately.
-	accessTokenTTL := time.Duration(viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES")) * time.Minute
+	accessTokenTTL := jwt.DefaultAccessTokenTTL()
 	if err := redis.BlacklistAllUserTokens(appID, userID, accessTokenTTL); err != nil {
 		_ = err // non-fatal
 	}
@@ -5871,10 +6674,7 @@ func (h *GUIHandler) renderUserSessions(c *gin.Context, appID, userID string) {
 		return
 	}
 
-	activeThresholdMinutes := viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES")
-	if activeThresholdMinutes <= 0 {
-		activeThresholdMinutes = 15
-	}
+	activeThresholdMinutes := int(jwt.DefaultAccessTokenTTL().Minutes())
 
 	var items []sessionItem
 	for _, sid := range sessionIDs {
@@ -5894,12 +6694,12 @@ func (h *GUIHandler) renderUserSessions(c *gin.Context, appID, userID string) {
 		}
 
 		if t, err := time.Parse(time.RFC3339, data["created_at"]); err == nil {
-			item.CreatedAtFormatted = formatTimeAgo(t)
+			item.CreatedAtFormatted = formatTimeAgo(t, web.GetPreferences(c).Location())
 		} else {
 			item.CreatedAtFormatted = data["created_at"]
 		}
 		if t, err := time.Parse(time.RFC3339, data["last_active"]); err == nil {
-			item.LastActiveFormatted = formatTimeAgo(t)
+			item.LastActiveFormatted = formatTimeAgo(t, web.GetPreferences(c).Location())
 			// Compute session status based on idle time
 			idle := int(time.Since(t).Minutes())
 			item.IdleMinutes = idle
@@ -5941,8 +6741,9 @@ func sortSessionsByLastActive(items []sessionItem) {
 	}
 }
 
-// formatTimeAgo returns a human-readable relative time string.
-func formatTimeAgo(t time.Time) string {
+// formatTimeAgo returns a human-readable relative time string. Times older
+// than a week are shown as a date in loc.
+func formatTimeAgo(t time.Time, loc *time.Location) string {
 	d := time.Since(t)
 	switch {
 	case d < time.Minute:
@@ -5966,7 +6767,7 @@ func formatTimeAgo(t time.Time) string {
 		}
 		return fmt.Sprintf("%d days ago", days)
 	default:
-		return t.Format("Jan 02, 2006 15:04")
+		return t.In(loc).Format("Jan 02, 2006 15:04")
 	}
 }
 
@@ -6078,7 +6879,7 @@ func (h *GUIHandler) IPRuleCreate(c *gin.Context) {
 	}
 
 	if err := geoip.ValidateRule(rule); err != nil {
-		c.String(http.StatusOK, fmt.Sprintf(`<div class="alert alert-danger">%s</div>`, escapeHTML(err.Error())))
+		renderAlert(c, http.StatusOK, alertFragment{Kind: "danger", Message: err.Error()})
 		return
 	}
 
@@ -6165,7 +6966,7 @@ func (h *GUIHandler) IPRuleUpdate(c *gin.Context) {
 	rule.IsActive = c.PostForm("is_active") == "on"
 
 	if err := geoip.ValidateRule(rule); err != nil {
-		c.String(http.StatusOK, fmt.Sprintf(`<div class="alert alert-danger">%s</div>`, escapeHTML(err.Error())))
+		renderAlert(c, http.StatusOK, alertFragment{Kind: "danger", Message: err.Error()})
 		return
 	}
 
@@ -6282,40 +7083,21 @@ func (h *GUIHandler) IPRuleCheckAccess(c *gin.Context) {
 
 	result := h.IPRuleEvaluator.EvaluateAccess(appID, ipAddress)
 
-	// Build a nice result display
-	var icon, alertClass, statusText string
-	if result.Allowed {
-		icon = "bi-check-circle-fill"
-		alertClass = "alert-success"
-		statusText = "Allowed"
-	} else {
-		icon = "bi-x-circle-fill"
-		alertClass = "alert-danger"
-		statusText = "Blocked"
+	alert := alertFragment{Kind: "success", Icon: "bi-check-circle-fill", Title: ipAddress, Message: "— Allowed", Dismissible: true}
+	if !result.Allowed {
+		alert.Kind, alert.Icon, alert.Message = "danger", "bi-x-circle-fill", "— Blocked"
 	}
-
-	locationInfo := ""
+	alert.Detail = "Reason: " + result.Reason
 	if result.GeoInfo != nil {
-		locationInfo = result.GeoInfo.String()
+		locationInfo := result.GeoInfo.String()
 		if result.GeoInfo.Country != "" {
 			locationInfo += " (" + result.GeoInfo.Country + ")"
 		}
+		if locationInfo != "" {
+			alert.Detail += " | Location: " + locationInfo
+		}
 	}
-
-	html := fmt.Sprintf(`<div class="alert %s alert-dismissible fade show">
-		<i class="bi %s me-2"></i>
-		<strong>%s</strong> &mdash; %s
-		<br><small class="text-muted">Reason: %s%s</small>
-		<button type="button" class="btn-close" data-bs-dismiss="alert"></button>
-	</div>`, alertClass, icon, ipAddress, statusText, escapeHTML(result.Reason),
-		func() string {
-			if locationInfo != "" {
-				return " | Location: " + escapeHTML(locationInfo)
-			}
-			return ""
-		}())
-
-	c.String(http.StatusOK, html)
+	renderAlert(c, http.StatusOK, alert)
 }
 
 // ============================================================================
@@ -6348,6 +7130,7 @@ func (h *GUIHandler) WebhookList(c *gin.Context) {
 		page = 1
 	}
 	appIDStr := c.Query("app_id")
+	pageSize := guiPageSize(c, 20)
 
 	var endpoints []models.WebhookEndpoint
 	var total int64
@@ -6359,9 +7142,9 @@ func (h *GUIHandler) WebhookList(c *gin.Context) {
 			c.String(http.StatusBadRequest, `<div class="alert alert-danger">Invalid app ID</div>`)
 			return
 		}
-		endpoints, total, err = h.WebhookService.ListEndpointsByApp(appID, page, 20)
+		endpoints, total, err = h.WebhookService.ListEndpointsByApp(appID, page, pageSize)
 	} else {
-		endpoints, total, err = h.WebhookService.ListAllEndpoints(page, 20)
+		endpoints, total, err = h.WebhookService.ListAllEndpoints(page, pageSize)
 	}
 
 	if err != nil {
@@ -6372,7 +7155,7 @@ func (h *GUIHandler) WebhookList(c *gin.Context) {
 		return
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(20)))
+	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
 	apps, _ := h.Repo.ListAllAppsWithTenantName()
 
 	c.HTML(http.StatusOK, "webhook_list", gin.H{
@@ -6549,7 +7332,8 @@ func (h *GUIHandler) WebhookDeliveries(c *gin.Context) {
 		page = 1
 	}
 
-	deliveries, total, svcErr := h.WebhookService.ListDeliveriesByEndpoint(id, page, 20)
+	pageSize := guiPageSize(c, 20)
+	deliveries, total, svcErr := h.WebhookService.ListDeliveriesByEndpoint(id, page, pageSize)
 	if svcErr != nil {
 		c.HTML(http.StatusInternalServerError, "webhook_deliveries", gin.H{
 			"Error": "Failed to load delivery history",
@@ -6558,7 +7342,7 @@ func (h *GUIHandler) WebhookDeliveries(c *gin.Context) {
 	}
 
 	ep, _ := h.WebhookService.GetEndpoint(id)
-	totalPages := int(math.Ceil(float64(total) / float64(20)))
+	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
 
 	c.HTML(http.StatusOK, "webhook_deliveries", gin.H{
 		"Endpoint":   ep,
@@ -6609,8 +7393,9 @@ func (h *GUIHandler) MyAccountSetBackupEmail(c *gin.Context) {
 		return
 	}
 
-	c.String(http.StatusOK,
-		fmt.Sprintf(`<div class="alert alert-success py-2 small">Backup email set to %s. Note: admin account backup email verification is not required.</div>`, backupEmail))
+	renderAlert(c, http.StatusOK, alertFragment{
+		Kind: "success", Message: "Backup email set to " + backupEmail + ". Note: admin account backup email verification is not required.", Class: "py-2 small",
+	})
 }
 
 // MyAccountRemoveBackupEmail removes the backup email from the admin account.
@@ -6798,6 +7583,28 @@ func (h *GUIHandler) UserImport(c *gin.Context) {
 		rows, parseErrors = userimport.ParseCSVImport(file)
 	}
 
+	// With the job queue enabled, run the import in the background and let the
+	// progress partial poll for the result
+	if h.JobQueue != nil {
+		if _, err := uuid.Parse(appID); err != nil {
+			renderErr("Invalid application ID.")
+			return
+		}
+		job, err := h.JobQueue.Enqueue(JobTypeUserImport, UserImportPayload{
+			AppID:       appID,
+			Rows:        rows,
+			ParseErrors: parseErrors,
+			IPAddress:   c.ClientIP(),
+			UserAgent:   c.GetHeader("User-Agent"),
+		}, getAdminUsername(c))
+		if err != nil {
+			renderErr("Failed to queue import: " + err.Error())
+			return
+		}
+		c.HTML(http.StatusOK, "user_import_progress", job)
+		return
+	}
+
 	result, err := h.Repo.ImportUsers(appID, rows)
 	if err != nil {
 		renderErr("Import failed: " + err.Error())
@@ -6933,7 +7740,7 @@ func (h *GUIHandler) SessionGroupList(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 
 	groups, total, err := h.Repo.ListSessionGroups(page, pageSize)
 	if err != nil {
@@ -7116,7 +7923,7 @@ func (h *GUIHandler) SessionGroupDelete(c *gin.Context) {
 	c.Header("HX-Trigger", "sessionGroupDeleted")
 
 	page := 1
-	pageSize := 10
+	pageSize := guiPageSize(c, 10)
 	groups, total, err := h.Repo.ListSessionGroups(page, pageSize)
 	if err != nil {
 		c.String(http.StatusInternalServerError,
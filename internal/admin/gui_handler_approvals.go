@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ============================================================
+// Sign-up Approval Queue
+// ============================================================
+
+// UserApprovalsPage renders the queue of registrations awaiting approval
+// (applications with registration_approval_required).
+// GET /gui/user-approvals
+func (h *GUIHandler) UserApprovalsPage(c *gin.Context) {
+	apps, err := h.Repo.ListAllAppsWithTenantName()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "user_approvals", gin.H{
+			"ActivePage": "user-approvals",
+			"AdminUser":  getAdminUsername(c),
+			"CSRFToken":  getCSRFToken(c),
+			"Error":      "Failed to load applications",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "user_approvals", gin.H{
+		"ActivePage": "user-approvals",
+		"AdminUser":  getAdminUsername(c),
+		"CSRFToken":  getCSRFToken(c),
+		"Data":       apps,
+	})
+}
+
+// UserApprovalList returns the paginated approval queue partial (HTMX fragment).
+// GET /gui/user-approvals/list
+func (h *GUIHandler) UserApprovalList(c *gin.Context) {
+	h.renderUserApprovalList(c, "")
+}
+
+// UserApprovalApprove approves the selected registrations (HTMX fragment).
+// POST /gui/user-approvals/approve
+func (h *GUIHandler) UserApprovalApprove(c *gin.Context) {
+	h.reviewRegistrations(c, true)
+}
+
+// UserApprovalReject rejects the selected registrations (HTMX fragment).
+// POST /gui/user-approvals/reject
+func (h *GUIHandler) UserApprovalReject(c *gin.Context) {
+	h.reviewRegistrations(c, false)
+}
+
+// reviewRegistrations approves or rejects the registrations posted as
+// user_ids, notifies the users by email and re-renders the queue.
+func (h *GUIHandler) reviewRegistrations(c *gin.Context, approve bool) {
+	var ids []string
+	for _, id := range c.PostFormArray("user_ids") {
+		if _, err := uuid.Parse(id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		h.renderUserApprovalList(c, "Select at least one registration.")
+		return
+	}
+
+	users, err := h.Repo.ReviewPendingUsers(ids, approve)
+	if err != nil {
+		h.renderUserApprovalList(c, "Failed to update the selected registrations.")
+		return
+	}
+
+	reviewedBy := getAdminUsername(c)
+	for _, u := range users {
+		logService.LogRegistrationReviewed(u.AppID, u.ID, approve, map[string]interface{}{
+			"email":       u.Email,
+			"reviewed_by": reviewedBy,
+		})
+	}
+	if h.EmailService != nil && len(users) > 0 {
+		go h.notifyReviewedUsers(users, approve)
+	}
+
+	// Refresh the user list as well, so both views stay in sync
+	c.Header("HX-Trigger", "userListRefresh")
+	h.renderUserApprovalList(c, "")
+}
+
+// notifyReviewedUsers emails the outcome of the review to each user. Failures
+// are logged only.
+func (h *GUIHandler) notifyReviewedUsers(users []models.User, approved bool) {
+	for _, u := range users {
+		userID := u.ID
+		var err error
+		if approved {
+			err = h.EmailService.SendRegistrationApprovedEmail(u.AppID, u.Email, &userID)
+		} else {
+			err = h.EmailService.SendRegistrationRejectedEmail(u.AppID, u.Email, &userID)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to send registration review email to user %s: %v", u.ID, err)
+		}
+	}
+}
+
+// renderUserApprovalList renders the approval queue for the app_id, search and
+// page parameters of the request (query string or form).
+func (h *GUIHandler) renderUserApprovalList(c *gin.Context, errMsg string) {
+	pageSize := guiPageSize(c, 15)
+
+	appID := c.Request.FormValue("app_id")
+	search := c.Request.FormValue("search")
+	page, _ := strconv.Atoi(c.Request.FormValue("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	users, total, err := h.Repo.ListPendingUsers(page, pageSize, appID, search)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "user_approval_list", gin.H{
+			"Users": nil,
+			"Error": "Failed to load registrations",
+		})
+		return
+	}
+	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+
+	c.HTML(http.StatusOK, "user_approval_list", gin.H{
+		"Users":      users,
+		"Page":       page,
+		"TotalPages": totalPages,
+		"Total":      total,
+		"AppID":      appID,
+		"Search":     search,
+		"Error":      errMsg,
+	})
+}
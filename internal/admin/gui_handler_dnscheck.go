@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/dnscheck"
+	"github.com/google/uuid"
+)
+
+// ============================================================
+// Sender Domain Verification (SPF / DKIM / DMARC)
+// ============================================================
+
+// EmailServerDNSCheck checks the SPF, DKIM and DMARC records of the domain of
+// an SMTP config's From address and renders the results with remediation hints.
+// The SPF include and DKIM selector default to those of the SMTP host's provider,
+// if it is a well-known one, and can be changed in the dialog.
+// GET /gui/email-servers/:id/dns-check
+func (h *GUIHandler) EmailServerDNSCheck(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		renderDNSCheckError(c, http.StatusBadRequest, "Invalid ID.")
+		return
+	}
+	config, err := h.EmailService.GetServerConfigByID(id)
+	if err != nil || config == nil {
+		renderDNSCheckError(c, http.StatusNotFound, "SMTP config not found.")
+		return
+	}
+
+	domain, err := dnscheck.DomainOf(config.FromAddress)
+	if err != nil {
+		renderDNSCheckError(c, http.StatusOK, "The From address of this config has no valid domain to check: "+config.FromAddress)
+		return
+	}
+
+	opts := dnscheck.SuggestOptions(config.SMTPHost)
+	if v, ok := c.GetQuery("spf_include"); ok {
+		opts.SPFInclude = v
+	}
+	if v, ok := c.GetQuery("dkim_selector"); ok {
+		opts.DKIMSelector = v
+	}
+
+	checker := h.DNSChecker
+	if checker == nil {
+		checker = dnscheck.NewChecker()
+	}
+	report := checker.Check(c.Request.Context(), domain, opts)
+
+	c.HTML(http.StatusOK, "email_server_dns_check", gin.H{
+		"ID":          config.ID.String(),
+		"Name":        config.Name,
+		"FromAddress": config.FromAddress,
+		"Options":     opts,
+		"Report":      report,
+	})
+}
+
+// renderDNSCheckError renders message as the body of the DNS check dialog.
+func renderDNSCheckError(c *gin.Context, status int, message string) {
+	c.HTML(status, "email_server_dns_check", gin.H{"Error": message})
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+)
+
+// ============================================================
+// Background Jobs (job queue)
+// ============================================================
+
+// backgroundJobListLimit is how many recent jobs the jobs table shows.
+const backgroundJobListLimit = 100
+
+// JobsPage renders the background jobs page.
+// GET /gui/jobs
+func (h *GUIHandler) JobsPage(c *gin.Context) {
+	data := web.TemplateData{
+		Theme:         web.GetTheme(c),
+		ActivePage:    "jobs",
+		AdminUsername: getAdminUsername(c),
+		AdminID:       getAdminID(c),
+		CSRFToken:     getCSRFToken(c),
+	}
+	c.HTML(http.StatusOK, "background_jobs", data)
+}
+
+// JobList returns the recent background jobs, optionally filtered by status.
+// GET /gui/jobs/list
+func (h *GUIHandler) JobList(c *gin.Context) {
+	if h.JobQueue == nil {
+		c.String(http.StatusOK,
+			`<div class="alert alert-secondary"><i class="bi bi-slash-circle me-2"></i>The background job queue is disabled (JOB_QUEUE_ENABLED=false).</div>`)
+		return
+	}
+	jobs, err := h.JobQueue.List(c.Query("status"), "", backgroundJobListLimit)
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			`<div class="alert alert-danger">Failed to load background jobs.</div>`)
+		return
+	}
+	c.HTML(http.StatusOK, "background_job_list", jobs)
+}
+
+// JobCancel cancels a queued or running job.
+// POST /gui/jobs/:id/cancel
+func (h *GUIHandler) JobCancel(c *gin.Context) {
+	h.jobAction(c, true, "Cancellation requested.")
+}
+
+// JobRetry queues a failed or cancelled job again.
+// POST /gui/jobs/:id/retry
+func (h *GUIHandler) JobRetry(c *gin.Context) {
+	h.jobAction(c, false, "Job queued for retry.")
+}
+
+// jobAction applies a cancel or retry action and renders the outcome as an alert.
+func (h *GUIHandler) jobAction(c *gin.Context, cancel bool, success string) {
+	if h.JobQueue == nil {
+		c.String(http.StatusServiceUnavailable,
+			`<div class="alert alert-danger">The background job queue is disabled.</div>`)
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, `<div class="alert alert-danger">Invalid job ID.</div>`)
+		return
+	}
+	if cancel {
+		err = h.JobQueue.Cancel(id)
+	} else {
+		err = h.JobQueue.Retry(id)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, jobqueue.ErrNotCancelled):
+			c.String(http.StatusConflict, `<div class="alert alert-warning">The job has already finished.</div>`)
+			return
+		case errors.Is(err, jobqueue.ErrNotRetryable):
+			c.String(http.StatusConflict, `<div class="alert alert-warning">Only failed or cancelled jobs can be retried.</div>`)
+			return
+		}
+		c.String(http.StatusInternalServerError, `<div class="alert alert-danger">Failed to update the job.</div>`)
+		return
+	}
+	c.Header("HX-Trigger", "backgroundJobsChanged")
+	renderSuccessAlert(c, success)
+}
+
+// UserImportJobStatus renders the progress of a queued user import. While the
+// job runs the progress partial polls this endpoint; once it has finished the
+// import result replaces it.
+// GET /gui/users/import/jobs/:id
+func (h *GUIHandler) UserImportJobStatus(c *gin.Context) {
+	if h.JobQueue == nil {
+		c.String(http.StatusServiceUnavailable,
+			`<div class="alert alert-danger py-2 mb-0 small">The background job queue is disabled.</div>`)
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, `<div class="alert alert-danger py-2 mb-0 small">Invalid job ID.</div>`)
+		return
+	}
+	job, err := h.JobQueue.Get(id)
+	if err != nil || job.Type != JobTypeUserImport {
+		c.String(http.StatusNotFound, `<div class="alert alert-danger py-2 mb-0 small">Import job not found.</div>`)
+		return
+	}
+
+	switch job.Status {
+	case jobqueue.StatusSucceeded:
+		var result dto.UserImportResult
+		if err := json.Unmarshal([]byte(job.Result), &result); err != nil {
+			c.String(http.StatusOK, `<div class="alert alert-warning py-2 mb-0 small">The import finished but its result could not be read.</div>`)
+			return
+		}
+		if result.Imported > 0 {
+			c.Header("HX-Trigger", "userImportComplete")
+		}
+		c.HTML(http.StatusOK, "user_import_result", gin.H{
+			"Result":    result,
+			"HasErrors": len(result.Errors) > 0,
+		})
+	case jobqueue.StatusFailed, jobqueue.StatusCancelled:
+		renderAlert(c, http.StatusOK, alertFragment{
+			Kind: "danger", Icon: "bi-x-circle", Title: "Import " + job.Status + ":", Message: job.Error, Class: "py-2 mb-0 small",
+		})
+	default:
+		c.HTML(http.StatusOK, "user_import_progress", job)
+	}
+}
@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/internal/livefeed"
+)
+
+// ============================================================
+// Live Dashboard (server-sent events)
+// ============================================================
+
+const (
+	// liveStreamHeartbeat keeps idle streams open through proxies.
+	liveStreamHeartbeat = 25 * time.Second
+	// liveStreamMaxAge closes streams periodically; the browser reconnects
+	// automatically, which re-checks the admin session.
+	liveStreamMaxAge = 15 * time.Minute
+)
+
+// DashboardLiveControls renders the live update filter bar of the dashboard,
+// with the admin's saved subscription filters (HTMX fragment).
+// GET /gui/dashboard/live-controls
+func (h *GUIHandler) DashboardLiveControls(c *gin.Context) {
+	var eventTypes, categories []string
+	seen := make(map[config.EventCategory]bool)
+	for _, def := range config.EventCatalog() {
+		eventTypes = append(eventTypes, def.Type)
+		if !seen[def.Category] {
+			seen[def.Category] = true
+			categories = append(categories, string(def.Category))
+		}
+	}
+	apps, err := h.Repo.ListAllAppsWithTenantName()
+	if err != nil {
+		apps = nil // Non-critical, proceed without the application filter
+	}
+
+	c.HTML(http.StatusOK, "dashboard_live_controls", gin.H{
+		"Enabled":    h.LiveFeed != nil,
+		"EventTypes": eventTypes,
+		"Categories": categories,
+		"Severities": []config.EventSeverity{config.SeverityCritical, config.SeverityImportant, config.SeverityInformational},
+		"Apps":       apps,
+		"ViewPrefs":  h.loadListViewPrefs(c, "dashboard"),
+	})
+}
+
+// DashboardStream streams new activity log events matching the query filter
+// (see livefeed.ParseFilter) as server-sent "activity" events.
+// GET /gui/dashboard/stream
+func (h *GUIHandler) DashboardStream(c *gin.Context) {
+	if h.LiveFeed == nil {
+		c.String(http.StatusNotFound, "Live updates are disabled")
+		return
+	}
+	filter, err := livefeed.ParseFilter(c.Request.URL.Query())
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, unsubscribe := h.LiveFeed.Subscribe(filter)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(liveStreamHeartbeat)
+	defer heartbeat.Stop()
+	maxAge := time.NewTimer(liveStreamMaxAge)
+	defer maxAge.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-maxAge.C:
+			return false
+		case e := <-events:
+			c.SSEvent("activity", e)
+			return true
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			return true
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/metricshistory"
+)
+
+// ============================================================
+// Dashboard metrics history charts
+// ============================================================
+
+// historyChart is one chart of the dashboard_metrics_history partial.
+type historyChart struct {
+	Title    string
+	Icon     string
+	Datasets []historyDataset
+}
+
+// historyDataset is one line of a history chart.
+type historyDataset struct {
+	Label  string
+	Values []*float64 // nil = no data
+}
+
+// historyCharts groups the recorded metrics into charts.
+var historyCharts = []struct {
+	Title   string
+	Icon    string
+	Metrics []string
+}{
+	{"Traffic", "bi-globe", []string{"http_requests", "http_errors"}},
+	{"Authentication", "bi-box-arrow-in-right", []string{"logins", "login_failures", "registrations", "logouts"}},
+	{"Sessions & Users", "bi-people", []string{"active_sessions", "users"}},
+}
+
+// metricsHistoryData is passed to the dashboard_metrics_history partial.
+type metricsHistoryData struct {
+	Range  string
+	Ranges []string
+	Labels []string // Bucket starts (UTC)
+	Charts []historyChart
+}
+
+// DashboardMetricsHistory renders the history charts of the dashboard from
+// the embedded metrics history (HTMX fragment).
+// GET /gui/dashboard/metrics-history?range=24h
+func (h *GUIHandler) DashboardMetricsHistory(c *gin.Context) {
+	if h.MetricsHistory == nil {
+		c.String(http.StatusOK,
+			`<div class="alert alert-secondary"><i class="bi bi-slash-circle me-2"></i>Metrics history is disabled (METRICS_HISTORY_ENABLED=false).</div>`)
+		return
+	}
+	r, ok := metricshistory.ParseRange(c.Query("range"))
+	if !ok {
+		r, _ = metricshistory.ParseRange("")
+	}
+	history, err := h.MetricsHistory.Query(c.Request.Context(), r, nil)
+	if err != nil {
+		log.Printf("Warning: Failed to read metrics history: %v", err)
+		c.String(http.StatusOK,
+			`<div class="alert alert-warning"><i class="bi bi-exclamation-triangle me-2"></i>Failed to load the metrics history.</div>`)
+		return
+	}
+
+	layout := "15:04"
+	if r.Span > 24*time.Hour {
+		layout = "Jan 2 15:04"
+	}
+	data := metricsHistoryData{Range: r.Name, Labels: make([]string, len(history.Timestamps))}
+	for _, rg := range metricshistory.Ranges {
+		data.Ranges = append(data.Ranges, rg.Name)
+	}
+	for i, ts := range history.Timestamps {
+		data.Labels[i] = ts.Format(layout)
+	}
+	byName := make(map[string]metricshistory.Series, len(history.Series))
+	for _, s := range history.Series {
+		byName[s.Metric.Name] = s
+	}
+	for _, def := range historyCharts {
+		chart := historyChart{Title: def.Title, Icon: def.Icon}
+		for _, name := range def.Metrics {
+			if s, ok := byName[name]; ok {
+				chart.Datasets = append(chart.Datasets, historyDataset{Label: s.Metric.Label, Values: s.Values})
+			}
+		}
+		data.Charts = append(data.Charts, chart)
+	}
+	c.HTML(http.StatusOK, "dashboard_metrics_history", data)
+}
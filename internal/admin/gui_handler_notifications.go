@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/notification"
+	"github.com/google/uuid"
+)
+
+// ============================================================
+// Notification Center (sidebar bell)
+// ============================================================
+
+// notificationBellLimit is how many recent notifications the bell dropdown lists.
+const notificationBellLimit = 10
+
+// notificationBellData is passed to the notification_bell partial.
+type notificationBellData struct {
+	UnreadCount int64
+	Items       []notification.Item
+}
+
+// NotificationBell renders the notification bell with the unread count and
+// the most recent notifications for the current admin.
+// GET /gui/notifications/bell
+func (h *GUIHandler) NotificationBell(c *gin.Context) {
+	adminID, err := uuid.Parse(getAdminID(c))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "")
+		return
+	}
+	h.renderNotificationBell(c, adminID)
+}
+
+// NotificationMarkRead marks one notification as read. When the notification
+// links to a GUI page, the browser is sent there; otherwise the bell is re-rendered.
+// POST /gui/notifications/:id/read
+func (h *GUIHandler) NotificationMarkRead(c *gin.Context) {
+	adminID, err := uuid.Parse(getAdminID(c))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "")
+		return
+	}
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, `<div class="alert alert-danger">Invalid notification ID.</div>`)
+		return
+	}
+	if err := h.Notifications.MarkRead(adminID, notificationID); err != nil {
+		c.String(http.StatusInternalServerError, `<div class="alert alert-danger">Failed to update notification.</div>`)
+		return
+	}
+	if link := safeGUIRedirect(c.PostForm("link")); link != "" {
+		c.Header("HX-Redirect", link)
+		c.Status(http.StatusOK)
+		return
+	}
+	h.renderNotificationBell(c, adminID)
+}
+
+// NotificationMarkAllRead marks all recent notifications as read for the current admin.
+// POST /gui/notifications/read-all
+func (h *GUIHandler) NotificationMarkAllRead(c *gin.Context) {
+	adminID, err := uuid.Parse(getAdminID(c))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "")
+		return
+	}
+	if err := h.Notifications.MarkAllRead(adminID); err != nil {
+		c.String(http.StatusInternalServerError, `<div class="alert alert-danger">Failed to update notifications.</div>`)
+		return
+	}
+	h.renderNotificationBell(c, adminID)
+}
+
+func (h *GUIHandler) renderNotificationBell(c *gin.Context, adminID uuid.UUID) {
+	data := notificationBellData{}
+	if count, err := h.Notifications.UnreadCount(adminID); err == nil {
+		data.UnreadCount = count
+	}
+	if items, err := h.Notifications.ListForAdmin(adminID, notificationBellLimit); err == nil {
+		data.Items = items
+	}
+	c.HTML(http.StatusOK, "notification_bell", data)
+}
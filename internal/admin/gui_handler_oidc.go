@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/web"
@@ -26,8 +27,7 @@ type OIDCClientListItem struct {
 	AllowedScopes     string
 	IsConfidential    bool
 	IsActive          bool
-	CreatedAtStr      string
-	CreatedAtFull     string
+	CreatedAt         time.Time
 }
 
 // oidcClientListData is passed to the oidc_client_list partial.
@@ -107,7 +107,7 @@ func (h *GUIHandler) OIDCClientList(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	pageSize := 15
+	pageSize := guiPageSize(c, 15)
 	appIDStr := c.Query("app_id")
 
 	var items []OIDCClientListItem
@@ -144,8 +144,7 @@ func (h *GUIHandler) OIDCClientList(c *gin.Context) {
 				AllowedScopes:     cl.AllowedScopes,
 				IsConfidential:    cl.IsConfidential,
 				IsActive:          cl.IsActive,
-				CreatedAtStr:      cl.CreatedAt.Format("Jan 2, 2006"),
-				CreatedAtFull:     cl.CreatedAt.Format("2006-01-02 15:04:05"),
+				CreatedAt:         cl.CreatedAt,
 			})
 		}
 	} else {
@@ -172,8 +171,7 @@ func (h *GUIHandler) OIDCClientList(c *gin.Context) {
 					AllowedScopes:     cl.AllowedScopes,
 					IsConfidential:    cl.IsConfidential,
 					IsActive:          cl.IsActive,
-					CreatedAtStr:      cl.CreatedAt.Format("Jan 2, 2006"),
-					CreatedAtFull:     cl.CreatedAt.Format("2006-01-02 15:04:05"),
+					CreatedAt:         cl.CreatedAt,
 				})
 			}
 		}
@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+)
+
+// ============================================================
+// Display Preferences (language, theme, page size, timezone, locale)
+// ============================================================
+
+// guiPageSize returns the admin's preferred page size for list views, or
+// fallback (the list's own default) if none is set.
+func guiPageSize(c *gin.Context, fallback int) int {
+	if size := web.GetPreferences(c).PageSize; size > 0 {
+		return size
+	}
+	return fallback
+}
+
+// adminPreferencesData is passed to the admin_preferences partial.
+type adminPreferencesData struct {
+	Preferences web.Preferences
+	PageSizes   []int
+	Locales     []web.Locale
+	Languages   []web.Language
+	Timezones   []string
+	CSRFToken   string
+}
+
+// MyAccountPreferences renders the display preferences form.
+// GET /gui/my-account/preferences
+func (h *GUIHandler) MyAccountPreferences(c *gin.Context) {
+	if h.ViewPrefRepo == nil {
+		c.String(http.StatusServiceUnavailable,
+			`<div class="alert alert-warning py-2"><small>Display preferences are unavailable.</small></div>`)
+		return
+	}
+	h.renderPreferences(c, web.GetPreferences(c))
+}
+
+// MyAccountSavePreferences validates and saves the display preferences form,
+// then reloads the page so the new language, theme, page size and date formats apply.
+// POST /gui/my-account/preferences
+func (h *GUIHandler) MyAccountSavePreferences(c *gin.Context) {
+	if h.ViewPrefRepo == nil {
+		c.String(http.StatusServiceUnavailable,
+			`<div class="alert alert-warning py-2"><small>Display preferences are unavailable.</small></div>`)
+		return
+	}
+	adminID, err := uuid.Parse(getAdminID(c))
+	if err != nil {
+		renderAlert(c, http.StatusUnauthorized, alertFragment{Kind: "danger", Message: "Not authenticated.", Class: "py-2 small"})
+		return
+	}
+
+	pref := models.AdminPreference{
+		AdminID:  adminID,
+		Theme:    c.PostForm("theme"),
+		Timezone: strings.TrimSpace(c.PostForm("timezone")),
+		Locale:   c.PostForm("locale"),
+		Language: c.PostForm("language"),
+	}
+	if v := c.PostForm("page_size"); v != "" {
+		if pref.PageSize, err = strconv.Atoi(v); err != nil {
+			pref.PageSize = -1
+		}
+	}
+
+	var msg string
+	switch {
+	case !web.ValidTheme(pref.Theme):
+		msg = "Choose a light or dark theme."
+	case !web.ValidPageSize(pref.PageSize):
+		msg = "Choose one of the listed page sizes."
+	case !web.ValidTimezone(pref.Timezone):
+		msg = "Unknown timezone " + pref.Timezone + ". Use an IANA name such as Europe/Berlin."
+	case !web.ValidLocale(pref.Locale):
+		msg = "Choose one of the listed date formats."
+	case !web.ValidLanguage(pref.Language):
+		msg = "Choose one of the listed languages."
+	}
+	if msg != "" {
+		renderAlert(c, http.StatusBadRequest, alertFragment{Kind: "danger", Message: msg, Class: "py-2 small"})
+		return
+	}
+
+	if err := h.ViewPrefRepo.UpsertPreferences(&pref); err != nil {
+		log.Printf("Failed to save GUI preferences for admin %s: %v", adminID, err)
+		renderAlert(c, http.StatusInternalServerError, alertFragment{Kind: "danger", Message: "Failed to save preferences.", Class: "py-2 small"})
+		return
+	}
+	if pref.Theme != "" {
+		web.SetThemeCookie(c, pref.Theme)
+	}
+
+	c.Header("HX-Refresh", "true")
+	renderAlert(c, http.StatusOK, alertFragment{Kind: "success", Message: "Preferences saved.", Class: "py-2 small"})
+}
+
+// PreferencesSaveTheme saves the theme chosen with the sidebar theme toggle.
+// POST /gui/preferences/theme
+func (h *GUIHandler) PreferencesSaveTheme(c *gin.Context) {
+	theme := c.PostForm("theme")
+	if theme != "light" && theme != "dark" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	web.SetThemeCookie(c, theme)
+
+	if h.ViewPrefRepo != nil {
+		if adminID, err := uuid.Parse(getAdminID(c)); err == nil {
+			if err := h.ViewPrefRepo.UpsertTheme(adminID, theme); err != nil {
+				log.Printf("Failed to save GUI theme for admin %s: %v", adminID, err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// renderPreferences renders the admin_preferences partial for prefs.
+func (h *GUIHandler) renderPreferences(c *gin.Context, prefs web.Preferences) {
+	c.HTML(http.StatusOK, "admin_preferences", adminPreferencesData{
+		Preferences: prefs,
+		PageSizes:   web.PageSizes,
+		Locales:     web.Locales,
+		Languages:   web.Languages,
+		Timezones:   web.Timezones,
+		CSRFToken:   getCSRFToken(c),
+	})
+}
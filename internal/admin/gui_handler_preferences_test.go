@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+)
+
+func TestGUIPageSize(t *testing.T) {
+	c, _ := newFragmentContext(t)
+	if got := guiPageSize(c, 20); got != 20 {
+		t.Errorf("guiPageSize without preferences = %d, want 20", got)
+	}
+	web.SetPreferences(c, web.Preferences{PageSize: 50})
+	if got := guiPageSize(c, 20); got != 50 {
+		t.Errorf("guiPageSize with preference = %d, want 50", got)
+	}
+}
+
+func TestMyAccountSavePreferencesRejectsInvalidValues(t *testing.T) {
+	h := &GUIHandler{ViewPrefRepo: &ViewPreferenceRepository{}}
+	for _, form := range []url.Values{
+		{"theme": {"blue"}},
+		{"page_size": {"7"}},
+		{"page_size": {"many"}},
+		{"timezone": {"Mars/Olympus"}},
+		{"locale": {"xx"}},
+		{"language": {"tlh"}},
+	} {
+		c, w := newFragmentContext(t)
+		c.Set(web.GUIAdminIDKey, uuid.New().String())
+		c.Request = httptest.NewRequest(http.MethodPost, "/gui/my-account/preferences", strings.NewReader(form.Encode()))
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		h.MyAccountSavePreferences(c)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%v: status = %d, want 400", form, w.Code)
+		}
+		if w.Header().Get("HX-Refresh") != "" {
+			t.Errorf("%v: page refreshed after a rejected save", form)
+		}
+	}
+}
+
+func TestAdminPreferencesFormSelectsSavedValues(t *testing.T) {
+	c, w := newFragmentContext(t)
+	web.SetPreferences(c, web.Preferences{Theme: "dark", PageSize: 25, Timezone: "Asia/Tokyo", Locale: "en-GB", Language: "de"})
+
+	(&GUIHandler{ViewPrefRepo: &ViewPreferenceRepository{}}).MyAccountPreferences(c)
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<option value="dark" selected>`,
+		`<option value="25" selected>`,
+		`value="Asia/Tokyo"`,
+		`<option value="en-GB" selected>English (United Kingdom) (14 Mar 2026)</option>`,
+		`<option value="de" selected>Deutsch</option>`,
+		`Anzeigeeinstellungen`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("form is missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/providercheck"
+	"github.com/google/uuid"
+)
+
+// ============================================================
+// Provider Configuration Tests (OAuth / SMS)
+// ============================================================
+
+// providerChecker returns the configured checker or one that probes the
+// providers' public endpoints.
+func (h *GUIHandler) providerChecker() *providercheck.Checker {
+	if h.ProviderChecker != nil {
+		return h.ProviderChecker
+	}
+	return providercheck.NewChecker()
+}
+
+// OAuthTestConfig tests an OAuth config: its credentials are sent to the
+// provider's token endpoint with an invalid authorization code, which the
+// provider only rejects as such after accepting the client ID and secret.
+// GET /gui/oauth/:id/test
+func (h *GUIHandler) OAuthTestConfig(c *gin.Context) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		renderProviderCheckError(c, http.StatusBadRequest, "Invalid ID.")
+		return
+	}
+	config, err := h.Repo.GetOAuthConfigByID(c.Param("id"))
+	if err != nil {
+		renderProviderCheckError(c, http.StatusNotFound, "OAuth config not found.")
+		return
+	}
+	report := h.providerChecker().CheckOAuth(c.Request.Context(), config)
+	c.HTML(http.StatusOK, "provider_check", gin.H{
+		"Subject": config.Provider + " sign-in (client " + config.ClientID + ")",
+		"Report":  report,
+	})
+}
+
+// MonitoringSMSCheck tests the SMS provider configured by SMS_PROVIDER without
+// sending a message.
+// GET /gui/monitoring/sms-check
+func (h *GUIHandler) MonitoringSMSCheck(c *gin.Context) {
+	report := h.providerChecker().CheckSMS(c.Request.Context())
+	subject := "the SMS provider"
+	if report.Provider != "" {
+		subject = "the " + report.Provider + " SMS provider"
+	}
+	c.HTML(http.StatusOK, "provider_check", gin.H{
+		"Subject": subject,
+		"Report":  report,
+	})
+}
+
+// renderProviderCheckError renders message as the body of the provider check dialog.
+func renderProviderCheckError(c *gin.Context, status int, message string) {
+	c.HTML(status, "provider_check", gin.H{"Error": message})
+}
@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMonitoringSMSCheckRendersFailure(t *testing.T) {
+	viper.Set("SMS_PROVIDER", "")
+	t.Cleanup(func() { viper.Set("SMS_PROVIDER", nil) })
+
+	c, w := newFragmentContext(t)
+	(&GUIHandler{}).MonitoringSMSCheck(c)
+
+	body := w.Body.String()
+	for _, want := range []string{"SMS provider", "Fail", "SMS_PROVIDER is not set", "has problems"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("SMS check is missing %q: %s", want, body)
+		}
+	}
+}
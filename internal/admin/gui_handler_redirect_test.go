@@ -0,0 +1,21 @@
+package admin
+
+import "testing"
+
+func TestSafeGUIRedirect(t *testing.T) {
+	tests := map[string]string{
+		"/gui/users":          "/gui/users",
+		"/gui/logs?since=24h": "/gui/logs?since=24h",
+		"":                    "",
+		"https://evil.com":    "",
+		"//evil.com/gui":      "",
+		"/\\evil.com":         "",
+		"gui/users":           "",
+		"javascript:alert(1)": "",
+	}
+	for in, want := range tests {
+		if got := safeGUIRedirect(in); got != want {
+			t.Errorf("safeGUIRedirect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/scheduler"
+	"github.com/gjovanovicst/auth_api/web"
+)
+
+// ============================================================
+// Scheduled Jobs (background job scheduler)
+// ============================================================
+
+// scheduledJobRunsLimit is how many recent runs the run history table shows.
+const scheduledJobRunsLimit = 50
+
+// ScheduledJobsPage renders the scheduled jobs page.
+// GET /gui/scheduled-jobs
+func (h *GUIHandler) ScheduledJobsPage(c *gin.Context) {
+	data := web.TemplateData{
+		Theme:         web.GetTheme(c),
+		ActivePage:    "scheduled-jobs",
+		AdminUsername: getAdminUsername(c),
+		AdminID:       getAdminID(c),
+		CSRFToken:     getCSRFToken(c),
+	}
+	c.HTML(http.StatusOK, "scheduled_jobs", data)
+}
+
+// ScheduledJobList returns the registered jobs with their last and next runs.
+// GET /gui/scheduled-jobs/list
+func (h *GUIHandler) ScheduledJobList(c *gin.Context) {
+	if h.Scheduler == nil {
+		c.String(http.StatusOK,
+			`<div class="alert alert-secondary"><i class="bi bi-slash-circle me-2"></i>The job scheduler is disabled (SCHEDULER_ENABLED=false).</div>`)
+		return
+	}
+	jobs, err := h.Scheduler.Jobs()
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			`<div class="alert alert-danger">Failed to load scheduled jobs.</div>`)
+		return
+	}
+	c.HTML(http.StatusOK, "scheduled_job_list", jobs)
+}
+
+// ScheduledJobRuns returns the recent run history, optionally filtered by job.
+// GET /gui/scheduled-jobs/runs
+func (h *GUIHandler) ScheduledJobRuns(c *gin.Context) {
+	if h.Scheduler == nil {
+		c.String(http.StatusOK, "")
+		return
+	}
+	jobName := c.Query("job")
+	runs, err := h.Scheduler.History(jobName, scheduledJobRunsLimit)
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			`<div class="alert alert-danger">Failed to load job run history.</div>`)
+		return
+	}
+	c.HTML(http.StatusOK, "scheduled_job_runs", gin.H{
+		"Job":  jobName,
+		"Runs": runs,
+	})
+}
+
+// ScheduledJobRunNow starts a job immediately.
+// POST /gui/scheduled-jobs/:name/run
+func (h *GUIHandler) ScheduledJobRunNow(c *gin.Context) {
+	if h.Scheduler == nil {
+		c.String(http.StatusServiceUnavailable,
+			`<div class="alert alert-danger">The job scheduler is disabled.</div>`)
+		return
+	}
+	name := c.Param("name")
+	if err := h.Scheduler.RunNow(name); err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrJobNotFound):
+			c.String(http.StatusNotFound, `<div class="alert alert-danger">Unknown job.</div>`)
+		case errors.Is(err, scheduler.ErrJobRunning):
+			c.String(http.StatusConflict, `<div class="alert alert-warning">The job is already running.</div>`)
+		default:
+			c.String(http.StatusInternalServerError, `<div class="alert alert-danger">Failed to start the job.</div>`)
+		}
+		return
+	}
+	c.Header("HX-Trigger", "scheduledJobsChanged")
+	c.String(http.StatusOK,
+		`<div class="alert alert-success alert-dismissible fade show" role="alert">Job started.<button type="button" class="btn-close" data-bs-dismiss="alert"></button></div>`)
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/internal/health"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+// ============================================================
+// Dashboard service health panel
+// ============================================================
+
+// serviceHealthData is passed to the dashboard_service_health partial.
+type serviceHealthData struct {
+	Latency    health.LatencySummary
+	Components map[string]dto.ComponentStatus // database, redis
+
+	// Email batch queue depth (nil JobQueue = batches are not queued)
+	EmailQueueEnabled bool
+	EmailQueued       int64
+	EmailRunning      int64
+}
+
+// DashboardServiceHealth renders the latency and error budget panel: p50/p95
+// latency and 5xx rates per route group, database and Redis health, and the
+// email batch queue depth (HTMX fragment).
+// GET /gui/dashboard/service-health
+func (h *GUIHandler) DashboardServiceHealth(c *gin.Context) {
+	if h.HealthHandler == nil {
+		c.String(http.StatusOK,
+			`<div class="alert alert-secondary"><i class="bi bi-slash-circle me-2"></i>Service health is not available.</div>`)
+		return
+	}
+
+	data := serviceHealthData{
+		Latency:    h.HealthHandler.GetLatencySummary(),
+		Components: h.HealthHandler.GetDatastoreHealth(),
+	}
+	if h.JobQueue != nil {
+		data.EmailQueueEnabled = true
+		// Non-critical: the panel shows 0 when the counts cannot be read
+		data.EmailQueued, _ = h.JobQueue.Count(jobqueue.StatusQueued, email.JobTypeEmailBatch)
+		data.EmailRunning, _ = h.JobQueue.Count(jobqueue.StatusRunning, email.JobTypeEmailBatch)
+	}
+	c.HTML(http.StatusOK, "dashboard_service_health", data)
+}
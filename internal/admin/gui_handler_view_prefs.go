@@ -0,0 +1,252 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/livefeed"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============================================================
+// Saved Filters & Column Preferences (users / logs list views)
+// ============================================================
+
+// listViewColumn describes a hideable column on an admin GUI list view.
+type listViewColumn struct {
+	Key    string
+	Label  string
+	Hidden bool
+}
+
+// listViewColumns lists the hideable columns of each list view that supports
+// saved filters, keyed by page name. Keys match the data-col attributes in the
+// list partial templates.
+var listViewColumns = map[string][]listViewColumn{
+	"users": {
+		{Key: "name", Label: "Name"},
+		{Key: "application", Label: "Application"},
+		{Key: "status", Label: "Status"},
+		{Key: "security", Label: "Security"},
+		{Key: "created", Label: "Created"},
+	},
+	"logs": {
+		{Key: "severity", Label: "Severity"},
+		{Key: "user", Label: "User"},
+		{Key: "application", Label: "Application"},
+		{Key: "ip", Label: "IP Address"},
+		{Key: "anomaly", Label: "Anomaly"},
+	},
+	// The dashboard has no list columns; its saved filters are live update subscriptions.
+	"dashboard": {},
+}
+
+// listViewFilterParams lists the query parameters a saved filter may carry for
+// each page. Anything else (including "page") is dropped before saving.
+var listViewFilterParams = map[string][]string{
+	"users":     {"app_id", "search"},
+	"logs":      {"event_type", "severity", "app_id", "search", "start_date", "end_date", "since"},
+	"dashboard": livefeed.FilterParams,
+}
+
+// listViewPrefs is the saved-filter and column state for one admin and page,
+// passed to the page template and the saved_filters partial.
+type listViewPrefs struct {
+	Page          string
+	Filters       []models.AdminSavedFilter
+	DefaultQuery  string
+	Columns       []listViewColumn
+	HiddenColumns []string
+	Error         string
+}
+
+// loadListViewPrefs loads the current admin's saved filters and column
+// preferences for a page. Failures are non-critical: the page renders with no
+// saved filters and all columns visible.
+func (h *GUIHandler) loadListViewPrefs(c *gin.Context, page string) listViewPrefs {
+	prefs := listViewPrefs{Page: page}
+
+	var hidden string
+	if adminID, err := uuid.Parse(getAdminID(c)); err == nil && h.ViewPrefRepo != nil {
+		if filters, err := h.ViewPrefRepo.ListSavedFilters(adminID, page); err == nil {
+			prefs.Filters = filters
+			for _, f := range filters {
+				if f.IsDefault {
+					prefs.DefaultQuery = f.Query
+				}
+			}
+		}
+		hidden, _ = h.ViewPrefRepo.GetHiddenColumns(adminID, page)
+	}
+
+	hiddenSet := make(map[string]bool)
+	for _, key := range strings.Split(hidden, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			hiddenSet[key] = true
+		}
+	}
+	for _, col := range listViewColumns[page] {
+		col.Hidden = hiddenSet[col.Key]
+		if col.Hidden {
+			prefs.HiddenColumns = append(prefs.HiddenColumns, col.Key)
+		}
+		prefs.Columns = append(prefs.Columns, col)
+	}
+	return prefs
+}
+
+// sanitizeFilterQuery keeps only the filter parameters known for the page and
+// re-encodes them, so a saved filter can never smuggle arbitrary parameters.
+func sanitizeFilterQuery(page, raw string) string {
+	parsed, err := url.ParseQuery(strings.TrimPrefix(raw, "?"))
+	if err != nil {
+		return ""
+	}
+	clean := url.Values{}
+	for _, key := range listViewFilterParams[page] {
+		if v := strings.TrimSpace(parsed.Get(key)); v != "" {
+			clean.Set(key, v)
+		}
+	}
+	return clean.Encode()
+}
+
+// viewPrefsContext validates the :page route param and the current admin.
+// It writes the error response itself and returns ok=false on failure.
+func (h *GUIHandler) viewPrefsContext(c *gin.Context) (string, uuid.UUID, bool) {
+	page := c.Param("page")
+	if _, known := listViewColumns[page]; !known {
+		c.String(http.StatusNotFound, "Unknown page")
+		return "", uuid.Nil, false
+	}
+	if h.ViewPrefRepo == nil {
+		c.String(http.StatusServiceUnavailable, "Saved filters are unavailable")
+		return "", uuid.Nil, false
+	}
+	adminID, err := uuid.Parse(getAdminID(c))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Not authenticated")
+		return "", uuid.Nil, false
+	}
+	return page, adminID, true
+}
+
+// renderSavedFilters re-renders the saved filters dropdown for a page.
+func (h *GUIHandler) renderSavedFilters(c *gin.Context, page, errMsg string) {
+	prefs := h.loadListViewPrefs(c, page)
+	prefs.Error = errMsg
+	c.HTML(http.StatusOK, "saved_filters", prefs)
+}
+
+// SavedFilterCreate saves the current filter combination under a name (HTMX fragment).
+// POST /gui/view-prefs/:page/filters
+func (h *GUIHandler) SavedFilterCreate(c *gin.Context) {
+	page, adminID, ok := h.viewPrefsContext(c)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		h.renderSavedFilters(c, page, "Filter name is required")
+		return
+	}
+	if len(name) > 100 {
+		h.renderSavedFilters(c, page, "Filter name must be 100 characters or less")
+		return
+	}
+
+	filter := &models.AdminSavedFilter{
+		AdminID:   adminID,
+		Page:      page,
+		Name:      name,
+		Query:     sanitizeFilterQuery(page, c.PostForm("query")),
+		IsDefault: c.PostForm("is_default") == "true" || c.PostForm("is_default") == "on",
+	}
+	if err := h.ViewPrefRepo.CreateSavedFilter(filter); err != nil {
+		h.renderSavedFilters(c, page, "Failed to save filter")
+		return
+	}
+	h.renderSavedFilters(c, page, "")
+}
+
+// SavedFilterSetDefault makes a saved filter the one applied on page load (HTMX fragment).
+// Toggling the current default clears it.
+// PUT /gui/view-prefs/:page/filters/:id/default
+func (h *GUIHandler) SavedFilterSetDefault(c *gin.Context) {
+	page, adminID, ok := h.viewPrefsContext(c)
+	if !ok {
+		return
+	}
+	filterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid filter ID")
+		return
+	}
+
+	// Clicking the star on the current default clears it
+	if c.PostForm("clear") == "true" {
+		filterID = uuid.Nil
+	}
+	if err := h.ViewPrefRepo.SetDefaultFilter(adminID, page, filterID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.renderSavedFilters(c, page, "Filter not found")
+			return
+		}
+		h.renderSavedFilters(c, page, "Failed to update default filter")
+		return
+	}
+	h.renderSavedFilters(c, page, "")
+}
+
+// SavedFilterDelete removes a saved filter (HTMX fragment).
+// DELETE /gui/view-prefs/:page/filters/:id
+func (h *GUIHandler) SavedFilterDelete(c *gin.Context) {
+	page, adminID, ok := h.viewPrefsContext(c)
+	if !ok {
+		return
+	}
+	filterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid filter ID")
+		return
+	}
+	if err := h.ViewPrefRepo.DeleteSavedFilter(adminID, filterID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		h.renderSavedFilters(c, page, "Failed to delete filter")
+		return
+	}
+	h.renderSavedFilters(c, page, "")
+}
+
+// ColumnPreferencesSave stores which columns are hidden on a list view.
+// The form carries one "visible" value per checked column key; every known
+// column not listed is stored as hidden.
+// PUT /gui/view-prefs/:page/columns
+func (h *GUIHandler) ColumnPreferencesSave(c *gin.Context) {
+	page, adminID, ok := h.viewPrefsContext(c)
+	if !ok {
+		return
+	}
+
+	visible := make(map[string]bool)
+	for _, key := range c.PostFormArray("visible") {
+		visible[key] = true
+	}
+	var hidden []string
+	for _, col := range listViewColumns[page] {
+		if !visible[col.Key] {
+			hidden = append(hidden, col.Key)
+		}
+	}
+
+	if err := h.ViewPrefRepo.UpsertHiddenColumns(adminID, page, strings.Join(hidden, ",")); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to save column preferences")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"testing"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// sanitizeFilterQuery tests
+// ---------------------------------------------------------------------------
+
+func TestSanitizeFilterQueryDropsUnknownParams(t *testing.T) {
+	got := sanitizeFilterQuery("logs", "?page=3&event_type=LOGIN_FAILED&since=24h&evil=1&search=")
+	want := "event_type=LOGIN_FAILED&since=24h"
+	if got != want {
+		t.Errorf("sanitizeFilterQuery(logs) = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeFilterQueryPerPage(t *testing.T) {
+	// event_type is a logs filter and must not survive on the users page.
+	got := sanitizeFilterQuery("users", "event_type=LOGIN&search=alice%40example.com")
+	want := "search=alice%40example.com"
+	if got != want {
+		t.Errorf("sanitizeFilterQuery(users) = %q, want %q", got, want)
+	}
+
+	if got := sanitizeFilterQuery("unknown", "search=x"); got != "" {
+		t.Errorf("sanitizeFilterQuery(unknown) = %q, want empty", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// resolveLogSince tests
+// ---------------------------------------------------------------------------
+
+func TestResolveLogSince(t *testing.T) {
+	if got := resolveLogSince("", "2026-01-02"); got != "2026-01-02" {
+		t.Errorf("empty since should keep start_date, got %q", got)
+	}
+	if got := resolveLogSince("2w", "2026-01-02"); got != "2026-01-02" {
+		t.Errorf("unknown since should keep start_date, got %q", got)
+	}
+
+	got := resolveLogSince("24h", "2026-01-02")
+	ts, err := time.Parse(time.RFC3339, got)
+	if err != nil {
+		t.Fatalf("since=24h should yield an RFC 3339 timestamp, got %q: %v", got, err)
+	}
+	if d := time.Since(ts); d < 23*time.Hour || d > 25*time.Hour {
+		t.Errorf("since=24h resolved to %v ago, want ~24h", d)
+	}
+}
@@ -1,23 +1,47 @@
 package admin
 
 import (
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"math"
 	"net/http"
+	"net/mail"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/bruteforce"
+	"github.com/gjovanovicst/auth_api/internal/claimmap"
+	"github.com/gjovanovicst/auth_api/internal/disposable"
+	"github.com/gjovanovicst/auth_api/internal/dnscheck"
 	"github.com/gjovanovicst/auth_api/internal/email"
 	"github.com/gjovanovicst/auth_api/internal/geoip"
+	"github.com/gjovanovicst/auth_api/internal/health"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/jwtkeys"
+	"github.com/gjovanovicst/auth_api/internal/metricshistory"
+	"github.com/gjovanovicst/auth_api/internal/notification"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/internal/reload"
+	"github.com/gjovanovicst/auth_api/internal/session"
 	"github.com/gjovanovicst/auth_api/internal/twofa"
 	userimport "github.com/gjovanovicst/auth_api/internal/user"
+	"github.com/gjovanovicst/auth_api/internal/usersync"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/gjovanovicst/auth_api/web"
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
@@ -27,6 +51,18 @@ type Handler struct {
 	IPRuleEvaluator   *geoip.IPRuleEvaluator         // IP rule evaluator for cache invalidation (nil = disabled)
 	TrustedDeviceRepo *twofa.TrustedDeviceRepository // Optional: trusted device management (nil = disabled)
 	GeoIPService      *geoip.Service                 // GeoIP service for IP access checks (nil = disabled)
+	StatsService      *StatsService                  // Per-app statistics reports (nil = disabled)
+	Notifications     *notification.Service          // Admin notification center (nil = disabled)
+	JobQueue          *jobqueue.Queue                // Background job queue for async imports (nil = disabled)
+	DashboardService  *DashboardService              // Dashboard aggregates for /admin/dashboard (nil = disabled)
+	BruteForceService *bruteforce.Service            // Brute-force counters reset on unlock (nil = disabled)
+	DisposableEmails  *disposable.Blocklist          // Disposable email blocklist for domain checks (nil = per-app lists only)
+	HealthHandler     *health.Handler                // Datastore latency and HTTP error rates for support bundles (nil = omitted)
+	DNSChecker        *dnscheck.Checker              // Sending domain verification lookups (nil = system resolver)
+	JWTKeys           *jwtkeys.Service               // RS256/ES256 token signing keys (nil = key endpoints disabled)
+	Reloader          *reload.Reloader               // Live configuration reload (nil = reload endpoint disabled)
+	MetricsHistory    *metricshistory.Service        // Embedded metrics history (nil = history endpoint disabled)
+	UserSync          *usersync.Service              // User change events for downstream sync (nil = backfill disabled)
 }
 
 func NewHandler(r *Repository, emailService *email.Service) *Handler {
@@ -53,20 +89,108 @@ func (h *Handler) CreateTenant(c *gin.Context) {
 	}
 
 	tenant := &models.Tenant{
-		Name: req.Name,
+		Name:              req.Name,
+		BillingCustomerID: req.BillingCustomerID,
+		MaxApps:           req.MaxApps,
 	}
 
 	if err := h.Repo.CreateTenant(tenant); err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create tenant"})
 		return
 	}
+	h.Notifications.TenantCreated(tenant.ID, tenant.Name)
 
-	c.JSON(http.StatusCreated, dto.TenantResponse{
-		ID:        tenant.ID,
-		Name:      tenant.Name,
-		CreatedAt: tenant.CreatedAt,
-		UpdatedAt: tenant.UpdatedAt,
-	})
+	c.JSON(http.StatusCreated, toTenantResponse(tenant))
+}
+
+// GetTenant retrieves a tenant
+// @Summary Get a tenant
+// @Description Retrieve a tenant by ID. The response carries an ETag header usable with If-Match on writes and If-None-Match on reads.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "Tenant ID"
+// @Success 200 {object} dto.TenantResponse
+// @Success 304 "Not modified (If-None-Match matched)"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/{id} [get]
+func (h *Handler) GetTenant(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tenant ID"})
+		return
+	}
+
+	tenant, err := h.Repo.GetTenantByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load tenant"})
+		return
+	}
+
+	if writeETag(c, resourceETag(tenant.ID, tenant.UpdatedAt)) {
+		return
+	}
+	c.JSON(http.StatusOK, toTenantResponse(tenant))
+}
+
+// DeleteTenant deletes a tenant
+// @Summary Delete a tenant
+// @Description Delete a tenant. Send If-Match with the tenant's ETag to delete only if it has not changed since it was read.
+// @Tags Admin
+// @Produce json
+// @Param   id        path    string  true   "Tenant ID"
+// @Param   If-Match  header  string  false  "ETag the tenant must still have"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/{id} [delete]
+func (h *Handler) DeleteTenant(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tenant ID"})
+		return
+	}
+
+	tenant, err := h.Repo.GetTenantByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load tenant"})
+		return
+	}
+	if etag := resourceETag(tenant.ID, tenant.UpdatedAt); readPreconditions(c).check(etag) != nil {
+		abortPreconditionFailed(c, etag)
+		return
+	}
+
+	if err := h.Repo.DeleteTenant(id); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete tenant"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Tenant deleted"})
+}
+
+// toTenantResponse maps a tenant to its API representation.
+func toTenantResponse(tenant *models.Tenant) dto.TenantResponse {
+	return dto.TenantResponse{
+		ID:                tenant.ID,
+		Name:              tenant.Name,
+		BillingCustomerID: tenant.BillingCustomerID,
+		MaxApps:           tenant.MaxApps,
+		ExternalID:        tenant.ExternalID,
+		CreatedAt:         tenant.CreatedAt,
+		UpdatedAt:         tenant.UpdatedAt,
+	}
 }
 
 // ListTenants lists all tenants with pagination
@@ -75,39 +199,36 @@ func (h *Handler) CreateTenant(c *gin.Context) {
 // @Tags Admin
 // @Accept json
 // @Produce json
-// @Param   page       query     int     false  "Page number" default(1)
-// @Param   page_size  query     int     false  "Page size" default(10)
+// @Param   page                 query     int     false  "Page number" default(1)
+// @Param   page_size            query     int     false  "Page size (max 100)" default(10)
+// @Param   sort                 query     string  false  "Sort field: name, created_at or updated_at (prefix with - for descending)" default(created_at)
+// @Param   order                query     string  false  "Sort order: asc or desc" default(desc)
+// @Param   filter[name]         query     string  false  "Name contains (case-insensitive)"
+// @Param   filter[external_id]  query     string  false  "External ID"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/tenants [get]
 func (h *Handler) ListTenants(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), tenantListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
 
-	tenants, total, err := h.Repo.ListTenants(page, pageSize)
+	tenants, total, err := h.Repo.ListTenants(q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list tenants"})
 		return
 	}
 
 	var response []dto.TenantResponse
-	for _, t := range tenants {
-		response = append(response, dto.TenantResponse{
-			ID:        t.ID,
-			Name:      t.Name,
-			CreatedAt: t.CreatedAt,
-			UpdatedAt: t.UpdatedAt,
-		})
+	for i := range tenants {
+		response = append(response, toTenantResponse(&tenants[i]))
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":        response,
-		"total":       total,
-		"page":        page,
-		"page_size":   pageSize,
-		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-	})
+	c.JSON(http.StatusOK, q.Response(response, total))
 }
 
 // CreateApp creates a new application for a tenant
@@ -119,6 +240,7 @@ func (h *Handler) ListTenants(c *gin.Context) {
 // @Param   app  body      dto.CreateAppRequest  true  "Application Creation Data"
 // @Success 201 {object} dto.AppResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse "Tenant application quota exceeded"
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/apps [post]
@@ -135,14 +257,36 @@ func (h *Handler) CreateApp(c *gin.Context) {
 		return
 	}
 
+	if err := quota.CheckTenantApps(h.Repo.DB, tenantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		appErr := quota.ToAppError(err)
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
 	app := &models.Application{
-		TenantID:          tenantID,
-		Name:              req.Name,
-		Description:       req.Description,
-		FrontendURL:       req.FrontendURL,
-		ResetPasswordPath: req.ResetPasswordPath,
-		MagicLinkPath:     req.MagicLinkPath,
-		VerifyEmailPath:   req.VerifyEmailPath,
+		TenantID:            tenantID,
+		Name:                req.Name,
+		Description:         req.Description,
+		FrontendURL:         req.FrontendURL,
+		MagicLinkEnabled:    req.MagicLinkEnabled,
+		PasswordlessOnly:    req.PasswordlessOnly,
+		ResetPasswordPath:   req.ResetPasswordPath,
+		MagicLinkPath:       req.MagicLinkPath,
+		VerifyEmailPath:     req.VerifyEmailPath,
+		AllowedRedirectURLs: req.AllowedRedirectURLs,
+		BlockedEmailDomains: req.BlockedEmailDomains,
+		SocialCallbackMode:  req.SocialCallbackMode,
+		// Email verification by code
+		EmailVerificationCode: req.EmailVerificationCode,
+		// Registration approval
+		RegistrationApprovalRequired: req.RegistrationApprovalRequired,
+		// Concurrent session limit
+		MaxSessionsPerUser: req.MaxSessionsPerUser,
+		SessionLimitPolicy: sessionLimitPolicyOrDefault(req.SessionLimitPolicy),
 	}
 
 	if err := h.Repo.CreateApp(app); err != nil {
@@ -153,43 +297,23 @@ func (h *Handler) CreateApp(c *gin.Context) {
 	// Seed default RBAC roles for the new application
 	if err := h.Repo.SeedDefaultRolesForApp(app.ID); err != nil {
 		// Log but don't fail — the app was created, roles can be seeded later
-		c.JSON(http.StatusCreated, dto.AppResponse{
-			ID:                app.ID,
-			TenantID:          app.TenantID,
-			Name:              app.Name,
-			Description:       app.Description,
-			FrontendURL:       app.FrontendURL,
-			ResetPasswordPath: app.ResetPasswordPath,
-			MagicLinkPath:     app.MagicLinkPath,
-			VerifyEmailPath:   app.VerifyEmailPath,
-			CreatedAt:         app.CreatedAt,
-			UpdatedAt:         app.UpdatedAt,
-		})
+		c.JSON(http.StatusCreated, toAppResponse(app))
 		return
 	}
 
-	c.JSON(http.StatusCreated, dto.AppResponse{
-		ID:                app.ID,
-		TenantID:          app.TenantID,
-		Name:              app.Name,
-		Description:       app.Description,
-		FrontendURL:       app.FrontendURL,
-		ResetPasswordPath: app.ResetPasswordPath,
-		MagicLinkPath:     app.MagicLinkPath,
-		VerifyEmailPath:   app.VerifyEmailPath,
-		CreatedAt:         app.CreatedAt,
-		UpdatedAt:         app.UpdatedAt,
-	})
+	c.JSON(http.StatusCreated, toAppResponse(app))
 }
 
 // GetAppDetails retrieves app details including OAuth configs
 // @Summary Get application details
-// @Description Retrieve details of a specific application including OAuth configurations
+// @Description Retrieve details of a specific application including OAuth configurations (without client secrets).
+// @Description The response carries an ETag header usable with If-Match on writes and If-None-Match on reads.
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Param   id   path      string  true  "Application ID"
 // @Success 200 {object} dto.AppResponse
+// @Success 304 "Not modified (If-None-Match matched)"
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Security AdminApiKey
@@ -202,21 +326,384 @@ func (h *Handler) GetAppDetails(c *gin.Context) {
 		return
 	}
 
-	// Simple mapping for now, ideally we'd map OAuth configs too in DTO
-	c.JSON(http.StatusOK, dto.AppResponse{
-		ID:                app.ID,
-		TenantID:          app.TenantID,
-		Name:              app.Name,
-		Description:       app.Description,
-		FrontendURL:       app.FrontendURL,
-		ResetPasswordPath: app.ResetPasswordPath,
-		MagicLinkPath:     app.MagicLinkPath,
-		VerifyEmailPath:   app.VerifyEmailPath,
-		CreatedAt:         app.CreatedAt,
-		UpdatedAt:         app.UpdatedAt,
+	if writeETag(c, resourceETag(app.ID, app.UpdatedAt)) {
+		return
+	}
+	c.JSON(http.StatusOK, toAppResponse(app))
+}
+
+// DeleteApp deletes an application
+// @Summary Delete an application
+// @Description Delete an application. Send If-Match with the application's ETag to delete only if it has not changed since it was read.
+// @Tags Admin
+// @Produce json
+// @Param   id        path    string  true   "Application ID"
+// @Param   If-Match  header  string  false  "ETag the application must still have"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id} [delete]
+func (h *Handler) DeleteApp(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+
+	app, err := h.Repo.GetAppByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load application"})
+		return
+	}
+	if etag := resourceETag(app.ID, app.UpdatedAt); readPreconditions(c).check(etag) != nil {
+		abortPreconditionFailed(c, etag)
+		return
+	}
+
+	if err := h.Repo.DeleteApp(id); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete application"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Application deleted"})
+}
+
+// toAppResponse maps an application to its API representation.
+func toAppResponse(app *models.Application) dto.AppResponse {
+	resp := dto.AppResponse{
+		ID:                  app.ID,
+		TenantID:            app.TenantID,
+		Name:                app.Name,
+		Description:         app.Description,
+		FrontendURL:         app.FrontendURL,
+		MagicLinkEnabled:    app.MagicLinkEnabled,
+		PasswordlessOnly:    app.PasswordlessOnly,
+		ResetPasswordPath:   app.ResetPasswordPath,
+		MagicLinkPath:       app.MagicLinkPath,
+		VerifyEmailPath:     app.VerifyEmailPath,
+		AllowedRedirectURLs: app.AllowedRedirectURLs,
+		BlockedEmailDomains: app.BlockedEmailDomains,
+		SocialCallbackMode:  app.SocialCallbackMode,
+		ParentAppID:         app.ParentAppID,
+		Environment:         app.Environment,
+		ShareUsers:          app.ShareUsers,
+		ExternalID:          app.ExternalID,
+		CreatedAt:           app.CreatedAt,
+		UpdatedAt:           app.UpdatedAt,
+		// Email verification by code
+		EmailVerificationCode: app.EmailVerificationCode,
+		// Registration approval
+		RegistrationApprovalRequired: app.RegistrationApprovalRequired,
+		// Concurrent session limit
+		MaxSessionsPerUser: app.MaxSessionsPerUser,
+		SessionLimitPolicy: app.SessionLimitPolicy,
+	}
+	for i := range app.OAuthProviderConfigs {
+		resp.OAuthConfigs = append(resp.OAuthConfigs, toOAuthConfigResponse(&app.OAuthProviderConfigs[i]))
+	}
+	return resp
+}
+
+// CloneApp creates a new application with the configuration of an existing one
+// @Summary Clone application configuration
+// @Description Create a new application from an existing application's configuration: feature flags, password
+// @Description policy, security and branding settings, OAuth provider configs, SMTP configs and email templates.
+// @Description Secrets are not copied — cloned OAuth configs are disabled until a client secret is set and cloned
+// @Description SMTP configs need their password re-entered. Users and API keys are not cloned.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   id     path      string               true  "Source application ID"
+// @Param   clone  body      dto.CloneAppRequest  true  "New application data"
+// @Success 201 {object} dto.CloneAppResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse "Tenant application quota exceeded"
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/clone [post]
+func (h *Handler) CloneApp(c *gin.Context) {
+	var req dto.CloneAppRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	source, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+
+	tenantID := source.TenantID
+	if req.TenantID != "" {
+		if tenantID, err = uuid.Parse(req.TenantID); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid Tenant ID"})
+			return
+		}
+	}
+
+	if err := quota.CheckTenantApps(h.Repo.DB, tenantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		appErr := quota.ToAppError(err)
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	result, err := h.Repo.CloneApp(source, tenantID, req.Name, req.Description)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to clone application"})
+		return
+	}
+
+	// Seed default RBAC roles for the new application (non-fatal on error)
+	if err := h.Repo.SeedDefaultRolesForApp(result.App.ID); err != nil {
+		log.Printf("Warning: failed to seed default roles for cloned app %s: %v", result.App.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, dto.CloneAppResponse{
+		AppResponse:          toAppResponse(result.App),
+		ClonedOAuthConfigs:   result.OAuthConfigs,
+		ClonedSMTPConfigs:    result.SMTPConfigs,
+		ClonedEmailTemplates: result.EmailTemplates,
 	})
 }
 
+// ForcePasswordRotation requires every user of an application to change their password
+// @Summary Force password rotation
+// @Description Require every user of the application whose password was set before now to change it at their
+// @Description next login, e.g. after a security incident. Their logins return a password change challenge
+// @Description instead of tokens until they do. Existing sessions are not revoked.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "Application ID"
+// @Success 200 {object} dto.ForcePasswordRotationResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/force-password-rotation [post]
+func (h *Handler) ForcePasswordRotation(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	now := time.Now().UTC()
+	users, err := h.Repo.ForcePasswordRotation(appID.String(), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to force password rotation"})
+		return
+	}
+	log.Printf("Forced password rotation for app %s (%d users with a password)\n", appID, users)
+
+	c.JSON(http.StatusOK, dto.ForcePasswordRotationResponse{
+		AppID:              appID.String(),
+		RotationRequiredAt: now,
+		UsersWithPassword:  users,
+	})
+}
+
+// RevokeAllAppSessions is the per-application session kill switch
+// @Summary Revoke all sessions of an application
+// @Description Emergency kill switch for incident response: every access and refresh token issued for the
+// @Description application until now is rejected, and all of its sessions, OIDC browser sessions and trusted
+// @Description devices are deleted. Users must sign in again; accounts and passwords are unchanged.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "Application ID"
+// @Success 200 {object} dto.RevokeAllAppSessionsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/revoke-all-sessions [post]
+func (h *Handler) RevokeAllAppSessions(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	result, err := h.Repo.RevokeAllAppSessions(appID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+			return
+		}
+		if result == nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to revoke application sessions"})
+			return
+		}
+		// The epoch is raised, so every token is already rejected
+		log.Printf("Warning: Kill switch for app %s incomplete: %v\n", appID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.RevokeAllAppSessionsResponse{
+		AppID:                 appID.String(),
+		AuthEpoch:             result.Epoch,
+		RevokedSessions:       result.Sessions,
+		RevokedTrustedDevices: result.TrustedDevices,
+	})
+}
+
+// ListEnvironments lists the environments of an application
+// @Summary List application environments
+// @Description List the development/staging/production environments of an application
+// @Tags Admin
+// @Produce json
+// @Param   id   path      string  true  "Application ID"
+// @Success 200 {array} dto.AppResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/environments [get]
+func (h *Handler) ListEnvironments(c *gin.Context) {
+	parent, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+
+	envs, err := h.Repo.ListEnvironments(parent.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list environments"})
+		return
+	}
+
+	response := make([]dto.AppResponse, 0, len(envs))
+	for i := range envs {
+		response = append(response, toAppResponse(&envs[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateEnvironment creates an environment of an application
+// @Summary Create an application environment
+// @Description Create a development, staging or production environment of an application. The environment
+// @Description copies the parent's non-secret settings and gets its own OAuth configs, SMTP configs, redirect
+// @Description URLs, API keys and signing key. Requests are routed to it by an app API key created for the
+// @Description environment. With share_users the environment uses the parent's user base.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   id   path      string                        true  "Parent application ID"
+// @Param   env  body      dto.CreateEnvironmentRequest  true  "Environment data"
+// @Success 201 {object} dto.AppResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse "Tenant application quota exceeded"
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse "Environment already exists"
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/environments [post]
+func (h *Handler) CreateEnvironment(c *gin.Context) {
+	var req dto.CreateEnvironmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	parent, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+	if parent.ParentAppID != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Environments cannot have environments of their own"})
+		return
+	}
+
+	existing, err := h.Repo.ListEnvironments(parent.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list environments"})
+		return
+	}
+	for _, env := range existing {
+		if env.Environment == req.Environment {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: fmt.Sprintf("Environment %q already exists", req.Environment)})
+			return
+		}
+	}
+
+	// Environments are applications and count against the tenant's quota.
+	if appErr := quota.ToAppError(quota.CheckTenantApps(h.Repo.DB, parent.TenantID)); appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	env, err := h.Repo.CreateEnvironment(parent, req.Environment, req.Name, req.FrontendURL, req.ShareUsers)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create environment"})
+		return
+	}
+
+	// Isolated environments manage their own users and need their own roles.
+	if !env.ShareUsers {
+		if err := h.Repo.SeedDefaultRolesForApp(env.ID); err != nil {
+			log.Printf("Warning: failed to seed default roles for environment %s: %v", env.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, toAppResponse(env))
+}
+
+// GetAppStats returns authentication statistics for an application over a date range.
+// @Summary Get per-app authentication statistics
+// @Description Registration counts, DAU/MAU, login success ratio, login provider breakdown, emails sent and 2FA
+// @Description adoption for an inclusive UTC date range (default: last 30 days, max 366 days). Past days are read
+// @Description from the nightly daily_app_metrics rollups; days not rolled up yet (normally today) are computed
+// @Description from activity logs. Results are cached briefly.
+// @Tags Admin
+// @Produce json
+// @Param   id    path   string  true   "Application ID"
+// @Param   from  query  string  false  "Start date (YYYY-MM-DD)"
+// @Param   to    query  string  false  "End date (YYYY-MM-DD, default: today)"
+// @Success 200 {object} dto.AppStatsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/stats [get]
+func (h *Handler) GetAppStats(c *gin.Context) {
+	if h.StatsService == nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "Statistics are not available"})
+		return
+	}
+
+	app, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+
+	from, to, err := ParseStatsRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	stats, err := h.StatsService.GetAppStats(app.ID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to compute statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
 // GetAppLoginConfig returns the public login configuration for an application.
 // It exposes only which social providers are enabled and whether OIDC/SSO is available.
 // No secrets are included. No authentication is required.
@@ -248,8 +735,22 @@ func (h *Handler) GetAppLoginConfig(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to retrieve provider config"})
 		return
 	}
-	if providers == nil {
-		providers = []string{}
+	// Providers the auth method policy does not permit are hidden from the login UI
+	allowedProviders := []string{}
+	for _, p := range providers {
+		method := p
+		if !models.IsBuiltinOAuthProvider(p) {
+			method = models.AuthMethodOIDC
+		}
+		if app.AuthMethodAllowed(method) {
+			allowedProviders = append(allowedProviders, p)
+		}
+	}
+	allowedMethods := []string{}
+	for _, m := range models.AuthMethods {
+		if app.AuthMethodAllowed(m) {
+			allowedMethods = append(allowedMethods, m)
+		}
 	}
 
 	hasClients, err := h.Repo.HasActiveOIDCClients(appIDStr)
@@ -266,15 +767,21 @@ func (h *Handler) GetAppLoginConfig(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.AppLoginConfigResponse{
 		AppID:                  appIDStr,
-		EnabledSocialProviders: providers,
+		EnabledSocialProviders: allowedProviders,
 		OIDCEnabled:            app.OIDCEnabled,
 		HasOIDCClients:         hasClients,
-		MagicLinkEnabled:       app.MagicLinkEnabled,
-		PasskeyLoginEnabled:    app.PasskeyLoginEnabled,
+		MagicLinkEnabled:       app.MagicLinkEnabled && app.AuthMethodAllowed(models.AuthMethodMagicLink),
+		PasskeyLoginEnabled:    app.PasskeyLoginEnabled && app.AuthMethodAllowed(models.AuthMethodPasskey),
+		PasswordlessOnly:       app.PasswordlessOnly,
 		TwoFAEnabled:           app.TwoFAEnabled,
 		TwoFARequired:          app.TwoFARequired,
 		SMS2FAEnabled:          app.SMS2FAEnabled,
 		TrustedDeviceEnabled:   app.TrustedDeviceEnabled,
+		EmailVerificationCode:  app.EmailVerificationCode,
+		// Registration approval
+		RegistrationApprovalRequired: app.RegistrationApprovalRequired,
+		// Auth method policy
+		AllowedAuthMethods: allowedMethods,
 		// Login Page Branding
 		LoginLogoURL:        app.LoginLogoURL,
 		LoginPrimaryColor:   app.LoginPrimaryColor,
@@ -300,8 +807,10 @@ func (h *Handler) GetAppLoginConfig(c *gin.Context) {
 // @Produce json
 // @Param   id      path      string                      true  "Application ID"
 // @Param   config  body      dto.UpsertOAuthConfigRequest true  "OAuth Config Data"
+// @Param   If-Unmodified-Since header string false "Replace an existing config only if it has not changed since this HTTP date"
 // @Success 200 {object} dto.OAuthConfigResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.EditConflictResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/apps/{id}/oauth-config [post]
@@ -313,38 +822,353 @@ func (h *Handler) UpsertOAuthConfig(c *gin.Context) {
 		return
 	}
 
-	var req dto.UpsertOAuthConfigRequest
+	var req dto.UpsertOAuthConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	guard, err := readUnmodifiedSince(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	claimMappings, err := parseClaimMappings(req.ClaimMappings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	config := &models.OAuthProviderConfig{
+		AppID:         appID,
+		Provider:      req.Provider,
+		ClientID:      req.ClientID,
+		ClientSecret:  req.ClientSecret,
+		RedirectURL:   req.RedirectURL,
+		IsEnabled:     true,
+		ClaimMappings: claimMappings,
+		// Just-in-time provisioning
+		ProvisioningMode:    provisioningModeOrDefault(req.ProvisioningMode),
+		AllowedEmailDomains: strings.TrimSpace(req.AllowedEmailDomains),
+		// Generic OpenID Connect providers
+		IssuerURL: strings.TrimSpace(req.IssuerURL),
+		Scopes:    strings.Join(strings.Fields(req.Scopes), " "),
+	}
+	if err := config.ValidateProvider(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.Repo.UpsertOAuthConfig(config, guard); err != nil {
+		if errors.Is(err, optlock.ErrConflict) {
+			if current, loadErr := h.Repo.GetOAuthConfigByProvider(appID, req.Provider); loadErr == nil {
+				abortEditConflict(c, current.UpdatedAt, toOAuthConfigResponse(current))
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save OAuth config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toOAuthConfigResponse(config))
+}
+
+// ListOAuthConfigs lists OAuth configs across applications
+// @Summary List OAuth configurations
+// @Description Retrieve a paginated list of OAuth provider configurations with their application and tenant names. Client secrets are never returned.
+// @Tags Admin
+// @Produce json
+// @Param   page                query     int     false  "Page number" default(1)
+// @Param   page_size           query     int     false  "Page size (max 100)" default(10)
+// @Param   sort                query     string  false  "Sort field: provider, app_name, tenant_name, created_at or updated_at (prefix with - for descending)" default(created_at)
+// @Param   order               query     string  false  "Sort order: asc or desc" default(desc)
+// @Param   filter[app_id]      query     string  false  "Application ID (also accepted as app_id)"
+// @Param   filter[provider]    query     string  false  "Provider: google, facebook or github"
+// @Param   filter[is_enabled]  query     bool    false  "Enabled state"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs [get]
+func (h *Handler) ListOAuthConfigs(c *gin.Context) {
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), oauthConfigListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	configs, total, err := h.Repo.ListOAuthConfigs(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list OAuth configs"})
+		return
+	}
+
+	response := make([]dto.OAuthConfigListItemResponse, 0, len(configs))
+	for _, cfg := range configs {
+		response = append(response, dto.OAuthConfigListItemResponse{
+			OAuthConfigResponse: dto.OAuthConfigResponse{
+				ID:          cfg.ID,
+				AppID:       cfg.AppID,
+				Provider:    cfg.Provider,
+				ClientID:    cfg.ClientID,
+				RedirectURL: cfg.RedirectURL,
+				IsEnabled:   cfg.IsEnabled,
+				ExternalID:  cfg.ExternalID,
+				CreatedAt:   cfg.CreatedAt,
+				UpdatedAt:   cfg.UpdatedAt,
+			},
+			AppName:    cfg.AppName,
+			TenantName: cfg.TenantName,
+		})
+	}
+
+	c.JSON(http.StatusOK, q.Response(response, total))
+}
+
+// GetOAuthConfig returns a single OAuth config
+// @Summary Get OAuth configuration
+// @Description Retrieve an OAuth provider configuration by ID. The client secret is never returned.
+// @Description The response carries an ETag header usable with If-Match on writes and If-None-Match on reads.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "OAuth config ID"
+// @Success 200 {object} dto.OAuthConfigResponse
+// @Success 304 "Not modified (If-None-Match matched)"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs/{id} [get]
+func (h *Handler) GetOAuthConfig(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid OAuth config ID"})
+		return
+	}
+
+	config, err := h.Repo.GetOAuthConfigByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "OAuth config not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load OAuth config"})
+		return
+	}
+
+	if writeETag(c, resourceETag(config.ID, config.UpdatedAt)) {
+		return
+	}
+	c.JSON(http.StatusOK, toOAuthConfigResponse(config))
+}
+
+// ToggleOAuthConfig enables or disables an OAuth config
+// @Summary Enable or disable OAuth configuration
+// @Description Set whether an OAuth provider configuration is enabled. Disabled providers are hidden from the login page and rejected at login.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   id       path  string                        true  "OAuth config ID"
+// @Param   request  body  dto.ToggleOAuthConfigRequest  true  "Enabled state"
+// @Success 200 {object} dto.OAuthConfigResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs/{id}/toggle [put]
+func (h *Handler) ToggleOAuthConfig(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid OAuth config ID"})
+		return
+	}
+
+	var req dto.ToggleOAuthConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	config := &models.OAuthProviderConfig{
-		AppID:        appID,
-		Provider:     req.Provider,
-		ClientID:     req.ClientID,
-		ClientSecret: req.ClientSecret,
-		RedirectURL:  req.RedirectURL,
-		IsEnabled:    true,
+	config, err := h.Repo.SetOAuthConfigEnabled(id, *req.IsEnabled)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "OAuth config not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update OAuth config"})
+		return
 	}
 
-	if err := h.Repo.UpsertOAuthConfig(config); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save OAuth config"})
+	c.JSON(http.StatusOK, toOAuthConfigResponse(config))
+}
+
+// DeleteOAuthConfig deletes an OAuth config
+// @Summary Delete OAuth configuration
+// @Description Remove an OAuth provider configuration from its application. Send If-Match with the config's ETag to delete only if it has not changed since it was read.
+// @Tags Admin
+// @Produce json
+// @Param   id        path    string  true   "OAuth config ID"
+// @Param   If-Match  header  string  false  "ETag the config must still have"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs/{id} [delete]
+func (h *Handler) DeleteOAuthConfig(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid OAuth config ID"})
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.OAuthConfigResponse{
+	if pre := readPreconditions(c); pre.ifMatch != "" {
+		config, err := h.Repo.GetOAuthConfigByID(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "OAuth config not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load OAuth config"})
+			return
+		}
+		if etag := resourceETag(config.ID, config.UpdatedAt); pre.check(etag) != nil {
+			abortPreconditionFailed(c, etag)
+			return
+		}
+	}
+
+	if err := h.Repo.DeleteOAuthConfig(id); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete OAuth config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "OAuth config deleted"})
+}
+
+// toOAuthConfigResponse maps an OAuth config to its API representation (without the secret).
+func toOAuthConfigResponse(config *models.OAuthProviderConfig) dto.OAuthConfigResponse {
+	return dto.OAuthConfigResponse{
 		ID:          config.ID,
 		AppID:       config.AppID,
 		Provider:    config.Provider,
 		ClientID:    config.ClientID,
 		RedirectURL: config.RedirectURL,
 		IsEnabled:   config.IsEnabled,
+		ExternalID:  config.ExternalID,
 		CreatedAt:   config.CreatedAt,
 		UpdatedAt:   config.UpdatedAt,
+		// Claim mappings
+		ClaimMappings: json.RawMessage(config.ClaimMappings),
+		// Just-in-time provisioning
+		ProvisioningMode:    config.ProvisioningMode,
+		AllowedEmailDomains: config.AllowedEmailDomains,
+		// Generic OpenID Connect providers
+		IssuerURL: config.IssuerURL,
+		Scopes:    config.Scopes,
+	}
+}
+
+// sessionLimitPolicyOrDefault returns policy, or the default "evict_oldest" when empty.
+func sessionLimitPolicyOrDefault(policy string) string {
+	if policy == "" {
+		return session.LimitPolicyEvictOldest
+	}
+	return policy
+}
+
+// provisioningModeOrDefault returns mode, or the default "auto" when empty.
+func provisioningModeOrDefault(mode string) string {
+	if mode == "" {
+		return models.ProvisioningModeAuto
+	}
+	return mode
+}
+
+// parseClaimMappings validates claim mapping rules (see internal/claimmap).
+// An empty, null or empty-array mapping is stored as NULL.
+func parseClaimMappings(raw []byte) (datatypes.JSON, error) {
+	mapping, err := claimmap.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(mapping.Rules) == 0 {
+		return nil, nil
+	}
+	return datatypes.JSON(raw), nil
+}
+
+// formatClaimMappings returns claim mappings as indented JSON for editing.
+func formatClaimMappings(mappings datatypes.JSON) string {
+	if len(mappings) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, mappings, "", "  "); err != nil {
+		return string(mappings)
+	}
+	return buf.String()
+}
+
+// ============================================================================
+// Dashboard
+// ============================================================================
+
+// GetDashboardStats returns the aggregate counts shown on the admin dashboard
+// @Summary Get dashboard statistics
+// @Description Returns system-wide counts of users, tenants, applications, recent events, sessions, trusted devices and verified phones.
+// @Description Counts come from a snapshot refreshed every minute; generated_at tells when it was computed.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} dto.DashboardStatsResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/dashboard/stats [get]
+func (h *Handler) GetDashboardStats(c *gin.Context) {
+	stats, err := h.DashboardService.GetStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load dashboard stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DashboardStatsResponse{
+		TotalUsers:         stats.TotalUsers,
+		ActiveUsers:        stats.ActiveUsers,
+		InactiveUsers:      stats.InactiveUsers,
+		TotalTenants:       stats.TotalTenants,
+		TotalApps:          stats.TotalApps,
+		RecentEventsCount:  stats.RecentEventsCount,
+		ActiveSessions:     stats.ActiveSessions,
+		TrustedDeviceCount: stats.TrustedDeviceCount,
+		VerifiedPhoneCount: stats.VerifiedPhoneCount,
+		GeneratedAt:        stats.GeneratedAt,
 	})
 }
 
+// GetDashboardActivity returns the most recent activity log entries
+// @Summary Get recent dashboard activity
+// @Description Returns the most recent activity log entries across all applications, newest first
+// @Tags Admin
+// @Produce json
+// @Param   limit  query  int  false  "Maximum entries (1-100, default 10)"
+// @Success 200 {array} models.ActivityLog
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/dashboard/activity [get]
+func (h *Handler) GetDashboardActivity(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	logs, err := h.DashboardService.GetRecentActivity(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load recent activity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
 // ============================================================================
 // Email Type Management
 // ============================================================================
@@ -388,6 +1212,71 @@ func (h *Handler) GetEmailType(c *gin.Context) {
 	c.JSON(http.StatusOK, emailType)
 }
 
+// GetEmailTypeVariables returns the variables templates of an email type can reference
+// @Summary List template variables of an email type
+// @Description Returns the email type's declared variables followed by the well-known variables it does not declare, each with a sample value and its syntax for the go_template/raw_html and placeholder engines. The email type can be given by ID or code.
+// @Tags Admin - Email
+// @Produce json
+// @Param code path string true "Email Type ID or Code"
+// @Success 200 {object} dto.EmailTypeVariablesResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/email-types/{code}/variables [get]
+func (h *Handler) GetEmailTypeVariables(c *gin.Context) {
+	emailType := h.findEmailType(c.Param("code"))
+	if emailType == nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Email type not found"})
+		return
+	}
+
+	vars, err := email.TypeVariables(emailType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to read email type variables: " + err.Error()})
+		return
+	}
+
+	response := dto.EmailTypeVariablesResponse{
+		EmailTypeID:   emailType.ID.String(),
+		EmailTypeCode: emailType.Code,
+		Variables:     make([]dto.EmailTemplateVariableResponse, len(vars)),
+	}
+	for i, v := range vars {
+		response.Variables[i] = dto.EmailTemplateVariableResponse{
+			Name:              v.Name,
+			Description:       v.Description,
+			Required:          v.Required,
+			DefaultValue:      v.DefaultValue,
+			Source:            v.Source,
+			Declared:          v.Declared,
+			WellKnown:         v.WellKnown,
+			SampleValue:       v.SampleValue,
+			GoTemplateSyntax:  v.GoTemplate,
+			PlaceholderSyntax: v.Placeholder,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// findEmailType looks up an email type by ID, or by code if idOrCode is not a UUID.
+// Returns nil if no such type exists.
+func (h *Handler) findEmailType(idOrCode string) *models.EmailType {
+	var (
+		emailType *models.EmailType
+		err       error
+	)
+	if id, parseErr := uuid.Parse(idOrCode); parseErr == nil {
+		emailType, err = h.EmailService.GetEmailTypeByID(id)
+	} else {
+		emailType, err = h.EmailService.GetEmailTypeByCode(idOrCode)
+	}
+	if err != nil {
+		return nil
+	}
+	return emailType
+}
+
 // CreateEmailType creates a new custom email type
 // @Summary Create a custom email type
 // @Description Register a new custom email type for use in templates
@@ -412,6 +1301,12 @@ func (h *Handler) CreateEmailType(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Code and name are required"})
 		return
 	}
+	if req.Category == "" {
+		req.Category = models.EmailCategoryTransactional
+	} else if !models.IsValidEmailCategory(req.Category) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Category must be transactional, security or product"})
+		return
+	}
 
 	// Check for duplicate code
 	existing, _ := h.EmailService.GetEmailTypeByCode(req.Code)
@@ -436,6 +1331,7 @@ func (h *Handler) CreateEmailType(c *gin.Context) {
 		Name:           req.Name,
 		Description:    req.Description,
 		DefaultSubject: req.DefaultSubject,
+		Category:       req.Category,
 		Variables:      varsJSON,
 		IsSystem:       false,
 		IsActive:       true,
@@ -451,7 +1347,7 @@ func (h *Handler) CreateEmailType(c *gin.Context) {
 
 // UpdateEmailType updates an existing email type
 // @Summary Update an email type
-// @Description Update an existing email type's name, description, subject, or variables
+// @Description Update an existing email type's name, description, subject, category, or variables
 // @Tags Admin - Email
 // @Accept json
 // @Produce json
@@ -492,6 +1388,13 @@ func (h *Handler) UpdateEmailType(c *gin.Context) {
 	if req.DefaultSubject != "" {
 		emailType.DefaultSubject = req.DefaultSubject
 	}
+	if req.Category != "" {
+		if !models.IsValidEmailCategory(req.Category) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Category must be transactional, security or product"})
+			return
+		}
+		emailType.Category = req.Category
+	}
 	if req.Variables != nil {
 		varsJSON, err := json.Marshal(req.Variables)
 		if err != nil {
@@ -541,14 +1444,19 @@ func (h *Handler) DeleteEmailType(c *gin.Context) {
 
 // SendCustomEmail sends an email of a specific type to a recipient
 // @Summary Send an email
-// @Description Send an email of the specified type using app's SMTP config and templates
+// @Description Send an email of the specified type using app's SMTP config and templates. With an idempotency key (the
+// @Description idempotency_key field or the Idempotency-Key header), a repeated request within
+// @Description EMAIL_IDEMPOTENCY_WINDOW_HOURS is not sent again and answers with duplicate set; reusing the key for
+// @Description another email type or recipient is a 409.
 // @Tags Admin - Email
 // @Accept json
 // @Produce json
 // @Param id path string true "Application ID"
+// @Param Idempotency-Key header string false "Idempotency key (the request's idempotency_key takes precedence)"
 // @Param request body dto.SendEmailRequest true "Send Email Data"
 // @Success 200 {object} dto.SendEmailResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/apps/{id}/send-email [post]
@@ -605,18 +1513,259 @@ func (h *Handler) SendCustomEmail(c *gin.Context) {
 		vars = make(map[string]string)
 	}
 
-	if err := h.EmailService.SendEmail(appID, req.TypeCode, req.ToEmail, vars); err != nil {
+	idempotencyKey := req.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = c.GetHeader("Idempotency-Key")
+	}
+
+	result, err := h.EmailService.SendEmailIdempotent(appID, req.TypeCode, req.ToEmail, nil, vars, idempotencyKey)
+	switch {
+	case errors.Is(err, email.ErrInvalidIdempotencyKey):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, email.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to send email: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.SendEmailResponse{
+	resp := dto.SendEmailResponse{
 		Message:  "Email sent successfully",
 		TypeCode: req.TypeCode,
 		ToEmail:  req.ToEmail,
+	}
+	switch {
+	case result.Duplicate:
+		resp.Message = "Email already sent for this idempotency key"
+		resp.Duplicate = true
+	case result.DeferredUntil != nil:
+		resp.Message = "Email deferred until the application's quiet hours end"
+		resp.DeferredUntil = result.DeferredUntil
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SendEmailBatch queues an email of a specific type to many recipients
+// @Summary Send an email to many recipients
+// @Description Queue an email of the specified type for up to EMAIL_BATCH_MAX_RECIPIENTS recipients, each with optional
+// @Description variables overriding the batch variables. Invalid addresses, recipients missing a required variable,
+// @Description repeated addresses and addresses on the app's suppression list are skipped and listed in the response.
+// @Description The emails are sent by an email_batch background job at rate_per_second (capped at
+// @Description EMAIL_BATCH_MAX_RATE_PER_SECOND); GET /admin/jobs/{id} returns the status of every recipient. Product
+// @Description emails queued during the app's quiet hours start when the quiet hours end (deferred_until).
+// @Tags Admin - Email
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body dto.SendEmailBatchRequest true "Batch Send Data"
+// @Success 202 {object} dto.SendEmailBatchResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/send-email-batch [post]
+func (h *Handler) SendEmailBatch(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	if h.JobQueue == nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Background jobs are disabled on this server"})
+		return
+	}
+
+	var req dto.SendEmailBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.TypeCode == "" || len(req.Recipients) == 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "type_code and recipients are required"})
+		return
+	}
+	if max := email.BatchMaxRecipients(); len(req.Recipients) > max {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: fmt.Sprintf("A batch can have at most %d recipients", max)})
+		return
+	}
+
+	emailType, err := h.EmailService.GetEmailTypeByCode(req.TypeCode)
+	if err != nil || emailType == nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Email type not found: " + req.TypeCode})
+		return
+	}
+	if !emailType.IsActive {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Email type is not active: " + req.TypeCode})
+		return
+	}
+
+	recipients, skipped, err := h.EmailService.PrepareBatch(appID, emailType, req.Variables, req.Recipients)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to prepare batch: " + err.Error()})
+		return
+	}
+	if skipped == nil {
+		skipped = []dto.EmailBatchRecipientStatus{}
+	}
+	rate := email.BatchRate(req.RatePerSecond)
+
+	// During the app's quiet hours the batch starts when they end
+	runAfter := time.Now()
+	deferredUntil := h.EmailService.DeferUntil(appID, req.TypeCode, runAfter)
+	if deferredUntil != nil {
+		runAfter = *deferredUntil
+	}
+	job, err := h.JobQueue.EnqueueAt(email.JobTypeEmailBatch, email.BatchPayload{
+		AppID:         appID.String(),
+		TypeCode:      req.TypeCode,
+		Variables:     req.Variables,
+		Recipients:    recipients,
+		Skipped:       skipped,
+		RatePerSecond: rate,
+	}, "api", runAfter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to queue batch: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.SendEmailBatchResponse{
+		Job:           jobqueue.ToResponse(job),
+		Queued:        len(recipients),
+		Skipped:       skipped,
+		RatePerSecond: rate,
+		DeferredUntil: deferredUntil,
 	})
 }
 
+// ListEmailSuppressions lists the suppressed addresses of an application
+// @Summary List suppressed email addresses
+// @Description Addresses that batch email sends of the application skip (bounces, complaints, unsubscribes, manual entries), newest first by default.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, email, reason (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param filter[email] query string false "Filter by email (case-insensitive substring)"
+// @Param filter[reason] query string false "Filter by reason: bounce, complaint, unsubscribe, manual"
+// @Success 200 {object} dto.EmailSuppressionListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-suppressions [get]
+func (h *Handler) ListEmailSuppressions(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), email.SuppressionListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	q = q.WithFilter("app_id", appID.String())
+	suppressions, total, err := h.EmailService.ListSuppressions(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list suppressions: " + err.Error()})
+		return
+	}
+	resp := dto.EmailSuppressionListResponse{
+		Suppressions: make([]dto.EmailSuppressionResponse, len(suppressions)),
+		Total:        total,
+		Page:         q.Page,
+		PageSize:     q.PageSize,
+		TotalPages:   q.TotalPages(total),
+		Sort:         q.Sort,
+		Order:        q.Order,
+	}
+	for i, s := range suppressions {
+		resp.Suppressions[i] = toEmailSuppressionResponse(&s)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddEmailSuppression adds an address to an application's suppression list
+// @Summary Suppress an email address
+// @Description Batch email sends of the application skip the address. Adding an address that is already suppressed has no effect.
+// @Tags Admin - Email
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body dto.EmailSuppressionRequest true "Suppression Data"
+// @Success 201 {object} dto.EmailSuppressionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-suppressions [post]
+func (h *Handler) AddEmailSuppression(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	var req dto.EmailSuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "A valid email is required"})
+		return
+	}
+	suppression, err := h.EmailService.AddSuppression(appID, req.Email, req.Reason, req.Note)
+	if err != nil {
+		if errors.Is(err, email.ErrInvalidSuppressionReason) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to add suppression: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, toEmailSuppressionResponse(suppression))
+}
+
+// DeleteEmailSuppression removes an address from an application's suppression list
+// @Summary Remove a suppressed email address
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param email path string true "Email address"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-suppressions/{email} [delete]
+func (h *Handler) DeleteEmailSuppression(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	deleted, err := h.EmailService.DeleteSuppression(appID, c.Param("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to remove suppression: " + err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Email address is not suppressed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Suppression removed successfully"})
+}
+
+func toEmailSuppressionResponse(s *models.EmailSuppression) dto.EmailSuppressionResponse {
+	return dto.EmailSuppressionResponse{
+		Email:     s.Email,
+		Reason:    s.Reason,
+		Note:      s.Note,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
 // ============================================================================
 // Email Server Config Management (App-scoped - legacy endpoints)
 // ============================================================================
@@ -695,6 +1844,10 @@ func (h *Handler) SaveEmailServerConfig(c *gin.Context) {
 	}
 
 	if err := h.EmailService.SaveServerConfig(config); err != nil {
+		if errors.Is(err, email.ErrUnverifiedSenderDomain) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save email config"})
 		return
 	}
@@ -735,21 +1888,37 @@ func (h *Handler) DeleteEmailServerConfig(c *gin.Context) {
 
 // ListAllEmailServerConfigs returns all SMTP configs across all apps
 // @Summary List all SMTP configs
-// @Description Retrieve all SMTP server configurations across all applications
+// @Description Retrieve a paginated list of SMTP server configurations across all applications
 // @Tags Admin - Email Servers
 // @Produce json
-// @Success 200 {array} dto.EmailServerConfigResponse
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: name, smtp_host, created_at, updated_at (prefix with - for descending)" default(name)
+// @Param order query string false "Sort order: asc or desc" default(asc)
+// @Param filter[app_id] query string false "Application ID (also accepted as app_id)"
+// @Param filter[name] query string false "Filter by name (case-insensitive substring)"
+// @Param filter[smtp_host] query string false "Filter by SMTP host"
+// @Param filter[is_default] query bool false "Filter by default flag"
+// @Param filter[is_active] query bool false "Filter by active state"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/email-servers [get]
 func (h *Handler) ListAllEmailServerConfigs(c *gin.Context) {
-	configs, err := h.EmailService.GetAllServerConfigs()
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), email.ServerConfigListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	configs, total, err := h.EmailService.ListServerConfigs(q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list email server configs"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": configs})
+	c.JSON(http.StatusOK, q.Response(configs, total))
 }
 
 // GetEmailServerConfigByID returns a single SMTP config by its ID
@@ -859,6 +2028,10 @@ func (h *Handler) CreateEmailServerConfig(c *gin.Context) {
 	}
 
 	if err := h.EmailService.SaveServerConfig(config); err != nil {
+		if errors.Is(err, email.ErrUnverifiedSenderDomain) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create email server config"})
 		return
 	}
@@ -919,6 +2092,10 @@ func (h *Handler) UpdateEmailServerConfigByID(c *gin.Context) {
 	existing.IsActive = req.IsActive
 
 	if err := h.EmailService.SaveServerConfig(existing); err != nil {
+		if errors.Is(err, email.ErrUnverifiedSenderDomain) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update email server config"})
 		return
 	}
@@ -992,51 +2169,50 @@ func (h *Handler) SendTestEmailByConfigID(c *gin.Context) {
 // Email Template Management
 // ============================================================================
 
-// ListEmailTemplates returns all templates for an app or global defaults
+// ListEmailTemplates returns the templates of an app or the global defaults
 // @Summary List email templates
-// @Description Retrieve email templates for a specific app or global defaults
+// @Description Retrieve a paginated list of the email templates of a specific app or of the global defaults
 // @Tags Admin - Email
 // @Produce json
-// @Param app_id query string false "Application ID (omit for global defaults)"
-// @Success 200 {array} dto.EmailTemplateResponse
+// @Param filter[app_id] query string false "Application ID, also accepted as app_id (omit for global defaults)"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, updated_at, name (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(asc)
+// @Param filter[email_type_id] query string false "Filter by email type ID"
+// @Param filter[name] query string false "Filter by name (case-insensitive substring)"
+// @Param filter[template_engine] query string false "Filter by engine: go_template, placeholder, raw_html"
+// @Param filter[is_active] query bool false "Filter by active state"
+// @Param filter[external_id] query string false "Filter by external ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/email-templates [get]
 func (h *Handler) ListEmailTemplates(c *gin.Context) {
-	appIDStr := c.Query("app_id")
-
-	if appIDStr == "" {
-		templates, err := h.EmailService.GetGlobalDefaultTemplates()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list templates"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"data": templates})
-		return
-	}
-
-	appID, err := uuid.Parse(appIDStr)
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), email.TemplateListSpec)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	templates, err := h.EmailService.GetTemplatesByApp(appID)
+	templates, total, err := h.EmailService.ListTemplates(q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list templates"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": templates})
+	c.JSON(http.StatusOK, q.Response(templates, total))
 }
 
 // GetEmailTemplate returns a single template by ID
 // @Summary Get email template
-// @Description Retrieve a specific email template by ID
+// @Description Retrieve a specific email template by ID. The response carries an ETag header usable with If-Match on writes and If-None-Match on reads.
 // @Tags Admin - Email
 // @Produce json
 // @Param id path string true "Template ID"
 // @Success 200 {object} dto.EmailTemplateResponse
+// @Success 304 "Not modified (If-None-Match matched)"
 // @Failure 404 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/email-templates/{id} [get]
@@ -1054,20 +2230,25 @@ func (h *Handler) GetEmailTemplate(c *gin.Context) {
 		return
 	}
 
+	if writeETag(c, resourceETag(tmpl.ID, tmpl.UpdatedAt)) {
+		return
+	}
 	c.JSON(http.StatusOK, tmpl)
 }
 
 // SaveEmailTemplate creates or updates an email template
 // @Summary Save email template
-// @Description Create or update an email template for a specific app or as global default
+// @Description Create or update an email template for a specific app or as global default. The template is linted with its engine first; problems such as parse errors or variables the email type does not declare are returned as warnings and do not prevent the save.
 // @Tags Admin - Email
 // @Accept json
 // @Produce json
 // @Param app_id query string false "Application ID (omit for global default)"
 // @Param email_type_id query string true "Email Type ID"
 // @Param template body dto.EmailTemplateRequest true "Template Data"
-// @Success 200 {object} map[string]string
+// @Param If-Unmodified-Since header string false "Replace an existing template only if it has not changed since this HTTP date"
+// @Success 200 {object} dto.EmailTemplateSaveResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.EditConflictResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/email-templates [post]
@@ -1091,6 +2272,11 @@ func (h *Handler) SaveEmailTemplate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
+	guard, err := readUnmodifiedSince(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
 
 	tmpl := &models.EmailTemplate{
 		Name:           req.Name,
@@ -1101,9 +2287,20 @@ func (h *Handler) SaveEmailTemplate(c *gin.Context) {
 		IsActive:       req.IsActive,
 	}
 
+	warnings, err := h.EmailService.LintTemplate(tmpl, emailTypeID)
+	if err != nil {
+		log.Printf("Failed to lint email template for type %s: %v", emailTypeID, err)
+	}
+
 	if appIDStr == "" {
 		// Global default
-		if err := h.EmailService.SaveGlobalTemplate(emailTypeID, tmpl); err != nil {
+		if err := h.EmailService.SaveGlobalTemplate(emailTypeID, tmpl, guard); err != nil {
+			if errors.Is(err, optlock.ErrConflict) {
+				if current, loadErr := h.EmailService.GetGlobalDefaultTemplates(); loadErr == nil {
+					abortEmailTemplateConflict(c, current, emailTypeID)
+					return
+				}
+			}
 			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save template"})
 			return
 		}
@@ -1113,23 +2310,160 @@ func (h *Handler) SaveEmailTemplate(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid app_id"})
 			return
 		}
-		if err := h.EmailService.SaveAppTemplate(appID, emailTypeID, tmpl); err != nil {
+		if err := h.EmailService.SaveAppTemplate(appID, emailTypeID, tmpl, guard); err != nil {
+			if errors.Is(err, optlock.ErrConflict) {
+				if current, loadErr := h.EmailService.GetTemplatesByApp(appID); loadErr == nil {
+					abortEmailTemplateConflict(c, current, emailTypeID)
+					return
+				}
+			}
 			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save template"})
 			return
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Email template saved successfully"})
+	c.JSON(http.StatusOK, dto.EmailTemplateSaveResponse{
+		Message:  "Email template saved successfully",
+		Warnings: emailTemplateWarnings(warnings),
+	})
+}
+
+// ValidateEmailTemplates lints every stored email template
+// @Summary Validate all email templates
+// @Description Lint every email template against the variables of its email type and report the templates with warnings (parse errors, unknown variables, unsupported raw_html actions). Use it after changing email type variables to find templates that no longer match.
+// @Tags Admin - Email
+// @Produce json
+// @Success 200 {object} dto.EmailTemplateValidationResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/email-templates/validate [get]
+func (h *Handler) ValidateEmailTemplates(c *gin.Context) {
+	results, err := h.EmailService.LintAllTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to validate templates: " + err.Error()})
+		return
+	}
+
+	response := dto.EmailTemplateValidationResponse{
+		Checked:   len(results),
+		Templates: []dto.EmailTemplateValidationResult{},
+	}
+	for _, r := range results {
+		if len(r.Warnings) == 0 {
+			continue
+		}
+		var appID *string
+		if r.Template.AppID != nil {
+			id := r.Template.AppID.String()
+			appID = &id
+		}
+		response.Templates = append(response.Templates, dto.EmailTemplateValidationResult{
+			TemplateID:     r.Template.ID.String(),
+			Name:           r.Template.Name,
+			AppID:          appID,
+			EmailTypeCode:  r.Template.EmailType.Code,
+			TemplateEngine: r.Template.TemplateEngine,
+			Warnings:       emailTemplateWarnings(r.Warnings),
+		})
+	}
+	response.Broken = len(response.Templates)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetEmailTemplateTestMatrix renders every template an application can send with
+// @Summary Test the email templates of an application
+// @Description Render, for every active email type, the application's own template, the global default and, when neither exists, the built-in default with sample variables and the app's name, frontend URL and branding. Reports render errors, required variables of the email type a template does not reference, and lint warnings. Use it before replacing the global defaults of an app with custom templates; effective marks the template the app's emails are sent with.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} dto.EmailTemplateTestMatrixResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/email-templates/test-matrix [get]
+func (h *Handler) GetEmailTemplateTestMatrix(c *gin.Context) {
+	app, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+	entries, err := h.EmailService.TemplateTestMatrix(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to test templates: " + err.Error()})
+		return
+	}
+
+	response := dto.EmailTemplateTestMatrixResponse{
+		AppID:   app.ID.String(),
+		Checked: len(entries),
+		Results: make([]dto.EmailTemplateTestResult, len(entries)),
+	}
+	for i := range entries {
+		e := &entries[i]
+		result := dto.EmailTemplateTestResult{
+			EmailTypeCode:    e.EmailType.Code,
+			EmailTypeName:    e.EmailType.Name,
+			Source:           e.Source,
+			Effective:        e.Effective,
+			OK:               e.OK(),
+			Subject:          e.Subject,
+			RenderError:      e.RenderError,
+			MissingVariables: e.MissingVariables,
+			Warnings:         emailTemplateWarnings(e.Warnings),
+		}
+		if result.MissingVariables == nil {
+			result.MissingVariables = []string{}
+		}
+		if e.Template != nil {
+			result.Name = e.Template.Name
+			result.TemplateEngine = e.Template.TemplateEngine
+			if e.Template.ID != uuid.Nil {
+				id := e.Template.ID.String()
+				result.TemplateID = &id
+			}
+		}
+		if !result.OK {
+			response.Failed++
+		}
+		response.Results[i] = result
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// emailTemplateWarnings converts lint warnings for API responses.
+func emailTemplateWarnings(warnings []email.LintWarning) []dto.EmailTemplateWarning {
+	out := make([]dto.EmailTemplateWarning, len(warnings))
+	for i, w := range warnings {
+		out[i] = dto.EmailTemplateWarning{Field: w.Field, Code: w.Code, Variable: w.Variable, Message: w.Message}
+	}
+	return out
+}
+
+// abortEmailTemplateConflict answers a conflicting SaveEmailTemplate with the
+// current template for emailTypeID among templates.
+func abortEmailTemplateConflict(c *gin.Context, templates []models.EmailTemplate, emailTypeID uuid.UUID) {
+	for i := range templates {
+		if templates[i].EmailTypeID == emailTypeID {
+			abortEditConflict(c, templates[i].UpdatedAt, templates[i])
+			return
+		}
+	}
+	c.JSON(http.StatusConflict, dto.ErrorResponse{Error: optlock.ErrConflict.Error()})
 }
 
 // DeleteEmailTemplate removes an email template
 // @Summary Delete email template
-// @Description Remove an email template by ID
+// @Description Remove an email template by ID. Send If-Match with the template's ETag to delete only if it has not changed since it was read.
 // @Tags Admin - Email
 // @Produce json
 // @Param id path string true "Template ID"
+// @Param If-Match header string false "ETag the template must still have"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/email-templates/{id} [delete]
@@ -1141,6 +2475,18 @@ func (h *Handler) DeleteEmailTemplate(c *gin.Context) {
 		return
 	}
 
+	if pre := readPreconditions(c); pre.ifMatch != "" {
+		tmpl, err := h.EmailService.GetTemplateByID(id)
+		if err != nil || tmpl == nil {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Template not found"})
+			return
+		}
+		if etag := resourceETag(tmpl.ID, tmpl.UpdatedAt); pre.check(etag) != nil {
+			abortPreconditionFailed(c, etag)
+			return
+		}
+	}
+
 	if err := h.EmailService.DeleteTemplate(id); err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete template"})
 		return
@@ -1151,13 +2497,16 @@ func (h *Handler) DeleteEmailTemplate(c *gin.Context) {
 
 // PreviewEmailTemplate renders a template with sample data
 // @Summary Preview email template
-// @Description Render a template with sample variables for preview
+// @Description Render a template with the given variables for preview. With user_id, the variables are resolved
+// @Description as for a real email to that user (profile fields, app settings and branding); the access to the
+// @Description user's data is recorded in their activity log.
 // @Tags Admin - Email
 // @Accept json
 // @Produce json
 // @Param preview body dto.EmailPreviewRequest true "Preview Data"
 // @Success 200 {object} dto.EmailPreviewResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/email-templates/preview [post]
@@ -1175,6 +2524,31 @@ func (h *Handler) PreviewEmailTemplate(c *gin.Context) {
 		TemplateEngine: req.TemplateEngine,
 	}
 
+	if req.UserID != "" {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+			return
+		}
+		previewedBy := "admin_api"
+		if v, ok := c.Get(web.ApiKeyIDKey); ok {
+			if id, ok := v.(uuid.UUID); ok {
+				previewedBy = "admin_api_key:" + id.String()
+			}
+		}
+		resp, err := previewEmailAsUser(c, h.EmailService, tmpl, userID, req.Variables, previewedBy)
+		if errors.Is(err, email.ErrPreviewUserNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "User not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to preview template: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
 	subject, htmlBody, textBody, err := h.EmailService.PreviewTemplate(tmpl, req.Variables)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to preview template: " + err.Error()})
@@ -1745,6 +3119,237 @@ func (h *Handler) AdminRevokeAllTrustedDevices(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "All trusted devices revoked"})
 }
 
+// ============================================================
+// User Listing (Admin REST API)
+// ============================================================
+
+// ListUsers returns users newest first using keyset (cursor) pagination, or
+// ranked full-text matches using offset pagination when q is set.
+//
+// @Summary List users (Admin)
+// @Description List users across all applications, newest first. Pass the opaque next_cursor or prev_cursor
+// @Description from a previous response as "cursor" to move between pages; ordering is stable while paging.
+// @Description With "q", users whose email or name match the full-text query are returned most relevant
+// @Description first, paginated with "page" and "limit" instead of a cursor. filter[...] parameters apply in
+// @Description both modes; the order is fixed, so sort and order are rejected.
+// @Tags Users
+// @Security AdminApiKey
+// @Produce json
+// @Param app_id  query string false "Filter by application UUID (same as filter[app_id])"
+// @Param search  query string false "Filter by email or name (case-insensitive)"
+// @Param filter[email] query string false "Filter by email (case-insensitive substring)"
+// @Param filter[name] query string false "Filter by name (case-insensitive substring)"
+// @Param filter[is_active] query bool false "Filter by active state"
+// @Param filter[email_verified] query bool false "Filter by email verification"
+// @Param filter[two_fa_enabled] query bool false "Filter by 2FA state"
+// @Param filter[approval_status] query string false "Filter by approval status: pending, approved, rejected"
+// @Param q       query string false "Full-text search over email and name, ranked by relevance"
+// @Param page    query int    false "Page number when q is set (default: 1)" minimum(1)
+// @Param limit   query int    false "Items per page (default: 20, max: 100)" minimum(1) maximum(100)
+// @Param cursor  query string false "Opaque cursor from a previous response"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	lq, err := pagination.ParseCursorListQuery(c.Request.URL.Query(), userListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		h.searchUsers(c, lq, q, limit)
+		return
+	}
+
+	cursor, err := pagination.Decode(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid cursor"})
+		return
+	}
+
+	users, page, err := h.Repo.ListUsersKeyset(limit, lq, strings.TrimSpace(c.Query("search")), cursor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list users"})
+		return
+	}
+	if users == nil {
+		users = []UserListItem{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       users,
+		"pagination": page,
+	})
+}
+
+// searchUsers responds with one page of ranked full-text matches for ListUsers.
+func (h *Handler) searchUsers(c *gin.Context, lq pagination.ListQuery, q string, limit int) {
+	// Ranked results have no stable keyset order
+	if c.Query("cursor") != "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "q cannot be combined with cursor pagination"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page <= 0 {
+		page = 1
+	}
+
+	users, total, err := h.Repo.SearchUsers(page, limit, lq, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to search users"})
+		return
+	}
+	if users == nil {
+		users = []UserListItem{}
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	c.JSON(http.StatusOK, gin.H{
+		"data": users,
+		"pagination": dto.PaginationResponse{
+			Page:         page,
+			Limit:        limit,
+			TotalRecords: total,
+			TotalPages:   totalPages,
+			HasNext:      page < totalPages,
+			HasPrevious:  page > 1,
+		},
+	})
+}
+
+// GetUserDetail returns a user with social accounts, passkeys and trusted devices.
+//
+// @Summary Get user details (Admin)
+// @Description Returns the full user view shown in the admin GUI: profile, status flags, lockout state,
+// @Description failed login counters (per account and per IP), linked social accounts, passkeys and
+// @Description (when enabled) trusted devices.
+// @Tags Users
+// @Security AdminApiKey
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id} [get]
+func (h *Handler) GetUserDetail(c *gin.Context) {
+	id := c.Param("id")
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	detail, err := h.Repo.GetUserDetailByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load user"})
+		return
+	}
+
+	if h.TrustedDeviceRepo != nil {
+		if devices, devErr := h.TrustedDeviceRepo.FindAllForUser(userID); devErr == nil {
+			detail.TrustedDevices = devices
+		}
+	}
+	if h.BruteForceService != nil {
+		if status, bfErr := h.BruteForceService.GetThrottleStatus(detail.AppID, detail.Email); bfErr == nil {
+			detail.LoginThrottle = status
+		}
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// ToggleUserActive activates or deactivates a user.
+//
+// @Summary Activate or deactivate a user (Admin)
+// @Description Sets the user's active state. Deactivating a user revokes all of their tokens immediately.
+// @Tags Users
+// @Security AdminApiKey
+// @Accept json
+// @Produce json
+// @Param id      path string                      true "User ID"
+// @Param request body dto.ToggleUserActiveRequest true "Active state"
+// @Success 200 {object} dto.UserActiveResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/toggle [put]
+func (h *Handler) ToggleUserActive(c *gin.Context) {
+	id := c.Param("id")
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var req dto.ToggleUserActiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	appID, err := h.Repo.SetUserActive(id, *req.IsActive)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update user"})
+		return
+	}
+
+	if !*req.IsActive {
+		revokeDeactivatedUserTokens(appID, id)
+	}
+
+	c.JSON(http.StatusOK, dto.UserActiveResponse{ID: userID, IsActive: *req.IsActive})
+}
+
+// UnlockUser clears a user's account lockout.
+//
+// @Summary Unlock a user account (Admin)
+// @Description Clears the lockout of a user locked by brute-force protection and resets their failed login counters.
+// @Tags Users
+// @Security AdminApiKey
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/unlock [put]
+func (h *Handler) UnlockUser(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	if err := unlockUserAccount(h.Repo, h.BruteForceService, id, "admin_api", "admin_api"); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to unlock user account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Account unlocked"})
+}
+
 // ============================================================
 // User Export / Import (Admin REST API)
 // ============================================================
@@ -1754,6 +3359,8 @@ func (h *Handler) AdminRevokeAllTrustedDevices(c *gin.Context) {
 // @Summary Export users as CSV or JSON (Admin)
 // @Description Export all users as CSV or JSON (max 10,000 rows). Optionally filter by app_id or search term.
 // @Description Use the X-Export-Truncated response header to detect if the result was capped at 10,000 rows.
+// @Description With async=true the export runs as a background job that writes the file to the file storage
+// @Description backend: the response is 202 with the job, and once it succeeded GET /admin/exports/{job_id} downloads it.
 // @Tags Users
 // @Security AdminApiKey
 // @Produce json
@@ -1761,8 +3368,10 @@ func (h *Handler) AdminRevokeAllTrustedDevices(c *gin.Context) {
 // @Param format  query string false "Export format: csv or json (default: csv)" Enums(csv, json)
 // @Param app_id  query string false "Filter by application UUID"
 // @Param search  query string false "Filter by email or name (case-insensitive)"
+// @Param async   query bool   false "Write the export to file storage in a background job"
 // @Success 200 {object} dto.UserExportResponse "JSON export"
 // @Success 200 {string} string "CSV export"
+// @Success 202 {object} dto.BackgroundJobResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -1780,6 +3389,20 @@ func (h *Handler) ExportUsers(c *gin.Context) {
 		req.Format = "csv"
 	}
 
+	if c.Query("async") == "true" {
+		if h.JobQueue == nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Background jobs are disabled on this server"})
+			return
+		}
+		job, err := h.JobQueue.Enqueue(JobTypeUserExport, req, "api")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to queue export: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, jobqueue.ToResponse(job))
+		return
+	}
+
 	items, truncated, err := h.Repo.ExportUsers(req.AppID, req.Search)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to export users"})
@@ -1793,31 +3416,35 @@ func (h *Handler) ExportUsers(c *gin.Context) {
 	}
 	c.Header("X-Export-Truncated", truncatedStr)
 
-	switch req.Format {
-	case "json":
-		filename := fmt.Sprintf("users_%s.json", timestamp)
-		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
-		c.Header("Content-Type", "application/json; charset=utf-8")
-		c.Writer.WriteHeader(http.StatusOK)
+	filename := fmt.Sprintf("users_%s.%s", timestamp, req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", exportContentTypes[req.Format])
+	c.Writer.WriteHeader(http.StatusOK)
+	writeUserExport(c.Writer, req.Format, items, truncated)
+}
+
+// exportContentTypes maps the export formats to their content types.
+var exportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json; charset=utf-8",
+}
 
+// writeUserExport encodes a user export as CSV or JSON into w.
+func writeUserExport(w io.Writer, format string, items []UserExportItem, truncated bool) {
+	if format == "json" {
 		resp := dto.UserExportResponse{
 			Data:       toUserExportDTOs(items),
 			Count:      len(items),
 			Truncated:  truncated,
 			ExportedAt: time.Now().UTC().Format(time.RFC3339),
 		}
-		enc := json.NewEncoder(c.Writer)
+		enc := json.NewEncoder(w)
 		enc.SetIndent("", "  ")
 		_ = enc.Encode(resp)
-
-	default: // csv
-		filename := fmt.Sprintf("users_%s.csv", timestamp)
-		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
-		c.Header("Content-Type", "text/csv; charset=utf-8")
-		c.Writer.WriteHeader(http.StatusOK)
-		_, _ = c.Writer.Write([]byte("\xef\xbb\xbf")) // UTF-8 BOM for Excel
-		writeUserCSV(c.Writer, items)
+		return
 	}
+	_, _ = w.Write([]byte("\xef\xbb\xbf")) // UTF-8 BOM for Excel
+	writeUserCSV(w, items)
 }
 
 // ImportUsers bulk-creates users from an uploaded CSV or JSON file.
@@ -1828,13 +3455,17 @@ func (h *Handler) ExportUsers(c *gin.Context) {
 // @Description Imported users have no password — they must use the password reset flow to set one.
 // @Description CSV expected columns: email (required), name, first_name, last_name, locale (all optional).
 // @Description JSON: top-level array or {"users":[...]} object, same fields.
+// @Description With async=true the import runs as a background job: the response is 202 with the job,
+// @Description and GET /admin/jobs/{id} returns its progress and, once finished, the import result.
 // @Tags Users
 // @Security AdminApiKey
 // @Accept multipart/form-data
 // @Produce json
-// @Param app_id query    string true "Target application UUID"
-// @Param file   formData file   true "CSV or JSON file to import"
+// @Param app_id query    string true  "Target application UUID"
+// @Param async  query    bool   false "Run the import as a background job"
+// @Param file   formData file   true  "CSV or JSON file to import"
 // @Success 200 {object} dto.UserImportResult
+// @Success 202 {object} dto.BackgroundJobResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -1870,6 +3501,30 @@ func (h *Handler) ImportUsers(c *gin.Context) {
 		rows, parseErrors = userimport.ParseCSVImport(file)
 	}
 
+	if c.Query("async") == "true" {
+		if h.JobQueue == nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Background jobs are disabled on this server"})
+			return
+		}
+		if _, err := uuid.Parse(appID); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid app_id"})
+			return
+		}
+		job, err := h.JobQueue.Enqueue(JobTypeUserImport, UserImportPayload{
+			AppID:       appID,
+			Rows:        rows,
+			ParseErrors: parseErrors,
+			IPAddress:   c.ClientIP(),
+			UserAgent:   c.Request.UserAgent(),
+		}, "api")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to queue import: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, jobqueue.ToResponse(job))
+		return
+	}
+
 	result, err := h.Repo.ImportUsers(appID, rows)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Import failed: " + err.Error()})
@@ -1883,6 +3538,72 @@ func (h *Handler) ImportUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GenerateUsers creates synthetic users with deterministic credentials for load testing.
+//
+// @Summary Generate synthetic users for load testing (Admin)
+// @Description Creates count users in batched multi-row inserts. User n gets the email
+// @Description <email_prefix><n>@<email_domain> (default loadtest-user-<n>@loadtest.invalid) for
+// @Description n = start_index .. start_index+count-1, and all share one password
+// @Description (default LoadTest-Password-1). Users are active and email-verified; existing emails
+// @Description are skipped. At most 100000 users per request. Only available when
+// @Description LOAD_TEST_USER_GENERATION_ENABLED=true. With async=true the generation runs as a
+// @Description background job: the response is 202 with the job, and GET /admin/jobs/{id} returns the result.
+// @Tags Users
+// @Security AdminApiKey
+// @Accept json
+// @Produce json
+// @Param async   query bool                     false "Run the generation as a background job"
+// @Param request body  dto.GenerateUsersRequest true  "Generation parameters"
+// @Success 200 {object} dto.GenerateUsersResult
+// @Success 202 {object} dto.BackgroundJobResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/users/generate [post]
+func (h *Handler) GenerateUsers(c *gin.Context) {
+	var req dto.GenerateUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Count > GenerateUsersMaxCount {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: fmt.Sprintf("count must be at most %d", GenerateUsersMaxCount)})
+		return
+	}
+	if _, err := h.Repo.GetAppByID(req.AppID); err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+	if err := quota.CheckAppUsers(h.Repo.DB, uuid.MustParse(req.AppID), req.Count); err != nil {
+		appErr := quota.ToAppError(err)
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	if c.Query("async") == "true" {
+		if h.JobQueue == nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Background jobs are disabled on this server"})
+			return
+		}
+		job, err := h.JobQueue.Enqueue(JobTypeUserGenerate, req, "api")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to queue generation: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, jobqueue.ToResponse(job))
+		return
+	}
+
+	result, err := h.Repo.GenerateUsers(c.Request.Context(), req, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Generation failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // toUserExportDTOs converts repository-level UserExportItem slice to the public DTO slice.
 func toUserExportDTOs(items []UserExportItem) []dto.UserExportItem {
 	out := make([]dto.UserExportItem, len(items))
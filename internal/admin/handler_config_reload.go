@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+// ReloadConfig reloads the configuration without a restart
+// @Summary Reload the configuration
+// @Description Reads the config file again and re-reads the settings, logging configuration and per-app configs cached in memory (OAuth provider configs, environments, IP rules, token signing keys), on every instance: the others are told through Redis. The response is the reload of the instance that handled the request; ok is false when a step failed (an unreadable config file leaves the previous values in place). Settings marked as requiring a restart, such as ports, CORS and database connections, still need one. Sending SIGHUP to the process reloads that instance only.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} dto.ConfigReloadResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/config/reload [post]
+func (h *Handler) ReloadConfig(c *gin.Context) {
+	if h.Reloader == nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "Live configuration reload is not available"})
+		return
+	}
+	res, err := h.Reloader.ReloadAll()
+	resp := dto.ConfigReloadResponse{
+		OK:         res.OK(),
+		Instance:   res.Instance,
+		StartedAt:  res.StartedAt,
+		DurationMs: res.Duration.Milliseconds(),
+		Changed:    res.Changed,
+		Steps:      make([]dto.ConfigReloadStep, len(res.Steps)),
+	}
+	for i, s := range res.Steps {
+		resp.Steps[i] = dto.ConfigReloadStep{Name: s.Name, Error: s.Error}
+	}
+	if err != nil {
+		resp.Warning = "Other instances were not reloaded: " + err.Error()
+	}
+	c.JSON(http.StatusOK, resp)
+}
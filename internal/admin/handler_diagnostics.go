@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/secretaudit"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+// GetSupportBundle gathers a self-diagnostic support bundle.
+// @Summary Download a support bundle
+// @Description Gathers the information needed to investigate a bug report into one JSON document: the build and
+// @Description dependency versions (including PostgreSQL and Redis), the effective configuration and the settings
+// @Description stored in the database with secrets redacted, database and Redis latency, applied, failed and pending
+// @Description migrations, background job and webhook retry queue depths, HTTP 5xx rates since startup and job and
+// @Description webhook failures in the last 24 hours. Sections that cannot be collected are listed in errors.
+// @Description The response is sent as a file download (support-bundle-<time>.json).
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} dto.SupportBundle
+// @Security AdminApiKey
+// @Router /admin/diagnostics [get]
+func (h *Handler) GetSupportBundle(c *gin.Context) {
+	bundle := buildSupportBundle(supportBundleSources{
+		DB:            h.Repo.DB,
+		Redis:         redis.Rdb,
+		Health:        h.HealthHandler,
+		MigrationsDir: supportBundleMigrationsDir,
+	})
+	c.Header("Content-Disposition", `attachment; filename="support-bundle-`+bundle.GeneratedAt.Format("20060102T150405Z")+`.json"`)
+	c.IndentedJSON(http.StatusOK, bundle)
+}
+
+// AuditSecrets audits the configured secrets.
+// @Summary Audit the strength of the configured secrets
+// @Description Checks the JWT secret (length, placeholder values, estimated entropy), the settings encryption key,
+// @Description the ADMIN_API_KEY, whether the well-known default application ID is still in use, email servers on
+// @Description example domains, OAuth configs and confidential OIDC clients without a secret, and sensitive settings
+// @Description stored in plaintext or encrypted with another key. Findings are sorted by severity and never include
+// @Description secret values. The same report is printed by running the server with -audit-secrets.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} dto.SecretAuditResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/diagnostics/secrets [get]
+func (h *Handler) AuditSecrets(c *gin.Context) {
+	report, err := secretaudit.Run(h.Repo.DB, secretaudit.Options{SensitiveSettingKeys: SensitiveSettingKeys()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to audit secrets: " + err.Error()})
+		return
+	}
+
+	response := dto.SecretAuditResponse{
+		GeneratedAt: report.GeneratedAt,
+		Checks:      report.Checks,
+		Counts:      make(map[string]int, len(secretaudit.Severities)),
+		Findings:    make([]dto.SecretAuditFinding, 0, len(report.Findings)),
+	}
+	for _, s := range secretaudit.Severities {
+		response.Counts[string(s)] = report.Count(s)
+	}
+	for _, f := range report.Findings {
+		response.Findings = append(response.Findings, dto.SecretAuditFinding{
+			Check:       f.Check,
+			Severity:    string(f.Severity),
+			Title:       f.Title,
+			Detail:      f.Detail,
+			Remediation: f.Remediation,
+			Targets:     f.Targets,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/disposable"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+// CheckDisposableEmail tests a domain against the disposable email blocklist.
+// @Summary Test a domain against the disposable email blocklist
+// @Description Reports whether registrations and email changes using the domain (or an email address) are rejected,
+// @Description which listed domain matched and which list it came from. With app_id, the application's blocked email
+// @Description domains are checked too. Includes the state of the loaded lists.
+// @Tags Admin
+// @Produce json
+// @Param   domain  query  string  true   "Domain or email address"
+// @Param   app_id  query  string  false  "Application ID (also check its blocked email domains)"
+// @Success 200 {object} dto.DisposableEmailCheckResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/disposable-emails/check [get]
+func (h *Handler) CheckDisposableEmail(c *gin.Context) {
+	domain := disposable.Domain(c.Query("domain"))
+	if domain == "" || !strings.Contains(domain, ".") {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "A valid domain or email address is required"})
+		return
+	}
+
+	var appDomains string
+	if appID := c.Query("app_id"); appID != "" {
+		app, err := h.Repo.GetAppByID(appID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+			return
+		}
+		appDomains = app.BlockedEmailDomains
+	}
+
+	match, blocked := h.DisposableEmails.Check(domain, appDomains)
+	status := h.DisposableEmails.Status()
+	c.JSON(http.StatusOK, dto.DisposableEmailCheckResponse{
+		Domain:        domain,
+		Disposable:    blocked,
+		MatchedDomain: match.Domain,
+		Source:        match.Source,
+		List: dto.DisposableEmailListStatus{
+			Enabled:      status.Enabled,
+			ListURL:      status.ListURL,
+			BundledCount: status.BundledCount,
+			RemoteCount:  status.RemoteCount,
+			RefreshedAt:  status.RefreshedAt,
+			LastError:    status.LastError,
+		},
+	})
+}
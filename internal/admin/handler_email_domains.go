@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/dnscheck"
+	"github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============================================================================
+// Tenant sending domains
+// ============================================================================
+
+// ListTenantEmailDomains lists the sending domains of a tenant
+// @Summary List tenant sending domains
+// @Description Domains the tenant's applications may send email from, with their verification status and the DNS TXT record that verifies them. Once a tenant has a sending domain, app SMTP From addresses must use a verified one.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} dto.TenantEmailDomainListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/{id}/email-domains [get]
+func (h *Handler) ListTenantEmailDomains(c *gin.Context) {
+	tenantID, ok := h.tenantParam(c)
+	if !ok {
+		return
+	}
+	domains, err := h.EmailService.ListSendingDomains(tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list sending domains: " + err.Error()})
+		return
+	}
+	resp := dto.TenantEmailDomainListResponse{Domains: make([]dto.TenantEmailDomainResponse, len(domains))}
+	for i := range domains {
+		resp.Domains[i] = toTenantEmailDomainResponse(&domains[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddTenantEmailDomain registers a sending domain for a tenant
+// @Summary Register a tenant sending domain
+// @Description Register a domain as pending. Publish the returned TXT record, then call the verify endpoint. While the tenant has registered domains, its applications can only save SMTP configs and send email with a From address on a verified one (or a subdomain of one).
+// @Tags Admin - Email
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param request body dto.TenantEmailDomainRequest true "Domain"
+// @Success 201 {object} dto.TenantEmailDomainResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/{id}/email-domains [post]
+func (h *Handler) AddTenantEmailDomain(c *gin.Context) {
+	tenantID, ok := h.tenantParam(c)
+	if !ok {
+		return
+	}
+	var req dto.TenantEmailDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	domain, err := h.EmailService.AddSendingDomain(tenantID, req.Domain)
+	if err != nil {
+		switch {
+		case errors.Is(err, email.ErrInvalidSendingDomain):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, email.ErrSendingDomainExists):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to add sending domain: " + err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, toTenantEmailDomainResponse(domain))
+}
+
+// VerifyTenantEmailDomain checks the verification record of a sending domain
+// @Summary Verify a tenant sending domain
+// @Description Look up the domain's TXT record and mark it verified or failed. A verified domain whose record is gone becomes failed. A DNS lookup error leaves the status unchanged.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param domain_id path string true "Sending domain ID"
+// @Success 200 {object} dto.TenantEmailDomainVerifyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/{id}/email-domains/{domain_id}/verify [post]
+func (h *Handler) VerifyTenantEmailDomain(c *gin.Context) {
+	tenantID, ok := h.tenantParam(c)
+	if !ok {
+		return
+	}
+	domainID, err := uuid.Parse(c.Param("domain_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid sending domain ID"})
+		return
+	}
+	checker := h.DNSChecker
+	if checker == nil {
+		checker = dnscheck.NewChecker()
+	}
+	domain, res, err := h.EmailService.VerifySendingDomain(c.Request.Context(), checker, tenantID, domainID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to verify sending domain: " + err.Error()})
+		return
+	}
+	if domain == nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Sending domain not found"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.TenantEmailDomainVerifyResponse{
+		Domain: toTenantEmailDomainResponse(domain),
+		Check:  string(res.Status),
+		Detail: res.Detail,
+		Hint:   res.Hint,
+	})
+}
+
+// DeleteTenantEmailDomain removes a sending domain of a tenant
+// @Summary Remove a tenant sending domain
+// @Description Once the last domain is removed, the tenant's applications are no longer restricted to verified sending domains.
+// @Tags Admin - Email
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param domain_id path string true "Sending domain ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/{id}/email-domains/{domain_id} [delete]
+func (h *Handler) DeleteTenantEmailDomain(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tenant ID"})
+		return
+	}
+	domainID, err := uuid.Parse(c.Param("domain_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid sending domain ID"})
+		return
+	}
+	deleted, err := h.EmailService.DeleteSendingDomain(tenantID, domainID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to remove sending domain: " + err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Sending domain not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sending domain removed successfully"})
+}
+
+// tenantParam parses the :id path parameter and checks that the tenant
+// exists, writing the error response otherwise.
+func (h *Handler) tenantParam(c *gin.Context) (uuid.UUID, bool) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tenant ID"})
+		return uuid.Nil, false
+	}
+	if _, err := h.Repo.GetTenantByID(tenantID.String()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Tenant not found"})
+			return uuid.Nil, false
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load tenant"})
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
+
+func toTenantEmailDomainResponse(d *models.TenantEmailDomain) dto.TenantEmailDomainResponse {
+	return dto.TenantEmailDomainResponse{
+		ID:            d.ID.String(),
+		TenantID:      d.TenantID.String(),
+		Domain:        d.Domain,
+		Status:        d.Status,
+		RecordName:    d.Domain,
+		RecordValue:   dnscheck.OwnershipPrefix + d.VerificationToken,
+		VerifiedAt:    d.VerifiedAt,
+		LastCheckedAt: d.LastCheckedAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+	}
+}
@@ -0,0 +1,550 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============================================================================
+// External ID endpoints — declarative management (e.g. a Terraform provider)
+// ============================================================================
+//
+// PUT /admin/<resource>/by-external-id/:external_id is an idempotent upsert:
+// 201 when it created the resource, 200 when it updated it. Every read and
+// write returns the resource's ETag; send it back in If-Match to update only
+// an unchanged resource, or send If-None-Match: * to create only.
+
+// maxExternalIDLength matches the external_id column size.
+const maxExternalIDLength = 255
+
+// upsertBadRequest is returned by an upsert check to reject the request with a
+// 400 response carrying the error text.
+type upsertBadRequest string
+
+func (e upsertBadRequest) Error() string { return string(e) }
+
+// externalIDParam reads and validates the :external_id path parameter.
+func externalIDParam(c *gin.Context) (string, bool) {
+	externalID := strings.TrimSpace(c.Param("external_id"))
+	if externalID == "" || len(externalID) > maxExternalIDLength {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid external_id: must be 1-255 characters"})
+		return "", false
+	}
+	return externalID, true
+}
+
+// writeUpsertError maps an error from an upsert by external ID to a response.
+// currentETag is the ETag of the resource as the precondition check saw it.
+func writeUpsertError(c *gin.Context, err error, currentETag, resource string) {
+	var badRequest upsertBadRequest
+	switch {
+	case errors.Is(err, errPreconditionFailed):
+		abortPreconditionFailed(c, currentETag)
+	case errors.As(err, &badRequest):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: badRequest.Error()})
+	case errors.Is(err, ErrClientSecretRequired):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, ErrExternalIDConflict), errors.Is(err, ErrImmutableField):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, quota.ErrExceeded):
+		appErr := quota.ToAppError(err)
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+	case isUniqueViolation(err):
+		// Two concurrent first-time upserts raced on the unique index
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "The " + resource + " was created concurrently; retry the request"})
+	default:
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save " + resource})
+	}
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "duplicate") || strings.Contains(msg, "unique") || strings.Contains(msg, "23505")
+}
+
+// respondUpserted writes the result of an upsert with its ETag.
+func respondUpserted(c *gin.Context, created bool, etag string, body interface{}) {
+	c.Header("ETag", etag)
+	if created {
+		c.JSON(http.StatusCreated, body)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// ---- Tenants ----
+
+// GetTenantByExternalID retrieves a tenant by external ID
+// @Summary Get a tenant by external ID
+// @Description Retrieve a tenant by the external ID it was created with through PUT /admin/tenants/by-external-id/{external_id}.
+// @Tags Admin
+// @Produce json
+// @Param   external_id  path  string  true  "External ID"
+// @Success 200 {object} dto.TenantResponse
+// @Success 304 "Not modified (If-None-Match matched)"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/by-external-id/{external_id} [get]
+func (h *Handler) GetTenantByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	tenant, err := h.Repo.GetTenantByExternalID(externalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load tenant"})
+		return
+	}
+	if writeETag(c, resourceETag(tenant.ID, tenant.UpdatedAt)) {
+		return
+	}
+	c.JSON(http.StatusOK, toTenantResponse(tenant))
+}
+
+// UpsertTenantByExternalID creates or updates a tenant by external ID
+// @Summary Create or update a tenant by external ID
+// @Description Idempotent upsert: creates the tenant on the first call (201) and replaces its name, billing customer and
+// @Description app quota on later calls (200). Send If-Match with an ETag to update only an unchanged tenant, or
+// @Description If-None-Match: * to create only.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   external_id    path    string                   true   "External ID"
+// @Param   tenant         body    dto.CreateTenantRequest  true   "Tenant Data"
+// @Param   If-Match       header  string                   false  "ETag the tenant must still have"
+// @Param   If-None-Match  header  string                   false  "* to create only"
+// @Success 200 {object} dto.TenantResponse
+// @Success 201 {object} dto.TenantResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/by-external-id/{external_id} [put]
+func (h *Handler) UpsertTenantByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pre := readPreconditions(c)
+	var currentETag string
+	tenant, created, err := h.Repo.UpsertTenantByExternalID(externalID, &models.Tenant{
+		Name:              req.Name,
+		BillingCustomerID: req.BillingCustomerID,
+		MaxApps:           req.MaxApps,
+	}, func(current *models.Tenant) error {
+		if current != nil {
+			currentETag = resourceETag(current.ID, current.UpdatedAt)
+		}
+		return pre.check(currentETag)
+	})
+	if err != nil {
+		writeUpsertError(c, err, currentETag, "tenant")
+		return
+	}
+	if created {
+		h.Notifications.TenantCreated(tenant.ID, tenant.Name)
+	}
+	respondUpserted(c, created, resourceETag(tenant.ID, tenant.UpdatedAt), toTenantResponse(tenant))
+}
+
+// ---- Applications ----
+
+// GetAppByExternalID retrieves an application by external ID
+// @Summary Get an application by external ID
+// @Description Retrieve an application, including its OAuth configurations (without client secrets), by the external ID
+// @Description it was created with through PUT /admin/apps/by-external-id/{external_id}.
+// @Tags Admin
+// @Produce json
+// @Param   external_id  path  string  true  "External ID"
+// @Success 200 {object} dto.AppResponse
+// @Success 304 "Not modified (If-None-Match matched)"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/by-external-id/{external_id} [get]
+func (h *Handler) GetAppByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	app, err := h.Repo.GetAppByExternalID(externalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load application"})
+		return
+	}
+	if writeETag(c, resourceETag(app.ID, app.UpdatedAt)) {
+		return
+	}
+	c.JSON(http.StatusOK, toAppResponse(app))
+}
+
+// UpsertAppByExternalID creates or updates an application by external ID
+// @Summary Create or update an application by external ID
+// @Description Idempotent upsert: creates the application on the first call (201, subject to the tenant's application
+// @Description quota) and replaces the fields of POST /admin/apps on later calls (200). tenant_id cannot change (409).
+// @Description Send If-Match with an ETag to update only an unchanged application, or If-None-Match: * to create only.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   external_id    path    string                true   "External ID"
+// @Param   app            body    dto.CreateAppRequest  true   "Application Data"
+// @Param   If-Match       header  string                false  "ETag the application must still have"
+// @Param   If-None-Match  header  string                false  "* to create only"
+// @Success 200 {object} dto.AppResponse
+// @Success 201 {object} dto.AppResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse "Tenant application quota exceeded"
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/by-external-id/{external_id} [put]
+func (h *Handler) UpsertAppByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.CreateAppRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	tenantID, err := uuid.Parse(req.TenantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid Tenant ID"})
+		return
+	}
+
+	pre := readPreconditions(c)
+	var currentETag string
+	app, created, err := h.Repo.UpsertAppByExternalID(externalID, &models.Application{
+		TenantID:            tenantID,
+		Name:                req.Name,
+		Description:         req.Description,
+		FrontendURL:         req.FrontendURL,
+		MagicLinkEnabled:    req.MagicLinkEnabled,
+		PasswordlessOnly:    req.PasswordlessOnly,
+		ResetPasswordPath:   req.ResetPasswordPath,
+		MagicLinkPath:       req.MagicLinkPath,
+		VerifyEmailPath:     req.VerifyEmailPath,
+		AllowedRedirectURLs: req.AllowedRedirectURLs,
+		BlockedEmailDomains: req.BlockedEmailDomains,
+		SocialCallbackMode:  req.SocialCallbackMode,
+		// Email verification by code
+		EmailVerificationCode: req.EmailVerificationCode,
+		// Registration approval
+		RegistrationApprovalRequired: req.RegistrationApprovalRequired,
+		// Concurrent session limit
+		MaxSessionsPerUser: req.MaxSessionsPerUser,
+		SessionLimitPolicy: sessionLimitPolicyOrDefault(req.SessionLimitPolicy),
+	}, func(current *models.Application) error {
+		if current != nil {
+			currentETag = resourceETag(current.ID, current.UpdatedAt)
+			return pre.check(currentETag)
+		}
+		if err := pre.check(""); err != nil {
+			return err
+		}
+		if err := quota.CheckTenantApps(h.Repo.DB, tenantID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return upsertBadRequest("Tenant not found")
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		writeUpsertError(c, err, currentETag, "application")
+		return
+	}
+	if created {
+		// Roles can be seeded later if this fails; the application exists
+		if err := h.Repo.SeedDefaultRolesForApp(app.ID); err != nil {
+			log.Printf("Failed to seed default roles for app %s: %v", app.ID, err)
+		}
+	}
+	respondUpserted(c, created, resourceETag(app.ID, app.UpdatedAt), toAppResponse(app))
+}
+
+// ---- OAuth configs ----
+
+// GetOAuthConfigByExternalID retrieves an OAuth config by external ID
+// @Summary Get an OAuth configuration by external ID
+// @Description Retrieve an OAuth provider configuration by its external ID. The client secret is never returned.
+// @Tags Admin
+// @Produce json
+// @Param   external_id  path  string  true  "External ID"
+// @Success 200 {object} dto.OAuthConfigResponse
+// @Success 304 "Not modified (If-None-Match matched)"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs/by-external-id/{external_id} [get]
+func (h *Handler) GetOAuthConfigByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	config, err := h.Repo.GetOAuthConfigByExternalID(externalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "OAuth config not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load OAuth config"})
+		return
+	}
+	if writeETag(c, resourceETag(config.ID, config.UpdatedAt)) {
+		return
+	}
+	c.JSON(http.StatusOK, toOAuthConfigResponse(config))
+}
+
+// UpsertOAuthConfigByExternalID creates or updates an OAuth config by external ID
+// @Summary Create or update an OAuth configuration by external ID
+// @Description Idempotent upsert: creates the OAuth config on the first call (201; client_secret required) and replaces
+// @Description its client ID, redirect URL and enabled flag on later calls (200; an empty client_secret keeps the stored one).
+// @Description An existing config for the same app and provider without an external ID is adopted; app_id and provider
+// @Description cannot change (409). Send If-Match with an ETag to update only an unchanged config, or If-None-Match: * to create only.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   external_id    path    string                                    true   "External ID"
+// @Param   config         body    dto.UpsertOAuthConfigByExternalIDRequest  true   "OAuth Config Data"
+// @Param   If-Match       header  string                                    false  "ETag the config must still have"
+// @Param   If-None-Match  header  string                                    false  "* to create only"
+// @Success 200 {object} dto.OAuthConfigResponse
+// @Success 201 {object} dto.OAuthConfigResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs/by-external-id/{external_id} [put]
+func (h *Handler) UpsertOAuthConfigByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.UpsertOAuthConfigByExternalIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	appID, err := uuid.Parse(req.AppID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	isEnabled := true
+	if req.IsEnabled != nil {
+		isEnabled = *req.IsEnabled
+	}
+	claimMappings, err := parseClaimMappings(req.ClaimMappings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	in := &models.OAuthProviderConfig{
+		AppID:         appID,
+		Provider:      req.Provider,
+		ClientID:      req.ClientID,
+		ClientSecret:  req.ClientSecret,
+		RedirectURL:   req.RedirectURL,
+		IsEnabled:     isEnabled,
+		ClaimMappings: claimMappings,
+		// Just-in-time provisioning
+		ProvisioningMode:    provisioningModeOrDefault(req.ProvisioningMode),
+		AllowedEmailDomains: strings.TrimSpace(req.AllowedEmailDomains),
+		// Generic OpenID Connect providers
+		IssuerURL: strings.TrimSpace(req.IssuerURL),
+		Scopes:    strings.Join(strings.Fields(req.Scopes), " "),
+	}
+	if err := in.ValidateProvider(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pre := readPreconditions(c)
+	var currentETag string
+	config, created, err := h.Repo.UpsertOAuthConfigByExternalID(externalID, in, func(current *models.OAuthProviderConfig) error {
+		if current != nil {
+			currentETag = resourceETag(current.ID, current.UpdatedAt)
+			return pre.check(currentETag)
+		}
+		if err := pre.check(""); err != nil {
+			return err
+		}
+		if err := h.Repo.DB.Select("id").First(&models.Application{}, "id = ?", appID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return upsertBadRequest("Application not found")
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		writeUpsertError(c, err, currentETag, "OAuth config")
+		return
+	}
+	respondUpserted(c, created, resourceETag(config.ID, config.UpdatedAt), toOAuthConfigResponse(config))
+}
+
+// ---- Email templates ----
+
+// GetEmailTemplateByExternalID retrieves an email template by external ID
+// @Summary Get an email template by external ID
+// @Description Retrieve an email template by its external ID
+// @Tags Admin - Email
+// @Produce json
+// @Param   external_id  path  string  true  "External ID"
+// @Success 200 {object} dto.EmailTemplateResponse
+// @Success 304 "Not modified (If-None-Match matched)"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/email-templates/by-external-id/{external_id} [get]
+func (h *Handler) GetEmailTemplateByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	tmpl, err := h.Repo.GetEmailTemplateByExternalID(externalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load template"})
+		return
+	}
+	if writeETag(c, resourceETag(tmpl.ID, tmpl.UpdatedAt)) {
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// UpsertEmailTemplateByExternalID creates or updates an email template by external ID
+// @Summary Create or update an email template by external ID
+// @Description Idempotent upsert: creates the template on the first call (201; omit app_id for a global default) and
+// @Description replaces its content on later calls (200). An existing template for the same app and email type without
+// @Description an external ID is adopted; app_id and email_type_id cannot change (409). Send If-Match with an ETag to
+// @Description update only an unchanged template, or If-None-Match: * to create only.
+// @Tags Admin - Email
+// @Accept json
+// @Produce json
+// @Param   external_id    path    string                                      true   "External ID"
+// @Param   template       body    dto.UpsertEmailTemplateByExternalIDRequest  true   "Template Data"
+// @Param   If-Match       header  string                                      false  "ETag the template must still have"
+// @Param   If-None-Match  header  string                                      false  "* to create only"
+// @Success 200 {object} dto.EmailTemplateResponse
+// @Success 201 {object} dto.EmailTemplateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/email-templates/by-external-id/{external_id} [put]
+func (h *Handler) UpsertEmailTemplateByExternalID(c *gin.Context) {
+	externalID, ok := externalIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.UpsertEmailTemplateByExternalIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tmpl := &models.EmailTemplate{
+		Name:           req.Name,
+		Subject:        req.Subject,
+		BodyHTML:       req.BodyHTML,
+		BodyText:       req.BodyText,
+		TemplateEngine: req.TemplateEngine,
+		FromEmail:      req.FromEmail,
+		FromName:       req.FromName,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+	emailTypeID, err := uuid.Parse(req.EmailTypeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid email_type_id"})
+		return
+	}
+	tmpl.EmailTypeID = emailTypeID
+	if req.AppID != nil && *req.AppID != "" {
+		appID, err := uuid.Parse(*req.AppID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid app_id"})
+			return
+		}
+		tmpl.AppID = &appID
+	}
+	if req.ServerConfigID != nil && *req.ServerConfigID != "" {
+		serverConfigID, err := uuid.Parse(*req.ServerConfigID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid server_config_id"})
+			return
+		}
+		tmpl.ServerConfigID = &serverConfigID
+	}
+
+	pre := readPreconditions(c)
+	var currentETag string
+	saved, created, err := h.Repo.UpsertEmailTemplateByExternalID(externalID, tmpl, func(current *models.EmailTemplate) error {
+		if current != nil {
+			currentETag = resourceETag(current.ID, current.UpdatedAt)
+			return pre.check(currentETag)
+		}
+		if err := pre.check(""); err != nil {
+			return err
+		}
+		if err := h.Repo.DB.Select("id").First(&models.EmailType{}, "id = ?", emailTypeID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return upsertBadRequest("Email type not found")
+			}
+			return err
+		}
+		if tmpl.AppID != nil {
+			if err := h.Repo.DB.Select("id").First(&models.Application{}, "id = ?", *tmpl.AppID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return upsertBadRequest("Application not found")
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeUpsertError(c, err, currentETag, "template")
+		return
+	}
+	respondUpserted(c, created, resourceETag(saved.ID, saved.UpdatedAt), saved)
+}
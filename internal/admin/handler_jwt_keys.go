@@ -0,0 +1,242 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/jwtkeys"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// Token signing keys
+// ============================================================================
+
+// ListJWTKeys lists the token signing keys
+// @Summary List token signing keys
+// @Description Shared keys published at /.well-known/jwks.json when JWT_SIGNING_ALGORITHM is RS256 or ES256: the active key signing new tokens of applications without keys of their own, a pending key published ahead of a rotation, and retiring keys that verify tokens they signed until expires_at. Private keys are never returned. Keys of applications are listed under /admin/apps/{id}/keys.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} dto.JWTSigningKeyListResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/jwt-keys [get]
+func (h *Handler) ListJWTKeys(c *gin.Context) {
+	if !h.jwtKeysEnabled(c) {
+		return
+	}
+	h.listJWTKeys(c, nil)
+}
+
+// RotateJWTKey rotates the token signing key
+// @Summary Rotate the token signing key
+// @Description Adds a key of the configured algorithm. It is published in the JWKS at once and signs new tokens after an hour, so services caching the JWKS learn it first; with immediate it signs right away. The keys it replaces keep verifying the tokens they signed until those expire. Keys are also rotated every JWT_KEY_ROTATION_DAYS by the jwt_key_rotation job. Applications with keys of their own are rotated under /admin/apps/{id}/keys.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body dto.JWTKeyRotateRequest false "Rotation options"
+// @Success 201 {object} dto.JWTSigningKeyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/jwt-keys/rotate [post]
+func (h *Handler) RotateJWTKey(c *gin.Context) {
+	if !h.jwtKeysEnabled(c) {
+		return
+	}
+	h.rotateJWTKey(c, nil)
+}
+
+// DeleteJWTKey deletes a token signing key
+// @Summary Delete a token signing key
+// @Description Removes a pending or retiring key from the JWKS at once; every token it signed stops validating. Use after an immediate rotation when a key leaked. The active key cannot be deleted.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Key ID (kid)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/jwt-keys/{id} [delete]
+func (h *Handler) DeleteJWTKey(c *gin.Context) {
+	if !h.jwtKeysEnabled(c) {
+		return
+	}
+	h.deleteJWTKey(c, nil, c.Param("id"))
+}
+
+// ListAppJWTKeys lists the token signing keys of an application
+// @Summary List the signing keys of an application
+// @Description Keys that sign only this application's tokens, in the same states as /admin/jwt-keys. An application without keys of its own (uses_shared_key) has its tokens signed with the shared key, or with JWT_SECRET under HS256.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} dto.JWTSigningKeyListResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/keys [get]
+func (h *Handler) ListAppJWTKeys(c *gin.Context) {
+	appID, ok := h.jwtKeysApp(c)
+	if !ok {
+		return
+	}
+	h.listJWTKeys(c, appID)
+}
+
+// RotateAppJWTKey rotates the token signing key of an application
+// @Summary Rotate the signing key of an application
+// @Description Adds a key that signs only this application's tokens, with a "kid" header naming it: JWT_SIGNING_ALGORITHM, or RS256 under HS256. The first rotation gives the application keys of its own; like later ones, the key is published in the JWKS an hour before it signs unless immediate is set. The keys it replaces keep verifying the tokens they signed until those expire, and the key is rotated every JWT_KEY_ROTATION_DAYS from then on.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body dto.JWTKeyRotateRequest false "Rotation options"
+// @Success 201 {object} dto.JWTSigningKeyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/keys/rotate [post]
+func (h *Handler) RotateAppJWTKey(c *gin.Context) {
+	appID, ok := h.jwtKeysApp(c)
+	if !ok {
+		return
+	}
+	h.rotateJWTKey(c, appID)
+}
+
+// DeleteAppJWTKey deletes a token signing key of an application
+// @Summary Delete a signing key of an application
+// @Description Removes a pending or retiring key of the application from the JWKS at once; every token it signed stops validating. The active key cannot be deleted.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param kid path string true "Key ID (kid)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/keys/{kid} [delete]
+func (h *Handler) DeleteAppJWTKey(c *gin.Context) {
+	appID, ok := h.jwtKeysApp(c)
+	if !ok {
+		return
+	}
+	h.deleteJWTKey(c, appID, c.Param("kid"))
+}
+
+// listJWTKeys writes the keys of an application (nil = the shared keys).
+func (h *Handler) listJWTKeys(c *gin.Context, appID *uuid.UUID) {
+	keys, err := h.JWTKeys.List(c.Request.Context(), appID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list signing keys: " + err.Error()})
+		return
+	}
+	resp := dto.JWTSigningKeyListResponse{Algorithm: jwtkeys.Algorithm(appID), Keys: make([]dto.JWTSigningKeyResponse, len(keys))}
+	resp.UsesSharedKey = appID != nil
+	for i, k := range keys {
+		resp.Keys[i] = toJWTSigningKeyResponse(&k.JWTSigningKey, k.State)
+		if k.State == jwtkeys.StateActive {
+			resp.UsesSharedKey = false
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// rotateJWTKey rotates the keys of an application (nil = the shared keys).
+func (h *Handler) rotateJWTKey(c *gin.Context, appID *uuid.UUID) {
+	var req dto.JWTKeyRotateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	key, err := h.JWTKeys.Rotate(c.Request.Context(), appID, req.Immediate)
+	if err != nil {
+		if errors.Is(err, jwtkeys.ErrSymmetric) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to rotate signing key: " + err.Error()})
+		return
+	}
+	state := jwtkeys.StatePending
+	if req.Immediate {
+		state = jwtkeys.StateActive
+	}
+	c.JSON(http.StatusCreated, toJWTSigningKeyResponse(key, state))
+}
+
+// deleteJWTKey deletes a key of an application (nil = a shared key).
+func (h *Handler) deleteJWTKey(c *gin.Context, appID *uuid.UUID, kid string) {
+	id, err := uuid.Parse(kid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid key ID"})
+		return
+	}
+	if err := h.JWTKeys.Delete(c.Request.Context(), appID, id.String()); err != nil {
+		switch {
+		case errors.Is(err, jwtkeys.ErrKeyNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, jwtkeys.ErrActiveKey):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete signing key: " + err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Signing key deleted successfully"})
+}
+
+func toJWTSigningKeyResponse(k *models.JWTSigningKey, state string) dto.JWTSigningKeyResponse {
+	resp := dto.JWTSigningKeyResponse{
+		ID:          k.ID.String(),
+		Algorithm:   k.Algorithm,
+		State:       state,
+		ActivatesAt: k.ActivatesAt,
+		ExpiresAt:   k.ExpiresAt,
+		CreatedAt:   k.CreatedAt,
+	}
+	if k.AppID != nil {
+		resp.AppID = k.AppID.String()
+	}
+	return resp
+}
+
+// jwtKeysEnabled writes a 503 when the key service is not configured.
+func (h *Handler) jwtKeysEnabled(c *gin.Context) bool {
+	if h.JWTKeys == nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "Token signing keys are not available"})
+		return false
+	}
+	return true
+}
+
+// jwtKeysApp resolves the application of the request, writing a 503 when
+// the key service is not configured and a 404 for an unknown application.
+func (h *Handler) jwtKeysApp(c *gin.Context) (*uuid.UUID, bool) {
+	if !h.jwtKeysEnabled(c) {
+		return nil, false
+	}
+	app, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return nil, false
+	}
+	return &app.ID, true
+}
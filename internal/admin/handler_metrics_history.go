@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/metricshistory"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+// ============================================================================
+// Metrics history
+// ============================================================================
+
+// GetMetricsHistory returns the embedded metrics history
+// @Summary Get the metrics history
+// @Description Request, 5xx, login, registration and logout counts per bucket, and active sessions and users, over the last 1h or 6h (minute buckets) or 24h, 7d or 30d (hourly buckets). Recorded in the database so the dashboard charts work without Prometheus; hourly buckets are kept for METRICS_HISTORY_RETENTION_DAYS. Buckets without data are null.
+// @Tags Admin
+// @Produce json
+// @Param range query string false "Time range: 1h, 6h, 24h, 7d or 30d" default(24h)
+// @Param metric query string false "Comma-separated metrics (http_requests, http_errors, logins, login_failures, registrations, logouts, active_sessions, users); all when omitted"
+// @Success 200 {object} dto.MetricsHistoryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/metrics/history [get]
+func (h *Handler) GetMetricsHistory(c *gin.Context) {
+	if h.MetricsHistory == nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "Metrics history is disabled (METRICS_HISTORY_ENABLED=false)"})
+		return
+	}
+	r, ok := metricshistory.ParseRange(c.Query("range"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid range: use 1h, 6h, 24h, 7d or 30d"})
+		return
+	}
+	metrics, err := metricshistory.ParseMetrics(c.Query("metric"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	history, err := h.MetricsHistory.Query(c.Request.Context(), r, metrics)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to read metrics history: " + err.Error()})
+		return
+	}
+
+	resp := dto.MetricsHistoryResponse{
+		Range:             r.Name,
+		ResolutionSeconds: r.Resolution,
+		Timestamps:        history.Timestamps,
+		Series:            make([]dto.MetricsHistorySeries, len(history.Series)),
+	}
+	for i, s := range history.Series {
+		resp.Series[i] = dto.MetricsHistorySeries{
+			Metric: s.Metric.Name,
+			Label:  s.Metric.Label,
+			Kind:   s.Metric.Kind,
+			Values: s.Values,
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/social"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+// ============================================================================
+// Social raw data retention
+// ============================================================================
+
+// RedactSocialRawData applies an application's raw data retention policy to stored social accounts
+// @Summary Redact the stored social provider data of an application
+// @Description Applies the application's social raw data retention policy to the provider payloads already stored with its social accounts: with "none" they are removed, with "allowlist" only the allowlisted fields are kept. New sign-ins follow the policy as soon as it is set; run this after tightening it. A "full" policy changes nothing.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} dto.SocialRawDataRedactResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/social-raw-data/redact [post]
+func (h *Handler) RedactSocialRawData(c *gin.Context) {
+	app, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+	checked, redacted, err := social.RedactStoredRawData(c.Request.Context(), h.Repo.DB, app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to redact social raw data: " + err.Error()})
+		return
+	}
+
+	policy := app.SocialRawData
+	if policy == "" {
+		policy = social.RawDataFull
+	}
+	c.JSON(http.StatusOK, dto.SocialRawDataRedactResponse{
+		Message:  fmt.Sprintf("Redacted the provider data of %d of %d social account(s)", redacted, checked),
+		Policy:   policy,
+		Checked:  checked,
+		Redacted: redacted,
+	})
+}
@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminToggleValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(&Repository{}, nil)
+	router := gin.New()
+	router.PUT("/users/:id/toggle", handler.ToggleUserActive)
+	router.PUT("/oauth-configs/:id/toggle", handler.ToggleOAuthConfig)
+
+	const validID = "3f9c1d4e-8a2b-4c5d-9e6f-7a8b9c0d1e2f"
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"user invalid id", "/users/not-a-uuid/toggle", `{"is_active":false}`},
+		{"user missing state", "/users/" + validID + "/toggle", `{}`},
+		{"user invalid json", "/users/" + validID + "/toggle", `invalid`},
+		{"oauth invalid id", "/oauth-configs/not-a-uuid/toggle", `{"is_enabled":true}`},
+		{"oauth missing state", "/oauth-configs/" + validID + "/toggle", `{}`},
+	}
+	for _, tc := range tests {
+		req, _ := http.NewRequest(http.MethodPut, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tc.name, w.Code)
+		}
+	}
+}
+
+func TestAdminUpsertByExternalIDValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(&Repository{}, nil)
+	router := gin.New()
+	// Registered next to the :id routes as in main.go, so a conflict would panic here
+	router.GET("/tenants/:id", handler.GetTenant)
+	router.PUT("/tenants/by-external-id/:external_id", handler.UpsertTenantByExternalID)
+	router.GET("/apps/:id", handler.GetAppDetails)
+	router.PUT("/apps/by-external-id/:external_id", handler.UpsertAppByExternalID)
+	router.GET("/oauth-configs/:id", handler.GetOAuthConfig)
+	router.PUT("/oauth-configs/by-external-id/:external_id", handler.UpsertOAuthConfigByExternalID)
+	router.GET("/email-templates/:id", handler.GetEmailTemplate)
+	router.PUT("/email-templates/by-external-id/:external_id", handler.UpsertEmailTemplateByExternalID)
+
+	const validID = "3f9c1d4e-8a2b-4c5d-9e6f-7a8b9c0d1e2f"
+	longID := string(bytes.Repeat([]byte("x"), maxExternalIDLength+1))
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"tenant external id too long", "/tenants/by-external-id/" + longID, `{"name":"Acme"}`},
+		{"tenant missing name", "/tenants/by-external-id/tf-acme", `{}`},
+		{"app invalid tenant", "/apps/by-external-id/tf-app", `{"tenant_id":"nope","name":"App"}`},
+		{"app invalid callback mode", "/apps/by-external-id/tf-app", `{"tenant_id":"` + validID + `","name":"App","social_callback_mode":"bogus"}`},
+		{"oauth invalid app", "/oauth-configs/by-external-id/tf-google", `{"app_id":"nope","provider":"google","client_id":"id","redirect_url":"https://x"}`},
+		{"oauth missing client id", "/oauth-configs/by-external-id/tf-google", `{"app_id":"` + validID + `","provider":"google","redirect_url":"https://x"}`},
+		{"template invalid type", "/email-templates/by-external-id/tf-welcome", `{"email_type_id":"nope","name":"Welcome","subject":"Hi","template_engine":"go_template"}`},
+		{"template invalid engine", "/email-templates/by-external-id/tf-welcome", `{"email_type_id":"` + validID + `","name":"Welcome","subject":"Hi","template_engine":"jinja"}`},
+		{"template invalid app", "/email-templates/by-external-id/tf-welcome", `{"app_id":"nope","email_type_id":"` + validID + `","name":"Welcome","subject":"Hi","template_engine":"go_template"}`},
+	}
+	for _, tc := range tests {
+		req, _ := http.NewRequest(http.MethodPut, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tc.name, w.Code)
+		}
+	}
+}
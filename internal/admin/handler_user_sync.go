@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// ============================================================================
+// User sync (change events for downstream systems)
+// ============================================================================
+
+// GetUserSyncStatus reports the user change events of an application waiting to be relayed
+// @Summary Get the user sync status of an application
+// @Description Number of user change events of the application waiting to be relayed to its user.created, user.updated, user.deleted and user.snapshot webhooks and the USER_SYNC_STREAM Redis stream. Follow a backfill with it.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} dto.UserSyncStatusResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/user-sync [get]
+func (h *Handler) GetUserSyncStatus(c *gin.Context) {
+	app, ok := h.userSyncApp(c)
+	if !ok {
+		return
+	}
+	pending, err := h.UserSync.Pending(c.Request.Context(), app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to count pending user changes: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.UserSyncStatusResponse{AppID: app.ID.String(), Pending: pending})
+}
+
+// BackfillUserSync queues a snapshot of every user of an application
+// @Summary Backfill the users of an application
+// @Description Queues a user.snapshot event with the sanitized state of every user of the application, relayed like other user changes, for the initial load of a new downstream consumer. Register a user.snapshot webhook (or set USER_SYNC_STREAM) first. Changes made during the backfill follow as user.updated events with a higher sequence.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 202 {object} dto.UserSyncBackfillResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/user-sync/backfill [post]
+func (h *Handler) BackfillUserSync(c *gin.Context) {
+	app, ok := h.userSyncApp(c)
+	if !ok {
+		return
+	}
+	queued, err := h.UserSync.Backfill(c.Request.Context(), app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to queue the backfill: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, dto.UserSyncBackfillResponse{
+		Message: fmt.Sprintf("Queued %d user snapshot(s)", queued),
+		Queued:  queued,
+	})
+}
+
+// userSyncApp returns the application of the request, after answering 503
+// when user sync is disabled or 404 for an unknown application.
+func (h *Handler) userSyncApp(c *gin.Context) (*models.Application, bool) {
+	if h.UserSync == nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "User sync is disabled (USER_SYNC_ENABLED=false)"})
+		return nil, false
+	}
+	app, err := h.Repo.GetAppByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return nil, false
+	}
+	return app, true
+}
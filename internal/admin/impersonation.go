@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/util"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScopeUsersImpersonate is the app API key scope required to issue
+// impersonation tokens. It must be granted by name: "*" and "users:*" do not
+// cover it.
+const ScopeUsersImpersonate = "users:impersonate"
+
+// ErrImpersonationTargetInactive is returned for a user that cannot sign in.
+var ErrImpersonationTargetInactive = errors.New("user is deactivated, locked or awaiting approval")
+
+// GetImpersonationTarget returns the user of the application to impersonate.
+// Users who could not sign in themselves cannot be impersonated.
+func (r *Repository) GetImpersonationTarget(appID, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.DB.Select("id, app_id, is_active, locked_at, lock_expires_at, approval_status").
+		Where("id = ? AND app_id = ? AND NOT is_service_account", userID, appID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	locked := user.LockedAt != nil && (user.LockExpiresAt == nil || user.LockExpiresAt.After(time.Now()))
+	if !user.IsActive || locked || (user.ApprovalStatus != "" && user.ApprovalStatus != models.ApprovalStatusApproved) {
+		return nil, ErrImpersonationTargetInactive
+	}
+	return &user, nil
+}
+
+// ImpersonateUser issues a short-lived impersonation token (App API)
+// @Summary Impersonate a user (App API)
+// @Description Issue an access token for signing in as the user in a support workflow. Requires an app API key granted the users:impersonate scope by name (wildcard scopes do not grant it).
+// @Description The token carries an "imp" claim (who impersonates and why) that clients use to show an impersonation banner; API responses to it include the X-Impersonated-By header.
+// @Description It expires after ttl_minutes, at most IMPERSONATION_TOKEN_MAX_TTL_MINUTES (default 15), and cannot be refreshed.
+// @Description Every token is recorded in the user's activity log (USER_IMPERSONATED) and in the admin audit log.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param user_id path string true "User ID"
+// @Param request body dto.ImpersonateUserRequest true "Impersonation details"
+// @Success 201 {object} dto.ImpersonationTokenResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AppApiKey
+// @Router /app/{id}/users/{user_id}/impersonate [post]
+func (h *Handler) ImpersonateUser(c *gin.Context) {
+	start := time.Now()
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	var req dto.ImpersonateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, err := h.Repo.GetImpersonationTarget(appID, userID); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "User not found"})
+		case errors.Is(err, ErrImpersonationTargetInactive):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Cannot impersonate user: " + err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load user"})
+		}
+		return
+	}
+
+	imp := &jwt.Impersonation{By: req.ImpersonatedBy, Reason: req.Reason}
+	var apiKeyID *uuid.UUID
+	if v, ok := c.Get(web.ApiKeyIDKey); ok {
+		if id, ok := v.(uuid.UUID); ok {
+			apiKeyID = &id
+			imp.ApiKeyID = id.String()
+		}
+	}
+	token, claims, err := jwt.GenerateImpersonationToken(appID.String(), userID.String(), imp, time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to generate token"})
+		return
+	}
+	expiresAt := claims.ExpiresAt.Time
+
+	ip, userAgent := util.GetClientInfo(c)
+	logService.LogUserImpersonated(appID, userID, ip, userAgent, map[string]interface{}{
+		"impersonated_by": imp.By,
+		"reason":          imp.Reason,
+		"api_key_id":      imp.ApiKeyID,
+		"token_id":        claims.ID,
+		"expires_at":      expiresAt.UTC().Format(time.RFC3339),
+	})
+
+	// Recorded whether or not ADMIN_AUDIT_CAPTURE_ENABLED captures Admin API requests
+	reqBody, _ := json.Marshal(req)
+	respBody, _ := json.Marshal(map[string]interface{}{"user_id": userID, "token_id": claims.ID, "expires_at": expiresAt})
+	entry := &models.AdminAuditLog{
+		Method:       c.Request.Method,
+		Route:        c.FullPath(),
+		Path:         c.Request.URL.Path,
+		StatusCode:   http.StatusCreated,
+		ApiKeyID:     apiKeyID,
+		IPAddress:    ip,
+		UserAgent:    userAgent,
+		RequestBody:  string(reqBody),
+		ResponseBody: string(respBody),
+		DurationMs:   time.Since(start).Milliseconds(),
+	}
+	if err := h.Repo.CreateAdminAuditLog(entry); err != nil {
+		log.Printf("Warning: failed to record admin audit log for impersonation of user %s: %v\n", userID, err)
+	}
+	log.Printf("Impersonation: token %s issued for user %s of app %s to %q (reason: %q)\n", claims.ID, userID, appID, imp.By, imp.Reason)
+
+	c.JSON(http.StatusCreated, dto.ImpersonationTokenResponse{
+		AccessToken:    token,
+		TokenType:      "Bearer",
+		ExpiresIn:      int64(time.Until(expiresAt).Seconds()),
+		ExpiresAt:      expiresAt,
+		UserID:         userID,
+		ImpersonatedBy: imp.By,
+	})
+}
@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AppKillSwitchResult summarizes what RevokeAllAppSessions invalidated.
+type AppKillSwitchResult struct {
+	Epoch          time.Time // Tokens issued at or before this second are rejected
+	Sessions       int64
+	TrustedDevices int64
+}
+
+// RevokeAllAppSessions is the per-application kill switch for incident
+// response. It raises the application's auth epoch, so that the auth
+// middleware and every refresh path reject all tokens of the application
+// issued until now, then deletes its sessions (including OIDC browser
+// sessions) and trusted devices, which invalidates "remember this device"
+// cookies. Accounts are untouched; users sign in again.
+//
+// The epoch has one-second precision: tokens issued later within the same
+// second are rejected too.
+func (r *Repository) RevokeAllAppSessions(appID uuid.UUID) (*AppKillSwitchResult, error) {
+	epoch := time.Now().UTC().Truncate(time.Second)
+	res := r.DB.Model(&models.Application{}).Where("id = ?", appID).UpdateColumn("auth_epoch_at", epoch)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	// Tokens are rejected once the epoch is in Redis; the rest is cleanup
+	if err := redis.SetAppAuthEpoch(appID.String(), epoch); err != nil {
+		return nil, fmt.Errorf("failed to store auth epoch: %w", err)
+	}
+	result := &AppKillSwitchResult{Epoch: epoch}
+
+	sessions, err := redis.DeleteAllAppSessions(appID.String())
+	result.Sessions = sessions
+	if err != nil {
+		log.Printf("Warning: Failed to delete sessions of app %s after raising its auth epoch: %v\n", appID, err)
+	}
+
+	devices := r.DB.Where("app_id = ?", appID).Delete(&models.TrustedDevice{})
+	if devices.Error != nil {
+		return result, fmt.Errorf("failed to delete trusted devices: %w", devices.Error)
+	}
+	result.TrustedDevices = devices.RowsAffected
+
+	log.Printf("Kill switch: revoked all sessions of app %s (%d sessions, %d trusted devices)\n", appID, result.Sessions, result.TrustedDevices)
+	return result, nil
+}
+
+// RestoreAppAuthEpochs copies the auth epochs recorded on applications to
+// Redis, where the auth middleware reads them, so that a Redis flush does not
+// revive tokens revoked by the kill switch. Called at startup.
+func (r *Repository) RestoreAppAuthEpochs() error {
+	var apps []models.Application
+	if err := r.DB.Select("id, auth_epoch_at").Where("auth_epoch_at IS NOT NULL").Find(&apps).Error; err != nil {
+		return err
+	}
+	for _, app := range apps {
+		current, err := redis.GetAppAuthEpoch(app.ID.String())
+		if err != nil {
+			return err
+		}
+		if current.Before(*app.AuthEpochAt) {
+			if err := redis.SetAppAuthEpoch(app.ID.String(), *app.AuthEpochAt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/secretbox"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthConfigExportVersion is the version of the OAuth config export format.
+const OAuthConfigExportVersion = 1
+
+// Secret handling of OAuth config export documents (OAuthConfigExport.Secrets)
+const (
+	OAuthSecretsRedacted  = "redacted"
+	OAuthSecretsEncrypted = "encrypted"
+	OAuthSecretsPlain     = "plain"
+)
+
+// errOAuthImportDryRun rolls back the transaction of a dry-run import.
+var errOAuthImportDryRun = errors.New("dry run")
+
+// OAuthConfigExportRow is an OAuth config with the name and external ID of its application.
+type OAuthConfigExportRow struct {
+	models.OAuthProviderConfig `gorm:"embedded"`
+	AppName                    string
+	AppExternalID              *string
+}
+
+// ListOAuthConfigsForExport returns the OAuth configs of the given applications
+// (all when appIDs is empty), optionally only those of a tenant, ordered by
+// application name and provider.
+func (r *Repository) ListOAuthConfigsForExport(appIDs []uuid.UUID, tenantID *uuid.UUID) ([]OAuthConfigExportRow, error) {
+	query := r.DB.Table("oauth_provider_configs").
+		Select("oauth_provider_configs.*, applications.name AS app_name, applications.external_id AS app_external_id").
+		Joins("JOIN applications ON applications.id = oauth_provider_configs.app_id")
+	if len(appIDs) > 0 {
+		query = query.Where("oauth_provider_configs.app_id IN ?", appIDs)
+	}
+	if tenantID != nil {
+		query = query.Where("applications.tenant_id = ?", *tenantID)
+	}
+	var rows []OAuthConfigExportRow
+	err := query.Order("applications.name, oauth_provider_configs.app_id, oauth_provider_configs.provider").Scan(&rows).Error
+	return rows, err
+}
+
+// ImportOAuthConfig creates or replaces the OAuth config of in's app and
+// provider. A config with an external ID is upserted by it, as by
+// PUT /admin/oauth-configs/by-external-id/:external_id. An empty client secret
+// keeps the stored secret and is an ErrClientSecretRequired for a new config.
+func (r *Repository) ImportOAuthConfig(in *models.OAuthProviderConfig) (bool, error) {
+	if in.ExternalID != nil {
+		_, created, err := r.UpsertOAuthConfigByExternalID(*in.ExternalID, in, func(*models.OAuthProviderConfig) error { return nil })
+		return created, err
+	}
+
+	created := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.OAuthProviderConfig
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, "app_id = ? AND provider = ?", in.AppID, in.Provider).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if in.ClientSecret == "" {
+				return ErrClientSecretRequired
+			}
+			created = true
+			return tx.Create(in).Error
+		}
+		if err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"client_id":    in.ClientID,
+			"redirect_url": in.RedirectURL,
+			"is_enabled":   in.IsEnabled,
+			// Replaced as a whole; nil removes the mappings
+			"claim_mappings": in.ClaimMappings,
+			// Just-in-time provisioning
+			"provisioning_mode":     in.ProvisioningMode,
+			"allowed_email_domains": in.AllowedEmailDomains,
+			// Generic OpenID Connect providers
+			"issuer_url": in.IssuerURL,
+			"scopes":     in.Scopes,
+		}
+		if in.ClientSecret != "" {
+			updates["client_secret"] = in.ClientSecret
+		}
+		return tx.Model(&current).Updates(updates).Error
+	})
+	return created, err
+}
+
+// buildOAuthConfigExport returns the export document of rows. With a transport
+// key the client secrets are sealed with it, otherwise they are left out.
+func buildOAuthConfigExport(rows []OAuthConfigExportRow, transportKey string) (*dto.OAuthConfigExport, error) {
+	doc := &dto.OAuthConfigExport{
+		Version:    OAuthConfigExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Secrets:    OAuthSecretsRedacted,
+		Configs:    make([]dto.OAuthConfigExportItem, len(rows)),
+	}
+	if transportKey != "" {
+		doc.Secrets = OAuthSecretsEncrypted
+	}
+	for i, row := range rows {
+		item := dto.OAuthConfigExportItem{
+			AppID:               row.AppID.String(),
+			AppName:             row.AppName,
+			AppExternalID:       row.AppExternalID,
+			Provider:            row.Provider,
+			ClientID:            row.ClientID,
+			RedirectURL:         row.RedirectURL,
+			IsEnabled:           row.IsEnabled,
+			ExternalID:          row.ExternalID,
+			ClaimMappings:       json.RawMessage(row.ClaimMappings),
+			ProvisioningMode:    row.ProvisioningMode,
+			AllowedEmailDomains: row.AllowedEmailDomains,
+			IssuerURL:           row.IssuerURL,
+			Scopes:              row.Scopes,
+		}
+		if transportKey != "" {
+			sealed, err := secretbox.Seal(row.ClientSecret, transportKey)
+			if err != nil {
+				return nil, err
+			}
+			item.ClientSecret = sealed
+		}
+		doc.Configs[i] = item
+	}
+	return doc, nil
+}
+
+// oauthConfigFromImport returns the OAuth config of an import item for its
+// target app, with the client secret decrypted as the document's secrets mode
+// requires.
+func oauthConfigFromImport(item dto.OAuthConfigExportItem, appID uuid.UUID, secrets, transportKey string) (*models.OAuthProviderConfig, error) {
+	if strings.TrimSpace(item.Provider) == "" || strings.TrimSpace(item.ClientID) == "" || strings.TrimSpace(item.RedirectURL) == "" {
+		return nil, fmt.Errorf("provider, client_id and redirect_url are required")
+	}
+	if item.ProvisioningMode != "" && item.ProvisioningMode != models.ProvisioningModeAuto && item.ProvisioningMode != models.ProvisioningModeExistingOnly {
+		return nil, fmt.Errorf("invalid provisioning_mode %q", item.ProvisioningMode)
+	}
+	claimMappings, err := parseClaimMappings(item.ClaimMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret string
+	switch secrets {
+	case OAuthSecretsEncrypted:
+		if item.ClientSecret != "" && !secretbox.IsEncrypted(item.ClientSecret) {
+			return nil, fmt.Errorf("client_secret is not encrypted")
+		}
+		if secret, err = secretbox.Open(item.ClientSecret, transportKey); err != nil {
+			return nil, err
+		}
+	case OAuthSecretsPlain:
+		secret = item.ClientSecret
+	}
+	// Redacted documents carry no secrets: existing configs keep theirs
+
+	config := &models.OAuthProviderConfig{
+		AppID:         appID,
+		Provider:      strings.TrimSpace(item.Provider),
+		ClientID:      strings.TrimSpace(item.ClientID),
+		ClientSecret:  secret,
+		RedirectURL:   strings.TrimSpace(item.RedirectURL),
+		IsEnabled:     item.IsEnabled,
+		ExternalID:    item.ExternalID,
+		ClaimMappings: claimMappings,
+		// Just-in-time provisioning
+		ProvisioningMode:    provisioningModeOrDefault(item.ProvisioningMode),
+		AllowedEmailDomains: strings.TrimSpace(item.AllowedEmailDomains),
+		// Generic OpenID Connect providers
+		IssuerURL: strings.TrimSpace(item.IssuerURL),
+		Scopes:    strings.Join(strings.Fields(item.Scopes), " "),
+	}
+	if err := config.ValidateProvider(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// resolveImportApp returns the application an import item goes to: the one
+// app_map maps its app ID to, else the app with its app's external ID, else
+// the app with the same ID.
+func (r *Repository) resolveImportApp(item dto.OAuthConfigExportItem, appMap map[string]string) (uuid.UUID, error) {
+	target, mapped := appMap[item.AppID]
+	if !mapped {
+		target = item.AppID
+		if item.AppExternalID != nil {
+			var app models.Application
+			err := r.DB.Select("id").First(&app, "external_id = ?", *item.AppExternalID).Error
+			if err == nil {
+				return app.ID, nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return uuid.Nil, err
+			}
+		}
+	}
+	appID, err := uuid.Parse(target)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid app ID %q", target)
+	}
+	if err := r.DB.Select("id").First(&models.Application{}, "id = ?", appID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, fmt.Errorf("application %s not found", appID)
+		}
+		return uuid.Nil, err
+	}
+	return appID, nil
+}
+
+// ExportOAuthConfigs exports OAuth provider configs
+// @Summary Export OAuth configurations
+// @Description Export the OAuth provider configs of all applications, or of the given applications or tenant, as a document POST /admin/oauth-configs/import accepts in another environment. Client secrets are left out unless secrets=encrypt, which seals them with the transport_key (AES-256-GCM); the same key is needed to import them.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   request  body      dto.OAuthConfigExportRequest  true  "Export options"
+// @Success 200 {object} dto.OAuthConfigExport
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs/export [post]
+func (h *Handler) ExportOAuthConfigs(c *gin.Context) {
+	var req dto.OAuthConfigExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	transportKey := ""
+	if req.Secrets == "encrypt" {
+		if len(req.TransportKey) < secretbox.MinTransportKeyLength {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: secretbox.ErrTransportKeyTooShort.Error()})
+			return
+		}
+		transportKey = req.TransportKey
+	}
+
+	appIDs := make([]uuid.UUID, 0, len(req.AppIDs))
+	for _, id := range req.AppIDs {
+		appID, err := uuid.Parse(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID: " + id})
+			return
+		}
+		appIDs = append(appIDs, appID)
+	}
+	var tenantID *uuid.UUID
+	if req.TenantID != "" {
+		id, err := uuid.Parse(req.TenantID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid Tenant ID"})
+			return
+		}
+		tenantID = &id
+	}
+
+	rows, err := h.Repo.ListOAuthConfigsForExport(appIDs, tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list OAuth configs"})
+		return
+	}
+	doc, err := buildOAuthConfigExport(rows, transportKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to encrypt client secrets"})
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// ImportOAuthConfigs imports OAuth provider configs
+// @Summary Import OAuth configurations
+// @Description Create or update OAuth provider configs from an export document, e.g. of another environment. Each config goes to the application app_map maps its app_id to, else the application with its app_external_id, else the one with the same ID, and replaces that app's config of the provider (or the config with its external_id). Encrypted secrets need the transport_key they were exported with; redacted documents keep the stored secrets and cannot create configs. A hand-written document with secrets=plain imports plaintext secrets, e.g. credentials kept in environment variables. All configs are imported in one transaction; with dry_run=true it is rolled back.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param   request  body      dto.OAuthConfigImportRequest  true  "Export document and import options"
+// @Success 200 {object} dto.OAuthConfigImportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/oauth-configs/import [post]
+func (h *Handler) ImportOAuthConfigs(c *gin.Context) {
+	var req dto.OAuthConfigImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Version > OAuthConfigExportVersion {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: fmt.Sprintf("Unsupported export version %d", req.Version)})
+		return
+	}
+	if req.Secrets == OAuthSecretsEncrypted && len(req.TransportKey) < secretbox.MinTransportKeyLength {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: secretbox.ErrTransportKeyTooShort.Error()})
+		return
+	}
+
+	resp := dto.OAuthConfigImportResponse{DryRun: req.DryRun, Results: make([]dto.OAuthConfigImportResult, len(req.Configs))}
+	err := h.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		repo := &Repository{DB: tx}
+		for i, item := range req.Configs {
+			result := dto.OAuthConfigImportResult{SourceAppID: item.AppID, Provider: item.Provider}
+			created, err := repo.importOAuthConfigItem(item, &req, &result)
+			switch {
+			case errors.Is(err, secretbox.ErrWrongTransportKey):
+				return err
+			case err != nil:
+				result.Status = "failed"
+				result.Error = err.Error()
+				resp.Failed++
+			case created:
+				result.Status = "created"
+				resp.Created++
+			default:
+				result.Status = "updated"
+				resp.Updated++
+			}
+			resp.Results[i] = result
+		}
+		if req.DryRun {
+			return errOAuthImportDryRun
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, secretbox.ErrWrongTransportKey):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	case err != nil && !errors.Is(err, errOAuthImportDryRun):
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to import OAuth configs"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// importOAuthConfigItem imports one config of an import request in a
+// savepoint, so a failed config does not abort the others.
+func (r *Repository) importOAuthConfigItem(item dto.OAuthConfigExportItem, req *dto.OAuthConfigImportRequest, result *dto.OAuthConfigImportResult) (bool, error) {
+	appID, err := r.resolveImportApp(item, req.AppMap)
+	if err != nil {
+		return false, err
+	}
+	result.AppID = appID.String()
+	config, err := oauthConfigFromImport(item, appID, req.Secrets, req.TransportKey)
+	if err != nil {
+		return false, err
+	}
+	created := false
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		var txErr error
+		created, txErr = (&Repository{DB: tx}).ImportOAuthConfig(config)
+		return txErr
+	})
+	return created, err
+}
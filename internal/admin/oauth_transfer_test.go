@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/internal/secretbox"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestOAuthConfigExportRoundTrip(t *testing.T) {
+	extID := "google-prod"
+	rows := []OAuthConfigExportRow{{
+		OAuthProviderConfig: models.OAuthProviderConfig{
+			AppID:            uuid.New(),
+			Provider:         "google",
+			ClientID:         "client-id",
+			ClientSecret:     "client-secret",
+			RedirectURL:      "https://auth.example.com/auth/google/callback",
+			IsEnabled:        true,
+			ExternalID:       &extID,
+			ProvisioningMode: models.ProvisioningModeExistingOnly,
+		},
+		AppName: "Shop",
+	}}
+	const key = "transport-key-0123"
+	target := uuid.New()
+
+	doc, err := buildOAuthConfigExport(rows, key)
+	if err != nil {
+		t.Fatalf("buildOAuthConfigExport: %v", err)
+	}
+	item := doc.Configs[0]
+	if doc.Secrets != OAuthSecretsEncrypted || !secretbox.IsEncrypted(item.ClientSecret) {
+		t.Fatalf("secret not encrypted: %s %q", doc.Secrets, item.ClientSecret)
+	}
+	if item.AppName != "Shop" || *item.ExternalID != extID {
+		t.Errorf("got %+v", item)
+	}
+
+	config, err := oauthConfigFromImport(item, target, doc.Secrets, key)
+	if err != nil {
+		t.Fatalf("oauthConfigFromImport: %v", err)
+	}
+	if config.AppID != target || config.ClientSecret != "client-secret" || config.ProvisioningMode != models.ProvisioningModeExistingOnly || !config.IsEnabled {
+		t.Errorf("got %+v", config)
+	}
+	if _, err := oauthConfigFromImport(item, target, doc.Secrets, "another-key-45678"); err != secretbox.ErrWrongTransportKey {
+		t.Errorf("wrong key: err = %v, want ErrWrongTransportKey", err)
+	}
+
+	redacted, err := buildOAuthConfigExport(rows, "")
+	if err != nil {
+		t.Fatalf("buildOAuthConfigExport: %v", err)
+	}
+	if redacted.Secrets != OAuthSecretsRedacted || redacted.Configs[0].ClientSecret != "" {
+		t.Errorf("secret exported without a transport key: %+v", redacted.Configs[0])
+	}
+	config, err = oauthConfigFromImport(redacted.Configs[0], target, redacted.Secrets, "")
+	if err != nil || config.ClientSecret != "" {
+		t.Errorf("redacted import: %+v, %v", config, err)
+	}
+}
+
+func TestOAuthConfigFromImportValidation(t *testing.T) {
+	appID := uuid.New()
+	item := dto.OAuthConfigExportItem{
+		AppID:       uuid.NewString(),
+		Provider:    "github",
+		ClientID:    "client-id",
+		RedirectURL: "https://auth.example.com/auth/github/callback",
+		IsEnabled:   true,
+	}
+
+	plain := item
+	plain.ClientSecret = "from-env"
+	if config, err := oauthConfigFromImport(plain, appID, OAuthSecretsPlain, ""); err != nil || config.ClientSecret != "from-env" {
+		t.Errorf("plain import: %+v, %v", config, err)
+	}
+
+	notSealed := item
+	notSealed.ClientSecret = "plaintext"
+	if _, err := oauthConfigFromImport(notSealed, appID, OAuthSecretsEncrypted, "transport-key-0123"); err == nil {
+		t.Error("unencrypted secret in an encrypted document: expected an error")
+	}
+
+	missing := item
+	missing.ClientID = ""
+	if _, err := oauthConfigFromImport(missing, appID, OAuthSecretsRedacted, ""); err == nil {
+		t.Error("missing client_id: expected an error")
+	}
+
+	badMode := item
+	badMode.ProvisioningMode = "sometimes"
+	if _, err := oauthConfigFromImport(badMode, appID, OAuthSecretsRedacted, ""); err == nil {
+		t.Error("invalid provisioning_mode: expected an error")
+	}
+}
@@ -1,16 +1,26 @@
 package admin
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/bruteforce"
+	"github.com/gjovanovicst/auth_api/internal/environment"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/internal/search"
 	"github.com/gjovanovicst/auth_api/internal/sso"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -35,16 +45,33 @@ func (r *Repository) GetTenantByID(id string) (*models.Tenant, error) {
 	return &tenant, nil
 }
 
-func (r *Repository) ListTenants(page, pageSize int) ([]models.Tenant, int64, error) {
+// tenantListSpec is the sorting and filtering of the Admin API tenant list.
+var tenantListSpec = &pagination.ListSpec{
+	DefaultPageSize: 10,
+	Sorts: map[string]string{
+		"name":       "tenants.name",
+		"created_at": "tenants.created_at",
+		"updated_at": "tenants.updated_at",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "tenants.id",
+	Filters: map[string]pagination.Filter{
+		"name":        {Column: "tenants.name", Kind: pagination.FilterContains},
+		"external_id": {Column: "tenants.external_id", Kind: pagination.FilterExact},
+	},
+}
+
+func (r *Repository) ListTenants(q pagination.ListQuery) ([]models.Tenant, int64, error) {
 	var tenants []models.Tenant
 	var total int64
 
-	if err := r.DB.Model(&models.Tenant{}).Count(&total).Error; err != nil {
+	query := q.ApplyFilters(r.DB.Model(&models.Tenant{}))
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (page - 1) * pageSize
-	if err := r.DB.Limit(pageSize).Offset(offset).Order("created_at desc").Find(&tenants).Error; err != nil {
+	if err := q.ApplyPage(query).Find(&tenants).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -86,8 +113,11 @@ func (r *Repository) ListTenantsWithAppCount(page, pageSize int) ([]TenantListIt
 	return items, total, nil
 }
 
-func (r *Repository) UpdateTenant(id string, name string) error {
-	return r.DB.Model(&models.Tenant{}).Where("id = ?", id).Update("name", name).Error
+func (r *Repository) UpdateTenant(id string, name string, maxApps int) error {
+	return r.DB.Model(&models.Tenant{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":     name,
+		"max_apps": maxApps,
+	}).Error
 }
 
 func (r *Repository) DeleteTenant(id string) error {
@@ -213,6 +243,7 @@ type AppListItem struct {
 	Passkey2FAEnabled   bool
 	PasskeyLoginEnabled bool
 	MagicLinkEnabled    bool
+	PasswordlessOnly    bool
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
 }
@@ -238,7 +269,7 @@ func (r *Repository) ListAppsWithDetails(page, pageSize int, tenantID string) ([
 			applications.created_at, applications.updated_at,
 			applications.two_fa_enabled, applications.two_fa_required,
 			applications.passkey2_fa_enabled, applications.passkey_login_enabled,
-			applications.magic_link_enabled,
+			applications.magic_link_enabled, applications.passwordless_only,
 			tenants.name as tenant_name,
 			COUNT(oauth_provider_configs.id) as o_auth_config_count`).
 		Joins("LEFT JOIN tenants ON tenants.id = applications.tenant_id").
@@ -284,6 +315,15 @@ type AppCustomizationSettings struct {
 	LoginPrimaryColor   string
 	LoginSecondaryColor string
 	LoginDisplayName    string
+	// Email Branding
+	EmailLogoURL       string
+	EmailFooterText    string
+	EmailPostalAddress string
+	EmailSocialLinks   string
+	// Email Quiet Hours
+	EmailQuietHoursStart string
+	EmailQuietHoursEnd   string
+	EmailTimeZone        string
 	// Password Policy
 	PwMinLength     int
 	PwMaxLength     int
@@ -296,13 +336,39 @@ type AppCustomizationSettings struct {
 	// Token TTL overrides (0 = use global defaults)
 	AccessTokenTTLMinutes int
 	RefreshTokenTTLHours  int
+	// Concurrent session limit (0 = unlimited) and policy (see session.LimitPolicy*)
+	MaxSessionsPerUser int
+	SessionLimitPolicy string
 	// Email Action Link Paths (empty = use system defaults)
 	ResetPasswordPath string
 	MagicLinkPath     string
 	VerifyEmailPath   string
-}
-
-func (r *Repository) UpdateApp(id string, name string, description string, frontendURL string, twoFAIssuerName string, twoFAEnabled bool, twoFARequired bool, passkey2FAEnabled bool, passkeyLoginEnabled bool, magicLinkEnabled bool, oidcEnabled bool, bf BruteForceAppSettings, custom AppCustomizationSettings) error {
+	// Redirect allowlist (empty = use ALLOWED_REDIRECT_DOMAINS)
+	AllowedRedirectURLs string
+	// Social login callback delivery mode (see social.CallbackMode*)
+	SocialCallbackMode string
+	// Social profile sync policy (see social.ProfileSync*) and interval of scheduled syncs
+	SocialProfileSync              string
+	SocialProfileSyncIntervalHours int
+	// Social raw data retention policy (see social.RawData*) and allowlisted fields
+	SocialRawData       string
+	SocialRawDataFields string
+	// Passkey-only mode: password registration, login and reset are disabled
+	PasswordlessOnly bool
+	// Per-app additions to the disposable email blocklist
+	BlockedEmailDomains string
+	// Verify email addresses with a 6-digit code instead of a link
+	EmailVerificationCode bool
+	// Hold new registrations for admin approval
+	RegistrationApprovalRequired bool
+	// Auth method policy (comma-separated models.AuthMethod*, empty = all)
+	AllowedAuthMethods string
+}
+
+// UpdateApp updates an application's settings. guard makes the update
+// conditional on the version the edit was based on (optlock.ErrConflict when
+// the application changed in the meantime).
+func (r *Repository) UpdateApp(id string, name string, description string, frontendURL string, twoFAIssuerName string, twoFAEnabled bool, twoFARequired bool, passkey2FAEnabled bool, passkeyLoginEnabled bool, magicLinkEnabled bool, oidcEnabled bool, bf BruteForceAppSettings, custom AppCustomizationSettings, guard optlock.Guard) error {
 	updates := map[string]interface{}{
 		"name":                  name,
 		"description":           description,
@@ -313,6 +379,7 @@ func (r *Repository) UpdateApp(id string, name string, description string, front
 		"passkey2_fa_enabled":   passkey2FAEnabled,
 		"passkey_login_enabled": passkeyLoginEnabled,
 		"magic_link_enabled":    magicLinkEnabled,
+		"passwordless_only":     custom.PasswordlessOnly,
 		"oidc_enabled":          oidcEnabled,
 		// Brute-force lockout overrides
 		"bf_lockout_enabled":   bf.LockoutEnabled,
@@ -334,6 +401,15 @@ func (r *Repository) UpdateApp(id string, name string, description string, front
 		"login_primary_color":   custom.LoginPrimaryColor,
 		"login_secondary_color": custom.LoginSecondaryColor,
 		"login_display_name":    custom.LoginDisplayName,
+		// Email Branding
+		"email_logo_url":       custom.EmailLogoURL,
+		"email_footer_text":    custom.EmailFooterText,
+		"email_postal_address": custom.EmailPostalAddress,
+		"email_social_links":   custom.EmailSocialLinks,
+		// Email Quiet Hours
+		"email_quiet_hours_start": custom.EmailQuietHoursStart,
+		"email_quiet_hours_end":   custom.EmailQuietHoursEnd,
+		"email_time_zone":         custom.EmailTimeZone,
 		// Password Policy
 		"pw_min_length":     custom.PwMinLength,
 		"pw_max_length":     custom.PwMaxLength,
@@ -346,10 +422,30 @@ func (r *Repository) UpdateApp(id string, name string, description string, front
 		// Token TTL overrides
 		"access_token_ttl_minutes": custom.AccessTokenTTLMinutes,
 		"refresh_token_ttl_hours":  custom.RefreshTokenTTLHours,
+		// Concurrent session limit
+		"max_sessions_per_user": custom.MaxSessionsPerUser,
+		"session_limit_policy":  custom.SessionLimitPolicy,
 		// Email Action Link Paths
 		"reset_password_path": custom.ResetPasswordPath,
 		"magic_link_path":     custom.MagicLinkPath,
 		"verify_email_path":   custom.VerifyEmailPath,
+		// Redirect allowlist
+		"allowed_redirect_urls": custom.AllowedRedirectURLs,
+		"social_callback_mode":  custom.SocialCallbackMode,
+		// Social profile sync
+		"social_profile_sync":                custom.SocialProfileSync,
+		"social_profile_sync_interval_hours": custom.SocialProfileSyncIntervalHours,
+		// Social raw data retention
+		"social_raw_data":        custom.SocialRawData,
+		"social_raw_data_fields": custom.SocialRawDataFields,
+		// Blocked email domains
+		"blocked_email_domains": custom.BlockedEmailDomains,
+		// Email verification by code
+		"email_verification_code": custom.EmailVerificationCode,
+		// Registration approval
+		"registration_approval_required": custom.RegistrationApprovalRequired,
+		// Auth method policy
+		"allowed_auth_methods": custom.AllowedAuthMethods,
 	}
 
 	// Only update CAPTCHA secret key if explicitly provided (non-nil and non-empty).
@@ -358,9 +454,40 @@ func (r *Repository) UpdateApp(id string, name string, description string, front
 		updates["bf_captcha_secret_key"] = bf.CaptchaSecretKey
 	}
 
-	return r.DB.Model(&models.Application{}).
-		Where("id = ?", id).
-		Updates(updates).Error
+	return optlock.Updates(r.DB, &models.Application{}, id, guard, updates)
+}
+
+// ForcePasswordRotation requires every user of an application whose password
+// was set before at to change it at their next login, and returns the number
+// of users with a password.
+func (r *Repository) ForcePasswordRotation(appID string, at time.Time) (int64, error) {
+	res := r.DB.Model(&models.Application{}).Where("id = ?", appID).Update("pw_rotation_required_at", at)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	var users int64
+	err := r.DB.Model(&models.User{}).Where("app_id = ? AND password_hash <> ''", appID).Count(&users).Error
+	return users, err
+}
+
+// PrunePasswordHistory trims the password history of every user of an
+// application to its keep most recent hashes (clears it when keep is 0) and
+// returns the number of users changed.
+func (r *Repository) PrunePasswordHistory(appID string, keep int) (int64, error) {
+	res := prunePasswordHistory(r.DB, appID, keep)
+	return res.RowsAffected, res.Error
+}
+
+// prunePasswordHistory builds the statement of PrunePasswordHistory. History
+// arrays hold the most recent hash first.
+func prunePasswordHistory(tx *gorm.DB, appID string, keep int) *gorm.DB {
+	return tx.Model(&models.User{}).
+		Where("app_id = ? AND jsonb_typeof(password_history) = 'array' AND jsonb_array_length(password_history) > ?", appID, keep).
+		UpdateColumn("password_history", gorm.Expr(
+			"(SELECT COALESCE(jsonb_agg(h.hash ORDER BY h.n), '[]'::jsonb) FROM jsonb_array_elements(password_history) WITH ORDINALITY AS h(hash, n) WHERE h.n <= ?)", keep))
 }
 
 func (r *Repository) DeleteApp(id string) error {
@@ -378,6 +505,181 @@ func (r *Repository) UpdateAppSMSTrustedDevice(id string, sms2FAEnabled bool, tr
 		}).Error
 }
 
+// UpdateAppQuotas updates the per-app quota overrides (0 = use the global default).
+func (r *Repository) UpdateAppQuotas(id string, maxUsers, maxApiKeys, maxEmailsPerDay int) error {
+	return r.DB.Model(&models.Application{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"max_users":          maxUsers,
+			"max_api_keys":       maxApiKeys,
+			"max_emails_per_day": maxEmailsPerDay,
+		}).Error
+}
+
+// ListEnvironments returns the environments of an application, ordered by environment name.
+func (r *Repository) ListEnvironments(parentID string) ([]models.Application, error) {
+	var apps []models.Application
+	if err := r.DB.Where("parent_app_id = ?", parentID).Order("environment ASC").Find(&apps).Error; err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// CreateEnvironment creates an environment of parent. The environment starts as a
+// copy of the parent's non-secret settings (security, branding, password policy,
+// token TTLs, quotas); secrets, OAuth/SMTP configs and API keys are not copied.
+func (r *Repository) CreateEnvironment(parent *models.Application, envName, name, frontendURL string, shareUsers bool) (*models.Application, error) {
+	env := copyAppSettings(parent)
+	env.ParentAppID = &parent.ID
+	env.Environment = envName
+	env.ShareUsers = shareUsers
+	env.Name = name
+	if env.Name == "" {
+		env.Name = fmt.Sprintf("%s (%s)", parent.Name, envName)
+	}
+	if frontendURL != "" {
+		env.FrontendURL = frontendURL
+	}
+
+	// Select("*") so zero values (e.g. disabled flags) are copied instead of
+	// being replaced by column defaults.
+	if err := r.DB.Select("*").Omit(clause.Associations).Create(&env).Error; err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// copyAppSettings returns a copy of src with a new ID and without secrets,
+// external ID, timestamps or loaded associations, ready to be inserted as a
+// new application.
+func copyAppSettings(src *models.Application) models.Application {
+	app := *src
+	app.ID = uuid.New()
+	app.ExternalID = nil       // unique; identifies the source to declarative upserts
+	app.OIDCRSAPrivateKey = "" // generated on first use, never shared
+	app.BfCaptchaSecretKey = nil
+	app.PwRotationRequiredAt = nil // incident response state of the source
+	app.AuthEpochAt = nil
+	app.CreatedAt = time.Time{}
+	app.UpdatedAt = time.Time{}
+	app.OAuthProviderConfigs = nil
+	app.EmailServerConfig = nil
+	app.OIDCClients = nil
+	return app
+}
+
+// CloneResult summarizes what CloneApp copied into the new application.
+type CloneResult struct {
+	App            *models.Application
+	OAuthConfigs   int
+	SMTPConfigs    int
+	EmailTemplates int
+}
+
+// CloneApp creates a new application under tenantID with the configuration of
+// source: all non-secret application settings (feature flags, password policy,
+// security, branding, token TTLs, quotas), OAuth provider configs, SMTP configs
+// and app-specific email templates.
+//
+// Secrets are never copied: OAuth configs are cloned without client secret and
+// disabled, SMTP configs are cloned without password (and disabled if they had
+// one). External IDs stay with the source. Users, API keys, OIDC clients and
+// environments are not cloned.
+func (r *Repository) CloneApp(source *models.Application, tenantID uuid.UUID, name, description string) (*CloneResult, error) {
+	app := copyAppSettings(source)
+	app.TenantID = tenantID
+	app.Name = name
+	if description != "" {
+		app.Description = description
+	}
+	app.ParentAppID = nil
+	app.Environment = environment.Production
+	app.ShareUsers = false
+
+	result := &CloneResult{App: &app}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("*").Omit(clause.Associations).Create(&app).Error; err != nil {
+			return err
+		}
+
+		var oauthConfigs []models.OAuthProviderConfig
+		if err := tx.Where("app_id = ?", source.ID).Find(&oauthConfigs).Error; err != nil {
+			return err
+		}
+		for _, src := range oauthConfigs {
+			cfg := cloneOAuthConfig(src, app.ID)
+			if err := tx.Select("*").Create(&cfg).Error; err != nil {
+				return err
+			}
+		}
+		result.OAuthConfigs = len(oauthConfigs)
+
+		var smtpConfigs []models.EmailServerConfig
+		if err := tx.Where("app_id = ?", source.ID).Find(&smtpConfigs).Error; err != nil {
+			return err
+		}
+		smtpIDs := make(map[uuid.UUID]uuid.UUID, len(smtpConfigs))
+		for _, cfg := range smtpConfigs {
+			newID := uuid.New()
+			smtpIDs[cfg.ID] = newID
+			cfg.ID = newID
+			cfg.AppID = &app.ID
+			cfg.IsActive = cfg.IsActive && cfg.SMTPPassword == ""
+			cfg.SMTPPassword = ""
+			cfg.CreatedAt, cfg.UpdatedAt = time.Time{}, time.Time{}
+			if err := tx.Select("*").Create(&cfg).Error; err != nil {
+				return err
+			}
+		}
+		result.SMTPConfigs = len(smtpConfigs)
+
+		var templates []models.EmailTemplate
+		if err := tx.Where("app_id = ?", source.ID).Find(&templates).Error; err != nil {
+			return err
+		}
+		for _, src := range templates {
+			tmpl := cloneEmailTemplate(src, app.ID, smtpIDs)
+			if err := tx.Select("*").Omit(clause.Associations).Create(&tmpl).Error; err != nil {
+				return err
+			}
+		}
+		result.EmailTemplates = len(templates)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cloneOAuthConfig returns a copy of cfg for the application appID: disabled,
+// without client secret and without external ID.
+func cloneOAuthConfig(cfg models.OAuthProviderConfig, appID uuid.UUID) models.OAuthProviderConfig {
+	cfg.ID = uuid.New()
+	cfg.AppID = appID
+	cfg.ClientSecret = ""
+	cfg.IsEnabled = false
+	cfg.ExternalID = nil
+	cfg.CreatedAt, cfg.UpdatedAt = time.Time{}, time.Time{}
+	return cfg
+}
+
+// cloneEmailTemplate returns a copy of tmpl for the application appID, without
+// external ID. smtpIDs maps the source's SMTP config IDs to their clones.
+func cloneEmailTemplate(tmpl models.EmailTemplate, appID uuid.UUID, smtpIDs map[uuid.UUID]uuid.UUID) models.EmailTemplate {
+	tmpl.ID = uuid.New()
+	tmpl.AppID = &appID
+	tmpl.ExternalID = nil
+	if tmpl.ServerConfigID != nil {
+		// Re-point links to the source's SMTP configs; global configs stay linked.
+		if newID, ok := smtpIDs[*tmpl.ServerConfigID]; ok {
+			tmpl.ServerConfigID = &newID
+		}
+	}
+	tmpl.CreatedAt, tmpl.UpdatedAt = time.Time{}, time.Time{}
+	return tmpl
+}
+
 // ListAllTenants returns all tenants (ID and Name only), ordered by name.
 // Used for populating dropdown selects in forms and filters.
 func (r *Repository) ListAllTenants() ([]models.Tenant, error) {
@@ -408,19 +710,30 @@ func (r *Repository) CountApps() (int64, error) {
 
 // OAuth Config Operations
 
-func (r *Repository) UpsertOAuthConfig(config *models.OAuthProviderConfig) error {
-	// Check if exists
-	var existing models.OAuthProviderConfig
-	err := r.DB.Where("app_id = ? AND provider = ?", config.AppID, config.Provider).First(&existing).Error
+// UpsertOAuthConfig creates the app's config for config.Provider or replaces
+// the existing one. guard applies to the replacement only (optlock.ErrConflict
+// when the existing config changed after the edit was loaded).
+func (r *Repository) UpsertOAuthConfig(config *models.OAuthProviderConfig, guard optlock.Guard) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Check if exists
+		var existing models.OAuthProviderConfig
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("app_id = ? AND provider = ?", config.AppID, config.Provider).First(&existing).Error
 
-	if err == nil {
-		// Update
-		config.ID = existing.ID
-		return r.DB.Save(config).Error
-	}
+		if err == nil {
+			if err := guard.Check(existing.UpdatedAt); err != nil {
+				return err
+			}
+			// Update (keep the creation time and any external ID the config is managed under)
+			config.ID = existing.ID
+			config.ExternalID = existing.ExternalID
+			config.CreatedAt = existing.CreatedAt
+			return tx.Save(config).Error
+		}
 
-	// Create
-	return r.DB.Create(config).Error
+		// Create
+		return tx.Create(config).Error
+	})
 }
 
 // OAuthConfigListItem holds an OAuth config with app and tenant names for list views.
@@ -433,6 +746,7 @@ type OAuthConfigListItem struct {
 	ClientID    string
 	RedirectURL string
 	IsEnabled   bool
+	ExternalID  *string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -440,37 +754,53 @@ type OAuthConfigListItem struct {
 // ListOAuthConfigsWithDetails returns paginated OAuth configs with app and tenant names.
 // If appID is non-empty, results are filtered to that application.
 func (r *Repository) ListOAuthConfigsWithDetails(page, pageSize int, appID string) ([]OAuthConfigListItem, int64, error) {
-	var total int64
-
-	countQuery := r.DB.Model(&models.OAuthProviderConfig{})
+	q := oauthConfigListSpec.Query(page, pageSize)
 	if appID != "" {
-		countQuery = countQuery.Where("oauth_provider_configs.app_id = ?", appID)
-	}
-	if err := countQuery.Count(&total).Error; err != nil {
+		q = q.WithFilter("app_id", appID)
+	}
+	return r.ListOAuthConfigs(q)
+}
+
+// oauthConfigListSpec is the sorting and filtering of the Admin API OAuth config list.
+var oauthConfigListSpec = &pagination.ListSpec{
+	DefaultPageSize: 10,
+	Sorts: map[string]string{
+		"provider":    "oauth_provider_configs.provider",
+		"app_name":    "applications.name",
+		"tenant_name": "tenants.name",
+		"created_at":  "oauth_provider_configs.created_at",
+		"updated_at":  "oauth_provider_configs.updated_at",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "oauth_provider_configs.id",
+	Filters: map[string]pagination.Filter{
+		"app_id":     {Column: "oauth_provider_configs.app_id", Kind: pagination.FilterUUID},
+		"provider":   {Column: "oauth_provider_configs.provider", Kind: pagination.FilterExact},
+		"is_enabled": {Column: "oauth_provider_configs.is_enabled", Kind: pagination.FilterBool},
+	},
+}
+
+// ListOAuthConfigs returns a page of OAuth configs with their application and tenant names.
+func (r *Repository) ListOAuthConfigs(q pagination.ListQuery) ([]OAuthConfigListItem, int64, error) {
+	var total int64
+	if err := q.ApplyFilters(r.DB.Model(&models.OAuthProviderConfig{})).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	var items []OAuthConfigListItem
-	offset := (page - 1) * pageSize
-
 	query := r.DB.Model(&models.OAuthProviderConfig{}).
 		Select(`oauth_provider_configs.id, oauth_provider_configs.app_id,
 			oauth_provider_configs.provider, oauth_provider_configs.client_id,
 			oauth_provider_configs.redirect_url, oauth_provider_configs.is_enabled,
+			oauth_provider_configs.external_id,
 			oauth_provider_configs.created_at, oauth_provider_configs.updated_at,
 			applications.name as app_name,
 			tenants.name as tenant_name`).
 		Joins("LEFT JOIN applications ON applications.id = oauth_provider_configs.app_id").
-		Joins("LEFT JOIN tenants ON tenants.id = applications.tenant_id").
-		Order("oauth_provider_configs.created_at desc").
-		Limit(pageSize).
-		Offset(offset)
-
-	if appID != "" {
-		query = query.Where("oauth_provider_configs.app_id = ?", appID)
-	}
+		Joins("LEFT JOIN tenants ON tenants.id = applications.tenant_id")
 
-	if err := query.Scan(&items).Error; err != nil {
+	if err := q.ApplyPage(q.ApplyFilters(query)).Scan(&items).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -486,18 +816,37 @@ func (r *Repository) GetOAuthConfigByID(id string) (*models.OAuthProviderConfig,
 	return &config, nil
 }
 
-// UpdateOAuthConfigByID updates an OAuth config by primary key.
-// If clientSecret is empty, the existing secret is preserved.
-func (r *Repository) UpdateOAuthConfigByID(id string, clientID string, clientSecret string, redirectURL string, isEnabled bool) error {
+// GetOAuthConfigByProvider returns the app's OAuth config for a provider.
+func (r *Repository) GetOAuthConfigByProvider(appID uuid.UUID, provider string) (*models.OAuthProviderConfig, error) {
+	var config models.OAuthProviderConfig
+	if err := r.DB.First(&config, "app_id = ? AND provider = ?", appID, provider).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateOAuthConfigByID updates the editable fields of an OAuth config by
+// primary key from in. If in.ClientSecret is empty, the existing secret is
+// preserved. guard makes the update conditional on the version the edit was
+// based on.
+func (r *Repository) UpdateOAuthConfigByID(id string, in *models.OAuthProviderConfig, guard optlock.Guard) error {
 	updates := map[string]interface{}{
-		"client_id":    clientID,
-		"redirect_url": redirectURL,
-		"is_enabled":   isEnabled,
+		"client_id":    in.ClientID,
+		"redirect_url": in.RedirectURL,
+		"is_enabled":   in.IsEnabled,
+		// nil removes the mappings
+		"claim_mappings": in.ClaimMappings,
+		// Just-in-time provisioning
+		"provisioning_mode":     in.ProvisioningMode,
+		"allowed_email_domains": in.AllowedEmailDomains,
+		// Generic OpenID Connect providers
+		"issuer_url": in.IssuerURL,
+		"scopes":     in.Scopes,
 	}
-	if clientSecret != "" {
-		updates["client_secret"] = clientSecret
+	if in.ClientSecret != "" {
+		updates["client_secret"] = in.ClientSecret
 	}
-	return r.DB.Model(&models.OAuthProviderConfig{}).Where("id = ?", id).Updates(updates).Error
+	return optlock.Updates(r.DB, &models.OAuthProviderConfig{}, id, guard, updates)
 }
 
 // DeleteOAuthConfig deletes an OAuth config by ID.
@@ -518,6 +867,19 @@ func (r *Repository) ToggleOAuthConfigEnabled(id string) (*models.OAuthProviderC
 	return &config, nil
 }
 
+// SetOAuthConfigEnabled sets the IsEnabled flag for an OAuth config and returns the updated config.
+func (r *Repository) SetOAuthConfigEnabled(id string, enabled bool) (*models.OAuthProviderConfig, error) {
+	var config models.OAuthProviderConfig
+	if err := r.DB.First(&config, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	config.IsEnabled = enabled
+	if err := r.DB.Model(&config).Update("is_enabled", enabled).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
 // GetEnabledOAuthProviders returns the provider names (e.g. "google", "github") that
 // are configured and enabled for the given app. Used by the public /app-config endpoint.
 func (r *Repository) GetEnabledOAuthProviders(appID string) ([]string, error) {
@@ -602,6 +964,7 @@ type UserListItem struct {
 	TwoFAEnabled       bool       `json:"two_fa_enabled"`
 	HasPassword        bool       `json:"has_password"`
 	SocialAccountCount int        `json:"social_account_count"`
+	ApprovalStatus     string     `json:"approval_status"`
 	LockedAt           *time.Time `json:"locked_at"`
 	LockExpiresAt      *time.Time `json:"lock_expires_at"`
 	CreatedAt          time.Time  `json:"created_at"`
@@ -620,6 +983,7 @@ type UserDetail struct {
 	AppName             string                      `json:"app_name"`
 	TenantName          string                      `json:"tenant_name"`
 	IsActive            bool                        `json:"is_active"`
+	ApprovalStatus      string                      `json:"approval_status"`
 	EmailVerified       bool                        `json:"email_verified"`
 	TwoFAEnabled        bool                        `json:"two_fa_enabled"`
 	HasPassword         bool                        `json:"has_password"`
@@ -635,6 +999,18 @@ type UserDetail struct {
 	SocialAccounts      []models.SocialAccount      `json:"social_accounts" gorm:"-"`
 	WebAuthnCredentials []models.WebAuthnCredential `json:"webauthn_credentials" gorm:"-"`
 	TrustedDevices      []models.TrustedDevice      `json:"trusted_devices" gorm:"-"`
+	LoginThrottle       *bruteforce.ThrottleStatus  `json:"login_throttle,omitempty" gorm:"-"`
+	// Groups assigned by claim mappings (JSON array)
+	Groups datatypes.JSON `json:"groups"`
+}
+
+// GroupNames returns the user's groups.
+func (d *UserDetail) GroupNames() []string {
+	var groups []string
+	if len(d.Groups) > 0 {
+		_ = json.Unmarshal(d.Groups, &groups)
+	}
+	return groups
 }
 
 // UserStatusCounts holds active/inactive user counts for dashboard display
@@ -643,43 +1019,84 @@ type UserStatusCounts struct {
 	InactiveUsers int64 `json:"inactive_users"`
 }
 
+// userListColumns is the SELECT list shared by the user list queries. It needs
+// the joins added by joinUserListDetails.
+const userListColumns = `users.id, users.email, users.name, users.app_id,
+	applications.name as app_name,
+	COALESCE(tenants.name, '') as tenant_name,
+	users.is_active, users.approval_status, users.email_verified, users.two_fa_enabled,
+	(users.password_hash != '') as has_password,
+	sa_count.count as social_account_count,
+	users.locked_at, users.lock_expires_at,
+	users.created_at`
+
+// applyUserListFilters adds the optional appID / search filters shared by the
+// user list and count queries; service accounts are listed separately
+// (ListServiceAccounts) and always left out. The filters only touch the users table, so
+// counts need no joins; the appID filter and ordering are served by
+// idx_users_app_created_at_id and the search by the trigram indexes on email
+// and name.
+func applyUserListFilters(q *gorm.DB, appID, search string) *gorm.DB {
+	q = q.Where("users.is_service_account = ?", false)
+	if appID != "" {
+		q = q.Where("users.app_id = ?", appID)
+	}
+	if search != "" {
+		searchTerm := "%" + search + "%"
+		q = q.Where("(users.email ILIKE ? OR users.name ILIKE ?)", searchTerm, searchTerm)
+	}
+	return q
+}
+
+// userListSpec is the filtering of the Admin API user list. Its keyset
+// cursors fix the order to newest first.
+var userListSpec = &pagination.ListSpec{
+	Sorts:        map[string]string{"created_at": "users.created_at"},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "users.id",
+	Filters: map[string]pagination.Filter{
+		"app_id":          {Column: "users.app_id", Kind: pagination.FilterUUID},
+		"email":           {Column: "users.email", Kind: pagination.FilterContains},
+		"name":            {Column: "users.name", Kind: pagination.FilterContains},
+		"is_active":       {Column: "users.is_active", Kind: pagination.FilterBool},
+		"email_verified":  {Column: "users.email_verified", Kind: pagination.FilterBool},
+		"two_fa_enabled":  {Column: "users.two_fa_enabled", Kind: pagination.FilterBool},
+		"approval_status": {Column: "users.approval_status", Kind: pagination.FilterExact},
+	},
+}
+
+// appUserListQuery returns the user list query restricted to appID ("" for
+// all applications).
+func appUserListQuery(appID string) pagination.ListQuery {
+	q := userListSpec.Query(1, 0)
+	if appID != "" {
+		q = q.WithFilter("app_id", appID)
+	}
+	return q
+}
+
+// joinUserListDetails adds the joins needed by userListColumns. Social
+// accounts are counted per returned row through the social_accounts.user_id
+// index instead of aggregating the whole table.
+func joinUserListDetails(q *gorm.DB) *gorm.DB {
+	return q.Joins("LEFT JOIN applications ON applications.id = users.app_id").
+		Joins("LEFT JOIN tenants ON tenants.id = applications.tenant_id").
+		Joins("LEFT JOIN LATERAL (SELECT COUNT(*) as count FROM social_accounts WHERE social_accounts.user_id = users.id) sa_count ON true")
+}
+
 // ListUsersWithDetails returns a paginated list of users with app/tenant info and social account counts.
 // Supports optional filtering by appID and text search on email/name.
 func (r *Repository) ListUsersWithDetails(page, pageSize int, appID, search string) ([]UserListItem, int64, error) {
 	var items []UserListItem
-	var total int64
-
-	// Build base conditions for reuse in both count and data queries
-	applyFilters := func(q *gorm.DB) *gorm.DB {
-		q = q.Joins("LEFT JOIN applications ON applications.id = users.app_id").
-			Joins("LEFT JOIN tenants ON tenants.id = applications.tenant_id").
-			Joins("LEFT JOIN (SELECT user_id, COUNT(*) as count FROM social_accounts GROUP BY user_id) sa_count ON sa_count.user_id = users.id")
-		if appID != "" {
-			q = q.Where("users.app_id = ?", appID)
-		}
-		if search != "" {
-			searchTerm := "%" + search + "%"
-			q = q.Where("(users.email ILIKE ? OR users.name ILIKE ?)", searchTerm, searchTerm)
-		}
-		return q
-	}
 
-	// Count total matching records (separate query)
-	countQuery := applyFilters(r.DB.Model(&models.User{}))
-	if err := countQuery.Count(&total).Error; err != nil {
+	total, err := r.CountUsers(appUserListQuery(appID), search)
+	if err != nil {
 		return nil, 0, err
 	}
 
 	// Fetch paginated results
-	dataQuery := applyFilters(r.DB.Model(&models.User{}).
-		Select(`users.id, users.email, users.name, users.app_id,
-			applications.name as app_name,
-			COALESCE(tenants.name, '') as tenant_name,
-			users.is_active, users.email_verified, users.two_fa_enabled,
-			(users.password_hash != '') as has_password,
-			COALESCE(sa_count.count, 0) as social_account_count,
-			users.locked_at, users.lock_expires_at,
-			users.created_at`))
+	dataQuery := joinUserListDetails(applyUserListFilters(r.DB.Model(&models.User{}).Select(userListColumns), appID, search))
 
 	offset := (page - 1) * pageSize
 	if err := dataQuery.Order("users.created_at desc").Offset(offset).Limit(pageSize).Scan(&items).Error; err != nil {
@@ -689,6 +1106,67 @@ func (r *Repository) ListUsersWithDetails(page, pageSize int, appID, search stri
 	return items, total, nil
 }
 
+// ListUsersKeyset returns one page of users, newest first, using keyset pagination.
+// The users match the filters of lq and the search; a nil cursor returns the
+// first page.
+func (r *Repository) ListUsersKeyset(limit int, lq pagination.ListQuery, search string, cursor *pagination.Cursor) ([]UserListItem, pagination.Page, error) {
+	var items []UserListItem
+
+	dataQuery := joinUserListDetails(lq.ApplyFilters(applyUserListFilters(r.DB.Model(&models.User{}).Select(userListColumns), "", search)))
+	if err := pagination.Apply(dataQuery, "users.created_at", "users.id", cursor, limit).Scan(&items).Error; err != nil {
+		return nil, pagination.Page{}, err
+	}
+
+	items, page := pagination.Finish(items, cursor, limit, func(u UserListItem) (time.Time, uuid.UUID) {
+		return u.CreatedAt, u.ID
+	})
+	return items, page, nil
+}
+
+// applyUserSearch restricts a user query to the full-text matches of q, or to
+// the plain email/name filter when q has no usable terms.
+func applyUserSearch(tx *gorm.DB, q string) *gorm.DB {
+	like := "%" + q + "%"
+	if tsq := search.Query(q); tsq != "" {
+		return tx.Where("("+search.UserDocument+" @@ to_tsquery('simple', ?) OR users.email ILIKE ? OR users.name ILIKE ?)", tsq, like, like)
+	}
+	return tx.Where("(users.email ILIKE ? OR users.name ILIKE ?)", like, like)
+}
+
+// SearchUsers returns one page of the users matching a full-text query, most
+// relevant first, and their total. Users match when their email or name
+// words start with every query term, or when the query occurs in their email
+// or name as with the plain search filter. The filters of lq further
+// restrict the search, e.g. to one application.
+func (r *Repository) SearchUsers(page, pageSize int, lq pagination.ListQuery, q string) ([]UserListItem, int64, error) {
+	var items []UserListItem
+
+	var total int64
+	if err := applyUserSearch(lq.ApplyFilters(applyUserListFilters(r.DB.Model(&models.User{}), "", "")), q).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	dataQuery := joinUserListDetails(applyUserSearch(lq.ApplyFilters(applyUserListFilters(r.DB.Model(&models.User{}).Select(userListColumns), "", "")), q))
+	if tsq := search.Query(q); tsq != "" {
+		dataQuery = dataQuery.Order(clause.Expr{SQL: "ts_rank(" + search.UserDocument + ", to_tsquery('simple', ?)) DESC", Vars: []interface{}{tsq}})
+	}
+	offset := (page - 1) * pageSize
+	if err := dataQuery.Order("users.created_at desc, users.id desc").Offset(offset).Limit(pageSize).Scan(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// CountUsers returns the number of users matching the filters of lq and the
+// search.
+func (r *Repository) CountUsers(lq pagination.ListQuery, search string) (int64, error) {
+	var total int64
+	if err := lq.ApplyFilters(applyUserListFilters(r.DB.Model(&models.User{}), "", search)).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 // GetUserDetailByID returns a full user detail view with social accounts, app name, and tenant name.
 func (r *Repository) GetUserDetailByID(id string) (*UserDetail, error) {
 	var detail UserDetail
@@ -698,14 +1176,14 @@ func (r *Repository) GetUserDetailByID(id string) (*UserDetail, error) {
 			users.profile_picture, users.locale, users.app_id,
 			applications.name as app_name,
 			COALESCE(tenants.name, '') as tenant_name,
-			users.is_active, users.email_verified, users.two_fa_enabled,
+			users.is_active, users.approval_status, users.email_verified, users.two_fa_enabled,
 			(users.password_hash != '') as has_password,
 			COALESCE(users.backup_email, '') as backup_email,
 			users.backup_email_verified,
 			COALESCE(users.phone_number, '') as phone_number,
 			users.phone_verified,
 			users.locked_at, users.lock_reason, users.lock_expires_at,
-			users.created_at, users.updated_at`).
+			users.created_at, users.updated_at, users.groups`).
 		Joins("LEFT JOIN applications ON applications.id = users.app_id").
 		Joins("LEFT JOIN tenants ON tenants.id = applications.tenant_id").
 		Where("users.id = ?", id).
@@ -739,18 +1217,43 @@ func (r *Repository) GetUserDetailByID(id string) (*UserDetail, error) {
 // ToggleUserActive toggles the is_active flag for a user and returns the new value along with the user's app_id.
 func (r *Repository) ToggleUserActive(id string) (isActive bool, appID string, err error) {
 	var user models.User
-	if err := r.DB.Select("id, is_active, app_id").First(&user, "id = ?", id).Error; err != nil {
+	if err := r.DB.Select("id, is_active, approval_status, app_id").First(&user, "id = ?", id).Error; err != nil {
 		return false, "", err
 	}
 
 	newActive := !user.IsActive
-	if err := r.DB.Model(&user).Update("is_active", newActive).Error; err != nil {
+	if err := r.DB.Model(&user).Updates(activeUpdates(&user, newActive)).Error; err != nil {
 		return false, "", err
 	}
 
 	return newActive, user.AppID.String(), nil
 }
 
+// SetUserActive sets the is_active flag for a user and returns the user's app_id.
+func (r *Repository) SetUserActive(id string, active bool) (appID string, err error) {
+	var user models.User
+	if err := r.DB.Select("id, approval_status, app_id").First(&user, "id = ?", id).Error; err != nil {
+		return "", err
+	}
+
+	if err := r.DB.Model(&user).Updates(activeUpdates(&user, active)).Error; err != nil {
+		return "", err
+	}
+
+	return user.AppID.String(), nil
+}
+
+// activeUpdates returns the columns to update when an admin activates or
+// deactivates user. Activating a registration that is pending approval, or
+// was rejected, approves it.
+func activeUpdates(user *models.User, active bool) map[string]interface{} {
+	updates := map[string]interface{}{"is_active": active}
+	if active && (user.ApprovalStatus == models.ApprovalStatusPending || user.ApprovalStatus == models.ApprovalStatusRejected) {
+		updates["approval_status"] = models.ApprovalStatusApproved
+	}
+	return updates
+}
+
 // UnlockUser clears the lockout fields for a user and returns the user's email and app_id.
 func (r *Repository) UnlockUser(id string) (email string, appID string, err error) {
 	var user models.User
@@ -773,16 +1276,86 @@ func (r *Repository) UnlockUser(id string) (email string, appID string, err erro
 func (r *Repository) CountUsersByStatus() (*UserStatusCounts, error) {
 	var counts UserStatusCounts
 
-	if err := r.DB.Model(&models.User{}).Where("is_active = ?", true).Count(&counts.ActiveUsers).Error; err != nil {
+	if err := r.DB.Model(&models.User{}).Where("is_active = ? AND NOT is_service_account", true).Count(&counts.ActiveUsers).Error; err != nil {
 		return nil, err
 	}
-	if err := r.DB.Model(&models.User{}).Where("is_active = ?", false).Count(&counts.InactiveUsers).Error; err != nil {
+	if err := r.DB.Model(&models.User{}).Where("is_active = ? AND NOT is_service_account", false).Count(&counts.InactiveUsers).Error; err != nil {
 		return nil, err
 	}
 
 	return &counts, nil
 }
 
+// ============================================================
+// Sign-up Approval Operations
+// ============================================================
+
+// ListPendingUsers returns one page of the registrations awaiting approval,
+// oldest first, and their total. Filters match the user list.
+func (r *Repository) ListPendingUsers(page, pageSize int, appID, search string) ([]UserListItem, int64, error) {
+	var items []UserListItem
+
+	var total int64
+	countQuery := applyUserListFilters(r.DB.Model(&models.User{}), appID, search).
+		Where("users.approval_status = ?", models.ApprovalStatusPending)
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	dataQuery := joinUserListDetails(applyUserListFilters(r.DB.Model(&models.User{}).Select(userListColumns), appID, search)).
+		Where("users.approval_status = ?", models.ApprovalStatusPending)
+	offset := (page - 1) * pageSize
+	if err := dataQuery.Order("users.created_at asc").Offset(offset).Limit(pageSize).Scan(&items).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// ReviewPendingUsers approves or rejects the registrations among ids that are
+// still pending approval, and returns the users it changed. Approved users
+// become active; rejected users stay inactive.
+func (r *Repository) ReviewPendingUsers(ids []string, approve bool) ([]models.User, error) {
+	status := models.ApprovalStatusRejected
+	if approve {
+		status = models.ApprovalStatusApproved
+	}
+
+	var users []models.User
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the rows so concurrent reviews do not notify a user twice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id, app_id, email").
+			Where("id IN ? AND approval_status = ?", ids, models.ApprovalStatusPending).
+			Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+
+		userIDs := make([]uuid.UUID, len(users))
+		for i, u := range users {
+			userIDs[i] = u.ID
+		}
+		return tx.Model(&models.User{}).Where("id IN ?", userIDs).Updates(map[string]interface{}{
+			"approval_status": status,
+			"is_active":       approve,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CountPendingUsers returns the number of registrations awaiting approval.
+func (r *Repository) CountPendingUsers() (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.User{}).Where("approval_status = ?", models.ApprovalStatusPending).Count(&count).Error
+	return count, err
+}
+
 // ============================================================
 // Activity Log Operations (Admin GUI - read only)
 // ============================================================
@@ -799,6 +1372,8 @@ type ActivityLogListItem struct {
 	IPAddress string    `json:"ip_address"`
 	IsAnomaly bool      `json:"is_anomaly"`
 	Timestamp time.Time `json:"timestamp"`
+	// Details is only loaded by SearchActivityLogs, for match snippets
+	Details string `json:"-"`
 }
 
 // ActivityLogExportItem extends ActivityLogListItem with extra fields useful for compliance exports.
@@ -827,52 +1402,54 @@ type ActivityLogDetail struct {
 	Timestamp time.Time  `json:"timestamp"`
 }
 
+// activityLogListColumns is the SELECT list shared by the activity log list queries.
+const activityLogListColumns = `activity_logs.id, activity_logs.app_id,
+	COALESCE(applications.name, '') as app_name,
+	activity_logs.user_id,
+	COALESCE(users.email, '') as user_email,
+	activity_logs.event_type, activity_logs.severity,
+	activity_logs.ip_address, activity_logs.is_anomaly,
+	activity_logs.timestamp`
+
+// applyActivityLogFilters adds the joins and optional filters shared by the
+// activity log list, count and export queries.
+func applyActivityLogFilters(q *gorm.DB, eventType, severity, appID, search, startDate, endDate string) *gorm.DB {
+	q = q.Joins("LEFT JOIN users ON users.id = activity_logs.user_id::uuid").
+		Joins("LEFT JOIN applications ON applications.id = activity_logs.app_id::uuid")
+	if eventType != "" {
+		q = q.Where("activity_logs.event_type = ?", eventType)
+	}
+	if severity != "" {
+		q = q.Where("activity_logs.severity = ?", severity)
+	}
+	if appID != "" {
+		q = q.Where("activity_logs.app_id = ?", appID)
+	}
+	if search != "" {
+		q = q.Where("users.email ILIKE ?", "%"+search+"%")
+	}
+	if startDate != "" {
+		q = q.Where("activity_logs.timestamp >= ?", startDate)
+	}
+	if endDate != "" {
+		q = q.Where("activity_logs.timestamp <= ?", endDate+" 23:59:59")
+	}
+	return q
+}
+
 // ListActivityLogs returns a paginated list of activity logs with user email and app name.
 // Supports optional filtering by eventType, severity, appID, date range, and text search on user email.
 func (r *Repository) ListActivityLogs(page, pageSize int, eventType, severity, appID, search, startDate, endDate string) ([]ActivityLogListItem, int64, error) {
 	var items []ActivityLogListItem
-	var total int64
-
-	// Build base conditions for reuse in both count and data queries
-	applyFilters := func(q *gorm.DB) *gorm.DB {
-		q = q.Joins("LEFT JOIN users ON users.id = activity_logs.user_id::uuid").
-			Joins("LEFT JOIN applications ON applications.id = activity_logs.app_id::uuid")
-		if eventType != "" {
-			q = q.Where("activity_logs.event_type = ?", eventType)
-		}
-		if severity != "" {
-			q = q.Where("activity_logs.severity = ?", severity)
-		}
-		if appID != "" {
-			q = q.Where("activity_logs.app_id = ?", appID)
-		}
-		if search != "" {
-			q = q.Where("users.email ILIKE ?", "%"+search+"%")
-		}
-		if startDate != "" {
-			q = q.Where("activity_logs.timestamp >= ?", startDate)
-		}
-		if endDate != "" {
-			q = q.Where("activity_logs.timestamp <= ?", endDate+" 23:59:59")
-		}
-		return q
-	}
 
-	// Count total matching records (separate query)
-	countQuery := applyFilters(r.DB.Model(&models.ActivityLog{}))
-	if err := countQuery.Count(&total).Error; err != nil {
+	total, err := r.CountActivityLogs(eventType, severity, appID, search, startDate, endDate)
+	if err != nil {
 		return nil, 0, err
 	}
 
 	// Fetch paginated results
-	dataQuery := applyFilters(r.DB.Model(&models.ActivityLog{}).
-		Select(`activity_logs.id, activity_logs.app_id,
-			COALESCE(applications.name, '') as app_name,
-			activity_logs.user_id,
-			COALESCE(users.email, '') as user_email,
-			activity_logs.event_type, activity_logs.severity,
-			activity_logs.ip_address, activity_logs.is_anomaly,
-			activity_logs.timestamp`))
+	dataQuery := applyActivityLogFilters(r.DB.Model(&models.ActivityLog{}).Select(activityLogListColumns),
+		eventType, severity, appID, search, startDate, endDate)
 
 	offset := (page - 1) * pageSize
 	if err := dataQuery.Order("activity_logs.timestamp desc").Offset(offset).Limit(pageSize).Scan(&items).Error; err != nil {
@@ -882,6 +1459,71 @@ func (r *Repository) ListActivityLogs(page, pageSize int, eventType, severity, a
 	return items, total, nil
 }
 
+// ListActivityLogsKeyset returns one page of activity logs, newest first, using keyset pagination.
+// Filters match ListActivityLogs; a nil cursor returns the first page.
+func (r *Repository) ListActivityLogsKeyset(limit int, eventType, severity, appID, search, startDate, endDate string, cursor *pagination.Cursor) ([]ActivityLogListItem, pagination.Page, error) {
+	var items []ActivityLogListItem
+
+	dataQuery := applyActivityLogFilters(r.DB.Model(&models.ActivityLog{}).Select(activityLogListColumns),
+		eventType, severity, appID, search, startDate, endDate)
+	if err := pagination.Apply(dataQuery, "activity_logs.timestamp", "activity_logs.id", cursor, limit).Scan(&items).Error; err != nil {
+		return nil, pagination.Page{}, err
+	}
+
+	items, page := pagination.Finish(items, cursor, limit, func(l ActivityLogListItem) (time.Time, uuid.UUID) {
+		return l.Timestamp, l.ID
+	})
+	return items, page, nil
+}
+
+// applyActivityLogSearch restricts an activity log query, joined with users by
+// applyActivityLogFilters, to the full-text matches of q, or to the plain user
+// email filter when q has no usable terms.
+func applyActivityLogSearch(tx *gorm.DB, q string) *gorm.DB {
+	like := "%" + q + "%"
+	if tsq := search.Query(q); tsq != "" {
+		return tx.Where("("+search.ActivityLogDocument+" @@ to_tsquery('simple', ?) OR users.email ILIKE ?)", tsq, like)
+	}
+	return tx.Where("users.email ILIKE ?", like)
+}
+
+// SearchActivityLogs returns one page of the activity logs matching a
+// full-text query and the other list filters, most relevant first, and their
+// total. Logs match when their event type, IP address, user agent or detail
+// values start with every query term, or when the query occurs in the user's
+// email as with the plain search filter. Details are included for snippets.
+func (r *Repository) SearchActivityLogs(page, pageSize int, eventType, severity, appID, q, startDate, endDate string) ([]ActivityLogListItem, int64, error) {
+	var items []ActivityLogListItem
+
+	var total int64
+	countQuery := applyActivityLogFilters(r.DB.Model(&models.ActivityLog{}), eventType, severity, appID, "", startDate, endDate)
+	if err := applyActivityLogSearch(countQuery, q).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	dataQuery := applyActivityLogFilters(r.DB.Model(&models.ActivityLog{}).Select(activityLogListColumns+", COALESCE(activity_logs.details::text, '') as details"),
+		eventType, severity, appID, "", startDate, endDate)
+	dataQuery = applyActivityLogSearch(dataQuery, q)
+	if tsq := search.Query(q); tsq != "" {
+		dataQuery = dataQuery.Order(clause.Expr{SQL: "ts_rank(" + search.ActivityLogDocument + ", to_tsquery('simple', ?)) DESC", Vars: []interface{}{tsq}})
+	}
+	offset := (page - 1) * pageSize
+	if err := dataQuery.Order("activity_logs.timestamp desc, activity_logs.id desc").Offset(offset).Limit(pageSize).Scan(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// CountActivityLogs returns the number of activity logs matching the list filters.
+func (r *Repository) CountActivityLogs(eventType, severity, appID, search, startDate, endDate string) (int64, error) {
+	var total int64
+	q := applyActivityLogFilters(r.DB.Model(&models.ActivityLog{}), eventType, severity, appID, search, startDate, endDate)
+	if err := q.Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 // GetActivityLogDetail returns a full activity log detail view with user email and app name.
 func (r *Repository) GetActivityLogDetail(id string) (*ActivityLogDetail, error) {
 	var detail ActivityLogDetail
@@ -945,32 +1587,8 @@ func (r *Repository) ListDistinctSeverities() ([]string, error) {
 func (r *Repository) ExportActivityLogs(eventType, severity, appID, search, startDate, endDate string) ([]ActivityLogExportItem, bool, error) {
 	var items []ActivityLogExportItem
 
-	applyFilters := func(q *gorm.DB) *gorm.DB {
-		q = q.Joins("LEFT JOIN users ON users.id = activity_logs.user_id::uuid").
-			Joins("LEFT JOIN applications ON applications.id = activity_logs.app_id::uuid")
-		if eventType != "" {
-			q = q.Where("activity_logs.event_type = ?", eventType)
-		}
-		if severity != "" {
-			q = q.Where("activity_logs.severity = ?", severity)
-		}
-		if appID != "" {
-			q = q.Where("activity_logs.app_id = ?", appID)
-		}
-		if search != "" {
-			q = q.Where("users.email ILIKE ?", "%"+search+"%")
-		}
-		if startDate != "" {
-			q = q.Where("activity_logs.timestamp >= ?", startDate)
-		}
-		if endDate != "" {
-			q = q.Where("activity_logs.timestamp <= ?", endDate+" 23:59:59")
-		}
-		return q
-	}
-
 	limit := ExportActivityLogsMaxRows + 1
-	dataQuery := applyFilters(r.DB.Model(&models.ActivityLog{}).
+	dataQuery := applyActivityLogFilters(r.DB.Model(&models.ActivityLog{}).
 		Select(`activity_logs.id, activity_logs.app_id,
 			COALESCE(applications.name, '') as app_name,
 			activity_logs.user_id,
@@ -978,7 +1596,8 @@ func (r *Repository) ExportActivityLogs(eventType, severity, appID, search, star
 			activity_logs.event_type, activity_logs.severity,
 			activity_logs.ip_address, activity_logs.user_agent,
 			activity_logs.is_anomaly,
-			activity_logs.timestamp`))
+			activity_logs.timestamp`),
+		eventType, severity, appID, search, startDate, endDate)
 
 	if err := dataQuery.Order("activity_logs.timestamp desc").Limit(limit).Scan(&items).Error; err != nil {
 		return nil, false, err
@@ -1324,7 +1943,7 @@ func (r *Repository) ExportUsers(appID, search string) ([]UserExportItem, bool,
 	var items []UserExportItem
 
 	applyFilters := func(q *gorm.DB) *gorm.DB {
-		q = q.Joins("LEFT JOIN (SELECT user_id, STRING_AGG(provider, ',') AS providers FROM social_accounts GROUP BY user_id) sa ON sa.user_id = users.id")
+		q = q.Joins("LEFT JOIN LATERAL (SELECT STRING_AGG(provider, ',') AS providers FROM social_accounts WHERE social_accounts.user_id = users.id) sa ON true")
 		if appID != "" {
 			q = q.Where("users.app_id = ?", appID)
 		}
@@ -1361,6 +1980,18 @@ func (r *Repository) ExportUsers(appID, search string) ([]UserExportItem, bool,
 // reported in the result. The caller is responsible for validating appID before
 // calling this method.
 func (r *Repository) ImportUsers(appID string, rows []dto.UserImportRow) (dto.UserImportResult, error) {
+	return r.ImportUsersWithContext(context.Background(), appID, rows, UserImportHooks{})
+}
+
+// UserImportHooks are optional callbacks invoked while ImportUsersWithContext runs.
+type UserImportHooks struct {
+	Progress func(done, total int)   // After each processed row
+	Created  func(user *models.User) // After each user is created
+}
+
+// ImportUsersWithContext is ImportUsers with progress callbacks. It stops
+// between rows when ctx is cancelled, returning the partial result and ctx.Err().
+func (r *Repository) ImportUsersWithContext(ctx context.Context, appID string, rows []dto.UserImportRow, hooks UserImportHooks) (dto.UserImportResult, error) {
 	result := dto.UserImportResult{Total: len(rows)}
 
 	appUUID, err := uuid.Parse(appID)
@@ -1369,6 +2000,12 @@ func (r *Repository) ImportUsers(appID string, rows []dto.UserImportRow) (dto.Us
 	}
 
 	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if hooks.Progress != nil && i > 0 {
+			hooks.Progress(i, len(rows))
+		}
 		rowNum := i + 1
 		normalizedEmail := strings.ToLower(strings.TrimSpace(row.Email))
 
@@ -1395,6 +2032,20 @@ func (r *Repository) ImportUsers(appID string, rows []dto.UserImportRow) (dto.Us
 			continue
 		}
 
+		// Enforce the app's user quota; remaining rows are reported as errors
+		if err := quota.CheckAppUsers(r.DB, appUUID, 1); err != nil {
+			msg := "database error checking user quota"
+			if errors.Is(err, quota.ErrExceeded) {
+				msg = err.Error()
+			}
+			result.Errors = append(result.Errors, dto.UserImportRowError{
+				Row:   rowNum,
+				Email: row.Email,
+				Error: msg,
+			})
+			continue
+		}
+
 		user := models.User{
 			ID:            uuid.New(),
 			AppID:         appUUID,
@@ -1416,6 +2067,9 @@ func (r *Repository) ImportUsers(appID string, rows []dto.UserImportRow) (dto.Us
 			continue
 		}
 		result.Imported++
+		if hooks.Created != nil {
+			hooks.Created(&user)
+		}
 	}
 
 	return result, nil
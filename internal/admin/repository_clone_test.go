@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestCloneCopiesDropExternalIDs(t *testing.T) {
+	externalID := func(s string) *string { return &s }
+
+	source := &models.Application{ID: uuid.New(), Name: "Shop", ExternalID: externalID("shop")}
+	app := copyAppSettings(source)
+	if app.ExternalID != nil {
+		t.Errorf("copied application keeps external ID %q", *app.ExternalID)
+	}
+	if app.ID == source.ID || app.Name != source.Name {
+		t.Errorf("copy = %+v, want a new ID and the source's settings", app)
+	}
+	if source.ExternalID == nil || *source.ExternalID != "shop" {
+		t.Error("copying changed the source's external ID")
+	}
+
+	cfg := cloneOAuthConfig(models.OAuthProviderConfig{
+		ID: uuid.New(), AppID: source.ID, Provider: "google", ClientSecret: "secret", IsEnabled: true, ExternalID: externalID("shop-google"),
+	}, app.ID)
+	if cfg.ExternalID != nil || cfg.ClientSecret != "" || cfg.IsEnabled || cfg.AppID != app.ID {
+		t.Errorf("cloned OAuth config = %+v, want no external ID or secret, disabled, under the new app", cfg)
+	}
+
+	smtpID, clonedSMTPID := uuid.New(), uuid.New()
+	tmpl := cloneEmailTemplate(models.EmailTemplate{
+		ID: uuid.New(), AppID: &source.ID, ServerConfigID: &smtpID, ExternalID: externalID("shop-welcome"),
+	}, app.ID, map[uuid.UUID]uuid.UUID{smtpID: clonedSMTPID})
+	if tmpl.ExternalID != nil || tmpl.AppID == nil || *tmpl.AppID != app.ID {
+		t.Errorf("cloned email template = %+v, want no external ID, under the new app", tmpl)
+	}
+	if tmpl.ServerConfigID == nil || *tmpl.ServerConfigID != clonedSMTPID {
+		t.Errorf("cloned email template SMTP config = %v, want %v", tmpl.ServerConfigID, clonedSMTPID)
+	}
+}
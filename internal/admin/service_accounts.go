@@ -0,0 +1,503 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// serviceAccountEmailDomain is the domain of the placeholder addresses of
+// service accounts. ".invalid" never resolves (RFC 2606), so no email reaches
+// a service account and no social login matches one.
+const serviceAccountEmailDomain = "service-accounts.invalid"
+
+// ErrInvalidTokenScope is returned for an empty scope or one containing whitespace.
+var ErrInvalidTokenScope = errors.New("scopes must be non-empty and must not contain whitespace")
+
+// MaxServiceAccountTokenTTL returns the longest lifetime of a service account
+// token (SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS, default 365 days).
+func MaxServiceAccountTokenTTL() time.Duration {
+	if d := viper.GetInt("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS"); d > 0 {
+		return time.Duration(d) * 24 * time.Hour
+	}
+	return 365 * 24 * time.Hour
+}
+
+// normalizeTokenScopes trims the scopes and drops duplicates, keeping their order.
+func normalizeTokenScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if s == "" || strings.ContainsAny(s, " \t\r\n") {
+			return nil, ErrInvalidTokenScope
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// ServiceAccountItem is a service account with its number of usable tokens.
+type ServiceAccountItem struct {
+	models.User
+	ActiveTokens int64
+}
+
+// CreateServiceAccount creates a service account of the application. It has
+// no password and a placeholder email address.
+func (r *Repository) CreateServiceAccount(appID uuid.UUID, name string) (*models.User, error) {
+	id := uuid.New()
+	account := &models.User{
+		ID:               id,
+		AppID:            appID,
+		Email:            id.String() + "@" + serviceAccountEmailDomain,
+		Name:             name,
+		EmailVerified:    true,
+		IsActive:         true,
+		IsServiceAccount: true,
+	}
+	if err := r.DB.Create(account).Error; err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// serviceAccountListSpec is the sorting and filtering of an application's
+// service accounts.
+var serviceAccountListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"created_at": "users.created_at",
+		"name":       "users.name",
+		"email":      "users.email",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderAsc,
+	TieBreaker:   "users.id",
+	Filters: map[string]pagination.Filter{
+		"app_id":    {Column: "users.app_id", Kind: pagination.FilterUUID},
+		"name":      {Column: "users.name", Kind: pagination.FilterContains},
+		"is_active": {Column: "users.is_active", Kind: pagination.FilterBool},
+	},
+}
+
+// ListServiceAccounts returns a page of service accounts sorted and filtered by q.
+func (r *Repository) ListServiceAccounts(q pagination.ListQuery) ([]ServiceAccountItem, int64, error) {
+	query := q.ApplyFilters(r.DB.Model(&models.User{}).Where("users.is_service_account"))
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []ServiceAccountItem
+	err := q.ApplyPage(query.
+		Select(`users.*, (SELECT COUNT(*) FROM service_account_tokens t
+			WHERE t.user_id = users.id AND t.revoked_at IS NULL AND t.expires_at > NOW()) AS active_tokens`)).
+		Scan(&items).Error
+	return items, total, err
+}
+
+// GetServiceAccount returns a service account of the application.
+func (r *Repository) GetServiceAccount(appID, id uuid.UUID) (*models.User, error) {
+	var account models.User
+	if err := r.DB.Where("id = ? AND app_id = ? AND is_service_account", id, appID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// DeleteServiceAccount revokes every token of the service account and deletes
+// it with its role assignments. The token records stay until they expire so
+// the revocations survive a Redis flush (RestoreServiceAccountTokenRevocations).
+func (r *Repository) DeleteServiceAccount(account *models.User) error {
+	var tokens []models.ServiceAccountToken
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", account.ID).Find(&tokens).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ServiceAccountToken{}).Where("user_id = ? AND revoked_at IS NULL", account.ID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", account.ID).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", account.ID).Delete(&models.User{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		if err := revokeServiceAccountTokenInRedis(&t); err != nil {
+			return fmt.Errorf("failed to revoke token %s: %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// CreateServiceAccountToken records a token issued to a service account.
+func (r *Repository) CreateServiceAccountToken(token *models.ServiceAccountToken) error {
+	return r.DB.Create(token).Error
+}
+
+// ListServiceAccountTokens returns the tokens of a service account, newest first.
+func (r *Repository) ListServiceAccountTokens(accountID uuid.UUID) ([]models.ServiceAccountToken, error) {
+	var tokens []models.ServiceAccountToken
+	err := r.DB.Where("user_id = ?", accountID).Order("created_at desc").Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeServiceAccountToken revokes a token of a service account. Revoking a
+// revoked token is a no-op.
+func (r *Repository) RevokeServiceAccountToken(accountID, tokenID uuid.UUID) (*models.ServiceAccountToken, error) {
+	var token models.ServiceAccountToken
+	if err := r.DB.Where("id = ? AND user_id = ?", tokenID, accountID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	if token.RevokedAt != nil {
+		return &token, nil
+	}
+	now := time.Now()
+	if err := r.DB.Model(&token).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	token.RevokedAt = &now
+	if err := revokeServiceAccountTokenInRedis(&token); err != nil {
+		return nil, fmt.Errorf("failed to store revocation: %w", err)
+	}
+	return &token, nil
+}
+
+// RestoreServiceAccountTokenRevocations copies the revocations of unexpired
+// service account tokens to Redis, where the auth middleware reads them, so
+// that a Redis flush does not revive revoked tokens. Called at startup.
+func (r *Repository) RestoreServiceAccountTokenRevocations() error {
+	var tokens []models.ServiceAccountToken
+	if err := r.DB.Where("revoked_at IS NOT NULL AND expires_at > NOW()").Find(&tokens).Error; err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		if err := revokeServiceAccountTokenInRedis(&t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeServiceAccountTokenInRedis stores the revocation of an unexpired token.
+func revokeServiceAccountTokenInRedis(t *models.ServiceAccountToken) error {
+	ttl := time.Until(t.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return redis.RevokeServiceAccountToken(t.AppID.String(), t.ID.String(), ttl)
+}
+
+func toServiceAccountResponse(account *models.User, activeTokens int64) dto.ServiceAccountResponse {
+	return dto.ServiceAccountResponse{
+		ID:           account.ID,
+		AppID:        account.AppID,
+		Name:         account.Name,
+		Email:        account.Email,
+		IsActive:     account.IsActive,
+		ActiveTokens: activeTokens,
+		CreatedAt:    account.CreatedAt,
+	}
+}
+
+func toServiceAccountTokenResponse(t *models.ServiceAccountToken) dto.ServiceAccountTokenResponse {
+	return dto.ServiceAccountTokenResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Scopes:    strings.Fields(t.Scopes),
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// ListServiceAccounts lists the service accounts of an application
+// @Summary List service accounts
+// @Description Non-human users of the application, oldest first by default. Service accounts are not part of the user list.
+// @Tags Users
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, name, email (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(asc)
+// @Param filter[name] query string false "Filter by name (case-insensitive substring)"
+// @Param filter[is_active] query bool false "Filter by active state"
+// @Success 200 {object} dto.ServiceAccountListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/service-accounts [get]
+func (h *Handler) ListServiceAccounts(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), serviceAccountListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	q = q.WithFilter("app_id", appID.String())
+	items, total, err := h.Repo.ListServiceAccounts(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list service accounts"})
+		return
+	}
+	resp := dto.ServiceAccountListResponse{
+		ServiceAccounts: make([]dto.ServiceAccountResponse, len(items)),
+		Total:           total,
+		Page:            q.Page,
+		PageSize:        q.PageSize,
+		TotalPages:      q.TotalPages(total),
+		Sort:            q.Sort,
+		Order:           q.Order,
+	}
+	for i := range items {
+		resp.ServiceAccounts[i] = toServiceAccountResponse(&items[i].User, items[i].ActiveTokens)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateServiceAccount creates a service account
+// @Summary Create a service account
+// @Description Creates a non-human user of the application. It cannot sign in interactively; issue tokens for it instead.
+// @Description Service accounts can be assigned roles like other users and are excluded from active-user (MAU) and billing counts.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body dto.CreateServiceAccountRequest true "Service account"
+// @Success 201 {object} dto.ServiceAccountResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/service-accounts [post]
+func (h *Handler) CreateServiceAccount(c *gin.Context) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return
+	}
+	var req dto.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, err := h.Repo.GetAppByID(appID.String()); err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Application not found"})
+		return
+	}
+	account, err := h.Repo.CreateServiceAccount(appID, strings.TrimSpace(req.Name))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create service account"})
+		return
+	}
+	c.JSON(http.StatusCreated, toServiceAccountResponse(account, 0))
+}
+
+// DeleteServiceAccount deletes a service account
+// @Summary Delete a service account
+// @Description Revokes every token of the service account and deletes it with its role assignments.
+// @Tags Users
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param account_id path string true "Service account ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/service-accounts/{account_id} [delete]
+func (h *Handler) DeleteServiceAccount(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+	if err := h.Repo.DeleteServiceAccount(account); err != nil {
+		log.Printf("Failed to delete service account %s: %v\n", account.ID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete service account"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Service account deleted"})
+}
+
+// ListServiceAccountTokens lists the tokens of a service account
+// @Summary List service account tokens
+// @Description Tokens issued to the service account, newest first, including revoked and expired ones. Token values are not shown again.
+// @Tags Users
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param account_id path string true "Service account ID"
+// @Success 200 {object} dto.ServiceAccountTokenListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/service-accounts/{account_id}/tokens [get]
+func (h *Handler) ListServiceAccountTokens(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+	tokens, err := h.Repo.ListServiceAccountTokens(account.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list tokens"})
+		return
+	}
+	resp := dto.ServiceAccountTokenListResponse{Tokens: make([]dto.ServiceAccountTokenResponse, len(tokens))}
+	for i := range tokens {
+		resp.Tokens[i] = toServiceAccountTokenResponse(&tokens[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateServiceAccountToken issues a token to a service account
+// @Summary Issue a service account token
+// @Description Issues a long-lived access token for the service account with the scopes in its "scope" claim. It expires after
+// @Description expires_in_days, at most SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS (default 365), cannot be refreshed, and is returned only once.
+// @Description The token is refused on user session routes; besides /auth/validate it reaches only routes whose scope it lists (log:read for /activity-logs).
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param account_id path string true "Service account ID"
+// @Param request body dto.CreateServiceAccountTokenRequest true "Token"
+// @Success 201 {object} dto.ServiceAccountTokenCreatedResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/service-accounts/{account_id}/tokens [post]
+func (h *Handler) CreateServiceAccountToken(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+	var req dto.CreateServiceAccountTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	scopes, err := normalizeTokenScopes(req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !account.IsActive {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Service account is deactivated"})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	if limit := MaxServiceAccountTokenTTL(); ttl <= 0 || ttl > limit {
+		ttl = limit
+	}
+	token := &models.ServiceAccountToken{
+		ID:        uuid.New(),
+		AppID:     account.AppID,
+		UserID:    account.ID,
+		Name:      strings.TrimSpace(req.Name),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second),
+	}
+	signed, err := jwt.GenerateServiceAccountToken(token.AppID.String(), token.UserID.String(), token.ID.String(), token.Scopes, token.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to generate token"})
+		return
+	}
+	if err := h.Repo.CreateServiceAccountToken(token); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save token"})
+		return
+	}
+	c.JSON(http.StatusCreated, dto.ServiceAccountTokenCreatedResponse{
+		ServiceAccountTokenResponse: toServiceAccountTokenResponse(token),
+		AccessToken:                 signed,
+		TokenType:                   "Bearer",
+	})
+}
+
+// RevokeServiceAccountToken revokes a token of a service account
+// @Summary Revoke a service account token
+// @Description The token is rejected from now on. Revoking a revoked token is a no-op.
+// @Tags Users
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param account_id path string true "Service account ID"
+// @Param token_id path string true "Token ID"
+// @Success 200 {object} dto.ServiceAccountTokenResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/apps/{id}/service-accounts/{account_id}/tokens/{token_id} [delete]
+func (h *Handler) RevokeServiceAccountToken(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+	tokenID, err := uuid.Parse(c.Param("token_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid token ID"})
+		return
+	}
+	token, err := h.Repo.RevokeServiceAccountToken(account.ID, tokenID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Token not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to revoke service account token %s: %v\n", tokenID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to revoke token"})
+		return
+	}
+	c.JSON(http.StatusOK, toServiceAccountTokenResponse(token))
+}
+
+// loadServiceAccount loads the service account named by the :id and
+// :account_id parameters, writing the error response when it fails.
+func (h *Handler) loadServiceAccount(c *gin.Context) (*models.User, bool) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid App ID"})
+		return nil, false
+	}
+	accountID, err := uuid.Parse(c.Param("account_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service account ID"})
+		return nil, false
+	}
+	account, err := h.Repo.GetServiceAccount(appID, accountID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Service account not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load service account"})
+		return nil, false
+	}
+	return account, true
+}
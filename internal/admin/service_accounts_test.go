@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestNormalizeTokenScopes(t *testing.T) {
+	got, err := normalizeTokenScopes([]string{" invoices:read", "invoices:write", "invoices:read "})
+	if err != nil {
+		t.Fatalf("normalizeTokenScopes: %v", err)
+	}
+	if want := []string{"invoices:read", "invoices:write"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTokenScopes() = %v, want %v", got, want)
+	}
+	if got, err := normalizeTokenScopes(nil); err != nil || len(got) != 0 {
+		t.Errorf("normalizeTokenScopes(nil) = %v, %v, want no scopes", got, err)
+	}
+	for _, scopes := range [][]string{{""}, {"  "}, {"invoices:read invoices:write"}, {"a\tb"}} {
+		if _, err := normalizeTokenScopes(scopes); !errors.Is(err, ErrInvalidTokenScope) {
+			t.Errorf("normalizeTokenScopes(%q) error = %v, want ErrInvalidTokenScope", scopes, err)
+		}
+	}
+}
+
+func TestMaxServiceAccountTokenTTL(t *testing.T) {
+	t.Cleanup(func() { viper.Set("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS", nil) })
+
+	viper.Set("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS", 0)
+	if got := MaxServiceAccountTokenTTL(); got != 365*24*time.Hour {
+		t.Errorf("MaxServiceAccountTokenTTL() unset = %v, want 365 days", got)
+	}
+	viper.Set("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS", 30)
+	if got := MaxServiceAccountTokenTTL(); got != 30*24*time.Hour {
+		t.Errorf("MaxServiceAccountTokenTTL() = %v, want 30 days", got)
+	}
+}
@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+)
+
+// Lifetimes above which the security settings warn.
+const (
+	warnAccessTokenMinutes = 60
+	warnRefreshTokenHours  = 90 * 24
+)
+
+// jwtSettingValidator returns the Validate function of a token setting.
+func jwtSettingValidator(key string) func(string) error {
+	return func(value string) error {
+		return jwt.ValidateSetting(key, value)
+	}
+}
+
+func warnAccessTokenTTL(value string) []string {
+	if minutes, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && minutes > warnAccessTokenMinutes {
+		return []string{fmt.Sprintf("Access tokens cannot be revoked one by one before they expire. Over %d minutes, a leaked token stays usable for a long time; prefer short access tokens renewed with refresh tokens.", warnAccessTokenMinutes)}
+	}
+	return nil
+}
+
+func warnRefreshTokenTTL(value string) []string {
+	if hours, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && hours > warnRefreshTokenHours {
+		return []string{fmt.Sprintf("Refresh tokens over %d days keep lost or shared devices signed in for a long time.", warnRefreshTokenHours/24)}
+	}
+	return nil
+}
+
+func accessTokenTTLImpact(current, value string) []string {
+	impact := []string{fmt.Sprintf("New access tokens are valid for %s minutes (now %s), unless their application sets its own lifetime.", value, current)}
+	if lowered(current, value) {
+		impact = append(impact, "Tokens already issued keep their longer expiry. Revoking all tokens of a user only covers the new lifetime, so until the old tokens expire they can be used again once such a revocation lapses.")
+	} else {
+		impact = append(impact, "Tokens already issued keep their expiry.")
+	}
+	return impact
+}
+
+func refreshTokenTTLImpact(current, value string) []string {
+	return []string{
+		fmt.Sprintf("New refresh tokens, issued at sign-in and on every refresh, are valid for %s hours (now %s), unless their application sets its own lifetime.", value, current),
+		"Refresh tokens already issued keep their expiry until they are next used.",
+	}
+}
+
+func issuerImpact(current, value string) []string {
+	switch {
+	case current == value:
+		return []string{"No change."}
+	case value == "":
+		return []string{"New tokens carry no issuer and the issuer of tokens is no longer checked."}
+	case current == "":
+		return []string{
+			fmt.Sprintf("New tokens carry iss=%q. Tokens already issued have no issuer and stay valid until they expire.", value),
+			"Services verifying the iss claim must be configured with the new issuer before this is applied.",
+		}
+	default:
+		return []string{
+			fmt.Sprintf("New tokens carry iss=%q. Tokens already issued with iss=%q are rejected: every user is signed out within a minute.", value, current),
+			"Services verifying the iss claim must be configured with the new issuer before this is applied.",
+		}
+	}
+}
+
+func audienceImpact(current, value string) []string {
+	if value == "" {
+		return []string{"New tokens carry no audience of their own; services requiring one reject them."}
+	}
+	impact := []string{fmt.Sprintf("New tokens without an audience of their own carry aud=%s. The Auth API does not check it.", strings.Join(splitTrimmedList(value), ", "))}
+	if removed := listDiff(splitTrimmedList(current), splitTrimmedList(value)); len(removed) > 0 {
+		impact = append(impact, fmt.Sprintf("Services only accepting %s reject new tokens.", strings.Join(removed, ", ")))
+	}
+	return impact
+}
+
+func forceSecureCookiesImpact(current, value string) []string {
+	if force, _ := strconv.ParseBool(value); force {
+		return []string{"The admin session and trusted-device cookies are only sent over HTTPS. Signing in to the admin GUI over plain HTTP (e.g. http://localhost) stops working."}
+	}
+	return []string{"Cookies get the Secure flag only when a request arrives over HTTPS (or X-Forwarded-Proto is https), and trusted-device cookies always in release mode or with SameSite=None."}
+}
+
+// validateSameSite checks a SameSite policy.
+func validateSameSite(value string) error {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "none", "lax", "strict":
+		return nil
+	}
+	return fmt.Errorf("must be none, lax or strict")
+}
+
+func sameSiteImpact(current, value string) []string {
+	impact := []string{"Applies to trusted-device cookies set from now on; existing cookies keep their policy until the device is trusted again."}
+	if strings.EqualFold(strings.TrimSpace(value), "strict") || strings.EqualFold(strings.TrimSpace(value), "lax") {
+		impact = append(impact, "Frontends on another site than the Auth API no longer send the cookie, so their users are asked for 2FA on every sign-in.")
+	}
+	return impact
+}
+
+// validateCORSOrigins checks a comma-separated list of CORS origins. Every
+// origin is a scheme, host and optional port; the list must not be empty,
+// because the CORS middleware refuses to start without origins.
+func validateCORSOrigins(value string) error {
+	origins := splitTrimmedList(value)
+	if len(origins) == 0 {
+		return fmt.Errorf("at least one origin is required")
+	}
+	for _, origin := range origins {
+		if origin == "*" || strings.Contains(origin, "*") {
+			return fmt.Errorf("origin %q: wildcards are not supported, list every origin", origin)
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("origin %q must start with http:// or https:// and include a host", origin)
+		}
+		if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return fmt.Errorf("origin %q must not have a path, query or credentials", origin)
+		}
+		if u.Path == "/" {
+			return fmt.Errorf("origin %q must not end with a slash", origin)
+		}
+	}
+	return nil
+}
+
+func warnCORSOrigins(value string) []string {
+	var warnings []string
+	for _, origin := range splitTrimmedList(value) {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme != "http" {
+			continue
+		}
+		if host := u.Hostname(); host != "localhost" && host != "127.0.0.1" && host != "::1" {
+			warnings = append(warnings, fmt.Sprintf("%s is not HTTPS: requests from it, and any cookies and tokens they carry, can be read on the network.", origin))
+		}
+	}
+	return warnings
+}
+
+func corsOriginsImpact(current, value string) []string {
+	currentOrigins, newOrigins := splitTrimmedList(current), splitTrimmedList(value)
+	var impact []string
+	if added := listDiff(newOrigins, currentOrigins); len(added) > 0 {
+		impact = append(impact, "Browsers on "+strings.Join(added, ", ")+" can call the API.")
+	}
+	if removed := listDiff(currentOrigins, newOrigins); len(removed) > 0 {
+		impact = append(impact, "Browsers on "+strings.Join(removed, ", ")+" can no longer call the API.")
+	}
+	if len(impact) == 0 {
+		impact = append(impact, "No change to the allowed origins.")
+	}
+	return impact
+}
+
+// lowered reports whether the integer value is lower than current.
+func lowered(current, value string) bool {
+	c, err1 := strconv.Atoi(strings.TrimSpace(current))
+	v, err2 := strconv.Atoi(strings.TrimSpace(value))
+	return err1 == nil && err2 == nil && v < c
+}
+
+// splitTrimmedList splits a comma-separated list, dropping empty entries.
+func splitTrimmedList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// listDiff returns the items of a that are not in b.
+func listDiff(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, item := range b {
+		in[item] = true
+	}
+	var diff []string
+	for _, item := range a {
+		if !in[item] {
+			diff = append(diff, item)
+		}
+	}
+	return diff
+}
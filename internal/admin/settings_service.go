@@ -3,6 +3,7 @@ package admin
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"runtime"
 	"strconv"
@@ -10,7 +11,11 @@ import (
 	"time"
 
 	"github.com/gjovanovicst/auth_api/internal/database"
+	"github.com/gjovanovicst/auth_api/internal/passhash"
 	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/secretbox"
+	"github.com/gjovanovicst/auth_api/internal/storage"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
 	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/spf13/viper"
 )
@@ -54,9 +59,19 @@ type SettingDefinition struct {
 	DefaultValue    string        // Default as string
 	Label           string        // Human-readable label
 	Description     string        // Help text
-	Sensitive       bool          // If true, value is masked in display
+	Sensitive       bool          // If true, value is masked in display and encrypted in the DB
 	RequiresRestart bool          // If true, changes need app restart
 	UIHint          SettingUIHint // Optional rendering hint for the GUI
+	// Validate optionally checks a value beyond its type (nil = type check only)
+	Validate func(value string) error
+	// EnvOnly settings are only read from their environment variable and are
+	// shown for reference; they cannot be stored in the DB.
+	EnvOnly bool
+	// Warn optionally returns warnings about a valid value.
+	Warn func(value string) []string
+	// Impact optionally describes what changing the value from current to
+	// value does. The GUI previews such settings before saving them.
+	Impact func(current, value string) []string
 }
 
 // ResolvedSetting holds a setting definition with its resolved value and source.
@@ -92,6 +107,10 @@ type SystemInfo struct {
 	StartTime   time.Time
 	ServerPort  string
 	GinMode     string
+	// Password hashing parameters and how many stored hashes already use them
+	PasswordHashing      passhash.Progress
+	PasswordHashingError string // set when the progress could not be counted
+	PasswordRehash       passhash.RehashStats
 }
 
 // SettingsService handles settings resolution and management.
@@ -115,14 +134,15 @@ var categoryMeta = []struct {
 	Icon  string
 }{
 	{"general", "General", "bi-gear"},
-	{"jwt", "JWT & Tokens", "bi-shield-lock"},
+	{"security", "Security", "bi-shield-lock"},
 	{"admin", "Admin Session", "bi-person-lock"},
 	{"cors", "CORS", "bi-globe"},
-	{"trusted_device", "Trusted Devices", "bi-device-hdd"},
 	{"log_retention", "Log Retention", "bi-archive"},
 	{"log_cleanup", "Log Cleanup", "bi-trash"},
 	{"log_behavior", "Log Behavior", "bi-toggles"},
 	{"oauth_redirect", "OAuth Redirects", "bi-box-arrow-up-right"},
+	{"password_hashing", "Password Hashing", "bi-key"},
+	{"storage", "File Storage", "bi-hdd-stack"},
 }
 
 // settingsRegistry is the single source of truth for all known settings.
@@ -130,15 +150,29 @@ var settingsRegistry = []SettingDefinition{
 	// --- General ---
 	{Key: "APP_NAME", EnvVar: "APP_NAME", Category: "general", Type: SettingTypeString, DefaultValue: "Auth API", Label: "Application Name", Description: "Display name used in emails, TOTP, and UI.", Sensitive: false, RequiresRestart: false},
 
-	// --- JWT & Tokens ---
-	{Key: "ACCESS_TOKEN_EXPIRATION_MINUTES", EnvVar: "ACCESS_TOKEN_EXPIRATION_MINUTES", Category: "jwt", Type: SettingTypeInt, DefaultValue: "15", Label: "Access Token Expiration (minutes)", Description: "How long access tokens remain valid.", Sensitive: false, RequiresRestart: false},
-	{Key: "REFRESH_TOKEN_EXPIRATION_HOURS", EnvVar: "REFRESH_TOKEN_EXPIRATION_HOURS", Category: "jwt", Type: SettingTypeInt, DefaultValue: "720", Label: "Refresh Token Expiration (hours)", Description: "How long refresh tokens remain valid (720 = 30 days).", Sensitive: false, RequiresRestart: false},
+	// --- Security ---
+	{Key: jwt.SettingAccessTokenMinutes, EnvVar: jwt.SettingAccessTokenMinutes, Category: "security", Type: SettingTypeInt, DefaultValue: "15", Label: "Access Token Expiration (minutes)", Description: "How long access tokens remain valid (1-1440). Applications can set their own. Changes apply to new tokens within a minute.", Sensitive: false, RequiresRestart: false, Validate: jwtSettingValidator(jwt.SettingAccessTokenMinutes), Warn: warnAccessTokenTTL, Impact: accessTokenTTLImpact},
+	{Key: jwt.SettingRefreshTokenHours, EnvVar: jwt.SettingRefreshTokenHours, Category: "security", Type: SettingTypeInt, DefaultValue: "720", Label: "Refresh Token Expiration (hours)", Description: "How long refresh tokens remain valid (720 = 30 days, at most 8760). Applications can set their own. Changes apply to new tokens within a minute.", Sensitive: false, RequiresRestart: false, Validate: jwtSettingValidator(jwt.SettingRefreshTokenHours), Warn: warnRefreshTokenTTL, Impact: refreshTokenTTLImpact},
+	{Key: jwt.SettingIssuer, EnvVar: jwt.SettingIssuer, Category: "security", Type: SettingTypeString, DefaultValue: "", Label: "Token Issuer", Description: "Stamped as the iss claim of new tokens (e.g. https://auth.example.com). Tokens of another issuer are rejected; tokens without one stay valid until they expire. Empty = no issuer.", Sensitive: false, RequiresRestart: false, Validate: jwtSettingValidator(jwt.SettingIssuer), Impact: issuerImpact},
+	{Key: jwt.SettingAudience, EnvVar: jwt.SettingAudience, Category: "security", Type: SettingTypeString, DefaultValue: "", Label: "Token Audience", Description: "Stamped as the aud claim of new tokens that have no audience of their own, for the services verifying them. The Auth API does not check it. Add one audience per tag.", Sensitive: false, RequiresRestart: false, UIHint: UIHintTagList, Validate: jwtSettingValidator(jwt.SettingAudience), Impact: audienceImpact},
+	{Key: jwt.SettingSecret, EnvVar: jwt.SettingSecret, Category: "security", Type: SettingTypeString, DefaultValue: "", Label: "Token Signing Secret", Description: "Signs all tokens; at least 32 bytes. Only read from the JWT_SECRET environment variable or the config file. Changing it signs every user out.", Sensitive: true, RequiresRestart: true, EnvOnly: true, Validate: jwtSettingValidator(jwt.SettingSecret)},
+	{Key: "COOKIE_FORCE_SECURE", EnvVar: "COOKIE_FORCE_SECURE", Category: "security", Type: SettingTypeBool, DefaultValue: "false", Label: "Force Secure Cookies", Description: "Always set the Secure flag on the admin session and trusted-device cookies, even when a request does not look like HTTPS (e.g. behind a proxy that does not send X-Forwarded-Proto).", Sensitive: false, RequiresRestart: false, Impact: forceSecureCookiesImpact},
+	{
+		Key: "TRUSTED_DEVICE_COOKIE_SAMESITE", EnvVar: "TRUSTED_DEVICE_COOKIE_SAMESITE",
+		Category: "security", Type: SettingTypeString, DefaultValue: "none",
+		Label: "Trusted Device Cookie SameSite Policy",
+		Description: `Controls the SameSite attribute of the trusted-device cookie. ` +
+			`"none" = cross-origin deployments where the Auth API and frontend are on different domains (requires HTTPS in production). ` +
+			`"lax" = same-site subdomains or same-site top-level navigations. ` +
+			`"strict" = same-origin only (Auth API and frontend share the exact same domain).`,
+		Sensitive: false, RequiresRestart: false, Validate: validateSameSite, Impact: sameSiteImpact,
+	},
+	{Key: "CORS_ALLOWED_ORIGINS", EnvVar: "CORS_ALLOWED_ORIGINS", Category: "security", Type: SettingTypeString, DefaultValue: "http://localhost:3000,http://localhost:5173,http://localhost:5174,http://localhost:5175,http://localhost:8080", Label: "CORS Allowed Origins", Description: "Origins allowed to make cross-origin requests (scheme, host and optional port, e.g. https://app.example.com). Add one origin per tag.", Sensitive: false, RequiresRestart: true, UIHint: UIHintTagList, Validate: validateCORSOrigins, Warn: warnCORSOrigins, Impact: corsOriginsImpact},
 
 	// --- Admin Session ---
 	{Key: "ADMIN_SESSION_EXPIRATION_HOURS", EnvVar: "ADMIN_SESSION_EXPIRATION_HOURS", Category: "admin", Type: SettingTypeInt, DefaultValue: "8", Label: "Session Expiration (hours)", Description: "How long admin GUI sessions remain active.", Sensitive: false, RequiresRestart: false},
 
 	// --- CORS ---
-	{Key: "CORS_ALLOWED_ORIGINS", EnvVar: "CORS_ALLOWED_ORIGINS", Category: "cors", Type: SettingTypeString, DefaultValue: "http://localhost:3000,http://localhost:5173,http://localhost:5174,http://localhost:5175,http://localhost:8080", Label: "Allowed Origins", Description: "Origins allowed to make cross-origin requests (e.g. https://app.example.com). Add one origin per tag.", Sensitive: false, RequiresRestart: true, UIHint: UIHintTagList},
 	{Key: "CORS_ALLOWED_METHODS", EnvVar: "CORS_ALLOWED_METHODS", Category: "cors", Type: SettingTypeString, DefaultValue: "GET,POST,PUT,DELETE,OPTIONS,HEAD", Label: "Allowed Methods", Description: "HTTP methods permitted in cross-origin requests. Add one method per tag.", Sensitive: false, RequiresRestart: true, UIHint: UIHintTagList},
 	{Key: "CORS_ALLOWED_HEADERS", EnvVar: "CORS_ALLOWED_HEADERS", Category: "cors", Type: SettingTypeString, DefaultValue: "Origin,Content-Type,Content-Length,Accept-Encoding,X-CSRF-Token,Authorization,Accept,Cache-Control,X-Requested-With,X-App-ID", Label: "Allowed Headers", Description: "Request headers browsers are permitted to send in cross-origin requests. Add one header per tag.", Sensitive: false, RequiresRestart: true, UIHint: UIHintTagList},
 	{Key: "CORS_EXPOSE_HEADERS", EnvVar: "CORS_EXPOSE_HEADERS", Category: "cors", Type: SettingTypeString, DefaultValue: "Content-Length,Access-Control-Allow-Origin,Access-Control-Allow-Headers,Content-Type", Label: "Expose Headers", Description: "Response headers exposed to browser JavaScript. Add one header per tag.", Sensitive: false, RequiresRestart: true, UIHint: UIHintTagList},
@@ -154,7 +188,10 @@ var settingsRegistry = []SettingDefinition{
 	{Key: "LOG_CLEANUP_ENABLED", EnvVar: "LOG_CLEANUP_ENABLED", Category: "log_cleanup", Type: SettingTypeBool, DefaultValue: "true", Label: "Cleanup Enabled", Description: "Enable automatic cleanup of expired activity logs.", Sensitive: false, RequiresRestart: true},
 	{Key: "LOG_CLEANUP_INTERVAL", EnvVar: "LOG_CLEANUP_INTERVAL", Category: "log_cleanup", Type: SettingTypeDuration, DefaultValue: "24h", Label: "Cleanup Interval", Description: "How often the cleanup job runs (e.g., 24h, 12h, 1h).", Sensitive: false, RequiresRestart: true},
 	{Key: "LOG_CLEANUP_BATCH_SIZE", EnvVar: "LOG_CLEANUP_BATCH_SIZE", Category: "log_cleanup", Type: SettingTypeInt, DefaultValue: "1000", Label: "Cleanup Batch Size", Description: "Number of records deleted per cleanup batch.", Sensitive: false, RequiresRestart: true},
-	{Key: "LOG_ARCHIVE_BEFORE_CLEANUP", EnvVar: "LOG_ARCHIVE_BEFORE_CLEANUP", Category: "log_cleanup", Type: SettingTypeBool, DefaultValue: "false", Label: "Archive Before Cleanup", Description: "Archive logs to a file before deleting them.", Sensitive: false, RequiresRestart: true},
+	{Key: "LOG_ARCHIVE_BEFORE_CLEANUP", EnvVar: "LOG_ARCHIVE_BEFORE_CLEANUP", Category: "log_cleanup", Type: SettingTypeBool, DefaultValue: "false", Label: "Archive Before Cleanup", Description: "Write expired logs to the file storage backend (gzipped NDJSON under activity-logs/) before deleting them. Logs that cannot be archived are kept.", Sensitive: false, RequiresRestart: true},
+	{Key: "LOG_ARCHIVE_ENABLED", EnvVar: "LOG_ARCHIVE_ENABLED", Category: "log_cleanup", Type: SettingTypeBool, DefaultValue: "false", Label: "Cold Archive Enabled", Description: "Move activity logs older than the hot-retention window to the file storage backend (activity_log_archive scheduled job), with archive query and restore APIs.", Sensitive: false, RequiresRestart: true},
+	{Key: "LOG_ARCHIVE_HOT_DAYS", EnvVar: "LOG_ARCHIVE_HOT_DAYS", Category: "log_cleanup", Type: SettingTypeInt, DefaultValue: "30", Label: "Hot Retention (days)", Description: "Days activity logs stay in the database before the cold archive moves them to file storage.", Sensitive: false, RequiresRestart: true},
+	{Key: "LOG_ARCHIVE_RESTORE_DAYS", EnvVar: "LOG_ARCHIVE_RESTORE_DAYS", Category: "log_cleanup", Type: SettingTypeInt, DefaultValue: "7", Label: "Restore Duration (days)", Description: "Days logs restored from the cold archive stay in the database by default.", Sensitive: false, RequiresRestart: true},
 
 	// --- Log Behavior ---
 	{Key: "LOG_TOKEN_REFRESH", EnvVar: "LOG_TOKEN_REFRESH", Category: "log_behavior", Type: SettingTypeBool, DefaultValue: "false", Label: "Log Token Refresh", Description: "Enable logging of token refresh events (high frequency).", Sensitive: false, RequiresRestart: true},
@@ -169,21 +206,45 @@ var settingsRegistry = []SettingDefinition{
 	{Key: "LOG_ANOMALY_UNUSUAL_TIME", EnvVar: "LOG_ANOMALY_UNUSUAL_TIME", Category: "log_behavior", Type: SettingTypeBool, DefaultValue: "false", Label: "Log Unusual Time", Description: "Log when a user accesses at an unusual time of day.", Sensitive: false, RequiresRestart: true},
 	{Key: "LOG_ANOMALY_SESSION_WINDOW", EnvVar: "LOG_ANOMALY_SESSION_WINDOW", Category: "log_behavior", Type: SettingTypeDuration, DefaultValue: "720h", Label: "Anomaly Session Window", Description: "How long to remember user patterns for anomaly detection (e.g., 720h = 30 days).", Sensitive: false, RequiresRestart: true},
 
-	// --- Trusted Devices ---
-	{
-		Key: "TRUSTED_DEVICE_COOKIE_SAMESITE", EnvVar: "TRUSTED_DEVICE_COOKIE_SAMESITE",
-		Category: "trusted_device", Type: SettingTypeString, DefaultValue: "none",
-		Label: "Cookie SameSite Policy",
-		Description: `Controls the SameSite attribute of the trusted-device cookie. ` +
-			`"none" = cross-origin deployments where the Auth API and frontend are on different domains (requires HTTPS in production). ` +
-			`"lax" = same-site subdomains or same-site top-level navigations. ` +
-			`"strict" = same-origin only (Auth API and frontend share the exact same domain).`,
-		Sensitive: false, RequiresRestart: false,
-	},
-
 	// --- OAuth Redirects ---
 	{Key: "ALLOWED_REDIRECT_DOMAINS", EnvVar: "ALLOWED_REDIRECT_DOMAINS", Category: "oauth_redirect", Type: SettingTypeString, DefaultValue: "", Label: "Allowed Redirect Domains", Description: "Comma-separated list of domains allowed for OAuth redirect URIs.", Sensitive: false, RequiresRestart: false},
 	{Key: "DEFAULT_REDIRECT_URI", EnvVar: "DEFAULT_REDIRECT_URI", Category: "oauth_redirect", Type: SettingTypeString, DefaultValue: "", Label: "Default Redirect URI", Description: "Default URI to redirect to after OAuth authentication.", Sensitive: false, RequiresRestart: false},
+
+	// --- Password Hashing ---
+	{Key: passhash.SettingAlgorithm, EnvVar: passhash.SettingAlgorithm, Category: "password_hashing", Type: SettingTypeString, DefaultValue: "bcrypt", Label: "Algorithm", Description: "Algorithm for new password hashes: bcrypt or argon2id. Existing hashes keep working and are upgraded on login. Changes apply within a minute.", Sensitive: false, RequiresRestart: false, Validate: passwordHashSettingValidator(passhash.SettingAlgorithm)},
+	{Key: passhash.SettingBcryptCost, EnvVar: passhash.SettingBcryptCost, Category: "password_hashing", Type: SettingTypeInt, DefaultValue: "12", Label: "bcrypt Cost", Description: "bcrypt work factor (4-31). Each step doubles the time to hash and verify a password.", Sensitive: false, RequiresRestart: false, Validate: passwordHashSettingValidator(passhash.SettingBcryptCost)},
+	{Key: passhash.SettingArgon2MemoryKB, EnvVar: passhash.SettingArgon2MemoryKB, Category: "password_hashing", Type: SettingTypeInt, DefaultValue: "65536", Label: "argon2id Memory (KB)", Description: "Memory used per argon2id hash (65536 = 64 MB).", Sensitive: false, RequiresRestart: false, Validate: passwordHashSettingValidator(passhash.SettingArgon2MemoryKB)},
+	{Key: passhash.SettingArgon2Iterations, EnvVar: passhash.SettingArgon2Iterations, Category: "password_hashing", Type: SettingTypeInt, DefaultValue: "3", Label: "argon2id Iterations", Description: "Number of argon2id passes over the memory.", Sensitive: false, RequiresRestart: false, Validate: passwordHashSettingValidator(passhash.SettingArgon2Iterations)},
+	{Key: passhash.SettingArgon2Parallelism, EnvVar: passhash.SettingArgon2Parallelism, Category: "password_hashing", Type: SettingTypeInt, DefaultValue: "2", Label: "argon2id Parallelism", Description: "Number of threads used per argon2id hash (1-255).", Sensitive: false, RequiresRestart: false, Validate: passwordHashSettingValidator(passhash.SettingArgon2Parallelism)},
+	{Key: passhash.SettingRehashOnLogin, EnvVar: passhash.SettingRehashOnLogin, Category: "password_hashing", Type: SettingTypeBool, DefaultValue: "true", Label: "Rehash on Login", Description: "After a successful login, rehash passwords stored with other parameters in the background.", Sensitive: false, RequiresRestart: false},
+
+	// --- File Storage ---
+	{Key: storage.SettingBackend, EnvVar: storage.SettingBackend, Category: "storage", Type: SettingTypeString, DefaultValue: storage.BackendLocal, Label: "Backend", Description: "Where profile pictures, export archives and archived activity logs are stored: local, s3 or gcs. Existing files are not moved when it changes. Changes apply within a minute.", Sensitive: false, RequiresRestart: false, Validate: storageSettingValidator(storage.SettingBackend)},
+	{Key: storage.SettingLocalPath, EnvVar: storage.SettingLocalPath, Category: "storage", Type: SettingTypeString, DefaultValue: "./data/storage", Label: "Local Path", Description: "Directory used by the local backend. Use a persistent volume in containers.", Sensitive: false, RequiresRestart: false},
+	{Key: storage.SettingS3Endpoint, EnvVar: storage.SettingS3Endpoint, Category: "storage", Type: SettingTypeString, DefaultValue: "", Label: "S3 Endpoint", Description: "Endpoint of an S3-compatible service (e.g. http://minio:9000). Leave empty for AWS S3.", Sensitive: false, RequiresRestart: false, Validate: storageSettingValidator(storage.SettingS3Endpoint)},
+	{Key: storage.SettingS3Region, EnvVar: storage.SettingS3Region, Category: "storage", Type: SettingTypeString, DefaultValue: "us-east-1", Label: "S3 Region", Description: "Region of the bucket.", Sensitive: false, RequiresRestart: false},
+	{Key: storage.SettingS3Bucket, EnvVar: storage.SettingS3Bucket, Category: "storage", Type: SettingTypeString, DefaultValue: "", Label: "S3 Bucket", Description: "Bucket name. The bucket should not be public; files are served through the API.", Sensitive: false, RequiresRestart: false},
+	{Key: storage.SettingS3AccessKeyID, EnvVar: storage.SettingS3AccessKeyID, Category: "storage", Type: SettingTypeString, DefaultValue: "", Label: "S3 Access Key ID", Description: "Access key ID with read, write and delete access to the bucket.", Sensitive: false, RequiresRestart: false},
+	{Key: storage.SettingS3SecretAccessKey, EnvVar: storage.SettingS3SecretAccessKey, Category: "storage", Type: SettingTypeString, DefaultValue: "", Label: "S3 Secret Access Key", Description: "Secret of the access key. Stored encrypted; leave empty to keep the current value.", Sensitive: true, RequiresRestart: false},
+	{Key: storage.SettingS3ForcePathStyle, EnvVar: storage.SettingS3ForcePathStyle, Category: "storage", Type: SettingTypeBool, DefaultValue: "false", Label: "S3 Path-Style Addressing", Description: "Address the bucket as <endpoint>/<bucket> instead of <bucket>.<endpoint> (needed by MinIO).", Sensitive: false, RequiresRestart: false},
+	{Key: storage.SettingGCSBucket, EnvVar: storage.SettingGCSBucket, Category: "storage", Type: SettingTypeString, DefaultValue: "", Label: "GCS Bucket", Description: "Google Cloud Storage bucket name.", Sensitive: false, RequiresRestart: false},
+	{Key: storage.SettingGCSCredentialsJSON, EnvVar: storage.SettingGCSCredentialsJSON, Category: "storage", Type: SettingTypeString, DefaultValue: "", Label: "GCS Service Account Key", Description: "Service account key JSON. Leave unset to use the application default credentials. Stored encrypted; leave empty to keep the current value.", Sensitive: true, RequiresRestart: false},
+}
+
+// storageSettingValidator returns the Validate function of a file storage
+// setting.
+func storageSettingValidator(key string) func(string) error {
+	return func(value string) error {
+		return storage.ValidateSetting(key, value)
+	}
+}
+
+// passwordHashSettingValidator returns the Validate function of a password
+// hashing setting.
+func passwordHashSettingValidator(key string) func(string) error {
+	return func(value string) error {
+		return passhash.ValidateSetting(key, value)
+	}
 }
 
 // GetSettingDefinition returns the definition for a given key, or nil if not found.
@@ -196,6 +257,18 @@ func GetSettingDefinition(key string) *SettingDefinition {
 	return nil
 }
 
+// SensitiveSettingKeys returns the keys of the settings holding secrets,
+// which are stored encrypted.
+func SensitiveSettingKeys() []string {
+	var keys []string
+	for _, def := range settingsRegistry {
+		if def.Sensitive && !def.EnvOnly {
+			keys = append(keys, def.Key)
+		}
+	}
+	return keys
+}
+
 // GetSystemInfo returns read-only system information.
 func (s *SettingsService) GetSystemInfo() SystemInfo {
 	info := SystemInfo{
@@ -245,6 +318,18 @@ func (s *SettingsService) GetSystemInfo() SystemInfo {
 		info.RedisStatus = "Not initialized"
 	}
 
+	// Count the password hashes already using the current parameters
+	info.PasswordRehash = passhash.Stats()
+	if database.DB != nil {
+		progress, err := passhash.GetProgress(database.DB)
+		if err != nil {
+			info.PasswordHashingError = err.Error()
+		}
+		info.PasswordHashing = progress
+	} else {
+		info.PasswordHashing.Params = passhash.CurrentParams()
+	}
+
 	return info
 }
 
@@ -354,6 +439,11 @@ func (s *SettingsService) resolveSetting(def SettingDefinition, dbSetting *model
 		resolved.Value = dbSetting.Value
 		resolved.RawValue = dbSetting.Value
 		resolved.Source = SourceDB
+		// Sensitive DB values are encrypted and never sent back to the browser
+		if def.Sensitive {
+			resolved.Value = "********"
+			resolved.RawValue = ""
+		}
 	} else {
 		resolved.Value = def.DefaultValue
 		resolved.RawValue = def.DefaultValue
@@ -369,11 +459,33 @@ func (s *SettingsService) UpdateSetting(key, value string) error {
 	if def == nil {
 		return fmt.Errorf("unknown setting key: %s", key)
 	}
+	if def.EnvOnly {
+		return fmt.Errorf("%s can only be set by the %s environment variable", key, def.EnvVar)
+	}
 
 	// Validate value against type
 	if err := validateSettingValue(def.Type, value); err != nil {
 		return fmt.Errorf("invalid value for %s: %w", key, err)
 	}
+	if def.Validate != nil {
+		if err := def.Validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+
+	if def.Sensitive {
+		// An empty value keeps the stored secret; Reset removes it
+		if value == "" {
+			if existing, err := s.repo.GetSettingByKey(key); err == nil && existing != nil {
+				return nil
+			}
+		}
+		encrypted, err := secretbox.Encrypt(value)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", key, err)
+		}
+		value = encrypted
+	}
 
 	return s.repo.UpsertSetting(key, value, def.Category)
 }
@@ -387,6 +499,62 @@ func (s *SettingsService) ResetSetting(key string) error {
 	return s.repo.DeleteSetting(key)
 }
 
+// SettingPreview is what saving a new value of a setting would do.
+type SettingPreview struct {
+	Setting  ResolvedSetting
+	Value    string   // New value (empty for sensitive settings)
+	Error    string   // Why the value is refused; empty when it can be saved
+	Warnings []string // Risks of the value
+	Impact   []string // Effects of the change
+}
+
+// PreviewSetting validates value for key like UpdateSetting and describes what
+// saving it would do, without saving it.
+func (s *SettingsService) PreviewSetting(key, value string) (*SettingPreview, error) {
+	def := GetSettingDefinition(key)
+	if def == nil {
+		return nil, fmt.Errorf("unknown setting key: %s", key)
+	}
+	dbSetting, err := s.repo.GetSettingByKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load setting: %w", err)
+	}
+	resolved := s.resolveSetting(*def, dbSetting)
+	preview := &SettingPreview{Setting: resolved, Value: value}
+	if def.Sensitive {
+		preview.Value = ""
+	}
+
+	switch {
+	case def.EnvOnly:
+		preview.Error = fmt.Sprintf("can only be set by the %s environment variable", def.EnvVar)
+	case resolved.Source == SourceEnv:
+		preview.Error = fmt.Sprintf("controlled by the %s environment variable", def.EnvVar)
+	default:
+		if err := validateSettingValue(def.Type, value); err != nil {
+			preview.Error = err.Error()
+		} else if def.Validate != nil {
+			if err := def.Validate(value); err != nil {
+				preview.Error = err.Error()
+			}
+		}
+	}
+	if preview.Error != "" {
+		return preview, nil
+	}
+
+	if def.Warn != nil {
+		preview.Warnings = def.Warn(value)
+	}
+	if def.Impact != nil {
+		preview.Impact = def.Impact(resolved.RawValue, value)
+	}
+	if def.RequiresRestart {
+		preview.Impact = append(preview.Impact, "Takes effect after the application is restarted.")
+	}
+	return preview, nil
+}
+
 // GetResolvedValue resolves a single setting by key using the standard
 // priority: env > DB > default. Returns empty string if the key is unknown.
 // This is safe to call concurrently and does not load the entire registry.
@@ -404,7 +572,12 @@ func (s *SettingsService) GetResolvedValue(key string) string {
 	if err == nil {
 		for i := range dbSettings {
 			if dbSettings[i].Key == key {
-				return dbSettings[i].Value
+				value, err := secretbox.Decrypt(dbSettings[i].Value)
+				if err != nil {
+					log.Printf("Warning: Failed to decrypt setting %s: %v\n", key, err)
+					return ""
+				}
+				return value
 			}
 		}
 	}
@@ -0,0 +1,198 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// statsRollupBackfillDays is how many days before yesterday the nightly rollup
+// checks for missing rows, so that missed runs are caught up.
+const statsRollupBackfillDays = 7
+
+// RunDailyRollup rolls up yesterday (UTC) and every one of the preceding
+// statsRollupBackfillDays days that has no rollup yet. Its signature matches
+// scheduler.JobFunc.
+func (s *StatsService) RunDailyRollup(ctx context.Context) error {
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	first := yesterday.AddDate(0, 0, -statsRollupBackfillDays)
+
+	var done []time.Time
+	if err := s.db.WithContext(ctx).Model(&models.DailyAppMetric{}).
+		Where("day >= ? AND day < ?", first, yesterday).
+		Distinct("day").Pluck("day", &done).Error; err != nil {
+		return err
+	}
+	rolledUp := make(map[string]bool, len(done))
+	for _, day := range done {
+		rolledUp[day.Format("2006-01-02")] = true
+	}
+
+	for day := first; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		if rolledUp[day.Format("2006-01-02")] {
+			continue
+		}
+		if err := s.RollupDay(ctx, day); err != nil {
+			return fmt.Errorf("rollup of %s: %w", day.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
+
+// RollupDay computes the daily metrics of every application for the UTC day
+// containing day and upserts them into daily_app_metrics. Emails sent come from
+// the daily quota counters in Redis, which only cover the last two days.
+func (s *StatsService) RollupDay(ctx context.Context, day time.Time) error {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.AddDate(0, 0, 1)
+	db := s.db.WithContext(ctx)
+
+	var appIDs []uuid.UUID
+	if err := db.Model(&models.Application{}).Pluck("id", &appIDs).Error; err != nil {
+		return err
+	}
+	if len(appIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	rows := make(map[uuid.UUID]*models.DailyAppMetric, len(appIDs))
+	methods := make(map[uuid.UUID]map[string]int64, len(appIDs))
+	for _, appID := range appIDs {
+		rows[appID] = &models.DailyAppMetric{AppID: appID, Day: start, RolledUpAt: now}
+		methods[appID] = map[string]int64{}
+	}
+
+	var signups []struct {
+		AppID uuid.UUID
+		Count int64
+	}
+	if err := db.Model(&models.User{}).
+		Select("app_id, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Group("app_id").Scan(&signups).Error; err != nil {
+		return err
+	}
+	for _, r := range signups {
+		if row, ok := rows[r.AppID]; ok {
+			row.Signups = r.Count
+		}
+	}
+
+	var activity []struct {
+		AppID        uuid.UUID
+		ActiveUsers  int64
+		Logins       int64
+		FailedLogins int64
+	}
+	if err := db.Model(&models.ActivityLog{}).
+		Select(`app_id,
+			COUNT(DISTINCT user_id) FILTER (WHERE user_id <> ? AND user_id NOT IN (`+models.ServiceAccountIDsSQL+`)) AS active_users,
+			COUNT(*) FILTER (WHERE event_type IN ?) AS logins,
+			COUNT(*) FILTER (WHERE event_type = ?) AS failed_logins`,
+			uuid.Nil, loginSuccessEvents(), logService.EventLoginFailed).
+		Where("timestamp >= ? AND timestamp < ?", start, end).
+		Group("app_id").Scan(&activity).Error; err != nil {
+		return err
+	}
+	for _, r := range activity {
+		if row, ok := rows[r.AppID]; ok {
+			row.ActiveUsers = r.ActiveUsers
+			row.Logins = r.Logins
+			row.FailedLogins = r.FailedLogins
+		}
+	}
+
+	var monthly []struct {
+		AppID uuid.UUID
+		Count int64
+	}
+	if err := db.Model(&models.ActivityLog{}).
+		Select("app_id, COUNT(DISTINCT user_id) AS count").
+		Where("user_id <> ? AND timestamp >= ? AND timestamp < ?", uuid.Nil, end.AddDate(0, 0, -statsMAUWindowDays), end).
+		Where("user_id NOT IN (" + models.ServiceAccountIDsSQL + ")").
+		Group("app_id").Scan(&monthly).Error; err != nil {
+		return err
+	}
+	for _, r := range monthly {
+		if row, ok := rows[r.AppID]; ok {
+			row.MonthlyUsers = r.Count
+		}
+	}
+
+	providers, err := s.loginMethodCounts(ctx, nil, start, end)
+	if err != nil {
+		return err
+	}
+	for _, p := range providers {
+		if m, ok := methods[p.AppID]; ok {
+			m[loginMethod(p.EventType, p.Provider)] += p.Count
+		}
+	}
+
+	metrics := make([]models.DailyAppMetric, 0, len(rows))
+	for _, appID := range appIDs {
+		row := rows[appID]
+		if row.EmailsSent, err = quota.DailyEmailCount(appID, start); err != nil {
+			log.Printf("Warning: Failed to read email count of app %s for %s: %v\n", appID, start.Format("2006-01-02"), err)
+		}
+		if row.LoginMethods, err = json.Marshal(methods[appID]); err != nil {
+			return err
+		}
+		metrics = append(metrics, *row)
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}, {Name: "day"}},
+		UpdateAll: true,
+	}).CreateInBatches(&metrics, 500).Error
+}
+
+// loginMethodRow is one group of the login method breakdown query.
+type loginMethodRow struct {
+	AppID     uuid.UUID
+	EventType string
+	Provider  string
+	Count     int64
+}
+
+// loginMethodCounts counts the successful logins in [start, end) by
+// application, event type and social provider. appID restricts the count to
+// one application when non-nil.
+func (s *StatsService) loginMethodCounts(ctx context.Context, appID *uuid.UUID, start, end time.Time) ([]loginMethodRow, error) {
+	var rows []loginMethodRow
+	q := s.db.WithContext(ctx).Model(&models.ActivityLog{}).
+		Select("app_id, event_type, COALESCE(details->>'provider', '') AS provider, COUNT(*) AS count").
+		Where("event_type IN ? AND timestamp >= ? AND timestamp < ?", loginSuccessEvents(), start, end)
+	if appID != nil {
+		q = q.Where("app_id = ?", *appID)
+	}
+	err := q.Group("app_id, event_type, provider").Scan(&rows).Error
+	return rows, err
+}
+
+// loginSuccessEvents returns the event types counted as successful logins.
+func loginSuccessEvents() []string {
+	events := make([]string, 0, len(loginMethodsByEvent))
+	for event := range loginMethodsByEvent {
+		events = append(events, event)
+	}
+	return events
+}
+
+// loginMethod returns the method name reported for a successful login event:
+// the provider for social logins, loginMethodsByEvent otherwise.
+func loginMethod(eventType, provider string) string {
+	if eventType == logService.EventSocialLogin && provider != "" {
+		return provider
+	}
+	return loginMethodsByEvent[eventType]
+}
@@ -0,0 +1,304 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	appRedis "github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	// statsDefaultRangeDays is the range used when no "from" date is given.
+	statsDefaultRangeDays = 30
+	// statsMaxRangeDays caps the range so a single report cannot scan years of logs.
+	statsMaxRangeDays = 366
+	// statsMAUWindowDays is the trailing window used for monthly active users.
+	statsMAUWindowDays = 30
+)
+
+// loginMethodsByEvent maps successful-login event types to the method name
+// reported in the provider breakdown. Social logins are broken down further by
+// the "provider" recorded in the log details.
+var loginMethodsByEvent = map[string]string{
+	logService.EventLogin:          "password",
+	logService.Event2FALogin:       "password_2fa",
+	logService.EventSocialLogin:    "social",
+	logService.EventPasskeyLogin:   "passkey",
+	logService.EventMagicLinkLogin: "magic_link",
+	logService.EventOIDCLogin:      "oidc",
+}
+
+// StatsService computes per-application authentication statistics for
+// operators building external reports. Like DashboardService it queries
+// *gorm.DB directly since the report spans users and activity logs.
+type StatsService struct {
+	db *gorm.DB
+}
+
+// NewStatsService creates a new StatsService.
+func NewStatsService(db *gorm.DB) *StatsService {
+	return &StatsService{db: db}
+}
+
+// ParseStatsRange parses inclusive YYYY-MM-DD bounds. An empty "to" means today
+// (UTC) and an empty "from" means 30 days ending on "to". Error messages are
+// suitable for returning to the client.
+func ParseStatsRange(fromStr, toStr string, now time.Time) (time.Time, time.Time, error) {
+	to := now.UTC().Truncate(24 * time.Hour)
+	if toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid to date. Use YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -(statsDefaultRangeDays - 1))
+	if fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid from date. Use YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from cannot be after to")
+	}
+	if to.Sub(from) >= statsMaxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("Date range cannot exceed %d days", statsMaxRangeDays)
+	}
+	return from, to, nil
+}
+
+// GetAppStats returns the statistics report for an application over the inclusive
+// UTC date range [from, to]. Reports are cached in Redis for APP_STATS_CACHE_TTL_SECONDS
+// (0 disables caching); cache failures fall through to a fresh computation.
+func (s *StatsService) GetAppStats(appID uuid.UUID, from, to time.Time) (*dto.AppStatsResponse, error) {
+	rangeKey := from.Format("20060102") + "-" + to.Format("20060102")
+	ttl := time.Duration(viper.GetInt("APP_STATS_CACHE_TTL_SECONDS")) * time.Second
+
+	if ttl > 0 && appRedis.Rdb != nil {
+		if cached, err := appRedis.GetAppStatsCache(appID.String(), rangeKey); err == nil {
+			var stats dto.AppStatsResponse
+			if json.Unmarshal([]byte(cached), &stats) == nil {
+				stats.Cached = true
+				return &stats, nil
+			}
+		}
+	}
+
+	stats, err := s.computeAppStats(appID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 && appRedis.Rdb != nil {
+		if payload, err := json.Marshal(stats); err == nil {
+			_ = appRedis.SetAppStatsCache(appID.String(), rangeKey, string(payload), ttl)
+		}
+	}
+	return stats, nil
+}
+
+// dailyRow is one day of the aggregated activity log / registration queries.
+type dailyRow struct {
+	Day          time.Time
+	Count        int64
+	ActiveUsers  int64
+	Logins       int64
+	FailedLogins int64
+}
+
+// computeAppStats builds the report from the daily_app_metrics rollups where
+// they exist and from the raw users and activity logs for the remaining days,
+// normally just today. Rolled-up days form a prefix of the range: every day
+// from the first one without a rollup is computed from the raw tables.
+func (s *StatsService) computeAppStats(appID uuid.UUID, from, to time.Time) (*dto.AppStatsResponse, error) {
+	end := to.AddDate(0, 0, 1) // exclusive upper bound
+	ctx := context.Background()
+
+	stats := &dto.AppStatsResponse{
+		AppID:       appID.String(),
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		Providers:   map[string]int64{},
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	var rollups []models.DailyAppMetric
+	if err := s.db.Where("app_id = ? AND day >= ? AND day <= ?", appID, from, to).
+		Order("day").Find(&rollups).Error; err != nil {
+		return nil, err
+	}
+	rawFrom := from
+	n := 0
+	for n < len(rollups) && rollups[n].Day.Equal(rawFrom) {
+		n++
+		rawFrom = rawFrom.AddDate(0, 0, 1)
+	}
+	rolledUp := rollups[:n]
+
+	// Registrations per day (users table is authoritative even if REGISTER logging is off)
+	var registrations []dailyRow
+	// Activity per day: distinct active users, successful and failed logins
+	var activity []dailyRow
+	if rawFrom.Before(end) {
+		if err := s.db.Model(&models.User{}).
+			Select("(created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS count").
+			Where("app_id = ? AND created_at >= ? AND created_at < ?", appID, rawFrom, end).
+			Group("day").Scan(&registrations).Error; err != nil {
+			return nil, err
+		}
+
+		if err := s.db.Model(&models.ActivityLog{}).
+			Select(`(timestamp AT TIME ZONE 'UTC')::date AS day,
+			COUNT(DISTINCT user_id) FILTER (WHERE user_id <> ? AND user_id NOT IN (`+models.ServiceAccountIDsSQL+`)) AS active_users,
+			COUNT(*) FILTER (WHERE event_type IN ?) AS logins,
+			COUNT(*) FILTER (WHERE event_type = ?) AS failed_logins`,
+				uuid.Nil, loginSuccessEvents(), logService.EventLoginFailed).
+			Where("app_id = ? AND timestamp >= ? AND timestamp < ?", appID, rawFrom, end).
+			Group("day").Scan(&activity).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	stats.Daily = buildDailySeries(from, to, registrations, activity)
+	applyRollups(stats.Daily, rolledUp)
+	for i := range stats.Daily {
+		d := &stats.Daily[i]
+		if day, _ := time.Parse("2006-01-02", d.Date); !day.Before(rawFrom) {
+			count, err := quota.DailyEmailCount(appID, day)
+			if err != nil {
+				log.Printf("Warning: Failed to read email count of app %s for %s: %v\n", appID, d.Date, err)
+			}
+			d.EmailsSent = count
+		}
+	}
+
+	var activeSum int64
+	for _, d := range stats.Daily {
+		stats.Registrations += d.Registrations
+		stats.Logins.Successful += d.Logins
+		stats.Logins.Failed += d.FailedLogins
+		stats.EmailsSent += d.EmailsSent
+		activeSum += d.ActiveUsers
+	}
+	stats.Logins.SuccessRatio = ratio(stats.Logins.Successful, stats.Logins.Successful+stats.Logins.Failed)
+	stats.ActiveUsers.AverageDAU = float64(activeSum) / float64(len(stats.Daily))
+
+	// Monthly active users: trailing window ending on "to"
+	if n := len(rolledUp); rawFrom.After(to) && n > 0 {
+		stats.ActiveUsers.MAU = rolledUp[n-1].MonthlyUsers
+	} else if err := s.db.Model(&models.ActivityLog{}).
+		Where("app_id = ? AND user_id <> ? AND timestamp >= ? AND timestamp < ?",
+			appID, uuid.Nil, end.AddDate(0, 0, -statsMAUWindowDays), end).
+		Where("user_id NOT IN (" + models.ServiceAccountIDsSQL + ")").
+		Distinct("user_id").Count(&stats.ActiveUsers.MAU).Error; err != nil {
+		return nil, err
+	}
+	if stats.ActiveUsers.MAU > 0 {
+		stats.ActiveUsers.Stickiness = stats.ActiveUsers.AverageDAU / float64(stats.ActiveUsers.MAU)
+	}
+
+	// Provider breakdown of successful logins
+	for _, r := range rolledUp {
+		var methods map[string]int64
+		if err := json.Unmarshal(r.LoginMethods, &methods); err != nil {
+			return nil, err
+		}
+		for method, count := range methods {
+			stats.Providers[method] += count
+		}
+	}
+	if rawFrom.Before(end) {
+		providers, err := s.loginMethodCounts(ctx, &appID, rawFrom, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range providers {
+			stats.Providers[loginMethod(p.EventType, p.Provider)] += p.Count
+		}
+	}
+
+	// 2FA adoption across the app's active users (current state, not range-bound)
+	var twoFA struct {
+		Total   int64
+		Enabled int64
+	}
+	if err := s.db.Model(&models.User{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE two_fa_enabled) AS enabled").
+		Where("app_id = ? AND is_active = ?", appID, true).
+		Scan(&twoFA).Error; err != nil {
+		return nil, err
+	}
+	stats.TwoFA = dto.AppTwoFAStats{
+		TotalUsers:    twoFA.Total,
+		EnabledUsers:  twoFA.Enabled,
+		AdoptionRatio: ratio(twoFA.Enabled, twoFA.Total),
+	}
+
+	return stats, nil
+}
+
+// buildDailySeries merges the per-day registration and activity rows into a
+// contiguous series covering every day in [from, to], filling gaps with zeros.
+func buildDailySeries(from, to time.Time, registrations, activity []dailyRow) []dto.AppDailyStats {
+	byDay := make(map[string]*dto.AppDailyStats)
+	var series []dto.AppDailyStats
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		series = append(series, dto.AppDailyStats{Date: d.Format("2006-01-02")})
+	}
+	for i := range series {
+		byDay[series[i].Date] = &series[i]
+	}
+
+	for _, r := range registrations {
+		if d, ok := byDay[r.Day.Format("2006-01-02")]; ok {
+			d.Registrations = r.Count
+		}
+	}
+	for _, r := range activity {
+		if d, ok := byDay[r.Day.Format("2006-01-02")]; ok {
+			d.ActiveUsers = r.ActiveUsers
+			d.Logins = r.Logins
+			d.FailedLogins = r.FailedLogins
+		}
+	}
+	return series
+}
+
+// applyRollups fills the days of series covered by rollups with their values.
+func applyRollups(series []dto.AppDailyStats, rollups []models.DailyAppMetric) {
+	byDay := make(map[string]*models.DailyAppMetric, len(rollups))
+	for i := range rollups {
+		byDay[rollups[i].Day.Format("2006-01-02")] = &rollups[i]
+	}
+	for i := range series {
+		if r, ok := byDay[series[i].Date]; ok {
+			series[i].Registrations = r.Signups
+			series[i].ActiveUsers = r.ActiveUsers
+			series[i].Logins = r.Logins
+			series[i].FailedLogins = r.FailedLogins
+			series[i].EmailsSent = r.EmailsSent
+		}
+	}
+}
+
+func ratio(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
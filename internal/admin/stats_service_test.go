@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// ---------------------------------------------------------------------------
+// ParseStatsRange tests
+// ---------------------------------------------------------------------------
+
+func TestParseStatsRangeDefaults(t *testing.T) {
+	now := time.Date(2026, 3, 31, 15, 4, 5, 0, time.UTC)
+	from, to, err := ParseStatsRange("", "", now)
+	if err != nil {
+		t.Fatalf("ParseStatsRange error: %v", err)
+	}
+	if got := to.Format("2006-01-02"); got != "2026-03-31" {
+		t.Errorf("to = %s, want 2026-03-31", got)
+	}
+	if got := from.Format("2006-01-02"); got != "2026-03-02" {
+		t.Errorf("from = %s, want 2026-03-02 (30 days inclusive)", got)
+	}
+}
+
+func TestParseStatsRangeErrors(t *testing.T) {
+	now := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	cases := []struct{ from, to string }{
+		{"2026-13-01", ""},
+		{"", "yesterday"},
+		{"2026-03-10", "2026-03-01"},
+		{"2024-01-01", "2026-01-01"},
+	}
+	for _, tc := range cases {
+		if _, _, err := ParseStatsRange(tc.from, tc.to, now); err == nil {
+			t.Errorf("ParseStatsRange(%q, %q) should fail", tc.from, tc.to)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// buildDailySeries tests
+// ---------------------------------------------------------------------------
+
+func TestBuildDailySeriesFillsGaps(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	series := buildDailySeries(from, to,
+		[]dailyRow{{Day: from, Count: 4}},
+		[]dailyRow{{Day: to, ActiveUsers: 2, Logins: 5, FailedLogins: 1}},
+	)
+
+	if len(series) != 3 {
+		t.Fatalf("len(series) = %d, want 3", len(series))
+	}
+	if series[0].Registrations != 4 || series[0].Logins != 0 {
+		t.Errorf("day 1 = %+v, want 4 registrations and no logins", series[0])
+	}
+	if series[1].Date != "2026-03-02" || series[1].ActiveUsers != 0 {
+		t.Errorf("day 2 = %+v, want an empty 2026-03-02 entry", series[1])
+	}
+	if series[2].ActiveUsers != 2 || series[2].Logins != 5 || series[2].FailedLogins != 1 {
+		t.Errorf("day 3 = %+v, want activity merged in", series[2])
+	}
+}
+
+func TestApplyRollupsOverridesRolledUpDays(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	series := buildDailySeries(from, to, nil, []dailyRow{{Day: to, Logins: 3}})
+	applyRollups(series, []models.DailyAppMetric{
+		{Day: from, Signups: 2, Logins: 7, FailedLogins: 1, EmailsSent: 4, ActiveUsers: 5},
+	})
+
+	if got := series[0]; got.Registrations != 2 || got.Logins != 7 || got.EmailsSent != 4 || got.ActiveUsers != 5 {
+		t.Errorf("rolled-up day = %+v, want the rollup values", got)
+	}
+	if series[1].Logins != 3 {
+		t.Errorf("raw day = %+v, want it left unchanged", series[1])
+	}
+}
+
+func TestLoginMethod(t *testing.T) {
+	if got := loginMethod(logService.EventSocialLogin, "google"); got != "google" {
+		t.Errorf("social login method = %q, want google", got)
+	}
+	if got := loginMethod(logService.EventSocialLogin, ""); got != "social" {
+		t.Errorf("social login without provider = %q, want social", got)
+	}
+	if got := loginMethod(logService.EventLogin, "ignored"); got != "password" {
+		t.Errorf("password login method = %q, want password", got)
+	}
+}
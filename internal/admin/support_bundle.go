@@ -0,0 +1,254 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/internal/health"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/secretbox"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// processStart approximates the start of the process for the bundle's uptime.
+var processStart = time.Now()
+
+// supportBundleMigrationsDir is where pending SQL migrations are looked for,
+// relative to the working directory (as in make migrate-up and cmd/setup).
+const supportBundleMigrationsDir = "migrations"
+
+// supportBundleSources are the dependencies the support bundle reads.
+type supportBundleSources struct {
+	DB            *gorm.DB
+	Redis         *goredis.Client // nil = Redis version not reported
+	Health        *health.Handler // nil = no datastore latency or HTTP error rates
+	MigrationsDir string
+}
+
+// buildSupportBundle gathers the support bundle. Sections that cannot be
+// collected are left empty and listed in Errors, so a partly broken
+// installation still produces a bundle.
+func buildSupportBundle(src supportBundleSources) dto.SupportBundle {
+	bundle := dto.SupportBundle{
+		GeneratedAt: time.Now().UTC(),
+		Build:       buildInfo(),
+		Runtime:     runtimeInfo(src.DB),
+		Settings:    []dto.SupportBundleSetting{},
+		Datastores:  map[string]dto.ComponentStatus{},
+	}
+	fail := func(section string, err error) {
+		bundle.Errors = append(bundle.Errors, fmt.Sprintf("%s: %v", section, err))
+	}
+
+	for _, v := range config.EffectiveConfig() {
+		bundle.Config = append(bundle.Config, dto.SupportBundleSetting{Key: v.EnvVar, Value: v.Value})
+	}
+	var stored []models.SystemSetting
+	if err := src.DB.Order("key").Find(&stored).Error; err != nil {
+		fail("settings", err)
+	} else {
+		bundle.Settings = storedSettings(stored)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := src.DB.WithContext(ctx).Raw("SHOW server_version").Scan(&bundle.Build.DatabaseVersion).Error; err != nil {
+		fail("database version", err)
+	}
+	if src.Redis != nil {
+		info, err := src.Redis.Info(ctx, "server").Result()
+		if err != nil {
+			fail("redis version", err)
+		}
+		bundle.Build.RedisVersion = infoField(info, "redis_version")
+	}
+
+	if src.Health != nil {
+		bundle.Datastores = src.Health.GetDatastoreHealth()
+		latency := src.Health.GetLatencySummary()
+		bundle.ErrorRates.HTTPRequests = latency.Overall.Requests
+		bundle.ErrorRates.HTTPErrors = latency.Overall.Errors
+		bundle.ErrorRates.HTTPErrorRate = latency.Overall.ErrorRate
+		for _, g := range latency.Groups {
+			bundle.ErrorRates.HTTPGroups = append(bundle.ErrorRates.HTTPGroups, dto.SupportBundleHTTPErrorRate{
+				Group: g.Group, Requests: g.Requests, Errors: g.Errors, ErrorRate: g.ErrorRate,
+			})
+		}
+	}
+
+	migrations, err := migrationStatus(src.DB, src.MigrationsDir)
+	if err != nil {
+		fail("migrations", err)
+	}
+	bundle.Migrations = migrations
+
+	bundle.Queues.Jobs = []dto.SupportBundleQueueDepth{}
+	if err := src.DB.Model(&models.BackgroundJob{}).
+		Select("type, status, COUNT(*) AS count").
+		Where("status IN ?", []string{jobqueue.StatusQueued, jobqueue.StatusRunning}).
+		Group("type, status").Order("type, status").
+		Scan(&bundle.Queues.Jobs).Error; err != nil {
+		fail("job queue", err)
+	}
+	if err := src.DB.Model(&models.WebhookDelivery{}).
+		Where("success = false AND next_retry_at IS NOT NULL").
+		Count(&bundle.Queues.WebhookRetries).Error; err != nil {
+		fail("webhook queue", err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	if err := src.DB.Model(&models.BackgroundJob{}).
+		Where("status = ? AND finished_at >= ?", jobqueue.StatusFailed, since).
+		Count(&bundle.ErrorRates.JobsFailed24h).Error; err != nil {
+		fail("failed jobs", err)
+	}
+	if err := src.DB.Model(&models.WebhookDelivery{}).
+		Where("success = false AND created_at >= ?", since).
+		Count(&bundle.ErrorRates.WebhookDeliveriesFailed24h).Error; err != nil {
+		fail("failed webhook deliveries", err)
+	}
+
+	return bundle
+}
+
+// buildInfo reads the version of the binary and its dependencies.
+func buildInfo() dto.SupportBundleBuild {
+	b := dto.SupportBundleBuild{GoVersion: runtime.Version(), Dependencies: []dto.SupportBundleDependency{}}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	b.Module = info.Main.Path
+	b.Version = info.Main.Version
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.VCSRevision = s.Value
+		case "vcs.time":
+			b.VCSTime = s.Value
+		case "vcs.modified":
+			b.VCSModified = s.Value == "true"
+		}
+	}
+	for _, dep := range info.Deps {
+		d := dto.SupportBundleDependency{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			d.Replace = strings.TrimSpace(dep.Replace.Path + " " + dep.Replace.Version)
+		}
+		b.Dependencies = append(b.Dependencies, d)
+	}
+	return b
+}
+
+func runtimeInfo(db *gorm.DB) dto.SupportBundleRuntime {
+	instance, _ := os.Hostname()
+	r := dto.SupportBundleRuntime{
+		Instance:      instance,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		NumCPU:        runtime.NumCPU(),
+		Goroutines:    runtime.NumGoroutine(),
+		UptimeSeconds: int64(time.Since(processStart).Seconds()),
+		GinMode:       viper.GetString("GIN_MODE"),
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		stats := sqlDB.Stats()
+		r.DBOpenConns = stats.OpenConnections
+		r.DBInUseConns = stats.InUse
+	}
+	return r
+}
+
+// storedSettings lists the settings stored in the database, redacting the
+// sensitive ones, and notes the ones the environment overrides.
+func storedSettings(stored []models.SystemSetting) []dto.SupportBundleSetting {
+	settings := make([]dto.SupportBundleSetting, 0, len(stored))
+	for _, s := range stored {
+		setting := dto.SupportBundleSetting{Key: s.Key, Value: s.Value, Source: string(SourceDB)}
+		def := GetSettingDefinition(s.Key)
+		if (def != nil && def.Sensitive) || secretbox.IsEncrypted(s.Value) {
+			setting.Value = config.Redacted
+		}
+		if def != nil && getEnvValue(def.EnvVar) != "" {
+			setting.Source = string(SourceEnv)
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// migrationStatus compares the migrations recorded in schema_migrations with
+// the SQL files in dir. Pending is only checked when dir exists.
+func migrationStatus(db *gorm.DB, dir string) (dto.SupportBundleMigrations, error) {
+	status := dto.SupportBundleMigrations{Failed: []string{}, Pending: []string{}}
+	var records []models.SchemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return status, err
+	}
+	applied := make(map[string]bool, len(records))
+	for _, r := range records {
+		if !r.Success {
+			status.Failed = append(status.Failed, r.Version)
+			continue
+		}
+		applied[r.Version] = true
+		status.Applied++
+		if r.Version >= status.Latest {
+			status.Latest = r.Version
+			appliedAt := r.AppliedAt
+			status.LatestAppliedAt = &appliedAt
+		}
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return status, nil
+	}
+	pending, err := pendingMigrations(dir, applied)
+	if err != nil {
+		return status, err
+	}
+	status.Pending = pending
+	status.PendingChecked = true
+	return status, nil
+}
+
+// pendingMigrations returns the versions of the forward SQL migrations in dir
+// that are not in applied, sorted.
+func pendingMigrations(dir string, applied map[string]bool) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	pending := []string{}
+	for _, p := range paths {
+		version := strings.TrimSuffix(filepath.Base(p), ".sql")
+		if strings.HasSuffix(version, "_rollback") || applied[version] {
+			continue
+		}
+		pending = append(pending, version)
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
+// infoField returns a field of a Redis INFO reply.
+func infoField(info, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), field+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
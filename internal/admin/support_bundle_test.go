@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestStoredSettingsRedactsSecrets(t *testing.T) {
+	t.Setenv("APP_NAME", "")
+	t.Setenv("ACCESS_TOKEN_EXPIRATION_MINUTES", "30")
+	got := storedSettings([]models.SystemSetting{
+		{Key: "APP_NAME", Value: "Shop"},
+		{Key: "ACCESS_TOKEN_EXPIRATION_MINUTES", Value: "15"},
+		{Key: "STORAGE_S3_SECRET_ACCESS_KEY", Value: "plaintext-secret"},
+		{Key: "UNKNOWN_KEY", Value: "enc:v1:abc"},
+	})
+	want := []struct{ value, source string }{
+		{"Shop", "db"},
+		{"15", "env"},
+		{config.Redacted, "db"},
+		{config.Redacted, "db"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d settings, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Value != w.value || got[i].Source != w.source {
+			t.Errorf("%s: got %q from %s, want %q from %s", got[i].Key, got[i].Value, got[i].Source, w.value, w.source)
+		}
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20260102_b.sql", "20260101_a.sql", "20260101_a_rollback.sql", "20260103_c.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := pendingMigrations(dir, map[string]bool{"20260102_b": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"20260101_a", "20260103_c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestInfoField(t *testing.T) {
+	info := "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n"
+	if got := infoField(info, "redis_version"); got != "7.2.4" {
+		t.Errorf("redis_version: got %q", got)
+	}
+	if got := infoField(info, "missing"); got != "" {
+		t.Errorf("missing: got %q", got)
+	}
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/passhash"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// JobTypeUserGenerate is the background job type for synthetic load-test users.
+const JobTypeUserGenerate = "user_generate"
+
+// GenerateUsersMaxCount is the maximum number of users one request may generate.
+const GenerateUsersMaxCount = 100000
+
+// Defaults for dto.GenerateUsersRequest.
+const (
+	defaultGenerateBatchSize   = 1000
+	maxGenerateBatchSize       = 5000
+	defaultGenerateEmailPrefix = "loadtest-user-"
+	defaultGenerateEmailDomain = "loadtest.invalid"
+	defaultGeneratePassword    = "LoadTest-Password-1"
+)
+
+// withGenerateDefaults fills in the optional fields of req.
+func withGenerateDefaults(req dto.GenerateUsersRequest) dto.GenerateUsersRequest {
+	if req.BatchSize <= 0 {
+		req.BatchSize = defaultGenerateBatchSize
+	}
+	if req.BatchSize > maxGenerateBatchSize {
+		req.BatchSize = maxGenerateBatchSize
+	}
+	if req.EmailPrefix = strings.ToLower(strings.TrimSpace(req.EmailPrefix)); req.EmailPrefix == "" {
+		req.EmailPrefix = defaultGenerateEmailPrefix
+	}
+	if req.EmailDomain = strings.ToLower(strings.TrimSpace(req.EmailDomain)); req.EmailDomain == "" {
+		req.EmailDomain = defaultGenerateEmailDomain
+	}
+	if req.Password == "" {
+		req.Password = defaultGeneratePassword
+	}
+	return req
+}
+
+// syntheticUserEmail returns the email of the synthetic user with index n.
+func syntheticUserEmail(req dto.GenerateUsersRequest, n int) string {
+	return fmt.Sprintf("%s%d@%s", req.EmailPrefix, n, req.EmailDomain)
+}
+
+// GenerateUsers creates req.Count synthetic users with deterministic emails and
+// a shared password, using one multi-row INSERT per batch. Emails that already
+// exist are skipped, so a generation can be re-run or extended with
+// start_index. The users are active and verified so they can sign in right
+// away; no activity logs or webhooks are produced for them. It stops between
+// batches when ctx is cancelled, returning the partial result and ctx.Err().
+func (r *Repository) GenerateUsers(ctx context.Context, req dto.GenerateUsersRequest, progress func(done, total int)) (dto.GenerateUsersResult, error) {
+	req = withGenerateDefaults(req)
+	result := dto.GenerateUsersResult{
+		Requested:  req.Count,
+		FirstEmail: syntheticUserEmail(req, req.StartIndex),
+		LastEmail:  syntheticUserEmail(req, req.StartIndex+req.Count-1),
+	}
+
+	appUUID, err := uuid.Parse(req.AppID)
+	if err != nil {
+		return result, fmt.Errorf("invalid app_id %q: %w", req.AppID, err)
+	}
+	if err := quota.CheckAppUsers(r.DB, appUUID, req.Count); err != nil {
+		return result, err
+	}
+
+	// All users share the password, so it is hashed once
+	hash, err := passhash.Hash(req.Password)
+	if err != nil {
+		return result, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	for done := 0; done < req.Count; done += req.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if progress != nil && done > 0 {
+			progress(done, req.Count)
+		}
+
+		size := req.BatchSize
+		if remaining := req.Count - done; remaining < size {
+			size = remaining
+		}
+		users := make([]models.User, size)
+		for i := range users {
+			n := req.StartIndex + done + i
+			users[i] = models.User{
+				ID:            uuid.New(),
+				AppID:         appUUID,
+				Email:         syntheticUserEmail(req, n),
+				PasswordHash:  hash,
+				Name:          fmt.Sprintf("Load Test User %d", n),
+				FirstName:     "Load Test",
+				LastName:      fmt.Sprintf("User %d", n),
+				EmailVerified: true,
+				IsActive:      true,
+			}
+		}
+
+		res := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&users)
+		if res.Error != nil {
+			return result, fmt.Errorf("failed to insert users %d-%d: %w", done+1, done+size, res.Error)
+		}
+		result.Created += int(res.RowsAffected)
+		result.Skipped += size - int(res.RowsAffected)
+	}
+	return result, nil
+}
+
+// RunUserGenerateJob generates users for a user_generate job and returns the
+// dto.GenerateUsersResult. Its signature matches jobqueue.HandlerFunc.
+func (r *Repository) RunUserGenerateJob(ctx context.Context, task *jobqueue.Task) (interface{}, error) {
+	var req dto.GenerateUsersRequest
+	if err := task.Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+
+	result, err := r.GenerateUsers(ctx, req, func(done, total int) {
+		task.SetProgress(done*100/total, fmt.Sprintf("%d of %d users processed", done, total))
+	})
+	if err != nil {
+		return nil, err
+	}
+	task.SetSummary(fmt.Sprintf("%d created, %d skipped", result.Created, result.Skipped))
+	return result, nil
+}
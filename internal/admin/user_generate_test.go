@@ -0,0 +1,25 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+func TestWithGenerateDefaults(t *testing.T) {
+	req := withGenerateDefaults(dto.GenerateUsersRequest{Count: 3, StartIndex: 5})
+	if req.BatchSize != defaultGenerateBatchSize || req.Password != defaultGeneratePassword {
+		t.Errorf("defaults not applied: %+v", req)
+	}
+	if got, want := syntheticUserEmail(req, 5), "loadtest-user-5@loadtest.invalid"; got != want {
+		t.Errorf("syntheticUserEmail() = %q, want %q", got, want)
+	}
+
+	req = withGenerateDefaults(dto.GenerateUsersRequest{BatchSize: 1000000, EmailPrefix: " Bench+", EmailDomain: "Example.TEST"})
+	if req.BatchSize != maxGenerateBatchSize {
+		t.Errorf("BatchSize = %d, want capped at %d", req.BatchSize, maxGenerateBatchSize)
+	}
+	if got, want := syntheticUserEmail(req, 42), "bench+42@example.test"; got != want {
+		t.Errorf("syntheticUserEmail() = %q, want %q", got, want)
+	}
+}
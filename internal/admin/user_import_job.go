@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	logService "github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// JobTypeUserImport is the background job type for bulk user imports.
+const JobTypeUserImport = "user_import"
+
+// UserImportPayload is the input of a user_import background job: rows already
+// parsed from the uploaded file, plus the rows that failed parsing so the
+// final result lists them too.
+type UserImportPayload struct {
+	AppID       string                   `json:"app_id"`
+	Rows        []dto.UserImportRow      `json:"rows"`
+	ParseErrors []dto.UserImportRowError `json:"parse_errors,omitempty"`
+	IPAddress   string                   `json:"ip_address,omitempty"` // Admin client, for the USER_REGISTER activity logs
+	UserAgent   string                   `json:"user_agent,omitempty"`
+}
+
+// RunUserImportJob imports users for a user_import job and returns the
+// dto.UserImportResult. Its signature matches jobqueue.HandlerFunc.
+func (r *Repository) RunUserImportJob(ctx context.Context, task *jobqueue.Task) (interface{}, error) {
+	var payload UserImportPayload
+	if err := task.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	appUUID, err := uuid.Parse(payload.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid app_id %q", payload.AppID)
+	}
+
+	lastPercent := -1
+	result, err := r.ImportUsersWithContext(ctx, payload.AppID, payload.Rows, UserImportHooks{
+		Progress: func(done, total int) {
+			// Persist at most once per percent to keep database writes low
+			if percent := done * 100 / total; percent != lastPercent {
+				lastPercent = percent
+				task.SetProgress(percent, fmt.Sprintf("%d of %d rows processed", done, total))
+			}
+		},
+		Created: func(user *models.User) {
+			logService.LogRegister(appUUID, user.ID, payload.IPAddress, payload.UserAgent, user.Email)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse errors come first so row numbers are meaningful
+	result.Errors = append(payload.ParseErrors, result.Errors...)
+	result.Total += len(payload.ParseErrors)
+	task.SetSummary(fmt.Sprintf("%d imported, %d skipped, %d error(s)",
+		result.Imported, result.Skipped, len(result.Errors)-result.Skipped))
+	return result, nil
+}
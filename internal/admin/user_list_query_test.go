@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/internal/search"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB returns a Postgres-dialect DB for building statements with ToSQL; it
+// never connects.
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	return db
+}
+
+func TestUserListQueries(t *testing.T) {
+	db := dryRunDB(t)
+
+	count := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return applyUserListFilters(tx.Model(&models.User{}), "app-id", "jane").Count(new(int64))
+	})
+	if strings.Contains(count, "JOIN") {
+		t.Errorf("count query joins other tables: %s", count)
+	}
+	if !strings.Contains(count, "users.app_id = 'app-id'") || !strings.Contains(count, "users.email ILIKE '%jane%'") {
+		t.Errorf("count query misses the filters: %s", count)
+	}
+	if !strings.Contains(count, "users.is_service_account = false") {
+		t.Errorf("count query includes service accounts: %s", count)
+	}
+
+	list := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return joinUserListDetails(applyUserListFilters(tx.Model(&models.User{}).Select(userListColumns), "app-id", "")).
+			Limit(20).Find(&[]UserListItem{})
+	})
+	if strings.Contains(list, "GROUP BY") {
+		t.Errorf("list query aggregates all social accounts: %s", list)
+	}
+	if !strings.Contains(list, "LEFT JOIN LATERAL") {
+		t.Errorf("list query does not count social accounts per row: %s", list)
+	}
+}
+
+func TestSearchQueries(t *testing.T) {
+	db := dryRunDB(t)
+
+	users := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return applyUserSearch(tx.Model(&models.User{}), "Jane it's").Count(new(int64))
+	})
+	// The expression must match the index definition to use it
+	if !strings.Contains(users, search.UserDocument+" @@ to_tsquery('simple', '''jane'':* & ''it''''s'':*')") {
+		t.Errorf("user search does not use the indexed document: %s", users)
+	}
+
+	logs := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return applyActivityLogSearch(tx.Model(&models.ActivityLog{}), "198.51.100.7").Count(new(int64))
+	})
+	if !strings.Contains(logs, search.ActivityLogDocument+" @@ to_tsquery") {
+		t.Errorf("activity log search does not use the indexed document: %s", logs)
+	}
+
+	plain := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return applyUserSearch(tx.Model(&models.User{}), "@").Count(new(int64))
+	})
+	if strings.Contains(plain, "to_tsquery") || !strings.Contains(plain, "users.email ILIKE '%@%'") {
+		t.Errorf("query without terms should only filter by substring: %s", plain)
+	}
+}
+
+func TestPrunePasswordHistoryQuery(t *testing.T) {
+	db := dryRunDB(t)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return prunePasswordHistory(tx, "app-id", 3)
+	})
+	if !strings.Contains(sql, "jsonb_array_length(password_history) > 3") || !strings.Contains(sql, "WHERE h.n <= 3") {
+		t.Errorf("prune does not keep the 3 most recent hashes: %s", sql)
+	}
+	if strings.Contains(sql, "updated_at") {
+		t.Errorf("prune should not touch updated_at: %s", sql)
+	}
+}
@@ -0,0 +1,304 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+)
+
+// Timeline entry categories besides the activity log event categories
+// (authentication, profile, email, social, ...).
+const (
+	TimelineCategoryAdmin   = "admin"
+	TimelineCategorySession = "session"
+	TimelineCategoryAccount = "account"
+)
+
+// userTimelineSourceLimit caps the entries loaded from each source for one
+// timeline page, so a very active user cannot make the page unbounded.
+const userTimelineSourceLimit = 500
+
+// TimelineEntry is one event on a user's timeline.
+type TimelineEntry struct {
+	Time      time.Time
+	Category  string // Activity log event category, or admin, session or account
+	EventType string // Activity log event type, or the admin request method and route
+	Title     string
+	Detail    string
+	IPAddress string
+	UserAgent string
+	Severity  string
+}
+
+// UserTimeline is a user's merged timeline for a date range, newest first.
+type UserTimeline struct {
+	Entries   []TimelineEntry
+	Truncated bool // A source hit userTimelineSourceLimit; narrow the range to see everything
+}
+
+// GetUserTimeline returns the activity logs of a user (including the failed
+// logins and lockouts recorded by email before the user was identified) and
+// the captured Admin API requests that targeted the user, in [from, to) and
+// newest first. The account creation is included when it falls in the range.
+func (r *Repository) GetUserTimeline(user *UserDetail, from, to time.Time) (*UserTimeline, error) {
+	timeline := &UserTimeline{}
+
+	var logs []models.ActivityLog
+	if err := r.DB.
+		Where("activity_logs.timestamp >= ? AND activity_logs.timestamp < ?", from, to).
+		Where("activity_logs.user_id = ? OR ((activity_logs.user_id IS NULL OR activity_logs.user_id = ?) AND activity_logs.app_id = ? AND activity_logs.details->>'email' = ?)",
+			user.ID, uuid.Nil, user.AppID, user.Email).
+		Order("activity_logs.timestamp DESC").
+		Limit(userTimelineSourceLimit + 1).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	if len(logs) > userTimelineSourceLimit {
+		logs = logs[:userTimelineSourceLimit]
+		timeline.Truncated = true
+	}
+	for i := range logs {
+		timeline.Entries = append(timeline.Entries, activityTimelineEntry(&logs[i]))
+	}
+
+	var requests []models.AdminAuditLog
+	if err := r.DB.Omit("request_body", "response_body").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("path LIKE ?", "%"+user.ID.String()+"%").
+		Order("created_at DESC").
+		Limit(userTimelineSourceLimit + 1).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	if len(requests) > userTimelineSourceLimit {
+		requests = requests[:userTimelineSourceLimit]
+		timeline.Truncated = true
+	}
+	for i := range requests {
+		timeline.Entries = append(timeline.Entries, adminTimelineEntry(&requests[i]))
+	}
+
+	if !user.CreatedAt.Before(from) && user.CreatedAt.Before(to) {
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			Time:     user.CreatedAt,
+			Category: TimelineCategoryAccount,
+			Title:    "Account created",
+			Detail:   "Application: " + user.AppName,
+		})
+	}
+
+	sortTimeline(timeline.Entries)
+	return timeline, nil
+}
+
+// activityTimelineEntry describes an activity log with its event catalog
+// description and category.
+func activityTimelineEntry(l *models.ActivityLog) TimelineEntry {
+	entry := TimelineEntry{
+		Time:      l.Timestamp,
+		Category:  string(config.CategorySecurity),
+		EventType: l.EventType,
+		Title:     l.EventType,
+		Detail:    summarizeTimelineDetails(l.Details),
+		IPAddress: l.IPAddress,
+		UserAgent: l.UserAgent,
+		Severity:  l.Severity,
+	}
+	if def, ok := config.GetEventDefinition(l.EventType); ok {
+		entry.Category = string(def.Category)
+		entry.Title = def.Description
+	}
+	return entry
+}
+
+// adminTimelineEntry describes a captured Admin API request.
+func adminTimelineEntry(a *models.AdminAuditLog) TimelineEntry {
+	by := "Admin API key"
+	if a.ApiKeyID == nil {
+		by = "Static admin API key"
+	}
+	return TimelineEntry{
+		Time:      a.CreatedAt,
+		Category:  TimelineCategoryAdmin,
+		EventType: a.Method + " " + a.Route,
+		Title:     "Admin API request",
+		Detail:    fmt.Sprintf("%s %s returned %d (%s)", a.Method, a.Path, a.StatusCode, by),
+		IPAddress: a.IPAddress,
+		UserAgent: a.UserAgent,
+	}
+}
+
+// summarizeTimelineDetails renders activity log details as "key: value"
+// pairs in key order, skipping nested values and anomaly reasons.
+func summarizeTimelineDetails(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal(raw, &details); err != nil {
+		return ""
+	}
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		switch v := details[k].(type) {
+		case string:
+			if v != "" {
+				parts = append(parts, k+": "+v)
+			}
+		case bool, float64:
+			parts = append(parts, fmt.Sprintf("%s: %v", k, v))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortTimeline orders entries newest first; entries at the same time keep
+// their order.
+func sortTimeline(entries []TimelineEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+}
+
+// filterTimeline keeps the entries of one category; an empty category keeps all.
+func filterTimeline(entries []TimelineEntry, category string) []TimelineEntry {
+	if category == "" {
+		return entries
+	}
+	filtered := make([]TimelineEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Category == category {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// sessionTimelineEntries describes the user's active sessions by their start.
+// Sessions are held in Redis only while they are valid.
+func sessionTimelineEntries(appID, userID string, from, to time.Time) []TimelineEntry {
+	sessionIDs, err := redis.GetUserSessionIDs(appID, userID)
+	if err != nil {
+		return nil
+	}
+	var entries []TimelineEntry
+	for _, sid := range sessionIDs {
+		data, err := redis.GetSession(appID, sid)
+		if err != nil {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, data["created_at"])
+		if err != nil || created.Before(from) || !created.Before(to) {
+			continue
+		}
+		detail := "Still active"
+		if data["last_active"] != "" {
+			detail = "Still active, last seen " + data["last_active"]
+		}
+		entries = append(entries, TimelineEntry{
+			Time:      created,
+			Category:  TimelineCategorySession,
+			Title:     "Session started",
+			Detail:    detail,
+			IPAddress: data["ip"],
+			UserAgent: data["user_agent"],
+		})
+	}
+	return entries
+}
+
+// timelineCategories are the category filter options of the timeline page.
+var timelineCategories = []string{
+	string(config.CategoryAuthentication),
+	string(config.CategoryPassword),
+	string(config.CategoryEmail),
+	string(config.CategoryTwoFactor),
+	string(config.CategorySocial),
+	string(config.CategoryPasskey),
+	string(config.CategoryMagicLink),
+	string(config.CategoryOIDC),
+	string(config.CategoryProfile),
+	string(config.CategorySecurity),
+	TimelineCategorySession,
+	TimelineCategoryAdmin,
+	TimelineCategoryAccount,
+}
+
+// UserTimelinePage renders everything known about one user on a single page:
+// activity (logins, profile changes, emails sent, social links, ...), active
+// sessions and Admin API requests that targeted the user.
+// GET /gui/users/:id/timeline
+func (h *GUIHandler) UserTimelinePage(c *gin.Context) {
+	data := web.TemplateData{
+		Theme:         web.GetTheme(c),
+		ActivePage:    "users",
+		AdminUsername: getAdminUsername(c),
+		AdminID:       getAdminID(c),
+		CSRFToken:     getCSRFToken(c),
+	}
+	detail, err := h.Repo.GetUserDetailByID(c.Param("id"))
+	if err != nil {
+		data.Error = "User not found"
+		c.HTML(http.StatusNotFound, "user_timeline", data)
+		return
+	}
+	from, to, _ := ParseStatsRange("", "", time.Now())
+	data.Data = gin.H{
+		"User":       detail,
+		"From":       from.Format("2006-01-02"),
+		"To":         to.Format("2006-01-02"),
+		"Categories": timelineCategories,
+	}
+	c.HTML(http.StatusOK, "user_timeline", data)
+}
+
+// UserTimelineList renders the timeline entries for the date range and
+// category filter (HTMX fragment).
+// GET /gui/users/:id/timeline/list?from=YYYY-MM-DD&to=YYYY-MM-DD&category=
+func (h *GUIHandler) UserTimelineList(c *gin.Context) {
+	detail, err := h.Repo.GetUserDetailByID(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "user_timeline_list", gin.H{"Error": "User not found"})
+		return
+	}
+	from, to, err := ParseStatsRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.HTML(http.StatusOK, "user_timeline_list", gin.H{"Error": err.Error()})
+		return
+	}
+	// The range is inclusive of the "to" day
+	end := to.AddDate(0, 0, 1)
+
+	timeline, err := h.Repo.GetUserTimeline(detail, from, end)
+	if err != nil {
+		c.HTML(http.StatusOK, "user_timeline_list", gin.H{"Error": "Failed to load the user timeline"})
+		return
+	}
+	timeline.Entries = append(timeline.Entries, sessionTimelineEntries(detail.AppID.String(), detail.ID.String(), from, end)...)
+	sortTimeline(timeline.Entries)
+
+	category := c.Query("category")
+	c.HTML(http.StatusOK, "user_timeline_list", gin.H{
+		"Entries":   filterTimeline(timeline.Entries, category),
+		"Truncated": timeline.Truncated,
+		"Category":  category,
+		"From":      from.Format("2006-01-02"),
+		"To":        to.Format("2006-01-02"),
+	})
+}
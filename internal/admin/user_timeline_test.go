@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestSummarizeTimelineDetails(t *testing.T) {
+	raw := json.RawMessage(`{"provider":"google","email":"a@example.com","anomaly_reasons":["new ip"],"attempts":3,"empty":""}`)
+	if got, want := summarizeTimelineDetails(raw), "attempts: 3, email: a@example.com, provider: google"; got != want {
+		t.Errorf("summarizeTimelineDetails() = %q, want %q", got, want)
+	}
+	if got := summarizeTimelineDetails(json.RawMessage(`not json`)); got != "" {
+		t.Errorf("summarizeTimelineDetails(invalid) = %q, want empty", got)
+	}
+}
+
+func TestActivityTimelineEntry(t *testing.T) {
+	entry := activityTimelineEntry(&models.ActivityLog{EventType: "SOCIAL_ACCOUNT_LINKED", Details: json.RawMessage(`{"provider":"github"}`)})
+	if entry.Category != "social" || entry.Title != "Social account linked" || entry.Detail != "provider: github" {
+		t.Errorf("activityTimelineEntry() = %+v", entry)
+	}
+
+	unknown := activityTimelineEntry(&models.ActivityLog{EventType: "SOMETHING_NEW"})
+	if unknown.Category != "security" || unknown.Title != "SOMETHING_NEW" {
+		t.Errorf("activityTimelineEntry(unknown) = %+v", unknown)
+	}
+}
+
+func TestSortAndFilterTimeline(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	entries := []TimelineEntry{
+		{Time: base, Category: "authentication", Title: "a"},
+		{Time: base.Add(2 * time.Hour), Category: TimelineCategoryAdmin, Title: "b"},
+		{Time: base.Add(time.Hour), Category: "authentication", Title: "c"},
+	}
+	sortTimeline(entries)
+	var titles []string
+	for _, e := range entries {
+		titles = append(titles, e.Title)
+	}
+	if got := strings.Join(titles, ""); got != "bca" {
+		t.Errorf("sorted titles = %s, want bca", got)
+	}
+
+	if got := filterTimeline(entries, ""); len(got) != 3 {
+		t.Errorf("filterTimeline(all) returned %d entries, want 3", len(got))
+	}
+	if got := filterTimeline(entries, "authentication"); len(got) != 2 || got[0].Title != "c" {
+		t.Errorf("filterTimeline(authentication) = %+v", got)
+	}
+}
+
+func TestRenderUserTimelineList(t *testing.T) {
+	c, w := newFragmentContext(t)
+	c.HTML(http.StatusOK, "user_timeline_list", gin.H{
+		"Entries": []TimelineEntry{{
+			Time:      time.Now(),
+			Category:  TimelineCategoryAdmin,
+			EventType: "PUT /admin/users/:id",
+			Title:     "Admin API request",
+			Detail:    `PUT /admin/users/` + uuid.NewString() + ` <script>`,
+			IPAddress: "10.0.0.1",
+		}},
+		"Truncated": true,
+		"From":      "2026-10-01",
+		"To":        "2026-10-16",
+	})
+
+	body := w.Body.String()
+	for _, want := range []string{"Admin API request", "PUT /admin/users/:id", "10.0.0.1", "narrow the date range", "&lt;script&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("timeline is missing %q: %s", want, body)
+		}
+	}
+	if strings.Contains(body, "<script>") {
+		t.Errorf("timeline contains unescaped detail: %s", body)
+	}
+}
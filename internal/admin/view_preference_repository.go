@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ViewPreferenceRepository handles per-admin saved filters and column preferences
+// for the admin GUI list views, and each admin's display preferences.
+type ViewPreferenceRepository struct {
+	DB *gorm.DB
+}
+
+// NewViewPreferenceRepository creates a new ViewPreferenceRepository.
+func NewViewPreferenceRepository(db *gorm.DB) *ViewPreferenceRepository {
+	return &ViewPreferenceRepository{DB: db}
+}
+
+// ListSavedFilters returns an admin's saved filters for a page, ordered by name.
+func (r *ViewPreferenceRepository) ListSavedFilters(adminID uuid.UUID, page string) ([]models.AdminSavedFilter, error) {
+	var filters []models.AdminSavedFilter
+	if err := r.DB.Where("admin_id = ? AND page = ?", adminID, page).
+		Order("name asc").Find(&filters).Error; err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// CreateSavedFilter stores a new saved filter. When the filter is marked as the
+// default, any previous default for the same admin and page is cleared.
+func (r *ViewPreferenceRepository) CreateSavedFilter(filter *models.AdminSavedFilter) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if filter.IsDefault {
+			if err := clearDefaultFilter(tx, filter.AdminID, filter.Page); err != nil {
+				return err
+			}
+		}
+		return tx.Create(filter).Error
+	})
+}
+
+// SetDefaultFilter marks the given filter as the admin's default for its page.
+// Passing uuid.Nil clears the default. Returns gorm.ErrRecordNotFound if the
+// filter does not belong to the admin.
+func (r *ViewPreferenceRepository) SetDefaultFilter(adminID uuid.UUID, page string, filterID uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultFilter(tx, adminID, page); err != nil {
+			return err
+		}
+		if filterID == uuid.Nil {
+			return nil
+		}
+		res := tx.Model(&models.AdminSavedFilter{}).
+			Where("id = ? AND admin_id = ? AND page = ?", filterID, adminID, page).
+			Updates(map[string]interface{}{"is_default": true, "updated_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteSavedFilter removes one of the admin's saved filters.
+// Returns gorm.ErrRecordNotFound if the filter does not belong to the admin.
+func (r *ViewPreferenceRepository) DeleteSavedFilter(adminID, filterID uuid.UUID) error {
+	res := r.DB.Where("id = ? AND admin_id = ?", filterID, adminID).Delete(&models.AdminSavedFilter{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetHiddenColumns returns the comma-separated hidden column keys for an admin
+// and page. Returns an empty string if no preference has been saved.
+func (r *ViewPreferenceRepository) GetHiddenColumns(adminID uuid.UUID, page string) (string, error) {
+	var pref models.AdminColumnPreference
+	if err := r.DB.First(&pref, "admin_id = ? AND page = ?", adminID, page).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return pref.HiddenColumns, nil
+}
+
+// UpsertHiddenColumns saves the hidden column keys for an admin and page.
+func (r *ViewPreferenceRepository) UpsertHiddenColumns(adminID uuid.UUID, page, hidden string) error {
+	pref := models.AdminColumnPreference{
+		AdminID:       adminID,
+		Page:          page,
+		HiddenColumns: hidden,
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "admin_id"}, {Name: "page"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hidden_columns", "updated_at"}),
+	}).Create(&pref).Error
+}
+
+// GetPreferences returns an admin's display preferences. Returns the zero value
+// (with AdminID set) if none have been saved.
+func (r *ViewPreferenceRepository) GetPreferences(adminID uuid.UUID) (*models.AdminPreference, error) {
+	pref := models.AdminPreference{AdminID: adminID}
+	if err := r.DB.First(&pref, "admin_id = ?", adminID).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+// UpsertPreferences saves all of an admin's display preferences.
+func (r *ViewPreferenceRepository) UpsertPreferences(pref *models.AdminPreference) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "admin_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"theme", "page_size", "timezone", "locale", "language", "updated_at"}),
+	}).Create(pref).Error
+}
+
+// UpsertTheme saves an admin's theme, leaving the other preferences unchanged.
+func (r *ViewPreferenceRepository) UpsertTheme(adminID uuid.UUID, theme string) error {
+	pref := models.AdminPreference{AdminID: adminID, Theme: theme}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "admin_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"theme", "updated_at"}),
+	}).Create(&pref).Error
+}
+
+// LoadPreferences returns an admin's display preferences for rendering.
+// It implements web.PreferencesLoader.
+func (r *ViewPreferenceRepository) LoadPreferences(adminID string) (web.Preferences, error) {
+	id, err := uuid.Parse(adminID)
+	if err != nil {
+		return web.Preferences{}, err
+	}
+	pref, err := r.GetPreferences(id)
+	if err != nil {
+		return web.Preferences{}, err
+	}
+	return web.Preferences{
+		Theme:    pref.Theme,
+		PageSize: pref.PageSize,
+		Timezone: pref.Timezone,
+		Locale:   pref.Locale,
+		Language: pref.Language,
+	}, nil
+}
+
+func clearDefaultFilter(tx *gorm.DB, adminID uuid.UUID, page string) error {
+	return tx.Model(&models.AdminSavedFilter{}).
+		Where("admin_id = ? AND page = ? AND is_default = ?", adminID, page, true).
+		Update("is_default", false).Error
+}
@@ -0,0 +1,238 @@
+// Package botdetect screens registrations for bots and automated sign-ups.
+// Each registration is assessed with a few heuristics — a honeypot field, the
+// time between loading the form and submitting it, registration velocity per
+// IP address and per network (ASN), and an optional external fraud score —
+// and receives a decision: allow, challenge (solve a CAPTCHA) or deny.
+package botdetect
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Decisions.
+const (
+	DecisionAllow     = "allow"
+	DecisionChallenge = "challenge"
+	DecisionDeny      = "deny"
+)
+
+// Reasons recorded with a decision.
+const (
+	ReasonHoneypot         = "honeypot"
+	ReasonFormTokenMissing = "form_token_missing"
+	ReasonFormTokenInvalid = "form_token_invalid"
+	ReasonSubmittedTooFast = "submitted_too_fast"
+	ReasonIPVelocity       = "ip_velocity"
+	ReasonASNVelocity      = "asn_velocity"
+	ReasonFraudScore       = "fraud_score"
+)
+
+// formTokenMaxAge is how long a form token is accepted after it was issued.
+const formTokenMaxAge = time.Hour
+
+// Signals describes a registration attempt.
+type Signals struct {
+	AppID     uuid.UUID
+	Email     string
+	IPAddress string
+	UserAgent string
+	Honeypot  string // Value of the honeypot field; humans leave it empty
+	FormToken string // Token from GET /register/form-token, issued when the form was shown
+}
+
+// Assessment is the outcome of screening a registration.
+type Assessment struct {
+	Decision   string
+	Reasons    []string
+	FraudScore *float64 // Set when a fraud scorer answered
+	ASN        uint     // Set when the ASN of the IP address is known
+}
+
+// Details returns the assessment as activity log details.
+func (a Assessment) Details() map[string]interface{} {
+	details := map[string]interface{}{
+		"decision": a.Decision,
+		"reasons":  a.Reasons,
+	}
+	if a.FraudScore != nil {
+		details["fraud_score"] = *a.FraudScore
+	}
+	if a.ASN != 0 {
+		details["asn"] = a.ASN
+	}
+	return details
+}
+
+// ASNLookupFunc resolves an IP address to its autonomous system number (0 when unknown).
+type ASNLookupFunc func(ipAddress string) (uint, string)
+
+// Config holds the screening settings.
+type Config struct {
+	Enabled        bool
+	MinSubmitTime  time.Duration // 0 disables the time-to-submit check
+	MaxPerIP       int           // Registrations per IP address per VelocityWindow; 0 disables
+	MaxPerASN      int           // Registrations per ASN per VelocityWindow; 0 disables
+	VelocityWindow time.Duration
+	ChallengeScore float64 // Fraud scores at or above this are challenged
+	DenyScore      float64 // Fraud scores at or above this are denied
+}
+
+// LoadConfig reads the REGISTRATION_BOT_* settings.
+func LoadConfig() Config {
+	window := time.Duration(viper.GetInt("REGISTRATION_VELOCITY_WINDOW_MINUTES")) * time.Minute
+	if window <= 0 {
+		window = time.Hour
+	}
+	return Config{
+		Enabled:        viper.GetBool("REGISTRATION_BOT_DETECTION_ENABLED"),
+		MinSubmitTime:  time.Duration(viper.GetInt("REGISTRATION_MIN_SUBMIT_SECONDS")) * time.Second,
+		MaxPerIP:       viper.GetInt("REGISTRATION_MAX_PER_IP"),
+		MaxPerASN:      viper.GetInt("REGISTRATION_MAX_PER_ASN"),
+		VelocityWindow: window,
+		ChallengeScore: viper.GetFloat64("REGISTRATION_FRAUD_CHALLENGE_SCORE"),
+		DenyScore:      viper.GetFloat64("REGISTRATION_FRAUD_DENY_SCORE"),
+	}
+}
+
+// Detector screens registrations. All methods are safe to call on a nil
+// *Detector (screening disabled).
+type Detector struct {
+	Config Config
+	Scorer FraudScorer   // Optional external fraud score
+	ASN    ASNLookupFunc // Optional; enables the per-ASN velocity check
+
+	secret []byte
+}
+
+// NewDetector creates a detector from configuration. Form tokens are signed
+// with secret.
+func NewDetector(cfg Config, secret string) *Detector {
+	return &Detector{Config: cfg, secret: []byte(secret)}
+}
+
+// Enabled reports whether registrations are screened.
+func (d *Detector) Enabled() bool {
+	return d != nil && d.Config.Enabled
+}
+
+// IssueFormToken returns a token recording when the registration form of
+// appID was shown. Clients send it back with the registration.
+func (d *Detector) IssueFormToken(appID uuid.UUID) string {
+	if d == nil {
+		return ""
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	return ts + "." + d.sign(appID, ts)
+}
+
+func (d *Detector) sign(appID uuid.UUID, ts string) string {
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write([]byte("registration_form|" + appID.String() + "|" + ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// formAge returns how long ago the form token was issued.
+func (d *Detector) formAge(appID uuid.UUID, token string) (time.Duration, error) {
+	ts, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(d.sign(appID, ts))) {
+		return 0, fmt.Errorf("invalid form token")
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid form token")
+	}
+	age := time.Since(time.Unix(unix, 0))
+	if age < 0 || age > formTokenMaxAge {
+		return 0, fmt.Errorf("expired form token")
+	}
+	return age, nil
+}
+
+// Assess screens a registration attempt. The attempt counts towards the
+// velocity limits whatever the decision. Failures of Redis or the fraud
+// scorer are logged and skipped, so screening never blocks registration on
+// its own errors.
+func (d *Detector) Assess(ctx context.Context, s Signals) Assessment {
+	a := Assessment{Decision: DecisionAllow, Reasons: []string{}}
+	if !d.Enabled() {
+		return a
+	}
+	cfg := d.Config
+
+	if strings.TrimSpace(s.Honeypot) != "" {
+		a.add(DecisionDeny, ReasonHoneypot)
+	}
+
+	if cfg.MinSubmitTime > 0 {
+		if s.FormToken == "" {
+			a.add(DecisionChallenge, ReasonFormTokenMissing)
+		} else if age, err := d.formAge(s.AppID, s.FormToken); err != nil {
+			a.add(DecisionChallenge, ReasonFormTokenInvalid)
+		} else if age < cfg.MinSubmitTime {
+			a.add(DecisionChallenge, ReasonSubmittedTooFast)
+		}
+	}
+
+	if cfg.MaxPerIP > 0 && s.IPAddress != "" {
+		if n, err := redis.IncrementRegistrationVelocity(s.AppID.String(), "ip", s.IPAddress, cfg.VelocityWindow); err != nil {
+			log.Printf("Warning: registration velocity check failed: %v", err)
+		} else if n > int64(cfg.MaxPerIP) {
+			a.add(DecisionChallenge, ReasonIPVelocity)
+		}
+	}
+
+	if d.ASN != nil && s.IPAddress != "" {
+		a.ASN, _ = d.ASN(s.IPAddress)
+	}
+	if cfg.MaxPerASN > 0 && a.ASN != 0 {
+		if n, err := redis.IncrementRegistrationVelocity(s.AppID.String(), "asn", strconv.FormatUint(uint64(a.ASN), 10), cfg.VelocityWindow); err != nil {
+			log.Printf("Warning: registration velocity check failed: %v", err)
+		} else if n > int64(cfg.MaxPerASN) {
+			a.add(DecisionChallenge, ReasonASNVelocity)
+		}
+	}
+
+	if d.Scorer != nil {
+		score, err := d.Scorer.Score(ctx, ScoreRequest{
+			AppID:     s.AppID.String(),
+			Email:     s.Email,
+			IPAddress: s.IPAddress,
+			UserAgent: s.UserAgent,
+			ASN:       a.ASN,
+			Reasons:   append([]string(nil), a.Reasons...),
+		})
+		if err != nil {
+			log.Printf("Warning: registration fraud scoring failed: %v", err)
+		} else {
+			a.FraudScore = &score
+			switch {
+			case cfg.DenyScore > 0 && score >= cfg.DenyScore:
+				a.add(DecisionDeny, ReasonFraudScore)
+			case cfg.ChallengeScore > 0 && score >= cfg.ChallengeScore:
+				a.add(DecisionChallenge, ReasonFraudScore)
+			}
+		}
+	}
+	return a
+}
+
+// add records a reason and raises the decision: deny outranks challenge,
+// which outranks allow.
+func (a *Assessment) add(decision, reason string) {
+	a.Reasons = append(a.Reasons, reason)
+	if decision == DecisionDeny || (decision == DecisionChallenge && a.Decision == DecisionAllow) {
+		a.Decision = decision
+	}
+}
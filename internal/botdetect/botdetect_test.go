@@ -0,0 +1,90 @@
+package botdetect
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAssessHeuristics(t *testing.T) {
+	appID := uuid.New()
+	d := NewDetector(Config{Enabled: true, MinSubmitTime: 3 * time.Second}, "test-secret")
+	fresh := d.IssueFormToken(appID)
+	ts := strconv.FormatInt(time.Now().Add(-10*time.Second).Unix(), 10)
+	old := ts + "." + d.sign(appID, ts)
+
+	tests := []struct {
+		name         string
+		signals      Signals
+		wantDecision string
+		wantReason   string
+	}{
+		{"human", Signals{AppID: appID, FormToken: old}, DecisionAllow, ""},
+		{"honeypot", Signals{AppID: appID, FormToken: old, Honeypot: "http://spam.example"}, DecisionDeny, ReasonHoneypot},
+		{"too fast", Signals{AppID: appID, FormToken: fresh}, DecisionChallenge, ReasonSubmittedTooFast},
+		{"no form token", Signals{AppID: appID}, DecisionChallenge, ReasonFormTokenMissing},
+		{"forged form token", Signals{AppID: appID, FormToken: ts + ".deadbeef"}, DecisionChallenge, ReasonFormTokenInvalid},
+		{"token of another app", Signals{AppID: uuid.New(), FormToken: old}, DecisionChallenge, ReasonFormTokenInvalid},
+		{"honeypot outranks challenge", Signals{AppID: appID, Honeypot: "x"}, DecisionDeny, ReasonHoneypot},
+	}
+	for _, tc := range tests {
+		a := d.Assess(context.Background(), tc.signals)
+		if a.Decision != tc.wantDecision {
+			t.Errorf("%s: decision = %s, want %s (reasons %v)", tc.name, a.Decision, tc.wantDecision, a.Reasons)
+		}
+		if tc.wantReason != "" && !contains(a.Reasons, tc.wantReason) {
+			t.Errorf("%s: reasons = %v, want %s", tc.name, a.Reasons, tc.wantReason)
+		}
+	}
+
+	var disabled *Detector
+	if a := disabled.Assess(context.Background(), Signals{Honeypot: "x"}); a.Decision != DecisionAllow {
+		t.Errorf("nil detector decision = %s, want allow", a.Decision)
+	}
+}
+
+func TestAssessFraudScore(t *testing.T) {
+	score := "50"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"score": ` + score + `}`))
+	}))
+	defer srv.Close()
+
+	d := NewDetector(Config{Enabled: true, ChallengeScore: 70, DenyScore: 90}, "test-secret")
+	d.Scorer = &HTTPScorer{URL: srv.URL, Token: "token", Client: srv.Client()}
+
+	for _, tc := range []struct {
+		score string
+		want  string
+	}{{"50", DecisionAllow}, {"75", DecisionChallenge}, {"95", DecisionDeny}} {
+		score = tc.score
+		a := d.Assess(context.Background(), Signals{AppID: uuid.New()})
+		if a.Decision != tc.want || a.FraudScore == nil {
+			t.Errorf("score %s: decision = %s (score %v), want %s", tc.score, a.Decision, a.FraudScore, tc.want)
+		}
+	}
+
+	// A failing scorer does not block registration
+	d.Scorer = &HTTPScorer{URL: srv.URL, Client: srv.Client()}
+	if a := d.Assess(context.Background(), Signals{AppID: uuid.New()}); a.Decision != DecisionAllow || a.FraudScore != nil {
+		t.Errorf("failing scorer: %+v, want allow without score", a)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
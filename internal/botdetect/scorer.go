@@ -0,0 +1,89 @@
+package botdetect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ScoreRequest is what a fraud scorer is asked about.
+type ScoreRequest struct {
+	AppID     string   `json:"app_id"`
+	Email     string   `json:"email"`
+	IPAddress string   `json:"ip_address"`
+	UserAgent string   `json:"user_agent"`
+	ASN       uint     `json:"asn,omitempty"`
+	Reasons   []string `json:"reasons"` // Heuristics that already fired
+}
+
+// FraudScorer is the integration point for external fraud scoring services.
+// Score returns a risk score from 0 (legitimate) to 100 (fraudulent).
+type FraudScorer interface {
+	Score(ctx context.Context, req ScoreRequest) (float64, error)
+}
+
+// HTTPScorer asks an HTTP endpoint for the score: it POSTs the ScoreRequest
+// as JSON and expects {"score": <0-100>} back.
+type HTTPScorer struct {
+	URL    string
+	Token  string // Sent as a bearer token when set
+	Client *http.Client
+}
+
+// NewHTTPScorerFromConfig returns the scorer configured by
+// REGISTRATION_FRAUD_SCORE_URL, or nil when none is configured.
+func NewHTTPScorerFromConfig() FraudScorer {
+	url := viper.GetString("REGISTRATION_FRAUD_SCORE_URL")
+	if url == "" {
+		return nil
+	}
+	timeout := time.Duration(viper.GetInt("REGISTRATION_FRAUD_SCORE_TIMEOUT_MS")) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &HTTPScorer{
+		URL:    url,
+		Token:  viper.GetString("REGISTRATION_FRAUD_SCORE_TOKEN"),
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Score implements FraudScorer.
+func (s *HTTPScorer) Score(ctx context.Context, req ScoreRequest) (float64, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("fraud score service returned status %d", resp.StatusCode)
+	}
+	var result struct {
+		Score *float64 `json:"score"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return 0, fmt.Errorf("invalid fraud score response: %w", err)
+	}
+	if result.Score == nil {
+		return 0, fmt.Errorf("fraud score response has no score")
+	}
+	return *result.Score, nil
+}
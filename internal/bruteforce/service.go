@@ -131,8 +131,20 @@ func parseDurations(s string) []time.Duration {
 	return durations
 }
 
+// AccountKey normalizes a login email into the identifier of the per-account
+// counters, so that varying the case or padding of the address does not start
+// a fresh set of counters.
+func AccountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 // Service orchestrates all brute-force protection features:
 // account lockout, progressive delays, and CAPTCHA triggering.
+//
+// Failed logins are counted per account (the normalized email) and per client
+// IP. The account counters are what stop attackers rotating IPs: the lockout,
+// the CAPTCHA challenge and the enforced login delay all follow the account,
+// whichever IP the attempt comes from.
 type Service struct {
 	DB *gorm.DB
 }
@@ -174,16 +186,19 @@ func (s *Service) IsAccountLocked(user *models.User) (bool, *time.Time, error) {
 }
 
 // HandleFailedLogin processes a failed login attempt for lockout purposes.
-// It increments the failure counter and, if the threshold is reached, locks the account.
+// It increments the account's failure counter and, if the threshold is reached, locks the account.
 // Returns: wasLocked bool, lockExpiresAt *time.Time, failCount int64, error.
 // The failCount is returned so callers can pass it to anomaly detection for notifications.
-func (s *Service) HandleFailedLogin(appID uuid.UUID, email string, cfg BruteForceConfig) (bool, *time.Time, int64, error) {
+func (s *Service) HandleFailedLogin(appID uuid.UUID, email, ipAddress string, cfg BruteForceConfig) (bool, *time.Time, int64, error) {
+	account := AccountKey(email)
+
 	// Always increment the failure counter so it is tracked even if lockout is disabled.
 	// This counter is shared with anomaly detection (same Redis key pattern).
-	count, err := redis.IncrFailedLogin(appID.String(), email, cfg.LockoutWindow)
+	count, err := redis.IncrFailedLogin(appID.String(), account, cfg.LockoutWindow)
 	if err != nil {
 		return false, nil, 0, fmt.Errorf("failed to increment failed login count: %w", err)
 	}
+	_ = redis.SetLastFailedLoginIP(appID.String(), account, ipAddress, cfg.LockoutWindow)
 
 	if !cfg.LockoutEnabled {
 		return false, nil, count, nil
@@ -194,14 +209,14 @@ func (s *Service) HandleFailedLogin(appID uuid.UUID, email string, cfg BruteForc
 	}
 
 	// Threshold reached — lock the account
-	wasLocked, expiresAt, lockErr := s.lockAccount(appID, email, cfg)
+	wasLocked, expiresAt, lockErr := s.lockAccount(appID, account, cfg)
 	return wasLocked, expiresAt, count, lockErr
 }
 
 // lockAccount applies a lockout to the user with escalating duration.
-func (s *Service) lockAccount(appID uuid.UUID, email string, cfg BruteForceConfig) (bool, *time.Time, error) {
+func (s *Service) lockAccount(appID uuid.UUID, account string, cfg BruteForceConfig) (bool, *time.Time, error) {
 	// Get and increment the lockout tier
-	tier, err := redis.IncrLockoutTier(appID.String(), email, cfg.LockoutTierTTL)
+	tier, err := redis.IncrLockoutTier(appID.String(), account, cfg.LockoutTierTTL)
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to increment lockout tier: %w", err)
 	}
@@ -220,7 +235,7 @@ func (s *Service) lockAccount(appID uuid.UUID, email string, cfg BruteForceConfi
 
 	// Update user record in DB
 	result := s.DB.Model(&models.User{}).
-		Where("app_id = ? AND email = ?", appID, email).
+		Where("app_id = ? AND LOWER(email) = ?", appID, account).
 		Updates(map[string]interface{}{
 			"locked_at":       now,
 			"lock_reason":     reason,
@@ -231,7 +246,7 @@ func (s *Service) lockAccount(appID uuid.UUID, email string, cfg BruteForceConfi
 	}
 
 	// Reset the failure counter so the next window starts fresh after unlock
-	_ = redis.ResetFailedLogins(appID.String(), email)
+	_ = redis.ResetFailedLogins(appID.String(), account)
 
 	return true, &expiresAt, nil
 }
@@ -250,9 +265,12 @@ func (s *Service) UnlockAccount(appID uuid.UUID, userID uuid.UUID, email string)
 	}
 
 	// Reset Redis counters
-	_ = redis.ResetLockoutTier(appID.String(), email)
-	_ = redis.ResetFailedLogins(appID.String(), email)
-	_ = redis.ResetDelayTier(appID.String(), email)
+	account := AccountKey(email)
+	_ = redis.ResetLockoutTier(appID.String(), account)
+	_ = redis.ResetFailedLogins(appID.String(), account)
+	_ = redis.ResetDelayTier(appID.String(), account)
+	_ = redis.ResetAccountLoginDelay(appID.String(), account)
+	_ = redis.ResetLastFailedLoginIP(appID.String(), account)
 
 	return nil
 }
@@ -261,7 +279,9 @@ func (s *Service) UnlockAccount(appID uuid.UUID, userID uuid.UUID, email string)
 // This resets delay tiers for both the email and IP identifiers.
 func (s *Service) ResetOnSuccess(appID uuid.UUID, email, ipAddress string) {
 	appIDStr := appID.String()
-	_ = redis.ResetDelayTier(appIDStr, email)
+	account := AccountKey(email)
+	_ = redis.ResetDelayTier(appIDStr, account)
+	_ = redis.ResetAccountLoginDelay(appIDStr, account)
 	_ = redis.ResetDelayTier(appIDStr, ipAddress)
 	// Note: failed login counter and lockout tier are NOT reset on success.
 	// The failed login counter is reset by the anomaly detector.
@@ -281,7 +301,7 @@ func (s *Service) GetDelay(appID uuid.UUID, email, ipAddress string, cfg BruteFo
 
 	appIDStr := appID.String()
 
-	emailTier, err := redis.GetDelayTier(appIDStr, email)
+	emailTier, err := redis.GetDelayTier(appIDStr, AccountKey(email))
 	if err != nil {
 		return 0, err
 	}
@@ -297,31 +317,57 @@ func (s *Service) GetDelay(appID uuid.UUID, email, ipAddress string, cfg BruteFo
 		tier = ipTier
 	}
 
-	// No delay if below the start threshold
+	return delayForTier(tier, cfg), nil
+}
+
+// delayForTier returns the delay in seconds for a delay tier: none below
+// DelayStartAfter, then 2^(tier - startAfter) seconds, capped at DelayMaxSeconds.
+func delayForTier(tier int64, cfg BruteForceConfig) int {
 	if tier < int64(cfg.DelayStartAfter) {
-		return 0, nil
+		return 0
 	}
-
-	// Calculate exponential delay: 2^(tier - startAfter) seconds, capped at max
 	exponent := tier - int64(cfg.DelayStartAfter)
+	if exponent > 30 {
+		return cfg.DelayMaxSeconds
+	}
 	delay := int(math.Pow(2, float64(exponent)))
 	if delay > cfg.DelayMaxSeconds {
 		delay = cfg.DelayMaxSeconds
 	}
-
-	return delay, nil
+	return delay
 }
 
-// IncrementDelayTier increments the delay tier for both email and IP after a failed login.
+// IncrementDelayTier increments the delay tier for both the account and the IP
+// after a failed login. Once the account tier calls for a delay, further login
+// attempts for the account are refused until it has passed (see AccountRetryAfter).
 func (s *Service) IncrementDelayTier(appID uuid.UUID, email, ipAddress string, cfg BruteForceConfig) {
 	if !cfg.DelayEnabled {
 		return
 	}
 	appIDStr := appID.String()
-	_, _ = redis.IncrDelayTier(appIDStr, email, cfg.DelayTierTTL)
+	account := AccountKey(email)
+	if tier, err := redis.IncrDelayTier(appIDStr, account, cfg.DelayTierTTL); err == nil {
+		if delay := delayForTier(tier, cfg); delay > 0 {
+			_ = redis.SetAccountLoginDelay(appIDStr, account, time.Duration(delay)*time.Second)
+		}
+	}
 	_, _ = redis.IncrDelayTier(appIDStr, ipAddress, cfg.DelayTierTTL)
 }
 
+// AccountRetryAfter returns how many seconds the account must wait before the
+// next login attempt is processed (0 = no wait). The wait applies regardless of
+// the client IP, so rotating IPs does not speed up guessing.
+func (s *Service) AccountRetryAfter(appID uuid.UUID, email string, cfg BruteForceConfig) (int, error) {
+	if !cfg.DelayEnabled {
+		return 0, nil
+	}
+	remaining, err := redis.GetAccountLoginDelay(appID.String(), AccountKey(email))
+	if err != nil || remaining <= 0 {
+		return 0, err
+	}
+	return int(math.Ceil(remaining.Seconds())), nil
+}
+
 // ==================== CAPTCHA Triggering ====================
 
 // IsCaptchaRequired checks whether CAPTCHA should be required for this login attempt.
@@ -331,10 +377,57 @@ func (s *Service) IsCaptchaRequired(appID uuid.UUID, email string, cfg BruteForc
 		return false, nil
 	}
 
-	count, err := redis.GetFailedLoginCount(appID.String(), email)
+	count, err := redis.GetFailedLoginCount(appID.String(), AccountKey(email))
 	if err != nil {
 		return false, err
 	}
 
 	return count >= int64(cfg.CaptchaThreshold), nil
 }
+
+// ==================== Admin Visibility ====================
+
+// ThrottleStatus is a snapshot of the brute-force counters of an account,
+// shown to admins on the user detail.
+type ThrottleStatus struct {
+	// Per-account counters
+	FailedAttempts int64 `json:"failed_attempts"` // Failures in the current lockout window
+	DelayTier      int64 `json:"delay_tier"`
+	RetryAfter     int   `json:"retry_after"` // Seconds until the account accepts another attempt
+	LockoutTier    int64 `json:"lockout_tier"`
+	// Per-IP counter of the client behind the latest failure
+	LastFailedIP string `json:"last_failed_ip,omitempty"`
+	IPDelayTier  int64  `json:"ip_delay_tier"`
+}
+
+// GetThrottleStatus reads the account and IP counters for a user.
+func (s *Service) GetThrottleStatus(appID uuid.UUID, email string) (*ThrottleStatus, error) {
+	appIDStr := appID.String()
+	account := AccountKey(email)
+
+	var status ThrottleStatus
+	var err error
+	if status.FailedAttempts, err = redis.GetFailedLoginCount(appIDStr, account); err != nil {
+		return nil, err
+	}
+	if status.DelayTier, err = redis.GetDelayTier(appIDStr, account); err != nil {
+		return nil, err
+	}
+	if status.LockoutTier, err = redis.GetLockoutTier(appIDStr, account); err != nil {
+		return nil, err
+	}
+	remaining, err := redis.GetAccountLoginDelay(appIDStr, account)
+	if err != nil {
+		return nil, err
+	}
+	status.RetryAfter = int(math.Ceil(remaining.Seconds()))
+	if status.LastFailedIP, err = redis.GetLastFailedLoginIP(appIDStr, account); err != nil {
+		return nil, err
+	}
+	if status.LastFailedIP != "" {
+		if status.IPDelayTier, err = redis.GetDelayTier(appIDStr, status.LastFailedIP); err != nil {
+			return nil, err
+		}
+	}
+	return &status, nil
+}
@@ -0,0 +1,21 @@
+package bruteforce
+
+import "testing"
+
+func TestAccountKey(t *testing.T) {
+	for _, email := range []string{"user@example.com", "User@Example.COM", "  user@example.com "} {
+		if got := AccountKey(email); got != "user@example.com" {
+			t.Errorf("AccountKey(%q) = %q, want one counter per account", email, got)
+		}
+	}
+}
+
+func TestDelayForTier(t *testing.T) {
+	cfg := ResolveConfig(nil) // Delays start after 2 failures, capped at 16s
+	cases := map[int64]int{0: 0, 1: 0, 2: 1, 3: 2, 5: 8, 6: 16, 7: 16, 100: 16}
+	for tier, want := range cases {
+		if got := delayForTier(tier, cfg); got != want {
+			t.Errorf("delayForTier(%d) = %d, want %d", tier, got, want)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Package claimmap maps the claims of an external identity (the user payload
+// returned by a social or OIDC provider) to user fields, roles and groups.
+//
+// A mapping is a JSON array of rules, configured per provider:
+//
+//	[
+//	  {"claim": "given_name", "target": "user.first_name", "transforms": ["trim"]},
+//	  {"claim": "hd", "target": "role", "value": "staff", "when": {"claim": "hd", "op": "equals", "value": "example.com"}},
+//	  {"claim": "teams", "target": "group", "transforms": ["lowercase", "prefix:gh-"]},
+//	  {"claim": "company", "target": "group", "map": {"@acme": "acme"}}
+//	]
+//
+// A rule reads the claim at a dot-separated path (arrays yield one value per
+// element), applies its transforms in order, looks each value up in its map
+// (values missing from a non-empty map are dropped) and assigns the result to
+// its target. A rule with a constant value assigns that value instead of the
+// claim. A rule with a condition only applies when the condition holds.
+package claimmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Targets.
+const (
+	TargetRole  = "role"
+	TargetGroup = "group"
+	// Prefix of user field targets, e.g. "user.first_name"
+	TargetUserPrefix = "user."
+)
+
+// UserFields are the user fields a rule can target (after the "user." prefix).
+var UserFields = []string{"name", "first_name", "last_name", "profile_picture", "locale"}
+
+// Condition operators.
+const (
+	OpExists     = "exists"
+	OpEquals     = "equals"
+	OpNotEquals  = "not_equals"
+	OpContains   = "contains"
+	OpStartsWith = "starts_with"
+	OpEndsWith   = "ends_with"
+	OpIn         = "in"
+	OpMatches    = "matches"
+)
+
+// maxRules bounds the size of a mapping.
+const maxRules = 100
+
+// Rule maps one claim to a target.
+type Rule struct {
+	Claim      string            `json:"claim"`                // Dot-separated path into the claims, e.g. "picture.data.url"
+	Target     string            `json:"target"`               // "user.<field>", "role" or "group"
+	Value      string            `json:"value,omitempty"`      // Constant value to assign instead of the claim
+	Transforms []string          `json:"transforms,omitempty"` // Applied in order, see applyTransform
+	Map        map[string]string `json:"map,omitempty"`        // Value lookup table applied after the transforms
+	When       *Condition        `json:"when,omitempty"`       // Optional condition
+}
+
+// Condition restricts a rule to identities whose claim satisfies it.
+type Condition struct {
+	Claim  string   `json:"claim"`
+	Op     string   `json:"op"`
+	Value  string   `json:"value,omitempty"`  // Operand of every operator except exists and in
+	Values []string `json:"values,omitempty"` // Operand of in
+
+	re *regexp.Regexp // Compiled Value of matches
+}
+
+// Mapping is a validated list of rules.
+type Mapping struct {
+	Rules []Rule
+}
+
+// Result is what a mapping assigns to an identity.
+type Result struct {
+	Fields map[string]string // User field (without the "user." prefix) to value
+	Roles  []string
+	Groups []string
+}
+
+// Parse parses and validates a JSON mapping. An empty or null mapping yields a
+// mapping without rules.
+func Parse(data []byte) (*Mapping, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" || trimmed == "null" {
+		return &Mapping{}, nil
+	}
+	var rules []Rule
+	if err := json.Unmarshal([]byte(trimmed), &rules); err != nil {
+		return nil, fmt.Errorf("claim mappings must be a JSON array of rules: %w", err)
+	}
+	if len(rules) > maxRules {
+		return nil, fmt.Errorf("claim mappings: at most %d rules are allowed", maxRules)
+	}
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("claim mappings: rule %d: %w", i+1, err)
+		}
+	}
+	return &Mapping{Rules: rules}, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Claim == "" && r.Value == "" {
+		return fmt.Errorf("claim or value is required")
+	}
+	switch {
+	case r.Target == TargetRole, r.Target == TargetGroup:
+	case strings.HasPrefix(r.Target, TargetUserPrefix):
+		if !isUserField(strings.TrimPrefix(r.Target, TargetUserPrefix)) {
+			return fmt.Errorf("unknown user field %q (allowed: %s)", r.Target, strings.Join(UserFields, ", "))
+		}
+	default:
+		return fmt.Errorf("unknown target %q (allowed: user.<field>, role, group)", r.Target)
+	}
+	for _, t := range r.Transforms {
+		if err := validateTransform(t); err != nil {
+			return err
+		}
+	}
+	if r.When != nil {
+		return r.When.validate()
+	}
+	return nil
+}
+
+func (c *Condition) validate() error {
+	if c.Claim == "" {
+		return fmt.Errorf("condition claim is required")
+	}
+	switch c.Op {
+	case OpExists, OpEquals, OpNotEquals, OpContains, OpStartsWith, OpEndsWith:
+	case OpIn:
+		if len(c.Values) == 0 {
+			return fmt.Errorf("condition op in requires values")
+		}
+	case OpMatches:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return fmt.Errorf("condition op matches: invalid pattern: %w", err)
+		}
+		c.re = re
+	default:
+		return fmt.Errorf("unknown condition op %q", c.Op)
+	}
+	return nil
+}
+
+func isUserField(field string) bool {
+	for _, f := range UserFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// MapsGroups reports whether the mapping has group rules, i.e. whether it
+// manages the groups of the users it is applied to.
+func (m *Mapping) MapsGroups() bool {
+	if m == nil {
+		return false
+	}
+	for _, r := range m.Rules {
+		if r.Target == TargetGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply evaluates the mapping against the claims. For user fields the last
+// matching rule wins; roles and groups are collected without duplicates.
+func (m *Mapping) Apply(claims map[string]interface{}) Result {
+	res := Result{Fields: map[string]string{}}
+	if m == nil {
+		return res
+	}
+	for _, r := range m.Rules {
+		if r.When != nil && !r.When.holds(claims) {
+			continue
+		}
+		var values []string
+		if r.Value != "" {
+			values = []string{r.Value}
+		} else {
+			values = Lookup(claims, r.Claim)
+		}
+		values = r.convert(values)
+		if len(values) == 0 {
+			continue
+		}
+		switch r.Target {
+		case TargetRole:
+			res.Roles = appendUnique(res.Roles, values...)
+		case TargetGroup:
+			res.Groups = appendUnique(res.Groups, values...)
+		default:
+			res.Fields[strings.TrimPrefix(r.Target, TargetUserPrefix)] = values[0]
+		}
+	}
+	return res
+}
+
+// convert applies the transforms and the map of the rule to values and drops
+// empty results.
+func (r *Rule) convert(values []string) []string {
+	for _, t := range r.Transforms {
+		var next []string
+		for _, v := range values {
+			next = append(next, applyTransform(t, v)...)
+		}
+		values = next
+	}
+	out := values[:0]
+	for _, v := range values {
+		if len(r.Map) > 0 {
+			mapped, ok := r.Map[v]
+			if !ok {
+				continue
+			}
+			v = mapped
+		}
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (c *Condition) holds(claims map[string]interface{}) bool {
+	values := Lookup(claims, c.Claim)
+	if c.Op == OpExists {
+		return len(values) > 0
+	}
+	if c.Op == OpNotEquals {
+		for _, v := range values {
+			if strings.EqualFold(v, c.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		switch c.Op {
+		case OpEquals:
+			if strings.EqualFold(v, c.Value) {
+				return true
+			}
+		case OpContains:
+			if strings.Contains(strings.ToLower(v), strings.ToLower(c.Value)) {
+				return true
+			}
+		case OpStartsWith:
+			if strings.HasPrefix(strings.ToLower(v), strings.ToLower(c.Value)) {
+				return true
+			}
+		case OpEndsWith:
+			if strings.HasSuffix(strings.ToLower(v), strings.ToLower(c.Value)) {
+				return true
+			}
+		case OpIn:
+			for _, want := range c.Values {
+				if strings.EqualFold(v, want) {
+					return true
+				}
+			}
+		case OpMatches:
+			re := c.re
+			if re == nil {
+				re = regexp.MustCompile(c.Value)
+			}
+			if re.MatchString(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Lookup returns the string values of the claim at a dot-separated path.
+// Arrays yield one value per element; numbers and booleans are formatted;
+// missing claims, nulls and objects yield nothing.
+func Lookup(claims map[string]interface{}, path string) []string {
+	var current []interface{}
+	current = append(current, claims)
+	for _, key := range strings.Split(path, ".") {
+		var next []interface{}
+		for _, node := range current {
+			obj, ok := node.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			v, ok := obj[key]
+			if !ok {
+				continue
+			}
+			if arr, ok := v.([]interface{}); ok {
+				next = append(next, arr...)
+			} else {
+				next = append(next, v)
+			}
+		}
+		current = next
+	}
+
+	var out []string
+	for _, v := range current {
+		switch val := v.(type) {
+		case string:
+			if val != "" {
+				out = append(out, val)
+			}
+		case float64, bool, json.Number:
+			out = append(out, fmt.Sprint(val))
+		}
+	}
+	return out
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,105 @@
+package claimmap
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testClaims = `{
+	"email": "Jane.Doe@Example.com",
+	"given_name": "  Jane ",
+	"hd": "example.com",
+	"teams": ["Platform", "Security"],
+	"departments": "eng, ops",
+	"company": "@acme",
+	"picture": {"data": {"url": "https://cdn.example.com/jane.png"}},
+	"verified": true
+}`
+
+func parseClaims(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var claims map[string]interface{}
+	if err := json.Unmarshal([]byte(testClaims), &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestApply(t *testing.T) {
+	m, err := Parse([]byte(`[
+		{"claim": "given_name", "target": "user.first_name", "transforms": ["trim"]},
+		{"claim": "picture.data.url", "target": "user.profile_picture"},
+		{"claim": "email", "target": "user.name", "transforms": ["email_local", "replace:.= ", "uppercase"]},
+		{"target": "role", "value": "staff", "when": {"claim": "hd", "op": "equals", "value": "EXAMPLE.com"}},
+		{"target": "role", "value": "contractor", "when": {"claim": "hd", "op": "not_equals", "value": "example.com"}},
+		{"target": "role", "value": "verified", "when": {"claim": "verified", "op": "in", "values": ["true"]}},
+		{"claim": "teams", "target": "group", "transforms": ["lowercase", "prefix:gh-"]},
+		{"claim": "departments", "target": "group", "transforms": ["split:,"]},
+		{"claim": "company", "target": "group", "map": {"@acme": "acme", "@other": "other"}},
+		{"claim": "teams", "target": "role", "map": {"Security": "security-admin"}},
+		{"target": "role", "value": "never", "when": {"claim": "missing", "op": "exists"}}
+	]`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	res := m.Apply(parseClaims(t))
+
+	wantFields := map[string]string{
+		"first_name":      "Jane",
+		"profile_picture": "https://cdn.example.com/jane.png",
+		"name":            "JANE DOE",
+	}
+	if !reflect.DeepEqual(res.Fields, wantFields) {
+		t.Errorf("Fields = %v, want %v", res.Fields, wantFields)
+	}
+	if want := []string{"staff", "verified", "security-admin"}; !reflect.DeepEqual(res.Roles, want) {
+		t.Errorf("Roles = %v, want %v", res.Roles, want)
+	}
+	if want := []string{"gh-platform", "gh-security", "eng", "ops", "acme"}; !reflect.DeepEqual(res.Groups, want) {
+		t.Errorf("Groups = %v, want %v", res.Groups, want)
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"not an array", `{"claim": "email"}`},
+		{"unknown target", `[{"claim": "email", "target": "tenant"}]`},
+		{"unknown user field", `[{"claim": "email", "target": "user.email"}]`},
+		{"no claim or value", `[{"target": "role"}]`},
+		{"unknown transform", `[{"claim": "email", "target": "group", "transforms": ["reverse"]}]`},
+		{"transform without argument", `[{"claim": "email", "target": "group", "transforms": ["prefix:"]}]`},
+		{"unknown op", `[{"target": "role", "value": "x", "when": {"claim": "hd", "op": "like"}}]`},
+		{"in without values", `[{"target": "role", "value": "x", "when": {"claim": "hd", "op": "in"}}]`},
+		{"invalid pattern", `[{"target": "role", "value": "x", "when": {"claim": "hd", "op": "matches", "value": "("}}]`},
+	}
+	for _, tc := range tests {
+		if _, err := Parse([]byte(tc.json)); err == nil {
+			t.Errorf("%s: Parse succeeded, want error", tc.name)
+		}
+	}
+
+	for _, empty := range []string{"", " ", "null", "[]"} {
+		m, err := Parse([]byte(empty))
+		if err != nil || len(m.Rules) != 0 {
+			t.Errorf("Parse(%q) = %v, %v; want no rules", empty, m, err)
+		}
+	}
+}
+
+func TestConditionMatches(t *testing.T) {
+	m, err := Parse([]byte(`[{"target": "group", "value": "corp", "when": {"claim": "email", "op": "matches", "value": "(?i)@example\\.com$"}}]`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if res := m.Apply(parseClaims(t)); !reflect.DeepEqual(res.Groups, []string{"corp"}) {
+		t.Errorf("Groups = %v, want [corp]", res.Groups)
+	}
+	if res := m.Apply(map[string]interface{}{"email": "jane@other.org"}); len(res.Groups) != 0 {
+		t.Errorf("Groups = %v, want none", res.Groups)
+	}
+}
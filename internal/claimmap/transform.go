@@ -0,0 +1,89 @@
+package claimmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transforms. Transforms with an argument are written "name:argument".
+//
+//	lowercase, uppercase, trim     change case / strip surrounding whitespace
+//	email_local, email_domain      the part of an email address before / after the @
+//	prefix:<s>, suffix:<s>         prepend / append s
+//	split:<sep>                    split into several values (e.g. a comma-separated claim)
+//	replace:<old>=<new>            replace every occurrence of old with new
+const (
+	TransformLowercase   = "lowercase"
+	TransformUppercase   = "uppercase"
+	TransformTrim        = "trim"
+	TransformEmailLocal  = "email_local"
+	TransformEmailDomain = "email_domain"
+	TransformPrefix      = "prefix"
+	TransformSuffix      = "suffix"
+	TransformSplit       = "split"
+	TransformReplace     = "replace"
+)
+
+func splitTransform(t string) (name, arg string) {
+	name, arg, _ = strings.Cut(t, ":")
+	return name, arg
+}
+
+func validateTransform(t string) error {
+	name, arg := splitTransform(t)
+	switch name {
+	case TransformLowercase, TransformUppercase, TransformTrim, TransformEmailLocal, TransformEmailDomain:
+		return nil
+	case TransformPrefix, TransformSuffix, TransformSplit:
+		if arg == "" {
+			return fmt.Errorf("transform %s requires an argument (%s:<value>)", name, name)
+		}
+		return nil
+	case TransformReplace:
+		if old, _, ok := strings.Cut(arg, "="); !ok || old == "" {
+			return fmt.Errorf("transform replace requires an argument (replace:<old>=<new>)")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown transform %q", t)
+}
+
+// applyTransform applies a validated transform to v. Split may yield several
+// values, the others exactly one.
+func applyTransform(t, v string) []string {
+	name, arg := splitTransform(t)
+	switch name {
+	case TransformLowercase:
+		v = strings.ToLower(v)
+	case TransformUppercase:
+		v = strings.ToUpper(v)
+	case TransformTrim:
+		v = strings.TrimSpace(v)
+	case TransformEmailLocal:
+		if at := strings.LastIndex(v, "@"); at >= 0 {
+			v = v[:at]
+		}
+	case TransformEmailDomain:
+		if at := strings.LastIndex(v, "@"); at >= 0 {
+			v = v[at+1:]
+		} else {
+			v = ""
+		}
+	case TransformPrefix:
+		v = arg + v
+	case TransformSuffix:
+		v += arg
+	case TransformSplit:
+		var out []string
+		for _, part := range strings.Split(v, arg) {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+		return out
+	case TransformReplace:
+		old, repl, _ := strings.Cut(arg, "=")
+		v = strings.ReplaceAll(v, old, repl)
+	}
+	return []string{v}
+}
@@ -0,0 +1,137 @@
+package config
+
+// EventCategory groups related activity log event types.
+type EventCategory string
+
+const (
+	CategoryAuthentication EventCategory = "authentication"
+	CategoryPassword       EventCategory = "password"
+	CategoryEmail          EventCategory = "email"
+	CategoryTwoFactor      EventCategory = "two_factor"
+	CategorySocial         EventCategory = "social"
+	CategoryPasskey        EventCategory = "passkey"
+	CategoryMagicLink      EventCategory = "magic_link"
+	CategoryOIDC           EventCategory = "oidc"
+	CategoryProfile        EventCategory = "profile"
+	CategorySecurity       EventCategory = "security"
+)
+
+// RetentionClass selects how long logs of an event type are kept. Each class
+// uses one of the LOG_RETENTION_* settings.
+type RetentionClass string
+
+const (
+	RetentionLong     RetentionClass = "long"     // LOG_RETENTION_CRITICAL (default 365 days)
+	RetentionStandard RetentionClass = "standard" // LOG_RETENTION_IMPORTANT (default 180 days)
+	RetentionShort    RetentionClass = "short"    // LOG_RETENTION_INFORMATIONAL (default 90 days)
+)
+
+// EventDefinition describes an activity log event type.
+type EventDefinition struct {
+	Type           string         `json:"type"`
+	Category       EventCategory  `json:"category"`
+	Severity       EventSeverity  `json:"severity"`        // Default severity (anomalies may raise it)
+	Retention      RetentionClass `json:"retention_class"` // How long logs are kept
+	DefaultEnabled bool           `json:"default_enabled"` // Logged unless disabled by LOG_DISABLED_EVENTS
+	UserVisible    bool           `json:"user_visible"`    // Shown in the user's security events feed
+	Description    string         `json:"description"`     // User-facing, English
+}
+
+// eventCatalog lists every event type the services log, grouped by category.
+// The constants in internal/log must match these types.
+var eventCatalog = []EventDefinition{
+	// Authentication
+	{Type: "LOGIN", Category: CategoryAuthentication, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Signed in with email and password"},
+	{Type: "LOGIN_FAILED", Category: CategoryAuthentication, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "Failed sign-in attempt"},
+	{Type: "LOGOUT", Category: CategoryAuthentication, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, Description: "Signed out"},
+	{Type: "REGISTER", Category: CategoryAuthentication, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, Description: "Account created"},
+	{Type: "REGISTRATION_APPROVED", Category: CategoryAuthentication, Severity: SeverityImportant, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Registration approved by an administrator"},
+	{Type: "REGISTRATION_REJECTED", Category: CategoryAuthentication, Severity: SeverityImportant, Retention: RetentionLong, DefaultEnabled: true, Description: "Registration rejected by an administrator"},
+	{Type: "TOKEN_REFRESH", Category: CategoryAuthentication, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: false, Description: "Session refreshed"},
+	{Type: "REAUTH", Category: CategoryAuthentication, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "Identity confirmed for a sensitive action"},
+	{Type: "REAUTH_FAILED", Category: CategoryAuthentication, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "Failed attempt to confirm identity"},
+
+	// Password management
+	{Type: "PASSWORD_CHANGE", Category: CategoryPassword, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Password changed"},
+	{Type: "PASSWORD_RESET", Category: CategoryPassword, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Password reset via email link"},
+
+	// Email
+	{Type: "EMAIL_VERIFY", Category: CategoryEmail, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, Description: "Email address verified"},
+	{Type: "EMAIL_VERIFY_RESEND", Category: CategoryEmail, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, Description: "Verification email sent again"},
+	{Type: "EMAIL_CHANGE", Category: CategoryEmail, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Email address changed"},
+	{Type: "EMAIL_SENT", Category: CategoryEmail, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, Description: "Email sent to the user"},
+	{Type: "EMAIL_PREVIEW", Category: CategoryEmail, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, Description: "Email template previewed with the user's data by an administrator"},
+
+	// Two-factor authentication
+	{Type: "2FA_ENABLE", Category: CategoryTwoFactor, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Two-factor authentication turned on"},
+	{Type: "2FA_DISABLE", Category: CategoryTwoFactor, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Two-factor authentication turned off"},
+	{Type: "2FA_LOGIN", Category: CategoryTwoFactor, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "Signed in with a second factor"},
+	{Type: "RECOVERY_CODE_USED", Category: CategoryTwoFactor, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Recovery code used to sign in"},
+	{Type: "RECOVERY_CODE_GENERATE", Category: CategoryTwoFactor, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "New recovery codes generated"},
+
+	// Social authentication
+	{Type: "SOCIAL_LOGIN", Category: CategorySocial, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "Signed in with a social account"},
+	{Type: "SOCIAL_ACCOUNT_LINKED", Category: CategorySocial, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, UserVisible: true, Description: "Social account linked"},
+	{Type: "SOCIAL_ACCOUNT_UNLINKED", Category: CategorySocial, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, UserVisible: true, Description: "Social account unlinked"},
+
+	// Passkeys
+	{Type: "PASSKEY_REGISTER", Category: CategoryPasskey, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, UserVisible: true, Description: "Passkey added"},
+	{Type: "PASSKEY_DELETE", Category: CategoryPasskey, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, UserVisible: true, Description: "Passkey removed"},
+	{Type: "PASSKEY_LOGIN", Category: CategoryPasskey, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, UserVisible: true, Description: "Signed in with a passkey"},
+
+	// Magic links
+	{Type: "MAGIC_LINK_REQUESTED", Category: CategoryMagicLink, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, Description: "Sign-in link requested"},
+	{Type: "MAGIC_LINK_LOGIN", Category: CategoryMagicLink, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, UserVisible: true, Description: "Signed in with an email link"},
+	{Type: "MAGIC_LINK_FAILED", Category: CategoryMagicLink, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, Description: "Invalid or expired sign-in link used"},
+
+	// OIDC
+	{Type: "OIDC_LOGIN", Category: CategoryOIDC, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, UserVisible: true, Description: "Signed in to a connected application"},
+	{Type: "OIDC_TOKEN_EXCHANGE", Category: CategoryOIDC, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: true, Description: "Connected application acted on your behalf"},
+	{Type: "OIDC_GRANT_REVOKED", Category: CategoryOIDC, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "Removed a connected application's access"},
+
+	// Profile and account
+	{Type: "PROFILE_ACCESS", Category: CategoryProfile, Severity: SeverityInformational, Retention: RetentionShort, DefaultEnabled: false, Description: "Profile viewed"},
+	{Type: "PROFILE_UPDATE", Category: CategoryProfile, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, Description: "Profile updated"},
+	{Type: "ACCOUNT_DELETION", Category: CategoryProfile, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, Description: "Account deleted"},
+
+	// Security
+	{Type: "BRUTE_FORCE_DETECTED", Category: CategorySecurity, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, Description: "Repeated failed sign-in attempts detected"},
+	{Type: "IP_BLOCKED", Category: CategorySecurity, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, Description: "Access blocked from this network or location"},
+	{Type: "ACCOUNT_LOCKED", Category: CategorySecurity, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Account locked after failed sign-in attempts"},
+	{Type: "ACCOUNT_UNLOCKED", Category: CategorySecurity, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Account unlocked"},
+	{Type: "USER_IMPERSONATED", Category: CategorySecurity, Severity: SeverityCritical, Retention: RetentionLong, DefaultEnabled: true, UserVisible: true, Description: "Support staff signed in as you"},
+	{Type: "REGISTRATION_SCREENED", Category: CategorySecurity, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, Description: "Registration checked for automated sign-up"},
+	{Type: "LINK_REPLAYED", Category: CategorySecurity, Severity: SeverityImportant, Retention: RetentionStandard, DefaultEnabled: true, UserVisible: true, Description: "Used or cancelled password reset or verification link opened again"},
+}
+
+// eventDefinitions indexes eventCatalog by type.
+var eventDefinitions = func() map[string]EventDefinition {
+	m := make(map[string]EventDefinition, len(eventCatalog))
+	for _, def := range eventCatalog {
+		m[def.Type] = def
+	}
+	return m
+}()
+
+// EventCatalog returns all event definitions, in catalog order.
+func EventCatalog() []EventDefinition {
+	return append([]EventDefinition(nil), eventCatalog...)
+}
+
+// UserVisibleEventTypes returns the event types shown to users in their
+// security events feed (GET /profile/security-events), in catalog order.
+func UserVisibleEventTypes() []string {
+	var types []string
+	for _, def := range eventCatalog {
+		if def.UserVisible {
+			types = append(types, def.Type)
+		}
+	}
+	return types
+}
+
+// GetEventDefinition returns the definition of an event type.
+func GetEventDefinition(eventType string) (EventDefinition, bool) {
+	def, ok := eventDefinitions[eventType]
+	return def, ok
+}
@@ -0,0 +1,337 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// FileSetting maps a key of the startup config file to the environment
+// variable it provides. The rest of the code keeps reading the environment
+// variable (through viper or os.Getenv), so the file needs no other wiring.
+type FileSetting struct {
+	Key    string // Dotted path in the file, e.g. "server.port"
+	EnvVar string // Environment variable, e.g. "PORT"
+	Secret bool   // Redacted by PrintEffectiveConfig
+}
+
+// FileSchema lists every setting accepted in the config file, by section.
+var FileSchema = []FileSetting{
+	// Server
+	{Key: "server.port", EnvVar: "PORT"},
+	{Key: "server.gin_mode", EnvVar: "GIN_MODE"},
+	{Key: "server.app_name", EnvVar: "APP_NAME"},
+	{Key: "server.public_url", EnvVar: "PUBLIC_URL"},
+	{Key: "server.frontend_url", EnvVar: "FRONTEND_URL"},
+	{Key: "server.admin_url", EnvVar: "ADMIN_URL"},
+	{Key: "server.admin_listen_addr", EnvVar: "ADMIN_LISTEN_ADDR"},
+	{Key: "server.request_max_body_bytes", EnvVar: "REQUEST_MAX_BODY_BYTES"},
+	{Key: "server.request_max_upload_bytes", EnvVar: "REQUEST_MAX_UPLOAD_BYTES"},
+	{Key: "server.request_max_headers", EnvVar: "REQUEST_MAX_HEADERS"},
+	{Key: "server.request_max_field_length", EnvVar: "REQUEST_MAX_FIELD_LENGTH"},
+	{Key: "server.request_max_name_length", EnvVar: "REQUEST_MAX_NAME_LENGTH"},
+	{Key: "server.request_max_description_length", EnvVar: "REQUEST_MAX_DESCRIPTION_LENGTH"},
+	{Key: "server.tls_cert_file", EnvVar: "TLS_CERT_FILE"},
+	{Key: "server.tls_key_file", EnvVar: "TLS_KEY_FILE"},
+	{Key: "server.tls_autocert_domains", EnvVar: "TLS_AUTOCERT_DOMAINS"},
+	{Key: "server.tls_autocert_email", EnvVar: "TLS_AUTOCERT_EMAIL"},
+	{Key: "server.tls_autocert_cache_dir", EnvVar: "TLS_AUTOCERT_CACHE_DIR"},
+	{Key: "server.tls_redirect_addr", EnvVar: "TLS_REDIRECT_ADDR"},
+	{Key: "server.http2_enabled", EnvVar: "HTTP2_ENABLED"},
+	{Key: "server.gui_dev_mode", EnvVar: "GUI_DEV_MODE"},
+	{Key: "server.gui_web_dir", EnvVar: "GUI_WEB_DIR"},
+	{Key: "server.cors_allowed_origins", EnvVar: "CORS_ALLOWED_ORIGINS"},
+	{Key: "server.cors_allowed_methods", EnvVar: "CORS_ALLOWED_METHODS"},
+	{Key: "server.cors_allowed_headers", EnvVar: "CORS_ALLOWED_HEADERS"},
+	{Key: "server.cors_expose_headers", EnvVar: "CORS_EXPOSE_HEADERS"},
+	{Key: "server.cors_max_age_hours", EnvVar: "CORS_MAX_AGE_HOURS"},
+	{Key: "server.cors_allow_credentials", EnvVar: "CORS_ALLOW_CREDENTIALS"},
+	{Key: "server.startup_retry_initial_interval_ms", EnvVar: "STARTUP_RETRY_INITIAL_INTERVAL_MS"},
+	{Key: "server.startup_retry_max_interval_seconds", EnvVar: "STARTUP_RETRY_MAX_INTERVAL_SECONDS"},
+	{Key: "server.startup_retry_max_wait_seconds", EnvVar: "STARTUP_RETRY_MAX_WAIT_SECONDS"},
+
+	// Database
+	{Key: "db.host", EnvVar: "DB_HOST"},
+	{Key: "db.port", EnvVar: "DB_PORT"},
+	{Key: "db.user", EnvVar: "DB_USER"},
+	{Key: "db.password", EnvVar: "DB_PASSWORD", Secret: true},
+	{Key: "db.name", EnvVar: "DB_NAME"},
+
+	// Redis
+	{Key: "redis.addr", EnvVar: "REDIS_ADDR"},
+	{Key: "redis.password", EnvVar: "REDIS_PASSWORD", Secret: true},
+	{Key: "redis.db", EnvVar: "REDIS_DB"},
+	{Key: "redis.notify_keyspace_events", EnvVar: "REDIS_NOTIFY_KEYSPACE_EVENTS"},
+	{Key: "redis.region", EnvVar: "REGION"},
+	{Key: "redis.replication_grace_seconds", EnvVar: "REGION_REPLICATION_GRACE_SECONDS"},
+
+	// JWT and one-time tokens
+	{Key: "jwt.secret", EnvVar: "JWT_SECRET", Secret: true},
+	{Key: "jwt.access_token_expiration_minutes", EnvVar: "ACCESS_TOKEN_EXPIRATION_MINUTES"},
+	{Key: "jwt.refresh_token_expiration_hours", EnvVar: "REFRESH_TOKEN_EXPIRATION_HOURS"},
+	{Key: "jwt.issuer", EnvVar: "JWT_ISSUER"},
+	{Key: "jwt.audience", EnvVar: "JWT_AUDIENCE"},
+	{Key: "jwt.signing_algorithm", EnvVar: "JWT_SIGNING_ALGORITHM"},
+	{Key: "jwt.accept_hs256", EnvVar: "JWT_ACCEPT_HS256"},
+	{Key: "jwt.key_rotation_days", EnvVar: "JWT_KEY_ROTATION_DAYS"},
+	{Key: "jwt.per_app_keys", EnvVar: "JWT_PER_APP_KEYS"},
+	{Key: "jwt.email_verification_token_ttl_minutes", EnvVar: "EMAIL_VERIFICATION_TOKEN_TTL_MINUTES"},
+	{Key: "jwt.password_reset_token_ttl_minutes", EnvVar: "PASSWORD_RESET_TOKEN_TTL_MINUTES"},
+
+	// Email (SMTP servers themselves are configured in the admin GUI)
+	{Key: "email.smtp_pool_max_connections", EnvVar: "SMTP_POOL_MAX_CONNECTIONS"},
+	{Key: "email.smtp_pool_idle_timeout_seconds", EnvVar: "SMTP_POOL_IDLE_TIMEOUT_SECONDS"},
+	{Key: "email.batch_max_recipients", EnvVar: "EMAIL_BATCH_MAX_RECIPIENTS"},
+	{Key: "email.batch_max_rate_per_second", EnvVar: "EMAIL_BATCH_MAX_RATE_PER_SECOND"},
+	{Key: "email.idempotency_window_hours", EnvVar: "EMAIL_IDEMPOTENCY_WINDOW_HOURS"},
+	{Key: "email.admin_email", EnvVar: "ADMIN_EMAIL"},
+
+	// Security
+	{Key: "security.admin_api_key", EnvVar: "ADMIN_API_KEY", Secret: true},
+	{Key: "security.admin_session_expiration_hours", EnvVar: "ADMIN_SESSION_EXPIRATION_HOURS"},
+	{Key: "security.admin_bootstrap_token", EnvVar: "ADMIN_BOOTSTRAP_TOKEN", Secret: true},
+	{Key: "security.admin_bootstrap_mtls_addr", EnvVar: "ADMIN_BOOTSTRAP_MTLS_ADDR"},
+	{Key: "security.admin_bootstrap_tls_cert", EnvVar: "ADMIN_BOOTSTRAP_TLS_CERT"},
+	{Key: "security.admin_bootstrap_tls_key", EnvVar: "ADMIN_BOOTSTRAP_TLS_KEY"},
+	{Key: "security.admin_bootstrap_client_ca", EnvVar: "ADMIN_BOOTSTRAP_CLIENT_CA"},
+	{Key: "security.admin_audit_capture_enabled", EnvVar: "ADMIN_AUDIT_CAPTURE_ENABLED"},
+	{Key: "security.admin_audit_max_body_bytes", EnvVar: "ADMIN_AUDIT_MAX_BODY_BYTES"},
+	{Key: "security.admin_audit_skip_routes", EnvVar: "ADMIN_AUDIT_SKIP_ROUTES"},
+	{Key: "security.admin_audit_retention_days", EnvVar: "ADMIN_AUDIT_RETENTION_DAYS"},
+	{Key: "security.allowed_redirect_domains", EnvVar: "ALLOWED_REDIRECT_DOMAINS"},
+	{Key: "security.trusted_device_cookie_samesite", EnvVar: "TRUSTED_DEVICE_COOKIE_SAMESITE"},
+	{Key: "security.cookie_force_secure", EnvVar: "COOKIE_FORCE_SECURE"},
+	{Key: "security.password_hash_algorithm", EnvVar: "PASSWORD_HASH_ALGORITHM"},
+	{Key: "security.password_bcrypt_cost", EnvVar: "PASSWORD_BCRYPT_COST"},
+	{Key: "security.password_argon2_memory_kb", EnvVar: "PASSWORD_ARGON2_MEMORY_KB"},
+	{Key: "security.password_argon2_iterations", EnvVar: "PASSWORD_ARGON2_ITERATIONS"},
+	{Key: "security.password_argon2_parallelism", EnvVar: "PASSWORD_ARGON2_PARALLELISM"},
+	{Key: "security.password_rehash_on_login", EnvVar: "PASSWORD_REHASH_ON_LOGIN"},
+	{Key: "security.settings_encryption_key", EnvVar: "SETTINGS_ENCRYPTION_KEY", Secret: true},
+	{Key: "security.webauthn_rp_id", EnvVar: "WEBAUTHN_RP_ID"},
+	{Key: "security.webauthn_rp_name", EnvVar: "WEBAUTHN_RP_NAME"},
+	{Key: "security.webauthn_rp_origins", EnvVar: "WEBAUTHN_RP_ORIGINS"},
+	{Key: "security.geoip_db_path", EnvVar: "GEOIP_DB_PATH"},
+	{Key: "security.oidc_enabled", EnvVar: "OIDC_ENABLED"},
+	{Key: "security.oidc_default_app_id", EnvVar: "OIDC_DEFAULT_APP_ID"},
+	{Key: "security.oauth_default_app_id", EnvVar: "OAUTH_DEFAULT_APP_ID"},
+	{Key: "security.oidc_id_token_expiration_minutes", EnvVar: "OIDC_ID_TOKEN_EXPIRATION_MINUTES"},
+	{Key: "security.oidc_auth_code_expiration_minutes", EnvVar: "OIDC_AUTH_CODE_EXPIRATION_MINUTES"},
+
+	// File storage (profile pictures, export archives, archived activity logs)
+	{Key: "storage.backend", EnvVar: "STORAGE_BACKEND"},
+	{Key: "storage.local_path", EnvVar: "STORAGE_LOCAL_PATH"},
+	{Key: "storage.s3_endpoint", EnvVar: "STORAGE_S3_ENDPOINT"},
+	{Key: "storage.s3_region", EnvVar: "STORAGE_S3_REGION"},
+	{Key: "storage.s3_bucket", EnvVar: "STORAGE_S3_BUCKET"},
+	{Key: "storage.s3_access_key_id", EnvVar: "STORAGE_S3_ACCESS_KEY_ID"},
+	{Key: "storage.s3_secret_access_key", EnvVar: "STORAGE_S3_SECRET_ACCESS_KEY", Secret: true},
+	{Key: "storage.s3_force_path_style", EnvVar: "STORAGE_S3_FORCE_PATH_STYLE"},
+	{Key: "storage.gcs_bucket", EnvVar: "STORAGE_GCS_BUCKET"},
+	{Key: "storage.gcs_credentials_json", EnvVar: "STORAGE_GCS_CREDENTIALS_JSON", Secret: true},
+}
+
+// DefaultConfigFiles are tried, in order, when no config file is given.
+var DefaultConfigFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// FindConfigFile returns path if set, else the first of DefaultConfigFiles
+// that exists in the working directory, else "" (no config file).
+func FindConfigFile(path string) string {
+	if path != "" {
+		return path
+	}
+	for _, name := range DefaultConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// fileEnv holds the environment variables LoadConfigFile set, with their
+// values, so that ReloadConfigFile can tell them from the real environment.
+var (
+	fileEnvMu sync.Mutex
+	fileEnv   = map[string]string{}
+)
+
+// LoadConfigFile reads a YAML or TOML config file (by extension) and exports
+// its settings as environment variables. Variables that are already set keep
+// their value, so the environment (and .env) overrides the file. Keys outside
+// FileSchema are rejected to catch typos. An empty path is a no-op.
+func LoadConfigFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	fileEnvMu.Lock()
+	defer fileEnvMu.Unlock()
+	for _, s := range FileSchema {
+		value, ok := values[s.EnvVar]
+		if !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(s.EnvVar); set {
+			continue
+		}
+		if err := os.Setenv(s.EnvVar, value); err != nil {
+			return err
+		}
+		fileEnv[s.EnvVar] = value
+	}
+	return nil
+}
+
+// ReloadConfigFile reads the config file again and updates the environment
+// variables it provides: changed values are exported, settings removed from
+// the file are unset. Variables set in the real environment still win, and so
+// do variables changed in the process since the file set them. It returns the
+// names of the variables that changed. On error nothing is changed.
+func ReloadConfigFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fileEnvMu.Lock()
+	defer fileEnvMu.Unlock()
+
+	var changed []string
+	for envVar, old := range fileEnv {
+		if current, ok := os.LookupEnv(envVar); !ok || current != old {
+			delete(fileEnv, envVar) // Overridden since; no longer the file's
+			continue
+		}
+		value, ok := values[envVar]
+		switch {
+		case !ok:
+			if err := os.Unsetenv(envVar); err != nil {
+				return changed, err
+			}
+			delete(fileEnv, envVar)
+		case value != old:
+			if err := os.Setenv(envVar, value); err != nil {
+				return changed, err
+			}
+			fileEnv[envVar] = value
+		default:
+			continue
+		}
+		changed = append(changed, envVar)
+	}
+	for envVar, value := range values {
+		if _, ours := fileEnv[envVar]; ours {
+			continue
+		}
+		if _, set := os.LookupEnv(envVar); set {
+			continue
+		}
+		if err := os.Setenv(envVar, value); err != nil {
+			return changed, err
+		}
+		fileEnv[envVar] = value
+		changed = append(changed, envVar)
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// readConfigFile reads a config file and returns the environment variable
+// values of the settings it sets.
+func readConfigFile(path string) (map[string]string, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	known := make(map[string]FileSetting, len(FileSchema))
+	for _, s := range FileSchema {
+		known[s.Key] = s
+	}
+	var unknown []string
+	for _, key := range v.AllKeys() {
+		if _, ok := known[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown settings in config file %s: %s", path, strings.Join(unknown, ", "))
+	}
+
+	values := make(map[string]string)
+	for _, s := range FileSchema {
+		if v.IsSet(s.Key) {
+			values[s.EnvVar] = fileValue(v.Get(s.Key))
+		}
+	}
+	return values, nil
+}
+
+// fileValue converts a config file value to its environment variable form;
+// lists become comma-separated.
+func fileValue(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// Redacted replaces secret values in the effective configuration.
+const Redacted = "[REDACTED]"
+
+// EffectiveValue is the resolved value of a FileSchema setting.
+type EffectiveValue struct {
+	Key    string // Dotted path in the file
+	EnvVar string
+	Value  string // Redacted for secrets that are set
+}
+
+// EffectiveConfig returns the resolved value of every FileSchema setting
+// (environment, config file or default), with secrets redacted.
+func EffectiveConfig() []EffectiveValue {
+	values := make([]EffectiveValue, 0, len(FileSchema))
+	for _, s := range FileSchema {
+		value := viper.GetString(s.EnvVar)
+		if s.Secret && value != "" {
+			value = Redacted
+		}
+		values = append(values, EffectiveValue{Key: s.Key, EnvVar: s.EnvVar, Value: value})
+	}
+	return values
+}
+
+// PrintEffectiveConfig writes the effective configuration as YAML, with
+// secrets redacted.
+func PrintEffectiveConfig(w io.Writer) error {
+	section := ""
+	for _, v := range EffectiveConfig() {
+		sec, name, _ := strings.Cut(v.Key, ".")
+		if sec != section {
+			if _, err := fmt.Fprintf(w, "%s:\n", sec); err != nil {
+				return err
+			}
+			section = sec
+		}
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", name, strconv.Quote(v.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
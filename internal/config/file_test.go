@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// unsetEnv unsets key for the duration of the test.
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "") // restores the previous value on cleanup
+	os.Unsetenv(key)
+}
+
+func TestLoadConfigFileYAML(t *testing.T) {
+	unsetEnv(t, "DB_HOST")
+	unsetEnv(t, "REDIS_DB")
+	unsetEnv(t, "WEBAUTHN_RP_ORIGINS")
+	t.Setenv("PORT", "9090")
+
+	path := writeConfig(t, "config.yaml", `
+server:
+  port: 8081
+db:
+  host: db.internal
+redis:
+  db: 2
+security:
+  webauthn_rp_origins:
+    - https://a.example.com
+    - https://b.example.com
+`)
+	if err := LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"DB_HOST":             "db.internal",
+		"REDIS_DB":            "2",
+		"WEBAUTHN_RP_ORIGINS": "https://a.example.com,https://b.example.com",
+		"PORT":                "9090", // the environment wins over the file
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadConfigFileTOML(t *testing.T) {
+	unsetEnv(t, "JWT_SECRET")
+	path := writeConfig(t, "config.toml", "[jwt]\nsecret = \"from-toml\"\n")
+	if err := LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile: %v", err)
+	}
+	if got := os.Getenv("JWT_SECRET"); got != "from-toml" {
+		t.Errorf("JWT_SECRET = %q, want %q", got, "from-toml")
+	}
+}
+
+func TestLoadConfigFileRejectsUnknownKeys(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "server:\n  prot: 8081\n")
+	err := LoadConfigFile(path)
+	if err == nil || !strings.Contains(err.Error(), "server.prot") {
+		t.Fatalf("LoadConfigFile() error = %v, want unknown setting server.prot", err)
+	}
+}
+
+func TestReloadConfigFile(t *testing.T) {
+	unsetEnv(t, "DB_HOST")
+	unsetEnv(t, "REDIS_DB")
+	unsetEnv(t, "JWT_ISSUER")
+	t.Setenv("PORT", "9090")
+
+	path := writeConfig(t, "config.yaml", "server:\n  port: 8081\ndb:\n  host: db-a\nredis:\n  db: 2\n")
+	if err := LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile: %v", err)
+	}
+	t.Cleanup(func() {
+		fileEnvMu.Lock()
+		fileEnv = map[string]string{}
+		fileEnvMu.Unlock()
+	})
+
+	if err := os.WriteFile(path, []byte("server:\n  port: 8082\ndb:\n  host: db-b\njwt:\n  issuer: https://auth.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := ReloadConfigFile(path)
+	if err != nil {
+		t.Fatalf("ReloadConfigFile: %v", err)
+	}
+	if got, want := strings.Join(changed, ","), "DB_HOST,JWT_ISSUER,REDIS_DB"; got != want {
+		t.Errorf("changed = %s, want %s", got, want)
+	}
+	for key, want := range map[string]string{
+		"DB_HOST":    "db-b",
+		"JWT_ISSUER": "https://auth.example.com",
+		"PORT":       "9090", // the environment still wins
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if _, set := os.LookupEnv("REDIS_DB"); set {
+		t.Error("REDIS_DB still set after it was removed from the file")
+	}
+
+	if err := os.WriteFile(path, []byte("db:\n  hots: db-c\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReloadConfigFile(path); err == nil {
+		t.Fatal("ReloadConfigFile accepted an unknown key")
+	}
+	if got := os.Getenv("DB_HOST"); got != "db-b" {
+		t.Errorf("DB_HOST = %q after a failed reload, want db-b", got)
+	}
+}
+
+func TestPrintEffectiveConfigRedactsSecrets(t *testing.T) {
+	viper.AutomaticEnv()
+	t.Setenv("JWT_SECRET", "super-secret-value")
+	t.Setenv("DB_HOST", "db.internal")
+	unsetEnv(t, "REDIS_PASSWORD")
+
+	var buf bytes.Buffer
+	if err := PrintEffectiveConfig(&buf); err != nil {
+		t.Fatalf("PrintEffectiveConfig: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "super-secret-value") {
+		t.Error("output contains the JWT secret")
+	}
+	for _, want := range []string{
+		"jwt:\n  secret: \"[REDACTED]\"",
+		"db:\n  host: \"db.internal\"",
+		"  password: \"\"", // unset secrets stay visibly empty
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -39,6 +40,13 @@ type LoggingConfig struct {
 	CleanupInterval      time.Duration
 	CleanupBatchSize     int
 	ArchiveBeforeCleanup bool
+
+	// Cold archive settings (activity_log_archive job): logs older than
+	// ArchiveHotDays days move to the file storage backend, restores keep
+	// them in activity_logs for ArchiveRestoreDays days
+	ArchiveEnabled     bool
+	ArchiveHotDays     int
+	ArchiveRestoreDays int
 }
 
 // AnomalyDetectionConfig holds settings for anomaly-based conditional logging
@@ -60,71 +68,64 @@ type AnomalyDetectionConfig struct {
 	NotificationCooldown time.Duration // Minimum time between notification emails per user
 }
 
-var defaultConfig *LoggingConfig
+var defaultConfig atomic.Pointer[LoggingConfig]
 
 // GetLoggingConfig returns the singleton logging configuration
 func GetLoggingConfig() *LoggingConfig {
-	if defaultConfig == nil {
-		defaultConfig = initializeLoggingConfig()
+	if cfg := defaultConfig.Load(); cfg != nil {
+		return cfg
+	}
+	defaultConfig.CompareAndSwap(nil, initializeLoggingConfig(os.Getenv))
+	return defaultConfig.Load()
+}
+
+// ReloadLoggingConfig reads the logging configuration again through resolve
+// (e.g. the system settings: env > DB > default; nil = the environment).
+// Enabled events, sampling rates and anomaly detection apply to the next
+// event; the cleanup and archive settings are only read at startup.
+func ReloadLoggingConfig(resolve func(key string) string) {
+	if resolve == nil {
+		resolve = os.Getenv
 	}
-	return defaultConfig
+	defaultConfig.Store(initializeLoggingConfig(resolve))
 }
 
-// initializeLoggingConfig creates the default logging configuration
-func initializeLoggingConfig() *LoggingConfig {
+// initializeLoggingConfig creates the logging configuration from the values
+// returned by get
+func initializeLoggingConfig(get func(string) string) *LoggingConfig {
 	config := &LoggingConfig{
 		EventSeverities:   initializeEventSeverities(),
-		EnabledEvents:     initializeEnabledEvents(),
-		SamplingRates:     initializeSamplingRates(),
-		AnomalyDetection:  initializeAnomalyDetection(),
-		RetentionPolicies: initializeRetentionPolicies(),
-
-		CleanupEnabled:       getEnvBool("LOG_CLEANUP_ENABLED", true),
-		CleanupInterval:      getEnvDuration("LOG_CLEANUP_INTERVAL", 24*time.Hour),
-		CleanupBatchSize:     getEnvInt("LOG_CLEANUP_BATCH_SIZE", 1000),
-		ArchiveBeforeCleanup: getEnvBool("LOG_ARCHIVE_BEFORE_CLEANUP", false),
+		EnabledEvents:     initializeEnabledEvents(get),
+		SamplingRates:     initializeSamplingRates(get),
+		AnomalyDetection:  initializeAnomalyDetection(get),
+		RetentionPolicies: initializeRetentionPolicies(get),
+
+		CleanupEnabled:       getEnvBool(get, "LOG_CLEANUP_ENABLED", true),
+		CleanupInterval:      getEnvDuration(get, "LOG_CLEANUP_INTERVAL", 24*time.Hour),
+		CleanupBatchSize:     getEnvInt(get, "LOG_CLEANUP_BATCH_SIZE", 1000),
+		ArchiveBeforeCleanup: getEnvBool(get, "LOG_ARCHIVE_BEFORE_CLEANUP", false),
+
+		ArchiveEnabled:     getEnvBool(get, "LOG_ARCHIVE_ENABLED", false),
+		ArchiveHotDays:     getEnvInt(get, "LOG_ARCHIVE_HOT_DAYS", 30),
+		ArchiveRestoreDays: getEnvInt(get, "LOG_ARCHIVE_RESTORE_DAYS", 7),
 	}
 
 	return config
 }
 
-// initializeEventSeverities maps event types to their severity levels
+// initializeEventSeverities maps event types to their default severity (see eventCatalog)
 func initializeEventSeverities() map[string]EventSeverity {
-	return map[string]EventSeverity{
-		// Critical events - always important for security audits
-		"LOGIN":              SeverityCritical,
-		"LOGOUT":             SeverityCritical,
-		"REGISTER":           SeverityCritical,
-		"PASSWORD_CHANGE":    SeverityCritical,
-		"PASSWORD_RESET":     SeverityCritical,
-		"EMAIL_CHANGE":       SeverityCritical,
-		"2FA_ENABLE":         SeverityCritical,
-		"2FA_DISABLE":        SeverityCritical,
-		"ACCOUNT_DELETION":   SeverityCritical,
-		"RECOVERY_CODE_USED": SeverityCritical,
-
-		// Important events - significant but not critical
-		"EMAIL_VERIFY":           SeverityImportant,
-		"2FA_LOGIN":              SeverityImportant,
-		"SOCIAL_LOGIN":           SeverityImportant,
-		"PROFILE_UPDATE":         SeverityImportant,
-		"RECOVERY_CODE_GENERATE": SeverityImportant,
-		"LOGIN_FAILED":           SeverityImportant,
-		"BRUTE_FORCE_DETECTED":   SeverityCritical,
-		"IP_BLOCKED":             SeverityCritical,
-		"ACCOUNT_LOCKED":         SeverityCritical,
-		"ACCOUNT_UNLOCKED":       SeverityCritical,
-
-		// Informational events - routine operations
-		"TOKEN_REFRESH":  SeverityInformational,
-		"PROFILE_ACCESS": SeverityInformational,
+	severities := make(map[string]EventSeverity, len(eventCatalog))
+	for _, def := range eventCatalog {
+		severities[def.Type] = def.Severity
 	}
+	return severities
 }
 
 // initializeEnabledEvents determines which events are enabled by default
-func initializeEnabledEvents() map[string]bool {
+func initializeEnabledEvents(get func(string) string) map[string]bool {
 	// Check environment variable for disabled events
-	disabledEventsStr := os.Getenv("LOG_DISABLED_EVENTS")
+	disabledEventsStr := get("LOG_DISABLED_EVENTS")
 	disabledEvents := make(map[string]bool)
 	if disabledEventsStr != "" {
 		for _, event := range strings.Split(disabledEventsStr, ",") {
@@ -132,30 +133,13 @@ func initializeEnabledEvents() map[string]bool {
 		}
 	}
 
-	enabled := map[string]bool{
-		"LOGIN":                  true,
-		"LOGOUT":                 true,
-		"REGISTER":               true,
-		"PASSWORD_CHANGE":        true,
-		"PASSWORD_RESET":         true,
-		"EMAIL_VERIFY":           true,
-		"EMAIL_CHANGE":           true,
-		"2FA_ENABLE":             true,
-		"2FA_DISABLE":            true,
-		"2FA_LOGIN":              true,
-		"TOKEN_REFRESH":          getEnvBool("LOG_TOKEN_REFRESH", false), // Disabled by default
-		"SOCIAL_LOGIN":           true,
-		"PROFILE_ACCESS":         getEnvBool("LOG_PROFILE_ACCESS", false), // Disabled by default
-		"PROFILE_UPDATE":         true,
-		"ACCOUNT_DELETION":       true,
-		"RECOVERY_CODE_USED":     true,
-		"RECOVERY_CODE_GENERATE": true,
-		"LOGIN_FAILED":           true,
-		"BRUTE_FORCE_DETECTED":   true,
-		"IP_BLOCKED":             true,
-		"ACCOUNT_LOCKED":         true,
-		"ACCOUNT_UNLOCKED":       true,
+	enabled := make(map[string]bool, len(eventCatalog))
+	for _, def := range eventCatalog {
+		enabled[def.Type] = def.DefaultEnabled
 	}
+	// High-frequency events that are disabled by default
+	enabled["TOKEN_REFRESH"] = getEnvBool(get, "LOG_TOKEN_REFRESH", false)
+	enabled["PROFILE_ACCESS"] = getEnvBool(get, "LOG_PROFILE_ACCESS", false)
 
 	// Apply disabled events from environment
 	for event := range disabledEvents {
@@ -166,47 +150,47 @@ func initializeEnabledEvents() map[string]bool {
 }
 
 // initializeSamplingRates sets sampling rates for high-frequency events
-func initializeSamplingRates() map[string]float64 {
+func initializeSamplingRates(get func(string) string) map[string]float64 {
 	return map[string]float64{
 		// Only sample token refresh if enabled
-		"TOKEN_REFRESH": getEnvFloat("LOG_SAMPLE_TOKEN_REFRESH", 0.01), // 1% by default
+		"TOKEN_REFRESH": getEnvFloat(get, "LOG_SAMPLE_TOKEN_REFRESH", 0.01), // 1% by default
 
 		// Sample profile access if enabled
-		"PROFILE_ACCESS": getEnvFloat("LOG_SAMPLE_PROFILE_ACCESS", 0.01), // 1% by default
+		"PROFILE_ACCESS": getEnvFloat(get, "LOG_SAMPLE_PROFILE_ACCESS", 0.01), // 1% by default
 
 		// All other events are logged at 100% (no sampling)
 	}
 }
 
 // initializeAnomalyDetection configures anomaly detection settings
-func initializeAnomalyDetection() AnomalyDetectionConfig {
+func initializeAnomalyDetection(get func(string) string) AnomalyDetectionConfig {
 	return AnomalyDetectionConfig{
-		Enabled:                getEnvBool("LOG_ANOMALY_DETECTION_ENABLED", true),
-		LogOnNewIP:             getEnvBool("LOG_ANOMALY_NEW_IP", true),
-		LogOnNewUserAgent:      getEnvBool("LOG_ANOMALY_NEW_USER_AGENT", true),
-		LogOnGeographicChange:  getEnvBool("LOG_ANOMALY_GEO_CHANGE", false), // Requires GeoIP
-		LogOnUnusualTimeAccess: getEnvBool("LOG_ANOMALY_UNUSUAL_TIME", false),
-		SessionWindow:          getEnvDuration("LOG_ANOMALY_SESSION_WINDOW", 30*24*time.Hour), // 30 days
+		Enabled:                getEnvBool(get, "LOG_ANOMALY_DETECTION_ENABLED", true),
+		LogOnNewIP:             getEnvBool(get, "LOG_ANOMALY_NEW_IP", true),
+		LogOnNewUserAgent:      getEnvBool(get, "LOG_ANOMALY_NEW_USER_AGENT", true),
+		LogOnGeographicChange:  getEnvBool(get, "LOG_ANOMALY_GEO_CHANGE", false), // Requires GeoIP
+		LogOnUnusualTimeAccess: getEnvBool(get, "LOG_ANOMALY_UNUSUAL_TIME", false),
+		SessionWindow:          getEnvDuration(get, "LOG_ANOMALY_SESSION_WINDOW", 30*24*time.Hour), // 30 days
 
 		// Brute-force detection
-		BruteForceEnabled:   getEnvBool("BRUTE_FORCE_ENABLED", true),
-		BruteForceThreshold: getEnvInt("BRUTE_FORCE_THRESHOLD", 5),
-		BruteForceWindow:    getEnvDuration("BRUTE_FORCE_WINDOW", 15*time.Minute),
+		BruteForceEnabled:   getEnvBool(get, "BRUTE_FORCE_ENABLED", true),
+		BruteForceThreshold: getEnvInt(get, "BRUTE_FORCE_THRESHOLD", 5),
+		BruteForceWindow:    getEnvDuration(get, "BRUTE_FORCE_WINDOW", 15*time.Minute),
 
 		// Notification settings
-		NotifyOnBruteForce:   getEnvBool("NOTIFY_ON_BRUTE_FORCE", true),
-		NotifyOnNewDevice:    getEnvBool("NOTIFY_ON_NEW_DEVICE", true),
-		NotifyOnGeoChange:    getEnvBool("NOTIFY_ON_GEO_CHANGE", true),
-		NotificationCooldown: getEnvDuration("NOTIFICATION_COOLDOWN", 1*time.Hour),
+		NotifyOnBruteForce:   getEnvBool(get, "NOTIFY_ON_BRUTE_FORCE", true),
+		NotifyOnNewDevice:    getEnvBool(get, "NOTIFY_ON_NEW_DEVICE", true),
+		NotifyOnGeoChange:    getEnvBool(get, "NOTIFY_ON_GEO_CHANGE", true),
+		NotificationCooldown: getEnvDuration(get, "NOTIFICATION_COOLDOWN", 1*time.Hour),
 	}
 }
 
 // initializeRetentionPolicies sets retention periods for different severity levels
-func initializeRetentionPolicies() map[EventSeverity]int {
+func initializeRetentionPolicies(get func(string) string) map[EventSeverity]int {
 	return map[EventSeverity]int{
-		SeverityCritical:      getEnvInt("LOG_RETENTION_CRITICAL", 365),     // 1 year
-		SeverityImportant:     getEnvInt("LOG_RETENTION_IMPORTANT", 180),    // 6 months
-		SeverityInformational: getEnvInt("LOG_RETENTION_INFORMATIONAL", 90), // 3 months
+		SeverityCritical:      getEnvInt(get, "LOG_RETENTION_CRITICAL", 365),     // 1 year
+		SeverityImportant:     getEnvInt(get, "LOG_RETENTION_IMPORTANT", 180),    // 6 months
+		SeverityInformational: getEnvInt(get, "LOG_RETENTION_INFORMATIONAL", 90), // 3 months
 	}
 }
 
@@ -246,10 +230,27 @@ func (c *LoggingConfig) GetRetentionDays(severity EventSeverity) int {
 	return 90
 }
 
-// Helper functions to read environment variables with defaults
+// GetEventRetentionDays returns the retention period in days for an event
+// type: its catalog retention class, or its severity for unknown types.
+func (c *LoggingConfig) GetEventRetentionDays(eventType string) int {
+	def, ok := GetEventDefinition(eventType)
+	if !ok {
+		return c.GetRetentionDays(c.GetEventSeverity(eventType))
+	}
+	switch def.Retention {
+	case RetentionLong:
+		return c.GetRetentionDays(SeverityCritical)
+	case RetentionStandard:
+		return c.GetRetentionDays(SeverityImportant)
+	default:
+		return c.GetRetentionDays(SeverityInformational)
+	}
+}
+
+// Helper functions to read settings with defaults
 
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+func getEnvBool(get func(string) string, key string, defaultValue bool) bool {
+	if value := get(key); value != "" {
 		if parsed, err := strconv.ParseBool(value); err == nil {
 			return parsed
 		}
@@ -257,8 +258,8 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+func getEnvInt(get func(string) string, key string, defaultValue int) int {
+	if value := get(key); value != "" {
 		if parsed, err := strconv.Atoi(value); err == nil {
 			return parsed
 		}
@@ -266,8 +267,8 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-func getEnvFloat(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
+func getEnvFloat(get func(string) string, key string, defaultValue float64) float64 {
+	if value := get(key); value != "" {
 		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
 			return parsed
 		}
@@ -275,8 +276,8 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 	return defaultValue
 }
 
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+func getEnvDuration(get func(string) string, key string, defaultValue time.Duration) time.Duration {
+	if value := get(key); value != "" {
 		if parsed, err := time.ParseDuration(value); err == nil {
 			return parsed
 		}
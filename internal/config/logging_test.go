@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReloadLoggingConfig(t *testing.T) {
+	t.Cleanup(func() { ReloadLoggingConfig(nil) })
+	settings := map[string]string{
+		"LOG_DISABLED_EVENTS":        "LOGIN",
+		"LOG_SAMPLE_TOKEN_REFRESH":   "0.5",
+		"LOG_ANOMALY_SESSION_WINDOW": "48h",
+	}
+	ReloadLoggingConfig(func(key string) string { return settings[key] })
+
+	cfg := GetLoggingConfig()
+	if cfg.IsEventEnabled("LOGIN") {
+		t.Error("LOGIN enabled, want disabled by LOG_DISABLED_EVENTS")
+	}
+	if got := cfg.GetSamplingRate("TOKEN_REFRESH"); got != 0.5 {
+		t.Errorf("TOKEN_REFRESH sampling rate = %v, want 0.5", got)
+	}
+	if got := cfg.AnomalyDetection.SessionWindow; got != 48*time.Hour {
+		t.Errorf("anomaly session window = %v, want 48h", got)
+	}
+	if !cfg.AnomalyDetection.BruteForceEnabled {
+		t.Error("unset settings should keep their defaults")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/startup"
 	"github.com/gjovanovicst/auth_api/pkg/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -34,9 +35,16 @@ func ConnectDatabase() {
 		},
 	)
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: newLogger,
+	// Retried so the service can start before PostgreSQL accepts connections
+	err := startup.Retry("database", startup.RetryConfigFromEnv(), func() error {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: newLogger,
+		})
+		if err != nil {
+			return err
+		}
+		DB = db
+		return nil
 	})
 
 	if err != nil {
@@ -56,26 +64,47 @@ func MigrateDatabase() {
 		&models.User{},
 		&models.SocialAccount{},
 		&models.ActivityLog{},
-		&models.SchemaMigration{},    // Migration tracking table
-		&models.AdminAccount{},       // Admin GUI accounts
-		&models.ApiKey{},             // API keys (admin + per-app)
-		&models.SystemSetting{},      // System settings (DB-backed config)
-		&models.EmailServerConfig{},  // Per-app SMTP configuration
-		&models.EmailType{},          // Email type registry
-		&models.EmailTemplate{},      // Email templates (per-app and global)
-		&models.Role{},               // RBAC roles (per-app)
-		&models.Permission{},         // RBAC permissions (global)
-		&models.UserRole{},           // RBAC user-role assignments
-		&models.WebAuthnCredential{}, // WebAuthn/Passkey credentials
-		&models.IPRule{},             // IP-based access rules (per-app)
-		&models.ApiKeyUsage{},        // API key daily usage analytics
-		&models.WebhookEndpoint{},    // Webhook endpoint registrations
-		&models.WebhookDelivery{},    // Webhook delivery history and retry tracking
-		&models.OIDCClient{},         // OIDC relying-party clients (per-app)
-		&models.OIDCAuthCode{},       // OIDC single-use authorization codes
-		&models.TrustedDevice{},      // Trusted device tokens for 2FA bypass
-		&models.SessionGroup{},       // SSO session groups (cross-app shared auth)
-		&models.SessionGroupApp{},    // Join table: app membership in a session group
+		&models.SchemaMigration{},       // Migration tracking table
+		&models.AdminAccount{},          // Admin GUI accounts
+		&models.ApiKey{},                // API keys (admin + per-app)
+		&models.SystemSetting{},         // System settings (DB-backed config)
+		&models.EmailServerConfig{},     // Per-app SMTP configuration
+		&models.EmailType{},             // Email type registry
+		&models.EmailTemplate{},         // Email templates (per-app and global)
+		&models.Role{},                  // RBAC roles (per-app)
+		&models.Permission{},            // RBAC permissions (global)
+		&models.UserRole{},              // RBAC user-role assignments
+		&models.WebAuthnCredential{},    // WebAuthn/Passkey credentials
+		&models.IPRule{},                // IP-based access rules (per-app)
+		&models.ApiKeyUsage{},           // API key daily usage analytics
+		&models.WebhookEndpoint{},       // Webhook endpoint registrations
+		&models.WebhookDelivery{},       // Webhook delivery history and retry tracking
+		&models.OIDCClient{},            // OIDC relying-party clients (per-app)
+		&models.OIDCAuthCode{},          // OIDC single-use authorization codes
+		&models.OIDCGrant{},             // OIDC clients authorized by end users
+		&models.TrustedDevice{},         // Trusted device tokens for 2FA bypass
+		&models.SessionGroup{},          // SSO session groups (cross-app shared auth)
+		&models.SessionGroupApp{},       // Join table: app membership in a session group
+		&models.AdminSavedFilter{},      // Admin GUI saved list filters
+		&models.AdminColumnPreference{}, // Admin GUI list column visibility
+		&models.AdminPreference{},       // Admin GUI language, theme, page size, timezone and locale
+		&models.UsageRecord{},           // Monthly per-app usage aggregates for billing
+		&models.AdminNotification{},     // Admin GUI notification center events
+		&models.AdminNotificationRead{}, // Per-admin notification read state
+		&models.ScheduledJobRun{},       // Background job scheduler run history
+		&models.BackgroundJob{},         // Background job queue (bulk imports, exports, purges)
+		&models.EmailSuppression{},      // Per-app addresses skipped by batch email sends
+		&models.AdminAuditLog{},         // Captured Admin API requests/responses (ADMIN_AUDIT_CAPTURE_ENABLED)
+		&models.DailyAppMetric{},        // Nightly per-app daily metrics rollups for reporting
+		&models.ActivityLogArchive{},    // Cold activity log archive files on the file storage backend
+		&models.ErasureCertificate{},    // Signed records of right-to-erasure (GDPR) requests
+		&models.EmailTemplateVariant{},  // Per-app A/B variants of email templates
+		&models.EmailVariantStat{},      // Send counts per email template variant
+		&models.ServiceAccountToken{},   // Long-lived access tokens of service accounts
+		&models.TenantEmailDomain{},     // Tenant sending domains verified by DNS TXT record
+		&models.JWTSigningKey{},         // RS256/ES256 token signing keys, rotated on a schedule
+		&models.MetricSample{},          // Embedded metrics history behind the dashboard charts
+		&models.UserChangeEvent{},       // Outbox of user changes relayed to downstream systems (USER_SYNC_ENABLED)
 	)
 
 	if err != nil {
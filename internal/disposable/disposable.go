@@ -0,0 +1,277 @@
+// Package disposable detects disposable (throwaway) email domains. The
+// blocklist combines a list bundled with the binary, an optional list
+// refreshed periodically from DISPOSABLE_EMAIL_LIST_URL, and per-application
+// additions stored on the application.
+package disposable
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+//go:embed domains.txt
+var bundledList string
+
+// maxListBytes caps the size of a downloaded list.
+const maxListBytes = 10 << 20
+
+// Sources a blocked domain can come from.
+const (
+	SourceBundled = "bundled"
+	SourceRemote  = "remote"
+	SourceApp     = "application"
+)
+
+// Match describes why an email domain is blocked.
+type Match struct {
+	Domain string // Listed domain that matched (the domain itself or a parent)
+	Source string // SourceBundled, SourceRemote or SourceApp
+}
+
+// Status describes the loaded lists.
+type Status struct {
+	Enabled      bool
+	ListURL      string
+	BundledCount int
+	RemoteCount  int
+	RefreshedAt  *time.Time // Last successful download of the remote list
+	LastError    string     // Error of the last failed download, if any
+}
+
+// Blocklist holds the disposable email domain lists. The remote list is
+// refreshed in the background per instance; lookups are in-memory. All
+// methods are safe to call on a nil *Blocklist (nothing is blocked).
+type Blocklist struct {
+	enabled  bool
+	url      string
+	interval time.Duration
+	client   *http.Client
+
+	mu          sync.RWMutex
+	bundled     map[string]struct{}
+	remote      map[string]struct{}
+	refreshedAt time.Time
+	lastError   string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBlocklist creates the blocklist from configuration but does not start
+// the refresh worker.
+func NewBlocklist() *Blocklist {
+	ctx, cancel := context.WithCancel(context.Background())
+	interval := time.Duration(viper.GetInt("DISPOSABLE_EMAIL_REFRESH_HOURS")) * time.Hour
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &Blocklist{
+		enabled:  viper.GetBool("DISPOSABLE_EMAIL_BLOCKING_ENABLED"),
+		url:      strings.TrimSpace(viper.GetString("DISPOSABLE_EMAIL_LIST_URL")),
+		interval: interval,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		bundled:  ParseList(bundledList),
+		remote:   map[string]struct{}{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start downloads the remote list and keeps it fresh until Shutdown. It does
+// nothing when blocking is disabled or no list URL is configured.
+func (b *Blocklist) Start() {
+	if b == nil || !b.enabled || b.url == "" {
+		return
+	}
+	b.wg.Add(1)
+	go b.worker()
+	log.Printf("Disposable email list refresh started (every %s)", b.interval)
+}
+
+// Shutdown stops the refresh worker.
+func (b *Blocklist) Shutdown() {
+	if b == nil {
+		return
+	}
+	b.cancel()
+	b.wg.Wait()
+}
+
+func (b *Blocklist) worker() {
+	defer b.wg.Done()
+	if err := b.Refresh(b.ctx); err != nil {
+		log.Printf("Warning: failed to refresh disposable email list: %v", err)
+	}
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Refresh(b.ctx); err != nil {
+				log.Printf("Warning: failed to refresh disposable email list: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh downloads the list from the configured URL and replaces the remote
+// list. On failure, or when the download holds no domains, the previous list
+// is kept.
+func (b *Blocklist) Refresh(ctx context.Context) error {
+	if b == nil || b.url == "" {
+		return nil
+	}
+	domains, err := b.download(ctx)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.lastError = err.Error()
+		return err
+	}
+	b.remote = domains
+	b.refreshedAt = time.Now().UTC()
+	b.lastError = ""
+	return nil
+}
+
+func (b *Blocklist) download(ctx context.Context) (map[string]struct{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list download returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxListBytes))
+	if err != nil {
+		return nil, err
+	}
+	domains := ParseList(string(body))
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("list download contained no domains")
+	}
+	return domains, nil
+}
+
+// Check reports whether the domain of emailOrDomain, or one of its parent
+// domains, is blocked. appDomains are the application's own additions (see
+// ParseList); they apply even when global blocking is disabled.
+func (b *Blocklist) Check(emailOrDomain, appDomains string) (Match, bool) {
+	domain := Domain(emailOrDomain)
+	if domain == "" {
+		return Match{}, false
+	}
+	if appDomains != "" {
+		if d, ok := lookup(ParseList(appDomains), domain); ok {
+			return Match{Domain: d, Source: SourceApp}, true
+		}
+	}
+	if b == nil || !b.enabled {
+		return Match{}, false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if d, ok := lookup(b.bundled, domain); ok {
+		return Match{Domain: d, Source: SourceBundled}, true
+	}
+	if d, ok := lookup(b.remote, domain); ok {
+		return Match{Domain: d, Source: SourceRemote}, true
+	}
+	return Match{}, false
+}
+
+// Status returns the state of the loaded lists.
+func (b *Blocklist) Status() Status {
+	if b == nil {
+		return Status{}
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	s := Status{
+		Enabled:      b.enabled,
+		ListURL:      b.url,
+		BundledCount: len(b.bundled),
+		RemoteCount:  len(b.remote),
+		LastError:    b.lastError,
+	}
+	if !b.refreshedAt.IsZero() {
+		t := b.refreshedAt
+		s.RefreshedAt = &t
+	}
+	return s
+}
+
+// lookup matches domain and its parent domains against set, so that
+// "a.mailinator.com" is blocked by "mailinator.com".
+func lookup(set map[string]struct{}, domain string) (string, bool) {
+	for d := domain; d != ""; {
+		if _, ok := set[d]; ok {
+			return d, true
+		}
+		i := strings.IndexByte(d, '.')
+		if i < 0 {
+			break
+		}
+		d = d[i+1:]
+	}
+	return "", false
+}
+
+// InList reports whether the domain of an email address (or a domain) is in
+// a list parsed by ParseList, directly or as a subdomain of a listed domain.
+func InList(domains map[string]struct{}, emailOrDomain string) bool {
+	_, ok := lookup(domains, Domain(emailOrDomain))
+	return ok
+}
+
+// ParseList parses a domain list separated by newlines or commas. Blank
+// entries and "#" comments are ignored; entries are lowercased and a leading
+// "@" or "*." is stripped.
+func ParseList(text string) map[string]struct{} {
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxListBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.ToLower(strings.TrimSpace(entry))
+			entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*."), "@")
+			entry = strings.Trim(entry, ".")
+			if entry != "" {
+				domains[entry] = struct{}{}
+			}
+		}
+	}
+	return domains
+}
+
+// Domain returns the lowercased domain of an email address. A value without
+// "@" is treated as a domain.
+func Domain(emailOrDomain string) string {
+	s := strings.ToLower(strings.TrimSpace(emailOrDomain))
+	if i := strings.LastIndexByte(s, '@'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.Trim(s, ".")
+}
@@ -0,0 +1,82 @@
+package disposable
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func newTestBlocklist(t *testing.T, url string) *Blocklist {
+	t.Helper()
+	viper.Set("DISPOSABLE_EMAIL_BLOCKING_ENABLED", true)
+	viper.Set("DISPOSABLE_EMAIL_LIST_URL", url)
+	t.Cleanup(func() {
+		viper.Set("DISPOSABLE_EMAIL_BLOCKING_ENABLED", nil)
+		viper.Set("DISPOSABLE_EMAIL_LIST_URL", nil)
+	})
+	return NewBlocklist()
+}
+
+func TestCheck(t *testing.T) {
+	b := newTestBlocklist(t, "")
+
+	tests := []struct {
+		input      string
+		appDomains string
+		wantDomain string
+		wantSource string
+	}{
+		{"User@Mailinator.com", "", "mailinator.com", SourceBundled},
+		{"user@inbox.mailinator.com", "", "mailinator.com", SourceBundled},
+		{"yopmail.com", "", "yopmail.com", SourceBundled},
+		{"user@example.com", "", "", ""},
+		{"user@example.com", "# partners\nexample.com, other.org", "example.com", SourceApp},
+		{"user@sub.example.com", "@example.com", "example.com", SourceApp},
+		{"user@notmailinator.com", "", "", ""},
+	}
+	for _, tc := range tests {
+		m, blocked := b.Check(tc.input, tc.appDomains)
+		if blocked != (tc.wantDomain != "") || m.Domain != tc.wantDomain || m.Source != tc.wantSource {
+			t.Errorf("Check(%q, %q) = %+v, %v; want %s from %s", tc.input, tc.appDomains, m, blocked, tc.wantDomain, tc.wantSource)
+		}
+	}
+
+	var nilList *Blocklist
+	if _, blocked := nilList.Check("user@mailinator.com", ""); blocked {
+		t.Error("nil blocklist blocked a bundled domain")
+	}
+	if _, blocked := nilList.Check("user@example.com", "example.com"); !blocked {
+		t.Error("nil blocklist ignored application domains")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	body := "# remote list\nthrowaway.test\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	b := newTestBlocklist(t, srv.URL)
+	if err := b.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if m, blocked := b.Check("a@throwaway.test", ""); !blocked || m.Source != SourceRemote {
+		t.Errorf("remote domain not blocked: %+v, %v", m, blocked)
+	}
+
+	// An empty download keeps the previous list.
+	body = "# nothing\n"
+	if err := b.Refresh(context.Background()); err == nil {
+		t.Error("Refresh() accepted an empty list")
+	}
+	if _, blocked := b.Check("a@throwaway.test", ""); !blocked {
+		t.Error("previous remote list was discarded")
+	}
+	if s := b.Status(); s.RemoteCount != 1 || s.RefreshedAt == nil || s.LastError == "" {
+		t.Errorf("Status() = %+v", s)
+	}
+}
@@ -0,0 +1,379 @@
+// Package dnscheck verifies the DNS records that let receivers authenticate
+// mail sent from a domain (SPF, DKIM and DMARC) and the record that proves a
+// tenant controls a sending domain.
+package dnscheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+// Check statuses
+const (
+	StatusPass  Status = "pass"  // The record is present and sound
+	StatusWarn  Status = "warn"  // The record is present but weak, or the check needs more input
+	StatusFail  Status = "fail"  // The record is missing or broken
+	StatusError Status = "error" // The DNS lookup itself failed
+)
+
+// Check names
+const (
+	CheckSPF   = "SPF"
+	CheckDKIM  = "DKIM"
+	CheckDMARC = "DMARC"
+	// CheckOwnership is the domain verification record (see VerifyOwnership)
+	CheckOwnership = "Ownership"
+)
+
+// OwnershipPrefix starts the TXT record that proves control of a domain:
+// "auth-api-verification=<token>".
+const OwnershipPrefix = "auth-api-verification="
+
+// Resolver is the subset of *net.Resolver used for lookups.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Check  string // CheckSPF, CheckDKIM or CheckDMARC
+	Name   string // DNS name that was queried
+	Status Status
+	Record string // The record found, if any
+	Detail string // What was found
+	Hint   string // How to fix the record; empty when the check passes
+}
+
+// Report holds the results of checking a domain.
+type Report struct {
+	Domain  string
+	Results []Result
+}
+
+// Passed reports whether every check passed.
+func (r Report) Passed() bool {
+	for _, res := range r.Results {
+		if res.Status != StatusPass {
+			return false
+		}
+	}
+	return true
+}
+
+// Options tune the checks to the mail provider.
+type Options struct {
+	// SPFInclude is the domain the provider asks to include in the SPF record
+	// (e.g. "_spf.google.com"). Empty = the record is not checked for it.
+	SPFInclude string
+	// DKIMSelector is the selector the provider signs with; the key is looked
+	// up at <selector>._domainkey.<domain>. Empty = DKIM cannot be checked.
+	DKIMSelector string
+}
+
+// providers are the SPF includes and DKIM selectors of common mail providers,
+// keyed by the domain suffix of their SMTP host.
+var providers = []struct {
+	hostSuffix string
+	options    Options
+}{
+	{"gmail.com", Options{SPFInclude: "_spf.google.com", DKIMSelector: "google"}},
+	{"googlemail.com", Options{SPFInclude: "_spf.google.com", DKIMSelector: "google"}},
+	{"office365.com", Options{SPFInclude: "spf.protection.outlook.com", DKIMSelector: "selector1"}},
+	{"sendgrid.net", Options{SPFInclude: "sendgrid.net", DKIMSelector: "s1"}},
+	{"mailgun.org", Options{SPFInclude: "mailgun.org"}},
+	{"amazonaws.com", Options{SPFInclude: "amazonses.com"}},
+	{"postmarkapp.com", Options{SPFInclude: "spf.mtasv.net"}},
+}
+
+// SuggestOptions returns the options of a well-known mail provider based on
+// its SMTP host, or empty options for other hosts.
+func SuggestOptions(smtpHost string) Options {
+	host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(smtpHost)), ".")
+	for _, p := range providers {
+		if host == p.hostSuffix || strings.HasSuffix(host, "."+p.hostSuffix) {
+			return p.options
+		}
+	}
+	return Options{}
+}
+
+// Checker runs the checks.
+type Checker struct {
+	Resolver Resolver
+	Timeout  time.Duration // For all lookups of one Check call
+}
+
+// NewChecker creates a Checker that uses the system resolver.
+func NewChecker() *Checker {
+	return &Checker{Resolver: net.DefaultResolver, Timeout: 5 * time.Second}
+}
+
+// DomainOf returns the domain of an email address such as a From address.
+func DomainOf(address string) (string, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %q: %w", address, err)
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	if at < 0 || at == len(addr.Address)-1 {
+		return "", fmt.Errorf("email address %q has no domain", address)
+	}
+	return strings.ToLower(addr.Address[at+1:]), nil
+}
+
+// Check runs the SPF, DKIM and DMARC checks for domain.
+func (c *Checker) Check(ctx context.Context, domain string, opts Options) Report {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	return Report{
+		Domain: domain,
+		Results: []Result{
+			c.checkSPF(ctx, domain, strings.TrimSpace(opts.SPFInclude)),
+			c.checkDKIM(ctx, domain, strings.TrimSpace(opts.DKIMSelector)),
+			c.checkDMARC(ctx, domain),
+		},
+	}
+}
+
+func (c *Checker) checkSPF(ctx context.Context, domain, include string) Result {
+	res := Result{Check: CheckSPF, Name: domain}
+	suggested := "v=spf1 ~all"
+	if include != "" {
+		suggested = "v=spf1 include:" + include + " ~all"
+	}
+
+	records, ok := c.lookup(ctx, &res, "v=spf1")
+	if !ok {
+		return res
+	}
+	switch len(records) {
+	case 0:
+		res.Status = StatusFail
+		res.Detail = "No SPF record found."
+		res.Hint = fmt.Sprintf("Add a TXT record at %s: %s", domain, suggested)
+		return res
+	case 1:
+		res.Record = records[0]
+	default:
+		res.Status = StatusFail
+		res.Record = strings.Join(records, "\n")
+		res.Detail = fmt.Sprintf("%d SPF records found; receivers treat this as an error.", len(records))
+		res.Hint = "Merge them into a single TXT record starting with v=spf1."
+		return res
+	}
+
+	terms := strings.Fields(strings.ToLower(res.Record))
+	if include != "" && !containsTerm(terms, "include:"+strings.ToLower(include)) {
+		res.Status = StatusFail
+		res.Detail = "The SPF record does not authorize the mail server."
+		res.Hint = fmt.Sprintf("Add include:%s before the all mechanism, e.g. %s", include, suggested)
+		return res
+	}
+
+	switch all := lastAll(terms); all {
+	case "+all", "all":
+		res.Status = StatusFail
+		res.Detail = "The SPF record ends in " + all + ", which authorizes every server on the internet."
+		res.Hint = "End the record with ~all (soft fail) or -all (fail) instead."
+	case "?all", "":
+		res.Status = StatusWarn
+		res.Detail = "The SPF record does not say what to do with other servers."
+		res.Hint = "End the record with ~all (soft fail) or -all (fail)."
+	default:
+		res.Status = StatusPass
+		res.Detail = "SPF record found."
+	}
+	return res
+}
+
+func (c *Checker) checkDKIM(ctx context.Context, domain, selector string) Result {
+	res := Result{Check: CheckDKIM}
+	if selector == "" {
+		res.Status = StatusWarn
+		res.Detail = "No DKIM selector given."
+		res.Hint = "Enter the selector your mail provider signs with (shown in its domain setup) to check the DKIM key."
+		return res
+	}
+	res.Name = selector + "._domainkey." + domain
+
+	records, ok := c.lookup(ctx, &res, "")
+	if !ok {
+		return res
+	}
+	var key string
+	for _, r := range records {
+		if strings.Contains(r, "p=") {
+			key = r
+			break
+		}
+	}
+	if key == "" {
+		res.Status = StatusFail
+		res.Detail = "No DKIM key found for selector " + selector + "."
+		res.Hint = fmt.Sprintf("Publish the DKIM public key from your mail provider as a TXT record at %s, or check the selector.", res.Name)
+		return res
+	}
+	res.Record = key
+
+	if tagValue(key, "p") == "" {
+		res.Status = StatusFail
+		res.Detail = "The DKIM key for selector " + selector + " is revoked (empty p= tag)."
+		res.Hint = "Publish the current public key from your mail provider."
+		return res
+	}
+	res.Status = StatusPass
+	res.Detail = "DKIM key found for selector " + selector + "."
+	return res
+}
+
+func (c *Checker) checkDMARC(ctx context.Context, domain string) Result {
+	res := Result{Check: CheckDMARC, Name: "_dmarc." + domain}
+	suggested := fmt.Sprintf("v=DMARC1; p=none; rua=mailto:dmarc-reports@%s", domain)
+
+	records, ok := c.lookup(ctx, &res, "v=dmarc1")
+	if !ok {
+		return res
+	}
+	switch len(records) {
+	case 0:
+		res.Status = StatusFail
+		res.Detail = "No DMARC record found."
+		res.Hint = fmt.Sprintf("Add a TXT record at %s, starting with monitoring: %s", res.Name, suggested)
+		return res
+	case 1:
+		res.Record = records[0]
+	default:
+		res.Status = StatusFail
+		res.Record = strings.Join(records, "\n")
+		res.Detail = fmt.Sprintf("%d DMARC records found; receivers ignore all of them.", len(records))
+		res.Hint = "Keep a single TXT record starting with v=DMARC1."
+		return res
+	}
+
+	switch policy := strings.ToLower(tagValue(res.Record, "p")); policy {
+	case "reject", "quarantine":
+		res.Status = StatusPass
+		res.Detail = "DMARC policy is " + policy + "."
+	case "none":
+		res.Status = StatusWarn
+		res.Detail = "DMARC policy is none: failing mail is only reported, not blocked."
+		res.Hint = "Once reports show your mail passes SPF or DKIM, change the policy to p=quarantine or p=reject."
+	default:
+		res.Status = StatusFail
+		res.Detail = "The DMARC record has no valid policy (p= tag)."
+		res.Hint = "Set p=none, p=quarantine or p=reject, e.g. " + suggested
+	}
+	return res
+}
+
+// VerifyOwnership checks that domain has the TXT record
+// "auth-api-verification=<token>".
+func (c *Checker) VerifyOwnership(ctx context.Context, domain, token string) Result {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	res := Result{Check: CheckOwnership, Name: domain}
+	expected := OwnershipPrefix + token
+
+	records, ok := c.lookup(ctx, &res, "")
+	if !ok {
+		return res
+	}
+	for _, r := range records {
+		if r == expected {
+			res.Status = StatusPass
+			res.Record = r
+			res.Detail = "Verification record found."
+			return res
+		}
+	}
+	res.Status = StatusFail
+	res.Detail = "No matching verification record found."
+	for _, r := range records {
+		if strings.HasPrefix(strings.ToLower(r), OwnershipPrefix) {
+			res.Record = r
+			res.Detail = "A verification record was found, but with a different token."
+			break
+		}
+	}
+	res.Hint = fmt.Sprintf("Add a TXT record at %s: %s (DNS changes can take a while to propagate)", domain, expected)
+	return res
+}
+
+// lookup fetches the TXT records at res.Name and keeps those starting with
+// prefix (case-insensitive; "" keeps all). A missing name yields no records;
+// other lookup failures are recorded in res and ok is false.
+func (c *Checker) lookup(ctx context.Context, res *Result, prefix string) (records []string, ok bool) {
+	txt, err := c.Resolver.LookupTXT(ctx, res.Name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, true
+		}
+		res.Status = StatusError
+		res.Detail = "DNS lookup failed: " + err.Error()
+		res.Hint = "Try again later; if this persists, check that the domain's name servers respond."
+		return nil, false
+	}
+	for _, r := range txt {
+		r = strings.TrimSpace(r)
+		if prefix == "" || hasPrefixFold(r, prefix) {
+			records = append(records, r)
+		}
+	}
+	return records, true
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return false
+	}
+	// "v=spf1" must be followed by a space or the end of the record, not e.g. "v=spf10"
+	return len(s) == len(prefix) || s[len(prefix)] == ' ' || s[len(prefix)] == ';'
+}
+
+func containsTerm(terms []string, term string) bool {
+	for _, t := range terms {
+		if strings.TrimPrefix(t, "+") == term {
+			return true
+		}
+	}
+	return false
+}
+
+// lastAll returns the all mechanism of an SPF record with its qualifier, or "".
+func lastAll(terms []string) string {
+	for i := len(terms) - 1; i >= 0; i-- {
+		if strings.TrimLeft(terms[i], "+-~?") == "all" {
+			return terms[i]
+		}
+	}
+	return ""
+}
+
+// tagValue returns the value of tag in a DKIM or DMARC tag list such as
+// "v=DMARC1; p=none". The tag name is matched case-insensitively.
+func tagValue(record, tag string) string {
+	for _, part := range strings.Split(record, ";") {
+		name, value, found := strings.Cut(part, "=")
+		if found && strings.EqualFold(strings.TrimSpace(name), tag) {
+			return strings.Join(strings.Fields(value), "")
+		}
+	}
+	return ""
+}
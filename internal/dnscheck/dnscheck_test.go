@@ -0,0 +1,191 @@
+package dnscheck
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+// fakeResolver serves TXT records from a map; missing names are NXDOMAIN.
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if name == "broken.example" {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	records, ok := f[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func check(t *testing.T, records fakeResolver, domain string, opts Options) map[string]Result {
+	t.Helper()
+	report := (&Checker{Resolver: records}).Check(context.Background(), domain, opts)
+	if report.Domain != domain {
+		t.Fatalf("Domain = %q, want %q", report.Domain, domain)
+	}
+	results := make(map[string]Result, len(report.Results))
+	for _, r := range report.Results {
+		results[r.Check] = r
+	}
+	return results
+}
+
+func TestCheckAllPass(t *testing.T) {
+	records := fakeResolver{
+		"example.com":                    {"google-site-verification=abc", "v=spf1 include:_spf.google.com ~all"},
+		"google._domainkey.example.com":  {"v=DKIM1; k=rsa; p=MIIBIjANBgkq"},
+		"_dmarc.example.com":             {"v=DMARC1; p=reject; rua=mailto:d@example.com"},
+		"unrelated._domainkey.other.com": {"v=DKIM1; p="},
+	}
+	report := (&Checker{Resolver: records}).Check(context.Background(), "Example.com.",
+		Options{SPFInclude: "_spf.google.com", DKIMSelector: "google"})
+	if !report.Passed() {
+		t.Fatalf("report did not pass: %+v", report.Results)
+	}
+	for _, r := range report.Results {
+		if r.Hint != "" {
+			t.Errorf("%s passed with hint %q", r.Check, r.Hint)
+		}
+	}
+}
+
+func TestCheckSPF(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []string
+		include string
+		want    Status
+	}{
+		{"missing", nil, "", StatusFail},
+		{"duplicate", []string{"v=spf1 -all", "v=spf1 ~all"}, "", StatusFail},
+		{"missing include", []string{"v=spf1 include:mailgun.org ~all"}, "_spf.google.com", StatusFail},
+		{"allows everyone", []string{"v=spf1 include:_spf.google.com +all"}, "_spf.google.com", StatusFail},
+		{"neutral", []string{"v=spf1 include:_spf.google.com ?all"}, "_spf.google.com", StatusWarn},
+		{"no all", []string{"v=spf1 include:_spf.google.com"}, "", StatusWarn},
+		{"not an spf record", []string{"v=spf10 -all"}, "", StatusFail},
+		{"hard fail", []string{"v=spf1 mx -all"}, "", StatusPass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := fakeResolver{}
+			if tt.records != nil {
+				records["example.com"] = tt.records
+			}
+			got := check(t, records, "example.com", Options{SPFInclude: tt.include})[CheckSPF]
+			if got.Status != tt.want {
+				t.Errorf("Status = %s (%s), want %s", got.Status, got.Detail, tt.want)
+			}
+			if got.Status != StatusPass && got.Hint == "" {
+				t.Error("expected a remediation hint")
+			}
+		})
+	}
+}
+
+func TestCheckDKIM(t *testing.T) {
+	records := fakeResolver{
+		"s1._domainkey.example.com":  {"k=rsa; p=MIGfMA0"},
+		"old._domainkey.example.com": {"v=DKIM1; p="},
+	}
+	for selector, want := range map[string]Status{
+		"s1":      StatusPass,
+		"old":     StatusFail,
+		"missing": StatusFail,
+		"":        StatusWarn,
+	} {
+		got := check(t, records, "example.com", Options{DKIMSelector: selector})[CheckDKIM]
+		if got.Status != want {
+			t.Errorf("selector %q: Status = %s (%s), want %s", selector, got.Status, got.Detail, want)
+		}
+	}
+}
+
+func TestCheckDMARC(t *testing.T) {
+	tests := []struct {
+		records []string
+		want    Status
+	}{
+		{nil, StatusFail},
+		{[]string{"v=DMARC1; p=none"}, StatusWarn},
+		{[]string{"v=DMARC1;p=Quarantine; pct=100"}, StatusPass},
+		{[]string{"v=DMARC1; rua=mailto:d@example.com"}, StatusFail},
+		{[]string{"v=DMARC1; p=reject", "v=DMARC1; p=none"}, StatusFail},
+	}
+	for _, tt := range tests {
+		records := fakeResolver{}
+		if tt.records != nil {
+			records["_dmarc.example.com"] = tt.records
+		}
+		got := check(t, records, "example.com", Options{})[CheckDMARC]
+		if got.Status != tt.want {
+			t.Errorf("records %q: Status = %s (%s), want %s", tt.records, got.Status, got.Detail, tt.want)
+		}
+	}
+}
+
+func TestCheckLookupError(t *testing.T) {
+	got := check(t, fakeResolver{}, "broken.example", Options{})[CheckSPF]
+	if got.Status != StatusError {
+		t.Errorf("Status = %s, want %s", got.Status, StatusError)
+	}
+	if got.Detail == "" || got.Hint == "" {
+		t.Errorf("lookup error without detail or hint: %+v", got)
+	}
+}
+
+func TestDomainOf(t *testing.T) {
+	for in, want := range map[string]string{
+		"noreply@Example.com":               "example.com",
+		"My App <noreply@mail.example.org>": "mail.example.org",
+	} {
+		got, err := DomainOf(in)
+		if err != nil || got != want {
+			t.Errorf("DomainOf(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := DomainOf("not an address"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
+
+func TestSuggestOptions(t *testing.T) {
+	if got := SuggestOptions("smtp.gmail.com"); got.SPFInclude != "_spf.google.com" || got.DKIMSelector != "google" {
+		t.Errorf("SuggestOptions(smtp.gmail.com) = %+v", got)
+	}
+	if got := SuggestOptions("email-smtp.eu-west-1.amazonaws.com"); got.SPFInclude != "amazonses.com" {
+		t.Errorf("SuggestOptions(SES) = %+v", got)
+	}
+	if got := SuggestOptions("mail.example.com"); got != (Options{}) {
+		t.Errorf("SuggestOptions(unknown) = %+v, want empty", got)
+	}
+}
+
+func TestVerifyOwnership(t *testing.T) {
+	records := fakeResolver{
+		"example.com": {"v=spf1 ~all", "auth-api-verification=tok123"},
+		"other.com":   {"auth-api-verification=stale"},
+	}
+	checker := &Checker{Resolver: records}
+	tests := []struct {
+		domain string
+		want   Status
+		record string
+	}{
+		{"Example.com.", StatusPass, "auth-api-verification=tok123"},
+		{"other.com", StatusFail, "auth-api-verification=stale"},
+		{"missing.com", StatusFail, ""},
+		{"broken.example", StatusError, ""},
+	}
+	for _, tt := range tests {
+		res := checker.VerifyOwnership(context.Background(), tt.domain, "tok123")
+		if res.Status != tt.want || res.Record != tt.record {
+			t.Errorf("%s: status %s, record %q; want %s, %q", tt.domain, res.Status, res.Record, tt.want, tt.record)
+		}
+		if tt.want == StatusFail && res.Hint == "" {
+			t.Errorf("%s: failed without a hint", tt.domain)
+		}
+	}
+}
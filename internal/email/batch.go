@@ -0,0 +1,309 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// JobTypeEmailBatch is the background job type for batch email sends.
+const JobTypeEmailBatch = "email_batch"
+
+// Batch recipient statuses
+const (
+	BatchStatusQueued     = "queued"     // Not sent yet
+	BatchStatusSent       = "sent"       // Accepted by the SMTP server
+	BatchStatusFailed     = "failed"     // Rendering or sending failed
+	BatchStatusSuppressed = "suppressed" // On the suppression list, or opted out of the email's category
+	BatchStatusDuplicate  = "duplicate"  // The address appears earlier in the batch
+	BatchStatusInvalid    = "invalid"    // Not a valid address, or a required variable is missing
+)
+
+// BatchPayload is the input of an email_batch background job: the recipients
+// to send to, plus those skipped when the batch was queued so the final
+// result lists them too.
+type BatchPayload struct {
+	AppID         string                          `json:"app_id"`
+	TypeCode      string                          `json:"type_code"`
+	Variables     map[string]string               `json:"variables,omitempty"`
+	Recipients    []dto.EmailBatchRecipient       `json:"recipients"`
+	Skipped       []dto.EmailBatchRecipientStatus `json:"skipped,omitempty"`
+	RatePerSecond float64                         `json:"rate_per_second"`
+}
+
+// BatchMaxRecipients returns the maximum number of recipients of one batch
+// (EMAIL_BATCH_MAX_RECIPIENTS, default 1000).
+func BatchMaxRecipients() int {
+	if n := viper.GetInt("EMAIL_BATCH_MAX_RECIPIENTS"); n > 0 {
+		return n
+	}
+	return 1000
+}
+
+// BatchRate returns the send rate of a batch: the requested rate, capped at
+// EMAIL_BATCH_MAX_RATE_PER_SECOND (default 10). A requested rate of 0 or less
+// means the maximum.
+func BatchRate(requested float64) float64 {
+	max := viper.GetFloat64("EMAIL_BATCH_MAX_RATE_PER_SECOND")
+	if max <= 0 {
+		max = 10
+	}
+	if requested <= 0 || requested > max {
+		return max
+	}
+	return requested
+}
+
+// PrepareBatch validates the recipients of a batch send of emailType. It
+// returns the recipients to send to, with normalized addresses, and the
+// skipped ones: invalid addresses, recipients missing a required variable
+// (from the batch or their own variables), repeated addresses and addresses
+// on the application's suppression list.
+func (s *Service) PrepareBatch(appID uuid.UUID, emailType *models.EmailType, vars map[string]string, recipients []dto.EmailBatchRecipient) ([]dto.EmailBatchRecipient, []dto.EmailBatchRecipientStatus, error) {
+	var required []string
+	if len(emailType.Variables) > 0 {
+		var typeVars []models.EmailTypeVariable
+		if err := json.Unmarshal(emailType.Variables, &typeVars); err != nil {
+			return nil, nil, fmt.Errorf("invalid variables on email type %s: %w", emailType.Code, err)
+		}
+		// Only explicit variables must be passed; the pipeline resolves the others
+		for _, v := range typeVars {
+			if v.Required && v.Source == models.VarSourceExplicit {
+				required = append(required, v.Name)
+			}
+		}
+	}
+
+	var send []dto.EmailBatchRecipient
+	var skipped []dto.EmailBatchRecipientStatus
+	seen := make(map[string]bool, len(recipients))
+	for _, r := range recipients {
+		addr, err := mail.ParseAddress(strings.TrimSpace(r.ToEmail))
+		if err != nil {
+			skipped = append(skipped, dto.EmailBatchRecipientStatus{ToEmail: r.ToEmail, Status: BatchStatusInvalid, Error: "invalid email address"})
+			continue
+		}
+		r.ToEmail = strings.ToLower(addr.Address)
+		if missing := missingVariable(required, vars, r.Variables); missing != "" {
+			skipped = append(skipped, dto.EmailBatchRecipientStatus{ToEmail: r.ToEmail, Status: BatchStatusInvalid, Error: "missing required variable: " + missing})
+			continue
+		}
+		if seen[r.ToEmail] {
+			skipped = append(skipped, dto.EmailBatchRecipientStatus{ToEmail: r.ToEmail, Status: BatchStatusDuplicate})
+			continue
+		}
+		seen[r.ToEmail] = true
+		send = append(send, r)
+	}
+
+	suppressed, err := s.suppressedRecipients(appID, send)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(suppressed) == 0 {
+		return send, skipped, nil
+	}
+	kept := send[:0]
+	for _, r := range send {
+		if suppressed[r.ToEmail] {
+			skipped = append(skipped, dto.EmailBatchRecipientStatus{ToEmail: r.ToEmail, Status: BatchStatusSuppressed})
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	return kept, skipped, nil
+}
+
+func missingVariable(required []string, vars, recipientVars map[string]string) string {
+	for _, name := range required {
+		if recipientVars[name] == "" && vars[name] == "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func (s *Service) suppressedRecipients(appID uuid.UUID, recipients []dto.EmailBatchRecipient) (map[string]bool, error) {
+	if s.repo == nil || len(recipients) == 0 {
+		return nil, nil
+	}
+	emails := make([]string, len(recipients))
+	for i, r := range recipients {
+		emails[i] = r.ToEmail
+	}
+	suppressed, err := s.repo.SuppressedEmails(appID, emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the suppression list: %w", err)
+	}
+	return suppressed, nil
+}
+
+// RunBatchJob sends the emails of an email_batch job and returns the
+// dto.EmailBatchResult. Its signature matches jobqueue.HandlerFunc.
+//
+// The result is checkpointed after every recipient, so a batch interrupted by
+// a shutdown, or cancelled and then retried, continues with the recipients
+// not handled yet instead of emailing everyone again.
+func (s *Service) RunBatchJob(ctx context.Context, task *jobqueue.Task) (interface{}, error) {
+	var payload BatchPayload
+	if err := task.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	appID, err := uuid.Parse(payload.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid app_id %q", payload.AppID)
+	}
+
+	result := resumeBatchResult(payload, task.Job.Result)
+
+	// Addresses may have been suppressed (e.g. unsubscribed) since the batch was queued
+	suppressed, err := s.suppressedRecipients(appID, payload.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &batchRun{
+		payload:    payload,
+		result:     result,
+		suppressed: suppressed,
+		send: func(to string, vars map[string]string) error {
+			// Quiet hours were applied when the batch was queued
+			_, err := s.send(appID, payload.TypeCode, to, nil, vars, false)
+			return err
+		},
+		checkpoint: func(done, total int) {
+			if err := task.SaveCheckpoint(result); err != nil {
+				// The batch goes on; a restart may only resend this recipient
+				task.SetProgress(done*100/total, fmt.Sprintf("%d of %d recipients processed (checkpoint failed: %v)", done, total, err))
+				return
+			}
+			task.SetProgress(done*100/total, fmt.Sprintf("%d of %d recipients processed", done, total))
+		},
+	}
+	runErr := b.run(ctx)
+	task.SetSummary(fmt.Sprintf("%d sent, %d failed, %d skipped", result.Sent, result.Failed, result.Skipped))
+	if runErr != nil {
+		return nil, runErr
+	}
+	return result, nil
+}
+
+// resumeBatchResult returns the result checkpointed by an earlier attempt of
+// the job, or a new result with every recipient queued.
+func resumeBatchResult(payload BatchPayload, checkpoint string) *dto.EmailBatchResult {
+	total := len(payload.Recipients) + len(payload.Skipped)
+	if checkpoint != "" {
+		var prev dto.EmailBatchResult
+		if err := json.Unmarshal([]byte(checkpoint), &prev); err == nil && len(prev.Recipients) == total {
+			return &prev
+		}
+	}
+
+	result := &dto.EmailBatchResult{
+		Total:      total,
+		Skipped:    len(payload.Skipped),
+		Recipients: make([]dto.EmailBatchRecipientStatus, 0, total),
+	}
+	for _, r := range payload.Recipients {
+		result.Recipients = append(result.Recipients, dto.EmailBatchRecipientStatus{ToEmail: r.ToEmail, Status: BatchStatusQueued})
+	}
+	result.Recipients = append(result.Recipients, payload.Skipped...)
+	return result
+}
+
+// batchRun sends the queued recipients of a batch at the payload's rate.
+type batchRun struct {
+	payload    BatchPayload
+	result     *dto.EmailBatchResult // Recipients[i] is the status of payload.Recipients[i]
+	suppressed map[string]bool
+	send       func(to string, vars map[string]string) error
+	checkpoint func(done, total int) // Called after each recipient
+}
+
+func (b *batchRun) run(ctx context.Context) error {
+	interval := time.Duration(float64(time.Second) / BatchRate(b.payload.RatePerSecond))
+	total := len(b.payload.Recipients)
+	var next time.Time
+	for i, r := range b.payload.Recipients {
+		status := &b.result.Recipients[i]
+		if status.Status != BatchStatusQueued {
+			continue // Handled by an earlier attempt
+		}
+
+		if b.suppressed[r.ToEmail] {
+			status.Status = BatchStatusSuppressed
+			b.result.Skipped++
+			b.checkpoint(i+1, total)
+			continue
+		}
+
+		if wait := time.Until(next); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next = time.Now().Add(interval)
+
+		err := b.send(r.ToEmail, mergeVariables(b.payload.Variables, r.Variables))
+		switch {
+		case err == nil:
+			status.Status = BatchStatusSent
+			b.result.Sent++
+		case errors.Is(err, ErrUnsubscribed):
+			status.Status = BatchStatusSuppressed
+			b.result.Skipped++
+		case errors.Is(err, quota.ErrExceeded):
+			// The remaining recipients would fail the same way
+			b.failRemaining(i, err)
+			b.checkpoint(total, total)
+			return nil
+		default:
+			status.Status = BatchStatusFailed
+			status.Error = err.Error()
+			b.result.Failed++
+		}
+		b.checkpoint(i+1, total)
+	}
+	return nil
+}
+
+// failRemaining marks the queued recipients from index from on as failed.
+func (b *batchRun) failRemaining(from int, err error) {
+	for i := from; i < len(b.payload.Recipients); i++ {
+		if status := &b.result.Recipients[i]; status.Status == BatchStatusQueued {
+			status.Status = BatchStatusFailed
+			status.Error = err.Error()
+			b.result.Failed++
+		}
+	}
+}
+
+// mergeVariables returns the batch variables overridden by the recipient's.
+func mergeVariables(batch, recipient map[string]string) map[string]string {
+	vars := make(map[string]string, len(batch)+len(recipient))
+	for k, v := range batch {
+		vars[k] = v
+	}
+	for k, v := range recipient {
+		vars[k] = v
+	}
+	return vars
+}
@@ -0,0 +1,214 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestPrepareBatchSkipsInvalidAndDuplicateRecipients(t *testing.T) {
+	svc := NewService(nil, nil)
+	emailType := &models.EmailType{
+		Code:      "newsletter",
+		Variables: datatypes.JSON(`[{"name": "issue", "required": true, "source": "explicit"}, {"name": "app_name", "required": true, "source": "setting"}]`),
+	}
+
+	send, skipped, err := svc.PrepareBatch(uuid.New(), emailType, map[string]string{"issue": "42"}, []dto.EmailBatchRecipient{
+		{ToEmail: " Alice@Example.com "},
+		{ToEmail: "not-an-address"},
+		{ToEmail: "alice@example.com"},
+		{ToEmail: "bob@example.com", Variables: map[string]string{"issue": "43"}},
+	})
+	if err != nil {
+		t.Fatalf("PrepareBatch: %v", err)
+	}
+	if len(send) != 2 || send[0].ToEmail != "alice@example.com" || send[1].ToEmail != "bob@example.com" {
+		t.Errorf("send = %+v, want alice and bob with normalized addresses", send)
+	}
+	want := []dto.EmailBatchRecipientStatus{
+		{ToEmail: "not-an-address", Status: BatchStatusInvalid, Error: "invalid email address"},
+		{ToEmail: "alice@example.com", Status: BatchStatusDuplicate},
+	}
+	if fmt.Sprint(skipped) != fmt.Sprint(want) {
+		t.Errorf("skipped = %+v, want %+v", skipped, want)
+	}
+
+	// Without the batch variable, only recipients that set it themselves are sent
+	send, skipped, err = svc.PrepareBatch(uuid.New(), emailType, nil, []dto.EmailBatchRecipient{
+		{ToEmail: "alice@example.com"},
+		{ToEmail: "bob@example.com", Variables: map[string]string{"issue": "43"}},
+	})
+	if err != nil {
+		t.Fatalf("PrepareBatch: %v", err)
+	}
+	if len(send) != 1 || send[0].ToEmail != "bob@example.com" {
+		t.Errorf("send = %+v, want only bob", send)
+	}
+	if len(skipped) != 1 || skipped[0].Status != BatchStatusInvalid || skipped[0].Error != "missing required variable: issue" {
+		t.Errorf("skipped = %+v, want alice as missing the issue variable", skipped)
+	}
+}
+
+func TestBatchRate(t *testing.T) {
+	cases := []struct {
+		requested, want float64
+	}{
+		{0, 10},
+		{-1, 10},
+		{2.5, 2.5},
+		{50, 10},
+	}
+	for _, tc := range cases {
+		if got := BatchRate(tc.requested); got != tc.want {
+			t.Errorf("BatchRate(%v) = %v, want %v", tc.requested, got, tc.want)
+		}
+	}
+}
+
+// newTestBatch returns a batch of the given recipients, with one skipped at
+// queue time, that records the variables of each send.
+func newTestBatch(rate float64, recipients ...string) (*batchRun, map[string]map[string]string) {
+	payload := BatchPayload{
+		TypeCode:      "newsletter",
+		Variables:     map[string]string{"issue": "42", "greeting": "Hi"},
+		Skipped:       []dto.EmailBatchRecipientStatus{{ToEmail: "dup@example.com", Status: BatchStatusDuplicate}},
+		RatePerSecond: rate,
+	}
+	for _, r := range recipients {
+		payload.Recipients = append(payload.Recipients, dto.EmailBatchRecipient{ToEmail: r})
+	}
+	sent := make(map[string]map[string]string)
+	b := &batchRun{
+		payload: payload,
+		result:  resumeBatchResult(payload, ""),
+		send: func(to string, vars map[string]string) error {
+			sent[to] = vars
+			return nil
+		},
+		checkpoint: func(done, total int) {},
+	}
+	return b, sent
+}
+
+func TestBatchRunReportsEachRecipient(t *testing.T) {
+	b, sent := newTestBatch(0, "a@example.com", "b@example.com", "c@example.com", "d@example.com")
+	b.payload.Recipients[0].Variables = map[string]string{"greeting": "Hello"}
+	b.suppressed = map[string]bool{"c@example.com": true}
+	send := b.send
+	b.send = func(to string, vars map[string]string) error {
+		if to == "b@example.com" {
+			return errors.New("mailbox unavailable")
+		}
+		return send(to, vars)
+	}
+
+	if err := b.run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	want := []dto.EmailBatchRecipientStatus{
+		{ToEmail: "a@example.com", Status: BatchStatusSent},
+		{ToEmail: "b@example.com", Status: BatchStatusFailed, Error: "mailbox unavailable"},
+		{ToEmail: "c@example.com", Status: BatchStatusSuppressed},
+		{ToEmail: "d@example.com", Status: BatchStatusSent},
+		{ToEmail: "dup@example.com", Status: BatchStatusDuplicate},
+	}
+	if fmt.Sprint(b.result.Recipients) != fmt.Sprint(want) {
+		t.Errorf("recipients = %+v, want %+v", b.result.Recipients, want)
+	}
+	if r := b.result; r.Total != 5 || r.Sent != 2 || r.Failed != 1 || r.Skipped != 2 {
+		t.Errorf("counts = total %d, sent %d, failed %d, skipped %d; want 5, 2, 1, 2", r.Total, r.Sent, r.Failed, r.Skipped)
+	}
+	if got := sent["a@example.com"]; got["greeting"] != "Hello" || got["issue"] != "42" {
+		t.Errorf("a@example.com variables = %v, want the recipient greeting and the batch issue", got)
+	}
+	if got := sent["d@example.com"]; got["greeting"] != "Hi" {
+		t.Errorf("d@example.com variables = %v, want the batch greeting", got)
+	}
+}
+
+func TestBatchRunThrottles(t *testing.T) {
+	b, _ := newTestBatch(20, "a@example.com", "b@example.com", "c@example.com")
+	var times []time.Time
+	b.send = func(to string, vars map[string]string) error {
+		times = append(times, time.Now())
+		return nil
+	}
+
+	if err := b.run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 45*time.Millisecond {
+			t.Errorf("send %d followed the previous one after %s, want at least 50ms at 20/s", i, gap)
+		}
+	}
+}
+
+func TestBatchRunStopsWhenQuotaExceeded(t *testing.T) {
+	b, _ := newTestBatch(0, "a@example.com", "b@example.com", "c@example.com")
+	calls := 0
+	b.send = func(to string, vars map[string]string) error {
+		calls++
+		if to == "b@example.com" {
+			return fmt.Errorf("cannot send newsletter email: %w: daily limit reached", quota.ErrExceeded)
+		}
+		return nil
+	}
+
+	if err := b.run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("sent %d emails, want no attempt after the quota error", calls)
+	}
+	if r := b.result; r.Sent != 1 || r.Failed != 2 || r.Recipients[2].Status != BatchStatusFailed {
+		t.Errorf("result = %+v, want b and c failed", r)
+	}
+}
+
+func TestBatchRunResumesFromCheckpoint(t *testing.T) {
+	b, sent := newTestBatch(0, "a@example.com", "b@example.com")
+	b.result.Recipients[0].Status = BatchStatusSent
+	b.result.Sent = 1
+
+	if err := b.run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, ok := sent["a@example.com"]; ok {
+		t.Error("a@example.com was sent again after the restart")
+	}
+	if _, ok := sent["b@example.com"]; !ok || b.result.Sent != 2 {
+		t.Errorf("b@example.com was not sent (sent count %d)", b.result.Sent)
+	}
+
+	// A checkpoint that does not match the payload is ignored
+	if r := resumeBatchResult(b.payload, `{"total": 1, "recipients": [{"to_email": "x@example.com", "status": "sent"}]}`); r.Sent != 0 || r.Recipients[0].Status != BatchStatusQueued {
+		t.Errorf("resumeBatchResult used a mismatched checkpoint: %+v", r)
+	}
+}
+
+func TestBatchRunStopsWhenCancelled(t *testing.T) {
+	b, sent := newTestBatch(1, "a@example.com", "b@example.com")
+	ctx, cancel := context.WithCancel(context.Background())
+	send := b.send
+	b.send = func(to string, vars map[string]string) error {
+		cancel() // Cancelled while waiting for the next send slot
+		return send(to, vars)
+	}
+
+	if err := b.run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("run = %v, want context.Canceled", err)
+	}
+	if len(sent) != 1 || b.result.Recipients[1].Status != BatchStatusQueued {
+		t.Errorf("sent %d emails, want b@example.com left queued", len(sent))
+	}
+}
@@ -0,0 +1,165 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// Branding is an application's email branding: a logo, footer text, postal
+// address and social links shown at the bottom of every email it sends.
+type Branding struct {
+	LogoURL       string
+	FooterText    string
+	PostalAddress string
+	SocialLinks   string // One link per line: "Label https://..." or just the URL
+}
+
+// SocialLink is one parsed line of Branding.SocialLinks.
+type SocialLink struct {
+	Label string
+	URL   string
+}
+
+// BrandingFromApp returns the email branding configured on app.
+func BrandingFromApp(app *models.Application) Branding {
+	return Branding{
+		LogoURL:       strings.TrimSpace(app.EmailLogoURL),
+		FooterText:    strings.TrimSpace(app.EmailFooterText),
+		PostalAddress: strings.TrimSpace(app.EmailPostalAddress),
+		SocialLinks:   strings.TrimSpace(app.EmailSocialLinks),
+	}
+}
+
+// IsEmpty reports whether no branding is configured.
+func (b Branding) IsEmpty() bool {
+	return b.logoURL() == "" && b.FooterText == "" && b.PostalAddress == "" && len(b.Links()) == 0
+}
+
+// logoURL returns LogoURL when it is an http(s) URL, otherwise "".
+func (b Branding) logoURL() string {
+	if isWebURL(b.LogoURL) {
+		return b.LogoURL
+	}
+	return ""
+}
+
+// Links parses SocialLinks, one link per line or comma. A line is either a
+// URL, labeled with its host, or a label followed by the URL. Lines without
+// an http(s) URL are ignored.
+func (b Branding) Links() []SocialLink {
+	var links []SocialLink
+	for _, line := range strings.FieldsFunc(b.SocialLinks, func(r rune) bool { return r == '\n' || r == ',' }) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		link := fields[len(fields)-1]
+		if !isWebURL(link) {
+			continue
+		}
+		label := strings.Join(fields[:len(fields)-1], " ")
+		if label == "" {
+			u, _ := url.Parse(link)
+			label = strings.TrimPrefix(u.Host, "www.")
+		}
+		links = append(links, SocialLink{Label: label, URL: link})
+	}
+	return links
+}
+
+// Vars returns the reserved brand_* template variables. They are set for
+// every email, empty when the application has no branding.
+func (b Branding) Vars() map[string]string {
+	var social []string
+	for _, l := range b.Links() {
+		social = append(social, l.Label+": "+l.URL)
+	}
+	vars := map[string]string{
+		VarBrandLogoURL:     b.logoURL(),
+		VarBrandFooterText:  b.FooterText,
+		VarBrandAddress:     b.PostalAddress,
+		VarBrandSocialLinks: strings.Join(social, " | "),
+		VarBrandFooter:      "",
+		VarBrandFooterPlain: "",
+	}
+	if !b.IsEmpty() {
+		vars[VarBrandFooter] = b.html()
+		vars[VarBrandFooterPlain] = b.text()
+	}
+	return vars
+}
+
+// html returns the branding block for HTML bodies. Every configured value is
+// escaped, so the block is safe to insert without further escaping.
+func (b Branding) html() string {
+	const muted = "font-size:12px;color:#6c757d;margin:4px 0;"
+	var sb strings.Builder
+	sb.WriteString(`<div style="text-align:center;margin-top:32px;padding-top:16px;border-top:1px solid #dee2e6;">`)
+	if logo := b.logoURL(); logo != "" {
+		fmt.Fprintf(&sb, `<img src="%s" alt="" style="max-height:40px;max-width:200px;margin-bottom:8px;">`, html.EscapeString(logo))
+	}
+	if links := b.Links(); len(links) > 0 {
+		sb.WriteString(`<p style="` + muted + `">`)
+		for i, l := range links {
+			if i > 0 {
+				sb.WriteString(" &middot; ")
+			}
+			fmt.Fprintf(&sb, `<a href="%s" style="color:#6c757d;">%s</a>`, html.EscapeString(l.URL), html.EscapeString(l.Label))
+		}
+		sb.WriteString(`</p>`)
+	}
+	for _, s := range []string{b.FooterText, b.PostalAddress} {
+		if s != "" {
+			fmt.Fprintf(&sb, `<p style="%s">%s</p>`, muted, strings.ReplaceAll(html.EscapeString(s), "\n", "<br>"))
+		}
+	}
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
+
+// text returns the branding block for plain-text bodies.
+func (b Branding) text() string {
+	var parts []string
+	if b.FooterText != "" {
+		parts = append(parts, b.FooterText)
+	}
+	if b.PostalAddress != "" {
+		parts = append(parts, b.PostalAddress)
+	}
+	for _, l := range b.Links() {
+		parts = append(parts, l.Label+": "+l.URL)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// referencesBranding reports whether a template places the branding itself
+// through one of the brand_* variables, in any engine's syntax.
+func referencesBranding(tmpl *models.EmailTemplate) bool {
+	body := tmpl.BodyHTML + tmpl.BodyText
+	return strings.Contains(body, "brand_") || strings.Contains(body, ".Brand")
+}
+
+// addBrandingFooter appends the branding block held in vars to the bodies of
+// an email. The HTML block goes before </body>.
+func addBrandingFooter(htmlBody, textBody string, vars map[string]string) (string, string) {
+	if block := vars[VarBrandFooter]; htmlBody != "" && block != "" {
+		if i := strings.LastIndex(strings.ToLower(htmlBody), "</body>"); i >= 0 {
+			htmlBody = htmlBody[:i] + block + htmlBody[i:]
+		} else {
+			htmlBody += block
+		}
+	}
+	if block := vars[VarBrandFooterPlain]; textBody != "" && block != "" {
+		textBody = strings.TrimRight(textBody, "\n") + "\n\n--\n" + block + "\n"
+	}
+	return htmlBody, textBody
+}
+
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
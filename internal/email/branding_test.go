@@ -0,0 +1,89 @@
+package email
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestBrandingLinks(t *testing.T) {
+	b := Branding{SocialLinks: "X https://x.com/acme\nhttps://www.linkedin.com/company/acme, Our Blog https://blog.acme.io\njavascript:alert(1)\nBroken link"}
+	want := []SocialLink{
+		{Label: "X", URL: "https://x.com/acme"},
+		{Label: "linkedin.com", URL: "https://www.linkedin.com/company/acme"},
+		{Label: "Our Blog", URL: "https://blog.acme.io"},
+	}
+	if got := b.Links(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Links() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBrandingVars(t *testing.T) {
+	if vars := (Branding{LogoURL: "data:image/png;base64,AAAA"}).Vars(); vars[VarBrandFooter] != "" || vars[VarBrandLogoURL] != "" {
+		t.Errorf("Vars() with only an invalid logo = %v, want no branding", vars)
+	}
+
+	b := Branding{
+		LogoURL:       "https://acme.io/logo.png",
+		FooterText:    "Acme <Support>",
+		PostalAddress: "1 Main St\nSpringfield",
+		SocialLinks:   "X https://x.com/acme",
+	}
+	vars := b.Vars()
+	if vars[VarBrandSocialLinks] != "X: https://x.com/acme" {
+		t.Errorf("brand_social_links = %q", vars[VarBrandSocialLinks])
+	}
+	block := vars[VarBrandFooter]
+	for _, want := range []string{`src="https://acme.io/logo.png"`, "Acme &lt;Support&gt;", "1 Main St<br>Springfield", `href="https://x.com/acme"`} {
+		if !strings.Contains(block, want) {
+			t.Errorf("brand_footer = %q, want it to contain %q", block, want)
+		}
+	}
+	if want := "Acme <Support>\n1 Main St\nSpringfield\nX: https://x.com/acme"; vars[VarBrandFooterPlain] != want {
+		t.Errorf("brand_footer_plain = %q, want %q", vars[VarBrandFooterPlain], want)
+	}
+}
+
+func TestAddBrandingFooter(t *testing.T) {
+	vars := Branding{FooterText: "Acme Inc."}.Vars()
+
+	htmlBody, textBody := addBrandingFooter("<html><body><p>Hi</p></body></html>", "Hi", vars)
+	if !strings.HasPrefix(htmlBody, "<html><body><p>Hi</p><div") || !strings.HasSuffix(htmlBody, "</div></body></html>") {
+		t.Errorf("HTML body = %q, want the block before </body>", htmlBody)
+	}
+	if textBody != "Hi\n\n--\nAcme Inc.\n" {
+		t.Errorf("text body = %q", textBody)
+	}
+
+	htmlBody, textBody = addBrandingFooter("<p>Hi</p>", "", Branding{}.Vars())
+	if htmlBody != "<p>Hi</p>" || textBody != "" {
+		t.Errorf("bodies without branding = %q, %q, want them unchanged", htmlBody, textBody)
+	}
+}
+
+func TestBrandingRendersInEveryEngine(t *testing.T) {
+	vars := Branding{FooterText: "Acme & Co"}.Vars()
+	block := vars[VarBrandFooter]
+	r := NewRenderer()
+	for _, tmpl := range []*models.EmailTemplate{
+		{TemplateEngine: models.TemplateEngineGoTemplate, BodyHTML: "<p>Hi</p>{{.BrandFooter}}"},
+		{TemplateEngine: models.TemplateEngineRawHTML, BodyHTML: "<p>Hi</p>{{.BrandFooter}}"},
+		{TemplateEngine: models.TemplateEnginePlaceholder, BodyHTML: "<p>Hi</p>{brand_footer}"},
+	} {
+		if !referencesBranding(tmpl) {
+			t.Errorf("referencesBranding(%q) = false, want true", tmpl.BodyHTML)
+		}
+		_, htmlBody, _, err := r.RenderTemplate(tmpl, vars)
+		if err != nil {
+			t.Fatalf("RenderTemplate(%s): %v", tmpl.TemplateEngine, err)
+		}
+		if htmlBody != "<p>Hi</p>"+block {
+			t.Errorf("RenderTemplate(%s) = %q, want the unescaped block", tmpl.TemplateEngine, htmlBody)
+		}
+	}
+	if referencesBranding(&models.EmailTemplate{BodyHTML: "<p>{{.AppName}}</p>"}) {
+		t.Error("referencesBranding(template without brand variables) = true, want false")
+	}
+}
@@ -8,6 +8,8 @@ func GetDefaultTemplate(typeCode string) *models.EmailTemplate {
 	switch typeCode {
 	case TypeEmailVerification:
 		return defaultEmailVerification()
+	case TypeEmailVerifyCode:
+		return defaultEmailVerifyCode()
 	case TypePasswordReset:
 		return defaultPasswordReset()
 	case TypeTwoFACode:
@@ -28,6 +30,10 @@ func GetDefaultTemplate(typeCode string) *models.EmailTemplate {
 		return defaultApiKeyExpiringSoon()
 	case TypeBackupEmailVerification:
 		return defaultBackupEmailVerification()
+	case TypeRegistrationApproved:
+		return defaultRegistrationApproved()
+	case TypeRegistrationRejected:
+		return defaultRegistrationRejected()
 	default:
 		return nil
 	}
@@ -89,6 +95,57 @@ If you did not create an account, you can safely ignore this email.`,
 	}
 }
 
+func defaultEmailVerifyCode() *models.EmailTemplate {
+	return &models.EmailTemplate{
+		Name:           "Default Email Verification Code",
+		Subject:        "Your Email Verification Code",
+		TemplateEngine: models.TemplateEngineGoTemplate,
+		BodyHTML: `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Verify Your Email</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f7fa;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,'Helvetica Neue',Arial,sans-serif;">
+<table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color:#f4f7fa;padding:40px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellspacing="0" cellpadding="0" style="background-color:#ffffff;border-radius:8px;box-shadow:0 2px 8px rgba(0,0,0,0.08);overflow:hidden;">
+  <tr><td style="background-color:#4f46e5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;font-weight:600;">{{.AppName}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;text-align:center;">
+    <h2 style="color:#1a1a2e;margin:0 0 16px;font-size:20px;">Verify Your Email Address</h2>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 32px;">
+      Enter the following code to verify your email address. This code is valid for {{.ExpirationMinutes}} minutes.
+    </p>
+    <div style="background-color:#f0f4ff;border:2px solid #4f46e5;border-radius:12px;padding:24px;display:inline-block;margin:0 0 32px;">
+      <span style="font-size:36px;font-weight:700;letter-spacing:8px;color:#1a1a2e;font-family:'Courier New',monospace;">{{.Code}}</span>
+    </div>
+    <p style="color:#a0aec0;font-size:13px;margin:0;">
+      If you did not create an account, you can safely ignore this email.
+    </p>
+  </td></tr>
+  <tr><td style="background-color:#f8fafc;padding:24px 40px;text-align:center;border-top:1px solid #e2e8f0;">
+    <p style="color:#a0aec0;font-size:12px;margin:0;">This email was sent by {{.AppName}}. Please do not reply to this email.</p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>`,
+		BodyText: `Verify Your Email Address
+
+Enter the following code to verify your email address with {{.AppName}}:
+
+{{.Code}}
+
+This code is valid for {{.ExpirationMinutes}} minutes.
+
+If you did not create an account, you can safely ignore this email.`,
+	}
+}
+
 func defaultPasswordReset() *models.EmailTemplate {
 	return &models.EmailTemplate{
 		Name:           "Default Password Reset",
@@ -725,3 +782,97 @@ This link will expire in {{.ExpirationMinutes}} minutes.
 If you did not request this, you can safely ignore this email.`,
 	}
 }
+
+func defaultRegistrationApproved() *models.EmailTemplate {
+	return &models.EmailTemplate{
+		Name:           "Default Registration Approved",
+		Subject:        "Your Account Has Been Approved",
+		TemplateEngine: models.TemplateEngineGoTemplate,
+		BodyHTML: `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Registration Approved</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f7fa;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,'Helvetica Neue',Arial,sans-serif;">
+<table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color:#f4f7fa;padding:40px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellspacing="0" cellpadding="0" style="background-color:#ffffff;border-radius:8px;box-shadow:0 2px 8px rgba(0,0,0,0.08);overflow:hidden;">
+  <tr><td style="background-color:#38a169;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;font-weight:600;">{{.AppName}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#1a1a2e;margin:0 0 16px;font-size:20px;">Registration Approved</h2>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      Your registration on {{.AppName}} has been approved. Your account is now active and you can sign in.
+    </p>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      If you have not verified your email address yet, please use the verification email we sent you before signing in.
+    </p>
+    <p style="color:#a0aec0;font-size:13px;margin:0;">
+      This is an automated notification. Please do not reply to this email.
+    </p>
+  </td></tr>
+  <tr><td style="background-color:#f8fafc;padding:24px 40px;text-align:center;border-top:1px solid #e2e8f0;">
+    <p style="color:#a0aec0;font-size:12px;margin:0;">This email was sent by {{.AppName}}.</p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>`,
+		BodyText: `Registration Approved
+
+Your registration on {{.AppName}} has been approved. Your account is now active and you can sign in.
+
+If you have not verified your email address yet, please use the verification email we sent you before signing in.`,
+	}
+}
+
+func defaultRegistrationRejected() *models.EmailTemplate {
+	return &models.EmailTemplate{
+		Name:           "Default Registration Not Approved",
+		Subject:        "Your Registration Was Not Approved",
+		TemplateEngine: models.TemplateEngineGoTemplate,
+		BodyHTML: `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Registration Not Approved</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f7fa;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,'Helvetica Neue',Arial,sans-serif;">
+<table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color:#f4f7fa;padding:40px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellspacing="0" cellpadding="0" style="background-color:#ffffff;border-radius:8px;box-shadow:0 2px 8px rgba(0,0,0,0.08);overflow:hidden;">
+  <tr><td style="background-color:#e53e3e;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;font-weight:600;">{{.AppName}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#1a1a2e;margin:0 0 16px;font-size:20px;">Registration Not Approved</h2>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      Your registration on {{.AppName}} was reviewed and has not been approved. You will not be able to sign in with this account.
+    </p>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      If you believe this was done in error, please contact the application administrator.
+    </p>
+    <p style="color:#a0aec0;font-size:13px;margin:0;">
+      This is an automated notification. Please do not reply to this email.
+    </p>
+  </td></tr>
+  <tr><td style="background-color:#f8fafc;padding:24px 40px;text-align:center;border-top:1px solid #e2e8f0;">
+    <p style="color:#a0aec0;font-size:12px;margin:0;">This email was sent by {{.AppName}}.</p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>`,
+		BodyText: `Registration Not Approved
+
+Your registration on {{.AppName}} was reviewed and has not been approved. You will not be able to sign in with this account.
+
+If you believe this was done in error, please contact the application administrator.`,
+	}
+}
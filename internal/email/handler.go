@@ -0,0 +1,85 @@
+package email
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/util"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// Handler serves the public unsubscribe page linked from non-transactional
+// emails.
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new email handler.
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// @Summary Unsubscribe page
+// @Description Show the confirmation page of an unsubscribe link from a non-transactional email
+// @Tags Email
+// @Produce html
+// @Param   token  query     string  true  "Signed unsubscribe token"
+// @Success 200 {string} string "HTML page"
+// @Failure 400 {string} string "HTML page"
+// @Router /unsubscribe [get]
+func (h *Handler) UnsubscribePage(c *gin.Context) {
+	token := c.Query("token")
+	claims, err := ParseUnsubscribeToken(token)
+	if err != nil {
+		h.render(c, http.StatusBadRequest, nil, gin.H{"Error": "This unsubscribe link is invalid or incomplete."})
+		return
+	}
+	h.render(c, http.StatusOK, claims, gin.H{"Token": token})
+}
+
+// @Summary Unsubscribe
+// @Description Apply an unsubscribe link: turns off the email category in the recipient's notification preferences, or suppresses the address for recipients without an account. Also serves RFC 8058 one-click unsubscribe requests from mail clients.
+// @Tags Email
+// @Produce html
+// @Param   token  query     string  true  "Signed unsubscribe token"
+// @Success 200 {string} string "HTML page"
+// @Failure 400 {string} string "HTML page"
+// @Failure 500 {string} string "HTML page"
+// @Router /unsubscribe [post]
+func (h *Handler) Unsubscribe(c *gin.Context) {
+	claims, err := h.Service.Unsubscribe(c.Query("token"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidUnsubscribeToken) {
+			h.render(c, http.StatusBadRequest, nil, gin.H{"Error": "This unsubscribe link is invalid or incomplete."})
+			return
+		}
+		log.Printf("Unsubscribe failed: %v", err)
+		h.render(c, http.StatusInternalServerError, nil, gin.H{"Error": "We could not process your request. Please try again later."})
+		return
+	}
+	h.render(c, http.StatusOK, claims, gin.H{"Done": true})
+}
+
+// render renders the unsubscribe page with the branding of the link's
+// application, when the link is valid.
+func (h *Handler) render(c *gin.Context, status int, claims *UnsubscribeClaims, data gin.H) {
+	data["Theme"] = "auto"
+	data["AppName"] = util.ResolveAppName()
+	if claims != nil {
+		data["Email"] = claims.Email
+		data["Category"] = CategoryLabel(claims.Category)
+		data["AppName"] = h.Service.resolveAppName(claims.AppID)
+		if h.Service.db != nil {
+			var app models.Application
+			if h.Service.db.Select("login_theme, login_primary_color").Limit(1).Find(&app, "id = ?", claims.AppID).Error == nil {
+				if app.LoginTheme != "" {
+					data["Theme"] = app.LoginTheme
+				}
+				data["PrimaryColor"] = app.LoginPrimaryColor
+			}
+		}
+	}
+	c.HTML(status, "unsubscribe", data)
+}
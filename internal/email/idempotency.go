@@ -0,0 +1,94 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	appRedis "github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// MaxIdempotencyKeyLength is the longest accepted idempotency key.
+const MaxIdempotencyKeyLength = 255
+
+var (
+	// ErrInvalidIdempotencyKey is returned for an idempotency key that is too long.
+	ErrInvalidIdempotencyKey = fmt.Errorf("idempotency key must be at most %d characters", MaxIdempotencyKeyLength)
+	// ErrIdempotencyKeyReused is returned when an idempotency key already sent
+	// an email of another type or to another recipient within the window.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different email")
+)
+
+// IdempotencyWindow returns how long an idempotency key prevents a second
+// email (EMAIL_IDEMPOTENCY_WINDOW_HOURS, default 24).
+func IdempotencyWindow() time.Duration {
+	if h := viper.GetInt("EMAIL_IDEMPOTENCY_WINDOW_HOURS"); h > 0 {
+		return time.Duration(h) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// idempotencyFingerprint identifies the email an idempotency key was used for.
+func idempotencyFingerprint(emailTypeCode, toEmail string) string {
+	return emailTypeCode + " " + strings.ToLower(strings.TrimSpace(toEmail))
+}
+
+// userEventKey is the idempotency key of an email sent once per user event:
+// the email type, the user and whatever identifies the event.
+func userEventKey(emailTypeCode string, userID *uuid.UUID, event ...string) string {
+	if userID == nil {
+		return ""
+	}
+	return strings.Join(append([]string{emailTypeCode, userID.String()}, event...), ":")
+}
+
+// SendResult is what happened to an email that did not fail.
+type SendResult struct {
+	Duplicate     bool       // Not sent: the idempotency key already sent the email
+	DeferredUntil *time.Time // Queued until the application's quiet hours end
+}
+
+// SendEmailIdempotent sends an email like SendEmailWithContext unless the
+// application already sent one with the same idempotency key within
+// IdempotencyWindow. Such a duplicate is reported in the result, not as an
+// error; ErrIdempotencyKeyReused is returned when the key was used for another
+// email type or recipient. Keys are held in Redis and released when the send
+// fails, so a retry can send; without Redis, or with an empty key, every call
+// sends.
+func (s *Service) SendEmailIdempotent(appID uuid.UUID, emailTypeCode, toEmail string, userID *uuid.UUID, vars map[string]string, idempotencyKey string) (SendResult, error) {
+	if len(idempotencyKey) > MaxIdempotencyKeyLength {
+		return SendResult{}, ErrInvalidIdempotencyKey
+	}
+	if idempotencyKey == "" || appRedis.Rdb == nil {
+		until, err := s.send(appID, emailTypeCode, toEmail, userID, vars, true)
+		return SendResult{DeferredUntil: until}, err
+	}
+
+	fingerprint := idempotencyFingerprint(emailTypeCode, toEmail)
+	claimed, stored, err := appRedis.ClaimEmailIdempotencyKey(appID.String(), idempotencyKey, fingerprint, IdempotencyWindow())
+	if err != nil {
+		log.Printf("Warning: failed to check email idempotency key %q for app %s, sending anyway: %v", idempotencyKey, appID, err)
+		until, err := s.send(appID, emailTypeCode, toEmail, userID, vars, true)
+		return SendResult{DeferredUntil: until}, err
+	}
+	if !claimed {
+		if stored != "" && stored != fingerprint {
+			return SendResult{}, ErrIdempotencyKeyReused
+		}
+		log.Printf("Skipped duplicate %s email for app %s (idempotency key %q)", emailTypeCode, appID, idempotencyKey)
+		return SendResult{Duplicate: true}, nil
+	}
+
+	until, err := s.send(appID, emailTypeCode, toEmail, userID, vars, true)
+	if err != nil {
+		if relErr := appRedis.ReleaseEmailIdempotencyKey(appID.String(), idempotencyKey); relErr != nil {
+			log.Printf("Warning: failed to release email idempotency key %q for app %s: %v", idempotencyKey, appID, relErr)
+		}
+		return SendResult{}, err
+	}
+	return SendResult{DeferredUntil: until}, nil
+}
@@ -0,0 +1,47 @@
+package email
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestIdempotencyWindow(t *testing.T) {
+	t.Cleanup(func() { viper.Set("EMAIL_IDEMPOTENCY_WINDOW_HOURS", nil) })
+
+	viper.Set("EMAIL_IDEMPOTENCY_WINDOW_HOURS", 0)
+	if got := IdempotencyWindow(); got != 24*time.Hour {
+		t.Errorf("IdempotencyWindow() unset = %v, want 24h", got)
+	}
+	viper.Set("EMAIL_IDEMPOTENCY_WINDOW_HOURS", 2)
+	if got := IdempotencyWindow(); got != 2*time.Hour {
+		t.Errorf("IdempotencyWindow() = %v, want 2h", got)
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	if a, b := idempotencyFingerprint(TypeWelcome, " Alice@Example.com"), idempotencyFingerprint(TypeWelcome, "alice@example.com"); a != b {
+		t.Errorf("fingerprints of the same address differ: %q, %q", a, b)
+	}
+
+	userID := uuid.MustParse("6f1c2a3e-0000-4000-8000-000000000001")
+	if got := userEventKey(TypeWelcome, nil); got != "" {
+		t.Errorf("userEventKey(no user) = %q, want none", got)
+	}
+	want := TypeNewDeviceLogin + ":6f1c2a3e-0000-4000-8000-000000000001:2026-10-16 12:00:00 UTC:203.0.113.7"
+	if got := userEventKey(TypeNewDeviceLogin, &userID, "2026-10-16 12:00:00 UTC", "203.0.113.7"); got != want {
+		t.Errorf("userEventKey() = %q, want %q", got, want)
+	}
+}
+
+func TestSendEmailIdempotentRejectsLongKey(t *testing.T) {
+	svc := NewService(nil, nil)
+	_, err := svc.SendEmailIdempotent(uuid.New(), TypeWelcome, "alice@example.com", nil, nil, strings.Repeat("k", MaxIdempotencyKeyLength+1))
+	if !errors.Is(err, ErrInvalidIdempotencyKey) {
+		t.Errorf("SendEmailIdempotent(long key) error = %v, want ErrInvalidIdempotencyKey", err)
+	}
+}
@@ -0,0 +1,229 @@
+package email
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"text/template/parse"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// Lint warning codes
+const (
+	LintParseError        = "parse_error"        // The field does not parse with the template's engine
+	LintUnknownVariable   = "unknown_variable"   // A referenced variable is neither declared by the email type nor well-known
+	LintUnsupportedAction = "unsupported_action" // A raw_html body uses a template action other than {{.Name}}
+)
+
+// Template fields, named as in the API
+const (
+	lintFieldSubject  = "subject"
+	lintFieldBodyHTML = "body_html"
+	lintFieldBodyText = "body_text"
+)
+
+var (
+	placeholderRe = regexp.MustCompile(`\{([a-z][a-z0-9_]*)\}`)
+	actionRe      = regexp.MustCompile(`\{\{(.*?)\}\}`)
+	simpleFieldRe = regexp.MustCompile(`^\.([A-Za-z_][A-Za-z0-9_]*)$`)
+)
+
+// LintWarning is a problem found in an email template. Warnings do not stop a
+// template from being saved, but the template may fail to render or render
+// variables empty.
+type LintWarning struct {
+	Field    string // "subject", "body_html" or "body_text"
+	Code     string // One of the Lint* codes
+	Variable string // The variable concerned, for LintUnknownVariable
+	Message  string
+}
+
+// TemplateLintResult is the outcome of linting a stored template.
+type TemplateLintResult struct {
+	Template models.EmailTemplate
+	Warnings []LintWarning
+}
+
+// LintTemplate checks that each field of tmpl parses with its template engine
+// and that every variable it references is declared by emailType or is
+// well-known. It returns an error only if emailType's variables are invalid.
+func LintTemplate(tmpl *models.EmailTemplate, emailType *models.EmailType) ([]LintWarning, error) {
+	l, err := lintTemplate(tmpl, emailType)
+	if err != nil {
+		return nil, err
+	}
+	return l.warnings, nil
+}
+
+// lintTemplate lints tmpl and returns the linter, which also holds the
+// variables tmpl references.
+func lintTemplate(tmpl *models.EmailTemplate, emailType *models.EmailType) (*linter, error) {
+	vars, err := TypeVariables(emailType)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, 2*len(vars))
+	for _, v := range vars {
+		known[v.Name] = true
+		known[snakeToPascal(v.Name)] = true
+	}
+	typeCode := ""
+	if emailType != nil {
+		typeCode = emailType.Code
+	}
+
+	l := &linter{known: known, typeCode: typeCode}
+	switch tmpl.TemplateEngine {
+	case models.TemplateEnginePlaceholder:
+		l.lintPlaceholders(lintFieldSubject, tmpl.Subject)
+		l.lintPlaceholders(lintFieldBodyHTML, tmpl.BodyHTML)
+		l.lintPlaceholders(lintFieldBodyText, tmpl.BodyText)
+	case models.TemplateEngineRawHTML:
+		// The subject is rendered as a Go template; bodies only substitute {{.Name}}
+		l.lintGoTemplate(lintFieldSubject, tmpl.Subject)
+		l.lintRawHTML(lintFieldBodyHTML, tmpl.BodyHTML)
+		l.lintRawHTML(lintFieldBodyText, tmpl.BodyText)
+	default:
+		l.lintGoTemplate(lintFieldSubject, tmpl.Subject)
+		l.lintGoTemplate(lintFieldBodyHTML, tmpl.BodyHTML)
+		l.lintGoTemplate(lintFieldBodyText, tmpl.BodyText)
+	}
+	return l, nil
+}
+
+// linter collects the warnings of one template.
+type linter struct {
+	known      map[string]bool
+	typeCode   string
+	warnings   []LintWarning
+	reported   map[string]bool // field + variable, so a variable is reported once per field
+	referenced map[string]bool // Variables referenced in any field, as written
+}
+
+// references reports whether the template references the variable name, in
+// snake_case or PascalCase.
+func (l *linter) references(name string) bool {
+	return l.referenced[name] || l.referenced[snakeToPascal(name)]
+}
+
+func (l *linter) lintGoTemplate(field, text string) {
+	if text == "" {
+		return
+	}
+	t, err := template.New(field).Parse(text)
+	if err != nil {
+		l.warnings = append(l.warnings, LintWarning{
+			Field:   field,
+			Code:    LintParseError,
+			Message: err.Error(),
+		})
+		return
+	}
+	for _, tt := range t.Templates() {
+		if tt.Tree != nil {
+			l.walk(field, tt.Tree.Root, true)
+		}
+	}
+}
+
+// walk reports the root fields referenced under node. Inside range and with
+// blocks dot is no longer the template data, so fields there are skipped;
+// $.Name always refers to the data.
+func (l *linter) walk(field string, node parse.Node, rootDot bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(field, child, rootDot)
+		}
+	case *parse.ActionNode:
+		l.walk(field, n.Pipe, rootDot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			l.walk(field, cmd, rootDot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			l.walk(field, arg, rootDot)
+		}
+	case *parse.ChainNode:
+		l.walk(field, n.Node, rootDot)
+	case *parse.FieldNode:
+		if rootDot {
+			l.checkVariable(field, n.Ident[0])
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			l.checkVariable(field, n.Ident[1])
+		}
+	case *parse.IfNode:
+		l.walkBranch(field, &n.BranchNode, rootDot, rootDot)
+	case *parse.RangeNode:
+		l.walkBranch(field, &n.BranchNode, false, rootDot)
+	case *parse.WithNode:
+		l.walkBranch(field, &n.BranchNode, false, rootDot)
+	case *parse.TemplateNode:
+		l.walk(field, n.Pipe, rootDot)
+	}
+}
+
+func (l *linter) walkBranch(field string, n *parse.BranchNode, listRootDot, elseRootDot bool) {
+	l.walk(field, n.Pipe, elseRootDot)
+	l.walk(field, n.List, listRootDot)
+	l.walk(field, n.ElseList, elseRootDot)
+}
+
+func (l *linter) lintPlaceholders(field, text string) {
+	for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+		l.checkVariable(field, m[1])
+	}
+}
+
+func (l *linter) lintRawHTML(field, text string) {
+	for _, m := range actionRe.FindAllStringSubmatch(text, -1) {
+		if f := simpleFieldRe.FindStringSubmatch(m[1]); f != nil {
+			l.checkVariable(field, f[1])
+			continue
+		}
+		l.warnings = append(l.warnings, LintWarning{
+			Field:   field,
+			Code:    LintUnsupportedAction,
+			Message: fmt.Sprintf("%s is sent as written: the raw_html engine only substitutes {{.Name}}", m[0]),
+		})
+	}
+}
+
+func (l *linter) checkVariable(field, name string) {
+	if l.referenced == nil {
+		l.referenced = make(map[string]bool)
+	}
+	l.referenced[name] = true
+	if l.known[name] {
+		return
+	}
+	key := field + "\x00" + name
+	if l.reported[key] {
+		return
+	}
+	if l.reported == nil {
+		l.reported = make(map[string]bool)
+	}
+	l.reported[key] = true
+
+	msg := fmt.Sprintf("Unknown variable %q: it is not well-known", name)
+	if l.typeCode != "" {
+		msg = fmt.Sprintf("Unknown variable %q: it is not declared by email type %s and is not well-known", name, l.typeCode)
+	}
+	l.warnings = append(l.warnings, LintWarning{
+		Field:    field,
+		Code:     LintUnknownVariable,
+		Variable: name,
+		Message:  msg,
+	})
+}
@@ -0,0 +1,96 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"gorm.io/datatypes"
+)
+
+var lintEmailType = &models.EmailType{
+	Code:      "order_shipped",
+	Variables: datatypes.JSON(`[{"name": "tracking_url"}]`),
+}
+
+func TestLintTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl models.EmailTemplate
+		want []LintWarning // Field, Code and Variable are compared
+	}{
+		{
+			name: "go template with declared, well-known and snake_case variables",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEngineGoTemplate,
+				Subject:        "Your order from {{.AppName}}",
+				BodyHTML:       `<a href="{{.TrackingUrl}}">Track</a> {{if .user_name}}Hi {{.user_name}}{{end}}`,
+				BodyText:       "Track at {{.tracking_url}}",
+			},
+		},
+		{
+			name: "go template with unknown variables",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEngineGoTemplate,
+				Subject:        "{{.OrderNumber}}",
+				BodyHTML:       `{{.OrderNumber}} {{if .Carrier}}{{.OrderNumber}}{{end}} {{with .AppName}}{{.Ignored}}{{$.Eta}}{{end}}`,
+			},
+			want: []LintWarning{
+				{Field: "subject", Code: LintUnknownVariable, Variable: "OrderNumber"},
+				{Field: "body_html", Code: LintUnknownVariable, Variable: "OrderNumber"},
+				{Field: "body_html", Code: LintUnknownVariable, Variable: "Carrier"},
+				{Field: "body_html", Code: LintUnknownVariable, Variable: "Eta"},
+			},
+		},
+		{
+			name: "go template that does not parse",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEngineGoTemplate,
+				Subject:        "Hello",
+				BodyHTML:       "{{if .AppName}}unclosed",
+			},
+			want: []LintWarning{{Field: "body_html", Code: LintParseError}},
+		},
+		{
+			name: "placeholder engine",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEnginePlaceholder,
+				Subject:        "{app_name}: {order_number}",
+				BodyHTML:       "<style>p{margin:0}</style><p>{tracking_url}</p>",
+			},
+			want: []LintWarning{{Field: "subject", Code: LintUnknownVariable, Variable: "order_number"}},
+		},
+		{
+			name: "raw html engine",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEngineRawHTML,
+				Subject:        "{{.AppName}}",
+				BodyHTML:       "{{.TrackingUrl}} {{.Missing}} {{if .AppName}}x{{end}}",
+			},
+			want: []LintWarning{
+				{Field: "body_html", Code: LintUnknownVariable, Variable: "Missing"},
+				{Field: "body_html", Code: LintUnsupportedAction},
+				{Field: "body_html", Code: LintUnsupportedAction},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LintTemplate(&tt.tmpl, lintEmailType)
+			if err != nil {
+				t.Fatalf("LintTemplate: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d warnings %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				if got[i].Field != w.Field || got[i].Code != w.Code || got[i].Variable != w.Variable {
+					t.Errorf("warning %d = %+v, want %+v", i, got[i], w)
+				}
+				if got[i].Message == "" {
+					t.Errorf("warning %d has no message", i)
+				}
+			}
+		})
+	}
+}
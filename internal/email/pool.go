@@ -0,0 +1,196 @@
+package email
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gopkg.in/mail.v2"
+)
+
+// poolKey identifies an SMTP server and account. Configs with the same
+// connection settings share connections; editing a config yields a new key,
+// and the old connections are closed once idle.
+type poolKey struct {
+	host     string
+	port     int
+	username string
+	password string
+	useTLS   bool
+}
+
+func poolKeyOf(config SMTPConfig) poolKey {
+	return poolKey{
+		host:     config.Host,
+		port:     config.Port,
+		username: config.Username,
+		password: config.Password,
+		useTLS:   config.UseTLS,
+	}
+}
+
+// smtpPool keeps SMTP connections open between messages so bursts of email
+// do not pay for a TCP/TLS handshake and login per message, and limits the
+// number of connections per server to stay under provider connection limits.
+type smtpPool struct {
+	maxConns    int           // Per server; senders beyond it wait for a connection
+	idleTimeout time.Duration // Idle connections are closed after this long
+
+	// dial opens a connection; replaced in tests
+	dial func(d *mail.Dialer) (mail.SendCloser, error)
+
+	mu        sync.Mutex
+	servers   map[poolKey]*serverPool
+	reapTimer *time.Timer // Pending run of reap; nil when no connection is idle
+	closed    bool
+}
+
+// serverPool holds the connections to one server.
+type serverPool struct {
+	slots chan struct{} // One token per connection in use
+	idle  []*pooledConn // Most recently used last
+}
+
+type pooledConn struct {
+	sc       mail.SendCloser
+	lastUsed time.Time
+}
+
+func newSMTPPool(maxConns int, idleTimeout time.Duration) *smtpPool {
+	return &smtpPool{
+		maxConns:    maxConns,
+		idleTimeout: idleTimeout,
+		dial:        func(d *mail.Dialer) (mail.SendCloser, error) { return d.Dial() },
+		servers:     make(map[poolKey]*serverPool),
+	}
+}
+
+// send delivers m through a pooled connection to the server of config,
+// dialing with d if no idle connection is available. A reused connection
+// that fails (e.g. the server closed it while idle) is discarded and the
+// message is retried once on a new connection.
+func (p *smtpPool) send(config SMTPConfig, d *mail.Dialer, m *mail.Message) error {
+	sp := p.server(poolKeyOf(config))
+	sp.slots <- struct{}{}
+	defer func() { <-sp.slots }()
+
+	if conn := p.takeIdle(sp); conn != nil {
+		if err := mail.Send(conn.sc, m); err == nil {
+			p.putIdle(sp, conn)
+			return nil
+		}
+		_ = conn.sc.Close()
+	}
+
+	sc, err := p.dial(d)
+	if err != nil {
+		return err
+	}
+	if err := mail.Send(sc, m); err != nil {
+		_ = sc.Close()
+		return err
+	}
+	p.putIdle(sp, &pooledConn{sc: sc})
+	return nil
+}
+
+func (p *smtpPool) server(key poolKey) *serverPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sp, ok := p.servers[key]
+	if !ok {
+		sp = &serverPool{slots: make(chan struct{}, p.maxConns)}
+		p.servers[key] = sp
+	}
+	return sp
+}
+
+// takeIdle returns the most recently used idle connection of sp that has
+// not expired, or nil.
+func (p *smtpPool) takeIdle(sp *serverPool) *pooledConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(sp.idle) > 0 {
+		conn := sp.idle[len(sp.idle)-1]
+		sp.idle = sp.idle[:len(sp.idle)-1]
+		if time.Since(conn.lastUsed) < p.idleTimeout {
+			return conn
+		}
+		go closeConn(conn) // Expired; the server may already have dropped it
+	}
+	return nil
+}
+
+func (p *smtpPool) putIdle(sp *serverPool, conn *pooledConn) {
+	conn.lastUsed = time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		go closeConn(conn) // The pool was shut down while the message was sent
+		return
+	}
+	sp.idle = append(sp.idle, conn)
+	if p.reapTimer == nil {
+		p.reapTimer = time.AfterFunc(p.idleTimeout, p.reap)
+	}
+}
+
+// reap closes the connections that have been idle for idleTimeout and
+// schedules itself again while any connection is idle.
+func (p *smtpPool) reap() {
+	var expired []*pooledConn
+	p.mu.Lock()
+	p.reapTimer = nil
+	next := time.Duration(-1)
+	for _, sp := range p.servers {
+		kept := sp.idle[:0]
+		for _, conn := range sp.idle {
+			if age := time.Since(conn.lastUsed); age >= p.idleTimeout {
+				expired = append(expired, conn)
+			} else {
+				kept = append(kept, conn)
+				if wait := p.idleTimeout - age; next < 0 || wait < next {
+					next = wait
+				}
+			}
+		}
+		sp.idle = kept
+	}
+	if next >= 0 && !p.closed {
+		p.reapTimer = time.AfterFunc(next, p.reap)
+	}
+	p.mu.Unlock()
+
+	for _, conn := range expired {
+		closeConn(conn)
+	}
+}
+
+// close closes all idle connections. Connections in use are closed when
+// their message has been sent.
+func (p *smtpPool) close() {
+	var idle []*pooledConn
+	p.mu.Lock()
+	p.closed = true
+	if p.reapTimer != nil {
+		p.reapTimer.Stop()
+		p.reapTimer = nil
+	}
+	for _, sp := range p.servers {
+		idle = append(idle, sp.idle...)
+		sp.idle = nil
+	}
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		closeConn(conn)
+	}
+}
+
+// closeConn ends an SMTP session. Errors are logged only, as the server may
+// already have closed an idle connection.
+func closeConn(conn *pooledConn) {
+	if err := conn.sc.Close(); err != nil {
+		log.Printf("Failed to close pooled SMTP connection: %v", err)
+	}
+}
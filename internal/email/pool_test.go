@@ -0,0 +1,201 @@
+package email
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/mail.v2"
+)
+
+// fakeConn is an SMTP connection that records the messages sent through it.
+type fakeConn struct {
+	mu      sync.Mutex
+	sent    int
+	fail    bool // Send fails, as on a connection the server has closed
+	closed  bool
+	inSend  *int32
+	maxSeen *int32
+}
+
+func (c *fakeConn) Send(from string, to []string, msg io.WriterTo) error {
+	if c.inSend != nil {
+		n := atomic.AddInt32(c.inSend, 1)
+		defer atomic.AddInt32(c.inSend, -1)
+		for {
+			max := atomic.LoadInt32(c.maxSeen)
+			if n <= max || atomic.CompareAndSwapInt32(c.maxSeen, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return errors.New("connection reset")
+	}
+	c.sent++
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// newTestPool returns a pool whose dials create fakeConns, and the list of
+// connections dialed so far.
+func newTestPool(maxConns int, idleTimeout time.Duration) (*smtpPool, func() []*fakeConn) {
+	var mu sync.Mutex
+	var conns []*fakeConn
+	p := newSMTPPool(maxConns, idleTimeout)
+	p.dial = func(d *mail.Dialer) (mail.SendCloser, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := &fakeConn{}
+		conns = append(conns, c)
+		return c, nil
+	}
+	return p, func() []*fakeConn {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*fakeConn(nil), conns...)
+	}
+}
+
+func testMessage() *mail.Message {
+	m := mail.NewMessage()
+	m.SetHeader("From", "noreply@example.com")
+	m.SetHeader("To", "user@example.com")
+	m.SetHeader("Subject", "Hello")
+	m.SetBody("text/plain", "Hello")
+	return m
+}
+
+var testSMTPConfig = SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "u", Password: "p"}
+
+func TestSMTPPoolReusesConnection(t *testing.T) {
+	p, dialed := newTestPool(2, time.Minute)
+	defer p.close()
+
+	for i := 0; i < 3; i++ {
+		if err := p.send(testSMTPConfig, nil, testMessage()); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	conns := dialed()
+	if len(conns) != 1 {
+		t.Fatalf("dialed %d connections, want 1", len(conns))
+	}
+	if conns[0].sent != 3 {
+		t.Errorf("sent %d messages on the connection, want 3", conns[0].sent)
+	}
+
+	other := testSMTPConfig
+	other.Username = "other"
+	if err := p.send(other, nil, testMessage()); err != nil {
+		t.Fatalf("send with other account: %v", err)
+	}
+	if got := len(dialed()); got != 2 {
+		t.Errorf("dialed %d connections after switching account, want 2", got)
+	}
+}
+
+func TestSMTPPoolRetriesStaleConnection(t *testing.T) {
+	p, dialed := newTestPool(1, time.Minute)
+	defer p.close()
+
+	if err := p.send(testSMTPConfig, nil, testMessage()); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	stale := dialed()[0]
+	stale.fail = true
+
+	if err := p.send(testSMTPConfig, nil, testMessage()); err != nil {
+		t.Fatalf("send on stale connection: %v", err)
+	}
+	conns := dialed()
+	if len(conns) != 2 {
+		t.Fatalf("dialed %d connections, want a second one after the failure", len(conns))
+	}
+	if !stale.isClosed() {
+		t.Error("stale connection was not closed")
+	}
+	if conns[1].sent != 1 {
+		t.Errorf("retry sent %d messages, want 1", conns[1].sent)
+	}
+}
+
+func TestSMTPPoolLimitsConnections(t *testing.T) {
+	var inSend, maxSeen int32
+	p := newSMTPPool(2, time.Minute)
+	defer p.close()
+	p.dial = func(d *mail.Dialer) (mail.SendCloser, error) {
+		return &fakeConn{inSend: &inSend, maxSeen: &maxSeen}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.send(testSMTPConfig, nil, testMessage()); err != nil {
+				t.Errorf("send: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxSeen > 2 {
+		t.Errorf("%d messages were sent at once, want at most 2", maxSeen)
+	}
+}
+
+func TestSMTPPoolClosesIdleConnections(t *testing.T) {
+	p, dialed := newTestPool(1, 20*time.Millisecond)
+	defer p.close()
+
+	if err := p.send(testSMTPConfig, nil, testMessage()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	conn := dialed()[0]
+
+	deadline := time.Now().Add(time.Second)
+	for !conn.isClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("idle connection was not closed after the idle timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := p.send(testSMTPConfig, nil, testMessage()); err != nil {
+		t.Fatalf("send after idle timeout: %v", err)
+	}
+	if got := len(dialed()); got != 2 {
+		t.Errorf("dialed %d connections, want a new one after the idle timeout", got)
+	}
+}
+
+func TestSMTPPoolCloseClosesIdleConnections(t *testing.T) {
+	p, dialed := newTestPool(1, time.Minute)
+
+	if err := p.send(testSMTPConfig, nil, testMessage()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	p.close()
+	if !dialed()[0].isClosed() {
+		t.Error("close left the idle connection open")
+	}
+}
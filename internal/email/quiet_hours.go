@@ -0,0 +1,171 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// JobTypeEmailDeferred is the background job type for emails deferred by an
+// application's quiet hours.
+const JobTypeEmailDeferred = "email_deferred"
+
+// QuietHours is the daily window, in local time, during which an application's
+// product emails are not sent. A window whose end is earlier than its start
+// runs overnight.
+type QuietHours struct {
+	Start    int // Minutes after local midnight
+	End      int
+	Location *time.Location
+}
+
+// ParseQuietHours parses quiet hours configured as "HH:MM" start and end times
+// in an IANA time zone (UTC when empty). It returns nil when start and end are
+// both empty.
+func ParseQuietHours(start, end, timeZone string) (*QuietHours, error) {
+	start, end, timeZone = strings.TrimSpace(start), strings.TrimSpace(end), strings.TrimSpace(timeZone)
+	if start == "" && end == "" {
+		return nil, nil
+	}
+	if start == "" || end == "" {
+		return nil, fmt.Errorf("quiet hours need both a start and an end time")
+	}
+	q := &QuietHours{Location: time.UTC}
+	var err error
+	if q.Start, err = parseClock(start); err != nil {
+		return nil, err
+	}
+	if q.End, err = parseClock(end); err != nil {
+		return nil, err
+	}
+	if q.Start == q.End {
+		return nil, fmt.Errorf("quiet hours must not start and end at the same time")
+	}
+	if timeZone != "" {
+		if q.Location, err = time.LoadLocation(timeZone); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q; use an IANA name such as Europe/Berlin", timeZone)
+		}
+	}
+	return q, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q; use HH:MM (24-hour)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Until returns when the quiet hours that include now end, or false when now is
+// outside the quiet hours.
+func (q *QuietHours) Until(now time.Time) (time.Time, bool) {
+	local := now.In(q.Location)
+	minute := local.Hour()*60 + local.Minute()
+	day := 0
+	switch {
+	case q.Start < q.End && minute >= q.Start && minute < q.End:
+	case q.Start > q.End && minute >= q.Start:
+		day = 1 // Overnight window, before midnight
+	case q.Start > q.End && minute < q.End:
+	default:
+		return time.Time{}, false
+	}
+	y, m, d := local.Date()
+	return time.Date(y, m, d+day, q.End/60, q.End%60, 0, 0, q.Location), true
+}
+
+// SetJobQueue enables quiet hours: product emails sent during an application's
+// quiet hours are queued as email_deferred jobs that run when the hours end.
+// Without a job queue they are sent immediately.
+func (s *Service) SetJobQueue(q *jobqueue.Queue) {
+	s.jobs = q
+}
+
+// DeferUntil returns when an email of the type may be sent, or nil when it may
+// be sent now. Only product emails are deferred; transactional emails (2FA
+// codes, password resets, verification) and security alerts always go out
+// immediately.
+func (s *Service) DeferUntil(appID uuid.UUID, emailTypeCode string, now time.Time) *time.Time {
+	if s.jobs == nil || s.db == nil || s.emailCategory(emailTypeCode) != models.EmailCategoryProduct {
+		return nil
+	}
+	var app models.Application
+	if err := s.db.Select("email_quiet_hours_start, email_quiet_hours_end, email_time_zone").
+		First(&app, "id = ?", appID).Error; err != nil {
+		return nil
+	}
+	q, err := ParseQuietHours(app.EmailQuietHoursStart, app.EmailQuietHoursEnd, app.EmailTimeZone)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid email quiet hours of app %s: %v", appID, err)
+		return nil
+	}
+	if q == nil {
+		return nil
+	}
+	if until, ok := q.Until(now); ok {
+		return &until
+	}
+	return nil
+}
+
+// DeferredEmailPayload is the input of an email_deferred background job.
+type DeferredEmailPayload struct {
+	AppID     string            `json:"app_id"`
+	TypeCode  string            `json:"type_code"`
+	ToEmail   string            `json:"to_email"`
+	UserID    string            `json:"user_id,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// deferEmail queues an email to be sent at until.
+func (s *Service) deferEmail(appID uuid.UUID, emailTypeCode, toEmail string, userID *uuid.UUID, vars map[string]string, until time.Time) error {
+	payload := DeferredEmailPayload{AppID: appID.String(), TypeCode: emailTypeCode, ToEmail: toEmail, Variables: vars}
+	if userID != nil {
+		payload.UserID = userID.String()
+	}
+	if _, err := s.jobs.EnqueueAt(JobTypeEmailDeferred, payload, "email_quiet_hours", until); err != nil {
+		return fmt.Errorf("failed to defer %s email until the quiet hours end: %w", emailTypeCode, err)
+	}
+	return nil
+}
+
+// RunDeferredEmailJob sends an email deferred by quiet hours. Its signature
+// matches jobqueue.HandlerFunc.
+func (s *Service) RunDeferredEmailJob(_ context.Context, task *jobqueue.Task) (interface{}, error) {
+	var payload DeferredEmailPayload
+	if err := task.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	appID, err := uuid.Parse(payload.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid app_id %q", payload.AppID)
+	}
+	var userID *uuid.UUID
+	if payload.UserID != "" {
+		id, err := uuid.Parse(payload.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user_id %q", payload.UserID)
+		}
+		userID = &id
+	}
+
+	_, err = s.send(appID, payload.TypeCode, payload.ToEmail, userID, payload.Variables, false)
+	if errors.Is(err, ErrUnsubscribed) {
+		// The recipient opted out while the email waited
+		task.SetSummary(fmt.Sprintf("%s email to %s skipped: %v", payload.TypeCode, payload.ToEmail, err))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	task.SetSummary(fmt.Sprintf("%s email sent to %s", payload.TypeCode, payload.ToEmail))
+	return nil, nil
+}
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	if q, err := ParseQuietHours("", " ", "Europe/Berlin"); q != nil || err != nil {
+		t.Errorf("ParseQuietHours(empty) = %v, %v, want no quiet hours", q, err)
+	}
+	for _, tt := range [][3]string{
+		{"22:00", "", ""},
+		{"22:00", "22:00", ""},
+		{"25:00", "07:00", ""},
+		{"10pm", "07:00", ""},
+		{"22:00", "07:00", "Mars/Olympus"},
+	} {
+		if _, err := ParseQuietHours(tt[0], tt[1], tt[2]); err == nil {
+			t.Errorf("ParseQuietHours(%q, %q, %q) succeeded, want an error", tt[0], tt[1], tt[2])
+		}
+	}
+
+	q, err := ParseQuietHours("22:30", "07:00", "")
+	if err != nil {
+		t.Fatalf("ParseQuietHours: %v", err)
+	}
+	if q.Start != 22*60+30 || q.End != 7*60 || q.Location != time.UTC {
+		t.Errorf("ParseQuietHours() = %+v, want 22:30-07:00 UTC", q)
+	}
+}
+
+func TestQuietHoursUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	overnight := &QuietHours{Start: 22 * 60, End: 7 * 60, Location: berlin}
+	daytime := &QuietHours{Start: 12 * 60, End: 13 * 60, Location: time.UTC}
+
+	tests := []struct {
+		name string
+		q    *QuietHours
+		now  time.Time
+		want time.Time // zero = not quiet
+	}{
+		{"overnight, before midnight", overnight, time.Date(2026, 10, 16, 23, 15, 0, 0, berlin), time.Date(2026, 10, 17, 7, 0, 0, 0, berlin)},
+		{"overnight, after midnight", overnight, time.Date(2026, 10, 17, 3, 0, 0, 0, berlin), time.Date(2026, 10, 17, 7, 0, 0, 0, berlin)},
+		{"overnight, at the end", overnight, time.Date(2026, 10, 17, 7, 0, 0, 0, berlin), time.Time{}},
+		{"overnight, daytime", overnight, time.Date(2026, 10, 16, 15, 0, 0, 0, berlin), time.Time{}},
+		{"overnight, in another zone", overnight, time.Date(2026, 10, 16, 21, 30, 0, 0, time.UTC), time.Date(2026, 10, 17, 7, 0, 0, 0, berlin)},
+		{"overnight, across a DST change", overnight, time.Date(2026, 10, 24, 23, 0, 0, 0, berlin), time.Date(2026, 10, 25, 7, 0, 0, 0, berlin)},
+		{"daytime, inside", daytime, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)},
+		{"daytime, outside", daytime, time.Date(2026, 10, 16, 11, 59, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.q.Until(tt.now)
+			if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+				t.Errorf("Until(%v) = %v, %v, want %v", tt.now, got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -128,9 +128,14 @@ func (r *Renderer) buildTemplateData(vars map[string]string) map[string]interfac
 	for k, v := range vars {
 		// Convert snake_case to PascalCase for Go template
 		pascalKey := snakeToPascal(k)
-		data[pascalKey] = v
+		var value interface{} = v
+		if k == VarBrandFooter {
+			// The branding block is HTML built from escaped values (see branding.go)
+			value = template.HTML(v) // #nosec G203 -- every configured value is escaped when the block is built
+		}
+		data[pascalKey] = value
 		// Also keep the original key for flexibility
-		data[k] = v
+		data[k] = value
 	}
 	return data
 }
@@ -1,9 +1,14 @@
 package email
 
 import (
+	"time"
+
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository handles database operations for the email system.
@@ -42,6 +47,19 @@ func (r *Repository) GetServerConfig(appID uuid.UUID) (*models.EmailServerConfig
 	return &config, nil
 }
 
+// GetParentServerConfig returns the default active SMTP configuration of the parent
+// application when appID is an environment. Returns nil, nil otherwise.
+func (r *Repository) GetParentServerConfig(appID uuid.UUID) (*models.EmailServerConfig, error) {
+	var parentID *uuid.UUID
+	if err := r.DB.Model(&models.Application{}).Select("parent_app_id").Where("id = ?", appID).Scan(&parentID).Error; err != nil {
+		return nil, err
+	}
+	if parentID == nil {
+		return nil, nil
+	}
+	return r.GetServerConfig(*parentID)
+}
+
 // GetServerConfigByID returns an SMTP configuration by its ID.
 func (r *Repository) GetServerConfigByID(id uuid.UUID) (*models.EmailServerConfig, error) {
 	var config models.EmailServerConfig
@@ -80,6 +98,42 @@ func (r *Repository) GetServerConfigsByApp(appID uuid.UUID) ([]models.EmailServe
 	return configs, nil
 }
 
+// ServerConfigListSpec is the sorting and filtering of the Admin API SMTP
+// config list.
+var ServerConfigListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"name":       "name",
+		"smtp_host":  "smtp_host",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	DefaultSort:  "name",
+	DefaultOrder: pagination.OrderAsc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"app_id":     {Column: "app_id", Kind: pagination.FilterUUID},
+		"name":       {Column: "name", Kind: pagination.FilterContains},
+		"smtp_host":  {Column: "smtp_host", Kind: pagination.FilterExact},
+		"is_default": {Column: "is_default", Kind: pagination.FilterBool},
+		"is_active":  {Column: "is_active", Kind: pagination.FilterBool},
+	},
+}
+
+// ListServerConfigs returns a page of SMTP configurations sorted and filtered by q.
+func (r *Repository) ListServerConfigs(q pagination.ListQuery) ([]models.EmailServerConfig, int64, error) {
+	var configs []models.EmailServerConfig
+	var total int64
+
+	query := q.ApplyFilters(r.DB.Model(&models.EmailServerConfig{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.ApplyPage(query).Find(&configs).Error; err != nil {
+		return nil, 0, err
+	}
+	return configs, total, nil
+}
+
 // GetAllServerConfigs returns all SMTP configurations across all applications and global.
 // Global configs (app_id IS NULL) are returned first.
 func (r *Repository) GetAllServerConfigs() ([]models.EmailServerConfig, error) {
@@ -280,6 +334,46 @@ func (r *Repository) GetTemplatesByApp(appID uuid.UUID) ([]models.EmailTemplate,
 	return templates, nil
 }
 
+// TemplateListSpec is the sorting and filtering of the Admin API email template
+// list.
+var TemplateListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"created_at": "email_templates.created_at",
+		"updated_at": "email_templates.updated_at",
+		"name":       "email_templates.name",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderAsc,
+	TieBreaker:   "email_templates.id",
+	Filters: map[string]pagination.Filter{
+		"app_id":          {Column: "email_templates.app_id", Kind: pagination.FilterUUID},
+		"email_type_id":   {Column: "email_templates.email_type_id", Kind: pagination.FilterUUID},
+		"name":            {Column: "email_templates.name", Kind: pagination.FilterContains},
+		"template_engine": {Column: "email_templates.template_engine", Kind: pagination.FilterExact},
+		"is_active":       {Column: "email_templates.is_active", Kind: pagination.FilterBool},
+		"external_id":     {Column: "email_templates.external_id", Kind: pagination.FilterExact},
+	},
+}
+
+// ListTemplates returns a page of templates with their email types, sorted and
+// filtered by q. Without an app_id filter the global defaults are listed.
+func (r *Repository) ListTemplates(q pagination.ListQuery) ([]models.EmailTemplate, int64, error) {
+	var templates []models.EmailTemplate
+	var total int64
+
+	query := q.ApplyFilters(r.DB.Model(&models.EmailTemplate{}))
+	if q.Filter("app_id") == "" {
+		query = query.Where("email_templates.app_id IS NULL")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.ApplyPage(query).Preload("EmailType").Find(&templates).Error; err != nil {
+		return nil, 0, err
+	}
+	return templates, total, nil
+}
+
 // GetGlobalDefaultTemplates returns all global default templates (app_id IS NULL).
 func (r *Repository) GetGlobalDefaultTemplates() ([]models.EmailTemplate, error) {
 	var templates []models.EmailTemplate
@@ -291,6 +385,17 @@ func (r *Repository) GetGlobalDefaultTemplates() ([]models.EmailTemplate, error)
 	return templates, nil
 }
 
+// GetAllTemplates returns every template, global defaults first.
+func (r *Repository) GetAllTemplates() ([]models.EmailTemplate, error) {
+	var templates []models.EmailTemplate
+	err := r.DB.Preload("EmailType").
+		Order("app_id IS NOT NULL, app_id, created_at asc").Find(&templates).Error
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
 // GetTemplateByID returns a template by its ID.
 func (r *Repository) GetTemplateByID(id uuid.UUID) (*models.EmailTemplate, error) {
 	var template models.EmailTemplate
@@ -314,28 +419,37 @@ func (r *Repository) UpdateTemplate(template *models.EmailTemplate) error {
 	return r.DB.Save(template).Error
 }
 
+// UpdateTemplateContent replaces the editable fields of template id with those
+// of template, provided guard allows it (optlock.ErrConflict otherwise).
+func (r *Repository) UpdateTemplateContent(id uuid.UUID, template *models.EmailTemplate, guard optlock.Guard) error {
+	return optlock.Updates(r.DB, &models.EmailTemplate{}, id, guard, map[string]interface{}{
+		"name":             template.Name,
+		"subject":          template.Subject,
+		"body_html":        template.BodyHTML,
+		"body_text":        template.BodyText,
+		"template_engine":  template.TemplateEngine,
+		"from_email":       template.FromEmail,
+		"from_name":        template.FromName,
+		"server_config_id": template.ServerConfigID,
+		"is_active":        template.IsActive,
+	})
+}
+
 // DeleteTemplate removes an email template.
 func (r *Repository) DeleteTemplate(id uuid.UUID) error {
 	return r.DB.Where("id = ?", id).Delete(&models.EmailTemplate{}).Error
 }
 
 // UpsertAppTemplate creates or updates a template for a specific app and email type.
-func (r *Repository) UpsertAppTemplate(appID uuid.UUID, emailTypeID uuid.UUID, template *models.EmailTemplate) error {
+// guard applies to the update of an existing template (optlock.ErrConflict when
+// it changed after the edit was loaded).
+func (r *Repository) UpsertAppTemplate(appID uuid.UUID, emailTypeID uuid.UUID, template *models.EmailTemplate, guard optlock.Guard) error {
 	// Check if one already exists
 	var existing models.EmailTemplate
 	err := r.DB.Where("app_id = ? AND email_type_id = ?", appID, emailTypeID).First(&existing).Error
 	if err == nil {
 		// Update existing
-		existing.Name = template.Name
-		existing.Subject = template.Subject
-		existing.BodyHTML = template.BodyHTML
-		existing.BodyText = template.BodyText
-		existing.TemplateEngine = template.TemplateEngine
-		existing.FromEmail = template.FromEmail
-		existing.FromName = template.FromName
-		existing.ServerConfigID = template.ServerConfigID
-		existing.IsActive = template.IsActive
-		return r.DB.Save(&existing).Error
+		return r.UpdateTemplateContent(existing.ID, template, guard)
 	}
 	if err != gorm.ErrRecordNotFound {
 		return err
@@ -348,20 +462,12 @@ func (r *Repository) UpsertAppTemplate(appID uuid.UUID, emailTypeID uuid.UUID, t
 }
 
 // UpsertGlobalTemplate creates or updates a global default template for an email type.
-func (r *Repository) UpsertGlobalTemplate(emailTypeID uuid.UUID, template *models.EmailTemplate) error {
+// guard applies as in UpsertAppTemplate.
+func (r *Repository) UpsertGlobalTemplate(emailTypeID uuid.UUID, template *models.EmailTemplate, guard optlock.Guard) error {
 	var existing models.EmailTemplate
 	err := r.DB.Where("app_id IS NULL AND email_type_id = ?", emailTypeID).First(&existing).Error
 	if err == nil {
-		existing.Name = template.Name
-		existing.Subject = template.Subject
-		existing.BodyHTML = template.BodyHTML
-		existing.BodyText = template.BodyText
-		existing.TemplateEngine = template.TemplateEngine
-		existing.FromEmail = template.FromEmail
-		existing.FromName = template.FromName
-		existing.ServerConfigID = template.ServerConfigID
-		existing.IsActive = template.IsActive
-		return r.DB.Save(&existing).Error
+		return r.UpdateTemplateContent(existing.ID, template, guard)
 	}
 	if err != gorm.ErrRecordNotFound {
 		return err
@@ -371,3 +477,262 @@ func (r *Repository) UpsertGlobalTemplate(emailTypeID uuid.UUID, template *model
 	template.EmailTypeID = emailTypeID
 	return r.DB.Create(template).Error
 }
+
+// ============================================================================
+// Suppression list operations
+// ============================================================================
+
+// SuppressionListSpec is the sorting and filtering of an application's
+// suppression list.
+var SuppressionListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"email":      "email",
+		"reason":     "reason",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"app_id": {Column: "app_id", Kind: pagination.FilterUUID},
+		"email":  {Column: "email", Kind: pagination.FilterContains},
+		"reason": {Column: "reason", Kind: pagination.FilterExact},
+	},
+}
+
+// ListSuppressions returns a page of suppressed addresses sorted and filtered by q.
+func (r *Repository) ListSuppressions(q pagination.ListQuery) ([]models.EmailSuppression, int64, error) {
+	var suppressions []models.EmailSuppression
+	var total int64
+
+	query := q.ApplyFilters(r.DB.Model(&models.EmailSuppression{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.ApplyPage(query).Find(&suppressions).Error; err != nil {
+		return nil, 0, err
+	}
+	return suppressions, total, nil
+}
+
+// AddSuppression adds an address to an application's suppression list. An
+// address that is already suppressed keeps its original reason.
+func (r *Repository) AddSuppression(suppression *models.EmailSuppression) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}, {Name: "email"}},
+		DoNothing: true,
+	}).Create(suppression).Error
+}
+
+// DeleteSuppression removes an address from an application's suppression list.
+// It returns false when the address was not suppressed.
+func (r *Repository) DeleteSuppression(appID uuid.UUID, email string) (bool, error) {
+	result := r.DB.Where("app_id = ? AND email = ?", appID, email).Delete(&models.EmailSuppression{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SuppressedEmails returns which of the given (lowercased) addresses are on an
+// application's suppression list.
+func (r *Repository) SuppressedEmails(appID uuid.UUID, emails []string) (map[string]bool, error) {
+	suppressed := make(map[string]bool)
+	if len(emails) == 0 {
+		return suppressed, nil
+	}
+	var found []string
+	if err := r.DB.Model(&models.EmailSuppression{}).
+		Where("app_id = ? AND email IN ?", appID, emails).
+		Pluck("email", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, e := range found {
+		suppressed[e] = true
+	}
+	return suppressed, nil
+}
+
+// ============================================================================
+// Sending domain operations
+// ============================================================================
+
+// ListSendingDomains returns the sending domains of a tenant, by domain.
+func (r *Repository) ListSendingDomains(tenantID uuid.UUID) ([]models.TenantEmailDomain, error) {
+	var domains []models.TenantEmailDomain
+	err := r.DB.Where("tenant_id = ?", tenantID).Order("domain").Find(&domains).Error
+	return domains, err
+}
+
+// ListAppSendingDomains returns the sending domains of an application's tenant.
+func (r *Repository) ListAppSendingDomains(appID uuid.UUID) ([]models.TenantEmailDomain, error) {
+	var domains []models.TenantEmailDomain
+	err := r.DB.Where("tenant_id = (SELECT tenant_id FROM applications WHERE id = ?)", appID).
+		Order("domain").Find(&domains).Error
+	return domains, err
+}
+
+// GetSendingDomain returns a sending domain of a tenant.
+// Returns nil, nil if not found.
+func (r *Repository) GetSendingDomain(tenantID, id uuid.UUID) (*models.TenantEmailDomain, error) {
+	var domain models.TenantEmailDomain
+	err := r.DB.First(&domain, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// CreateSendingDomain registers a sending domain. It returns false when the
+// tenant already registered the domain.
+func (r *Repository) CreateSendingDomain(domain *models.TenantEmailDomain) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "domain"}},
+		DoNothing: true,
+	}).Create(domain)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateSendingDomain saves the verification state of a sending domain.
+func (r *Repository) UpdateSendingDomain(domain *models.TenantEmailDomain) error {
+	return r.DB.Model(domain).Select("status", "verified_at", "last_checked_at", "last_error").Updates(domain).Error
+}
+
+// DeleteSendingDomain removes a sending domain of a tenant. It returns false
+// when the domain was not found.
+func (r *Repository) DeleteSendingDomain(tenantID, id uuid.UUID) (bool, error) {
+	result := r.DB.Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&models.TenantEmailDomain{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ============================================================================
+// Template variant operations
+// ============================================================================
+
+// VariantListSpec is the sorting and filtering of an application's template
+// variants.
+var VariantListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"name":       "name",
+		"weight":     "weight",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderAsc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"app_id":        {Column: "app_id", Kind: pagination.FilterUUID},
+		"email_type_id": {Column: "email_type_id", Kind: pagination.FilterUUID},
+		"is_active":     {Column: "is_active", Kind: pagination.FilterBool},
+	},
+}
+
+// ListVariants returns a page of template variants sorted and filtered by q.
+func (r *Repository) ListVariants(q pagination.ListQuery) ([]models.EmailTemplateVariant, int64, error) {
+	var variants []models.EmailTemplateVariant
+	var total int64
+
+	query := q.ApplyFilters(r.DB.Model(&models.EmailTemplateVariant{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.ApplyPage(query).Find(&variants).Error; err != nil {
+		return nil, 0, err
+	}
+	return variants, total, nil
+}
+
+// GetVariantsByType returns the template variants of an application's email
+// type, oldest first.
+func (r *Repository) GetVariantsByType(appID, emailTypeID uuid.UUID) ([]models.EmailTemplateVariant, error) {
+	var variants []models.EmailTemplateVariant
+	err := r.DB.Where("app_id = ? AND email_type_id = ?", appID, emailTypeID).
+		Order("created_at asc, id asc").Find(&variants).Error
+	return variants, err
+}
+
+// ActiveVariants returns the active template variants of an application for
+// an email type code, oldest first.
+func (r *Repository) ActiveVariants(appID uuid.UUID, typeCode string) ([]models.EmailTemplateVariant, error) {
+	var variants []models.EmailTemplateVariant
+	err := r.DB.Joins("JOIN email_types ON email_types.id = email_template_variants.email_type_id").
+		Where("email_template_variants.app_id = ? AND email_types.code = ? AND email_template_variants.is_active = ?", appID, typeCode, true).
+		Order("email_template_variants.created_at asc, email_template_variants.id asc").
+		Find(&variants).Error
+	return variants, err
+}
+
+// GetVariant returns a template variant of an application, or nil if there is none.
+func (r *Repository) GetVariant(appID, id uuid.UUID) (*models.EmailTemplateVariant, error) {
+	var variant models.EmailTemplateVariant
+	err := r.DB.Where("app_id = ? AND id = ?", appID, id).First(&variant).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// VariantNameTaken reports whether another variant of the application's email
+// type has the given name.
+func (r *Repository) VariantNameTaken(appID, emailTypeID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.EmailTemplateVariant{}).
+		Where("app_id = ? AND email_type_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", appID, emailTypeID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ActiveVariantWeight returns the total weight of the active variants of an
+// application's email type, other than excludeID.
+func (r *Repository) ActiveVariantWeight(appID, emailTypeID uuid.UUID, excludeID uuid.UUID) (int, error) {
+	var total int
+	err := r.DB.Model(&models.EmailTemplateVariant{}).
+		Where("app_id = ? AND email_type_id = ? AND is_active = ? AND id <> ?", appID, emailTypeID, true, excludeID).
+		Select("COALESCE(SUM(weight), 0)").Scan(&total).Error
+	return total, err
+}
+
+// SaveVariant creates or updates a template variant.
+func (r *Repository) SaveVariant(variant *models.EmailTemplateVariant) error {
+	return r.DB.Save(variant).Error
+}
+
+// DeleteVariant deletes a template variant with its send statistics.
+func (r *Repository) DeleteVariant(variant *models.EmailTemplateVariant) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("app_id = ? AND email_type_id = ? AND variant_id = ?", variant.AppID, variant.EmailTypeID, variant.ID).
+			Delete(&models.EmailVariantStat{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(variant).Error
+	})
+}
+
+// RecordVariantSend counts one email sent with a variant (uuid.Nil for the
+// control), as sent or as failed.
+func (r *Repository) RecordVariantSend(appID, emailTypeID, variantID uuid.UUID, sent bool, at time.Time) error {
+	stat := models.EmailVariantStat{AppID: appID, EmailTypeID: emailTypeID, VariantID: variantID}
+	updates := map[string]interface{}{}
+	if sent {
+		stat.Sent, stat.LastSentAt = 1, &at
+		updates["sent"] = gorm.Expr("email_variant_stats.sent + 1")
+		updates["last_sent_at"] = at
+	} else {
+		stat.Failed = 1
+		updates["failed"] = gorm.Expr("email_variant_stats.failed + 1")
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}, {Name: "email_type_id"}, {Name: "variant_id"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&stat).Error
+}
+
+// VariantStats returns the send statistics of an application's email type.
+func (r *Repository) VariantStats(appID, emailTypeID uuid.UUID) ([]models.EmailVariantStat, error) {
+	var stats []models.EmailVariantStat
+	err := r.DB.Where("app_id = ? AND email_type_id = ?", appID, emailTypeID).Find(&stats).Error
+	return stats, err
+}
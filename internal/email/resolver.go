@@ -12,6 +12,7 @@ import (
 
 // VariableResolver handles the multi-source resolution of email template variables.
 // Resolution priority (highest wins):
+//  0. Reserved branding variables (brand_*), which callers cannot override
 //  1. Explicit variables passed by the caller
 //  2. User profile fields (when userID is provided)
 //  3. App/system settings (app_name, frontend_url, etc.)
@@ -27,7 +28,7 @@ func NewVariableResolver(db *gorm.DB) *VariableResolver {
 
 // ResolveVariables builds the final variable map by merging values from all sources.
 // The resolution pipeline applies values in order of increasing priority:
-// static defaults -> settings -> user fields -> explicit vars.
+// static defaults -> settings -> user fields -> explicit vars -> branding.
 func (r *VariableResolver) ResolveVariables(
 	appID uuid.UUID,
 	emailTypeCode string,
@@ -60,6 +61,9 @@ func (r *VariableResolver) ResolveVariables(
 		}
 	}
 
+	// Reserved branding variables always come from the application
+	r.applyBrandingVars(resolved, appID)
+
 	return resolved
 }
 
@@ -100,7 +104,26 @@ func (r *VariableResolver) applySettingsVars(vars map[string]string, appID uuid.
 	vars[VarAppName] = r.resolveAppName(appID)
 
 	// frontend_url: per-app FrontendURL → FRONTEND_URL env var → default
-	vars[VarFrontendURL] = r.resolveAppFrontendURL(appID)
+	if frontendURL, err := r.resolveAppFrontendURL(appID); err == nil {
+		vars[VarFrontendURL] = frontendURL
+	} else {
+		log.Printf("Warning: frontend URL of app %s not exposed to email templates: %v", appID, err)
+	}
+}
+
+// applyBrandingVars sets the reserved brand_* variables from the application's
+// email branding, overriding any value passed by the caller.
+func (r *VariableResolver) applyBrandingVars(vars map[string]string, appID uuid.UUID) {
+	var app models.Application
+	if r.db != nil {
+		if err := r.db.Select("email_logo_url, email_footer_text, email_postal_address, email_social_links").
+			First(&app, "id = ?", appID).Error; err != nil {
+			log.Printf("Warning: failed to load email branding of app %s: %v", appID, err)
+		}
+	}
+	for k, v := range BrandingFromApp(&app).Vars() {
+		vars[k] = v
+	}
 }
 
 // applyUserVars loads the user by ID and populates user-sourced variables.
@@ -152,14 +175,15 @@ func (r *VariableResolver) resolveAppName(appID uuid.UUID) string {
 }
 
 // resolveAppFrontendURL returns the effective frontend URL for an application.
-// Delegates to util.ResolveFrontendURL with per-app DB lookup.
+// Delegates to util.ResolveAllowedFrontendURL with per-app DB lookup, so the URL
+// is rejected if it is not covered by the app's redirect allowlist.
 // Priority: per-app FrontendURL → FRONTEND_URL env var → http://localhost:8080
-func (r *VariableResolver) resolveAppFrontendURL(appID uuid.UUID) string {
+func (r *VariableResolver) resolveAppFrontendURL(appID uuid.UUID) (string, error) {
 	if r.db != nil {
 		var app models.Application
-		if err := r.db.Select("frontend_url").First(&app, "id = ?", appID).Error; err == nil {
-			return util.ResolveFrontendURL(app.FrontendURL)
+		if err := r.db.Select("frontend_url, allowed_redirect_urls").First(&app, "id = ?", appID).Error; err == nil {
+			return util.ResolveAllowedFrontendURL(app.FrontendURL, app.AllowedRedirectURLs)
 		}
 	}
-	return util.ResolveFrontendURL("")
+	return util.ResolveFrontendURL(""), nil
 }
@@ -4,69 +4,61 @@ import (
 	"crypto/tls"
 	"fmt"
 	"log"
+	"time"
 
 	"gopkg.in/mail.v2"
 )
 
 // Sender handles the low-level SMTP email sending.
-type Sender struct{}
+type Sender struct {
+	pool *smtpPool // Reused SMTP connections (nil = one connection per message)
+}
 
 // NewSender creates a new Sender.
 func NewSender() *Sender {
 	return &Sender{}
 }
 
+// EnablePool makes Send reuse SMTP connections: up to maxConns per server,
+// each closed after idleTimeout without use. Call before sending.
+func (s *Sender) EnablePool(maxConns int, idleTimeout time.Duration) {
+	s.pool = newSMTPPool(maxConns, idleTimeout)
+}
+
+// Close closes the pooled SMTP connections, if any.
+func (s *Sender) Close() {
+	if s.pool != nil {
+		s.pool.close()
+	}
+}
+
 // Send sends an email using the provided SMTP configuration.
 // If htmlBody is provided, it sends a multipart email (HTML + text fallback).
 // If only textBody is provided, it sends a plain text email.
 func (s *Sender) Send(config SMTPConfig, to, subject, htmlBody, textBody string) error {
+	return s.SendWithHeaders(config, to, subject, htmlBody, textBody, nil)
+}
+
+// SendWithHeaders is Send with extra message headers, such as List-Unsubscribe.
+func (s *Sender) SendWithHeaders(config SMTPConfig, to, subject, htmlBody, textBody string, headers map[string]string) error {
 	// Check if we're in development mode (no real SMTP configured)
 	if config.Host == "" || config.Host == "smtp.example.com" {
 		s.logDevEmail(to, config.FromAddress, subject, textBody, htmlBody)
 		return nil
 	}
 
-	m := mail.NewMessage()
-
-	// Set From header with optional display name
-	if config.FromName != "" {
-		m.SetAddressHeader("From", config.FromAddress, config.FromName)
-	} else {
-		m.SetHeader("From", config.FromAddress)
+	m, err := newMessage(config, to, subject, htmlBody, textBody, headers)
+	if err != nil {
+		return err
 	}
+	d := newDialer(config)
 
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-
-	// Set body based on available content
-	if htmlBody != "" && textBody != "" {
-		// Multipart: HTML primary with text fallback
-		m.SetBody("text/plain", textBody)
-		m.AddAlternative("text/html", htmlBody)
-	} else if htmlBody != "" {
-		m.SetBody("text/html", htmlBody)
-	} else if textBody != "" {
-		m.SetBody("text/plain", textBody)
+	if s.pool != nil {
+		err = s.pool.send(config, d, m)
 	} else {
-		return fmt.Errorf("email must have either HTML or text body")
+		err = d.DialAndSend(m)
 	}
-
-	d := mail.NewDialer(config.Host, config.Port, config.Username, config.Password)
-
-	if config.UseTLS {
-		d.TLSConfig = &tls.Config{
-			ServerName: config.Host,
-			MinVersion: tls.VersionTLS12,
-		}
-		// Port 465 uses implicit TLS (SSL), other ports use STARTTLS
-		if config.Port == 465 {
-			d.SSL = true
-		} else {
-			d.StartTLSPolicy = mail.MandatoryStartTLS
-		}
-	}
-
-	if err := d.DialAndSend(m); err != nil {
+	if err != nil {
 		log.Printf("Failed to send email to %s via %s:%d: %v", to, config.Host, config.Port, err)
 		// Fallback: log the email content for debugging
 		s.logDevEmail(to, config.FromAddress, subject, textBody, htmlBody)
@@ -91,8 +83,27 @@ func (s *Sender) SendTest(config SMTPConfig, to, subject, htmlBody, textBody str
 		return fmt.Errorf("SMTP port is not configured. Common ports: 587 (STARTTLS), 465 (SSL), 25 (unencrypted)")
 	}
 
+	m, err := newMessage(config, to, subject, htmlBody, textBody, nil)
+	if err != nil {
+		return err
+	}
+	d := newDialer(config)
+
+	// Never pooled: the test must show that the config can connect and log in
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("SMTP error (%s:%d): %w", config.Host, config.Port, err)
+	}
+
+	log.Printf("Test email sent successfully to %s via %s:%d", to, config.Host, config.Port)
+	return nil
+}
+
+// newMessage builds an email. If htmlBody is provided, it is a multipart email
+// (HTML + text fallback); if only textBody is provided, a plain text email.
+func newMessage(config SMTPConfig, to, subject, htmlBody, textBody string, headers map[string]string) (*mail.Message, error) {
 	m := mail.NewMessage()
 
+	// Set From header with optional display name
 	if config.FromName != "" {
 		m.SetAddressHeader("From", config.FromAddress, config.FromName)
 	} else {
@@ -101,8 +112,13 @@ func (s *Sender) SendTest(config SMTPConfig, to, subject, htmlBody, textBody str
 
 	m.SetHeader("To", to)
 	m.SetHeader("Subject", subject)
+	for name, value := range headers {
+		m.SetHeader(name, value)
+	}
 
+	// Set body based on available content
 	if htmlBody != "" && textBody != "" {
+		// Multipart: HTML primary with text fallback
 		m.SetBody("text/plain", textBody)
 		m.AddAlternative("text/html", htmlBody)
 	} else if htmlBody != "" {
@@ -110,9 +126,13 @@ func (s *Sender) SendTest(config SMTPConfig, to, subject, htmlBody, textBody str
 	} else if textBody != "" {
 		m.SetBody("text/plain", textBody)
 	} else {
-		return fmt.Errorf("email must have either HTML or text body")
+		return nil, fmt.Errorf("email must have either HTML or text body")
 	}
+	return m, nil
+}
 
+// newDialer creates the SMTP dialer for config.
+func newDialer(config SMTPConfig) *mail.Dialer {
 	d := mail.NewDialer(config.Host, config.Port, config.Username, config.Password)
 
 	if config.UseTLS {
@@ -120,19 +140,14 @@ func (s *Sender) SendTest(config SMTPConfig, to, subject, htmlBody, textBody str
 			ServerName: config.Host,
 			MinVersion: tls.VersionTLS12,
 		}
+		// Port 465 uses implicit TLS (SSL), other ports use STARTTLS
 		if config.Port == 465 {
 			d.SSL = true
 		} else {
 			d.StartTLSPolicy = mail.MandatoryStartTLS
 		}
 	}
-
-	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("SMTP error (%s:%d): %w", config.Host, config.Port, err)
-	}
-
-	log.Printf("Test email sent successfully to %s via %s:%d", to, config.Host, config.Port)
-	return nil
+	return d
 }
 
 // logDevEmail logs email content to stdout for development/debugging.
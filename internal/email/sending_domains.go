@@ -0,0 +1,170 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/dnscheck"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Tenants register the domains their applications send email from and prove
+// control of each with a DNS TXT record (see dnscheck.VerifyOwnership). Once a
+// tenant has registered a domain, its applications may only send from
+// verified ones: app SMTP configs with another From domain cannot be saved,
+// and emails whose From address (app SMTP config or template override) uses
+// another domain are not sent. Tenants without registered domains, and the
+// global SMTP config, are not restricted.
+
+var (
+	// ErrInvalidSendingDomain is returned by AddSendingDomain for a value that
+	// is not a domain name.
+	ErrInvalidSendingDomain = errors.New("invalid domain")
+	// ErrSendingDomainExists is returned by AddSendingDomain for a domain the
+	// tenant already registered.
+	ErrSendingDomainExists = errors.New("domain already registered")
+	// ErrUnverifiedSenderDomain is returned when a From address does not use a
+	// verified sending domain of the application's tenant.
+	ErrUnverifiedSenderDomain = errors.New("sender domain not verified")
+)
+
+// ListSendingDomains returns the sending domains of a tenant.
+func (s *Service) ListSendingDomains(tenantID uuid.UUID) ([]models.TenantEmailDomain, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.ListSendingDomains(tenantID)
+}
+
+// AddSendingDomain registers a pending sending domain for a tenant, with the
+// token to publish in its verification record.
+func (s *Service) AddSendingDomain(tenantID uuid.UUID, domain string) (*models.TenantEmailDomain, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if !validDomainName(domain) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSendingDomain, domain)
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	d := &models.TenantEmailDomain{
+		TenantID:          tenantID,
+		Domain:            domain,
+		Status:            models.EmailDomainStatusPending,
+		VerificationToken: hex.EncodeToString(token),
+	}
+	created, err := s.repo.CreateSendingDomain(d)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, fmt.Errorf("%w: %s", ErrSendingDomainExists, domain)
+	}
+	return d, nil
+}
+
+// VerifySendingDomain looks up the verification record of a tenant's sending
+// domain and records the outcome. A domain that was verified before is
+// marked failed when the record is gone, so removing the record revokes it.
+// A DNS lookup error leaves the status unchanged. It returns nil, nil when
+// the domain is not found.
+func (s *Service) VerifySendingDomain(ctx context.Context, checker *dnscheck.Checker, tenantID, id uuid.UUID) (*models.TenantEmailDomain, *dnscheck.Result, error) {
+	if s.repo == nil {
+		return nil, nil, fmt.Errorf("email repository not initialized")
+	}
+	d, err := s.repo.GetSendingDomain(tenantID, id)
+	if err != nil || d == nil {
+		return nil, nil, err
+	}
+
+	res := checker.VerifyOwnership(ctx, d.Domain, d.VerificationToken)
+	now := time.Now()
+	d.LastCheckedAt = &now
+	switch res.Status {
+	case dnscheck.StatusPass:
+		if d.Status != models.EmailDomainStatusVerified {
+			d.VerifiedAt = &now
+		}
+		d.Status = models.EmailDomainStatusVerified
+		d.LastError = ""
+	case dnscheck.StatusFail:
+		d.Status = models.EmailDomainStatusFailed
+		d.VerifiedAt = nil
+		d.LastError = res.Detail
+	default:
+		d.LastError = res.Detail
+	}
+	if err := s.repo.UpdateSendingDomain(d); err != nil {
+		return nil, nil, err
+	}
+	return d, &res, nil
+}
+
+// DeleteSendingDomain removes a sending domain of a tenant. It returns false
+// when the domain was not found.
+func (s *Service) DeleteSendingDomain(tenantID, id uuid.UUID) (bool, error) {
+	if s.repo == nil {
+		return false, fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.DeleteSendingDomain(tenantID, id)
+}
+
+// CheckSenderDomain returns an error wrapping ErrUnverifiedSenderDomain when
+// the application's tenant has registered sending domains and fromAddress
+// does not use a verified one (or a subdomain of one).
+func (s *Service) CheckSenderDomain(appID uuid.UUID, fromAddress string) error {
+	if s.repo == nil {
+		return nil
+	}
+	domains, err := s.repo.ListAppSendingDomains(appID)
+	if err != nil {
+		return fmt.Errorf("failed to look up sending domains: %w", err)
+	}
+	return checkSenderDomain(domains, fromAddress)
+}
+
+// checkSenderDomain checks fromAddress against a tenant's sending domains.
+func checkSenderDomain(domains []models.TenantEmailDomain, fromAddress string) error {
+	if len(domains) == 0 {
+		return nil
+	}
+	domain, err := dnscheck.DomainOf(fromAddress)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnverifiedSenderDomain, err)
+	}
+	for _, d := range domains {
+		if d.Status == models.EmailDomainStatusVerified && (domain == d.Domain || strings.HasSuffix(domain, "."+d.Domain)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not a verified sending domain of the application's tenant", ErrUnverifiedSenderDomain, domain)
+}
+
+// validDomainName reports whether domain is a lowercase DNS name with at
+// least two labels.
+func validDomainName(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(domain) > 253 || len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package email
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestCheckSenderDomain(t *testing.T) {
+	domains := []models.TenantEmailDomain{
+		{Domain: "example.com", Status: models.EmailDomainStatusVerified},
+		{Domain: "pending.org", Status: models.EmailDomainStatusPending},
+	}
+	tests := []struct {
+		from string
+		ok   bool
+	}{
+		{"noreply@example.com", true},
+		{"Acme <noreply@Example.COM>", true},
+		{"noreply@mail.example.com", true},
+		{"noreply@notexample.com", false},
+		{"noreply@pending.org", false},
+		{"noreply@other.net", false},
+		{"not an address", false},
+	}
+	for _, tt := range tests {
+		err := checkSenderDomain(domains, tt.from)
+		if tt.ok && err != nil {
+			t.Errorf("%q: unexpected error %v", tt.from, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrUnverifiedSenderDomain) {
+			t.Errorf("%q: error = %v, want ErrUnverifiedSenderDomain", tt.from, err)
+		}
+	}
+
+	// Tenants without sending domains are not restricted
+	if err := checkSenderDomain(nil, "noreply@other.net"); err != nil {
+		t.Errorf("unrestricted tenant: %v", err)
+	}
+}
+
+func TestValidDomainName(t *testing.T) {
+	for _, d := range []string{"example.com", "mail.example.co.uk", "xn--bcher-kva.example", "a-b.io"} {
+		if !validDomainName(d) {
+			t.Errorf("validDomainName(%q) = false", d)
+		}
+	}
+	for _, d := range []string{"", "localhost", "-bad.com", "bad-.com", "a..com", "exa mple.com", "user@example.com", "Example.com"} {
+		if validDomainName(d) {
+			t.Errorf("validDomainName(%q) = true", d)
+		}
+	}
+}
@@ -1,12 +1,21 @@
 package email
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/quota"
+	"github.com/gjovanovicst/auth_api/internal/tokenstore"
 	"github.com/gjovanovicst/auth_api/internal/util"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/optlock"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -18,8 +27,30 @@ type Service struct {
 	renderer *Renderer
 	sender   *Sender
 	resolver *VariableResolver
+	db       *gorm.DB
+	onSent   SentCallback
+	onFailed FailedCallback
+
+	onUserSent UserSentCallback
+	jobs       *jobqueue.Queue // Queues emails deferred by quiet hours; nil = send immediately
 }
 
+// SentCallback is invoked after an app-scoped email has been handed to the SMTP
+// server successfully. It lets main.go wire usage metering without creating an
+// import cycle. Admin emails (not scoped to an app) do not trigger it.
+type SentCallback func(appID uuid.UUID, emailTypeCode string)
+
+// UserSentCallback is invoked after an app-scoped email addressed to a known
+// user has been sent. main.go uses it to record the email on the user's
+// activity log. variant names the template variant the email was sent with
+// ("control" for the regular template), or is empty when the app has no
+// active variants of the email type.
+type UserSentCallback func(appID, userID uuid.UUID, emailTypeCode, variant string)
+
+// FailedCallback is invoked when the SMTP server rejects or cannot be reached
+// for an app-scoped email. main.go uses it to raise admin notifications.
+type FailedCallback func(appID uuid.UUID, emailTypeCode string, err error)
+
 // NewService creates a new email Service with all its dependencies.
 // The db parameter is used for variable resolution (user lookups, settings).
 // If repo is nil, the service operates in legacy mode (no DB templates, global SMTP only).
@@ -29,9 +60,38 @@ func NewService(repo *Repository, db *gorm.DB) *Service {
 		renderer: NewRenderer(),
 		sender:   NewSender(),
 		resolver: NewVariableResolver(db),
+		db:       db,
 	}
 }
 
+// SetSentCallback sets the callback invoked after each successfully sent app email.
+func (s *Service) SetSentCallback(cb SentCallback) {
+	s.onSent = cb
+}
+
+// SetUserSentCallback sets the callback invoked after each app email sent to a known user.
+func (s *Service) SetUserSentCallback(cb UserSentCallback) {
+	s.onUserSent = cb
+}
+
+// EnableConnectionPool makes the service reuse SMTP connections between
+// messages, with up to maxConns connections per SMTP server, each closed after
+// idleTimeout without use. Test emails always use a new connection.
+// Call Shutdown to close the pooled connections.
+func (s *Service) EnableConnectionPool(maxConns int, idleTimeout time.Duration) {
+	s.sender.EnablePool(maxConns, idleTimeout)
+}
+
+// Shutdown closes pooled SMTP connections.
+func (s *Service) Shutdown() {
+	s.sender.Close()
+}
+
+// SetFailedCallback sets the callback invoked when sending an app email fails at the SMTP stage.
+func (s *Service) SetFailedCallback(cb FailedCallback) {
+	s.onFailed = cb
+}
+
 // SendEmail is a backward-compatible wrapper around SendEmailWithContext.
 // It sends an email without user context (no auto-populated user profile variables).
 func (s *Service) SendEmail(appID uuid.UUID, emailTypeCode string, toEmail string, vars map[string]string) error {
@@ -39,41 +99,119 @@ func (s *Service) SendEmail(appID uuid.UUID, emailTypeCode string, toEmail strin
 }
 
 // SendEmailWithContext is the primary method for sending any email. It:
-// 1. Resolves all template variables through the multi-source pipeline
-// 2. Resolves the email template (app-specific -> global -> hardcoded default)
-// 3. Renders the template with the resolved variables
-// 4. Resolves the SMTP config (template-linked config -> per-app default -> global)
-// 5. Sends the email
+// 1. Defers product emails during the app's quiet hours (see quiet_hours.go) and enforces the app's daily email quota
+// 2. Resolves all template variables through the multi-source pipeline
+// 3. Resolves the email template (app-specific -> global -> hardcoded default) or an A/B variant
+// 4. Renders the template with the resolved variables and the app's email branding
+// 5. Resolves the SMTP config (template-linked config -> per-app default -> global)
+// 6. Sends the email
 //
 // Variable resolution priority (highest wins):
+//   - Reserved branding variables (brand_*) from the app's email branding
 //   - Explicit vars passed by the caller
 //   - User profile fields (when userID is provided)
 //   - App/system settings (app_name, frontend_url, etc.)
 //   - Static default values defined on the email type's variable declarations
 func (s *Service) SendEmailWithContext(appID uuid.UUID, emailTypeCode string, toEmail string, userID *uuid.UUID, vars map[string]string) error {
+	_, err := s.send(appID, emailTypeCode, toEmail, userID, vars, true)
+	return err
+}
+
+// send sends an email like SendEmailWithContext. When deferrable is set and
+// the app's quiet hours defer the email, it queues the email instead and
+// returns when it will be sent.
+func (s *Service) send(appID uuid.UUID, emailTypeCode string, toEmail string, userID *uuid.UUID, vars map[string]string, deferrable bool) (*time.Time, error) {
+	// Respect the recipient's notification preferences for non-transactional email
+	category := s.emailCategory(emailTypeCode)
+	if category != models.EmailCategoryTransactional {
+		if err := s.checkOptOut(appID, toEmail, userID, category); err != nil {
+			return nil, err
+		}
+	}
+
+	// Hold product emails until the app's quiet hours end
+	if deferrable {
+		if until := s.DeferUntil(appID, emailTypeCode, time.Now()); until != nil {
+			return until, s.deferEmail(appID, emailTypeCode, toEmail, userID, vars, *until)
+		}
+	}
+
+	// Enforce the app's daily email quota
+	if s.db != nil {
+		if err := quota.CheckAppEmails(s.db, appID); err != nil {
+			return nil, fmt.Errorf("cannot send %s email: %w", emailTypeCode, err)
+		}
+	}
+
 	// Resolve all variables through the pipeline
 	resolvedVars := s.resolver.ResolveVariables(appID, emailTypeCode, toEmail, userID, vars)
 
+	var headers map[string]string
+	var unsubscribeLink string
+	if category != models.EmailCategoryTransactional {
+		link, err := UnsubscribeURL(appID, toEmail, category)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build unsubscribe link for %s: %w", emailTypeCode, err)
+		}
+		unsubscribeLink = link
+		resolvedVars[VarUnsubscribeURL] = link
+		headers = unsubscribeHeaders(link)
+	}
+
 	// 1. Resolve template
 	tmpl, err := s.resolveTemplate(appID, emailTypeCode)
 	if err != nil {
-		return fmt.Errorf("failed to resolve template for %s: %w", emailTypeCode, err)
+		return nil, fmt.Errorf("failed to resolve template for %s: %w", emailTypeCode, err)
 	}
 	if tmpl == nil {
-		return fmt.Errorf("no template found for email type: %s", emailTypeCode)
+		return nil, fmt.Errorf("no template found for email type: %s", emailTypeCode)
 	}
+	tmpl, variant := s.chooseVariant(appID, emailTypeCode, tmpl)
 
 	// 2. Render template
 	subject, htmlBody, textBody, err := s.renderer.RenderTemplate(tmpl, resolvedVars)
 	if err != nil {
-		return fmt.Errorf("failed to render template for %s: %w", emailTypeCode, err)
+		return nil, fmt.Errorf("failed to render template for %s: %w", emailTypeCode, err)
+	}
+	if !referencesBranding(tmpl) {
+		htmlBody, textBody = addBrandingFooter(htmlBody, textBody, resolvedVars)
+	}
+	if unsubscribeLink != "" {
+		htmlBody, textBody = addUnsubscribeFooter(htmlBody, textBody, unsubscribeLink, category)
 	}
 
 	// 3. Resolve SMTP config (considers template's linked server config)
 	smtpConfig := s.resolveSMTPConfigForTemplate(appID, tmpl)
 
-	// 4. Send email
-	return s.sender.Send(smtpConfig, toEmail, subject, htmlBody, textBody)
+	// 4. Send email, unless the From domain is not verified for the tenant
+	if smtpConfig.AppScoped {
+		if err = s.CheckSenderDomain(appID, smtpConfig.FromAddress); err != nil {
+			log.Printf("Warning: %s email for app %s blocked: %v", emailTypeCode, appID, err)
+		}
+	}
+	if err == nil {
+		err = s.sender.SendWithHeaders(smtpConfig, toEmail, subject, htmlBody, textBody, headers)
+	}
+	if err != nil {
+		s.recordVariantSend(appID, variant, false)
+		if s.onFailed != nil {
+			s.onFailed(appID, emailTypeCode, err)
+		}
+		return nil, err
+	}
+	s.recordVariantSend(appID, variant, true)
+	quota.RecordEmailSent(appID)
+	if s.onSent != nil {
+		s.onSent(appID, emailTypeCode)
+	}
+	if s.onUserSent != nil && userID != nil {
+		variantName := ""
+		if variant != nil {
+			variantName = variant.Name
+		}
+		s.onUserSent(appID, *userID, emailTypeCode, variantName)
+	}
+	return nil, nil
 }
 
 // SendVerificationEmail sends an email verification email.
@@ -82,10 +220,14 @@ func (s *Service) SendVerificationEmail(appID uuid.UUID, toEmail, token string,
 	// Load per-app verify_email_path so the link is configurable per application.
 	var app models.Application
 	if s.resolver.db != nil {
-		s.resolver.db.Select("frontend_url, verify_email_path").First(&app, "id = ?", appID)
+		s.resolver.db.Select("frontend_url, verify_email_path, allowed_redirect_urls").First(&app, "id = ?", appID)
+	}
+	frontendURL, err := util.ResolveAllowedFrontendURL(app.FrontendURL, app.AllowedRedirectURLs)
+	if err != nil {
+		return fmt.Errorf("verification link: %w", err)
 	}
 	verifyPath := util.ResolveLinkPath(app.VerifyEmailPath, util.DefaultVerifyEmailPath)
-	verificationLink := fmt.Sprintf("%s%s?token=%s&email=%s", util.ResolveFrontendURL(app.FrontendURL), verifyPath, token, url.QueryEscape(toEmail))
+	verificationLink := fmt.Sprintf("%s%s?token=%s&email=%s", frontendURL, verifyPath, token, url.QueryEscape(toEmail))
 
 	return s.SendEmailWithContext(appID, TypeEmailVerification, toEmail, userID, map[string]string{
 		VarVerificationLink:  verificationLink,
@@ -93,12 +235,22 @@ func (s *Service) SendVerificationEmail(appID uuid.UUID, toEmail, token string,
 	})
 }
 
+// SendVerificationCodeEmail sends an email verification code, for applications
+// that verify addresses with a code instead of a link.
+// The userID parameter enables auto-population of user profile variables in the template.
+func (s *Service) SendVerificationCodeEmail(appID uuid.UUID, toEmail, code string, userID *uuid.UUID) error {
+	return s.SendEmailWithContext(appID, TypeEmailVerifyCode, toEmail, userID, map[string]string{
+		VarCode:              code,
+		VarExpirationMinutes: strconv.Itoa(int(tokenstore.CodeTTL(tokenstore.PurposeEmailVerification).Minutes())),
+	})
+}
+
 // SendPasswordResetEmail sends a password reset email.
 // The userID parameter enables auto-population of user profile variables in the template.
 func (s *Service) SendPasswordResetEmail(appID uuid.UUID, toEmail, resetLink string, userID *uuid.UUID) error {
 	return s.SendEmailWithContext(appID, TypePasswordReset, toEmail, userID, map[string]string{
 		VarResetLink:         resetLink,
-		VarExpirationMinutes: "60",
+		VarExpirationMinutes: strconv.Itoa(int(tokenstore.TTL(tokenstore.PurposePasswordReset).Minutes())),
 	})
 }
 
@@ -111,24 +263,49 @@ func (s *Service) Send2FACodeEmail(appID uuid.UUID, toEmail, code string, userID
 	})
 }
 
-// SendWelcomeEmail sends a welcome email after successful email verification.
+// SendWelcomeEmail sends a welcome email after successful email verification,
+// once per user within IdempotencyWindow.
 // The userID parameter enables auto-population of user profile variables in the template.
 func (s *Service) SendWelcomeEmail(appID uuid.UUID, toEmail string, userID *uuid.UUID) error {
-	return s.SendEmailWithContext(appID, TypeWelcome, toEmail, userID, map[string]string{})
+	_, err := s.SendEmailIdempotent(appID, TypeWelcome, toEmail, userID, map[string]string{}, userEventKey(TypeWelcome, userID))
+	return err
 }
 
-// SendAccountDeactivatedEmail sends a notification when an account is deactivated.
+// SendAccountDeactivatedEmail sends a notification when an account is
+// deactivated, once per user within IdempotencyWindow.
 // The userID parameter enables auto-population of user profile variables in the template.
 func (s *Service) SendAccountDeactivatedEmail(appID uuid.UUID, toEmail string, userID *uuid.UUID) error {
-	return s.SendEmailWithContext(appID, TypeAccountDeactivated, toEmail, userID, map[string]string{})
+	_, err := s.SendEmailIdempotent(appID, TypeAccountDeactivated, toEmail, userID, map[string]string{},
+		userEventKey(TypeAccountDeactivated, userID))
+	return err
+}
+
+// SendRegistrationApprovedEmail notifies a user that their registration, held
+// for approval, was approved, once per user within IdempotencyWindow.
+// The userID parameter enables auto-population of user profile variables in the template.
+func (s *Service) SendRegistrationApprovedEmail(appID uuid.UUID, toEmail string, userID *uuid.UUID) error {
+	_, err := s.SendEmailIdempotent(appID, TypeRegistrationApproved, toEmail, userID, map[string]string{},
+		userEventKey(TypeRegistrationApproved, userID))
+	return err
 }
 
-// SendPasswordChangedEmail sends a security notification when a password is changed.
+// SendRegistrationRejectedEmail notifies a user that their registration, held
+// for approval, was rejected, once per user within IdempotencyWindow.
+// The userID parameter enables auto-population of user profile variables in the template.
+func (s *Service) SendRegistrationRejectedEmail(appID uuid.UUID, toEmail string, userID *uuid.UUID) error {
+	_, err := s.SendEmailIdempotent(appID, TypeRegistrationRejected, toEmail, userID, map[string]string{},
+		userEventKey(TypeRegistrationRejected, userID))
+	return err
+}
+
+// SendPasswordChangedEmail sends a security notification when a password is
+// changed, once per user and change time.
 // The userID parameter enables auto-population of user profile variables in the template.
 func (s *Service) SendPasswordChangedEmail(appID uuid.UUID, toEmail, changeTime string, userID *uuid.UUID) error {
-	return s.SendEmailWithContext(appID, TypePasswordChanged, toEmail, userID, map[string]string{
+	_, err := s.SendEmailIdempotent(appID, TypePasswordChanged, toEmail, userID, map[string]string{
 		VarChangeTime: changeTime,
-	})
+	}, userEventKey(TypePasswordChanged, userID, changeTime))
+	return err
 }
 
 // SendMagicLinkEmail sends a magic link login email.
@@ -140,35 +317,42 @@ func (s *Service) SendMagicLinkEmail(appID uuid.UUID, toEmail, magicLink string,
 	})
 }
 
-// SendNewDeviceLoginEmail sends a notification when a login is detected from a new device or location.
+// SendNewDeviceLoginEmail sends a notification when a login is detected from a
+// new device or location, once per user, login time and IP address.
 // The userID parameter enables auto-population of user profile variables in the template.
 func (s *Service) SendNewDeviceLoginEmail(appID uuid.UUID, toEmail string, userID *uuid.UUID, loginIP, loginLocation, loginDevice, loginTime string) error {
-	return s.SendEmailWithContext(appID, TypeNewDeviceLogin, toEmail, userID, map[string]string{
+	_, err := s.SendEmailIdempotent(appID, TypeNewDeviceLogin, toEmail, userID, map[string]string{
 		VarLoginIP:       loginIP,
 		VarLoginLocation: loginLocation,
 		VarLoginDevice:   loginDevice,
 		VarLoginTime:     loginTime,
-	})
+	}, userEventKey(TypeNewDeviceLogin, userID, loginTime, loginIP))
+	return err
 }
 
-// SendSuspiciousActivityEmail sends a security alert when suspicious activity is detected on an account.
+// SendSuspiciousActivityEmail sends a security alert when suspicious activity
+// is detected on an account, once per user, alert type, login time and IP address.
 // The userID parameter enables auto-population of user profile variables in the template.
 func (s *Service) SendSuspiciousActivityEmail(appID uuid.UUID, toEmail string, userID *uuid.UUID, loginIP, loginLocation, loginDevice, loginTime, alertType, alertDetails string) error {
-	return s.SendEmailWithContext(appID, TypeSuspiciousActivity, toEmail, userID, map[string]string{
+	_, err := s.SendEmailIdempotent(appID, TypeSuspiciousActivity, toEmail, userID, map[string]string{
 		VarLoginIP:       loginIP,
 		VarLoginLocation: loginLocation,
 		VarLoginDevice:   loginDevice,
 		VarLoginTime:     loginTime,
 		VarAlertType:     alertType,
 		VarAlertDetails:  alertDetails,
-	})
+	}, userEventKey(TypeSuspiciousActivity, userID, alertType, loginTime, loginIP))
+	return err
 }
 
 // SendBackupEmailVerification sends a verification email to a user's pending backup email address.
 // The token is stored in Redis and expires after the given duration. The userID enables
 // auto-population of user profile variables in the template.
 func (s *Service) SendBackupEmailVerification(appID uuid.UUID, toBackupEmail, token string, userID *uuid.UUID) error {
-	frontendURL := s.resolver.resolveAppFrontendURL(appID)
+	frontendURL, err := s.resolver.resolveAppFrontendURL(appID)
+	if err != nil {
+		return fmt.Errorf("backup email verification link: %w", err)
+	}
 	verificationLink := fmt.Sprintf("%s/verify-backup-email?token=%s", frontendURL, token)
 
 	return s.SendEmailWithContext(appID, TypeBackupEmailVerification, toBackupEmail, userID, map[string]string{
@@ -299,7 +483,7 @@ func (s *Service) resolveTemplate(appID uuid.UUID, typeCode string) (*models.Ema
 }
 
 // resolveSMTPConfig resolves the SMTP configuration for an application.
-// Resolution order: per-app DB config -> global DB config -> dev/fallback mode (logs to stdout).
+// Resolution order: per-app DB config -> parent app DB config (environments) -> global DB config -> dev/fallback mode (logs to stdout).
 func (s *Service) resolveSMTPConfig(appID uuid.UUID) SMTPConfig {
 	// Try per-app config from DB
 	if s.repo != nil {
@@ -307,6 +491,13 @@ func (s *Service) resolveSMTPConfig(appID uuid.UUID) SMTPConfig {
 		if err != nil {
 			log.Printf("Warning: failed to look up SMTP config for app %s: %v", appID, err)
 		}
+		if config == nil && err == nil {
+			// Environments without their own SMTP config use the parent application's
+			config, err = s.repo.GetParentServerConfig(appID)
+			if err != nil {
+				log.Printf("Warning: failed to look up parent SMTP config for app %s: %v", appID, err)
+			}
+		}
 		if config != nil && config.IsActive {
 			return SMTPConfig{
 				Host:        config.SMTPHost,
@@ -316,6 +507,7 @@ func (s *Service) resolveSMTPConfig(appID uuid.UUID) SMTPConfig {
 				FromAddress: config.FromAddress,
 				FromName:    config.FromName,
 				UseTLS:      config.UseTLS,
+				AppScoped:   true,
 			}
 		}
 
@@ -365,6 +557,7 @@ func (s *Service) resolveSMTPConfigForTemplate(appID uuid.UUID, tmpl *models.Ema
 				FromAddress: config.FromAddress,
 				FromName:    config.FromName,
 				UseTLS:      config.UseTLS,
+				AppScoped:   config.AppID != nil,
 			}
 		} else {
 			// Linked config not found or inactive, fall back
@@ -378,6 +571,7 @@ func (s *Service) resolveSMTPConfigForTemplate(appID uuid.UUID, tmpl *models.Ema
 	// Step 2: Apply template-level sender overrides
 	if tmpl.FromEmail != "" {
 		smtpConfig.FromAddress = tmpl.FromEmail
+		smtpConfig.AppScoped = smtpConfig.AppScoped || tmpl.AppID != nil
 	}
 	if tmpl.FromName != "" {
 		smtpConfig.FromName = tmpl.FromName
@@ -423,11 +617,20 @@ func (s *Service) GetServerConfigAny(appID uuid.UUID) (*models.EmailServerConfig
 // For new configs (ID is zero), it creates a new record.
 // For existing configs (ID is set), it updates the existing record.
 // Handles is_default flag: if this config is set as default, clears the default flag on other configs for the same app.
+// App configs whose From address does not use a verified sending domain of the
+// app's tenant are rejected with ErrUnverifiedSenderDomain.
 func (s *Service) SaveServerConfig(config *models.EmailServerConfig) error {
 	if s.repo == nil {
 		return fmt.Errorf("email repository not initialized")
 	}
 
+	// App configs may only send from the tenant's verified sending domains
+	if config.AppID != nil {
+		if err := s.CheckSenderDomain(*config.AppID, config.FromAddress); err != nil {
+			return err
+		}
+	}
+
 	// Handle is_default: if setting this config as default, clear others first
 	if config.IsDefault {
 		if err := s.repo.ClearDefaultFlag(config.AppID); err != nil {
@@ -460,6 +663,15 @@ func (s *Service) GetServerConfigsByApp(appID uuid.UUID) ([]models.EmailServerCo
 	return s.repo.GetServerConfigsByApp(appID)
 }
 
+// ListServerConfigs returns a page of SMTP configurations sorted and filtered
+// by q (see ServerConfigListSpec).
+func (s *Service) ListServerConfigs(q pagination.ListQuery) ([]models.EmailServerConfig, int64, error) {
+	if s.repo == nil {
+		return nil, 0, fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.ListServerConfigs(q)
+}
+
 // GetAllServerConfigs returns all SMTP configurations across all applications.
 func (s *Service) GetAllServerConfigs() ([]models.EmailServerConfig, error) {
 	if s.repo == nil {
@@ -551,6 +763,15 @@ func (s *Service) GetTemplatesByApp(appID uuid.UUID) ([]models.EmailTemplate, er
 	return s.repo.GetTemplatesByApp(appID)
 }
 
+// ListTemplates returns a page of templates sorted and filtered by q (see
+// TemplateListSpec); without an app_id filter, of the global defaults.
+func (s *Service) ListTemplates(q pagination.ListQuery) ([]models.EmailTemplate, int64, error) {
+	if s.repo == nil {
+		return nil, 0, fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.ListTemplates(q)
+}
+
 // GetGlobalDefaultTemplates returns all global default templates.
 func (s *Service) GetGlobalDefaultTemplates() ([]models.EmailTemplate, error) {
 	if s.repo == nil {
@@ -568,19 +789,30 @@ func (s *Service) GetTemplateByID(id uuid.UUID) (*models.EmailTemplate, error) {
 }
 
 // SaveAppTemplate creates or updates a template for a specific app and email type.
-func (s *Service) SaveAppTemplate(appID uuid.UUID, emailTypeID uuid.UUID, template *models.EmailTemplate) error {
+// guard makes the update of an existing template conditional on its version.
+func (s *Service) SaveAppTemplate(appID uuid.UUID, emailTypeID uuid.UUID, template *models.EmailTemplate, guard optlock.Guard) error {
 	if s.repo == nil {
 		return fmt.Errorf("email repository not initialized")
 	}
-	return s.repo.UpsertAppTemplate(appID, emailTypeID, template)
+	return s.repo.UpsertAppTemplate(appID, emailTypeID, template, guard)
 }
 
 // SaveGlobalTemplate creates or updates a global default template.
-func (s *Service) SaveGlobalTemplate(emailTypeID uuid.UUID, template *models.EmailTemplate) error {
+// guard makes the update of an existing template conditional on its version.
+func (s *Service) SaveGlobalTemplate(emailTypeID uuid.UUID, template *models.EmailTemplate, guard optlock.Guard) error {
+	if s.repo == nil {
+		return fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.UpsertGlobalTemplate(emailTypeID, template, guard)
+}
+
+// UpdateTemplate replaces the editable fields of an existing template,
+// provided guard allows it (optlock.ErrConflict otherwise).
+func (s *Service) UpdateTemplate(template *models.EmailTemplate, guard optlock.Guard) error {
 	if s.repo == nil {
 		return fmt.Errorf("email repository not initialized")
 	}
-	return s.repo.UpsertGlobalTemplate(emailTypeID, template)
+	return s.repo.UpdateTemplateContent(template.ID, template, guard)
 }
 
 // DeleteTemplate removes a template by ID.
@@ -596,6 +828,71 @@ func (s *Service) PreviewTemplate(tmpl *models.EmailTemplate, vars map[string]st
 	return s.renderer.RenderTemplate(tmpl, vars)
 }
 
+// ErrPreviewUserNotFound is returned by PreviewTemplateForUser for an unknown user.
+var ErrPreviewUserNotFound = errors.New("preview user not found")
+
+// PreviewTemplateForUser renders a template with the variables a real email
+// to the user would get: the user's profile fields, their application's
+// settings and branding, and vars as explicit variables. The app branding is
+// appended as it would be when sending. It returns the user's application ID
+// so that callers can record the access to the user's data.
+func (s *Service) PreviewTemplateForUser(tmpl *models.EmailTemplate, userID uuid.UUID, vars map[string]string) (uuid.UUID, string, string, string, error) {
+	if s.resolver.db == nil {
+		return uuid.Nil, "", "", "", fmt.Errorf("database not initialized")
+	}
+	var user models.User
+	if err := s.resolver.db.Select("id, app_id, email").First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, "", "", "", ErrPreviewUserNotFound
+		}
+		return uuid.Nil, "", "", "", err
+	}
+
+	resolved := s.resolver.ResolveVariables(user.AppID, "", user.Email, &user.ID, vars)
+	subject, htmlBody, textBody, err := s.renderer.RenderTemplate(tmpl, resolved)
+	if err != nil {
+		return user.AppID, "", "", "", err
+	}
+	if !referencesBranding(tmpl) {
+		htmlBody, textBody = addBrandingFooter(htmlBody, textBody, resolved)
+	}
+	return user.AppID, subject, htmlBody, textBody, nil
+}
+
+// LintTemplate checks tmpl against the variables of the email type emailTypeID
+// (see the package-level LintTemplate).
+func (s *Service) LintTemplate(tmpl *models.EmailTemplate, emailTypeID uuid.UUID) ([]LintWarning, error) {
+	emailType, err := s.GetEmailTypeByID(emailTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if emailType == nil {
+		return nil, fmt.Errorf("email type not found")
+	}
+	return LintTemplate(tmpl, emailType)
+}
+
+// LintAllTemplates lints every stored template against its email type, for
+// finding templates broken by changes to email type variables.
+func (s *Service) LintAllTemplates() ([]TemplateLintResult, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	templates, err := s.repo.GetAllTemplates()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TemplateLintResult, 0, len(templates))
+	for i := range templates {
+		warnings, err := LintTemplate(&templates[i], &templates[i].EmailType)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, TemplateLintResult{Template: templates[i], Warnings: warnings})
+	}
+	return results, nil
+}
+
 // ResetTemplateToDefault overwrites a template's content with the hardcoded default.
 // Only works for system email types that have a built-in default in defaults.go.
 func (s *Service) ResetTemplateToDefault(id uuid.UUID) error {
@@ -628,6 +925,11 @@ func (s *Service) ResetTemplateToDefault(id uuid.UUID) error {
 // SendTestEmail sends a test email using the specified app's default SMTP configuration.
 func (s *Service) SendTestEmail(appID uuid.UUID, toEmail string) error {
 	smtpConfig := s.resolveSMTPConfig(appID)
+	if smtpConfig.AppScoped {
+		if err := s.CheckSenderDomain(appID, smtpConfig.FromAddress); err != nil {
+			return err
+		}
+	}
 	appName := s.resolveAppName(appID)
 
 	subject := fmt.Sprintf("[Test] Email from %s", appName)
@@ -667,6 +969,9 @@ func (s *Service) SendTestEmailWithConfigID(configID uuid.UUID, toEmail string)
 
 	appName := "System"
 	if config.AppID != nil {
+		if err := s.CheckSenderDomain(*config.AppID, config.FromAddress); err != nil {
+			return err
+		}
 		appName = s.resolveAppName(*config.AppID)
 	}
 	configName := config.Name
@@ -684,3 +989,50 @@ func (s *Service) SendTestEmailWithConfigID(configID uuid.UUID, toEmail string)
 
 	return s.sender.SendTest(smtpConfig, toEmail, subject, htmlBody, textBody)
 }
+
+// ErrInvalidSuppressionReason is returned by AddSuppression for an unknown reason.
+var ErrInvalidSuppressionReason = errors.New("invalid suppression reason")
+
+// ListSuppressions returns a page of suppressed addresses sorted and filtered
+// by q (see SuppressionListSpec).
+func (s *Service) ListSuppressions(q pagination.ListQuery) ([]models.EmailSuppression, int64, error) {
+	if s.repo == nil {
+		return nil, 0, fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.ListSuppressions(q)
+}
+
+// AddSuppression adds an address to an application's suppression list. The
+// address is stored lowercased; an unknown reason is rejected.
+func (s *Service) AddSuppression(appID uuid.UUID, email, reason, note string) (*models.EmailSuppression, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	switch reason {
+	case "":
+		reason = models.SuppressionReasonManual
+	case models.SuppressionReasonManual, models.SuppressionReasonBounce,
+		models.SuppressionReasonComplaint, models.SuppressionReasonUnsubscribe:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSuppressionReason, reason)
+	}
+	suppression := &models.EmailSuppression{
+		AppID:  appID,
+		Email:  strings.ToLower(strings.TrimSpace(email)),
+		Reason: reason,
+		Note:   note,
+	}
+	if err := s.repo.AddSuppression(suppression); err != nil {
+		return nil, err
+	}
+	return suppression, nil
+}
+
+// DeleteSuppression removes an address from an application's suppression
+// list. It returns false when the address was not suppressed.
+func (s *Service) DeleteSuppression(appID uuid.UUID, email string) (bool, error) {
+	if s.repo == nil {
+		return false, fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.DeleteSuppression(appID, strings.ToLower(strings.TrimSpace(email)))
+}
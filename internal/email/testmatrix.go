@@ -0,0 +1,177 @@
+package email
+
+import (
+	"fmt"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Template sources in a test matrix, in resolution order
+const (
+	MatrixSourceApp     = "app"     // The application's own template
+	MatrixSourceGlobal  = "global"  // The global default template
+	MatrixSourceBuiltin = "builtin" // The hardcoded default (see defaults.go)
+	MatrixSourceNone    = "none"    // No template: emails of the type cannot be sent
+)
+
+// MatrixEntry is the outcome of rendering one template of a test matrix.
+type MatrixEntry struct {
+	EmailType models.EmailType
+	Source    string                // One of the MatrixSource* constants
+	Template  *models.EmailTemplate // nil for MatrixSourceNone
+	Effective bool                  // The template emails of the type are sent with
+
+	Subject          string        // Rendered with sample variables
+	RenderError      string        // Empty when the template rendered
+	MissingVariables []string      // Required variables of the type the template does not reference
+	Warnings         []LintWarning // See LintTemplate
+}
+
+// OK reports whether the template rendered without errors or warnings and
+// references every required variable.
+func (e *MatrixEntry) OK() bool {
+	return e.Source != MatrixSourceNone && e.RenderError == "" &&
+		len(e.MissingVariables) == 0 && len(e.Warnings) == 0
+}
+
+// TemplateTestMatrix renders every active template an application can send
+// with, for each active email type: the app's own template, the global
+// default and, when neither exists, the hardcoded default. Templates are
+// rendered with their engine, using sample values for the type's variables
+// and the app's name, frontend URL and branding. Both the app template and
+// the global default are reported, so the global defaults an app falls back
+// to can be checked before replacing them with custom templates.
+func (s *Service) TemplateTestMatrix(appID uuid.UUID) ([]MatrixEntry, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	types, err := s.repo.GetActiveEmailTypes()
+	if err != nil {
+		return nil, err
+	}
+	appTemplates, err := s.repo.GetTemplatesByApp(appID)
+	if err != nil {
+		return nil, err
+	}
+	globalTemplates, err := s.repo.GetGlobalDefaultTemplates()
+	if err != nil {
+		return nil, err
+	}
+	appByType := activeTemplatesByType(appTemplates)
+	globalByType := activeTemplatesByType(globalTemplates)
+
+	var entries []MatrixEntry
+	for i := range types {
+		emailType := &types[i]
+		vars, err := s.matrixVariables(appID, emailType)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := []matrixCandidate{
+			{MatrixSourceApp, appByType[emailType.ID]},
+			{MatrixSourceGlobal, globalByType[emailType.ID]},
+		}
+		if candidates[0].tmpl == nil && candidates[1].tmpl == nil {
+			candidates = append(candidates, matrixCandidate{MatrixSourceBuiltin, GetDefaultTemplate(emailType.Code)})
+		}
+
+		effective := true
+		for _, c := range candidates {
+			if c.tmpl == nil {
+				continue
+			}
+			entry, err := testTemplate(c.tmpl, emailType, vars)
+			if err != nil {
+				return nil, err
+			}
+			entry.Source = c.source
+			entry.Effective = effective
+			effective = false
+			entries = append(entries, entry)
+		}
+		if effective {
+			entries = append(entries, MatrixEntry{
+				EmailType:   *emailType,
+				Source:      MatrixSourceNone,
+				Effective:   true,
+				RenderError: "no template: emails of this type cannot be sent for the application",
+			})
+		}
+	}
+	return entries, nil
+}
+
+// matrixCandidate is a template an email type may be sent with.
+type matrixCandidate struct {
+	source string
+	tmpl   *models.EmailTemplate
+}
+
+// matrixVariables returns the variables templates of emailType are rendered
+// with in a test matrix for the application appID.
+func (s *Service) matrixVariables(appID uuid.UUID, emailType *models.EmailType) (map[string]string, error) {
+	typeVars, err := TypeVariables(emailType)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string, len(typeVars))
+	for _, v := range typeVars {
+		vars[v.Name] = v.SampleValue
+	}
+	if s.resolver != nil {
+		s.resolver.applySettingsVars(vars, appID)
+		s.resolver.applyBrandingVars(vars, appID)
+	}
+	return vars, nil
+}
+
+// activeTemplatesByType indexes the active templates by email type.
+func activeTemplatesByType(templates []models.EmailTemplate) map[uuid.UUID]*models.EmailTemplate {
+	byType := make(map[uuid.UUID]*models.EmailTemplate, len(templates))
+	for i := range templates {
+		if templates[i].IsActive {
+			byType[templates[i].EmailTypeID] = &templates[i]
+		}
+	}
+	return byType
+}
+
+// testTemplate lints tmpl, renders it with vars and lists the required
+// variables of emailType it does not reference. It returns an error only if
+// emailType's variables are invalid.
+func testTemplate(tmpl *models.EmailTemplate, emailType *models.EmailType, vars map[string]string) (MatrixEntry, error) {
+	entry := MatrixEntry{EmailType: *emailType, Template: tmpl}
+	l, err := lintTemplate(tmpl, emailType)
+	if err != nil {
+		return entry, err
+	}
+	entry.Warnings = l.warnings
+
+	subject, _, _, err := NewRenderer().RenderTemplate(tmpl, vars)
+	if err != nil {
+		entry.RenderError = err.Error()
+	} else {
+		entry.Subject = subject
+	}
+
+	parsed := true
+	for _, w := range l.warnings {
+		if w.Code == LintParseError {
+			parsed = false
+		}
+	}
+	if parsed {
+		typeVars, err := TypeVariables(emailType)
+		if err != nil {
+			return entry, err
+		}
+		for _, v := range typeVars {
+			if v.Declared && v.Required && !l.references(v.Name) {
+				entry.MissingVariables = append(entry.MissingVariables, v.Name)
+			}
+		}
+	}
+	return entry, nil
+}
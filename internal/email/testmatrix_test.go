@@ -0,0 +1,90 @@
+package email
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"gorm.io/datatypes"
+)
+
+func TestTestTemplate(t *testing.T) {
+	emailType := &models.EmailType{
+		Code:      "order_shipped",
+		Variables: datatypes.JSON(`[{"name": "tracking_url", "required": true}, {"name": "carrier"}]`),
+	}
+	vars := map[string]string{VarAppName: "Shop", "tracking_url": "https://example.com/t/1"}
+
+	tests := []struct {
+		name        string
+		tmpl        models.EmailTemplate
+		subject     string
+		renderError bool
+		missing     []string
+		warnings    int
+	}{
+		{
+			name: "go template referencing the required variable",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEngineGoTemplate,
+				Subject:        "{{.AppName}} order shipped",
+				BodyHTML:       `<a href="{{.TrackingUrl}}">Track</a>`,
+			},
+			subject: "Shop order shipped",
+		},
+		{
+			name: "placeholder template without the required variable",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEnginePlaceholder,
+				Subject:        "{app_name} order shipped",
+				BodyText:       "Shipped with {carrier}",
+			},
+			subject: "Shop order shipped",
+			missing: []string{"tracking_url"},
+		},
+		{
+			name: "raw html template with an unknown variable",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEngineRawHTML,
+				Subject:        "Shipped",
+				BodyHTML:       "{{.tracking_url}} {{.Eta}}",
+			},
+			subject:  "Shipped",
+			warnings: 1,
+		},
+		{
+			name: "go template that does not parse",
+			tmpl: models.EmailTemplate{
+				TemplateEngine: models.TemplateEngineGoTemplate,
+				Subject:        "Shipped",
+				BodyHTML:       "{{if .AppName}}unclosed",
+			},
+			renderError: true,
+			warnings:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := testTemplate(&tt.tmpl, emailType, vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Subject != tt.subject {
+				t.Errorf("subject = %q, want %q", entry.Subject, tt.subject)
+			}
+			if (entry.RenderError != "") != tt.renderError {
+				t.Errorf("render error = %q", entry.RenderError)
+			}
+			if !reflect.DeepEqual(entry.MissingVariables, tt.missing) {
+				t.Errorf("missing variables = %v, want %v", entry.MissingVariables, tt.missing)
+			}
+			if len(entry.Warnings) != tt.warnings {
+				t.Errorf("got %d warnings, want %d: %+v", len(entry.Warnings), tt.warnings, entry.Warnings)
+			}
+			entry.Source = MatrixSourceApp
+			if ok := !tt.renderError && tt.missing == nil && tt.warnings == 0; entry.OK() != ok {
+				t.Errorf("OK() = %v, want %v", entry.OK(), ok)
+			}
+		})
+	}
+}
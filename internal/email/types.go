@@ -5,6 +5,7 @@ import "github.com/gjovanovicst/auth_api/pkg/models"
 // Email type code constants
 const (
 	TypeEmailVerification  = "email_verification"
+	TypeEmailVerifyCode    = "email_verification_code"
 	TypePasswordReset      = "password_reset"
 	TypeTwoFACode          = "two_fa_code"
 	TypeWelcome            = "welcome"
@@ -45,6 +46,16 @@ const (
 	VarApiKeyExpiresAt   = "api_key_expires_at" // #nosec G101 -- template variable name string, not a credential
 	VarDaysUntilExpiry   = "days_until_expiry"
 	VarBackupEmail       = "backup_email"
+	VarUnsubscribeURL    = "unsubscribe_url"
+
+	// Reserved branding variables, set from the application's email branding
+	// for every email; callers cannot override them (see branding.go)
+	VarBrandLogoURL     = "brand_logo_url"
+	VarBrandFooterText  = "brand_footer_text"
+	VarBrandAddress     = "brand_address"
+	VarBrandSocialLinks = "brand_social_links"
+	VarBrandFooter      = "brand_footer"
+	VarBrandFooterPlain = "brand_footer_plain"
 )
 
 // WellKnownVariables is the registry of all variables the system can auto-resolve.
@@ -64,12 +75,22 @@ var WellKnownVariables = []models.EmailTypeVariable{
 	// App/system settings variables (auto-resolved from config)
 	{Name: VarAppName, Description: "Application name", Source: models.VarSourceSetting},
 	{Name: VarFrontendURL, Description: "Frontend base URL", Source: models.VarSourceSetting},
+	{Name: VarUnsubscribeURL, Description: "Signed unsubscribe link (non-transactional email types only)", Source: models.VarSourceSetting},
+
+	// Reserved branding variables (from the application's email branding; appended
+	// automatically to templates that reference none of them)
+	{Name: VarBrandLogoURL, Description: "Email logo URL", Source: models.VarSourceSetting},
+	{Name: VarBrandFooterText, Description: "Email footer text", Source: models.VarSourceSetting},
+	{Name: VarBrandAddress, Description: "Physical postal address of the sender", Source: models.VarSourceSetting},
+	{Name: VarBrandSocialLinks, Description: "Social links as \"Label: URL\" separated by \" | \"", Source: models.VarSourceSetting},
+	{Name: VarBrandFooter, Description: "Complete HTML branding block (logo, social links, footer text, address)", Source: models.VarSourceSetting},
+	{Name: VarBrandFooterPlain, Description: "Complete plain-text branding block", Source: models.VarSourceSetting},
 
 	// Explicit variables (must be passed by the caller)
 	{Name: VarVerificationLink, Description: "Email verification URL (built from token + frontend URL)", Source: models.VarSourceExplicit},
 	{Name: VarVerificationToken, Description: "Raw email verification token", Source: models.VarSourceExplicit},
 	{Name: VarResetLink, Description: "Password reset URL", Source: models.VarSourceExplicit},
-	{Name: VarCode, Description: "2FA verification code", Source: models.VarSourceExplicit},
+	{Name: VarCode, Description: "Verification code (2FA or email verification)", Source: models.VarSourceExplicit},
 	{Name: VarExpirationMinutes, Description: "Expiration time in minutes", Source: models.VarSourceExplicit},
 	{Name: VarChangeTime, Description: "Timestamp when the change occurred", Source: models.VarSourceExplicit},
 	{Name: VarMagicLink, Description: "Magic link login URL", Source: models.VarSourceExplicit},
@@ -101,6 +122,10 @@ type SMTPConfig struct {
 	FromAddress string
 	FromName    string
 	UseTLS      bool
+	// AppScoped is set when the application chose the From address (its own
+	// SMTP config or template override), which is then checked against its
+	// tenant's sending domains.
+	AppScoped bool
 }
 
 // EmailData holds all the data needed to render and send an email.
@@ -123,3 +148,10 @@ const (
 
 // TypeBackupEmailVerification is the email type code for backup email verification.
 const TypeBackupEmailVerification = "backup_email_verification"
+
+// Email type codes of the sign-up approval notifications, sent when an admin
+// approves or rejects a registration held for approval.
+const (
+	TypeRegistrationApproved = "registration_approved"
+	TypeRegistrationRejected = "registration_rejected"
+)
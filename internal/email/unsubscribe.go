@@ -0,0 +1,207 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+var (
+	// ErrUnsubscribed is returned when the recipient has opted out of the
+	// category of the email being sent.
+	ErrUnsubscribed = errors.New("recipient has unsubscribed from this kind of email")
+	// ErrInvalidUnsubscribeToken is returned for a malformed or tampered unsubscribe link.
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe link")
+)
+
+// UnsubscribeClaims identify the recipient and email category of an
+// unsubscribe link.
+type UnsubscribeClaims struct {
+	AppID    uuid.UUID `json:"a"`
+	Email    string    `json:"e"`
+	Category string    `json:"c"`
+}
+
+// NewUnsubscribeToken serializes claims as base64(JSON) followed by an
+// HMAC-SHA256 signature keyed with JWT_SECRET. Tokens do not expire, so links
+// in old emails keep working.
+func NewUnsubscribeToken(claims UnsubscribeClaims) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + signUnsubscribePayload(payload), nil
+}
+
+func signUnsubscribePayload(payload string) string {
+	mac := hmac.New(sha256.New, []byte(viper.GetString("JWT_SECRET")))
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ParseUnsubscribeToken verifies the signature of an unsubscribe token and
+// returns its claims.
+func ParseUnsubscribeToken(token string) (*UnsubscribeClaims, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(signUnsubscribePayload(payload))) {
+		return nil, ErrInvalidUnsubscribeToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidUnsubscribeToken
+	}
+	var claims UnsubscribeClaims
+	if err := json.Unmarshal(data, &claims); err != nil || claims.Email == "" {
+		return nil, ErrInvalidUnsubscribeToken
+	}
+	if claims.Category != models.EmailCategorySecurity && claims.Category != models.EmailCategoryProduct {
+		return nil, ErrInvalidUnsubscribeToken
+	}
+	return &claims, nil
+}
+
+// UnsubscribeURL returns the unsubscribe link for a recipient and category,
+// served by this API under PUBLIC_URL.
+func UnsubscribeURL(appID uuid.UUID, email, category string) (string, error) {
+	token, err := NewUnsubscribeToken(UnsubscribeClaims{AppID: appID, Email: strings.ToLower(email), Category: category})
+	if err != nil {
+		return "", err
+	}
+	base := strings.TrimRight(viper.GetString("PUBLIC_URL"), "/")
+	return base + "/unsubscribe?token=" + url.QueryEscape(token), nil
+}
+
+// CategoryLabel returns a short description of an email category for the
+// unsubscribe page.
+func CategoryLabel(category string) string {
+	switch category {
+	case models.EmailCategorySecurity:
+		return "security alerts"
+	case models.EmailCategoryProduct:
+		return "product emails"
+	}
+	return category
+}
+
+// unsubscribeHeaders returns the List-Unsubscribe headers (RFC 2369 and the
+// RFC 8058 one-click variant) that let mail clients show an unsubscribe button.
+func unsubscribeHeaders(link string) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      "<" + link + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// addUnsubscribeFooter appends an unsubscribe link to the bodies of an email
+// whose template does not already include it (through {{.unsubscribe_url}}).
+func addUnsubscribeFooter(htmlBody, textBody, link, category string) (string, string) {
+	if htmlBody != "" && !strings.Contains(htmlBody, html.EscapeString(link)) && !strings.Contains(htmlBody, link) {
+		footer := fmt.Sprintf(`<p style="font-size:12px;color:#6c757d;text-align:center;margin-top:24px;">`+
+			`Don't want these emails? <a href="%s" style="color:#6c757d;">Unsubscribe from %s</a>.</p>`,
+			html.EscapeString(link), CategoryLabel(category))
+		if i := strings.LastIndex(strings.ToLower(htmlBody), "</body>"); i >= 0 {
+			htmlBody = htmlBody[:i] + footer + htmlBody[i:]
+		} else {
+			htmlBody += footer
+		}
+	}
+	if textBody != "" && !strings.Contains(textBody, link) {
+		textBody += fmt.Sprintf("\n\n--\nUnsubscribe from %s: %s\n", CategoryLabel(category), link)
+	}
+	return htmlBody, textBody
+}
+
+// emailCategory returns the category of an email type; unknown types are
+// treated as transactional.
+func (s *Service) emailCategory(typeCode string) string {
+	if s.repo == nil {
+		return models.EmailCategoryTransactional
+	}
+	emailType, err := s.repo.GetEmailTypeByCode(typeCode)
+	if err != nil || emailType == nil || emailType.Category == "" {
+		return models.EmailCategoryTransactional
+	}
+	return emailType.Category
+}
+
+// checkOptOut returns ErrUnsubscribed if the recipient opted out of the
+// category: through the notification preferences of the user with that
+// address, or by being on the application's suppression list.
+func (s *Service) checkOptOut(appID uuid.UUID, toEmail string, userID *uuid.UUID, category string) error {
+	if s.db == nil {
+		return nil
+	}
+	email := strings.ToLower(toEmail)
+	var user models.User
+	query := s.db.Select("notify_security_alerts", "notify_product_emails")
+	if userID != nil {
+		query = query.Where("id = ?", *userID)
+	} else {
+		query = query.Where("app_id = ? AND LOWER(email) = ?", appID, email)
+	}
+	result := query.Limit(1).Find(&user)
+	if result.Error != nil {
+		return fmt.Errorf("failed to check notification preferences: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		if (category == models.EmailCategorySecurity && !user.NotifySecurityAlerts) ||
+			(category == models.EmailCategoryProduct && !user.NotifyProductEmails) {
+			return ErrUnsubscribed
+		}
+	}
+
+	if s.repo != nil {
+		suppressed, err := s.repo.SuppressedEmails(appID, []string{email})
+		if err != nil {
+			return fmt.Errorf("failed to check the suppression list: %w", err)
+		}
+		if suppressed[email] {
+			return ErrUnsubscribed
+		}
+	}
+	return nil
+}
+
+// Unsubscribe applies an unsubscribe link. The user with the link's address
+// has the category turned off in their notification preferences; other
+// recipients (e.g. of batch sends) are added to the suppression list.
+func (s *Service) Unsubscribe(token string) (*UnsubscribeClaims, error) {
+	claims, err := ParseUnsubscribeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if s.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	column := "notify_product_emails"
+	if claims.Category == models.EmailCategorySecurity {
+		column = "notify_security_alerts"
+	}
+	result := s.db.Model(&models.User{}).
+		Where("app_id = ? AND LOWER(email) = ?", claims.AppID, claims.Email).
+		Update(column, false)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return claims, nil
+	}
+
+	if _, err := s.AddSuppression(claims.AppID, claims.Email, models.SuppressionReasonUnsubscribe,
+		"Unsubscribed from "+CategoryLabel(claims.Category)); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
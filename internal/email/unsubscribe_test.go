@@ -0,0 +1,120 @@
+package email
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestUnsubscribeTokenRoundTrip(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+
+	appID := uuid.New()
+	token, err := NewUnsubscribeToken(UnsubscribeClaims{AppID: appID, Email: "user@example.com", Category: models.EmailCategoryProduct})
+	if err != nil {
+		t.Fatalf("NewUnsubscribeToken: %v", err)
+	}
+	claims, err := ParseUnsubscribeToken(token)
+	if err != nil {
+		t.Fatalf("ParseUnsubscribeToken: %v", err)
+	}
+	if claims.AppID != appID || claims.Email != "user@example.com" || claims.Category != models.EmailCategoryProduct {
+		t.Errorf("claims = %+v, want the encoded ones", claims)
+	}
+
+	// Changing the payload invalidates the signature
+	payload, sig, _ := strings.Cut(token, ".")
+	forged, _ := NewUnsubscribeToken(UnsubscribeClaims{AppID: appID, Email: "other@example.com", Category: models.EmailCategoryProduct})
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	for _, bad := range []string{"", payload, forgedPayload + "." + sig, token + "x"} {
+		if _, err := ParseUnsubscribeToken(bad); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+			t.Errorf("ParseUnsubscribeToken(%q) = %v, want ErrInvalidUnsubscribeToken", bad, err)
+		}
+	}
+
+	// A token signed with another secret is rejected
+	viper.Set("JWT_SECRET", "other-secret")
+	if _, err := ParseUnsubscribeToken(token); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+		t.Errorf("token signed with another secret: err = %v, want ErrInvalidUnsubscribeToken", err)
+	}
+}
+
+func TestUnsubscribeTokenRejectsTransactional(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+
+	token, err := NewUnsubscribeToken(UnsubscribeClaims{AppID: uuid.New(), Email: "user@example.com", Category: models.EmailCategoryTransactional})
+	if err != nil {
+		t.Fatalf("NewUnsubscribeToken: %v", err)
+	}
+	if _, err := ParseUnsubscribeToken(token); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+		t.Errorf("err = %v, want transactional email to have no unsubscribe link", err)
+	}
+}
+
+func TestUnsubscribeURL(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	viper.Set("PUBLIC_URL", "https://auth.example.com/")
+	defer func() {
+		viper.Set("JWT_SECRET", "")
+		viper.Set("PUBLIC_URL", "")
+	}()
+
+	link, err := UnsubscribeURL(uuid.New(), "User@Example.com", models.EmailCategorySecurity)
+	if err != nil {
+		t.Fatalf("UnsubscribeURL: %v", err)
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "https" || u.Host != "auth.example.com" || u.Path != "/unsubscribe" {
+		t.Fatalf("link = %q, want https://auth.example.com/unsubscribe?token=...", link)
+	}
+	claims, err := ParseUnsubscribeToken(u.Query().Get("token"))
+	if err != nil {
+		t.Fatalf("ParseUnsubscribeToken: %v", err)
+	}
+	if claims.Email != "user@example.com" {
+		t.Errorf("email = %q, want it lowercased", claims.Email)
+	}
+}
+
+func TestAddUnsubscribeFooter(t *testing.T) {
+	link := "https://auth.example.com/unsubscribe?token=a.b&x=1"
+
+	html, text := addUnsubscribeFooter("<html><body><p>News</p></BODY></html>", "News", link, models.EmailCategoryProduct)
+	if !strings.Contains(html, `href="https://auth.example.com/unsubscribe?token=a.b&amp;x=1"`) {
+		t.Errorf("html = %q, want the escaped link", html)
+	}
+	if !strings.HasSuffix(html, "</p></BODY></html>") || strings.Index(html, "Unsubscribe") > strings.Index(html, "</BODY>") {
+		t.Errorf("html = %q, want the footer before </body>", html)
+	}
+	if !strings.Contains(text, "Unsubscribe from product emails: "+link) {
+		t.Errorf("text = %q, want the unsubscribe line", text)
+	}
+
+	// Templates that already render {{.unsubscribe_url}} are left alone
+	body := `<p><a href="https://auth.example.com/unsubscribe?token=a.b&amp;x=1">Opt out</a></p>`
+	if html, text := addUnsubscribeFooter(body, "Opt out: "+link, link, models.EmailCategoryProduct); html != body || text != "Opt out: "+link {
+		t.Errorf("footer added to bodies that already contain the link: %q, %q", html, text)
+	}
+
+	// Empty bodies stay empty
+	if html, text := addUnsubscribeFooter("", "", link, models.EmailCategoryProduct); html != "" || text != "" {
+		t.Errorf("footer added to empty bodies: %q, %q", html, text)
+	}
+}
+
+func TestUnsubscribeHeaders(t *testing.T) {
+	h := unsubscribeHeaders("https://auth.example.com/unsubscribe?token=abc")
+	if h["List-Unsubscribe"] != "<https://auth.example.com/unsubscribe?token=abc>" {
+		t.Errorf("List-Unsubscribe = %q", h["List-Unsubscribe"])
+	}
+	if h["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", h["List-Unsubscribe-Post"])
+	}
+}
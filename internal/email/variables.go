@@ -0,0 +1,163 @@
+package email
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// sampleValues are the values used for well-known variables when previewing
+// templates and in the variable list shown by the template editor.
+var sampleValues = map[string]string{
+	VarAppName:           "My Application",
+	VarUserEmail:         "user@example.com",
+	VarUserName:          "John Doe",
+	VarFirstName:         "John",
+	VarLastName:          "Doe",
+	VarLocale:            "en",
+	VarProfilePicture:    "https://example.com/avatar.png",
+	VarFrontendURL:       "https://example.com",
+	VarVerificationLink:  "https://example.com/verify?token=abc123",
+	VarVerificationToken: "abc123",
+	VarResetLink:         "https://example.com/reset?token=xyz789",
+	VarCode:              "123456",
+	VarExpirationMinutes: "5",
+	VarChangeTime:        "2026-02-22 10:30:00 UTC",
+	VarMagicLink:         "https://example.com/magic-link?token=ml456",
+	VarLoginIP:           "203.0.113.10",
+	VarLoginLocation:     "Berlin, Germany",
+	VarLoginDevice:       "Firefox on Linux",
+	VarLoginTime:         "2026-02-22 10:30:00 UTC",
+	VarAlertType:         "new_device",
+	VarAlertDetails:      "Sign-in from a device not seen before",
+	VarApiKeyName:        "CI deploy key",
+	VarApiKeyPrefix:      "ak_a1b2c3",
+	VarApiKeyType:        "admin",
+	VarApiKeyExpiresAt:   "2026-03-01 00:00 UTC",
+	VarDaysUntilExpiry:   "7",
+	VarBackupEmail:       "backup@example.com",
+	VarUnsubscribeURL:    "https://auth.example.com/unsubscribe?token=abc123",
+	VarBrandLogoURL:      sampleBranding.LogoURL,
+	VarBrandFooterText:   sampleBranding.FooterText,
+	VarBrandAddress:      sampleBranding.PostalAddress,
+	VarBrandSocialLinks:  sampleBranding.Vars()[VarBrandSocialLinks],
+	VarBrandFooter:       sampleBranding.html(),
+	VarBrandFooterPlain:  sampleBranding.text(),
+}
+
+// sampleBranding is the email branding shown in template previews.
+var sampleBranding = Branding{
+	LogoURL:       "https://example.com/logo.png",
+	FooterText:    "You are receiving this email because you have an account with My Application.",
+	PostalAddress: "My Company Ltd, 1 Example Street, Berlin, Germany",
+	SocialLinks:   "X https://x.com/example\nGitHub https://github.com/example",
+}
+
+// SampleValue returns the preview value of a variable: the sample for a
+// well-known variable, otherwise defaultValue, otherwise the name in brackets.
+func SampleValue(name, defaultValue string) string {
+	if v, ok := sampleValues[name]; ok {
+		return v
+	}
+	if defaultValue != "" {
+		return defaultValue
+	}
+	return "[" + name + "]"
+}
+
+// SampleVariables returns sample values for all well-known variables, for
+// rendering template previews.
+func SampleVariables() map[string]string {
+	vars := make(map[string]string, len(sampleValues))
+	for k, v := range sampleValues {
+		vars[k] = v
+	}
+	return vars
+}
+
+// SampleExplicitVariables returns sample values for the well-known variables
+// that callers pass at send time (links, codes, ...), for previews that
+// resolve the other variables from a real user.
+func SampleExplicitVariables() map[string]string {
+	vars := make(map[string]string)
+	for _, v := range WellKnownVariables {
+		if v.Source == models.VarSourceExplicit {
+			vars[v.Name] = sampleValues[v.Name]
+		}
+	}
+	return vars
+}
+
+// TemplateVariable is a variable that templates of an email type can reference.
+type TemplateVariable struct {
+	models.EmailTypeVariable
+	Declared    bool   // Listed in the email type's variables
+	WellKnown   bool   // In WellKnownVariables
+	SampleValue string // Value used for previews
+	GoTemplate  string // Reference syntax for the go_template and raw_html engines, e.g. {{.AppName}}
+	Placeholder string // Reference syntax for the placeholder engine, e.g. {app_name}
+}
+
+// Syntax returns how a template using engine references the variable.
+func (v TemplateVariable) Syntax(engine string) string {
+	if engine == models.TemplateEnginePlaceholder {
+		return v.Placeholder
+	}
+	return v.GoTemplate
+}
+
+// TypeVariables returns the variables available to templates of an email type:
+// the type's declared variables in their declared order, followed by the
+// well-known variables it does not declare. A declared variable that is also
+// well-known keeps its declared settings and inherits a missing description
+// or source from the registry.
+func TypeVariables(emailType *models.EmailType) ([]TemplateVariable, error) {
+	var declared []models.EmailTypeVariable
+	if emailType != nil && len(emailType.Variables) > 0 {
+		if err := json.Unmarshal(emailType.Variables, &declared); err != nil {
+			return nil, fmt.Errorf("failed to parse variables of email type %s: %w", emailType.Code, err)
+		}
+	}
+
+	wellKnown := make(map[string]models.EmailTypeVariable, len(WellKnownVariables))
+	for _, v := range WellKnownVariables {
+		wellKnown[v.Name] = v
+	}
+
+	seen := make(map[string]bool, len(declared)+len(WellKnownVariables))
+	vars := make([]TemplateVariable, 0, len(declared)+len(WellKnownVariables))
+	for _, v := range declared {
+		if v.Name == "" || seen[v.Name] {
+			continue
+		}
+		seen[v.Name] = true
+		known, isWellKnown := wellKnown[v.Name]
+		if isWellKnown {
+			if v.Description == "" {
+				v.Description = known.Description
+			}
+			if v.Source == "" {
+				v.Source = known.Source
+			}
+		}
+		vars = append(vars, newTemplateVariable(v, true, isWellKnown))
+	}
+	for _, v := range WellKnownVariables {
+		if !seen[v.Name] {
+			vars = append(vars, newTemplateVariable(v, false, true))
+		}
+	}
+	return vars, nil
+}
+
+func newTemplateVariable(v models.EmailTypeVariable, declared, wellKnown bool) TemplateVariable {
+	return TemplateVariable{
+		EmailTypeVariable: v,
+		Declared:          declared,
+		WellKnown:         wellKnown,
+		SampleValue:       SampleValue(v.Name, v.DefaultValue),
+		GoTemplate:        "{{." + snakeToPascal(v.Name) + "}}",
+		Placeholder:       "{" + v.Name + "}",
+	}
+}
@@ -0,0 +1,102 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"gorm.io/datatypes"
+)
+
+func TestTypeVariablesMergesDeclaredAndWellKnown(t *testing.T) {
+	emailType := &models.EmailType{
+		Code: "order_shipped",
+		Variables: datatypes.JSON(`[
+			{"name": "tracking_url", "description": "Carrier tracking link", "required": true},
+			{"name": "order_number", "default_value": "A-1000"},
+			{"name": "app_name", "required": true},
+			{"name": "tracking_url"}
+		]`),
+	}
+
+	vars, err := TypeVariables(emailType)
+	if err != nil {
+		t.Fatalf("TypeVariables: %v", err)
+	}
+	if len(vars) != 3+len(WellKnownVariables)-1 {
+		t.Fatalf("got %d variables, want the 3 declared plus the other well-known ones", len(vars))
+	}
+
+	want := []struct {
+		name                string
+		declared, wellKnown bool
+		sample              string
+	}{
+		{"tracking_url", true, false, "[tracking_url]"},
+		{"order_number", true, false, "A-1000"},
+		{"app_name", true, true, "My Application"},
+	}
+	for i, w := range want {
+		v := vars[i]
+		if v.Name != w.name || v.Declared != w.declared || v.WellKnown != w.wellKnown || v.SampleValue != w.sample {
+			t.Errorf("vars[%d] = %+v, want %+v", i, v, w)
+		}
+	}
+
+	appName := vars[2]
+	if !appName.Required || appName.Description != "Application name" || appName.Source != models.VarSourceSetting {
+		t.Errorf("declared well-known variable = %+v, want declared settings with the registry's description and source", appName)
+	}
+	if appName.GoTemplate != "{{.AppName}}" || appName.Placeholder != "{app_name}" {
+		t.Errorf("syntax = %q / %q", appName.GoTemplate, appName.Placeholder)
+	}
+	if got := appName.Syntax(models.TemplateEnginePlaceholder); got != "{app_name}" {
+		t.Errorf("Syntax(placeholder) = %q", got)
+	}
+	if got := appName.Syntax(models.TemplateEngineRawHTML); got != "{{.AppName}}" {
+		t.Errorf("Syntax(raw_html) = %q", got)
+	}
+
+	for _, v := range vars[3:] {
+		if v.Declared || !v.WellKnown || v.Name == VarAppName {
+			t.Errorf("undeclared variable %+v should be well-known and not repeat a declared one", v)
+		}
+	}
+}
+
+func TestTypeVariablesWithoutType(t *testing.T) {
+	vars, err := TypeVariables(nil)
+	if err != nil {
+		t.Fatalf("TypeVariables(nil): %v", err)
+	}
+	if len(vars) != len(WellKnownVariables) {
+		t.Errorf("got %d variables, want the %d well-known ones", len(vars), len(WellKnownVariables))
+	}
+}
+
+func TestTypeVariablesInvalidJSON(t *testing.T) {
+	_, err := TypeVariables(&models.EmailType{Code: "broken", Variables: datatypes.JSON(`{`)})
+	if err == nil {
+		t.Error("expected an error for invalid variables JSON")
+	}
+}
+
+func TestSampleVariablesCoverWellKnown(t *testing.T) {
+	samples := SampleVariables()
+	for _, v := range WellKnownVariables {
+		if samples[v.Name] == "" {
+			t.Errorf("well-known variable %s has no sample value", v.Name)
+		}
+	}
+}
+
+func TestSampleExplicitVariables(t *testing.T) {
+	samples := SampleExplicitVariables()
+	if samples[VarResetLink] == "" || samples[VarCode] == "" {
+		t.Errorf("SampleExplicitVariables() = %v, want samples for reset_link and code", samples)
+	}
+	for _, name := range []string{VarUserEmail, VarFirstName, VarAppName, VarBrandFooter} {
+		if _, ok := samples[name]; ok {
+			t.Errorf("SampleExplicitVariables() includes %s, which is resolved from the user or app", name)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidVariant is returned for a template variant with invalid fields.
+	ErrInvalidVariant = errors.New("invalid template variant")
+	// ErrVariantNameTaken is returned when another variant of the same
+	// application and email type has the name.
+	ErrVariantNameTaken = errors.New("a variant with this name already exists for the email type")
+	// ErrVariantNotFound is returned for an unknown variant.
+	ErrVariantNotFound = errors.New("template variant not found")
+)
+
+// variantRoll returns a number in [0, 100) that selects the variant of an
+// email. Replaced by tests.
+var variantRoll = func() int { return rand.Intn(100) } // #nosec G404 -- traffic split, not a secret
+
+// variantChoice is the variant an email was sent with, for its statistics.
+type variantChoice struct {
+	EmailTypeID uuid.UUID
+	VariantID   uuid.UUID // uuid.Nil for the control
+	Name        string
+}
+
+// pickVariant returns the variant that a roll in [0, 100) selects: each
+// variant takes as many values as its weight, in order, and the values left
+// select the control (nil).
+func pickVariant(variants []models.EmailTemplateVariant, roll int) *models.EmailTemplateVariant {
+	cumulative := 0
+	for i := range variants {
+		cumulative += variants[i].Weight
+		if roll < cumulative {
+			return &variants[i]
+		}
+	}
+	return nil
+}
+
+// applyVariant returns a copy of the regular template with the subject, bodies
+// and engine of the variant.
+func applyVariant(tmpl *models.EmailTemplate, variant *models.EmailTemplateVariant) *models.EmailTemplate {
+	applied := *tmpl
+	applied.Subject = variant.Subject
+	applied.BodyHTML = variant.BodyHTML
+	applied.BodyText = variant.BodyText
+	applied.TemplateEngine = variant.TemplateEngine
+	return &applied
+}
+
+// chooseVariant picks the template to send for an email of the application
+// when it has active variants of the email type. It returns tmpl and a nil
+// choice when there are none, or when they cannot be loaded.
+func (s *Service) chooseVariant(appID uuid.UUID, typeCode string, tmpl *models.EmailTemplate) (*models.EmailTemplate, *variantChoice) {
+	if s.repo == nil {
+		return tmpl, nil
+	}
+	variants, err := s.repo.ActiveVariants(appID, typeCode)
+	if err != nil {
+		log.Printf("Warning: failed to load template variants of %s for app %s: %v", typeCode, appID, err)
+		return tmpl, nil
+	}
+	if len(variants) == 0 {
+		return tmpl, nil
+	}
+	choice := &variantChoice{EmailTypeID: variants[0].EmailTypeID, Name: models.ControlVariantName}
+	if variant := pickVariant(variants, variantRoll()); variant != nil {
+		choice.VariantID, choice.Name = variant.ID, variant.Name
+		return applyVariant(tmpl, variant), choice
+	}
+	return tmpl, choice
+}
+
+// recordVariantSend counts an email sent with a variant in its statistics.
+func (s *Service) recordVariantSend(appID uuid.UUID, choice *variantChoice, sent bool) {
+	if choice == nil || s.repo == nil {
+		return
+	}
+	if err := s.repo.RecordVariantSend(appID, choice.EmailTypeID, choice.VariantID, sent, time.Now().UTC()); err != nil {
+		log.Printf("Warning: failed to record send of template variant %s for app %s: %v", choice.Name, appID, err)
+	}
+}
+
+// ListVariants returns a page of template variants sorted and filtered by q
+// (see VariantListSpec).
+func (s *Service) ListVariants(q pagination.ListQuery) ([]models.EmailTemplateVariant, int64, error) {
+	if s.repo == nil {
+		return nil, 0, fmt.Errorf("email repository not initialized")
+	}
+	return s.repo.ListVariants(q)
+}
+
+// GetVariant returns a template variant of an application (ErrVariantNotFound
+// when there is none).
+func (s *Service) GetVariant(appID, id uuid.UUID) (*models.EmailTemplateVariant, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	variant, err := s.repo.GetVariant(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if variant == nil {
+		return nil, ErrVariantNotFound
+	}
+	return variant, nil
+}
+
+// SaveVariant validates and stores a new or changed template variant and
+// returns the lint warnings of its subject and bodies. Names are unique per
+// application and email type, "control" is reserved, weights range from 1 to
+// 100 and the active variants of a type may not weigh more than 100 together.
+func (s *Service) SaveVariant(variant *models.EmailTemplateVariant) ([]LintWarning, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	variant.Name = strings.TrimSpace(variant.Name)
+	if variant.TemplateEngine == "" {
+		variant.TemplateEngine = models.TemplateEngineGoTemplate
+	}
+	switch {
+	case variant.Name == "" || len(variant.Name) > 50:
+		return nil, fmt.Errorf("%w: name must be 1 to 50 characters", ErrInvalidVariant)
+	case strings.EqualFold(variant.Name, models.ControlVariantName):
+		return nil, fmt.Errorf("%w: %q is reserved for the regular template", ErrInvalidVariant, models.ControlVariantName)
+	case strings.TrimSpace(variant.Subject) == "" || len(variant.Subject) > 255:
+		return nil, fmt.Errorf("%w: subject must be 1 to 255 characters", ErrInvalidVariant)
+	case variant.TemplateEngine != models.TemplateEngineGoTemplate && variant.TemplateEngine != models.TemplateEnginePlaceholder &&
+		variant.TemplateEngine != models.TemplateEngineRawHTML:
+		return nil, fmt.Errorf("%w: unknown template engine %q", ErrInvalidVariant, variant.TemplateEngine)
+	case variant.Weight < 1 || variant.Weight > 100:
+		return nil, fmt.Errorf("%w: weight must be a percentage from 1 to 100", ErrInvalidVariant)
+	}
+
+	emailType, err := s.repo.GetEmailTypeByID(variant.EmailTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if emailType == nil {
+		return nil, fmt.Errorf("%w: email type not found", ErrInvalidVariant)
+	}
+	taken, err := s.repo.VariantNameTaken(variant.AppID, variant.EmailTypeID, variant.Name, variant.ID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrVariantNameTaken
+	}
+	if variant.IsActive {
+		others, err := s.repo.ActiveVariantWeight(variant.AppID, variant.EmailTypeID, variant.ID)
+		if err != nil {
+			return nil, err
+		}
+		if others+variant.Weight > 100 {
+			return nil, fmt.Errorf("%w: the active variants of %s would weigh %d%%; at most 100%% can be split between variants",
+				ErrInvalidVariant, emailType.Code, others+variant.Weight)
+		}
+	}
+
+	warnings, err := LintTemplate(&models.EmailTemplate{
+		Subject:        variant.Subject,
+		BodyHTML:       variant.BodyHTML,
+		BodyText:       variant.BodyText,
+		TemplateEngine: variant.TemplateEngine,
+	}, emailType)
+	if err != nil {
+		log.Printf("Failed to lint template variant %s of type %s: %v", variant.Name, emailType.Code, err)
+	}
+	if err := s.repo.SaveVariant(variant); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// DeleteVariant deletes a template variant of an application with its
+// statistics (ErrVariantNotFound when there is none).
+func (s *Service) DeleteVariant(appID, id uuid.UUID) error {
+	variant, err := s.GetVariant(appID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteVariant(variant)
+}
+
+// VariantStats is the send statistics of one variant of an email type.
+type VariantStats struct {
+	VariantID  uuid.UUID // uuid.Nil for the control
+	Name       string
+	Weight     int // Configured percentage; for the control, what the active variants leave
+	IsActive   bool
+	Sent       int64
+	Failed     int64
+	LastSentAt *time.Time
+}
+
+// GetVariantStats returns the statistics of the control and of every variant
+// of an application's email type, control first.
+func (s *Service) GetVariantStats(appID, emailTypeID uuid.UUID) ([]VariantStats, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("email repository not initialized")
+	}
+	variants, err := s.repo.GetVariantsByType(appID, emailTypeID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.repo.VariantStats(appID, emailTypeID)
+	if err != nil {
+		return nil, err
+	}
+	return buildVariantStats(variants, stats), nil
+}
+
+// buildVariantStats joins variants with their counters. Counters of deleted
+// variants are left out.
+func buildVariantStats(variants []models.EmailTemplateVariant, stats []models.EmailVariantStat) []VariantStats {
+	byVariant := make(map[uuid.UUID]models.EmailVariantStat, len(stats))
+	for _, st := range stats {
+		byVariant[st.VariantID] = st
+	}
+
+	result := []VariantStats{{Name: models.ControlVariantName, Weight: 100, IsActive: true}}
+	for _, v := range variants {
+		if v.IsActive {
+			result[0].Weight -= v.Weight
+		}
+		st := byVariant[v.ID]
+		result = append(result, VariantStats{
+			VariantID: v.ID, Name: v.Name, Weight: v.Weight, IsActive: v.IsActive,
+			Sent: st.Sent, Failed: st.Failed, LastSentAt: st.LastSentAt,
+		})
+	}
+	st := byVariant[uuid.Nil]
+	result[0].Sent, result[0].Failed, result[0].LastSentAt = st.Sent, st.Failed, st.LastSentAt
+	return result
+}
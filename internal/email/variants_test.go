@@ -0,0 +1,79 @@
+package email
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestPickVariant(t *testing.T) {
+	variants := []models.EmailTemplateVariant{{Name: "a", Weight: 20}, {Name: "b", Weight: 30}}
+	tests := []struct {
+		roll int
+		want string
+	}{
+		{0, "a"}, {19, "a"}, {20, "b"}, {49, "b"}, {50, ""}, {99, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if v := pickVariant(variants, tt.roll); v != nil {
+			got = v.Name
+		}
+		if got != tt.want {
+			t.Errorf("pickVariant(roll %d) = %q, want %q", tt.roll, got, tt.want)
+		}
+	}
+	if v := pickVariant([]models.EmailTemplateVariant{{Name: "all", Weight: 100}}, 99); v == nil || v.Name != "all" {
+		t.Errorf("pickVariant(weight 100, roll 99) = %v, want the variant", v)
+	}
+}
+
+func TestApplyVariant(t *testing.T) {
+	tmpl := &models.EmailTemplate{Name: "Welcome", Subject: "Hi", BodyHTML: "<p>Hi</p>", FromEmail: "team@acme.io",
+		TemplateEngine: models.TemplateEngineGoTemplate}
+	variant := &models.EmailTemplateVariant{Subject: "Hello {name}", BodyHTML: "<p>Hello</p>", BodyText: "Hello",
+		TemplateEngine: models.TemplateEnginePlaceholder}
+
+	got := applyVariant(tmpl, variant)
+	if got.Subject != variant.Subject || got.BodyHTML != variant.BodyHTML || got.BodyText != variant.BodyText ||
+		got.TemplateEngine != variant.TemplateEngine {
+		t.Errorf("applyVariant() = %+v, want the variant's content", got)
+	}
+	if got.Name != "Welcome" || got.FromEmail != "team@acme.io" {
+		t.Errorf("applyVariant() = %+v, want the template's other settings", got)
+	}
+	if tmpl.Subject != "Hi" {
+		t.Errorf("applyVariant() changed the template's subject to %q", tmpl.Subject)
+	}
+}
+
+func TestBuildVariantStats(t *testing.T) {
+	a, b, deleted := uuid.New(), uuid.New(), uuid.New()
+	last := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	variants := []models.EmailTemplateVariant{
+		{ID: a, Name: "short", Weight: 30, IsActive: true},
+		{ID: b, Name: "paused", Weight: 50},
+	}
+	stats := []models.EmailVariantStat{
+		{VariantID: uuid.Nil, Sent: 70, Failed: 1, LastSentAt: &last},
+		{VariantID: a, Sent: 30},
+		{VariantID: deleted, Sent: 5},
+	}
+
+	got := buildVariantStats(variants, stats)
+	if len(got) != 3 {
+		t.Fatalf("buildVariantStats() returned %d entries, want 3", len(got))
+	}
+	if c := got[0]; c.Name != models.ControlVariantName || c.VariantID != uuid.Nil || c.Weight != 70 || c.Sent != 70 ||
+		c.Failed != 1 || c.LastSentAt != &last {
+		t.Errorf("control = %+v, want weight 70 and its counters", c)
+	}
+	if v := got[1]; v.VariantID != a || v.Sent != 30 || !v.IsActive {
+		t.Errorf("short = %+v", v)
+	}
+	if v := got[2]; v.VariantID != b || v.Sent != 0 || v.Weight != 50 || v.IsActive {
+		t.Errorf("paused = %+v, want no sends", v)
+	}
+}
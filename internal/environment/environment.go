@@ -0,0 +1,139 @@
+// Package environment resolves application environments.
+//
+// An environment (development, staging, production) is a child Application
+// whose ParentAppID points at the application it belongs to. Because it is a
+// regular application it automatically has its own OAuth provider configs, SMTP
+// configs, frontend/redirect URLs, app API keys and OIDC signing key. The
+// environment is selected per request by the app API key used (a key bound to
+// the environment) or by sending the environment's own ID as X-App-ID.
+//
+// The user base is either isolated (users, sessions and tokens are scoped to
+// the environment) or shared with the parent application (ShareUsers). For
+// shared environments the request's app ID is the parent's, and only
+// configuration lookups use the environment — see ConfigAppID.
+package environment
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Supported environment names.
+const (
+	Development = "development"
+	Staging     = "staging"
+	Production  = "production"
+)
+
+// ContextKey is the Gin context key holding the environment's application ID
+// (uuid.UUID) when a request was routed to an environment.
+const ContextKey = "environment_app_id"
+
+// cacheTTL bounds how long a resolved environment is reused. Environments are
+// looked up on every app-scoped request, so results are cached in memory.
+const cacheTTL = 30 * time.Second
+
+// IsValid reports whether name is a supported environment name.
+func IsValid(name string) bool {
+	switch name {
+	case Development, Staging, Production:
+		return true
+	}
+	return false
+}
+
+// ConfigAppID returns the application whose configuration (OAuth providers,
+// SMTP, branding) applies to the request: the environment selected for the
+// request, or appID when the request is not routed to an environment.
+func ConfigAppID(c *gin.Context, appID uuid.UUID) uuid.UUID {
+	if v, ok := c.Get(ContextKey); ok {
+		if envID, ok := v.(uuid.UUID); ok {
+			return envID
+		}
+	}
+	return appID
+}
+
+// Info describes how an application relates to its parent.
+type Info struct {
+	AppID       uuid.UUID
+	ParentAppID *uuid.UUID // nil for top-level applications
+	ShareUsers  bool
+}
+
+// IsEnvironment reports whether the application is an environment of another application.
+func (i *Info) IsEnvironment() bool {
+	return i != nil && i.ParentAppID != nil
+}
+
+type cacheEntry struct {
+	info    *Info
+	expires time.Time
+}
+
+// Resolver looks up environment information for applications.
+type Resolver struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	cache map[uuid.UUID]cacheEntry
+}
+
+// NewResolver creates a new Resolver.
+func NewResolver(db *gorm.DB) *Resolver {
+	return &Resolver{db: db, cache: make(map[uuid.UUID]cacheEntry)}
+}
+
+// Lookup returns the environment information of an application, or nil if the
+// application does not exist or cannot be loaded.
+func (r *Resolver) Lookup(appID uuid.UUID) *Info {
+	if r == nil || appID == uuid.Nil {
+		return nil
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	if e, ok := r.cache[appID]; ok && now.Before(e.expires) {
+		r.mu.Unlock()
+		return e.info
+	}
+	r.mu.Unlock()
+
+	var app models.Application
+	var info *Info
+	err := r.db.Select("id, parent_app_id, share_users").First(&app, "id = ?", appID).Error
+	if err == nil {
+		info = &Info{AppID: app.ID, ParentAppID: app.ParentAppID, ShareUsers: app.ShareUsers}
+	} else if err != gorm.ErrRecordNotFound {
+		// Transient error: do not cache, the next request retries.
+		return nil
+	}
+
+	r.mu.Lock()
+	r.cache[appID] = cacheEntry{info: info, expires: now.Add(cacheTTL)}
+	r.mu.Unlock()
+	return info
+}
+
+// Purge drops every cached lookup.
+func (r *Resolver) Purge() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.cache = make(map[uuid.UUID]cacheEntry)
+	r.mu.Unlock()
+}
+
+// UserPoolAppID returns the application that owns the user base of appID: the
+// parent for an environment sharing users, otherwise appID itself.
+func (r *Resolver) UserPoolAppID(appID uuid.UUID) uuid.UUID {
+	if info := r.Lookup(appID); info.IsEnvironment() && info.ShareUsers {
+		return *info.ParentAppID
+	}
+	return appID
+}
@@ -189,6 +189,13 @@ func (e *IPRuleEvaluator) InvalidateCache(appID uuid.UUID) {
 	delete(e.cache, appID)
 }
 
+// InvalidateAll clears the cached rules of every application.
+func (e *IPRuleEvaluator) InvalidateAll() {
+	e.cacheMu.Lock()
+	defer e.cacheMu.Unlock()
+	e.cache = make(map[uuid.UUID]*cachedRules)
+}
+
 // getRules retrieves rules for an app from cache or database
 func (e *IPRuleEvaluator) getRules(appID uuid.UUID) []models.IPRule {
 	e.cacheMu.RLock()
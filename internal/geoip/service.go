@@ -39,6 +39,9 @@ type Service struct {
 	reader    *geoip2.Reader
 	available bool
 	mu        sync.RWMutex
+
+	// Optional GeoLite2-ASN database (see LoadASNDatabase)
+	asnReader *geoip2.Reader
 }
 
 // NewService creates a new GeoIP service. If dbPath is empty or the database file
@@ -122,11 +125,53 @@ func (s *Service) LookupCountry(ipStr string) string {
 	return info.Country
 }
 
+// LoadASNDatabase opens a MaxMind GeoLite2-ASN database for LookupASN. An
+// empty path or a file that cannot be opened leaves ASN lookups disabled.
+func (s *Service) LoadASNDatabase(dbPath string) {
+	if dbPath == "" {
+		return
+	}
+	reader, err := geoip2.Open(dbPath)
+	if err != nil {
+		log.Printf("GeoIP: Failed to open ASN database at %s: %v. ASN lookups disabled.", dbPath, err)
+		return
+	}
+	s.mu.Lock()
+	s.asnReader = reader
+	s.mu.Unlock()
+	log.Printf("GeoIP: ASN database loaded successfully from %s", dbPath)
+}
+
+// LookupASN resolves an IP address to its autonomous system number and
+// organization. Returns 0 and an empty string if no ASN database is loaded or
+// the IP is private or not found.
+func (s *Service) LookupASN(ipStr string) (uint, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.asnReader == nil {
+		return 0, ""
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return 0, ""
+	}
+	record, err := s.asnReader.ASN(ip)
+	if err != nil {
+		return 0, ""
+	}
+	return record.AutonomousSystemNumber, record.AutonomousSystemOrganization
+}
+
 // Close releases the GeoIP database resources.
 func (s *Service) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.asnReader != nil {
+		_ = s.asnReader.Close()
+		s.asnReader = nil
+	}
 	if s.reader != nil {
 		err := s.reader.Close()
 		s.reader = nil
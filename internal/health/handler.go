@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -402,6 +403,15 @@ func (h *Handler) GetHealthData() dto.HealthResponse {
 	}
 }
 
+// GetDatastoreHealth checks only PostgreSQL and Redis, for panels refreshed
+// too often to also probe SMTP.
+func (h *Handler) GetDatastoreHealth() map[string]dto.ComponentStatus {
+	return map[string]dto.ComponentStatus{
+		"database": checkDatabase(h.db),
+		"redis":    checkRedis(h.rdb),
+	}
+}
+
 // MetricsSummary holds key application and runtime metrics in a typed,
 // template-friendly structure. Counters are totals since process start.
 type MetricsSummary struct {
@@ -490,6 +500,51 @@ func (h *Handler) GetMetricsSummary() MetricsSummary {
 	return summary
 }
 
+// CounterTotals holds the request and auth counters summed across their
+// labels, since process start.
+type CounterTotals struct {
+	Requests      float64
+	ServerErrors  float64 // Responses with a 5xx status
+	LoginSuccess  float64
+	LoginFailure  float64
+	Registrations float64
+	Logouts       float64
+}
+
+// GetCounterTotals reads the request and auth counters from the Prometheus
+// registry. Unlike GetMetricsSummary it does not refresh the gauges, so it
+// is cheap enough to call every minute.
+func GetCounterTotals() CounterTotals {
+	var totals CounterTotals
+	mfs, err := registry.Gather()
+	if err != nil {
+		return totals
+	}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			val := m.GetCounter().GetValue()
+			switch mf.GetName() {
+			case "http_requests_total":
+				totals.Requests += val
+				for _, lp := range m.GetLabel() {
+					if lp.GetName() == "status_code" && strings.HasPrefix(lp.GetValue(), "5") {
+						totals.ServerErrors += val
+					}
+				}
+			case "auth_login_success_total":
+				totals.LoginSuccess += val
+			case "auth_login_failure_total":
+				totals.LoginFailure += val
+			case "auth_register_total":
+				totals.Registrations += val
+			case "auth_logout_total":
+				totals.Logouts += val
+			}
+		}
+	}
+	return totals
+}
+
 // ----------------------------------------------------------------------------
 // SMTP address resolver — called from main.go during startup
 // ----------------------------------------------------------------------------
@@ -0,0 +1,200 @@
+package health
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	promdto "github.com/prometheus/client_model/go"
+	"github.com/spf13/viper"
+)
+
+// ----------------------------------------------------------------------------
+// Latency and error budget — read from the HTTP request metrics
+// ----------------------------------------------------------------------------
+
+// excludedLatencyRoutes are long-lived requests whose duration is not latency:
+// they would dominate the percentiles of their route group.
+var excludedLatencyRoutes = map[string]bool{
+	"/gui/dashboard/stream": true,
+}
+
+// RouteGroupStats holds request latency and error figures for one route group
+// (first path segment, e.g. "/auth"), since process start.
+type RouteGroupStats struct {
+	Group     string
+	Requests  float64
+	Errors    float64 // Responses with a 5xx status
+	ErrorRate float64 // Errors / Requests, in percent
+	P50Ms     float64
+	P95Ms     float64
+
+	// BudgetRemaining is the share of the error budget left, in percent: 100
+	// with no errors, 0 when the error rate reaches 100 - SLO target, and
+	// negative once the budget is exhausted.
+	BudgetRemaining float64
+}
+
+// LatencySummary is the latency and error budget panel of the admin dashboard.
+type LatencySummary struct {
+	SLOTarget float64 // Availability target in percent (SLO_AVAILABILITY_TARGET)
+	Overall   RouteGroupStats
+	Groups    []RouteGroupStats // Sorted by request count, busiest first
+}
+
+// RouteGroup returns the group of a route pattern: its first path segment.
+func RouteGroup(path string) string {
+	if path == "unmatched" || path == "" {
+		return "unmatched"
+	}
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	return "/" + segment
+}
+
+// sloTarget returns SLO_AVAILABILITY_TARGET (default 99.9), clamped to a
+// meaningful range.
+func sloTarget() float64 {
+	target := viper.GetFloat64("SLO_AVAILABILITY_TARGET")
+	if target <= 0 || target >= 100 {
+		return 99.9
+	}
+	return target
+}
+
+// latencyAccumulator merges histogram buckets of several routes.
+type latencyAccumulator struct {
+	requests float64
+	errors   float64
+	count    uint64
+	buckets  map[float64]uint64 // upper bound -> cumulative count
+}
+
+func (a *latencyAccumulator) addHistogram(h *promdto.Histogram) {
+	if a.buckets == nil {
+		a.buckets = make(map[float64]uint64)
+	}
+	a.count += h.GetSampleCount()
+	for _, b := range h.GetBucket() {
+		a.buckets[b.GetUpperBound()] += b.GetCumulativeCount()
+	}
+}
+
+func (a *latencyAccumulator) stats(group string, target float64) RouteGroupStats {
+	s := RouteGroupStats{
+		Group:           group,
+		Requests:        a.requests,
+		Errors:          a.errors,
+		BudgetRemaining: 100,
+		P50Ms:           histogramQuantile(0.50, a.buckets, a.count) * 1000,
+		P95Ms:           histogramQuantile(0.95, a.buckets, a.count) * 1000,
+	}
+	if a.requests > 0 {
+		s.ErrorRate = a.errors / a.requests * 100
+		s.BudgetRemaining = 100 - s.ErrorRate/(100-target)*100
+	}
+	return s
+}
+
+// histogramQuantile estimates the q-quantile (in seconds) from cumulative
+// buckets by linear interpolation within the bucket holding the rank, as
+// Prometheus' histogram_quantile does. Observations above the largest bucket
+// are reported as its upper bound.
+func histogramQuantile(q float64, buckets map[float64]uint64, count uint64) float64 {
+	if count == 0 || len(buckets) == 0 {
+		return 0
+	}
+	bounds := make([]float64, 0, len(buckets))
+	for upper := range buckets {
+		bounds = append(bounds, upper)
+	}
+	sort.Float64s(bounds)
+
+	rank := q * float64(count)
+	lower, below := 0.0, 0.0
+	for _, upper := range bounds {
+		cumulative := float64(buckets[upper])
+		if cumulative >= rank {
+			if cumulative == below {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-below)/(cumulative-below)
+		}
+		lower, below = upper, cumulative
+	}
+	return bounds[len(bounds)-1]
+}
+
+// GetLatencySummary reads request latency percentiles and 5xx error rates per
+// route group from the Prometheus registry, with the remaining error budget
+// against SLO_AVAILABILITY_TARGET.
+func (h *Handler) GetLatencySummary() LatencySummary {
+	summary := LatencySummary{SLOTarget: sloTarget()}
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		return summary
+	}
+
+	groups := make(map[string]*latencyAccumulator)
+	overall := &latencyAccumulator{}
+	group := func(labels []*promdto.LabelPair) (*latencyAccumulator, bool) {
+		var path string
+		for _, lp := range labels {
+			if lp.GetName() == "path" {
+				path = lp.GetValue()
+			}
+		}
+		if excludedLatencyRoutes[path] {
+			return nil, false
+		}
+		name := RouteGroup(path)
+		if groups[name] == nil {
+			groups[name] = &latencyAccumulator{}
+		}
+		return groups[name], true
+	}
+
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "http_requests_total":
+			for _, m := range mf.GetMetric() {
+				acc, ok := group(m.GetLabel())
+				if !ok {
+					continue
+				}
+				val := m.GetCounter().GetValue()
+				acc.requests += val
+				overall.requests += val
+				for _, lp := range m.GetLabel() {
+					if lp.GetName() == "status_code" {
+						if code, _ := strconv.Atoi(lp.GetValue()); code >= 500 {
+							acc.errors += val
+							overall.errors += val
+						}
+					}
+				}
+			}
+		case "http_request_duration_seconds":
+			for _, m := range mf.GetMetric() {
+				acc, ok := group(m.GetLabel())
+				if !ok {
+					continue
+				}
+				acc.addHistogram(m.GetHistogram())
+				overall.addHistogram(m.GetHistogram())
+			}
+		}
+	}
+
+	summary.Overall = overall.stats("all", summary.SLOTarget)
+	for name, acc := range groups {
+		summary.Groups = append(summary.Groups, acc.stats(name, summary.SLOTarget))
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		if summary.Groups[i].Requests != summary.Groups[j].Requests {
+			return summary.Groups[i].Requests > summary.Groups[j].Requests
+		}
+		return summary.Groups[i].Group < summary.Groups[j].Group
+	})
+	return summary
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	// 10 requests: 5 under 10ms, 4 in 10-100ms, 1 in 100ms-1s
+	buckets := map[float64]uint64{0.01: 5, 0.1: 9, 1: 10}
+	tests := []struct {
+		q, want float64
+	}{
+		{0.5, 0.01},
+		{0.9, 0.1},
+		{0.95, 0.55},
+	}
+	for _, tc := range tests {
+		if got := histogramQuantile(tc.q, buckets, 10); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("histogramQuantile(%v) = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+	if got := histogramQuantile(0.5, nil, 0); got != 0 {
+		t.Errorf("empty histogram: got %v, want 0", got)
+	}
+}
+
+func TestRouteGroup(t *testing.T) {
+	for path, want := range map[string]string{
+		"/auth/login":          "/auth",
+		"/admin/apps/:id":      "/admin",
+		"/health":              "/health",
+		"unmatched":            "unmatched",
+		"/gui/dashboard/stats": "/gui",
+	} {
+		if got := RouteGroup(path); got != want {
+			t.Errorf("RouteGroup(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestGetLatencySummaryErrorBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(PrometheusMiddleware())
+	r.GET("/latencytest/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/latencytest/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	r.GET("/gui/dashboard/stream", func(c *gin.Context) { time.Sleep(10 * time.Millisecond) })
+	for i := 0; i < 999; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/latencytest/ok", nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/latencytest/fail", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/gui/dashboard/stream", nil))
+
+	summary := (&Handler{}).GetLatencySummary()
+	var group *RouteGroupStats
+	for i := range summary.Groups {
+		if summary.Groups[i].Group == "/latencytest" {
+			group = &summary.Groups[i]
+		}
+		if summary.Groups[i].Group == "/gui" {
+			t.Error("long-lived stream requests should not be counted")
+		}
+	}
+	if group == nil {
+		t.Fatal("route group /latencytest missing")
+	}
+	if group.Requests != 1000 || group.Errors != 1 {
+		t.Errorf("requests = %v, errors = %v; want 1000, 1", group.Requests, group.Errors)
+	}
+	// 0.1% errors against a 99.9% target uses the whole budget
+	if math.Abs(group.BudgetRemaining) > 1e-6 {
+		t.Errorf("BudgetRemaining = %v, want 0", group.BudgetRemaining)
+	}
+}
@@ -0,0 +1,183 @@
+package jobqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Handler exposes the background job queue on the Admin API.
+type Handler struct {
+	Queue *Queue
+}
+
+// NewHandler creates a new job queue handler.
+func NewHandler(queue *Queue) *Handler {
+	return &Handler{Queue: queue}
+}
+
+// ToResponse maps a job to its API representation.
+func ToResponse(job *models.BackgroundJob) dto.BackgroundJobResponse {
+	resp := dto.BackgroundJobResponse{
+		ID:              job.ID,
+		Type:            job.Type,
+		Status:          job.Status,
+		Progress:        job.Progress,
+		ProgressMessage: job.ProgressMessage,
+		Error:           job.Error,
+		Attempts:        job.Attempts,
+		MaxAttempts:     job.MaxAttempts,
+		CancelRequested: job.CancelRequested,
+		CreatedBy:       job.CreatedBy,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+	if job.Result != "" {
+		resp.Result = json.RawMessage(job.Result)
+	}
+	return resp
+}
+
+// ListJobs returns recent background jobs, newest first
+// @Summary List background jobs
+// @Description Returns recent background jobs (bulk imports and other long-running admin operations), newest first by default.
+// @Tags Admin
+// @Produce json
+// @Param   page                query  int     false  "Page number" default(1)
+// @Param   page_size           query  int     false  "Page size (max 100)" default(50)
+// @Param   sort                query  string  false  "Sort field: created_at, finished_at, status, type (prefix with - for descending)" default(created_at)
+// @Param   order               query  string  false  "Sort order: asc or desc" default(desc)
+// @Param   filter[status]      query  string  false  "Status: queued, running, succeeded, failed, cancelled (also accepted as status)"
+// @Param   filter[type]        query  string  false  "Job type (also accepted as type)"
+// @Param   filter[created_by]  query  string  false  "Admin who queued the job"
+// @Success 200 {object} dto.BackgroundJobListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/jobs [get]
+func (h *Handler) ListJobs(c *gin.Context) {
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), jobListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	jobs, total, err := h.Queue.ListPage(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load background jobs"})
+		return
+	}
+
+	resp := dto.BackgroundJobListResponse{
+		Jobs:       make([]dto.BackgroundJobResponse, 0, len(jobs)),
+		Total:      total,
+		Page:       q.Page,
+		PageSize:   q.PageSize,
+		TotalPages: q.TotalPages(total),
+		Sort:       q.Sort,
+		Order:      q.Order,
+	}
+	for i := range jobs {
+		resp.Jobs = append(resp.Jobs, ToResponse(&jobs[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetJob returns a background job with its progress and result
+// @Summary Get a background job
+// @Description Returns a background job. Poll it to follow progress; `result` is set once the job has succeeded.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "Job ID"
+// @Success 200 {object} dto.BackgroundJobResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/jobs/{id} [get]
+func (h *Handler) GetJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+	job, err := h.Queue.Get(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Background job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load background job"})
+		return
+	}
+	c.JSON(http.StatusOK, ToResponse(job))
+}
+
+// CancelJob cancels a queued or running background job
+// @Summary Cancel a background job
+// @Description Cancels a queued job, or asks a running job to stop. Work already done by a running job is kept.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "Job ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/jobs/{id}/cancel [post]
+func (h *Handler) CancelJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+	if err := h.Queue.Cancel(id); err != nil {
+		if errors.Is(err, ErrNotCancelled) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to cancel background job"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Cancellation requested"})
+}
+
+// RetryJob queues a failed or cancelled background job again
+// @Summary Retry a background job
+// @Description Queues a failed or cancelled job to run again from the start with a fresh set of attempts.
+// @Tags Admin
+// @Produce json
+// @Param   id  path  string  true  "Job ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/jobs/{id}/retry [post]
+func (h *Handler) RetryJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+	if err := h.Queue.Retry(id); err != nil {
+		if errors.Is(err, ErrNotRetryable) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to retry background job"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Job queued for retry"})
+}
+
+func parseJobID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid job ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
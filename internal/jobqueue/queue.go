@@ -0,0 +1,438 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// cancelPollInterval is how often a worker checks the database for a
+// cancellation requested through another instance.
+const cancelPollInterval = 3 * time.Second
+
+var (
+	ErrUnknownType  = errors.New("unknown background job type")
+	ErrNotCancelled = errors.New("background job has already finished")
+	ErrNotRetryable = errors.New("only failed or cancelled background jobs can be retried")
+	ErrQueueClosed  = errors.New("background job queue is shut down")
+)
+
+// HandlerFunc executes one job. The returned result is stored as JSON and the
+// error, if any, fails the attempt (it is retried until MaxAttempts). The
+// context is cancelled when the job is cancelled or the server shuts down.
+type HandlerFunc func(ctx context.Context, task *Task) (result interface{}, err error)
+
+// Task is the job being executed, passed to a HandlerFunc.
+type Task struct {
+	Job   *models.BackgroundJob
+	queue *Queue
+}
+
+// Decode unmarshals the job payload into v.
+func (t *Task) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(t.Job.Payload), v)
+}
+
+// SetProgress records how far the job has got (0-100) with a short message.
+// Failures are logged only; progress is informational.
+func (t *Task) SetProgress(percent int, message string) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	t.Job.Progress = percent
+	t.Job.ProgressMessage = message
+	if err := t.queue.repo.UpdateProgress(t.Job.ID, percent, truncate(message, 255)); err != nil {
+		log.Printf("Job queue: failed to update progress of job %s: %v", t.Job.ID, err)
+	}
+}
+
+// SaveCheckpoint stores v as the job's result while it runs, so an attempt
+// restarted after a shutdown, or a retry of a cancelled job, can read
+// Job.Result and resume instead of starting over. The checkpoint is kept when
+// the job is cancelled and replaced by the final result on success.
+func (t *Task) SaveCheckpoint(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.Job.Result = string(data)
+	return t.queue.repo.UpdateResult(t.Job.ID, t.Job.Result)
+}
+
+// SetSummary sets the message stored with the job when it finishes.
+func (t *Task) SetSummary(message string) {
+	t.Job.ProgressMessage = message
+}
+
+type jobType struct {
+	handler     HandlerFunc
+	maxAttempts int
+}
+
+// Queue is a DB-backed background job queue processed by a pool of workers.
+// Jobs are claimed with SELECT ... FOR UPDATE SKIP LOCKED, so any number of
+// API instances can share the queue.
+type Queue struct {
+	repo     *Repository
+	instance string
+	workers  int
+	poll     time.Duration
+
+	mu      sync.Mutex
+	types   map[string]jobType
+	running map[uuid.UUID]context.CancelFunc
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewQueue creates a Queue. The worker pool size and polling interval come from
+// JOB_QUEUE_WORKERS (default 2) and JOB_QUEUE_POLL_SECONDS (default 2).
+func NewQueue(repo *Repository) *Queue {
+	workers := viper.GetInt("JOB_QUEUE_WORKERS")
+	if workers <= 0 {
+		workers = 2
+	}
+	poll := viper.GetInt("JOB_QUEUE_POLL_SECONDS")
+	if poll <= 0 {
+		poll = 2
+	}
+	instance, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		repo:     repo,
+		instance: instance,
+		workers:  workers,
+		poll:     time.Duration(poll) * time.Second,
+		types:    make(map[string]jobType),
+		running:  make(map[uuid.UUID]context.CancelFunc),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register adds a job type. Failed jobs of this type are attempted up to
+// maxAttempts times in total (minimum 1).
+func (q *Queue) Register(name string, maxAttempts int, handler HandlerFunc) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.types[name] = jobType{handler: handler, maxAttempts: maxAttempts}
+}
+
+// Types returns the registered job type names, sorted.
+func (q *Queue) Types() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	names := make([]string, 0, len(q.types))
+	for name := range q.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start requeues jobs this instance left running and launches the workers.
+func (q *Queue) Start() {
+	if n, err := q.repo.RequeueInterrupted(q.instance); err != nil {
+		log.Printf("Job queue: failed to requeue interrupted jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("Job queue: requeued %d interrupted job(s)", n)
+	}
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	log.Printf("Background job queue started (workers: %d, poll: %s)", q.workers, q.poll)
+}
+
+// Shutdown stops the workers. Running jobs are cancelled and put back in the queue.
+func (q *Queue) Shutdown() {
+	if q == nil {
+		return
+	}
+	log.Println("Shutting down background job queue...")
+	q.cancel()
+	q.wg.Wait()
+}
+
+// Enqueue stores a new job of a registered type with a JSON-encodable payload.
+func (q *Queue) Enqueue(jobTypeName string, payload interface{}, createdBy string) (*models.BackgroundJob, error) {
+	return q.EnqueueAt(jobTypeName, payload, createdBy, time.Now())
+}
+
+// EnqueueAt stores a new job like Enqueue that does not start before runAfter.
+func (q *Queue) EnqueueAt(jobTypeName string, payload interface{}, createdBy string, runAfter time.Time) (*models.BackgroundJob, error) {
+	q.mu.Lock()
+	jt, ok := q.types[jobTypeName]
+	q.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, jobTypeName)
+	}
+	if q.ctx.Err() != nil {
+		return nil, ErrQueueClosed
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+	job := &models.BackgroundJob{
+		Type:        jobTypeName,
+		Status:      StatusQueued,
+		Payload:     string(data),
+		MaxAttempts: jt.maxAttempts,
+		RunAfter:    runAfter.UTC(),
+		CreatedBy:   createdBy,
+	}
+	if err := q.repo.Create(job); err != nil {
+		return nil, err
+	}
+
+	q.notify()
+	return job, nil
+}
+
+// notify wakes an idle worker instead of waiting for the next poll.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Get returns a job by ID.
+func (q *Queue) Get(id uuid.UUID) (*models.BackgroundJob, error) {
+	return q.repo.GetByID(id)
+}
+
+// List returns recent jobs, newest first, optionally filtered by status and type.
+func (q *Queue) List(status, jobType string, limit int) ([]models.BackgroundJob, error) {
+	return q.repo.List(status, jobType, limit)
+}
+
+// ListPage returns a page of jobs sorted and filtered by q (see jobListSpec).
+func (q *Queue) ListPage(lq pagination.ListQuery) ([]models.BackgroundJob, int64, error) {
+	return q.repo.ListPage(lq)
+}
+
+// Count returns the number of jobs of a type in a status, e.g. the queue depth
+// of a job type with StatusQueued.
+func (q *Queue) Count(status, jobType string) (int64, error) {
+	return q.repo.Count(status, jobType)
+}
+
+// Cancel cancels a queued job or stops a running one. A job running on another
+// instance stops within a few seconds, when its worker notices the request.
+func (q *Queue) Cancel(id uuid.UUID) error {
+	ok, err := q.repo.RequestCancel(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotCancelled
+	}
+	q.mu.Lock()
+	if stop, running := q.running[id]; running {
+		stop()
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+// Retry queues a failed or cancelled job to run again from scratch.
+func (q *Queue) Retry(id uuid.UUID) error {
+	ok, err := q.repo.Requeue(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotRetryable
+	}
+	q.notify()
+	return nil
+}
+
+// Cleanup deletes finished jobs older than JOB_QUEUE_RETENTION_DAYS (default 30).
+// Its signature matches scheduler.JobFunc.
+func (q *Queue) Cleanup(ctx context.Context) error {
+	days := viper.GetInt("JOB_QUEUE_RETENTION_DAYS")
+	if days <= 0 {
+		days = 30
+	}
+	deleted, err := q.repo.DeleteFinishedBefore(time.Now().UTC().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Job queue: deleted %d finished job(s) older than %d days", deleted, days)
+	}
+	return nil
+}
+
+// worker claims and executes jobs until the queue shuts down.
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.poll)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting again
+		for q.ctx.Err() == nil && q.runNext() {
+		}
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// runNext claims one job and executes it. It reports whether a job was run.
+func (q *Queue) runNext() bool {
+	types := q.Types()
+	if len(types) == 0 {
+		return false
+	}
+	job, err := q.repo.ClaimNext(types, q.instance)
+	if err != nil {
+		log.Printf("Job queue: failed to claim job: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	jt := q.types[job.Type]
+	ctx, stop := context.WithCancel(q.ctx)
+	q.running[job.ID] = stop
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		delete(q.running, job.ID)
+		q.mu.Unlock()
+		stop()
+	}()
+
+	go q.watchCancel(ctx, job.ID, stop)
+
+	task := &Task{Job: job, queue: q}
+	result, runErr := runHandler(ctx, jt.handler, task)
+	q.finish(ctx, task, jt, result, runErr)
+	return true
+}
+
+// watchCancel stops a running job when a cancellation is requested through the database.
+func (q *Queue) watchCancel(ctx context.Context, id uuid.UUID, stop context.CancelFunc) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if requested, err := q.repo.IsCancelRequested(id); err == nil && requested {
+				stop()
+				return
+			}
+		}
+	}
+}
+
+// finish records the outcome of an attempt: success, cancellation, a retry
+// with exponential backoff, or final failure.
+func (q *Queue) finish(ctx context.Context, task *Task, jt jobType, result interface{}, runErr error) {
+	job := task.Job
+	var err error
+	switch {
+	case runErr == nil:
+		var data []byte
+		if result != nil {
+			if data, err = json.Marshal(result); err != nil {
+				log.Printf("Job queue: failed to encode result of job %s: %v", job.ID, err)
+			}
+		}
+		err = q.repo.Finish(job.ID, StatusSucceeded, string(data), "", truncate(job.ProgressMessage, 255))
+	case ctx.Err() != nil && q.ctx.Err() == nil:
+		// Cancelled by an admin (a shutdown requeues the job instead)
+		err = q.repo.Finish(job.ID, StatusCancelled, job.Result, "cancelled", "")
+	case q.ctx.Err() != nil:
+		// Server shutdown: run the job again on the next start (or on another instance)
+		err = q.repo.Retry(job.ID, "interrupted by server shutdown", time.Now().UTC())
+	case job.Attempts < jt.maxAttempts:
+		runAfter := time.Now().UTC().Add(retryBackoff(job.Attempts))
+		log.Printf("Job queue: job %s (%s) failed, retrying at %s: %v", job.ID, job.Type, runAfter.Format(time.RFC3339), runErr)
+		err = q.repo.Retry(job.ID, runErr.Error(), runAfter)
+	default:
+		log.Printf("Job queue: job %s (%s) failed after %d attempt(s): %v", job.ID, job.Type, job.Attempts, runErr)
+		err = q.repo.Finish(job.ID, StatusFailed, "", runErr.Error(), "")
+	}
+	if err != nil {
+		log.Printf("Job queue: failed to record result of job %s: %v", job.ID, err)
+	}
+}
+
+// retryBackoff returns the delay before retrying after the given attempt:
+// 30s, 1m, 2m, ... capped at 30 minutes.
+func retryBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := 30 * time.Second
+	for i := 1; i < attempt && delay < 30*time.Minute; i++ {
+		delay *= 2
+	}
+	if delay > 30*time.Minute {
+		delay = 30 * time.Minute
+	}
+	return delay
+}
+
+// runHandler calls the handler, turning a panic into an error.
+func runHandler(ctx context.Context, handler HandlerFunc, task *Task) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, task)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,51 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{7, 30 * time.Minute},
+		{20, 30 * time.Minute},
+	}
+	for _, tc := range cases {
+		if got := retryBackoff(tc.attempt); got != tc.want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	q := NewQueue(nil)
+	if _, err := q.Enqueue("missing", nil, "admin"); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("unknown type: err = %v, want ErrUnknownType", err)
+	}
+
+	q.Register("noop", 0, func(context.Context, *Task) (interface{}, error) { return nil, nil })
+	if got := q.Types(); len(got) != 1 || got[0] != "noop" {
+		t.Errorf("Types() = %v, want [noop]", got)
+	}
+	q.Shutdown()
+	if _, err := q.Enqueue("noop", nil, "admin"); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("after shutdown: err = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestRunHandlerRecoversPanic(t *testing.T) {
+	_, err := runHandler(context.Background(), func(context.Context, *Task) (interface{}, error) { panic("boom") }, &Task{})
+	if err == nil || err.Error() != "panic: boom" {
+		t.Errorf("err = %v, want panic error", err)
+	}
+}
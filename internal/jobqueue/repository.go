@@ -0,0 +1,218 @@
+package jobqueue
+
+import (
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles persistence of background jobs.
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository creates a new job queue Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create stores a new job.
+func (r *Repository) Create(job *models.BackgroundJob) error {
+	return r.DB.Create(job).Error
+}
+
+// GetByID returns a job by ID.
+func (r *Repository) GetByID(id uuid.UUID) (*models.BackgroundJob, error) {
+	var job models.BackgroundJob
+	if err := r.DB.First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List returns the most recent jobs, newest first, optionally filtered by status and type.
+func (r *Repository) List(status, jobType string, limit int) ([]models.BackgroundJob, error) {
+	var jobs []models.BackgroundJob
+	query := r.DB.Order("created_at DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	err := query.Find(&jobs).Error
+	return jobs, err
+}
+
+// jobListSpec is the sorting and filtering of the Admin API job list.
+var jobListSpec = &pagination.ListSpec{
+	DefaultPageSize: 50,
+	Sorts: map[string]string{
+		"created_at":  "created_at",
+		"finished_at": "finished_at",
+		"status":      "status",
+		"type":        "type",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"status":     {Column: "status", Kind: pagination.FilterExact},
+		"type":       {Column: "type", Kind: pagination.FilterExact},
+		"created_by": {Column: "created_by", Kind: pagination.FilterExact},
+	},
+}
+
+// ListPage returns a page of jobs sorted and filtered by q.
+func (r *Repository) ListPage(q pagination.ListQuery) ([]models.BackgroundJob, int64, error) {
+	var jobs []models.BackgroundJob
+	var total int64
+
+	query := q.ApplyFilters(r.DB.Model(&models.BackgroundJob{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.ApplyPage(query).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// Count returns the number of jobs of a type in a status.
+func (r *Repository) Count(status, jobType string) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.BackgroundJob{}).Where("status = ? AND type = ?", status, jobType).Count(&count).Error
+	return count, err
+}
+
+// ClaimNext atomically moves the oldest runnable queued job of one of the given
+// types to running and returns it, or nil when there is none. SKIP LOCKED lets
+// several instances poll the same table without claiming the same job.
+func (r *Repository) ClaimNext(types []string, instance string) (*models.BackgroundJob, error) {
+	var jobs []models.BackgroundJob
+	err := r.DB.Raw(`
+		UPDATE background_jobs
+		SET status = ?, locked_by = ?, attempts = attempts + 1, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM background_jobs
+			WHERE status = ? AND run_after <= NOW() AND type IN ?
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, StatusRunning, instance, StatusQueued, types).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// UpdateProgress saves the progress of a running job.
+func (r *Repository) UpdateProgress(id uuid.UUID, percent int, message string) error {
+	return r.DB.Model(&models.BackgroundJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"progress":         percent,
+		"progress_message": message,
+	}).Error
+}
+
+// UpdateResult saves the checkpoint of a running job.
+func (r *Repository) UpdateResult(id uuid.UUID, result string) error {
+	return r.DB.Model(&models.BackgroundJob{}).Where("id = ?", id).Update("result", result).Error
+}
+
+// IsCancelRequested reports whether cancellation was requested for a job.
+func (r *Repository) IsCancelRequested(id uuid.UUID) (bool, error) {
+	var job models.BackgroundJob
+	err := r.DB.Select("cancel_requested").First(&job, "id = ?", id).Error
+	return job.CancelRequested, err
+}
+
+// Finish records the final state of a job.
+func (r *Repository) Finish(id uuid.UUID, status, result, errMsg, message string) error {
+	updates := map[string]interface{}{
+		"status":      status,
+		"result":      result,
+		"error":       errMsg,
+		"finished_at": time.Now().UTC(),
+	}
+	if status == StatusSucceeded {
+		updates["progress"] = 100
+	}
+	if message != "" {
+		updates["progress_message"] = message
+	}
+	return r.DB.Model(&models.BackgroundJob{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Retry puts a failed job back in the queue to run again after runAfter.
+func (r *Repository) Retry(id uuid.UUID, errMsg string, runAfter time.Time) error {
+	return r.DB.Model(&models.BackgroundJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    StatusQueued,
+		"error":     errMsg,
+		"locked_by": "",
+		"run_after": runAfter,
+	}).Error
+}
+
+// RequestCancel cancels a queued job immediately and flags a running job so
+// its worker stops it. It returns false when the job has already finished.
+func (r *Repository) RequestCancel(id uuid.UUID) (bool, error) {
+	now := time.Now().UTC()
+	result := r.DB.Model(&models.BackgroundJob{}).
+		Where("id = ? AND status = ?", id, StatusQueued).
+		Updates(map[string]interface{}{"status": StatusCancelled, "cancel_requested": true, "finished_at": now})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.RowsAffected > 0, result.Error
+	}
+	result = r.DB.Model(&models.BackgroundJob{}).
+		Where("id = ? AND status = ?", id, StatusRunning).
+		Update("cancel_requested", true)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Requeue puts a failed or cancelled job back in the queue with a fresh set of
+// attempts. It returns false when the job is not in one of those states.
+func (r *Repository) Requeue(id uuid.UUID) (bool, error) {
+	result := r.DB.Model(&models.BackgroundJob{}).
+		Where("id = ? AND status IN ?", id, []string{StatusFailed, StatusCancelled}).
+		Updates(map[string]interface{}{
+			"status":           StatusQueued,
+			"attempts":         0,
+			"progress":         0,
+			"progress_message": "",
+			"error":            "",
+			"cancel_requested": false,
+			"locked_by":        "",
+			"run_after":        time.Now().UTC(),
+			"started_at":       nil,
+			"finished_at":      nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RequeueInterrupted returns the jobs an instance left running (e.g. because
+// the server was stopped mid-job) to the queue.
+func (r *Repository) RequeueInterrupted(instance string) (int64, error) {
+	result := r.DB.Model(&models.BackgroundJob{}).
+		Where("locked_by = ? AND status = ?", instance, StatusRunning).
+		Updates(map[string]interface{}{
+			"status":    StatusQueued,
+			"locked_by": "",
+			"error":     "interrupted by server shutdown",
+			"run_after": time.Now().UTC(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteFinishedBefore removes finished jobs created before the cutoff and
+// returns how many were deleted.
+func (r *Repository) DeleteFinishedBefore(cutoff time.Time) (int64, error) {
+	result := r.DB.Where("status IN ? AND created_at < ?",
+		[]string{StatusSucceeded, StatusFailed, StatusCancelled}, cutoff).
+		Delete(&models.BackgroundJob{})
+	return result.RowsAffected, result.Error
+}
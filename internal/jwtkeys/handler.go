@@ -0,0 +1,57 @@
+package jwtkeys
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/spf13/viper"
+)
+
+// jwksMaxAge is how long clients may cache the JWKS. Rotated-in keys are
+// published for PublishAhead before they sign, which is longer.
+const jwksMaxAge = "public, max-age=300"
+
+// Metadata is the discovery document served at
+// /.well-known/openid-configuration when the OIDC provider is disabled: just
+// enough for JWT libraries to find the keys that verify access tokens.
+type Metadata struct {
+	Issuer                         string   `json:"issuer"`
+	JwksURI                        string   `json:"jwks_uri"`
+	TokenSigningAlgValuesSupported []string `json:"token_signing_alg_values_supported"`
+}
+
+// JWKS handles GET /.well-known/jwks.json
+// @Summary Token verification keys
+// @Description Public keys access and refresh tokens are signed with (RS256 or ES256, selected by JWT_SIGNING_ALGORITHM), including pending and retiring keys. Tokens name their key in the "kid" header. Empty while tokens are signed with HS256.
+// @Tags Discovery
+// @Produce json
+// @Success 200 {object} jwt.JWKS
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, jwt.PublicKeys())
+}
+
+// Discovery handles GET /.well-known/openid-configuration when the OIDC
+// provider is disabled.
+// @Summary Token verification metadata
+// @Description Issuer and JWKS location for verifying access tokens offline. With OIDC_ENABLED=true this redirects to the discovery document of OIDC_DEFAULT_APP_ID instead.
+// @Tags Discovery
+// @Produce json
+// @Success 200 {object} Metadata
+// @Router /.well-known/openid-configuration [get]
+func Discovery(c *gin.Context) {
+	base := strings.TrimRight(viper.GetString("PUBLIC_URL"), "/")
+	issuer := jwt.Issuer()
+	if issuer == "" {
+		issuer = base
+	}
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, Metadata{
+		Issuer:                         issuer,
+		JwksURI:                        base + "/.well-known/jwks.json",
+		TokenSigningAlgValuesSupported: []string{jwt.SigningAlgorithm()},
+	})
+}
@@ -0,0 +1,515 @@
+// Package jwtkeys stores the RS256/ES256 keys access and refresh tokens are
+// signed with (JWT_SIGNING_ALGORITHM), rotates them and keeps pkg/jwt
+// supplied with the current set.
+//
+// Keys live in the jwt_signing_keys table, their private halves encrypted
+// with secretbox, so every instance signs with the same key. A rotation adds
+// a key that is published in the JWKS for PublishAhead before it signs
+// tokens, so services caching the JWKS learn it in time; the keys it
+// replaces stay published until the last token they signed has expired.
+// Instances reload the keys every few minutes, and immediately (throttled)
+// when a token names a key they do not know yet.
+package jwtkeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/secretbox"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	// PublishAhead is how long a rotated-in key is published before it signs.
+	PublishAhead = time.Hour
+
+	refreshInterval = 5 * time.Minute
+	// reloadThrottle limits reloads triggered by tokens with unknown key IDs.
+	reloadThrottle = 10 * time.Second
+)
+
+// Key states reported by List
+const (
+	StatePending  = "pending"  // Published, signs from ActivatesAt
+	StateActive   = "active"   // Signs new tokens
+	StateRetiring = "retiring" // Replaced; verifies tokens until ExpiresAt
+)
+
+var (
+	// ErrSymmetric is returned by Rotate while tokens are signed with HS256.
+	ErrSymmetric = errors.New("tokens are signed with JWT_SECRET (JWT_SIGNING_ALGORITHM=HS256); there is no key to rotate")
+	// ErrActiveKey is returned by Delete for the key that signs new tokens.
+	ErrActiveKey = errors.New("the active signing key cannot be deleted; rotate first")
+	// ErrKeyNotFound is returned by Delete for an unknown key.
+	ErrKeyNotFound = errors.New("signing key not found")
+)
+
+// Key is a stored signing key with its state.
+type Key struct {
+	models.JWTSigningKey
+	State string
+}
+
+// Service manages the signing keys.
+type Service struct {
+	db *gorm.DB
+
+	mu         sync.Mutex // Serializes loads
+	loadedAt   time.Time
+	activeKIDs map[string]bool // Keys signing new tokens, shared or of an application
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates the service; Start loads the keys.
+func NewService(db *gorm.DB) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{db: db, ctx: ctx, cancel: cancel}
+}
+
+// Start loads the keys, creating the first one if the configured algorithm
+// needs one, and keeps them fresh until Shutdown.
+func (s *Service) Start() error {
+	if err := s.Load(s.ctx); err != nil {
+		return err
+	}
+	jwt.SetUnknownKeyHandler(s.reloadThrottled)
+	s.wg.Add(1)
+	go s.worker()
+	return nil
+}
+
+// Shutdown stops the refresh worker.
+func (s *Service) Shutdown() {
+	if s == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Service) worker() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(s.ctx); err != nil {
+				log.Printf("Warning: failed to reload JWT signing keys: %v", err)
+			}
+		}
+	}
+}
+
+// reloadThrottled reloads the keys unless they were loaded moments ago.
+func (s *Service) reloadThrottled() {
+	s.mu.Lock()
+	recent := time.Since(s.loadedAt) < reloadThrottle
+	s.mu.Unlock()
+	if recent {
+		return
+	}
+	if err := s.Load(s.ctx); err != nil {
+		log.Printf("Warning: failed to reload JWT signing keys: %v", err)
+	}
+}
+
+// Load reads the published keys and installs them in pkg/jwt. When the
+// configured algorithm is RS256 or ES256 and there is no shared key of it,
+// one is created that signs right away. So is a key for every application
+// that has keys of its own (with JWT_PER_APP_KEYS: every application) but
+// none of the current algorithm; it is published for PublishAhead first when
+// the application's tokens are signed with a shared key meanwhile. Keys of
+// another algorithm (after a change of JWT_SIGNING_ALGORITHM) are retired.
+func (s *Service) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	rows, err := s.published(ctx, now)
+	if err != nil {
+		return err
+	}
+	scopes := byScope(rows)
+	if jwt.SigningAlgorithm() != jwt.AlgHS256 {
+		if _, ok := scopes[""]; !ok {
+			scopes[""] = nil
+		}
+	}
+	if perAppKeys() {
+		var appIDs []uuid.UUID
+		if err := s.db.WithContext(ctx).Model(&models.Application{}).Pluck("id", &appIDs).Error; err != nil {
+			return fmt.Errorf("load applications: %w", err)
+		}
+		for _, id := range appIDs {
+			if _, ok := scopes[id.String()]; !ok {
+				scopes[id.String()] = nil
+			}
+		}
+	}
+
+	var (
+		signing      *jwt.SigningKey
+		appSigning   []*jwt.SigningKey
+		verification []*jwt.SigningKey
+		retire       []string
+	)
+	active := make(map[string]bool)
+	expires := now.Add(maxTokenLifetime())
+	sharedSigns := signingRow(scopes[""], jwt.SigningAlgorithm(), now) != nil
+	for scope, keys := range scopes {
+		appID := scopeAppID(scope)
+		alg := Algorithm(appID)
+		if alg != jwt.AlgHS256 && !hasKey(keys, alg) {
+			activates := now
+			if appID != nil && sharedSigns {
+				activates = now.Add(PublishAhead)
+			}
+			row, err := s.create(ctx, appID, alg, activates)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, *row)
+			log.Printf("Created %s JWT signing key %s%s", alg, row.ID, scopeSuffix(appID))
+		}
+		for i := range keys {
+			if keys[i].Algorithm != alg && keys[i].ExpiresAt == nil {
+				keys[i].ExpiresAt = &expires
+				retire = append(retire, keys[i].ID.String())
+			}
+		}
+
+		current := signingRow(keys, alg, now)
+		var scopeSigning *jwt.SigningKey
+		for i := range keys {
+			key, err := decode(&keys[i])
+			if err != nil {
+				log.Printf("Warning: skipping JWT signing key %s: %v", keys[i].ID, err)
+				continue
+			}
+			verification = append(verification, key)
+			if current != nil && keys[i].ID == current.ID {
+				scopeSigning = key
+			}
+		}
+		if current != nil && scopeSigning == nil {
+			return fmt.Errorf("the active %s signing key%s cannot be decrypted", alg, scopeSuffix(appID))
+		}
+		if scopeSigning == nil {
+			continue
+		}
+		active[scopeSigning.ID] = true
+		if appID == nil {
+			signing = scopeSigning
+		} else {
+			appSigning = append(appSigning, scopeSigning)
+		}
+	}
+	if len(retire) > 0 {
+		if err := s.db.WithContext(ctx).Model(&models.JWTSigningKey{}).
+			Where("id IN ?", retire).Update("expires_at", expires).Error; err != nil {
+			return fmt.Errorf("retire signing keys: %w", err)
+		}
+	}
+
+	jwt.SetSigningKeys(signing, verification, appSigning...)
+	s.loadedAt = now
+	s.activeKIDs = active
+	return nil
+}
+
+// List returns the published keys of an application (nil = the shared
+// keys), oldest first.
+func (s *Service) List(ctx context.Context, appID *uuid.UUID) ([]Key, error) {
+	now := time.Now()
+	rows, err := s.published(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	rows = byScope(rows)[scopeOf(appID)]
+	active := signingRow(rows, Algorithm(appID), now)
+	keys := make([]Key, len(rows))
+	for i, row := range rows {
+		keys[i] = Key{JWTSigningKey: row, State: StateRetiring}
+		switch {
+		case active != nil && row.ID == active.ID:
+			keys[i].State = StateActive
+		case row.ActivatesAt.After(now):
+			keys[i].State = StatePending
+		}
+	}
+	return keys, nil
+}
+
+// Rotate adds a key to an application (nil = the shared keys) that signs new
+// tokens after PublishAhead, or right away when immediate is set (e.g. after
+// a key leaked; delete the old key afterwards). The keys it replaces stay
+// published until the tokens they signed have expired. The first rotation of
+// an application gives it keys of its own: once the key activates, its
+// tokens are no longer signed with the shared key.
+func (s *Service) Rotate(ctx context.Context, appID *uuid.UUID, immediate bool) (*models.JWTSigningKey, error) {
+	alg := Algorithm(appID)
+	if alg == jwt.AlgHS256 {
+		return nil, ErrSymmetric
+	}
+	activates := time.Now().Add(PublishAhead)
+	if immediate {
+		activates = time.Now()
+	}
+	var row *models.JWTSigningKey
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expires := activates.Add(maxTokenLifetime())
+		if err := inScope(tx.Model(&models.JWTSigningKey{}), appID).Where("expires_at IS NULL").
+			Update("expires_at", expires).Error; err != nil {
+			return fmt.Errorf("retire signing keys: %w", err)
+		}
+		var err error
+		row, err = (&Service{db: tx}).create(ctx, appID, alg, activates)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Load(ctx); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Delete removes a key of an application (nil = a shared key) that no longer
+// signs tokens, invalidating every token it signed.
+func (s *Service) Delete(ctx context.Context, appID *uuid.UUID, id string) error {
+	if err := s.Load(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	active := s.activeKIDs[id]
+	s.mu.Unlock()
+	if active {
+		return ErrActiveKey
+	}
+	res := inScope(s.db.WithContext(ctx), appID).Where("id = ?", id).Delete(&models.JWTSigningKey{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrKeyNotFound
+	}
+	return s.Load(ctx)
+}
+
+// RunRotation is the scheduled job: it rotates the shared key and the keys of
+// each application once the active one is older than JWT_KEY_ROTATION_DAYS
+// (0 disables rotation) and deletes keys whose tokens have all expired.
+func (s *Service) RunRotation(ctx context.Context) error {
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Where("expires_at < ?", now).
+		Delete(&models.JWTSigningKey{}).Error; err != nil {
+		return fmt.Errorf("delete expired signing keys: %w", err)
+	}
+	days := viper.GetInt("JWT_KEY_ROTATION_DAYS")
+	if days <= 0 {
+		return s.Load(ctx)
+	}
+	rows, err := s.published(ctx, now)
+	if err != nil {
+		return err
+	}
+	rotated := false
+	for scope, keys := range byScope(rows) {
+		appID := scopeAppID(scope)
+		alg := Algorithm(appID)
+		if alg == jwt.AlgHS256 || !dueForRotation(keys, alg, now, time.Duration(days)*24*time.Hour) {
+			continue
+		}
+		row, err := s.Rotate(ctx, appID, false)
+		if err != nil {
+			return err
+		}
+		rotated = true
+		log.Printf("Rotated JWT signing key%s: %s signs from %s", scopeSuffix(appID), row.ID, row.ActivatesAt.Format(time.RFC3339))
+	}
+	if rotated {
+		return nil
+	}
+	return s.Load(ctx)
+}
+
+// published returns the keys that have not expired, oldest first.
+func (s *Service) published(ctx context.Context, now time.Time) ([]models.JWTSigningKey, error) {
+	var rows []models.JWTSigningKey
+	err := s.db.WithContext(ctx).Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("activates_at ASC").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("load signing keys: %w", err)
+	}
+	return rows, nil
+}
+
+// create generates and stores a key of alg for an application (nil = a
+// shared key) that signs from activates.
+func (s *Service) create(ctx context.Context, appID *uuid.UUID, alg string, activates time.Time) (*models.JWTSigningKey, error) {
+	key, err := jwt.GenerateSigningKey(alg)
+	if err != nil {
+		return nil, err
+	}
+	pem, err := jwt.MarshalPrivateKey(key.Private)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := secretbox.Encrypt(pem)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt signing key: %w", err)
+	}
+	row := &models.JWTSigningKey{AppID: appID, Algorithm: alg, PrivateKey: encrypted, ActivatesAt: activates}
+	if err := s.db.WithContext(ctx).Create(row).Error; err != nil {
+		return nil, fmt.Errorf("store signing key: %w", err)
+	}
+	return row, nil
+}
+
+// decode decrypts and parses a stored key.
+func decode(row *models.JWTSigningKey) (*jwt.SigningKey, error) {
+	pem, err := secretbox.Decrypt(row.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	private, err := jwt.ParsePrivateKey(pem)
+	if err != nil {
+		return nil, err
+	}
+	return &jwt.SigningKey{
+		ID:          row.ID.String(),
+		AppID:       scopeOf(row.AppID),
+		Algorithm:   row.Algorithm,
+		Private:     private,
+		ActivatesAt: row.ActivatesAt,
+	}, nil
+}
+
+// Algorithm returns the algorithm of the keys of an application (nil = the
+// shared keys): JWT_SIGNING_ALGORITHM, except that applications with keys of
+// their own use RS256 while the other tokens are signed with JWT_SECRET.
+func Algorithm(appID *uuid.UUID) string {
+	alg := jwt.SigningAlgorithm()
+	if appID != nil && alg == jwt.AlgHS256 {
+		return jwt.AlgRS256
+	}
+	return alg
+}
+
+// perAppKeys reports whether every application gets keys of its own
+// (JWT_PER_APP_KEYS); otherwise only those rotated under /admin/apps/:id/keys.
+func perAppKeys() bool {
+	return viper.GetBool("JWT_PER_APP_KEYS")
+}
+
+// byScope groups keys by application ID, "" for the shared keys, keeping
+// their order.
+func byScope(rows []models.JWTSigningKey) map[string][]models.JWTSigningKey {
+	scopes := make(map[string][]models.JWTSigningKey)
+	for _, row := range rows {
+		scope := scopeOf(row.AppID)
+		scopes[scope] = append(scopes[scope], row)
+	}
+	return scopes
+}
+
+// scopeOf returns the scope of the keys of an application, "" for nil.
+func scopeOf(appID *uuid.UUID) string {
+	if appID == nil {
+		return ""
+	}
+	return appID.String()
+}
+
+// scopeAppID is the inverse of scopeOf.
+func scopeAppID(scope string) *uuid.UUID {
+	if scope == "" {
+		return nil
+	}
+	id := uuid.MustParse(scope)
+	return &id
+}
+
+// scopeSuffix describes the scope in log messages.
+func scopeSuffix(appID *uuid.UUID) string {
+	if appID == nil {
+		return ""
+	}
+	return " of application " + appID.String()
+}
+
+// inScope restricts q to the keys of an application, nil = the shared keys.
+func inScope(q *gorm.DB, appID *uuid.UUID) *gorm.DB {
+	if appID == nil {
+		return q.Where("app_id IS NULL")
+	}
+	return q.Where("app_id = ?", *appID)
+}
+
+// hasKey reports whether rows hold a key of alg, activated or not.
+func hasKey(rows []models.JWTSigningKey, alg string) bool {
+	for i := range rows {
+		if rows[i].Algorithm == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// signingRow returns the key that signs new tokens: the newest key of alg
+// that has activated. rows are sorted by activation time.
+func signingRow(rows []models.JWTSigningKey, alg string, now time.Time) *models.JWTSigningKey {
+	var active *models.JWTSigningKey
+	for i := range rows {
+		if rows[i].Algorithm == alg && !rows[i].ActivatesAt.After(now) {
+			active = &rows[i]
+		}
+	}
+	return active
+}
+
+// dueForRotation reports whether the newest key of alg activated more than
+// every ago. A key still waiting to activate means a rotation is under way.
+func dueForRotation(rows []models.JWTSigningKey, alg string, now time.Time, every time.Duration) bool {
+	for i := range rows {
+		if rows[i].Algorithm == alg && rows[i].ActivatesAt.After(now) {
+			return false
+		}
+	}
+	active := signingRow(rows, alg, now)
+	return active != nil && now.Sub(active.ActivatesAt) >= every
+}
+
+// maxTokenLifetime is how long a replaced key must stay published: the
+// longest lifetime of the tokens it signed. Service account tokens
+// (SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS, default 365) usually outlive session
+// tokens by far.
+func maxTokenLifetime() time.Duration {
+	lifetime := jwt.DefaultRefreshTokenTTL()
+	if access := jwt.DefaultAccessTokenTTL(); access > lifetime {
+		lifetime = access
+	}
+	serviceAccount := 365 * 24 * time.Hour
+	if d := viper.GetInt("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS"); d > 0 {
+		serviceAccount = time.Duration(d) * 24 * time.Hour
+	}
+	if serviceAccount > lifetime {
+		lifetime = serviceAccount
+	}
+	return lifetime
+}
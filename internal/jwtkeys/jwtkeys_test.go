@@ -0,0 +1,119 @@
+package jwtkeys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func keyRow(alg string, activates time.Time) models.JWTSigningKey {
+	return models.JWTSigningKey{ID: uuid.New(), Algorithm: alg, ActivatesAt: activates}
+}
+
+func TestSigningRow(t *testing.T) {
+	now := time.Now()
+	old := keyRow(jwt.AlgRS256, now.Add(-48*time.Hour))
+	current := keyRow(jwt.AlgRS256, now.Add(-time.Hour))
+	pending := keyRow(jwt.AlgRS256, now.Add(time.Hour))
+	ec := keyRow(jwt.AlgES256, now.Add(-time.Minute))
+	rows := []models.JWTSigningKey{old, current, ec, pending}
+
+	if got := signingRow(rows, jwt.AlgRS256, now); got == nil || got.ID != current.ID {
+		t.Errorf("signingRow(RS256) = %v, want the newest activated key", got)
+	}
+	if got := signingRow(rows, jwt.AlgES256, now); got == nil || got.ID != ec.ID {
+		t.Errorf("signingRow(ES256) = %v, want the ES256 key", got)
+	}
+	if got := signingRow(rows[3:], jwt.AlgRS256, now); got != nil {
+		t.Errorf("signingRow(pending only) = %v, want nil", got)
+	}
+}
+
+func TestDueForRotation(t *testing.T) {
+	now := time.Now()
+	every := 90 * 24 * time.Hour
+	stale := keyRow(jwt.AlgES256, now.Add(-every-time.Hour))
+	fresh := keyRow(jwt.AlgES256, now.Add(-time.Hour))
+	pending := keyRow(jwt.AlgES256, now.Add(time.Minute))
+
+	tests := []struct {
+		name string
+		rows []models.JWTSigningKey
+		want bool
+	}{
+		{"no keys", nil, false},
+		{"fresh key", []models.JWTSigningKey{fresh}, false},
+		{"stale key", []models.JWTSigningKey{stale}, true},
+		{"rotation under way", []models.JWTSigningKey{stale, pending}, false},
+		{"other algorithm", []models.JWTSigningKey{keyRow(jwt.AlgRS256, stale.ActivatesAt)}, false},
+	}
+	for _, tc := range tests {
+		if got := dueForRotation(tc.rows, jwt.AlgES256, now, every); got != tc.want {
+			t.Errorf("%s: dueForRotation = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestByScope(t *testing.T) {
+	now := time.Now()
+	appID := uuid.New()
+	shared := keyRow(jwt.AlgRS256, now.Add(-time.Hour))
+	own := keyRow(jwt.AlgRS256, now.Add(-2*time.Hour))
+	own.AppID = &appID
+	next := keyRow(jwt.AlgRS256, now.Add(time.Hour))
+	next.AppID = &appID
+
+	scopes := byScope([]models.JWTSigningKey{own, shared, next})
+	if len(scopes) != 2 || len(scopes[""]) != 1 || len(scopes[appID.String()]) != 2 {
+		t.Fatalf("byScope = %v", scopes)
+	}
+	if got := signingRow(scopes[appID.String()], jwt.AlgRS256, now); got == nil || got.ID != own.ID {
+		t.Errorf("signing key of the application = %v, want its activated key", got)
+	}
+	if id := scopeAppID(appID.String()); id == nil || *id != appID {
+		t.Errorf("scopeAppID = %v, want %s", id, appID)
+	}
+	if scopeAppID("") != nil {
+		t.Error("scopeAppID(\"\") is not the shared scope")
+	}
+	if !hasKey(scopes[appID.String()][1:], jwt.AlgRS256) || hasKey(scopes[""], jwt.AlgES256) {
+		t.Error("hasKey ignores pending keys or matches another algorithm")
+	}
+}
+
+func TestAlgorithm(t *testing.T) {
+	appID := uuid.New()
+	t.Cleanup(func() { viper.Set(jwt.SettingSigningAlgorithm, nil) })
+
+	viper.Set(jwt.SettingSigningAlgorithm, "HS256")
+	if got := Algorithm(nil); got != jwt.AlgHS256 {
+		t.Errorf("shared algorithm = %s, want HS256", got)
+	}
+	if got := Algorithm(&appID); got != jwt.AlgRS256 {
+		t.Errorf("application algorithm under HS256 = %s, want RS256", got)
+	}
+	viper.Set(jwt.SettingSigningAlgorithm, "ES256")
+	if got := Algorithm(&appID); got != jwt.AlgES256 {
+		t.Errorf("application algorithm = %s, want ES256", got)
+	}
+}
+
+func TestMaxTokenLifetime(t *testing.T) {
+	viper.Set("REFRESH_TOKEN_EXPIRATION_HOURS", 720)
+	viper.Set("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS", 7)
+	t.Cleanup(func() {
+		viper.Set("REFRESH_TOKEN_EXPIRATION_HOURS", nil)
+		viper.Set("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS", nil)
+	})
+	if got := maxTokenLifetime(); got != 720*time.Hour {
+		t.Errorf("maxTokenLifetime = %v, want the refresh token lifetime", got)
+	}
+	viper.Set("SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS", 365)
+	if got := maxTokenLifetime(); got != 365*24*time.Hour {
+		t.Errorf("maxTokenLifetime = %v, want the service account token lifetime", got)
+	}
+}
@@ -0,0 +1,79 @@
+package livefeed
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/google/uuid"
+)
+
+// FilterParams lists the query parameters understood by ParseFilter.
+var FilterParams = []string{"event_type", "category", "severity", "app_id", "anomaly"}
+
+// Filter selects the events a dashboard subscribes to. Zero values match
+// everything.
+type Filter struct {
+	EventTypes    map[string]bool // Any of these event types
+	Categories    map[string]bool // Any of these event catalog categories
+	Severity      string          // Exact severity (INFORMATIONAL, IMPORTANT, CRITICAL)
+	AppID         uuid.UUID
+	AnomaliesOnly bool
+}
+
+// ParseFilter builds a Filter from query parameters: event_type and category
+// take comma-separated lists, severity and app_id a single value, and
+// anomaly=true keeps only anomalies.
+func ParseFilter(values url.Values) (Filter, error) {
+	f := Filter{
+		EventTypes:    splitSet(values.Get("event_type"), strings.ToUpper),
+		Categories:    splitSet(values.Get("category"), strings.ToLower),
+		Severity:      strings.ToUpper(strings.TrimSpace(values.Get("severity"))),
+		AnomaliesOnly: values.Get("anomaly") == "true",
+	}
+	if raw := strings.TrimSpace(values.Get("app_id")); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid app_id: %w", err)
+		}
+		f.AppID = id
+	}
+	return f, nil
+}
+
+// Matches reports whether e passes the filter.
+func (f Filter) Matches(e Event) bool {
+	if len(f.EventTypes) > 0 && !f.EventTypes[e.EventType] {
+		return false
+	}
+	if len(f.Categories) > 0 {
+		def, ok := config.GetEventDefinition(e.EventType)
+		if !ok || !f.Categories[string(def.Category)] {
+			return false
+		}
+	}
+	if f.Severity != "" && f.Severity != e.Severity {
+		return false
+	}
+	if f.AppID != uuid.Nil && f.AppID != e.AppID {
+		return false
+	}
+	if f.AnomaliesOnly && !e.IsAnomaly {
+		return false
+	}
+	return true
+}
+
+func splitSet(raw string, normalize func(string) string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			set[normalize(item)] = true
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
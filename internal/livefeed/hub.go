@@ -0,0 +1,179 @@
+// Package livefeed streams activity log events to admin dashboards as they are
+// written. Events are relayed through Redis pub/sub so every instance behind a
+// load balancer sees the events written by the others.
+package livefeed
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Channel is the Redis pub/sub channel carrying live activity events.
+const Channel = "admin:live_activity"
+
+// subscriberBuffer is the number of events queued per subscriber. Events for
+// a subscriber that falls further behind are dropped rather than blocking the
+// publisher.
+const subscriberBuffer = 64
+
+// Event is an activity log entry as streamed to the dashboard.
+type Event struct {
+	ID        uuid.UUID `json:"id"`
+	AppID     uuid.UUID `json:"app_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	EventType string    `json:"event_type"`
+	Severity  string    `json:"severity"`
+	IPAddress string    `json:"ip_address"`
+	IsAnomaly bool      `json:"is_anomaly"`
+	Details   string    `json:"details"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventFromLog converts a stored activity log into a live event.
+func EventFromLog(l models.ActivityLog) Event {
+	details := string(l.Details)
+	if details == "{}" {
+		details = ""
+	}
+	return Event{
+		ID:        l.ID,
+		AppID:     l.AppID,
+		UserID:    l.UserID,
+		EventType: l.EventType,
+		Severity:  l.Severity,
+		IPAddress: l.IPAddress,
+		IsAnomaly: l.IsAnomaly,
+		Details:   details,
+		Timestamp: l.Timestamp,
+	}
+}
+
+type subscriber struct {
+	filter Filter
+	events chan Event
+}
+
+// Hub fans live events out to the subscribed dashboards. All methods are safe
+// to call on a nil *Hub (live updates disabled).
+type Hub struct {
+	rdb    *redis.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu   sync.RWMutex
+	subs map[*subscriber]struct{}
+}
+
+// NewHub creates a Hub relaying events through rdb. With a nil client events
+// are only delivered to subscribers of this instance.
+func NewHub(rdb *redis.Client) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Hub{rdb: rdb, ctx: ctx, cancel: cancel, subs: make(map[*subscriber]struct{})}
+}
+
+// Start relays events published by any instance to local subscribers until
+// Shutdown. It does nothing without a Redis client.
+func (h *Hub) Start() {
+	if h == nil || h.rdb == nil {
+		return
+	}
+	pubsub := h.rdb.Subscribe(h.ctx, Channel)
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-h.ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var e Event
+				if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+					log.Printf("Warning: invalid live activity event: %v\n", err)
+					continue
+				}
+				h.deliver(e)
+			}
+		}
+	}()
+}
+
+// Shutdown stops relaying events.
+func (h *Hub) Shutdown() {
+	if h != nil {
+		h.cancel()
+	}
+}
+
+// Publish sends an event to every subscribed dashboard, on all instances.
+func (h *Hub) Publish(e Event) {
+	if h == nil {
+		return
+	}
+	if h.rdb == nil {
+		h.deliver(e)
+		return
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := h.rdb.Publish(h.ctx, Channel, payload).Err(); err != nil {
+		log.Printf("Warning: failed to publish live activity event: %v\n", err)
+		h.deliver(e) // Still update the dashboards of this instance
+	}
+}
+
+// Subscribe registers a dashboard for the events matching filter. The returned
+// function unsubscribes and must be called when the dashboard disconnects.
+func (h *Hub) Subscribe(filter Filter) (<-chan Event, func()) {
+	if h == nil {
+		return nil, func() {}
+	}
+	sub := &subscriber{filter: filter, events: make(chan Event, subscriberBuffer)}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, sub)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Subscribers returns the number of connected dashboards on this instance.
+func (h *Hub) Subscribers() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+func (h *Hub) deliver(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if !sub.filter.Matches(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default: // Subscriber is too slow; drop rather than block
+		}
+	}
+}
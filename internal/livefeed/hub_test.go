@@ -0,0 +1,74 @@
+package livefeed
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFilterMatches(t *testing.T) {
+	appID := uuid.New()
+	f, err := ParseFilter(url.Values{
+		"category": {"authentication"},
+		"severity": {"important"},
+		"app_id":   {appID.String()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+		want  bool
+	}{
+		{"match", Event{EventType: "LOGIN_FAILED", Severity: "IMPORTANT", AppID: appID}, true},
+		{"other category", Event{EventType: "PASSWORD_CHANGE", Severity: "IMPORTANT", AppID: appID}, false},
+		{"other severity", Event{EventType: "LOGIN", Severity: "CRITICAL", AppID: appID}, false},
+		{"other app", Event{EventType: "LOGIN_FAILED", Severity: "IMPORTANT", AppID: uuid.New()}, false},
+		{"unknown type", Event{EventType: "CUSTOM", Severity: "IMPORTANT", AppID: appID}, false},
+	}
+	for _, tc := range tests {
+		if got := f.Matches(tc.event); got != tc.want {
+			t.Errorf("%s: Matches() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	types, _ := ParseFilter(url.Values{"event_type": {"login, register"}, "anomaly": {"true"}})
+	if !types.Matches(Event{EventType: "REGISTER", IsAnomaly: true}) || types.Matches(Event{EventType: "REGISTER"}) {
+		t.Error("event_type list or anomaly filter not applied")
+	}
+	if _, err := ParseFilter(url.Values{"app_id": {"nope"}}); err == nil {
+		t.Error("invalid app_id accepted")
+	}
+}
+
+func TestHubDeliversToMatchingSubscribers(t *testing.T) {
+	hub := NewHub(nil)
+	logins, unsubscribe := hub.Subscribe(Filter{EventTypes: map[string]bool{"LOGIN": true}})
+	all, unsubscribeAll := hub.Subscribe(Filter{})
+	defer unsubscribeAll()
+
+	hub.Publish(Event{EventType: "REGISTER"})
+	hub.Publish(Event{EventType: "LOGIN"})
+
+	select {
+	case e := <-logins:
+		if e.EventType != "LOGIN" {
+			t.Errorf("filtered subscriber got %s", e.EventType)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+	}
+	if len(all) != 2 {
+		t.Errorf("unfiltered subscriber got %d events, want 2", len(all))
+	}
+
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+	if n := hub.Subscribers(); n != 1 {
+		t.Errorf("Subscribers() = %d after unsubscribe, want 1", n)
+	}
+}
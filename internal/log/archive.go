@@ -0,0 +1,55 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/storage"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// archivePrefix is the storage key prefix of archived activity logs.
+const archivePrefix = "activity-logs/"
+
+// archiveKey returns the storage key of the n-th batch archived by the cleanup
+// run started at runAt: activity-logs/YYYY/MM/DD/<run>-<n>.ndjson.gz.
+func archiveKey(runAt time.Time, n int) string {
+	runAt = runAt.UTC()
+	return fmt.Sprintf("%s%s/%s-%04d.ndjson.gz", archivePrefix, runAt.Format("2006/01/02"), runAt.Format("20060102T150405Z"), n)
+}
+
+// encodeArchive encodes logs as gzip-compressed newline-delimited JSON.
+func encodeArchive(logs []models.ActivityLog) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for i := range logs {
+		if err := enc.Encode(&logs[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// archiveLogs writes logs to the file storage backend under key.
+func archiveLogs(ctx context.Context, key string, logs []models.ActivityLog) error {
+	data, err := encodeArchive(logs)
+	if err != nil {
+		return fmt.Errorf("failed to encode log archive: %w", err)
+	}
+	backend, err := storage.Current()
+	if err != nil {
+		return fmt.Errorf("file storage is unavailable: %w", err)
+	}
+	if err := backend.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "application/gzip"); err != nil {
+		return fmt.Errorf("failed to store log archive %s: %w", key, err)
+	}
+	return nil
+}
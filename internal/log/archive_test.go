@@ -0,0 +1,48 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestArchiveKey(t *testing.T) {
+	runAt := time.Date(2026, 10, 16, 3, 4, 5, 0, time.UTC)
+	if got, want := archiveKey(runAt, 2), "activity-logs/2026/10/16/20261016T030405Z-0002.ndjson.gz"; got != want {
+		t.Errorf("archiveKey() = %s, want %s", got, want)
+	}
+}
+
+func TestEncodeArchive(t *testing.T) {
+	logs := []models.ActivityLog{
+		{ID: uuid.New(), EventType: "LOGIN", Details: json.RawMessage(`{"a":1}`)},
+		{ID: uuid.New(), EventType: "LOGOUT", Details: json.RawMessage(`{}`)},
+	}
+	data, err := encodeArchive(logs)
+	if err != nil {
+		t.Fatalf("encodeArchive: %v", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("archive is not gzip: %v", err)
+	}
+	scanner := bufio.NewScanner(zr)
+	var decoded []models.ActivityLog
+	for scanner.Scan() {
+		var l models.ActivityLog
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			t.Fatalf("line %d is not JSON: %v", len(decoded)+1, err)
+		}
+		decoded = append(decoded, l)
+	}
+	if len(decoded) != 2 || decoded[0].ID != logs[0].ID || decoded[1].EventType != "LOGOUT" {
+		t.Errorf("decoded %+v, want the two logs in order", decoded)
+	}
+}
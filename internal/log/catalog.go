@@ -0,0 +1,53 @@
+package log
+
+import (
+	"log"
+	"sync"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+)
+
+// warnedEventTypes remembers event types outside the catalog already reported.
+var warnedEventTypes sync.Map
+
+// checkEventType warns once per type when an event type is not in the event
+// catalog (config.EventCatalog), so new events do not go out undocumented.
+func checkEventType(eventType string) {
+	if _, ok := config.GetEventDefinition(eventType); ok {
+		return
+	}
+	if _, seen := warnedEventTypes.LoadOrStore(eventType, true); !seen {
+		log.Printf("Warning: activity event type %q is not in the event catalog; it is logged as INFORMATIONAL\n", eventType)
+	}
+}
+
+// DescribeEvent returns the user-facing description of an event type, or the
+// type itself when it is not in the catalog.
+func DescribeEvent(eventType string) string {
+	if def, ok := config.GetEventDefinition(eventType); ok {
+		return def.Description
+	}
+	return eventType
+}
+
+// EventCatalog returns the event catalog with the retention and enabled state
+// resolved from the current logging configuration.
+func EventCatalog() []dto.ActivityEventDefinition {
+	cfg := config.GetLoggingConfig()
+	defs := config.EventCatalog()
+	events := make([]dto.ActivityEventDefinition, 0, len(defs))
+	for _, def := range defs {
+		events = append(events, dto.ActivityEventDefinition{
+			Type:           def.Type,
+			Category:       string(def.Category),
+			Severity:       string(def.Severity),
+			RetentionClass: string(def.Retention),
+			RetentionDays:  cfg.GetEventRetentionDays(def.Type),
+			Enabled:        cfg.IsEventEnabled(def.Type),
+			UserVisible:    def.UserVisible,
+			Description:    def.Description,
+		})
+	}
+	return events
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// TestEventConstantsInCatalog fails when an Event* constant is added to
+// service.go without a catalog entry, or the catalog lists an unknown type.
+func TestEventConstantsInCatalog(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "service.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constants := make(map[string]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "Event") || i >= len(spec.Values) {
+				continue
+			}
+			if lit, ok := spec.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				value, _ := strconv.Unquote(lit.Value)
+				constants[value] = name.Name
+			}
+		}
+		return true
+	})
+	if len(constants) == 0 {
+		t.Fatal("no Event* constants found in service.go")
+	}
+
+	for value, name := range constants {
+		if _, ok := config.GetEventDefinition(value); !ok {
+			t.Errorf("%s (%q) is missing from the event catalog", name, value)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, def := range config.EventCatalog() {
+		if seen[def.Type] {
+			t.Errorf("%s is listed twice in the event catalog", def.Type)
+		}
+		seen[def.Type] = true
+		if _, ok := constants[def.Type]; !ok {
+			t.Errorf("catalog type %s has no Event* constant", def.Type)
+		}
+		if def.Description == "" || def.Category == "" || def.Severity == "" || def.Retention == "" {
+			t.Errorf("catalog entry %s is incomplete: %+v", def.Type, def)
+		}
+	}
+}
+
+func TestEventCatalogDefaults(t *testing.T) {
+	events := make(map[string]bool)
+	for _, e := range EventCatalog() {
+		events[e.Type] = e.Enabled
+		if e.RetentionDays <= 0 {
+			t.Errorf("%s: retention_days = %d", e.Type, e.RetentionDays)
+		}
+	}
+	// High-frequency events are off unless LOG_TOKEN_REFRESH / LOG_PROFILE_ACCESS enable them
+	if events[EventTokenRefresh] || events[EventProfileAccess] {
+		t.Error("TOKEN_REFRESH and PROFILE_ACCESS should be disabled by default")
+	}
+	if !events[EventLogin] {
+		t.Error("LOGIN should be enabled by default")
+	}
+	if got := DescribeEvent(EventPasswordChange); got != "Password changed" {
+		t.Errorf("DescribeEvent(PASSWORD_CHANGE) = %q", got)
+	}
+	if got := DescribeEvent("CUSTOM_EVENT"); got != "CUSTOM_EVENT" {
+		t.Errorf("DescribeEvent(unknown) = %q, want the type itself", got)
+	}
+}
+
+func TestToSecurityEvent(t *testing.T) {
+	entry := models.ActivityLog{
+		ID:        uuid.New(),
+		EventType: EventLogin,
+		IsAnomaly: true,
+		Details:   json.RawMessage(`{"anomaly_reasons":["new_user_agent","unusual_time_access"],"internal":"x"}`),
+	}
+	event := toSecurityEvent(entry)
+	if event.MessageKey != "security_event.login" || event.Category != "authentication" || event.Description == "" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if !event.NewDevice || event.NewLocation {
+		t.Errorf("new_device = %v, new_location = %v; want true, false", event.NewDevice, event.NewLocation)
+	}
+
+	for _, eventType := range config.UserVisibleEventTypes() {
+		if eventType == EventTokenRefresh || eventType == EventProfileAccess {
+			t.Errorf("%s should not be shown in the security events feed", eventType)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -123,6 +125,10 @@ func (cs *CleanupService) runCleanup() {
 
 // deleteExpiredLogs deletes logs that have passed their expiration date
 func (cs *CleanupService) deleteExpiredLogs() (int64, error) {
+	if cs.config.ArchiveBeforeCleanup {
+		return cs.archiveAndDeleteExpiredLogs()
+	}
+
 	batchSize := cs.config.CleanupBatchSize
 	totalDeleted := int64(0)
 	now := time.Now().UTC()
@@ -154,6 +160,52 @@ func (cs *CleanupService) deleteExpiredLogs() (int64, error) {
 	return totalDeleted, nil
 }
 
+// archiveAndDeleteExpiredLogs writes each batch of expired logs to the file
+// storage backend before deleting it. A batch that cannot be archived is not
+// deleted, and the run stops so it is retried next time.
+func (cs *CleanupService) archiveAndDeleteExpiredLogs() (int64, error) {
+	batchSize := cs.config.CleanupBatchSize
+	totalDeleted := int64(0)
+	now := time.Now().UTC()
+
+	for batch := 1; ; batch++ {
+		var logs []models.ActivityLog
+		if err := cs.db.
+			Where("expires_at IS NOT NULL AND expires_at < ?", now).
+			Order("timestamp, id").
+			Limit(batchSize).
+			Find(&logs).Error; err != nil {
+			return totalDeleted, fmt.Errorf("failed to load expired logs: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		if err := archiveLogs(cs.ctx, archiveKey(now, batch), logs); err != nil {
+			return totalDeleted, err
+		}
+
+		ids := make([]uuid.UUID, len(logs))
+		for i := range logs {
+			ids[i] = logs[i].ID
+		}
+		result := cs.db.Where("id IN ?", ids).Delete(&models.ActivityLog{})
+		if result.Error != nil {
+			return totalDeleted, fmt.Errorf("failed to delete archived logs: %w", result.Error)
+		}
+		totalDeleted += result.RowsAffected
+
+		if len(logs) < batchSize {
+			break
+		}
+
+		// Small delay between batches to avoid overwhelming the database
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return totalDeleted, nil
+}
+
 // DeleteLogsByUserID deletes all logs for a specific user (for GDPR compliance)
 func (cs *CleanupService) DeleteLogsByUserID(userID string) (int64, error) {
 	result := cs.db.Table("activity_logs").Where("user_id = ?", userID).Delete(nil)
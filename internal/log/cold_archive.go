@@ -0,0 +1,490 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
+	"github.com/gjovanovicst/auth_api/internal/storage"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobTypeArchiveRestore is the background job type that copies archived
+// activity logs back into activity_logs.
+const JobTypeArchiveRestore = "activity_log_restore"
+
+// coldArchivePrefix is the storage key prefix of the cold activity log
+// archive, partitioned by application and UTC day.
+const coldArchivePrefix = archivePrefix + "archive/"
+
+// ArchiveMaxRangeDays is the largest date range, in days, an archive query or
+// restore may cover.
+const ArchiveMaxRangeDays = 31
+
+// archiveQueryDefaultLimit is the number of logs an archive query returns
+// when no limit is given.
+const archiveQueryDefaultLimit = 100
+
+// Archiver moves activity logs older than the hot-retention window
+// (LOG_ARCHIVE_HOT_DAYS) to the file storage backend and reads them back for
+// compliance queries and restores. Every archived file is recorded in
+// activity_log_archives.
+type Archiver struct {
+	DB *gorm.DB
+}
+
+// NewArchiver creates an Archiver.
+func NewArchiver(db *gorm.DB) *Archiver {
+	return &Archiver{DB: db}
+}
+
+// archivePartition is one (application, UTC day) of activity logs.
+type archivePartition struct {
+	AppID uuid.UUID
+	Day   time.Time
+}
+
+// coldArchiveKey returns the storage key of the part-th file of an
+// application's logs for a UTC day:
+// activity-logs/archive/app=<app_id>/date=YYYY-MM-DD/part-NNNN.ndjson.gz.
+func coldArchiveKey(appID uuid.UUID, day time.Time, part int) string {
+	return fmt.Sprintf("%sapp=%s/date=%s/part-%04d.ndjson.gz", coldArchivePrefix, appID, day.UTC().Format("2006-01-02"), part)
+}
+
+// archiveCutoff returns the start of the oldest UTC day that is still within
+// the hot-retention window of hotDays days; older logs are archived.
+func archiveCutoff(now time.Time, hotDays int) time.Time {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return today.AddDate(0, 0, -hotDays)
+}
+
+// RunArchive archives every partition older than the hot-retention window,
+// one file per LOG_CLEANUP_BATCH_SIZE logs, and deletes the archived logs.
+// Logs restored from the archive are left alone until their restore expires.
+// Its signature matches the job scheduler's job functions.
+func (a *Archiver) RunArchive(ctx context.Context) error {
+	cfg := config.GetLoggingConfig()
+	if cfg.ArchiveHotDays < 1 {
+		return fmt.Errorf("LOG_ARCHIVE_HOT_DAYS must be at least 1, got %d", cfg.ArchiveHotDays)
+	}
+	cutoff := archiveCutoff(time.Now(), cfg.ArchiveHotDays)
+
+	var partitions []archivePartition
+	if err := a.DB.WithContext(ctx).Raw(`
+		SELECT app_id, (timestamp AT TIME ZONE 'UTC')::date AS day
+		FROM activity_logs
+		WHERE timestamp < ?
+		GROUP BY 1, 2
+		ORDER BY 2, 1
+	`, cutoff).Scan(&partitions).Error; err != nil {
+		return fmt.Errorf("failed to list log partitions to archive: %w", err)
+	}
+
+	var files int
+	var archived int64
+	for _, p := range partitions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, rows, err := a.archivePartition(ctx, p, cfg.CleanupBatchSize)
+		files += n
+		archived += rows
+		if err != nil {
+			return err
+		}
+	}
+
+	if files > 0 {
+		log.Printf("Activity log archive: archived %d logs older than %s into %d files", archived, cutoff.Format("2006-01-02"), files)
+	}
+	return nil
+}
+
+// archivePartition writes the logs of one partition to the file storage
+// backend in batches of batchSize, appending parts after the ones already
+// archived, and deletes each batch once its file is recorded. A batch that
+// cannot be stored is kept, so it is retried on the next run.
+func (a *Archiver) archivePartition(ctx context.Context, p archivePartition, batchSize int) (int, int64, error) {
+	db := a.DB.WithContext(ctx)
+	start := p.Day.UTC()
+	end := start.AddDate(0, 0, 1)
+
+	var existing []models.ActivityLogArchive
+	if err := db.Where("app_id = ? AND day = ?", p.AppID, start).Order("part").Find(&existing).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to load archive manifest: %w", err)
+	}
+	part := 1
+	var restoredUntil *time.Time
+	for _, m := range existing {
+		if m.RestoredUntil != nil {
+			restoredUntil = m.RestoredUntil
+		}
+		if m.Part >= part {
+			part = m.Part + 1
+		}
+	}
+	if restoredUntil != nil {
+		if restoredUntil.After(time.Now()) {
+			// Restored for a compliance review; archived again once it expires
+			return 0, 0, nil
+		}
+		if err := a.expireRestore(ctx, p, *restoredUntil); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	backend, err := storage.Current()
+	if err != nil {
+		return 0, 0, fmt.Errorf("file storage is unavailable: %w", err)
+	}
+
+	var files int
+	var archived int64
+	for ; ; part++ {
+		var logs []models.ActivityLog
+		if err := db.
+			Where("app_id = ? AND timestamp >= ? AND timestamp < ?", p.AppID, start, end).
+			Order("timestamp, id").
+			Limit(batchSize).
+			Find(&logs).Error; err != nil {
+			return files, archived, fmt.Errorf("failed to load logs to archive: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		data, err := encodeArchive(logs)
+		if err != nil {
+			return files, archived, fmt.Errorf("failed to encode log archive: %w", err)
+		}
+		key := coldArchiveKey(p.AppID, start, part)
+		if err := backend.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "application/gzip"); err != nil {
+			return files, archived, fmt.Errorf("failed to store log archive %s: %w", key, err)
+		}
+
+		ids := make([]uuid.UUID, len(logs))
+		for i := range logs {
+			ids[i] = logs[i].ID
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.ActivityLogArchive{
+				AppID:      p.AppID,
+				Day:        start,
+				Part:       part,
+				Key:        key,
+				Backend:    backend.Name(),
+				RowCount:   int64(len(logs)),
+				SizeBytes:  int64(len(data)),
+				ArchivedAt: time.Now().UTC(),
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id IN ?", ids).Delete(&models.ActivityLog{}).Error
+		})
+		if err != nil {
+			return files, archived, fmt.Errorf("failed to record log archive %s: %w", key, err)
+		}
+		files++
+		archived += int64(len(logs))
+
+		if len(logs) < batchSize {
+			break
+		}
+
+		// Small delay between batches to avoid overwhelming the database
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return files, archived, nil
+}
+
+// expireRestore deletes the restored copies of a partition's archived logs
+// (the rows whose expires_at is the restore expiry) and clears the restore.
+func (a *Archiver) expireRestore(ctx context.Context, p archivePartition, restoredUntil time.Time) error {
+	start := p.Day.UTC()
+	return a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("app_id = ? AND timestamp >= ? AND timestamp < ? AND expires_at = ?", p.AppID, start, start.AddDate(0, 0, 1), restoredUntil).
+			Delete(&models.ActivityLog{}).Error; err != nil {
+			return fmt.Errorf("failed to delete restored logs: %w", err)
+		}
+		if err := tx.Model(&models.ActivityLogArchive{}).
+			Where("app_id = ? AND day = ?", p.AppID, start).
+			Update("restored_until", nil).Error; err != nil {
+			return fmt.Errorf("failed to clear archive restore: %w", err)
+		}
+		return nil
+	})
+}
+
+// readArchive reads the logs of one archived file.
+func readArchive(ctx context.Context, backend storage.Backend, key string) ([]models.ActivityLog, error) {
+	obj, err := backend.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log archive %s: %w", key, err)
+	}
+	defer obj.Body.Close()
+
+	logs, err := decodeArchive(obj.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode log archive %s: %w", key, err)
+	}
+	return logs, nil
+}
+
+// decodeArchive decodes gzip-compressed newline-delimited JSON written by
+// encodeArchive.
+func decodeArchive(r io.Reader) ([]models.ActivityLog, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var logs []models.ActivityLog
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	for {
+		var entry models.ActivityLog
+		if err := dec.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		logs = append(logs, entry)
+	}
+	return logs, nil
+}
+
+// parseArchiveRange parses the required start and end dates of an archive
+// query or restore and returns the first and last UTC day of the range.
+func parseArchiveRange(startDateStr, endDateStr string) (time.Time, time.Time, *errors.AppError) {
+	startDate, endDate, appErr := parseDateFilters(startDateStr, endDateStr)
+	if appErr != nil {
+		return time.Time{}, time.Time{}, appErr
+	}
+	if startDate == nil || endDate == nil {
+		return time.Time{}, time.Time{}, errors.NewAppError(errors.ErrBadRequest, "start_date and end_date are required")
+	}
+	if endDate.Sub(*startDate) >= ArchiveMaxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, errors.NewAppError(errors.ErrBadRequest, fmt.Sprintf("The date range cannot exceed %d days", ArchiveMaxRangeDays))
+	}
+	return *startDate, *endDate, nil
+}
+
+// matchesArchiveQuery reports whether an archived log matches the optional
+// user and event type filters of an archive query.
+func matchesArchiveQuery(entry *models.ActivityLog, userID *uuid.UUID, eventType string) bool {
+	if userID != nil && entry.UserID != *userID {
+		return false
+	}
+	return eventType == "" || entry.EventType == eventType
+}
+
+// ListArchives lists archived files, optionally for one application and a
+// date range, newest day first. At most ExportMaxRows files are returned.
+func (a *Archiver) ListArchives(req dto.ActivityLogArchiveListRequest) (*dto.ActivityLogArchiveListResponse, *errors.AppError) {
+	startDate, endDate, appErr := parseDateFilters(req.StartDate, req.EndDate)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	query := a.DB.Model(&models.ActivityLogArchive{})
+	if req.AppID != "" {
+		query = query.Where("app_id = ?", req.AppID)
+	}
+	if startDate != nil {
+		query = query.Where("day >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("day <= ?", *endDate)
+	}
+
+	var archives []models.ActivityLogArchive
+	if err := query.Order("day DESC, app_id, part").Limit(ExportMaxRows + 1).Find(&archives).Error; err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to list log archives")
+	}
+	truncated := len(archives) > ExportMaxRows
+	if truncated {
+		archives = archives[:ExportMaxRows]
+	}
+
+	data := make([]dto.ActivityLogArchiveResponse, len(archives))
+	for i, m := range archives {
+		data[i] = dto.ActivityLogArchiveResponse{
+			AppID:      m.AppID.String(),
+			Day:        m.Day.Format("2006-01-02"),
+			Part:       m.Part,
+			Key:        m.Key,
+			Backend:    m.Backend,
+			RowCount:   m.RowCount,
+			SizeBytes:  m.SizeBytes,
+			ArchivedAt: m.ArchivedAt.Format(time.RFC3339),
+		}
+		if m.RestoredUntil != nil {
+			until := m.RestoredUntil.Format(time.RFC3339)
+			data[i].RestoredUntil = &until
+		}
+	}
+
+	return &dto.ActivityLogArchiveListResponse{Data: data, Count: len(data), Truncated: truncated}, nil
+}
+
+// QueryArchive reads the archived logs of one application in a date range
+// straight from the file storage backend, without restoring them, and returns
+// the ones matching the user and event type filters in timestamp order.
+func (a *Archiver) QueryArchive(ctx context.Context, req dto.ActivityLogArchiveQueryRequest) (*dto.ActivityLogArchiveQueryResponse, *errors.AppError) {
+	startDate, endDate, appErr := parseArchiveRange(req.StartDate, req.EndDate)
+	if appErr != nil {
+		return nil, appErr
+	}
+	var userID *uuid.UUID
+	if req.UserID != "" {
+		parsed, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return nil, errors.NewAppError(errors.ErrBadRequest, "Invalid user_id")
+		}
+		userID = &parsed
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = archiveQueryDefaultLimit
+	}
+
+	var archives []models.ActivityLogArchive
+	if err := a.DB.WithContext(ctx).
+		Where("app_id = ? AND day >= ? AND day <= ?", req.AppID, startDate, endDate).
+		Order("day, part").
+		Find(&archives).Error; err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to load log archives")
+	}
+
+	resp := &dto.ActivityLogArchiveQueryResponse{Data: []dto.ActivityLogResponse{}}
+	if len(archives) == 0 {
+		return resp, nil
+	}
+	backend, err := storage.Current()
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "File storage is unavailable: "+err.Error())
+	}
+
+	var qs QueryService
+	for _, m := range archives {
+		logs, err := readArchive(ctx, backend, m.Key)
+		if err != nil {
+			log.Printf("Warning: %v\n", err)
+			return nil, errors.NewAppError(errors.ErrInternal, "Failed to read log archive "+m.Key)
+		}
+		resp.FilesScanned++
+		for i := range logs {
+			resp.RowsScanned++
+			if !matchesArchiveQuery(&logs[i], userID, req.EventType) {
+				continue
+			}
+			if len(resp.Data) == limit {
+				resp.Truncated = true
+				break
+			}
+			resp.Data = append(resp.Data, qs.convertToResponse(logs[i]))
+		}
+		if resp.Truncated {
+			break
+		}
+	}
+	resp.Count = len(resp.Data)
+
+	return resp, nil
+}
+
+// RunRestoreJob copies the archived logs of one application in a date range
+// back into activity_logs for an activity_log_restore job (payload
+// dto.ActivityLogArchiveRestoreRequest) and returns its
+// dto.ActivityLogArchiveRestoreResult. Restored logs expire after the restore
+// days; the archive files are kept. Its signature matches
+// jobqueue.HandlerFunc.
+func (a *Archiver) RunRestoreJob(ctx context.Context, task *jobqueue.Task) (interface{}, error) {
+	var req dto.ActivityLogArchiveRestoreRequest
+	if err := task.Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	startDate, endDate, appErr := parseArchiveRange(req.StartDate, req.EndDate)
+	if appErr != nil {
+		return nil, appErr
+	}
+	days := req.Days
+	if days <= 0 {
+		days = config.GetLoggingConfig().ArchiveRestoreDays
+	}
+	// Postgres keeps microseconds; expireRestore matches rows on this value
+	restoredUntil := time.Now().UTC().Add(time.Duration(days) * 24 * time.Hour).Truncate(time.Microsecond)
+
+	db := a.DB.WithContext(ctx)
+	var archives []models.ActivityLogArchive
+	if err := db.
+		Where("app_id = ? AND day >= ? AND day <= ?", req.AppID, startDate, endDate).
+		Order("day, part").
+		Find(&archives).Error; err != nil {
+		return nil, fmt.Errorf("failed to load log archives: %w", err)
+	}
+	if len(archives) == 0 {
+		task.SetSummary("No archived logs in the date range")
+		return dto.ActivityLogArchiveRestoreResult{RestoredUntil: restoredUntil.Format(time.RFC3339)}, nil
+	}
+
+	// Mark the partitions first so a concurrent archive run skips them
+	if err := db.Model(&models.ActivityLogArchive{}).
+		Where("app_id = ? AND day >= ? AND day <= ?", req.AppID, startDate, endDate).
+		Update("restored_until", restoredUntil).Error; err != nil {
+		return nil, fmt.Errorf("failed to mark log archives as restored: %w", err)
+	}
+
+	backend, err := storage.Current()
+	if err != nil {
+		return nil, fmt.Errorf("file storage is unavailable: %w", err)
+	}
+
+	var restored int64
+	for i, m := range archives {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		logs, err := readArchive(ctx, backend, m.Key)
+		if err != nil {
+			return nil, err
+		}
+		for j := range logs {
+			logs[j].ExpiresAt = &restoredUntil
+		}
+		if len(logs) > 0 {
+			// A second restore of the same range extends the expiry of the copies
+			if err := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+			}).CreateInBatches(&logs, 500).Error; err != nil {
+				return nil, fmt.Errorf("failed to restore logs from %s: %w", m.Key, err)
+			}
+		}
+		restored += int64(len(logs))
+		task.SetProgress((i+1)*100/len(archives), fmt.Sprintf("%d of %d files restored", i+1, len(archives)))
+	}
+
+	task.SetSummary(fmt.Sprintf("%d logs restored from %d files until %s", restored, len(archives), restoredUntil.Format(time.RFC3339)))
+	return dto.ActivityLogArchiveRestoreResult{
+		Files:         len(archives),
+		Restored:      restored,
+		RestoredUntil: restoredUntil.Format(time.RFC3339),
+	}, nil
+}
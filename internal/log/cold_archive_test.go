@@ -0,0 +1,96 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestColdArchiveKey(t *testing.T) {
+	appID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	day := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	want := "activity-logs/archive/app=00000000-0000-0000-0000-000000000001/date=2026-08-01/part-0003.ndjson.gz"
+	if got := coldArchiveKey(appID, day, 3); got != want {
+		t.Errorf("coldArchiveKey() = %s, want %s", got, want)
+	}
+}
+
+func TestArchiveCutoff(t *testing.T) {
+	now := time.Date(2026, 10, 16, 23, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	want := time.Date(2026, 9, 16, 0, 0, 0, 0, time.UTC)
+	if got := archiveCutoff(now, 30); !got.Equal(want) {
+		t.Errorf("archiveCutoff() = %s, want %s", got, want)
+	}
+}
+
+func TestDecodeArchiveRoundTrip(t *testing.T) {
+	ts := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	logs := []models.ActivityLog{
+		{ID: uuid.New(), UserID: uuid.New(), EventType: "LOGIN", Timestamp: ts, Details: json.RawMessage(`{"a":1}`)},
+		{ID: uuid.New(), UserID: uuid.New(), EventType: "LOGOUT", Timestamp: ts.Add(time.Minute), Details: json.RawMessage(`{}`)},
+	}
+	data, err := encodeArchive(logs)
+	if err != nil {
+		t.Fatalf("encodeArchive: %v", err)
+	}
+
+	decoded, err := decodeArchive(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decodeArchive: %v", err)
+	}
+	if len(decoded) != len(logs) {
+		t.Fatalf("decoded %d logs, want %d", len(decoded), len(logs))
+	}
+	for i := range logs {
+		if decoded[i].ID != logs[i].ID || decoded[i].EventType != logs[i].EventType || !decoded[i].Timestamp.Equal(logs[i].Timestamp) {
+			t.Errorf("log %d = %+v, want %+v", i, decoded[i], logs[i])
+		}
+	}
+
+	if _, err := decodeArchive(bytes.NewReader([]byte("not gzip"))); err == nil {
+		t.Error("decodeArchive accepted data that is not gzip")
+	}
+}
+
+func TestParseArchiveRange(t *testing.T) {
+	tests := []struct {
+		start, end string
+		wantErr    bool
+	}{
+		{"2026-08-01", "2026-08-31", false},
+		{"2026-08-01", "2026-08-01", false},
+		{"2026-08-01", "2026-09-01", true}, // 32 days
+		{"2026-08-02", "2026-08-01", true},
+		{"", "2026-08-01", true},
+		{"2026-08-01", "08/31/2026", true},
+	}
+	for _, tt := range tests {
+		_, _, appErr := parseArchiveRange(tt.start, tt.end)
+		if (appErr != nil) != tt.wantErr {
+			t.Errorf("parseArchiveRange(%q, %q) error = %v, wantErr %v", tt.start, tt.end, appErr, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchesArchiveQuery(t *testing.T) {
+	userID := uuid.New()
+	entry := &models.ActivityLog{UserID: userID, EventType: "LOGIN"}
+	other := uuid.New()
+
+	if !matchesArchiveQuery(entry, nil, "") {
+		t.Error("no filters should match")
+	}
+	if !matchesArchiveQuery(entry, &userID, "LOGIN") {
+		t.Error("matching user and event type should match")
+	}
+	if matchesArchiveQuery(entry, &other, "") {
+		t.Error("other user should not match")
+	}
+	if matchesArchiveQuery(entry, nil, "LOGOUT") {
+		t.Error("other event type should not match")
+	}
+}
@@ -7,12 +7,17 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/internal/jobqueue"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 )
 
 type Handler struct {
 	QueryService *QueryService
+	Archiver     *Archiver       // Cold archive of old activity logs (set when LOG_ARCHIVE_ENABLED)
+	JobQueue     *jobqueue.Queue // Runs archive restores (nil when JOB_QUEUE_ENABLED is off)
 }
 
 func NewHandler(queryService *QueryService) *Handler {
@@ -26,10 +31,20 @@ func NewHandler(queryService *QueryService) *Handler {
 // @Produce json
 // @Param page query int false "Page number (default: 1)" minimum(1)
 // @Param limit query int false "Items per page (default: 20, max: 100)" minimum(1) maximum(100)
-// @Param event_type query string false "Filter by event type"
+// @Param event_type query string false "Filter by event type (same as filter[event_type])"
 // @Param start_date query string false "Start date filter (YYYY-MM-DD)"
 // @Param end_date query string false "End date filter (YYYY-MM-DD)"
+// @Param sort query string false "Sort field: timestamp, event_type, severity (prefix with - for descending; offset pagination only)" default(timestamp)
+// @Param order query string false "Sort order: asc or desc (offset pagination only)" default(desc)
+// @Param filter[app_id] query string false "Filter by application ID"
+// @Param filter[user_id] query string false "Filter by user ID"
+// @Param filter[severity] query string false "Filter by severity: CRITICAL, IMPORTANT, INFORMATIONAL"
+// @Param filter[ip_address] query string false "Filter by IP address"
+// @Param filter[is_anomaly] query bool false "Filter by anomaly flag"
+// @Param pagination query string false "Pagination mode: offset (default) or cursor" Enums(offset, cursor)
+// @Param cursor query string false "Opaque cursor from a previous response (implies pagination=cursor)"
 // @Success 200 {object} dto.ActivityLogListResponse
+// @Success 200 {object} dto.ActivityLogCursorListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -56,6 +71,16 @@ func (h *Handler) GetUserActivityLogs(c *gin.Context) {
 		return
 	}
 
+	if req.UsesCursor() {
+		response, appErr := h.QueryService.ListUserActivityLogsCursor(userUUID, req)
+		if appErr != nil {
+			c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
 	// Get activity logs
 	response, appErr := h.QueryService.ListUserActivityLogs(userUUID, req)
 	if appErr != nil {
@@ -122,7 +147,11 @@ func (h *Handler) GetActivityLogByID(c *gin.Context) {
 // @Param event_type query string false "Filter by event type"
 // @Param start_date query string false "Start date filter (YYYY-MM-DD)"
 // @Param end_date query string false "End date filter (YYYY-MM-DD)"
+// @Param pagination query string false "Pagination mode: offset (default) or cursor" Enums(offset, cursor)
+// @Param cursor query string false "Opaque cursor from a previous response (implies pagination=cursor)"
+// @Param q query string false "Full-text search over event type, IP address, user agent and details; results are ranked by relevance (offset pagination only)"
 // @Success 200 {object} dto.ActivityLogListResponse
+// @Success 200 {object} dto.ActivityLogCursorListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 403 {object} dto.ErrorResponse
@@ -139,8 +168,28 @@ func (h *Handler) GetAllActivityLogs(c *gin.Context) {
 		return
 	}
 
+	parse := pagination.ParseListQuery
+	if req.UsesCursor() {
+		parse = pagination.ParseCursorListQuery
+	}
+	lq, err := parse(c.Request.URL.Query(), activityLogListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if req.UsesCursor() {
+		response, appErr := h.QueryService.ListAllActivityLogsCursor(req, lq)
+		if appErr != nil {
+			c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
 	// Get activity logs
-	response, appErr := h.QueryService.ListAllActivityLogs(req)
+	response, appErr := h.QueryService.ListAllActivityLogs(req, lq)
 	if appErr != nil {
 		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
 		return
@@ -158,53 +207,10 @@ func (h *Handler) GetAllActivityLogs(c *gin.Context) {
 // @Failure 401 {object} dto.ErrorResponse
 // @Router /activity-logs/event-types [get]
 func (h *Handler) GetEventTypes(c *gin.Context) {
-	eventTypes := []string{
-		// Authentication
-		EventLogin,
-		EventLoginFailed,
-		EventLogout,
-		EventRegister,
-		EventTokenRefresh,
-
-		// Password management
-		EventPasswordChange,
-		EventPasswordReset,
-
-		// Email
-		EventEmailVerify,
-		EventEmailVerifyResend,
-		EventEmailChange,
-
-		// Two-factor authentication
-		Event2FAEnable,
-		Event2FADisable,
-		Event2FALogin,
-		EventRecoveryCodeUsed,
-		EventRecoveryCodeGen,
-
-		// Social authentication
-		EventSocialLogin,
-		EventSocialAccountLinked,
-		EventSocialAccountUnlinked,
-
-		// Passkey / WebAuthn
-		EventPasskeyRegister,
-		EventPasskeyDelete,
-		EventPasskeyLogin,
-
-		// Magic link
-		EventMagicLinkRequested,
-		EventMagicLinkLogin,
-		EventMagicLinkFailed,
-
-		// Profile & account
-		EventProfileAccess,
-		EventProfileUpdate,
-		EventAccountDeletion,
-
-		// Security events
-		EventBruteForceDetected,
-		EventIPBlocked,
+	defs := config.EventCatalog()
+	eventTypes := make([]string, 0, len(defs))
+	for _, def := range defs {
+		eventTypes = append(eventTypes, def.Type)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -212,6 +218,61 @@ func (h *Handler) GetEventTypes(c *gin.Context) {
 	})
 }
 
+// @Summary Get security events
+// @Description Retrieve the authenticated user's security timeline: sign-ins, password and email changes, 2FA changes, passkeys and sign-ins from new devices. Each entry carries a stable message_key for translation and an English description.
+// @Tags Activity Logs
+// @Security ApiKeyAuth
+// @Produce json
+// @Param page query int false "Page number (default: 1)" minimum(1)
+// @Param limit query int false "Items per page (default: 20, max: 100)" minimum(1) maximum(100)
+// @Param event_type query string false "Filter by a user-visible event type"
+// @Param start_date query string false "Start date filter (YYYY-MM-DD)"
+// @Param end_date query string false "End date filter (YYYY-MM-DD)"
+// @Success 200 {object} dto.SecurityEventListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /profile/security-events [get]
+func (h *Handler) GetSecurityEvents(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "User ID not found in context"})
+		return
+	}
+
+	var req dto.SecurityEventListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	response, appErr := h.QueryService.ListUserSecurityEvents(userUUID, req)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get the activity event catalog
+// @Description List every activity log event type with its category, default severity, retention and user-facing description, for building integrations on top of activity logs and webhooks
+// @Tags Admin
+// @Security AdminApiKey
+// @Produce json
+// @Success 200 {object} dto.ActivityEventCatalogResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /admin/activity-logs/event-catalog [get]
+func (h *Handler) GetEventCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.ActivityEventCatalogResponse{Events: EventCatalog()})
+}
+
 // @Summary Export user activity logs
 // @Description Export the authenticated user's activity logs as CSV or JSON (max 10,000 rows). Use the X-Export-Truncated response header to detect if the result was capped.
 // @Tags Activity Logs
@@ -337,3 +398,118 @@ func (h *Handler) writeExportResponse(c *gin.Context, logs []dto.ActivityLogResp
 		_ = enc.Encode(resp)
 	}
 }
+
+// @Summary List activity log archives (Admin)
+// @Description List the files of the cold activity log archive (logs older than LOG_ARCHIVE_HOT_DAYS moved to the file storage backend), newest day first (max 10,000 files).
+// @Tags Activity Logs
+// @Security ApiKeyAuth
+// @Produce json
+// @Param app_id query string false "Filter by application ID"
+// @Param start_date query string false "Start date filter (YYYY-MM-DD)"
+// @Param end_date query string false "End date filter (YYYY-MM-DD)"
+// @Success 200 {object} dto.ActivityLogArchiveListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/activity-logs/archives [get]
+func (h *Handler) ListActivityLogArchives(c *gin.Context) {
+	if h.Archiver == nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Activity log archiving is disabled on this server"})
+		return
+	}
+
+	var req dto.ActivityLogArchiveListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response, appErr := h.Archiver.ListArchives(req)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Query archived activity logs (Admin)
+// @Description Read one application's archived activity logs straight from the file storage backend, without restoring them. The date range is required and covers at most 31 days.
+// @Tags Activity Logs
+// @Security ApiKeyAuth
+// @Produce json
+// @Param app_id query string true "Application ID"
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param user_id query string false "Filter by user ID"
+// @Param event_type query string false "Filter by event type"
+// @Param limit query int false "Maximum logs returned (default: 100, max: 1000)" minimum(1) maximum(1000)
+// @Success 200 {object} dto.ActivityLogArchiveQueryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/activity-logs/archives/query [get]
+func (h *Handler) QueryActivityLogArchives(c *gin.Context) {
+	if h.Archiver == nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Activity log archiving is disabled on this server"})
+		return
+	}
+
+	var req dto.ActivityLogArchiveQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response, appErr := h.Archiver.QueryArchive(c.Request.Context(), req)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Restore archived activity logs (Admin)
+// @Description Queue a background job that copies one application's archived activity logs in a date range (at most 31 days) back into activity_logs, where they are queryable for `days` days (default LOG_ARCHIVE_RESTORE_DAYS). The archive files are kept.
+// @Tags Activity Logs
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.ActivityLogArchiveRestoreRequest true "Restore request"
+// @Success 202 {object} dto.BackgroundJobResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/activity-logs/archives/restore [post]
+func (h *Handler) RestoreActivityLogArchives(c *gin.Context) {
+	if h.Archiver == nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Activity log archiving is disabled on this server"})
+		return
+	}
+	if h.JobQueue == nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Background jobs are disabled on this server"})
+		return
+	}
+
+	var req dto.ActivityLogArchiveRestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, _, appErr := parseArchiveRange(req.StartDate, req.EndDate); appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	job, err := h.JobQueue.Enqueue(JobTypeArchiveRestore, req, "api")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to queue restore: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, jobqueue.ToResponse(job))
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+)
+
+// WithImpersonation returns details with the support agent added when c was
+// authenticated with an impersonation token, so that actions taken while
+// signed in as the user are attributed to whoever did them. details may be
+// nil; it is returned unchanged for ordinary requests.
+func WithImpersonation(c *gin.Context, details map[string]interface{}) map[string]interface{} {
+	v, ok := c.Get("impersonation")
+	if !ok {
+		return details
+	}
+	imp, ok := v.(*jwt.Impersonation)
+	if !ok || imp == nil {
+		return details
+	}
+	if details == nil {
+		details = make(map[string]interface{})
+	}
+	details["impersonated_by"] = imp.By
+	details["impersonation_reason"] = imp.Reason
+	if imp.ApiKeyID != "" {
+		details["impersonation_api_key_id"] = imp.ApiKeyID
+	}
+	return details
+}
@@ -3,11 +3,13 @@ package log
 import (
 	"encoding/json"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	"github.com/gjovanovicst/auth_api/pkg/errors"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 )
 
@@ -92,8 +94,9 @@ func (s *QueryService) ListUserActivityLogs(userID uuid.UUID, req dto.ActivityLo
 	}, nil
 }
 
-// ListAllActivityLogs retrieves activity logs for all users (admin) with pagination and filtering.
-func (s *QueryService) ListAllActivityLogs(req dto.ActivityLogListRequest) (*dto.ActivityLogListResponse, *errors.AppError) {
+// ListAllActivityLogs retrieves activity logs for all users (admin), sorted and filtered by lq
+// and paginated by the page and limit of req.
+func (s *QueryService) ListAllActivityLogs(req dto.ActivityLogListRequest, lq pagination.ListQuery) (*dto.ActivityLogListResponse, *errors.AppError) {
 	if req.Page <= 0 {
 		req.Page = 1
 	}
@@ -109,7 +112,8 @@ func (s *QueryService) ListAllActivityLogs(req dto.ActivityLogListRequest) (*dto
 		return nil, appErr
 	}
 
-	logs, totalCount, err := s.Repo.ListAllActivityLogs(req.Page, req.Limit, req.EventType, startDate, endDate)
+	lq.Page, lq.PageSize = req.Page, req.Limit
+	logs, totalCount, err := s.Repo.ListAllActivityLogs(lq, strings.TrimSpace(req.Q), startDate, endDate)
 	if err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to retrieve activity logs")
 	}
@@ -135,6 +139,59 @@ func (s *QueryService) ListAllActivityLogs(req dto.ActivityLogListRequest) (*dto
 	}, nil
 }
 
+// ListUserActivityLogsCursor retrieves activity logs for a specific user using keyset pagination.
+func (s *QueryService) ListUserActivityLogsCursor(userID uuid.UUID, req dto.ActivityLogListRequest) (*dto.ActivityLogCursorListResponse, *errors.AppError) {
+	lq := activityLogListSpec.Query(1, 0)
+	if req.EventType != "" {
+		lq = lq.WithFilter("event_type", req.EventType)
+	}
+	return s.listActivityLogsCursor(&userID, req, lq)
+}
+
+// ListAllActivityLogsCursor retrieves activity logs for all users (admin) filtered by lq using
+// keyset pagination.
+func (s *QueryService) ListAllActivityLogsCursor(req dto.ActivityLogListRequest, lq pagination.ListQuery) (*dto.ActivityLogCursorListResponse, *errors.AppError) {
+	return s.listActivityLogsCursor(nil, req, lq)
+}
+
+func (s *QueryService) listActivityLogsCursor(userID *uuid.UUID, req dto.ActivityLogListRequest, lq pagination.ListQuery) (*dto.ActivityLogCursorListResponse, *errors.AppError) {
+	// Ranked results have no stable keyset order
+	if strings.TrimSpace(req.Q) != "" {
+		return nil, errors.NewAppError(errors.ErrBadRequest, "q cannot be combined with cursor pagination")
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	cursor, err := pagination.Decode(req.Cursor)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrBadRequest, "Invalid cursor")
+	}
+
+	startDate, endDate, appErr := parseDateFilters(req.StartDate, req.EndDate)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	logs, page, err := s.Repo.ListActivityLogsKeyset(userID, req.Limit, lq, startDate, endDate, cursor)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to retrieve activity logs")
+	}
+
+	responseData := make([]dto.ActivityLogResponse, len(logs))
+	for i, log := range logs {
+		responseData[i] = s.convertToResponse(log)
+	}
+
+	return &dto.ActivityLogCursorListResponse{
+		Data:       responseData,
+		Pagination: page,
+	}, nil
+}
+
 // GetActivityLogByID retrieves a specific activity log by ID.
 func (s *QueryService) GetActivityLogByID(id uuid.UUID, requestingUserID uuid.UUID) (*dto.ActivityLogResponse, *errors.AppError) {
 	log, err := s.Repo.GetActivityLogByID(id)
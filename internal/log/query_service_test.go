@@ -0,0 +1,21 @@
+package log
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/google/uuid"
+)
+
+func TestCursorListingRejectsQ(t *testing.T) {
+	s := NewQueryService(nil) // rejected before the repository is queried
+	req := dto.ActivityLogListRequest{Pagination: "cursor", Q: "login"}
+
+	if _, appErr := s.ListAllActivityLogsCursor(req, activityLogListSpec.Query(1, 0)); appErr == nil || appErr.Code != http.StatusBadRequest {
+		t.Errorf("all logs: got %v, want 400", appErr)
+	}
+	if _, appErr := s.ListUserActivityLogsCursor(uuid.New(), req); appErr == nil || appErr.Code != http.StatusBadRequest {
+		t.Errorf("user logs: got %v, want 400", appErr)
+	}
+}
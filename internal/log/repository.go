@@ -3,9 +3,12 @@ package log
 import (
 	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/search"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -53,19 +56,63 @@ func (r *Repository) ListUserActivityLogs(userID uuid.UUID, page, limit int, eve
 	return logs, totalCount, nil
 }
 
-// ListAllActivityLogs retrieves activity logs for all users (admin functionality) with pagination and filtering
-func (r *Repository) ListAllActivityLogs(page, limit int, eventType string, startDate, endDate *time.Time) ([]models.ActivityLog, int64, error) {
+// ListUserActivityLogsByTypes retrieves a user's activity logs restricted to the given event types
+func (r *Repository) ListUserActivityLogsByTypes(userID uuid.UUID, eventTypes []string, page, limit int, startDate, endDate *time.Time) ([]models.ActivityLog, int64, error) {
 	var logs []models.ActivityLog
 	var totalCount int64
 
-	// Build the base query
-	query := r.DB.Model(&models.ActivityLog{})
+	query := r.DB.Model(&models.ActivityLog{}).Where("user_id = ? AND event_type IN ?", userID, eventTypes)
+	if startDate != nil {
+		query = query.Where("timestamp >= ?", startDate)
+	}
+	if endDate != nil {
+		endOfDay := endDate.Add(24 * time.Hour)
+		query = query.Where("timestamp < ?", endOfDay)
+	}
 
-	// Apply event type filter if provided
-	if eventType != "" {
-		query = query.Where("event_type = ?", eventType)
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("timestamp DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
 	}
 
+	return logs, totalCount, nil
+}
+
+// activityLogListSpec is the sorting and filtering of the admin activity log
+// list. Cursor pages are always in its default order.
+var activityLogListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"timestamp":  "timestamp",
+		"event_type": "event_type",
+		"severity":   "severity",
+	},
+	DefaultSort:  "timestamp",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"app_id":     {Column: "app_id", Kind: pagination.FilterUUID},
+		"user_id":    {Column: "user_id", Kind: pagination.FilterUUID},
+		"event_type": {Column: "event_type", Kind: pagination.FilterExact},
+		"severity":   {Column: "severity", Kind: pagination.FilterExact},
+		"ip_address": {Column: "ip_address", Kind: pagination.FilterExact},
+		"is_anomaly": {Column: "is_anomaly", Kind: pagination.FilterBool},
+	},
+}
+
+// ListAllActivityLogs retrieves a page of activity logs for all users (admin functionality),
+// sorted and filtered by lq. A non-empty q restricts the result to full-text matches, most
+// relevant first.
+func (r *Repository) ListAllActivityLogs(lq pagination.ListQuery, q string, startDate, endDate *time.Time) ([]models.ActivityLog, int64, error) {
+	var logs []models.ActivityLog
+	var totalCount int64
+
+	// Build the base query
+	query := lq.ApplyFilters(r.DB.Model(&models.ActivityLog{}))
+
 	// Apply date range filters if provided
 	if startDate != nil {
 		query = query.Where("timestamp >= ?", startDate)
@@ -76,20 +123,56 @@ func (r *Repository) ListAllActivityLogs(page, limit int, eventType string, star
 		query = query.Where("timestamp < ?", endOfDay)
 	}
 
+	// Apply full-text search if provided
+	tsq := search.Query(q)
+	if tsq != "" {
+		query = query.Where(search.ActivityLogDocument+" @@ to_tsquery('simple', ?)", tsq)
+	}
+
 	// Get total count for pagination
 	if err := query.Count(&totalCount).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Apply pagination and ordering
-	offset := (page - 1) * limit
-	if err := query.Order("timestamp DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+	// Apply pagination and ordering; the sort breaks ties between equally relevant matches
+	if tsq != "" {
+		query = query.Order(clause.Expr{SQL: "ts_rank(" + search.ActivityLogDocument + ", to_tsquery('simple', ?)) DESC", Vars: []interface{}{tsq}})
+	}
+	if err := lq.ApplyPage(query).Find(&logs).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return logs, totalCount, nil
 }
 
+// ListActivityLogsKeyset retrieves one page of activity logs, newest first, using keyset
+// pagination. userID restricts the result to a single user when non-nil, and the filters
+// of lq apply; a nil cursor returns the first page.
+func (r *Repository) ListActivityLogsKeyset(userID *uuid.UUID, limit int, lq pagination.ListQuery, startDate, endDate *time.Time, cursor *pagination.Cursor) ([]models.ActivityLog, pagination.Page, error) {
+	var logs []models.ActivityLog
+
+	query := lq.ApplyFilters(r.DB.Model(&models.ActivityLog{}))
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if startDate != nil {
+		query = query.Where("timestamp >= ?", startDate)
+	}
+	if endDate != nil {
+		endOfDay := endDate.Add(24 * time.Hour)
+		query = query.Where("timestamp < ?", endOfDay)
+	}
+
+	if err := pagination.Apply(query, "timestamp", "id", cursor, limit).Find(&logs).Error; err != nil {
+		return nil, pagination.Page{}, err
+	}
+
+	logs, page := pagination.Finish(logs, cursor, limit, func(l models.ActivityLog) (time.Time, uuid.UUID) {
+		return l.Timestamp, l.ID
+	})
+	return logs, page, nil
+}
+
 // GetActivityLogByID retrieves a specific activity log by ID
 func (r *Repository) GetActivityLogByID(id uuid.UUID) (*models.ActivityLog, error) {
 	var log models.ActivityLog
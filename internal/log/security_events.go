@@ -0,0 +1,103 @@
+package log
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// securityEventMessagePrefix prefixes the translation keys of security events,
+// e.g. "security_event.password_change".
+const securityEventMessagePrefix = "security_event."
+
+// ListUserSecurityEvents returns the user's security timeline: the activity logs
+// of user-visible event types (config.UserVisibleEventTypes), newest first.
+func (s *QueryService) ListUserSecurityEvents(userID uuid.UUID, req dto.SecurityEventListRequest) (*dto.SecurityEventListResponse, *errors.AppError) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	eventTypes := config.UserVisibleEventTypes()
+	if req.EventType != "" {
+		if def, ok := config.GetEventDefinition(req.EventType); !ok || !def.UserVisible {
+			return nil, errors.NewAppError(errors.ErrBadRequest, "Unsupported event_type for security events")
+		}
+		eventTypes = []string{req.EventType}
+	}
+
+	startDate, endDate, appErr := parseDateFilters(req.StartDate, req.EndDate)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	logs, totalCount, err := s.Repo.ListUserActivityLogsByTypes(userID, eventTypes, req.Page, req.Limit, startDate, endDate)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to retrieve security events")
+	}
+
+	responseData := make([]dto.SecurityEventResponse, len(logs))
+	for i, log := range logs {
+		responseData[i] = toSecurityEvent(log)
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(req.Limit)))
+	return &dto.SecurityEventListResponse{
+		Data: responseData,
+		Pagination: dto.PaginationResponse{
+			Page:         req.Page,
+			Limit:        req.Limit,
+			TotalRecords: totalCount,
+			TotalPages:   totalPages,
+			HasNext:      req.Page < totalPages,
+			HasPrevious:  req.Page > 1,
+		},
+	}, nil
+}
+
+// toSecurityEvent converts an activity log into a security timeline entry. The
+// raw details are not exposed; only the anomaly reasons recorded by the anomaly
+// detector are turned into the new_device / new_location flags.
+func toSecurityEvent(log models.ActivityLog) dto.SecurityEventResponse {
+	event := dto.SecurityEventResponse{
+		ID:          log.ID.String(),
+		EventType:   log.EventType,
+		MessageKey:  securityEventMessagePrefix + strings.ToLower(log.EventType),
+		Description: DescribeEvent(log.EventType),
+		Timestamp:   log.Timestamp.Format(time.RFC3339),
+		IPAddress:   log.IPAddress,
+		UserAgent:   log.UserAgent,
+	}
+	if def, ok := config.GetEventDefinition(log.EventType); ok {
+		event.Category = string(def.Category)
+	}
+
+	if log.IsAnomaly && len(log.Details) > 0 {
+		var details struct {
+			AnomalyReasons []string `json:"anomaly_reasons"`
+		}
+		if err := json.Unmarshal(log.Details, &details); err == nil {
+			for _, reason := range details.AnomalyReasons {
+				switch reason {
+				case "new_ip_address", "new_user_agent":
+					event.NewDevice = true
+				case "new_geographic_location":
+					event.NewLocation = true
+				}
+			}
+		}
+	}
+	return event
+}
@@ -35,6 +35,7 @@ const (
 	EventRecoveryCodeUsed      = "RECOVERY_CODE_USED"
 	EventRecoveryCodeGen       = "RECOVERY_CODE_GENERATE"
 	EventEmailVerifyResend     = "EMAIL_VERIFY_RESEND"
+	EventEmailSent             = "EMAIL_SENT"
 	EventPasskeyRegister       = "PASSKEY_REGISTER"
 	EventPasskeyDelete         = "PASSKEY_DELETE"
 	EventPasskeyLogin          = "PASSKEY_LOGIN"
@@ -42,11 +43,21 @@ const (
 	EventMagicLinkLogin        = "MAGIC_LINK_LOGIN"
 	EventMagicLinkFailed       = "MAGIC_LINK_FAILED"
 	EventOIDCLogin             = "OIDC_LOGIN"
+	EventOIDCTokenExchange     = "OIDC_TOKEN_EXCHANGE"
+	EventOIDCGrantRevoked      = "OIDC_GRANT_REVOKED"
 	EventLoginFailed           = "LOGIN_FAILED"
 	EventBruteForceDetected    = "BRUTE_FORCE_DETECTED"
 	EventIPBlocked             = "IP_BLOCKED"
 	EventAccountLocked         = "ACCOUNT_LOCKED"
 	EventAccountUnlocked       = "ACCOUNT_UNLOCKED"
+	EventReauth                = "REAUTH"
+	EventReauthFailed          = "REAUTH_FAILED"
+	EventRegistrationScreened  = "REGISTRATION_SCREENED"
+	EventRegistrationApproved  = "REGISTRATION_APPROVED"
+	EventRegistrationRejected  = "REGISTRATION_REJECTED"
+	EventEmailPreview          = "EMAIL_PREVIEW"
+	EventUserImpersonated      = "USER_IMPERSONATED"
+	EventLinkReplayed          = "LINK_REPLAYED"
 )
 
 // AnomalyCallback is invoked asynchronously after an anomaly is detected and logged.
@@ -54,6 +65,14 @@ const (
 // wire it to the email service for sending notifications without creating an import cycle.
 type AnomalyCallback func(appID, userID uuid.UUID, email string, result AnomalyResult)
 
+// AnomalyObserver is invoked for every detected anomaly, whether or not the user
+// is notified. It is used to raise admin notifications on anomaly spikes.
+type AnomalyObserver func(appID uuid.UUID, result AnomalyResult)
+
+// ActivityObserver is invoked for every activity log written to the database.
+// It is used to stream live events to the admin dashboard.
+type ActivityObserver func(activityLog models.ActivityLog)
+
 // LogEntry represents a log entry to be processed
 type LogEntry struct {
 	AppID     uuid.UUID
@@ -69,12 +88,14 @@ type LogEntry struct {
 
 // Service handles asynchronous activity logging
 type Service struct {
-	db              *gorm.DB
-	logChannel      chan LogEntry
-	ctx             context.Context
-	cancel          context.CancelFunc
-	anomalyDetector *AnomalyDetector
-	anomalyCallback AnomalyCallback
+	db               *gorm.DB
+	logChannel       chan LogEntry
+	ctx              context.Context
+	cancel           context.CancelFunc
+	anomalyDetector  *AnomalyDetector
+	anomalyCallback  AnomalyCallback
+	anomalyObserver  AnomalyObserver
+	activityObserver ActivityObserver
 }
 
 var serviceInstance *Service
@@ -118,8 +139,20 @@ func (s *Service) SetAnomalyCallback(cb AnomalyCallback) {
 	s.anomalyCallback = cb
 }
 
+// SetAnomalyObserver sets the function invoked for every detected anomaly.
+func (s *Service) SetAnomalyObserver(obs AnomalyObserver) {
+	s.anomalyObserver = obs
+}
+
+// SetActivityObserver sets the function invoked for every written activity log.
+func (s *Service) SetActivityObserver(obs ActivityObserver) {
+	s.activityObserver = obs
+}
+
 // LogActivity logs a user activity asynchronously with smart filtering
 func (s *Service) LogActivity(appID, userID uuid.UUID, eventType, ipAddress, userAgent string, details map[string]interface{}) {
+	checkEventType(eventType)
+
 	// Get logging configuration
 	cfg := config.GetLoggingConfig()
 
@@ -178,6 +211,9 @@ func (s *Service) LogActivity(appID, userID uuid.UUID, eventType, ipAddress, use
 			}
 			if isAnomaly {
 				details["anomaly_reasons"] = result.Reasons
+				if s.anomalyObserver != nil {
+					go s.anomalyObserver(appID, result)
+				}
 			}
 		}
 	}
@@ -208,6 +244,7 @@ func (s *Service) LogActivity(appID, userID uuid.UUID, eventType, ipAddress, use
 // This is used by login handlers that run anomaly detection themselves and want
 // to trigger notification callbacks.
 func (s *Service) LogActivityWithAnomalyResult(appID, userID uuid.UUID, email, eventType, ipAddress, userAgent string, details map[string]interface{}, anomalyResult *AnomalyResult) {
+	checkEventType(eventType)
 	if details == nil {
 		details = make(map[string]interface{})
 	}
@@ -240,6 +277,11 @@ func (s *Service) LogActivityWithAnomalyResult(appID, userID uuid.UUID, email, e
 		log.Printf("Warning: Activity log channel is full, dropping log entry for user %s, event %s", userID, eventType)
 	}
 
+	// Report every anomaly to the observer (admin spike notifications)
+	if isAnomaly && s.anomalyObserver != nil {
+		go s.anomalyObserver(appID, *anomalyResult)
+	}
+
 	// Fire anomaly callback if applicable
 	if anomalyResult != nil && anomalyResult.NotifyUser && s.anomalyCallback != nil {
 		// Run callback asynchronously so it doesn't block the caller
@@ -288,7 +330,7 @@ func (s *Service) processLogEntry(entry LogEntry) {
 	// Get logging configuration for severity and retention
 	cfg := config.GetLoggingConfig()
 	cfgSeverity := cfg.GetEventSeverity(entry.EventType)
-	retentionDays := cfg.GetRetentionDays(cfgSeverity)
+	retentionDays := cfg.GetEventRetentionDays(entry.EventType)
 
 	// Calculate expiration time
 	expiresAt := entry.Timestamp.AddDate(0, 0, retentionDays)
@@ -320,6 +362,9 @@ func (s *Service) processLogEntry(entry LogEntry) {
 		err := s.db.Create(&activityLog).Error
 		if err == nil {
 			// Successfully logged
+			if s.activityObserver != nil {
+				s.activityObserver(activityLog)
+			}
 			return
 		}
 
@@ -401,8 +446,8 @@ func LogIPBlocked(appID uuid.UUID, ipAddress, userAgent string, details map[stri
 }
 
 // LogLogout logs a logout event
-func LogLogout(appID, userID uuid.UUID, ipAddress, userAgent string) {
-	GetLogService().LogActivity(appID, userID, EventLogout, ipAddress, userAgent, nil)
+func LogLogout(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
+	GetLogService().LogActivity(appID, userID, EventLogout, ipAddress, userAgent, details)
 }
 
 // LogRegister logs a user registration event
@@ -419,8 +464,8 @@ func LogPasswordChange(appID, userID uuid.UUID, ipAddress, userAgent string) {
 }
 
 // LogPasswordReset logs a password reset event
-func LogPasswordReset(appID, userID uuid.UUID, ipAddress, userAgent string) {
-	GetLogService().LogActivity(appID, userID, EventPasswordReset, ipAddress, userAgent, nil)
+func LogPasswordReset(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
+	GetLogService().LogActivity(appID, userID, EventPasswordReset, ipAddress, userAgent, details)
 }
 
 // LogEmailVerify logs an email verification event
@@ -433,6 +478,18 @@ func LogEmailVerifyResend(appID, userID uuid.UUID, ipAddress, userAgent string)
 	GetLogService().LogActivity(appID, userID, EventEmailVerifyResend, ipAddress, userAgent, nil)
 }
 
+// LogEmailSent logs an app email sent to a user. Its signature matches
+// email.UserSentCallback.
+func LogEmailSent(appID, userID uuid.UUID, emailTypeCode, variant string) {
+	details := map[string]interface{}{
+		"email_type": emailTypeCode,
+	}
+	if variant != "" {
+		details["variant"] = variant
+	}
+	GetLogService().LogActivity(appID, userID, EventEmailSent, "", "", details)
+}
+
 // Log2FAEnable logs a 2FA enable event
 func Log2FAEnable(appID, userID uuid.UUID, ipAddress, userAgent string) {
 	GetLogService().LogActivity(appID, userID, Event2FAEnable, ipAddress, userAgent, nil)
@@ -465,8 +522,8 @@ func LogSocialLogin(appID, userID uuid.UUID, ipAddress, userAgent string, provid
 }
 
 // LogProfileAccess logs profile access (optional, for high-security environments)
-func LogProfileAccess(appID, userID uuid.UUID, ipAddress, userAgent string) {
-	GetLogService().LogActivity(appID, userID, EventProfileAccess, ipAddress, userAgent, nil)
+func LogProfileAccess(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
+	GetLogService().LogActivity(appID, userID, EventProfileAccess, ipAddress, userAgent, details)
 }
 
 // LogRecoveryCodeUsed logs when a recovery code is used
@@ -554,6 +611,71 @@ func LogOIDCLogin(appID, userID uuid.UUID, ipAddress, userAgent string, clientID
 	GetLogService().LogActivity(appID, userID, EventOIDCLogin, ipAddress, userAgent, details)
 }
 
+// LogOIDCTokenExchange logs a token exchange (RFC 8693) performed by an OIDC client on behalf of a user
+func LogOIDCTokenExchange(appID, userID uuid.UUID, ipAddress, userAgent string, clientID, audience, scope string) {
+	details := map[string]interface{}{
+		"client_id": clientID,
+		"audience":  audience,
+		"scope":     scope,
+	}
+	GetLogService().LogActivity(appID, userID, EventOIDCTokenExchange, ipAddress, userAgent, details)
+}
+
+// LogOIDCGrantRevoked logs a user revoking an OIDC client's access to their account
+func LogOIDCGrantRevoked(appID, userID uuid.UUID, ipAddress, userAgent string, clientID string, details map[string]interface{}) {
+	if details == nil {
+		details = make(map[string]interface{})
+	}
+	details["client_id"] = clientID
+	GetLogService().LogActivity(appID, userID, EventOIDCGrantRevoked, ipAddress, userAgent, details)
+}
+
+// LogReauth logs a passed re-authentication challenge
+func LogReauth(appID, userID uuid.UUID, ipAddress, userAgent string, method, purpose string) {
+	details := map[string]interface{}{
+		"method":  method,
+		"purpose": purpose,
+	}
+	GetLogService().LogActivity(appID, userID, EventReauth, ipAddress, userAgent, details)
+}
+
+// LogReauthFailed logs a failed answer to a re-authentication challenge
+func LogReauthFailed(appID, userID uuid.UUID, ipAddress, userAgent string, method, purpose string) {
+	details := map[string]interface{}{
+		"method":  method,
+		"purpose": purpose,
+	}
+	GetLogService().LogActivity(appID, userID, EventReauthFailed, ipAddress, userAgent, details)
+}
+
+// LogRegistrationScreened logs the bot detection decision (allow, challenge or deny)
+// for a registration attempt. userID is uuid.Nil unless the registration went through.
+func LogRegistrationScreened(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
+	GetLogService().LogActivity(appID, userID, EventRegistrationScreened, ipAddress, userAgent, details)
+}
+
+// LogRegistrationReviewed logs an admin's approval or rejection of a
+// registration held for approval
+func LogRegistrationReviewed(appID, userID uuid.UUID, approved bool, details map[string]interface{}) {
+	eventType := EventRegistrationRejected
+	if approved {
+		eventType = EventRegistrationApproved
+	}
+	GetLogService().LogActivity(appID, userID, eventType, "", "", details)
+}
+
+// LogEmailPreview logs that an admin rendered an email template preview with
+// the user's real profile data
+func LogEmailPreview(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
+	GetLogService().LogActivity(appID, userID, EventEmailPreview, ipAddress, userAgent, details)
+}
+
+// LogUserImpersonated logs that an application backend issued an
+// impersonation token for the user
+func LogUserImpersonated(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
+	GetLogService().LogActivity(appID, userID, EventUserImpersonated, ipAddress, userAgent, details)
+}
+
 // LogAccountLocked logs when a user account is locked due to repeated failed login attempts
 func LogAccountLocked(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
 	GetLogService().LogActivity(appID, userID, EventAccountLocked, ipAddress, userAgent, details)
@@ -563,3 +685,9 @@ func LogAccountLocked(appID, userID uuid.UUID, ipAddress, userAgent string, deta
 func LogAccountUnlocked(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
 	GetLogService().LogActivity(appID, userID, EventAccountUnlocked, ipAddress, userAgent, details)
 }
+
+// LogLinkReplayed logs that a password reset or email verification link was
+// opened again after it was used or revoked
+func LogLinkReplayed(appID, userID uuid.UUID, ipAddress, userAgent string, details map[string]interface{}) {
+	GetLogService().LogActivity(appID, userID, EventLinkReplayed, ipAddress, userAgent, details)
+}
@@ -0,0 +1,339 @@
+// Package metricshistory keeps a rolling history of key metrics in the
+// database, so the admin dashboard can chart them for operators who do not
+// run Prometheus.
+//
+// Every instance samples its request and auth counters once a minute and adds
+// the increase to the minute bucket of the metric_samples table; gauges
+// (active sessions, users) are recorded once a minute by a scheduled job. An
+// hourly job downsamples minute buckets into hourly ones, keeps minute buckets
+// for MinuteRetention and hourly ones for METRICS_HISTORY_RETENTION_DAYS.
+package metricshistory
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/health"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Bucket sizes in seconds (models.MetricSample.Resolution)
+const (
+	ResolutionMinute = 60
+	ResolutionHour   = 3600
+)
+
+const (
+	// MinuteRetention is how long minute buckets are kept.
+	MinuteRetention = 48 * time.Hour
+
+	sampleInterval = time.Minute
+)
+
+// Metric kinds
+const (
+	KindCounter = "counter" // Buckets hold the increase; downsampled by sum
+	KindGauge   = "gauge"   // Buckets hold the value; downsampled by average
+)
+
+// Metric describes a recorded metric.
+type Metric struct {
+	Name  string
+	Label string
+	Kind  string
+}
+
+// Metrics are the recorded metrics, in chart order.
+var Metrics = []Metric{
+	{Name: "http_requests", Label: "HTTP requests", Kind: KindCounter},
+	{Name: "http_errors", Label: "HTTP 5xx responses", Kind: KindCounter},
+	{Name: "logins", Label: "Successful logins", Kind: KindCounter},
+	{Name: "login_failures", Label: "Failed logins", Kind: KindCounter},
+	{Name: "registrations", Label: "Registrations", Kind: KindCounter},
+	{Name: "logouts", Label: "Logouts", Kind: KindCounter},
+	{Name: "active_sessions", Label: "Active sessions", Kind: KindGauge},
+	{Name: "users", Label: "Users", Kind: KindGauge},
+}
+
+// LookupMetric returns the metric named name.
+func LookupMetric(name string) (Metric, bool) {
+	for _, m := range Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+// kindOf returns the kind of the metric named name; unknown metrics are
+// treated as counters.
+func kindOf(name string) string {
+	if m, ok := LookupMetric(name); ok {
+		return m.Kind
+	}
+	return KindCounter
+}
+
+// counterValues maps the process counters to their metric names.
+func counterValues(t health.CounterTotals) map[string]float64 {
+	return map[string]float64{
+		"http_requests":  t.Requests,
+		"http_errors":    t.ServerErrors,
+		"logins":         t.LoginSuccess,
+		"login_failures": t.LoginFailure,
+		"registrations":  t.Registrations,
+		"logouts":        t.Logouts,
+	}
+}
+
+// GaugeFunc returns the current values of the gauge metrics, by name.
+type GaugeFunc func(ctx context.Context) (map[string]float64, error)
+
+// Service records and reads the metrics history.
+type Service struct {
+	db       *gorm.DB
+	gauges   GaugeFunc
+	counters func() health.CounterTotals
+
+	mu   sync.Mutex // Serializes samples
+	prev map[string]float64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates the service; Start begins sampling the counters of this
+// instance. gauges may be nil when no gauges are recorded.
+func NewService(db *gorm.DB, gauges GaugeFunc) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		db:       db,
+		gauges:   gauges,
+		counters: health.GetCounterTotals,
+		prev:     map[string]float64{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start samples the counters of this instance every minute until Shutdown.
+func (s *Service) Start() {
+	s.wg.Add(1)
+	go s.worker()
+	log.Printf("Metrics history enabled (retention %d days)", retentionDays())
+}
+
+// Shutdown stops sampling, recording what was counted since the last sample.
+func (s *Service) Shutdown() {
+	s.cancel()
+	s.wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.sampleCounters(ctx, time.Now()); err != nil {
+		log.Printf("Warning: Failed to record final metrics sample: %v", err)
+	}
+}
+
+func (s *Service) worker() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.sampleCounters(s.ctx, now); err != nil {
+				log.Printf("Warning: Failed to record metrics sample: %v", err)
+			}
+		}
+	}
+}
+
+// sampleCounters adds the increase of each counter since the previous sample
+// to the minute bucket of now. Instances add to the same buckets, so the
+// history covers the whole cluster. The increase is kept for the next sample
+// when it cannot be stored.
+func (s *Service) sampleCounters(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := counterValues(s.counters())
+	deltas := counterDeltas(s.prev, current)
+	bucket := now.UTC().Truncate(time.Minute)
+	samples := make([]models.MetricSample, 0, len(deltas))
+	for _, m := range Metrics {
+		if d, ok := deltas[m.Name]; ok {
+			samples = append(samples, models.MetricSample{Metric: m.Name, Resolution: ResolutionMinute, BucketStart: bucket, Value: d})
+		}
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "metric"}, {Name: "resolution"}, {Name: "bucket_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"value": gorm.Expr("metric_samples.value + excluded.value"),
+		}),
+	}).Create(&samples).Error
+	if err != nil {
+		return err
+	}
+	s.prev = current
+	return nil
+}
+
+// counterDeltas returns the increase of each counter from prev to current. A
+// counter lower than before was reset, so all of its current value is new.
+func counterDeltas(prev, current map[string]float64) map[string]float64 {
+	deltas := make(map[string]float64, len(current))
+	for name, v := range current {
+		d := v - prev[name]
+		if d < 0 {
+			d = v
+		}
+		deltas[name] = d
+	}
+	return deltas
+}
+
+// RecordGauges stores the current gauge values in the minute bucket. Its
+// signature matches scheduler.JobFunc.
+func (s *Service) RecordGauges(ctx context.Context) error {
+	if s.gauges == nil {
+		return nil
+	}
+	values, err := s.gauges(ctx)
+	if err != nil {
+		return err
+	}
+	bucket := time.Now().UTC().Truncate(time.Minute)
+	samples := make([]models.MetricSample, 0, len(values))
+	for _, m := range Metrics {
+		if v, ok := values[m.Name]; ok && m.Kind == KindGauge {
+			samples = append(samples, models.MetricSample{Metric: m.Name, Resolution: ResolutionMinute, BucketStart: bucket, Value: v})
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "metric"}, {Name: "resolution"}, {Name: "bucket_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+	}).Create(&samples).Error
+}
+
+// RunRollup downsamples the minute buckets of every completed hour since the
+// last hourly bucket (at most MinuteRetention back) into hourly buckets, then
+// deletes minute buckets older than MinuteRetention and hourly buckets older
+// than METRICS_HISTORY_RETENTION_DAYS (default 30). Its signature matches
+// scheduler.JobFunc.
+func (s *Service) RunRollup(ctx context.Context) error {
+	db := s.db.WithContext(ctx)
+	now := time.Now().UTC()
+	end := now.Truncate(time.Hour)
+	from := now.Add(-MinuteRetention).Truncate(time.Hour)
+
+	// The last hourly bucket is rolled up again: minute samples of other
+	// instances may have arrived after it was
+	var last models.MetricSample
+	err := db.Where("resolution = ?", ResolutionHour).Order("bucket_start DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return err
+	}
+	if last.BucketStart.After(from) {
+		from = last.BucketStart.UTC()
+	}
+
+	if from.Before(end) {
+		var minutes []models.MetricSample
+		if err := db.Where("resolution = ? AND bucket_start >= ? AND bucket_start < ?", ResolutionMinute, from, end).
+			Find(&minutes).Error; err != nil {
+			return err
+		}
+		if hours := downsample(minutes, time.Hour); len(hours) > 0 {
+			if err := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "metric"}, {Name: "resolution"}, {Name: "bucket_start"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value"}),
+			}).CreateInBatches(&hours, 500).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	days := retentionDays()
+	if err := db.Where("resolution = ? AND bucket_start < ?", ResolutionMinute, now.Add(-MinuteRetention)).
+		Delete(&models.MetricSample{}).Error; err != nil {
+		return err
+	}
+	res := db.Where("resolution = ? AND bucket_start < ?", ResolutionHour, now.AddDate(0, 0, -days)).
+		Delete(&models.MetricSample{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("Metrics history: deleted %d hourly sample(s) older than %d days", res.RowsAffected, days)
+	}
+	return nil
+}
+
+// downsample merges samples into buckets of size step: counters are summed,
+// gauges averaged. The result is ordered by metric and bucket.
+func downsample(samples []models.MetricSample, step time.Duration) []models.MetricSample {
+	type key struct {
+		metric string
+		bucket int64
+	}
+	type acc struct {
+		sum float64
+		n   int
+	}
+	buckets := make(map[key]*acc)
+	var keys []key
+	for _, sm := range samples {
+		k := key{sm.Metric, sm.BucketStart.UTC().Truncate(step).Unix()}
+		a := buckets[k]
+		if a == nil {
+			a = &acc{}
+			buckets[k] = a
+			keys = append(keys, k)
+		}
+		a.sum += sm.Value
+		a.n++
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].metric != keys[j].metric {
+			return keys[i].metric < keys[j].metric
+		}
+		return keys[i].bucket < keys[j].bucket
+	})
+
+	out := make([]models.MetricSample, 0, len(keys))
+	for _, k := range keys {
+		a := buckets[k]
+		v := a.sum
+		if kindOf(k.metric) == KindGauge {
+			v /= float64(a.n)
+		}
+		out = append(out, models.MetricSample{
+			Metric:      k.metric,
+			Resolution:  int(step / time.Second),
+			BucketStart: time.Unix(k.bucket, 0).UTC(),
+			Value:       v,
+		})
+	}
+	return out
+}
+
+// retentionDays returns METRICS_HISTORY_RETENTION_DAYS (default 30).
+func retentionDays() int {
+	days := viper.GetInt("METRICS_HISTORY_RETENTION_DAYS")
+	if days <= 0 {
+		days = 30
+	}
+	return days
+}
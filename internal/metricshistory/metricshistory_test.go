@@ -0,0 +1,109 @@
+package metricshistory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestCounterDeltas(t *testing.T) {
+	deltas := counterDeltas(
+		map[string]float64{"http_requests": 100, "logins": 10},
+		map[string]float64{"http_requests": 150, "logins": 4, "logouts": 2},
+	)
+	want := map[string]float64{
+		"http_requests": 50,
+		"logins":        4, // Reset: the whole value is new
+		"logouts":       2, // First sample
+	}
+	for name, w := range want {
+		if deltas[name] != w {
+			t.Errorf("delta of %s = %v, want %v", name, deltas[name], w)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	h := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	samples := []models.MetricSample{
+		{Metric: "http_requests", Resolution: ResolutionMinute, BucketStart: h, Value: 5},
+		{Metric: "http_requests", Resolution: ResolutionMinute, BucketStart: h.Add(30 * time.Minute), Value: 7},
+		{Metric: "http_requests", Resolution: ResolutionMinute, BucketStart: h.Add(time.Hour), Value: 1},
+		{Metric: "active_sessions", Resolution: ResolutionMinute, BucketStart: h.Add(time.Minute), Value: 10},
+		{Metric: "active_sessions", Resolution: ResolutionMinute, BucketStart: h.Add(2 * time.Minute), Value: 20},
+	}
+	got := downsample(samples, time.Hour)
+	want := []models.MetricSample{
+		{Metric: "active_sessions", Resolution: ResolutionHour, BucketStart: h, Value: 15},
+		{Metric: "http_requests", Resolution: ResolutionHour, BucketStart: h, Value: 12},
+		{Metric: "http_requests", Resolution: ResolutionHour, BucketStart: h.Add(time.Hour), Value: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d samples, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Metric != want[i].Metric || got[i].Resolution != want[i].Resolution ||
+			!got[i].BucketStart.Equal(want[i].BucketStart) || got[i].Value != want[i].Value {
+			t.Errorf("sample %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFillSeries(t *testing.T) {
+	start := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	vals := fillSeries(start, time.Minute, 3, []models.MetricSample{
+		{Metric: "logins", BucketStart: start, Value: 1},
+		{Metric: "logins", BucketStart: start.Add(2 * time.Minute), Value: 3},
+		{Metric: "logins", BucketStart: start.Add(-time.Minute), Value: 9},    // Before the range
+		{Metric: "logins", BucketStart: start.Add(3 * time.Minute), Value: 9}, // After the range
+	})["logins"]
+	if len(vals) != 3 {
+		t.Fatalf("got %d values, want 3", len(vals))
+	}
+	if vals[0] == nil || *vals[0] != 1 || vals[1] != nil || vals[2] == nil || *vals[2] != 3 {
+		t.Errorf("unexpected series: %v %v %v", vals[0], vals[1], vals[2])
+	}
+}
+
+func TestMergeMissing(t *testing.T) {
+	h := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	rows := mergeMissing(
+		[]models.MetricSample{{Metric: "logins", BucketStart: h, Value: 1}},
+		[]models.MetricSample{
+			{Metric: "logins", BucketStart: h, Value: 2},
+			{Metric: "logins", BucketStart: h.Add(time.Hour), Value: 3},
+		},
+	)
+	if len(rows) != 2 || rows[0].Value != 1 || rows[1].Value != 3 {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	if r, ok := ParseRange(""); !ok || r.Name != DefaultRange {
+		t.Errorf("ParseRange(\"\") = %+v, %v", r, ok)
+	}
+	if r, ok := ParseRange("6h"); !ok || r.Resolution != ResolutionMinute {
+		t.Errorf("ParseRange(6h) = %+v, %v", r, ok)
+	}
+	if r, ok := ParseRange("30d"); !ok || r.Resolution != ResolutionHour || int(r.Span/time.Hour) != 720 {
+		t.Errorf("ParseRange(30d) = %+v, %v", r, ok)
+	}
+	if _, ok := ParseRange("1y"); ok {
+		t.Error("ParseRange(1y) succeeded")
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	if m, err := ParseMetrics(""); err != nil || m != nil {
+		t.Errorf("ParseMetrics(\"\") = %v, %v; want all", m, err)
+	}
+	m, err := ParseMetrics("logins, http_errors")
+	if err != nil || len(m) != 2 || m[0].Name != "logins" || m[1].Kind != KindCounter {
+		t.Errorf("ParseMetrics = %+v, %v", m, err)
+	}
+	if _, err := ParseMetrics("logins,cpu"); err == nil {
+		t.Error("unknown metric accepted")
+	}
+}
@@ -0,0 +1,166 @@
+package metricshistory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// Range is a time range the history can be charted over.
+type Range struct {
+	Name       string
+	Span       time.Duration
+	Resolution int // Bucket size in seconds
+}
+
+// Ranges are the supported ranges, shortest first. Ranges up to 6 hours use
+// minute buckets, longer ones hourly buckets.
+var Ranges = []Range{
+	{Name: "1h", Span: time.Hour, Resolution: ResolutionMinute},
+	{Name: "6h", Span: 6 * time.Hour, Resolution: ResolutionMinute},
+	{Name: "24h", Span: 24 * time.Hour, Resolution: ResolutionHour},
+	{Name: "7d", Span: 7 * 24 * time.Hour, Resolution: ResolutionHour},
+	{Name: "30d", Span: 30 * 24 * time.Hour, Resolution: ResolutionHour},
+}
+
+// DefaultRange is the range charted when none is given.
+const DefaultRange = "24h"
+
+// ParseRange returns the range named name, DefaultRange for "".
+func ParseRange(name string) (Range, bool) {
+	if name == "" {
+		name = DefaultRange
+	}
+	for _, r := range Ranges {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Range{}, false
+}
+
+// ParseMetrics returns the metrics named in a comma-separated list, nil (all)
+// for "".
+func ParseMetrics(list string) ([]Metric, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	var metrics []Metric
+	for _, name := range strings.Split(list, ",") {
+		m, ok := LookupMetric(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown metric %q", strings.TrimSpace(name))
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// Series is the history of one metric. Values line up with
+// History.Timestamps; nil marks a bucket without data.
+type Series struct {
+	Metric Metric
+	Values []*float64
+}
+
+// History is the history of some metrics over a range.
+type History struct {
+	Range      Range
+	Timestamps []time.Time // Bucket starts, oldest first; the last bucket is still filling
+	Series     []Series
+}
+
+// Query returns the history of metrics (nil = all) over r, ending with the
+// current bucket. Hourly buckets not rolled up yet, including the current
+// one, are computed from the minute buckets.
+func (s *Service) Query(ctx context.Context, r Range, metrics []Metric) (*History, error) {
+	if metrics == nil {
+		metrics = Metrics
+	}
+	names := make([]string, len(metrics))
+	for i, m := range metrics {
+		names[i] = m.Name
+	}
+	step := time.Duration(r.Resolution) * time.Second
+	n := int(r.Span / step)
+	end := time.Now().UTC().Truncate(step)
+	start := end.Add(-time.Duration(n-1) * step)
+
+	db := s.db.WithContext(ctx)
+	var rows []models.MetricSample
+	if err := db.Where("metric IN ? AND resolution = ? AND bucket_start >= ?", names, r.Resolution, start).
+		Order("bucket_start").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if r.Resolution == ResolutionHour {
+		// The rollup runs a few minutes past the hour, so the previous hour
+		// may be missing as well as the current one
+		var minutes []models.MetricSample
+		if err := db.Where("metric IN ? AND resolution = ? AND bucket_start >= ?", names, ResolutionMinute, end.Add(-time.Hour)).
+			Find(&minutes).Error; err != nil {
+			return nil, err
+		}
+		rows = mergeMissing(rows, downsample(minutes, time.Hour))
+	}
+
+	h := &History{Range: r, Timestamps: make([]time.Time, n), Series: make([]Series, len(metrics))}
+	for i := range h.Timestamps {
+		h.Timestamps[i] = start.Add(time.Duration(i) * step)
+	}
+	values := fillSeries(start, step, n, rows)
+	for i, m := range metrics {
+		vals := values[m.Name]
+		if vals == nil {
+			vals = make([]*float64, n)
+		}
+		h.Series[i] = Series{Metric: m, Values: vals}
+	}
+	return h, nil
+}
+
+// mergeMissing returns rows plus the samples of extra whose metric and
+// bucket are not in rows.
+func mergeMissing(rows, extra []models.MetricSample) []models.MetricSample {
+	type key struct {
+		metric string
+		bucket int64
+	}
+	have := make(map[key]bool, len(rows))
+	for _, r := range rows {
+		have[key{r.Metric, r.BucketStart.Unix()}] = true
+	}
+	for _, e := range extra {
+		if !have[key{e.Metric, e.BucketStart.Unix()}] {
+			rows = append(rows, e)
+		}
+	}
+	return rows
+}
+
+// fillSeries places samples into n buckets of size step starting at start,
+// by metric. Buckets without a sample stay nil; samples outside the buckets
+// are ignored.
+func fillSeries(start time.Time, step time.Duration, n int, samples []models.MetricSample) map[string][]*float64 {
+	out := make(map[string][]*float64)
+	for _, sm := range samples {
+		offset := sm.BucketStart.Sub(start)
+		if offset < 0 || offset%step != 0 {
+			continue
+		}
+		i := int(offset / step)
+		if i >= n {
+			continue
+		}
+		vals := out[sm.Metric]
+		if vals == nil {
+			vals = make([]*float64, n)
+			out[sm.Metric] = vals
+		}
+		v := sm.Value
+		vals[i] = &v
+	}
+	return out
+}
@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/util"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// AdminAuditRecorder stores captured Admin API requests. Implemented by admin.Repository.
+type AdminAuditRecorder interface {
+	CreateAdminAuditLog(entry *models.AdminAuditLog) error
+}
+
+// adminAuditSkipKey is set by SkipAdminAudit on routes that must not be captured.
+const adminAuditSkipKey = "admin_audit_skip"
+
+// redactedValue replaces secrets in captured bodies and query strings.
+const redactedValue = "[REDACTED]"
+
+// SkipAdminAudit opts a single route out of AdminAuditMiddleware, e.g. large
+// exports or the audit log endpoints themselves.
+func SkipAdminAudit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(adminAuditSkipKey, true)
+		c.Next()
+	}
+}
+
+// AdminAuditMiddleware records every Admin API request with its response in
+// the admin audit log. Install it after AdminAuthMiddleware so the API key is
+// known. JSON and form bodies are stored with secrets (passwords, tokens, keys,
+// ...) redacted; other content types are summarized, and bodies larger than
+// ADMIN_AUDIT_MAX_BODY_BYTES are not stored at all, since redaction needs the
+// whole document. Routes listed in ADMIN_AUDIT_SKIP_ROUTES ("/admin/users/export"
+// or "GET /admin/users/export") and routes using SkipAdminAudit are not recorded.
+func AdminAuditMiddleware(recorder AdminAuditRecorder) gin.HandlerFunc {
+	limit := viper.GetInt("ADMIN_AUDIT_MAX_BODY_BYTES")
+	if limit <= 0 {
+		limit = 16 * 1024
+	}
+	skipRoutes := make(map[string]bool)
+	for _, route := range strings.Split(viper.GetString("ADMIN_AUDIT_SKIP_ROUTES"), ",") {
+		if route = strings.Join(strings.Fields(route), " "); route != "" {
+			skipRoutes[route] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if skipRoutes[route] || skipRoutes[c.Request.Method+" "+route] {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		reqBody, reqTruncated := captureRequestBody(c, limit)
+		writer := &auditResponseWriter{ResponseWriter: c.Writer, limit: limit}
+		c.Writer = writer
+
+		c.Next()
+
+		if c.GetBool(adminAuditSkipKey) {
+			return
+		}
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ip, userAgent := util.GetClientInfo(c)
+		entry := &models.AdminAuditLog{
+			Method:            c.Request.Method,
+			Route:             route,
+			Path:              redactedRequestURI(c.Request.URL),
+			StatusCode:        writer.Status(),
+			IPAddress:         ip,
+			UserAgent:         userAgent,
+			RequestTruncated:  reqTruncated,
+			ResponseTruncated: writer.truncated,
+			DurationMs:        time.Since(start).Milliseconds(),
+		}
+		if !reqTruncated {
+			entry.RequestBody = redactBody(reqBody, c.GetHeader("Content-Type"))
+		}
+		if !writer.truncated {
+			entry.ResponseBody = redactBody(writer.body.Bytes(), writer.Header().Get("Content-Type"))
+		}
+		if v, ok := c.Get(web.ApiKeyIDKey); ok {
+			if id, ok := v.(uuid.UUID); ok {
+				entry.ApiKeyID = &id
+			}
+		}
+
+		go func() {
+			if err := recorder.CreateAdminAuditLog(entry); err != nil {
+				log.Printf("Warning: failed to record admin audit log for %s %s: %v\n", entry.Method, entry.Route, err)
+			}
+		}()
+	}
+}
+
+// captureRequestBody reads up to limit bytes of the request body and puts
+// them back in front of the rest, so handlers still see the whole body.
+// truncated is true when the body is larger than limit; body is then nil.
+func captureRequestBody(c *gin.Context, limit int) (body []byte, truncated bool) {
+	if c.Request.Body == nil {
+		return nil, false
+	}
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	c.Request.Body = replayBody{Reader: io.MultiReader(bytes.NewReader(head), c.Request.Body), Closer: c.Request.Body}
+	if err != nil || len(head) > limit {
+		return nil, true
+	}
+	return head, false
+}
+
+// replayBody is a request body whose first bytes were already read.
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// auditResponseWriter keeps a copy of the response body up to limit bytes.
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseWriter) capture(b []byte) {
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(b) > w.limit {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
+
+// isSensitiveField reports whether a JSON, form or query field holds a secret.
+func isSensitiveField(name string) bool {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	switch name {
+	case "key", "code", "pin", "otp", "raw_key":
+		return true
+	}
+	for _, part := range []string{"password", "secret", "token", "api_key", "apikey", "private_key", "transport_key", "credential", "authorization", "cookie", "recovery_code", "backup_code"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactBody returns body as stored in the audit log: JSON and form bodies
+// with sensitive fields redacted, anything else as a short summary.
+func redactBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || (mediaType == "" && json.Valid(body)):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return fmt.Sprintf("[%d bytes of invalid JSON not captured]", len(body))
+		}
+		out, err := json.Marshal(redactJSON(doc))
+		if err != nil {
+			return fmt.Sprintf("[%d bytes of JSON not captured]", len(body))
+		}
+		return string(out)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("[%d bytes of invalid form data not captured]", len(body))
+		}
+		return redactValues(values).Encode()
+	default:
+		if mediaType == "" {
+			mediaType = "unknown content type"
+		}
+		return fmt.Sprintf("[%d bytes of %s not captured]", len(body), mediaType)
+	}
+}
+
+// redactJSON replaces the values of sensitive fields at any depth.
+func redactJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if isSensitiveField(k) {
+				if item != nil && item != "" {
+					val[k] = redactedValue
+				}
+				continue
+			}
+			val[k] = redactJSON(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redactJSON(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func redactValues(values url.Values) url.Values {
+	for k, vs := range values {
+		if isSensitiveField(k) {
+			for i := range vs {
+				vs[i] = redactedValue
+			}
+		}
+	}
+	return values
+}
+
+// redactedRequestURI returns the path and query of u with sensitive query
+// parameters redacted.
+func redactedRequestURI(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	values, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return u.Path + "?" + redactedValue
+	}
+	return u.Path + "?" + redactValues(values).Encode()
+}
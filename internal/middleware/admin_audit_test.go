@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/spf13/viper"
+)
+
+type auditRecorderFunc func(entry *models.AdminAuditLog) error
+
+func (f auditRecorderFunc) CreateAdminAuditLog(entry *models.AdminAuditLog) error { return f(entry) }
+
+// auditRouter returns a router with AdminAuditMiddleware whose recorded entries
+// are sent to the returned channel. The handlers echo the request body.
+func auditRouter(t *testing.T) (*gin.Engine, <-chan *models.AdminAuditLog) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	entries := make(chan *models.AdminAuditLog, 1)
+	r := gin.New()
+	r.Use(AdminAuditMiddleware(auditRecorderFunc(func(e *models.AdminAuditLog) error {
+		entries <- e
+		return nil
+	})))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusCreated, c.ContentType(), body)
+	}
+	r.POST("/admin/accounts", echo)
+	r.POST("/admin/users/import", echo)
+	r.GET("/admin/users/export", SkipAdminAudit(), echo)
+	return r, entries
+}
+
+func waitEntry(t *testing.T, entries <-chan *models.AdminAuditLog) *models.AdminAuditLog {
+	t.Helper()
+	select {
+	case e := <-entries:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("no audit entry recorded")
+		return nil
+	}
+}
+
+func TestAdminAuditMiddlewareRedactsSecrets(t *testing.T) {
+	r, entries := auditRouter(t)
+
+	body := `{"username":"ops","password":"hunter2","nested":{"client_secret":"abc","apiKey":"ak_1"},"items":[{"token":"t"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/accounts?token=qs-secret&page=2", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != body {
+		t.Fatalf("handler did not receive the full body: %q", w.Body.String())
+	}
+	e := waitEntry(t, entries)
+	if e.Route != "/admin/accounts" || e.Method != http.MethodPost || e.StatusCode != http.StatusCreated {
+		t.Errorf("entry = %s %s %d", e.Method, e.Route, e.StatusCode)
+	}
+	for _, secret := range []string{"hunter2", "abc", "ak_1", `"t"`, "qs-secret"} {
+		if strings.Contains(e.RequestBody+e.ResponseBody+e.Path, secret) {
+			t.Errorf("secret %s captured: path %q, request %q, response %q", secret, e.Path, e.RequestBody, e.ResponseBody)
+		}
+	}
+	if !strings.Contains(e.RequestBody, `"username":"ops"`) || !strings.Contains(e.Path, "page=2") {
+		t.Errorf("non-secret fields missing: path %q, request %q", e.Path, e.RequestBody)
+	}
+}
+
+func TestAdminAuditMiddlewareSizeLimit(t *testing.T) {
+	viper.Set("ADMIN_AUDIT_MAX_BODY_BYTES", 64)
+	defer viper.Set("ADMIN_AUDIT_MAX_BODY_BYTES", 0)
+	r, entries := auditRouter(t)
+
+	body := `{"note":"` + strings.Repeat("x", 100) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/accounts", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != body {
+		t.Fatalf("handler did not receive the full body (%d bytes)", w.Body.Len())
+	}
+	e := waitEntry(t, entries)
+	if !e.RequestTruncated || !e.ResponseTruncated || e.RequestBody != "" || e.ResponseBody != "" {
+		t.Errorf("oversized bodies stored: %+v", e)
+	}
+}
+
+func TestAdminAuditMiddlewareOptOut(t *testing.T) {
+	viper.Set("ADMIN_AUDIT_SKIP_ROUTES", "POST  /admin/users/import")
+	defer viper.Set("ADMIN_AUDIT_SKIP_ROUTES", "")
+	r, entries := auditRouter(t)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/admin/users/export", nil),
+		httptest.NewRequest(http.MethodPost, "/admin/users/import", strings.NewReader("a,b")),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	select {
+	case e := <-entries:
+		t.Errorf("opted-out route recorded: %s %s", e.Method, e.Route)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name, body, contentType, want string
+	}{
+		{"empty", "", "application/json", ""},
+		{"form", "username=ops&password=hunter2", "application/x-www-form-urlencoded", "password=%5BREDACTED%5D&username=ops"},
+		{"empty secret kept", `{"password":""}`, "application/json", `{"password":""}`},
+		{"large numbers", `{"count":12345678901234567890}`, "application/json; charset=utf-8", `{"count":12345678901234567890}`},
+		{"csv", "email\na@example.com\n", "text/csv", "[20 bytes of text/csv not captured]"},
+	}
+	for _, tc := range tests {
+		if got := redactBody([]byte(tc.body), tc.contentType); got != tc.want {
+			t.Errorf("%s: redactBody() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
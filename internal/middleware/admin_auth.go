@@ -47,6 +47,7 @@ func AdminAuthMiddleware(keyValidator web.ApiKeyValidator) gin.HandlerFunc {
 				go keyValidator.IncrementDailyUsage(foundKey.ID)
 				scopes := parseScopes(foundKey.Scopes)
 				c.Set(web.ApiKeyScopesKey, scopes)
+				c.Set(web.ApiKeyIDKey, foundKey.ID)
 				c.Set(web.AuthTypeKey, web.AuthTypeAdmin)
 				c.Next()
 				return
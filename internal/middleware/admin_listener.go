@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminListenerKey marks requests received on the admin listener.
+type adminListenerKey struct{}
+
+// MarkAdminListener is the http.Server ConnContext hook of the admin listener
+// (ADMIN_LISTEN_ADDR). AdminListenerMiddleware uses the mark to tell the two
+// listeners apart, as both serve the same router.
+func MarkAdminListener(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, adminListenerKey{}, true)
+}
+
+// IsAdminPath reports whether path belongs to the admin surface: the admin GUI
+// (/gui) and the admin API (/admin).
+func IsAdminPath(path string) bool {
+	for _, prefix := range []string{"/gui", "/admin"} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminListenerMiddleware splits the routes between the public and the admin
+// listener when ADMIN_LISTEN_ADDR is set: the admin surface answers only on
+// the admin listener and everything else only on the public one, so the admin
+// port can be firewalled off without a path filter in a reverse proxy. The
+// health check answers on both for probes. Other requests get 404, as if the
+// route did not exist (ErrorPagesMiddleware writes the body).
+func AdminListenerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path == "/health" {
+			c.Next()
+			return
+		}
+		onAdminListener, _ := c.Request.Context().Value(adminListenerKey{}).(bool)
+		if onAdminListener != IsAdminPath(path) {
+			c.Status(http.StatusNotFound)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminListenerMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AdminListenerMiddleware())
+	r.GET("/*any", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		path       string
+		admin      bool
+		wantStatus int
+	}{
+		{"/login", false, http.StatusOK},
+		{"/login", true, http.StatusNotFound},
+		{"/gui/login", false, http.StatusNotFound},
+		{"/gui/login", true, http.StatusOK},
+		{"/admin/apps", false, http.StatusNotFound},
+		{"/admin/apps", true, http.StatusOK},
+		{"/app/123/email-config", true, http.StatusNotFound},
+		{"/administrator", false, http.StatusOK},
+		{"/health", false, http.StatusOK},
+		{"/health", true, http.StatusOK},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.admin {
+			req = req.WithContext(MarkAdminListener(req.Context(), nil))
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.wantStatus {
+			t.Errorf("GET %s (admin listener: %v) = %d, want %d", tc.path, tc.admin, w.Code, tc.wantStatus)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/internal/admin"
+	"github.com/gjovanovicst/auth_api/internal/environment"
 	"github.com/gjovanovicst/auth_api/web"
 	"github.com/google/uuid"
 )
@@ -19,7 +20,8 @@ const (
 // AppApiKeyMiddleware validates per-application API keys.
 // It requires both X-App-ID (already set by AppIDMiddleware) and X-App-API-Key headers.
 // The key is looked up by SHA-256 hash and must be a non-revoked, non-expired "app" type key
-// bound to the same application ID from the X-App-ID header.
+// bound to the application in the :id URL parameter (or, on routes without one, the
+// X-App-ID header or the environment it selected).
 //
 // This middleware is OPTIONAL — it can be applied to specific route groups
 // that require app-level key authentication in addition to the existing X-App-ID header.
@@ -66,8 +68,19 @@ func AppApiKeyMiddleware(keyValidator web.ApiKeyValidator) gin.HandlerFunc {
 			return
 		}
 
-		// Must be bound to the same application
-		if foundKey.AppID == nil || *foundKey.AppID != appID {
+		// Must be bound to the application in the URL. EnvironmentMiddleware may have
+		// rewritten the app ID to a shared-users parent, so the route's :id is the only
+		// reliable owner; the environment's config is resolved only after this check.
+		if foundKey.AppID == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key does not match the application"})
+			return
+		}
+		if routeID := c.Param("id"); routeID != "" {
+			if routeAppID, err := uuid.Parse(routeID); err != nil || *foundKey.AppID != routeAppID {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key does not match the application"})
+				return
+			}
+		} else if *foundKey.AppID != appID && *foundKey.AppID != environment.ConfigAppID(c, appID) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key does not match the application"})
 			return
 		}
@@ -79,6 +92,7 @@ func AppApiKeyMiddleware(keyValidator web.ApiKeyValidator) gin.HandlerFunc {
 		// Parse scopes and set on context
 		scopes := parseScopes(foundKey.Scopes)
 		c.Set(web.ApiKeyScopesKey, scopes)
+		c.Set(web.ApiKeyIDKey, foundKey.ID)
 		c.Set(web.AuthTypeKey, web.AuthTypeApp)
 		c.Next()
 	}
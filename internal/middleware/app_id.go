@@ -19,11 +19,15 @@ func AppIDMiddleware() gin.HandlerFunc {
 		path := c.Request.URL.Path
 
 		// Skip validation for Swagger documentation, Admin API routes, GUI routes,
-		// and OIDC routes (OIDC routes carry app_id in URL path, not X-App-ID header)
+		// OIDC routes (OIDC routes carry app_id in URL path, not X-App-ID header)
+		// unsubscribe links (the app is part of the signed token) and uploaded
+		// profile pictures (the app is part of the URL, used in <img> tags)
 		if (len(path) >= 8 && path[:8] == "/swagger") ||
 			(len(path) >= 6 && path[:6] == "/admin") ||
 			(len(path) >= 4 && path[:4] == "/gui") ||
-			(len(path) >= 5 && path[:5] == "/oidc") {
+			(len(path) >= 5 && path[:5] == "/oidc") ||
+			path == "/unsubscribe" ||
+			strings.HasPrefix(path, "/avatars/") {
 			c.Next()
 			return
 		}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/environment"
 	"github.com/google/uuid"
 )
 
@@ -39,8 +40,9 @@ func AppRouteGuardMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Ensure they match — prevents cross-app access
-		if contextAppID != urlAppID {
+		// Ensure they match — prevents cross-app access. A shared-users environment
+		// runs under its parent's app ID, so its own ID is accepted as well.
+		if contextAppID != urlAppID && environment.ConfigAppID(c, contextAppID) != urlAppID {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "X-App-ID header does not match the application in the URL"})
 			return
 		}
@@ -2,15 +2,26 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/internal/rbac"
 	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/region"
+	"github.com/gjovanovicst/auth_api/internal/revocation"
 	"github.com/gjovanovicst/auth_api/pkg/jwt"
 )
 
-// AuthMiddleware authenticates requests using JWT
+// HeaderImpersonatedBy is set on responses to requests made with an
+// impersonation token, naming the support agent signed in as the user.
+const HeaderImpersonatedBy = "X-Impersonated-By"
+
+// AuthMiddleware authenticates requests using JWT. Verified tokens are cached
+// in-process (JWT_CACHE_SIZE) until they expire.
 func AuthMiddleware() gin.HandlerFunc {
+	tokenCache := verifiedTokenCache()
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -24,7 +35,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Parse and validate JWT
-		claims, err := jwt.ParseToken(tokenString)
+		claims, err := tokenCache.parseToken(tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
@@ -37,30 +48,64 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Delegated (token exchange) tokens are narrowed to the scope and audience
+		// of another client; they do not grant access to the user's own routes
+		if claims.Actor != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Delegated tokens are not accepted by this API"})
+			return
+		}
+
 		// Check Redis blacklists only if Redis is available
 		if redis.Rdb != nil {
-			// Check if the specific access token is blacklisted
-			blacklisted, err := redis.IsAccessTokenBlacklisted(claims.AppID, tokenString)
+			// Check if the specific access token is blacklisted. Tokens with an ID are
+			// only looked up when the in-memory revocation filter says they may be revoked.
+			if claims.ID == "" || revocation.MayBeRevoked(claims.AppID, claims.ID) {
+				blacklisted, err := redis.IsAccessTokenBlacklisted(claims.AppID, tokenString)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Token validation error"})
+					return
+				}
+				if blacklisted {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+					return
+				}
+			}
+
+			// Check if all tokens for this user are blacklisted (e.g., after password change)
+			userBlacklisted, err := redis.IsUserTokensBlacklisted(claims.AppID, claims.UserID)
 			if err != nil {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Token validation error"})
 				return
 			}
-			if blacklisted {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			if userBlacklisted {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "All user tokens have been revoked"})
 				return
 			}
 
-			// Check if all tokens for this user are blacklisted (e.g., after password change)
-			userBlacklisted, err := redis.IsUserTokensBlacklisted(claims.AppID, claims.UserID)
+			// Check if the application revoked every token issued before its auth epoch (kill switch)
+			epochRevoked, err := redis.IsRevokedByAppAuthEpoch(claims.AppID, claims.IssuedAtTime())
 			if err != nil {
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Token validation error"})
 				return
 			}
-			if userBlacklisted {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "All user tokens have been revoked"})
+			if epochRevoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "All application sessions have been revoked"})
 				return
 			}
 
+			// Service account tokens outlive the user blacklists and are revoked one by one
+			if claims.ServiceAccount {
+				revoked, err := redis.IsServiceAccountTokenRevoked(claims.AppID, claims.ID)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Token validation error"})
+					return
+				}
+				if revoked {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+					return
+				}
+			}
+
 			// Check if the session still exists in Redis (ensures revoked sessions are immediately rejected)
 			if claims.SessionID != "" {
 				sessionExists, err := redis.SessionExists(claims.AppID, claims.SessionID)
@@ -68,6 +113,21 @@ func AuthMiddleware() gin.HandlerFunc {
 					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Session validation error"})
 					return
 				}
+				// In multi-region deployments a revocation tombstone rejects sessions a
+				// lagging replica still holds, and a missing session is tolerated for
+				// tokens issued within the replication grace window
+				if cfg := region.Current(); cfg.Enabled() {
+					revoked, err := redis.IsSessionRevoked(claims.AppID, claims.SessionID)
+					if err != nil {
+						c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Session validation error"})
+						return
+					}
+					if revoked {
+						sessionExists = false
+					} else if !sessionExists {
+						sessionExists = cfg.WithinGrace(claims.IssuedAtTime(), time.Now())
+					}
+				}
 				if !sessionExists {
 					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
 					return
@@ -86,6 +146,58 @@ func AuthMiddleware() gin.HandlerFunc {
 		if claims.SessionID != "" {
 			c.Set("sessionID", claims.SessionID)
 		}
+		if claims.Scope != "" {
+			c.Set("scope", claims.Scope)
+		}
+		if claims.ServiceAccount {
+			c.Set("serviceAccount", true)
+		}
+		if claims.Impersonation != nil {
+			// Read by RejectImpersonation and log.WithImpersonation; the header lets
+			// clients keep the impersonation banner up on every response
+			c.Set("impersonation", claims.Impersonation)
+			c.Header(HeaderImpersonatedBy, claims.Impersonation.By)
+		}
+		c.Next()
+	}
+}
+
+// RejectImpersonation refuses requests made with an impersonation token. It is
+// applied to routes that change the user's credentials, second factors, email
+// address or account, which a support agent signed in as the user must not do.
+// Must run after AuthMiddleware.
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := c.Get("impersonation"); impersonated {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating a user"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RejectServiceAccount refuses service account tokens. It is applied to the
+// routes of interactive user sessions (profile, credentials, second factors,
+// sessions), which a service account has no use for. Must run after AuthMiddleware.
+func RejectServiceAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("serviceAccount") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Service account tokens are not accepted on this route"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTokenScope limits service account tokens to the routes their scope
+// names: such a token passes only when its space-separated scope claim lists
+// scope. Tokens of users pass unchanged. Must run after AuthMiddleware.
+func RequireTokenScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("serviceAccount") && !slices.Contains(strings.Fields(c.GetString("scope")), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token lacks the " + scope + " scope"})
+			return
+		}
 		c.Next()
 	}
 }
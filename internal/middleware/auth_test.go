@@ -230,8 +230,13 @@ func TestAuthMiddlewareBlacklistedToken(t *testing.T) {
 		t.Fatalf("Failed to generate test token: %v", err)
 	}
 
+	claims, err := jwt.ParseToken(token)
+	if err != nil {
+		t.Fatalf("Failed to parse test token: %v", err)
+	}
+
 	// Blacklist the token
-	if err := redis.BlacklistAccessToken("test-app-id", token, userID, time.Hour); err != nil {
+	if err := redis.BlacklistAccessToken("test-app-id", token, claims.ID, userID, time.Hour); err != nil {
 		t.Fatalf("Failed to blacklist token: %v", err)
 	}
 
@@ -315,6 +320,52 @@ func TestAuthMiddlewareUserTokensBlacklisted(t *testing.T) {
 	redis.Rdb.Del(ctx, "app:test-app-id:blacklist_user:"+userID)
 }
 
+func TestAuthMiddlewareAppAuthEpoch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := redis.Rdb.Context()
+	if _, err := redis.Rdb.Ping(ctx).Result(); err != nil {
+		t.Skip("Redis connection failed, skipping test")
+	}
+
+	appID := "test-epoch-app-" + time.Now().Format("20060102150405")
+	token, err := jwt.GenerateAccessToken(appID, "test-user-id", "", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+	defer redis.Rdb.Del(ctx, "app:"+appID+":auth_epoch")
+
+	router := gin.New()
+	router.Use(AuthMiddleware())
+	router.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+	request := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// An epoch before the token was issued does not affect it
+	if err := redis.SetAppAuthEpoch(appID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Failed to set auth epoch: %v", err)
+	}
+	if w := request(); w.Code != http.StatusOK {
+		t.Fatalf("Expected status code 200 for a token issued after the epoch, got %d", w.Code)
+	}
+
+	// The kill switch raises the epoch to now
+	if err := redis.SetAppAuthEpoch(appID, time.Now()); err != nil {
+		t.Fatalf("Failed to set auth epoch: %v", err)
+	}
+	w := request()
+	if w.Code != http.StatusUnauthorized || !contains(w.Body.String(), "All application sessions have been revoked") {
+		t.Fatalf("Expected 401 for a token issued before the epoch, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) &&
@@ -374,6 +425,32 @@ func TestAuthMiddlewareInvalidToken(t *testing.T) {
 	}
 }
 
+func TestAuthMiddlewareDelegatedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(AuthMiddleware())
+	router.GET("/profile", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	// A token-exchange token for another client, with a narrowed scope
+	actor := &jwt.Actor{Subject: "orders-api"}
+	token, err := jwt.GenerateDelegatedAccessToken("test-app-id", "test-user-id", "", nil, []string{"orders-api"}, "openid", actor, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate delegated token: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status code 401 for a delegated token, got %d", w.Code)
+	}
+}
+
 func TestAuthMiddlewareTokenWithoutBearer(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -560,3 +637,80 @@ func TestAuthMiddlewareAndAuthorizeRoleTogether(t *testing.T) {
 		t.Fatalf("Expected status code 200, got %d. Body: %s", w.Code, w.Body.String())
 	}
 }
+
+func TestRejectImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		impersonation *jwt.Impersonation
+		want          int
+	}{
+		{"user token", nil, http.StatusOK},
+		{"impersonation token", &jwt.Impersonation{By: "agent@example.com", Reason: "ticket 42"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.impersonation != nil {
+					c.Set("impersonation", tt.impersonation)
+				}
+			})
+			router.PUT("/profile/password", RejectImpersonation(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest(http.MethodPut, "/profile/password", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status code %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestServiceAccountTokenRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		serviceAccount bool
+		scope          string
+		path           string
+		want           int
+	}{
+		{"user on session route", false, "", "/profile", http.StatusOK},
+		{"service account on session route", true, "log:read", "/profile", http.StatusForbidden},
+		{"user on scoped route", false, "", "/activity-logs", http.StatusOK},
+		{"service account with scope", true, "user:read log:read", "/activity-logs", http.StatusOK},
+		{"service account without scope", true, "user:read", "/activity-logs", http.StatusForbidden},
+		{"service account with no scopes", true, "", "/activity-logs", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.serviceAccount {
+					c.Set("serviceAccount", true)
+				}
+				if tt.scope != "" {
+					c.Set("scope", tt.scope)
+				}
+			})
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			router.GET("/profile", RejectServiceAccount(), ok)
+			router.GET("/activity-logs", RequireTokenScope("log:read"), ok)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status code %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,17 +24,31 @@ func splitTrimmed(s string) []string {
 	return out
 }
 
-// CORSMiddleware creates and configures CORS middleware from Viper settings.
-// All values are read once at startup; a server restart is required to apply changes.
-func CORSMiddleware() gin.HandlerFunc {
-	config := cors.Config{
-		AllowOrigins:     splitTrimmed(viper.GetString("CORS_ALLOWED_ORIGINS")),
-		AllowMethods:     splitTrimmed(viper.GetString("CORS_ALLOWED_METHODS")),
-		AllowHeaders:     splitTrimmed(viper.GetString("CORS_ALLOWED_HEADERS")),
-		ExposeHeaders:    splitTrimmed(viper.GetString("CORS_EXPOSE_HEADERS")),
-		AllowCredentials: viper.GetBool("CORS_ALLOW_CREDENTIALS"),
-		MaxAge:           time.Duration(viper.GetInt("CORS_MAX_AGE_HOURS")) * time.Hour,
+// CORSMiddleware creates and configures CORS middleware. Values are read once
+// at startup through resolve (env > DB > default; nil = Viper only), so a
+// server restart is required to apply changes. Invalid stored values fall back
+// to the Viper configuration instead of failing startup.
+func CORSMiddleware(resolve func(key string) string) gin.HandlerFunc {
+	if resolve != nil {
+		config := corsConfig(resolve)
+		err := config.Validate()
+		if err == nil {
+			return cors.New(config)
+		}
+		log.Printf("Warning: Invalid CORS settings (%v), using the environment configuration\n", err)
 	}
+	return cors.New(corsConfig(viper.GetString))
+}
 
-	return cors.New(config)
+func corsConfig(resolve func(key string) string) cors.Config {
+	allowCredentials, _ := strconv.ParseBool(strings.TrimSpace(resolve("CORS_ALLOW_CREDENTIALS")))
+	maxAgeHours, _ := strconv.Atoi(strings.TrimSpace(resolve("CORS_MAX_AGE_HOURS")))
+	return cors.Config{
+		AllowOrigins:     splitTrimmed(resolve("CORS_ALLOWED_ORIGINS")),
+		AllowMethods:     splitTrimmed(resolve("CORS_ALLOWED_METHODS")),
+		AllowHeaders:     splitTrimmed(resolve("CORS_ALLOWED_HEADERS")),
+		ExposeHeaders:    splitTrimmed(resolve("CORS_EXPOSE_HEADERS")),
+		AllowCredentials: allowCredentials,
+		MaxAge:           time.Duration(maxAgeHours) * time.Hour,
+	}
 }
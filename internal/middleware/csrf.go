@@ -52,12 +52,12 @@ func CSRFMiddleware(sessionValidator web.SessionValidator) gin.HandlerFunc {
 		}
 
 		if token == "" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "CSRF token missing"})
+			RespondError(c, http.StatusForbidden, "CSRF token missing")
 			return
 		}
 
 		if !sessionValidator.ValidateCSRFToken(sessionIDStr, token) {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "CSRF token invalid"})
+			RespondError(c, http.StatusForbidden, "CSRF token invalid")
 			return
 		}
 
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/admin"
+	"github.com/gjovanovicst/auth_api/internal/environment"
+	"github.com/gjovanovicst/auth_api/web"
+	"github.com/google/uuid"
+)
+
+// EnvironmentLookup resolves the environment information of an application.
+// Implemented by environment.Resolver.
+type EnvironmentLookup interface {
+	Lookup(appID uuid.UUID) *environment.Info
+}
+
+// EnvironmentMiddleware routes requests to application environments. It must run
+// after AppIDMiddleware. The environment is selected by:
+//   - an X-App-API-Key bound to an environment of the X-App-ID application, or
+//   - the environment's own ID sent as X-App-ID.
+//
+// For an isolated environment the request's app ID becomes the environment ID.
+// For an environment sharing users, the app ID stays the parent's so users,
+// sessions and tokens are shared, and environment.ContextKey carries the
+// environment for configuration lookups (see environment.ConfigAppID).
+//
+// Requests that do not resolve to an environment pass through unchanged; key
+// validation itself is left to AppApiKeyMiddleware.
+func EnvironmentMiddleware(keyValidator web.ApiKeyValidator, envs EnvironmentLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appIDVal, exists := c.Get(AppIDKey)
+		if !exists {
+			c.Next()
+			return
+		}
+		appID, ok := appIDVal.(uuid.UUID)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		envID := appID
+		if apiKey := c.GetHeader(HeaderAppAPIKey); apiKey != "" && keyValidator != nil {
+			h := sha256.Sum256([]byte(apiKey))
+			foundKey, err := keyValidator.FindActiveKeyByHash(hex.EncodeToString(h[:]))
+			if err == nil && foundKey != nil && foundKey.KeyType == admin.KeyTypeApp &&
+				foundKey.AppID != nil && *foundKey.AppID != appID {
+				envID = *foundKey.AppID
+			}
+		}
+
+		env := envs.Lookup(envID)
+		// A key-selected environment must belong to the requested application.
+		if !env.IsEnvironment() || (envID != appID && *env.ParentAppID != appID) {
+			c.Next()
+			return
+		}
+
+		c.Set(environment.ContextKey, env.AppID)
+		if env.ShareUsers {
+			c.Set(AppIDKey, *env.ParentAppID)
+		} else {
+			c.Set(AppIDKey, env.AppID)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/admin"
+	"github.com/gjovanovicst/auth_api/internal/environment"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+type fakeEnvKeys map[string]*models.ApiKey
+
+func (f fakeEnvKeys) FindActiveKeyByHash(keyHash string) (*models.ApiKey, error) {
+	return f[keyHash], nil
+}
+func (f fakeEnvKeys) UpdateApiKeyLastUsed(uuid.UUID) {}
+func (f fakeEnvKeys) IncrementDailyUsage(uuid.UUID)  {}
+
+func (f fakeEnvKeys) add(raw string, appID uuid.UUID) {
+	h := sha256.Sum256([]byte(raw))
+	f[hex.EncodeToString(h[:])] = &models.ApiKey{ID: uuid.New(), KeyType: admin.KeyTypeApp, AppID: &appID}
+}
+
+type fakeEnvLookup map[uuid.UUID]*environment.Info
+
+func (f fakeEnvLookup) Lookup(appID uuid.UUID) *environment.Info { return f[appID] }
+
+func TestEnvironmentMiddleware(t *testing.T) {
+	parent, isolated, shared, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	envs := fakeEnvLookup{
+		parent:   {AppID: parent},
+		isolated: {AppID: isolated, ParentAppID: &parent},
+		shared:   {AppID: shared, ParentAppID: &parent, ShareUsers: true},
+		other:    {AppID: other},
+	}
+	keys := fakeEnvKeys{}
+	keys.add("parent-key", parent)
+	keys.add("isolated-key", isolated)
+	keys.add("shared-key", shared)
+
+	tests := []struct {
+		name       string
+		appID      uuid.UUID
+		apiKey     string
+		wantApp    uuid.UUID
+		wantConfig uuid.UUID
+	}{
+		{"parent key", parent, "parent-key", parent, parent},
+		{"no key", parent, "", parent, parent},
+		{"isolated environment key", parent, "isolated-key", isolated, isolated},
+		{"shared environment key", parent, "shared-key", parent, shared},
+		{"isolated environment id", isolated, "", isolated, isolated},
+		{"shared environment id", shared, "", parent, shared},
+		{"key of another app's environment", other, "isolated-key", other, other},
+		{"unknown key", parent, "bogus", parent, parent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotApp, gotConfig uuid.UUID
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(AppIDKey, tt.appID) })
+			r.Use(EnvironmentMiddleware(keys, envs))
+			r.GET("/test", func(c *gin.Context) {
+				gotApp = c.MustGet(AppIDKey).(uuid.UUID)
+				gotConfig = environment.ConfigAppID(c, gotApp)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(HeaderAppAPIKey, tt.apiKey)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotApp != tt.wantApp {
+				t.Errorf("app ID = %s, want %s", gotApp, tt.wantApp)
+			}
+			if gotConfig != tt.wantConfig {
+				t.Errorf("config app ID = %s, want %s", gotConfig, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func TestAppApiKeyMiddleware_EnvironmentKeys(t *testing.T) {
+	parent, isolated, shared := uuid.New(), uuid.New(), uuid.New()
+	envs := fakeEnvLookup{
+		parent:   {AppID: parent},
+		isolated: {AppID: isolated, ParentAppID: &parent},
+		shared:   {AppID: shared, ParentAppID: &parent, ShareUsers: true},
+	}
+	keys := fakeEnvKeys{}
+	keys.add("parent-key", parent)
+	keys.add("isolated-key", isolated)
+	keys.add("shared-key", shared)
+
+	tests := []struct {
+		name     string
+		headerID uuid.UUID
+		routeID  uuid.UUID
+		apiKey   string
+		want     int
+	}{
+		{"parent key on parent", parent, parent, "parent-key", http.StatusOK},
+		{"isolated key on isolated", isolated, isolated, "isolated-key", http.StatusOK},
+		{"shared key on shared", shared, shared, "shared-key", http.StatusOK},
+		{"shared key on parent", parent, parent, "shared-key", http.StatusUnauthorized},
+		{"isolated key on parent", parent, parent, "isolated-key", http.StatusUnauthorized},
+		{"parent key on shared", shared, shared, "parent-key", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(AppIDKey, tt.headerID) })
+			r.Use(EnvironmentMiddleware(keys, envs))
+			g := r.Group("/app/:id", AppApiKeyMiddleware(keys), AppRouteGuardMiddleware())
+			g.GET("/webhooks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/app/"+tt.routeID.String()+"/webhooks", nil)
+			req.Header.Set(HeaderAppAPIKey, tt.apiKey)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gjovanovicst/auth_api/web"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Error repeats the detail (or
+// the title) so clients reading the {"error": "..."} body of other error
+// responses keep working.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Error    string `json:"error"`
+}
+
+// ErrorPagesMiddleware replaces Gin's default error responses: it recovers
+// from panics with a 500 and gives every error status that no handler wrote a
+// body for (unknown routes, c.Status without a body) a response through
+// RespondError. It must be the first middleware after the logger so it also
+// catches panics in the others.
+func ErrorPagesMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("panic serving %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, rec, debug.Stack())
+				if c.Writer.Written() {
+					c.Abort() // Too late for an error page
+					return
+				}
+				RespondError(c, http.StatusInternalServerError, "")
+			}
+		}()
+		c.Next()
+		if status := c.Writer.Status(); status >= http.StatusBadRequest && !c.Writer.Written() {
+			RespondError(c, status, "")
+		}
+	}
+}
+
+// RespondError aborts the request with an error response negotiated for the
+// client: an alert fragment for HTMX requests, an HTML error page for the
+// admin GUI and browsers, and problem+json (RFC 7807) for API clients.
+// detail is shown to the client; empty means the status text alone.
+func RespondError(c *gin.Context, status int, detail string) {
+	title := http.StatusText(status)
+	if title == "" {
+		title = "Error"
+	}
+	switch {
+	case c.GetHeader("HX-Request") == "true":
+		message := detail
+		if message == "" {
+			message = title
+		}
+		c.HTML(status, "alert", gin.H{"Kind": "danger", "Message": message, "Dismissible": true})
+	case wantsHTML(c):
+		c.HTML(status, "error", gin.H{
+			"Status": status,
+			"Title":  title,
+			"Detail": detail,
+			"GUI":    isGUIPath(c.Request.URL.Path),
+			"Theme":  web.GetTheme(c),
+		})
+	default:
+		p := Problem{Type: "about:blank", Title: title, Status: status, Detail: detail, Instance: c.Request.URL.Path, Error: detail}
+		if p.Error == "" {
+			p.Error = title
+		}
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(status, p)
+	}
+	c.Abort()
+}
+
+// wantsHTML reports whether the error response should be an HTML page: for
+// admin GUI routes, and for other routes when the Accept header prefers HTML
+// (a browser navigating to it). API clients sending no Accept header or */*
+// get JSON.
+func wantsHTML(c *gin.Context) bool {
+	return isGUIPath(c.Request.URL.Path) || c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) == binding.MIMEHTML
+}
+
+func isGUIPath(path string) bool {
+	return path == "/gui" || strings.HasPrefix(path, "/gui/")
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newErrorPagesRouter returns a router with ErrorPagesMiddleware and stand-in
+// "error" and "alert" templates.
+func newErrorPagesRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.SetHTMLTemplate(template.Must(template.New("").Parse(
+		`{{define "error"}}page {{.Status}} {{.Title}}: {{.Detail}}{{end}}` +
+			`{{define "alert"}}alert {{.Message}}{{end}}`)))
+	r.Use(ErrorPagesMiddleware())
+	r.GET("/api/panic", func(c *gin.Context) { panic("boom") })
+	r.GET("/gui/panic", func(c *gin.Context) { panic("boom") })
+	r.GET("/api/forbidden", func(c *gin.Context) { RespondError(c, http.StatusForbidden, "No <access>") })
+	r.GET("/api/empty", func(c *gin.Context) { c.Status(http.StatusConflict) })
+	return r
+}
+
+func doErrorRequest(r *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorPagesProblemJSON(t *testing.T) {
+	r := newErrorPagesRouter()
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantDetail string
+	}{
+		{"/api/missing", http.StatusNotFound, ""},
+		{"/api/panic", http.StatusInternalServerError, ""},
+		{"/api/forbidden", http.StatusForbidden, "No <access>"},
+		{"/api/empty", http.StatusConflict, ""},
+	}
+	for _, tc := range tests {
+		w := doErrorRequest(r, tc.path, map[string]string{"Accept": "application/json"})
+		if w.Code != tc.wantStatus {
+			t.Errorf("GET %s = %d, want %d", tc.path, w.Code, tc.wantStatus)
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, ProblemContentType) {
+			t.Errorf("GET %s Content-Type = %q, want %s", tc.path, ct, ProblemContentType)
+		}
+		var p Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("GET %s: invalid JSON %q: %v", tc.path, w.Body.String(), err)
+		}
+		wantError := tc.wantDetail
+		if wantError == "" {
+			wantError = http.StatusText(tc.wantStatus)
+		}
+		if p.Status != tc.wantStatus || p.Title != http.StatusText(tc.wantStatus) || p.Detail != tc.wantDetail ||
+			p.Instance != tc.path || p.Error != wantError {
+			t.Errorf("GET %s problem = %+v", tc.path, p)
+		}
+	}
+}
+
+func TestErrorPagesHTML(t *testing.T) {
+	r := newErrorPagesRouter()
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		want    string
+	}{
+		{"GUI route", "/gui/missing", nil, "page 404 Not Found: "},
+		{"GUI panic", "/gui/panic", nil, "page 500 Internal Server Error: "},
+		{"browser", "/api/forbidden", map[string]string{"Accept": "text/html,application/xhtml+xml,*/*;q=0.8"}, "page 403 Forbidden: No &lt;access&gt;"},
+		{"HTMX", "/gui/missing", map[string]string{"HX-Request": "true"}, "alert Not Found"},
+	}
+	for _, tc := range tests {
+		w := doErrorRequest(r, tc.path, tc.headers)
+		if got := w.Body.String(); got != tc.want {
+			t.Errorf("%s: body = %q, want %q", tc.name, got, tc.want)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("%s: Content-Type = %q, want text/html", tc.name, ct)
+		}
+	}
+}
+
+func TestErrorPagesLeavesWrittenResponses(t *testing.T) {
+	r := newErrorPagesRouter()
+	r.GET("/api/bad", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad input"})
+	})
+	w := doErrorRequest(r, "/api/bad", nil)
+	if w.Code != http.StatusBadRequest || w.Body.String() != `{"error":"bad input"}` {
+		t.Errorf("GET /api/bad = %d %q, want the handler's response", w.Code, w.Body.String())
+	}
+}
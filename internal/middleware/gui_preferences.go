@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/web"
+)
+
+// GUIPreferencesMiddleware loads the authenticated admin's display preferences
+// (theme, page size, timezone, locale) for handlers and templates. A saved theme
+// is also copied into the gui_theme cookie, which the base layout reads before
+// CSS loads, so it follows the admin across browsers.
+// Must run after GUIAuthMiddleware. If the preferences cannot be loaded the
+// GUI renders with the defaults.
+func GUIPreferencesMiddleware(loader web.PreferencesLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID := c.GetString(web.GUIAdminIDKey)
+		if adminID == "" {
+			c.Next()
+			return
+		}
+
+		prefs, err := loader.LoadPreferences(adminID)
+		if err != nil {
+			log.Printf("Failed to load GUI preferences for admin %s: %v", adminID, err)
+			c.Next()
+			return
+		}
+		web.SetPreferences(c, prefs)
+
+		if prefs.Theme != "" {
+			if cookie, err := c.Cookie(web.ThemeCookieName); err != nil || cookie != prefs.Theme {
+				web.SetThemeCookie(c, prefs.Theme)
+			}
+		}
+
+		c.Next()
+	}
+}
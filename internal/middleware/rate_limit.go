@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -147,6 +148,23 @@ func memIncr(e *memEntry, window time.Duration) int64 {
 	return e.count
 }
 
+// memWindowReset returns how long until the current window of e expires.
+func memWindowReset(e *memEntry, window time.Duration) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.windowAt.IsZero() {
+		return window
+	}
+	return time.Until(e.windowAt.Add(window))
+}
+
+// memLockoutRemaining returns how long until the hard lockout of e expires.
+func memLockoutRemaining(e *memEntry) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Until(e.lockExp)
+}
+
 func memSetLockout(e *memEntry, dur time.Duration) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -210,7 +228,9 @@ func tryRedis(c *gin.Context, cfg RateLimitConfig, attemptsKey, lockoutKey strin
 			return false
 		}
 		if val == "locked" {
-			rejectRequest(c, cfg, "Too many failed attempts. Please try again later.")
+			retryAfter := redis.Rdb.TTL(ctx, lockoutKey).Val()
+			setRateLimitHeaders(c, cfg.MaxAttempts, 0, retryAfter)
+			rejectRequest(c, cfg, "Too many failed attempts. Please try again later.", retryAfter)
 			return true
 		}
 	}
@@ -228,7 +248,9 @@ func tryRedis(c *gin.Context, cfg RateLimitConfig, attemptsKey, lockoutKey strin
 		}
 	}
 	if currentCount >= cfg.MaxAttempts {
-		rejectRequest(c, cfg, "Too many requests. Please wait a moment before trying again.")
+		retryAfter := redis.Rdb.TTL(ctx, attemptsKey).Val()
+		setRateLimitHeaders(c, cfg.MaxAttempts, 0, retryAfter)
+		rejectRequest(c, cfg, "Too many requests. Please wait a moment before trying again.", retryAfter)
 		return true
 	}
 
@@ -239,17 +261,22 @@ func tryRedis(c *gin.Context, cfg RateLimitConfig, attemptsKey, lockoutKey strin
 		return false
 	}
 	// Set TTL on first increment
+	reset := cfg.Window
 	if newCount == 1 {
 		redis.Rdb.Expire(ctx, attemptsKey, cfg.Window)
+	} else if ttl := redis.Rdb.TTL(ctx, attemptsKey).Val(); ttl > 0 {
+		reset = ttl
 	}
 
 	// 4. Check hard lockout threshold
 	if cfg.LockoutThreshold > 0 && newCount >= cfg.LockoutThreshold {
 		redis.Rdb.Set(ctx, lockoutKey, "locked", cfg.LockoutDuration)
-		rejectRequest(c, cfg, "Too many failed attempts. Your access has been temporarily locked.")
+		setRateLimitHeaders(c, cfg.MaxAttempts, 0, cfg.LockoutDuration)
+		rejectRequest(c, cfg, "Too many failed attempts. Your access has been temporarily locked.", cfg.LockoutDuration)
 		return true
 	}
 
+	setRateLimitHeaders(c, cfg.MaxAttempts, cfg.MaxAttempts-newCount, reset)
 	c.Next()
 	return true
 }
@@ -260,13 +287,17 @@ func tryMemory(c *gin.Context, cfg RateLimitConfig, fullKey string) {
 
 	// 1. Check hard lockout
 	if cfg.LockoutThreshold > 0 && memIsLocked(entry) {
-		rejectRequest(c, cfg, "Too many failed attempts. Please try again later.")
+		retryAfter := memLockoutRemaining(entry)
+		setRateLimitHeaders(c, cfg.MaxAttempts, 0, retryAfter)
+		rejectRequest(c, cfg, "Too many failed attempts. Please try again later.", retryAfter)
 		return
 	}
 
 	// 2. Check soft limit
 	if memGetAttempts(entry, cfg.Window) >= cfg.MaxAttempts {
-		rejectRequest(c, cfg, "Too many requests. Please wait a moment before trying again.")
+		retryAfter := memWindowReset(entry, cfg.Window)
+		setRateLimitHeaders(c, cfg.MaxAttempts, 0, retryAfter)
+		rejectRequest(c, cfg, "Too many requests. Please wait a moment before trying again.", retryAfter)
 		return
 	}
 
@@ -276,16 +307,39 @@ func tryMemory(c *gin.Context, cfg RateLimitConfig, fullKey string) {
 	// 4. Check hard lockout threshold
 	if cfg.LockoutThreshold > 0 && newCount >= cfg.LockoutThreshold {
 		memSetLockout(entry, cfg.LockoutDuration)
-		rejectRequest(c, cfg, "Too many failed attempts. Your access has been temporarily locked.")
+		setRateLimitHeaders(c, cfg.MaxAttempts, 0, cfg.LockoutDuration)
+		rejectRequest(c, cfg, "Too many failed attempts. Your access has been temporarily locked.", cfg.LockoutDuration)
 		return
 	}
 
+	setRateLimitHeaders(c, cfg.MaxAttempts, cfg.MaxAttempts-newCount, memWindowReset(entry, cfg.Window))
 	c.Next()
 }
 
-// rejectRequest either aborts with JSON 429 or sets a context key, depending
-// on the config.
-func rejectRequest(c *gin.Context, cfg RateLimitConfig, msg string) {
+// setRateLimitHeaders writes the RateLimit-* response headers
+// (draft-ietf-httpapi-ratelimit-headers) describing the current window.
+func setRateLimitHeaders(c *gin.Context, limit, remaining int64, reset time.Duration) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("RateLimit-Limit", strconv.FormatInt(limit, 10))
+	c.Header("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	c.Header("RateLimit-Reset", strconv.FormatInt(ceilSeconds(reset), 10))
+}
+
+// ceilSeconds rounds d up to whole seconds, never returning less than 1 so
+// clients always wait before retrying.
+func ceilSeconds(d time.Duration) int64 {
+	secs := int64((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+// rejectRequest either aborts with JSON 429 (including a Retry-After header)
+// or sets a context key, depending on the config.
+func rejectRequest(c *gin.Context, cfg RateLimitConfig, msg string, retryAfter time.Duration) {
 	if cfg.UseContextKey {
 		// GUI mode: let the downstream handler render the error.
 		c.Set(web.RateLimitErrorKey, msg)
@@ -293,6 +347,7 @@ func rejectRequest(c *gin.Context, cfg RateLimitConfig, msg string) {
 		return
 	}
 	// API mode: abort with JSON.
+	c.Header("Retry-After", strconv.FormatInt(ceilSeconds(retryAfter), 10))
 	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 		"error": msg,
 	})
@@ -375,6 +430,18 @@ func APIResendVerificationRateLimit() gin.HandlerFunc {
 	})
 }
 
+// APIVerifyEmailCodeRateLimit — 10 requests/min per IP, lockout after 20.
+// Each code also allows only a few attempts (EMAIL_VERIFICATION_CODE_MAX_ATTEMPTS).
+func APIVerifyEmailCodeRateLimit() gin.HandlerFunc {
+	return RateLimitMiddleware(RateLimitConfig{
+		KeyPrefix:        "api:verify-email-code",
+		MaxAttempts:      10,
+		Window:           60 * time.Second,
+		LockoutThreshold: 20,
+		LockoutDuration:  15 * time.Minute,
+	})
+}
+
 // APIRefreshTokenRateLimit — 10 requests/min per IP
 func APIRefreshTokenRateLimit() gin.HandlerFunc {
 	return RateLimitMiddleware(RateLimitConfig{
@@ -428,6 +495,18 @@ func APIPasskey2FARateLimit() gin.HandlerFunc {
 	})
 }
 
+// APIReauthRateLimit — 10 requests/min per IP, lockout after 20
+// (re-authentication challenges: begin + verify)
+func APIReauthRateLimit() gin.HandlerFunc {
+	return RateLimitMiddleware(RateLimitConfig{
+		KeyPrefix:        "api:reauth",
+		MaxAttempts:      10,
+		Window:           60 * time.Second,
+		LockoutThreshold: 20,
+		LockoutDuration:  15 * time.Minute,
+	})
+}
+
 // APIMagicLinkRateLimit — 5 requests per 15 minutes per IP
 // Magic links are sensitive (email-based auth), so use a tighter window.
 func APIMagicLinkRateLimit() gin.HandlerFunc {
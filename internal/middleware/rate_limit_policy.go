@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// ---------------------------------------------------------------------------
+// Declarative rate-limit policies
+// ---------------------------------------------------------------------------
+
+// RateLimitKeyBy selects which identity a policy counts requests against.
+type RateLimitKeyBy string
+
+const (
+	// RateLimitByIP counts requests per client IP.
+	RateLimitByIP RateLimitKeyBy = "ip"
+	// RateLimitByUser counts requests per authenticated user (falls back to IP).
+	RateLimitByUser RateLimitKeyBy = "user"
+	// RateLimitByApp counts requests per application (falls back to IP).
+	RateLimitByApp RateLimitKeyBy = "app"
+	// RateLimitByAPIKey counts requests per admin/app API key (falls back to IP).
+	RateLimitByAPIKey RateLimitKeyBy = "api_key"
+)
+
+// Policy names applied to route groups in cmd/api/main.go.
+const (
+	PolicyPublicAuth = "public_auth"
+	PolicyUserAPI    = "user_api"
+	PolicyAdminAPI   = "admin_api"
+	PolicyAppAPI     = "app_api"
+	PolicyEmailSend  = "email_send"
+)
+
+// RateLimitPolicy is a named, configurable request quota for a route group.
+// Each field can be overridden at startup via environment variables:
+//
+//	RATE_LIMIT_<NAME>_LIMIT   — requests allowed per window (0 disables the policy)
+//	RATE_LIMIT_<NAME>_WINDOW  — window duration, e.g. "1m", "30s"
+//	RATE_LIMIT_<NAME>_KEY_BY  — one of ip, user, app, api_key
+//
+// where <NAME> is the upper-cased policy name (e.g. RATE_LIMIT_ADMIN_API_LIMIT).
+type RateLimitPolicy struct {
+	Name   string
+	KeyBy  RateLimitKeyBy
+	Limit  int64
+	Window time.Duration
+}
+
+// defaultRateLimitPolicies are the built-in quotas. They are intentionally
+// generous: the tighter per-endpoint limiters (APILoginRateLimit etc.) remain
+// in place and fire first for sensitive endpoints.
+var defaultRateLimitPolicies = map[string]RateLimitPolicy{
+	PolicyPublicAuth: {Name: PolicyPublicAuth, KeyBy: RateLimitByIP, Limit: 120, Window: time.Minute},
+	PolicyUserAPI:    {Name: PolicyUserAPI, KeyBy: RateLimitByUser, Limit: 300, Window: time.Minute},
+	PolicyAdminAPI:   {Name: PolicyAdminAPI, KeyBy: RateLimitByAPIKey, Limit: 600, Window: time.Minute},
+	PolicyAppAPI:     {Name: PolicyAppAPI, KeyBy: RateLimitByApp, Limit: 300, Window: time.Minute},
+	PolicyEmailSend:  {Name: PolicyEmailSend, KeyBy: RateLimitByApp, Limit: 30, Window: time.Minute},
+}
+
+// ResolveRateLimitPolicy returns the named policy with any environment
+// overrides applied. Unknown names resolve to an IP-keyed policy with no
+// limit unless RATE_LIMIT_<NAME>_LIMIT is set.
+func ResolveRateLimitPolicy(name string) RateLimitPolicy {
+	policy, ok := defaultRateLimitPolicies[name]
+	if !ok {
+		policy = RateLimitPolicy{Name: name, KeyBy: RateLimitByIP, Window: time.Minute}
+	}
+
+	envPrefix := "RATE_LIMIT_" + strings.ToUpper(name)
+	if viper.IsSet(envPrefix + "_LIMIT") {
+		policy.Limit = viper.GetInt64(envPrefix + "_LIMIT")
+	}
+	if raw := viper.GetString(envPrefix + "_WINDOW"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			policy.Window = d
+		}
+	}
+	switch by := RateLimitKeyBy(strings.ToLower(viper.GetString(envPrefix + "_KEY_BY"))); by {
+	case RateLimitByIP, RateLimitByUser, RateLimitByApp, RateLimitByAPIKey:
+		policy.KeyBy = by
+	}
+	return policy
+}
+
+// PolicyRateLimit returns a middleware enforcing the named policy. Rejected
+// requests receive a JSON 429 with Retry-After; every response carries the
+// RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset headers. A policy
+// with a non-positive limit is disabled and passes all requests through.
+func PolicyRateLimit(name string) gin.HandlerFunc {
+	policy := ResolveRateLimitPolicy(name)
+	if policy.Limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return RateLimitMiddleware(RateLimitConfig{
+		KeyPrefix:   "policy:" + policy.Name,
+		KeyFunc:     RateLimitKeyFunc(policy.KeyBy),
+		MaxAttempts: policy.Limit,
+		Window:      policy.Window,
+	})
+}
+
+// RateLimitKeyFunc returns the request identity extractor for the given
+// KeyBy mode. Identities are namespaced ("user:…", "app:…") so that a
+// fallback to IP never collides with a real user or app identifier.
+func RateLimitKeyFunc(by RateLimitKeyBy) func(c *gin.Context) string {
+	switch by {
+	case RateLimitByUser:
+		return func(c *gin.Context) string {
+			if userID := c.GetString("userID"); userID != "" {
+				return "user:" + userID
+			}
+			return "ip:" + c.ClientIP()
+		}
+	case RateLimitByApp:
+		return func(c *gin.Context) string {
+			if appID := rateLimitAppID(c); appID != "" {
+				return "app:" + appID
+			}
+			return "ip:" + c.ClientIP()
+		}
+	case RateLimitByAPIKey:
+		return func(c *gin.Context) string {
+			key := c.GetHeader("X-Admin-API-Key")
+			if key == "" {
+				key = c.GetHeader("X-App-API-Key")
+			}
+			if key != "" {
+				// Never store raw key material in Redis key names.
+				h := sha256.Sum256([]byte(key))
+				return "key:" + hex.EncodeToString(h[:8])
+			}
+			return "ip:" + c.ClientIP()
+		}
+	default:
+		return func(c *gin.Context) string {
+			return "ip:" + c.ClientIP()
+		}
+	}
+}
+
+// rateLimitAppID resolves the application a request targets: the value set by
+// AppIDMiddleware, then the ":id" / ":app_id" route params, then X-App-ID.
+func rateLimitAppID(c *gin.Context) string {
+	if v, ok := c.Get(AppIDKey); ok {
+		if id, ok := v.(uuid.UUID); ok {
+			return id.String()
+		}
+		return fmt.Sprint(v)
+	}
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	if id := c.Param("app_id"); id != "" {
+		return id
+	}
+	return c.GetHeader(HeaderAppID)
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/internal/redis"
 	"github.com/gjovanovicst/auth_api/web"
+	"github.com/spf13/viper"
 )
 
 // ---------------------------------------------------------------------------
@@ -78,6 +80,7 @@ func clearFallback() {
 	clearRateLimitState(
 		"test:under", "test:over", "test:lockout",
 		"test:ctxkey", "test:customkey", "test:window",
+		"test:headers", "policy:test_policy",
 		"gui:login",
 	)
 }
@@ -516,3 +519,164 @@ func TestLoginRateLimitUsesContextKey(t *testing.T) {
 		t.Error("LoginRateLimitMiddleware should use context key mode, but rate limit error key was not set")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// RateLimit-* / Retry-After header tests
+// ---------------------------------------------------------------------------
+
+func TestRateLimitHeaders(t *testing.T) {
+	clearFallback()
+
+	cfg := RateLimitConfig{
+		KeyPrefix:   "test:headers",
+		MaxAttempts: 2,
+		Window:      60 * time.Second,
+	}
+	r := newTestRouter(RateLimitMiddleware(cfg))
+
+	w := doRequest(r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("expected RateLimit-Limit 2, got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "1" {
+		t.Errorf("expected RateLimit-Remaining 1, got %q", got)
+	}
+	reset, err := strconv.Atoi(w.Header().Get("RateLimit-Reset"))
+	if err != nil || reset < 1 || reset > 60 {
+		t.Errorf("expected RateLimit-Reset within (0, 60], got %q", w.Header().Get("RateLimit-Reset"))
+	}
+
+	doRequest(r)
+	w = doRequest(r)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected RateLimit-Remaining 0 on 429, got %q", got)
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("expected Retry-After within (0, 60], got %q", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestRateLimitNoRetryAfterInContextKeyMode(t *testing.T) {
+	clearFallback()
+
+	cfg := RateLimitConfig{
+		KeyPrefix:     "test:ctxkey",
+		MaxAttempts:   1,
+		Window:        60 * time.Second,
+		UseContextKey: true,
+	}
+	r := newTestRouter(RateLimitMiddleware(cfg))
+
+	doRequest(r)
+	w := doRequest(r)
+	if w.Header().Get("Retry-After") != "" {
+		t.Error("context-key (GUI) mode should not set Retry-After on a 200 response")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Declarative policy tests
+// ---------------------------------------------------------------------------
+
+func TestResolveRateLimitPolicyDefaults(t *testing.T) {
+	policy := ResolveRateLimitPolicy(PolicyEmailSend)
+	if policy.KeyBy != RateLimitByApp {
+		t.Errorf("expected email_send to be keyed by app, got %q", policy.KeyBy)
+	}
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		t.Errorf("expected positive default limit/window, got %d/%s", policy.Limit, policy.Window)
+	}
+}
+
+func TestResolveRateLimitPolicyEnvOverride(t *testing.T) {
+	viper.Set("RATE_LIMIT_TEST_POLICY_LIMIT", 7)
+	viper.Set("RATE_LIMIT_TEST_POLICY_WINDOW", "30s")
+	viper.Set("RATE_LIMIT_TEST_POLICY_KEY_BY", "user")
+	defer func() {
+		viper.Set("RATE_LIMIT_TEST_POLICY_LIMIT", nil)
+		viper.Set("RATE_LIMIT_TEST_POLICY_WINDOW", nil)
+		viper.Set("RATE_LIMIT_TEST_POLICY_KEY_BY", nil)
+	}()
+
+	policy := ResolveRateLimitPolicy("test_policy")
+	if policy.Limit != 7 {
+		t.Errorf("expected limit 7, got %d", policy.Limit)
+	}
+	if policy.Window != 30*time.Second {
+		t.Errorf("expected window 30s, got %s", policy.Window)
+	}
+	if policy.KeyBy != RateLimitByUser {
+		t.Errorf("expected key_by user, got %q", policy.KeyBy)
+	}
+}
+
+func TestPolicyRateLimitDisabledWithoutLimit(t *testing.T) {
+	clearFallback()
+
+	r := newTestRouter(PolicyRateLimit("test_policy"))
+	for i := 0; i < 5; i++ {
+		w := doRequest(r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 from disabled policy, got %d", i+1, w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatal("disabled policy should not emit RateLimit headers")
+		}
+	}
+}
+
+func TestPolicyRateLimitKeyedByApp(t *testing.T) {
+	clearFallback()
+	viper.Set("RATE_LIMIT_TEST_POLICY_LIMIT", 1)
+	viper.Set("RATE_LIMIT_TEST_POLICY_KEY_BY", "app")
+	defer func() {
+		viper.Set("RATE_LIMIT_TEST_POLICY_LIMIT", nil)
+		viper.Set("RATE_LIMIT_TEST_POLICY_KEY_BY", nil)
+	}()
+
+	r := newTestRouter(PolicyRateLimit("test_policy"))
+	send := func(appID string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/test", nil)
+		req.Header.Set(HeaderAppID, appID)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	appA := "00000000-0000-0000-0000-00000000000a"
+	appB := "00000000-0000-0000-0000-00000000000b"
+	if code := send(appA); code != http.StatusOK {
+		t.Fatalf("app A first request: expected 200, got %d", code)
+	}
+	if code := send(appA); code != http.StatusTooManyRequests {
+		t.Fatalf("app A second request: expected 429, got %d", code)
+	}
+	// A different app from the same IP has its own quota.
+	if code := send(appB); code != http.StatusOK {
+		t.Fatalf("app B first request: expected 200, got %d", code)
+	}
+}
+
+func TestRateLimitKeyFuncAPIKeyIsHashed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("X-Admin-API-Key", "super-secret-admin-key")
+
+	key := RateLimitKeyFunc(RateLimitByAPIKey)(c)
+	if key == "" || key == "key:super-secret-admin-key" {
+		t.Fatalf("expected hashed API key identity, got %q", key)
+	}
+
+	c.Request.Header.Del("X-Admin-API-Key")
+	if key := RateLimitKeyFunc(RateLimitByAPIKey)(c); key[:3] != "ip:" {
+		t.Fatalf("expected IP fallback without API key, got %q", key)
+	}
+}
@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// RequestLimits are the size and content limits RequestLimitsMiddleware
+// enforces. A zero or negative value disables that limit.
+type RequestLimits struct {
+	MaxBodyBytes         int64 // JSON, form and other non-multipart bodies
+	MaxUploadBytes       int64 // multipart/form-data bodies (avatars, CSV imports)
+	MaxHeaders           int   // header values, counting repeated headers
+	MaxFieldLength       int   // characters in any string value
+	MaxNameLength        int   // characters in name fields (see nameFields)
+	MaxDescriptionLength int   // characters in description fields
+}
+
+// nameFields and descriptionFields get the tighter length caps, matched by
+// JSON key or form field name at any depth.
+var (
+	nameFields        = map[string]bool{"name": true, "first_name": true, "last_name": true, "display_name": true, "username": true}
+	descriptionFields = map[string]bool{"description": true}
+)
+
+// RequestLimitsFromConfig reads the limits from REQUEST_MAX_BODY_BYTES,
+// REQUEST_MAX_UPLOAD_BYTES, REQUEST_MAX_HEADERS, REQUEST_MAX_FIELD_LENGTH,
+// REQUEST_MAX_NAME_LENGTH and REQUEST_MAX_DESCRIPTION_LENGTH.
+func RequestLimitsFromConfig() RequestLimits {
+	return RequestLimits{
+		MaxBodyBytes:         viper.GetInt64("REQUEST_MAX_BODY_BYTES"),
+		MaxUploadBytes:       viper.GetInt64("REQUEST_MAX_UPLOAD_BYTES"),
+		MaxHeaders:           viper.GetInt("REQUEST_MAX_HEADERS"),
+		MaxFieldLength:       viper.GetInt("REQUEST_MAX_FIELD_LENGTH"),
+		MaxNameLength:        viper.GetInt("REQUEST_MAX_NAME_LENGTH"),
+		MaxDescriptionLength: viper.GetInt("REQUEST_MAX_DESCRIPTION_LENGTH"),
+	}
+}
+
+// RequestLimitsMiddleware rejects oversized or malformed input before it
+// reaches a handler:
+//   - more header values than MaxHeaders: 431
+//   - a body larger than MaxBodyBytes (MaxUploadBytes for multipart): 413
+//   - query parameters, JSON string values (keys included) and urlencoded
+//     form values that are not valid UTF-8, contain NUL characters (which
+//     PostgreSQL cannot store) or exceed their length cap: 400
+//
+// JSON and urlencoded bodies are read up to the limit, checked and put back
+// for the handler. Multipart bodies are only size-limited. Malformed JSON is
+// passed through, so handlers keep reporting their own binding errors. All
+// checks run in linear time; no input is matched against a regular
+// expression. Limits are read from the config on every request.
+func RequestLimitsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, msg := RequestLimitsFromConfig().check(c.Writer, c.Request)
+		if status != 0 {
+			RespondError(c, status, msg)
+			return
+		}
+		c.Next()
+	}
+}
+
+// check enforces the limits on r, replacing its body with a bounded one. It
+// returns the status and message to reject the request with, or 0.
+func (l RequestLimits) check(w http.ResponseWriter, r *http.Request) (int, string) {
+	if l.MaxHeaders > 0 {
+		n := 0
+		for _, values := range r.Header {
+			n += len(values)
+		}
+		if n > l.MaxHeaders {
+			return http.StatusRequestHeaderFieldsTooLarge, "Too many request headers"
+		}
+	}
+
+	if err := l.checkValues(r.URL.Query()); err != nil {
+		return http.StatusBadRequest, "Invalid query parameter: " + err.Error()
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return 0, ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	limit := l.MaxBodyBytes
+	if mediaType == "multipart/form-data" {
+		limit = l.MaxUploadBytes
+	}
+	if limit > 0 {
+		if r.ContentLength > limit {
+			return http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", limit)
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if !isJSON && mediaType != "application/x-www-form-urlencoded" {
+		return 0, ""
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", limit)
+		}
+		return http.StatusBadRequest, "Failed to read request body"
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !utf8.Valid(body) {
+		return http.StatusBadRequest, "Request body is not valid UTF-8"
+	}
+	if isJSON {
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if dec.Decode(&v) != nil {
+			return 0, "" // Left to the handler's binding
+		}
+		err = l.checkJSON("", v)
+	} else {
+		var form url.Values
+		if form, err = url.ParseQuery(string(body)); err != nil {
+			return 0, "" // Left to the handler's form parsing
+		}
+		err = l.checkValues(form)
+	}
+	if err != nil {
+		return http.StatusBadRequest, "Invalid request body: " + err.Error()
+	}
+	return 0, ""
+}
+
+// checkJSON checks every key and string value of a decoded JSON document.
+// key is the object key v was found under.
+func (l RequestLimits) checkJSON(key string, v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		return l.checkString(key, v)
+	case []interface{}:
+		for _, item := range v {
+			if err := l.checkJSON(key, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			if err := l.checkString("", k); err != nil {
+				return fmt.Errorf("key: %w", err)
+			}
+			if err := l.checkJSON(k, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkValues checks the names and values of query parameters or form fields.
+func (l RequestLimits) checkValues(values url.Values) error {
+	for key, vs := range values {
+		if err := l.checkString("", key); err != nil {
+			return fmt.Errorf("name: %w", err)
+		}
+		for _, v := range vs {
+			if err := l.checkString(key, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkString checks one value found under key ("" for keys themselves).
+func (l RequestLimits) checkString(key, s string) error {
+	what := "value"
+	if key != "" {
+		what = fmt.Sprintf("field %q", key)
+	}
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("%s is not valid UTF-8", what)
+	}
+	if strings.IndexByte(s, 0) >= 0 {
+		return fmt.Errorf("%s contains a NUL character", what)
+	}
+	max := l.MaxFieldLength
+	field := strings.ToLower(key)
+	if nameFields[field] {
+		max = tighter(max, l.MaxNameLength)
+	} else if descriptionFields[field] {
+		max = tighter(max, l.MaxDescriptionLength)
+	}
+	// Byte length bounds the character count, so most values skip counting
+	if max > 0 && len(s) > max && utf8.RuneCountInString(s) > max {
+		return fmt.Errorf("%s exceeds %d characters", what, max)
+	}
+	return nil
+}
+
+// tighter returns the smaller of two limits, where zero or less is no limit.
+func tighter(a, b int) int {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
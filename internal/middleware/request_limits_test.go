@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// doLimitedRequest sends a request through RequestLimitsMiddleware with small
+// limits and returns the response; the handler echoes the body it received.
+func doLimitedRequest(t *testing.T, method, target, contentType, body string, headers int) *httptest.ResponseRecorder {
+	t.Helper()
+	for key, value := range map[string]int{
+		"REQUEST_MAX_BODY_BYTES":         64,
+		"REQUEST_MAX_UPLOAD_BYTES":       128,
+		"REQUEST_MAX_HEADERS":            10,
+		"REQUEST_MAX_FIELD_LENGTH":       40,
+		"REQUEST_MAX_NAME_LENGTH":        8,
+		"REQUEST_MAX_DESCRIPTION_LENGTH": 16,
+	} {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+
+	r := gin.New()
+	r.Use(RequestLimitsMiddleware())
+	r.Any("/*any", func(c *gin.Context) {
+		b, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(b))
+	})
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i < headers; i++ {
+		req.Header.Add("X-Extra", "1")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestLimitsAllowsValidInput(t *testing.T) {
+	body := `{"name":"Zoë","tags":["a","b"],"n":1}`
+	w := doLimitedRequest(t, http.MethodPost, "/register?q=ok", "application/json", body, 0)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s), want 200", w.Code, w.Body.String())
+	}
+	if w.Body.String() != body {
+		t.Errorf("handler got body %q, want %q", w.Body.String(), body)
+	}
+}
+
+func TestRequestLimitsRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		headers     int
+		want        int
+	}{
+		{"too many headers", "/", "", "", 20, http.StatusRequestHeaderFieldsTooLarge},
+		{"JSON body too large", "/", "application/json", `{"a":"` + strings.Repeat("x", 100) + `"}`, 0, http.StatusRequestEntityTooLarge},
+		{"upload too large", "/", "multipart/form-data; boundary=x", strings.Repeat("x", 200), 0, http.StatusRequestEntityTooLarge},
+		{"invalid UTF-8 body", "/", "application/json", "{\"a\":\"\xff\"}", 0, http.StatusBadRequest},
+		{"escaped NUL", "/", "application/json", `{"a":"x\u0000y"}`, 0, http.StatusBadRequest},
+		{"long name", "/", "application/json", `{"user":{"first_name":"Alexander"}}`, 0, http.StatusBadRequest},
+		{"long description", "/", "application/json", `{"description":"` + strings.Repeat("d", 17) + `"}`, 0, http.StatusBadRequest},
+		{"long value in array", "/", "application/json", `{"a":["` + strings.Repeat("v", 41) + `"]}`, 0, http.StatusBadRequest},
+		{"long form name", "/", "application/x-www-form-urlencoded", "name=" + strings.Repeat("n", 9), 0, http.StatusBadRequest},
+		{"invalid UTF-8 query", "/?q=%ff", "", "", 0, http.StatusBadRequest},
+		{"long query value", "/?q=" + strings.Repeat("q", 41), "", "", 0, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		method := http.MethodPost
+		if tt.body == "" {
+			method = http.MethodGet
+		}
+		w := doLimitedRequest(t, method, tt.target, tt.contentType, tt.body, tt.headers)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d (%s), want %d", tt.name, w.Code, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestRequestLimitsCountsCharacters(t *testing.T) {
+	// 8 characters, 16 bytes
+	w := doLimitedRequest(t, http.MethodPost, "/", "application/json", `{"name":"ŽžŽžŽžŽž"}`, 0)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d (%s), want 200", w.Code, w.Body.String())
+	}
+}
+
+func TestRequestLimitsPassesMalformedJSON(t *testing.T) {
+	w := doLimitedRequest(t, http.MethodPost, "/", "application/json", `{"name":`, 0)
+	if w.Code != http.StatusOK || w.Body.String() != `{"name":` {
+		t.Errorf("status = %d, body %q; want the malformed body passed to the handler", w.Code, w.Body.String())
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -77,3 +78,41 @@ func HasScope(c *gin.Context, required string) bool {
 	}
 	return false
 }
+
+// RequireScope rejects requests whose API key lacks the scope (see HasScope)
+// with 403 Forbidden.
+func RequireScope(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasScope(c, required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + required + " scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasExplicitScope checks whether the validated API key was granted the
+// required scope by name. Unlike HasScope, wildcards ("*", "resource:*") do
+// not cover it, and a request without API key scopes is denied: scopes such
+// as users:impersonate must never be granted implicitly.
+func HasExplicitScope(c *gin.Context, required string) bool {
+	granted, _ := c.Value(web.ApiKeyScopesKey).([]string)
+	for _, g := range granted {
+		if g == required {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireExplicitScope rejects requests whose API key was not granted the
+// scope by name (see HasExplicitScope) with 403 Forbidden.
+func RequireExplicitScope(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasExplicitScope(c, required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + required + " scope (wildcards do not grant it)"})
+			return
+		}
+		c.Next()
+	}
+}
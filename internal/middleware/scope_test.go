@@ -220,3 +220,49 @@ func TestHasScope_MultipleGranted_NoneMatch(t *testing.T) {
 		t.Error("expected false when required scope is absent from granted list")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// RequireScope unit tests
+// ---------------------------------------------------------------------------
+
+// TestRequireScope: requests pass with the scope and are rejected with 403 without it.
+func TestRequireScope(t *testing.T) {
+	c, w := newTestContext()
+	c.Set(web.ApiKeyScopesKey, []string{"users:read"})
+	RequireScope("users:impersonate")(c)
+	if !c.IsAborted() || w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without the scope, got aborted=%v status=%d", c.IsAborted(), w.Code)
+	}
+
+	c, _ = newTestContext()
+	c.Set(web.ApiKeyScopesKey, []string{"users:*"})
+	RequireScope("users:impersonate")(c)
+	if c.IsAborted() {
+		t.Error("expected request with 'users:*' to pass")
+	}
+}
+
+func TestRequireExplicitScope(t *testing.T) {
+	for _, granted := range [][]string{{"*"}, {"users:*"}, {"users:read"}, {}} {
+		c, w := newTestContext()
+		c.Set(web.ApiKeyScopesKey, granted)
+		RequireExplicitScope("users:impersonate")(c)
+		if !c.IsAborted() || w.Code != http.StatusForbidden {
+			t.Errorf("scopes %v: expected 403, got aborted=%v status=%d", granted, c.IsAborted(), w.Code)
+		}
+	}
+
+	// Without API key scopes (static admin key) the scope is not granted either
+	c, _ := newTestContext()
+	RequireExplicitScope("users:impersonate")(c)
+	if !c.IsAborted() {
+		t.Error("expected request without API key scopes to be rejected")
+	}
+
+	c, _ = newTestContext()
+	c.Set(web.ApiKeyScopesKey, []string{"users:read", "users:impersonate"})
+	RequireExplicitScope("users:impersonate")(c)
+	if c.IsAborted() {
+		t.Error("expected request with 'users:impersonate' to pass")
+	}
+}
@@ -73,6 +73,18 @@ func SecurityHeadersMiddleware() gin.HandlerFunc {
 				"frame-ancestors 'none'",
 				"base-uri 'self'",
 			}, "; "))
+		} else if path == "/unsubscribe" {
+			// Unsubscribe page linked from emails — assets from /gui/static/* and the
+			// same auto-theme inline script as the OIDC pages; its form posts to itself
+			h.Set("Content-Security-Policy", strings.Join([]string{
+				"default-src 'self'",
+				"script-src 'self' 'unsafe-inline'",
+				"style-src 'self' 'unsafe-inline'",
+				"img-src 'self' data:",
+				"frame-ancestors 'none'",
+				"form-action 'self'",
+				"base-uri 'self'",
+			}, "; "))
 		} else if strings.HasPrefix(path, "/swagger") {
 			// Swagger UI — needs inline scripts/styles and to fetch its own JSON spec
 			h.Set("Content-Security-Policy", strings.Join([]string{
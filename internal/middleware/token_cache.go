@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/spf13/viper"
+)
+
+// tokenCache is a fixed-size LRU of successfully verified JWTs, keyed by the
+// SHA-256 hash of the token, so repeated requests with the same token skip
+// parsing and signature verification. Entries expire with their token and are
+// dropped when the accepted signing keys change (jwt.KeyGeneration), so a
+// deleted key stops validating cached tokens too. Revocation checks are not
+// cached and still run on every request.
+type tokenCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[[sha256.Size]byte]*list.Element
+	order    *list.List // front = most recently used
+}
+
+// tokenCacheEntry is a cached verification result.
+type tokenCacheEntry struct {
+	key       [sha256.Size]byte
+	claims    *jwt.Claims
+	expiresAt time.Time
+	keyGen    uint64 // jwt.KeyGeneration when the token was verified
+}
+
+var (
+	sharedTokenCache     *tokenCache
+	sharedTokenCacheOnce sync.Once
+)
+
+// verifiedTokenCache returns the process-wide cache sized by JWT_CACHE_SIZE,
+// or nil when caching is disabled (size 0).
+func verifiedTokenCache() *tokenCache {
+	sharedTokenCacheOnce.Do(func() {
+		if size := viper.GetInt("JWT_CACHE_SIZE"); size > 0 {
+			sharedTokenCache = newTokenCache(size)
+		}
+	})
+	return sharedTokenCache
+}
+
+// newTokenCache returns a cache holding at most capacity tokens.
+func newTokenCache(capacity int) *tokenCache {
+	return &tokenCache{
+		capacity: capacity,
+		entries:  make(map[[sha256.Size]byte]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// parseToken returns the claims of tokenString, verifying it only when it is
+// not cached. A nil cache always verifies.
+func (tc *tokenCache) parseToken(tokenString string) (*jwt.Claims, error) {
+	if tc == nil {
+		return jwt.ParseToken(tokenString)
+	}
+	key := sha256.Sum256([]byte(tokenString))
+	// Read before verifying, so a key change during verification drops the entry
+	keyGen := jwt.KeyGeneration()
+	if claims, ok := tc.get(key, time.Now(), keyGen); ok {
+		return claims, nil
+	}
+	claims, err := jwt.ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	// Tokens without an expiry are verified every time
+	if claims.ExpiresAt != nil {
+		tc.add(key, claims, claims.ExpiresAt.Time, keyGen)
+	}
+	return claims, nil
+}
+
+// get returns the cached claims for key if present, not expired at now and
+// verified under key generation keyGen.
+func (tc *tokenCache) get(key [sha256.Size]byte, now time.Time, keyGen uint64) (*jwt.Claims, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	elem, ok := tc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*tokenCacheEntry)
+	if !now.Before(entry.expiresAt) || entry.keyGen != keyGen {
+		tc.order.Remove(elem)
+		delete(tc.entries, key)
+		return nil, false
+	}
+	tc.order.MoveToFront(elem)
+	return entry.claims, true
+}
+
+// add caches claims verified under key generation keyGen under key until
+// expiresAt, evicting the least recently used entry when the cache is full.
+func (tc *tokenCache) add(key [sha256.Size]byte, claims *jwt.Claims, expiresAt time.Time, keyGen uint64) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if elem, ok := tc.entries[key]; ok {
+		entry := elem.Value.(*tokenCacheEntry)
+		entry.claims, entry.expiresAt, entry.keyGen = claims, expiresAt, keyGen
+		tc.order.MoveToFront(elem)
+		return
+	}
+	if tc.order.Len() >= tc.capacity {
+		oldest := tc.order.Back()
+		tc.order.Remove(oldest)
+		delete(tc.entries, oldest.Value.(*tokenCacheEntry).key)
+	}
+	tc.entries[key] = tc.order.PushFront(&tokenCacheEntry{key: key, claims: claims, expiresAt: expiresAt, keyGen: keyGen})
+}
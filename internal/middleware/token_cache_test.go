@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+)
+
+func TestTokenCacheParseToken(t *testing.T) {
+	token, err := jwt.GenerateAccessToken("test-app-id", "test-user-id", "", nil, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+
+	tc := newTokenCache(2)
+	first, err := tc.parseToken(token)
+	if err != nil {
+		t.Fatalf("parseToken() error = %v", err)
+	}
+	second, err := tc.parseToken(token)
+	if err != nil {
+		t.Fatalf("parseToken() error = %v on the cached token", err)
+	}
+	if first != second {
+		t.Error("second parseToken() did not return the cached claims")
+	}
+
+	if _, err := tc.parseToken(token + "x"); err == nil {
+		t.Error("parseToken() accepted a token with a bad signature")
+	}
+	if n := tc.order.Len(); n != 1 {
+		t.Errorf("cache holds %d tokens, want 1 (invalid tokens are not cached)", n)
+	}
+
+	var nilCache *tokenCache
+	if _, err := nilCache.parseToken(token); err != nil {
+		t.Errorf("parseToken() without a cache error = %v", err)
+	}
+}
+
+func TestTokenCacheEvictionAndExpiry(t *testing.T) {
+	now := time.Now()
+	key := func(s string) [sha256.Size]byte { return sha256.Sum256([]byte(s)) }
+	claims := &jwt.Claims{UserID: "u"}
+
+	tc := newTokenCache(2)
+	tc.add(key("a"), claims, now.Add(time.Minute), 0)
+	tc.add(key("b"), claims, now.Add(time.Minute), 0)
+	tc.get(key("a"), now, 0) // "b" becomes least recently used
+	tc.add(key("c"), claims, now.Add(time.Minute), 0)
+
+	if _, ok := tc.get(key("b"), now, 0); ok {
+		t.Error("least recently used token was not evicted")
+	}
+	if _, ok := tc.get(key("a"), now, 0); !ok {
+		t.Error("recently used token was evicted")
+	}
+	if _, ok := tc.get(key("c"), now.Add(2*time.Minute), 0); ok {
+		t.Error("expired token was returned")
+	}
+	if n := tc.order.Len(); n != 1 {
+		t.Errorf("cache holds %d tokens after expiry, want 1", n)
+	}
+}
+
+func TestTokenCacheDropsTokensOfDeletedKeys(t *testing.T) {
+	key, err := jwt.GenerateSigningKey(jwt.AlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.ID = "test-deleted-key"
+	jwt.SetSigningKeys(key, nil)
+	defer jwt.SetSigningKeys(nil, nil)
+
+	token, err := jwt.GenerateAccessToken("test-app-id", "test-user-id", "", nil, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+	tc := newTokenCache(2)
+	if _, err := tc.parseToken(token); err != nil {
+		t.Fatalf("parseToken() error = %v", err)
+	}
+
+	// The key is deleted: back to HS256, the key is no longer loaded
+	jwt.SetSigningKeys(nil, nil)
+	if _, err := tc.parseToken(token); err == nil {
+		t.Error("parseToken() accepted a cached token signed with a deleted key")
+	}
+}
+
+func TestTokenCacheKeyGeneration(t *testing.T) {
+	now := time.Now()
+	key := sha256.Sum256([]byte("a"))
+	tc := newTokenCache(2)
+	tc.add(key, &jwt.Claims{UserID: "u"}, now.Add(time.Minute), 1)
+	if _, ok := tc.get(key, now, 2); ok {
+		t.Error("token verified under an older key generation was returned")
+	}
+	if n := tc.order.Len(); n != 0 {
+		t.Errorf("cache holds %d tokens, want 0 (stale entry dropped)", n)
+	}
+}
@@ -0,0 +1,305 @@
+// Package migrationguard checks SQL migrations for operations that lock busy
+// tables in production, and splits migrations into statements so that those
+// building indexes CONCURRENTLY can run outside a transaction.
+package migrationguard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rules reported by Lint. A rule can be acknowledged for one migration with a
+// "-- migration-guard:ignore <rule>" comment in the file.
+const (
+	// RuleNotNullWithoutDefault: ADD COLUMN ... NOT NULL without a DEFAULT
+	// fails on a table with rows, and a table rewrite is needed to fix it.
+	RuleNotNullWithoutDefault = "not-null-without-default"
+	// RuleSetNotNull: ALTER COLUMN ... SET NOT NULL scans the whole table
+	// while holding an exclusive lock.
+	RuleSetNotNull = "set-not-null"
+	// RuleBlockingIndex: CREATE INDEX without CONCURRENTLY blocks writes to
+	// the table until the index is built.
+	RuleBlockingIndex = "blocking-index"
+	// RuleConcurrentlyInTransaction: CREATE/DROP INDEX CONCURRENTLY cannot
+	// run inside a transaction block.
+	RuleConcurrentlyInTransaction = "concurrently-in-transaction"
+)
+
+// Finding severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is one unsafe operation found in a migration.
+type Finding struct {
+	Rule      string
+	Severity  string
+	Table     string // Table the statement locks; empty when not table-specific
+	Statement string // The statement, whitespace-collapsed and shortened
+	Message   string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s [%s] %s: %s", f.Severity, f.Rule, f.Message, f.Statement)
+}
+
+// LargeTableFunc reports whether an existing table is large enough for a
+// locking operation on it to be an error rather than a warning, and whether
+// that is known. Unknown sizes are reported as warnings.
+type LargeTableFunc func(table string) (large, known bool)
+
+var (
+	createTableRe  = regexp.MustCompile(`(?i)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	createIndexRe  = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:[\w."]+\s+)?ON\s+(?:ONLY\s+)?([\w."]+)`)
+	alterTableRe   = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+(.*)$`)
+	addColumnRe    = regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?`)
+	addConstraint  = regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT|PRIMARY|UNIQUE|CHECK|FOREIGN|EXCLUDE)\b`)
+	notNullRe      = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	defaultRe      = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	setNotNullRe   = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?\S+\s+SET\s+NOT\s+NULL\b`)
+	concurrentlyRe = regexp.MustCompile(`(?i)^(?:CREATE\s+(?:UNIQUE\s+)?|DROP\s+|REINDEX\s+(?:\(.*\)\s+)?(?:INDEX|TABLE)\s+)(?:INDEX\s+)?CONCURRENTLY\b`)
+	beginRe        = regexp.MustCompile(`(?i)^(?:BEGIN|START\s+TRANSACTION)\b`)
+	commitRe       = regexp.MustCompile(`(?i)^(?:COMMIT|END|ROLLBACK)\b`)
+	ignoreRe       = regexp.MustCompile(`(?i)--[ \t]*migration-guard:ignore[ \t]+([\w-]+(?:[ \t,]+[\w-]+)*)`)
+	spaceRe        = regexp.MustCompile(`\s+`)
+)
+
+// Lint checks one migration. Operations on tables created earlier in the same
+// migration are always safe. Locking operations on existing tables are errors
+// when large reports the table as large, warnings otherwise; CONCURRENTLY
+// inside an explicit transaction is always an error.
+func Lint(sql string, large LargeTableFunc) []Finding {
+	ignored := map[string]bool{}
+	for _, m := range ignoreRe.FindAllStringSubmatch(sql, -1) {
+		for _, rule := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			ignored[strings.ToLower(rule)] = true
+		}
+	}
+
+	created := map[string]bool{}
+	inTransaction := false
+	var findings []Finding
+	report := func(rule, table, stmt, msg string, sizeDependent bool) {
+		if ignored[rule] {
+			return
+		}
+		severity := SeverityError
+		if sizeDependent {
+			severity = SeverityWarning
+			if large != nil {
+				if isLarge, known := large(table); known && isLarge {
+					severity = SeverityError
+				}
+			}
+		}
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Table: table, Statement: shorten(stmt), Message: msg})
+	}
+
+	for _, raw := range Split(sql) {
+		stmt := spaceRe.ReplaceAllString(stripComments(raw), " ")
+		switch {
+		case beginRe.MatchString(stmt):
+			inTransaction = true
+		case commitRe.MatchString(stmt):
+			inTransaction = false
+		case concurrentlyRe.MatchString(stmt) && inTransaction:
+			report(RuleConcurrentlyInTransaction, "", stmt, "CONCURRENTLY cannot run inside BEGIN ... COMMIT; move it out of the transaction", false)
+		}
+
+		if m := createTableRe.FindStringSubmatch(stmt); m != nil {
+			created[tableName(m[1])] = true
+			continue
+		}
+		if m := createIndexRe.FindStringSubmatch(stmt); m != nil {
+			table := tableName(m[2])
+			if m[1] == "" && !created[table] {
+				report(RuleBlockingIndex, table, stmt, fmt.Sprintf("index build blocks writes to %s; use CREATE INDEX CONCURRENTLY", table), true)
+			}
+			continue
+		}
+		m := alterTableRe.FindStringSubmatch(stmt)
+		if m == nil {
+			continue
+		}
+		table := tableName(m[1])
+		if created[table] {
+			continue
+		}
+		for _, action := range splitTopLevel(m[2], ',') {
+			action = strings.TrimSpace(action)
+			switch {
+			case addColumnRe.MatchString(action) && !addConstraint.MatchString(action):
+				if notNullRe.MatchString(action) && !defaultRe.MatchString(action) {
+					report(RuleNotNullWithoutDefault, table, stmt, fmt.Sprintf("NOT NULL column without DEFAULT fails on a non-empty %s; add a DEFAULT", table), true)
+				}
+			case setNotNullRe.MatchString(action):
+				report(RuleSetNotNull, table, stmt, fmt.Sprintf("SET NOT NULL scans %s under an exclusive lock; validate a CHECK (col IS NOT NULL) NOT VALID constraint first", table), true)
+			}
+		}
+	}
+	return findings
+}
+
+// HasErrors reports whether any finding is an error.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsStatementExecution reports whether a migration builds or drops an
+// index CONCURRENTLY and so has to be executed one statement at a time:
+// PostgreSQL runs a multi-statement query string in a single transaction.
+func NeedsStatementExecution(sql string) bool {
+	for _, raw := range Split(sql) {
+		if concurrentlyRe.MatchString(spaceRe.ReplaceAllString(stripComments(raw), " ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Split splits a migration into its statements, honoring quoted strings,
+// quoted identifiers, dollar-quoted bodies and comments. Statements keep
+// their comments; empty statements are dropped.
+func Split(sql string) []string {
+	var stmts []string
+	start := 0
+	flush := func(end int) {
+		if s := strings.TrimSpace(sql[start:end]); s != "" && strings.TrimSpace(stripComments(s)) != "" {
+			stmts = append(stmts, s)
+		}
+		start = end + 1
+	}
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			i = skipUntil(sql, i+2, "\n") - 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = skipUntil(sql, i+2, "*/") - 1
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '$':
+			if tag := dollarTag(sql[i:]); tag != "" {
+				i = skipUntil(sql, i+len(tag), tag) - 1
+			}
+		case c == ';':
+			flush(i)
+		}
+	}
+	if start < len(sql) {
+		flush(len(sql))
+	}
+	return stmts
+}
+
+// skipUntil returns the index just past the next end at or after i, or
+// len(s) when there is none.
+func skipUntil(s string, i int, end string) int {
+	if i > len(s) {
+		return len(s)
+	}
+	if j := strings.Index(s[i:], end); j >= 0 {
+		return i + j + len(end)
+	}
+	return len(s)
+}
+
+// skipQuoted returns the index of the quote closing the string or identifier
+// opened at i; doubled quotes are escapes.
+func skipQuoted(s string, i int, quote byte) int {
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == quote {
+			if j+1 < len(s) && s[j+1] == quote {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(s)
+}
+
+var dollarTagRe = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// dollarTag returns the dollar-quote tag ($$ or $name$) s starts with.
+func dollarTag(s string) string {
+	return dollarTagRe.FindString(s)
+}
+
+// stripComments removes comments from a statement, outside quotes.
+func stripComments(stmt string) string {
+	var b strings.Builder
+	for i := 0; i < len(stmt); i++ {
+		switch c := stmt[i]; {
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"):
+			i = skipUntil(stmt, i+2, "\n") - 1
+			b.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			i = skipUntil(stmt, i+2, "*/") - 1
+			b.WriteByte(' ')
+		case c == '\'' || c == '"':
+			end := skipQuoted(stmt, i, c)
+			if end >= len(stmt) {
+				end = len(stmt) - 1
+			}
+			b.WriteString(stmt[i : end+1])
+			i = end
+		case c == '$':
+			tag := dollarTag(stmt[i:])
+			if tag == "" {
+				b.WriteByte(c)
+				continue
+			}
+			end := skipUntil(stmt, i+len(tag), tag)
+			b.WriteString(stmt[i:end])
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// splitTopLevel splits s at sep outside parentheses and quotes.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\'', '"':
+			i = skipQuoted(s, i, s[i])
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// tableName normalizes a table reference: unquoted, lower case and without
+// the public schema.
+func tableName(ref string) string {
+	name := strings.ToLower(strings.ReplaceAll(ref, `"`, ""))
+	return strings.TrimPrefix(name, "public.")
+}
+
+// shorten returns stmt cut to a length fit for a report line.
+func shorten(stmt string) string {
+	const max = 120
+	if len(stmt) <= max {
+		return stmt
+	}
+	return stmt[:max-3] + "..."
+}
@@ -0,0 +1,140 @@
+package migrationguard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	sql := `-- Migration: example
+CREATE TABLE t (id INT, note TEXT DEFAULT 'a;b');
+DO $$
+BEGIN
+    RAISE NOTICE 'x; y';
+END $$;
+/* block; comment */
+INSERT INTO "odd;name" VALUES (1);
+-- trailing comment only;
+`
+	want := []string{
+		"-- Migration: example\nCREATE TABLE t (id INT, note TEXT DEFAULT 'a;b')",
+		"DO $$\nBEGIN\n    RAISE NOTICE 'x; y';\nEND $$",
+		`/* block; comment */
+INSERT INTO "odd;name" VALUES (1)`,
+	}
+	if got := Split(sql); !reflect.DeepEqual(got, want) {
+		t.Errorf("Split() = %q, want %q", got, want)
+	}
+}
+
+func rules(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, f.Severity+" "+f.Rule)
+	}
+	return out
+}
+
+func TestLint(t *testing.T) {
+	allLarge := func(string) (bool, bool) { return true, true }
+
+	tests := []struct {
+		name  string
+		sql   string
+		large LargeTableFunc
+		want  []string
+	}{
+		{
+			"blocking index on a large table",
+			"CREATE INDEX IF NOT EXISTS idx_users_x ON users(x);",
+			allLarge,
+			[]string{"error blocking-index"},
+		},
+		{
+			"blocking index on a table of unknown size",
+			"CREATE UNIQUE INDEX idx_users_x ON public.users(x);",
+			nil,
+			[]string{"warning blocking-index"},
+		},
+		{
+			"blocking index on a small table",
+			"CREATE INDEX idx_users_x ON users(x);",
+			func(string) (bool, bool) { return false, true },
+			[]string{"warning blocking-index"},
+		},
+		{
+			"concurrent index",
+			"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_x ON users(x);",
+			allLarge,
+			nil,
+		},
+		{
+			"index on a table created in the migration",
+			"CREATE TABLE IF NOT EXISTS widgets (id UUID PRIMARY KEY, x INT NOT NULL);\nCREATE INDEX idx_widgets_x ON widgets(x);\nALTER TABLE widgets ADD COLUMN y INT NOT NULL;",
+			allLarge,
+			nil,
+		},
+		{
+			"not null column without default",
+			"ALTER TABLE users ADD COLUMN IF NOT EXISTS tier VARCHAR(20) NOT NULL;",
+			allLarge,
+			[]string{"error not-null-without-default"},
+		},
+		{
+			"not null column with default",
+			"ALTER TABLE users ADD COLUMN tier VARCHAR(20) NOT NULL DEFAULT 'free', ADD CONSTRAINT chk CHECK (tier <> '');",
+			allLarge,
+			nil,
+		},
+		{
+			"set not null",
+			"ALTER TABLE users ALTER COLUMN app_id SET NOT NULL;",
+			allLarge,
+			[]string{"error set-not-null"},
+		},
+		{
+			"concurrently inside a transaction",
+			"BEGIN;\nCREATE INDEX CONCURRENTLY idx_users_x ON users(x);\nCOMMIT;",
+			nil,
+			[]string{"error concurrently-in-transaction"},
+		},
+		{
+			"concurrently after a transaction",
+			"BEGIN;\nALTER TABLE users ADD COLUMN x INT;\nCOMMIT;\nCREATE INDEX CONCURRENTLY idx_users_x ON users(x);",
+			nil,
+			nil,
+		},
+		{
+			"acknowledged rule",
+			"-- migration-guard:ignore blocking-index, set-not-null\nCREATE INDEX idx_users_x ON users(x);\nALTER TABLE users ALTER COLUMN x SET NOT NULL;",
+			allLarge,
+			nil,
+		},
+		{
+			"statements in a DO block",
+			"DO $$ BEGIN CREATE INDEX idx_users_x ON users(x); END $$;",
+			allLarge,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules(Lint(tt.sql, tt.large))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsStatementExecution(t *testing.T) {
+	if !NeedsStatementExecution("SELECT 1;\ncreate index concurrently idx ON users(x);") {
+		t.Error("NeedsStatementExecution(CREATE INDEX CONCURRENTLY) = false, want true")
+	}
+	if !NeedsStatementExecution("DROP INDEX CONCURRENTLY IF EXISTS idx;") {
+		t.Error("NeedsStatementExecution(DROP INDEX CONCURRENTLY) = false, want true")
+	}
+	if NeedsStatementExecution("-- CREATE INDEX CONCURRENTLY is not used here\nCREATE INDEX idx ON users(x);") {
+		t.Error("NeedsStatementExecution(comment) = true, want false")
+	}
+}
@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+)
+
+// Handler exposes the admin notification feed on the Admin API.
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new notification handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{Service: service}
+}
+
+// ListFeed returns recent admin notifications, newest first
+// @Summary List admin notifications
+// @Description Returns the admin notification feed (new tenants, SMTP failures, anomaly spikes, API key expirations). Poll with `since` set to the newest created_at seen to receive only new entries.
+// @Tags Admin
+// @Produce json
+// @Param   since              query  string  false  "Only notifications created after this time (RFC 3339)"
+// @Param   page               query  int     false  "Page number" default(1)
+// @Param   page_size          query  int     false  "Page size (max 100)" default(50)
+// @Param   sort               query  string  false  "Sort field: created_at, type, severity (prefix with - for descending)" default(created_at)
+// @Param   order              query  string  false  "Sort order: asc or desc" default(desc)
+// @Param   filter[type]       query  string  false  "Notification type (also accepted as type)"
+// @Param   filter[severity]   query  string  false  "Severity: info, warning, critical"
+// @Param   filter[app_id]     query  string  false  "Related application ID"
+// @Success 200 {object} dto.AdminNotificationFeedResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/notifications [get]
+func (h *Handler) ListFeed(c *gin.Context) {
+	var since *time.Time
+	if raw := c.Query("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid since: expected RFC 3339 timestamp"})
+			return
+		}
+		since = &t
+	}
+
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), feedListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	notifications, total, err := h.Service.Feed(since, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load notifications"})
+		return
+	}
+
+	resp := dto.AdminNotificationFeedResponse{
+		Notifications: make([]dto.AdminNotificationResponse, 0, len(notifications)),
+		Total:         total,
+		Page:          q.Page,
+		PageSize:      q.PageSize,
+		TotalPages:    q.TotalPages(total),
+		Sort:          q.Sort,
+		Order:         q.Order,
+	}
+	for _, n := range notifications {
+		resp.Notifications = append(resp.Notifications, dto.AdminNotificationResponse{
+			ID:        n.ID,
+			Type:      n.Type,
+			Severity:  n.Severity,
+			Title:     n.Title,
+			Message:   n.Message,
+			Link:      n.Link,
+			AppID:     n.AppID,
+			CreatedAt: n.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
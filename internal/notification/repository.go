@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository handles persistence of admin notifications and their read state.
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository creates a new notification Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Item is a notification together with its read state for one admin.
+type Item struct {
+	models.AdminNotification
+	Read bool `json:"read"`
+}
+
+// Create stores a new notification.
+func (r *Repository) Create(n *models.AdminNotification) error {
+	return r.DB.Create(n).Error
+}
+
+// ExistsSince reports whether a notification with the given dedup key was created at or after since.
+func (r *Repository) ExistsSince(dedupKey string, since time.Time) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.AdminNotification{}).
+		Where("dedup_key = ? AND created_at >= ?", dedupKey, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListForAdmin returns the most recent notifications created at or after since,
+// newest first, with the admin's read state.
+func (r *Repository) ListForAdmin(adminID uuid.UUID, since time.Time, limit int) ([]Item, error) {
+	var items []Item
+	err := r.DB.Model(&models.AdminNotification{}).
+		Select("admin_notifications.*, (admin_notification_reads.admin_id IS NOT NULL) AS \"read\"").
+		Joins("LEFT JOIN admin_notification_reads ON admin_notification_reads.notification_id = admin_notifications.id AND admin_notification_reads.admin_id = ?", adminID).
+		Where("admin_notifications.created_at >= ?", since).
+		Order("admin_notifications.created_at DESC").
+		Limit(limit).
+		Scan(&items).Error
+	return items, err
+}
+
+// CountUnread returns how many notifications created at or after since the admin has not read.
+func (r *Repository) CountUnread(adminID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.AdminNotification{}).
+		Where("created_at >= ?", since).
+		Where("NOT EXISTS (SELECT 1 FROM admin_notification_reads r WHERE r.notification_id = admin_notifications.id AND r.admin_id = ?)", adminID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one notification as read for the admin. Marking twice is a no-op.
+func (r *Repository) MarkRead(adminID, notificationID uuid.UUID) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.AdminNotificationRead{
+		NotificationID: notificationID,
+		AdminID:        adminID,
+		ReadAt:         time.Now().UTC(),
+	}).Error
+}
+
+// MarkAllRead marks every notification created at or after since as read for the admin.
+func (r *Repository) MarkAllRead(adminID uuid.UUID, since time.Time) error {
+	return r.DB.Exec(`
+		INSERT INTO admin_notification_reads (notification_id, admin_id, read_at)
+		SELECT id, ?, NOW() FROM admin_notifications WHERE created_at >= ?
+		ON CONFLICT DO NOTHING
+	`, adminID, since).Error
+}
+
+// feedListSpec is the sorting and filtering of the Admin API notification feed.
+var feedListSpec = &pagination.ListSpec{
+	DefaultPageSize: 50,
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"type":       "type",
+		"severity":   "severity",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"type":     {Column: "type", Kind: pagination.FilterExact},
+		"severity": {Column: "severity", Kind: pagination.FilterExact},
+		"app_id":   {Column: "app_id", Kind: pagination.FilterUUID},
+	},
+}
+
+// ListFeed returns a page of notifications created after since (when non-nil),
+// sorted and filtered by q.
+func (r *Repository) ListFeed(since *time.Time, q pagination.ListQuery) ([]models.AdminNotification, int64, error) {
+	var notifications []models.AdminNotification
+	var total int64
+
+	query := q.ApplyFilters(r.DB.Model(&models.AdminNotification{}))
+	if since != nil {
+		query = query.Where("created_at > ?", *since)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.ApplyPage(query).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
@@ -0,0 +1,230 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Notification types raised by the system.
+const (
+	TypeTenantCreated  = "tenant_created"
+	TypeSMTPFailure    = "smtp_failure"
+	TypeAnomalySpike   = "anomaly_spike"
+	TypeApiKeyExpiring = "api_key_expiring"
+)
+
+// Notification severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Service raises admin notifications and serves them to the GUI and Admin API.
+// All methods are safe to call on a nil *Service (notifications disabled), so
+// producers can hold an optional reference without nil checks.
+type Service struct {
+	repo *Repository
+
+	// Anomaly spike detection: anomalies are counted per app in fixed one-hour
+	// windows; crossing the threshold raises one notification per window.
+	mu               sync.Mutex
+	anomalyWindows   map[uuid.UUID]*anomalyWindow
+	anomalyThreshold int
+}
+
+type anomalyWindow struct {
+	start    time.Time
+	count    int
+	notified bool
+}
+
+// NewService creates a new notification Service.
+func NewService(repo *Repository) *Service {
+	threshold := viper.GetInt("ADMIN_NOTIFY_ANOMALY_THRESHOLD")
+	if threshold <= 0 {
+		threshold = 20
+	}
+	return &Service{
+		repo:             repo,
+		anomalyWindows:   make(map[uuid.UUID]*anomalyWindow),
+		anomalyThreshold: threshold,
+	}
+}
+
+// window returns how far back notifications are shown in the GUI
+// (ADMIN_NOTIFICATION_WINDOW_DAYS, default 30).
+func window() time.Duration {
+	days := viper.GetInt("ADMIN_NOTIFICATION_WINDOW_DAYS")
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Notify stores a notification. Failures are logged, never returned, so
+// raising a notification cannot break the operation that triggered it.
+func (s *Service) Notify(n *models.AdminNotification) {
+	if s == nil {
+		return
+	}
+	if n.Severity == "" {
+		n.Severity = SeverityInfo
+	}
+	if err := s.repo.Create(n); err != nil {
+		log.Printf("Warning: failed to store admin notification %q: %v", n.Title, err)
+	}
+}
+
+// NotifyOnce stores a notification unless one with the same dedup key was
+// raised within the last period. Use it for events that can repeat in bursts.
+func (s *Service) NotifyOnce(dedupKey string, period time.Duration, n *models.AdminNotification) {
+	if s == nil {
+		return
+	}
+	exists, err := s.repo.ExistsSince(dedupKey, time.Now().Add(-period))
+	if err != nil {
+		log.Printf("Warning: failed to check admin notification %q: %v", dedupKey, err)
+		return
+	}
+	if exists {
+		return
+	}
+	n.DedupKey = dedupKey
+	s.Notify(n)
+}
+
+// TenantCreated raises a notification for a newly created tenant.
+func (s *Service) TenantCreated(tenantID uuid.UUID, name string) {
+	s.Notify(&models.AdminNotification{
+		Type:     TypeTenantCreated,
+		Severity: SeverityInfo,
+		Title:    fmt.Sprintf("New tenant created: %s", name),
+		Link:     "/gui/tenants",
+		DedupKey: "tenant_created:" + tenantID.String(),
+	})
+}
+
+// SMTPFailure raises a notification when an email could not be delivered.
+// Repeats for the same application are suppressed for an hour.
+func (s *Service) SMTPFailure(appID uuid.UUID, emailTypeCode string, sendErr error) {
+	n := &models.AdminNotification{
+		Type:     TypeSMTPFailure,
+		Severity: SeverityWarning,
+		Title:    "Email delivery failed",
+		Message:  fmt.Sprintf("Sending a %s email failed: %v", emailTypeCode, sendErr),
+		Link:     "/gui/email-servers",
+	}
+	if appID != uuid.Nil {
+		n.AppID = &appID
+	}
+	s.NotifyOnce("smtp_failure:"+appID.String(), time.Hour, n)
+}
+
+// RecordAnomaly counts a detected login anomaly for an application and raises
+// a critical notification when the hourly count reaches the spike threshold
+// (ADMIN_NOTIFY_ANOMALY_THRESHOLD, default 20).
+func (s *Service) RecordAnomaly(appID uuid.UUID) {
+	if s == nil {
+		return
+	}
+	count, spike := s.countAnomaly(appID, time.Now())
+	if !spike {
+		return
+	}
+	n := &models.AdminNotification{
+		Type:     TypeAnomalySpike,
+		Severity: SeverityCritical,
+		Title:    "Spike in suspicious login activity",
+		Message:  fmt.Sprintf("%d login anomalies detected within an hour.", count),
+		Link:     "/gui/logs?since=24h",
+	}
+	if appID != uuid.Nil {
+		n.AppID = &appID
+	}
+	s.NotifyOnce("anomaly_spike:"+appID.String(), time.Hour, n)
+}
+
+// countAnomaly adds one anomaly to the app's current window and reports the
+// window's count and whether this anomaly crossed the spike threshold.
+func (s *Service) countAnomaly(appID uuid.UUID, now time.Time) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.anomalyWindows[appID]
+	if w == nil || now.Sub(w.start) >= time.Hour {
+		w = &anomalyWindow{start: now}
+		s.anomalyWindows[appID] = w
+	}
+	w.count++
+	spike := w.count >= s.anomalyThreshold && !w.notified
+	if spike {
+		w.notified = true
+	}
+	return w.count, spike
+}
+
+// ApiKeyExpiring raises a notification for an API key that expires within
+// daysLeft days. The caller is responsible for raising it once per reminder.
+func (s *Service) ApiKeyExpiring(keyID uuid.UUID, name, keyPrefix string, expiresAt time.Time, daysLeft int) {
+	severity := SeverityWarning
+	if daysLeft <= 1 {
+		severity = SeverityCritical
+	}
+	s.Notify(&models.AdminNotification{
+		Type:     TypeApiKeyExpiring,
+		Severity: severity,
+		Title:    fmt.Sprintf("API key %q expires in %d day(s)", name, daysLeft),
+		Message:  fmt.Sprintf("Key %s... expires at %s.", keyPrefix, expiresAt.UTC().Format(time.RFC1123)),
+		Link:     "/gui/api-keys",
+		DedupKey: "api_key_expiring:" + keyID.String(),
+	})
+}
+
+// ListForAdmin returns recent notifications with the admin's read state.
+func (s *Service) ListForAdmin(adminID uuid.UUID, limit int) ([]Item, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.repo.ListForAdmin(adminID, time.Now().Add(-window()), limit)
+}
+
+// UnreadCount returns how many recent notifications the admin has not read.
+func (s *Service) UnreadCount(adminID uuid.UUID) (int64, error) {
+	if s == nil {
+		return 0, nil
+	}
+	return s.repo.CountUnread(adminID, time.Now().Add(-window()))
+}
+
+// MarkRead marks one notification as read for the admin.
+func (s *Service) MarkRead(adminID, notificationID uuid.UUID) error {
+	if s == nil {
+		return nil
+	}
+	return s.repo.MarkRead(adminID, notificationID)
+}
+
+// MarkAllRead marks all recent notifications as read for the admin.
+func (s *Service) MarkAllRead(adminID uuid.UUID) error {
+	if s == nil {
+		return nil
+	}
+	return s.repo.MarkAllRead(adminID, time.Now().Add(-window()))
+}
+
+// Feed returns a page of the Admin API feed sorted and filtered by q (see
+// feedListSpec).
+func (s *Service) Feed(since *time.Time, q pagination.ListQuery) ([]models.AdminNotification, int64, error) {
+	if s == nil {
+		return nil, 0, nil
+	}
+	return s.repo.ListFeed(since, q)
+}
@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCountAnomalySpike(t *testing.T) {
+	s := &Service{anomalyWindows: make(map[uuid.UUID]*anomalyWindow), anomalyThreshold: 3}
+	app, other := uuid.New(), uuid.New()
+	start := time.Now()
+
+	var spikes []int
+	for i := 1; i <= 5; i++ {
+		if _, spike := s.countAnomaly(app, start.Add(time.Duration(i)*time.Minute)); spike {
+			spikes = append(spikes, i)
+		}
+	}
+	if len(spikes) != 1 || spikes[0] != 3 {
+		t.Errorf("spikes at %v, want exactly one at the 3rd anomaly", spikes)
+	}
+
+	if count, spike := s.countAnomaly(other, start); count != 1 || spike {
+		t.Errorf("other app: count %d spike %v, want 1 false", count, spike)
+	}
+
+	// A new window starts an hour after the first anomaly of the previous one
+	count, _ := s.countAnomaly(app, start.Add(61*time.Minute))
+	if count != 1 {
+		t.Errorf("count after window reset = %d, want 1", count)
+	}
+}
+
+func TestNilServiceIsNoop(t *testing.T) {
+	var s *Service
+	s.TenantCreated(uuid.New(), "acme")
+	s.RecordAnomaly(uuid.New())
+	if n, err := s.UnreadCount(uuid.New()); n != 0 || err != nil {
+		t.Errorf("UnreadCount on nil service = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,257 @@
+// Package oauthconfig resolves the OAuth provider credentials that apply to an
+// application. The first of these layers that has a config for the provider
+// wins, whether it is enabled or not, so a disabled config turns the provider
+// off for the apps resolving to it:
+//
+//  1. the application's own config in the database;
+//  2. for an environment, its parent application's config;
+//  3. the global default: the config of the application set by
+//     OAUTH_DEFAULT_APP_ID (unset = no global default);
+//  4. the <PROVIDER>_CLIENT_ID, _CLIENT_SECRET and _REDIRECT_URL environment
+//     variables. This layer is deprecated; every app and provider falling back
+//     to it is logged once.
+package oauthconfig
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Sources of a resolved config
+const (
+	SourceApp           = "app"
+	SourceParentApp     = "parent_app"
+	SourceGlobalDefault = "global_default"
+	SourceEnv           = "env"
+)
+
+// Providers are the supported OAuth providers, in display order.
+var Providers = []string{"google", "facebook", "github"}
+
+// cacheTTL bounds how long a resolved config is reused, and so how long a
+// change takes to reach sign-ins. Configs are looked up on every social login
+// step, so results are cached in memory.
+const cacheTTL = 30 * time.Second
+
+// warnedEnvFallback records the app/provider pairs whose environment variable
+// fallback was logged.
+var warnedEnvFallback sync.Map
+
+// Resolved is the config that applies to an application and where it came from.
+type Resolved struct {
+	Config      *models.OAuthProviderConfig
+	Source      string
+	SourceAppID uuid.UUID // Application whose config applies (uuid.Nil for SourceEnv)
+}
+
+// Layer is one step of a resolution, as reported by Explain.
+type Layer struct {
+	Source  string
+	AppID   uuid.UUID // uuid.Nil for SourceEnv, or when the layer does not apply
+	Found   bool
+	Skipped string // Why the layer was not checked (e.g. "not an environment")
+}
+
+type cacheKey struct {
+	appID    uuid.UUID
+	provider string
+}
+
+type cacheEntry struct {
+	resolved *Resolved
+	expires  time.Time
+}
+
+// Resolver resolves OAuth provider configs.
+type Resolver struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	cache map[cacheKey]cacheEntry
+}
+
+// NewResolver creates a new Resolver.
+func NewResolver(db *gorm.DB) *Resolver {
+	return &Resolver{db: db, cache: make(map[cacheKey]cacheEntry)}
+}
+
+// Resolve returns the config of provider that applies to appID, or
+// gorm.ErrRecordNotFound when no layer has one.
+func (r *Resolver) Resolve(appID uuid.UUID, provider string) (*Resolved, error) {
+	key := cacheKey{appID, provider}
+	now := time.Now()
+	r.mu.Lock()
+	if e, ok := r.cache[key]; ok && now.Before(e.expires) {
+		r.mu.Unlock()
+		return found(e.resolved)
+	}
+	r.mu.Unlock()
+
+	resolved, _, err := r.resolve(appID, provider, false)
+	if err != nil {
+		// Transient error: do not cache, the next sign-in retries.
+		return nil, err
+	}
+	if resolved != nil && resolved.Source == SourceEnv {
+		if _, warned := warnedEnvFallback.LoadOrStore(key, true); !warned {
+			log.Printf("Deprecated: %s OAuth credentials of app %s are resolved from the %s_* environment variables; store them in the database (POST /admin/oauth-configs/import)",
+				provider, appID, envPrefix(provider))
+		}
+	}
+
+	r.mu.Lock()
+	r.cache[key] = cacheEntry{resolved: resolved, expires: now.Add(cacheTTL)}
+	r.mu.Unlock()
+	return found(resolved)
+}
+
+// Purge drops every cached config, so the next sign-in of each app resolves
+// its config again.
+func (r *Resolver) Purge() {
+	r.mu.Lock()
+	r.cache = make(map[cacheKey]cacheEntry)
+	r.mu.Unlock()
+}
+
+// Explain resolves the config of provider for appID without the cache and
+// reports every layer, for debugging. The resolved config is nil when no
+// layer has one.
+func (r *Resolver) Explain(appID uuid.UUID, provider string) (*Resolved, []Layer, error) {
+	return r.resolve(appID, provider, true)
+}
+
+func found(resolved *Resolved) (*Resolved, error) {
+	if resolved == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return resolved, nil
+}
+
+// resolve walks the layers. With all set it checks every layer and reports them.
+func (r *Resolver) resolve(appID uuid.UUID, provider string, all bool) (*Resolved, []Layer, error) {
+	var resolved *Resolved
+	var layers []Layer
+	use := func(layer Layer, config *models.OAuthProviderConfig) {
+		layers = append(layers, layer)
+		if layer.Found && resolved == nil {
+			resolved = &Resolved{Config: config, Source: layer.Source, SourceAppID: layer.AppID}
+		}
+	}
+
+	// 1. The application's own config
+	config, err := r.appConfig(appID, provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	use(Layer{Source: SourceApp, AppID: appID, Found: config != nil}, config)
+
+	// 2. The parent application's config (environments)
+	if resolved == nil || all {
+		var app models.Application
+		if err := r.db.Select("id, parent_app_id").First(&app, "id = ?", appID).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return nil, nil, err
+		}
+		if app.ParentAppID == nil {
+			use(Layer{Source: SourceParentApp, Skipped: "not an environment"}, nil)
+		} else {
+			config, err := r.appConfig(*app.ParentAppID, provider)
+			if err != nil {
+				return nil, nil, err
+			}
+			use(Layer{Source: SourceParentApp, AppID: *app.ParentAppID, Found: config != nil}, config)
+		}
+	}
+
+	// 3. The global default application's config
+	if resolved == nil || all {
+		defaultAppID, err := GlobalDefaultAppID()
+		switch {
+		case err != nil:
+			use(Layer{Source: SourceGlobalDefault, Skipped: err.Error()}, nil)
+		case defaultAppID == uuid.Nil:
+			use(Layer{Source: SourceGlobalDefault, Skipped: "OAUTH_DEFAULT_APP_ID is not set"}, nil)
+		default:
+			config, err := r.appConfig(defaultAppID, provider)
+			if err != nil {
+				return nil, nil, err
+			}
+			use(Layer{Source: SourceGlobalDefault, AppID: defaultAppID, Found: config != nil}, config)
+		}
+	}
+
+	// 4. Environment variables (deprecated)
+	if resolved == nil || all {
+		config := EnvConfig(provider)
+		if config != nil {
+			config.AppID = appID
+		}
+		use(Layer{Source: SourceEnv, Found: config != nil}, config)
+	}
+	return resolved, layers, nil
+}
+
+// appConfig returns the config of provider stored for appID, or nil.
+func (r *Resolver) appConfig(appID uuid.UUID, provider string) (*models.OAuthProviderConfig, error) {
+	var config models.OAuthProviderConfig
+	err := r.db.Where("app_id = ? AND provider = ?", appID, provider).First(&config).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// GlobalDefaultAppID returns the application whose configs are the global
+// defaults (OAUTH_DEFAULT_APP_ID), or uuid.Nil when none is set.
+func GlobalDefaultAppID() (uuid.UUID, error) {
+	raw := strings.TrimSpace(viper.GetString("OAUTH_DEFAULT_APP_ID"))
+	if raw == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("OAUTH_DEFAULT_APP_ID is not a valid UUID")
+	}
+	return id, nil
+}
+
+// EnvConfig returns the config of provider set by environment variables, or
+// nil when its client ID or secret is missing. Facebook also accepts the
+// FACEBOOK_APP_ID and FACEBOOK_APP_SECRET names.
+func EnvConfig(provider string) *models.OAuthProviderConfig {
+	prefix := envPrefix(provider)
+	clientID := viper.GetString(prefix + "_CLIENT_ID")
+	clientSecret := viper.GetString(prefix + "_CLIENT_SECRET")
+	if provider == "facebook" {
+		if clientID == "" {
+			clientID = viper.GetString("FACEBOOK_APP_ID")
+		}
+		if clientSecret == "" {
+			clientSecret = viper.GetString("FACEBOOK_APP_SECRET")
+		}
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil
+	}
+	return &models.OAuthProviderConfig{
+		Provider:         provider,
+		ClientID:         clientID,
+		ClientSecret:     clientSecret,
+		RedirectURL:      viper.GetString(prefix + "_REDIRECT_URL"),
+		IsEnabled:        true,
+		ProvisioningMode: models.ProvisioningModeAuto,
+	}
+}
+
+func envPrefix(provider string) string {
+	return strings.ToUpper(provider)
+}
@@ -0,0 +1,60 @@
+package oauthconfig
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func setEnv(t *testing.T, values map[string]string) {
+	t.Helper()
+	for k, v := range values {
+		viper.Set(k, v)
+	}
+	t.Cleanup(func() {
+		for k := range values {
+			viper.Set(k, nil)
+		}
+	})
+}
+
+func TestEnvConfig(t *testing.T) {
+	setEnv(t, map[string]string{
+		"GOOGLE_CLIENT_ID":      "google-id",
+		"GOOGLE_CLIENT_SECRET":  "google-secret",
+		"GOOGLE_REDIRECT_URL":   "https://auth.example.com/auth/google/callback",
+		"GITHUB_CLIENT_ID":      "github-id",
+		"FACEBOOK_APP_ID":       "fb-id",
+		"FACEBOOK_APP_SECRET":   "fb-secret",
+		"FACEBOOK_REDIRECT_URL": "https://auth.example.com/auth/facebook/callback",
+	})
+
+	google := EnvConfig("google")
+	if google == nil || google.ClientID != "google-id" || google.ClientSecret != "google-secret" || !google.IsEnabled {
+		t.Errorf("google: got %+v", google)
+	}
+	if fb := EnvConfig("facebook"); fb == nil || fb.ClientID != "fb-id" || fb.ClientSecret != "fb-secret" {
+		t.Errorf("facebook with the FACEBOOK_APP_* names: got %+v", fb)
+	}
+	if gh := EnvConfig("github"); gh != nil {
+		t.Errorf("github without a secret: got %+v, want nil", gh)
+	}
+}
+
+func TestGlobalDefaultAppID(t *testing.T) {
+	if id, err := GlobalDefaultAppID(); err != nil || id != uuid.Nil {
+		t.Errorf("unset: got %s, %v", id, err)
+	}
+
+	want := uuid.New()
+	setEnv(t, map[string]string{"OAUTH_DEFAULT_APP_ID": " " + want.String() + " "})
+	if id, err := GlobalDefaultAppID(); err != nil || id != want {
+		t.Errorf("got %s, %v, want %s", id, err, want)
+	}
+
+	viper.Set("OAUTH_DEFAULT_APP_ID", "default")
+	if _, err := GlobalDefaultAppID(); err == nil {
+		t.Error("invalid UUID: expected an error")
+	}
+}
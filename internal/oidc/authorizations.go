@@ -0,0 +1,207 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/util"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/google/uuid"
+)
+
+// ─── End-user authorizations ───────────────────────────────────────────────────
+
+// Scopes returns the scopes the user granted the client.
+func (a Authorization) Scopes() []string {
+	return strings.Fields(a.Grant.Scopes)
+}
+
+// ListAuthorizations handles GET /profile/authorizations
+// @Summary List authorized applications
+// @Description Returns the third-party OIDC clients the authenticated user has authorized, with the scopes granted to each, most recently used first
+// @Tags OIDC
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.OIDCAuthorizationListResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /profile/authorizations [get]
+func (h *Handler) ListAuthorizations(c *gin.Context) {
+	appID, userID, ok := authorizationsUser(c)
+	if !ok {
+		return
+	}
+	authorizations, err := h.Service.ListAuthorizations(appID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list authorized applications"})
+		return
+	}
+
+	resp := dto.OIDCAuthorizationListResponse{Authorizations: make([]dto.OIDCAuthorizationResponse, len(authorizations))}
+	for i, a := range authorizations {
+		resp.Authorizations[i] = dto.OIDCAuthorizationResponse{
+			ClientID:    a.Client.ClientID,
+			Name:        a.Client.Name,
+			Description: a.Client.Description,
+			LogoURL:     a.Client.LogoURL,
+			Scopes:      a.Scopes(),
+			GrantedAt:   a.Grant.GrantedAt.Format(time.RFC3339),
+			LastUsedAt:  a.Grant.LastUsedAt.Format(time.RFC3339),
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeAuthorization handles DELETE /profile/authorizations/:client_id
+// @Summary Revoke an authorized application
+// @Description Revokes the authenticated user's grant to a third-party OIDC client. The client's access and refresh tokens stop working, and the user is asked for consent again the next time the client signs them in.
+// @Tags OIDC
+// @Produce json
+// @Security ApiKeyAuth
+// @Param client_id path string true "OIDC client ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /profile/authorizations/{client_id} [delete]
+func (h *Handler) RevokeAuthorization(c *gin.Context) {
+	appID, userID, ok := authorizationsUser(c)
+	if !ok {
+		return
+	}
+	clientID := c.Param("client_id")
+	if err := h.Service.RevokeGrant(appID, userID, clientID); err != nil {
+		if errors.Is(err, ErrGrantNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Authorized application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to revoke authorization"})
+		return
+	}
+
+	ipAddress, userAgent := util.GetClientInfo(c)
+	log.LogOIDCGrantRevoked(appID, userID, ipAddress, userAgent, clientID, log.WithImpersonation(c, nil))
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Authorization revoked successfully"})
+}
+
+// authorizationsUser returns the application and user of an authenticated
+// request, or responds with an error.
+func authorizationsUser(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "User ID not found in context"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(userIDVal.(string))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	appIDVal, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "App ID missing from context"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return appIDVal.(uuid.UUID), userID, true
+}
+
+// AuthorizationsPage handles GET /oidc/:app_id/authorizations
+// Renders the hosted page listing the applications the user signed in to the
+// OIDC provider (see sessionUserID) has authorized.
+// @Summary Authorized applications page
+// @Tags OIDC
+// @Produce html
+// @Param app_id path string true "Application UUID"
+// @Success 200
+// @Router /oidc/{app_id}/authorizations [get]
+func (h *Handler) AuthorizationsPage(c *gin.Context) {
+	app, ok := h.loadApp(c)
+	if !ok {
+		return
+	}
+	if !app.OIDCEnabled {
+		h.renderError(c, app, "OIDC is not enabled for this application")
+		return
+	}
+	userID := h.sessionUserID(c, app.ID.String())
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		h.renderError(c, app, "Your session has expired. Sign in to an application that uses "+app.Name+" to manage authorized applications.")
+		return
+	}
+	authorizations, err := h.Service.ListAuthorizations(app.ID, uid)
+	if err != nil {
+		h.renderError(c, app, "failed to list authorized applications")
+		return
+	}
+
+	theme, primaryColor := appTheme(app)
+	c.HTML(http.StatusOK, "oidc_authorizations", gin.H{
+		"AppID":          app.ID.String(),
+		"AppName":        app.Name,
+		"Authorizations": authorizations,
+		"CSRFToken":      h.authorizationsCSRFToken(c, app.ID.String()),
+		"Revoked":        c.Query("revoked") != "",
+		"Theme":          theme,
+		"PrimaryColor":   primaryColor,
+	})
+}
+
+// AuthorizationsRevoke handles POST /oidc/:app_id/authorizations/revoke
+// Revokes a grant from the hosted authorizations page.
+// @Summary Revoke an application from the authorizations page
+// @Tags OIDC
+// @Accept application/x-www-form-urlencoded
+// @Param app_id path string true "Application UUID"
+// @Param client_id formData string true "OIDC client ID"
+// @Param csrf_token formData string true "Token rendered with the page"
+// @Success 303
+// @Router /oidc/{app_id}/authorizations/revoke [post]
+func (h *Handler) AuthorizationsRevoke(c *gin.Context) {
+	app, ok := h.loadApp(c)
+	if !ok {
+		return
+	}
+	if !app.OIDCEnabled {
+		h.renderError(c, app, "OIDC is not enabled for this application")
+		return
+	}
+	uid, err := uuid.Parse(h.sessionUserID(c, app.ID.String()))
+	if err != nil {
+		h.renderError(c, app, "Your session has expired. Sign in to an application that uses "+app.Name+" to manage authorized applications.")
+		return
+	}
+	csrfToken := h.authorizationsCSRFToken(c, app.ID.String())
+	if csrfToken == "" || c.PostForm("csrf_token") != csrfToken {
+		h.renderError(c, app, "invalid or expired form, please try again")
+		return
+	}
+
+	clientID := c.PostForm("client_id")
+	if err := h.Service.RevokeGrant(app.ID, uid, clientID); err != nil && !errors.Is(err, ErrGrantNotFound) {
+		h.renderError(c, app, "failed to revoke authorization")
+		return
+	} else if err == nil {
+		ipAddress, userAgent := util.GetClientInfo(c)
+		log.LogOIDCGrantRevoked(app.ID, uid, ipAddress, userAgent, clientID, nil)
+	}
+	c.Redirect(http.StatusSeeOther, "/oidc/"+app.ID.String()+"/authorizations?revoked=1")
+}
+
+// authorizationsCSRFToken derives the token the authorizations page's forms
+// carry from the browser session cookie, which cross-site forms cannot read.
+// It returns "" without a session.
+func (h *Handler) authorizationsCSRFToken(c *gin.Context, appID string) string {
+	session, err := c.Cookie("oidc_session_" + appID)
+	if err != nil || session == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte("oidc_authorizations:" + session))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,157 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ErrGrantNotFound is returned when revoking a client the user has not
+// authorized.
+var ErrGrantNotFound = errors.New("authorization not found")
+
+// Authorization is an active grant of a user to an OIDC client.
+type Authorization struct {
+	Grant  models.OIDCGrant
+	Client models.OIDCClient
+}
+
+// RecordGrant records that a user authorized an OIDC client for scopes. The
+// scopes are added to those the user granted the client before, unless the
+// grant was revoked since.
+func (s *Service) RecordGrant(appID, userID uuid.UUID, clientID string, scopes []string) error {
+	grant, err := s.repo.GetGrant(userID, clientID)
+	if err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+		grant = &models.OIDCGrant{AppID: appID, UserID: userID, ClientID: clientID}
+	}
+	if !grant.Active() {
+		grant.Scopes = ""
+	}
+	grant.Scopes = mergeScopes(grant.Scopes, scopes)
+	now := time.Now()
+	grant.GrantedAt = now
+	grant.LastUsedAt = now
+	return s.repo.SaveGrant(grant)
+}
+
+// UseGrant checks that a refresh token issued to an OIDC client at issuedAt
+// is covered by the user's grant, and records that tokens were issued to the
+// client. Tokens issued before the grant was last revoked are rejected. A
+// grant is recorded for tokens issued before grants were.
+func (s *Service) UseGrant(appID, userID uuid.UUID, clientID string, issuedAt time.Time, scopes []string) error {
+	grant, err := s.repo.GetGrant(userID, clientID)
+	if err != nil {
+		if isNotFound(err) {
+			return s.RecordGrant(appID, userID, clientID, scopes)
+		}
+		return err
+	}
+	if !grant.Active() || (grant.RevokedAt != nil && !issuedAt.After(*grant.RevokedAt)) {
+		return fmt.Errorf("invalid_grant: authorization has been revoked")
+	}
+	return s.repo.TouchGrant(grant.ID, time.Now())
+}
+
+// HasGrant reports whether a user has authorized an OIDC client for all of
+// scopes, so the consent screen can be skipped.
+func (s *Service) HasGrant(userID uuid.UUID, clientID string, scopes []string) bool {
+	grant, err := s.repo.GetGrant(userID, clientID)
+	if err != nil || !grant.Active() {
+		return false
+	}
+	granted := strings.Fields(grant.Scopes)
+	for _, scope := range scopes {
+		if !sliceContains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListAuthorizations returns the OIDC clients of an application a user has
+// authorized, most recently used first. Grants of deleted clients are left
+// out.
+func (s *Service) ListAuthorizations(appID, userID uuid.UUID) ([]Authorization, error) {
+	grants, err := s.repo.ListGrantsByUser(appID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var clientIDs []string
+	for _, g := range grants {
+		if g.Active() {
+			clientIDs = append(clientIDs, g.ClientID)
+		}
+	}
+	clients, err := s.repo.GetClientsByClientIDs(clientIDs)
+	if err != nil {
+		return nil, err
+	}
+	byClientID := make(map[string]models.OIDCClient, len(clients))
+	for _, c := range clients {
+		byClientID[c.ClientID] = c
+	}
+
+	authorizations := []Authorization{}
+	for _, g := range grants {
+		client, ok := byClientID[g.ClientID]
+		if !g.Active() || !ok {
+			continue
+		}
+		authorizations = append(authorizations, Authorization{Grant: g, Client: client})
+	}
+	return authorizations, nil
+}
+
+// RevokeGrant revokes a user's grant to an OIDC client: the client's unused
+// authorization codes, sessions and refresh tokens are invalidated, and the
+// consent screen is shown again the next time the client asks the user to
+// sign in. It returns ErrGrantNotFound when the user has not authorized the
+// client.
+func (s *Service) RevokeGrant(appID, userID uuid.UUID, clientID string) error {
+	grant, err := s.repo.GetGrant(userID, clientID)
+	if err != nil {
+		if isNotFound(err) {
+			return ErrGrantNotFound
+		}
+		return err
+	}
+	if grant.AppID != appID || !grant.Active() {
+		return ErrGrantNotFound
+	}
+
+	now := time.Now()
+	grant.RevokedAt = &now
+	grant.Scopes = ""
+	if err := s.repo.SaveGrant(grant); err != nil {
+		return err
+	}
+	if err := s.repo.MarkUserAuthCodesUsed(userID, clientID); err != nil {
+		return err
+	}
+	// Access tokens die with their session; refresh tokens are rejected by
+	// UseGrant, so a failure here only delays the revocation of access tokens
+	if _, err := redis.DeleteOIDCClientSessions(appID.String(), userID.String(), clientID); err != nil {
+		log.Printf("[OIDC] RevokeGrant: failed to delete sessions of client %s: %v", clientID, err)
+	}
+	return nil
+}
+
+// mergeScopes adds the scopes not yet in the space-separated list granted.
+func mergeScopes(granted string, scopes []string) string {
+	merged := strings.Fields(granted)
+	for _, scope := range scopes {
+		if !sliceContains(merged, scope) {
+			merged = append(merged, scope)
+		}
+	}
+	return strings.Join(merged, " ")
+}
@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestMergeScopes(t *testing.T) {
+	if got := mergeScopes("openid email", []string{"email", "profile", "openid", "roles"}); got != "openid email profile roles" {
+		t.Errorf("mergeScopes = %q", got)
+	}
+	if got := mergeScopes("", []string{"openid"}); got != "openid" {
+		t.Errorf("mergeScopes(\"\") = %q", got)
+	}
+}
+
+func TestOIDCGrantActive(t *testing.T) {
+	granted := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	before, after := granted.Add(-time.Hour), granted.Add(time.Hour)
+
+	if g := (models.OIDCGrant{GrantedAt: granted}); !g.Active() {
+		t.Error("grant never revoked is not active")
+	}
+	if g := (models.OIDCGrant{GrantedAt: granted, RevokedAt: &after}); g.Active() {
+		t.Error("revoked grant is active")
+	}
+	if g := (models.OIDCGrant{GrantedAt: granted, RevokedAt: &before}); !g.Active() {
+		t.Error("grant authorized again after revocation is not active")
+	}
+}
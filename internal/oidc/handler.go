@@ -16,7 +16,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/internal/health"
 	"github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/passhash"
 	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/revocation"
 	"github.com/gjovanovicst/auth_api/internal/util"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	pkgjwt "github.com/gjovanovicst/auth_api/pkg/jwt"
@@ -75,7 +77,7 @@ func (h *Handler) WellKnownConfiguration(c *gin.Context) {
 		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
 		ScopesSupported:                   []string{"openid", "profile", "email", "roles", "offline_access"},
 		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
-		GrantTypesSupported:               []string{"authorization_code", "client_credentials", "refresh_token"},
+		GrantTypesSupported:               []string{"authorization_code", "client_credentials", "refresh_token", GrantTypeTokenExchange},
 		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "name", "given_name", "family_name", "email", "email_verified", "picture", "locale", "roles"},
 		CodeChallengeMethodsSupported:     []string{"S256"},
 	}
@@ -99,8 +101,11 @@ func (h *Handler) JWKS(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "key unavailable"})
 		return
 	}
-	jwk := PublicKeyToJWK(&key.PublicKey, app.ID.String())
-	c.JSON(http.StatusOK, JWKS{Keys: []JWK{jwk}})
+	// The app's ID token key, then the keys access and refresh tokens are
+	// signed with when JWT_SIGNING_ALGORITHM is RS256 or ES256
+	jwks := JWKS{Keys: []JWK{PublicKeyToJWK(&key.PublicKey, app.ID.String())}}
+	jwks.Keys = append(jwks.Keys, pkgjwt.PublicKeys().Keys...)
+	c.JSON(http.StatusOK, jwks)
 }
 
 // ─── Authorize endpoint ────────────────────────────────────────────────────────
@@ -168,7 +173,7 @@ func (h *Handler) Authorize(c *gin.Context) {
 	}
 
 	// Already authenticated — skip to consent (or auto-approve)
-	if !client.RequireConsent {
+	if !h.consentRequired(client, userID, scopes) {
 		// Still enforce 2FA even for already-authenticated sessions
 		user, err := h.Repo.GetUserByID(userID)
 		if err == nil && user.TwoFAEnabled {
@@ -244,6 +249,10 @@ func (h *Handler) AuthorizeSubmit(c *gin.Context) {
 		// Validate credentials
 		user, authErr := h.authenticateUser(app, email, password)
 		if authErr != nil {
+			errMsg := "Invalid email or password"
+			if app.PasswordlessOnly || !app.AuthMethodAllowed(models.AuthMethodPassword) {
+				errMsg = "Password sign-in is not supported for this application"
+			}
 			scopes := strings.Fields(origReq.Scope)
 			c.HTML(http.StatusOK, "oidc_login", gin.H{
 				"AppID":        app.ID.String(),
@@ -252,7 +261,7 @@ func (h *Handler) AuthorizeSubmit(c *gin.Context) {
 				"ClientLogo":   client.LogoURL,
 				"ConsentToken": req.ConsentToken,
 				"Scopes":       scopes,
-				"Error":        "Invalid email or password",
+				"Error":        errMsg,
 				"Theme":        theme,
 				"PrimaryColor": primaryColor,
 				"UITheme":      postUITheme,
@@ -276,7 +285,7 @@ func (h *Handler) AuthorizeSubmit(c *gin.Context) {
 		}
 
 		// Proceed to consent or auto-approve
-		if !client.RequireConsent {
+		if !h.consentRequired(client, user.ID.String(), strings.Fields(origReq.Scope)) {
 			h.issueCodeAndRedirectForUser(c, app, client, user.ID.String(), origReq)
 			return
 		}
@@ -362,6 +371,8 @@ func (h *Handler) Token(c *gin.Context) {
 		h.handleClientCredentialsGrant(c, app, req)
 	case "refresh_token":
 		h.handleRefreshTokenGrant(c, app, req)
+	case GrantTypeTokenExchange:
+		h.handleTokenExchangeGrant(c, app, req)
 	default:
 		c.JSON(http.StatusBadRequest, dto.OIDCTokenErrorResponse{
 			Error:            "unsupported_grant_type",
@@ -389,6 +400,10 @@ func (h *Handler) handleAuthCodeGrant(c *gin.Context, app *models.Application, r
 	}
 
 	scopes := strings.Fields(ac.Scopes)
+	if err := h.Service.RecordGrant(app.ID, user.ID, client.ClientID, scopes); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.OIDCTokenErrorResponse{Error: "server_error", ErrorDescription: "failed to record authorization"})
+		return
+	}
 	accessToken, refreshToken, idToken, expiresIn, err := h.Service.MintTokensForUser(app, client, user, scopes, ac.Nonce)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.OIDCTokenErrorResponse{Error: "server_error", ErrorDescription: "failed to mint tokens"})
@@ -451,10 +466,16 @@ func (h *Handler) handleRefreshTokenGrant(c *gin.Context, app *models.Applicatio
 		return
 	}
 
+	// Refresh tokens issued before the application's auth epoch were revoked by the kill switch
+	if revoked, err := redis.IsRevokedByAppAuthEpoch(app.ID.String(), claims.IssuedAtTime()); err != nil || revoked {
+		c.JSON(http.StatusBadRequest, dto.OIDCTokenErrorResponse{Error: "invalid_grant", ErrorDescription: "refresh_token has been revoked"})
+		return
+	}
+
 	// Fix #7: Blacklist the old refresh token so it cannot be reused
 	if claims.ExpiresAt != nil {
 		if ttl := time.Until(claims.ExpiresAt.Time); ttl > 0 {
-			_ = redis.BlacklistAccessToken(app.ID.String(), req.RefreshToken, claims.UserID, ttl)
+			_ = redis.BlacklistAccessToken(app.ID.String(), req.RefreshToken, "", claims.UserID, ttl)
 		}
 	}
 
@@ -490,6 +511,16 @@ func (h *Handler) handleRefreshTokenGrant(c *gin.Context, app *models.Applicatio
 		}
 	}
 
+	// Refresh tokens issued before the user revoked the client's grant are rejected
+	if err := h.Service.UseGrant(app.ID, user.ID, client.ClientID, claims.IssuedAtTime(), requestedScopes); err != nil {
+		if strings.HasPrefix(err.Error(), "invalid_grant:") {
+			c.JSON(http.StatusBadRequest, dto.OIDCTokenErrorResponse{Error: "invalid_grant", ErrorDescription: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.OIDCTokenErrorResponse{Error: "server_error", ErrorDescription: "failed to check authorization"})
+		return
+	}
+
 	accessToken, refreshToken, idToken, expiresIn, err := h.Service.MintTokensForUser(app, client, user, requestedScopes, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.OIDCTokenErrorResponse{Error: "server_error", ErrorDescription: "failed to mint tokens"})
@@ -505,6 +536,46 @@ func (h *Handler) handleRefreshTokenGrant(c *gin.Context, app *models.Applicatio
 	})
 }
 
+func (h *Handler) handleTokenExchangeGrant(c *gin.Context, app *models.Application, req dto.OIDCTokenRequest) {
+	result, err := h.Service.TokenExchangeGrant(app, TokenExchangeRequest{
+		ClientID:           req.ClientID,
+		ClientSecret:       req.ClientSecret,
+		SubjectToken:       req.SubjectToken,
+		SubjectTokenType:   req.SubjectTokenType,
+		RequestedTokenType: req.RequestedTokenType,
+		Audience:           req.Audience,
+		Scope:              req.Scope,
+	})
+	if err != nil {
+		errCode, _, _ := strings.Cut(err.Error(), ":")
+		status := http.StatusBadRequest
+		switch errCode {
+		case "invalid_client", "unauthorized_client":
+			status = http.StatusUnauthorized
+		case "invalid_request", "invalid_grant", "invalid_scope", "invalid_target":
+			// 400 Bad Request
+		default:
+			c.JSON(http.StatusInternalServerError, dto.OIDCTokenErrorResponse{Error: "server_error", ErrorDescription: "failed to exchange token"})
+			return
+		}
+		c.JSON(status, dto.OIDCTokenErrorResponse{Error: errCode, ErrorDescription: err.Error()})
+		return
+	}
+
+	if userID, err := uuid.Parse(result.UserID); err == nil {
+		ipAddress, userAgent := util.GetClientInfo(c)
+		log.LogOIDCTokenExchange(app.ID, userID, ipAddress, userAgent, req.ClientID, result.Audience, result.Scope)
+	}
+
+	c.JSON(http.StatusOK, dto.OIDCTokenResponse{
+		AccessToken:     result.AccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       result.ExpiresIn,
+		Scope:           result.Scope,
+		IssuedTokenType: TokenTypeAccessToken,
+	})
+}
+
 // ─── UserInfo endpoint ─────────────────────────────────────────────────────────
 
 // UserInfo handles GET /oidc/:app_id/userinfo
@@ -690,7 +761,7 @@ func (h *Handler) Revoke(c *gin.Context) {
 			}
 		}
 		// Best-effort: ignore blacklist errors.
-		_ = redis.BlacklistAccessToken(app.ID.String(), req.Token, claims.UserID, ttl)
+		_ = revocation.Revoke(app.ID.String(), req.Token, claims.ID, claims.UserID, ttl)
 	}
 
 	c.Status(http.StatusOK)
@@ -777,7 +848,7 @@ func (h *Handler) EndSession(c *gin.Context) {
 	// AuthMiddleware rejects any further requests with those tokens.
 	if logoutUserID != "" {
 		appIDStr := app.ID.String()
-		accessTokenTTL := time.Duration(viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES")) * time.Minute
+		accessTokenTTL := pkgjwt.DefaultAccessTokenTTL()
 		_ = redis.DeleteAllUserSessions(appIDStr, logoutUserID, "")
 		_ = redis.BlacklistAllUserTokens(appIDStr, logoutUserID, accessTokenTTL)
 		// Cross-app SSO logout: revoke sessions in all peer apps that share the
@@ -1138,6 +1209,20 @@ func (h *Handler) sessionUserID(c *gin.Context, appID string) string {
 	return userID
 }
 
+// consentRequired reports whether the consent screen must be shown before
+// issuing a code to client: the client requires consent and the user has not
+// already granted it all of scopes.
+func (h *Handler) consentRequired(client *models.OIDCClient, userID string, scopes []string) bool {
+	if !client.RequireConsent {
+		return false
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return true
+	}
+	return !h.Service.HasGrant(uid, client.ClientID, scopes)
+}
+
 // issueCodeAndRedirect creates an auth code for a pre-authenticated user.
 func (h *Handler) issueCodeAndRedirect(c *gin.Context, app *models.Application, client *models.OIDCClient, userID string, req dto.OIDCAuthorizeRequest, consentToken string) {
 	h.issueCodeAndRedirectForUser(c, app, client, userID, &req)
@@ -1189,6 +1274,9 @@ func (h *Handler) issueCodeAndRedirectForUser(c *gin.Context, app *models.Applic
 
 // authenticateUser validates email + password for the OIDC login form.
 func (h *Handler) authenticateUser(app *models.Application, email, password string) (*models.User, error) {
+	if app.PasswordlessOnly || !app.AuthMethodAllowed(models.AuthMethodPassword) {
+		return nil, fmt.Errorf("password sign-in is disabled")
+	}
 	user, err := h.Repo.GetUserByEmail(app.ID.String(), email)
 	if err != nil {
 		return nil, fmt.Errorf("invalid credentials")
@@ -1196,9 +1284,13 @@ func (h *Handler) authenticateUser(app *models.Application, email, password stri
 	if !user.IsActive {
 		return nil, fmt.Errorf("account is inactive")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+	if user.IsServiceAccount {
+		return nil, fmt.Errorf("service accounts cannot sign in interactively")
+	}
+	if err := passhash.Verify(user.PasswordHash, password); err != nil {
 		return nil, fmt.Errorf("invalid credentials")
 	}
+	passhash.RehashAfterLogin(user.ID, user.PasswordHash, password)
 	return user, nil
 }
 
@@ -8,6 +8,8 @@ import (
 	"encoding/pem"
 	"fmt"
 	"math/big"
+
+	pkgjwt "github.com/gjovanovicst/auth_api/pkg/jwt"
 )
 
 const rsaKeyBits = 2048
@@ -48,19 +50,10 @@ func PEMToPrivateKey(pemStr string) (*rsa.PrivateKey, error) {
 }
 
 // JWKS represents a JSON Web Key Set.
-type JWKS struct {
-	Keys []JWK `json:"keys"`
-}
+type JWKS = pkgjwt.JWKS
 
-// JWK is a single JSON Web Key (RSA public key, RS256).
-type JWK struct {
-	Kty string `json:"kty"`
-	Use string `json:"use"`
-	Alg string `json:"alg"`
-	Kid string `json:"kid"`
-	N   string `json:"n"`
-	E   string `json:"e"`
-}
+// JWK is a single JSON Web Key. ID token keys are RSA public keys (RS256).
+type JWK = pkgjwt.JWK
 
 // PublicKeyToJWK converts an RSA public key to a JWK.
 // kid is the key identifier (typically the app UUID).
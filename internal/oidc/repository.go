@@ -62,6 +62,16 @@ func (r *Repository) ListClientsByApp(appID uuid.UUID) ([]models.OIDCClient, err
 	return clients, err
 }
 
+// GetClientsByClientIDs fetches the OIDCClients with the given client_id strings.
+func (r *Repository) GetClientsByClientIDs(clientIDs []string) ([]models.OIDCClient, error) {
+	var clients []models.OIDCClient
+	if len(clientIDs) == 0 {
+		return clients, nil
+	}
+	err := r.DB.Where("client_id IN ?", clientIDs).Find(&clients).Error
+	return clients, err
+}
+
 // UpdateClient persists changes to an OIDCClient.
 func (r *Repository) UpdateClient(client *models.OIDCClient) error {
 	return r.DB.Save(client).Error
@@ -99,6 +109,44 @@ func (r *Repository) DeleteExpiredAuthCodes() error {
 	return r.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCAuthCode{}).Error
 }
 
+// ─── OIDCGrant ─────────────────────────────────────────────────────────────────
+
+// GetGrant fetches the grant of a user to an OIDC client.
+func (r *Repository) GetGrant(userID uuid.UUID, clientID string) (*models.OIDCGrant, error) {
+	var g models.OIDCGrant
+	err := r.DB.Where("user_id = ? AND client_id = ?", userID, clientID).First(&g).Error
+	return &g, err
+}
+
+// SaveGrant inserts or updates a grant.
+func (r *Repository) SaveGrant(grant *models.OIDCGrant) error {
+	return r.DB.Save(grant).Error
+}
+
+// TouchGrant records that tokens were issued to the client of a grant.
+func (r *Repository) TouchGrant(id uuid.UUID, at time.Time) error {
+	return r.DB.Model(&models.OIDCGrant{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+// ListGrantsByUser returns the grants of a user in an application, most
+// recently used first.
+func (r *Repository) ListGrantsByUser(appID, userID uuid.UUID) ([]models.OIDCGrant, error) {
+	var grants []models.OIDCGrant
+	err := r.DB.Where("app_id = ? AND user_id = ?", appID, userID).
+		Order("last_used_at DESC").Find(&grants).Error
+	return grants, err
+}
+
+// MarkUserAuthCodesUsed marks the unused authorization codes issued to an
+// OIDC client for a user as used, so they can no longer be exchanged.
+func (r *Repository) MarkUserAuthCodesUsed(userID uuid.UUID, clientID string) error {
+	return r.DB.Model(&models.OIDCAuthCode{}).
+		Where("user_id = ? AND client_id = ? AND used = ?", userID, clientID, false).
+		Update("used", true).Error
+}
+
 // ─── User lookup (needed by service layer) ─────────────────────────────────────
 
 // GetUserByID fetches a User by UUID string.
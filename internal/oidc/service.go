@@ -370,6 +370,9 @@ func (s *Service) MintTokensForUser(app *models.Application, client *models.OIDC
 	if err := redis.SetOIDCGrantedScopes(app.ID.String(), sessionID, strings.Join(scopes, " "), sessionTTL); err != nil {
 		log.Printf("[OIDC] MintTokensForUser: failed to store granted scopes: %v", err)
 	}
+	if err := redis.SetSessionOIDCClient(app.ID.String(), sessionID, client.ClientID); err != nil {
+		log.Printf("[OIDC] MintTokensForUser: failed to tag session with client: %v", err)
+	}
 
 	// RS256 ID token
 	rsaKey, err := s.GetOrCreateRSAKey(app.ID)
@@ -401,7 +404,7 @@ func (s *Service) MintTokensForUser(app *models.Application, client *models.OIDC
 
 	accessTTLSec := int(accessTTL.Seconds())
 	if accessTTLSec <= 0 {
-		accessTTLSec = viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES") * 60
+		accessTTLSec = int(pkgjwt.DefaultAccessTokenTTL().Seconds())
 	}
 	if accessTTLSec <= 0 {
 		accessTTLSec = 900
@@ -427,6 +430,9 @@ func (s *Service) ClientCredentialsGrant(app *models.Application, clientID, clie
 	if !containsGrantType(client.AllowedGrantTypes, "client_credentials") {
 		return "", 0, fmt.Errorf("unauthorized_client: grant type not allowed")
 	}
+	if !app.AuthMethodAllowed(models.AuthMethodClientCredentials) {
+		return "", 0, fmt.Errorf("unauthorized_client: grant type not allowed for this application")
+	}
 	if err := bcrypt.CompareHashAndPassword([]byte(client.ClientSecretHash), []byte(clientSecret)); err != nil {
 		return "", 0, fmt.Errorf("invalid_client: bad credentials")
 	}
@@ -451,7 +457,7 @@ func (s *Service) ClientCredentialsGrant(app *models.Application, clientID, clie
 
 	ccAccessTTLSec := int(ccAccessTTL.Seconds())
 	if ccAccessTTLSec <= 0 {
-		ccAccessTTLSec = viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES") * 60
+		ccAccessTTLSec = int(pkgjwt.DefaultAccessTokenTTL().Seconds())
 	}
 	if ccAccessTTLSec <= 0 {
 		ccAccessTTLSec = 900
@@ -467,6 +473,15 @@ func (s *Service) GetUserInfo(app *models.Application, accessToken string, scope
 	if err != nil {
 		return nil, fmt.Errorf("invalid_token")
 	}
+	if revoked, err := redis.IsRevokedByAppAuthEpoch(app.ID.String(), claims.IssuedAtTime()); err != nil || revoked {
+		return nil, fmt.Errorf("invalid_token")
+	}
+	// The session is deleted when the user logs out or revokes the client's grant
+	if claims.SessionID != "" {
+		if exists, err := redis.SessionExists(app.ID.String(), claims.SessionID); err != nil || !exists {
+			return nil, fmt.Errorf("invalid_token")
+		}
+	}
 	user, err := s.repo.GetUserByID(claims.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("user not found")
@@ -0,0 +1,231 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	userpkg "github.com/gjovanovicst/auth_api/internal/user"
+	pkgjwt "github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RFC 8693 identifiers used by the token exchange grant.
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// TokenExchangeRequest carries the validated form parameters of a token exchange call.
+type TokenExchangeRequest struct {
+	ClientID           string
+	ClientSecret       string
+	SubjectToken       string
+	SubjectTokenType   string
+	RequestedTokenType string
+	Audience           string
+	Scope              string
+}
+
+// TokenExchangeResult is the outcome of a successful token exchange.
+type TokenExchangeResult struct {
+	AccessToken string
+	ExpiresIn   int
+	Scope       string
+	UserID      string
+	Audience    string
+}
+
+// TokenExchangeGrant implements the RFC 8693 token exchange grant. A confidential
+// client holding a user's access token exchanges it for a new access token that is
+// narrower in scope and/or bound to a different audience (another OIDC client of the
+// same application). The issued token:
+//   - keeps the subject's user ID and session ID, so revoking the user's session
+//     immediately invalidates every token derived from it;
+//   - never outlives the subject token;
+//   - carries the exchanging client in the "act" claim, preserving any prior chain.
+//
+// Errors are prefixed with the RFC 6749 / RFC 8693 error code so the handler can
+// map them onto the token endpoint error response.
+func (s *Service) TokenExchangeGrant(app *models.Application, req TokenExchangeRequest) (*TokenExchangeResult, error) {
+	if req.SubjectToken == "" {
+		return nil, fmt.Errorf("invalid_request: subject_token is required")
+	}
+	if req.SubjectTokenType != TokenTypeAccessToken && req.SubjectTokenType != TokenTypeJWT {
+		return nil, fmt.Errorf("invalid_request: unsupported subject_token_type")
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != TokenTypeAccessToken {
+		return nil, fmt.Errorf("invalid_request: unsupported requested_token_type")
+	}
+
+	client, err := s.repo.GetClientByClientID(req.ClientID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("invalid_client: unknown client")
+		}
+		return nil, err
+	}
+	if !client.IsActive || client.AppID != app.ID {
+		return nil, fmt.Errorf("invalid_client: client disabled")
+	}
+	// Token exchange hands out user-bound tokens, so only confidential clients
+	// that can authenticate themselves may use it.
+	if !client.IsConfidential {
+		return nil, fmt.Errorf("unauthorized_client: public clients cannot exchange tokens")
+	}
+	if !containsGrantType(client.AllowedGrantTypes, GrantTypeTokenExchange) {
+		return nil, fmt.Errorf("unauthorized_client: grant type not allowed")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(client.ClientSecretHash), []byte(req.ClientSecret)); err != nil {
+		return nil, fmt.Errorf("invalid_client: bad credentials")
+	}
+
+	claims, err := s.validateSubjectToken(app, req.SubjectToken)
+	if err != nil {
+		return nil, err
+	}
+
+	audience := client.ClientID
+	if req.Audience != "" {
+		target, err := s.repo.GetClientByClientID(req.Audience)
+		if err != nil || !target.IsActive || target.AppID != app.ID {
+			return nil, fmt.Errorf("invalid_target: unknown audience")
+		}
+		audience = target.ClientID
+	}
+
+	scope, err := resolveExchangeScopes(app.ID.String(), claims, client.AllowedScopes, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// The exchanged token must never outlive the token it was derived from.
+	accessTTL := resolveAccessTTL(app)
+	if claims.ExpiresAt != nil {
+		if remaining := time.Until(claims.ExpiresAt.Time); remaining < accessTTL {
+			accessTTL = remaining
+		}
+	}
+	if accessTTL <= 0 {
+		return nil, fmt.Errorf("invalid_grant: subject_token expired")
+	}
+
+	actor := &pkgjwt.Actor{Subject: client.ClientID, Actor: claims.Actor}
+	accessToken, err := pkgjwt.GenerateDelegatedAccessToken(app.ID.String(), claims.UserID, claims.SessionID, claims.Roles, []string{audience}, scope, actor, accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token: %w", err)
+	}
+
+	return &TokenExchangeResult{
+		AccessToken: accessToken,
+		ExpiresIn:   int(accessTTL.Seconds()),
+		Scope:       scope,
+		UserID:      claims.UserID,
+		Audience:    audience,
+	}, nil
+}
+
+// validateSubjectToken ensures the subject token is a live access token issued for
+// this application: signature and expiry are valid, it has not been revoked and
+// its session still exists. Service account tokens are refused: they have no
+// session, and their revocation is tracked apart from user tokens. So are
+// impersonation tokens, whose imp claim a delegated token would not carry.
+func (s *Service) validateSubjectToken(app *models.Application, token string) (*pkgjwt.Claims, error) {
+	claims, err := pkgjwt.ParseToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid_grant: invalid subject_token")
+	}
+	if claims.TokenType != "" && claims.TokenType != pkgjwt.TokenTypeAccess {
+		return nil, fmt.Errorf("invalid_grant: subject_token is not an access token")
+	}
+	if claims.AppID != app.ID.String() {
+		return nil, fmt.Errorf("invalid_grant: subject_token was issued for a different application")
+	}
+	if claims.ServiceAccount {
+		return nil, fmt.Errorf("invalid_grant: service account tokens cannot be exchanged")
+	}
+	if claims.Impersonation != nil {
+		return nil, fmt.Errorf("invalid_grant: impersonation tokens cannot be exchanged")
+	}
+	if redis.Rdb == nil {
+		return nil, fmt.Errorf("token validation service unavailable")
+	}
+	if blacklisted, err := redis.IsAccessTokenBlacklisted(claims.AppID, token); err != nil || blacklisted {
+		return nil, fmt.Errorf("invalid_grant: subject_token has been revoked")
+	}
+	if blacklisted, err := redis.IsUserTokensBlacklisted(claims.AppID, claims.UserID); err != nil || blacklisted {
+		return nil, fmt.Errorf("invalid_grant: subject_token has been revoked")
+	}
+	if revoked, err := redis.IsRevokedByAppAuthEpoch(claims.AppID, claims.IssuedAtTime()); err != nil || revoked {
+		return nil, fmt.Errorf("invalid_grant: subject_token has been revoked")
+	}
+	if claims.SessionID != "" {
+		exists, err := redis.SessionExists(claims.AppID, claims.SessionID)
+		if err != nil || !exists {
+			return nil, fmt.Errorf("invalid_grant: subject_token session has been revoked")
+		}
+	}
+	return claims, nil
+}
+
+// resolveExchangeScopes computes the scope of the exchanged token. Requested scopes
+// must be a subset of both the exchanging client's allowed scopes and the scopes the
+// subject token already carries (its "scope" claim for delegated tokens, otherwise the
+// scopes granted to its OIDC session). When nothing is requested, the intersection
+// of the two is used. Exchange can narrow scope but never widen it.
+func resolveExchangeScopes(appID string, claims *pkgjwt.Claims, clientAllowed, requested string) (string, error) {
+	allowed := splitScopes(clientAllowed)
+
+	original := splitScopes(claims.Scope)
+	if len(original) == 0 && claims.SessionID != "" {
+		granted, _ := redis.GetOIDCGrantedScopes(appID, claims.SessionID)
+		original = splitScopes(granted)
+	}
+
+	ceiling := allowed
+	if len(original) > 0 {
+		ceiling = intersectScopes(allowed, original)
+	}
+
+	wanted := splitScopes(requested)
+	if len(wanted) == 0 {
+		return strings.Join(ceiling, " "), nil
+	}
+	for _, sc := range wanted {
+		if !sliceContains(ceiling, sc) {
+			return "", fmt.Errorf("invalid_scope: scope %q exceeds the subject token's grant", sc)
+		}
+	}
+	return strings.Join(wanted, " "), nil
+}
+
+// resolveAccessTTL returns the access token TTL for the app, falling back to the
+// global configuration and finally to 15 minutes.
+func resolveAccessTTL(app *models.Application) time.Duration {
+	accessTTL, _ := userpkg.ResolveTokenTTLs(app)
+	if accessTTL <= 0 {
+		accessTTL = pkgjwt.DefaultAccessTokenTTL()
+	}
+	if accessTTL <= 0 {
+		accessTTL = 15 * time.Minute
+	}
+	return accessTTL
+}
+
+// splitScopes splits a scope list that may be space- or comma-separated.
+func splitScopes(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+}
+
+func intersectScopes(a, b []string) []string {
+	out := make([]string, 0, len(a))
+	for _, sc := range a {
+		if sliceContains(b, sc) {
+			out = append(out, sc)
+		}
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package oidc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pkgjwt "github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestValidateSubjectToken_ServiceAccount(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-jwt-secret-that-is-at-least-32-bytes-long!")
+	app := &models.Application{ID: uuid.New()}
+
+	// Refused before any revocation check, so a revoked token is refused too.
+	token, err := pkgjwt.GenerateServiceAccountToken(app.ID.String(), uuid.NewString(), uuid.NewString(), "log:read", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&Service{}).validateSubjectToken(app, token)
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid_grant") {
+		t.Errorf("service account subject_token: err = %v, want invalid_grant", err)
+	}
+}
+
+func TestValidateSubjectToken_Impersonation(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-jwt-secret-that-is-at-least-32-bytes-long!")
+	app := &models.Application{ID: uuid.New()}
+
+	imp := &pkgjwt.Impersonation{By: "agent@example.com", Reason: "ticket 4711"}
+	token, _, err := pkgjwt.GenerateImpersonationToken(app.ID.String(), uuid.NewString(), imp, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&Service{}).validateSubjectToken(app, token)
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid_grant") {
+		t.Errorf("impersonation subject_token: err = %v, want invalid_grant", err)
+	}
+}
@@ -0,0 +1,287 @@
+// Package passhash hashes and verifies user passwords with the algorithm and
+// cost configured in the system settings (bcrypt or argon2id), and rehashes
+// passwords stored with outdated parameters in the background after a
+// successful login (see RehashAfterLogin).
+//
+// Verification always accepts both algorithms, so changing the settings never
+// locks users out: existing hashes keep working and are upgraded as users sign
+// in.
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported algorithms.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// Setting keys read through the resolver set with SetSettingResolver.
+const (
+	SettingAlgorithm         = "PASSWORD_HASH_ALGORITHM"
+	SettingBcryptCost        = "PASSWORD_BCRYPT_COST"
+	SettingArgon2MemoryKB    = "PASSWORD_ARGON2_MEMORY_KB"
+	SettingArgon2Iterations  = "PASSWORD_ARGON2_ITERATIONS"
+	SettingArgon2Parallelism = "PASSWORD_ARGON2_PARALLELISM"
+	SettingRehashOnLogin     = "PASSWORD_REHASH_ON_LOGIN"
+)
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+	// paramsTTL is how long resolved settings are reused before they are
+	// resolved again, so setting changes apply without a restart.
+	paramsTTL = time.Minute
+)
+
+// ErrMismatch is returned by Verify when the password does not match the hash.
+var ErrMismatch = errors.New("password does not match")
+
+// Params are the hashing parameters for new hashes.
+type Params struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2MemoryKB    uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	RehashOnLogin     bool
+}
+
+// DefaultParams are used for settings that are unset or invalid.
+var DefaultParams = Params{
+	Algorithm:         AlgorithmBcrypt,
+	BcryptCost:        12,
+	Argon2MemoryKB:    64 * 1024,
+	Argon2Iterations:  3,
+	Argon2Parallelism: 2,
+	RehashOnLogin:     true,
+}
+
+// Validate reports whether p can be used to hash passwords.
+func (p Params) Validate() error {
+	switch p.Algorithm {
+	case AlgorithmBcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if p.Argon2MemoryKB < 8*uint32(p.Argon2Parallelism) || p.Argon2Iterations < 1 || p.Argon2Parallelism < 1 {
+			return errors.New("argon2id needs at least 1 iteration, 1 thread and 8 KB of memory per thread")
+		}
+	default:
+		return fmt.Errorf("unknown password hash algorithm %q", p.Algorithm)
+	}
+	return nil
+}
+
+// ValidateSetting checks a single setting value, for the admin settings editor.
+func ValidateSetting(key, value string) error {
+	p := DefaultParams
+	var err error
+	switch key {
+	case SettingAlgorithm:
+		p.Algorithm = value
+	case SettingBcryptCost:
+		p.BcryptCost, err = strconv.Atoi(value)
+	case SettingArgon2MemoryKB:
+		p.Algorithm = AlgorithmArgon2id
+		p.Argon2MemoryKB, err = parseUint32(value)
+	case SettingArgon2Iterations:
+		p.Algorithm = AlgorithmArgon2id
+		p.Argon2Iterations, err = parseUint32(value)
+	case SettingArgon2Parallelism:
+		p.Algorithm = AlgorithmArgon2id
+		var n uint64
+		n, err = strconv.ParseUint(value, 10, 8)
+		p.Argon2Parallelism = uint8(n)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.Validate()
+}
+
+// SettingResolverFunc resolves a system setting by key (env > DB > default).
+type SettingResolverFunc func(key string) string
+
+var (
+	mu         sync.Mutex
+	resolver   SettingResolverFunc
+	current    = DefaultParams
+	resolvedAt time.Time
+)
+
+// SetSettingResolver makes the package read its parameters from the system
+// settings. Without a resolver DefaultParams are used.
+func SetSettingResolver(fn SettingResolverFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	resolver = fn
+	resolvedAt = time.Time{}
+}
+
+// ReloadSettings makes the next hash resolve the settings again instead of
+// waiting for the cached ones to expire.
+func ReloadSettings() {
+	mu.Lock()
+	defer mu.Unlock()
+	resolvedAt = time.Time{}
+}
+
+// CurrentParams returns the parameters used for new hashes.
+func CurrentParams() Params {
+	mu.Lock()
+	defer mu.Unlock()
+	if resolver != nil && time.Since(resolvedAt) > paramsTTL {
+		current = resolveParams(resolver)
+		resolvedAt = time.Now()
+	}
+	return current
+}
+
+// resolveParams reads the parameters from the settings, falling back to
+// DefaultParams when they are invalid.
+func resolveParams(resolve SettingResolverFunc) Params {
+	p := DefaultParams
+	if v := strings.ToLower(strings.TrimSpace(resolve(SettingAlgorithm))); v != "" {
+		p.Algorithm = v
+	}
+	if v, err := strconv.Atoi(resolve(SettingBcryptCost)); err == nil {
+		p.BcryptCost = v
+	}
+	if v, err := parseUint32(resolve(SettingArgon2MemoryKB)); err == nil {
+		p.Argon2MemoryKB = v
+	}
+	if v, err := parseUint32(resolve(SettingArgon2Iterations)); err == nil {
+		p.Argon2Iterations = v
+	}
+	if v, err := strconv.ParseUint(resolve(SettingArgon2Parallelism), 10, 8); err == nil {
+		p.Argon2Parallelism = uint8(v)
+	}
+	if v, err := strconv.ParseBool(resolve(SettingRehashOnLogin)); err == nil {
+		p.RehashOnLogin = v
+	}
+	if err := p.Validate(); err != nil {
+		log.Printf("Warning: Invalid password hashing settings (%v), using defaults\n", err)
+		return DefaultParams
+	}
+	return p
+}
+
+// Hash hashes password with the current parameters.
+func Hash(password string) (string, error) {
+	return HashWith(CurrentParams(), password)
+}
+
+// HashWith hashes password with p.
+func HashWith(p Params, password string) (string, error) {
+	if p.Algorithm == AlgorithmArgon2id {
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, p.Argon2Iterations, p.Argon2MemoryKB, p.Argon2Parallelism, argon2KeyLen)
+		return argon2Prefix(p) + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key), nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+	return string(hash), err
+}
+
+// Verify checks password against a bcrypt or argon2id hash. It returns
+// ErrMismatch when the password is wrong and another error when the hash
+// cannot be parsed.
+func Verify(hash, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return verifyArgon2(hash, password)
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+// NeedsRehash reports whether hash was not produced with the current
+// parameters.
+func NeedsRehash(hash string) bool {
+	return !hasParams(hash, CurrentParams())
+}
+
+// hasParams reports whether hash was produced with p.
+func hasParams(hash string, p Params) bool {
+	if p.Algorithm == AlgorithmArgon2id {
+		return strings.HasPrefix(hash, argon2Prefix(p))
+	}
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost == p.BcryptCost
+}
+
+// CurrentHashPatterns returns SQL LIKE patterns matching the hashes produced
+// with the current parameters.
+func CurrentHashPatterns() []string {
+	p := CurrentParams()
+	if p.Algorithm == AlgorithmArgon2id {
+		return []string{argon2Prefix(p) + "%"}
+	}
+	cost := fmt.Sprintf("%02d", p.BcryptCost)
+	return []string{"$2a$" + cost + "$%", "$2b$" + cost + "$%", "$2y$" + cost + "$%"}
+}
+
+// argon2Prefix is the PHC-format prefix of argon2id hashes produced with p.
+func argon2Prefix(p Params) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$", argon2.Version, p.Argon2MemoryKB, p.Argon2Iterations, p.Argon2Parallelism)
+}
+
+// verifyArgon2 checks password against a PHC-format argon2id hash.
+func verifyArgon2(hash, password string) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return errors.New("malformed argon2id hash")
+	}
+	var version int
+	var memory, iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return errors.New("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return errors.New("malformed argon2id parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return errors.New("malformed argon2id salt")
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return errors.New("malformed argon2id key")
+	}
+	got := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func parseUint32(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	return uint32(v), err
+}
@@ -0,0 +1,115 @@
+package passhash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fastParams keeps the tests fast; the production defaults take ~100ms per hash.
+var fastParams = Params{
+	Algorithm:         AlgorithmBcrypt,
+	BcryptCost:        4,
+	Argon2MemoryKB:    64,
+	Argon2Iterations:  1,
+	Argon2Parallelism: 1,
+	RehashOnLogin:     true,
+}
+
+func useSettings(t *testing.T, values map[string]string) {
+	t.Helper()
+	SetSettingResolver(func(key string) string { return values[key] })
+	t.Cleanup(func() { SetSettingResolver(nil) })
+}
+
+func TestHashAndVerify(t *testing.T) {
+	argon := fastParams
+	argon.Algorithm = AlgorithmArgon2id
+
+	for _, p := range []Params{fastParams, argon} {
+		t.Run(p.Algorithm, func(t *testing.T) {
+			hash, err := HashWith(p, "correct horse")
+			if err != nil {
+				t.Fatalf("HashWith: %v", err)
+			}
+			if err := Verify(hash, "correct horse"); err != nil {
+				t.Errorf("Verify(correct) = %v, want nil", err)
+			}
+			if err := Verify(hash, "wrong horse"); !errors.Is(err, ErrMismatch) {
+				t.Errorf("Verify(wrong) = %v, want ErrMismatch", err)
+			}
+		})
+	}
+}
+
+func TestVerifyMalformedArgon2(t *testing.T) {
+	err := Verify("$argon2id$v=19$m=64,t=1,p=1$salt", "pw")
+	if err == nil || errors.Is(err, ErrMismatch) {
+		t.Errorf("Verify(malformed) = %v, want a parse error", err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	useSettings(t, map[string]string{SettingBcryptCost: "5"})
+
+	cost4, _ := HashWith(fastParams, "pw")
+	cost5Params := fastParams
+	cost5Params.BcryptCost = 5
+	cost5, _ := HashWith(cost5Params, "pw")
+	argon := fastParams
+	argon.Algorithm = AlgorithmArgon2id
+	argonHash, _ := HashWith(argon, "pw")
+
+	if !NeedsRehash(cost4) {
+		t.Error("bcrypt cost 4 with cost 5 configured: NeedsRehash = false")
+	}
+	if NeedsRehash(cost5) {
+		t.Error("bcrypt cost 5 with cost 5 configured: NeedsRehash = true")
+	}
+	if !NeedsRehash(argonHash) {
+		t.Error("argon2id with bcrypt configured: NeedsRehash = false")
+	}
+
+	patterns := CurrentHashPatterns()
+	if len(patterns) != 3 || !strings.HasPrefix(cost5, strings.TrimSuffix(patterns[0], "%")) {
+		t.Errorf("CurrentHashPatterns() = %v, want patterns matching %q", patterns, cost5[:7])
+	}
+}
+
+func TestCurrentParamsFallsBackOnInvalidSettings(t *testing.T) {
+	useSettings(t, map[string]string{SettingAlgorithm: "md5", SettingBcryptCost: "4"})
+	if got := CurrentParams(); got != DefaultParams {
+		t.Errorf("CurrentParams() = %+v, want defaults", got)
+	}
+}
+
+func TestValidateSetting(t *testing.T) {
+	tests := []struct {
+		key, value string
+		ok         bool
+	}{
+		{SettingAlgorithm, "argon2id", true},
+		{SettingAlgorithm, "md5", false},
+		{SettingBcryptCost, "10", true},
+		{SettingBcryptCost, "3", false},
+		{SettingBcryptCost, "32", false},
+		{SettingArgon2MemoryKB, "4", false},
+		{SettingArgon2Iterations, "0", false},
+		{SettingArgon2Parallelism, "300", false},
+		{SettingRehashOnLogin, "false", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSetting(tt.key, tt.value); (err == nil) != tt.ok {
+			t.Errorf("ValidateSetting(%s, %q) = %v, want ok=%v", tt.key, tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestProgressPercent(t *testing.T) {
+	if got := (Progress{}).Percent(); got != 100 {
+		t.Errorf("Percent() with no hashes = %v, want 100", got)
+	}
+	if got := (Progress{Total: 4, Current: 1}).Percent(); got != 25 {
+		t.Errorf("Percent() = %v, want 25", got)
+	}
+}
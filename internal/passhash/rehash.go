@@ -0,0 +1,144 @@
+package passhash
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// rehashQueueSize bounds the pending rehashes; logins beyond it are skipped
+// and picked up on the user's next login.
+const rehashQueueSize = 256
+
+// rehashRequest is a password verified at login whose stored hash is outdated.
+type rehashRequest struct {
+	userID   uuid.UUID
+	oldHash  string
+	password string
+}
+
+// RehashStats counts the rehashes done by this instance since it started.
+type RehashStats struct {
+	Running  bool
+	Rehashed int64
+	Failed   int64
+	Skipped  int64 // queue full
+}
+
+// Progress reports how many users have a password and how many of those
+// hashes already use the current parameters.
+type Progress struct {
+	Params  Params
+	Total   int64
+	Current int64
+}
+
+// Percent returns the share of current hashes, 100 when there are none.
+func (p Progress) Percent() float64 {
+	if p.Total == 0 {
+		return 100
+	}
+	return float64(p.Current) * 100 / float64(p.Total)
+}
+
+var (
+	queue                     chan rehashRequest
+	rehashed, failed, skipped atomic.Int64
+)
+
+// Start runs the background rehash worker until ctx is done. Before Start,
+// RehashAfterLogin does nothing.
+func Start(ctx context.Context, db *gorm.DB) {
+	q := make(chan rehashRequest, rehashQueueSize)
+	mu.Lock()
+	queue = q
+	mu.Unlock()
+	go runRehash(ctx, db, q)
+	log.Println("Password rehash worker started")
+}
+
+// RehashAfterLogin queues an upgrade of the user's password hash when it was
+// produced with outdated parameters. Call it only after password verified
+// against oldHash. It never blocks the login.
+func RehashAfterLogin(userID uuid.UUID, oldHash, password string) {
+	mu.Lock()
+	q := queue
+	mu.Unlock()
+	if q == nil || !CurrentParams().RehashOnLogin || !NeedsRehash(oldHash) {
+		return
+	}
+	select {
+	case q <- rehashRequest{userID: userID, oldHash: oldHash, password: password}:
+	default:
+		skipped.Add(1)
+	}
+}
+
+// Stats returns the counters of the rehash worker.
+func Stats() RehashStats {
+	mu.Lock()
+	running := queue != nil
+	mu.Unlock()
+	return RehashStats{Running: running, Rehashed: rehashed.Load(), Failed: failed.Load(), Skipped: skipped.Load()}
+}
+
+// GetProgress counts the users whose password hash uses the current parameters.
+func GetProgress(db *gorm.DB) (Progress, error) {
+	progress := Progress{Params: CurrentParams()}
+	base := db.Model(&models.User{}).Where("password_hash <> ''")
+	if err := base.Count(&progress.Total).Error; err != nil {
+		return progress, err
+	}
+
+	current := db.Model(&models.User{})
+	var cond *gorm.DB
+	for _, pattern := range CurrentHashPatterns() {
+		if cond == nil {
+			cond = db.Where("password_hash LIKE ?", pattern)
+		} else {
+			cond = cond.Or("password_hash LIKE ?", pattern)
+		}
+	}
+	err := current.Where(cond).Count(&progress.Current).Error
+	return progress, err
+}
+
+func runRehash(ctx context.Context, db *gorm.DB, q chan rehashRequest) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-q:
+			if err := rehash(ctx, db, req); err != nil {
+				failed.Add(1)
+				log.Printf("Warning: Failed to rehash password of user %s: %v\n", req.userID, err)
+			}
+		}
+	}
+}
+
+// rehash stores a new hash of the password. The update only applies while the
+// stored hash is still the verified one, so a concurrent password change wins.
+func rehash(ctx context.Context, db *gorm.DB, req rehashRequest) error {
+	if !NeedsRehash(req.oldHash) {
+		return nil
+	}
+	hash, err := Hash(req.password)
+	if err != nil {
+		return err
+	}
+	res := db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND password_hash = ?", req.userID, req.oldHash).
+		Update("password_hash", hash)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		rehashed.Add(1)
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+// Package providercheck tests the configuration of external sign-in and
+// messaging providers — OAuth client credentials and the SMS provider — so
+// that misconfigurations are caught before users hit them.
+package providercheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+// Check statuses
+const (
+	StatusPass  Status = "pass"  // The setting works
+	StatusWarn  Status = "warn"  // The setting may work but looks wrong, or cannot be fully verified
+	StatusFail  Status = "fail"  // The setting is missing or rejected by the provider
+	StatusError Status = "error" // The provider could not be reached
+)
+
+// Check names
+const (
+	CheckCredentials   = "Credentials"
+	CheckRedirectURL   = "Redirect URL"
+	CheckTokenEndpoint = "Token endpoint"
+	CheckSMSProvider   = "SMS provider"
+	CheckSMSAccount    = "Account"
+	CheckSMSSender     = "Sender number"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Check  string // One of the Check* names
+	Status Status
+	Detail string // What was found
+	Hint   string // How to fix the setting; empty when the check passes
+}
+
+// Report holds the results of testing one provider configuration.
+type Report struct {
+	Provider string // e.g. "google" or "twilio"
+	Results  []Result
+}
+
+// Passed reports whether every check passed.
+func (r Report) Passed() bool {
+	for _, res := range r.Results {
+		if res.Status != StatusPass {
+			return false
+		}
+	}
+	return true
+}
+
+// Provider endpoints probed by the checks
+// #nosec G101 -- These are public OAuth endpoint URLs, not credentials
+const (
+	DefaultGoogleTokenURL   = "https://oauth2.googleapis.com/token"
+	DefaultFacebookTokenURL = "https://graph.facebook.com/v18.0/oauth/access_token"
+	DefaultGithubTokenURL   = "https://github.com/login/oauth/access_token"
+	DefaultTwilioAPIURL     = "https://api.twilio.com"
+)
+
+// probeCode is the authorization code sent to token endpoints. Providers reject
+// it as invalid only after they have accepted the client credentials.
+const probeCode = "provider-check-invalid-code"
+
+// Checker runs the checks.
+type Checker struct {
+	Client           *http.Client
+	GoogleTokenURL   string
+	FacebookTokenURL string
+	GithubTokenURL   string
+	TwilioAPIURL     string
+}
+
+// NewChecker creates a Checker that probes the providers' public endpoints.
+func NewChecker() *Checker {
+	return &Checker{
+		Client:           &http.Client{Timeout: 10 * time.Second},
+		GoogleTokenURL:   DefaultGoogleTokenURL,
+		FacebookTokenURL: DefaultFacebookTokenURL,
+		GithubTokenURL:   DefaultGithubTokenURL,
+		TwilioAPIURL:     DefaultTwilioAPIURL,
+	}
+}
+
+// CheckOAuth tests an OAuth provider config: that its credentials are set,
+// that its redirect URL points at the provider's callback route, and that the
+// provider's token endpoint accepts the client ID and secret.
+func (c *Checker) CheckOAuth(ctx context.Context, cfg *models.OAuthProviderConfig) Report {
+	return Report{
+		Provider: cfg.Provider,
+		Results: []Result{
+			checkCredentials(cfg),
+			checkRedirectURL(cfg.Provider, cfg.RedirectURL),
+			c.checkTokenEndpoint(ctx, cfg),
+		},
+	}
+}
+
+func checkCredentials(cfg *models.OAuthProviderConfig) Result {
+	res := Result{Check: CheckCredentials}
+	switch {
+	case strings.TrimSpace(cfg.ClientID) == "":
+		res.Status = StatusFail
+		res.Detail = "The client ID is empty."
+		res.Hint = "Copy the client ID from the provider's developer console."
+	case cfg.ClientSecret == "":
+		res.Status = StatusFail
+		res.Detail = "The client secret is empty."
+		res.Hint = "Copy the client secret from the provider's developer console."
+	default:
+		res.Status = StatusPass
+		res.Detail = "Client ID and secret are set."
+	}
+	return res
+}
+
+func checkRedirectURL(provider, redirectURL string) Result {
+	res := Result{Check: CheckRedirectURL}
+	callbackPath := "/auth/" + provider + "/callback"
+	if !models.IsBuiltinOAuthProvider(provider) {
+		callbackPath = "/auth/oidc/" + provider + "/callback"
+	}
+	u, err := url.Parse(strings.TrimSpace(redirectURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		res.Status = StatusFail
+		res.Detail = fmt.Sprintf("%q is not an absolute http(s) URL.", redirectURL)
+		res.Hint = "Use the public URL of this API's callback, e.g. https://auth.example.com" + callbackPath + "."
+		return res
+	}
+	switch {
+	case !strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), callbackPath):
+		res.Status = StatusWarn
+		res.Detail = fmt.Sprintf("The path %q is not this API's %s callback route.", u.Path, provider)
+		res.Hint = "The provider redirects users here after sign-in; it should end in " + callbackPath + " unless a proxy rewrites it."
+	case u.Scheme == "http" && !isLoopback(u.Hostname()):
+		res.Status = StatusWarn
+		res.Detail = "The redirect URL uses plain http."
+		res.Hint = "Providers reject http redirect URLs outside local development; use https."
+	default:
+		res.Status = StatusPass
+		res.Detail = "Points at the " + provider + " callback route."
+	}
+	return res
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// tokenError is the error body of OAuth token endpoints. Facebook nests it.
+type tokenError struct {
+	Error            json.RawMessage `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+	AccessToken      string          `json:"access_token"`
+}
+
+// code returns the error code of the body ("" when there is none).
+func (e tokenError) code() (code, message string) {
+	if len(e.Error) == 0 {
+		return "", ""
+	}
+	if err := json.Unmarshal(e.Error, &code); err == nil {
+		return code, e.ErrorDescription
+	}
+	var fb struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(e.Error, &fb); err == nil {
+		return fb.Type, fb.Message
+	}
+	return "unknown", ""
+}
+
+func (c *Checker) checkTokenEndpoint(ctx context.Context, cfg *models.OAuthProviderConfig) Result {
+	res := Result{Check: CheckTokenEndpoint}
+	if strings.TrimSpace(cfg.ClientID) == "" || cfg.ClientSecret == "" {
+		res.Status = StatusWarn
+		res.Detail = "Skipped: credentials are incomplete."
+		return res
+	}
+
+	var req *http.Request
+	var err error
+	switch {
+	case cfg.Provider == "google" || cfg.Provider == "github" || cfg.IsGenericOIDC():
+		tokenURL := c.GoogleTokenURL
+		if cfg.Provider == "github" {
+			tokenURL = c.GithubTokenURL
+		} else if cfg.IsGenericOIDC() {
+			if tokenURL, err = c.discoverTokenURL(ctx, cfg.IssuerURL); err != nil {
+				res.Status = StatusError
+				res.Detail = "The provider's discovery document could not be read: " + err.Error()
+				res.Hint = "Check the issuer URL; " + strings.TrimSuffix(cfg.IssuerURL, "/") + "/.well-known/openid-configuration must be reachable from the server."
+				return res
+			}
+		}
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {probeCode},
+			"client_id":     {cfg.ClientID},
+			"client_secret": {cfg.ClientSecret},
+			"redirect_uri":  {cfg.RedirectURL},
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	case cfg.Provider == "facebook":
+		// An app access token request validates the credentials directly
+		q := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {cfg.ClientID},
+			"client_secret": {cfg.ClientSecret},
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.FacebookTokenURL+"?"+q.Encode(), nil)
+	default:
+		res.Status = StatusWarn
+		res.Detail = fmt.Sprintf("No endpoint check is available for provider %q.", cfg.Provider)
+		return res
+	}
+	if err != nil {
+		res.Status = StatusError
+		res.Detail = "Failed to build the request: " + err.Error()
+		return res
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		res.Status = StatusError
+		res.Detail = "The token endpoint could not be reached: " + err.Error()
+		res.Hint = "Check the server's outbound network access to " + req.URL.Host + "."
+		return res
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var te tokenError
+	_ = json.Unmarshal(body, &te)
+	code, message := te.code()
+
+	switch {
+	case cfg.Provider == "facebook" && resp.StatusCode == http.StatusOK && te.AccessToken != "":
+		res.Status = StatusPass
+		res.Detail = "Facebook issued an app access token for these credentials."
+	case code == "invalid_grant" || code == "bad_verification_code":
+		// The test code was rejected, so the client credentials were accepted
+		res.Status = StatusPass
+		res.Detail = "The provider accepted the client ID and secret."
+	case code == "invalid_client" || code == "incorrect_client_credentials" || code == "unauthorized_client" || code == "OAuthException":
+		res.Status = StatusFail
+		res.Detail = "The provider rejected the client credentials" + describe(code, message)
+		res.Hint = "Re-copy the client ID and secret from the provider's developer console; the secret may have been rotated."
+	case code == "redirect_uri_mismatch":
+		res.Status = StatusFail
+		res.Detail = "The redirect URL is not registered with the provider" + describe(code, message)
+		res.Hint = "Add " + cfg.RedirectURL + " to the authorized redirect URIs of the OAuth client."
+	default:
+		res.Status = StatusWarn
+		res.Detail = fmt.Sprintf("Unexpected response (HTTP %d)%s", resp.StatusCode, describe(code, message))
+		res.Hint = "The credentials could not be verified; try a real sign-in."
+	}
+	return res
+}
+
+// discoverTokenURL returns the token endpoint of the OpenID Connect discovery
+// document of issuer.
+func (c *Checker) discoverTokenURL(ctx context.Context, issuer string) (string, error) {
+	if issuer == "" {
+		return "", fmt.Errorf("no issuer URL is set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var doc struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&doc); err != nil {
+		return "", err
+	}
+	if doc.TokenEndpoint == "" {
+		return "", fmt.Errorf("it has no token_endpoint")
+	}
+	return doc.TokenEndpoint, nil
+}
+
+// describe formats a provider error code and message for a Detail.
+func describe(code, message string) string {
+	switch {
+	case code != "" && message != "":
+		return fmt.Sprintf(": %s (%s).", code, message)
+	case code != "":
+		return ": " + code + "."
+	}
+	return "."
+}
@@ -0,0 +1,164 @@
+package providercheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/spf13/viper"
+)
+
+func testChecker(srv *httptest.Server) *Checker {
+	return &Checker{
+		Client:           srv.Client(),
+		GoogleTokenURL:   srv.URL + "/google",
+		FacebookTokenURL: srv.URL + "/facebook",
+		GithubTokenURL:   srv.URL + "/github",
+		TwilioAPIURL:     srv.URL,
+	}
+}
+
+func TestCheckOAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		secret := r.FormValue("client_secret")
+		switch r.URL.Path {
+		case "/google":
+			if secret != "good" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"invalid_client","error_description":"Unauthorized"}`)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant","error_description":"Malformed auth code."}`)
+		case "/github":
+			if r.FormValue("redirect_uri") != "https://auth.example.com/auth/github/callback" {
+				fmt.Fprint(w, `{"error":"redirect_uri_mismatch"}`)
+				return
+			}
+			fmt.Fprint(w, `{"error":"bad_verification_code"}`)
+		case "/facebook":
+			if secret != "good" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":{"message":"Error validating client secret.","type":"OAuthException","code":1}}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"app|token","token_type":"bearer"}`)
+		case "/okta/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":"http://%s/okta","token_endpoint":"http://%s/google"}`, r.Host, r.Host)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c := testChecker(srv)
+
+	tests := []struct {
+		name     string
+		cfg      models.OAuthProviderConfig
+		statuses [3]Status // credentials, redirect URL, token endpoint
+	}{
+		{"google ok", models.OAuthProviderConfig{Provider: "google", ClientID: "id", ClientSecret: "good", RedirectURL: "https://auth.example.com/auth/google/callback"},
+			[3]Status{StatusPass, StatusPass, StatusPass}},
+		{"google bad secret", models.OAuthProviderConfig{Provider: "google", ClientID: "id", ClientSecret: "bad", RedirectURL: "https://auth.example.com/auth/google/callback"},
+			[3]Status{StatusPass, StatusPass, StatusFail}},
+		{"github unregistered redirect", models.OAuthProviderConfig{Provider: "github", ClientID: "id", ClientSecret: "good", RedirectURL: "https://other.example.com/auth/github/callback"},
+			[3]Status{StatusPass, StatusPass, StatusFail}},
+		{"github http redirect on localhost", models.OAuthProviderConfig{Provider: "github", ClientID: "id", ClientSecret: "good", RedirectURL: "http://localhost:8080/auth/github/callback"},
+			[3]Status{StatusPass, StatusPass, StatusFail}},
+		{"facebook ok, wrong path", models.OAuthProviderConfig{Provider: "facebook", ClientID: "id", ClientSecret: "good", RedirectURL: "https://auth.example.com/callback"},
+			[3]Status{StatusPass, StatusWarn, StatusPass}},
+		{"facebook bad secret, http", models.OAuthProviderConfig{Provider: "facebook", ClientID: "id", ClientSecret: "bad", RedirectURL: "http://auth.example.com/auth/facebook/callback"},
+			[3]Status{StatusPass, StatusWarn, StatusFail}},
+		{"oidc ok", models.OAuthProviderConfig{Provider: "okta", IssuerURL: srv.URL + "/okta", ClientID: "id", ClientSecret: "good", RedirectURL: "https://auth.example.com/auth/oidc/okta/callback"},
+			[3]Status{StatusPass, StatusPass, StatusPass}},
+		{"oidc without discovery, built-in path", models.OAuthProviderConfig{Provider: "keycloak", IssuerURL: srv.URL + "/keycloak", ClientID: "id", ClientSecret: "good", RedirectURL: "https://auth.example.com/auth/keycloak/callback"},
+			[3]Status{StatusPass, StatusWarn, StatusError}},
+		{"missing secret", models.OAuthProviderConfig{Provider: "google", ClientID: "id", RedirectURL: "not a url"},
+			[3]Status{StatusFail, StatusFail, StatusWarn}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := c.CheckOAuth(context.Background(), &tt.cfg)
+			if len(rep.Results) != 3 {
+				t.Fatalf("got %d results, want 3", len(rep.Results))
+			}
+			for i, want := range tt.statuses {
+				if got := rep.Results[i]; got.Status != want {
+					t.Errorf("%s: status %s (%s), want %s", got.Check, got.Status, got.Detail, want)
+				}
+			}
+			if rep.Passed() != (tt.statuses == [3]Status{StatusPass, StatusPass, StatusPass}) {
+				t.Errorf("Passed() = %v", rep.Passed())
+			}
+		})
+	}
+}
+
+func TestCheckSMS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, token, _ := r.BasicAuth()
+		if token != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":20003,"message":"Authenticate"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/2010-04-01/Accounts/AC1.json":
+			fmt.Fprint(w, `{"status":"active"}`)
+		case "/2010-04-01/Accounts/AC1/IncomingPhoneNumbers.json":
+			if r.URL.Query().Get("PhoneNumber") == "+15005550006" {
+				fmt.Fprint(w, `{"incoming_phone_numbers":[{"phone_number":"+15005550006"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"incoming_phone_numbers":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c := testChecker(srv)
+
+	keys := []string{"SMS_PROVIDER", "SMS_TWILIO_ACCOUNT_SID", "SMS_TWILIO_AUTH_TOKEN", "SMS_TWILIO_FROM_NUMBER"}
+	t.Cleanup(func() {
+		for _, k := range keys {
+			viper.Set(k, nil)
+		}
+	})
+	set := func(values ...string) {
+		for i, k := range keys {
+			viper.Set(k, values[i])
+		}
+	}
+
+	tests := []struct {
+		name     string
+		values   []string
+		statuses []Status
+	}{
+		{"disabled", []string{"", "", "", ""}, []Status{StatusFail}},
+		{"unknown provider", []string{"nexmo", "", "", ""}, []Status{StatusFail}},
+		{"incomplete", []string{"twilio", "AC1", "", "+15005550006"}, []Status{StatusFail}},
+		{"ok", []string{"twilio", "AC1", "good", "+15005550006"}, []Status{StatusPass, StatusPass, StatusPass}},
+		{"bad token", []string{"twilio", "AC1", "bad", "+15005550006"}, []Status{StatusPass, StatusFail}},
+		{"foreign number", []string{"twilio", "AC1", "good", "+15005550001"}, []Status{StatusPass, StatusPass, StatusFail}},
+		{"not E.164", []string{"twilio", "AC1", "good", "MyBrand"}, []Status{StatusPass, StatusPass, StatusWarn}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set(tt.values...)
+			rep := c.CheckSMS(context.Background())
+			if len(rep.Results) != len(tt.statuses) {
+				t.Fatalf("got %+v, want %d results", rep.Results, len(tt.statuses))
+			}
+			for i, want := range tt.statuses {
+				if got := rep.Results[i]; got.Status != want {
+					t.Errorf("%s: status %s (%s), want %s", got.Check, got.Status, got.Detail, want)
+				}
+			}
+		})
+	}
+}
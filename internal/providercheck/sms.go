@@ -0,0 +1,140 @@
+package providercheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/internal/sms"
+	"github.com/spf13/viper"
+)
+
+// e164 matches phone numbers in E.164 format.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// CheckSMS tests the SMS provider configured by SMS_PROVIDER (see
+// sms.NewSenderFromConfig). For Twilio it verifies the account credentials
+// and that the sender number belongs to the account, without sending an SMS.
+func (c *Checker) CheckSMS(ctx context.Context) Report {
+	provider := viper.GetString("SMS_PROVIDER")
+	rep := Report{Provider: provider}
+	switch provider {
+	case sms.ProviderTwilio:
+		sid := viper.GetString("SMS_TWILIO_ACCOUNT_SID")
+		token := viper.GetString("SMS_TWILIO_AUTH_TOKEN")
+		from := viper.GetString("SMS_TWILIO_FROM_NUMBER")
+		if sid == "" || token == "" || from == "" {
+			rep.Results = append(rep.Results, Result{
+				Check: CheckSMSProvider, Status: StatusFail,
+				Detail: "SMS_PROVIDER=twilio but the Twilio credentials are incomplete, so SMS is disabled.",
+				Hint:   "Set SMS_TWILIO_ACCOUNT_SID, SMS_TWILIO_AUTH_TOKEN and SMS_TWILIO_FROM_NUMBER.",
+			})
+			return rep
+		}
+		rep.Results = append(rep.Results, Result{Check: CheckSMSProvider, Status: StatusPass, Detail: "Twilio is configured."})
+		account := c.checkTwilioAccount(ctx, sid, token)
+		rep.Results = append(rep.Results, account)
+		if account.Status == StatusPass {
+			rep.Results = append(rep.Results, c.checkTwilioSender(ctx, sid, token, from))
+		}
+	case sms.ProviderDisabled:
+		rep.Results = append(rep.Results, Result{
+			Check: CheckSMSProvider, Status: StatusFail,
+			Detail: "SMS is disabled (SMS_PROVIDER is not set); SMS 2FA codes cannot be sent.",
+			Hint:   "Set SMS_PROVIDER=twilio and the SMS_TWILIO_* variables.",
+		})
+	default:
+		rep.Results = append(rep.Results, Result{
+			Check: CheckSMSProvider, Status: StatusFail,
+			Detail: fmt.Sprintf("Unknown SMS_PROVIDER %q, so SMS is disabled.", provider),
+			Hint:   "Supported providers: twilio.",
+		})
+	}
+	return rep
+}
+
+// twilioGet performs an authenticated GET against the Twilio REST API and
+// decodes the JSON response into out.
+func (c *Checker) twilioGet(ctx context.Context, sid, token, path string, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.TwilioAPIURL, "/")+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.SetBasicAuth(sid, token)
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256<<10))
+	_ = json.Unmarshal(body, out)
+	return resp.StatusCode, nil
+}
+
+func (c *Checker) checkTwilioAccount(ctx context.Context, sid, token string) Result {
+	res := Result{Check: CheckSMSAccount}
+	var account struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	status, err := c.twilioGet(ctx, sid, token, "/2010-04-01/Accounts/"+url.PathEscape(sid)+".json", &account)
+	switch {
+	case err != nil:
+		res.Status = StatusError
+		res.Detail = "The Twilio API could not be reached: " + err.Error()
+		res.Hint = "Check the server's outbound network access to api.twilio.com."
+	case status == http.StatusUnauthorized || status == http.StatusForbidden || status == http.StatusNotFound:
+		res.Status = StatusFail
+		res.Detail = fmt.Sprintf("Twilio rejected the account SID or auth token (HTTP %d).", status)
+		res.Hint = "Re-copy SMS_TWILIO_ACCOUNT_SID and SMS_TWILIO_AUTH_TOKEN from the Twilio console."
+	case status != http.StatusOK:
+		res.Status = StatusWarn
+		res.Detail = fmt.Sprintf("Unexpected response from Twilio (HTTP %d). %s", status, account.Message)
+	case account.Status != "" && account.Status != "active":
+		res.Status = StatusFail
+		res.Detail = fmt.Sprintf("The Twilio account is %s.", account.Status)
+		res.Hint = "Reactivate the account in the Twilio console."
+	default:
+		res.Status = StatusPass
+		res.Detail = "The credentials are valid and the account is active."
+	}
+	return res
+}
+
+func (c *Checker) checkTwilioSender(ctx context.Context, sid, token, from string) Result {
+	res := Result{Check: CheckSMSSender}
+	if !e164.MatchString(from) {
+		res.Status = StatusWarn
+		res.Detail = fmt.Sprintf("%q is not an E.164 phone number.", from)
+		res.Hint = "Use the number in E.164 format (e.g. +14155552671), unless it is an alphanumeric sender ID."
+		return res
+	}
+	var numbers struct {
+		IncomingPhoneNumbers []struct {
+			PhoneNumber string `json:"phone_number"`
+		} `json:"incoming_phone_numbers"`
+	}
+	path := "/2010-04-01/Accounts/" + url.PathEscape(sid) + "/IncomingPhoneNumbers.json?PhoneNumber=" + url.QueryEscape(from)
+	status, err := c.twilioGet(ctx, sid, token, path, &numbers)
+	switch {
+	case err != nil:
+		res.Status = StatusError
+		res.Detail = "The Twilio API could not be reached: " + err.Error()
+	case status != http.StatusOK:
+		res.Status = StatusWarn
+		res.Detail = fmt.Sprintf("The account's phone numbers could not be listed (HTTP %d).", status)
+	case len(numbers.IncomingPhoneNumbers) == 0:
+		res.Status = StatusFail
+		res.Detail = from + " is not a phone number of this Twilio account."
+		res.Hint = "Set SMS_TWILIO_FROM_NUMBER to a number bought in the Twilio console."
+	default:
+		res.Status = StatusPass
+		res.Detail = from + " belongs to the account."
+	}
+	return res
+}
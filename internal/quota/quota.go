@@ -0,0 +1,179 @@
+// Package quota enforces per-tenant and per-application resource limits.
+//
+// Each limit is resolved from the tenant/application override first and falls
+// back to a global default from the environment. A resolved limit of 0 means
+// unlimited, so quotas are opt-in:
+//
+//	QUOTA_MAX_APPS_PER_TENANT   — applications per tenant   (Tenant.MaxApps)
+//	QUOTA_MAX_USERS_PER_APP     — users per application     (Application.MaxUsers)
+//	QUOTA_MAX_API_KEYS_PER_APP  — active app API keys       (Application.MaxApiKeys)
+//	QUOTA_MAX_EMAILS_PER_DAY    — emails sent per UTC day   (Application.MaxEmailsPerDay)
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	appRedis "github.com/gjovanovicst/auth_api/internal/redis"
+	appErrors "github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Environment keys holding the global default limits.
+const (
+	EnvMaxAppsPerTenant = "QUOTA_MAX_APPS_PER_TENANT"
+	EnvMaxUsersPerApp   = "QUOTA_MAX_USERS_PER_APP"
+	EnvMaxApiKeysPerApp = "QUOTA_MAX_API_KEYS_PER_APP"
+	EnvMaxEmailsPerDay  = "QUOTA_MAX_EMAILS_PER_DAY"
+)
+
+// emailCounterDayFormat is the UTC day bucket of the daily email counter.
+const emailCounterDayFormat = "20060102"
+
+// ErrExceeded is wrapped by every quota violation. Callers should use
+// errors.Is(err, quota.ErrExceeded) and may return err.Error() to the client.
+var ErrExceeded = errors.New("quota exceeded")
+
+// ToAppError converts a quota check error into an AppError: quota violations
+// become 403 responses carrying the violation message, anything else is an
+// internal error. Returns nil for a nil error.
+func ToAppError(err error) *appErrors.AppError {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrExceeded) {
+		return appErrors.NewAppError(appErrors.ErrForbidden, err.Error())
+	}
+	return appErrors.NewAppError(appErrors.ErrInternal, "Failed to check quota")
+}
+
+// Resolve returns the effective limit: the override when positive, otherwise
+// the global default stored under envKey. 0 means unlimited.
+func Resolve(override int, envKey string) int {
+	if override > 0 {
+		return override
+	}
+	if v := viper.GetInt(envKey); v > 0 {
+		return v
+	}
+	return 0
+}
+
+// CheckTenantApps returns an ErrExceeded error if the tenant already owns its
+// maximum number of applications.
+func CheckTenantApps(db *gorm.DB, tenantID uuid.UUID) error {
+	var tenant models.Tenant
+	if err := db.Select("id, max_apps").First(&tenant, "id = ?", tenantID).Error; err != nil {
+		return err
+	}
+	limit := Resolve(tenant.MaxApps, EnvMaxAppsPerTenant)
+	if limit == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&models.Application{}).Where("tenant_id = ?", tenantID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return fmt.Errorf("%w: tenant has reached its limit of %d applications", ErrExceeded, limit)
+	}
+	return nil
+}
+
+// CheckAppUsers returns an ErrExceeded error if adding n users would take the
+// application over its user limit.
+func CheckAppUsers(db *gorm.DB, appID uuid.UUID, n int) error {
+	limit, err := appLimit(db, appID, "max_users", EnvMaxUsersPerApp)
+	if err != nil || limit == 0 {
+		return err
+	}
+
+	var count int64
+	if err := db.Model(&models.User{}).Where("app_id = ?", appID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count+int64(n) > int64(limit) {
+		return fmt.Errorf("%w: application has reached its limit of %d users", ErrExceeded, limit)
+	}
+	return nil
+}
+
+// CheckAppApiKeys returns an ErrExceeded error if the application already has
+// its maximum number of active (non-revoked, non-expired) API keys.
+func CheckAppApiKeys(db *gorm.DB, appID uuid.UUID) error {
+	limit, err := appLimit(db, appID, "max_api_keys", EnvMaxApiKeysPerApp)
+	if err != nil || limit == 0 {
+		return err
+	}
+
+	var count int64
+	if err := db.Model(&models.ApiKey{}).
+		Where("app_id = ? AND is_revoked = ? AND (expires_at IS NULL OR expires_at > ?)", appID, false, time.Now()).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return fmt.Errorf("%w: application has reached its limit of %d API keys", ErrExceeded, limit)
+	}
+	return nil
+}
+
+// CheckAppEmails returns an ErrExceeded error if the application has already
+// sent its maximum number of emails today (UTC). The daily counter lives in
+// Redis; when Redis is unavailable the quota is not enforced.
+func CheckAppEmails(db *gorm.DB, appID uuid.UUID) error {
+	if appRedis.Rdb == nil {
+		return nil
+	}
+	limit, err := appLimit(db, appID, "max_emails_per_day", EnvMaxEmailsPerDay)
+	if err != nil || limit == 0 {
+		return err
+	}
+
+	count, err := appRedis.GetDailyEmailCount(appID.String(), time.Now().UTC().Format(emailCounterDayFormat))
+	if err != nil {
+		log.Printf("Warning: failed to read daily email count for app %s: %v", appID, err)
+		return nil
+	}
+	if count >= int64(limit) {
+		return fmt.Errorf("%w: application has reached its limit of %d emails per day", ErrExceeded, limit)
+	}
+	return nil
+}
+
+// RecordEmailSent counts one sent email against the application's daily quota.
+func RecordEmailSent(appID uuid.UUID) {
+	if appRedis.Rdb == nil {
+		return
+	}
+	if _, err := appRedis.IncrDailyEmailCount(appID.String(), time.Now().UTC().Format(emailCounterDayFormat)); err != nil {
+		log.Printf("Warning: failed to count email for app %s: %v", appID, err)
+	}
+}
+
+// DailyEmailCount returns the number of emails the application sent on the
+// given UTC day. The counters expire two days after their day, so older days
+// report 0; without Redis it always reports 0.
+func DailyEmailCount(appID uuid.UUID, day time.Time) (int64, error) {
+	if appRedis.Rdb == nil {
+		return 0, nil
+	}
+	return appRedis.GetDailyEmailCount(appID.String(), day.UTC().Format(emailCounterDayFormat))
+}
+
+// appLimit loads a single per-app override column and resolves it against the
+// global default. A missing application resolves to the global default.
+func appLimit(db *gorm.DB, appID uuid.UUID, column, envKey string) (int, error) {
+	var override int
+	err := db.Model(&models.Application{}).Select(column).Where("id = ?", appID).Scan(&override).Error
+	if err != nil {
+		return 0, err
+	}
+	return Resolve(override, envKey), nil
+}
@@ -0,0 +1,56 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestResolve(t *testing.T) {
+	const key = "QUOTA_TEST_LIMIT"
+	t.Cleanup(func() { viper.Set(key, nil) })
+
+	viper.Set(key, 0)
+	if got := Resolve(0, key); got != 0 {
+		t.Errorf("no override, no default = %d, want 0 (unlimited)", got)
+	}
+	if got := Resolve(5, key); got != 5 {
+		t.Errorf("override 5 = %d, want 5", got)
+	}
+
+	viper.Set(key, 100)
+	if got := Resolve(0, key); got != 100 {
+		t.Errorf("default 100 = %d, want 100", got)
+	}
+	if got := Resolve(10, key); got != 10 {
+		t.Errorf("override wins over default: got %d, want 10", got)
+	}
+
+	viper.Set(key, -3)
+	if got := Resolve(0, key); got != 0 {
+		t.Errorf("negative default = %d, want 0", got)
+	}
+}
+
+func TestToAppError(t *testing.T) {
+	if ToAppError(nil) != nil {
+		t.Fatal("expected nil for nil error")
+	}
+
+	exceeded := fmt.Errorf("%w: application has reached its limit of %d users", ErrExceeded, 3)
+	appErr := ToAppError(exceeded)
+	if appErr.Code != http.StatusForbidden {
+		t.Errorf("code = %d, want 403", appErr.Code)
+	}
+	if appErr.Message != "quota exceeded: application has reached its limit of 3 users" {
+		t.Errorf("message = %q", appErr.Message)
+	}
+
+	appErr = ToAppError(errors.New("connection refused"))
+	if appErr.Code != http.StatusInternalServerError || appErr.Message != "Failed to check quota" {
+		t.Errorf("unexpected internal error mapping: %+v", appErr)
+	}
+}
@@ -2,10 +2,10 @@ package rbac
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 )
 
@@ -307,7 +307,13 @@ func (h *Handler) CreatePermission(c *gin.Context) {
 // @Produce json
 // @Param app_id query string true "Application ID"
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(20)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: user_email, user_name, role_name or assigned_at (prefix with - for descending)" default(user_email)
+// @Param order query string false "Sort order: asc or desc" default(asc)
+// @Param filter[user_id] query string false "User ID"
+// @Param filter[role_id] query string false "Role ID"
+// @Param filter[role_name] query string false "Role name"
+// @Param filter[user_email] query string false "User email contains (case-insensitive)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -320,28 +326,19 @@ func (h *Handler) ListUserRoles(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), userRoleListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	items, total, err := h.Service.Repo.GetUsersWithRoleInApp(appID, page, pageSize)
+	items, total, err := h.Service.Repo.ListUserRoles(appID, q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list user-role assignments"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":        items,
-		"total":       total,
-		"page":        page,
-		"page_size":   pageSize,
-		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-	})
+	c.JSON(http.StatusOK, q.Response(items, total))
 }
 
 // AssignRole assigns a role to a user.
@@ -2,6 +2,7 @@ package rbac
 
 import (
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -215,25 +216,45 @@ func (r *Repository) RevokeRoleFromUser(userID, roleID string) error {
 
 // GetUsersWithRoleInApp returns all user-role assignments for an app, with user info.
 func (r *Repository) GetUsersWithRoleInApp(appID string, page, pageSize int) ([]UserRoleListItem, int64, error) {
+	return r.ListUserRoles(appID, userRoleListSpec.Query(page, pageSize))
+}
+
+// userRoleListSpec is the sorting and filtering of the user-role assignment list.
+var userRoleListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"user_email":  "users.email",
+		"user_name":   "users.name",
+		"role_name":   "roles.name",
+		"assigned_at": "user_roles.assigned_at",
+	},
+	DefaultSort:  "user_email",
+	DefaultOrder: pagination.OrderAsc,
+	TieBreaker:   "roles.name, user_roles.user_id",
+	Filters: map[string]pagination.Filter{
+		"user_id":    {Column: "user_roles.user_id", Kind: pagination.FilterUUID},
+		"role_id":    {Column: "user_roles.role_id", Kind: pagination.FilterUUID},
+		"role_name":  {Column: "roles.name", Kind: pagination.FilterExact},
+		"user_email": {Column: "users.email", Kind: pagination.FilterContains},
+	},
+}
+
+// ListUserRoles returns a page of the user-role assignments of an app, with user info.
+func (r *Repository) ListUserRoles(appID string, q pagination.ListQuery) ([]UserRoleListItem, int64, error) {
 	var items []UserRoleListItem
 	var total int64
 
-	countQuery := r.DB.Model(&models.UserRole{}).Where("user_roles.app_id = ?", appID)
-	if err := countQuery.Count(&total).Error; err != nil {
+	query := q.ApplyFilters(r.DB.Model(&models.UserRole{}).
+		Joins("JOIN users ON users.id = user_roles.user_id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.app_id = ?", appID))
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (page - 1) * pageSize
-	err := r.DB.Model(&models.UserRole{}).
-		Select(`user_roles.user_id, user_roles.role_id, user_roles.app_id,
+	err := q.ApplyPage(query.Select(`user_roles.user_id, user_roles.role_id, user_roles.app_id,
 			user_roles.assigned_at,
 			users.email as user_email, users.name as user_name,
-			roles.name as role_name`).
-		Joins("JOIN users ON users.id = user_roles.user_id").
-		Joins("JOIN roles ON roles.id = user_roles.role_id").
-		Where("user_roles.app_id = ?", appID).
-		Order("users.email ASC, roles.name ASC").
-		Offset(offset).Limit(pageSize).
+			roles.name as role_name`)).
 		Scan(&items).Error
 
 	return items, total, err
@@ -8,10 +8,10 @@ import (
 	"time"
 
 	redispkg "github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
 	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
-	"github.com/spf13/viper"
 )
 
 // CachedAccess holds the cached RBAC data for a user in an application.
@@ -37,11 +37,7 @@ func cacheKey(appID, userID string) string {
 
 // cacheTTL returns the RBAC cache TTL (matches access token lifetime).
 func cacheTTL() time.Duration {
-	minutes := viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES")
-	if minutes <= 0 {
-		minutes = 15
-	}
-	return time.Minute * time.Duration(minutes)
+	return jwt.DefaultAccessTokenTTL()
 }
 
 // ============================================================
@@ -243,6 +239,20 @@ func (s *Service) AssignRoleToUser(userID, roleID, appID string, assignedBy *uui
 	return nil
 }
 
+// AssignRoleByName assigns the role with the given name to a user in an
+// application. Used by claim mappings, which refer to roles by name.
+func (s *Service) AssignRoleByName(appID, userID, roleName string) error {
+	role, err := s.Repo.GetRoleByName(appID, roleName)
+	if err != nil {
+		return fmt.Errorf("role %q not found: %w", roleName, err)
+	}
+	if err := s.Repo.AssignRoleToUser(userID, role.ID.String(), appID, nil); err != nil {
+		return err
+	}
+	s.InvalidateCache(appID, userID)
+	return nil
+}
+
 // AssignDefaultRole assigns the "member" role to a user in an application.
 // This is intended to be called on user registration and social account creation.
 // Non-fatal: logs a warning and returns nil if the role doesn't exist yet.
@@ -0,0 +1,190 @@
+package reauth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/util"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// Handler serves the re-authentication challenge endpoints.
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new re-authentication handler.
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// identity returns the application, user and session of the access token,
+// as set by AuthMiddleware. It writes the error response and returns false
+// when they are missing.
+func identity(c *gin.Context) (uuid.UUID, string, string, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "User ID not found in context"})
+		return uuid.Nil, "", "", false
+	}
+	appIDVal, exists := c.Get("appID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "App ID not found in context"})
+		return uuid.Nil, "", "", false
+	}
+	appID, err := uuid.Parse(appIDVal.(string))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid app ID in token"})
+		return uuid.Nil, "", "", false
+	}
+	sessionID := ""
+	if sid, exists := c.Get("sessionID"); exists {
+		sessionID = sid.(string)
+	}
+	return appID, userID.(string), sessionID, true
+}
+
+// @Summary List re-authentication methods
+// @Description List the challenge methods the current user can answer (password, totp, webauthn, email_otp)
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} dto.ReauthMethodsResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /auth/challenge/methods [get]
+func (h *Handler) Methods(c *gin.Context) {
+	appID, userID, _, ok := identity(c)
+	if !ok {
+		return
+	}
+
+	methods, appErr := h.Service.AvailableMethods(appID, userID)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+	c.JSON(http.StatusOK, dto.ReauthMethodsResponse{Methods: methods})
+}
+
+// @Summary Request a re-authentication challenge
+// @Description Start a challenge of the requested method for the current user, to confirm their identity before a sensitive action. webauthn returns assertion options for navigator.credentials.get(); email_otp emails a 6-digit code. The challenge expires after 5 minutes and can only be answered from the same session.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.ReauthChallengeRequest true "Challenge method and the action being confirmed"
+// @Success 200 {object} dto.ReauthChallengeResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /auth/challenge [post]
+func (h *Handler) Begin(c *gin.Context) {
+	var req dto.ReauthChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	appID, userID, sessionID, ok := identity(c)
+	if !ok {
+		return
+	}
+
+	resp, appErr := h.Service.Begin(appID, userID, sessionID, req)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Answer a re-authentication challenge
+// @Description Verify the answer to a challenge (password, code, or passkey assertion) and return a signed proof. The proof is valid for a few minutes (REAUTH_PROOF_TTL_SECONDS) and is bound to the user, session and purpose. A challenge allows 5 incorrect answers.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.ReauthVerifyRequest true "Challenge ID and answer"
+// @Success 200 {object} dto.ReauthProofResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /auth/challenge/verify [post]
+func (h *Handler) Verify(c *gin.Context) {
+	var req dto.ReauthVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	appID, userID, sessionID, ok := identity(c)
+	if !ok {
+		return
+	}
+
+	resp, method, purpose, appErr := h.Service.Verify(appID, userID, sessionID, req)
+	ipAddress, userAgent := util.GetClientInfo(c)
+	if uid, err := uuid.Parse(userID); err == nil && method != "" {
+		if appErr == nil {
+			log.LogReauth(appID, uid, ipAddress, userAgent, method, purpose)
+		} else if appErr.Code == http.StatusUnauthorized {
+			log.LogReauthFailed(appID, uid, ipAddress, userAgent, method, purpose)
+		}
+	}
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Check a re-authentication proof
+// @Description Validate a proof before carrying out a sensitive action. The proof must belong to the caller's session, match the purpose when given, and be younger than max_age_seconds when given. A proof is accepted only once.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.ReauthProofCheckRequest true "Proof and the expected purpose"
+// @Success 200 {object} dto.ReauthProofCheckResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /auth/challenge/proof [post]
+func (h *Handler) CheckProof(c *gin.Context) {
+	var req dto.ReauthProofCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	appID, userID, sessionID, ok := identity(c)
+	if !ok {
+		return
+	}
+
+	resp, appErr := h.Service.CheckProof(appID, userID, sessionID, req)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,302 @@
+// Package reauth lets a signed-in user confirm their identity again before a
+// sensitive action. A client requests a challenge of a specific method for the
+// current session, answers it, and receives a short-lived signed proof that
+// its backend (or POST /auth/challenge/proof) checks before carrying out the
+// action.
+package reauth
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	emailpkg "github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/internal/passhash"
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/tokenstore"
+	"github.com/gjovanovicst/auth_api/internal/twofa"
+	"github.com/gjovanovicst/auth_api/internal/user"
+	passkey "github.com/gjovanovicst/auth_api/internal/webauthn"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Challenge methods.
+const (
+	MethodPassword = "password"
+	MethodTOTP     = "totp"
+	MethodWebAuthn = "webauthn"
+	MethodEmailOTP = "email_otp"
+)
+
+// challengeTTL is how long a challenge can be answered.
+const challengeTTL = 5 * time.Minute
+
+// maxAttempts is the number of wrong answers after which a challenge is discarded.
+const maxAttempts = 5
+
+// challenge is a started challenge as stored in Redis. It can only be
+// answered from the session that requested it.
+type challenge struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+	Method    string `json:"method"`
+	Purpose   string `json:"purpose"`
+}
+
+// Service issues and verifies re-authentication challenges.
+type Service struct {
+	UserRepo        *user.Repository
+	TwoFAService    *twofa.Service
+	WebAuthnService *passkey.Service
+	EmailService    *emailpkg.Service // Optional: if nil, email codes are logged (dev mode)
+}
+
+// NewService creates a new re-authentication service.
+func NewService(userRepo *user.Repository, twofaService *twofa.Service, webauthnService *passkey.Service, emailService *emailpkg.Service) *Service {
+	return &Service{
+		UserRepo:        userRepo,
+		TwoFAService:    twofaService,
+		WebAuthnService: webauthnService,
+		EmailService:    emailService,
+	}
+}
+
+// AvailableMethods returns the challenge methods the user can answer:
+// password when one is set, totp when an authenticator app is enrolled,
+// webauthn when the application allows passkeys and the user has one, and
+// email_otp when the email address is verified.
+func (s *Service) AvailableMethods(appID uuid.UUID, userID string) ([]string, *errors.AppError) {
+	usr, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrNotFound, "User not found")
+	}
+	return s.availableMethods(appID, usr), nil
+}
+
+func (s *Service) availableMethods(appID uuid.UUID, usr *models.User) []string {
+	methods := []string{}
+	if usr.PasswordHash != "" {
+		methods = append(methods, MethodPassword)
+	}
+	if usr.TwoFAEnabled && usr.TwoFASecret != "" {
+		methods = append(methods, MethodTOTP)
+	}
+	if s.WebAuthnService != nil && s.WebAuthnService.IsPasskeyAllowed(appID) {
+		if has, appErr := s.WebAuthnService.HasPasskeys(usr.ID, appID); appErr == nil && has {
+			methods = append(methods, MethodWebAuthn)
+		}
+	}
+	if usr.EmailVerified {
+		methods = append(methods, MethodEmailOTP)
+	}
+	return methods
+}
+
+// Begin starts a challenge of method for the user of sessionID. For webauthn
+// the assertion options are returned; for email_otp a code is emailed.
+func (s *Service) Begin(appID uuid.UUID, userID, sessionID string, req dto.ReauthChallengeRequest) (*dto.ReauthChallengeResponse, *errors.AppError) {
+	usr, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrNotFound, "User not found")
+	}
+	if !containsMethod(s.availableMethods(appID, usr), req.Method) {
+		return nil, errors.NewAppError(errors.ErrBadRequest, "Challenge method is not available for this account")
+	}
+
+	resp := &dto.ReauthChallengeResponse{
+		ChallengeID: uuid.NewString(),
+		Method:      req.Method,
+		ExpiresIn:   int(challengeTTL.Seconds()),
+	}
+
+	switch req.Method {
+	case MethodWebAuthn:
+		options, appErr := s.WebAuthnService.BeginLogin(appID, usr.ID)
+		if appErr != nil {
+			return nil, appErr
+		}
+		resp.Options = options
+	case MethodEmailOTP:
+		code, err := tokenstore.IssueCode(appID.String(), userID, tokenstore.PurposeReauth)
+		if err != nil {
+			return nil, errors.NewAppError(errors.ErrInternal, "Failed to store verification code")
+		}
+		if s.EmailService != nil {
+			if err := s.EmailService.Send2FACodeEmail(appID, usr.Email, code, &usr.ID); err != nil {
+				log.Printf("Error sending re-authentication code to %s: %v", usr.Email, err)
+				return nil, errors.NewAppError(errors.ErrInternal, "Failed to send verification code email")
+			}
+		} else {
+			log.Printf("[DEV MODE] Re-authentication code for user %s: %s", userID, code)
+		}
+	}
+
+	data, err := json.Marshal(challenge{UserID: userID, SessionID: sessionID, Method: req.Method, Purpose: req.Purpose})
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to start challenge")
+	}
+	if err := redis.SetReauthChallenge(appID.String(), resp.ChallengeID, string(data), challengeTTL); err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to start challenge")
+	}
+	return resp, nil
+}
+
+// Verify checks the answer to a challenge and returns a signed proof. Every
+// answer counts as an attempt before it is checked; after maxAttempts the
+// challenge is discarded.
+// The returned method and purpose are those of the challenge, for logging,
+// and are empty when the challenge does not exist.
+func (s *Service) Verify(appID uuid.UUID, userID, sessionID string, req dto.ReauthVerifyRequest) (*dto.ReauthProofResponse, string, string, *errors.AppError) {
+	invalid := errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired challenge")
+
+	data, err := redis.GetReauthChallenge(appID.String(), req.ChallengeID)
+	if err != nil {
+		return nil, "", "", invalid
+	}
+	var ch challenge
+	if err := json.Unmarshal([]byte(data), &ch); err != nil || ch.UserID != userID || ch.SessionID != sessionID {
+		return nil, "", "", invalid
+	}
+
+	// Count the attempt before checking the answer so that concurrent answers
+	// to one challenge cannot get past maxAttempts
+	tooMany := errors.NewAppError(errors.ErrUnauthorized, "Too many incorrect attempts. Request a new challenge.")
+	attempts, err := redis.IncrementReauthChallengeAttempts(appID.String(), req.ChallengeID, challengeTTL)
+	if err != nil {
+		return nil, ch.Method, ch.Purpose, errors.NewAppError(errors.ErrInternal, "Failed to verify challenge")
+	}
+	if attempts > maxAttempts {
+		s.discard(appID, req.ChallengeID)
+		return nil, ch.Method, ch.Purpose, tooMany
+	}
+
+	if appErr := s.checkAnswer(appID, userID, ch.Method, req); appErr != nil {
+		if appErr.Code == http.StatusUnauthorized && attempts >= maxAttempts {
+			s.discard(appID, req.ChallengeID)
+			return nil, ch.Method, ch.Purpose, tooMany
+		}
+		return nil, ch.Method, ch.Purpose, appErr
+	}
+	s.discard(appID, req.ChallengeID)
+
+	ttl := jwt.DefaultReauthProofTTL()
+	proof, err := jwt.GenerateReauthProof(appID.String(), userID, sessionID, ch.Method, ch.Purpose, ttl)
+	if err != nil {
+		return nil, ch.Method, ch.Purpose, errors.NewAppError(errors.ErrInternal, "Failed to issue proof")
+	}
+	return &dto.ReauthProofResponse{
+		Proof:           proof,
+		Method:          ch.Method,
+		Purpose:         ch.Purpose,
+		AuthenticatedAt: time.Now().UTC().Format(time.RFC3339),
+		ExpiresIn:       int(ttl.Seconds()),
+	}, ch.Method, ch.Purpose, nil
+}
+
+// checkAnswer verifies the answer for method. A wrong answer returns an
+// Unauthorized error; a malformed request returns BadRequest.
+func (s *Service) checkAnswer(appID uuid.UUID, userID, method string, req dto.ReauthVerifyRequest) *errors.AppError {
+	switch method {
+	case MethodPassword:
+		if req.Password == "" {
+			return errors.NewAppError(errors.ErrBadRequest, "Password is required")
+		}
+		usr, err := s.UserRepo.GetUserByID(userID)
+		if err != nil {
+			return errors.NewAppError(errors.ErrNotFound, "User not found")
+		}
+		if err := passhash.Verify(usr.PasswordHash, req.Password); err != nil {
+			return errors.NewAppError(errors.ErrUnauthorized, "Invalid password")
+		}
+	case MethodTOTP:
+		if req.Code == "" {
+			return errors.NewAppError(errors.ErrBadRequest, "Code is required")
+		}
+		return s.TwoFAService.VerifyTOTP(userID, req.Code)
+	case MethodWebAuthn:
+		if len(req.Credential) == 0 {
+			return errors.NewAppError(errors.ErrBadRequest, "Credential is required")
+		}
+		uid, err := uuid.Parse(userID)
+		if err != nil {
+			return errors.NewAppError(errors.ErrBadRequest, "Invalid user ID")
+		}
+		return s.WebAuthnService.FinishLogin(appID, uid, req.Credential)
+	case MethodEmailOTP:
+		if req.Code == "" {
+			return errors.NewAppError(errors.ErrBadRequest, "Code is required")
+		}
+		if err := tokenstore.VerifyCode(appID.String(), userID, tokenstore.PurposeReauth, req.Code); err != nil {
+			if err != tokenstore.ErrInvalidToken && err != tokenstore.ErrTooManyAttempts {
+				return errors.NewAppError(errors.ErrInternal, "Failed to verify code")
+			}
+			return errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired code")
+		}
+	default:
+		return errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired challenge")
+	}
+	return nil
+}
+
+func (s *Service) discard(appID uuid.UUID, challengeID string) {
+	if err := redis.DeleteReauthChallenge(appID.String(), challengeID); err != nil {
+		log.Printf("Warning: Failed to delete re-authentication challenge: %v", err)
+	}
+}
+
+// CheckProof validates a proof presented for a sensitive action by the user
+// of sessionID and uses it up: a proof is accepted once. The proof must have
+// been issued to the same application, user and session, for req.Purpose
+// when set, and no longer than req.MaxAgeSeconds ago when set.
+func (s *Service) CheckProof(appID uuid.UUID, userID, sessionID string, req dto.ReauthProofCheckRequest) (*dto.ReauthProofCheckResponse, *errors.AppError) {
+	invalid := errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired re-authentication proof")
+
+	claims, err := jwt.ParseReauthProof(req.Proof)
+	if err != nil || claims.IssuedAt == nil || claims.ExpiresAt == nil || claims.ID == "" {
+		return nil, invalid
+	}
+	if claims.AppID != appID.String() || claims.UserID != userID || claims.SessionID != sessionID {
+		return nil, invalid
+	}
+	if req.Purpose != "" && claims.Purpose != req.Purpose {
+		return nil, errors.NewAppError(errors.ErrForbidden, "Re-authentication proof was issued for a different action")
+	}
+	authenticatedAt := claims.IssuedAt.Time
+	if req.MaxAgeSeconds > 0 && time.Since(authenticatedAt) > time.Duration(req.MaxAgeSeconds)*time.Second {
+		return nil, errors.NewAppError(errors.ErrUnauthorized, "Re-authentication is too old")
+	}
+
+	first, err := redis.MarkReauthProofUsed(appID.String(), claims.ID, time.Until(claims.ExpiresAt.Time)+time.Minute)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to check proof")
+	}
+	if !first {
+		return nil, errors.NewAppError(errors.ErrUnauthorized, "Re-authentication proof has already been used")
+	}
+
+	method := ""
+	if len(claims.AMR) > 0 {
+		method = claims.AMR[0]
+	}
+	return &dto.ReauthProofCheckResponse{
+		Valid:           true,
+		UserID:          claims.UserID,
+		Method:          method,
+		Purpose:         claims.Purpose,
+		AuthenticatedAt: authenticatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
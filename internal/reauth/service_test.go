@@ -0,0 +1,45 @@
+package reauth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestCheckProofRejectsMismatches(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-jwt-secret-that-is-at-least-32-bytes-long!")
+	appID := uuid.New()
+	s := &Service{}
+
+	proof, err := jwt.GenerateReauthProof(appID.String(), "user-1", "session-1", MethodPassword, "delete_project", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateReauthProof: %v", err)
+	}
+	access, _ := jwt.GenerateAccessToken(appID.String(), "user-1", "session-1", nil, 0)
+
+	tests := []struct {
+		name      string
+		appID     uuid.UUID
+		userID    string
+		sessionID string
+		req       dto.ReauthProofCheckRequest
+		wantCode  int
+	}{
+		{"access token", appID, "user-1", "session-1", dto.ReauthProofCheckRequest{Proof: access}, http.StatusUnauthorized},
+		{"other application", uuid.New(), "user-1", "session-1", dto.ReauthProofCheckRequest{Proof: proof}, http.StatusUnauthorized},
+		{"other user", appID, "user-2", "session-1", dto.ReauthProofCheckRequest{Proof: proof}, http.StatusUnauthorized},
+		{"other session", appID, "user-1", "session-2", dto.ReauthProofCheckRequest{Proof: proof}, http.StatusUnauthorized},
+		{"other purpose", appID, "user-1", "session-1", dto.ReauthProofCheckRequest{Proof: proof, Purpose: "export_data"}, http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		_, appErr := s.CheckProof(tc.appID, tc.userID, tc.sessionID, tc.req)
+		if appErr == nil || appErr.Code != tc.wantCode {
+			t.Errorf("%s: CheckProof() = %v, want status %d", tc.name, appErr, tc.wantCode)
+		}
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/region"
+	"github.com/gjovanovicst/auth_api/internal/startup"
+	"github.com/gjovanovicst/auth_api/pkg/jwt"
 	"github.com/go-redis/redis/v8"
 	"github.com/spf13/viper"
 )
@@ -21,7 +25,10 @@ func ConnectRedis() {
 		DB:       viper.GetInt("REDIS_DB"),
 	})
 
-	_, err := Rdb.Ping(ctx).Result()
+	// Retried so the service can start before Redis accepts connections
+	err := startup.Retry("redis", startup.RetryConfigFromEnv(), func() error {
+		return Rdb.Ping(ctx).Err()
+	})
 	if err != nil {
 		log.Fatalf("Could not connect to Redis: %v", err)
 	}
@@ -32,7 +39,7 @@ func ConnectRedis() {
 // SetRefreshToken stores a refresh token with its expiration
 func SetRefreshToken(appID, userID, token string) error {
 	key := fmt.Sprintf("app:%s:refresh_token:%s", appID, userID)
-	expiration := time.Hour * time.Duration(viper.GetInt("REFRESH_TOKEN_EXPIRATION_HOURS"))
+	expiration := jwt.DefaultRefreshTokenTTL()
 	return Rdb.Set(ctx, key, token, expiration).Err()
 }
 
@@ -72,60 +79,6 @@ func IsRefreshTokenRevoked(appID, userID, token string) (bool, error) {
 	return val != token, nil // If value doesn't match, it means a new token was issued, old one is implicitly revoked
 }
 
-// SetEmailVerificationToken stores an email verification token and a reverse lookup key (userID → token).
-// The reverse lookup allows invalidating old tokens when a new one is issued.
-func SetEmailVerificationToken(appID, userID, token string, expiration time.Duration) error {
-	key := fmt.Sprintf("app:%s:email_verify:%s", appID, token)
-	if err := Rdb.Set(ctx, key, userID, expiration).Err(); err != nil {
-		return err
-	}
-	// Store reverse lookup: userID → token (so we can find and invalidate old tokens)
-	reverseKey := fmt.Sprintf("app:%s:email_verify_user:%s", appID, userID)
-	return Rdb.Set(ctx, reverseKey, token, expiration).Err()
-}
-
-// GetEmailVerificationToken retrieves an email verification token
-func GetEmailVerificationToken(appID, token string) (string, error) {
-	key := fmt.Sprintf("app:%s:email_verify:%s", appID, token)
-	return Rdb.Get(ctx, key).Result()
-}
-
-// GetEmailVerificationTokenByUserID retrieves the current verification token for a user (reverse lookup).
-func GetEmailVerificationTokenByUserID(appID, userID string) (string, error) {
-	key := fmt.Sprintf("app:%s:email_verify_user:%s", appID, userID)
-	return Rdb.Get(ctx, key).Result()
-}
-
-// DeleteEmailVerificationToken deletes an email verification token and its reverse lookup key.
-func DeleteEmailVerificationToken(appID, token string) error {
-	key := fmt.Sprintf("app:%s:email_verify:%s", appID, token)
-	// Look up the userID so we can also clean up the reverse key
-	userID, err := Rdb.Get(ctx, key).Result()
-	if err == nil && userID != "" {
-		reverseKey := fmt.Sprintf("app:%s:email_verify_user:%s", appID, userID)
-		Rdb.Del(ctx, reverseKey) // Best-effort cleanup
-	}
-	return Rdb.Del(ctx, key).Err()
-}
-
-// SetPasswordResetToken stores a password reset token
-func SetPasswordResetToken(appID, userID, token string, expiration time.Duration) error {
-	key := fmt.Sprintf("app:%s:password_reset:%s", appID, token)
-	return Rdb.Set(ctx, key, userID, expiration).Err()
-}
-
-// GetPasswordResetToken retrieves a password reset token
-func GetPasswordResetToken(appID, token string) (string, error) {
-	key := fmt.Sprintf("app:%s:password_reset:%s", appID, token)
-	return Rdb.Get(ctx, key).Result()
-}
-
-// DeletePasswordResetToken deletes a password reset token
-func DeletePasswordResetToken(appID, token string) error {
-	key := fmt.Sprintf("app:%s:password_reset:%s", appID, token)
-	return Rdb.Del(ctx, key).Err()
-}
-
 // Magic Link related functions
 
 // SetMagicLinkToken stores a magic link token and a reverse lookup key (userID → token).
@@ -206,10 +159,48 @@ func DeleteTempUserSession(appID, tempToken string) error {
 
 // Access Token Blacklisting Functions
 
-// BlacklistAccessToken adds an access token to the blacklist with its remaining TTL
-func BlacklistAccessToken(appID, tokenString string, userID string, expiration time.Duration) error {
+// RevokedTokenIDsChannel is the pub/sub channel on which the IDs of revoked access
+// tokens are announced as "appID:jti", so every instance can update its revocation
+// filter without waiting for the next refresh.
+const RevokedTokenIDsChannel = "revoked_token_ids"
+
+// revokedTokenIDsKey is a sorted set of "appID:jti" members scored by token expiry,
+// from which revocation filters are rebuilt.
+const revokedTokenIDsKey = "revoked_token_ids"
+
+// BlacklistAccessToken adds an access token to the blacklist with its remaining TTL.
+// When the token has an ID (jti), it is also indexed and announced on
+// RevokedTokenIDsChannel for the in-memory revocation filters.
+func BlacklistAccessToken(appID, tokenString, tokenID, userID string, expiration time.Duration) error {
 	key := fmt.Sprintf("app:%s:blacklist_token:%s", appID, tokenString)
-	return Rdb.Set(ctx, key, userID, expiration).Err()
+	if err := Rdb.Set(ctx, key, userID, expiration).Err(); err != nil {
+		return err
+	}
+	if tokenID == "" {
+		return nil
+	}
+	member := appID + ":" + tokenID
+	expiresAt := float64(time.Now().Add(expiration).Unix())
+	if err := Rdb.ZAdd(ctx, revokedTokenIDsKey, &redis.Z{Score: expiresAt, Member: member}).Err(); err != nil {
+		return err
+	}
+	return Rdb.Publish(ctx, RevokedTokenIDsChannel, member).Err()
+}
+
+// ListRevokedTokenIDs prunes expired entries from the revoked token ID index and
+// returns the remaining "appID:jti" members.
+func ListRevokedTokenIDs() ([]string, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := Rdb.ZRemRangeByScore(ctx, revokedTokenIDsKey, "-inf", now).Err(); err != nil {
+		return nil, err
+	}
+	return Rdb.ZRange(ctx, revokedTokenIDsKey, 0, -1).Result()
+}
+
+// SubscribeRevokedTokenIDs subscribes to RevokedTokenIDsChannel. The caller must
+// close the returned subscription.
+func SubscribeRevokedTokenIDs(c context.Context) *redis.PubSub {
+	return Rdb.Subscribe(c, RevokedTokenIDsChannel)
 }
 
 // IsAccessTokenBlacklisted checks if an access token is blacklisted
@@ -308,16 +299,40 @@ func GetSession(appID, sessionID string) (map[string]string, error) {
 	return result, nil
 }
 
-// GetSessionRefreshToken retrieves only the refresh_token field from a session.
-func GetSessionRefreshToken(appID, sessionID string) (string, error) {
+// GetSessionTokenState retrieves the refresh token of a session with its
+// token version, region and last rotation time. It returns redis.Nil when the
+// session does not exist. Sessions stored without a version report version 0.
+func GetSessionTokenState(appID, sessionID string) (region.TokenState, error) {
 	key := fmt.Sprintf("app:%s:session:%s", appID, sessionID)
-	return Rdb.HGet(ctx, key, "refresh_token").Result()
+	values, err := Rdb.HMGet(ctx, key, "refresh_token", "token_version", "region", "rotated_at").Result()
+	if err != nil {
+		return region.TokenState{}, err
+	}
+	token, ok := values[0].(string)
+	if !ok {
+		return region.TokenState{}, redis.Nil
+	}
+	state := region.TokenState{RefreshToken: token}
+	if v, ok := values[1].(string); ok {
+		state.Version, _ = strconv.ParseInt(v, 10, 64)
+	}
+	state.Region, _ = values[2].(string)
+	if v, ok := values[3].(string); ok {
+		state.RotatedAt, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	return state, nil
 }
 
-// UpdateSessionRefreshToken updates the refresh token stored in a session hash.
-func UpdateSessionRefreshToken(appID, sessionID, newRefreshToken string) error {
+// SetSessionTokenState stores the refresh token of a session with its token
+// version, region and rotation time.
+func SetSessionTokenState(appID, sessionID string, state region.TokenState) error {
 	key := fmt.Sprintf("app:%s:session:%s", appID, sessionID)
-	return Rdb.HSet(ctx, key, "refresh_token", newRefreshToken).Err()
+	return Rdb.HSet(ctx, key, map[string]interface{}{
+		"refresh_token": state.RefreshToken,
+		"token_version": state.Version,
+		"region":        state.Region,
+		"rotated_at":    state.RotatedAt.UTC().Format(time.RFC3339Nano),
+	}).Err()
 }
 
 // ResetSessionTTL resets the TTL on a session hash key.
@@ -340,6 +355,7 @@ func DeleteSession(appID, sessionID, userID string) error {
 	if err := Rdb.Del(ctx, key).Err(); err != nil {
 		return err
 	}
+	markSessionsRevoked(appID, sessionID)
 	// Remove from user session index
 	indexKey := fmt.Sprintf("app:%s:user_sessions:%s", appID, userID)
 	Rdb.SRem(ctx, indexKey, sessionID)
@@ -394,6 +410,7 @@ func DeleteAllUserSessions(appID, userID, exceptSessionID string) error {
 		}
 		sessionKey := fmt.Sprintf("app:%s:session:%s", appID, sid)
 		Rdb.Del(ctx, sessionKey)
+		markSessionsRevoked(appID, sid)
 		// Remove from app-level session index
 		appIndexKey := fmt.Sprintf("app:%s:all_sessions", appID)
 		Rdb.SRem(ctx, appIndexKey, sid)
@@ -412,6 +429,34 @@ func DeleteAllUserSessions(appID, userID, exceptSessionID string) error {
 	return nil
 }
 
+// markSessionsRevoked leaves a revocation tombstone for each session in
+// multi-region deployments, so that regions whose replica still holds a
+// session, or recreates it from a recent token, reject it (see
+// internal/region). Failures are logged; the session is deleted regardless.
+func markSessionsRevoked(appID string, sessionIDs ...string) {
+	cfg := region.Current()
+	if !cfg.Enabled() {
+		return
+	}
+	for _, sid := range sessionIDs {
+		key := fmt.Sprintf("app:%s:session_revoked:%s", appID, sid)
+		if err := Rdb.Set(ctx, key, cfg.Name, cfg.TombstoneTTL()).Err(); err != nil {
+			log.Printf("Warning: Failed to store revocation tombstone of session %s: %v", sid, err)
+		}
+	}
+}
+
+// IsSessionRevoked reports whether a session was revoked recently, in any
+// region. Tombstones are only written in multi-region deployments.
+func IsSessionRevoked(appID, sessionID string) (bool, error) {
+	key := fmt.Sprintf("app:%s:session_revoked:%s", appID, sessionID)
+	n, err := Rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 // SessionExists checks whether a session hash key exists in Redis.
 func SessionExists(appID, sessionID string) (bool, error) {
 	key := fmt.Sprintf("app:%s:session:%s", appID, sessionID)
@@ -477,6 +522,102 @@ func GetAllSessionsForApp(appID string) ([]map[string]string, error) {
 	return sessions, nil
 }
 
+// ==================== Application Auth Epoch ====================
+
+// SetAppAuthEpoch records the application's auth epoch: every token of the
+// application issued at or before it is rejected. The key has no TTL, the epoch
+// stays until it is raised again.
+func SetAppAuthEpoch(appID string, epoch time.Time) error {
+	key := fmt.Sprintf("app:%s:auth_epoch", appID)
+	return Rdb.Set(ctx, key, epoch.Unix(), 0).Err()
+}
+
+// GetAppAuthEpoch returns the application's auth epoch, the zero time when none
+// was set.
+func GetAppAuthEpoch(appID string) (time.Time, error) {
+	key := fmt.Sprintf("app:%s:auth_epoch", appID)
+	sec, err := Rdb.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// IsRevokedByAppAuthEpoch reports whether a token of appID issued at issuedAt
+// was revoked by the application's auth epoch. Tokens without an issue time
+// (zero issuedAt) count as issued before any epoch.
+func IsRevokedByAppAuthEpoch(appID string, issuedAt time.Time) (bool, error) {
+	epoch, err := GetAppAuthEpoch(appID)
+	if err != nil || epoch.IsZero() {
+		return false, err
+	}
+	return issuedAt.IsZero() || !issuedAt.After(epoch), nil
+}
+
+// RevokeServiceAccountToken records that the service account token tokenID was
+// revoked. The key lives until the token would have expired.
+func RevokeServiceAccountToken(appID, tokenID string, expiration time.Duration) error {
+	key := fmt.Sprintf("app:%s:revoked_service_token:%s", appID, tokenID)
+	return Rdb.Set(ctx, key, "revoked", expiration).Err()
+}
+
+// IsServiceAccountTokenRevoked checks if a service account token was revoked.
+func IsServiceAccountTokenRevoked(appID, tokenID string) (bool, error) {
+	key := fmt.Sprintf("app:%s:revoked_service_token:%s", appID, tokenID)
+	n, err := Rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// DeleteAllAppSessions removes every session of an application, with its user
+// and app session indexes, expiry metadata and OIDC browser sessions, and
+// returns the number of sessions removed. Keys are found with SCAN, so
+// sessions missing from the indexes are removed too.
+func DeleteAllAppSessions(appID string) (int64, error) {
+	sessions, err := deleteKeysMatching(fmt.Sprintf("app:%s:session:*", appID))
+	if err != nil {
+		return sessions, err
+	}
+	for _, pattern := range []string{
+		fmt.Sprintf("app:%s:user_sessions:*", appID),
+		fmt.Sprintf("session_meta:%s:*", appID),
+		fmt.Sprintf("app:%s:oidc_browser:*", appID),
+	} {
+		if _, err := deleteKeysMatching(pattern); err != nil {
+			return sessions, err
+		}
+	}
+	return sessions, Rdb.Del(ctx, fmt.Sprintf("app:%s:all_sessions", appID)).Err()
+}
+
+// deleteKeysMatching deletes the keys matching pattern and returns how many
+// were deleted.
+func deleteKeysMatching(pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := Rdb.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := Rdb.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
 // Admin Session Functions
 
 // SetAdminSession stores an admin session in Redis
@@ -630,6 +771,65 @@ func DeleteWebAuthnLoginChallenge(appID, identifier string) error {
 	return Rdb.Del(ctx, key).Err()
 }
 
+// Re-authentication Challenge Functions
+
+// SetReauthChallenge stores a pending re-authentication challenge (POST /auth/challenge).
+func SetReauthChallenge(appID, challengeID, challengeJSON string, expiration time.Duration) error {
+	key := fmt.Sprintf("app:%s:reauth_challenge:%s", appID, challengeID)
+	return Rdb.Set(ctx, key, challengeJSON, expiration).Err()
+}
+
+// GetReauthChallenge retrieves a pending re-authentication challenge.
+func GetReauthChallenge(appID, challengeID string) (string, error) {
+	key := fmt.Sprintf("app:%s:reauth_challenge:%s", appID, challengeID)
+	return Rdb.Get(ctx, key).Result()
+}
+
+// IncrementReauthChallengeAttempts counts a failed answer to a re-authentication
+// challenge and returns the number of failures so far.
+func IncrementReauthChallengeAttempts(appID, challengeID string, expiration time.Duration) (int64, error) {
+	key := fmt.Sprintf("app:%s:reauth_attempts:%s", appID, challengeID)
+	attempts, err := Rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if attempts == 1 {
+		Rdb.Expire(ctx, key, expiration)
+	}
+	return attempts, nil
+}
+
+// DeleteReauthChallenge removes a re-authentication challenge and its failure count.
+func DeleteReauthChallenge(appID, challengeID string) error {
+	return Rdb.Del(ctx,
+		fmt.Sprintf("app:%s:reauth_challenge:%s", appID, challengeID),
+		fmt.Sprintf("app:%s:reauth_attempts:%s", appID, challengeID),
+	).Err()
+}
+
+// MarkReauthProofUsed records that a re-authentication proof was accepted.
+// It returns false when the proof had already been used.
+func MarkReauthProofUsed(appID, proofID string, expiration time.Duration) (bool, error) {
+	key := fmt.Sprintf("app:%s:reauth_proof_used:%s", appID, proofID)
+	return Rdb.SetNX(ctx, key, "1", expiration).Result()
+}
+
+// Registration Velocity Functions
+
+// IncrementRegistrationVelocity counts a registration attempt from a source
+// (an IP address or an ASN, per kind) within window and returns the count.
+func IncrementRegistrationVelocity(appID, kind, source string, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("app:%s:reg_velocity:%s:%s", appID, kind, source)
+	count, err := Rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		Rdb.Expire(ctx, key, window)
+	}
+	return count, nil
+}
+
 // Admin 2FA Functions
 
 // SetAdmin2FATempSecret stores a temporary TOTP secret during admin 2FA setup (10-minute TTL).
@@ -852,6 +1052,55 @@ func ResetDelayTier(appID, identifier string) error {
 	return Rdb.Del(ctx, key).Err()
 }
 
+// ==================== Per-Account Login Throttling ====================
+
+// SetAccountLoginDelay blocks further login attempts for an account until the
+// delay has passed. Unlike the delay tiers, this is enforced for every client IP.
+func SetAccountLoginDelay(appID, account string, delay time.Duration) error {
+	key := fmt.Sprintf("app:%s:account_login_delay:%s", appID, account)
+	return Rdb.Set(ctx, key, "1", delay).Err()
+}
+
+// GetAccountLoginDelay returns how long login attempts for an account are
+// still blocked (0 if they are not).
+func GetAccountLoginDelay(appID, account string) (time.Duration, error) {
+	key := fmt.Sprintf("app:%s:account_login_delay:%s", appID, account)
+	ttl, err := Rdb.PTTL(ctx, key).Result()
+	if err != nil || ttl < 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// ResetAccountLoginDelay lifts the login delay of an account.
+func ResetAccountLoginDelay(appID, account string) error {
+	key := fmt.Sprintf("app:%s:account_login_delay:%s", appID, account)
+	return Rdb.Del(ctx, key).Err()
+}
+
+// SetLastFailedLoginIP records the client IP of the latest failed login for an
+// account, so admins can see the IP-based counters next to the account ones.
+func SetLastFailedLoginIP(appID, account, ip string, ttl time.Duration) error {
+	key := fmt.Sprintf("app:%s:last_failed_login_ip:%s", appID, account)
+	return Rdb.Set(ctx, key, ip, ttl).Err()
+}
+
+// GetLastFailedLoginIP returns the IP recorded by SetLastFailedLoginIP ("" if none).
+func GetLastFailedLoginIP(appID, account string) (string, error) {
+	key := fmt.Sprintf("app:%s:last_failed_login_ip:%s", appID, account)
+	ip, err := Rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return ip, err
+}
+
+// ResetLastFailedLoginIP clears the IP recorded by SetLastFailedLoginIP.
+func ResetLastFailedLoginIP(appID, account string) error {
+	key := fmt.Sprintf("app:%s:last_failed_login_ip:%s", appID, account)
+	return Rdb.Del(ctx, key).Err()
+}
+
 // ─── OIDC browser session (login cookie) ───────────────────────────────────────
 
 // SetOIDCBrowserSession stores an opaque session token → userID mapping used by
@@ -987,6 +1236,45 @@ func GetOIDCGrantedScopes(appID, sessionID string) (string, error) {
 	return val, err
 }
 
+// ─── OIDC client sessions ──────────────────────────────────────────────────────
+
+// SetSessionOIDCClient records on a session the client_id of the OIDC client
+// its tokens were issued to, so they can be revoked with the user's grant.
+func SetSessionOIDCClient(appID, sessionID, clientID string) error {
+	key := fmt.Sprintf("app:%s:session:%s", appID, sessionID)
+	return Rdb.HSet(ctx, key, "oidc_client_id", clientID).Err()
+}
+
+// DeleteOIDCClientSessions deletes the sessions of a user whose tokens were
+// issued to the OIDC client clientID, along with their granted scopes, and
+// returns how many were deleted.
+func DeleteOIDCClientSessions(appID, userID, clientID string) (int, error) {
+	sessionIDs, err := GetUserSessionIDs(appID, userID)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, sid := range sessionIDs {
+		key := fmt.Sprintf("app:%s:session:%s", appID, sid)
+		owner, err := Rdb.HGet(ctx, key, "oidc_client_id").Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		if owner != clientID {
+			continue
+		}
+		if err := DeleteSession(appID, sid, userID); err != nil {
+			return deleted, err
+		}
+		Rdb.Del(ctx, fmt.Sprintf("app:%s:oidc_scopes:%s", appID, sid))
+		deleted++
+	}
+	return deleted, nil
+}
+
 // ============================================================================
 // Account Merge Token helpers
 //
@@ -1116,3 +1404,102 @@ func GetExpiredSessionMetaKeys() ([]string, error) {
 
 	return expiredKeys, nil
 }
+
+// ============================================================
+// Admin statistics cache
+// ============================================================
+
+// SetAppStatsCache stores a serialized per-app statistics report for a date range.
+func SetAppStatsCache(appID, rangeKey, payload string, ttl time.Duration) error {
+	key := fmt.Sprintf("app:%s:stats:%s", appID, rangeKey)
+	return Rdb.Set(ctx, key, payload, ttl).Err()
+}
+
+// GetAppStatsCache returns a cached per-app statistics report, or redis.Nil if absent.
+func GetAppStatsCache(appID, rangeKey string) (string, error) {
+	key := fmt.Sprintf("app:%s:stats:%s", appID, rangeKey)
+	return Rdb.Get(ctx, key).Result()
+}
+
+// ============================================================
+// Quota counters
+// ============================================================
+
+// IncrDailyEmailCount increments the number of emails sent by an app on the given
+// UTC day (YYYYMMDD). The counter expires two days after it is created.
+func IncrDailyEmailCount(appID, day string) (int64, error) {
+	key := fmt.Sprintf("app:%s:quota:emails:%s", appID, day)
+	count, err := Rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		Rdb.Expire(ctx, key, 48*time.Hour)
+	}
+	return count, nil
+}
+
+// GetDailyEmailCount returns the number of emails sent by an app on the given UTC day.
+func GetDailyEmailCount(appID, day string) (int64, error) {
+	key := fmt.Sprintf("app:%s:quota:emails:%s", appID, day)
+	count, err := Rdb.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// ClaimEmailIdempotencyKey reserves an idempotency key of an app's emails for
+// window, storing fingerprint (the email type and recipient it was used for).
+// When the key is already reserved it returns false and the stored fingerprint.
+func ClaimEmailIdempotencyKey(appID, idempotencyKey, fingerprint string, window time.Duration) (bool, string, error) {
+	key := fmt.Sprintf("app:%s:email_idempotency:%s", appID, idempotencyKey)
+	claimed, err := Rdb.SetNX(ctx, key, fingerprint, window).Result()
+	if err != nil || claimed {
+		return claimed, "", err
+	}
+	stored, err := Rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return false, "", nil
+	}
+	return false, stored, err
+}
+
+// ReleaseEmailIdempotencyKey frees an idempotency key whose email was not sent,
+// so that a retry can send it.
+func ReleaseEmailIdempotencyKey(appID, idempotencyKey string) error {
+	return Rdb.Del(ctx, fmt.Sprintf("app:%s:email_idempotency:%s", appID, idempotencyKey)).Err()
+}
+
+// ============================================================
+// Job scheduler locks
+// ============================================================
+
+// AcquireJobLock claims one scheduled occurrence (Unix time) of a background job
+// so that only one server instance runs it. It returns false when another
+// instance already claimed the occurrence. The lock is left to expire.
+func AcquireJobLock(jobName string, occurrence int64, owner string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("scheduler:lock:%s:%d", jobName, occurrence)
+	return Rdb.SetNX(ctx, key, owner, ttl).Result()
+}
+
+// ============================================================
+// Dashboard stats snapshot
+// ============================================================
+
+const dashboardStatsKey = "dashboard:stats"
+
+// SetDashboardStats stores the pre-aggregated admin dashboard stats (JSON) for ttl.
+func SetDashboardStats(data []byte, ttl time.Duration) error {
+	return Rdb.Set(ctx, dashboardStatsKey, data, ttl).Err()
+}
+
+// GetDashboardStats returns the stored dashboard stats snapshot, or nil when
+// there is none.
+func GetDashboardStats() ([]byte, error) {
+	data, err := Rdb.Get(ctx, dashboardStatsKey).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return data, err
+}
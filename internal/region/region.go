@@ -0,0 +1,132 @@
+// Package region makes session token state tolerate replication lag between
+// the Redis replicas of an active-active multi-region deployment.
+//
+// Every session token carries a token version ("tv"), raised on each refresh,
+// and the region that issued it ("rgn"). A region whose replica has not yet
+// caught up with a write made elsewhere uses them to tell a token that is
+// newer than its local state from one that was superseded or revoked:
+//
+//   - A refresh token with a higher version than the stored one was rotated
+//     in another region: the highest version wins and the token is accepted.
+//   - Two regions rotating the same version concurrently both succeed once
+//     within the grace window; the next rotation raises the version and the
+//     later branch wins.
+//   - A token of a session that is missing locally is accepted only within
+//     the grace window after it was issued, since its session may not have
+//     replicated yet.
+//   - Revocation always wins: revoked sessions leave a tombstone that rejects
+//     their tokens even while a lagging replica still holds the session.
+//
+// With REGION unset, all of this is off and token state is strictly local.
+package region
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultGrace is the replication grace window used when
+// REGION_REPLICATION_GRACE_SECONDS is not set.
+const DefaultGrace = 5 * time.Second
+
+// minTombstoneTTL is the shortest time a session revocation tombstone is kept.
+const minTombstoneTTL = 10 * time.Minute
+
+// Refresh rejections returned by Config.ResolveRefresh.
+var (
+	ErrRefreshTokenMismatch   = errors.New("refresh token does not match the session")
+	ErrRefreshTokenSuperseded = errors.New("refresh token was superseded by a newer rotation")
+)
+
+// Config is the multi-region configuration of this instance.
+type Config struct {
+	Name  string        // REGION: name of this instance's region; empty disables multi-region handling
+	Grace time.Duration // REGION_REPLICATION_GRACE_SECONDS: expected worst-case replication lag
+}
+
+// Current returns the configuration from REGION and
+// REGION_REPLICATION_GRACE_SECONDS.
+func Current() Config {
+	c := Config{Name: viper.GetString("REGION"), Grace: DefaultGrace}
+	if s := viper.GetInt("REGION_REPLICATION_GRACE_SECONDS"); s > 0 {
+		c.Grace = time.Duration(s) * time.Second
+	}
+	return c
+}
+
+// Enabled reports whether multi-region handling is on (REGION is set).
+func (c Config) Enabled() bool {
+	return c.Name != ""
+}
+
+// WithinGrace reports whether t (a token's issue time or a rotation time) is
+// recent enough that the writes made with it may not have replicated to this
+// region yet. It is always false when multi-region handling is off.
+func (c Config) WithinGrace(t, now time.Time) bool {
+	if !c.Enabled() || t.IsZero() {
+		return false
+	}
+	return now.Sub(t) <= c.Grace
+}
+
+// TombstoneTTL is how long a revoked session's tombstone is kept: ten grace
+// windows, and at least ten minutes, which outlasts any replication lag the
+// grace window is meant for.
+func (c Config) TombstoneTTL() time.Duration {
+	if ttl := 10 * c.Grace; ttl > minTombstoneTTL {
+		return ttl
+	}
+	return minTombstoneTTL
+}
+
+// TokenState is a session's refresh token state as stored in the local replica.
+type TokenState struct {
+	RefreshToken string
+	Version      int64     // 0 for sessions stored before token versions existed
+	Region       string    // Region of the last rotation
+	RotatedAt    time.Time // Time of the last rotation; zero when unknown
+}
+
+// PresentedToken is the refresh token presented for rotation and its claims.
+type PresentedToken struct {
+	Token   string
+	Version int64
+	Region  string
+}
+
+// ResolveRefresh decides whether a presented refresh token may be rotated
+// given the local state of its session. It returns nil when it may, or the
+// reason it is rejected. Without multi-region handling only the stored token
+// is accepted.
+func (c Config) ResolveRefresh(presented PresentedToken, state TokenState, now time.Time) error {
+	if presented.Token == state.RefreshToken {
+		return nil
+	}
+	if !c.Enabled() || presented.Version == 0 {
+		return ErrRefreshTokenMismatch
+	}
+	switch {
+	case presented.Version > state.Version:
+		// Rotated in another region; the local replica has not caught up
+		return nil
+	case presented.Version == state.Version &&
+		presented.Region != state.Region &&
+		c.WithinGrace(state.RotatedAt, now):
+		// Both regions rotated the same version before seeing each other's write
+		return nil
+	default:
+		return ErrRefreshTokenSuperseded
+	}
+}
+
+// NextVersion returns the version of the tokens issued by a rotation: one
+// above both the presented token and the local state, so the highest version
+// keeps winning after replicas converge.
+func NextVersion(presented PresentedToken, state TokenState) int64 {
+	if presented.Version > state.Version {
+		return presented.Version + 1
+	}
+	return state.Version + 1
+}
@@ -0,0 +1,81 @@
+package region
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveRefresh(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cfg := Config{Name: "eu-west", Grace: 5 * time.Second}
+	state := TokenState{RefreshToken: "stored", Version: 3, Region: "eu-west", RotatedAt: now.Add(-2 * time.Second)}
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		presented PresentedToken
+		state     TokenState
+		want      error
+	}{
+		{"matching token", cfg, PresentedToken{Token: "stored", Version: 3, Region: "eu-west"}, state, nil},
+		{"matching legacy token", Config{}, PresentedToken{Token: "stored"}, state, nil},
+		{"single region mismatch", Config{Grace: DefaultGrace}, PresentedToken{Token: "other", Version: 4, Region: "us-east"}, state, ErrRefreshTokenMismatch},
+		{"unversioned mismatch", cfg, PresentedToken{Token: "other"}, state, ErrRefreshTokenMismatch},
+		{"newer version from another region", cfg, PresentedToken{Token: "other", Version: 4, Region: "us-east"}, state, nil},
+		{"older version", cfg, PresentedToken{Token: "other", Version: 2, Region: "eu-west"}, state, ErrRefreshTokenSuperseded},
+		{"concurrent rotation within grace", cfg, PresentedToken{Token: "other", Version: 3, Region: "us-east"}, state, nil},
+		{"same version from the same region", cfg, PresentedToken{Token: "other", Version: 3, Region: "eu-west"}, state, ErrRefreshTokenSuperseded},
+		{
+			"concurrent rotation after grace", cfg,
+			PresentedToken{Token: "other", Version: 3, Region: "us-east"},
+			TokenState{RefreshToken: "stored", Version: 3, Region: "eu-west", RotatedAt: now.Add(-time.Minute)},
+			ErrRefreshTokenSuperseded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ResolveRefresh(tt.presented, tt.state, now); got != tt.want {
+				t.Errorf("ResolveRefresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	if got := NextVersion(PresentedToken{Version: 5}, TokenState{Version: 3}); got != 6 {
+		t.Errorf("NextVersion(presented newer) = %d, want 6", got)
+	}
+	if got := NextVersion(PresentedToken{Version: 2}, TokenState{Version: 3}); got != 4 {
+		t.Errorf("NextVersion(state newer) = %d, want 4", got)
+	}
+	if got := NextVersion(PresentedToken{}, TokenState{}); got != 1 {
+		t.Errorf("NextVersion(legacy) = %d, want 1", got)
+	}
+}
+
+func TestWithinGrace(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cfg := Config{Name: "eu-west", Grace: 5 * time.Second}
+
+	if !cfg.WithinGrace(now.Add(-3*time.Second), now) {
+		t.Error("WithinGrace(3s ago) = false, want true")
+	}
+	if cfg.WithinGrace(now.Add(-10*time.Second), now) {
+		t.Error("WithinGrace(10s ago) = true, want false")
+	}
+	if cfg.WithinGrace(time.Time{}, now) {
+		t.Error("WithinGrace(zero time) = true, want false")
+	}
+	if (Config{Grace: 5 * time.Second}).WithinGrace(now, now) {
+		t.Error("WithinGrace() with REGION unset = true, want false")
+	}
+}
+
+func TestTombstoneTTL(t *testing.T) {
+	if got := (Config{Grace: 5 * time.Second}).TombstoneTTL(); got != minTombstoneTTL {
+		t.Errorf("TombstoneTTL(5s) = %v, want %v", got, minTombstoneTTL)
+	}
+	if got := (Config{Grace: 2 * time.Minute}).TombstoneTTL(); got != 20*time.Minute {
+		t.Errorf("TombstoneTTL(2m) = %v, want 20m", got)
+	}
+}
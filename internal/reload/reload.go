@@ -0,0 +1,241 @@
+// Package reload applies configuration changes without restarting the
+// process. A reload reads the config file again, then runs the registered
+// hooks, which re-read settings or drop cached settings and per-app configs
+// so they are resolved again on next use.
+//
+// SIGHUP reloads the instance that receives it. A reload requested through
+// the Admin API (ReloadAll) is also announced on Channel, so every instance
+// sharing the Redis server reloads.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/go-redis/redis/v8"
+)
+
+// Channel is the pub/sub channel reloads are announced on. The payload is the
+// ID of the instance that reloaded first.
+const Channel = "config_reload"
+
+// Triggers reported in Result
+const (
+	TriggerSignal = "signal" // SIGHUP
+	TriggerAdmin  = "admin"  // POST /admin/config/reload
+	TriggerPeer   = "peer"   // Announced by another instance
+)
+
+// StepConfigFile is the name of the step that reads the config file.
+const StepConfigFile = "config_file"
+
+// Step is the outcome of one part of a reload.
+type Step struct {
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// Result describes a reload.
+type Result struct {
+	Trigger   string        `json:"trigger"`
+	Instance  string        `json:"instance"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Changed   []string      `json:"changed"` // Environment variables changed by the config file
+	Steps     []Step        `json:"steps"`
+}
+
+// OK reports whether every step succeeded.
+func (r *Result) OK() bool {
+	for _, s := range r.Steps {
+		if s.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type hook struct {
+	name string
+	fn   func() error
+}
+
+// Reloader runs reloads. All methods are safe to call on a nil *Reloader
+// (live reload disabled).
+type Reloader struct {
+	configFile string
+	rdb        *redis.Client
+	instance   string
+
+	mu    sync.Mutex // Serializes reloads
+	hooks []hook
+	last  *Result
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a Reloader for the config file at configFile ("" = none),
+// announcing reloads through rdb (nil = this instance only).
+func New(configFile string, rdb *redis.Client) *Reloader {
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Reloader{
+		configFile: configFile,
+		rdb:        rdb,
+		instance:   host + "-" + strconv.Itoa(os.Getpid()),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Register adds a hook run on every reload, after the config file was read,
+// in registration order. A failing hook does not stop the others.
+func (r *Reloader) Register(name string, fn func() error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook{name: name, fn: fn})
+}
+
+// Reload reloads this instance. A config file that cannot be read leaves the
+// environment unchanged; the hooks still run.
+func (r *Reloader) Reload(trigger string) Result {
+	if r == nil {
+		return Result{Trigger: trigger}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := Result{Trigger: trigger, Instance: r.instance, StartedAt: time.Now(), Changed: []string{}}
+	if r.configFile != "" {
+		step := Step{Name: StepConfigFile}
+		changed, err := config.ReloadConfigFile(r.configFile)
+		if err != nil {
+			step.Error = err.Error()
+		}
+		if changed != nil {
+			res.Changed = changed
+		}
+		res.Steps = append(res.Steps, step)
+	}
+	for _, h := range r.hooks {
+		step := Step{Name: h.name}
+		if err := runHook(h.fn); err != nil {
+			step.Error = err.Error()
+		}
+		res.Steps = append(res.Steps, step)
+	}
+	res.Duration = time.Since(res.StartedAt)
+	r.last = &res
+
+	if res.OK() {
+		log.Printf("Configuration reloaded (%s) in %v; changed: %v", trigger, res.Duration, res.Changed)
+	} else {
+		for _, s := range res.Steps {
+			if s.Error != "" {
+				log.Printf("Warning: configuration reload (%s): %s: %s", trigger, s.Name, s.Error)
+			}
+		}
+	}
+	return res
+}
+
+// runHook runs fn, turning a panic into an error so one broken hook cannot
+// take the process down.
+func runHook(fn func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn()
+}
+
+// ReloadAll reloads this instance and announces the reload to the others.
+// The result is that of this instance; the error reports a failed
+// announcement.
+func (r *Reloader) ReloadAll() (Result, error) {
+	res := r.Reload(TriggerAdmin)
+	if r == nil || r.rdb == nil {
+		return res, nil
+	}
+	if err := r.rdb.Publish(r.ctx, Channel, r.instance).Err(); err != nil {
+		return res, fmt.Errorf("announce reload: %w", err)
+	}
+	return res, nil
+}
+
+// Last returns the most recent reload of this instance, nil if none.
+func (r *Reloader) Last() *Result {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Start reloads on SIGHUP and on reloads announced by other instances until
+// Shutdown.
+func (r *Reloader) Start() {
+	if r == nil {
+		return
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var (
+		pubsub        *redis.PubSub
+		announcements <-chan *redis.Message
+	)
+	if r.rdb != nil {
+		pubsub = r.rdb.Subscribe(r.ctx, Channel)
+		announcements = pubsub.Channel()
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer signal.Stop(hup)
+		if pubsub != nil {
+			defer pubsub.Close()
+		}
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-hup:
+				r.Reload(TriggerSignal)
+			case msg, ok := <-announcements:
+				if !ok {
+					announcements = nil
+					continue
+				}
+				if msg.Payload != r.instance {
+					r.Reload(TriggerPeer)
+				}
+			}
+		}
+	}()
+	log.Printf("Live configuration reload enabled (SIGHUP, POST /admin/config/reload)")
+}
+
+// Shutdown stops listening for reloads.
+func (r *Reloader) Shutdown() {
+	if r == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,85 @@
+package reload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadRunsHooksInOrder(t *testing.T) {
+	r := New("", nil)
+	var order []string
+	r.Register("first", func() error { order = append(order, "first"); return nil })
+	r.Register("failing", func() error { order = append(order, "failing"); return errors.New("boom") })
+	r.Register("panicking", func() error { panic("oops") })
+	r.Register("last", func() error { order = append(order, "last"); return nil })
+
+	res := r.Reload(TriggerAdmin)
+	if got := len(order); got != 3 || order[0] != "first" || order[2] != "last" {
+		t.Errorf("hooks ran %v, want first, failing, last", order)
+	}
+	if res.OK() {
+		t.Error("OK() = true with failing hooks")
+	}
+	want := []Step{{Name: "first"}, {Name: "failing", Error: "boom"}, {Name: "panicking", Error: "panic: oops"}, {Name: "last"}}
+	if len(res.Steps) != len(want) {
+		t.Fatalf("steps = %+v, want %+v", res.Steps, want)
+	}
+	for i := range want {
+		if res.Steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, res.Steps[i], want[i])
+		}
+	}
+	if last := r.Last(); last == nil || last.Trigger != TriggerAdmin {
+		t.Errorf("Last() = %+v, want the admin reload", last)
+	}
+}
+
+func TestReloadReadsConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  prot: 8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := New(path, nil)
+	res := r.Reload(TriggerSignal)
+	if res.OK() || len(res.Steps) != 1 || res.Steps[0].Name != StepConfigFile {
+		t.Errorf("steps = %+v, want a failed %s step", res.Steps, StepConfigFile)
+	}
+}
+
+func TestSIGHUPReloads(t *testing.T) {
+	r := New("", nil)
+	done := make(chan struct{}, 1)
+	r.Register("signal", func() error { done <- struct{}{}; return nil })
+	r.Start()
+	defer r.Shutdown()
+
+	self, _ := os.FindProcess(os.Getpid())
+	if err := self.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("cannot send SIGHUP: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after SIGHUP")
+	}
+	if last := r.Last(); last == nil || last.Trigger != TriggerSignal {
+		t.Errorf("Last() = %+v, want a signal reload", last)
+	}
+}
+
+func TestNilReloader(t *testing.T) {
+	var r *Reloader
+	r.Register("x", func() error { return nil })
+	r.Start()
+	r.Shutdown()
+	if _, err := r.ReloadAll(); err != nil {
+		t.Errorf("ReloadAll() on nil = %v", err)
+	}
+	if r.Last() != nil {
+		t.Error("Last() on nil is not nil")
+	}
+}
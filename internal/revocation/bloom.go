@@ -0,0 +1,61 @@
+package revocation
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloom is a fixed-size bloom filter of strings. It never reports a false
+// negative: MayContain returns false only for strings that were never added.
+type bloom struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+// newBloom returns a bloom filter sized for n strings at false positive rate p.
+func newBloom(n int, p float64) *bloom {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// hashes returns the two base hashes of s, combined as h1 + i*h2 (Kirsch and
+// Mitzenmacher) for the k bit positions.
+func hashes(s string) (uint64, uint64) {
+	a := fnv.New64a()
+	_, _ = a.Write([]byte(s))
+	b := fnv.New64()
+	_, _ = b.Write([]byte(s))
+	return a.Sum64(), b.Sum64() | 1
+}
+
+// Add adds s to the filter.
+func (b *bloom) Add(s string) {
+	h1, h2 := hashes(s)
+	for i := uint64(0); i < b.k; i++ {
+		pos := (h1 + i*h2) % b.m
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// MayContain reports whether s may have been added to the filter.
+func (b *bloom) MayContain(s string) bool {
+	h1, h2 := hashes(s)
+	for i := uint64(0); i < b.k; i++ {
+		pos := (h1 + i*h2) % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+// Package revocation keeps an in-memory bloom filter of revoked access token
+// IDs (jti), so the auth middleware can skip the Redis blacklist lookup for the
+// common case of a token that was never revoked.
+//
+// The filter is rebuilt periodically from the Redis index written by
+// redis.BlacklistAccessToken and updated in between from the revocations
+// announced on redis.RevokedTokenIDsChannel; Revoke also adds the token to the
+// filter of the instance that revokes it. Until the filter has been loaded,
+// while the announcement subscription is down, or when the filter has not been
+// refreshed for a while, every token is reported as possibly revoked and the
+// middleware falls back to Redis.
+package revocation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+const (
+	// minCapacity is the smallest number of token IDs a filter is sized for.
+	minCapacity = 10000
+	// falsePositiveRate is the target false positive rate at capacity.
+	falsePositiveRate = 0.001
+	// staleRefreshes is the number of missed refreshes after which a filter is
+	// no longer trusted.
+	staleRefreshes = 3
+	// resubscribeDelay is the first pause before subscribing again after the
+	// announcement subscription dropped; it doubles, up to the refresh
+	// interval, while subscribing keeps failing.
+	resubscribeDelay = time.Second
+)
+
+// Filter is a bloom filter of revoked "appID:jti" token IDs.
+type Filter struct {
+	mu         sync.RWMutex
+	bloom      *bloom
+	loadedAt   time.Time
+	maxAge     time.Duration // 0 = never stale
+	subscribed bool          // announcements are being received; refreshes load only then
+	gen        uint64        // incremented when the subscription drops, discarding loads started before
+}
+
+var defaultFilter = &Filter{}
+
+// Start loads the default filter and keeps it up to date until ctx is done,
+// rebuilding it from Redis every interval.
+func Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	defaultFilter.mu.Lock()
+	defaultFilter.maxAge = staleRefreshes * interval
+	defaultFilter.mu.Unlock()
+	go defaultFilter.run(ctx, interval)
+	log.Printf("Access token revocation filter started (refresh interval: %v)", interval)
+}
+
+// Revoke blacklists an access token with redis.BlacklistAccessToken and adds
+// its ID to this instance's filter at once, so that requests served here do
+// not depend on the announcement coming back over pub/sub.
+func Revoke(appID, tokenString, tokenID, userID string, expiration time.Duration) error {
+	if tokenID != "" {
+		defaultFilter.Add(appID + ":" + tokenID)
+	}
+	return redis.BlacklistAccessToken(appID, tokenString, tokenID, userID, expiration)
+}
+
+// MayBeRevoked reports whether the access token with the given ID may have been
+// revoked. It returns false only when the token is certainly not revoked.
+func MayBeRevoked(appID, tokenID string) bool {
+	return defaultFilter.MayContain(appID + ":" + tokenID)
+}
+
+// MayContain reports whether id may be in the filter. An unloaded or stale
+// filter may contain anything.
+func (f *Filter) MayContain(id string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.bloom == nil || (f.maxAge > 0 && time.Since(f.loadedAt) > f.maxAge) {
+		return true
+	}
+	return f.bloom.MayContain(id)
+}
+
+// Add adds id to a loaded filter. Adds beyond its capacity are still recorded,
+// at a higher false positive rate, until the next rebuild resizes the filter.
+func (f *Filter) Add(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.bloom == nil {
+		return
+	}
+	f.bloom.Add(id)
+}
+
+// Load replaces the contents of the filter with ids.
+func (f *Filter) Load(ids []string) {
+	b := newFilledBloom(ids)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bloom = b
+	f.loadedAt = time.Now()
+}
+
+func newFilledBloom(ids []string) *bloom {
+	capacity := 2 * len(ids)
+	if capacity < minCapacity {
+		capacity = minCapacity
+	}
+	b := newBloom(capacity, falsePositiveRate)
+	for _, id := range ids {
+		b.Add(id)
+	}
+	return b
+}
+
+// run keeps the filter current until ctx is done: it subscribes to revocation
+// announcements, reloads the filter every time the subscription is confirmed,
+// and subscribes again whenever the subscription drops. The filter is marked
+// unloaded for as long as announcements may be missed.
+func (f *Filter) run(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				f.refresh()
+			}
+		}
+	}()
+
+	delay := resubscribeDelay
+	for {
+		sub := redis.SubscribeRevokedTokenIDs(ctx)
+		confirmed, err := f.listen(ctx, sub, interval)
+		_ = sub.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		f.unsubscribed()
+		if confirmed {
+			delay = resubscribeDelay
+			log.Printf("Warning: Token revocation subscription lost, checking every token in Redis until it is restored: %v\n", err)
+		} else {
+			log.Printf("Warning: Failed to subscribe to token revocations: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > interval {
+			delay = interval
+		}
+	}
+}
+
+// listen applies the announcements received on sub until it fails, and
+// reports whether the subscription had been confirmed. A confirmed
+// subscription reloads the filter; subscribing first means no revocation is
+// missed between the load and the first announcement, and announcements
+// received while loading are applied afterwards. A connection that stays
+// silent for interval is pinged, and one that does not answer before the next
+// interval counts as dropped.
+func (f *Filter) listen(ctx context.Context, sub *goredis.PubSub, interval time.Duration) (bool, error) {
+	confirmed, pinged := false, false
+	for {
+		msg, err := sub.ReceiveTimeout(ctx, interval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return confirmed, ctx.Err()
+			}
+			var netErr net.Error
+			if !pinged && errors.As(err, &netErr) && netErr.Timeout() {
+				if err := sub.Ping(ctx); err != nil {
+					return confirmed, err
+				}
+				pinged = true
+				continue
+			}
+			return confirmed, err
+		}
+		pinged = false
+
+		switch m := msg.(type) {
+		case *goredis.Subscription:
+			if m.Kind == "subscribe" {
+				confirmed = true
+				f.mu.Lock()
+				f.subscribed = true
+				f.mu.Unlock()
+				f.refresh()
+			}
+		case *goredis.Message:
+			f.Add(m.Payload)
+		}
+	}
+}
+
+// unsubscribed marks the filter unloaded until the subscription is confirmed
+// again and the filter reloaded, since announcements are missed meanwhile.
+func (f *Filter) unsubscribed() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bloom = nil
+	f.subscribed = false
+	f.gen++
+}
+
+// refresh rebuilds the filter from the Redis index while the subscription is
+// up. On failure the previous filter is kept until it becomes stale.
+func (f *Filter) refresh() {
+	f.mu.RLock()
+	gen, subscribed := f.gen, f.subscribed
+	f.mu.RUnlock()
+	if !subscribed {
+		return
+	}
+
+	ids, err := redis.ListRevokedTokenIDs()
+	if err != nil {
+		log.Printf("Warning: Failed to load revoked token IDs: %v\n", err)
+		return
+	}
+	b := newFilledBloom(ids)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.gen != gen || !f.subscribed {
+		return // The subscription dropped while loading
+	}
+	f.bloom = b
+	f.loadedAt = time.Now()
+}
@@ -0,0 +1,68 @@
+package revocation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	f := &Filter{}
+	if !f.MayContain("app:jti-1") {
+		t.Fatal("unloaded filter must report every ID as possibly revoked")
+	}
+
+	f.Load([]string{"app:jti-1", "app:jti-2"})
+	f.Add("app:jti-3")
+	for _, id := range []string{"app:jti-1", "app:jti-2", "app:jti-3"} {
+		if !f.MayContain(id) {
+			t.Errorf("MayContain(%q) = false for a revoked ID", id)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.MayContain(fmt.Sprintf("app:other-%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 {
+		t.Errorf("%d false positives in 10000 lookups, want about 10", falsePositives)
+	}
+
+	f.maxAge = time.Minute
+	f.loadedAt = time.Now().Add(-2 * time.Minute)
+	if !f.MayContain("app:other-0") {
+		t.Error("stale filter must report every ID as possibly revoked")
+	}
+}
+
+func TestBloomNoFalseNegatives(t *testing.T) {
+	b := newBloom(1000, falsePositiveRate)
+	for i := 0; i < 5000; i++ {
+		b.Add(fmt.Sprintf("id-%d", i))
+	}
+	for i := 0; i < 5000; i++ {
+		if id := fmt.Sprintf("id-%d", i); !b.MayContain(id) {
+			t.Fatalf("MayContain(%q) = false after Add", id)
+		}
+	}
+}
+
+func TestFilterUnsubscribed(t *testing.T) {
+	f := &Filter{subscribed: true}
+	f.Load([]string{"app:jti-1"})
+	if f.MayContain("app:other") {
+		t.Fatal("loaded filter reported an unrevoked ID")
+	}
+
+	f.unsubscribed()
+	if !f.MayContain("app:other") {
+		t.Error("filter without a subscription must report every ID as possibly revoked")
+	}
+	// Refreshes are skipped until the subscription is confirmed again
+	f.refresh()
+	if !f.MayContain("app:other") {
+		t.Error("refresh without a subscription reloaded the filter")
+	}
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2026, 10, 16, 9, 7, 30, 0, time.UTC) // Friday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 16, 9, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 16, 9, 15, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2026, 10, 17, 3, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 1-5", time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 1", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}, // day-of-month OR day-of-week
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"10,40 8-10 * * *", time.Date(2026, 10, 16, 9, 10, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNeverMatches(t *testing.T) {
+	s, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next = %v, want zero time", got)
+	}
+}
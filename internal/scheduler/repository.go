@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Repository handles persistence of scheduled job run history.
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository creates a new scheduler Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// CreateRun stores a new run record.
+func (r *Repository) CreateRun(run *models.ScheduledJobRun) error {
+	return r.DB.Create(run).Error
+}
+
+// FinishRun saves the outcome of a run.
+func (r *Repository) FinishRun(run *models.ScheduledJobRun) error {
+	return r.DB.Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+		"duration_ms": run.DurationMs,
+	}).Error
+}
+
+// LastRuns returns the most recent run of every job, keyed by job name.
+func (r *Repository) LastRuns() (map[string]models.ScheduledJobRun, error) {
+	var runs []models.ScheduledJobRun
+	err := r.DB.Raw(`
+		SELECT DISTINCT ON (job_name) *
+		FROM scheduled_job_runs
+		ORDER BY job_name, started_at DESC
+	`).Scan(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	last := make(map[string]models.ScheduledJobRun, len(runs))
+	for _, run := range runs {
+		last[run.JobName] = run
+	}
+	return last, nil
+}
+
+// ListRuns returns the most recent runs, newest first, optionally for one job.
+func (r *Repository) ListRuns(jobName string, limit int) ([]models.ScheduledJobRun, error) {
+	var runs []models.ScheduledJobRun
+	query := r.DB.Order("started_at DESC").Limit(limit)
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+// DeleteRunsBefore removes run records started before the cutoff and returns
+// how many were deleted.
+func (r *Repository) DeleteRunsBefore(cutoff time.Time) (int64, error) {
+	result := r.DB.Where("started_at < ?", cutoff).Delete(&models.ScheduledJobRun{})
+	return result.RowsAffected, result.Error
+}
+
+// MarkInterrupted fails the runs an instance left in the running state, e.g.
+// because the server was stopped while a job was executing.
+func (r *Repository) MarkInterrupted(instance string) error {
+	return r.DB.Model(&models.ScheduledJobRun{}).
+		Where("instance = ? AND status = ?", instance, StatusRunning).
+		Updates(map[string]interface{}{
+			"status":      StatusFailed,
+			"error":       "interrupted by server shutdown",
+			"finished_at": time.Now().UTC(),
+		}).Error
+}
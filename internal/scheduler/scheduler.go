@@ -0,0 +1,315 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Run triggers.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Run statuses.
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// lockTTL is how long the claim on a scheduled occurrence is kept in Redis.
+// It only has to outlive clock skew between instances.
+const lockTTL = time.Hour
+
+var (
+	ErrJobNotFound     = errors.New("scheduled job not found")
+	ErrJobRunning      = errors.New("scheduled job is already running")
+	ErrDuplicateJob    = errors.New("scheduled job already registered")
+	ErrSchedulerClosed = errors.New("scheduler is shut down")
+)
+
+// JobFunc is the work performed by a scheduled job. The context is cancelled
+// when the scheduler shuts down; long-running jobs should honour it.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name        string
+	description string
+	spec        string
+	schedule    *Schedule
+	run         JobFunc
+	next        time.Time
+	running     bool
+}
+
+// JobStatus describes a registered job for the admin GUI.
+type JobStatus struct {
+	Name        string
+	Description string
+	Spec        string
+	NextRun     time.Time
+	Running     bool
+	LastRun     *models.ScheduledJobRun
+}
+
+// Scheduler runs recurring background jobs on cron schedules. When several API
+// instances run the same schedule, a Redis lock per scheduled occurrence makes
+// sure only one of them executes it. Every execution is recorded in the
+// scheduled_job_runs table.
+type Scheduler struct {
+	repo     *Repository
+	instance string
+
+	// acquire claims a scheduled occurrence of a job across instances.
+	acquire func(name string, occurrence time.Time) (bool, error)
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a Scheduler with the built-in run history cleanup job
+// registered. Call Register to add jobs, then Start.
+func NewScheduler(repo *Repository) *Scheduler {
+	instance, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		repo:     repo,
+		instance: instance,
+		jobs:     make(map[string]*job),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	s.acquire = func(name string, occurrence time.Time) (bool, error) {
+		return redis.AcquireJobLock(name, occurrence.Unix(), s.instance, lockTTL)
+	}
+	_ = s.Register("scheduler_history_cleanup",
+		"Deletes scheduled job run history older than SCHEDULER_HISTORY_DAYS (default 30)",
+		"30 3 * * *", s.cleanupHistory)
+	return s
+}
+
+// Register adds a job that runs on the given cron schedule (see ParseCron).
+func (s *Scheduler) Register(name, description, spec string, run JobFunc) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, name)
+	}
+	s.jobs[name] = &job{
+		name:        name,
+		description: description,
+		spec:        spec,
+		schedule:    schedule,
+		run:         run,
+		next:        schedule.Next(time.Now()),
+	}
+	return nil
+}
+
+// Start launches the scheduling loop.
+func (s *Scheduler) Start() {
+	if err := s.repo.MarkInterrupted(s.instance); err != nil {
+		log.Printf("Scheduler: failed to close interrupted runs: %v", err)
+	}
+	s.wg.Add(1)
+	go s.loop()
+	log.Printf("Job scheduler started (%d jobs)", len(s.jobs))
+}
+
+// Shutdown stops the scheduling loop and waits for running jobs to return.
+func (s *Scheduler) Shutdown() {
+	if s == nil {
+		return
+	}
+	log.Println("Shutting down job scheduler...")
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loop wakes at every minute boundary and dispatches the jobs that are due.
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.dispatchDue(time.Now())
+		}
+	}
+}
+
+// dispatchDue starts every job whose next run time has passed. A job that is
+// still running from its previous occurrence is skipped for this occurrence.
+func (s *Scheduler) dispatchDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.next.IsZero() || j.next.After(now) {
+			continue
+		}
+		occurrence := j.next
+		j.next = j.schedule.Next(now)
+		if j.running {
+			log.Printf("Scheduler: job %s is still running, skipping run at %s", j.name, occurrence.Format(time.RFC3339))
+			continue
+		}
+		j.running = true
+		s.wg.Add(1)
+		go s.execute(j, TriggerSchedule, occurrence)
+	}
+}
+
+// RunNow starts a job immediately, outside its schedule.
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return ErrSchedulerClosed
+	}
+	j, ok := s.jobs[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if j.running {
+		return ErrJobRunning
+	}
+	j.running = true
+	s.wg.Add(1)
+	go s.execute(j, TriggerManual, time.Now())
+	return nil
+}
+
+// execute runs one occurrence of a job and records it in the run history.
+// Scheduled occurrences first claim the Redis lock; losing the claim means
+// another instance runs this occurrence.
+func (s *Scheduler) execute(j *job, trigger string, occurrence time.Time) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}()
+
+	if trigger == TriggerSchedule {
+		claimed, err := s.acquire(j.name, occurrence)
+		if err != nil {
+			log.Printf("Scheduler: failed to acquire lock for job %s: %v", j.name, err)
+			return
+		}
+		if !claimed {
+			return
+		}
+	}
+
+	run := &models.ScheduledJobRun{
+		JobName:   j.name,
+		Trigger:   trigger,
+		Status:    StatusRunning,
+		Instance:  s.instance,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := s.repo.CreateRun(run); err != nil {
+		log.Printf("Scheduler: failed to record run of job %s: %v", j.name, err)
+	}
+
+	err := runJob(s.ctx, j.run)
+
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Status = StatusSuccess
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+		log.Printf("Scheduler: job %s failed: %v", j.name, err)
+	}
+	if run.ID != uuid.Nil {
+		if err := s.repo.FinishRun(run); err != nil {
+			log.Printf("Scheduler: failed to record result of job %s: %v", j.name, err)
+		}
+	}
+}
+
+// runJob calls fn, turning a panic into an error so one faulty job cannot
+// take the server down.
+func runJob(ctx context.Context, fn JobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Jobs returns the registered jobs with their next and last runs, sorted by name.
+func (s *Scheduler) Jobs() ([]JobStatus, error) {
+	s.mu.Lock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, JobStatus{
+			Name:        j.name,
+			Description: j.description,
+			Spec:        j.spec,
+			NextRun:     j.next,
+			Running:     j.running,
+		})
+	}
+	s.mu.Unlock()
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].Name < statuses[b].Name })
+
+	last, err := s.repo.LastRuns()
+	if err != nil {
+		return statuses, err
+	}
+	for i := range statuses {
+		if run, ok := last[statuses[i].Name]; ok {
+			statuses[i].LastRun = &run
+		}
+	}
+	return statuses, nil
+}
+
+// History returns the most recent runs, optionally for one job.
+func (s *Scheduler) History(jobName string, limit int) ([]models.ScheduledJobRun, error) {
+	return s.repo.ListRuns(jobName, limit)
+}
+
+// cleanupHistory deletes run records older than SCHEDULER_HISTORY_DAYS (default 30).
+func (s *Scheduler) cleanupHistory(ctx context.Context) error {
+	days := viper.GetInt("SCHEDULER_HISTORY_DAYS")
+	if days <= 0 {
+		days = 30
+	}
+	deleted, err := s.repo.DeleteRunsBefore(time.Now().UTC().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Scheduler: deleted %d job run record(s) older than %d days", deleted, days)
+	}
+	return nil
+}
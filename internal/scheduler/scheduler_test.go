@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	s := NewScheduler(nil)
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Register("job", "", "0 * * * *", noop); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.Register("job", "", "0 * * * *", noop); !errors.Is(err, ErrDuplicateJob) {
+		t.Errorf("duplicate: err = %v, want ErrDuplicateJob", err)
+	}
+	if err := s.Register("bad", "", "not a cron", noop); err == nil {
+		t.Error("invalid spec accepted")
+	}
+	if err := s.RunNow("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("RunNow(missing): err = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestRunJobRecoversPanic(t *testing.T) {
+	err := runJob(context.Background(), func(context.Context) error { panic("boom") })
+	if err == nil || err.Error() != "panic: boom" {
+		t.Errorf("err = %v, want panic error", err)
+	}
+}
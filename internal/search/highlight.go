@@ -0,0 +1,89 @@
+package search
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// termPattern returns a case-insensitive pattern matching any term of q,
+// longest first, or nil when q has no terms.
+func termPattern(q string) *regexp.Regexp {
+	terms := Terms(q)
+	if len(terms) == 0 {
+		return nil
+	}
+	sort.Slice(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// Highlight returns text as HTML with the occurrences of the terms of q
+// wrapped in <mark>. Everything else is escaped.
+func Highlight(text, q string) template.HTML {
+	return highlight(text, termPattern(q))
+}
+
+func highlight(text string, pattern *regexp.Regexp) template.HTML {
+	if pattern == nil {
+		return template.HTML(html.EscapeString(text))
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return template.HTML(b.String())
+}
+
+// Snippet returns about width characters of text around the first occurrence
+// of a term of q, highlighted as by Highlight, or "" when no term occurs.
+func Snippet(text, q string, width int) template.HTML {
+	pattern := termPattern(q)
+	if pattern == nil {
+		return ""
+	}
+	m := pattern.FindStringIndex(text)
+	if m == nil {
+		return ""
+	}
+
+	start := m[0] - width/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + width
+	if end < m[1] {
+		end = m[1]
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+	// Keep the window on rune boundaries
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	out := highlight(text[start:end], pattern)
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+// Package search provides Postgres full-text search over users and activity
+// logs: the searchable document of each table, a safe conversion of free-text
+// queries into prefix-matching tsqueries, and helpers highlighting the matched
+// terms in the admin GUI.
+//
+// The document expressions are indexed by the GIN expression indexes of
+// migrations/20261016_add_full_text_search.sql. Postgres only uses those
+// indexes when a query repeats the expression exactly, so both must change
+// together.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// UserDocument is the tsvector of a user: the email (whole, its domain and its
+// alphanumeric parts, so "jane", "example" and "example.com" all match
+// jane.doe@example.com), name, first name and last name.
+const UserDocument = `to_tsvector('simple', coalesce(users.email, '') || ' ' || ` +
+	`split_part(coalesce(users.email, ''), '@', 2) || ' ' || ` +
+	`regexp_replace(coalesce(users.email, ''), '[^[:alnum:]]+', ' ', 'g') || ' ' || ` +
+	`coalesce(users.name, '') || ' ' || coalesce(users.first_name, '') || ' ' || coalesce(users.last_name, ''))`
+
+// ActivityLogDocument is the tsvector of an activity log: event type, IP
+// address, user agent and the string and numeric values of its details.
+const ActivityLogDocument = `(to_tsvector('simple', coalesce(activity_logs.event_type, '') || ' ' || ` +
+	`coalesce(activity_logs.ip_address, '') || ' ' || coalesce(activity_logs.user_agent, '')) || ` +
+	`jsonb_to_tsvector('simple', coalesce(activity_logs.details, '{}'::jsonb), '["string", "numeric"]'))`
+
+// maxTerms is the number of query terms used; further terms are ignored.
+const maxTerms = 8
+
+// Terms returns the lowercased search terms of a free-text query: its
+// whitespace-separated words that contain a letter or digit.
+func Terms(q string) []string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(q)) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, word)
+		if len(terms) == maxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// Query converts a free-text query into to_tsquery('simple', ...) input
+// matching documents that contain every term as a word prefix. Terms are
+// quoted, so tsquery operators in the input are matched literally. It returns
+// "" when q has no usable terms.
+func Query(q string) string {
+	terms := Terms(q)
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `''`)
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = "'" + quote.Replace(term) + "':*"
+	}
+	return strings.Join(parts, " & ")
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"", ""},
+		{"  !!! ", ""},
+		{"Jane", "'jane':*"},
+		{"jane  EXAMPLE.com", "'jane':* & 'example.com':*"},
+		{"o'brien", "'o''brien':*"},
+		{`a\b | c`, `'a\\b':* & 'c':*`},
+	}
+	for _, tc := range tests {
+		if got := Query(tc.q); got != tc.want {
+			t.Errorf("Query(%q) = %q, want %q", tc.q, got, tc.want)
+		}
+	}
+
+	if got := len(Terms(strings.Repeat("word ", 20))); got != maxTerms {
+		t.Errorf("Terms kept %d terms, want %d", got, maxTerms)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("Jane <jane@example.com>", "JANE example")
+	want := "<mark>Jane</mark> &lt;<mark>jane</mark>@<mark>example</mark>.com&gt;"
+	if string(got) != want {
+		t.Errorf("Highlight() = %q, want %q", got, want)
+	}
+	if got := Highlight("<b>", ""); got != "&lt;b&gt;" {
+		t.Errorf("Highlight() without terms = %q", got)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("a", 50) + " needle " + strings.Repeat("b", 50)
+	got := string(Snippet(text, "needle", 20))
+	if !strings.Contains(got, "<mark>needle</mark>") || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("Snippet() = %q", got)
+	}
+	if got := Snippet(text, "missing", 20); got != "" {
+		t.Errorf("Snippet() without a match = %q, want empty", got)
+	}
+}
+
+// TestMigrationMatchesDocuments guards against the index expressions drifting
+// from the query expressions, which silently disables the indexes.
+func TestMigrationMatchesDocuments(t *testing.T) {
+	migration, err := os.ReadFile("../../migrations/20261016_add_full_text_search.sql")
+	if err != nil {
+		t.Fatalf("read migration: %v", err)
+	}
+	for _, doc := range []struct{ table, expr string }{
+		{"users", UserDocument},
+		{"activity_logs", ActivityLogDocument},
+	} {
+		unqualified := strings.ReplaceAll(doc.expr, doc.table+".", "")
+		if !strings.Contains(string(migration), unqualified) {
+			t.Errorf("migration has no index on %s matching %s", doc.table, unqualified)
+		}
+	}
+}
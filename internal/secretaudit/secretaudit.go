@@ -0,0 +1,368 @@
+// Package secretaudit audits the configured secrets — the JWT signing secret,
+// the settings encryption key, the admin API key, provider credentials and the
+// secrets stored in the system settings — and reports the weak, missing or
+// placeholder ones, most severe first.
+//
+// The audit only reads: secret values never appear in the report.
+package secretaudit
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/secretbox"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Severity ranks a finding.
+type Severity string
+
+// Severities, most severe first
+const (
+	SeverityCritical Severity = "critical" // Tokens or data can be forged or read
+	SeverityHigh     Severity = "high"     // A secret is weak, or a feature is broken by a missing one
+	SeverityMedium   Severity = "medium"   // Hardening is missing
+	SeverityLow      Severity = "low"      // Worth knowing
+)
+
+var severityRank = map[Severity]int{SeverityCritical: 0, SeverityHigh: 1, SeverityMedium: 2, SeverityLow: 3}
+
+// Severities lists the severities, most severe first.
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+
+// Check names
+const (
+	CheckJWTSecret             = "jwt_secret"
+	CheckSettingsEncryptionKey = "settings_encryption_key"
+	CheckAdminAPIKey           = "admin_api_key"
+	CheckDefaultAppID          = "default_app_id"
+	CheckSMTPHosts             = "smtp_hosts"
+	CheckOAuthClientSecrets    = "oauth_client_secrets"
+	CheckOIDCClientSecrets     = "oidc_client_secrets"
+	CheckStoredSettings        = "stored_settings"
+)
+
+// Checks lists every check, in the order they run.
+var Checks = []string{
+	CheckJWTSecret, CheckSettingsEncryptionKey, CheckAdminAPIKey, CheckDefaultAppID,
+	CheckSMTPHosts, CheckOAuthClientSecrets, CheckOIDCClientSecrets, CheckStoredSettings,
+}
+
+// DefaultAppID is the well-known ID of the default application created by the
+// multi-tenancy migration and the setup tool.
+var DefaultAppID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+const (
+	// minSecretBits is the estimated entropy below which a signing or
+	// encryption secret is reported as weak.
+	minSecretBits = 128
+	// minKeyLength is the shortest accepted signing or encryption secret.
+	minKeyLength = 32
+)
+
+// placeholderMarkers appear in the example values of .env.example, the config
+// file example and the documentation.
+var placeholderMarkers = []string{"your_", "your-", "change-this", "changeme", "change_me", "example", "placeholder", "replace-me", "secret-key-here"}
+
+// exampleDomains never deliver mail (RFC 2606).
+var exampleDomains = []string{"example.com", "example.org", "example.net"}
+
+// Finding is one problem found by the audit.
+type Finding struct {
+	Check       string // One of the Check* names
+	Severity    Severity
+	Title       string
+	Detail      string   // What was found, without secret values
+	Remediation string   // How to fix it
+	Targets     []string // Affected records (e.g. "Shop / google"), if any
+}
+
+// Report is the result of an audit.
+type Report struct {
+	GeneratedAt time.Time
+	Checks      []string  // Checks that ran
+	Findings    []Finding // Most severe first
+}
+
+// Count returns the number of findings of severity s.
+func (r *Report) Count(s Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed reports whether the report has critical or high findings.
+func (r *Report) Failed() bool {
+	return r.Count(SeverityCritical)+r.Count(SeverityHigh) > 0
+}
+
+// Options configure an audit.
+type Options struct {
+	// SensitiveSettingKeys are the system settings holding secrets, which
+	// must be stored encrypted.
+	SensitiveSettingKeys []string
+}
+
+// Run audits the configured secrets. Database checks that fail are reported
+// as errors; the configuration checks always run.
+func Run(db *gorm.DB, opts Options) (*Report, error) {
+	r := &Report{GeneratedAt: time.Now().UTC(), Checks: Checks}
+	r.Findings = append(r.Findings, checkJWTSecret(viper.GetString("JWT_SECRET"))...)
+	r.Findings = append(r.Findings, checkSettingsEncryptionKey(viper.GetString("SETTINGS_ENCRYPTION_KEY"))...)
+	r.Findings = append(r.Findings, checkAdminAPIKey(viper.GetString("ADMIN_API_KEY"))...)
+
+	for _, check := range []func(*gorm.DB, Options) ([]Finding, error){
+		checkDefaultAppID, checkSMTPHosts, checkOAuthClientSecrets, checkOIDCClientSecrets, checkStoredSettings,
+	} {
+		findings, err := check(db, opts)
+		if err != nil {
+			return nil, err
+		}
+		r.Findings = append(r.Findings, findings...)
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		return severityRank[r.Findings[i].Severity] < severityRank[r.Findings[j].Severity]
+	})
+	return r, nil
+}
+
+func checkJWTSecret(secret string) []Finding {
+	const fix = "Generate a random secret (e.g. openssl rand -base64 48) and set JWT_SECRET. Changing it signs every user out and, without SETTINGS_ENCRYPTION_KEY, makes the encrypted settings unreadable."
+	switch {
+	case secret == "":
+		return []Finding{{Check: CheckJWTSecret, Severity: SeverityCritical, Title: "JWT secret is not set", Detail: "JWT_SECRET is empty; the server refuses to start without it.", Remediation: fix}}
+	case isPlaceholder(secret):
+		return []Finding{{Check: CheckJWTSecret, Severity: SeverityCritical, Title: "JWT secret is a placeholder", Detail: "JWT_SECRET looks like an example value from the documentation. Anyone who knows it can forge tokens for every user.", Remediation: fix}}
+	case len(secret) < minKeyLength:
+		return []Finding{{Check: CheckJWTSecret, Severity: SeverityCritical, Title: "JWT secret is too short", Detail: fmt.Sprintf("JWT_SECRET is %d bytes; at least %d are required.", len(secret), minKeyLength), Remediation: fix}}
+	}
+	if bits := EstimateEntropyBits(secret); bits < minSecretBits {
+		return []Finding{{Check: CheckJWTSecret, Severity: SeverityHigh, Title: "JWT secret has low entropy", Detail: fmt.Sprintf("JWT_SECRET has an estimated %.0f bits of entropy; at least %d are recommended. Secrets made of words or repeated characters can be guessed offline from any token.", bits, minSecretBits), Remediation: fix}}
+	}
+	return nil
+}
+
+func checkSettingsEncryptionKey(key string) []Finding {
+	const fix = "Set SETTINGS_ENCRYPTION_KEY to a random value of at least 32 bytes, then enter the secrets stored in the settings again."
+	switch {
+	case key == "":
+		return []Finding{{Check: CheckSettingsEncryptionKey, Severity: SeverityLow, Title: "Settings encryption key is derived from the JWT secret", Detail: "SETTINGS_ENCRYPTION_KEY is not set, so secrets stored in the settings are encrypted with a key derived from JWT_SECRET. Rotating JWT_SECRET makes them unreadable.", Remediation: fix}}
+	case isPlaceholder(key):
+		return []Finding{{Check: CheckSettingsEncryptionKey, Severity: SeverityCritical, Title: "Settings encryption key is a placeholder", Detail: "SETTINGS_ENCRYPTION_KEY looks like an example value; the secrets stored in the settings can be decrypted by anyone with database access.", Remediation: fix}}
+	case len(key) < minKeyLength || EstimateEntropyBits(key) < minSecretBits:
+		return []Finding{{Check: CheckSettingsEncryptionKey, Severity: SeverityHigh, Title: "Settings encryption key is weak", Detail: fmt.Sprintf("SETTINGS_ENCRYPTION_KEY is %d bytes with an estimated %.0f bits of entropy; at least %d bytes and %d bits are recommended.", len(key), EstimateEntropyBits(key), minKeyLength, minSecretBits), Remediation: fix}}
+	}
+	return nil
+}
+
+func checkAdminAPIKey(key string) []Finding {
+	const fix = "Set ADMIN_API_KEY to a random value of at least 32 bytes, or unset it and use the admin API keys created in the admin GUI."
+	switch {
+	case key == "":
+		return nil
+	case isPlaceholder(key):
+		return []Finding{{Check: CheckAdminAPIKey, Severity: SeverityCritical, Title: "Admin API key is a placeholder", Detail: "ADMIN_API_KEY looks like an example value and grants full access to the Admin API.", Remediation: fix}}
+	case len(key) < minKeyLength:
+		return []Finding{{Check: CheckAdminAPIKey, Severity: SeverityHigh, Title: "Admin API key is short", Detail: fmt.Sprintf("ADMIN_API_KEY is %d bytes; at least %d are recommended for a key granting full access to the Admin API.", len(key), minKeyLength), Remediation: fix}}
+	}
+	return nil
+}
+
+func checkDefaultAppID(db *gorm.DB, _ Options) ([]Finding, error) {
+	var app models.Application
+	err := db.Select("id, name").First(&app, "id = ?", DefaultAppID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the default application: %w", err)
+	}
+	var users int64
+	if err := db.Model(&models.User{}).Where("app_id = ?", DefaultAppID).Count(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	if users == 0 {
+		return []Finding{{Check: CheckDefaultAppID, Severity: SeverityLow, Title: "Default application still exists", Detail: fmt.Sprintf("%q uses the well-known ID %s but has no users.", app.Name, DefaultAppID), Remediation: "Delete the default application if it is not used, or disable it.", Targets: []string{app.Name}}}, nil
+	}
+	return []Finding{{Check: CheckDefaultAppID, Severity: SeverityMedium, Title: "Default application ID in use", Detail: fmt.Sprintf("%q uses the well-known ID %s and has %d users. Every installation shares this ID, so sign-up, login and password reset requests against it need no knowledge of the deployment.", app.Name, DefaultAppID, users), Remediation: "Create applications with random IDs for production use and move clients to them; keep IP rules and rate limits on the default application while it is in use.", Targets: []string{app.Name}}}, nil
+}
+
+func checkSMTPHosts(db *gorm.DB, _ Options) ([]Finding, error) {
+	var configs []models.EmailServerConfig
+	if err := db.Select("id, app_id, name, smtp_host, is_active").Where("is_active = ?", true).Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load email servers: %w", err)
+	}
+	var targets []string
+	for _, cfg := range configs {
+		if isExampleHost(cfg.SMTPHost) {
+			targets = append(targets, fmt.Sprintf("%s (%s)", cfg.Name, cfg.SMTPHost))
+		}
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	return []Finding{{Check: CheckSMTPHosts, Severity: SeverityHigh, Title: "Email servers use example hosts", Detail: fmt.Sprintf("%d active email servers point at example domains, which never deliver mail: verification, password reset and 2FA emails are lost.", len(targets)), Remediation: "Set the real SMTP host under Email Servers, or deactivate these servers.", Targets: targets}}, nil
+}
+
+func checkOAuthClientSecrets(db *gorm.DB, _ Options) ([]Finding, error) {
+	var rows []struct {
+		models.OAuthProviderConfig
+		AppName string
+	}
+	err := db.Table("oauth_provider_configs").
+		Select("oauth_provider_configs.*, applications.name AS app_name").
+		Joins("LEFT JOIN applications ON applications.id = oauth_provider_configs.app_id").
+		Order("applications.name, oauth_provider_configs.provider").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OAuth configs: %w", err)
+	}
+	var missing, placeholder, missingDisabled []string
+	for _, row := range rows {
+		target := row.AppName + " / " + row.Provider
+		switch {
+		case row.ClientSecret == "" && row.IsEnabled:
+			missing = append(missing, target)
+		case row.ClientSecret == "":
+			missingDisabled = append(missingDisabled, target)
+		case isPlaceholder(row.ClientSecret):
+			placeholder = append(placeholder, target)
+		}
+	}
+	var findings []Finding
+	if len(missing) > 0 {
+		findings = append(findings, Finding{Check: CheckOAuthClientSecrets, Severity: SeverityHigh, Title: "Enabled OAuth configs without a client secret", Detail: fmt.Sprintf("%d enabled OAuth configs have an empty client secret; sign-in with these providers fails.", len(missing)), Remediation: "Enter the client secret from the provider's developer console, or disable the config.", Targets: missing})
+	}
+	if len(placeholder) > 0 {
+		findings = append(findings, Finding{Check: CheckOAuthClientSecrets, Severity: SeverityHigh, Title: "OAuth client secrets are placeholders", Detail: fmt.Sprintf("%d OAuth configs have a client secret that looks like an example value.", len(placeholder)), Remediation: "Enter the client secret from the provider's developer console.", Targets: placeholder})
+	}
+	if len(missingDisabled) > 0 {
+		findings = append(findings, Finding{Check: CheckOAuthClientSecrets, Severity: SeverityLow, Title: "Disabled OAuth configs without a client secret", Detail: fmt.Sprintf("%d disabled OAuth configs have no client secret (e.g. cloned or imported without secrets).", len(missingDisabled)), Remediation: "Enter the client secret before enabling them, or delete them.", Targets: missingDisabled})
+	}
+	return findings, nil
+}
+
+func checkOIDCClientSecrets(db *gorm.DB, _ Options) ([]Finding, error) {
+	var clients []models.OIDCClient
+	if err := db.Select("id, name, client_id, client_secret_hash, is_confidential").Where("is_confidential = ? AND (client_secret_hash = '' OR client_secret_hash IS NULL)", true).Find(&clients).Error; err != nil {
+		return nil, fmt.Errorf("failed to load OIDC clients: %w", err)
+	}
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	targets := make([]string, 0, len(clients))
+	for _, client := range clients {
+		targets = append(targets, fmt.Sprintf("%s (%s)", client.Name, client.ClientID))
+	}
+	return []Finding{{Check: CheckOIDCClientSecrets, Severity: SeverityHigh, Title: "Confidential OIDC clients without a secret", Detail: fmt.Sprintf("%d confidential OIDC clients have no client secret, so they cannot authenticate at the token endpoint.", len(clients)), Remediation: "Rotate the client secret under OIDC Clients, or make the client public (PKCE only).", Targets: targets}}, nil
+}
+
+func checkStoredSettings(db *gorm.DB, opts Options) ([]Finding, error) {
+	if len(opts.SensitiveSettingKeys) == 0 {
+		return nil, nil
+	}
+	var settings []models.SystemSetting
+	if err := db.Where("key IN ?", opts.SensitiveSettingKeys).Order("key").Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	var plaintext, unreadable []string
+	for _, s := range settings {
+		switch {
+		case s.Value == "":
+		case !secretbox.IsEncrypted(s.Value):
+			plaintext = append(plaintext, s.Key)
+		default:
+			if _, err := secretbox.Decrypt(s.Value); err != nil {
+				unreadable = append(unreadable, s.Key)
+			}
+		}
+	}
+	var findings []Finding
+	if len(unreadable) > 0 {
+		findings = append(findings, Finding{Check: CheckStoredSettings, Severity: SeverityHigh, Title: "Stored secrets cannot be decrypted", Detail: fmt.Sprintf("%d secrets stored in the settings were encrypted with another key; the features using them do not work.", len(unreadable)), Remediation: "Restore the previous SETTINGS_ENCRYPTION_KEY (or JWT_SECRET), or enter these settings again.", Targets: unreadable})
+	}
+	if len(plaintext) > 0 {
+		findings = append(findings, Finding{Check: CheckStoredSettings, Severity: SeverityMedium, Title: "Secrets stored in plaintext", Detail: fmt.Sprintf("%d secrets in the settings were stored before encryption was introduced and are still plaintext in the database.", len(plaintext)), Remediation: "Save these settings again in the admin GUI (Settings) to store them encrypted.", Targets: plaintext})
+	}
+	return findings, nil
+}
+
+// EstimateEntropyBits estimates the entropy of secret from the frequency of
+// its characters (Shannon entropy times length). It overestimates secrets
+// made of words, but catches short, repetitive and low-variety secrets.
+func EstimateEntropyBits(secret string) float64 {
+	if secret == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range secret {
+		counts[r]++
+		n++
+	}
+	perChar := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(n)
+}
+
+// isPlaceholder reports whether value looks like an example value.
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, marker := range placeholderMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExampleHost reports whether host is in an example domain.
+func isExampleHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	for _, domain := range exampleDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes the report as plain text, for the command line.
+func WriteText(w io.Writer, r *Report) error {
+	counts := make([]string, 0, len(Severities))
+	for _, s := range Severities {
+		counts = append(counts, fmt.Sprintf("%d %s", r.Count(s), s))
+	}
+	if _, err := fmt.Fprintf(w, "Secrets audit (%d checks): %s\n", len(r.Checks), strings.Join(counts, ", ")); err != nil {
+		return err
+	}
+	for _, f := range r.Findings {
+		if _, err := fmt.Fprintf(w, "\n[%s] %s\n  %s\n", strings.ToUpper(string(f.Severity)), f.Title, f.Detail); err != nil {
+			return err
+		}
+		for _, target := range f.Targets {
+			if _, err := fmt.Fprintf(w, "  - %s\n", target); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "  Fix: %s\n", f.Remediation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package secretaudit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateEntropyBits(t *testing.T) {
+	if bits := EstimateEntropyBits(""); bits != 0 {
+		t.Errorf("empty: got %.1f", bits)
+	}
+	if bits := EstimateEntropyBits(strings.Repeat("a", 64)); bits != 0 {
+		t.Errorf("repeated character: got %.1f", bits)
+	}
+	if bits := EstimateEntropyBits("q8Vn3LZx7Kp2Rt9WmYc4Hb6Jd1Fs5Ge0Uo8Ai3Ek7Nw2Pz6Tv9Xy4Mr1Bq5Cl0Dh"); bits < minSecretBits {
+		t.Errorf("random secret: got %.1f bits, want at least %d", bits, minSecretBits)
+	}
+}
+
+func TestCheckJWTSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		want   Severity // Empty = no finding
+	}{
+		{"empty", "", SeverityCritical},
+		{"placeholder", "your-super-secret-jwt-key-change-this-in-production", SeverityCritical},
+		{"short", "k8Vn3LZx7Kp2", SeverityCritical},
+		{"low entropy", strings.Repeat("ab", 20), SeverityHigh},
+		{"strong", "q8Vn3LZx7Kp2Rt9WmYc4Hb6Jd1Fs5Ge0Uo8Ai3Ek7Nw2Pz6Tv9Xy4Mr1Bq5Cl0Dh", ""},
+	}
+	for _, tt := range tests {
+		findings := checkJWTSecret(tt.secret)
+		switch {
+		case tt.want == "" && len(findings) > 0:
+			t.Errorf("%s: got %+v, want no finding", tt.name, findings)
+		case tt.want != "" && (len(findings) != 1 || findings[0].Severity != tt.want):
+			t.Errorf("%s: got %+v, want one %s finding", tt.name, findings, tt.want)
+		}
+	}
+}
+
+func TestCheckFindingsNeverIncludeSecrets(t *testing.T) {
+	secret := "your-super-secret-jwt-key"
+	findings := append(checkJWTSecret(secret), checkAdminAPIKey(secret)...)
+	findings = append(findings, checkSettingsEncryptionKey(secret)...)
+	for _, f := range findings {
+		if strings.Contains(f.Detail+f.Title+f.Remediation, secret) {
+			t.Errorf("%s finding contains the secret: %+v", f.Check, f)
+		}
+	}
+}
+
+func TestIsExampleHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"smtp.example.com":  true,
+		"example.org":       true,
+		"MAIL.EXAMPLE.NET.": true,
+		"smtp.gmail.com":    false,
+		"notexample.com":    false,
+		"":                  false,
+	} {
+		if got := isExampleHost(host); got != want {
+			t.Errorf("isExampleHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	r := &Report{
+		GeneratedAt: time.Now(),
+		Checks:      Checks,
+		Findings: []Finding{
+			{Check: CheckJWTSecret, Severity: SeverityCritical, Title: "JWT secret is a placeholder", Detail: "d", Remediation: "fix"},
+			{Check: CheckSMTPHosts, Severity: SeverityHigh, Title: "Email servers use example hosts", Detail: "d", Remediation: "fix", Targets: []string{"Default (smtp.example.com)"}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteText(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"1 critical, 1 high, 0 medium, 0 low", "[CRITICAL] JWT secret is a placeholder", "  - Default (smtp.example.com)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if !r.Failed() {
+		t.Error("Failed() = false with critical findings")
+	}
+}
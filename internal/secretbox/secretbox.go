@@ -0,0 +1,152 @@
+// Package secretbox encrypts secrets stored in the database, such as storage
+// backend credentials kept in the system settings, with AES-256-GCM.
+//
+// The key is derived from SETTINGS_ENCRYPTION_KEY, or from JWT_SECRET when it
+// is unset. Changing the key makes the stored secrets unreadable, so they have
+// to be entered again.
+//
+// Seal and Open encrypt with a caller-supplied transport key instead, for
+// secrets that leave the server, e.g. in OAuth config exports.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// prefix marks encrypted values, so values stored before encryption was
+// introduced are still read as plaintext.
+const prefix = "enc:v1:"
+
+// ErrNoKey is returned when neither SETTINGS_ENCRYPTION_KEY nor JWT_SECRET is set.
+var ErrNoKey = errors.New("no encryption key: set SETTINGS_ENCRYPTION_KEY or JWT_SECRET")
+
+// IsEncrypted reports whether value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt encrypts plaintext. The empty string is returned unchanged.
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead, err := newAEAD()
+	if err != nil {
+		return "", err
+	}
+	return seal(aead, plaintext)
+}
+
+// Decrypt decrypts a value produced by Encrypt. Values without the encryption
+// prefix are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	aead, err := newAEAD()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, value)
+	if err == errOpen {
+		return "", errors.New("cannot decrypt value: the encryption key has changed")
+	}
+	return plaintext, err
+}
+
+// MinTransportKeyLength is the shortest transport key Seal and Open accept.
+const MinTransportKeyLength = 16
+
+// ErrTransportKeyTooShort is returned for transport keys shorter than MinTransportKeyLength.
+var ErrTransportKeyTooShort = errors.New("the transport key must be at least 16 characters")
+
+// ErrWrongTransportKey is returned by Open when the value was sealed with another key.
+var ErrWrongTransportKey = errors.New("cannot decrypt value: wrong transport key")
+
+// Seal encrypts plaintext with a transport key. The empty string is returned unchanged.
+func Seal(plaintext, transportKey string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead, err := transportAEAD(transportKey)
+	if err != nil {
+		return "", err
+	}
+	return seal(aead, plaintext)
+}
+
+// Open decrypts a value produced by Seal with the same transport key. Values
+// without the encryption prefix are returned unchanged.
+func Open(value, transportKey string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	aead, err := transportAEAD(transportKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, value)
+	if err == errOpen {
+		return "", ErrWrongTransportKey
+	}
+	return plaintext, err
+}
+
+// errOpen is returned by open when authentication fails.
+var errOpen = errors.New("cannot decrypt value")
+
+func seal(aead cipher.AEAD, plaintext string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func open(aead cipher.AEAD, value string) (string, error) {
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errOpen
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD() (cipher.AEAD, error) {
+	secret := viper.GetString("SETTINGS_ENCRYPTION_KEY")
+	if secret == "" {
+		secret = viper.GetString("JWT_SECRET")
+	}
+	if secret == "" {
+		return nil, ErrNoKey
+	}
+	return deriveAEAD("auth-api settings encryption:" + secret)
+}
+
+func transportAEAD(transportKey string) (cipher.AEAD, error) {
+	if len(transportKey) < MinTransportKeyLength {
+		return nil, ErrTransportKeyTooShort
+	}
+	return deriveAEAD("auth-api transport encryption:" + transportKey)
+}
+
+func deriveAEAD(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
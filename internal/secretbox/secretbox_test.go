@@ -0,0 +1,52 @@
+package secretbox
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	viper.Set("SETTINGS_ENCRYPTION_KEY", "test-settings-key")
+	t.Cleanup(func() { viper.Set("SETTINGS_ENCRYPTION_KEY", "") })
+
+	enc, err := Encrypt("s3-secret")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEncrypted(enc) || enc == "s3-secret" {
+		t.Fatalf("Encrypt() = %q, want an encrypted value", enc)
+	}
+	if got, err := Decrypt(enc); err != nil || got != "s3-secret" {
+		t.Errorf("Decrypt() = %q, %v, want s3-secret", got, err)
+	}
+
+	// Plaintext stored before encryption is read as is
+	if got, err := Decrypt("legacy"); err != nil || got != "legacy" {
+		t.Errorf("Decrypt(plaintext) = %q, %v, want legacy", got, err)
+	}
+
+	viper.Set("SETTINGS_ENCRYPTION_KEY", "another-key")
+	if _, err := Decrypt(enc); err == nil {
+		t.Error("Decrypt() with a changed key succeeded, want an error")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	sealed, err := Seal("client-secret", "transport-key-0123")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatalf("Seal() = %q, want an encrypted value", sealed)
+	}
+	if got, err := Open(sealed, "transport-key-0123"); err != nil || got != "client-secret" {
+		t.Errorf("Open() = %q, %v, want client-secret", got, err)
+	}
+	if _, err := Open(sealed, "another-key-45678"); err != ErrWrongTransportKey {
+		t.Errorf("Open() with another key: err = %v, want ErrWrongTransportKey", err)
+	}
+	if _, err := Seal("client-secret", "short"); err != ErrTransportKeyTooShort {
+		t.Errorf("Seal() with a short key: err = %v, want ErrTransportKeyTooShort", err)
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// unixPrefix selects a Unix domain socket in a listen address.
+const unixPrefix = "unix:"
+
+// Listen opens a listener for addr: a TCP address such as ":9090" or
+// "127.0.0.1:9090", or "unix:/path/to/socket" for a Unix domain socket. A
+// stale socket file left by a previous run is replaced; the socket is made
+// accessible to the owner and group only.
+func Listen(addr string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(addr, unixPrefix)
+	if !isUnix {
+		return net.Listen("tcp", addr)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("missing socket path in %q", addr)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	return ln, nil
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSOptions configure the built-in TLS termination of the public listener.
+// Either certificate files or autocert domains enable it.
+type TLSOptions struct {
+	CertFile, KeyFile string   // PEM certificate (chain) and private key
+	AutocertDomains   []string // Domains to obtain Let's Encrypt certificates for
+	AutocertEmail     string   // Optional ACME account contact
+	AutocertCacheDir  string   // Where certificates and the ACME account key are kept
+	DisableHTTP2      bool     // Serve HTTP/1.1 only
+}
+
+// Enabled reports whether TLS is configured.
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || len(o.AutocertDomains) > 0
+}
+
+// Validate reports incomplete or conflicting options.
+func (o TLSOptions) Validate() error {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if o.CertFile != "" && len(o.AutocertDomains) > 0 {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS are mutually exclusive")
+	}
+	if len(o.AutocertDomains) > 0 && o.AutocertCacheDir == "" {
+		return fmt.Errorf("TLS_AUTOCERT_CACHE_DIR is required with TLS_AUTOCERT_DOMAINS")
+	}
+	return nil
+}
+
+// NewTLSServer returns a server for addr that terminates TLS with the given
+// options, serving HTTP/2 unless disabled. The autocert manager is returned
+// when certificates come from Let's Encrypt (nil otherwise); its HTTPHandler
+// answers HTTP-01 challenges on the redirect listener.
+func NewTLSServer(addr string, handler http.Handler, opts TLSOptions) (*http.Server, *autocert.Manager, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var manager *autocert.Manager
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(opts.AutocertDomains) > 0 {
+		manager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.AutocertDomains...),
+			Cache:      autocert.DirCache(opts.AutocertCacheDir),
+			Email:      opts.AutocertEmail,
+		}
+		tlsConfig = manager.TLSConfig() // Also answers TLS-ALPN-01 challenges
+		tlsConfig.MinVersion = tls.VersionTLS12
+	} else {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if opts.DisableHTTP2 {
+		// A non-nil, empty map turns off the automatic HTTP/2 support
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		tlsConfig.NextProtos = removeProto(tlsConfig.NextProtos, "h2")
+	}
+	return srv, manager, nil
+}
+
+// RedirectHandler redirects every request to the same URL over HTTPS on
+// httpsPort ("443" is left out of the URL). GET and HEAD get a 301, other
+// methods a 308 so clients repeat them with the same method and body.
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6 literal
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host += ":" + httpsPort
+		}
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
+
+// NewRedirectServer returns a plain HTTP server for addr that redirects to
+// HTTPS on httpsPort. With an autocert manager it also answers ACME HTTP-01
+// challenges.
+func NewRedirectServer(addr, httpsPort string, manager *autocert.Manager) *http.Server {
+	handler := RedirectHandler(httpsPort)
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+func removeProto(protos []string, proto string) []string {
+	out := make([]string, 0, len(protos))
+	for _, p := range protos {
+		if p != proto {
+			out = append(out, p)
+		}
+	}
+	return out
+}
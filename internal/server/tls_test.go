@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		method, host, target, httpsPort string
+		wantStatus                      int
+		wantLocation                    string
+	}{
+		{http.MethodGet, "auth.example.com", "/login?next=%2Fgui", "443", http.StatusMovedPermanently, "https://auth.example.com/login?next=%2Fgui"},
+		{http.MethodGet, "auth.example.com:80", "/", "8443", http.StatusMovedPermanently, "https://auth.example.com:8443/"},
+		{http.MethodPost, "auth.example.com", "/login", "443", http.StatusPermanentRedirect, "https://auth.example.com/login"},
+		{http.MethodGet, "[::1]:8080", "/health", "443", http.StatusMovedPermanently, "https://[::1]/health"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		req.Host = tc.host
+		w := httptest.NewRecorder()
+		RedirectHandler(tc.httpsPort).ServeHTTP(w, req)
+		if w.Code != tc.wantStatus || w.Header().Get("Location") != tc.wantLocation {
+			t.Errorf("%s %s%s = %d %q, want %d %q", tc.method, tc.host, tc.target,
+				w.Code, w.Header().Get("Location"), tc.wantStatus, tc.wantLocation)
+		}
+	}
+}
+
+func TestTLSOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr bool
+	}{
+		{"disabled", TLSOptions{}, false},
+		{"certificate files", TLSOptions{CertFile: "cert.pem", KeyFile: "key.pem"}, false},
+		{"key missing", TLSOptions{CertFile: "cert.pem"}, true},
+		{"autocert", TLSOptions{AutocertDomains: []string{"auth.example.com"}, AutocertCacheDir: "certs"}, false},
+		{"autocert without cache", TLSOptions{AutocertDomains: []string{"auth.example.com"}}, true},
+		{"both", TLSOptions{CertFile: "cert.pem", KeyFile: "key.pem", AutocertDomains: []string{"auth.example.com"}, AutocertCacheDir: "certs"}, true},
+	}
+	for _, tc := range tests {
+		if err := tc.opts.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestNewTLSServerAutocertHTTP2(t *testing.T) {
+	opts := TLSOptions{AutocertDomains: []string{"auth.example.com"}, AutocertCacheDir: t.TempDir()}
+	srv, manager, err := NewTLSServer(":8443", http.NotFoundHandler(), opts)
+	if err != nil {
+		t.Fatalf("NewTLSServer: %v", err)
+	}
+	if manager == nil {
+		t.Fatal("no autocert manager returned")
+	}
+	if !contains(srv.TLSConfig.NextProtos, "h2") {
+		t.Errorf("NextProtos = %v, want h2 offered", srv.TLSConfig.NextProtos)
+	}
+
+	opts.DisableHTTP2 = true
+	srv, _, err = NewTLSServer(":8443", http.NotFoundHandler(), opts)
+	if err != nil {
+		t.Fatalf("NewTLSServer: %v", err)
+	}
+	if contains(srv.TLSConfig.NextProtos, "h2") || srv.TLSNextProto == nil {
+		t.Errorf("HTTP/2 still enabled: NextProtos = %v", srv.TLSConfig.NextProtos)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
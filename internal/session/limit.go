@@ -0,0 +1,110 @@
+package session
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Session limit policies — what happens when a new sign-in would exceed an
+// application's MaxSessionsPerUser. Configured per app.
+const (
+	LimitPolicyReject      = "reject"       // Refuse the new sign-in
+	LimitPolicyEvictOldest = "evict_oldest" // Revoke the user's oldest sessions (default)
+)
+
+// IsValidLimitPolicy reports whether policy is a supported session limit policy.
+func IsValidLimitPolicy(policy string) bool {
+	return policy == LimitPolicyReject || policy == LimitPolicyEvictOldest
+}
+
+// LimitFunc returns the maximum number of concurrent sessions per user of an
+// application (0 = unlimited) and its limit policy.
+type LimitFunc func(appID string) (maxSessions int, policy string)
+
+// DBLimits returns a LimitFunc reading the limits from the applications table.
+// Lookup failures are treated as unlimited.
+func DBLimits(db *gorm.DB) LimitFunc {
+	return func(appID string) (int, string) {
+		var app models.Application
+		if err := db.Select("max_sessions_per_user, session_limit_policy").First(&app, "id = ?", appID).Error; err != nil {
+			return 0, ""
+		}
+		return app.MaxSessionsPerUser, app.SessionLimitPolicy
+	}
+}
+
+// limit returns the session limit of an application, with the policy
+// defaulting to LimitPolicyEvictOldest.
+func (s *Service) limit(appID string) (int, string) {
+	if s.Limits == nil {
+		return 0, ""
+	}
+	maxSessions, policy := s.Limits(appID)
+	if maxSessions <= 0 {
+		return 0, ""
+	}
+	if !IsValidLimitPolicy(policy) {
+		policy = LimitPolicyEvictOldest
+	}
+	return maxSessions, policy
+}
+
+// enforceLimit makes room for a new session of the user: with the reject
+// policy it refuses the sign-in once the user has the maximum number of
+// sessions, with evict_oldest it revokes the oldest sessions. Redis failures
+// are logged and let the sign-in proceed.
+func (s *Service) enforceLimit(appID, userID string) *errors.AppError {
+	maxSessions, policy := s.limit(appID)
+	if maxSessions == 0 {
+		return nil
+	}
+	sessionIDs, err := redis.GetUserSessionIDs(appID, userID)
+	if err != nil {
+		log.Printf("Warning: Failed to count sessions of user %s for the session limit: %v\n", userID, err)
+		return nil
+	}
+	if len(sessionIDs) < maxSessions {
+		return nil
+	}
+	if policy == LimitPolicyReject {
+		return errors.NewAppError(errors.ErrForbidden, fmt.Sprintf("Maximum of %d active sessions reached. Sign out on another device to sign in here.", maxSessions))
+	}
+
+	createdAt := make(map[string]string, len(sessionIDs))
+	for _, sid := range sessionIDs {
+		if data, err := redis.GetSession(appID, sid); err == nil {
+			createdAt[sid] = data["created_at"]
+		}
+	}
+	for _, sid := range oldestSessions(sessionIDs, createdAt, len(sessionIDs)-maxSessions+1) {
+		if err := redis.DeleteSession(appID, sid, userID); err != nil {
+			log.Printf("Warning: Failed to evict session %s of user %s: %v\n", sid, userID, err)
+			continue
+		}
+		log.Printf("Info: evicted session %s of user %s in app %s (session limit %d)\n", sid, userID, appID, maxSessions)
+	}
+	return nil
+}
+
+// oldestSessions returns the n session IDs with the earliest creation time
+// (RFC 3339, as stored by redis.CreateSession). Sessions without a creation
+// time sort first.
+func oldestSessions(sessionIDs []string, createdAt map[string]string, n int) []string {
+	sorted := append([]string(nil), sessionIDs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return createdAt[sorted[i]] < createdAt[sorted[j]]
+	})
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	if n < 0 {
+		n = 0
+	}
+	return sorted[:n]
+}
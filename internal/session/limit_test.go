@@ -0,0 +1,50 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOldestSessions(t *testing.T) {
+	ids := []string{"c", "a", "d", "b"}
+	createdAt := map[string]string{
+		"a": "2026-10-01T08:00:00Z",
+		"b": "2026-10-03T08:00:00Z",
+		"c": "2026-10-02T08:00:00Z",
+		// "d" has no creation time and sorts first
+	}
+
+	if got, want := oldestSessions(ids, createdAt, 2), []string{"d", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("oldestSessions(2) = %v, want %v", got, want)
+	}
+	if got := oldestSessions(ids, createdAt, 10); len(got) != len(ids) {
+		t.Errorf("oldestSessions(10) = %v, want all %d sessions", got, len(ids))
+	}
+	if got := oldestSessions(ids, createdAt, 0); len(got) != 0 {
+		t.Errorf("oldestSessions(0) = %v, want none", got)
+	}
+	if !reflect.DeepEqual(ids, []string{"c", "a", "d", "b"}) {
+		t.Errorf("oldestSessions modified its input: %v", ids)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     LimitFunc
+		wantMax    int
+		wantPolicy string
+	}{
+		{"no lookup", nil, 0, ""},
+		{"unlimited", func(string) (int, string) { return 0, LimitPolicyReject }, 0, ""},
+		{"reject", func(string) (int, string) { return 3, LimitPolicyReject }, 3, LimitPolicyReject},
+		{"unknown policy", func(string) (int, string) { return 2, "" }, 2, LimitPolicyEvictOldest},
+	}
+	for _, tc := range tests {
+		s := &Service{Limits: tc.limits}
+		gotMax, gotPolicy := s.limit("app")
+		if gotMax != tc.wantMax || gotPolicy != tc.wantPolicy {
+			t.Errorf("%s: limit = %d, %q; want %d, %q", tc.name, gotMax, gotPolicy, tc.wantMax, tc.wantPolicy)
+		}
+	}
+}
@@ -5,14 +5,19 @@ import (
 	"time"
 
 	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/region"
+	"github.com/gjovanovicst/auth_api/internal/revocation"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	"github.com/gjovanovicst/auth_api/pkg/errors"
 	"github.com/gjovanovicst/auth_api/pkg/jwt"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 )
 
 // Service handles session lifecycle management backed by Redis.
-type Service struct{}
+type Service struct {
+	Limits LimitFunc // Optional: per-app concurrent session limits; if nil, sessions are unlimited
+}
 
 // NewService creates a new session service.
 func NewService() *Service {
@@ -26,7 +31,15 @@ func NewService() *Service {
 //
 // accessTTL and refreshTTL control token lifetimes. Pass 0 to use the global
 // defaults configured via environment variables.
+//
+// When the application limits concurrent sessions per user, the limit is
+// enforced first: the sign-in is rejected or the oldest sessions are revoked,
+// depending on the app's policy.
 func (s *Service) CreateSession(appID, userID, ip, userAgent string, roles []string, accessTTL, refreshTTL time.Duration) (accessToken, refreshToken, sessionID string, appErr *errors.AppError) {
+	if appErr := s.enforceLimit(appID, userID); appErr != nil {
+		return "", "", "", appErr
+	}
+
 	sessionID = uuid.New().String()
 
 	// Resolve effective refresh TTL for Redis session expiry
@@ -35,12 +48,16 @@ func (s *Service) CreateSession(appID, userID, ip, userAgent string, roles []str
 		effectiveRefreshTTL = jwt.DefaultRefreshTokenTTL()
 	}
 
-	accessToken, err := jwt.GenerateAccessToken(appID, userID, sessionID, roles, accessTTL)
+	// Session tokens start at version 1; every refresh raises it
+	cfg := region.Current()
+	version := jwt.TokenVersion{Version: 1, Region: cfg.Name}
+
+	accessToken, err := jwt.GenerateVersionedAccessToken(appID, userID, sessionID, roles, accessTTL, version)
 	if err != nil {
 		return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to generate access token")
 	}
 
-	refreshToken, err = jwt.GenerateRefreshToken(appID, userID, sessionID, roles, refreshTTL)
+	refreshToken, err = jwt.GenerateVersionedRefreshToken(appID, userID, sessionID, roles, refreshTTL, version)
 	if err != nil {
 		return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to generate refresh token")
 	}
@@ -48,6 +65,14 @@ func (s *Service) CreateSession(appID, userID, ip, userAgent string, roles []str
 	if err := redis.CreateSession(appID, sessionID, userID, refreshToken, ip, userAgent, effectiveRefreshTTL); err != nil {
 		return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to create session")
 	}
+	if err := redis.SetSessionTokenState(appID, sessionID, region.TokenState{
+		RefreshToken: refreshToken,
+		Version:      version.Version,
+		Region:       cfg.Name,
+		RotatedAt:    time.Now(),
+	}); err != nil {
+		return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to create session")
+	}
 
 	// Clear any user-wide token blacklist so the newly issued tokens are not immediately
 	// rejected by AuthMiddleware. The blacklist was set to invalidate pre-reset tokens;
@@ -79,21 +104,44 @@ func (s *Service) RefreshSession(oldRefreshToken string, accessTTL, refreshTTL t
 		return "", "", "", errors.NewAppError(errors.ErrUnauthorized, "Session expired, please log in again")
 	}
 
-	// Verify session exists and refresh token matches
-	storedToken, err := redis.GetSessionRefreshToken(claims.AppID, claims.SessionID)
-	if err != nil {
+	// Reject tokens issued before the application's auth epoch (kill switch)
+	if revoked, err := redis.IsRevokedByAppAuthEpoch(claims.AppID, claims.IssuedAtTime()); err != nil || revoked {
 		return "", "", "", errors.NewAppError(errors.ErrUnauthorized, "Session expired or revoked")
 	}
-	if storedToken != oldRefreshToken {
+
+	// In multi-region deployments, revocation in any region wins over the
+	// session state of a lagging replica
+	cfg := region.Current()
+	now := time.Now()
+	if cfg.Enabled() {
+		if revoked, err := redis.IsSessionRevoked(claims.AppID, claims.SessionID); err != nil || revoked {
+			return "", "", "", errors.NewAppError(errors.ErrUnauthorized, "Session expired or revoked")
+		}
+	}
+
+	// Verify session exists and refresh token matches. Across regions, a newer
+	// token version than the local replica's or a concurrent rotation within
+	// the grace window is accepted too (see region.Config.ResolveRefresh).
+	presented := region.PresentedToken{Token: oldRefreshToken, Version: claims.TokenVersion, Region: claims.Region}
+	state, err := redis.GetSessionTokenState(claims.AppID, claims.SessionID)
+	notReplicated := false
+	if err != nil {
+		// A session created in another region moments ago may not have replicated yet
+		if err != goredis.Nil || !cfg.WithinGrace(claims.IssuedAtTime(), now) {
+			return "", "", "", errors.NewAppError(errors.ErrUnauthorized, "Session expired or revoked")
+		}
+		notReplicated = true
+	} else if err := cfg.ResolveRefresh(presented, state, now); err != nil {
 		return "", "", "", errors.NewAppError(errors.ErrUnauthorized, "Refresh token revoked or invalid")
 	}
 
 	// Generate new token pair (same session ID)
-	newAccessToken, tokenErr := jwt.GenerateAccessToken(claims.AppID, claims.UserID, claims.SessionID, claims.Roles, accessTTL)
+	version := jwt.TokenVersion{Version: region.NextVersion(presented, state), Region: cfg.Name}
+	newAccessToken, tokenErr := jwt.GenerateVersionedAccessToken(claims.AppID, claims.UserID, claims.SessionID, claims.Roles, accessTTL, version)
 	if tokenErr != nil {
 		return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to generate new access token")
 	}
-	newRefreshToken, tokenErr := jwt.GenerateRefreshToken(claims.AppID, claims.UserID, claims.SessionID, claims.Roles, refreshTTL)
+	newRefreshToken, tokenErr := jwt.GenerateVersionedRefreshToken(claims.AppID, claims.UserID, claims.SessionID, claims.Roles, refreshTTL, version)
 	if tokenErr != nil {
 		return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to generate new refresh token")
 	}
@@ -104,10 +152,24 @@ func (s *Service) RefreshSession(oldRefreshToken string, accessTTL, refreshTTL t
 		effectiveRefreshTTL = jwt.DefaultRefreshTokenTTL()
 	}
 
+	// Recreate a not yet replicated session locally; replication merges it
+	// with the original
+	if notReplicated {
+		if err := redis.CreateSession(claims.AppID, claims.SessionID, claims.UserID, newRefreshToken, "", "", effectiveRefreshTTL); err != nil {
+			return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to update session")
+		}
+	}
+
 	// Update session with new refresh token and touch last_active
-	if err := redis.UpdateSessionRefreshToken(claims.AppID, claims.SessionID, newRefreshToken); err != nil {
+	if err := redis.SetSessionTokenState(claims.AppID, claims.SessionID, region.TokenState{
+		RefreshToken: newRefreshToken,
+		Version:      version.Version,
+		Region:       cfg.Name,
+		RotatedAt:    now,
+	}); err != nil {
 		return "", "", "", errors.NewAppError(errors.ErrInternal, "Failed to update session")
 	}
+
 	// Reset the Redis session TTL so it slides forward with the newly issued
 	// refresh token. Without this, the session key expires at the original
 	// login time regardless of per-app TTL overrides or token rotation.
@@ -139,7 +201,8 @@ func (s *Service) RevokeAllSessions(appID, userID, exceptSessionID string) *erro
 	return nil
 }
 
-// ListSessions returns all active sessions for a user.
+// ListSessions returns all active sessions for a user, along with the
+// application's concurrent session limit.
 func (s *Service) ListSessions(appID, userID, currentSessionID string) (*dto.SessionListResponse, *errors.AppError) {
 	sessionIDs, err := redis.GetUserSessionIDs(appID, userID)
 	if err != nil {
@@ -163,7 +226,8 @@ func (s *Service) ListSessions(appID, userID, currentSessionID string) (*dto.Ses
 		})
 	}
 
-	return &dto.SessionListResponse{Sessions: sessions}, nil
+	maxSessions, policy := s.limit(appID)
+	return &dto.SessionListResponse{Sessions: sessions, MaxSessions: maxSessions, LimitPolicy: policy}, nil
 }
 
 // LogoutSession handles the logout flow for a specific session.
@@ -180,7 +244,7 @@ func (s *Service) LogoutSession(appID, userID, sessionID, accessToken string) *e
 		if err == nil {
 			remainingTime := time.Until(claims.ExpiresAt.Time)
 			if remainingTime > 0 {
-				if err := redis.BlacklistAccessToken(appID, accessToken, userID, remainingTime); err != nil {
+				if err := revocation.Revoke(appID, accessToken, claims.ID, userID, remainingTime); err != nil {
 					log.Printf("Warning: Failed to blacklist access token: %v\n", err)
 				}
 			}
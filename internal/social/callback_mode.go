@@ -0,0 +1,156 @@
+package social
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Callback modes — how social login callbacks deliver their result (tokens,
+// 2FA challenge, merge prompt or error) to the frontend. Configured per app.
+const (
+	CallbackModeQuery       = "query"        // 302 to the redirect URI with the result as query parameters (default)
+	CallbackModeFragment    = "fragment"     // 302 to the redirect URI with the result in the URL fragment
+	CallbackModeJSON        = "json"         // JSON response body (400 when the result is an error)
+	CallbackModePostMessage = "post_message" // HTML page that postMessages the result to window.opener
+)
+
+// IsValidCallbackMode reports whether mode is a supported callback mode.
+func IsValidCallbackMode(mode string) bool {
+	switch mode {
+	case CallbackModeQuery, CallbackModeFragment, CallbackModeJSON, CallbackModePostMessage:
+		return true
+	}
+	return false
+}
+
+// postMessagePage posts the callback result to the window that opened the
+// login popup, restricted to the origin of the redirect URI, then closes itself.
+var postMessagePage = template.Must(template.New("social_post_message").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Signing in</title></head>
+<body>
+<p>Signing in&hellip; You can close this window.</p>
+<script nonce="{{.Nonce}}">
+(function () {
+    var result = {{.Result}};
+    if (window.opener) {
+        window.opener.postMessage({type: "social_login", result: result}, {{.Origin}});
+        window.close();
+    }
+})();
+</script>
+</body>
+</html>
+`))
+
+// callbackMode returns the callback mode configured for the application,
+// falling back to CallbackModeQuery.
+func (h *Handler) callbackMode(appID string) string {
+	mode, err := h.Service.SocialRepo.GetCallbackMode(appID)
+	if err != nil || !IsValidCallbackMode(mode) {
+		return CallbackModeQuery
+	}
+	return mode
+}
+
+// respondCallback delivers a social login callback result. target is the URL
+// the query mode redirects to: the redirect URI with the result appended as
+// query parameters, or the relay-state return URL with tokens in its fragment
+// (see OAuthState.LoginRedirectURL). Other modes re-encode that result.
+func respondCallback(c *gin.Context, mode, redirectURI, target string) {
+	if mode == CallbackModeQuery || mode == "" {
+		c.Redirect(http.StatusFound, target)
+		return
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		c.Redirect(http.StatusFound, target)
+		return
+	}
+	base, result, relay := splitCallbackResult(u, redirectURI)
+
+	switch mode {
+	case CallbackModeFragment:
+		c.Redirect(http.StatusFound, base+"#"+result.Encode())
+	case CallbackModeJSON:
+		body := gin.H{}
+		for key := range result {
+			body[key] = result.Get(key)
+		}
+		if relay {
+			body["return_to"] = base
+		}
+		status := http.StatusOK
+		if result.Has("error") {
+			status = http.StatusBadRequest
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(status, body)
+	case CallbackModePostMessage:
+		data := map[string]string{}
+		for key := range result {
+			data[key] = result.Get(key)
+		}
+		if relay {
+			data["return_to"] = base
+		}
+		nonce, err := generateRandomString(16)
+		if err != nil {
+			c.Redirect(http.StatusFound, target)
+			return
+		}
+		// The API-wide CSP forbids scripts; allow only this page's inline script.
+		c.Header("Content-Security-Policy", "default-src 'none'; script-src 'nonce-"+nonce+"'; frame-ancestors 'none'; base-uri 'none'")
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		_ = postMessagePage.Execute(c.Writer, gin.H{
+			"Nonce":  nonce,
+			"Result": data,
+			"Origin": origin(base),
+		})
+	default:
+		c.Redirect(http.StatusFound, target)
+	}
+}
+
+// splitCallbackResult separates a query-mode target URL into the URL the
+// result is addressed to and the result parameters. Query parameters that
+// were already part of the redirect URI stay in the base URL. relay is true
+// when the target is a relay-state return URL rather than the redirect URI.
+func splitCallbackResult(u *url.URL, redirectURI string) (base string, result url.Values, relay bool) {
+	if u.Fragment != "" {
+		// Relay state: tokens are already in the fragment of the return URL
+		result, _ = url.ParseQuery(u.Fragment)
+		b := *u
+		b.Fragment = ""
+		return b.String(), result, true
+	}
+
+	var own url.Values
+	if r, err := url.Parse(redirectURI); err == nil {
+		own = r.Query()
+	}
+	result = url.Values{}
+	for key, values := range u.Query() {
+		if _, ok := own[key]; !ok {
+			result[key] = values
+		}
+	}
+	b := *u
+	b.RawQuery = own.Encode()
+	b.Fragment = ""
+	return b.String(), result, false
+}
+
+// origin returns the scheme://host of a URL, used as the postMessage target origin.
+func origin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "null"
+	}
+	return u.Scheme + "://" + u.Host
+}
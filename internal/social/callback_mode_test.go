@@ -0,0 +1,77 @@
+package social
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func runRespondCallback(mode, redirectURI, target string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/auth/google/callback", nil)
+	respondCallback(c, mode, redirectURI, target)
+	return w
+}
+
+func TestRespondCallbackModes(t *testing.T) {
+	redirectURI := "https://app.example.com/cb?tenant=acme"
+	target := redirectURI + "&access_token=tok&refresh_token=ref&provider=google"
+
+	w := runRespondCallback(CallbackModeQuery, redirectURI, target)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != target {
+		t.Errorf("query: %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = runRespondCallback(CallbackModeFragment, redirectURI, target)
+	want := "https://app.example.com/cb?tenant=acme#access_token=tok&provider=google&refresh_token=ref"
+	if w.Code != http.StatusFound || w.Header().Get("Location") != want {
+		t.Errorf("fragment: %d %q, want %q", w.Code, w.Header().Get("Location"), want)
+	}
+
+	w = runRespondCallback(CallbackModeJSON, redirectURI, target)
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if w.Code != http.StatusOK || body["access_token"] != "tok" || body["provider"] != "google" || body["tenant"] != "" {
+		t.Errorf("json: %d %v", w.Code, body)
+	}
+
+	w = runRespondCallback(CallbackModeJSON, redirectURI, redirectURI+"&error=ip_blocked")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("json error: status %d, want 400", w.Code)
+	}
+
+	w = runRespondCallback(CallbackModePostMessage, redirectURI, target)
+	html := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(html, `"https://app.example.com"`) || !strings.Contains(html, `"access_token":"tok"`) {
+		t.Errorf("post_message: %d %s", w.Code, html)
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'nonce-") {
+		t.Errorf("post_message CSP = %q", csp)
+	}
+}
+
+func TestRespondCallbackRelayState(t *testing.T) {
+	target := "https://app.example.com/orders/42#access_token=tok&provider=github&refresh_token=ref"
+
+	w := runRespondCallback(CallbackModeJSON, "https://app.example.com/cb", target)
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if body["access_token"] != "tok" || body["return_to"] != "https://app.example.com/orders/42" {
+		t.Errorf("json relay: %v", body)
+	}
+
+	w = runRespondCallback(CallbackModeFragment, "https://app.example.com/cb", target)
+	if got := w.Header().Get("Location"); got != target {
+		t.Errorf("fragment relay: %q, want %q", got, target)
+	}
+}
@@ -0,0 +1,101 @@
+package social
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/gjovanovicst/auth_api/internal/claimmap"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// AssignRoleByNameFunc assigns an application role to a user by its name.
+type AssignRoleByNameFunc func(appID, userID, roleName string) error
+
+// applyClaimMappings applies the claim mappings of the provider's config to
+// the user signing in with the given provider payload: mapped user fields are
+// overwritten, mapped roles are added (never revoked) and, when the mapping
+// has group rules, the user's groups are replaced by the mapped groups.
+// Failures are logged only; they never block the sign-in.
+func (s *Service) applyClaimMappings(appID uuid.UUID, provider string, rawData []byte, u *models.User) {
+	config, err := s.SocialRepo.GetOAuthProviderConfig(appID.String(), provider)
+	if err != nil || len(config.ClaimMappings) == 0 {
+		return
+	}
+	mapping, err := claimmap.Parse(config.ClaimMappings)
+	if err != nil {
+		log.Printf("Warning: invalid claim mappings for %s in app %s: %v", provider, appID, err)
+		return
+	}
+	if len(mapping.Rules) == 0 {
+		return
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(rawData, &claims); err != nil {
+		log.Printf("Warning: failed to decode %s claims for user %s: %v", provider, u.ID, err)
+		return
+	}
+	result := mapping.Apply(claims)
+
+	updates := map[string]interface{}{}
+	for field, value := range result.Fields {
+		updates[field] = value
+	}
+	var groupsJSON []byte
+	if mapping.MapsGroups() {
+		groups := result.Groups
+		if groups == nil {
+			groups = []string{}
+		}
+		groupsJSON, _ = json.Marshal(groups)
+		updates["groups"] = string(groupsJSON)
+	}
+	if len(updates) > 0 {
+		if err := s.UserRepo.DB.Model(&models.User{}).Where("id = ?", u.ID).Updates(updates).Error; err != nil {
+			log.Printf("Warning: failed to apply claim mappings to user %s: %v", u.ID, err)
+		} else {
+			setMappedFields(u, result.Fields)
+			if groupsJSON != nil {
+				u.Groups = groupsJSON
+			}
+		}
+	}
+
+	if len(result.Roles) == 0 || s.AssignRoleByName == nil {
+		return
+	}
+	has := map[string]bool{}
+	if s.LookupRoles != nil {
+		if current, err := s.LookupRoles(appID.String(), u.ID.String()); err == nil {
+			for _, r := range current {
+				has[r] = true
+			}
+		}
+	}
+	for _, role := range result.Roles {
+		if has[role] {
+			continue
+		}
+		if err := s.AssignRoleByName(appID.String(), u.ID.String(), role); err != nil {
+			log.Printf("Warning: failed to assign mapped role %q to user %s in app %s: %v", role, u.ID, appID, err)
+		}
+	}
+}
+
+// setMappedFields copies the mapped user fields onto u.
+func setMappedFields(u *models.User, fields map[string]string) {
+	for field, value := range fields {
+		switch field {
+		case "name":
+			u.Name = value
+		case "first_name":
+			u.FirstName = value
+		case "last_name":
+			u.LastName = value
+		case "profile_picture":
+			u.ProfilePicture = value
+		case "locale":
+			u.Locale = value
+		}
+	}
+}
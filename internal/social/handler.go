@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/internal/config"
+	"github.com/gjovanovicst/auth_api/internal/environment"
 	"github.com/gjovanovicst/auth_api/internal/geoip"
 	"github.com/gjovanovicst/auth_api/internal/health"
 	"github.com/gjovanovicst/auth_api/internal/log"
@@ -17,6 +18,7 @@ import (
 	twofa "github.com/gjovanovicst/auth_api/internal/twofa"
 	"github.com/gjovanovicst/auth_api/internal/util"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -28,6 +30,7 @@ type Handler struct {
 	AnomalyDetector       *log.AnomalyDetector                                 // Anomaly detector for login monitoring (nil = disabled)
 	TwoFAService          *twofa.Service                                       // Optional: if set, auto-sends SMS 2FA code on social login with SMS 2FA
 	ValidateTrustedDevice func(plainToken string) (uuid.UUID, uuid.UUID, bool) // Optional: if set, trusted device bypass is checked before requiring 2FA
+	Environments          *environment.Resolver                                // Optional: maps environments sharing users to the parent's user base
 }
 
 func NewHandler(s *Service) *Handler {
@@ -60,8 +63,8 @@ func (h *Handler) trySendBackupEmailCode(appID uuid.UUID, userID string) {
 
 // checkIPAccessRedirect evaluates IP rules for the given app and IP address.
 // Returns true if access is allowed, false if blocked.
-// When blocked, it redirects with an error parameter and logs the event.
-func (h *Handler) checkIPAccessRedirect(c *gin.Context, appID uuid.UUID, ipAddress, userAgent, redirectURI string) bool {
+// When blocked, it responds with an error in the given callback mode and logs the event.
+func (h *Handler) checkIPAccessRedirect(c *gin.Context, mode string, appID uuid.UUID, ipAddress, userAgent, redirectURI string) bool {
 	if h.IPRuleEvaluator == nil {
 		return true // No evaluator configured, allow by default
 	}
@@ -72,7 +75,7 @@ func (h *Handler) checkIPAccessRedirect(c *gin.Context, appID uuid.UUID, ipAddre
 			"country": result.Country,
 		})
 		frontendURL := fmt.Sprintf("%s?error=ip_blocked", redirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, redirectURI, frontendURL)
 		return false
 	}
 	return true
@@ -174,12 +177,23 @@ func (h *Handler) getGithubConfig(appID string) (*oauth2.Config, error) {
 	}, nil
 }
 
+// redirectAllowlist returns the application's own redirect allowlist, or nil
+// when it has none (the global ALLOWED_REDIRECT_DOMAINS then apply).
+func (h *Handler) redirectAllowlist(appID string) []string {
+	raw, err := h.Service.SocialRepo.GetRedirectAllowlist(appID)
+	if err != nil {
+		return nil
+	}
+	return util.ParseAllowlist(raw)
+}
+
 // GoogleLogin godoc
 // @Summary      Google OAuth2 Login
 // @Description  Redirects user to Google OAuth2 login page
 // @Tags         social
 // @Produce      json
 // @Param        redirect_uri query string false "Frontend callback URL"
+// @Param        return_to    query string false "App page to return to after login (tokens are delivered in its URL fragment)"
 // @Success      307 {string} string "Redirect"
 // @Router       /auth/google/login [get]
 func (h *Handler) GoogleLogin(c *gin.Context) {
@@ -189,8 +203,9 @@ func (h *Handler) GoogleLogin(c *gin.Context) {
 		return
 	}
 	appID := appIDVal.(uuid.UUID)
+	configAppID := environment.ConfigAppID(c, appID)
 
-	googleConfig, err := h.getGoogleConfig(appID.String())
+	googleConfig, err := h.getGoogleConfig(configAppID.String())
 	if err != nil {
 		stdlog.Printf("Failed to get Google OAuth config for app %s: %v", appID.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth configuration error"})
@@ -203,8 +218,8 @@ func (h *Handler) GoogleLogin(c *gin.Context) {
 		redirectURI = GetDefaultRedirectURI()
 	}
 
-	// Create secure state with redirect URI
-	state, err := CreateOAuthState(redirectURI, appID.String())
+	// Create signed state with redirect URI and optional return URL (relay state)
+	state, err := CreateOAuthState(redirectURI, c.Query("return_to"), configAppID.String(), h.redirectAllowlist(configAppID.String()))
 	if err != nil {
 		stdlog.Printf("Invalid OAuth redirect URI for Google login: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -240,7 +255,7 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 	}
 
 	// Parse and validate state
-	state, err := ParseOAuthState(encodedState)
+	state, err := ParseOAuthState(encodedState, h.redirectAllowlist)
 	if err != nil {
 		// Redirect to default with error
 		errorMsg := url.QueryEscape(fmt.Sprintf("Invalid state: %v", err))
@@ -249,11 +264,14 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 		return
 	}
 
+	// Deliver the result the way the application expects (redirect, JSON or postMessage page)
+	mode := h.callbackMode(state.AppID)
+
 	code := c.Query("code")
 	if code == "" {
 		// Redirect to frontend with error
 		frontendURL := fmt.Sprintf("%s?error=authorization_code_missing", state.RedirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
@@ -268,29 +286,31 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 		parsedAppID, err := uuid.Parse(state.AppID)
 		if err != nil {
 			frontendURL := fmt.Sprintf("%s?error=invalid_app_id_state", redirectURI)
-			c.Redirect(http.StatusFound, frontendURL)
+			respondCallback(c, mode, state.RedirectURI, frontendURL)
 			return
 		}
 		appID = parsedAppID
 	} else {
 		frontendURL := fmt.Sprintf("%s?error=app_id_missing", redirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
-	googleConfig, err := h.getGoogleConfig(appID.String())
+	googleConfig, err := h.getGoogleConfig(environment.ConfigAppID(c, appID).String())
 	if err != nil {
 		frontendURL := fmt.Sprintf("%s?error=config_error", redirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
+	// Environments sharing users resolve to the parent application's user base
+	appID = h.Environments.UserPoolAppID(appID)
 
 	token, err := googleConfig.Exchange(context.Background(), code)
 	if err != nil {
 		// Redirect to frontend with error
 		errorMsg := url.QueryEscape(fmt.Sprintf("Could not retrieve token: %v", err))
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
@@ -299,17 +319,27 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 		// Redirect to frontend with error
 		errorMsg := url.QueryEscape(appErr.Message)
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
+	h.completeLogin(c, mode, state, appID, result, "google")
+}
+
+// completeLogin finishes a social sign-in once the provider's user was
+// resolved to result: it asks for a merge confirmation, requires 2FA, or
+// issues tokens, and delivers the outcome in the callback mode.
+func (h *Handler) completeLogin(c *gin.Context, mode string, state *OAuthState, appID uuid.UUID, result *SocialLoginResult, provider string) {
+	redirectURI := state.RedirectURI
+
 	// Merge required — redirect so the frontend can prompt the user to confirm.
 	if result.RequiresMerge {
-		frontendURL := fmt.Sprintf("%s?requires_merge=true&merge_token=%s&provider=google&email=%s",
+		frontendURL := fmt.Sprintf("%s?requires_merge=true&merge_token=%s&provider=%s&email=%s",
 			redirectURI,
 			url.QueryEscape(result.MergeToken),
+			provider,
 			url.QueryEscape(result.MergeEmail))
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, state.WithReturnTo(frontendURL))
 		return
 	}
 
@@ -321,7 +351,7 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 		// Redirect to frontend with error
 		errorMsg := url.QueryEscape("Failed to fetch user for 2FA check")
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
@@ -336,23 +366,20 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 				if tdUserID, tdAppID, ok := h.ValidateTrustedDevice(cookieToken); ok &&
 					tdUserID == user.ID && tdAppID == appID {
 					// Check IP-based access rules before completing login
-					if !h.checkIPAccessRedirect(c, appID, ipAddress, userAgent, redirectURI) {
+					if !h.checkIPAccessRedirect(c, mode, appID, ipAddress, userAgent, redirectURI) {
 						return
 					}
 					accessToken, refreshToken, sessionErr := h.Service.CreateSessionOrTokens(appID.String(), userID.String(), ipAddress, userAgent)
 					if sessionErr != nil {
 						errorMsg := url.QueryEscape(sessionErr.Message)
 						frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-						c.Redirect(http.StatusFound, frontendURL)
+						respondCallback(c, mode, state.RedirectURI, frontendURL)
 						return
 					}
-					h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, "google")
-					frontendURL := fmt.Sprintf("%s?access_token=%s&refresh_token=%s&provider=google",
-						redirectURI,
-						url.QueryEscape(accessToken),
-						url.QueryEscape(refreshToken))
+					h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, provider)
+					frontendURL := state.LoginRedirectURL(accessToken, refreshToken, provider)
 					health.IncLoginSuccess(appID.String())
-					c.Redirect(http.StatusFound, frontendURL)
+					respondCallback(c, mode, state.RedirectURI, frontendURL)
 					return
 				}
 			}
@@ -364,7 +391,7 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 			// Redirect to frontend with error
 			errorMsg := url.QueryEscape("Failed to create temporary session for 2FA")
 			frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-			c.Redirect(http.StatusFound, frontendURL)
+			respondCallback(c, mode, state.RedirectURI, frontendURL)
 			return
 		}
 		// Redirect with 2FA requirement — NO session created yet
@@ -381,13 +408,13 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 		if twoFAMethod == "backup_email" {
 			h.trySendBackupEmailCode(appID, user.ID.String())
 		}
-		redirectURL := fmt.Sprintf("%s?temp_token=%s&requires_2fa=true&provider=google&method=%s", redirectURI, tempToken, twoFAMethod)
-		c.Redirect(http.StatusFound, redirectURL)
+		redirectURL := state.WithReturnTo(fmt.Sprintf("%s?temp_token=%s&requires_2fa=true&provider=%s&method=%s", redirectURI, tempToken, provider, twoFAMethod))
+		respondCallback(c, mode, state.RedirectURI, redirectURL)
 		return
 	}
 
 	// Check IP-based access rules before completing login
-	if !h.checkIPAccessRedirect(c, appID, ipAddress, userAgent, redirectURI) {
+	if !h.checkIPAccessRedirect(c, mode, appID, ipAddress, userAgent, redirectURI) {
 		return
 	}
 
@@ -395,21 +422,18 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 	if sessionErr != nil {
 		errorMsg := url.QueryEscape(sessionErr.Message)
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
 	// Log social login activity with anomaly detection
-	h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, "google")
+	h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, provider)
 
-	// Redirect to frontend with tokens in URL parameters
-	frontendURL := fmt.Sprintf("%s?access_token=%s&refresh_token=%s&provider=google",
-		redirectURI,
-		url.QueryEscape(accessToken),
-		url.QueryEscape(refreshToken))
+	// Redirect to frontend with tokens (in the return URL's fragment when a relay state was given)
+	frontendURL := state.LoginRedirectURL(accessToken, refreshToken, provider)
 
 	health.IncLoginSuccess(appID.String())
-	c.Redirect(http.StatusFound, frontendURL)
+	respondCallback(c, mode, state.RedirectURI, frontendURL)
 }
 
 // FacebookLogin godoc
@@ -418,6 +442,7 @@ func (h *Handler) GoogleCallback(c *gin.Context) {
 // @Tags         social
 // @Produce      json
 // @Param        redirect_uri query string false "Frontend callback URL"
+// @Param        return_to    query string false "App page to return to after login (tokens are delivered in its URL fragment)"
 // @Success      307 {string} string "Redirect"
 // @Router       /auth/facebook/login [get]
 func (h *Handler) FacebookLogin(c *gin.Context) {
@@ -427,8 +452,9 @@ func (h *Handler) FacebookLogin(c *gin.Context) {
 		return
 	}
 	appID := appIDVal.(uuid.UUID)
+	configAppID := environment.ConfigAppID(c, appID)
 
-	facebookConfig, err := h.getFacebookConfig(appID.String())
+	facebookConfig, err := h.getFacebookConfig(configAppID.String())
 	if err != nil {
 		stdlog.Printf("Failed to get Facebook OAuth config for app %s: %v", appID.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth configuration error"})
@@ -441,8 +467,8 @@ func (h *Handler) FacebookLogin(c *gin.Context) {
 		redirectURI = GetDefaultRedirectURI()
 	}
 
-	// Create secure state with redirect URI
-	state, err := CreateOAuthState(redirectURI, appID.String())
+	// Create signed state with redirect URI and optional return URL (relay state)
+	state, err := CreateOAuthState(redirectURI, c.Query("return_to"), configAppID.String(), h.redirectAllowlist(configAppID.String()))
 	if err != nil {
 		stdlog.Printf("Invalid OAuth redirect URI for Facebook login: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -478,7 +504,7 @@ func (h *Handler) FacebookCallback(c *gin.Context) {
 	}
 
 	// Parse and validate state
-	state, err := ParseOAuthState(encodedState)
+	state, err := ParseOAuthState(encodedState, h.redirectAllowlist)
 	if err != nil {
 		// Redirect to default with error
 		errorMsg := url.QueryEscape(fmt.Sprintf("Invalid state: %v", err))
@@ -487,11 +513,14 @@ func (h *Handler) FacebookCallback(c *gin.Context) {
 		return
 	}
 
+	// Deliver the result the way the application expects (redirect, JSON or postMessage page)
+	mode := h.callbackMode(state.AppID)
+
 	code := c.Query("code")
 	if code == "" {
 		// Redirect to frontend with error
 		frontendURL := fmt.Sprintf("%s?error=authorization_code_missing", state.RedirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
@@ -506,29 +535,31 @@ func (h *Handler) FacebookCallback(c *gin.Context) {
 		parsedAppID, err := uuid.Parse(state.AppID)
 		if err != nil {
 			frontendURL := fmt.Sprintf("%s?error=invalid_app_id_state", redirectURI)
-			c.Redirect(http.StatusFound, frontendURL)
+			respondCallback(c, mode, state.RedirectURI, frontendURL)
 			return
 		}
 		appID = parsedAppID
 	} else {
 		frontendURL := fmt.Sprintf("%s?error=app_id_missing", redirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
-	facebookConfig, err := h.getFacebookConfig(appID.String())
+	facebookConfig, err := h.getFacebookConfig(environment.ConfigAppID(c, appID).String())
 	if err != nil {
 		frontendURL := fmt.Sprintf("%s?error=config_error", redirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
+	// Environments sharing users resolve to the parent application's user base
+	appID = h.Environments.UserPoolAppID(appID)
 
 	token, err := facebookConfig.Exchange(context.Background(), code)
 	if err != nil {
 		// Redirect to frontend with error
 		errorMsg := url.QueryEscape(fmt.Sprintf("Could not retrieve token: %v", err))
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
@@ -537,116 +568,11 @@ func (h *Handler) FacebookCallback(c *gin.Context) {
 		// Redirect to frontend with error
 		errorMsg := url.QueryEscape(appErr.Message)
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
-		return
-	}
-
-	// Merge required — redirect so the frontend can prompt the user to confirm.
-	if result.RequiresMerge {
-		frontendURL := fmt.Sprintf("%s?requires_merge=true&merge_token=%s&provider=facebook&email=%s",
-			redirectURI,
-			url.QueryEscape(result.MergeToken),
-			url.QueryEscape(result.MergeEmail))
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
-	userID := result.UserID
-
-	// Fetch user to check 2FA status
-	user, err := h.Service.UserRepo.GetUserByID(userID.String())
-	if err != nil {
-		// Redirect to frontend with error
-		errorMsg := url.QueryEscape("Failed to fetch user for 2FA check")
-		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
-		return
-	}
-
-	ipAddress, userAgent := util.GetClientInfo(c)
-
-	if user.TwoFAEnabled && h.Service.IsAppTwoFAEnabled(appID) {
-		// Trusted device check: if the client presents a valid trusted-device cookie
-		// matching this user + app, skip 2FA entirely and issue tokens immediately.
-		if h.ValidateTrustedDevice != nil {
-			if cookieToken, cookieErr := c.Cookie("trusted_device"); cookieErr == nil && cookieToken != "" {
-				if tdUserID, tdAppID, ok := h.ValidateTrustedDevice(cookieToken); ok &&
-					tdUserID == user.ID && tdAppID == appID {
-					// Check IP-based access rules before completing login
-					if !h.checkIPAccessRedirect(c, appID, ipAddress, userAgent, redirectURI) {
-						return
-					}
-					accessToken, refreshToken, sessionErr := h.Service.CreateSessionOrTokens(appID.String(), userID.String(), ipAddress, userAgent)
-					if sessionErr != nil {
-						errorMsg := url.QueryEscape(sessionErr.Message)
-						frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-						c.Redirect(http.StatusFound, frontendURL)
-						return
-					}
-					h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, "facebook")
-					frontendURL := fmt.Sprintf("%s?access_token=%s&refresh_token=%s&provider=facebook",
-						redirectURI,
-						url.QueryEscape(accessToken),
-						url.QueryEscape(refreshToken))
-					health.IncLoginSuccess(appID.String())
-					c.Redirect(http.StatusFound, frontendURL)
-					return
-				}
-			}
-		}
-
-		tempToken := uuid.New().String()
-		err := redis.SetTempUserSession(appID.String(), tempToken, user.ID.String(), 10*time.Minute)
-		if err != nil {
-			// Redirect to frontend with error
-			errorMsg := url.QueryEscape("Failed to create temporary session for 2FA")
-			frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-			c.Redirect(http.StatusFound, frontendURL)
-			return
-		}
-		// Redirect with 2FA requirement — NO session created yet
-		// Include the user's configured 2FA method so the frontend can show the correct input
-		twoFAMethod := user.TwoFAMethod
-		if twoFAMethod == "" {
-			twoFAMethod = "totp"
-		}
-		// Auto-send SMS code if the user's 2FA method is SMS
-		if twoFAMethod == "sms" {
-			h.trySendSMSCode(appID, user.ID.String())
-		}
-		// Auto-send backup email code if the user's 2FA method is backup_email
-		if twoFAMethod == "backup_email" {
-			h.trySendBackupEmailCode(appID, user.ID.String())
-		}
-		redirectURL := fmt.Sprintf("%s?temp_token=%s&requires_2fa=true&provider=facebook&method=%s", redirectURI, tempToken, twoFAMethod)
-		c.Redirect(http.StatusFound, redirectURL)
-		return
-	}
-
-	// Check IP-based access rules before completing login
-	if !h.checkIPAccessRedirect(c, appID, ipAddress, userAgent, redirectURI) {
-		return
-	}
-
-	accessToken, refreshToken, sessionErr := h.Service.CreateSessionOrTokens(appID.String(), userID.String(), ipAddress, userAgent)
-	if sessionErr != nil {
-		errorMsg := url.QueryEscape(sessionErr.Message)
-		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
-		return
-	}
-
-	// Log social login activity with anomaly detection
-	h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, "facebook")
-
-	// Redirect to frontend with tokens in URL parameters
-	frontendURL := fmt.Sprintf("%s?access_token=%s&refresh_token=%s&provider=facebook",
-		redirectURI,
-		url.QueryEscape(accessToken),
-		url.QueryEscape(refreshToken))
-
-	health.IncLoginSuccess(appID.String())
-	c.Redirect(http.StatusFound, frontendURL)
+	h.completeLogin(c, mode, state, appID, result, "facebook")
 }
 
 // GithubLogin godoc
@@ -655,6 +581,7 @@ func (h *Handler) FacebookCallback(c *gin.Context) {
 // @Tags         social
 // @Produce      json
 // @Param        redirect_uri query string false "Frontend callback URL"
+// @Param        return_to    query string false "App page to return to after login (tokens are delivered in its URL fragment)"
 // @Success      307 {string} string "Redirect"
 // @Router       /auth/github/login [get]
 func (h *Handler) GithubLogin(c *gin.Context) {
@@ -664,8 +591,9 @@ func (h *Handler) GithubLogin(c *gin.Context) {
 		return
 	}
 	appID := appIDVal.(uuid.UUID)
+	configAppID := environment.ConfigAppID(c, appID)
 
-	githubConfig, err := h.getGithubConfig(appID.String())
+	githubConfig, err := h.getGithubConfig(configAppID.String())
 	if err != nil {
 		stdlog.Printf("Failed to get GitHub OAuth config for app %s: %v", appID.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth configuration error"})
@@ -678,8 +606,8 @@ func (h *Handler) GithubLogin(c *gin.Context) {
 		redirectURI = GetDefaultRedirectURI()
 	}
 
-	// Create secure state with redirect URI
-	state, err := CreateOAuthState(redirectURI, appID.String())
+	// Create signed state with redirect URI and optional return URL (relay state)
+	state, err := CreateOAuthState(redirectURI, c.Query("return_to"), configAppID.String(), h.redirectAllowlist(configAppID.String()))
 	if err != nil {
 		stdlog.Printf("Invalid OAuth redirect URI for GitHub login: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -715,7 +643,7 @@ func (h *Handler) GithubCallback(c *gin.Context) {
 	}
 
 	// Parse and validate state
-	state, err := ParseOAuthState(encodedState)
+	state, err := ParseOAuthState(encodedState, h.redirectAllowlist)
 	if err != nil {
 		// Redirect to default with error
 		errorMsg := url.QueryEscape(fmt.Sprintf("Invalid state: %v", err))
@@ -724,11 +652,14 @@ func (h *Handler) GithubCallback(c *gin.Context) {
 		return
 	}
 
+	// Deliver the result the way the application expects (redirect, JSON or postMessage page)
+	mode := h.callbackMode(state.AppID)
+
 	code := c.Query("code")
 	if code == "" {
 		// Redirect to frontend with error
 		frontendURL := fmt.Sprintf("%s?error=authorization_code_missing", state.RedirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
@@ -743,29 +674,31 @@ func (h *Handler) GithubCallback(c *gin.Context) {
 		parsedAppID, err := uuid.Parse(state.AppID)
 		if err != nil {
 			frontendURL := fmt.Sprintf("%s?error=invalid_app_id_state", redirectURI)
-			c.Redirect(http.StatusFound, frontendURL)
+			respondCallback(c, mode, state.RedirectURI, frontendURL)
 			return
 		}
 		appID = parsedAppID
 	} else {
 		frontendURL := fmt.Sprintf("%s?error=app_id_missing", redirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
-	githubConfig, err := h.getGithubConfig(appID.String())
+	githubConfig, err := h.getGithubConfig(environment.ConfigAppID(c, appID).String())
 	if err != nil {
 		frontendURL := fmt.Sprintf("%s?error=config_error", redirectURI)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
+	// Environments sharing users resolve to the parent application's user base
+	appID = h.Environments.UserPoolAppID(appID)
 
 	token, err := githubConfig.Exchange(context.Background(), code)
 	if err != nil {
 		// Redirect to frontend with error
 		errorMsg := url.QueryEscape(fmt.Sprintf("Could not retrieve token: %v", err))
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
@@ -774,118 +707,153 @@ func (h *Handler) GithubCallback(c *gin.Context) {
 		// Redirect to frontend with error
 		errorMsg := url.QueryEscape(appErr.Message)
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
-	// Merge required — redirect so the frontend can prompt the user to confirm.
-	if result.RequiresMerge {
-		frontendURL := fmt.Sprintf("%s?requires_merge=true&merge_token=%s&provider=github&email=%s",
-			redirectURI,
-			url.QueryEscape(result.MergeToken),
-			url.QueryEscape(result.MergeEmail))
-		c.Redirect(http.StatusFound, frontendURL)
+	h.completeLogin(c, mode, state, appID, result, "github")
+}
+
+// OIDCLogin godoc
+// @Summary      OpenID Connect Login
+// @Description  Redirects user to the login page of a generic OpenID Connect provider (Okta, Auth0, Azure AD, Keycloak, ...) configured for the application. The provider's endpoints are taken from its discovery document.
+// @Tags         social
+// @Produce      json
+// @Param        provider     path  string true  "Provider name, as configured in the application's OAuth provider configs"
+// @Param        redirect_uri query string false "Frontend callback URL"
+// @Param        return_to    query string false "App page to return to after login (tokens are delivered in its URL fragment)"
+// @Success      307 {string} string "Redirect"
+// @Failure      404 {object} map[string]string
+// @Router       /auth/oidc/{provider}/login [get]
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	appIDVal, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "App ID missing from context"})
 		return
 	}
+	appID := appIDVal.(uuid.UUID)
+	configAppID := environment.ConfigAppID(c, appID)
 
-	userID := result.UserID
+	provider := c.Param("provider")
+	oidcConfig, _, err := h.getOIDCConfig(c.Request.Context(), configAppID.String(), provider)
+	if err != nil {
+		stdlog.Printf("Failed to get OpenID Connect config of %q for app %s: %v", provider, appID.String(), err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "OpenID Connect provider not found or not configured"})
+		return
+	}
 
-	// Fetch user to check 2FA status
-	user, err := h.Service.UserRepo.GetUserByID(userID.String())
+	// Get redirect URI from query parameter or use default
+	redirectURI := c.Query("redirect_uri")
+	if redirectURI == "" {
+		redirectURI = GetDefaultRedirectURI()
+	}
+
+	// Create signed state with redirect URI and optional return URL (relay state)
+	state, err := CreateOAuthState(redirectURI, c.Query("return_to"), configAppID.String(), h.redirectAllowlist(configAppID.String()))
 	if err != nil {
-		// Redirect to frontend with error
-		errorMsg := url.QueryEscape("Failed to fetch user for 2FA check")
-		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
+		stdlog.Printf("Invalid OAuth redirect URI for %s login: %v", provider, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid redirect URI",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, oidcConfig.AuthCodeURL(state))
+}
+
+// OIDCCallback godoc
+// @Summary      OpenID Connect Callback
+// @Description  Handles the callback of a generic OpenID Connect provider and returns JWT tokens
+// @Tags         social
+// @Produce      json
+// @Param        provider path  string true "Provider name"
+// @Param        state    query string true "State token"
+// @Param        code     query string true "Authorization code"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /auth/oidc/{provider}/callback [get]
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	encodedState := c.Query("state")
+	if encodedState == "" {
+		frontendURL := fmt.Sprintf("%s?error=missing_state", GetDefaultRedirectURI())
 		c.Redirect(http.StatusFound, frontendURL)
 		return
 	}
 
-	if user.TwoFAEnabled && h.Service.IsAppTwoFAEnabled(appID) {
-		// Trusted device check: if the client presents a valid trusted-device cookie
-		// matching this user + app, skip 2FA entirely and issue tokens immediately.
-		ipAddress, userAgent := util.GetClientInfo(c)
-		if h.ValidateTrustedDevice != nil {
-			if cookieToken, cookieErr := c.Cookie("trusted_device"); cookieErr == nil && cookieToken != "" {
-				if tdUserID, tdAppID, ok := h.ValidateTrustedDevice(cookieToken); ok &&
-					tdUserID == user.ID && tdAppID == appID {
-					// Check IP-based access rules before completing login
-					if !h.checkIPAccessRedirect(c, appID, ipAddress, userAgent, redirectURI) {
-						return
-					}
-					accessToken, refreshToken, sessionErr := h.Service.CreateSessionOrTokens(appID.String(), userID.String(), ipAddress, userAgent)
-					if sessionErr != nil {
-						errorMsg := url.QueryEscape(sessionErr.Message)
-						frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-						c.Redirect(http.StatusFound, frontendURL)
-						return
-					}
-					h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, "github")
-					frontendURL := fmt.Sprintf("%s?access_token=%s&refresh_token=%s&provider=github",
-						redirectURI,
-						url.QueryEscape(accessToken),
-						url.QueryEscape(refreshToken))
-					health.IncLoginSuccess(appID.String())
-					c.Redirect(http.StatusFound, frontendURL)
-					return
-				}
-			}
-		}
+	state, err := ParseOAuthState(encodedState, h.redirectAllowlist)
+	if err != nil {
+		errorMsg := url.QueryEscape(fmt.Sprintf("Invalid state: %v", err))
+		frontendURL := fmt.Sprintf("%s?error=%s", GetDefaultRedirectURI(), errorMsg)
+		c.Redirect(http.StatusFound, frontendURL)
+		return
+	}
 
-		tempToken := uuid.New().String()
-		err := redis.SetTempUserSession(appID.String(), tempToken, user.ID.String(), 10*time.Minute)
+	// Deliver the result the way the application expects (redirect, JSON or postMessage page)
+	mode := h.callbackMode(state.AppID)
+	redirectURI := state.RedirectURI
+
+	// The provider reports a denied or failed authorization in the error parameter
+	if providerErr := c.Query("error"); providerErr != "" {
+		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, url.QueryEscape(providerErr))
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		frontendURL := fmt.Sprintf("%s?error=authorization_code_missing", redirectURI)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
+		return
+	}
+
+	var appID uuid.UUID
+	appIDVal, exists := c.Get("app_id")
+	if exists {
+		appID = appIDVal.(uuid.UUID)
+	} else if state.AppID != "" {
+		parsedAppID, err := uuid.Parse(state.AppID)
 		if err != nil {
-			// Redirect to frontend with error
-			errorMsg := url.QueryEscape("Failed to create temporary session for 2FA")
-			frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-			c.Redirect(http.StatusFound, frontendURL)
+			frontendURL := fmt.Sprintf("%s?error=invalid_app_id_state", redirectURI)
+			respondCallback(c, mode, state.RedirectURI, frontendURL)
 			return
 		}
-		// Redirect with 2FA requirement — NO session created yet
-		// Include the user's configured 2FA method so the frontend can show the correct input
-		twoFAMethod := user.TwoFAMethod
-		if twoFAMethod == "" {
-			twoFAMethod = "totp"
-		}
-		// Auto-send SMS code if the user's 2FA method is SMS
-		if twoFAMethod == "sms" {
-			h.trySendSMSCode(appID, user.ID.String())
-		}
-		// Auto-send backup email code if the user's 2FA method is backup_email
-		if twoFAMethod == "backup_email" {
-			h.trySendBackupEmailCode(appID, user.ID.String())
-		}
-		redirectURL := fmt.Sprintf("%s?temp_token=%s&requires_2fa=true&provider=github&method=%s", redirectURI, tempToken, twoFAMethod)
-		c.Redirect(http.StatusFound, redirectURL)
+		appID = parsedAppID
+	} else {
+		frontendURL := fmt.Sprintf("%s?error=app_id_missing", redirectURI)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
-	// Only create session when 2FA is NOT required
-	ipAddress, userAgent := util.GetClientInfo(c)
-
-	// Check IP-based access rules before completing login
-	if !h.checkIPAccessRedirect(c, appID, ipAddress, userAgent, redirectURI) {
+	provider := c.Param("provider")
+	oidcConfig, providerConfig, err := h.getOIDCConfig(c.Request.Context(), environment.ConfigAppID(c, appID).String(), provider)
+	if err != nil {
+		stdlog.Printf("Failed to get OpenID Connect config of %q for app %s: %v", provider, appID.String(), err)
+		frontendURL := fmt.Sprintf("%s?error=config_error", redirectURI)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
+	// Environments sharing users resolve to the parent application's user base
+	appID = h.Environments.UserPoolAppID(appID)
 
-	accessToken, refreshToken, sessionErr := h.Service.CreateSessionOrTokens(appID.String(), userID.String(), ipAddress, userAgent)
-	if sessionErr != nil {
-		errorMsg := url.QueryEscape(sessionErr.Message)
+	token, err := oidcConfig.Exchange(c.Request.Context(), code)
+	if err != nil {
+		errorMsg := url.QueryEscape(fmt.Sprintf("Could not retrieve token: %v", err))
 		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
-		c.Redirect(http.StatusFound, frontendURL)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
 		return
 	}
 
-	// Log social login activity with anomaly detection
-	h.runSocialLoginAnomalyDetection(appID, userID, user.Email, ipAddress, userAgent, "github")
-
-	// Redirect to frontend with tokens in URL parameters
-	frontendURL := fmt.Sprintf("%s?access_token=%s&refresh_token=%s&provider=github",
-		redirectURI,
-		url.QueryEscape(accessToken),
-		url.QueryEscape(refreshToken))
+	result, appErr := h.Service.HandleOIDCCallback(appID, providerConfig, token.AccessToken)
+	if appErr != nil {
+		errorMsg := url.QueryEscape(appErr.Message)
+		frontendURL := fmt.Sprintf("%s?error=%s", redirectURI, errorMsg)
+		respondCallback(c, mode, state.RedirectURI, frontendURL)
+		return
+	}
 
-	health.IncLoginSuccess(appID.String())
-	c.Redirect(http.StatusFound, frontendURL)
+	h.completeLogin(c, mode, state, appID, result, provider)
 }
 
 // ListSocialAccounts godoc
@@ -912,21 +880,8 @@ func (h *Handler) ListSocialAccounts(c *gin.Context) {
 	}
 
 	socialAccounts := make([]dto.SocialAccountResponse, len(accounts))
-	for i, sa := range accounts {
-		socialAccounts[i] = dto.SocialAccountResponse{
-			ID:             sa.ID.String(),
-			Provider:       sa.Provider,
-			ProviderUserID: sa.ProviderUserID,
-			Email:          sa.Email,
-			Name:           sa.Name,
-			FirstName:      sa.FirstName,
-			LastName:       sa.LastName,
-			ProfilePicture: sa.ProfilePicture,
-			Username:       sa.Username,
-			Locale:         sa.Locale,
-			CreatedAt:      sa.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      sa.UpdatedAt.Format(time.RFC3339),
-		}
+	for i := range accounts {
+		socialAccounts[i] = socialAccountResponse(&accounts[i])
 	}
 
 	c.JSON(http.StatusOK, dto.SocialAccountListResponse{
@@ -934,6 +889,75 @@ func (h *Handler) ListSocialAccounts(c *gin.Context) {
 	})
 }
 
+// socialAccountResponse converts a social account to its API representation.
+func socialAccountResponse(sa *models.SocialAccount) dto.SocialAccountResponse {
+	resp := dto.SocialAccountResponse{
+		ID:             sa.ID.String(),
+		Provider:       sa.Provider,
+		ProviderUserID: sa.ProviderUserID,
+		Email:          sa.Email,
+		Name:           sa.Name,
+		FirstName:      sa.FirstName,
+		LastName:       sa.LastName,
+		ProfilePicture: sa.ProfilePicture,
+		Username:       sa.Username,
+		Locale:         sa.Locale,
+		CreatedAt:      sa.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      sa.UpdatedAt.Format(time.RFC3339),
+	}
+	if sa.LastSyncedAt != nil {
+		resp.LastSyncedAt = sa.LastSyncedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// SyncSocialAccount godoc
+// @Summary      Sync a social profile
+// @Description  Refreshes the name and avatar of the authenticated user from the linked account of a provider, using the stored provider access token. Requires the application's social profile sync policy to be "on_demand" or "scheduled".
+// @Tags         social
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        provider path string true "Provider (google, facebook, github or the name of an OpenID Connect provider)"
+// @Success      200 {object} dto.SyncSocialAccountResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse "Profile sync is not enabled for the application"
+// @Failure      404 {object} dto.ErrorResponse "No account of the provider is linked"
+// @Failure      409 {object} dto.ErrorResponse "The stored access token expired; sign in with the provider again"
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /profile/social-accounts/{provider}/sync [post]
+func (h *Handler) SyncSocialAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	appIDVal, appIDExists := c.Get("app_id")
+	if !appIDExists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "App ID missing from context"})
+		return
+	}
+	appID := appIDVal.(uuid.UUID)
+
+	provider := c.Param("provider")
+	if !models.IsOIDCProviderName(provider) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported provider"})
+		return
+	}
+
+	account, appErr := h.Service.SyncSocialProfile(c.Request.Context(), appID, userID.(string), provider)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{"error": appErr.Message})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SyncSocialAccountResponse{
+		Message: "Social profile synced successfully",
+		Account: socialAccountResponse(account),
+	})
+}
+
 // UnlinkSocialAccount godoc
 // @Summary      Unlink a social account
 // @Description  Removes a linked social account from the authenticated user's profile
@@ -1000,6 +1024,7 @@ func (h *Handler) GoogleLink(c *gin.Context) {
 		return
 	}
 	appID := appIDVal.(uuid.UUID)
+	configAppID := environment.ConfigAppID(c, appID)
 
 	userID, userExists := c.Get("userID")
 	if !userExists {
@@ -1007,7 +1032,7 @@ func (h *Handler) GoogleLink(c *gin.Context) {
 		return
 	}
 
-	googleConfig, err := h.getGoogleConfig(appID.String())
+	googleConfig, err := h.getGoogleConfig(configAppID.String())
 	if err != nil {
 		stdlog.Printf("Failed to get Google OAuth config for app %s: %v", appID.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth configuration error"})
@@ -1019,7 +1044,7 @@ func (h *Handler) GoogleLink(c *gin.Context) {
 		redirectURI = GetDefaultRedirectURI()
 	}
 
-	state, err := CreateOAuthLinkState(redirectURI, appID.String(), userID.(string))
+	state, err := CreateOAuthLinkState(redirectURI, configAppID.String(), userID.(string), h.redirectAllowlist(configAppID.String()))
 	if err != nil {
 		stdlog.Printf("Invalid OAuth redirect URI for Google link: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redirect URI"})
@@ -1048,7 +1073,7 @@ func (h *Handler) GoogleLinkCallback(c *gin.Context) {
 		return
 	}
 
-	state, err := ParseOAuthState(encodedState)
+	state, err := ParseOAuthState(encodedState, h.redirectAllowlist)
 	if err != nil {
 		errorMsg := url.QueryEscape(fmt.Sprintf("Invalid state: %v", err))
 		frontendURL := fmt.Sprintf("%s?error=%s", GetDefaultRedirectURI(), errorMsg)
@@ -1086,12 +1111,14 @@ func (h *Handler) GoogleLinkCallback(c *gin.Context) {
 		return
 	}
 
-	googleConfig, err := h.getGoogleConfig(appID.String())
+	googleConfig, err := h.getGoogleConfig(environment.ConfigAppID(c, appID).String())
 	if err != nil {
 		frontendURL := fmt.Sprintf("%s?error=config_error", redirectURI)
 		c.Redirect(http.StatusFound, frontendURL)
 		return
 	}
+	// Environments sharing users resolve to the parent application's user base
+	appID = h.Environments.UserPoolAppID(appID)
 
 	token, err := googleConfig.Exchange(context.Background(), code)
 	if err != nil {
@@ -1136,6 +1163,7 @@ func (h *Handler) FacebookLink(c *gin.Context) {
 		return
 	}
 	appID := appIDVal.(uuid.UUID)
+	configAppID := environment.ConfigAppID(c, appID)
 
 	userID, userExists := c.Get("userID")
 	if !userExists {
@@ -1143,7 +1171,7 @@ func (h *Handler) FacebookLink(c *gin.Context) {
 		return
 	}
 
-	facebookConfig, err := h.getFacebookConfig(appID.String())
+	facebookConfig, err := h.getFacebookConfig(configAppID.String())
 	if err != nil {
 		stdlog.Printf("Failed to get Facebook OAuth config for app %s: %v", appID.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth configuration error"})
@@ -1155,7 +1183,7 @@ func (h *Handler) FacebookLink(c *gin.Context) {
 		redirectURI = GetDefaultRedirectURI()
 	}
 
-	state, err := CreateOAuthLinkState(redirectURI, appID.String(), userID.(string))
+	state, err := CreateOAuthLinkState(redirectURI, configAppID.String(), userID.(string), h.redirectAllowlist(configAppID.String()))
 	if err != nil {
 		stdlog.Printf("Invalid OAuth redirect URI for Facebook link: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redirect URI"})
@@ -1184,7 +1212,7 @@ func (h *Handler) FacebookLinkCallback(c *gin.Context) {
 		return
 	}
 
-	state, err := ParseOAuthState(encodedState)
+	state, err := ParseOAuthState(encodedState, h.redirectAllowlist)
 	if err != nil {
 		errorMsg := url.QueryEscape(fmt.Sprintf("Invalid state: %v", err))
 		frontendURL := fmt.Sprintf("%s?error=%s", GetDefaultRedirectURI(), errorMsg)
@@ -1222,12 +1250,14 @@ func (h *Handler) FacebookLinkCallback(c *gin.Context) {
 		return
 	}
 
-	facebookConfig, err := h.getFacebookConfig(appID.String())
+	facebookConfig, err := h.getFacebookConfig(environment.ConfigAppID(c, appID).String())
 	if err != nil {
 		frontendURL := fmt.Sprintf("%s?error=config_error", redirectURI)
 		c.Redirect(http.StatusFound, frontendURL)
 		return
 	}
+	// Environments sharing users resolve to the parent application's user base
+	appID = h.Environments.UserPoolAppID(appID)
 
 	token, err := facebookConfig.Exchange(context.Background(), code)
 	if err != nil {
@@ -1272,6 +1302,7 @@ func (h *Handler) GithubLink(c *gin.Context) {
 		return
 	}
 	appID := appIDVal.(uuid.UUID)
+	configAppID := environment.ConfigAppID(c, appID)
 
 	userID, userExists := c.Get("userID")
 	if !userExists {
@@ -1279,7 +1310,7 @@ func (h *Handler) GithubLink(c *gin.Context) {
 		return
 	}
 
-	githubConfig, err := h.getGithubConfig(appID.String())
+	githubConfig, err := h.getGithubConfig(configAppID.String())
 	if err != nil {
 		stdlog.Printf("Failed to get GitHub OAuth config for app %s: %v", appID.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth configuration error"})
@@ -1291,7 +1322,7 @@ func (h *Handler) GithubLink(c *gin.Context) {
 		redirectURI = GetDefaultRedirectURI()
 	}
 
-	state, err := CreateOAuthLinkState(redirectURI, appID.String(), userID.(string))
+	state, err := CreateOAuthLinkState(redirectURI, configAppID.String(), userID.(string), h.redirectAllowlist(configAppID.String()))
 	if err != nil {
 		stdlog.Printf("Invalid OAuth redirect URI for GitHub link: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redirect URI"})
@@ -1320,7 +1351,7 @@ func (h *Handler) GithubLinkCallback(c *gin.Context) {
 		return
 	}
 
-	state, err := ParseOAuthState(encodedState)
+	state, err := ParseOAuthState(encodedState, h.redirectAllowlist)
 	if err != nil {
 		errorMsg := url.QueryEscape(fmt.Sprintf("Invalid state: %v", err))
 		frontendURL := fmt.Sprintf("%s?error=%s", GetDefaultRedirectURI(), errorMsg)
@@ -1358,12 +1389,14 @@ func (h *Handler) GithubLinkCallback(c *gin.Context) {
 		return
 	}
 
-	githubConfig, err := h.getGithubConfig(appID.String())
+	githubConfig, err := h.getGithubConfig(environment.ConfigAppID(c, appID).String())
 	if err != nil {
 		frontendURL := fmt.Sprintf("%s?error=config_error", redirectURI)
 		c.Redirect(http.StatusFound, frontendURL)
 		return
 	}
+	// Environments sharing users resolve to the parent application's user base
+	appID = h.Environments.UserPoolAppID(appID)
 
 	token, err := githubConfig.Exchange(context.Background(), code)
 	if err != nil {
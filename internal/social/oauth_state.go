@@ -1,7 +1,9 @@
 package social
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -9,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/util"
 	"github.com/spf13/viper"
 )
 
@@ -18,8 +21,9 @@ type OAuthState struct {
 	AppID       string    `json:"app_id"`
 	Nonce       string    `json:"nonce"`
 	Timestamp   time.Time `json:"timestamp"`
-	UserID      string    `json:"user_id,omitempty"` // Set when linking a social account to an authenticated user
-	Flow        string    `json:"flow,omitempty"`    // "login" (default) or "link"
+	UserID      string    `json:"user_id,omitempty"`   // Set when linking a social account to an authenticated user
+	Flow        string    `json:"flow,omitempty"`      // "login" (default) or "link"
+	ReturnTo    string    `json:"return_to,omitempty"` // Relay state: app page to return the user to after login
 }
 
 // generateRandomString generates a cryptographically secure random string
@@ -31,55 +35,45 @@ func generateRandomString(length int) (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes)[:length], nil
 }
 
-// IsAllowedRedirectURI validates if the redirect URI is allowed
-func IsAllowedRedirectURI(redirectURI string) bool {
-	if redirectURI == "" {
-		return false
-	}
+// defaultRedirectDomains are allowed for development when ALLOWED_REDIRECT_DOMAINS is not set.
+var defaultRedirectDomains = []string{
+	"localhost:3000",
+	"localhost:5173",
+	"localhost:5174",
+	"localhost:5175",
+	"localhost:8080",
+	"127.0.0.1:3000",
+	"127.0.0.1:5173",
+	"127.0.0.1:8080",
+}
 
-	// Parse the URL to validate it
-	parsedURL, err := url.Parse(redirectURI)
-	if err != nil {
-		return false
+// IsAllowedRedirectURI validates if the redirect URI is allowed. When the
+// application has its own redirect allowlist (appAllowlist) only that list
+// applies; otherwise the global ALLOWED_REDIRECT_DOMAINS are used.
+func IsAllowedRedirectURI(redirectURI string, appAllowlist []string) bool {
+	if len(appAllowlist) > 0 {
+		return util.IsURLAllowed(redirectURI, appAllowlist)
 	}
 
 	// Get allowed domains from environment
 	allowedDomains := viper.GetStringSlice("ALLOWED_REDIRECT_DOMAINS")
 	if len(allowedDomains) == 0 {
-		// Default allowed domains for development
-		allowedDomains = []string{
-			"localhost:3000",
-			"localhost:5173",
-			"localhost:5174",
-			"localhost:5175",
-			"localhost:8080",
-			"127.0.0.1:3000",
-			"127.0.0.1:5173",
-			"127.0.0.1:8080",
-		}
-	}
-
-	// Check if the host is in the allowed list
-	host := parsedURL.Host
-	for _, allowedDomain := range allowedDomains {
-		if host == allowedDomain {
-			return true
-		}
-		// Allow subdomains if domain starts with a dot (e.g., ".example.com")
-		if strings.HasPrefix(allowedDomain, ".") && strings.HasSuffix(host, allowedDomain) {
-			return true
-		}
-	}
-
-	return false
+		allowedDomains = defaultRedirectDomains
+	}
+	return util.IsURLAllowed(redirectURI, allowedDomains)
 }
 
-// CreateOAuthState creates a secure state parameter with redirect URI
-func CreateOAuthState(redirectURI string, appID string) (string, error) {
+// CreateOAuthState creates a signed state parameter with redirect URI. returnTo
+// is the optional page of the app the user started from; like the redirect URI
+// it must be covered by the redirect allowlist.
+func CreateOAuthState(redirectURI string, returnTo string, appID string, appAllowlist []string) (string, error) {
 	// Validate redirect URI
-	if !IsAllowedRedirectURI(redirectURI) {
+	if !IsAllowedRedirectURI(redirectURI, appAllowlist) {
 		return "", fmt.Errorf("redirect URI not allowed: %s", redirectURI)
 	}
+	if returnTo != "" && !IsAllowedRedirectURI(returnTo, appAllowlist) {
+		return "", fmt.Errorf("return URL not allowed: %s", returnTo)
+	}
 
 	// Generate a random nonce
 	nonce, err := generateRandomString(16)
@@ -93,23 +87,47 @@ func CreateOAuthState(redirectURI string, appID string) (string, error) {
 		AppID:       appID,
 		Nonce:       nonce,
 		Timestamp:   time.Now(),
+		ReturnTo:    returnTo,
 	}
+	return encodeOAuthState(state)
+}
 
-	// Encode state as JSON
+// encodeOAuthState serializes the state as base64(JSON) followed by an
+// HMAC-SHA256 signature keyed with JWT_SECRET, so the redirect URI and relay
+// state cannot be altered while the user is at the provider.
+func encodeOAuthState(state OAuthState) (string, error) {
 	stateJSON, err := json.Marshal(state)
 	if err != nil {
 		return "", err
 	}
+	payload := base64.URLEncoding.EncodeToString(stateJSON)
+	return payload + "." + signOAuthState(payload), nil
+}
 
-	// Base64 encode the state
-	encodedState := base64.URLEncoding.EncodeToString(stateJSON)
-	return encodedState, nil
+// signOAuthState returns the base64url HMAC-SHA256 signature of an encoded state payload.
+func signOAuthState(payload string) string {
+	mac := hmac.New(sha256.New, []byte(viper.GetString("JWT_SECRET")))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
 
-// ParseOAuthState parses and validates the OAuth state parameter
-func ParseOAuthState(encodedState string) (*OAuthState, error) {
+// ParseOAuthState parses and validates the OAuth state parameter. The
+// signature is verified first; the redirect URI and return URL are then checked
+// again against the current allowlist of the application named in the state
+// (allowlistFor returns that list), so allowlist changes apply immediately.
+func ParseOAuthState(encodedState string, allowlistFor func(appID string) []string) (*OAuthState, error) {
+	// Verify signature
+	dotIdx := strings.LastIndex(encodedState, ".")
+	if dotIdx < 0 {
+		return nil, fmt.Errorf("invalid state format: missing signature")
+	}
+	payload := encodedState[:dotIdx]
+	if !hmac.Equal([]byte(encodedState[dotIdx+1:]), []byte(signOAuthState(payload))) {
+		return nil, fmt.Errorf("invalid state signature")
+	}
+
 	// Base64 decode
-	stateJSON, err := base64.URLEncoding.DecodeString(encodedState)
+	stateJSON, err := base64.URLEncoding.DecodeString(payload)
 	if err != nil {
 		return nil, fmt.Errorf("invalid state encoding: %v", err)
 	}
@@ -125,14 +143,52 @@ func ParseOAuthState(encodedState string) (*OAuthState, error) {
 		return nil, fmt.Errorf("state has expired")
 	}
 
-	// Validate redirect URI again
-	if !IsAllowedRedirectURI(state.RedirectURI) {
+	// Validate redirect URI and return URL again
+	allowlist := allowlistFor(state.AppID)
+	if !IsAllowedRedirectURI(state.RedirectURI, allowlist) {
 		return nil, fmt.Errorf("redirect URI not allowed: %s", state.RedirectURI)
 	}
+	if state.ReturnTo != "" && !IsAllowedRedirectURI(state.ReturnTo, allowlist) {
+		return nil, fmt.Errorf("return URL not allowed: %s", state.ReturnTo)
+	}
 
 	return &state, nil
 }
 
+// LoginRedirectURL builds the URL the user is sent to once social login has
+// issued tokens. Without a return URL the tokens are appended to the redirect
+// URI as query parameters. With a return URL the user goes straight back to
+// that page and the tokens are put in the URL fragment, which browsers never
+// send to servers or in Referer headers.
+func (s *OAuthState) LoginRedirectURL(accessToken, refreshToken, provider string) string {
+	if s.ReturnTo == "" {
+		return fmt.Sprintf("%s?access_token=%s&refresh_token=%s&provider=%s",
+			s.RedirectURI,
+			url.QueryEscape(accessToken),
+			url.QueryEscape(refreshToken),
+			provider)
+	}
+	base, _, _ := strings.Cut(s.ReturnTo, "#")
+	params := url.Values{}
+	params.Set("access_token", accessToken)
+	params.Set("refresh_token", refreshToken)
+	params.Set("provider", provider)
+	return base + "#" + params.Encode()
+}
+
+// WithReturnTo appends the relay state to an intermediate redirect (2FA
+// challenge, merge confirmation) so the frontend can resume it afterwards.
+func (s *OAuthState) WithReturnTo(redirectURL string) string {
+	if s.ReturnTo == "" {
+		return redirectURL
+	}
+	sep := "?"
+	if strings.Contains(redirectURL, "?") {
+		sep = "&"
+	}
+	return redirectURL + sep + "return_to=" + url.QueryEscape(s.ReturnTo)
+}
+
 // GetDefaultRedirectURI returns the default redirect URI for fallback
 func GetDefaultRedirectURI() string {
 	defaultURI := viper.GetString("DEFAULT_REDIRECT_URI")
@@ -145,9 +201,9 @@ func GetDefaultRedirectURI() string {
 
 // CreateOAuthLinkState creates a secure state parameter for the account linking flow.
 // It embeds the authenticated user's ID so the link callback can associate the provider.
-func CreateOAuthLinkState(redirectURI string, appID string, userID string) (string, error) {
+func CreateOAuthLinkState(redirectURI string, appID string, userID string, appAllowlist []string) (string, error) {
 	// Validate redirect URI
-	if !IsAllowedRedirectURI(redirectURI) {
+	if !IsAllowedRedirectURI(redirectURI, appAllowlist) {
 		return "", fmt.Errorf("redirect URI not allowed: %s", redirectURI)
 	}
 
@@ -167,13 +223,5 @@ func CreateOAuthLinkState(redirectURI string, appID string, userID string) (stri
 		Flow:        "link",
 	}
 
-	// Encode state as JSON
-	stateJSON, err := json.Marshal(state)
-	if err != nil {
-		return "", err
-	}
-
-	// Base64 encode the state
-	encodedState := base64.URLEncoding.EncodeToString(stateJSON)
-	return encodedState, nil
+	return encodeOAuthState(state)
 }
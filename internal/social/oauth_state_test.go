@@ -0,0 +1,67 @@
+package social
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestOAuthStateRelay(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+	allowlist := []string{"app.example.com"}
+	allowlistFor := func(string) []string { return allowlist }
+
+	encoded, err := CreateOAuthState("https://app.example.com/callback", "https://app.example.com/orders/42#tab", "app-1", allowlist)
+	if err != nil {
+		t.Fatalf("CreateOAuthState: %v", err)
+	}
+	state, err := ParseOAuthState(encoded, allowlistFor)
+	if err != nil {
+		t.Fatalf("ParseOAuthState: %v", err)
+	}
+
+	got := state.LoginRedirectURL("a.b.c", "r+1", "google")
+	want := "https://app.example.com/orders/42#access_token=a.b.c&provider=google&refresh_token=r%2B1"
+	if got != want {
+		t.Errorf("LoginRedirectURL = %q, want %q", got, want)
+	}
+	if got := state.WithReturnTo("https://app.example.com/callback?requires_2fa=true"); !strings.HasSuffix(got, "&return_to=https%3A%2F%2Fapp.example.com%2Forders%2F42%23tab") {
+		t.Errorf("WithReturnTo = %q", got)
+	}
+
+	if _, err := CreateOAuthState("https://app.example.com/callback", "https://evil.com/", "app-1", allowlist); err == nil {
+		t.Error("return URL outside the allowlist was accepted")
+	}
+}
+
+func TestParseOAuthStateRejectsTampering(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+	allowlistFor := func(string) []string { return []string{"app.example.com"} }
+
+	encoded, err := CreateOAuthState("https://app.example.com/callback", "", "app-1", allowlistFor(""))
+	if err != nil {
+		t.Fatalf("CreateOAuthState: %v", err)
+	}
+	payload, sig, _ := strings.Cut(encoded, ".")
+
+	for name, state := range map[string]string{
+		"unsigned":      payload,
+		"bad signature": payload + "." + strings.Repeat("A", len(sig)),
+		"other payload": "e30=." + sig,
+	} {
+		if _, err := ParseOAuthState(state, allowlistFor); err == nil {
+			t.Errorf("%s state was accepted", name)
+		}
+	}
+
+	state, err := ParseOAuthState(encoded, allowlistFor)
+	if err != nil {
+		t.Fatalf("ParseOAuthState: %v", err)
+	}
+	if got := state.LoginRedirectURL("tok", "ref", "github"); got != "https://app.example.com/callback?access_token=tok&refresh_token=ref&provider=github" {
+		t.Errorf("LoginRedirectURL without relay state = %q", got)
+	}
+}
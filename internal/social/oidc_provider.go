@@ -0,0 +1,203 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"golang.org/x/oauth2"
+)
+
+// oidcDiscoveryTTL bounds how long a provider's discovery document is reused,
+// and so how long an endpoint change takes to reach sign-ins.
+const oidcDiscoveryTTL = time.Hour
+
+// oidcDiscovery is the part of an OpenID Connect discovery document used to
+// sign in with a generic provider.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+type oidcDiscoveryEntry struct {
+	doc     *oidcDiscovery
+	expires time.Time
+}
+
+// oidcDiscoveryCache holds the discovery documents by issuer URL.
+var oidcDiscoveryCache = struct {
+	sync.Mutex
+	entries map[string]oidcDiscoveryEntry
+}{entries: make(map[string]oidcDiscoveryEntry)}
+
+// discoverOIDC returns the discovery document of the OpenID Connect provider
+// issuer, fetched from its /.well-known/openid-configuration.
+func discoverOIDC(ctx context.Context, issuer string) (*oidcDiscovery, error) {
+	now := time.Now()
+	oidcDiscoveryCache.Lock()
+	if e, ok := oidcDiscoveryCache.entries[issuer]; ok && now.Before(e.expires) {
+		oidcDiscoveryCache.Unlock()
+		return e.doc, nil
+	}
+	oidcDiscoveryCache.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	// #nosec G107,G704 -- The issuer URL is set by an administrator in the provider config
+	resp, err := profileClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OpenID Connect discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenID Connect discovery request failed with HTTP %d", resp.StatusCode)
+	}
+	var doc oidcDiscovery
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenID Connect discovery document: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", doc.Issuer, issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("discovery document of %s lacks the authorization, token or userinfo endpoint", issuer)
+	}
+
+	oidcDiscoveryCache.Lock()
+	oidcDiscoveryCache.entries[issuer] = oidcDiscoveryEntry{doc: &doc, expires: now.Add(oidcDiscoveryTTL)}
+	oidcDiscoveryCache.Unlock()
+	return &doc, nil
+}
+
+// oidcBool is a boolean claim. Some providers (e.g. Cognito) send
+// email_verified as the string "true".
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = oidcBool(s == "true")
+	return nil
+}
+
+// oidcUserInfo holds the standard claims of a UserInfo response.
+type oidcUserInfo struct {
+	Sub               string   `json:"sub"`
+	Email             string   `json:"email"`
+	EmailVerified     oidcBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	GivenName         string   `json:"given_name"`
+	FamilyName        string   `json:"family_name"`
+	Picture           string   `json:"picture"`
+	PreferredUsername string   `json:"preferred_username"`
+	Locale            string   `json:"locale"`
+}
+
+// fetchOIDCUserInfo fetches the claims of the user the access token belongs
+// to from a UserInfo endpoint, and returns them with the raw response. It
+// returns errTokenRejected when the provider does not accept the token.
+func fetchOIDCUserInfo(ctx context.Context, userinfoURL, accessToken string) (*oidcUserInfo, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, userinfoURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	// #nosec G107,G704 -- The URL comes from the discovery document of the configured issuer
+	resp, err := profileClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, nil, errTokenRejected
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("userinfo request failed with HTTP %d", resp.StatusCode)
+	}
+	var info oidcUserInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse userinfo response: %w", err)
+	}
+	if info.Sub == "" {
+		return nil, nil, fmt.Errorf("userinfo response has no sub claim")
+	}
+	return &info, data, nil
+}
+
+// getOIDCConfig returns the OAuth2 config of the generic OpenID Connect
+// provider named provider for the app, with the endpoints of its discovery
+// document, and the provider config it was built from.
+func (h *Handler) getOIDCConfig(ctx context.Context, appID, provider string) (*oauth2.Config, *models.OAuthProviderConfig, error) {
+	if models.IsBuiltinOAuthProvider(provider) || !models.IsOIDCProviderName(provider) {
+		return nil, nil, fmt.Errorf("%q is not an OpenID Connect provider", provider)
+	}
+	config, err := h.Service.SocialRepo.GetOAuthProviderConfig(appID, provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !config.IsEnabled {
+		return nil, nil, fmt.Errorf("%s login is disabled for this app", provider)
+	}
+	if config.IssuerURL == "" {
+		return nil, nil, fmt.Errorf("%s has no issuer URL", provider)
+	}
+	doc, err := discoverOIDC(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &oauth2.Config{
+		RedirectURL:  config.RedirectURL,
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		Scopes:       config.OIDCScopes(),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+	}, config, nil
+}
+
+// fetchOIDCProfile fetches the current profile of a social account of a
+// generic OpenID Connect provider from the provider's UserInfo endpoint.
+func (s *Service) fetchOIDCProfile(ctx context.Context, account *models.SocialAccount) (*providerProfile, error) {
+	config, err := s.SocialRepo.GetOAuthProviderConfig(account.AppID.String(), account.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s config: %w", account.Provider, err)
+	}
+	if config.IssuerURL == "" {
+		return nil, fmt.Errorf("%s has no issuer URL", account.Provider)
+	}
+	doc, err := discoverOIDC(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	info, data, err := fetchOIDCUserInfo(ctx, doc.UserinfoEndpoint, account.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &providerProfile{
+		ProviderUserID: info.Sub,
+		Name:           info.Name,
+		FirstName:      info.GivenName,
+		LastName:       info.FamilyName,
+		Picture:        info.Picture,
+		Username:       info.PreferredUsername,
+		Locale:         info.Locale,
+		Raw:            data,
+	}, nil
+}
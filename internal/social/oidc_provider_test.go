@@ -0,0 +1,83 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscoverOIDC(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		base := "http://" + r.Host
+		switch r.URL.Path {
+		case "/good/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":"%s/good/","authorization_endpoint":"%s/authorize","token_endpoint":"%s/token","userinfo_endpoint":"%s/userinfo"}`, base, base, base, base)
+		case "/other/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":"https://attacker.example.com","authorization_endpoint":"%s/authorize","token_endpoint":"%s/token","userinfo_endpoint":"%s/userinfo"}`, base, base, base)
+		case "/partial/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":"%s/partial","authorization_endpoint":"%s/authorize","token_endpoint":"%s/token"}`, base, base, base)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	doc, err := discoverOIDC(context.Background(), srv.URL+"/good")
+	if err != nil {
+		t.Fatalf("discoverOIDC: %v", err)
+	}
+	if doc.TokenEndpoint != srv.URL+"/token" || doc.UserinfoEndpoint != srv.URL+"/userinfo" {
+		t.Errorf("got %+v", doc)
+	}
+	if _, err := discoverOIDC(context.Background(), srv.URL+"/good"); err != nil || requests != 1 {
+		t.Errorf("second lookup: err = %v, %d requests, want 1 (cached)", err, requests)
+	}
+
+	for _, issuer := range []string{"/other", "/partial", "/missing"} {
+		if _, err := discoverOIDC(context.Background(), srv.URL+issuer); err == nil {
+			t.Errorf("%s: expected an error", issuer)
+		}
+	}
+}
+
+func TestFetchOIDCUserInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			fmt.Fprint(w, `{"sub":"00u1","email":"jane@example.com","email_verified":true,"name":"Jane Doe","given_name":"Jane","family_name":"Doe","preferred_username":"jdoe","groups":["staff"]}`)
+		case "Bearer string-verified":
+			fmt.Fprint(w, `{"sub":"00u2","email":"joe@example.com","email_verified":"true"}`)
+		case "Bearer no-sub":
+			fmt.Fprint(w, `{"email":"joe@example.com"}`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	info, raw, err := fetchOIDCUserInfo(context.Background(), srv.URL, "good")
+	if err != nil {
+		t.Fatalf("good token: %v", err)
+	}
+	if info.Sub != "00u1" || info.Email != "jane@example.com" || !info.EmailVerified || info.GivenName != "Jane" || info.PreferredUsername != "jdoe" {
+		t.Errorf("got %+v", info)
+	}
+	if len(raw) == 0 {
+		t.Error("raw claims are empty")
+	}
+
+	info, _, err = fetchOIDCUserInfo(context.Background(), srv.URL, "string-verified")
+	if err != nil || !info.EmailVerified {
+		t.Errorf("email_verified as a string: info = %+v, err = %v", info, err)
+	}
+	if _, _, err := fetchOIDCUserInfo(context.Background(), srv.URL, "no-sub"); err == nil {
+		t.Error("response without sub: expected an error")
+	}
+	if _, _, err := fetchOIDCUserInfo(context.Background(), srv.URL, "expired"); err != errTokenRejected {
+		t.Errorf("expired token: err = %v, want errTokenRejected", err)
+	}
+}
@@ -0,0 +1,291 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Profile sync policies — when the profile data of linked social accounts is
+// refreshed from the provider. Configured per app.
+const (
+	ProfileSyncLogin     = "login"     // Only when the user signs in with the provider (default)
+	ProfileSyncOnDemand  = "on_demand" // Also via POST /profile/social-accounts/:provider/sync
+	ProfileSyncScheduled = "scheduled" // Also by the social_profile_sync job
+)
+
+// IsValidProfileSync reports whether policy is a supported profile sync policy.
+func IsValidProfileSync(policy string) bool {
+	switch policy {
+	case ProfileSyncLogin, ProfileSyncOnDemand, ProfileSyncScheduled:
+		return true
+	}
+	return false
+}
+
+// profileSyncBatchSize caps the social accounts synced per scheduled run.
+const profileSyncBatchSize = 200
+
+// Provider profile endpoints (variables so tests can point them at a fake provider)
+var (
+	googleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
+	facebookMeURL     = "https://graph.facebook.com/v18.0/me"
+	githubUserURL     = "https://api.github.com/user"
+)
+
+var profileClient = &http.Client{Timeout: 10 * time.Second}
+
+// errTokenRejected is returned when the provider no longer accepts the stored
+// access token (expired or revoked), so only a new sign-in can refresh the profile.
+var errTokenRejected = fmt.Errorf("provider rejected the stored access token")
+
+// providerProfile is the profile data a provider returns for an access token.
+type providerProfile struct {
+	ProviderUserID string
+	Name           string
+	FirstName      string
+	LastName       string
+	Picture        string
+	Username       string
+	Locale         string
+	Raw            json.RawMessage
+}
+
+// fetchProviderProfile fetches the current profile of the user the access token belongs to.
+func fetchProviderProfile(ctx context.Context, provider, accessToken string) (*providerProfile, error) {
+	var req *http.Request
+	var err error
+	switch provider {
+	case "google":
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL+"?access_token="+url.QueryEscape(accessToken), nil)
+	case "facebook":
+		q := url.Values{
+			"fields":       {"id,name,first_name,last_name,picture.type(large),locale"},
+			"access_token": {accessToken},
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, facebookMeURL+"?"+q.Encode(), nil)
+	case "github":
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, githubUserURL, nil)
+		if err == nil {
+			req.Header.Set("Authorization", "token "+accessToken)
+		}
+	default:
+		return nil, fmt.Errorf("profile sync is not supported for provider %q", provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G107,G704 -- The URLs are the providers' fixed profile endpoints
+	resp, err := profileClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	// Facebook reports expired tokens as 400 OAuthException
+	if resp.StatusCode == http.StatusUnauthorized || (provider == "facebook" && resp.StatusCode == http.StatusBadRequest) {
+		return nil, errTokenRejected
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s profile request failed with HTTP %d", provider, resp.StatusCode)
+	}
+
+	p := &providerProfile{Raw: data}
+	switch provider {
+	case "google":
+		var u struct {
+			ID         string `json:"id"`
+			Name       string `json:"name"`
+			GivenName  string `json:"given_name"`
+			FamilyName string `json:"family_name"`
+			Picture    string `json:"picture"`
+			Locale     string `json:"locale"`
+		}
+		err = json.Unmarshal(data, &u)
+		p.ProviderUserID, p.Name, p.FirstName, p.LastName, p.Picture, p.Locale = u.ID, u.Name, u.GivenName, u.FamilyName, u.Picture, u.Locale
+	case "facebook":
+		var u struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+			Picture   struct {
+				Data struct {
+					URL string `json:"url"`
+				} `json:"data"`
+			} `json:"picture"`
+			Locale string `json:"locale"`
+		}
+		err = json.Unmarshal(data, &u)
+		p.ProviderUserID, p.Name, p.FirstName, p.LastName, p.Picture, p.Locale = u.ID, u.Name, u.FirstName, u.LastName, u.Picture.Data.URL, u.Locale
+	case "github":
+		var u struct {
+			ID        int64  `json:"id"`
+			Login     string `json:"login"`
+			Name      string `json:"name"`
+			AvatarURL string `json:"avatar_url"`
+		}
+		err = json.Unmarshal(data, &u)
+		p.ProviderUserID, p.Name, p.Picture, p.Username = strconv.FormatInt(u.ID, 10), u.Name, u.AvatarURL, u.Login
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s profile: %w", provider, err)
+	}
+	return p, nil
+}
+
+// applyProfile copies the fetched profile onto the social account and returns
+// the user profile fields to update: the non-empty ones that differ.
+func applyProfile(account *models.SocialAccount, u *models.User, p *providerProfile) map[string]interface{} {
+	account.Name = p.Name
+	account.ProfilePicture = p.Picture
+	if p.FirstName != "" || p.LastName != "" {
+		account.FirstName = p.FirstName
+		account.LastName = p.LastName
+	}
+	if p.Username != "" {
+		account.Username = p.Username
+	}
+	if p.Locale != "" {
+		account.Locale = p.Locale
+	}
+	account.RawData = datatypes.JSON(p.Raw)
+
+	changes := map[string]interface{}{}
+	for column, field := range map[string][2]string{
+		"name":            {u.Name, p.Name},
+		"first_name":      {u.FirstName, p.FirstName},
+		"last_name":       {u.LastName, p.LastName},
+		"profile_picture": {u.ProfilePicture, p.Picture},
+		"locale":          {u.Locale, p.Locale},
+	} {
+		if current, synced := field[0], field[1]; synced != "" && synced != current {
+			changes[column] = synced
+		}
+	}
+	return changes
+}
+
+// syncAccount refreshes a social account and its user's profile from the provider.
+func (s *Service) syncAccount(ctx context.Context, account *models.SocialAccount) error {
+	if account.AccessToken == "" || (account.ExpiresAt != nil && account.ExpiresAt.Before(time.Now())) {
+		return errTokenRejected
+	}
+	var p *providerProfile
+	var err error
+	if models.IsBuiltinOAuthProvider(account.Provider) {
+		p, err = fetchProviderProfile(ctx, account.Provider, account.AccessToken)
+	} else {
+		p, err = s.fetchOIDCProfile(ctx, account)
+	}
+	if err != nil {
+		return err
+	}
+	if p.ProviderUserID != account.ProviderUserID {
+		// The token belongs to a different provider account
+		return errTokenRejected
+	}
+
+	u, err := s.UserRepo.GetUserByID(account.UserID.String())
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	changes := applyProfile(account, u, p)
+	now := time.Now()
+	account.LastSyncedAt = &now
+	if err := s.updateSocialAccount(account); err != nil {
+		return fmt.Errorf("failed to update social account: %w", err)
+	}
+	if len(changes) > 0 {
+		if err := s.UserRepo.DB.Model(&models.User{}).Where("id = ?", u.ID).Updates(changes).Error; err != nil {
+			return fmt.Errorf("failed to update user profile: %w", err)
+		}
+	}
+	return nil
+}
+
+// SyncSocialProfile refreshes the user's profile from the linked account of a
+// provider on demand. Requires the app's profile sync policy to be "on_demand"
+// or "scheduled".
+func (s *Service) SyncSocialProfile(ctx context.Context, appID uuid.UUID, userID, provider string) (*models.SocialAccount, *errors.AppError) {
+	policy, err := s.SocialRepo.GetProfileSyncPolicy(appID.String())
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to retrieve application settings")
+	}
+	if policy != ProfileSyncOnDemand && policy != ProfileSyncScheduled {
+		return nil, errors.NewAppError(errors.ErrForbidden, "Social profile sync is not enabled for this application")
+	}
+
+	account, err := s.SocialRepo.GetSocialAccountByUserAndProvider(appID.String(), userID, provider)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewAppError(errors.ErrNotFound, "No linked "+provider+" account found")
+		}
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to retrieve social account")
+	}
+
+	if err := s.syncAccount(ctx, account); err != nil {
+		if err == errTokenRejected {
+			return nil, errors.NewAppError(errors.ErrConflict, "The stored "+provider+" access token is no longer valid. Sign in with "+provider+" again to refresh your profile.")
+		}
+		log.Printf("Social profile sync failed for account %s: %v", account.ID, err)
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to sync profile from "+provider)
+	}
+	return account, nil
+}
+
+// RunScheduledProfileSync syncs the social accounts of apps with the
+// "scheduled" policy that have not been synced within the app's interval.
+// Accounts whose token the provider rejects are marked as synced so they are
+// not retried before the next interval; the next sign-in refreshes the token.
+func (s *Service) RunScheduledProfileSync(ctx context.Context) error {
+	accounts, err := s.SocialRepo.GetSocialAccountsDueForSync(profileSyncBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list social accounts due for sync: %w", err)
+	}
+
+	var synced, rejected, failed int
+	for i := range accounts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		account := &accounts[i]
+		err := s.syncAccount(ctx, account)
+		switch {
+		case err == nil:
+			synced++
+		case err == errTokenRejected:
+			rejected++
+			if err := s.SocialRepo.TouchSocialAccountSynced(account.ID.String()); err != nil {
+				log.Printf("Failed to mark social account %s as synced: %v", account.ID, err)
+			}
+		default:
+			failed++
+			log.Printf("Social profile sync failed for account %s: %v", account.ID, err)
+		}
+	}
+
+	if len(accounts) > 0 {
+		log.Printf("Social profile sync: %d synced, %d with expired tokens, %d failed", synced, rejected, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d social profiles failed to sync", failed, len(accounts))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestFetchProviderProfile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("access_token")
+		if r.URL.Path == "/github" {
+			token = r.Header.Get("Authorization")
+		}
+		switch {
+		case r.URL.Path == "/facebook" && token == "expired":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Session has expired","type":"OAuthException","code":190}}`)
+		case token == "expired" || token == "token expired":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/google":
+			fmt.Fprint(w, `{"id":"g1","name":"Jane Doe","given_name":"Jane","family_name":"Doe","picture":"https://img/g.png","locale":"en"}`)
+		case r.URL.Path == "/facebook":
+			fmt.Fprint(w, `{"id":"f1","name":"Jane Doe","first_name":"Jane","last_name":"Doe","picture":{"data":{"url":"https://img/f.png"}}}`)
+		case r.URL.Path == "/github" && token == "token good":
+			fmt.Fprint(w, `{"id":42,"login":"jdoe","name":"Jane Doe","avatar_url":"https://img/gh.png"}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	origGoogle, origFacebook, origGithub := googleUserInfoURL, facebookMeURL, githubUserURL
+	googleUserInfoURL, facebookMeURL, githubUserURL = srv.URL+"/google", srv.URL+"/facebook", srv.URL+"/github"
+	t.Cleanup(func() { googleUserInfoURL, facebookMeURL, githubUserURL = origGoogle, origFacebook, origGithub })
+
+	tests := []struct {
+		provider, token string
+		wantID          string
+		wantPicture     string
+		wantRejected    bool
+	}{
+		{"google", "good", "g1", "https://img/g.png", false},
+		{"facebook", "good", "f1", "https://img/f.png", false},
+		{"github", "good", "42", "https://img/gh.png", false},
+		{"google", "expired", "", "", true},
+		{"facebook", "expired", "", "", true},
+		{"github", "expired", "", "", true},
+	}
+	for _, tt := range tests {
+		p, err := fetchProviderProfile(context.Background(), tt.provider, tt.token)
+		if tt.wantRejected {
+			if err != errTokenRejected {
+				t.Errorf("%s %s: err = %v, want errTokenRejected", tt.provider, tt.token, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.provider, err)
+		}
+		if p.ProviderUserID != tt.wantID || p.Picture != tt.wantPicture || p.Name != "Jane Doe" {
+			t.Errorf("%s: got %+v", tt.provider, p)
+		}
+	}
+
+	if _, err := fetchProviderProfile(context.Background(), "twitter", "good"); err == nil {
+		t.Error("unsupported provider: expected an error")
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	account := &models.SocialAccount{Name: "Old", FirstName: "Old", LastName: "Name", Username: "old", Locale: "de"}
+	u := &models.User{Name: "Old", FirstName: "Jane", LastName: "Name", ProfilePicture: "https://img/old.png", Locale: "de"}
+	p := &providerProfile{Name: "Jane Doe", Picture: "https://img/new.png", Username: "jdoe", Raw: []byte(`{}`)}
+
+	changes := applyProfile(account, u, p)
+	if account.Name != "Jane Doe" || account.ProfilePicture != "https://img/new.png" || account.Username != "jdoe" {
+		t.Errorf("account not updated: %+v", account)
+	}
+	if account.FirstName != "Old" || account.Locale != "de" {
+		t.Errorf("fields the provider did not return were cleared: %+v", account)
+	}
+	want := map[string]interface{}{"name": "Jane Doe", "profile_picture": "https://img/new.png"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for k, v := range want {
+		if changes[k] != v {
+			t.Errorf("changes[%q] = %v, want %v", k, changes[k], v)
+		}
+	}
+}
+
+func TestIsValidProfileSync(t *testing.T) {
+	for _, policy := range []string{ProfileSyncLogin, ProfileSyncOnDemand, ProfileSyncScheduled} {
+		if !IsValidProfileSync(policy) {
+			t.Errorf("IsValidProfileSync(%q) = false", policy)
+		}
+	}
+	if IsValidProfileSync("") || IsValidProfileSync("hourly") {
+		t.Error("IsValidProfileSync accepted an unknown policy")
+	}
+}
@@ -0,0 +1,50 @@
+package social
+
+import (
+	"fmt"
+
+	"github.com/gjovanovicst/auth_api/internal/disposable"
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+)
+
+// providerNames are the display names of the social providers.
+var providerNames = map[string]string{
+	"google":   "Google",
+	"facebook": "Facebook",
+	"github":   "GitHub",
+}
+
+// checkProvisioning enforces the just-in-time provisioning controls of the
+// provider's config before an account is created for a user signing in with
+// the provider for the first time.
+func (s *Service) checkProvisioning(appID uuid.UUID, provider, email string, emailVerified bool) *errors.AppError {
+	config, err := s.SocialRepo.GetOAuthProviderConfig(appID.String(), provider)
+	if err != nil {
+		// Without a config the sign-in could not have started; keep the previous behavior
+		return nil
+	}
+	return provisioningError(config, email, emailVerified)
+}
+
+// provisioningError returns the error for a user that config does not allow
+// to be provisioned, or nil.
+func provisioningError(config *models.OAuthProviderConfig, email string, emailVerified bool) *errors.AppError {
+	name := providerNames[config.Provider]
+	if name == "" {
+		name = config.Provider
+	}
+	if config.ProvisioningMode == models.ProvisioningModeExistingOnly {
+		return errors.NewAppError(errors.ErrForbidden, fmt.Sprintf("No account exists for this %s login. Please ask your administrator to create your account first.", name))
+	}
+	if allowed := disposable.ParseList(config.AllowedEmailDomains); len(allowed) > 0 {
+		if !emailVerified {
+			return errors.NewAppError(errors.ErrForbidden, fmt.Sprintf("A verified email address is required to sign up with %s.", name))
+		}
+		if !disposable.InList(allowed, email) {
+			return errors.NewAppError(errors.ErrForbidden, fmt.Sprintf("Sign-up with %s is restricted to approved email domains.", name))
+		}
+	}
+	return nil
+}
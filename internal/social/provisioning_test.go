@@ -0,0 +1,36 @@
+package social
+
+import (
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+)
+
+func TestProvisioningError(t *testing.T) {
+	auto := &models.OAuthProviderConfig{Provider: "google", ProvisioningMode: models.ProvisioningModeAuto}
+	existingOnly := &models.OAuthProviderConfig{Provider: "google", ProvisioningMode: models.ProvisioningModeExistingOnly}
+	restricted := &models.OAuthProviderConfig{Provider: "google", ProvisioningMode: models.ProvisioningModeAuto, AllowedEmailDomains: "company.com, @partner.org"}
+
+	tests := []struct {
+		name     string
+		config   *models.OAuthProviderConfig
+		email    string
+		verified bool
+		allowed  bool
+	}{
+		{"auto", auto, "jane@gmail.com", false, true},
+		{"existing only", existingOnly, "jane@company.com", true, false},
+		{"allowed domain", restricted, "jane@company.com", true, true},
+		{"allowed subdomain", restricted, "jane@eu.company.com", true, true},
+		{"second allowed domain", restricted, "Jane@Partner.org", true, true},
+		{"other domain", restricted, "jane@gmail.com", true, false},
+		{"lookalike domain", restricted, "jane@notcompany.com", true, false},
+		{"unverified email", restricted, "jane@company.com", false, false},
+	}
+	for _, tc := range tests {
+		err := provisioningError(tc.config, tc.email, tc.verified)
+		if (err == nil) != tc.allowed {
+			t.Errorf("%s: error = %v, want allowed = %v", tc.name, err, tc.allowed)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Raw data retention policies — how much of a provider's user payload is kept
+// in SocialAccount.RawData. Configured per app.
+const (
+	RawDataFull      = "full"      // The whole payload (default)
+	RawDataAllowlist = "allowlist" // Only the app's allowlisted fields
+	RawDataNone      = "none"      // Nothing
+)
+
+// IsValidRawDataPolicy reports whether policy is a supported raw data retention policy.
+func IsValidRawDataPolicy(policy string) bool {
+	switch policy {
+	case RawDataFull, RawDataAllowlist, RawDataNone:
+		return true
+	}
+	return false
+}
+
+// rawDataRedactBatchSize caps the social accounts loaded per query when
+// redacting stored raw data.
+const rawDataRedactBatchSize = 500
+
+// ParseRawDataFields splits a comma or newline separated list of allowlisted
+// fields. Fields are dot-separated paths into the payload (e.g. "address.country").
+func ParseRawDataFields(raw string) []string {
+	var fields []string
+	for _, f := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// RedactRawData returns the part of a provider payload the policy keeps: all
+// of it, only the allowlisted fields, or nothing (nil). With the allowlist
+// policy, a payload that is not a JSON object or has none of the fields is
+// dropped.
+func RedactRawData(policy string, fields []string, raw []byte) datatypes.JSON {
+	switch {
+	case len(raw) == 0 || policy == RawDataNone:
+		return nil
+	case policy != RawDataAllowlist:
+		return datatypes.JSON(raw)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil
+	}
+	kept := map[string]interface{}{}
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		value, ok := lookupPath(payload, path)
+		if !ok {
+			continue
+		}
+		dst := kept
+		for _, key := range path[:len(path)-1] {
+			next, ok := dst[key].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				dst[key] = next
+			}
+			dst = next
+		}
+		dst[path[len(path)-1]] = value
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return nil
+	}
+	return datatypes.JSON(data)
+}
+
+// lookupPath returns the value at a path of object keys.
+func lookupPath(payload map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = payload
+	for _, key := range path {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// applyRawDataPolicy redacts the raw data of a social account as its
+// application's retention policy requires. When the policy cannot be read,
+// the raw data is dropped rather than stored in full.
+func (s *Service) applyRawDataPolicy(account *models.SocialAccount) {
+	if len(account.RawData) == 0 {
+		return
+	}
+	policy, fields, err := s.SocialRepo.GetRawDataPolicy(account.AppID.String())
+	if err != nil {
+		log.Printf("Warning: failed to read the raw data policy of app %s, dropping provider data: %v", account.AppID, err)
+		policy = RawDataNone
+	}
+	account.RawData = RedactRawData(policy, ParseRawDataFields(fields), account.RawData)
+}
+
+// createSocialAccount stores a new social account under the raw data policy.
+func (s *Service) createSocialAccount(account *models.SocialAccount) error {
+	s.applyRawDataPolicy(account)
+	return s.SocialRepo.CreateSocialAccount(account)
+}
+
+// updateSocialAccount saves a social account under the raw data policy.
+func (s *Service) updateSocialAccount(account *models.SocialAccount) error {
+	s.applyRawDataPolicy(account)
+	return s.SocialRepo.UpdateSocialAccount(account)
+}
+
+// RedactStoredRawData applies an application's current raw data policy to the
+// raw data already stored for its social accounts, e.g. after the policy was
+// tightened. It returns the number of accounts checked and changed.
+func RedactStoredRawData(ctx context.Context, db *gorm.DB, appID uuid.UUID) (checked, redacted int64, err error) {
+	var app models.Application
+	if err := db.WithContext(ctx).Select("id, social_raw_data, social_raw_data_fields").First(&app, "id = ?", appID).Error; err != nil {
+		return 0, 0, err
+	}
+	switch app.SocialRawData {
+	case RawDataAllowlist:
+	case RawDataNone:
+		res := db.WithContext(ctx).Model(&models.SocialAccount{}).
+			Where("app_id = ? AND raw_data IS NOT NULL", appID).
+			Update("raw_data", nil)
+		return res.RowsAffected, res.RowsAffected, res.Error
+	default:
+		// Full retention keeps everything
+		return 0, 0, nil
+	}
+
+	fields := ParseRawDataFields(app.SocialRawDataFields)
+	lastID := uuid.Nil
+	for {
+		var accounts []models.SocialAccount
+		err := db.WithContext(ctx).Select("id, raw_data").
+			Where("app_id = ? AND raw_data IS NOT NULL AND id > ?", appID, lastID).
+			Order("id").Limit(rawDataRedactBatchSize).
+			Find(&accounts).Error
+		if err != nil {
+			return checked, redacted, err
+		}
+		for _, a := range accounts {
+			checked++
+			kept := RedactRawData(RawDataAllowlist, fields, a.RawData)
+			if sameJSON(kept, a.RawData) {
+				continue
+			}
+			var value interface{}
+			if kept != nil {
+				value = kept
+			}
+			if err := db.WithContext(ctx).Model(&models.SocialAccount{}).Where("id = ?", a.ID).Update("raw_data", value).Error; err != nil {
+				return checked, redacted, fmt.Errorf("failed to redact social account %s: %w", a.ID, err)
+			}
+			redacted++
+		}
+		if len(accounts) < rawDataRedactBatchSize {
+			return checked, redacted, nil
+		}
+		lastID = accounts[len(accounts)-1].ID
+	}
+}
+
+// sameJSON reports whether two JSON documents are equal, ignoring formatting
+// and key order.
+func sameJSON(a, b []byte) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return string(ca) == string(cb)
+}
@@ -0,0 +1,54 @@
+package social
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactRawData(t *testing.T) {
+	raw := []byte(`{"sub":"00u1","email":"jane@example.com","address":{"country":"RS","street":"Main 1"},"groups":["staff"]}`)
+
+	tests := []struct {
+		name   string
+		policy string
+		fields []string
+		want   string // "" = nil
+	}{
+		{"full", RawDataFull, nil, string(raw)},
+		{"unset policy keeps everything", "", nil, string(raw)},
+		{"none", RawDataNone, []string{"sub"}, ""},
+		{"allowlist", RawDataAllowlist, []string{"sub", "address.country", "missing"}, `{"address":{"country":"RS"},"sub":"00u1"}`},
+		{"allowlist keeps nested objects whole", RawDataAllowlist, []string{"address"}, `{"address":{"country":"RS","street":"Main 1"}}`},
+		{"allowlist without matches", RawDataAllowlist, []string{"phone", "sub.id"}, ""},
+	}
+	for _, tt := range tests {
+		got := RedactRawData(tt.policy, tt.fields, raw)
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if got := RedactRawData(RawDataAllowlist, []string{"sub"}, []byte(`["not an object"]`)); got != nil {
+		t.Errorf("non-object payload: got %s, want nil", got)
+	}
+	if got := RedactRawData(RawDataFull, nil, nil); got != nil {
+		t.Errorf("empty payload: got %s, want nil", got)
+	}
+}
+
+func TestParseRawDataFields(t *testing.T) {
+	got := ParseRawDataFields(" sub, email\r\naddress.country,, \n")
+	want := []string{"sub", "email", "address.country"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSameJSON(t *testing.T) {
+	if !sameJSON([]byte(`{"a": 1, "b": [1, 2]}`), []byte(`{"b":[1,2],"a":1}`)) {
+		t.Error("reordered keys: expected equal")
+	}
+	if sameJSON([]byte(`{"a":1}`), nil) || sameJSON([]byte(`{"a":1}`), []byte(`{"a":2}`)) {
+		t.Error("different documents: expected not equal")
+	}
+}
@@ -1,26 +1,54 @@
 package social
 
 import (
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/oauthconfig"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Repository struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	OAuthConfigs *oauthconfig.Resolver // Layered, cached OAuth provider config resolution
 }
 
 func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{DB: db}
+	return &Repository{DB: db, OAuthConfigs: oauthconfig.NewResolver(db)}
 }
 
 func (r *Repository) CreateSocialAccount(socialAccount *models.SocialAccount) error {
 	return r.DB.Create(socialAccount).Error
 }
 
+// GetOAuthProviderConfig returns the provider config that applies to an
+// application: its own, its parent's for an environment, the global default or
+// the environment variables (see package oauthconfig).
 func (r *Repository) GetOAuthProviderConfig(appID string, provider string) (*models.OAuthProviderConfig, error) {
-	var config models.OAuthProviderConfig
-	err := r.DB.Where("app_id = ? AND provider = ?", appID, provider).First(&config).Error
-	return &config, err
+	id, err := uuid.Parse(appID)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := r.OAuthConfigs.Resolve(id, provider)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.Config, nil
+}
+
+// GetRedirectAllowlist returns the raw redirect allowlist of an application.
+func (r *Repository) GetRedirectAllowlist(appID string) (string, error) {
+	var app models.Application
+	err := r.DB.Select("allowed_redirect_urls").First(&app, "id = ?", appID).Error
+	return app.AllowedRedirectURLs, err
+}
+
+// GetCallbackMode returns how social login callbacks of an application deliver their result.
+func (r *Repository) GetCallbackMode(appID string) (string, error) {
+	var app models.Application
+	err := r.DB.Select("social_callback_mode").First(&app, "id = ?", appID).Error
+	return app.SocialCallbackMode, err
 }
 
 func (r *Repository) GetSocialAccountByProviderAndUserID(appID, provider, providerUserID string) (*models.SocialAccount, error) {
@@ -61,3 +89,47 @@ func (r *Repository) CountSocialAccountsByUserID(userID string) (int64, error) {
 	err := r.DB.Model(&models.SocialAccount{}).Where("user_id = ?", userID).Count(&count).Error
 	return count, err
 }
+
+// GetProfileSyncPolicy returns when the social profiles of an application's users are refreshed.
+func (r *Repository) GetProfileSyncPolicy(appID string) (string, error) {
+	var app models.Application
+	err := r.DB.Select("social_profile_sync").First(&app, "id = ?", appID).Error
+	return app.SocialProfileSync, err
+}
+
+// GetRawDataPolicy returns how much of the provider payloads of an
+// application's social accounts is stored, and the allowlisted fields.
+func (r *Repository) GetRawDataPolicy(appID string) (string, string, error) {
+	var app models.Application
+	err := r.DB.Select("social_raw_data, social_raw_data_fields").First(&app, "id = ?", appID).Error
+	return app.SocialRawData, app.SocialRawDataFields, err
+}
+
+func (r *Repository) GetSocialAccountByUserAndProvider(appID, userID, provider string) (*models.SocialAccount, error) {
+	var socialAccount models.SocialAccount
+	err := r.DB.Where("app_id = ? AND user_id = ? AND provider = ?", appID, userID, provider).First(&socialAccount).Error
+	return &socialAccount, err
+}
+
+// GetSocialAccountsDueForSync returns social accounts of apps with the
+// "scheduled" profile sync policy that were not synced within the app's
+// interval, least recently synced first. Accounts of inactive users are skipped.
+func (r *Repository) GetSocialAccountsDueForSync(limit int) ([]models.SocialAccount, error) {
+	var socialAccounts []models.SocialAccount
+	err := r.DB.Model(&models.SocialAccount{}).
+		Joins("JOIN applications ON applications.id = social_accounts.app_id").
+		Joins("JOIN users ON users.id = social_accounts.user_id").
+		Where("applications.social_profile_sync = ?", "scheduled").
+		Where("users.is_active = ?", true).
+		Where("social_accounts.access_token <> ''").
+		Where("(social_accounts.last_synced_at IS NULL OR social_accounts.last_synced_at < NOW() - make_interval(hours => GREATEST(applications.social_profile_sync_interval_hours, 1)))").
+		Order("social_accounts.last_synced_at ASC NULLS FIRST").
+		Limit(limit).
+		Find(&socialAccounts).Error
+	return socialAccounts, err
+}
+
+// TouchSocialAccountSynced records a sync attempt without changing the profile data.
+func (r *Repository) TouchSocialAccountSynced(id string) error {
+	return r.DB.Model(&models.SocialAccount{}).Where("id = ?", id).Update("last_synced_at", time.Now()).Error
+}
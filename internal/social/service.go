@@ -1,6 +1,7 @@
 package social
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log"
@@ -8,6 +9,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/passhash"
+	"github.com/gjovanovicst/auth_api/internal/quota"
 	"github.com/gjovanovicst/auth_api/internal/redis"
 	"github.com/gjovanovicst/auth_api/internal/session"
 	"github.com/gjovanovicst/auth_api/internal/user"
@@ -16,7 +19,6 @@ import (
 	"github.com/gjovanovicst/auth_api/pkg/jwt"
 	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
@@ -58,6 +60,8 @@ type Service struct {
 	LookupRoles       user.RoleLookupFunc        // Optional: if nil, tokens are generated without roles
 	AssignDefaultRole user.AssignDefaultRoleFunc // Optional: if nil, no default role on social signup
 	WebhookService    *webhook.Service           // Optional: if nil, webhook dispatch is skipped
+	// Optional: if nil, roles assigned by claim mappings are ignored
+	AssignRoleByName AssignRoleByNameFunc
 }
 
 func NewService(ur *user.Repository, sr *Repository) *Service {
@@ -105,6 +109,21 @@ func (s *Service) assignDefaultRole(appID, userID string) {
 	}
 }
 
+// holdForApproval puts a newly created social user on hold when the
+// application requires registration approval. The returned error is the
+// response to the sign-in that created the account.
+func (s *Service) holdForApproval(appID uuid.UUID, newUser *models.User) *errors.AppError {
+	pending, err := user.HoldForApproval(s.UserRepo.DB, appID, newUser)
+	if err != nil {
+		log.Printf("Warning: failed to hold social user %s for approval: %v", newUser.ID.String(), err)
+		return errors.NewAppError(errors.ErrInternal, "Failed to create new user")
+	}
+	if pending {
+		return user.CheckAccountActive(newUser)
+	}
+	return nil
+}
+
 // CreateSessionOrTokens creates a session via the session service if available,
 // otherwise falls back to legacy token generation.
 // Per-app token TTL overrides are resolved via ResolveTokenTTLs.
@@ -143,7 +162,24 @@ func (s *Service) CreateSessionOrTokens(appID, userID, ip, userAgent string) (ac
 	return accessToken, refreshToken, nil
 }
 
+// checkProviderAllowed returns an error when the application's auth method
+// policy does not permit signing in with the social provider.
+func (s *Service) checkProviderAllowed(appID uuid.UUID, provider string) *errors.AppError {
+	var app models.Application
+	if err := s.SocialRepo.DB.Select("allowed_auth_methods").First(&app, "id = ?", appID).Error; err != nil {
+		return nil // Fail open like the other application flag lookups
+	}
+	if !app.AuthMethodAllowed(provider) {
+		return errors.NewAppError(errors.ErrForbidden, "Sign-in with "+provider+" is not allowed for this application")
+	}
+	return nil
+}
+
 func (s *Service) HandleGoogleCallback(appID uuid.UUID, googleAccessToken string) (*SocialLoginResult, *errors.AppError) {
+	if appErr := s.checkProviderAllowed(appID, models.AuthMethodGoogle); appErr != nil {
+		return nil, appErr
+	}
+
 	// Fetch user info from Google
 	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + googleAccessToken)
 	if err != nil {
@@ -183,8 +219,10 @@ func (s *Service) HandleGoogleCallback(appID uuid.UUID, googleAccessToken string
 		socialAccount.Locale = googleUser.Locale
 		socialAccount.RawData = rawDataJSON
 		socialAccount.AccessToken = googleAccessToken
+		syncedAt := time.Now()
+		socialAccount.LastSyncedAt = &syncedAt
 
-		if err := s.SocialRepo.UpdateSocialAccount(socialAccount); err != nil {
+		if err := s.updateSocialAccount(socialAccount); err != nil {
 			return nil, errors.NewAppError(errors.ErrInternal, "Failed to update social account")
 		}
 
@@ -192,8 +230,8 @@ func (s *Service) HandleGoogleCallback(appID uuid.UUID, googleAccessToken string
 		foundUser, err := s.UserRepo.GetUserByID(socialAccount.UserID.String())
 		if err == nil {
 			// Check if account is active
-			if !foundUser.IsActive {
-				return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+			if appErr := user.CheckAccountActive(foundUser); appErr != nil {
+				return nil, appErr
 			}
 
 			updated := false
@@ -228,6 +266,7 @@ func (s *Service) HandleGoogleCallback(appID uuid.UUID, googleAccessToken string
 					log.Printf("Failed to update user profile: %v", err)
 				}
 			}
+			s.applyClaimMappings(appID, "google", userData, foundUser)
 		}
 
 		return &SocialLoginResult{UserID: socialAccount.UserID}, nil
@@ -238,8 +277,8 @@ func (s *Service) HandleGoogleCallback(appID uuid.UUID, googleAccessToken string
 	// prompt the user to confirm ownership before linking the social account.
 	existingUser, err := s.UserRepo.GetUserByEmail(appID.String(), googleUser.Email)
 	if err == nil {
-		if !existingUser.IsActive {
-			return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+		if appErr := user.CheckAccountActive(existingUser); appErr != nil {
+			return nil, appErr
 		}
 		rawDataJSON, _ := json.Marshal(googleUser)
 		mergeToken, mergeErr := s.createMergeToken(appID.String(), existingUser.ID.String(), "google", googleUser.ID, googleUser.Email, googleUser.Name, googleUser.GivenName, googleUser.FamilyName, googleUser.Picture, "", googleUser.Locale, rawDataJSON, googleAccessToken)
@@ -254,6 +293,12 @@ func (s *Service) HandleGoogleCallback(appID uuid.UUID, googleAccessToken string
 	}
 
 	// No existing user or social account — create new user and social account.
+	if appErr := s.checkProvisioning(appID, "google", googleUser.Email, googleUser.VerifiedEmail); appErr != nil {
+		return nil, appErr
+	}
+	if appErr := quota.ToAppError(quota.CheckAppUsers(s.UserRepo.DB, appID, 1)); appErr != nil {
+		return nil, appErr
+	}
 	newUser := &models.User{
 		AppID:          appID,
 		Email:          googleUser.Email,
@@ -288,14 +333,24 @@ func (s *Service) HandleGoogleCallback(appID uuid.UUID, googleAccessToken string
 		AccessToken:    googleAccessToken,
 		ExpiresAt:      nil,
 	}
-	if err := s.SocialRepo.CreateSocialAccount(newSocialAccount); err != nil {
+	if err := s.createSocialAccount(newSocialAccount); err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to create social account")
 	}
 
+	s.applyClaimMappings(appID, "google", userData, newUser)
+
+	if appErr := s.holdForApproval(appID, newUser); appErr != nil {
+		return nil, appErr
+	}
+
 	return &SocialLoginResult{UserID: newUser.ID}, nil
 }
 
 func (s *Service) HandleFacebookCallback(appID uuid.UUID, facebookAccessToken string) (*SocialLoginResult, *errors.AppError) {
+	if appErr := s.checkProviderAllowed(appID, models.AuthMethodFacebook); appErr != nil {
+		return nil, appErr
+	}
+
 	// Fetch user info from Facebook Graph API with extended fields
 	resp, err := http.Get("https://graph.facebook.com/v18.0/me?fields=id,name,email,first_name,last_name,picture.type(large),locale&access_token=" + facebookAccessToken)
 	if err != nil {
@@ -338,8 +393,10 @@ func (s *Service) HandleFacebookCallback(appID uuid.UUID, facebookAccessToken st
 		socialAccount.Locale = facebookUser.Locale
 		socialAccount.RawData = rawDataJSON
 		socialAccount.AccessToken = facebookAccessToken
+		syncedAt := time.Now()
+		socialAccount.LastSyncedAt = &syncedAt
 
-		if err := s.SocialRepo.UpdateSocialAccount(socialAccount); err != nil {
+		if err := s.updateSocialAccount(socialAccount); err != nil {
 			return nil, errors.NewAppError(errors.ErrInternal, "Failed to update social account")
 		}
 
@@ -347,8 +404,8 @@ func (s *Service) HandleFacebookCallback(appID uuid.UUID, facebookAccessToken st
 		foundUser, err := s.UserRepo.GetUserByID(socialAccount.UserID.String())
 		if err == nil {
 			// Check if account is active
-			if !foundUser.IsActive {
-				return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+			if appErr := user.CheckAccountActive(foundUser); appErr != nil {
+				return nil, appErr
 			}
 
 			updated := false
@@ -383,6 +440,7 @@ func (s *Service) HandleFacebookCallback(appID uuid.UUID, facebookAccessToken st
 					log.Printf("Failed to update user profile: %v", err)
 				}
 			}
+			s.applyClaimMappings(appID, "facebook", userData, foundUser)
 		}
 
 		return &SocialLoginResult{UserID: socialAccount.UserID}, nil
@@ -392,8 +450,8 @@ func (s *Service) HandleFacebookCallback(appID uuid.UUID, facebookAccessToken st
 	// If yes, issue a merge token instead of silently auto-linking.
 	existingUser, err := s.UserRepo.GetUserByEmail(appID.String(), facebookUser.Email)
 	if err == nil {
-		if !existingUser.IsActive {
-			return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+		if appErr := user.CheckAccountActive(existingUser); appErr != nil {
+			return nil, appErr
 		}
 		rawDataJSON, _ := json.Marshal(facebookUser)
 		mergeToken, mergeErr := s.createMergeToken(appID.String(), existingUser.ID.String(), "facebook", facebookUser.ID, facebookUser.Email, facebookUser.Name, facebookUser.FirstName, facebookUser.LastName, facebookUser.Picture.Data.URL, "", facebookUser.Locale, rawDataJSON, facebookAccessToken)
@@ -408,6 +466,12 @@ func (s *Service) HandleFacebookCallback(appID uuid.UUID, facebookAccessToken st
 	}
 
 	// No existing user or social account — create new user and social account.
+	if appErr := s.checkProvisioning(appID, "facebook", facebookUser.Email, true); appErr != nil {
+		return nil, appErr
+	}
+	if appErr := quota.ToAppError(quota.CheckAppUsers(s.UserRepo.DB, appID, 1)); appErr != nil {
+		return nil, appErr
+	}
 	newUser := &models.User{
 		AppID:          appID,
 		Email:          facebookUser.Email,
@@ -441,14 +505,24 @@ func (s *Service) HandleFacebookCallback(appID uuid.UUID, facebookAccessToken st
 		AccessToken:    facebookAccessToken,
 		ExpiresAt:      nil,
 	}
-	if err := s.SocialRepo.CreateSocialAccount(newSocialAccount); err != nil {
+	if err := s.createSocialAccount(newSocialAccount); err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to create social account")
 	}
 
+	s.applyClaimMappings(appID, "facebook", userData, newUser)
+
+	if appErr := s.holdForApproval(appID, newUser); appErr != nil {
+		return nil, appErr
+	}
+
 	return &SocialLoginResult{UserID: newUser.ID}, nil
 }
 
 func (s *Service) HandleGithubCallback(appID uuid.UUID, githubAccessToken string) (*SocialLoginResult, *errors.AppError) {
+	if appErr := s.checkProviderAllowed(appID, models.AuthMethodGithub); appErr != nil {
+		return nil, appErr
+	}
+
 	// Fetch user info from GitHub API
 	client := &http.Client{}
 	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
@@ -534,8 +608,10 @@ func (s *Service) HandleGithubCallback(appID uuid.UUID, githubAccessToken string
 		socialAccount.Username = githubUser.Login
 		socialAccount.RawData = rawDataJSON
 		socialAccount.AccessToken = githubAccessToken
+		syncedAt := time.Now()
+		socialAccount.LastSyncedAt = &syncedAt
 
-		if err := s.SocialRepo.UpdateSocialAccount(socialAccount); err != nil {
+		if err := s.updateSocialAccount(socialAccount); err != nil {
 			return nil, errors.NewAppError(errors.ErrInternal, "Failed to update social account")
 		}
 
@@ -543,8 +619,8 @@ func (s *Service) HandleGithubCallback(appID uuid.UUID, githubAccessToken string
 		foundUser, err := s.UserRepo.GetUserByID(socialAccount.UserID.String())
 		if err == nil {
 			// Check if account is active
-			if !foundUser.IsActive {
-				return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+			if appErr := user.CheckAccountActive(foundUser); appErr != nil {
+				return nil, appErr
 			}
 
 			updated := false
@@ -567,6 +643,7 @@ func (s *Service) HandleGithubCallback(appID uuid.UUID, githubAccessToken string
 					log.Printf("Failed to update user profile: %v", err)
 				}
 			}
+			s.applyClaimMappings(appID, "github", userData, foundUser)
 		}
 
 		return &SocialLoginResult{UserID: socialAccount.UserID}, nil
@@ -576,8 +653,8 @@ func (s *Service) HandleGithubCallback(appID uuid.UUID, githubAccessToken string
 	// If yes, issue a merge token instead of silently auto-linking.
 	existingUser, err := s.UserRepo.GetUserByEmail(appID.String(), githubUser.Email)
 	if err == nil {
-		if !existingUser.IsActive {
-			return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+		if appErr := user.CheckAccountActive(existingUser); appErr != nil {
+			return nil, appErr
 		}
 		rawDataJSON, _ := json.Marshal(githubUser)
 		mergeToken, mergeErr := s.createMergeToken(appID.String(), existingUser.ID.String(), "github", strconv.FormatInt(githubUser.ID, 10), githubUser.Email, githubUser.Name, "", "", githubUser.AvatarURL, githubUser.Login, "", rawDataJSON, githubAccessToken)
@@ -592,6 +669,12 @@ func (s *Service) HandleGithubCallback(appID uuid.UUID, githubAccessToken string
 	}
 
 	// No existing user or social account — create new user and social account.
+	if appErr := s.checkProvisioning(appID, "github", githubUser.Email, true); appErr != nil {
+		return nil, appErr
+	}
+	if appErr := quota.ToAppError(quota.CheckAppUsers(s.UserRepo.DB, appID, 1)); appErr != nil {
+		return nil, appErr
+	}
 	newUser := &models.User{
 		AppID:          appID,
 		Email:          githubUser.Email,
@@ -620,10 +703,179 @@ func (s *Service) HandleGithubCallback(appID uuid.UUID, githubAccessToken string
 		AccessToken:    githubAccessToken,
 		ExpiresAt:      nil,
 	}
-	if err := s.SocialRepo.CreateSocialAccount(newSocialAccount); err != nil {
+	if err := s.createSocialAccount(newSocialAccount); err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to create social account")
 	}
 
+	s.applyClaimMappings(appID, "github", userData, newUser)
+
+	if appErr := s.holdForApproval(appID, newUser); appErr != nil {
+		return nil, appErr
+	}
+
+	return &SocialLoginResult{UserID: newUser.ID}, nil
+}
+
+// HandleOIDCCallback signs in the user of a generic OpenID Connect provider
+// whose access token was issued for config, using the claims of the
+// provider's UserInfo endpoint. The provider's name (config.Provider) is
+// recorded as the social account's provider.
+func (s *Service) HandleOIDCCallback(appID uuid.UUID, config *models.OAuthProviderConfig, oidcAccessToken string) (*SocialLoginResult, *errors.AppError) {
+	if appErr := s.checkProviderAllowed(appID, models.AuthMethodOIDC); appErr != nil {
+		return nil, appErr
+	}
+	provider := config.Provider
+
+	doc, err := discoverOIDC(context.Background(), config.IssuerURL)
+	if err != nil {
+		log.Printf("OpenID Connect discovery failed for %s: %v", provider, err)
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to reach the "+provider+" identity provider")
+	}
+	oidcUser, userData, err := fetchOIDCUserInfo(context.Background(), doc.UserinfoEndpoint, oidcAccessToken)
+	if err != nil {
+		log.Printf("Failed to get user info from %s: %v", provider, err)
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to get user info from "+provider)
+	}
+	if oidcUser.Email == "" {
+		return nil, errors.NewAppError(errors.ErrBadRequest, "The "+provider+" identity provider did not return an email address (is the email scope requested?)")
+	}
+	emailVerified := bool(oidcUser.EmailVerified)
+
+	// Check if social account already exists
+	socialAccount, err := s.SocialRepo.GetSocialAccountByProviderAndUserID(appID.String(), provider, oidcUser.Sub)
+	if err == nil { // Social account found, user exists
+		// Update social account with latest data from provider
+		socialAccount.Email = oidcUser.Email
+		socialAccount.Name = oidcUser.Name
+		socialAccount.FirstName = oidcUser.GivenName
+		socialAccount.LastName = oidcUser.FamilyName
+		socialAccount.ProfilePicture = oidcUser.Picture
+		socialAccount.Username = oidcUser.PreferredUsername
+		socialAccount.Locale = oidcUser.Locale
+		socialAccount.RawData = userData
+		socialAccount.AccessToken = oidcAccessToken
+		syncedAt := time.Now()
+		socialAccount.LastSyncedAt = &syncedAt
+
+		if err := s.updateSocialAccount(socialAccount); err != nil {
+			return nil, errors.NewAppError(errors.ErrInternal, "Failed to update social account")
+		}
+
+		// Also update user profile with latest data
+		foundUser, err := s.UserRepo.GetUserByID(socialAccount.UserID.String())
+		if err == nil {
+			// Check if account is active
+			if appErr := user.CheckAccountActive(foundUser); appErr != nil {
+				return nil, appErr
+			}
+
+			updated := false
+			if foundUser.Name != oidcUser.Name && oidcUser.Name != "" {
+				foundUser.Name = oidcUser.Name
+				updated = true
+			}
+			if foundUser.FirstName != oidcUser.GivenName && oidcUser.GivenName != "" {
+				foundUser.FirstName = oidcUser.GivenName
+				updated = true
+			}
+			if foundUser.LastName != oidcUser.FamilyName && oidcUser.FamilyName != "" {
+				foundUser.LastName = oidcUser.FamilyName
+				updated = true
+			}
+			if foundUser.ProfilePicture != oidcUser.Picture && oidcUser.Picture != "" {
+				foundUser.ProfilePicture = oidcUser.Picture
+				updated = true
+			}
+			if foundUser.Locale != oidcUser.Locale && oidcUser.Locale != "" {
+				foundUser.Locale = oidcUser.Locale
+				updated = true
+			}
+			// A verified email at the provider verifies the user's email
+			if emailVerified && !foundUser.EmailVerified && foundUser.Email == oidcUser.Email {
+				foundUser.EmailVerified = true
+				updated = true
+			}
+			if updated {
+				if err := s.UserRepo.UpdateUser(foundUser); err != nil {
+					// Log error but don't fail authentication
+					log.Printf("Failed to update user profile: %v", err)
+				}
+			}
+			s.applyClaimMappings(appID, provider, userData, foundUser)
+		}
+
+		return &SocialLoginResult{UserID: socialAccount.UserID}, nil
+	}
+
+	// Social account not found — an existing user with this email must confirm
+	// the merge before the provider's account is linked.
+	existingUser, err := s.UserRepo.GetUserByEmail(appID.String(), oidcUser.Email)
+	if err == nil {
+		if appErr := user.CheckAccountActive(existingUser); appErr != nil {
+			return nil, appErr
+		}
+		mergeToken, mergeErr := s.createMergeToken(appID.String(), existingUser.ID.String(), provider, oidcUser.Sub, oidcUser.Email, oidcUser.Name, oidcUser.GivenName, oidcUser.FamilyName, oidcUser.Picture, oidcUser.PreferredUsername, oidcUser.Locale, userData, oidcAccessToken)
+		if mergeErr != nil {
+			return nil, mergeErr
+		}
+		return &SocialLoginResult{
+			RequiresMerge: true,
+			MergeToken:    mergeToken,
+			MergeEmail:    oidcUser.Email,
+		}, nil
+	}
+
+	// No existing user or social account — create new user and social account.
+	if appErr := s.checkProvisioning(appID, provider, oidcUser.Email, emailVerified); appErr != nil {
+		return nil, appErr
+	}
+	if appErr := quota.ToAppError(quota.CheckAppUsers(s.UserRepo.DB, appID, 1)); appErr != nil {
+		return nil, appErr
+	}
+	newUser := &models.User{
+		AppID:          appID,
+		Email:          oidcUser.Email,
+		EmailVerified:  emailVerified,
+		Name:           oidcUser.Name,
+		FirstName:      oidcUser.GivenName,
+		LastName:       oidcUser.FamilyName,
+		ProfilePicture: oidcUser.Picture,
+		Locale:         oidcUser.Locale,
+		// PasswordHash is not set for social logins
+	}
+	if err := s.UserRepo.CreateUser(newUser); err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to create new user")
+	}
+
+	// Assign default 'member' role to new social user
+	s.assignDefaultRole(appID.String(), newUser.ID.String())
+
+	newSocialAccount := &models.SocialAccount{
+		AppID:          appID,
+		UserID:         newUser.ID,
+		Provider:       provider,
+		ProviderUserID: oidcUser.Sub,
+		Email:          oidcUser.Email,
+		Name:           oidcUser.Name,
+		FirstName:      oidcUser.GivenName,
+		LastName:       oidcUser.FamilyName,
+		ProfilePicture: oidcUser.Picture,
+		Username:       oidcUser.PreferredUsername,
+		Locale:         oidcUser.Locale,
+		RawData:        userData,
+		AccessToken:    oidcAccessToken,
+		ExpiresAt:      nil,
+	}
+	if err := s.createSocialAccount(newSocialAccount); err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to create social account")
+	}
+
+	s.applyClaimMappings(appID, provider, userData, newUser)
+
+	if appErr := s.holdForApproval(appID, newUser); appErr != nil {
+		return nil, appErr
+	}
+
 	return &SocialLoginResult{UserID: newUser.ID}, nil
 }
 
@@ -745,7 +997,7 @@ func (s *Service) HandleGoogleLinkCallback(appID uuid.UUID, userID string, googl
 		RawData:        rawDataJSON,
 		AccessToken:    googleAccessToken,
 	}
-	if err := s.SocialRepo.CreateSocialAccount(newLinkAccount); err != nil {
+	if err := s.createSocialAccount(newLinkAccount); err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to link Google account")
 	}
 
@@ -818,7 +1070,7 @@ func (s *Service) HandleFacebookLinkCallback(appID uuid.UUID, userID string, fac
 		RawData:        rawDataJSON,
 		AccessToken:    facebookAccessToken,
 	}
-	if err := s.SocialRepo.CreateSocialAccount(newLinkAccount); err != nil {
+	if err := s.createSocialAccount(newLinkAccount); err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to link Facebook account")
 	}
 
@@ -927,7 +1179,7 @@ func (s *Service) HandleGithubLinkCallback(appID uuid.UUID, userID string, githu
 		RawData:        rawDataJSON,
 		AccessToken:    githubAccessToken,
 	}
-	if err := s.SocialRepo.CreateSocialAccount(newLinkAccount); err != nil {
+	if err := s.createSocialAccount(newLinkAccount); err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to link GitHub account")
 	}
 
@@ -989,13 +1241,13 @@ func (s *Service) ConfirmMerge(appID uuid.UUID, mergeToken, password, ip, userAg
 	if err != nil {
 		return "", "", errors.NewAppError(errors.ErrNotFound, "User not found")
 	}
-	if !existingUser.IsActive {
-		return "", "", errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+	if appErr := user.CheckAccountActive(existingUser); appErr != nil {
+		return "", "", appErr
 	}
 	if existingUser.PasswordHash == "" {
 		return "", "", errors.NewAppError(errors.ErrBadRequest, "This account has no password set. Please use a social login provider.")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.PasswordHash), []byte(password)); err != nil {
+	if err := passhash.Verify(existingUser.PasswordHash, password); err != nil {
 		return "", "", errors.NewAppError(errors.ErrUnauthorized, "Invalid password")
 	}
 
@@ -1017,10 +1269,12 @@ func (s *Service) ConfirmMerge(appID uuid.UUID, mergeToken, password, ip, userAg
 		RawData:        datatypes.JSON(payload.RawData),
 		AccessToken:    payload.AccessToken,
 	}
-	if err := s.SocialRepo.CreateSocialAccount(newSocialAccount); err != nil {
+	if err := s.createSocialAccount(newSocialAccount); err != nil {
 		return "", "", errors.NewAppError(errors.ErrInternal, "Failed to link social account")
 	}
 
+	s.applyClaimMappings(appID, payload.Provider, payload.RawData, existingUser)
+
 	// 4. Consume the merge token (best-effort; failure is non-fatal)
 	_ = redis.DeleteMergeToken(appID.String(), mergeToken)
 
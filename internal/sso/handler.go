@@ -226,6 +226,10 @@ func (h *Handler) Exchange(c *gin.Context) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "user account is deactivated in the target application"})
 		return
 	}
+	if targetUser.IsServiceAccount {
+		c.JSON(http.StatusForbidden, gin.H{"error": "service accounts cannot sign in interactively"})
+		return
+	}
 
 	// Resolve per-app token TTL overrides.
 	var app models.Application
@@ -0,0 +1,101 @@
+// Package startup waits for the service's dependencies (PostgreSQL, Redis)
+// when it starts, so that it survives being started before them, as container
+// orchestrators routinely do.
+package startup
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Defaults used when the STARTUP_RETRY_* settings are not set.
+const (
+	DefaultInitialInterval = 500 * time.Millisecond
+	DefaultMaxInterval     = 10 * time.Second
+	DefaultMaxWait         = 60 * time.Second
+)
+
+// RetryConfig controls how long and how often a dependency is retried.
+type RetryConfig struct {
+	InitialInterval time.Duration // Delay before the second attempt; doubled after each failure
+	MaxInterval     time.Duration // Upper bound of the delay between attempts
+	MaxWait         time.Duration // Total time to keep retrying; 0 means a single attempt
+}
+
+// now, sleep and jitter are replaced by tests.
+var (
+	now    = time.Now
+	sleep  = time.Sleep
+	jitter = func(d time.Duration) time.Duration { return time.Duration(rand.Int63n(int64(d) + 1)) }
+)
+
+// RetryConfigFromEnv returns the configuration from
+// STARTUP_RETRY_INITIAL_INTERVAL_MS, STARTUP_RETRY_MAX_INTERVAL_SECONDS and
+// STARTUP_RETRY_MAX_WAIT_SECONDS. They are read from the environment rather
+// than viper because the setup commands connect without it.
+func RetryConfigFromEnv() RetryConfig {
+	cfg := RetryConfig{
+		InitialInterval: DefaultInitialInterval,
+		MaxInterval:     DefaultMaxInterval,
+		MaxWait:         DefaultMaxWait,
+	}
+	if ms, ok := envInt("STARTUP_RETRY_INITIAL_INTERVAL_MS"); ok && ms > 0 {
+		cfg.InitialInterval = time.Duration(ms) * time.Millisecond
+	}
+	if s, ok := envInt("STARTUP_RETRY_MAX_INTERVAL_SECONDS"); ok && s > 0 {
+		cfg.MaxInterval = time.Duration(s) * time.Second
+	}
+	if s, ok := envInt("STARTUP_RETRY_MAX_WAIT_SECONDS"); ok && s >= 0 {
+		cfg.MaxWait = time.Duration(s) * time.Second
+	}
+	return cfg
+}
+
+func envInt(name string) (int, bool) {
+	v, err := strconv.Atoi(os.Getenv(name))
+	return v, err == nil
+}
+
+// backoff returns the delay after the given failed attempt (1-based): the
+// initial interval doubled per attempt and capped at the maximum, of which
+// the upper half is randomized so that replicas started together do not
+// retry in lockstep.
+func (c RetryConfig) backoff(attempt int) time.Duration {
+	d := c.InitialInterval
+	for i := 1; i < attempt && d < c.MaxInterval; i++ {
+		d *= 2
+	}
+	if d > c.MaxInterval {
+		d = c.MaxInterval
+	}
+	return d/2 + jitter(d/2)
+}
+
+// Retry calls connect until it succeeds or cfg.MaxWait has elapsed, waiting
+// with exponential backoff and jitter between attempts, and returns the last
+// error when it gives up. Every attempt is logged as key=value pairs under
+// the dependency name.
+func Retry(dependency string, cfg RetryConfig, connect func() error) error {
+	start := now()
+	for attempt := 1; ; attempt++ {
+		err := connect()
+		elapsed := now().Sub(start).Round(time.Millisecond)
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("[startup] dependency=%s status=connected attempt=%d elapsed=%s", dependency, attempt, elapsed)
+			}
+			return nil
+		}
+
+		delay := cfg.backoff(attempt)
+		if elapsed+delay > cfg.MaxWait {
+			log.Printf("[startup] dependency=%s status=giving_up attempt=%d elapsed=%s max_wait=%s error=%q", dependency, attempt, elapsed, cfg.MaxWait, err.Error())
+			return err
+		}
+		log.Printf("[startup] dependency=%s status=unavailable attempt=%d elapsed=%s retry_in=%s error=%q", dependency, attempt, elapsed, delay.Round(time.Millisecond), err.Error())
+		sleep(delay)
+	}
+}
@@ -0,0 +1,105 @@
+package startup
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock makes Retry sleep instantly and without jitter.
+func fakeClock(t *testing.T) *[]time.Duration {
+	t.Helper()
+	current := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	origNow, origSleep, origJitter := now, sleep, jitter
+	now = func() time.Time { return current }
+	sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		current = current.Add(d)
+	}
+	jitter = func(d time.Duration) time.Duration { return d }
+	t.Cleanup(func() { now, sleep, jitter = origNow, origSleep, origJitter })
+	return &slept
+}
+
+func TestBackoff(t *testing.T) {
+	fakeClock(t)
+	cfg := RetryConfig{InitialInterval: 500 * time.Millisecond, MaxInterval: 3 * time.Second}
+	want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for i, w := range want {
+		if got := cfg.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	slept := fakeClock(t)
+	cfg := RetryConfig{InitialInterval: time.Second, MaxInterval: 10 * time.Second, MaxWait: time.Minute}
+
+	attempts := 0
+	err := Retry("test", cfg, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Retry() = %v, want nil", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+	if len(*slept) != 2 || (*slept)[0] != time.Second || (*slept)[1] != 2*time.Second {
+		t.Errorf("slept = %v, want [1s 2s]", *slept)
+	}
+}
+
+func TestRetryGivesUpAfterMaxWait(t *testing.T) {
+	slept := fakeClock(t)
+	cfg := RetryConfig{InitialInterval: time.Second, MaxInterval: 4 * time.Second, MaxWait: 10 * time.Second}
+
+	wantErr := errors.New("connection refused")
+	attempts := 0
+	err := Retry("test", cfg, func() error {
+		attempts++
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("Retry() = %v, want %v", err, wantErr)
+	}
+	// Waits 1s, 2s and 4s; another 4s would exceed the 10s budget
+	if attempts != 4 || len(*slept) != 3 {
+		t.Errorf("attempts = %d, sleeps = %v, want 4 attempts and 3 sleeps", attempts, *slept)
+	}
+}
+
+func TestRetryWithoutMaxWaitTriesOnce(t *testing.T) {
+	slept := fakeClock(t)
+	attempts := 0
+	err := Retry("test", RetryConfig{InitialInterval: time.Second, MaxInterval: time.Second}, func() error {
+		attempts++
+		return errors.New("connection refused")
+	})
+	if err == nil || attempts != 1 || len(*slept) != 0 {
+		t.Errorf("Retry() = %v after %d attempts and %d sleeps, want an error after 1 attempt", err, attempts, len(*slept))
+	}
+}
+
+func TestRetryConfigFromEnv(t *testing.T) {
+	t.Setenv("STARTUP_RETRY_INITIAL_INTERVAL_MS", "")
+	t.Setenv("STARTUP_RETRY_MAX_INTERVAL_SECONDS", "")
+	t.Setenv("STARTUP_RETRY_MAX_WAIT_SECONDS", "")
+	if got := RetryConfigFromEnv(); got != (RetryConfig{DefaultInitialInterval, DefaultMaxInterval, DefaultMaxWait}) {
+		t.Errorf("RetryConfigFromEnv() = %+v, want defaults", got)
+	}
+
+	t.Setenv("STARTUP_RETRY_INITIAL_INTERVAL_MS", "250")
+	t.Setenv("STARTUP_RETRY_MAX_INTERVAL_SECONDS", "5")
+	t.Setenv("STARTUP_RETRY_MAX_WAIT_SECONDS", "0")
+	want := RetryConfig{InitialInterval: 250 * time.Millisecond, MaxInterval: 5 * time.Second}
+	if got := RetryConfigFromEnv(); got != want {
+		t.Errorf("RetryConfigFromEnv() = %+v, want %+v", got, want)
+	}
+}
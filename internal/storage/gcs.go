@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// gcsScope is the OAuth scope needed to read and write objects.
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// gcsEndpoint is the Cloud Storage XML API endpoint.
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// GCS stores objects in a Google Cloud Storage bucket.
+type GCS struct {
+	bucket string
+	client *http.Client
+}
+
+// NewGCS creates a GCS backend authenticated with the service account key in
+// cfg.GCSCredentialsJSON, or with the application default credentials when it
+// is empty.
+func NewGCS(cfg Config) (*GCS, error) {
+	if cfg.GCSBucket == "" {
+		return nil, errors.New("GCS bucket is not set")
+	}
+	ctx := context.Background()
+	var creds *google.Credentials
+	var err error
+	if cfg.GCSCredentialsJSON != "" {
+		creds, err = google.CredentialsFromJSON(ctx, []byte(cfg.GCSCredentialsJSON), gcsScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, gcsScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GCS credentials: %w", err)
+	}
+	return &GCS{bucket: cfg.GCSBucket, client: oauth2.NewClient(ctx, creds.TokenSource)}, nil
+}
+
+// Name implements Backend.
+func (g *GCS) Name() string { return BackendGCS }
+
+func (g *GCS) do(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("invalid storage key %q", key)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, gcsEndpoint+"/"+g.bucket+"/"+escapeS3Path(key), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return g.client.Do(req)
+}
+
+// Put implements Backend.
+func (g *GCS) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	resp, err := g.do(ctx, http.MethodPut, key, body, size, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp, "GCS")
+}
+
+// Get implements Backend.
+func (g *GCS) Get(ctx context.Context, key string) (*Object, error) {
+	resp, err := g.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp, "GCS"); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return &Object{Body: resp.Body, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}, nil
+}
+
+// Delete implements Backend.
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	resp, err := g.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp, "GCS")
+}
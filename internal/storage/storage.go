@@ -0,0 +1,218 @@
+// Package storage stores files such as profile pictures, export archives and
+// archived activity logs on the backend configured in the system settings:
+// the local disk, an S3-compatible object store or Google Cloud Storage.
+//
+// Objects are addressed by slash-separated keys (e.g. "avatars/<app>/<file>").
+// Current returns the configured backend; it is rebuilt when the settings
+// change, so switching backends does not need a restart. Objects are not
+// migrated between backends.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported backends.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendGCS   = "gcs"
+)
+
+// Setting keys read through the resolver set with SetSettingResolver.
+const (
+	SettingBackend            = "STORAGE_BACKEND"
+	SettingLocalPath          = "STORAGE_LOCAL_PATH"
+	SettingS3Endpoint         = "STORAGE_S3_ENDPOINT"
+	SettingS3Region           = "STORAGE_S3_REGION"
+	SettingS3Bucket           = "STORAGE_S3_BUCKET"
+	SettingS3AccessKeyID      = "STORAGE_S3_ACCESS_KEY_ID"
+	SettingS3SecretAccessKey  = "STORAGE_S3_SECRET_ACCESS_KEY"
+	SettingS3ForcePathStyle   = "STORAGE_S3_FORCE_PATH_STYLE"
+	SettingGCSBucket          = "STORAGE_GCS_BUCKET"
+	SettingGCSCredentialsJSON = "STORAGE_GCS_CREDENTIALS_JSON"
+)
+
+// configTTL is how long the resolved settings are reused before they are
+// resolved again.
+const configTTL = time.Minute
+
+// ErrNotFound is returned by Get and Delete when the object does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Object is an object read from a backend. The caller must close Body.
+type Object struct {
+	Body        io.ReadCloser
+	Size        int64  // -1 when unknown
+	ContentType string // may be empty
+}
+
+// Backend stores objects by key.
+type Backend interface {
+	// Name returns the backend name (BackendLocal, BackendS3 or BackendGCS).
+	Name() string
+	// Put stores size bytes read from body under key, replacing any existing
+	// object. size may be -1 when unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key.
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects and configures a backend.
+type Config struct {
+	Backend            string
+	LocalPath          string
+	S3Endpoint         string
+	S3Region           string
+	S3Bucket           string
+	S3AccessKeyID      string
+	S3SecretAccessKey  string
+	S3ForcePathStyle   bool
+	GCSBucket          string
+	GCSCredentialsJSON string
+}
+
+// DefaultConfig is used for settings that are unset.
+var DefaultConfig = Config{
+	Backend:   BackendLocal,
+	LocalPath: "./data/storage",
+	S3Region:  "us-east-1",
+}
+
+// New creates the backend described by cfg.
+func New(cfg Config) (Backend, error) {
+	switch cfg.Backend {
+	case BackendLocal:
+		return NewLocal(cfg.LocalPath)
+	case BackendS3:
+		return NewS3(cfg)
+	case BackendGCS:
+		return NewGCS(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// ValidateSetting checks a single setting value, for the admin settings editor.
+func ValidateSetting(key, value string) error {
+	switch key {
+	case SettingBackend:
+		switch value {
+		case BackendLocal, BackendS3, BackendGCS:
+			return nil
+		}
+		return fmt.Errorf("storage backend must be %s, %s or %s", BackendLocal, BackendS3, BackendGCS)
+	case SettingS3Endpoint:
+		if value != "" && !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+			return errors.New("endpoint must be an http:// or https:// URL")
+		}
+	}
+	return nil
+}
+
+// ValidKey reports whether key is a clean, relative object key.
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	return path.Clean(key) == key && key != "." && !strings.HasPrefix(key, "../") && key != ".."
+}
+
+// SettingResolverFunc resolves a system setting by key (env > DB > default).
+// Encrypted values must be returned decrypted.
+type SettingResolverFunc func(key string) string
+
+var (
+	mu         sync.Mutex
+	resolver   SettingResolverFunc
+	current    Backend
+	currentCfg Config
+	currentErr error
+	resolvedAt time.Time
+)
+
+// SetSettingResolver makes the package read its configuration from the system
+// settings. Without a resolver DefaultConfig is used.
+func SetSettingResolver(fn SettingResolverFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	resolver = fn
+	resolvedAt = time.Time{}
+}
+
+// ReloadSettings makes the next Current resolve the settings again, rebuilding
+// the backend if they changed.
+func ReloadSettings() {
+	mu.Lock()
+	defer mu.Unlock()
+	resolvedAt = time.Time{}
+}
+
+// Current returns the configured backend.
+func Current() (Backend, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil && time.Since(resolvedAt) <= configTTL {
+		return current, currentErr
+	}
+	cfg := DefaultConfig
+	if resolver != nil {
+		cfg = resolveConfig(resolver)
+	}
+	resolvedAt = time.Now()
+	if current == nil || cfg != currentCfg {
+		current, currentErr = New(cfg)
+		if currentErr != nil {
+			current = unavailable{name: cfg.Backend, err: currentErr}
+		}
+		currentCfg = cfg
+	}
+	return current, currentErr
+}
+
+// resolveConfig reads the configuration from the settings.
+func resolveConfig(resolve SettingResolverFunc) Config {
+	cfg := DefaultConfig
+	if v := strings.ToLower(strings.TrimSpace(resolve(SettingBackend))); v != "" {
+		cfg.Backend = v
+	}
+	if v := strings.TrimSpace(resolve(SettingLocalPath)); v != "" {
+		cfg.LocalPath = v
+	}
+	if v := strings.TrimSpace(resolve(SettingS3Region)); v != "" {
+		cfg.S3Region = v
+	}
+	cfg.S3Endpoint = strings.TrimSpace(resolve(SettingS3Endpoint))
+	cfg.S3Bucket = strings.TrimSpace(resolve(SettingS3Bucket))
+	cfg.S3AccessKeyID = strings.TrimSpace(resolve(SettingS3AccessKeyID))
+	cfg.S3SecretAccessKey = resolve(SettingS3SecretAccessKey)
+	cfg.S3ForcePathStyle, _ = strconv.ParseBool(resolve(SettingS3ForcePathStyle))
+	cfg.GCSBucket = strings.TrimSpace(resolve(SettingGCSBucket))
+	cfg.GCSCredentialsJSON = resolve(SettingGCSCredentialsJSON)
+	return cfg
+}
+
+// unavailable is returned by Current when the configured backend cannot be
+// created, so that callers holding it get the configuration error.
+type unavailable struct {
+	name string
+	err  error
+}
+
+func (u unavailable) Name() string { return u.name }
+
+func (u unavailable) Put(context.Context, string, io.Reader, int64, string) error { return u.err }
+
+func (u unavailable) Get(context.Context, string) (*Object, error) { return nil, u.err }
+
+func (u unavailable) Delete(context.Context, string) error { return u.err }
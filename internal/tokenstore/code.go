@@ -0,0 +1,134 @@
+package tokenstore
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+)
+
+// Numeric codes are the alternative to links for applications whose users
+// type a short code instead of following a link. A user has at most one code
+// per purpose: issuing a new one replaces the previous one. Like tokens, only
+// a hash is stored; a code is single-use and is discarded after
+// CodeMaxAttempts wrong guesses.
+
+// codeDigits is the length of a numeric code.
+const codeDigits = 6
+
+// ErrTooManyAttempts is returned when a wrong code used up the last allowed
+// attempt; the code has been discarded and a new one must be requested.
+var ErrTooManyAttempts = errors.New("too many incorrect attempts")
+
+// CodeTTL returns how long codes stay valid
+// (EMAIL_VERIFICATION_CODE_TTL_MINUTES, default 15 minutes; re-authentication
+// codes 5 minutes).
+func CodeTTL(purpose Purpose) time.Duration {
+	switch purpose {
+	case PurposeEmailVerification:
+		if m := viper.GetInt("EMAIL_VERIFICATION_CODE_TTL_MINUTES"); m > 0 {
+			return time.Duration(m) * time.Minute
+		}
+	case PurposeReauth:
+		return 5 * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+// CodeMaxAttempts returns how many wrong guesses a code allows
+// (EMAIL_VERIFICATION_CODE_MAX_ATTEMPTS, default 5).
+func CodeMaxAttempts() int64 {
+	if n := viper.GetInt64("EMAIL_VERIFICATION_CODE_MAX_ATTEMPTS"); n > 0 {
+		return n
+	}
+	return 5
+}
+
+func codeKey(appID string, purpose Purpose, userID string) string {
+	return fmt.Sprintf("app:%s:code:%s:%s", appID, purpose, userID)
+}
+
+// IssueCode creates a numeric code of purpose for userID, valid for
+// CodeTTL(purpose), replacing any previous code, and returns it.
+func IssueCode(appID, userID string, purpose Purpose) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	code := fmt.Sprintf("%0*d", codeDigits, n.Int64())
+	key := codeKey(appID, purpose, userID)
+	_, err = redis.Rdb.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, "hash", hashSecret(code), "attempts", 0)
+		pipe.Expire(ctx, key, CodeTTL(purpose))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// VerifyCode checks the code of purpose issued to userID and uses it up.
+// Every call counts as an attempt before the code is compared, so concurrent
+// guesses cannot outrun the limit: a wrong code returns ErrInvalidToken, and
+// the attempt that reaches CodeMaxAttempts (or any past it) discards the code
+// and returns ErrTooManyAttempts. Of concurrent calls with the right code only
+// one succeeds.
+func VerifyCode(appID, userID string, purpose Purpose, code string) error {
+	key := codeKey(appID, purpose, userID)
+	var attempts *goredis.IntCmd
+	var ttl *goredis.DurationCmd
+	var stored *goredis.StringCmd
+	if _, err := redis.Rdb.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		attempts = pipe.HIncrBy(ctx, key, "attempts", 1)
+		ttl = pipe.TTL(ctx, key)
+		stored = pipe.HGet(ctx, key, "hash")
+		return nil
+	}); err != nil && err != goredis.Nil {
+		return err
+	}
+	if ttl.Val() < 0 || stored.Err() != nil {
+		// There was no code (expired, used or never issued) and the increment
+		// created the key without an expiry
+		redis.Rdb.Del(ctx, key)
+		return ErrInvalidToken
+	}
+	max := CodeMaxAttempts()
+	if attempts.Val() > max {
+		if err := redis.Rdb.Del(ctx, key).Err(); err != nil {
+			return err
+		}
+		return ErrTooManyAttempts
+	}
+
+	if len(code) == codeDigits && subtle.ConstantTimeCompare([]byte(stored.Val()), []byte(hashSecret(code))) == 1 {
+		deleted, err := redis.Rdb.Del(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return ErrInvalidToken // Used by a concurrent request
+		}
+		return nil
+	}
+
+	if attempts.Val() >= max {
+		if err := redis.Rdb.Del(ctx, key).Err(); err != nil {
+			return err
+		}
+		return ErrTooManyAttempts
+	}
+	return ErrInvalidToken
+}
+
+// RevokeCode discards the code of purpose issued to userID, if any.
+func RevokeCode(appID, userID string, purpose Purpose) error {
+	return redis.Rdb.Del(ctx, codeKey(appID, purpose, userID)).Err()
+}
@@ -0,0 +1,68 @@
+package tokenstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestIssueAndVerifyCode(t *testing.T) {
+	requireRedis(t)
+	appID, userID := uuid.NewString(), uuid.NewString()
+
+	first, err := IssueCode(appID, userID, PurposeEmailVerification)
+	if err != nil {
+		t.Fatalf("IssueCode: %v", err)
+	}
+	if len(first) != 6 {
+		t.Errorf("code %q, want 6 digits", first)
+	}
+	if stored := redis.Rdb.HGet(ctx, codeKey(appID, PurposeEmailVerification, userID), "hash").Val(); stored == first {
+		t.Error("code stored in plain text")
+	}
+
+	// A new code replaces the previous one
+	code, _ := IssueCode(appID, userID, PurposeEmailVerification)
+	if code != first {
+		if err := VerifyCode(appID, userID, PurposeEmailVerification, first); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("VerifyCode with the replaced code = %v, want ErrInvalidToken", err)
+		}
+	}
+	if err := VerifyCode(appID, userID, PurposeEmailVerification, code); err != nil {
+		t.Fatalf("VerifyCode: %v", err)
+	}
+	if err := VerifyCode(appID, userID, PurposeEmailVerification, code); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("second VerifyCode = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyCodeAttemptLimit(t *testing.T) {
+	requireRedis(t)
+	viper.Set("EMAIL_VERIFICATION_CODE_MAX_ATTEMPTS", 3)
+	defer viper.Set("EMAIL_VERIFICATION_CODE_MAX_ATTEMPTS", nil)
+	appID, userID := uuid.NewString(), uuid.NewString()
+
+	code, err := IssueCode(appID, userID, PurposeEmailVerification)
+	if err != nil {
+		t.Fatalf("IssueCode: %v", err)
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 1; i < 3; i++ {
+		if err := VerifyCode(appID, userID, PurposeEmailVerification, wrong); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("attempt %d = %v, want ErrInvalidToken", i, err)
+		}
+	}
+	if err := VerifyCode(appID, userID, PurposeEmailVerification, wrong); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("last attempt = %v, want ErrTooManyAttempts", err)
+	}
+	// The code is gone, even when guessed right afterwards
+	if err := VerifyCode(appID, userID, PurposeEmailVerification, code); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyCode after the limit = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,84 @@
+package tokenstore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// Tokens that were used or revoked leave a record behind for SpentTTL, so a
+// link opened again is recognised as a replay instead of an unknown token.
+// The record keeps the hash of the secret, so only the holder of the real
+// link can trigger a replay.
+
+// SpentTTL is how long used and revoked tokens are remembered.
+const SpentTTL = 7 * 24 * time.Hour
+
+// Replay reasons
+const (
+	ReplayUsed    = "used"    // The token was already used
+	ReplayRevoked = "revoked" // The token was revoked (newer link, password or email change)
+)
+
+// Client identifies the browser or device requesting or using a token.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// SameDevice reports whether c and other look like the same device: the same
+// IP address or the same user agent. Unknown clients never match.
+func (c Client) SameDevice(other Client) bool {
+	return (c.IP != "" && c.IP == other.IP) || (c.UserAgent != "" && c.UserAgent == other.UserAgent)
+}
+
+// ReplayError is returned by Lookup and Consume for a token that was used or
+// revoked in the last SpentTTL. It matches ErrInvalidToken with errors.Is.
+type ReplayError struct {
+	UserID string
+	Reason string    // ReplayUsed or ReplayRevoked
+	At     time.Time // When the token was used or revoked
+	By     Client    // Client that used the token (empty when revoked)
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("token already %s at %s", e.Reason, e.At.Format(time.RFC3339))
+}
+
+func (e *ReplayError) Unwrap() error { return ErrInvalidToken }
+
+func spentKey(appID string, purpose Purpose, id string) string {
+	return fmt.Sprintf("app:%s:token_spent:%s:%s", appID, purpose, id)
+}
+
+// markSpent records a used or revoked token in pipe.
+func markSpent(pipe goredis.Pipeliner, appID string, purpose Purpose, id, userID, hash, reason string, by Client) {
+	key := spentKey(appID, purpose, id)
+	pipe.HSet(ctx, key,
+		"user", userID, "hash", hash, "reason", reason,
+		"at", strconv.FormatInt(time.Now().Unix(), 10),
+		"ip", by.IP, "ua", by.UserAgent)
+	pipe.Expire(ctx, key, SpentTTL)
+}
+
+// lookupSpent returns the ReplayError of a used or revoked token, or nil if
+// the token is unknown.
+func lookupSpent(appID string, purpose Purpose, id, secret string) (*ReplayError, error) {
+	fields, err := redis.Rdb.HGetAll(ctx, spentKey(appID, purpose, id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if fields["user"] == "" || !hashMatches(fields["hash"], secret) {
+		return nil, nil
+	}
+	at, _ := strconv.ParseInt(fields["at"], 10, 64)
+	return &ReplayError{
+		UserID: fields["user"],
+		Reason: fields["reason"],
+		At:     time.Unix(at, 0).UTC(),
+		By:     Client{IP: fields["ip"], UserAgent: fields["ua"]},
+	}, nil
+}
@@ -0,0 +1,89 @@
+package tokenstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSameDevice(t *testing.T) {
+	phone := Client{IP: "203.0.113.7", UserAgent: "Mobile Safari"}
+	tests := []struct {
+		name  string
+		other Client
+		want  bool
+	}{
+		{"same IP and agent", phone, true},
+		{"same IP", Client{IP: phone.IP, UserAgent: "Firefox"}, true},
+		{"same agent", Client{IP: "198.51.100.1", UserAgent: phone.UserAgent}, true},
+		{"different", Client{IP: "198.51.100.1", UserAgent: "Firefox"}, false},
+		{"unknown", Client{}, false},
+	}
+	for _, tt := range tests {
+		if got := phone.SameDevice(tt.other); got != tt.want {
+			t.Errorf("%s: SameDevice = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if (Client{}).SameDevice(Client{}) {
+		t.Error("two unknown clients match")
+	}
+}
+
+func TestReplayOfUsedToken(t *testing.T) {
+	requireRedis(t)
+	appID, userID := uuid.NewString(), uuid.NewString()
+	requester := Client{IP: "203.0.113.7", UserAgent: "Firefox"}
+	user := Client{IP: "198.51.100.1", UserAgent: "Mobile Safari"}
+
+	token, err := IssueFor(appID, userID, PurposePasswordReset, requester)
+	if err != nil {
+		t.Fatalf("IssueFor: %v", err)
+	}
+	r, err := ConsumeFrom(appID, PurposePasswordReset, token, user)
+	if err != nil || r.UserID != userID || r.RequestedBy != requester {
+		t.Fatalf("ConsumeFrom = %+v, %v; want user %s requested by %+v", r, err, userID, requester)
+	}
+
+	_, err = Lookup(appID, PurposePasswordReset, token)
+	var replay *ReplayError
+	if !errors.As(err, &replay) {
+		t.Fatalf("Lookup of a used token = %v, want a ReplayError", err)
+	}
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Error("ReplayError does not match ErrInvalidToken")
+	}
+	if replay.UserID != userID || replay.Reason != ReplayUsed || replay.By != user || replay.At.IsZero() {
+		t.Errorf("ReplayError = %+v, want used by %+v", replay, user)
+	}
+
+	// A wrong secret for the same id is not a replay
+	id, _, _ := splitToken(token)
+	if _, err := Lookup(appID, PurposePasswordReset, id+".wrong"); !errors.Is(err, ErrInvalidToken) || errors.As(err, &replay) {
+		t.Errorf("Lookup with a wrong secret = %v, want a plain ErrInvalidToken", err)
+	}
+}
+
+func TestReplayOfRevokedToken(t *testing.T) {
+	requireRedis(t)
+	appID, userID := uuid.NewString(), uuid.NewString()
+
+	first, err := Issue(appID, userID, PurposePasswordReset)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, _ := Issue(appID, userID, PurposePasswordReset)
+	if _, err := Consume(appID, PurposePasswordReset, second); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+
+	_, err = Consume(appID, PurposePasswordReset, first)
+	var replay *ReplayError
+	if !errors.As(err, &replay) || replay.Reason != ReplayRevoked {
+		t.Fatalf("Consume of a revoked token = %v, want a revoked ReplayError", err)
+	}
+	// The used token keeps its own reason
+	if _, err := Consume(appID, PurposePasswordReset, second); !errors.As(err, &replay) || replay.Reason != ReplayUsed {
+		t.Errorf("Consume of the used token = %v, want a used ReplayError", err)
+	}
+}
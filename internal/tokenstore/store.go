@@ -0,0 +1,249 @@
+// Package tokenstore keeps the one-time tokens sent by email (email
+// verification and password reset links) in Redis.
+//
+// A token is "<id>.<secret>". Redis stores only a SHA-256 hash of the secret,
+// under a key derived from the id, so a dump of Redis does not reveal usable
+// links; the hash is compared in constant time. Tokens are single-use, expire
+// after a per-purpose TTL, and every outstanding token of a user can be
+// revoked at once (e.g. when the password changes). Used and revoked tokens
+// are remembered for a while, so replays can be told apart and logged (see
+// ReplayError).
+package tokenstore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+)
+
+// Purpose is what a token may be used for; a token issued for one purpose is
+// never accepted for another.
+type Purpose string
+
+const (
+	PurposeEmailVerification Purpose = "email_verify"
+	PurposePasswordReset     Purpose = "password_reset"
+	PurposeReauth            Purpose = "reauth" // Codes for re-authentication challenges
+	// Login challenges for expired passwords
+	PurposePasswordChange Purpose = "password_change"
+)
+
+var ctx = context.Background()
+
+// ErrInvalidToken is returned for a token that is malformed, unknown,
+// expired, revoked or already used.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// TTL returns how long tokens of purpose stay valid
+// (EMAIL_VERIFICATION_TOKEN_TTL_MINUTES, default 24 hours;
+// PASSWORD_RESET_TOKEN_TTL_MINUTES, default 60 minutes; password change
+// challenges 10 minutes).
+func TTL(purpose Purpose) time.Duration {
+	switch purpose {
+	case PurposeEmailVerification:
+		if m := viper.GetInt("EMAIL_VERIFICATION_TOKEN_TTL_MINUTES"); m > 0 {
+			return time.Duration(m) * time.Minute
+		}
+		return 24 * time.Hour
+	case PurposePasswordReset:
+		if m := viper.GetInt("PASSWORD_RESET_TOKEN_TTL_MINUTES"); m > 0 {
+			return time.Duration(m) * time.Minute
+		}
+		return time.Hour
+	case PurposePasswordChange:
+		return 10 * time.Minute
+	}
+	return time.Hour
+}
+
+func tokenKey(appID string, purpose Purpose, id string) string {
+	return fmt.Sprintf("app:%s:token:%s:%s", appID, purpose, id)
+}
+
+// userKey holds the ids of a user's outstanding tokens of one purpose.
+func userKey(appID string, purpose Purpose, userID string) string {
+	return fmt.Sprintf("app:%s:token_user:%s:%s", appID, purpose, userID)
+}
+
+// Issue creates a token of purpose for userID, valid for TTL(purpose), and
+// returns it. Only its hash is stored.
+func Issue(appID, userID string, purpose Purpose) (string, error) {
+	return IssueFor(appID, userID, purpose, Client{})
+}
+
+// IssueFor is Issue for a token requested by client, which is returned with
+// the token when it is used (see Redemption).
+func IssueFor(appID, userID string, purpose Purpose, client Client) (string, error) {
+	id, secret, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	ttl := TTL(purpose)
+	uKey := userKey(appID, purpose, userID)
+	_, err = redis.Rdb.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		key := tokenKey(appID, purpose, id)
+		pipe.HSet(ctx, key, "user", userID, "hash", hashSecret(secret), "ip", client.IP, "ua", client.UserAgent)
+		pipe.Expire(ctx, key, ttl)
+		pipe.SAdd(ctx, uKey, id)
+		pipe.Expire(ctx, uKey, ttl) // Outlives every token in the set
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return id + "." + secret, nil
+}
+
+// Lookup returns the user a valid token of purpose was issued to, without
+// using it up. Callers that still have to validate input before acting on the
+// token call Consume once that succeeded. A token used or revoked before
+// returns a *ReplayError.
+func Lookup(appID string, purpose Purpose, token string) (string, error) {
+	fields, err := lookup(appID, purpose, token)
+	if err != nil {
+		return "", err
+	}
+	return fields["user"], nil
+}
+
+// lookup returns the stored fields of a valid token.
+func lookup(appID string, purpose Purpose, token string) (map[string]string, error) {
+	id, secret, ok := splitToken(token)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	fields, err := redis.Rdb.HGetAll(ctx, tokenKey(appID, purpose, id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if fields["user"] != "" && hashMatches(fields["hash"], secret) {
+		return fields, nil
+	}
+	if fields["user"] == "" {
+		replay, err := lookupSpent(appID, purpose, id, secret)
+		if err != nil {
+			return nil, err
+		}
+		if replay != nil {
+			return nil, replay
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+// Redemption is a token that was used.
+type Redemption struct {
+	UserID      string
+	RequestedBy Client // Client that requested the token (empty if not recorded)
+}
+
+// Consume validates a token of purpose and uses it up, returning the user it
+// was issued to. Of concurrent calls with the same token only one succeeds.
+// Using a password reset or change token also revokes the user's other tokens
+// of that purpose.
+func Consume(appID string, purpose Purpose, token string) (string, error) {
+	r, err := ConsumeFrom(appID, purpose, token, Client{})
+	return r.UserID, err
+}
+
+// ConsumeFrom is Consume for a token used by client, which is reported if the
+// token is replayed.
+func ConsumeFrom(appID string, purpose Purpose, token string, client Client) (Redemption, error) {
+	fields, err := lookup(appID, purpose, token)
+	if err != nil {
+		return Redemption{}, err
+	}
+	r := Redemption{UserID: fields["user"], RequestedBy: Client{IP: fields["ip"], UserAgent: fields["ua"]}}
+	id, _, _ := splitToken(token)
+	deleted, err := redis.Rdb.Del(ctx, tokenKey(appID, purpose, id)).Result()
+	if err != nil {
+		return Redemption{}, err
+	}
+	if deleted == 0 {
+		return Redemption{}, ErrInvalidToken // Used by a concurrent request
+	}
+
+	_, err = redis.Rdb.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		markSpent(pipe, appID, purpose, id, r.UserID, fields["hash"], ReplayUsed, client)
+		return nil
+	})
+	if err == nil {
+		if purpose == PurposePasswordReset || purpose == PurposePasswordChange {
+			err = RevokeAll(appID, r.UserID, purpose)
+		} else {
+			err = redis.Rdb.SRem(ctx, userKey(appID, purpose, r.UserID), id).Err()
+		}
+	}
+	if err != nil {
+		// The token itself is gone; only cleanup failed
+		return r, fmt.Errorf("token used, but cleanup failed: %w", err)
+	}
+	return r, nil
+}
+
+// RevokeAll invalidates every outstanding token of purpose issued to userID.
+func RevokeAll(appID, userID string, purpose Purpose) error {
+	uKey := userKey(appID, purpose, userID)
+	ids, err := redis.Rdb.SMembers(ctx, uKey).Result()
+	if err != nil {
+		return err
+	}
+	hashes := make([]*goredis.StringCmd, len(ids))
+	if len(ids) > 0 {
+		if _, err := redis.Rdb.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for i, id := range ids {
+				hashes[i] = pipe.HGet(ctx, tokenKey(appID, purpose, id), "hash")
+			}
+			return nil
+		}); err != nil && err != goredis.Nil {
+			return err
+		}
+	}
+	_, err = redis.Rdb.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, id := range ids {
+			// Tokens already used or expired have no hash left
+			if hash := hashes[i].Val(); hash != "" {
+				markSpent(pipe, appID, purpose, id, userID, hash, ReplayRevoked, Client{})
+			}
+			pipe.Del(ctx, tokenKey(appID, purpose, id))
+		}
+		pipe.Del(ctx, uKey)
+		return nil
+	})
+	return err
+}
+
+// newToken returns a random token id (for the Redis key) and secret.
+func newToken() (id, secret string, err error) {
+	b := make([]byte, 9+32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:9]), base64.RawURLEncoding.EncodeToString(b[9:]), nil
+}
+
+func splitToken(token string) (id, secret string, ok bool) {
+	id, secret, ok = strings.Cut(token, ".")
+	return id, secret, ok && id != "" && secret != ""
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashMatches compares a stored hash with the hash of secret in constant time.
+func hashMatches(stored, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(hashSecret(secret))) == 1
+}
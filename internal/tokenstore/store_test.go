@@ -0,0 +1,165 @@
+package tokenstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/redis"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// requireRedis connects to the test Redis (REDIS_ADDR, DB 1) or skips the test.
+func requireRedis(t *testing.T) {
+	t.Helper()
+	viper.AutomaticEnv()
+	viper.SetDefault("REDIS_ADDR", "localhost:6379")
+	viper.SetDefault("REDIS_DB", 1)
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     viper.GetString("REDIS_ADDR"),
+		Password: viper.GetString("REDIS_PASSWORD"),
+		DB:       viper.GetInt("REDIS_DB"),
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		t.Skipf("Redis not available: %v", err)
+	}
+	orig := redis.Rdb
+	redis.Rdb = client
+	t.Cleanup(func() {
+		redis.Rdb = orig
+		_ = client.Close()
+	})
+}
+
+func TestTTL(t *testing.T) {
+	defer viper.Set("PASSWORD_RESET_TOKEN_TTL_MINUTES", nil)
+
+	if got := TTL(PurposeEmailVerification); got != 24*time.Hour {
+		t.Errorf("TTL(email_verify) = %s, want 24h by default", got)
+	}
+	if got := TTL(PurposePasswordReset); got != time.Hour {
+		t.Errorf("TTL(password_reset) = %s, want 1h by default", got)
+	}
+	viper.Set("PASSWORD_RESET_TOKEN_TTL_MINUTES", 15)
+	if got := TTL(PurposePasswordReset); got != 15*time.Minute {
+		t.Errorf("TTL(password_reset) = %s, want the configured 15m", got)
+	}
+	if got := TTL(PurposePasswordChange); got != 10*time.Minute {
+		t.Errorf("TTL(password_change) = %s, want 10m", got)
+	}
+}
+
+func TestTokenFormat(t *testing.T) {
+	id, secret, err := newToken()
+	if err != nil {
+		t.Fatalf("newToken: %v", err)
+	}
+	if len(id) != 12 || len(secret) != 43 {
+		t.Errorf("id %q and secret %q, want 12 and 43 base64url characters", id, secret)
+	}
+	other, _, _ := newToken()
+	if other == id {
+		t.Error("two tokens share an id")
+	}
+
+	for _, bad := range []string{"", "abc", ".abc", "abc.", "6f1c6a5e-8c53-4d1f-9d0e-2c0b1c2d3e4f"} {
+		if _, _, ok := splitToken(bad); ok {
+			t.Errorf("splitToken(%q) accepted a malformed token", bad)
+		}
+	}
+	if hashSecret(secret) == secret || len(hashSecret(secret)) != 64 {
+		t.Errorf("hashSecret(%q) = %q, want a hex SHA-256", secret, hashSecret(secret))
+	}
+}
+
+func TestMalformedTokenDoesNotReachRedis(t *testing.T) {
+	orig := redis.Rdb
+	redis.Rdb = nil // Any Redis call would panic
+	defer func() { redis.Rdb = orig }()
+
+	if _, err := Lookup("app", PurposePasswordReset, "not-a-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Lookup = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestIssueAndConsume(t *testing.T) {
+	requireRedis(t)
+	appID, userID := uuid.NewString(), uuid.NewString()
+
+	token, err := Issue(appID, userID, PurposeEmailVerification)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	id, secret, _ := splitToken(token)
+	stored, err := redis.Rdb.HGet(ctx, tokenKey(appID, PurposeEmailVerification, id), "hash").Result()
+	if err != nil || stored == secret || strings.Contains(stored, secret) {
+		t.Errorf("stored hash = %q (err %v), want only the hash of the secret", stored, err)
+	}
+	if ttl := redis.Rdb.TTL(ctx, tokenKey(appID, PurposeEmailVerification, id)).Val(); ttl <= 0 || ttl > 24*time.Hour {
+		t.Errorf("token TTL = %s, want at most 24h", ttl)
+	}
+
+	// Another purpose or a wrong secret is rejected
+	if _, err := Lookup(appID, PurposePasswordReset, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Lookup with another purpose = %v, want ErrInvalidToken", err)
+	}
+	if _, err := Lookup(appID, PurposeEmailVerification, id+".wrong"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Lookup with a wrong secret = %v, want ErrInvalidToken", err)
+	}
+
+	if got, err := Lookup(appID, PurposeEmailVerification, token); err != nil || got != userID {
+		t.Fatalf("Lookup = %q, %v; want %q", got, err, userID)
+	}
+	if got, err := Consume(appID, PurposeEmailVerification, token); err != nil || got != userID {
+		t.Fatalf("Consume = %q, %v; want %q", got, err, userID)
+	}
+	if _, err := Consume(appID, PurposeEmailVerification, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("second Consume = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestConsumeResetTokenRevokesOthers(t *testing.T) {
+	requireRedis(t)
+	appID, userID := uuid.NewString(), uuid.NewString()
+
+	first, err := Issue(appID, userID, PurposePasswordReset)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, _ := Issue(appID, userID, PurposePasswordReset)
+	verify, _ := Issue(appID, userID, PurposeEmailVerification)
+
+	if _, err := Consume(appID, PurposePasswordReset, second); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if _, err := Lookup(appID, PurposePasswordReset, first); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("earlier reset token still valid after a reset: %v", err)
+	}
+	if _, err := Lookup(appID, PurposeEmailVerification, verify); err != nil {
+		t.Errorf("verification token revoked by a reset: %v", err)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	requireRedis(t)
+	appID, userID := uuid.NewString(), uuid.NewString()
+
+	token, err := Issue(appID, userID, PurposePasswordReset)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if err := RevokeAll(appID, userID, PurposePasswordReset); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+	if _, err := Consume(appID, PurposePasswordReset, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Consume after RevokeAll = %v, want ErrInvalidToken", err)
+	}
+	// Revoking a user without tokens is not an error
+	if err := RevokeAll(appID, uuid.NewString(), PurposePasswordReset); err != nil {
+		t.Errorf("RevokeAll without tokens: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	stdlog "log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -736,15 +737,21 @@ func (h *Handler) createSessionOrTokens(appID, userID, ip, userAgent string, rol
 //
 // In all cases Secure=true is enforced in release mode so the cookie is never
 // transmitted over plain HTTP in production. In debug/test mode Secure=false so
-// local http://localhost flows continue to work.
+// local http://localhost flows continue to work, unless COOKIE_FORCE_SECURE is
+// true.
 func (h *Handler) trustedDeviceCookieAttrs() (secure bool, sameSite http.SameSite) {
 	secure = gin.Mode() == gin.ReleaseMode
 
-	var policy string
+	var policy, forceSecure string
 	if h.SettingResolver != nil {
 		policy = h.SettingResolver("TRUSTED_DEVICE_COOKIE_SAMESITE")
+		forceSecure = h.SettingResolver("COOKIE_FORCE_SECURE")
 	} else {
 		policy = os.Getenv("TRUSTED_DEVICE_COOKIE_SAMESITE")
+		forceSecure = os.Getenv("COOKIE_FORCE_SECURE")
+	}
+	if force, err := strconv.ParseBool(strings.TrimSpace(forceSecure)); err == nil && force {
+		secure = true
 	}
 	policy = strings.ToLower(strings.TrimSpace(policy))
 
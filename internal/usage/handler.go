@@ -0,0 +1,67 @@
+package usage
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Handler exposes usage metering endpoints on the Admin API.
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new usage handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{Service: service}
+}
+
+// GetTenantUsage returns monthly usage aggregates for a tenant and its applications
+// @Summary Get tenant usage
+// @Description Returns API calls, email sends and monthly active users per application of a tenant for a billing period
+// @Tags Admin
+// @Produce json
+// @Param   id      path   string  true   "Tenant ID"
+// @Param   period  query  string  false  "Billing period (YYYY-MM, UTC); defaults to the current month"
+// @Success 200 {object} dto.TenantUsageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Security AdminApiKey
+// @Router /admin/tenants/{id}/usage [get]
+func (h *Handler) GetTenantUsage(c *gin.Context) {
+	if h.Service == nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "Usage metering is disabled"})
+		return
+	}
+
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid Tenant ID"})
+		return
+	}
+
+	periodStart, err := ParsePeriod(c.Query("period"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	usage, err := h.Service.GetTenantUsage(tenantID, periodStart)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to compute usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
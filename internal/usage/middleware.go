@@ -0,0 +1,37 @@
+package usage
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// APICallMiddleware counts every request made on behalf of an application as
+// one API call. The application is taken from AppIDMiddleware or, for OIDC
+// routes, from the ":app_id" path parameter. Admin and GUI requests carry no
+// app and are therefore not metered.
+func (s *Service) APICallMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if appID := requestAppID(c); appID != uuid.Nil {
+			s.Record(appID, MetricAPICalls, 1)
+		}
+	}
+}
+
+func requestAppID(c *gin.Context) uuid.UUID {
+	if v, ok := c.Get(middleware.AppIDKey); ok {
+		if id, ok := v.(uuid.UUID); ok {
+			return id
+		}
+	}
+	if strings.HasPrefix(c.Request.URL.Path, "/oidc/") {
+		if id, err := uuid.Parse(c.Param("app_id")); err == nil {
+			return id
+		}
+	}
+	return uuid.Nil
+}
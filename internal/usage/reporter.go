@@ -0,0 +1,144 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Reporter pushes usage deltas to an external billing system.
+// Implement this interface to add a new billing provider.
+type Reporter interface {
+	// Report sends one usage delta. Implementations must treat Identifier as an
+	// idempotency key: the same record may be reported again if marking it as
+	// reported fails after a successful call.
+	Report(ctx context.Context, rec Report) error
+}
+
+// Report is a single usage delta for a tenant's billing customer.
+type Report struct {
+	Identifier string // Stable idempotency key for this delta
+	CustomerID string // Tenant billing customer ID
+	TenantID   uuid.UUID
+	AppID      uuid.UUID
+	Period     string // YYYY-MM
+	Metric     string
+	Quantity   int64 // Delta since the last successful report
+	Timestamp  time.Time
+}
+
+// Reporter constants for supported billing providers.
+const (
+	ReporterStripe   = "stripe"
+	ReporterDisabled = "" // Empty string = usage is metered but not reported
+)
+
+// NewReporterFromConfig creates the billing reporter selected by the
+// BILLING_REPORTER environment variable. Returns nil if reporting is disabled.
+//
+// Supported values for BILLING_REPORTER:
+//   - "stripe" — Stripe metered billing via meter events (requires STRIPE_SECRET_KEY
+//     and at least one STRIPE_METER_EVENT_* name)
+//   - "" (empty) — reporting is disabled; usage is still aggregated locally
+func NewReporterFromConfig() Reporter {
+	provider := viper.GetString("BILLING_REPORTER")
+
+	switch provider {
+	case ReporterStripe:
+		key := viper.GetString("STRIPE_SECRET_KEY")
+		events := map[string]string{
+			MetricAPICalls:    viper.GetString("STRIPE_METER_EVENT_API_CALLS"),
+			MetricEmailSends:  viper.GetString("STRIPE_METER_EVENT_EMAIL_SENDS"),
+			MetricActiveUsers: viper.GetString("STRIPE_METER_EVENT_ACTIVE_USERS"),
+		}
+		if key == "" {
+			log.Println("Warning: BILLING_REPORTER=stripe but STRIPE_SECRET_KEY is not set. Usage reporting will be disabled.")
+			return nil
+		}
+		log.Println("Billing reporter: Stripe meter events")
+		return NewStripeReporter(key, events)
+	case ReporterDisabled:
+		return nil
+	default:
+		log.Printf("Warning: unknown BILLING_REPORTER=%q. Usage reporting will be disabled.", provider)
+		return nil
+	}
+}
+
+// stripeMeterEventsURL is the Stripe Billing meter events endpoint.
+const stripeMeterEventsURL = "https://api.stripe.com/v1/billing/meter_events"
+
+// StripeReporter implements Reporter using Stripe Billing meter events.
+// Each metric maps to a meter event name; metrics without a configured event
+// name are acknowledged without being sent, so they are never billed.
+type StripeReporter struct {
+	secretKey  string
+	eventNames map[string]string
+	endpoint   string
+	client     *http.Client
+}
+
+// NewStripeReporter creates a new Stripe meter event reporter.
+func NewStripeReporter(secretKey string, eventNames map[string]string) *StripeReporter {
+	return &StripeReporter{
+		secretKey:  secretKey,
+		eventNames: eventNames,
+		endpoint:   stripeMeterEventsURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Report sends the delta as a Stripe meter event. The record identifier is used
+// as the meter event identifier, which Stripe de-duplicates.
+func (r *StripeReporter) Report(ctx context.Context, rec Report) error {
+	eventName := r.eventNames[rec.Metric]
+	if eventName == "" {
+		return nil
+	}
+
+	formData := url.Values{}
+	formData.Set("event_name", eventName)
+	formData.Set("identifier", rec.Identifier)
+	formData.Set("timestamp", strconv.FormatInt(rec.Timestamp.Unix(), 10))
+	formData.Set("payload[stripe_customer_id]", rec.CustomerID)
+	formData.Set("payload[value]", strconv.FormatInt(rec.Quantity, 10))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return fmt.Errorf("stripe: failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe: HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		var stripeErr struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if jsonErr := json.Unmarshal(respBody, &stripeErr); jsonErr == nil && stripeErr.Error.Message != "" {
+			return fmt.Errorf("stripe: API error %s: %s", stripeErr.Error.Code, stripeErr.Error.Message)
+		}
+		return fmt.Errorf("stripe: unexpected HTTP status %d", resp.StatusCode)
+	}
+
+	return nil
+}
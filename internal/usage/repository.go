@@ -0,0 +1,114 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles persistence of monthly usage aggregates.
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository creates a new usage Repository.
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// AddQuantity atomically adds delta to the (app, period, metric) aggregate,
+// creating the row on first use. The tenant is resolved from the application.
+func (r *Repository) AddQuantity(appID uuid.UUID, period, metric string, delta int64) error {
+	return r.DB.Exec(`
+		INSERT INTO usage_records (app_id, tenant_id, period, metric, quantity, reported_quantity, updated_at)
+		SELECT id, tenant_id, ?, ?, ?, 0, NOW() FROM applications WHERE id = ?
+		ON CONFLICT (app_id, period, metric)
+		DO UPDATE SET quantity = usage_records.quantity + EXCLUDED.quantity, updated_at = NOW()
+	`, period, metric, delta, appID).Error
+}
+
+// SnapshotActiveUsers stores the number of distinct users with activity in
+// [start, end) as the period's active-user aggregate for every application
+// that had activity. Unlike counters, the snapshot overwrites the previous value.
+// Service accounts are not billed as active users.
+func (r *Repository) SnapshotActiveUsers(period string, start, end time.Time) error {
+	return r.DB.Exec(`
+		INSERT INTO usage_records (app_id, tenant_id, period, metric, quantity, reported_quantity, updated_at)
+		SELECT a.id, a.tenant_id, ?, ?, COUNT(DISTINCT l.user_id), 0, NOW()
+		FROM activity_logs l
+		JOIN applications a ON a.id = l.app_id
+		WHERE l.timestamp >= ? AND l.timestamp < ? AND l.user_id <> ?
+			AND l.user_id NOT IN (`+models.ServiceAccountIDsSQL+`)
+		GROUP BY a.id, a.tenant_id
+		ON CONFLICT (app_id, period, metric)
+		DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = NOW()
+	`, period, MetricActiveUsers, start, end, uuid.Nil).Error
+}
+
+// CountActiveUsers returns distinct users with activity in [start, end) per
+// application, service accounts excluded.
+func (r *Repository) CountActiveUsers(appIDs []uuid.UUID, start, end time.Time) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(appIDs))
+	if len(appIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		AppID uuid.UUID
+		Count int64
+	}
+	if err := r.DB.Model(&models.ActivityLog{}).
+		Select("app_id, COUNT(DISTINCT user_id) AS count").
+		Where("app_id IN ? AND timestamp >= ? AND timestamp < ? AND user_id <> ?", appIDs, start, end, uuid.Nil).
+		Where("user_id NOT IN (" + models.ServiceAccountIDsSQL + ")").
+		Group("app_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.AppID] = row.Count
+	}
+	return counts, nil
+}
+
+// ListByTenantPeriod returns all usage aggregates of a tenant's applications for a period.
+func (r *Repository) ListByTenantPeriod(tenantID uuid.UUID, period string) ([]models.UsageRecord, error) {
+	var records []models.UsageRecord
+	err := r.DB.Where("tenant_id = ? AND period = ?", tenantID, period).Find(&records).Error
+	return records, err
+}
+
+// UnreportedRecord is a usage aggregate with quantity not yet pushed to the
+// billing reporter, joined with its tenant's billing customer.
+type UnreportedRecord struct {
+	models.UsageRecord
+	BillingCustomerID string
+}
+
+// ListUnreported returns aggregates whose quantity exceeds the reported quantity
+// for tenants that have a billing customer configured.
+func (r *Repository) ListUnreported(limit int) ([]UnreportedRecord, error) {
+	var records []UnreportedRecord
+	err := r.DB.Table("usage_records").
+		Select("usage_records.*, tenants.billing_customer_id").
+		Joins("JOIN tenants ON tenants.id = usage_records.tenant_id").
+		Where("usage_records.quantity > usage_records.reported_quantity AND tenants.billing_customer_id <> ''").
+		Order("usage_records.id").
+		Limit(limit).
+		Scan(&records).Error
+	return records, err
+}
+
+// MarkReported records that the aggregate has been reported up to quantity.
+func (r *Repository) MarkReported(id uint, quantity int64) error {
+	return r.DB.Model(&models.UsageRecord{}).Where("id = ?", id).Update("reported_quantity", quantity).Error
+}
+
+// GetTenant returns a tenant with its applications.
+func (r *Repository) GetTenant(tenantID uuid.UUID) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := r.DB.Preload("Apps").First(&tenant, "id = ?", tenantID).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
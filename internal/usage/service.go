@@ -0,0 +1,304 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/dto"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Billable metrics tracked per application and month.
+const (
+	MetricAPICalls    = "api_calls"
+	MetricEmailSends  = "email_sends"
+	MetricActiveUsers = "active_users"
+)
+
+// PeriodLayout is the month bucket format used for usage periods.
+const PeriodLayout = "2006-01"
+
+// reportBatchSize caps how many aggregates are reported per run.
+const reportBatchSize = 500
+
+// counterKey identifies one in-memory counter. The period is captured when the
+// event is recorded so events near a month boundary land in the right bucket.
+type counterKey struct {
+	AppID  uuid.UUID
+	Period string
+	Metric string
+}
+
+// Service meters per-application usage for billing. Counters are accumulated
+// in memory on the request path and flushed to usage_records periodically, so
+// metering never adds a database round-trip to API calls. On a separate,
+// slower schedule the service snapshots monthly active users from activity
+// logs and pushes unreported deltas to the optional billing Reporter.
+//
+// It runs as an in-process background goroutine (same pattern as
+// admin.ApiKeyNotificationService). Counts not yet flushed are lost if the
+// process crashes; a graceful Shutdown flushes them.
+type Service struct {
+	repo     *Repository
+	reporter Reporter
+
+	mu      sync.Mutex
+	pending map[counterKey]int64
+
+	flushInterval  time.Duration
+	reportInterval time.Duration
+	ctx            context.Context
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+}
+
+// NewService creates the service but does not start it. reporter may be nil,
+// in which case usage is aggregated locally but never pushed anywhere.
+func NewService(repo *Repository, reporter Reporter) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	flush := time.Duration(viper.GetInt("USAGE_FLUSH_INTERVAL_SECONDS")) * time.Second
+	if flush <= 0 {
+		flush = time.Minute
+	}
+	report := time.Duration(viper.GetInt("USAGE_REPORT_INTERVAL_MINUTES")) * time.Minute
+	if report <= 0 {
+		report = time.Hour
+	}
+	return &Service{
+		repo:           repo,
+		reporter:       reporter,
+		pending:        make(map[counterKey]int64),
+		flushInterval:  flush,
+		reportInterval: report,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// Start launches the background flush and report workers.
+func (s *Service) Start() {
+	s.wg.Add(1)
+	go s.worker()
+	log.Printf("Usage metering service started (flush: %s, report: %s)", s.flushInterval, s.reportInterval)
+}
+
+// Shutdown stops the background workers and flushes pending counters.
+func (s *Service) Shutdown() {
+	if s == nil {
+		return
+	}
+	log.Println("Shutting down usage metering service...")
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	s.flush()
+}
+
+// Record adds n to the metric counter of an application for the current month.
+// It is safe to call on a nil Service and ignores requests without an app.
+func (s *Service) Record(appID uuid.UUID, metric string, n int64) {
+	if s == nil || appID == uuid.Nil || n <= 0 {
+		return
+	}
+	key := counterKey{AppID: appID, Period: time.Now().UTC().Format(PeriodLayout), Metric: metric}
+	s.mu.Lock()
+	s.pending[key] += n
+	s.mu.Unlock()
+}
+
+// RecordEmailSent counts one successfully sent email. Its signature matches
+// email.SentCallback so it can be passed to SetSentCallback directly.
+func (s *Service) RecordEmailSent(appID uuid.UUID, _ string) {
+	s.Record(appID, MetricEmailSends, 1)
+}
+
+func (s *Service) worker() {
+	defer s.wg.Done()
+	flushTicker := time.NewTicker(s.flushInterval)
+	defer flushTicker.Stop()
+	reportTicker := time.NewTicker(s.reportInterval)
+	defer reportTicker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-flushTicker.C:
+			s.flush()
+		case <-reportTicker.C:
+			s.flush()
+			s.snapshotActiveUsers(time.Now().UTC())
+			s.report()
+		}
+	}
+}
+
+// takePending swaps out the pending counters so they can be flushed without
+// holding the lock during database writes.
+func (s *Service) takePending() map[counterKey]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return nil
+	}
+	batch := s.pending
+	s.pending = make(map[counterKey]int64)
+	return batch
+}
+
+// flush writes pending counters to the database. Counters that fail to persist
+// are merged back so they are retried on the next flush.
+func (s *Service) flush() {
+	batch := s.takePending()
+	for key, n := range batch {
+		if err := s.repo.AddQuantity(key.AppID, key.Period, key.Metric, n); err != nil {
+			log.Printf("Usage metering: failed to flush %s for app %s: %v", key.Metric, key.AppID, err)
+			s.mu.Lock()
+			s.pending[key] += n
+			s.mu.Unlock()
+		}
+	}
+}
+
+// snapshotActiveUsers refreshes the active-user aggregates for the current
+// month. During the first report interval of a month the previous month is
+// refreshed too, so activity just before the boundary is captured.
+func (s *Service) snapshotActiveUsers(now time.Time) {
+	start := monthStart(now)
+	periods := []time.Time{start}
+	if now.Sub(start) < s.reportInterval {
+		periods = append(periods, start.AddDate(0, -1, 0))
+	}
+	for _, p := range periods {
+		if err := s.repo.SnapshotActiveUsers(p.Format(PeriodLayout), p, p.AddDate(0, 1, 0)); err != nil {
+			log.Printf("Usage metering: failed to snapshot active users for %s: %v", p.Format(PeriodLayout), err)
+		}
+	}
+}
+
+// report pushes unreported deltas to the billing reporter.
+func (s *Service) report() {
+	if s.reporter == nil {
+		return
+	}
+	records, err := s.repo.ListUnreported(reportBatchSize)
+	if err != nil {
+		log.Printf("Usage metering: failed to list unreported usage: %v", err)
+		return
+	}
+	for _, rec := range records {
+		delta := rec.Quantity - rec.ReportedQuantity
+		err := s.reporter.Report(s.ctx, Report{
+			// Including the cumulative total makes the identifier unique per delta
+			// yet stable across retries of the same delta.
+			Identifier: fmt.Sprintf("%s-%s-%s-%d", rec.AppID, rec.Period, rec.Metric, rec.Quantity),
+			CustomerID: rec.BillingCustomerID,
+			TenantID:   rec.TenantID,
+			AppID:      rec.AppID,
+			Period:     rec.Period,
+			Metric:     rec.Metric,
+			Quantity:   delta,
+			Timestamp:  time.Now().UTC(),
+		})
+		if err != nil {
+			log.Printf("Usage metering: failed to report %s for app %s: %v", rec.Metric, rec.AppID, err)
+			continue
+		}
+		if err := s.repo.MarkReported(rec.ID, rec.Quantity); err != nil {
+			log.Printf("Usage metering: failed to mark usage %d as reported: %v", rec.ID, err)
+		}
+	}
+}
+
+// ParsePeriod parses a YYYY-MM period. An empty string means the current month (UTC).
+func ParsePeriod(period string, now time.Time) (time.Time, error) {
+	if period == "" {
+		return monthStart(now), nil
+	}
+	start, err := time.Parse(PeriodLayout, period)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid period. Use YYYY-MM")
+	}
+	return start, nil
+}
+
+// GetTenantUsage builds the usage report for all applications of a tenant in
+// the month starting at periodStart. Counters still pending in memory are
+// included and active users are computed live from activity logs.
+func (s *Service) GetTenantUsage(tenantID uuid.UUID, periodStart time.Time) (*dto.TenantUsageResponse, error) {
+	tenant, err := s.repo.GetTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	period := periodStart.Format(PeriodLayout)
+
+	records, err := s.repo.ListByTenantPeriod(tenantID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]uuid.UUID, 0, len(tenant.Apps))
+	apps := make(map[uuid.UUID]*dto.AppUsage, len(tenant.Apps))
+	resp := &dto.TenantUsageResponse{
+		TenantID:          tenant.ID.String(),
+		BillingCustomerID: tenant.BillingCustomerID,
+		Period:            period,
+		Apps:              make([]dto.AppUsage, len(tenant.Apps)),
+		GeneratedAt:       time.Now().UTC(),
+	}
+	for i, app := range tenant.Apps {
+		resp.Apps[i] = dto.AppUsage{AppID: app.ID.String(), AppName: app.Name}
+		apps[app.ID] = &resp.Apps[i]
+		appIDs = append(appIDs, app.ID)
+	}
+
+	for _, rec := range records {
+		if a, ok := apps[rec.AppID]; ok {
+			addQuantity(&a.Usage, rec.Metric, rec.Quantity)
+			addQuantity(&a.Reported, rec.Metric, rec.ReportedQuantity)
+		}
+	}
+
+	s.mu.Lock()
+	for key, n := range s.pending {
+		if a, ok := apps[key.AppID]; ok && key.Period == period {
+			addQuantity(&a.Usage, key.Metric, n)
+		}
+	}
+	s.mu.Unlock()
+
+	active, err := s.repo.CountActiveUsers(appIDs, periodStart, periodStart.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	for id, a := range apps {
+		a.Usage.ActiveUsers = active[id]
+		resp.Totals.APICalls += a.Usage.APICalls
+		resp.Totals.EmailSends += a.Usage.EmailSends
+		resp.Totals.ActiveUsers += a.Usage.ActiveUsers
+	}
+	return resp, nil
+}
+
+// addQuantity adds n to the field of q that corresponds to metric.
+func addQuantity(q *dto.UsageQuantities, metric string, n int64) {
+	switch metric {
+	case MetricAPICalls:
+		q.APICalls += n
+	case MetricEmailSends:
+		q.EmailSends += n
+	case MetricActiveUsers:
+		q.ActiveUsers += n
+	}
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
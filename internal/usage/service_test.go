@@ -0,0 +1,155 @@
+package usage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/middleware"
+	"github.com/google/uuid"
+)
+
+func TestRecordAggregatesPendingCounters(t *testing.T) {
+	s := NewService(nil, nil)
+	appID := uuid.New()
+
+	s.Record(appID, MetricAPICalls, 1)
+	s.Record(appID, MetricAPICalls, 2)
+	s.RecordEmailSent(appID, "verification")
+	s.Record(uuid.Nil, MetricAPICalls, 1) // ignored: no app
+	s.Record(appID, MetricAPICalls, 0)    // ignored: nothing to add
+
+	var nilSvc *Service
+	nilSvc.Record(appID, MetricAPICalls, 1) // must not panic
+
+	batch := s.takePending()
+	period := time.Now().UTC().Format(PeriodLayout)
+	if got := batch[counterKey{appID, period, MetricAPICalls}]; got != 3 {
+		t.Errorf("api_calls = %d, want 3", got)
+	}
+	if got := batch[counterKey{appID, period, MetricEmailSends}]; got != 1 {
+		t.Errorf("email_sends = %d, want 1", got)
+	}
+	if len(batch) != 2 {
+		t.Errorf("expected 2 counters, got %d", len(batch))
+	}
+	if s.takePending() != nil {
+		t.Error("expected pending counters to be cleared after take")
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 3, 17, 15, 4, 5, 0, time.UTC)
+
+	got, err := ParsePeriod("", now)
+	if err != nil || !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("empty period = %v, %v; want start of current month", got, err)
+	}
+	got, err = ParsePeriod("2025-12", now)
+	if err != nil || !got.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("2025-12 = %v, %v", got, err)
+	}
+	for _, bad := range []string{"2025-13", "2025-1", "december"} {
+		if _, err := ParsePeriod(bad, now); err == nil {
+			t.Errorf("ParsePeriod(%q) expected error", bad)
+		}
+	}
+}
+
+func TestAPICallMiddlewareCountsAppRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewService(nil, nil)
+	appID := uuid.New()
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.Request.URL.Path == "/with-app" {
+			c.Set(middleware.AppIDKey, appID)
+		}
+	})
+	r.Use(s.APICallMiddleware())
+	r.GET("/with-app", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/admin/x", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/oidc/:app_id/userinfo", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/with-app", "/with-app", "/admin/x", "/oidc/" + appID.String() + "/userinfo"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	batch := s.takePending()
+	if got := batch[counterKey{appID, time.Now().UTC().Format(PeriodLayout), MetricAPICalls}]; got != 3 {
+		t.Errorf("api_calls = %d, want 3", got)
+	}
+}
+
+func TestStripeReporterSendsMeterEvent(t *testing.T) {
+	var form map[string]string
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = r.ParseForm()
+		form = map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rep := NewStripeReporter("sk_test_123", map[string]string{MetricAPICalls: "api_requests"})
+	rep.endpoint = srv.URL
+
+	err := rep.Report(context.Background(), Report{
+		Identifier: "id-1",
+		CustomerID: "cus_42",
+		Metric:     MetricAPICalls,
+		Quantity:   17,
+		Timestamp:  time.Unix(1700000000, 0),
+	})
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if auth != "Bearer sk_test_123" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	want := map[string]string{
+		"event_name":                  "api_requests",
+		"identifier":                  "id-1",
+		"timestamp":                   "1700000000",
+		"payload[stripe_customer_id]": "cus_42",
+		"payload[value]":              "17",
+	}
+	for k, v := range want {
+		if form[k] != v {
+			t.Errorf("form[%s] = %q, want %q", k, form[k], v)
+		}
+	}
+
+	// Metrics without a meter event are acknowledged without a request.
+	form = nil
+	if err := rep.Report(context.Background(), Report{Metric: MetricEmailSends, Quantity: 1}); err != nil {
+		t.Fatalf("Report unmapped metric: %v", err)
+	}
+	if form != nil {
+		t.Error("expected no request for a metric without a meter event")
+	}
+}
+
+func TestStripeReporterReturnsAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"code":"resource_missing","message":"No such customer"}}`))
+	}))
+	defer srv.Close()
+
+	rep := NewStripeReporter("sk_test_123", map[string]string{MetricAPICalls: "api_requests"})
+	rep.endpoint = srv.URL
+
+	err := rep.Report(context.Background(), Report{Metric: MetricAPICalls, Quantity: 1, Timestamp: time.Now()})
+	if err == nil || err.Error() != "stripe: API error resource_missing: No such customer" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package user
+
+import (
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	msgRegistered                = "User registered successfully. Please check your email for verification."
+	msgRegisteredPendingApproval = "User registered successfully. Please check your email for verification. Your account is pending approval; you will be notified by email once it has been reviewed."
+)
+
+// RegisteredMessage returns the response message for a successful registration of u.
+func RegisteredMessage(u *models.User) string {
+	if u.ApprovalStatus == models.ApprovalStatusPending {
+		return msgRegisteredPendingApproval
+	}
+	return msgRegistered
+}
+
+// HoldForApproval puts a newly created user on hold ("pending approval",
+// inactive) when the application requires registration approval. It reports
+// whether the user is now pending.
+func HoldForApproval(db *gorm.DB, appID uuid.UUID, u *models.User) (bool, error) {
+	var app models.Application
+	if err := db.Select("registration_approval_required").First(&app, "id = ?", appID).Error; err != nil {
+		return false, err
+	}
+	if !app.RegistrationApprovalRequired {
+		return false, nil
+	}
+	if err := db.Model(&models.User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"is_active":       false,
+		"approval_status": models.ApprovalStatusPending,
+	}).Error; err != nil {
+		return false, err
+	}
+	u.IsActive = false
+	u.ApprovalStatus = models.ApprovalStatusPending
+	return true, nil
+}
+
+// CheckAccountActive returns the sign-in error for an account that is pending
+// approval, was rejected, or was deactivated by an administrator, and for
+// service accounts, which never sign in interactively.
+func CheckAccountActive(u *models.User) *errors.AppError {
+	switch {
+	case u.IsServiceAccount:
+		return errors.NewAppError(errors.ErrForbidden, "Service accounts cannot sign in interactively.")
+	case u.ApprovalStatus == models.ApprovalStatusPending:
+		return errors.NewAppError(errors.ErrForbidden, "Account is pending approval. You will be notified by email once it has been reviewed.")
+	case u.ApprovalStatus == models.ApprovalStatusRejected:
+		return errors.NewAppError(errors.ErrForbidden, "Registration was not approved. Please contact your administrator.")
+	case !u.IsActive:
+		return errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gjovanovicst/auth_api/internal/storage"
+	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// MaxAvatarSize is the largest profile picture that can be uploaded.
+const MaxAvatarSize = 2 << 20
+
+// avatarPrefix is the storage key prefix, and URL path, of uploaded profile pictures.
+const avatarPrefix = "avatars/"
+
+// avatarExtensions maps the accepted image types to file extensions.
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// AvatarURL returns the public URL of the uploaded profile picture stored under key.
+func AvatarURL(key string) string {
+	return strings.TrimRight(viper.GetString("PUBLIC_URL"), "/") + "/" + key
+}
+
+// avatarKeyFromURL returns the storage key of a profile picture URL produced by
+// AvatarURL, or "" when the picture was not uploaded (e.g. a social provider URL).
+func avatarKeyFromURL(url string) string {
+	base := strings.TrimRight(viper.GetString("PUBLIC_URL"), "/") + "/"
+	if !strings.HasPrefix(url, base+avatarPrefix) {
+		return ""
+	}
+	key := strings.TrimPrefix(url, base)
+	if !storage.ValidKey(key) {
+		return ""
+	}
+	return key
+}
+
+// UploadAvatar stores data as the user's profile picture and points
+// profile_picture at it. The previous uploaded picture, if any, is deleted.
+// Every upload gets a new random name, so the URLs can be cached forever.
+func (s *Service) UploadAvatar(ctx context.Context, appID uuid.UUID, userID string, data []byte) (string, *errors.AppError) {
+	if len(data) == 0 {
+		return "", errors.NewAppError(errors.ErrBadRequest, "Profile picture is empty")
+	}
+	if len(data) > MaxAvatarSize {
+		return "", errors.NewAppError(errors.ErrBadRequest, "Profile picture must be at most 2 MB")
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return "", errors.NewAppError(errors.ErrBadRequest, "Profile picture must be a PNG, JPEG, GIF or WebP image")
+	}
+
+	user, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return "", errors.NewAppError(errors.ErrNotFound, "User not found")
+	}
+
+	backend, err := storage.Current()
+	if err != nil {
+		log.Printf("Warning: File storage is unavailable: %v\n", err)
+		return "", errors.NewAppError(errors.ErrInternal, "File storage is unavailable")
+	}
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", errors.NewAppError(errors.ErrInternal, "Failed to store profile picture")
+	}
+	key := avatarPrefix + appID.String() + "/" + user.ID.String() + "-" + hex.EncodeToString(suffix) + ext
+	if err := backend.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		log.Printf("Warning: Failed to store profile picture of user %s: %v\n", userID, err)
+		return "", errors.NewAppError(errors.ErrInternal, "Failed to store profile picture")
+	}
+
+	url := AvatarURL(key)
+	if err := s.Repo.UpdateUserProfile(userID, map[string]interface{}{"profile_picture": url}); err != nil {
+		deleteAvatar(ctx, key)
+		return "", errors.NewAppError(errors.ErrInternal, "Failed to update profile")
+	}
+	if old := avatarKeyFromURL(user.ProfilePicture); old != "" {
+		deleteAvatar(ctx, old)
+	}
+	return url, nil
+}
+
+// RemoveAvatar clears the user's profile picture, deleting it from storage
+// when it was uploaded.
+func (s *Service) RemoveAvatar(ctx context.Context, userID string) *errors.AppError {
+	user, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return errors.NewAppError(errors.ErrNotFound, "User not found")
+	}
+	if user.ProfilePicture == "" {
+		return nil
+	}
+	if err := s.Repo.UpdateUserProfile(userID, map[string]interface{}{"profile_picture": ""}); err != nil {
+		return errors.NewAppError(errors.ErrInternal, "Failed to update profile")
+	}
+	if key := avatarKeyFromURL(user.ProfilePicture); key != "" {
+		deleteAvatar(ctx, key)
+	}
+	return nil
+}
+
+// deleteAvatar removes an uploaded profile picture. Failures only leave an
+// orphaned file behind, so they are logged.
+func deleteAvatar(ctx context.Context, key string) {
+	backend, err := storage.Current()
+	if err == nil {
+		err = backend.Delete(ctx, key)
+	}
+	if err != nil && err != storage.ErrNotFound {
+		log.Printf("Warning: Failed to delete profile picture %s: %v\n", key, err)
+	}
+}
+
+// DeleteUploadedAvatar removes the file behind a profile picture URL if it
+// was uploaded to this API; other URLs are left alone. It is used when a user
+// is removed outside of the user service (e.g. an admin erasure).
+func DeleteUploadedAvatar(ctx context.Context, profilePicture string) {
+	if key := avatarKeyFromURL(profilePicture); key != "" {
+		deleteAvatar(ctx, key)
+	}
+}
@@ -0,0 +1,44 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestAvatarKeyFromURL(t *testing.T) {
+	viper.Set("PUBLIC_URL", "https://auth.example.com/")
+	t.Cleanup(func() { viper.Set("PUBLIC_URL", "") })
+
+	key := "avatars/" + uuid.NewString() + "/u-0123456789abcdef.png"
+	if got := avatarKeyFromURL(AvatarURL(key)); got != key {
+		t.Errorf("avatarKeyFromURL(AvatarURL(%q)) = %q", key, got)
+	}
+	for _, url := range []string{
+		"https://lh3.googleusercontent.com/a/photo.jpg",
+		"https://auth.example.com/avatars/../secrets",
+		"https://evil.example.com/avatars/x/y.png",
+		"",
+	} {
+		if got := avatarKeyFromURL(url); got != "" {
+			t.Errorf("avatarKeyFromURL(%q) = %q, want empty", url, got)
+		}
+	}
+}
+
+func TestUploadAvatarRejectsInvalidFiles(t *testing.T) {
+	s := &Service{}
+	tests := map[string][]byte{
+		"empty":     nil,
+		"not image": []byte("%PDF-1.4 not an image"),
+		"too large": make([]byte, MaxAvatarSize+1),
+	}
+	for name, data := range tests {
+		if _, err := s.UploadAvatar(context.Background(), uuid.New(), uuid.NewString(), data); err == nil || err.Code != http.StatusBadRequest {
+			t.Errorf("%s: UploadAvatar() error = %v, want 400", name, err)
+		}
+	}
+}
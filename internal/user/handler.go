@@ -1,15 +1,20 @@
 package user
 
 import (
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gjovanovicst/auth_api/internal/botdetect"
 	"github.com/gjovanovicst/auth_api/internal/bruteforce"
 	"github.com/gjovanovicst/auth_api/internal/config"
 	"github.com/gjovanovicst/auth_api/internal/geoip"
 	"github.com/gjovanovicst/auth_api/internal/health"
 	"github.com/gjovanovicst/auth_api/internal/log"
+	"github.com/gjovanovicst/auth_api/internal/storage"
+	"github.com/gjovanovicst/auth_api/internal/tokenstore"
 	"github.com/gjovanovicst/auth_api/internal/util"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	"github.com/gjovanovicst/auth_api/pkg/jwt"
@@ -29,6 +34,7 @@ type Handler struct {
 	AnomalyDetector       *log.AnomalyDetector      // Anomaly detector for login monitoring (nil = disabled)
 	BruteForceService     *bruteforce.Service       // Brute-force protection service (lockout, delays, CAPTCHA)
 	ValidateTrustedDevice TrustedDeviceValidateFunc // Optional: skip 2FA when a valid trusted-device cookie is present
+	BotDetector           *botdetect.Detector       // Registration bot detection (nil = disabled)
 }
 
 func NewHandler(s *Service) *Handler {
@@ -55,6 +61,56 @@ func (h *Handler) checkIPAccess(c *gin.Context, appID uuid.UUID, ipAddress, user
 	return true
 }
 
+// screenRegistration runs bot detection for a registration attempt and logs
+// the decision. Returns the assessment and true if the registration may
+// proceed. A challenged registration proceeds once the client solves the
+// application's CAPTCHA; without CAPTCHA enabled for the application a
+// challenge is treated as a denial. When rejected, it sends the JSON error
+// response.
+func (h *Handler) screenRegistration(c *gin.Context, appID uuid.UUID, req dto.RegisterRequest, ipAddress, userAgent string) (botdetect.Assessment, bool) {
+	assessment := h.BotDetector.Assess(c.Request.Context(), botdetect.Signals{
+		AppID:     appID,
+		Email:     req.Email,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Honeypot:  req.Website,
+		FormToken: req.FormToken,
+	})
+	if assessment.Decision == botdetect.DecisionAllow {
+		return assessment, true
+	}
+
+	details := assessment.Details()
+	details["email"] = req.Email
+
+	if assessment.Decision == botdetect.DecisionChallenge {
+		var app models.Application
+		bfCfg := bruteforce.ResolveConfig(nil)
+		if err := h.Service.DB.Select("bf_captcha_enabled, bf_captcha_site_key, bf_captcha_secret_key").First(&app, "id = ?", appID).Error; err == nil {
+			bfCfg = bruteforce.ResolveConfig(&app)
+		}
+		if bfCfg.CaptchaEnabled {
+			if req.CaptchaToken != "" && bruteforce.VerifyCaptcha(req.CaptchaToken, ipAddress, bfCfg) == nil {
+				// Passed the challenge; logged with the new user once registered
+				assessment.Reasons = append(assessment.Reasons, "captcha_passed")
+				return assessment, true
+			}
+			log.LogRegistrationScreened(appID, uuid.Nil, ipAddress, userAgent, details)
+			c.JSON(http.StatusForbidden, dto.CaptchaRequiredResponse{
+				Error:           "CAPTCHA verification required",
+				CaptchaRequired: true,
+				SiteKey:         bfCfg.CaptchaSiteKey,
+			})
+			return assessment, false
+		}
+		details["decision"] = botdetect.DecisionDeny
+	}
+
+	log.LogRegistrationScreened(appID, uuid.Nil, ipAddress, userAgent, details)
+	c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "Registration could not be completed"})
+	return assessment, false
+}
+
 // runLoginAnomalyDetection runs anomaly detection for a successful login and logs with the result.
 // eventType allows callers to emit the appropriate event (e.g. EventLogin, EventMagicLinkLogin).
 func (h *Handler) runLoginAnomalyDetection(appID, userID uuid.UUID, email, ipAddress, userAgent string, eventType string, details map[string]interface{}) {
@@ -111,7 +167,7 @@ func (h *Handler) handleFailedLogin(appID uuid.UUID, email, ipAddress, userAgent
 	// to avoid double-counting with the anomaly detector.
 	if h.BruteForceService != nil {
 		var lockErr error
-		wasLocked, lockExpiresAt, failCount, lockErr = h.BruteForceService.HandleFailedLogin(appID, email, bfCfg)
+		wasLocked, lockExpiresAt, failCount, lockErr = h.BruteForceService.HandleFailedLogin(appID, email, ipAddress, bfCfg)
 		if lockErr == nil && wasLocked {
 			log.LogAccountLocked(appID, uuid.Nil, ipAddress, userAgent, map[string]interface{}{
 				"email":        email,
@@ -132,7 +188,7 @@ func (h *Handler) handleFailedLogin(appID uuid.UUID, email, ipAddress, userAgent
 			count := failCount
 			if h.BruteForceService == nil {
 				var err error
-				count, err = h.AnomalyDetector.IncrementAndCheckBruteForce(appID, email, cfg.AnomalyDetection.BruteForceWindow)
+				count, err = h.AnomalyDetector.IncrementAndCheckBruteForce(appID, bruteforce.AccountKey(email), cfg.AnomalyDetection.BruteForceWindow)
 				if err != nil {
 					count = 0
 				}
@@ -179,6 +235,7 @@ func (h *Handler) handleFailedLogin(appID uuid.UUID, email, ipAddress, userAgent
 // @Param   registration  body      dto.RegisterRequest  true  "User Registration Data"
 // @Success 201 {object}  dto.UserResponse
 // @Failure 400 {object}  dto.ErrorResponse
+// @Failure 403 {object}  dto.ErrorResponse "User quota exceeded"
 // @Failure 409 {object}  dto.ErrorResponse
 // @Failure 429 {object}  dto.ErrorResponse
 // @Failure 500 {object}  dto.ErrorResponse
@@ -202,21 +259,50 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 	appID := appIDVal.(uuid.UUID)
+	ipAddress, userAgent := util.GetClientInfo(c)
+
+	// Screen for bots and automated sign-ups
+	var assessment botdetect.Assessment
+	if h.BotDetector.Enabled() {
+		var allowed bool
+		if assessment, allowed = h.screenRegistration(c, appID, req, ipAddress, userAgent); !allowed {
+			return
+		}
+	}
 
-	userID, err := h.Service.RegisterUser(appID, req.Email, req.Password)
+	newUser, err := h.Service.RegisterUser(appID, req.Email, req.Password)
 	if err != nil {
 		c.JSON(err.Code, dto.ErrorResponse{Error: err.Message})
 		return
 	}
+	userID := newUser.ID
 
 	// Log registration activity
-	ipAddress, userAgent := util.GetClientInfo(c)
 	log.LogRegister(appID, userID, ipAddress, userAgent, req.Email)
+	if h.BotDetector.Enabled() {
+		log.LogRegistrationScreened(appID, userID, ipAddress, userAgent, assessment.Details())
+	}
 
 	// Increment registration metric
 	health.IncRegister(appID.String())
 
-	c.JSON(http.StatusCreated, dto.MessageResponse{Message: "User registered successfully. Please check your email for verification."})
+	c.JSON(http.StatusCreated, dto.MessageResponse{Message: RegisteredMessage(newUser)})
+}
+
+// @Summary Get a registration form token
+// @Description Issue a token recording when the registration form was shown. Send it back as form_token with POST /register; registrations submitted faster than REGISTRATION_MIN_SUBMIT_SECONDS, or without a token when that check is enabled, are challenged with a CAPTCHA.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object}  dto.RegistrationFormTokenResponse
+// @Failure 500 {object}  dto.ErrorResponse
+// @Router /register/form-token [get]
+func (h *Handler) RegistrationFormToken(c *gin.Context) {
+	appIDVal, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "App ID missing from context"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.RegistrationFormTokenResponse{FormToken: h.BotDetector.IssueFormToken(appIDVal.(uuid.UUID))})
 }
 
 // @Summary User login
@@ -226,11 +312,13 @@ func (h *Handler) Register(c *gin.Context) {
 // @Produce json
 // @Param   login  body      dto.LoginRequest  true  "User Login Data"
 // @Success 200 {object}  dto.LoginResponse
+// @Success 200 {object}  dto.PasswordChangeRequiredResponse "Password expired or rotation forced; no tokens issued"
 // @Success 202 {object}  dto.TwoFARequiredResponse "2FA verification or setup required"
 // @Failure 400 {object}  dto.ErrorResponse
 // @Failure 401 {object}  dto.ErrorResponse "May include retry_after (seconds) advisory field"
 // @Failure 403 {object}  dto.CaptchaRequiredResponse "CAPTCHA verification required"
 // @Failure 423 {object}  dto.AccountLockedResponse "Account is locked"
+// @Failure 429 {object}  dto.LoginThrottledResponse "Login attempts for this account are delayed"
 // @Failure 500 {object}  dto.ErrorResponse
 // @Router /login [post]
 func (h *Handler) Login(c *gin.Context) {
@@ -280,6 +368,18 @@ func (h *Handler) Login(c *gin.Context) {
 
 	// --- Brute-force pre-auth checks ---
 	if h.BruteForceService != nil {
+		// Enforce the per-account delay before looking at the credentials, whatever
+		// the client IP, so attackers rotating IPs cannot guess faster.
+		if wait, waitErr := h.BruteForceService.AccountRetryAfter(appID, req.Email, bfCfg); waitErr == nil && wait > 0 {
+			health.IncLoginFailure(appID.String(), "account_throttled")
+			c.Header("Retry-After", strconv.Itoa(wait))
+			c.JSON(http.StatusTooManyRequests, dto.LoginThrottledResponse{
+				Error:      "Too many failed login attempts for this account, please wait before retrying",
+				RetryAfter: wait,
+			})
+			return
+		}
+
 		// Check CAPTCHA requirement (before any processing).
 		// CAPTCHA acts as a gate — the request is rejected until a valid token is provided.
 		captchaRequired, captchaErr := h.BruteForceService.IsCaptchaRequired(appID, req.Email, bfCfg)
@@ -352,17 +452,21 @@ func (h *Handler) Login(c *gin.Context) {
 
 	// Successful credential verification — reset brute-force counters
 	if h.AnomalyDetector != nil {
-		_ = h.AnomalyDetector.ResetBruteForceCounter(appID, req.Email)
+		_ = h.AnomalyDetector.ResetBruteForceCounter(appID, bruteforce.AccountKey(req.Email))
 	}
 	if h.BruteForceService != nil {
 		h.BruteForceService.ResetOnSuccess(appID, req.Email, ipAddress)
 	}
 
-	// Password expired: inform the client so it can redirect to change-password flow.
-	// No tokens are issued in this case.
+	// Password expired: return a challenge the client answers with a new
+	// password. No tokens are issued in this case.
 	if loginResult.PasswordExpired {
-		c.JSON(http.StatusOK, dto.LoginResponse{
-			PasswordExpired: true,
+		c.JSON(http.StatusOK, dto.PasswordChangeRequiredResponse{
+			PasswordExpired:        true,
+			PasswordChangeRequired: true,
+			PasswordChangeToken:    loginResult.PasswordChangeToken,
+			ExpiresIn:              int(tokenstore.TTL(tokenstore.PurposePasswordChange).Seconds()),
+			Message:                "Your password must be changed before you can sign in.",
 		})
 		return
 	}
@@ -513,7 +617,9 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 
 	// Note: We don't log password reset requests for security reasons
 	// as it could be used to enumerate valid email addresses
-	if err := h.Service.RequestPasswordReset(appID, req.Email); err != nil {
+	ipAddress, userAgent := util.GetClientInfo(c)
+	client := tokenstore.Client{IP: ipAddress, UserAgent: userAgent}
+	if err := h.Service.RequestPasswordReset(appID, req.Email, client); err != nil {
 		c.JSON(err.Code, gin.H{"error": err.Message})
 		return
 	}
@@ -553,17 +659,71 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 	}
 	appID := appIDVal.(uuid.UUID)
 
-	userID, err := h.Service.ConfirmPasswordReset(appID, req.Token, req.NewPassword)
+	ipAddress, userAgent := util.GetClientInfo(c)
+	client := tokenstore.Client{IP: ipAddress, UserAgent: userAgent}
+	use, err := h.Service.ConfirmPasswordReset(appID, req.Token, req.NewPassword, client)
+	if err != nil {
+		if use.Replay != nil {
+			log.LogLinkReplayed(appID, use.UserID, ipAddress, userAgent, use.ReplayDetails(tokenstore.PurposePasswordReset, client))
+		}
+		c.JSON(err.Code, gin.H{"error": err.Message})
+		return
+	}
+
+	// Log password reset completion, noting a link used on another device
+	// than the one that requested it
+	var details map[string]interface{}
+	if use.RequestedBy != (tokenstore.Client{}) && !client.SameDevice(use.RequestedBy) {
+		details = map[string]interface{}{"requested_ip": use.RequestedBy.IP, "new_device": true}
+	}
+	log.LogPasswordReset(appID, use.UserID, ipAddress, userAgent, details)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully. All sessions have been signed out."})
+}
+
+// @Summary Change expired password
+// @Description Answer the password change challenge returned by login when the password has expired or the
+// @Description application forced a rotation. All sessions are signed out; sign in again with the new password.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param   change  body      dto.ChangeExpiredPasswordRequest  true  "Challenge Token and New Password"
+// @Success 200 {object}  dto.MessageResponse
+// @Failure 400 {object}  dto.ErrorResponse
+// @Failure 401 {object}  dto.ErrorResponse
+// @Failure 429 {object}  dto.ErrorResponse
+// @Failure 500 {object}  dto.ErrorResponse
+// @Router /change-expired-password [post]
+func (h *Handler) ChangeExpiredPassword(c *gin.Context) {
+	var req dto.ChangeExpiredPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appIDVal, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "App ID missing from context"})
+		return
+	}
+	appID := appIDVal.(uuid.UUID)
+
+	userID, err := h.Service.ChangeExpiredPassword(appID, req.Token, req.NewPassword)
 	if err != nil {
 		c.JSON(err.Code, gin.H{"error": err.Message})
 		return
 	}
 
-	// Log password reset completion
 	ipAddress, userAgent := util.GetClientInfo(c)
-	log.LogPasswordReset(appID, userID, ipAddress, userAgent)
+	log.LogPasswordChange(appID, userID, ipAddress, userAgent)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully."})
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully. Please sign in with your new password."})
 }
 
 // @Summary Verify email
@@ -590,7 +750,56 @@ func (h *Handler) VerifyEmail(c *gin.Context) {
 	}
 	appID := appIDVal.(uuid.UUID)
 
-	userID, err := h.Service.VerifyEmail(appID, token)
+	ipAddress, userAgent := util.GetClientInfo(c)
+	client := tokenstore.Client{IP: ipAddress, UserAgent: userAgent}
+	use, err := h.Service.VerifyEmail(appID, token, client)
+	if err != nil {
+		if use.Replay != nil {
+			log.LogLinkReplayed(appID, use.UserID, ipAddress, userAgent, use.ReplayDetails(tokenstore.PurposeEmailVerification, client))
+		}
+		c.JSON(err.Code, gin.H{"error": err.Message})
+		return
+	}
+
+	// Log email verification
+	log.LogEmailVerify(appID, use.UserID, ipAddress, userAgent)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully!"})
+}
+
+// @Summary Verify email with a code
+// @Description Verify the user's email address with the 6-digit code sent by email (applications with email_verification_code enabled). A code allows a limited number of incorrect attempts, after which a new code must be requested via /resend-verification.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param   request  body      dto.VerifyEmailCodeRequest  true  "Email address and code"
+// @Success 200 {object}  dto.MessageResponse
+// @Failure 400 {object}  dto.ErrorResponse
+// @Failure 401 {object}  dto.ErrorResponse
+// @Failure 429 {object}  dto.ErrorResponse
+// @Failure 500 {object}  dto.ErrorResponse
+// @Router /verify-email/code [post]
+func (h *Handler) VerifyEmailCode(c *gin.Context) {
+	var req dto.VerifyEmailCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appIDVal, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "App ID missing from context"})
+		return
+	}
+	appID := appIDVal.(uuid.UUID)
+
+	userID, err := h.Service.VerifyEmailCode(appID, req.Email, req.Code)
 	if err != nil {
 		c.JSON(err.Code, gin.H{"error": err.Message})
 		return
@@ -669,7 +878,7 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	ipAddress, userAgent := util.GetClientInfo(c)
 	appIDVal, appIDExists := c.Get("app_id")
 	if appIDExists {
-		log.LogProfileAccess(appIDVal.(uuid.UUID), user.ID, ipAddress, userAgent)
+		log.LogProfileAccess(appIDVal.(uuid.UUID), user.ID, ipAddress, userAgent, log.WithImpersonation(c, nil))
 	}
 
 	// Convert social accounts to DTO
@@ -773,7 +982,7 @@ func (h *Handler) Logout(c *gin.Context) {
 	ipAddress, userAgent := util.GetClientInfo(c)
 	userUUID, parseErr := uuid.Parse(userID.(string))
 	if parseErr == nil {
-		log.LogLogout(appID, userUUID, ipAddress, userAgent)
+		log.LogLogout(appID, userUUID, ipAddress, userAgent, log.WithImpersonation(c, nil))
 	}
 
 	// Increment logout metric
@@ -784,7 +993,7 @@ func (h *Handler) Logout(c *gin.Context) {
 
 // ValidateToken godoc
 // @Summary      Validate JWT Token
-// @Description  Validates a JWT token and returns basic user info for external services
+// @Description  Validates a JWT token and returns basic user info for external services. Tokens of service accounts also return service_account and the token's scope.
 // @Tags         auth
 // @Security     ApiKeyAuth
 // @Produce      json
@@ -811,11 +1020,16 @@ func (h *Handler) ValidateToken(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"valid":  true,
 		"userID": user.ID,
 		"email":  user.Email,
-	})
+	}
+	if user.IsServiceAccount {
+		resp["service_account"] = true
+		resp["scope"] = c.GetString("scope")
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // @Summary Update user profile
@@ -868,7 +1082,7 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	}
 	appIDVal, appIDExists := c.Get("app_id")
 	if appIDExists {
-		log.LogProfileUpdate(appIDVal.(uuid.UUID), user.ID, ipAddress, userAgent, details)
+		log.LogProfileUpdate(appIDVal.(uuid.UUID), user.ID, ipAddress, userAgent, log.WithImpersonation(c, details))
 	}
 
 	// Convert social accounts to DTO
@@ -916,6 +1130,188 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	})
 }
 
+// @Summary Upload profile picture
+// @Description Upload a PNG, JPEG, GIF or WebP image (max 2 MB) as the authenticated user's profile picture.
+// @Description It is stored on the configured file storage backend and profile_picture is set to its URL.
+// @Tags User
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param   avatar  formData  file  true  "Image file"
+// @Success 200 {object}  dto.AvatarResponse
+// @Failure 400 {object}  dto.ErrorResponse
+// @Failure 401 {object}  dto.ErrorResponse
+// @Failure 500 {object}  dto.ErrorResponse
+// @Router /profile/avatar [post]
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "User ID not found in context"})
+		return
+	}
+	appIDVal, _ := c.Get("app_id")
+	appID, _ := appIDVal.(uuid.UUID)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxAvatarSize+(64<<10))
+	file, _, err := c.Request.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "missing or too large avatar field; upload an image of at most 2 MB as 'avatar'"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxAvatarSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Failed to read the uploaded file"})
+		return
+	}
+
+	url, appErr := h.Service.UploadAvatar(c.Request.Context(), appID, userID.(string), data)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	ipAddress, userAgent := util.GetClientInfo(c)
+	if parsedUserID, err := uuid.Parse(userID.(string)); err == nil {
+		log.LogProfileUpdate(appID, parsedUserID, ipAddress, userAgent, log.WithImpersonation(c, map[string]interface{}{
+			"updated_fields": map[string]string{"profile_picture": url},
+		}))
+	}
+
+	c.JSON(http.StatusOK, dto.AvatarResponse{ProfilePicture: url})
+}
+
+// @Summary Remove profile picture
+// @Description Clear the authenticated user's profile picture, deleting it from storage when it was uploaded
+// @Tags User
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object}  dto.MessageResponse
+// @Failure 401 {object}  dto.ErrorResponse
+// @Failure 500 {object}  dto.ErrorResponse
+// @Router /profile/avatar [delete]
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "User ID not found in context"})
+		return
+	}
+	if appErr := h.Service.RemoveAvatar(c.Request.Context(), userID.(string)); appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Profile picture removed"})
+}
+
+// @Summary Get uploaded profile picture
+// @Description Serve a profile picture uploaded through POST /profile/avatar. The URL is returned in profile_picture; no authentication is needed.
+// @Tags User
+// @Produce image/png,image/jpeg,image/gif,image/webp
+// @Param   app_id  path  string  true  "Application ID"
+// @Param   file    path  string  true  "File name"
+// @Success 200 {file}  binary
+// @Failure 404 {object}  dto.ErrorResponse
+// @Router /avatars/{app_id}/{file} [get]
+func (h *Handler) ServeAvatar(c *gin.Context) {
+	if _, err := uuid.Parse(c.Param("app_id")); err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Not found"})
+		return
+	}
+	key := avatarPrefix + c.Param("app_id") + "/" + c.Param("file")
+	if !storage.ValidKey(key) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Not found"})
+		return
+	}
+
+	backend, err := storage.Current()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "File storage is unavailable"})
+		return
+	}
+	obj, err := backend.Get(c.Request.Context(), key)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Not found"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, dto.ErrorResponse{Error: "Failed to read profile picture"})
+		return
+	}
+	defer obj.Body.Close()
+
+	// Uploads get a new name each time, so the content never changes
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, obj.Body, nil)
+}
+
+// @Summary Get notification preferences
+// @Description Get which non-transactional emails (security alerts, product emails) the authenticated user receives
+// @Tags User
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object}  dto.NotificationPreferencesResponse
+// @Failure 401 {object}  dto.ErrorResponse
+// @Failure 404 {object}  dto.ErrorResponse
+// @Router /profile/notification-preferences [get]
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "User ID not found in context"})
+		return
+	}
+
+	prefs, appErr := h.Service.GetNotificationPreferences(userID.(string))
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// @Summary Update notification preferences
+// @Description Opt in or out of non-transactional emails. Transactional email (verification, password reset, 2FA codes) is always sent.
+// @Tags User
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param   preferences  body      dto.UpdateNotificationPreferencesRequest  true  "Preferences to change"
+// @Success 200 {object}  dto.NotificationPreferencesResponse
+// @Failure 400 {object}  dto.ErrorResponse
+// @Failure 401 {object}  dto.ErrorResponse
+// @Failure 500 {object}  dto.ErrorResponse
+// @Router /profile/notification-preferences [put]
+func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "User ID not found in context"})
+		return
+	}
+
+	var req dto.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	prefs, appErr := h.Service.UpdateNotificationPreferences(userID.(string), req)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	ipAddress, userAgent := util.GetClientInfo(c)
+	if appIDVal, ok := c.Get("app_id"); ok {
+		if uid, err := uuid.Parse(userID.(string)); err == nil {
+			log.LogProfileUpdate(appIDVal.(uuid.UUID), uid, ipAddress, userAgent, log.WithImpersonation(c, map[string]interface{}{
+				"notification_preferences": prefs,
+			}))
+		}
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
 // @Summary Update user email
 // @Description Update authenticated user's email address (requires password verification and email re-verification)
 // @Tags User
@@ -7,8 +7,9 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/gjovanovicst/auth_api/internal/passhash"
 	"github.com/gjovanovicst/auth_api/pkg/models"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
 )
 
 // ValidatePasswordPolicy checks a plaintext password against the application's
@@ -88,7 +89,7 @@ func CheckPasswordHistory(newPassword string, user *models.User, historyCount in
 	}
 
 	for _, h := range hashes[:limit] {
-		if err := bcrypt.CompareHashAndPassword([]byte(h), []byte(newPassword)); err == nil {
+		if err := passhash.Verify(h, newPassword); err == nil {
 			return fmt.Errorf("password has been used recently; please choose a different password")
 		}
 	}
@@ -96,11 +97,30 @@ func CheckPasswordHistory(newPassword string, user *models.User, historyCount in
 	return nil
 }
 
-// AppendPasswordHistory prepends the new bcrypt hash to the user's password
+// MaxPasswordHistoryCount is the largest number of previous passwords an
+// application can remember.
+const MaxPasswordHistoryCount = 24
+
+// ClampPasswordHistoryCount limits a configured history count to
+// [0, MaxPasswordHistoryCount].
+func ClampPasswordHistoryCount(n int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > MaxPasswordHistoryCount {
+		return MaxPasswordHistoryCount
+	}
+	return n
+}
+
+// AppendPasswordHistory prepends the new password hash to the user's password
 // history JSONB array and trims the array to at most keepCount entries.
-// If keepCount is 0 the function is a no-op.
+// If keepCount is 0 (history disabled) the stored history is cleared.
 func AppendPasswordHistory(user *models.User, newHash string, keepCount int) {
 	if keepCount <= 0 {
+		if len(user.PasswordHistory) > 0 {
+			user.PasswordHistory = datatypes.JSON("[]")
+		}
 		return
 	}
 
@@ -152,3 +172,17 @@ func ResolveTokenTTLs(app *models.Application) (accessTTL, refreshTTL time.Durat
 
 	return accessTTL, refreshTTL
 }
+
+// PasswordChangeRequired reports whether the user must change their password
+// before signing in: it exceeded the application's maximum age, or it was set
+// before the application's last forced rotation. Passwords never explicitly
+// changed (PasswordChangedAt nil) are caught by forced rotations only.
+func PasswordChangeRequired(user *models.User, app *models.Application) bool {
+	if IsPasswordExpired(user, app.PwMaxAgeDays) {
+		return true
+	}
+	if app.PwRotationRequiredAt == nil {
+		return false
+	}
+	return user.PasswordChangedAt == nil || user.PasswordChangedAt.Before(*app.PwRotationRequiredAt)
+}
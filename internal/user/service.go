@@ -1,16 +1,22 @@
 package user
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"log"
 	"math/big"
 	"time"
 
+	"github.com/gjovanovicst/auth_api/internal/disposable"
 	emailpkg "github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/internal/passhash"
+	"github.com/gjovanovicst/auth_api/internal/quota"
 	"github.com/gjovanovicst/auth_api/internal/redis"
+	"github.com/gjovanovicst/auth_api/internal/revocation"
 	"github.com/gjovanovicst/auth_api/internal/session"
 	"github.com/gjovanovicst/auth_api/internal/sms"
+	"github.com/gjovanovicst/auth_api/internal/tokenstore"
 	"github.com/gjovanovicst/auth_api/internal/util"
 	"github.com/gjovanovicst/auth_api/internal/webhook"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
@@ -18,14 +24,9 @@ import (
 	"github.com/gjovanovicst/auth_api/pkg/jwt"
 	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-// bcryptCost is the cost factor for password hashing. Set to 12 for stronger
-// security (matches the admin account bcrypt cost in cmd/setup).
-const bcryptCost = 12
-
 // RoleLookupFunc is a function that returns role names for a user in an app.
 // Used to populate JWT claims with roles without importing the rbac package directly.
 type RoleLookupFunc func(appID, userID string) ([]string, error)
@@ -48,6 +49,7 @@ type Service struct {
 	WebhookService    *webhook.Service      // Optional: if nil, webhook dispatch is skipped
 	SMSSender         sms.Sender            // Optional: if nil, SMS 2FA auto-send is skipped
 	GroupLogoutFunc   GroupLogoutFunc       // Optional: if non-nil, called after logout for SSO group propagation
+	DisposableEmails  *disposable.Blocklist // Optional: if nil, only per-app blocked email domains are enforced
 }
 
 func NewService(r *Repository, es *emailpkg.Service, db *gorm.DB) *Service {
@@ -89,20 +91,72 @@ func (s *Service) getUserRoles(appID, userID string) []string {
 type LoginResult struct {
 	RequiresTwoFA      bool
 	RequiresTwoFASetup bool
-	PasswordExpired    bool // true when the password has exceeded its max age; no tokens are issued
+	PasswordExpired    bool // true when the password has expired or a rotation was forced; no tokens are issued
 	UserID             uuid.UUID
 	AccessToken        string // #nosec G101,G117 -- This is a result field, not a hardcoded credential
 	RefreshToken       string // #nosec G101,G117 -- This is a result field, not a hardcoded credential
 	SessionID          string
 	TwoFAResponse      *dto.TwoFARequiredResponse
 	TwoFASetupResponse *dto.TwoFASetupRequiredResponse
+	// PasswordChangeToken is set with PasswordExpired: it authorizes one
+	// ChangeExpiredPassword call
+	PasswordChangeToken string
+}
+
+// msgPasswordAuthNotSupported is the error returned by password endpoints of
+// applications in passkey-only mode.
+const msgPasswordAuthNotSupported = "Password authentication is not supported for this application. Sign in with a passkey or magic link."
+
+// msgPasswordAuthNotAllowed is the error returned by password endpoints of
+// applications whose auth method policy does not permit passwords.
+const msgPasswordAuthNotAllowed = "Password authentication is not allowed for this application."
+
+// CheckPasswordAuthAllowed returns an error when the application runs in
+// passkey-only mode or its auth method policy does not permit passwords; in
+// both cases password registration, login and reset are disabled.
+func (s *Service) CheckPasswordAuthAllowed(appID uuid.UUID) *errors.AppError {
+	var app models.Application
+	if err := s.DB.Select("passwordless_only, allowed_auth_methods").First(&app, "id = ?", appID).Error; err != nil {
+		return nil // Fail open like the other application flag lookups
+	}
+	if app.PasswordlessOnly {
+		return errors.NewAppError(errors.ErrBadRequest, msgPasswordAuthNotSupported)
+	}
+	if !app.AuthMethodAllowed(models.AuthMethodPassword) {
+		return errors.NewAppError(errors.ErrForbidden, msgPasswordAuthNotAllowed)
+	}
+	return nil
+}
+
+// msgDisposableEmail is the error returned when an email address uses a
+// blocked (disposable) domain.
+const msgDisposableEmail = "Disposable email addresses are not allowed. Please use a permanent email address."
+
+// CheckEmailDomain returns an error when the domain of email is on the
+// disposable email blocklist or on the application's blocked email domains.
+func (s *Service) CheckEmailDomain(appID uuid.UUID, email string) *errors.AppError {
+	// A failed lookup leaves the per-app list empty; the global list still applies
+	var app models.Application
+	s.DB.Select("blocked_email_domains").First(&app, "id = ?", appID)
+	if match, blocked := s.DisposableEmails.Check(email, app.BlockedEmailDomains); blocked {
+		log.Printf("Rejected email domain %s for app %s (%s list)", match.Domain, appID, match.Source)
+		return errors.NewAppError(errors.ErrBadRequest, msgDisposableEmail)
+	}
+	return nil
 }
 
-func (s *Service) RegisterUser(appID uuid.UUID, email, password string) (uuid.UUID, *errors.AppError) {
+func (s *Service) RegisterUser(appID uuid.UUID, email, password string) (*models.User, *errors.AppError) {
+	if appErr := s.CheckPasswordAuthAllowed(appID); appErr != nil {
+		return nil, appErr
+	}
+	if appErr := s.CheckEmailDomain(appID, email); appErr != nil {
+		return nil, appErr
+	}
+
 	// Check if user already exists
 	_, err := s.Repo.GetUserByEmail(appID.String(), email)
 	if err == nil { // User found, meaning email is already registered
-		return uuid.UUID{}, errors.NewAppError(errors.ErrConflict, "Email already registered")
+		return nil, errors.NewAppError(errors.ErrConflict, "Email already registered")
 	}
 
 	// Load app for password policy
@@ -115,13 +169,18 @@ func (s *Service) RegisterUser(appID uuid.UUID, email, password string) (uuid.UU
 
 	// Validate password against policy
 	if pErr := ValidatePasswordPolicy(password, &app); pErr != nil {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrBadRequest, pErr.Error())
+		return nil, errors.NewAppError(errors.ErrBadRequest, pErr.Error())
+	}
+
+	// Enforce the app's user quota before doing any expensive work
+	if appErr := quota.ToAppError(quota.CheckAppUsers(s.DB, appID, 1)); appErr != nil {
+		return nil, appErr
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	hashedPassword, err := passhash.Hash(password)
 	if err != nil {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to hash password")
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to hash password")
 	}
 
 	// Build initial password history (one entry: the new hash)
@@ -136,10 +195,26 @@ func (s *Service) RegisterUser(appID uuid.UUID, email, password string) (uuid.UU
 	AppendPasswordHistory(newUser, string(hashedPassword), app.PwHistoryCount)
 
 	if err := s.Repo.CreateUser(newUser); err != nil {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to create user")
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to create user")
+	}
+
+	if appErr := s.CompleteRegistration(appID, newUser); appErr != nil {
+		return nil, appErr
 	}
 
-	user := newUser
+	return newUser, nil
+}
+
+// CompleteRegistration runs the steps that follow the creation of a new account:
+// the approval hold, the user.registered webhook, the default role and the
+// verification email. Shared by password registration and passkey-only
+// account registration.
+func (s *Service) CompleteRegistration(appID uuid.UUID, user *models.User) *errors.AppError {
+	// Hold the account for approval before the webhook announces it
+	if _, err := HoldForApproval(s.DB, appID, user); err != nil {
+		log.Printf("Warning: failed to hold user %s for approval: %v", user.ID.String(), err)
+		return errors.NewAppError(errors.ErrInternal, "Failed to complete registration")
+	}
 
 	// Dispatch webhook event (non-fatal)
 	if s.WebhookService != nil {
@@ -156,21 +231,53 @@ func (s *Service) RegisterUser(appID uuid.UUID, email, password string) (uuid.UU
 		}
 	}
 
-	// Generate email verification token and send email
-	verificationToken := uuid.New().String()
+	return s.sendVerification(appID, user.ID, user.Email)
+}
+
+// sendVerification emails toEmail a verification link, or a verification code
+// for applications with email_verification_code set.
+func (s *Service) sendVerification(appID, userID uuid.UUID, toEmail string) *errors.AppError {
+	// A failed lookup falls back to the link
+	var app models.Application
+	s.DB.Select("email_verification_code").First(&app, "id = ?", appID)
 
-	if err := redis.SetEmailVerificationToken(appID.String(), user.ID.String(), verificationToken, 24*time.Hour); err != nil {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to store verification token")
+	if app.EmailVerificationCode {
+		code, err := tokenstore.IssueCode(appID.String(), userID.String(), tokenstore.PurposeEmailVerification)
+		if err != nil {
+			return errors.NewAppError(errors.ErrInternal, "Failed to store verification code")
+		}
+		if err := s.EmailService.SendVerificationCodeEmail(appID, toEmail, code, &userID); err != nil {
+			return errors.NewAppError(errors.ErrInternal, "Failed to send verification email")
+		}
+		return nil
 	}
 
-	if err := s.EmailService.SendVerificationEmail(appID, user.Email, verificationToken, &user.ID); err != nil {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to send verification email")
+	verificationToken, err := tokenstore.Issue(appID.String(), userID.String(), tokenstore.PurposeEmailVerification)
+	if err != nil {
+		return errors.NewAppError(errors.ErrInternal, "Failed to store verification token")
+	}
+	if err := s.EmailService.SendVerificationEmail(appID, toEmail, verificationToken, &userID); err != nil {
+		return errors.NewAppError(errors.ErrInternal, "Failed to send verification email")
 	}
+	return nil
+}
 
-	return user.ID, nil
+// revokeVerification invalidates the outstanding verification links and code
+// of a user.
+func revokeVerification(appID, userID string) {
+	if err := tokenstore.RevokeAll(appID, userID, tokenstore.PurposeEmailVerification); err != nil {
+		log.Printf("Warning: Failed to revoke old email verification tokens: %v\n", err)
+	}
+	if err := tokenstore.RevokeCode(appID, userID, tokenstore.PurposeEmailVerification); err != nil {
+		log.Printf("Warning: Failed to revoke old email verification code: %v\n", err)
+	}
 }
 
 func (s *Service) LoginUser(appID uuid.UUID, email, password, ip, userAgent string) (*LoginResult, *errors.AppError) {
+	if appErr := s.CheckPasswordAuthAllowed(appID); appErr != nil {
+		return nil, appErr
+	}
+
 	user, err := s.Repo.GetUserByEmail(appID.String(), email)
 	if err != nil { // User not found
 		return nil, errors.NewAppError(errors.ErrUnauthorized, "Invalid credentials")
@@ -194,13 +301,13 @@ func (s *Service) LoginUser(appID uuid.UUID, email, password, ip, userAgent stri
 	}
 
 	// Compare password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+	if err := passhash.Verify(user.PasswordHash, password); err != nil {
 		return nil, errors.NewAppError(errors.ErrUnauthorized, "Invalid credentials")
 	}
 
-	// Check if account is active
-	if !user.IsActive {
-		return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+	// Check if account is active (and approved)
+	if appErr := CheckAccountActive(user); appErr != nil {
+		return nil, appErr
 	}
 
 	// Check if email is verified
@@ -208,19 +315,28 @@ func (s *Service) LoginUser(appID uuid.UUID, email, password, ip, userAgent stri
 		return nil, errors.NewAppError(errors.ErrForbidden, "Email not verified. Please check your inbox.")
 	}
 
+	// Upgrade a hash with outdated parameters in the background
+	passhash.RehashAfterLogin(user.ID, user.PasswordHash, password)
+
 	// Load application flags once — used for 2FA gate, forced-setup check,
 	// password expiry check, and TTL resolution.
 	// Fail-open: if the query fails we treat all flags as safe defaults.
 	var app models.Application
 	appLoaded := s.DB.Select(
-		"two_fa_enabled, two_fa_required, pw_max_age_days, access_token_ttl_minutes, refresh_token_ttl_hours",
+		"two_fa_enabled, two_fa_required, pw_max_age_days, pw_rotation_required_at, access_token_ttl_minutes, refresh_token_ttl_hours",
 	).First(&app, "id = ?", appID).Error == nil
 
 	// Check if the user's password has expired (before issuing any session).
-	if appLoaded && IsPasswordExpired(user, app.PwMaxAgeDays) {
+	// The client exchanges the challenge token for a password change.
+	if appLoaded && PasswordChangeRequired(user, &app) {
+		changeToken, err := tokenstore.Issue(appID.String(), user.ID.String(), tokenstore.PurposePasswordChange)
+		if err != nil {
+			return nil, errors.NewAppError(errors.ErrInternal, "Failed to create password change challenge")
+		}
 		return &LoginResult{
-			PasswordExpired: true,
-			UserID:          user.ID,
+			PasswordExpired:     true,
+			PasswordChangeToken: changeToken,
+			UserID:              user.ID,
 		}, nil
 	}
 
@@ -376,6 +492,9 @@ func (s *Service) RefreshUserToken(refreshToken string, accessTTL, refreshTTL ti
 	if revoked, err := redis.IsRefreshTokenRevoked(claims.AppID, claims.UserID, refreshToken); err != nil || revoked {
 		return "", "", "", errors.NewAppError(errors.ErrUnauthorized, "Refresh token revoked or invalid")
 	}
+	if revoked, err := redis.IsRevokedByAppAuthEpoch(claims.AppID, claims.IssuedAtTime()); err != nil || revoked {
+		return "", "", "", errors.NewAppError(errors.ErrUnauthorized, "Refresh token revoked or invalid")
+	}
 
 	// Generate new access and refresh tokens (re-fetch roles for freshness)
 	roles := s.getUserRoles(claims.AppID, claims.UserID)
@@ -428,7 +547,7 @@ func (s *Service) LogoutUser(appID, userID, sessionID, refreshToken, accessToken
 		remainingTime := time.Until(claims.ExpiresAt.Time)
 		if remainingTime > 0 {
 			// Only blacklist if token hasn't expired yet
-			if err := redis.BlacklistAccessToken(appID, accessToken, userID, remainingTime); err != nil {
+			if err := revocation.Revoke(appID, accessToken, claims.ID, userID, remainingTime); err != nil {
 				// Log the error but don't fail logout completely
 				log.Printf("Warning: Failed to blacklist access token: %v\n", err)
 			}
@@ -485,27 +604,74 @@ func (s *Service) CreateSessionForUser(appID, userID uuid.UUID, ip, userAgent st
 	return at, rt, err
 }
 
-func (s *Service) RequestPasswordReset(appID uuid.UUID, email string) *errors.AppError {
+// LinkUse describes the use of a password reset or email verification link,
+// for the activity log.
+type LinkUse struct {
+	UserID uuid.UUID
+	// Replay is set when the link was used or revoked before; the request
+	// failed and should be logged as LINK_REPLAYED
+	Replay *tokenstore.ReplayError
+	// RequestedBy is the client that requested a password reset link
+	RequestedBy tokenstore.Client
+}
+
+// ReplayDetails returns the activity log details of a replayed link used by
+// client.
+func (u LinkUse) ReplayDetails(purpose tokenstore.Purpose, client tokenstore.Client) map[string]interface{} {
+	details := map[string]interface{}{
+		"purpose":     string(purpose),
+		"reason":      u.Replay.Reason,
+		"spent_at":    u.Replay.At.Format(time.RFC3339),
+		"same_device": u.Replay.Reason == tokenstore.ReplayUsed && client.SameDevice(u.Replay.By),
+	}
+	if u.Replay.By.IP != "" {
+		details["first_used_ip"] = u.Replay.By.IP
+	}
+	return details
+}
+
+// linkUse returns the LinkUse of a failed token lookup.
+func linkUse(err error) LinkUse {
+	replay, ok := err.(*tokenstore.ReplayError)
+	if !ok {
+		return LinkUse{}
+	}
+	userID, _ := uuid.Parse(replay.UserID)
+	return LinkUse{UserID: userID, Replay: replay}
+}
+
+func (s *Service) RequestPasswordReset(appID uuid.UUID, email string, client tokenstore.Client) *errors.AppError {
+	if appErr := s.CheckPasswordAuthAllowed(appID); appErr != nil {
+		return appErr
+	}
+
 	user, err := s.Repo.GetUserByEmail(appID.String(), email)
 	if err != nil {
 		// For security, always return a generic success message even if email not found
 		return nil
 	}
 
-	resetToken := uuid.New().String()
-	// Store token in Redis with expiration (e.g., 1 hour)
-	if err := redis.SetPasswordResetToken(appID.String(), user.ID.String(), resetToken, time.Hour); err != nil {
+	resetToken, err := tokenstore.IssueFor(appID.String(), user.ID.String(), tokenstore.PurposePasswordReset, client)
+	if err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to generate reset token")
 	}
 
 	// Resolve per-app frontend URL and reset-password path so the link points to the correct frontend.
 	var app models.Application
-	if dbErr := s.DB.Select("frontend_url, reset_password_path").First(&app, "id = ?", appID).Error; dbErr != nil {
+	if dbErr := s.DB.Select("frontend_url, reset_password_path, allowed_redirect_urls").First(&app, "id = ?", appID).Error; dbErr != nil {
 		app.FrontendURL = ""
 		app.ResetPasswordPath = ""
+		app.AllowedRedirectURLs = ""
+	}
+	frontendURL, urlErr := util.ResolveAllowedFrontendURL(app.FrontendURL, app.AllowedRedirectURLs)
+	if urlErr != nil {
+		// Never send a token to a host outside the allowlist. Still report success
+		// so the response does not reveal that the account exists.
+		log.Printf("Warning: password reset email for app %s not sent: %v", appID, urlErr)
+		return nil
 	}
 	resetPath := util.ResolveLinkPath(app.ResetPasswordPath, util.DefaultResetPasswordPath)
-	resetLink := fmt.Sprintf("%s%s?token=%s", util.ResolveFrontendURL(app.FrontendURL), resetPath, resetToken)
+	resetLink := fmt.Sprintf("%s%s?token=%s", frontendURL, resetPath, resetToken)
 	if err := s.EmailService.SendPasswordResetEmail(appID, user.Email, resetLink, &user.ID); err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to send password reset email")
 	}
@@ -513,20 +679,49 @@ func (s *Service) RequestPasswordReset(appID uuid.UUID, email string) *errors.Ap
 	return nil
 }
 
-func (s *Service) VerifyEmail(appID uuid.UUID, token string) (uuid.UUID, *errors.AppError) {
-	userID, err := redis.GetEmailVerificationToken(appID.String(), token)
-	if err != nil || userID == "" {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired verification token")
+// VerifyEmail verifies an email address with the link sent to it, opened by
+// client. A link used or revoked before fails with LinkUse.Replay set.
+func (s *Service) VerifyEmail(appID uuid.UUID, token string, client tokenstore.Client) (LinkUse, *errors.AppError) {
+	// Use up the token (single-use)
+	r, err := tokenstore.ConsumeFrom(appID.String(), tokenstore.PurposeEmailVerification, token, client)
+	if r.UserID == "" {
+		return linkUse(err), errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired verification token")
 	}
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+	userID, appErr := s.markEmailVerified(appID, r.UserID)
+	return LinkUse{UserID: userID}, appErr
+}
 
-	// Update user's email_verified status in DB
-	if err := s.Repo.UpdateUserEmailVerified(userID, true); err != nil {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to verify email")
+// VerifyEmailCode verifies the email address of the user registered with
+// email using the code sent to it. Each code allows a limited number of
+// attempts (see tokenstore.CodeMaxAttempts).
+func (s *Service) VerifyEmailCode(appID uuid.UUID, email, code string) (uuid.UUID, *errors.AppError) {
+	invalid := errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired verification code")
+	user, err := s.Repo.GetUserByEmail(appID.String(), email)
+	if err != nil || user.EmailVerified {
+		// Same answer as a wrong code, so the response does not reveal accounts
+		return uuid.UUID{}, invalid
+	}
+
+	if err := tokenstore.VerifyCode(appID.String(), user.ID.String(), tokenstore.PurposeEmailVerification, code); err != nil {
+		switch err {
+		case tokenstore.ErrTooManyAttempts:
+			return uuid.UUID{}, errors.NewAppError(errors.ErrUnauthorized, "Too many incorrect attempts. Request a new verification code.")
+		case tokenstore.ErrInvalidToken:
+			return uuid.UUID{}, invalid
+		}
+		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to verify code")
 	}
+	return s.markEmailVerified(appID, user.ID.String())
+}
 
-	// Invalidate the token after use
-	if err := redis.DeleteEmailVerificationToken(appID.String(), token); err != nil {
-		log.Printf("Warning: Failed to delete used email verification token from Redis: %v\n", err)
+// markEmailVerified sets email_verified for a user whose verification link
+// or code was accepted.
+func (s *Service) markEmailVerified(appID uuid.UUID, userID string) (uuid.UUID, *errors.AppError) {
+	if err := s.Repo.UpdateUserEmailVerified(userID, true); err != nil {
+		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to verify email")
 	}
 
 	// Parse UUID for return
@@ -545,7 +740,7 @@ func (s *Service) VerifyEmail(appID uuid.UUID, token string) (uuid.UUID, *errors
 	return userUUID, nil
 }
 
-// ResendVerificationEmail resends the email verification link for a user.
+// ResendVerificationEmail resends the email verification link (or code) for a user.
 // Returns nil even if the user is not found or already verified (to prevent email enumeration).
 func (s *Service) ResendVerificationEmail(appID uuid.UUID, email string) *errors.AppError {
 	user, err := s.Repo.GetUserByEmail(appID.String(), email)
@@ -559,37 +754,90 @@ func (s *Service) ResendVerificationEmail(appID uuid.UUID, email string) *errors
 		return nil
 	}
 
-	userID := user.ID.String()
+	// Invalidate any existing verification link or code for this user
+	revokeVerification(appID.String(), user.ID.String())
 
-	// Invalidate any existing verification token for this user
-	oldToken, err := redis.GetEmailVerificationTokenByUserID(appID.String(), userID)
-	if err == nil && oldToken != "" {
-		if delErr := redis.DeleteEmailVerificationToken(appID.String(), oldToken); delErr != nil {
-			log.Printf("Warning: Failed to delete old email verification token from Redis: %v\n", delErr)
-		}
+	return s.sendVerification(appID, user.ID, user.Email)
+}
+
+// ConfirmPasswordReset sets a new password with a reset link opened by
+// client. A link used or revoked before fails with LinkUse.Replay set.
+func (s *Service) ConfirmPasswordReset(appID uuid.UUID, token, newPassword string, client tokenstore.Client) (LinkUse, *errors.AppError) {
+	if appErr := s.CheckPasswordAuthAllowed(appID); appErr != nil {
+		return LinkUse{}, appErr
 	}
 
-	// Generate and store new verification token
-	verificationToken := uuid.New().String()
-	if err := redis.SetEmailVerificationToken(appID.String(), userID, verificationToken, 24*time.Hour); err != nil {
-		return errors.NewAppError(errors.ErrInternal, "Failed to store verification token")
+	// Validate the reset token; it is used up only once the new password is accepted
+	userID, err := tokenstore.Lookup(appID.String(), tokenstore.PurposePasswordReset, token)
+	if err != nil || userID == "" {
+		return linkUse(err), errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired reset token")
 	}
 
-	if err := s.EmailService.SendVerificationEmail(appID, user.Email, verificationToken, &user.ID); err != nil {
-		return errors.NewAppError(errors.ErrInternal, "Failed to send verification email")
+	// Load app for password policy
+	var app models.Application
+	if dbErr := s.DB.Select(
+		"pw_min_length, pw_max_length, pw_require_upper, pw_require_lower, pw_require_digit, pw_require_symbol, pw_history_count",
+	).First(&app, "id = ?", appID).Error; dbErr != nil {
+		app = models.Application{}
 	}
 
-	return nil
+	// Validate new password against policy
+	if pErr := ValidatePasswordPolicy(newPassword, &app); pErr != nil {
+		return LinkUse{}, errors.NewAppError(errors.ErrBadRequest, pErr.Error())
+	}
+
+	// Fetch user to check history
+	resetUser, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return LinkUse{}, errors.NewAppError(errors.ErrNotFound, "User not found")
+	}
+	if hErr := CheckPasswordHistory(newPassword, resetUser, app.PwHistoryCount); hErr != nil {
+		return LinkUse{}, errors.NewAppError(errors.ErrBadRequest, hErr.Error())
+	}
+
+	hashedPassword, err := passhash.Hash(newPassword)
+	if err != nil {
+		return LinkUse{}, errors.NewAppError(errors.ErrInternal, "Failed to hash new password")
+	}
+
+	AppendPasswordHistory(resetUser, string(hashedPassword), app.PwHistoryCount)
+
+	// Use up the token, and every other reset token of the user, before the
+	// password changes; a concurrent request with the same token fails here
+	r, err := tokenstore.ConsumeFrom(appID.String(), tokenstore.PurposePasswordReset, token, client)
+	if r.UserID != userID {
+		return linkUse(err), errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired reset token")
+	}
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+
+	if err := s.Repo.UpdateUserPasswordWithHistory(userID, string(hashedPassword), resetUser.PasswordHistory); err != nil {
+		return LinkUse{}, errors.NewAppError(errors.ErrInternal, "Failed to update password")
+	}
+
+	// Security: whoever requested the reset may not be the account owner
+	s.afterPasswordChange(appID, resetUser)
+
+	return LinkUse{UserID: resetUser.ID, RequestedBy: r.RequestedBy}, nil
 }
 
-func (s *Service) ConfirmPasswordReset(appID uuid.UUID, token, newPassword string) (uuid.UUID, *errors.AppError) {
-	// Validate reset token from Redis
-	userID, err := redis.GetPasswordResetToken(appID.String(), token)
+// ChangeExpiredPassword sets a new password for a user whose login returned a
+// password change challenge (expired password or forced rotation). The new
+// password must satisfy the application's policy and differ from the current
+// one. No session is created: the user signs in again with the new password,
+// which runs the usual 2FA checks.
+func (s *Service) ChangeExpiredPassword(appID uuid.UUID, token, newPassword string) (uuid.UUID, *errors.AppError) {
+	if appErr := s.CheckPasswordAuthAllowed(appID); appErr != nil {
+		return uuid.UUID{}, appErr
+	}
+
+	// The challenge is used up only once the new password is accepted
+	userID, err := tokenstore.Lookup(appID.String(), tokenstore.PurposePasswordChange, token)
 	if err != nil || userID == "" {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired reset token")
+		return uuid.UUID{}, errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired password change token")
 	}
 
-	// Load app for password policy
 	var app models.Application
 	if dbErr := s.DB.Select(
 		"pw_min_length, pw_max_length, pw_require_upper, pw_require_lower, pw_require_digit, pw_require_symbol, pw_history_count",
@@ -597,48 +845,64 @@ func (s *Service) ConfirmPasswordReset(appID uuid.UUID, token, newPassword strin
 		app = models.Application{}
 	}
 
-	// Validate new password against policy
 	if pErr := ValidatePasswordPolicy(newPassword, &app); pErr != nil {
 		return uuid.UUID{}, errors.NewAppError(errors.ErrBadRequest, pErr.Error())
 	}
 
-	// Fetch user to check history
-	resetUser, err := s.Repo.GetUserByID(userID)
+	user, err := s.Repo.GetUserByID(userID)
 	if err != nil {
 		return uuid.UUID{}, errors.NewAppError(errors.ErrNotFound, "User not found")
 	}
-	if hErr := CheckPasswordHistory(newPassword, resetUser, app.PwHistoryCount); hErr != nil {
+	if passhash.Verify(user.PasswordHash, newPassword) == nil {
+		return uuid.UUID{}, errors.NewAppError(errors.ErrBadRequest, "New password must be different from the current password")
+	}
+	if hErr := CheckPasswordHistory(newPassword, user, app.PwHistoryCount); hErr != nil {
 		return uuid.UUID{}, errors.NewAppError(errors.ErrBadRequest, hErr.Error())
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcryptCost)
+	hashedPassword, err := passhash.Hash(newPassword)
 	if err != nil {
 		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to hash new password")
 	}
+	AppendPasswordHistory(user, hashedPassword, app.PwHistoryCount)
 
-	AppendPasswordHistory(resetUser, string(hashedPassword), app.PwHistoryCount)
+	if usedBy, err := tokenstore.Consume(appID.String(), tokenstore.PurposePasswordChange, token); usedBy != userID {
+		return uuid.UUID{}, errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired password change token")
+	} else if err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
 
-	if err := s.Repo.UpdateUserPasswordWithHistory(userID, string(hashedPassword), resetUser.PasswordHistory); err != nil {
+	if err := s.Repo.UpdateUserPasswordWithHistory(userID, hashedPassword, user.PasswordHistory); err != nil {
 		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Failed to update password")
 	}
 
-	// Invalidate the token after use
-	if err := redis.DeletePasswordResetToken(appID.String(), token); err != nil {
-		log.Printf("Warning: Failed to delete used password reset token from Redis: %v\n", err)
-	}
+	s.afterPasswordChange(appID, user)
+
+	return user.ID, nil
+}
 
-	// Security: Revoke all existing tokens for this user after password change
+// afterPasswordChange requires a new login everywhere after the user's
+// password changed (sessions, refresh tokens and outstanding access tokens
+// are revoked, as are password reset links) and emails the user a
+// password-changed notification. Failures are logged: the password has
+// already changed.
+func (s *Service) afterPasswordChange(appID uuid.UUID, user *models.User) {
+	userID := user.ID.String()
+	if s.SessionService != nil {
+		if err := s.SessionService.RevokeAllUserSessions(appID.String(), userID); err != nil {
+			log.Printf("Warning: Failed to revoke sessions of user %s after password change: %v\n", userID, err.Message)
+		}
+	}
 	if err := s.RevokeAllUserTokens(appID.String(), userID); err != nil {
-		log.Printf("Warning: Failed to revoke all user tokens after password reset: %v\n", err.Message)
+		log.Printf("Warning: Failed to revoke all user tokens after password change: %v\n", err.Message)
 	}
 
-	// Parse UUID for return
-	userUUID, parseErr := uuid.Parse(userID)
-	if parseErr != nil {
-		return uuid.UUID{}, errors.NewAppError(errors.ErrInternal, "Invalid user ID format")
+	if s.EmailService != nil {
+		changeTime := time.Now().UTC().Format("2006-01-02 15:04:05 UTC")
+		if err := s.EmailService.SendPasswordChangedEmail(appID, user.Email, changeTime, &user.ID); err != nil {
+			log.Printf("Warning: Failed to send password changed email to user %s: %v\n", userID, err)
+		}
 	}
-
-	return userUUID, nil
 }
 
 // RevokeAllUserTokens revokes all access and refresh tokens for a user
@@ -659,6 +923,11 @@ func (s *Service) RevokeAllUserTokens(appID, userID string) *errors.AppError {
 		return errors.NewAppError(errors.ErrInternal, "Failed to blacklist user tokens")
 	}
 
+	// Outstanding password reset links must not outlive a password change
+	if err := tokenstore.RevokeAll(appID, userID, tokenstore.PurposePasswordReset); err != nil {
+		log.Printf("Warning: Failed to revoke password reset tokens for user %s: %v\n", userID, err)
+	}
+
 	return nil
 }
 
@@ -696,6 +965,38 @@ func (s *Service) UpdateUserProfile(userID string, req dto.UpdateProfileRequest)
 	return nil
 }
 
+// GetNotificationPreferences returns the user's notification preferences.
+func (s *Service) GetNotificationPreferences(userID string) (*dto.NotificationPreferencesResponse, *errors.AppError) {
+	user, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrNotFound, "User not found")
+	}
+	return &dto.NotificationPreferencesResponse{
+		SecurityAlerts: user.NotifySecurityAlerts,
+		ProductEmails:  user.NotifyProductEmails,
+	}, nil
+}
+
+// UpdateNotificationPreferences updates the provided notification preferences
+// and returns the resulting preferences.
+func (s *Service) UpdateNotificationPreferences(userID string, req dto.UpdateNotificationPreferencesRequest) (*dto.NotificationPreferencesResponse, *errors.AppError) {
+	updates := make(map[string]interface{})
+	if req.SecurityAlerts != nil {
+		updates["notify_security_alerts"] = *req.SecurityAlerts
+	}
+	if req.ProductEmails != nil {
+		updates["notify_product_emails"] = *req.ProductEmails
+	}
+	if len(updates) == 0 {
+		return nil, errors.NewAppError(errors.ErrBadRequest, "No fields provided for update")
+	}
+
+	if err := s.Repo.UpdateUserProfile(userID, updates); err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to update notification preferences")
+	}
+	return s.GetNotificationPreferences(userID)
+}
+
 // UpdateUserEmail updates the user's email address after verifying password
 func (s *Service) UpdateUserEmail(appID uuid.UUID, userID string, req dto.UpdateEmailRequest) *errors.AppError {
 	// Get current user to verify password
@@ -705,10 +1006,14 @@ func (s *Service) UpdateUserEmail(appID uuid.UUID, userID string, req dto.Update
 	}
 
 	// Verify current password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	if err := passhash.Verify(user.PasswordHash, req.Password); err != nil {
 		return errors.NewAppError(errors.ErrUnauthorized, "Invalid password")
 	}
 
+	if appErr := s.CheckEmailDomain(appID, req.Email); appErr != nil {
+		return appErr
+	}
+
 	// Check if new email is already in use
 	existingUser, err := s.Repo.GetUserByEmail(appID.String(), req.Email)
 	if err == nil && existingUser.ID != user.ID {
@@ -720,21 +1025,22 @@ func (s *Service) UpdateUserEmail(appID uuid.UUID, userID string, req dto.Update
 		return errors.NewAppError(errors.ErrInternal, "Failed to update email")
 	}
 
-	// Generate and send new email verification token
-	verificationToken := uuid.New().String()
-	if err := redis.SetEmailVerificationToken(appID.String(), userID, verificationToken, 24*time.Hour); err != nil {
-		return errors.NewAppError(errors.ErrInternal, "Failed to generate verification token")
-	}
-
-	if err := s.EmailService.SendVerificationEmail(appID, req.Email, verificationToken, &user.ID); err != nil {
-		return errors.NewAppError(errors.ErrInternal, "Failed to send verification email")
+	// Links and codes sent to the previous address must not verify the new
+	// one, and reset links sent there must not take over the account
+	revokeVerification(appID.String(), userID)
+	if err := tokenstore.RevokeAll(appID.String(), userID, tokenstore.PurposePasswordReset); err != nil {
+		log.Printf("Warning: Failed to revoke password reset tokens for user %s: %v\n", userID, err)
 	}
 
-	return nil
+	return s.sendVerification(appID, user.ID, req.Email)
 }
 
 // UpdateUserPassword updates the user's password after verifying current password
 func (s *Service) UpdateUserPassword(appID uuid.UUID, userID string, req dto.UpdatePasswordRequest) *errors.AppError {
+	if appErr := s.CheckPasswordAuthAllowed(appID); appErr != nil {
+		return appErr
+	}
+
 	// Get current user to verify password
 	user, err := s.Repo.GetUserByID(userID)
 	if err != nil {
@@ -742,7 +1048,7 @@ func (s *Service) UpdateUserPassword(appID uuid.UUID, userID string, req dto.Upd
 	}
 
 	// Verify current password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+	if err := passhash.Verify(user.PasswordHash, req.CurrentPassword); err != nil {
 		return errors.NewAppError(errors.ErrUnauthorized, "Invalid current password")
 	}
 
@@ -770,7 +1076,7 @@ func (s *Service) UpdateUserPassword(appID uuid.UUID, userID string, req dto.Upd
 	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcryptCost)
+	hashedPassword, err := passhash.Hash(req.NewPassword)
 	if err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to hash new password")
 	}
@@ -782,10 +1088,8 @@ func (s *Service) UpdateUserPassword(appID uuid.UUID, userID string, req dto.Upd
 		return errors.NewAppError(errors.ErrInternal, "Failed to update password")
 	}
 
-	// Revoke all existing tokens for security
-	if err := s.RevokeAllUserTokens(appID.String(), userID); err != nil {
-		log.Printf("Warning: Failed to revoke all user tokens after password change: %v\n", err.Message)
-	}
+	// Revoke all sessions and tokens for security, and notify the user
+	s.afterPasswordChange(appID, user)
 
 	// Dispatch webhook event (non-fatal)
 	if s.WebhookService != nil {
@@ -801,6 +1105,10 @@ func (s *Service) UpdateUserPassword(appID uuid.UUID, userID string, req dto.Upd
 // Returns ErrConflict if the user already has a password — callers should use
 // UpdateUserPassword instead in that case.
 func (s *Service) SetInitialPassword(appID uuid.UUID, userID string, newPassword string) *errors.AppError {
+	if appErr := s.CheckPasswordAuthAllowed(appID); appErr != nil {
+		return appErr
+	}
+
 	user, err := s.Repo.GetUserByID(userID)
 	if err != nil {
 		return errors.NewAppError(errors.ErrNotFound, "User not found")
@@ -822,15 +1130,21 @@ func (s *Service) SetInitialPassword(appID uuid.UUID, userID string, newPassword
 		return errors.NewAppError(errors.ErrBadRequest, pErr.Error())
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcryptCost)
+	hashedPassword, err := passhash.Hash(newPassword)
 	if err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to hash password")
 	}
+	AppendPasswordHistory(user, string(hashedPassword), app.PwHistoryCount)
 
 	if err := s.Repo.UpdateUserPasswordWithHistory(userID, string(hashedPassword), user.PasswordHistory); err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to set password")
 	}
 
+	// A reset link requested before the password was set must not replace it
+	if err := tokenstore.RevokeAll(appID.String(), userID, tokenstore.PurposePasswordReset); err != nil {
+		log.Printf("Warning: Failed to revoke password reset tokens for user %s: %v\n", userID, err)
+	}
+
 	// Dispatch webhook event (non-fatal)
 	if s.WebhookService != nil {
 		s.WebhookService.Dispatch(appID, "user.password_set", map[string]interface{}{
@@ -851,7 +1165,7 @@ func (s *Service) DeleteUserAccount(appID uuid.UUID, userID string, req dto.Dele
 
 	// Verify password (if user has password - social login users might not)
 	if user.PasswordHash != "" {
-		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		if err := passhash.Verify(user.PasswordHash, req.Password); err != nil {
 			return errors.NewAppError(errors.ErrUnauthorized, "Invalid password")
 		}
 	}
@@ -879,6 +1193,9 @@ func (s *Service) DeleteUserAccount(appID uuid.UUID, userID string, req dto.Dele
 	if err := s.Repo.DeleteUser(userID); err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to delete account")
 	}
+	if key := avatarKeyFromURL(user.ProfilePicture); key != "" {
+		deleteAvatar(context.Background(), key)
+	}
 
 	return nil
 }
@@ -898,12 +1215,15 @@ func generateSecure6DigitCode() string {
 func (s *Service) RequestMagicLink(appID uuid.UUID, email string) *errors.AppError {
 	// Check if magic link is enabled for this application
 	var app models.Application
-	if err := s.DB.Select("magic_link_enabled, frontend_url, magic_link_path").First(&app, "id = ?", appID).Error; err != nil {
+	if err := s.DB.Select("magic_link_enabled, allowed_auth_methods, frontend_url, magic_link_path, allowed_redirect_urls").First(&app, "id = ?", appID).Error; err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to load application settings")
 	}
 	if !app.MagicLinkEnabled {
 		return errors.NewAppError(errors.ErrBadRequest, "Magic link login is not enabled for this application")
 	}
+	if !app.AuthMethodAllowed(models.AuthMethodMagicLink) {
+		return errors.NewAppError(errors.ErrForbidden, "Magic link login is not allowed for this application")
+	}
 
 	user, err := s.Repo.GetUserByEmail(appID.String(), email)
 	if err != nil {
@@ -911,8 +1231,8 @@ func (s *Service) RequestMagicLink(appID uuid.UUID, email string) *errors.AppErr
 		return nil
 	}
 
-	// Check if account is active
-	if !user.IsActive {
+	// Check if account is active (service accounts cannot sign in)
+	if !user.IsActive || user.IsServiceAccount {
 		// Return nil to prevent email enumeration
 		return nil
 	}
@@ -932,8 +1252,13 @@ func (s *Service) RequestMagicLink(appID uuid.UUID, email string) *errors.AppErr
 	}
 
 	// Build the magic link URL — use per-app FrontendURL and MagicLinkPath, falling back to global defaults.
+	frontendURL, urlErr := util.ResolveAllowedFrontendURL(app.FrontendURL, app.AllowedRedirectURLs)
+	if urlErr != nil {
+		log.Printf("Warning: magic link email for app %s not sent: %v", appID, urlErr)
+		return nil
+	}
 	magicPath := util.ResolveLinkPath(app.MagicLinkPath, util.DefaultMagicLinkPath)
-	magicLink := fmt.Sprintf("%s%s?token=%s&app_id=%s", util.ResolveFrontendURL(app.FrontendURL), magicPath, magicToken, appID.String())
+	magicLink := fmt.Sprintf("%s%s?token=%s&app_id=%s", frontendURL, magicPath, magicToken, appID.String())
 
 	if err := s.EmailService.SendMagicLinkEmail(appID, user.Email, magicLink, &user.ID); err != nil {
 		return errors.NewAppError(errors.ErrInternal, "Failed to send magic link email")
@@ -947,12 +1272,15 @@ func (s *Service) RequestMagicLink(appID uuid.UUID, email string) *errors.AppErr
 func (s *Service) VerifyMagicLink(appID uuid.UUID, token, ip, userAgent string) (*LoginResult, *errors.AppError) {
 	// Check if magic link is enabled for this application
 	var app models.Application
-	if err := s.DB.Select("magic_link_enabled, access_token_ttl_minutes, refresh_token_ttl_hours").First(&app, "id = ?", appID).Error; err != nil {
+	if err := s.DB.Select("magic_link_enabled, allowed_auth_methods, access_token_ttl_minutes, refresh_token_ttl_hours").First(&app, "id = ?", appID).Error; err != nil {
 		return nil, errors.NewAppError(errors.ErrInternal, "Failed to load application settings")
 	}
 	if !app.MagicLinkEnabled {
 		return nil, errors.NewAppError(errors.ErrBadRequest, "Magic link login is not enabled for this application")
 	}
+	if !app.AuthMethodAllowed(models.AuthMethodMagicLink) {
+		return nil, errors.NewAppError(errors.ErrForbidden, "Magic link login is not allowed for this application")
+	}
 
 	// Retrieve userID from Redis
 	userID, err := redis.GetMagicLinkToken(appID.String(), token)
@@ -976,9 +1304,9 @@ func (s *Service) VerifyMagicLink(appID uuid.UUID, token, ip, userAgent string)
 		return nil, errors.NewAppError(errors.ErrUnauthorized, "User not found")
 	}
 
-	// Verify account is still active
-	if !user.IsActive {
-		return nil, errors.NewAppError(errors.ErrForbidden, "Account is deactivated. Please contact your administrator.")
+	// Verify account is still active (and approved)
+	if appErr := CheckAccountActive(user); appErr != nil {
+		return nil, appErr
 	}
 
 	// Verify email is still verified
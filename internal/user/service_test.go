@@ -1,10 +1,13 @@
 package user
 
 import (
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gjovanovicst/auth_api/internal/email"
 	"github.com/gjovanovicst/auth_api/pkg/errors"
+	"github.com/gjovanovicst/auth_api/pkg/models"
 	"github.com/spf13/viper"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -145,3 +148,104 @@ func TestEmailValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckAccountActive(t *testing.T) {
+	cases := []struct {
+		name    string
+		user    models.User
+		blocked bool
+	}{
+		{"active", models.User{IsActive: true}, false},
+		{"approved", models.User{IsActive: true, ApprovalStatus: models.ApprovalStatusApproved}, false},
+		{"deactivated", models.User{IsActive: false}, true},
+		{"pending", models.User{IsActive: false, ApprovalStatus: models.ApprovalStatusPending}, true},
+		// The approval status blocks sign-in even if the account is active
+		{"pending but active", models.User{IsActive: true, ApprovalStatus: models.ApprovalStatusPending}, true},
+		{"rejected", models.User{IsActive: false, ApprovalStatus: models.ApprovalStatusRejected}, true},
+		{"service account", models.User{IsActive: true, IsServiceAccount: true}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			appErr := CheckAccountActive(&tc.user)
+			if (appErr != nil) != tc.blocked {
+				t.Fatalf("CheckAccountActive() = %v, want blocked=%v", appErr, tc.blocked)
+			}
+			if appErr != nil && appErr.Code != http.StatusForbidden {
+				t.Errorf("expected status 403, got %d", appErr.Code)
+			}
+		})
+	}
+
+	if RegisteredMessage(&models.User{ApprovalStatus: models.ApprovalStatusPending}) == RegisteredMessage(&models.User{}) {
+		t.Error("expected a different registration message for accounts pending approval")
+	}
+}
+
+func TestPasswordHistory(t *testing.T) {
+	hash := func(pw string) string {
+		h, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("hash: %v", err)
+		}
+		return string(h)
+	}
+	user := &models.User{}
+	for _, pw := range []string{"first-pass", "second-pass", "third-pass"} {
+		AppendPasswordHistory(user, hash(pw), 2)
+	}
+
+	if err := CheckPasswordHistory("third-pass", user, 2); err == nil {
+		t.Error("reusing the current password was accepted")
+	}
+	if err := CheckPasswordHistory("second-pass", user, 2); err == nil {
+		t.Error("reusing the previous password was accepted")
+	}
+	if err := CheckPasswordHistory("first-pass", user, 2); err != nil {
+		t.Errorf("password older than the history was rejected: %v", err)
+	}
+	if err := CheckPasswordHistory("second-pass", user, 1); err != nil {
+		t.Errorf("history count 1 rejected the previous password: %v", err)
+	}
+
+	// Disabling the history clears the stored hashes on the next change
+	AppendPasswordHistory(user, hash("fourth-pass"), 0)
+	if string(user.PasswordHistory) != "[]" {
+		t.Errorf("history after disabling = %s, want []", user.PasswordHistory)
+	}
+
+	if got := ClampPasswordHistoryCount(100); got != MaxPasswordHistoryCount {
+		t.Errorf("ClampPasswordHistoryCount(100) = %d, want %d", got, MaxPasswordHistoryCount)
+	}
+	if got := ClampPasswordHistoryCount(-1); got != 0 {
+		t.Errorf("ClampPasswordHistoryCount(-1) = %d, want 0", got)
+	}
+}
+
+func TestPasswordChangeRequired(t *testing.T) {
+	now := time.Now().UTC()
+	daysAgo := func(d int) *time.Time {
+		at := now.AddDate(0, 0, -d)
+		return &at
+	}
+
+	tests := []struct {
+		name      string
+		changedAt *time.Time
+		app       models.Application
+		want      bool
+	}{
+		{"no policy", daysAgo(400), models.Application{}, false},
+		{"within max age", daysAgo(10), models.Application{PwMaxAgeDays: 90}, false},
+		{"past max age", daysAgo(100), models.Application{PwMaxAgeDays: 90}, true},
+		{"never changed, max age only", nil, models.Application{PwMaxAgeDays: 90}, false},
+		{"changed before forced rotation", daysAgo(2), models.Application{PwRotationRequiredAt: daysAgo(1)}, true},
+		{"changed after forced rotation", daysAgo(1), models.Application{PwRotationRequiredAt: daysAgo(2)}, false},
+		{"never changed, forced rotation", nil, models.Application{PwRotationRequiredAt: daysAgo(1)}, true},
+	}
+	for _, tt := range tests {
+		user := &models.User{PasswordChangedAt: tt.changedAt}
+		if got := PasswordChangeRequired(user, &tt.app); got != tt.want {
+			t.Errorf("%s: PasswordChangeRequired = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
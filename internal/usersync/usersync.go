@@ -0,0 +1,422 @@
+// Package usersync relays user changes to downstream systems such as CRMs and
+// data warehouses, so they stay in sync without polling the Admin API.
+//
+// A trigger on the users table records every insert, update and delete in the
+// user_change_events outbox, in the same transaction as the change. The relay
+// of each instance claims pending events, appends them to the Redis stream
+// USER_SYNC_STREAM (if set), sends them as user.created, user.updated and
+// user.deleted webhooks of the user's application, and deletes them. Events
+// carry the sanitized current state of the user, so an event that is relayed
+// late still carries the latest data; a deleted user is sent with its
+// identifiers only. Delivery is at least once: consumers
+// should upsert by user ID and may ignore events with a lower sequence than
+// the last one applied.
+//
+// Backfill queues a user.snapshot event for every user of an application, for
+// the initial load of a new consumer.
+package usersync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/internal/webhook"
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operations recorded in user_change_events
+const (
+	OperationCreated  = "created"
+	OperationUpdated  = "updated"
+	OperationDeleted  = "deleted"
+	OperationSnapshot = "snapshot" // Queued by Backfill
+)
+
+// EventType returns the webhook event type of an operation, e.g. user.created.
+func EventType(operation string) string {
+	return "user." + operation
+}
+
+const (
+	batchSize = 100
+
+	// triggerLockKey is the advisory lock serializing trigger installs of
+	// instances starting together.
+	triggerLockKey = 4627010
+)
+
+// captureTriggerSQL installs the trigger recording user changes. Updates that
+// only touch updated_at are not recorded. A deleted user is recorded with its
+// identifiers only, so that erasing a user leaves no personal data in the
+// outbox; deletions recorded by earlier versions with the last row are
+// reduced to the same.
+var captureTriggerSQL = []string{
+	`CREATE OR REPLACE FUNCTION capture_user_change() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		INSERT INTO user_change_events (user_id, app_id, operation, snapshot)
+		VALUES (OLD.id, OLD.app_id, 'deleted',
+			jsonb_build_object('id', OLD.id, 'app_id', OLD.app_id, 'deleted_at', now()));
+		RETURN OLD;
+	END IF;
+	IF TG_OP = 'UPDATE' AND to_jsonb(NEW) - 'updated_at' = to_jsonb(OLD) - 'updated_at' THEN
+		RETURN NEW;
+	END IF;
+	INSERT INTO user_change_events (user_id, app_id, operation)
+	VALUES (NEW.id, NEW.app_id, CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`,
+	`CREATE OR REPLACE TRIGGER users_capture_change AFTER INSERT OR UPDATE OR DELETE ON users
+	FOR EACH ROW EXECUTE FUNCTION capture_user_change()`,
+	`UPDATE user_change_events
+	SET snapshot = jsonb_build_object('id', user_id, 'app_id', app_id, 'deleted_at', created_at)
+	WHERE operation = 'deleted' AND snapshot - 'id' - 'app_id' - 'deleted_at' <> '{}'::jsonb`,
+}
+
+// Snapshot is the state of a user sent downstream: the profile and account
+// status, without password hashes, 2FA secrets or recovery codes.
+type Snapshot struct {
+	ID                   uuid.UUID      `json:"id"`
+	AppID                uuid.UUID      `json:"app_id"`
+	Email                string         `json:"email"`
+	EmailVerified        bool           `json:"email_verified"`
+	IsActive             bool           `json:"is_active"`
+	Name                 string         `json:"name"`
+	FirstName            string         `json:"first_name"`
+	LastName             string         `json:"last_name"`
+	ProfilePicture       string         `json:"profile_picture"`
+	Locale               string         `json:"locale"`
+	TwoFAEnabled         bool           `json:"two_fa_enabled"`
+	TwoFAMethod          string         `json:"two_fa_method"`
+	BackupEmail          string         `json:"backup_email"`
+	BackupEmailVerified  bool           `json:"backup_email_verified"`
+	PhoneNumber          string         `json:"phone_number"`
+	PhoneVerified        bool           `json:"phone_verified"`
+	LockedAt             *time.Time     `json:"locked_at"`
+	LockReason           string         `json:"lock_reason"`
+	LockExpiresAt        *time.Time     `json:"lock_expires_at"`
+	PasswordChangedAt    *time.Time     `json:"password_changed_at"`
+	NotifySecurityAlerts bool           `json:"notify_security_alerts"`
+	NotifyProductEmails  bool           `json:"notify_product_emails"`
+	ApprovalStatus       string         `json:"approval_status"`
+	Groups               datatypes.JSON `json:"groups"`
+	IsServiceAccount     bool           `json:"is_service_account"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+}
+
+// SnapshotOf returns the snapshot of u.
+func SnapshotOf(u *models.User) *Snapshot {
+	groups := u.Groups
+	if len(groups) == 0 {
+		groups = datatypes.JSON("[]")
+	}
+	return &Snapshot{
+		ID:                   u.ID,
+		AppID:                u.AppID,
+		Email:                u.Email,
+		EmailVerified:        u.EmailVerified,
+		IsActive:             u.IsActive,
+		Name:                 u.Name,
+		FirstName:            u.FirstName,
+		LastName:             u.LastName,
+		ProfilePicture:       u.ProfilePicture,
+		Locale:               u.Locale,
+		TwoFAEnabled:         u.TwoFAEnabled,
+		TwoFAMethod:          u.TwoFAMethod,
+		BackupEmail:          u.BackupEmail,
+		BackupEmailVerified:  u.BackupEmailVerified,
+		PhoneNumber:          u.PhoneNumber,
+		PhoneVerified:        u.PhoneVerified,
+		LockedAt:             u.LockedAt,
+		LockReason:           u.LockReason,
+		LockExpiresAt:        u.LockExpiresAt,
+		PasswordChangedAt:    u.PasswordChangedAt,
+		NotifySecurityAlerts: u.NotifySecurityAlerts,
+		NotifyProductEmails:  u.NotifyProductEmails,
+		ApprovalStatus:       u.ApprovalStatus,
+		Groups:               groups,
+		IsServiceAccount:     u.IsServiceAccount,
+		CreatedAt:            u.CreatedAt,
+		UpdatedAt:            u.UpdatedAt,
+	}
+}
+
+// Event is a user change as sent downstream: the data of the webhook and the
+// "event" field of stream entries.
+type Event struct {
+	Sequence  int64     `json:"sequence"`
+	EventType string    `json:"event_type"`
+	Operation string    `json:"operation"`
+	UserID    uuid.UUID `json:"user_id"`
+	AppID     uuid.UUID `json:"app_id"`
+	ChangedAt time.Time `json:"changed_at"`
+	User      *Snapshot `json:"user"` // nil for a deleted user
+}
+
+// Service installs the capture trigger and relays the outbox.
+type Service struct {
+	db           *gorm.DB
+	webhooks     *webhook.Service // nil = no webhooks
+	rdb          *redis.Client    // nil = no stream
+	stream       string
+	streamMaxLen int64
+	interval     time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates the service from the USER_SYNC_* settings; Start
+// installs the trigger and begins relaying.
+func NewService(db *gorm.DB, webhooks *webhook.Service, rdb *redis.Client) *Service {
+	interval := time.Duration(viper.GetInt("USER_SYNC_POLL_INTERVAL_SECONDS")) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		db:           db,
+		webhooks:     webhooks,
+		rdb:          rdb,
+		stream:       viper.GetString("USER_SYNC_STREAM"),
+		streamMaxLen: viper.GetInt64("USER_SYNC_STREAM_MAXLEN"),
+		interval:     interval,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start installs the capture trigger and relays pending events every poll
+// interval until Shutdown. Without the trigger, only backfilled events are
+// relayed.
+func (s *Service) Start() {
+	if err := s.InstallTrigger(s.ctx); err != nil {
+		log.Printf("Warning: User sync: failed to install the capture trigger on users, changes are not recorded: %v", err)
+	}
+	if s.stream != "" && s.rdb == nil {
+		log.Printf("Warning: User sync: USER_SYNC_STREAM is set but Redis is not available; events are relayed to webhooks only")
+	}
+	s.wg.Add(1)
+	go s.worker()
+	log.Printf("User sync enabled (stream %q, polling every %v)", s.stream, s.interval)
+}
+
+// Shutdown stops relaying; pending events are relayed after the next start.
+func (s *Service) Shutdown() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// InstallTrigger creates or replaces the trigger recording user changes.
+func (s *Service) InstallTrigger(ctx context.Context) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", triggerLockKey).Error; err != nil {
+			return err
+		}
+		for _, stmt := range captureTriggerSQL {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveTrigger drops the capture trigger if it is installed, so that the
+// outbox does not grow while the relay is disabled.
+func RemoveTrigger(ctx context.Context, db *gorm.DB) error {
+	var installed bool
+	if err := db.WithContext(ctx).Raw(
+		"SELECT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'users_capture_change')").Scan(&installed).Error; err != nil {
+		return err
+	}
+	if !installed {
+		return nil
+	}
+	if err := db.WithContext(ctx).Exec("DROP TRIGGER IF EXISTS users_capture_change ON users").Error; err != nil {
+		return err
+	}
+	log.Printf("User sync disabled: removed the capture trigger on users")
+	return nil
+}
+
+func (s *Service) worker() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Relay(s.ctx); err != nil && s.ctx.Err() == nil {
+				log.Printf("Warning: User sync: failed to relay user changes: %v", err)
+			}
+		}
+	}
+}
+
+// Relay relays pending events in batches until none are left.
+func (s *Service) Relay(ctx context.Context) error {
+	for ctx.Err() == nil {
+		n, err := s.relayBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if n < batchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// relayBatch claims up to batchSize pending events, appends them to the
+// stream and deletes them in one transaction, then sends the webhooks. It
+// returns the number claimed. Events that cannot be appended stay pending.
+// SKIP LOCKED lets the instances relay in parallel.
+func (s *Service) relayBatch(ctx context.Context) (int, error) {
+	var (
+		events  []Event
+		claimed int
+	)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var changes []models.UserChangeEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Order("id").Limit(batchSize).Find(&changes).Error; err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		var ids []uuid.UUID
+		for _, c := range changes {
+			if c.Operation != OperationDeleted {
+				ids = append(ids, c.UserID)
+			}
+		}
+		var users []models.User
+		if len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Find(&users).Error; err != nil {
+				return err
+			}
+		}
+		events = buildEvents(changes, users)
+
+		if err := s.appendToStream(ctx, events); err != nil {
+			return err
+		}
+		seqs := make([]int64, len(changes))
+		for i, c := range changes {
+			seqs[i] = c.ID
+		}
+		if err := tx.Where("id IN ?", seqs).Delete(&models.UserChangeEvent{}).Error; err != nil {
+			return err
+		}
+		claimed = len(changes)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if s.webhooks != nil {
+		for _, e := range events {
+			s.webhooks.Dispatch(e.AppID, e.EventType, e)
+		}
+	}
+	return claimed, nil
+}
+
+// buildEvents returns the events to send for changes, given the current rows
+// of the users they concern. A created, updated or snapshot change of a user
+// that no longer exists is dropped: its deletion is a later change. A deleted
+// event carries no user, only the user and app IDs.
+func buildEvents(changes []models.UserChangeEvent, users []models.User) []Event {
+	byID := make(map[uuid.UUID]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	events := make([]Event, 0, len(changes))
+	for _, c := range changes {
+		e := Event{
+			Sequence:  c.ID,
+			EventType: EventType(c.Operation),
+			Operation: c.Operation,
+			UserID:    c.UserID,
+			AppID:     c.AppID,
+			ChangedAt: c.CreatedAt.UTC(),
+		}
+		if c.Operation != OperationDeleted {
+			u := byID[c.UserID]
+			if u == nil {
+				continue
+			}
+			// The app of a user moved to another app is the new one
+			e.AppID = u.AppID
+			e.User = SnapshotOf(u)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// appendToStream adds events to USER_SYNC_STREAM, capped at about
+// USER_SYNC_STREAM_MAXLEN entries (0 = uncapped).
+func (s *Service) appendToStream(ctx context.Context, events []Event) error {
+	if s.stream == "" || s.rdb == nil || len(events) == 0 {
+		return nil
+	}
+	pipe := s.rdb.Pipeline()
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: s.stream,
+			MaxLen: s.streamMaxLen,
+			Approx: s.streamMaxLen > 0,
+			Values: map[string]interface{}{
+				"event_type": e.EventType,
+				"app_id":     e.AppID.String(),
+				"user_id":    e.UserID.String(),
+				"event":      string(data),
+			},
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("append to stream %s: %w", s.stream, err)
+	}
+	return nil
+}
+
+// Backfill queues a snapshot event for every user of the application, for
+// the initial load of a new consumer. It returns the number queued.
+func (s *Service) Backfill(ctx context.Context, appID uuid.UUID) (int64, error) {
+	res := s.db.WithContext(ctx).Exec(
+		`INSERT INTO user_change_events (user_id, app_id, operation)
+		SELECT id, app_id, ? FROM users WHERE app_id = ? ORDER BY created_at, id`,
+		OperationSnapshot, appID)
+	return res.RowsAffected, res.Error
+}
+
+// Pending returns the number of events of the application waiting to be
+// relayed, e.g. to follow a backfill.
+func (s *Service) Pending(ctx context.Context, appID uuid.UUID) (int64, error) {
+	var n int64
+	err := s.db.WithContext(ctx).Model(&models.UserChangeEvent{}).Where("app_id = ?", appID).Count(&n).Error
+	return n, err
+}
@@ -0,0 +1,88 @@
+package usersync
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestBuildEvents(t *testing.T) {
+	oldApp, newApp := uuid.New(), uuid.New()
+	alice := models.User{ID: uuid.New(), AppID: newApp, Email: "alice@example.com", PasswordHash: "secret"}
+	bob := uuid.New()
+	at := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+	changes := []models.UserChangeEvent{
+		{ID: 1, UserID: alice.ID, AppID: oldApp, Operation: OperationCreated, CreatedAt: at},
+		{ID: 2, UserID: bob, AppID: oldApp, Operation: OperationUpdated, CreatedAt: at}, // Deleted since
+		{ID: 3, UserID: bob, AppID: oldApp, Operation: OperationDeleted, CreatedAt: at,
+			Snapshot: datatypes.JSON(`{"id": "` + bob.String() + `", "email": "bob@example.com", "created_at": "2026-10-01T08:00:00.123456+00:00"}`)},
+	}
+	events := buildEvents(changes, []models.User{alice})
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+
+	created := events[0]
+	if created.Sequence != 1 || created.EventType != "user.created" || created.AppID != newApp || created.User.Email != alice.Email {
+		t.Errorf("unexpected created event: %+v", created)
+	}
+	data, err := json.Marshal(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields struct {
+		User map[string]interface{} `json:"user"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields.User["password_hash"]; ok {
+		t.Error("snapshot exposes the password hash")
+	}
+	if string(created.User.Groups) != "[]" {
+		t.Errorf("groups = %s, want []", created.User.Groups)
+	}
+
+	deleted := events[1]
+	if deleted.EventType != "user.deleted" || deleted.UserID != bob || deleted.AppID != oldApp || deleted.User != nil {
+		t.Errorf("unexpected deleted event: %+v %+v", deleted, deleted.User)
+	}
+}
+
+// An erased user must not reach webhooks or the stream with personal data,
+// even from a deletion recorded by an earlier trigger with the last row.
+func TestDeletedEventWithoutPersonalData(t *testing.T) {
+	_, onDelete, _ := strings.Cut(captureTriggerSQL[0], "IF TG_OP = 'DELETE' THEN")
+	onDelete, _, _ = strings.Cut(onDelete, "END IF;")
+	if !strings.Contains(onDelete, "jsonb_build_object('id', OLD.id, 'app_id', OLD.app_id, 'deleted_at', now())") ||
+		strings.Contains(onDelete, "to_jsonb(OLD)") {
+		t.Errorf("trigger records more than the IDs of a deleted user: %s", onDelete)
+	}
+
+	carol := uuid.New()
+	changes := []models.UserChangeEvent{{ID: 7, UserID: carol, AppID: uuid.New(), Operation: OperationDeleted,
+		Snapshot: datatypes.JSON(`{"id": "` + carol.String() + `", "email": "carol@example.com", "name": "Carol Jones",
+			"phone_number": "+15550100", "backup_email": "carol@backup.example.com"}`)}}
+	events := buildEvents(changes, nil)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	data, err := json.Marshal(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pii := range []string{"carol@example.com", "Carol Jones", "+15550100", "carol@backup.example.com"} {
+		if strings.Contains(string(data), pii) {
+			t.Errorf("deleted event exposes %q: %s", pii, data)
+		}
+	}
+	if !strings.Contains(string(data), carol.String()) {
+		t.Errorf("deleted event lacks the user ID: %s", data)
+	}
+}
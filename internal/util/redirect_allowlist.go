@@ -0,0 +1,69 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrURLNotAllowed is returned when a URL is not covered by the redirect allowlist.
+var ErrURLNotAllowed = errors.New("URL is not in the redirect allowlist")
+
+// ParseAllowlist splits a redirect allowlist stored as text into its entries.
+// Entries may be separated by commas, whitespace or newlines.
+func ParseAllowlist(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+}
+
+// IsURLAllowed reports whether rawURL is an absolute http(s) URL whose host is
+// covered by the allowlist. Supported entry formats:
+//
+//	app.example.com          — exact host (with port if the URL has one, e.g. localhost:3000)
+//	.example.com             — any subdomain of example.com
+//	https://app.example.com  — exact scheme and host
+//
+// Host comparison is case-insensitive. URLs with embedded credentials are rejected.
+func IsURLAllowed(rawURL string, allowlist []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.User != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimRight(strings.TrimSpace(entry), "/"))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "://") {
+			if e, err := url.Parse(entry); err == nil && e.Scheme == scheme && e.Host == host {
+				return true
+			}
+			continue
+		}
+		if host == entry {
+			return true
+		}
+		if strings.HasPrefix(entry, ".") && strings.HasSuffix(host, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveAllowedFrontendURL resolves the frontend URL used to build email links
+// (see ResolveFrontendURL) and checks it against the application's redirect
+// allowlist. An empty allowlist allows any URL.
+func ResolveAllowedFrontendURL(appFrontendURL, allowlist string) (string, error) {
+	base := ResolveFrontendURL(appFrontendURL)
+	if entries := ParseAllowlist(allowlist); len(entries) > 0 && !IsURLAllowed(base, entries) {
+		return "", ErrURLNotAllowed
+	}
+	return base, nil
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	"github.com/gjovanovicst/auth_api/pkg/jwt"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -39,6 +40,7 @@ type Handler struct {
 	IPRuleEvaluator   *geoip.IPRuleEvaluator // IP access control evaluator (nil = no IP rules)
 	AnomalyDetector   *log.AnomalyDetector   // Anomaly detector for login monitoring (nil = disabled)
 	WebhookService    *webhook.Service       // Optional: webhook dispatcher (nil = disabled)
+	UserService       *user.Service          // Completes passkey-only account registration (webhook, default role, verification email)
 	DB                *gorm.DB               // for loading per-app token TTL overrides
 }
 
@@ -562,6 +564,115 @@ func (h *Handler) FinishPasswordlessLogin(c *gin.Context) {
 	})
 }
 
+// ============================================================================
+// Passkey-only Account Registration (Public)
+// ============================================================================
+
+// @Summary Begin passkey account registration
+// @Description Start creating a new account from a passkey registration ceremony. Only available for applications in passkey-only mode, where password registration is disabled.
+// @Tags Passkeys
+// @Accept json
+// @Produce json
+// @Param request body dto.PasskeyAccountBeginRequest true "Account email and optional name"
+// @Success 200 {object} dto.PasskeyAccountBeginResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse "Application is not in passkey-only mode, or user quota exceeded"
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /passkey/register-account/begin [post]
+func (h *Handler) BeginAccountRegistration(c *gin.Context) {
+	var req dto.PasskeyAccountBeginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	appIDVal, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "App ID missing from context"})
+		return
+	}
+	appID := appIDVal.(uuid.UUID)
+
+	if h.UserService != nil {
+		if appErr := h.UserService.CheckEmailDomain(appID, req.Email); appErr != nil {
+			c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+			return
+		}
+	}
+
+	options, sessionID, appErr := h.Service.BeginAccountRegistration(appID, req.Email, strings.TrimSpace(req.Name))
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PasskeyAccountBeginResponse{
+		Options:   options,
+		SessionID: sessionID,
+	})
+}
+
+// @Summary Finish passkey account registration
+// @Description Complete the passkey registration ceremony and create the account with its first passkey. A verification email is sent; the account can sign in once the email is verified.
+// @Tags Passkeys
+// @Accept json
+// @Produce json
+// @Param request body dto.PasskeyAccountFinishRequest true "Registration response"
+// @Success 201 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /passkey/register-account/finish [post]
+func (h *Handler) FinishAccountRegistration(c *gin.Context) {
+	var req dto.PasskeyAccountFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	appIDVal, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "App ID missing from context"})
+		return
+	}
+	appID := appIDVal.(uuid.UUID)
+
+	ipAddress, userAgent := util.GetClientInfo(c)
+	if !h.checkIPAccess(c, appID, ipAddress, userAgent) {
+		return
+	}
+
+	usr, appErr := h.Service.FinishAccountRegistration(appID, req.SessionID, req.Name, req.Credential)
+	if appErr != nil {
+		c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+		return
+	}
+
+	if h.UserService != nil {
+		if appErr := h.UserService.CompleteRegistration(appID, usr); appErr != nil {
+			c.JSON(appErr.Code, dto.ErrorResponse{Error: appErr.Message})
+			return
+		}
+	}
+
+	log.LogRegister(appID, usr.ID, ipAddress, userAgent, usr.Email)
+	log.LogPasskeyRegister(appID, usr.ID, ipAddress, userAgent, req.Name)
+	health.IncRegister(appID.String())
+
+	c.JSON(http.StatusCreated, dto.MessageResponse{Message: user.RegisteredMessage(usr)})
+}
+
 // ============================================================================
 // Helper functions
 // ============================================================================
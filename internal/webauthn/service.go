@@ -8,6 +8,7 @@ import (
 	"time"
 
 	emailpkg "github.com/gjovanovicst/auth_api/internal/email"
+	"github.com/gjovanovicst/auth_api/internal/quota"
 	"github.com/gjovanovicst/auth_api/internal/redis"
 	"github.com/gjovanovicst/auth_api/internal/user"
 	"github.com/gjovanovicst/auth_api/pkg/errors"
@@ -322,9 +323,13 @@ func (s *Service) BeginPasswordlessLogin(appID uuid.UUID) (json.RawMessage, stri
 		return nil, "", appErr
 	}
 
-	if !app.PasskeyLoginEnabled {
+	// Passkey-only applications always allow passwordless login
+	if !app.PasskeyLoginEnabled && !app.PasswordlessOnly {
 		return nil, "", errors.NewAppError(errors.ErrForbidden, "Passwordless login is not enabled for this application")
 	}
+	if !app.AuthMethodAllowed(models.AuthMethodPasskey) {
+		return nil, "", errors.NewAppError(errors.ErrForbidden, msgPasskeyLoginNotAllowed)
+	}
 
 	wan, err := GetWebAuthnForPasswordless(s.DB, app)
 	if err != nil {
@@ -366,9 +371,13 @@ func (s *Service) FinishPasswordlessLogin(appID uuid.UUID, sessionID string, cre
 		return "", appErr
 	}
 
-	if !app.PasskeyLoginEnabled {
+	// Passkey-only applications always allow passwordless login
+	if !app.PasskeyLoginEnabled && !app.PasswordlessOnly {
 		return "", errors.NewAppError(errors.ErrForbidden, "Passwordless login is not enabled for this application")
 	}
+	if !app.AuthMethodAllowed(models.AuthMethodPasskey) {
+		return "", errors.NewAppError(errors.ErrForbidden, msgPasskeyLoginNotAllowed)
+	}
 
 	sessionJSON, err := redis.GetWebAuthnLoginChallenge(appID.String(), sessionID)
 	if err != nil {
@@ -417,6 +426,9 @@ func (s *Service) FinishPasswordlessLogin(appID uuid.UUID, sessionID string, cre
 		if !usr.IsActive {
 			return nil, fmt.Errorf("account is deactivated")
 		}
+		if usr.IsServiceAccount {
+			return nil, fmt.Errorf("service accounts cannot sign in interactively")
+		}
 		if !usr.EmailVerified {
 			return nil, fmt.Errorf("email not verified")
 		}
@@ -457,6 +469,9 @@ func (s *Service) FinishPasswordlessLogin(appID uuid.UUID, sessionID string, cre
 	if !usr.IsActive {
 		return "", errors.NewAppError(errors.ErrForbidden, "Account is deactivated")
 	}
+	if usr.IsServiceAccount {
+		return "", errors.NewAppError(errors.ErrForbidden, "Service accounts cannot sign in interactively")
+	}
 	if !usr.EmailVerified {
 		return "", errors.NewAppError(errors.ErrForbidden, "Email not verified")
 	}
@@ -469,6 +484,156 @@ func (s *Service) FinishPasswordlessLogin(appID uuid.UUID, sessionID string, cre
 	return dbCred.UserID.String(), nil
 }
 
+// ============================================================================
+// Passkey-only Account Registration
+// ============================================================================
+
+// pendingAccount is the state of a passkey-only account registration kept in
+// Redis between the begin and finish calls. The user ID is chosen up front
+// because it becomes the credential's user handle.
+type pendingAccount struct {
+	UserID  uuid.UUID              `json:"user_id"`
+	Email   string                 `json:"email"`
+	Name    string                 `json:"name"`
+	Session gowebauthn.SessionData `json:"session"`
+}
+
+// BeginAccountRegistration starts the creation of a new account from a passkey
+// registration ceremony, for applications in passkey-only mode. Returns the
+// creation options and a session ID that the client must pass back.
+func (s *Service) BeginAccountRegistration(appID uuid.UUID, email, name string) (json.RawMessage, string, *errors.AppError) {
+	app, appErr := s.getPasswordlessOnlyApp(appID)
+	if appErr != nil {
+		return nil, "", appErr
+	}
+
+	if _, err := s.UserRepo.GetUserByEmail(appID.String(), email); err == nil {
+		return nil, "", errors.NewAppError(errors.ErrConflict, "Email already registered")
+	}
+	if appErr := quota.ToAppError(quota.CheckAppUsers(s.DB, appID, 1)); appErr != nil {
+		return nil, "", appErr
+	}
+
+	wan, err := GetWebAuthnForPasswordless(s.DB, app)
+	if err != nil {
+		log.Printf("Failed to initialize WebAuthn: %v", err)
+		return nil, "", errors.NewAppError(errors.ErrInternal, "WebAuthn is not configured")
+	}
+
+	// Discoverable credential, so the account can sign in without an email
+	pending := pendingAccount{UserID: uuid.New(), Email: email, Name: name}
+	webauthnUser := &WebAuthnUser{User: &models.User{ID: pending.UserID, AppID: appID, Email: email, Name: name}}
+	options, session, err := wan.BeginRegistration(webauthnUser)
+	if err != nil {
+		log.Printf("Failed to begin WebAuthn account registration: %v", err)
+		return nil, "", errors.NewAppError(errors.ErrInternal, "Failed to start passkey registration")
+	}
+	pending.Session = *session
+
+	sessionID := uuid.New().String()
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		return nil, "", errors.NewAppError(errors.ErrInternal, "Failed to serialize session")
+	}
+	if err := redis.SetWebAuthnRegistrationChallenge(appID.String(), sessionID, string(pendingJSON), challengeTTL); err != nil {
+		return nil, "", errors.NewAppError(errors.ErrInternal, "Failed to store registration challenge")
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, "", errors.NewAppError(errors.ErrInternal, "Failed to serialize options")
+	}
+
+	return optionsJSON, sessionID, nil
+}
+
+// FinishAccountRegistration verifies the attestation of a passkey-only account
+// registration and creates the account together with its first passkey. The
+// account still has to verify its email address before it can sign in.
+func (s *Service) FinishAccountRegistration(appID uuid.UUID, sessionID, credentialName string, credentialJSON json.RawMessage) (*models.User, *errors.AppError) {
+	app, appErr := s.getPasswordlessOnlyApp(appID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	pendingJSON, err := redis.GetWebAuthnRegistrationChallenge(appID.String(), sessionID)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrUnauthorized, "Invalid or expired registration session")
+	}
+
+	var pending pendingAccount
+	if err := json.Unmarshal([]byte(pendingJSON), &pending); err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to parse session data")
+	}
+
+	wan, err := GetWebAuthnForPasswordless(s.DB, app)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInternal, "WebAuthn is not configured")
+	}
+
+	parsedResponse, err := protocol.ParseCredentialCreationResponseBody(strings.NewReader(string(credentialJSON)))
+	if err != nil {
+		log.Printf("Failed to parse credential creation response: %v", err)
+		return nil, errors.NewAppError(errors.ErrBadRequest, "Invalid credential response")
+	}
+
+	usr := &models.User{
+		ID:       pending.UserID,
+		AppID:    appID,
+		Email:    pending.Email,
+		Name:     pending.Name,
+		IsActive: true,
+	}
+	credential, err := wan.CreateCredential(&WebAuthnUser{User: usr}, pending.Session, parsedResponse)
+	if err != nil {
+		log.Printf("Failed to create WebAuthn credential: %v", err)
+		return nil, errors.NewAppError(errors.ErrBadRequest, "Failed to verify passkey registration")
+	}
+
+	// The email may have been taken, or the quota reached, since the ceremony began
+	if _, err := s.UserRepo.GetUserByEmail(appID.String(), usr.Email); err == nil {
+		return nil, errors.NewAppError(errors.ErrConflict, "Email already registered")
+	}
+	if appErr := quota.ToAppError(quota.CheckAppUsers(s.DB, appID, 1)); appErr != nil {
+		return nil, appErr
+	}
+
+	if credentialName == "" {
+		credentialName = "Passkey 1"
+	}
+	dbCred := &models.WebAuthnCredential{
+		UserID:          &usr.ID,
+		AppID:           &appID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Name:            credentialName,
+		Transports:      serializeTransports(credential.Transport),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+
+	// Create the account and its passkey together: an account without a
+	// passkey could never sign in to a passkey-only application
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(usr).Error; err != nil {
+			return err
+		}
+		return tx.Create(dbCred).Error
+	}); err != nil {
+		log.Printf("Failed to create passkey-only account: %v", err)
+		return nil, errors.NewAppError(errors.ErrInternal, "Failed to create user")
+	}
+
+	if err := redis.DeleteWebAuthnRegistrationChallenge(appID.String(), sessionID); err != nil {
+		log.Printf("Warning: Failed to delete WebAuthn registration challenge: %v", err)
+	}
+
+	return usr, nil
+}
+
 // ============================================================================
 // Credential Management
 // ============================================================================
@@ -548,14 +713,33 @@ func (s *Service) getAppWithPasskeyCheck(appID uuid.UUID) (*models.Application,
 		return nil, appErr
 	}
 
-	// Passkey is allowed if either 2FA passkey or passwordless login is enabled
-	if !app.Passkey2FAEnabled && !app.PasskeyLoginEnabled {
+	// Passkey is allowed if 2FA passkey, passwordless login or passkey-only mode is enabled
+	if !app.Passkey2FAEnabled && !app.PasskeyLoginEnabled && !app.PasswordlessOnly {
 		return nil, errors.NewAppError(errors.ErrForbidden, "Passkey support is not enabled for this application")
 	}
 
 	return app, nil
 }
 
+// msgPasskeyLoginNotAllowed is returned when the application's auth method
+// policy does not permit signing in with a passkey.
+const msgPasskeyLoginNotAllowed = "Passkey sign-in is not allowed for this application"
+
+// getPasswordlessOnlyApp fetches the app and verifies that it runs in passkey-only mode.
+func (s *Service) getPasswordlessOnlyApp(appID uuid.UUID) (*models.Application, *errors.AppError) {
+	app, appErr := s.getApp(appID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !app.PasswordlessOnly {
+		return nil, errors.NewAppError(errors.ErrForbidden, "Passkey account registration is only available for passkey-only applications")
+	}
+	if !app.AuthMethodAllowed(models.AuthMethodPasskey) {
+		return nil, errors.NewAppError(errors.ErrForbidden, msgPasskeyLoginNotAllowed)
+	}
+	return app, nil
+}
+
 // updateCredentialAfterLogin updates the sign count for the credential that was just used.
 func (s *Service) updateCredentialAfterLogin(creds []models.WebAuthnCredential, credential *gowebauthn.Credential) {
 	for _, c := range creds {
@@ -2,11 +2,11 @@ package webhook
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gjovanovicst/auth_api/pkg/dto"
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 )
 
@@ -55,18 +55,6 @@ func toDeliveryResponse(d models.WebhookDelivery) dto.WebhookDeliveryResponse {
 	return r
 }
 
-func parsePage(c *gin.Context) (int, int) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
-	return page, pageSize
-}
-
 // ============================================================================
 // Admin API endpoints (X-Admin-API-Key)
 // ============================================================================
@@ -77,29 +65,24 @@ func parsePage(c *gin.Context) (int, int) {
 // @Tags Webhooks
 // @Produce json
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(20)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, event_type, url (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param filter[event_type] query string false "Filter by event type"
+// @Param filter[is_active] query bool false "Filter by active state"
+// @Param filter[app_id] query string false "Filter by application ID"
 // @Success 200 {object} dto.WebhookEndpointListResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security AdminApiKey
 // @Router /admin/webhooks [get]
 func (h *Handler) AdminListEndpoints(c *gin.Context) {
-	page, pageSize := parsePage(c)
-	endpoints, total, err := h.Service.ListAllEndpoints(page, pageSize)
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), endpointListSpec)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list webhook endpoints"})
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
-
-	resp := make([]dto.WebhookEndpointResponse, len(endpoints))
-	for i, ep := range endpoints {
-		resp[i] = toEndpointResponse(ep)
-	}
-	c.JSON(http.StatusOK, dto.WebhookEndpointListResponse{
-		Endpoints: resp,
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
-	})
+	h.listEndpoints(c, q)
 }
 
 // AdminCreateEndpoint registers a new webhook endpoint for an application.
@@ -152,7 +135,11 @@ func (h *Handler) AdminCreateEndpoint(c *gin.Context) {
 // @Produce json
 // @Param app_id path string true "Application ID"
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(20)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, event_type, url (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param filter[event_type] query string false "Filter by event type"
+// @Param filter[is_active] query bool false "Filter by active state"
 // @Success 200 {object} dto.WebhookEndpointListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -164,9 +151,18 @@ func (h *Handler) AdminListEndpointsByApp(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid app_id"})
 		return
 	}
-	page, pageSize := parsePage(c)
-	endpoints, total, svcErr := h.Service.ListEndpointsByApp(appID, page, pageSize)
-	if svcErr != nil {
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), endpointListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	h.listEndpoints(c, q.WithFilter("app_id", appID.String()))
+}
+
+// listEndpoints responds with a page of webhook endpoints.
+func (h *Handler) listEndpoints(c *gin.Context, q pagination.ListQuery) {
+	endpoints, total, err := h.Service.ListEndpoints(q)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list webhook endpoints"})
 		return
 	}
@@ -176,10 +172,13 @@ func (h *Handler) AdminListEndpointsByApp(c *gin.Context) {
 		resp[i] = toEndpointResponse(ep)
 	}
 	c.JSON(http.StatusOK, dto.WebhookEndpointListResponse{
-		Endpoints: resp,
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
+		Endpoints:  resp,
+		Total:      total,
+		Page:       q.Page,
+		PageSize:   q.PageSize,
+		TotalPages: q.TotalPages(total),
+		Sort:       q.Sort,
+		Order:      q.Order,
 	})
 }
 
@@ -247,7 +246,12 @@ func (h *Handler) AdminDeleteEndpoint(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Webhook Endpoint ID"
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(20)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, status_code, latency_ms, event_type (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param filter[event_type] query string false "Filter by event type"
+// @Param filter[success] query bool false "Filter by delivery outcome"
+// @Param filter[status_code] query int false "Filter by HTTP status code"
 // @Success 200 {object} dto.WebhookDeliveryListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -259,23 +263,12 @@ func (h *Handler) AdminListDeliveriesByEndpoint(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid endpoint ID"})
 		return
 	}
-	page, pageSize := parsePage(c)
-	deliveries, total, svcErr := h.Service.ListDeliveriesByEndpoint(id, page, pageSize)
-	if svcErr != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch delivery logs"})
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), deliveryListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
-
-	resp := make([]dto.WebhookDeliveryResponse, len(deliveries))
-	for i, d := range deliveries {
-		resp[i] = toDeliveryResponse(d)
-	}
-	c.JSON(http.StatusOK, dto.WebhookDeliveryListResponse{
-		Deliveries: resp,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-	})
+	h.listDeliveries(c, q.WithFilter("endpoint_id", id.String()))
 }
 
 // AdminListDeliveriesByApp returns all delivery logs for an app.
@@ -285,7 +278,13 @@ func (h *Handler) AdminListDeliveriesByEndpoint(c *gin.Context) {
 // @Produce json
 // @Param app_id path string true "Application ID"
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(20)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, status_code, latency_ms, event_type (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param filter[event_type] query string false "Filter by event type"
+// @Param filter[success] query bool false "Filter by delivery outcome"
+// @Param filter[status_code] query int false "Filter by HTTP status code"
+// @Param filter[endpoint_id] query string false "Filter by webhook endpoint ID"
 // @Success 200 {object} dto.WebhookDeliveryListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -297,9 +296,18 @@ func (h *Handler) AdminListDeliveriesByApp(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid app_id"})
 		return
 	}
-	page, pageSize := parsePage(c)
-	deliveries, total, svcErr := h.Service.ListDeliveriesByApp(appID, page, pageSize)
-	if svcErr != nil {
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), deliveryListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	h.listDeliveries(c, q.WithFilter("app_id", appID.String()))
+}
+
+// listDeliveries responds with a page of delivery logs.
+func (h *Handler) listDeliveries(c *gin.Context, q pagination.ListQuery) {
+	deliveries, total, err := h.Service.ListDeliveries(q)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch delivery logs"})
 		return
 	}
@@ -311,8 +319,11 @@ func (h *Handler) AdminListDeliveriesByApp(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.WebhookDeliveryListResponse{
 		Deliveries: resp,
 		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
+		Page:       q.Page,
+		PageSize:   q.PageSize,
+		TotalPages: q.TotalPages(total),
+		Sort:       q.Sort,
+		Order:      q.Order,
 	})
 }
 
@@ -369,7 +380,11 @@ func (h *Handler) AppCreateEndpoint(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Application ID"
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(20)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, event_type, url (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param filter[event_type] query string false "Filter by event type"
+// @Param filter[is_active] query bool false "Filter by active state"
 // @Success 200 {object} dto.WebhookEndpointListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Security AppApiKey
@@ -380,23 +395,12 @@ func (h *Handler) AppListEndpoints(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid app ID"})
 		return
 	}
-	page, pageSize := parsePage(c)
-	endpoints, total, svcErr := h.Service.ListEndpointsByApp(appID, page, pageSize)
-	if svcErr != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to list webhook endpoints"})
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), endpointListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
-
-	resp := make([]dto.WebhookEndpointResponse, len(endpoints))
-	for i, ep := range endpoints {
-		resp[i] = toEndpointResponse(ep)
-	}
-	c.JSON(http.StatusOK, dto.WebhookEndpointListResponse{
-		Endpoints: resp,
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
-	})
+	h.listEndpoints(c, q.WithFilter("app_id", appID.String()))
 }
 
 // AppToggleEndpoint enables or disables a webhook endpoint via the App API.
@@ -467,48 +471,36 @@ func (h *Handler) AppDeleteEndpoint(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Webhook endpoint deleted"})
 }
 
-// AppListDeliveries returns delivery history for an endpoint via the App API.
+// AppListDeliveries returns the app's delivery history via the App API.
 // @Summary List webhook delivery logs (app API)
 // @Tags Webhooks
 // @Produce json
 // @Param id path string true "Application ID"
-// @Param endpoint_id path string true "Webhook Endpoint ID"
+// @Param filter[endpoint_id] query string false "Filter by webhook endpoint ID"
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(20)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Param sort query string false "Sort field: created_at, status_code, latency_ms, event_type (prefix with - for descending)" default(created_at)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param filter[event_type] query string false "Filter by event type"
+// @Param filter[success] query bool false "Filter by delivery outcome"
+// @Param filter[status_code] query int false "Filter by HTTP status code"
 // @Success 200 {object} dto.WebhookDeliveryListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Security AppApiKey
-// @Router /app/{id}/webhooks/{endpoint_id}/deliveries [get]
+// @Router /app/{id}/webhooks/deliveries [get]
 func (h *Handler) AppListDeliveries(c *gin.Context) {
-	_, err := uuid.Parse(c.Param("id"))
+	appID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid app ID"})
 		return
 	}
 
-	endpointID, err := uuid.Parse(c.Param("endpoint_id"))
+	q, err := pagination.ParseListQuery(c.Request.URL.Query(), deliveryListSpec)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid endpoint ID"})
-		return
-	}
-
-	page, pageSize := parsePage(c)
-	deliveries, total, svcErr := h.Service.ListDeliveriesByEndpoint(endpointID, page, pageSize)
-	if svcErr != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch delivery logs"})
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
-
-	resp := make([]dto.WebhookDeliveryResponse, len(deliveries))
-	for i, d := range deliveries {
-		resp[i] = toDeliveryResponse(d)
-	}
-	c.JSON(http.StatusOK, dto.WebhookDeliveryListResponse{
-		Deliveries: resp,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-	})
+	h.listDeliveries(c, q.WithFilter("app_id", appID.String()))
 }
 
 // ============================================================================
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -47,33 +48,42 @@ func (r *Repository) GetEndpointByAppAndEvent(appID uuid.UUID, eventType string)
 
 // ListEndpointsByApp returns all (non-deleted) webhook endpoints for an application.
 func (r *Repository) ListEndpointsByApp(appID uuid.UUID, page, pageSize int) ([]models.WebhookEndpoint, int64, error) {
-	var endpoints []models.WebhookEndpoint
-	var total int64
-
-	query := r.db.Model(&models.WebhookEndpoint{}).Where("app_id = ? AND deleted_at IS NULL", appID)
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&endpoints).Error; err != nil {
-		return nil, 0, err
-	}
-	return endpoints, total, nil
+	return r.ListEndpoints(endpointListSpec.Query(page, pageSize).WithFilter("app_id", appID.String()))
 }
 
 // ListAllEndpoints returns all non-deleted webhook endpoints (admin use).
 func (r *Repository) ListAllEndpoints(page, pageSize int) ([]models.WebhookEndpoint, int64, error) {
+	return r.ListEndpoints(endpointListSpec.Query(page, pageSize))
+}
+
+// endpointListSpec is the sorting and filtering of webhook endpoint lists.
+var endpointListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"event_type": "event_type",
+		"url":        "url",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"app_id":     {Column: "app_id", Kind: pagination.FilterUUID},
+		"event_type": {Column: "event_type", Kind: pagination.FilterExact},
+		"is_active":  {Column: "is_active", Kind: pagination.FilterBool},
+	},
+}
+
+// ListEndpoints returns a page of non-deleted webhook endpoints.
+func (r *Repository) ListEndpoints(q pagination.ListQuery) ([]models.WebhookEndpoint, int64, error) {
 	var endpoints []models.WebhookEndpoint
 	var total int64
 
-	query := r.db.Model(&models.WebhookEndpoint{}).Where("deleted_at IS NULL")
+	query := q.ApplyFilters(r.db.Model(&models.WebhookEndpoint{}).Where("deleted_at IS NULL"))
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&endpoints).Error; err != nil {
+	if err := q.ApplyPage(query).Find(&endpoints).Error; err != nil {
 		return nil, 0, err
 	}
 	return endpoints, total, nil
@@ -112,33 +122,45 @@ func (r *Repository) CreateDelivery(d *models.WebhookDelivery) error {
 
 // GetDeliveriesByEndpoint returns delivery history for a specific endpoint, paginated.
 func (r *Repository) GetDeliveriesByEndpoint(endpointID uuid.UUID, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
-	var deliveries []models.WebhookDelivery
-	var total int64
-
-	query := r.db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&deliveries).Error; err != nil {
-		return nil, 0, err
-	}
-	return deliveries, total, nil
+	return r.ListDeliveries(deliveryListSpec.Query(page, pageSize).WithFilter("endpoint_id", endpointID.String()))
 }
 
 // GetDeliveriesByApp returns delivery history across all endpoints for an app, paginated.
 func (r *Repository) GetDeliveriesByApp(appID uuid.UUID, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	return r.ListDeliveries(deliveryListSpec.Query(page, pageSize).WithFilter("app_id", appID.String()))
+}
+
+// deliveryListSpec is the sorting and filtering of webhook delivery lists.
+var deliveryListSpec = &pagination.ListSpec{
+	Sorts: map[string]string{
+		"created_at":  "created_at",
+		"status_code": "status_code",
+		"latency_ms":  "latency_ms",
+		"event_type":  "event_type",
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	TieBreaker:   "id",
+	Filters: map[string]pagination.Filter{
+		"app_id":      {Column: "app_id", Kind: pagination.FilterUUID},
+		"endpoint_id": {Column: "endpoint_id", Kind: pagination.FilterUUID},
+		"event_type":  {Column: "event_type", Kind: pagination.FilterExact},
+		"success":     {Column: "success", Kind: pagination.FilterBool},
+		"status_code": {Column: "status_code", Kind: pagination.FilterInt},
+	},
+}
+
+// ListDeliveries returns a page of delivery history.
+func (r *Repository) ListDeliveries(q pagination.ListQuery) ([]models.WebhookDelivery, int64, error) {
 	var deliveries []models.WebhookDelivery
 	var total int64
 
-	query := r.db.Model(&models.WebhookDelivery{}).Where("app_id = ?", appID)
+	query := q.ApplyFilters(r.db.Model(&models.WebhookDelivery{}))
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&deliveries).Error; err != nil {
+	if err := q.ApplyPage(query).Find(&deliveries).Error; err != nil {
 		return nil, 0, err
 	}
 	return deliveries, total, nil
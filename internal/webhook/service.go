@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/gjovanovicst/auth_api/pkg/models"
+	"github.com/gjovanovicst/auth_api/pkg/pagination"
 	"github.com/google/uuid"
 )
 
@@ -114,6 +115,11 @@ func (s *Service) ListAllEndpoints(page, pageSize int) ([]models.WebhookEndpoint
 	return s.repo.ListAllEndpoints(page, pageSize)
 }
 
+// ListEndpoints returns a page of endpoints sorted and filtered by q (see endpointListSpec).
+func (s *Service) ListEndpoints(q pagination.ListQuery) ([]models.WebhookEndpoint, int64, error) {
+	return s.repo.ListEndpoints(q)
+}
+
 // SetEndpointActive enables or disables a webhook endpoint.
 func (s *Service) SetEndpointActive(id uuid.UUID, isActive bool) error {
 	return s.repo.UpdateEndpointActive(id, isActive)
@@ -150,6 +156,11 @@ func (s *Service) ListDeliveriesByApp(appID uuid.UUID, page, pageSize int) ([]mo
 	return s.repo.GetDeliveriesByApp(appID, page, pageSize)
 }
 
+// ListDeliveries returns a page of delivery history sorted and filtered by q (see deliveryListSpec).
+func (s *Service) ListDeliveries(q pagination.ListQuery) ([]models.WebhookDelivery, int64, error) {
+	return s.repo.ListDeliveries(q)
+}
+
 // ============================================================================
 // Event dispatch
 // ============================================================================
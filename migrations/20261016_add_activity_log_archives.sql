@@ -0,0 +1,28 @@
+-- Migration: Add activity log archive manifest
+-- Date: 2026-10-16
+-- Description: Creates the activity_log_archives table written by the
+--              activity_log_archive job. Each row describes one gzipped NDJSON
+--              file on the file storage backend holding one application's
+--              activity logs of one UTC day (a partition may span several
+--              parts). The admin archive query and restore APIs read it to find
+--              the files of a date range; restored_until is set while a
+--              partition's logs are restored into activity_logs.
+
+CREATE TABLE IF NOT EXISTS activity_log_archives (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL,
+    day DATE NOT NULL,
+    part INTEGER NOT NULL,
+    key VARCHAR(512) NOT NULL,
+    backend VARCHAR(20) NOT NULL,
+    row_count BIGINT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    archived_at TIMESTAMPTZ NOT NULL,
+    restored_until TIMESTAMPTZ
+);
+
+-- One row per part of an (app, day) partition
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activity_log_archives_partition ON activity_log_archives(app_id, day, part);
+
+-- Index for listing archives by date range across applications
+CREATE INDEX IF NOT EXISTS idx_activity_log_archives_day ON activity_log_archives(day);
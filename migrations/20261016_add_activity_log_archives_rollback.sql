@@ -0,0 +1,7 @@
+-- Rollback: Add activity log archive manifest
+-- Date: 2026-10-16
+-- Archived files on the file storage backend are not deleted.
+
+DROP INDEX IF EXISTS idx_activity_log_archives_day;
+DROP INDEX IF EXISTS idx_activity_log_archives_partition;
+DROP TABLE IF EXISTS activity_log_archives;
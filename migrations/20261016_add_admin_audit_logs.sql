@@ -0,0 +1,29 @@
+-- Migration: Add admin API audit capture
+-- Date: 2026-10-16
+-- Description: Stores Admin API requests and responses (bodies redacted) for
+--              forensic review when ADMIN_AUDIT_CAPTURE_ENABLED is set.
+
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    api_key_id UUID,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_body TEXT NOT NULL DEFAULT '',
+    response_body TEXT NOT NULL DEFAULT '',
+    request_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    response_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Newest-first listing and retention cleanup
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_created_at ON admin_audit_logs(created_at);
+
+-- Filters in GET /admin/audit-logs
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_route ON admin_audit_logs(route);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_status_code ON admin_audit_logs(status_code);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_api_key_id ON admin_audit_logs(api_key_id);
@@ -0,0 +1,8 @@
+-- Rollback: Add admin API audit capture
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_admin_audit_logs_api_key_id;
+DROP INDEX IF EXISTS idx_admin_audit_logs_status_code;
+DROP INDEX IF EXISTS idx_admin_audit_logs_route;
+DROP INDEX IF EXISTS idx_admin_audit_logs_created_at;
+DROP TABLE IF EXISTS admin_audit_logs;
@@ -0,0 +1,31 @@
+-- Migration: Add admin notification center
+-- Date: 2026-10-16
+-- Description: Stores important system events (new tenants, SMTP failures, anomaly
+--              spikes, API key expirations) for the admin GUI bell and the Admin API
+--              feed, with per-admin read state.
+
+CREATE TABLE IF NOT EXISTS admin_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL DEFAULT 'info',
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    link VARCHAR(500) NOT NULL DEFAULT '',
+    app_id UUID REFERENCES applications(id) ON DELETE SET NULL,
+    dedup_key VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_notifications_type ON admin_notifications(type);
+CREATE INDEX IF NOT EXISTS idx_admin_notifications_app_id ON admin_notifications(app_id);
+CREATE INDEX IF NOT EXISTS idx_admin_notifications_dedup_key ON admin_notifications(dedup_key);
+CREATE INDEX IF NOT EXISTS idx_admin_notifications_created_at ON admin_notifications(created_at);
+
+CREATE TABLE IF NOT EXISTS admin_notification_reads (
+    notification_id UUID NOT NULL REFERENCES admin_notifications(id) ON DELETE CASCADE,
+    admin_id UUID NOT NULL REFERENCES admin_accounts(id) ON DELETE CASCADE,
+    read_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (notification_id, admin_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_notification_reads_admin_id ON admin_notification_reads(admin_id);
@@ -0,0 +1,5 @@
+-- Rollback: Add admin notification center
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS admin_notification_reads;
+DROP TABLE IF EXISTS admin_notifications;
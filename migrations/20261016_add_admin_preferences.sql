@@ -0,0 +1,13 @@
+-- Migration: Add admin GUI display preferences
+-- Date: 2026-10-16
+-- Description: Creates per-admin storage for the admin GUI theme, list page
+--              size, display timezone and date format locale.
+
+CREATE TABLE IF NOT EXISTS admin_preferences (
+    admin_id UUID PRIMARY KEY REFERENCES admin_accounts(id) ON DELETE CASCADE,
+    theme VARCHAR(10) NOT NULL DEFAULT '',
+    page_size INTEGER NOT NULL DEFAULT 0,
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    locale VARCHAR(10) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
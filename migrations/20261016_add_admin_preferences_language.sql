@@ -0,0 +1,6 @@
+-- Migration: Add admin GUI language preference
+-- Date: 2026-10-16
+-- Description: Adds the per-admin GUI language (message catalog code, e.g. "de")
+--              to the admin display preferences. Empty means English.
+
+ALTER TABLE admin_preferences ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT '';
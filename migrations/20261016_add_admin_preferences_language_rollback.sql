@@ -0,0 +1,4 @@
+-- Rollback: Add admin GUI language preference
+-- Date: 2026-10-16
+
+ALTER TABLE admin_preferences DROP COLUMN IF EXISTS language;
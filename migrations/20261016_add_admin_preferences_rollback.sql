@@ -0,0 +1,4 @@
+-- Rollback: Remove admin GUI display preferences
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS admin_preferences;
@@ -0,0 +1,26 @@
+-- Migration: Add admin saved filters and column preferences
+-- Date: 2026-10-16
+-- Description: Creates per-admin storage for named filter combinations and
+--              hidden-column preferences on the admin GUI users and logs pages.
+
+CREATE TABLE IF NOT EXISTS admin_saved_filters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL REFERENCES admin_accounts(id) ON DELETE CASCADE,
+    page VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing an admin's filters for a page
+CREATE INDEX IF NOT EXISTS idx_admin_saved_filters_admin_page ON admin_saved_filters(admin_id, page);
+
+CREATE TABLE IF NOT EXISTS admin_column_preferences (
+    admin_id UUID NOT NULL REFERENCES admin_accounts(id) ON DELETE CASCADE,
+    page VARCHAR(50) NOT NULL,
+    hidden_columns TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (admin_id, page)
+);
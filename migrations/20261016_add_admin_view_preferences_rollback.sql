@@ -0,0 +1,5 @@
+-- Rollback: Remove admin saved filters and column preferences
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS admin_column_preferences;
+DROP TABLE IF EXISTS admin_saved_filters;
@@ -0,0 +1,7 @@
+-- Migration: Add per-application auth method policy
+-- Date: 2026-10-16
+-- Description: allowed_auth_methods lists the sign-in methods an application
+--              permits (comma-separated: password, google, facebook, github,
+--              magic_link, passkey, client_credentials). Empty = all methods.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS allowed_auth_methods VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Rollback: Add per-application auth method policy
+-- Date: 2026-10-16
+
+ALTER TABLE applications DROP COLUMN IF EXISTS allowed_auth_methods;
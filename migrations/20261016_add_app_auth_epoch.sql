@@ -0,0 +1,10 @@
+-- Migration: Add application auth epoch
+-- Date: 2026-10-16
+-- Description: Adds auth_epoch_at to applications, the time the session kill
+--              switch (POST /admin/apps/:id/revoke-all-sessions) last ran.
+--              Tokens of the application issued at or before it are rejected.
+--              The auth middleware reads the epoch from Redis; it is copied
+--              there from this column at startup.
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS auth_epoch_at TIMESTAMPTZ;
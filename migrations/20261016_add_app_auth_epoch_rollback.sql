@@ -0,0 +1,5 @@
+-- Rollback: Add application auth epoch
+-- Date: 2026-10-16
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS auth_epoch_at;
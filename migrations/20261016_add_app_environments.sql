@@ -0,0 +1,12 @@
+-- Migration: Add application environments
+-- Date: 2026-10-16
+-- Description: Lets an application own child environments (development, staging,
+--              production). Each environment is an application row pointing at its
+--              parent; share_users selects whether it uses the parent's user base.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS parent_app_id UUID DEFAULT NULL REFERENCES applications(id) ON DELETE CASCADE;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS environment VARCHAR(20) NOT NULL DEFAULT 'production';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS share_users BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_applications_parent_app_id ON applications(parent_app_id);
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_applications_parent_environment ON applications(parent_app_id, environment) WHERE parent_app_id IS NOT NULL;
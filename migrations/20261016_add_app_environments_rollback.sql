@@ -0,0 +1,9 @@
+-- Rollback: Add application environments
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_applications_parent_environment;
+DROP INDEX IF EXISTS idx_applications_parent_app_id;
+
+ALTER TABLE applications DROP COLUMN IF EXISTS share_users;
+ALTER TABLE applications DROP COLUMN IF EXISTS environment;
+ALTER TABLE applications DROP COLUMN IF EXISTS parent_app_id;
@@ -0,0 +1,4 @@
+-- Rollback: Add background job queue
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS background_jobs;
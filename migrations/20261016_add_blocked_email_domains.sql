@@ -0,0 +1,7 @@
+-- Migration: Add per-application blocked email domains
+-- Date: 2026-10-16
+-- Description: Domains listed in blocked_email_domains are rejected at
+--              registration and email change, in addition to the global
+--              disposable email blocklist (comma or newline separated).
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS blocked_email_domains TEXT NOT NULL DEFAULT '';
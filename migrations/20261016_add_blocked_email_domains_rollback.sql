@@ -0,0 +1,4 @@
+-- Rollback: Add per-application blocked email domains
+-- Date: 2026-10-16
+
+ALTER TABLE applications DROP COLUMN IF EXISTS blocked_email_domains;
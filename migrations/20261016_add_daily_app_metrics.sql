@@ -0,0 +1,24 @@
+-- Migration: Add daily per-app metrics rollups
+-- Date: 2026-10-16
+-- Description: Creates the daily_app_metrics table filled by the nightly
+--              daily_metrics_rollup job: signups, logins, failed logins, emails
+--              sent, daily and monthly active users and the login method
+--              breakdown per application and UTC day. Per-app statistics reports
+--              read rolled-up days from it instead of the raw activity logs.
+
+CREATE TABLE IF NOT EXISTS daily_app_metrics (
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    signups BIGINT NOT NULL DEFAULT 0,
+    logins BIGINT NOT NULL DEFAULT 0,
+    failed_logins BIGINT NOT NULL DEFAULT 0,
+    emails_sent BIGINT NOT NULL DEFAULT 0,
+    active_users BIGINT NOT NULL DEFAULT 0,
+    monthly_users BIGINT NOT NULL DEFAULT 0,
+    login_methods JSONB NOT NULL DEFAULT '{}',
+    rolled_up_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (app_id, day)
+);
+
+-- Index for finding the days that were already rolled up
+CREATE INDEX IF NOT EXISTS idx_daily_app_metrics_day ON daily_app_metrics(day);
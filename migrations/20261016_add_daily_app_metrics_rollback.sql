@@ -0,0 +1,5 @@
+-- Rollback: Add daily per-app metrics rollups
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_daily_app_metrics_day;
+DROP TABLE IF EXISTS daily_app_metrics;
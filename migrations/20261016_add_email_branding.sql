@@ -0,0 +1,10 @@
+-- Migration: Add per-application email branding
+-- Date: 2026-10-16
+-- Description: Logo URL, footer text, physical address and social links that are
+--              injected into every email of the application (brand_* template variables).
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS email_logo_url       VARCHAR(500) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS email_footer_text    TEXT         NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS email_postal_address VARCHAR(500) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS email_social_links   TEXT         NOT NULL DEFAULT '';
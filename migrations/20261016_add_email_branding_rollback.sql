@@ -0,0 +1,8 @@
+-- Rollback: Add per-application email branding
+-- Date: 2026-10-16
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS email_logo_url,
+    DROP COLUMN IF EXISTS email_footer_text,
+    DROP COLUMN IF EXISTS email_postal_address,
+    DROP COLUMN IF EXISTS email_social_links;
@@ -0,0 +1,9 @@
+-- Migration: Add per-application email quiet hours
+-- Date: 2026-10-16
+-- Description: Daily window (local time in a configurable time zone) during which
+--              product emails of the application are deferred until the window opens.
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS email_quiet_hours_start VARCHAR(5)  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS email_quiet_hours_end   VARCHAR(5)  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS email_time_zone         VARCHAR(64) NOT NULL DEFAULT '';
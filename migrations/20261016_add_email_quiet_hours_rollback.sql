@@ -0,0 +1,7 @@
+-- Rollback: Add per-application email quiet hours
+-- Date: 2026-10-16
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS email_quiet_hours_start,
+    DROP COLUMN IF EXISTS email_quiet_hours_end,
+    DROP COLUMN IF EXISTS email_time_zone;
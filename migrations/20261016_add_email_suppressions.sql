@@ -0,0 +1,16 @@
+-- Migration: Add email suppression list
+-- Date: 2026-10-16
+-- Description: Per-application list of addresses (bounces, complaints,
+--              unsubscribes, manual entries) that batch email sends skip.
+
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    reason VARCHAR(20) NOT NULL DEFAULT 'manual',
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One entry per app and address (lowercased)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_suppressions_app_email ON email_suppressions(app_id, email);
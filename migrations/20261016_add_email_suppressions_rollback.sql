@@ -0,0 +1,4 @@
+-- Rollback: Add email suppression list
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS email_suppressions;
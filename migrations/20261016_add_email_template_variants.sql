@@ -0,0 +1,35 @@
+-- Migration: Add email template A/B variants
+-- Date: 2026-10-16
+-- Description: Creates email_template_variants (alternative subjects and bodies
+--              of an email type per application, each sent for a percentage of
+--              the type's emails) and email_variant_stats (send and failure
+--              counts per variant; variant_id is the nil UUID for the regular
+--              template).
+
+CREATE TABLE IF NOT EXISTS email_template_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    email_type_id UUID NOT NULL REFERENCES email_types(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body_html TEXT,
+    body_text TEXT,
+    template_engine VARCHAR(20) NOT NULL DEFAULT 'go_template',
+    weight INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_variants_app_type_name
+    ON email_template_variants(app_id, email_type_id, name);
+
+CREATE TABLE IF NOT EXISTS email_variant_stats (
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    email_type_id UUID NOT NULL REFERENCES email_types(id) ON DELETE CASCADE,
+    variant_id UUID NOT NULL,
+    sent BIGINT NOT NULL DEFAULT 0,
+    failed BIGINT NOT NULL DEFAULT 0,
+    last_sent_at TIMESTAMPTZ,
+    PRIMARY KEY (app_id, email_type_id, variant_id)
+);
@@ -0,0 +1,5 @@
+-- Rollback: Add email template A/B variants
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS email_variant_stats;
+DROP TABLE IF EXISTS email_template_variants;
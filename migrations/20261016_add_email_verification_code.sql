@@ -0,0 +1,77 @@
+-- Migration: Seed email_verification_code email type and default template
+-- Date: 2026-10-16
+-- Description: Adds the 'email_verification_code' email type and its global default
+--              template, plus the per-application email_verification_code switch:
+--              when set, addresses are verified with a 6-digit code entered at
+--              POST /verify-email/code instead of a link.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS email_verification_code BOOLEAN NOT NULL DEFAULT false;
+
+-- 1. Insert the email type
+INSERT INTO email_types (code, name, description, default_subject, variables, is_system, is_active) VALUES
+(
+    'email_verification_code',
+    'Email Verification Code',
+    'Sent at registration, on request and after an email change to applications that verify addresses with a code instead of a link. Contains a 6-digit code for POST /verify-email/code.',
+    'Your Email Verification Code',
+    '[{"name": "app_name",           "description": "Application name",                          "required": true},
+      {"name": "code",               "description": "6-digit email verification code",           "required": true},
+      {"name": "expiration_minutes", "description": "Number of minutes before the code expires", "required": false}]'::jsonb,
+    TRUE, TRUE
+)
+ON CONFLICT (code) DO NOTHING;
+
+-- 2. Insert the global default template
+INSERT INTO email_templates (app_id, email_type_id, name, subject, body_html, body_text, template_engine, is_active) VALUES
+(
+    NULL,
+    (SELECT id FROM email_types WHERE code = 'email_verification_code'),
+    'Default Email Verification Code',
+    'Your Email Verification Code',
+    '<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Verify Your Email</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f7fa;font-family:-apple-system,BlinkMacSystemFont,''Segoe UI'',Roboto,''Helvetica Neue'',Arial,sans-serif;">
+<table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color:#f4f7fa;padding:40px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellspacing="0" cellpadding="0" style="background-color:#ffffff;border-radius:8px;box-shadow:0 2px 8px rgba(0,0,0,0.08);overflow:hidden;">
+  <tr><td style="background-color:#4f46e5;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;font-weight:600;">{{.AppName}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;text-align:center;">
+    <h2 style="color:#1a1a2e;margin:0 0 16px;font-size:20px;">Verify Your Email Address</h2>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 32px;">
+      Enter the following code to verify your email address. This code is valid for {{.ExpirationMinutes}} minutes.
+    </p>
+    <div style="background-color:#f0f4ff;border:2px solid #4f46e5;border-radius:12px;padding:24px;display:inline-block;margin:0 0 32px;">
+      <span style="font-size:36px;font-weight:700;letter-spacing:8px;color:#1a1a2e;font-family:''Courier New'',monospace;">{{.Code}}</span>
+    </div>
+    <p style="color:#a0aec0;font-size:13px;margin:0;">
+      If you did not create an account, you can safely ignore this email.
+    </p>
+  </td></tr>
+  <tr><td style="background-color:#f8fafc;padding:24px 40px;text-align:center;border-top:1px solid #e2e8f0;">
+    <p style="color:#a0aec0;font-size:12px;margin:0;">This email was sent by {{.AppName}}. Please do not reply to this email.</p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    'Verify Your Email Address
+
+Enter the following code to verify your email address with {{.AppName}}:
+
+{{.Code}}
+
+This code is valid for {{.ExpirationMinutes}} minutes.
+
+If you did not create an account, you can safely ignore this email.',
+    'go_template',
+    TRUE
+)
+ON CONFLICT (email_type_id) WHERE app_id IS NULL DO NOTHING;
@@ -0,0 +1,12 @@
+-- Rollback: Seed email_verification_code email type and default template
+-- Date: 2026-10-16
+
+-- 1. Delete the global default template first (foreign key constraint)
+DELETE FROM email_templates
+WHERE email_type_id = (SELECT id FROM email_types WHERE code = 'email_verification_code')
+  AND app_id IS NULL;
+
+-- 2. Delete the email type
+DELETE FROM email_types WHERE code = 'email_verification_code';
+
+ALTER TABLE applications DROP COLUMN IF EXISTS email_verification_code;
@@ -0,0 +1,29 @@
+-- Migration: Add erasure certificates
+-- Date: 2026-10-16
+-- Description: Creates the erasure_certificates table. Each row records a
+--              right-to-erasure (GDPR) request carried out by an admin through
+--              POST /admin/users/:id/erase or the admin GUI: the mode
+--              (anonymize or delete), the rows erased per table and an HMAC
+--              signature. The erased email address is only kept as a keyed
+--              hash (subject_hash).
+
+CREATE TABLE IF NOT EXISTS erasure_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    mode VARCHAR(20) NOT NULL,
+    subject_hash VARCHAR(64) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    erased_by VARCHAR(255) NOT NULL,
+    counts JSONB NOT NULL,
+    erased_at TIMESTAMPTZ NOT NULL,
+    signature VARCHAR(64) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_erasure_certificates_app_id ON erasure_certificates(app_id);
+CREATE INDEX IF NOT EXISTS idx_erasure_certificates_user_id ON erasure_certificates(user_id);
+
+-- Index for looking up the certificates of an email address by its hash
+CREATE INDEX IF NOT EXISTS idx_erasure_certificates_subject_hash ON erasure_certificates(subject_hash);
+
+CREATE INDEX IF NOT EXISTS idx_erasure_certificates_erased_at ON erasure_certificates(erased_at);
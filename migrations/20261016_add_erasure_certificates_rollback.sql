@@ -0,0 +1,9 @@
+-- Rollback: Add erasure certificates
+-- Date: 2026-10-16
+-- Erased data is not restored; only the certificates are dropped.
+
+DROP INDEX IF EXISTS idx_erasure_certificates_erased_at;
+DROP INDEX IF EXISTS idx_erasure_certificates_subject_hash;
+DROP INDEX IF EXISTS idx_erasure_certificates_user_id;
+DROP INDEX IF EXISTS idx_erasure_certificates_app_id;
+DROP TABLE IF EXISTS erasure_certificates;
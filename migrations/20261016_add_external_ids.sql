@@ -0,0 +1,16 @@
+-- Migration: Add external IDs for declarative admin API upserts
+-- Date: 2026-10-16
+-- Description: Optional caller-assigned identifiers (e.g. Terraform resource IDs)
+--              used by PUT /admin/<resource>/by-external-id/:external_id to create
+--              or update tenants, applications, OAuth configs and email templates
+--              idempotently. NULL = resource not managed by external ID.
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+ALTER TABLE oauth_provider_configs ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+ALTER TABLE email_templates ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_tenants_external_id ON tenants(external_id);
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_applications_external_id ON applications(external_id);
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_oauth_provider_configs_external_id ON oauth_provider_configs(external_id);
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_email_templates_external_id ON email_templates(external_id);
@@ -0,0 +1,12 @@
+-- Rollback: Add external IDs for declarative admin API upserts
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_email_templates_external_id;
+DROP INDEX IF EXISTS idx_oauth_provider_configs_external_id;
+DROP INDEX IF EXISTS idx_applications_external_id;
+DROP INDEX IF EXISTS idx_tenants_external_id;
+
+ALTER TABLE email_templates DROP COLUMN IF EXISTS external_id;
+ALTER TABLE oauth_provider_configs DROP COLUMN IF EXISTS external_id;
+ALTER TABLE applications DROP COLUMN IF EXISTS external_id;
+ALTER TABLE tenants DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,9 @@
+-- Migration: Add forced password rotation
+-- Date: 2026-10-16
+-- Description: Adds pw_rotation_required_at to applications. Users whose
+--              password was set before it must change it at their next login;
+--              POST /admin/apps/:id/force-password-rotation sets it to the
+--              current time after a security incident.
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS pw_rotation_required_at TIMESTAMPTZ;
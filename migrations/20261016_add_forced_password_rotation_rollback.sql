@@ -0,0 +1,5 @@
+-- Rollback: Add forced password rotation
+-- Date: 2026-10-16
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS pw_rotation_required_at;
@@ -0,0 +1,11 @@
+-- Migration: Add full-text search indexes
+-- Date: 2026-10-16
+-- Description: GIN expression indexes behind the admin full-text search (the q
+--              parameter of the user and activity log lists). The expressions
+--              must match search.UserDocument and search.ActivityLogDocument
+--              in internal/search exactly, or Postgres will not use them.
+--              The indexes are built CONCURRENTLY so the tables stay writable.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_search ON users USING gin ((to_tsvector('simple', coalesce(email, '') || ' ' || split_part(coalesce(email, ''), '@', 2) || ' ' || regexp_replace(coalesce(email, ''), '[^[:alnum:]]+', ' ', 'g') || ' ' || coalesce(name, '') || ' ' || coalesce(first_name, '') || ' ' || coalesce(last_name, ''))));
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_activity_logs_search ON activity_logs USING gin (((to_tsvector('simple', coalesce(event_type, '') || ' ' || coalesce(ip_address, '') || ' ' || coalesce(user_agent, '')) || jsonb_to_tsvector('simple', coalesce(details, '{}'::jsonb), '["string", "numeric"]'))));
@@ -0,0 +1,5 @@
+-- Rollback: Remove full-text search indexes
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_activity_logs_search;
+DROP INDEX IF EXISTS idx_users_search;
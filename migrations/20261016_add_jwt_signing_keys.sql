@@ -0,0 +1,17 @@
+-- Migration: Add JWT signing keys
+-- Date: 2026-10-16
+-- Description: RS256/ES256 keys that sign access and refresh tokens when
+--              JWT_SIGNING_ALGORITHM is not HS256. Public halves are published
+--              at /.well-known/jwks.json; keys are rotated on a schedule.
+
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    algorithm VARCHAR(10) NOT NULL,
+    private_key TEXT NOT NULL,
+    activates_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_activates_at ON jwt_signing_keys(activates_at);
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_expires_at ON jwt_signing_keys(expires_at);
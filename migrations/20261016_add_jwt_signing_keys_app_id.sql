@@ -0,0 +1,10 @@
+-- Migration: Add per-application JWT signing keys
+-- Date: 2026-10-16
+-- Description: Lets an application sign its tokens with RS256/ES256 keys of
+--              its own, rotated under /admin/apps/:id/keys. Keys without an
+--              app_id stay shared by the other applications.
+
+ALTER TABLE jwt_signing_keys
+    ADD COLUMN IF NOT EXISTS app_id UUID REFERENCES applications(id) ON DELETE CASCADE;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_jwt_signing_keys_app_id ON jwt_signing_keys(app_id);
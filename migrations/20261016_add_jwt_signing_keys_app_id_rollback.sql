@@ -0,0 +1,10 @@
+-- Rollback: Add per-application JWT signing keys
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_jwt_signing_keys_app_id;
+
+-- Keys of applications cannot become shared keys
+DELETE FROM jwt_signing_keys WHERE app_id IS NOT NULL;
+
+ALTER TABLE jwt_signing_keys
+    DROP COLUMN IF EXISTS app_id;
@@ -0,0 +1,4 @@
+-- Rollback: Add JWT signing keys
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS jwt_signing_keys;
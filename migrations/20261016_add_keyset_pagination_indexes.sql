@@ -0,0 +1,8 @@
+-- Migration: Add keyset pagination indexes
+-- Date: 2026-10-16
+-- Description: Composite (timestamp, id) indexes backing cursor pagination of the
+--              users and activity_logs lists, so fetching a page costs the same
+--              regardless of its depth.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_activity_logs_timestamp_id ON activity_logs(timestamp DESC, id DESC);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_created_at_id ON users(created_at DESC, id DESC);
@@ -0,0 +1,5 @@
+-- Rollback: Remove keyset pagination indexes
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_users_created_at_id;
+DROP INDEX IF EXISTS idx_activity_logs_timestamp_id;
@@ -0,0 +1,18 @@
+-- Migration: Add embedded metrics history
+-- Date: 2026-10-16
+-- Description: Creates the metric_samples table behind the admin dashboard
+--              history charts: request, error and auth counters and session
+--              and user gauges in minute buckets (kept 48 hours) and hourly
+--              buckets (kept METRICS_HISTORY_RETENTION_DAYS, default 30), so
+--              the charts work without Prometheus.
+
+CREATE TABLE IF NOT EXISTS metric_samples (
+    metric VARCHAR(50) NOT NULL,
+    resolution INTEGER NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (metric, resolution, bucket_start)
+);
+
+-- Index for downsampling and pruning by age
+CREATE INDEX IF NOT EXISTS idx_metric_samples_resolution_bucket ON metric_samples(resolution, bucket_start);
@@ -0,0 +1,5 @@
+-- Rollback: Add embedded metrics history
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_metric_samples_resolution_bucket;
+DROP TABLE IF EXISTS metric_samples;
@@ -0,0 +1,12 @@
+-- Migration: Add email categories and user notification preferences
+-- Date: 2026-10-16
+-- Description: Classifies email types as transactional, security or product
+--              email and lets users opt out of security alerts and product
+--              email. Non-transactional email carries a signed unsubscribe link.
+
+ALTER TABLE email_types ADD COLUMN IF NOT EXISTS category VARCHAR(20) NOT NULL DEFAULT 'transactional';
+
+UPDATE email_types SET category = 'security' WHERE code IN ('new_device_login', 'suspicious_activity');
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notify_security_alerts BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notify_product_emails BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,7 @@
+-- Rollback: Add email categories and user notification preferences
+-- Date: 2026-10-16
+
+ALTER TABLE users DROP COLUMN IF EXISTS notify_product_emails;
+ALTER TABLE users DROP COLUMN IF EXISTS notify_security_alerts;
+
+ALTER TABLE email_types DROP COLUMN IF EXISTS category;
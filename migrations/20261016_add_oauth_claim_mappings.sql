@@ -0,0 +1,9 @@
+-- Migration: Add claim mapping rules to OAuth provider configs
+-- Date: 2026-10-16
+-- Description: Per-provider JSON rules mapping the claims returned by the identity provider
+--              to user fields, roles and groups on sign-in (NULL = no mapping), and the
+--              groups they assign to users.
+
+ALTER TABLE oauth_provider_configs ADD COLUMN IF NOT EXISTS claim_mappings JSONB;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS groups JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,6 @@
+-- Rollback: Add claim mapping rules to OAuth provider configs
+-- Date: 2026-10-16
+
+ALTER TABLE users DROP COLUMN IF EXISTS groups;
+
+ALTER TABLE oauth_provider_configs DROP COLUMN IF EXISTS claim_mappings;
@@ -0,0 +1,9 @@
+-- Migration: Add generic OpenID Connect OAuth providers
+-- Date: 2026-10-16
+-- Description: Adds issuer_url and scopes to oauth_provider_configs. A config
+--              whose provider is not google, facebook or github is a generic
+--              OpenID Connect provider (Okta, Auth0, Azure AD, Keycloak, ...)
+--              whose endpoints are read from the issuer's discovery document.
+
+ALTER TABLE oauth_provider_configs ADD COLUMN IF NOT EXISTS issuer_url VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE oauth_provider_configs ADD COLUMN IF NOT EXISTS scopes VARCHAR(500) NOT NULL DEFAULT '';
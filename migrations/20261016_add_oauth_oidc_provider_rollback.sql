@@ -0,0 +1,5 @@
+-- Rollback: Add generic OpenID Connect OAuth providers
+-- Date: 2026-10-16
+
+ALTER TABLE oauth_provider_configs DROP COLUMN IF EXISTS scopes;
+ALTER TABLE oauth_provider_configs DROP COLUMN IF EXISTS issuer_url;
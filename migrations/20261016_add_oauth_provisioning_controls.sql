@@ -0,0 +1,8 @@
+-- Migration: Add just-in-time provisioning controls to OAuth provider configs
+-- Date: 2026-10-16
+-- Description: Whether users signing in with a provider for the first time are created
+--              ('auto', previous behavior) or must already exist ('existing_only'), and the
+--              email domains whose users may be created (empty = any).
+
+ALTER TABLE oauth_provider_configs ADD COLUMN IF NOT EXISTS provisioning_mode VARCHAR(20) NOT NULL DEFAULT 'auto';
+ALTER TABLE oauth_provider_configs ADD COLUMN IF NOT EXISTS allowed_email_domains TEXT NOT NULL DEFAULT '';
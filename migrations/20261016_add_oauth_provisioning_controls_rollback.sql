@@ -0,0 +1,5 @@
+-- Rollback: Add just-in-time provisioning controls to OAuth provider configs
+-- Date: 2026-10-16
+
+ALTER TABLE oauth_provider_configs DROP COLUMN IF EXISTS allowed_email_domains;
+ALTER TABLE oauth_provider_configs DROP COLUMN IF EXISTS provisioning_mode;
@@ -0,0 +1,23 @@
+-- Migration: Add OIDC grants
+-- Date: 2026-10-16
+-- Description: Creates oidc_grants, one row per user and OIDC client the user
+--              authorized. Grants are listed on the user's authorizations
+--              page; revoking one invalidates the client's tokens and asks
+--              for consent again.
+
+CREATE TABLE IF NOT EXISTS oidc_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    client_id TEXT NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    granted_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One grant per user and client
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oidc_grant_user_client ON oidc_grants(user_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_oidc_grants_app_id ON oidc_grants(app_id);
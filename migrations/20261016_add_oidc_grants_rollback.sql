@@ -0,0 +1,6 @@
+-- Rollback: Add OIDC grants
+-- Date: 2026-10-16
+
+DROP INDEX IF EXISTS idx_oidc_grants_app_id;
+DROP INDEX IF EXISTS idx_oidc_grant_user_client;
+DROP TABLE IF EXISTS oidc_grants;
@@ -0,0 +1,7 @@
+-- Migration: Add per-application passkey-only mode
+-- Date: 2026-10-16
+-- Description: When passwordless_only is set, password registration, login and
+--              reset are disabled and users authenticate with passkeys or magic
+--              links; accounts are created from a WebAuthn registration ceremony.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS passwordless_only BOOLEAN NOT NULL DEFAULT false;
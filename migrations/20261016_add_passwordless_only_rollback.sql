@@ -0,0 +1,4 @@
+-- Rollback: Add per-application passkey-only mode
+-- Date: 2026-10-16
+
+ALTER TABLE applications DROP COLUMN IF EXISTS passwordless_only;
@@ -0,0 +1,10 @@
+-- Migration: Add tenant and application quotas
+-- Date: 2026-10-16
+-- Description: Adds per-tenant and per-application resource limits. A value of 0
+--              falls back to the global QUOTA_* environment defaults.
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS max_apps INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS max_users INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS max_api_keys INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS max_emails_per_day INTEGER NOT NULL DEFAULT 0;
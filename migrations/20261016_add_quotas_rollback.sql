@@ -0,0 +1,8 @@
+-- Rollback: Add tenant and application quotas
+-- Date: 2026-10-16
+
+ALTER TABLE applications DROP COLUMN IF EXISTS max_emails_per_day;
+ALTER TABLE applications DROP COLUMN IF EXISTS max_api_keys;
+ALTER TABLE applications DROP COLUMN IF EXISTS max_users;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS max_apps;
@@ -0,0 +1,6 @@
+-- Migration: Add per-application redirect allowlist
+-- Date: 2026-10-16
+-- Description: Hosts/origins allowed as social login redirect targets and as the base
+--              of email links. Empty falls back to the global ALLOWED_REDIRECT_DOMAINS.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS allowed_redirect_urls TEXT NOT NULL DEFAULT '';
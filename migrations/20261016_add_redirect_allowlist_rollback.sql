@@ -0,0 +1,4 @@
+-- Rollback: Add per-application redirect allowlist
+-- Date: 2026-10-16
+
+ALTER TABLE applications DROP COLUMN IF EXISTS allowed_redirect_urls;
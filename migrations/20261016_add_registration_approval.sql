@@ -0,0 +1,135 @@
+-- Migration: Add sign-up approval (moderated registration)
+-- Date: 2026-10-16
+-- Description: Adds the per-application registration_approval_required switch and the
+--              users.approval_status column ('' = not moderated, 'pending', 'approved',
+--              'rejected'). Pending and rejected users are inactive until an admin approves
+--              them. Also seeds the 'registration_approved' and 'registration_rejected'
+--              email types and their global default templates.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS registration_approval_required BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS approval_status VARCHAR(20) NOT NULL DEFAULT '';
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_approval_status ON users(approval_status);
+
+-- 1. Insert the email types
+INSERT INTO email_types (code, name, description, default_subject, variables, is_system, is_active) VALUES
+(
+    'registration_approved',
+    'Registration Approved',
+    'Sent when an admin approves a registration held for approval (applications with registration_approval_required).',
+    'Your Account Has Been Approved',
+    '[{"name": "app_name",   "description": "Application name",     "required": true},
+      {"name": "user_email", "description": "User email address",   "required": true}]'::jsonb,
+    TRUE, TRUE
+),
+(
+    'registration_rejected',
+    'Registration Rejected',
+    'Sent when an admin rejects a registration held for approval (applications with registration_approval_required).',
+    'Your Registration Was Not Approved',
+    '[{"name": "app_name",   "description": "Application name",     "required": true},
+      {"name": "user_email", "description": "User email address",   "required": true}]'::jsonb,
+    TRUE, TRUE
+)
+ON CONFLICT (code) DO NOTHING;
+
+-- 2. Insert the global default templates
+INSERT INTO email_templates (app_id, email_type_id, name, subject, body_html, body_text, template_engine, is_active) VALUES
+(
+    NULL,
+    (SELECT id FROM email_types WHERE code = 'registration_approved'),
+    'Default Registration Approved',
+    'Your Account Has Been Approved',
+    '<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Registration Approved</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f7fa;font-family:-apple-system,BlinkMacSystemFont,''Segoe UI'',Roboto,''Helvetica Neue'',Arial,sans-serif;">
+<table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color:#f4f7fa;padding:40px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellspacing="0" cellpadding="0" style="background-color:#ffffff;border-radius:8px;box-shadow:0 2px 8px rgba(0,0,0,0.08);overflow:hidden;">
+  <tr><td style="background-color:#38a169;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;font-weight:600;">{{.AppName}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#1a1a2e;margin:0 0 16px;font-size:20px;">Registration Approved</h2>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      Your registration on {{.AppName}} has been approved. Your account is now active and you can sign in.
+    </p>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      If you have not verified your email address yet, please use the verification email we sent you before signing in.
+    </p>
+    <p style="color:#a0aec0;font-size:13px;margin:0;">
+      This is an automated notification. Please do not reply to this email.
+    </p>
+  </td></tr>
+  <tr><td style="background-color:#f8fafc;padding:24px 40px;text-align:center;border-top:1px solid #e2e8f0;">
+    <p style="color:#a0aec0;font-size:12px;margin:0;">This email was sent by {{.AppName}}.</p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    'Registration Approved
+
+Your registration on {{.AppName}} has been approved. Your account is now active and you can sign in.
+
+If you have not verified your email address yet, please use the verification email we sent you before signing in.',
+    'go_template',
+    TRUE
+)
+ON CONFLICT (email_type_id) WHERE app_id IS NULL DO NOTHING;
+
+INSERT INTO email_templates (app_id, email_type_id, name, subject, body_html, body_text, template_engine, is_active) VALUES
+(
+    NULL,
+    (SELECT id FROM email_types WHERE code = 'registration_rejected'),
+    'Default Registration Not Approved',
+    'Your Registration Was Not Approved',
+    '<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Registration Not Approved</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f7fa;font-family:-apple-system,BlinkMacSystemFont,''Segoe UI'',Roboto,''Helvetica Neue'',Arial,sans-serif;">
+<table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color:#f4f7fa;padding:40px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellspacing="0" cellpadding="0" style="background-color:#ffffff;border-radius:8px;box-shadow:0 2px 8px rgba(0,0,0,0.08);overflow:hidden;">
+  <tr><td style="background-color:#e53e3e;padding:32px 40px;text-align:center;">
+    <h1 style="color:#ffffff;margin:0;font-size:24px;font-weight:600;">{{.AppName}}</h1>
+  </td></tr>
+  <tr><td style="padding:40px;">
+    <h2 style="color:#1a1a2e;margin:0 0 16px;font-size:20px;">Registration Not Approved</h2>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      Your registration on {{.AppName}} was reviewed and has not been approved. You will not be able to sign in with this account.
+    </p>
+    <p style="color:#4a5568;font-size:16px;line-height:1.6;margin:0 0 24px;">
+      If you believe this was done in error, please contact the application administrator.
+    </p>
+    <p style="color:#a0aec0;font-size:13px;margin:0;">
+      This is an automated notification. Please do not reply to this email.
+    </p>
+  </td></tr>
+  <tr><td style="background-color:#f8fafc;padding:24px 40px;text-align:center;border-top:1px solid #e2e8f0;">
+    <p style="color:#a0aec0;font-size:12px;margin:0;">This email was sent by {{.AppName}}.</p>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>',
+    'Registration Not Approved
+
+Your registration on {{.AppName}} was reviewed and has not been approved. You will not be able to sign in with this account.
+
+If you believe this was done in error, please contact the application administrator.',
+    'go_template',
+    TRUE
+)
+ON CONFLICT (email_type_id) WHERE app_id IS NULL DO NOTHING;
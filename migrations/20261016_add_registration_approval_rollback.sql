@@ -0,0 +1,15 @@
+-- Rollback: Add sign-up approval (moderated registration)
+-- Date: 2026-10-16
+
+-- 1. Delete the global default templates first (foreign key constraint)
+DELETE FROM email_templates
+WHERE email_type_id IN (SELECT id FROM email_types WHERE code IN ('registration_approved', 'registration_rejected'))
+  AND app_id IS NULL;
+
+-- 2. Delete the email types
+DELETE FROM email_types WHERE code IN ('registration_approved', 'registration_rejected');
+
+DROP INDEX IF EXISTS idx_users_approval_status;
+ALTER TABLE users DROP COLUMN IF EXISTS approval_status;
+
+ALTER TABLE applications DROP COLUMN IF EXISTS registration_approval_required;
@@ -0,0 +1,21 @@
+-- Migration: Add scheduled job run history
+-- Date: 2026-10-16
+-- Description: Records each execution of a recurring background job (API key
+--              expiry reminders, history cleanup, ...) for the admin GUI
+--              Scheduled Jobs page.
+
+CREATE TABLE IF NOT EXISTS scheduled_job_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(20) NOT NULL DEFAULT 'schedule',
+    status VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    instance VARCHAR(255) NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_job_name ON scheduled_job_runs(job_name);
+CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_status ON scheduled_job_runs(status);
+CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_started_at ON scheduled_job_runs(started_at);
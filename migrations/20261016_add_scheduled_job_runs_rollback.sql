@@ -0,0 +1,4 @@
+-- Rollback: Add scheduled job run history
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS scheduled_job_runs;
@@ -0,0 +1,14 @@
+-- Migration: Add security settings category
+-- Date: 2026-10-16
+-- Description: The token lifetimes, the trusted-device cookie policy and the
+--              CORS allowed origins moved to the new "security" category of the
+--              admin settings. Settings are looked up by category, so stored
+--              values are moved with them.
+
+UPDATE system_settings SET category = 'security'
+WHERE key IN (
+    'ACCESS_TOKEN_EXPIRATION_MINUTES',
+    'REFRESH_TOKEN_EXPIRATION_HOURS',
+    'TRUSTED_DEVICE_COOKIE_SAMESITE',
+    'CORS_ALLOWED_ORIGINS'
+);
@@ -0,0 +1,10 @@
+-- Rollback: Remove security settings category
+-- Date: 2026-10-16
+-- Settings added with the category (JWT_ISSUER, JWT_AUDIENCE,
+-- COOKIE_FORCE_SECURE) are removed.
+
+UPDATE system_settings SET category = 'jwt'
+WHERE key IN ('ACCESS_TOKEN_EXPIRATION_MINUTES', 'REFRESH_TOKEN_EXPIRATION_HOURS');
+UPDATE system_settings SET category = 'trusted_device' WHERE key = 'TRUSTED_DEVICE_COOKIE_SAMESITE';
+UPDATE system_settings SET category = 'cors' WHERE key = 'CORS_ALLOWED_ORIGINS';
+DELETE FROM system_settings WHERE key IN ('JWT_ISSUER', 'JWT_AUDIENCE', 'COOKIE_FORCE_SECURE');
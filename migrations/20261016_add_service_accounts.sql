@@ -0,0 +1,28 @@
+-- Migration: Add service accounts
+-- Date: 2026-10-16
+-- Description: Marks non-human users (service accounts), which cannot sign in
+--              interactively and are excluded from user lists and active-user
+--              counts, and creates service_account_tokens (metadata of the
+--              long-lived access tokens issued to them; id is the token's jti).
+--              Token rows outlive a deleted service account so that its revoked
+--              tokens can be restored to Redis until they expire.
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Partial index serving models.ServiceAccountIDsSQL: service accounts are few
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_service_accounts ON users(id) WHERE is_service_account;
+
+CREATE TABLE IF NOT EXISTS service_account_tokens (
+    id UUID PRIMARY KEY,
+    app_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_account_tokens_app_id ON service_account_tokens(app_id);
+CREATE INDEX IF NOT EXISTS idx_service_account_tokens_user_id ON service_account_tokens(user_id);
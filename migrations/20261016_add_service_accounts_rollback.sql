@@ -0,0 +1,9 @@
+-- Rollback: Add service accounts
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS service_account_tokens;
+
+DROP INDEX IF EXISTS idx_users_service_accounts;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS is_service_account;
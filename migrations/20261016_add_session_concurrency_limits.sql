@@ -0,0 +1,7 @@
+-- Migration: Add per-application session concurrency limits
+-- Date: 2026-10-16
+-- Description: Maximum simultaneous sessions per user (0 = unlimited) and what happens when a
+--              new sign-in would exceed it: 'reject' the sign-in or 'evict_oldest' sessions.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS max_sessions_per_user INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS session_limit_policy VARCHAR(20) NOT NULL DEFAULT 'evict_oldest';
@@ -0,0 +1,5 @@
+-- Rollback: Add per-application session concurrency limits
+-- Date: 2026-10-16
+
+ALTER TABLE applications DROP COLUMN IF EXISTS session_limit_policy;
+ALTER TABLE applications DROP COLUMN IF EXISTS max_sessions_per_user;
@@ -0,0 +1,6 @@
+-- Migration: Add per-application social callback mode
+-- Date: 2026-10-16
+-- Description: How Google/Facebook/GitHub login callbacks deliver their result:
+--              'query' (default, previous behavior), 'fragment', 'json' or 'post_message'.
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS social_callback_mode VARCHAR(20) NOT NULL DEFAULT 'query';
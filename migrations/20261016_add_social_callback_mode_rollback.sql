@@ -0,0 +1,4 @@
+-- Rollback: Add per-application social callback mode
+-- Date: 2026-10-16
+
+ALTER TABLE applications DROP COLUMN IF EXISTS social_callback_mode;
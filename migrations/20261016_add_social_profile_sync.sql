@@ -0,0 +1,13 @@
+-- Migration: Add social profile sync
+-- Date: 2026-10-16
+-- Description: Per-application policy for refreshing the profile data of linked
+--              social accounts ("login", "on_demand" or "scheduled") and the
+--              interval of scheduled syncs, plus the time each social account
+--              was last synced with the provider.
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS social_profile_sync VARCHAR(20) DEFAULT 'login',
+    ADD COLUMN IF NOT EXISTS social_profile_sync_interval_hours INTEGER DEFAULT 24;
+
+ALTER TABLE social_accounts
+    ADD COLUMN IF NOT EXISTS last_synced_at TIMESTAMPTZ;
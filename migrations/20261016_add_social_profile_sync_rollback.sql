@@ -0,0 +1,9 @@
+-- Rollback: Add social profile sync
+-- Date: 2026-10-16
+
+ALTER TABLE social_accounts
+    DROP COLUMN IF EXISTS last_synced_at;
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS social_profile_sync_interval_hours,
+    DROP COLUMN IF EXISTS social_profile_sync;
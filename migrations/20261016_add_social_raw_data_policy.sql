@@ -0,0 +1,10 @@
+-- Migration: Add social raw data retention policy
+-- Date: 2026-10-16
+-- Description: Per-application policy for how much of the providers' user
+--              payloads is kept in social_accounts.raw_data ("full",
+--              "allowlist" of social_raw_data_fields, or "none"). Existing rows
+--              are redacted with POST /admin/apps/:id/social-raw-data/redact.
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS social_raw_data VARCHAR(20) DEFAULT 'full',
+    ADD COLUMN IF NOT EXISTS social_raw_data_fields TEXT DEFAULT '';
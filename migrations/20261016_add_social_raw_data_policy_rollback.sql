@@ -0,0 +1,6 @@
+-- Rollback: Add social raw data retention policy
+-- Date: 2026-10-16
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS social_raw_data_fields,
+    DROP COLUMN IF EXISTS social_raw_data;
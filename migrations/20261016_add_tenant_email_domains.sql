@@ -0,0 +1,21 @@
+-- Migration: Add tenant sending domains
+-- Date: 2026-10-16
+-- Description: Domains a tenant's applications may send email from, verified
+--              with a DNS TXT record. Once a tenant has registered a domain,
+--              app SMTP From addresses must use a verified one.
+
+CREATE TABLE IF NOT EXISTS tenant_email_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    domain VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMPTZ,
+    last_checked_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One entry per tenant and domain (lowercased)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_email_domains_tenant_domain ON tenant_email_domains(tenant_id, domain);
@@ -0,0 +1,4 @@
+-- Rollback: Add tenant sending domains
+-- Date: 2026-10-16
+
+DROP TABLE IF EXISTS tenant_email_domains;
@@ -0,0 +1,25 @@
+-- Migration: Add per-application usage metering
+-- Date: 2026-10-16
+-- Description: Creates monthly usage aggregates (API calls, email sends, monthly
+--              active users) per application for billing, and an optional
+--              billing customer reference on tenants.
+
+CREATE TABLE IF NOT EXISTS usage_records (
+    id BIGSERIAL PRIMARY KEY,
+    app_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    period VARCHAR(7) NOT NULL,
+    metric VARCHAR(50) NOT NULL,
+    quantity BIGINT NOT NULL DEFAULT 0,
+    reported_quantity BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One aggregate row per app, month and metric (upsert target)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_records_app_period_metric ON usage_records(app_id, period, metric);
+
+-- Index for tenant-level usage reports
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_period ON usage_records(tenant_id, period);
+
+-- External billing customer (e.g. Stripe customer ID) used by the usage reporter
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS billing_customer_id VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Rollback: Add per-application usage metering
+-- Date: 2026-10-16
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS billing_customer_id;
+
+DROP INDEX IF EXISTS idx_usage_records_tenant_period;
+DROP INDEX IF EXISTS idx_usage_records_app_period_metric;
+DROP TABLE IF EXISTS usage_records;
@@ -0,0 +1,19 @@
+-- Migration: Add user change events
+-- Date: 2026-10-16
+-- Description: Creates the user_change_events outbox. With USER_SYNC_ENABLED,
+--              a trigger on users (installed by the service at startup)
+--              records every insert, update and delete here, and each
+--              instance relays the events to webhooks and an optional Redis
+--              stream, then deletes them.
+
+CREATE TABLE IF NOT EXISTS user_change_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    app_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    snapshot JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for backfill progress per application
+CREATE INDEX IF NOT EXISTS idx_user_change_events_app_id ON user_change_events(app_id);
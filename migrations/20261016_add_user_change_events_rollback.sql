@@ -0,0 +1,7 @@
+-- Rollback: Add user change events
+-- Date: 2026-10-16
+
+DROP TRIGGER IF EXISTS users_capture_change ON users;
+DROP FUNCTION IF EXISTS capture_user_change();
+DROP INDEX IF EXISTS idx_user_change_events_app_id;
+DROP TABLE IF EXISTS user_change_events;
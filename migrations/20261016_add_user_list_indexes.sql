@@ -0,0 +1,14 @@
+-- Migration: Add user list indexes
+-- Date: 2026-10-16
+-- Description: Indexes for the admin user list at large user counts:
+--              - (app_id, created_at, id) serves the per-application list, its
+--                ordering and cursor pagination without sorting
+--              - trigram GIN indexes on email and name serve the ILIKE '%term%'
+--                search (requires the pg_trgm extension)
+--              The indexes are built CONCURRENTLY so users stays writable.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_app_created_at_id ON users(app_id, created_at DESC, id DESC);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_name_trgm ON users USING gin (name gin_trgm_ops);
@@ -0,0 +1,7 @@
+-- Rollback: Remove user list indexes
+-- Date: 2026-10-16
+-- The pg_trgm extension is left installed; other objects may depend on it.
+
+DROP INDEX IF EXISTS idx_users_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_app_created_at_id;
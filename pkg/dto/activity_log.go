@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/gjovanovicst/auth_api/pkg/pagination"
+
 // ActivityLogResponse represents a single activity log entry in API responses
 type ActivityLogResponse struct {
 	ID        string      `json:"id"`
@@ -13,13 +15,24 @@ type ActivityLogResponse struct {
 	Severity  string      `json:"severity" example:"INFORMATIONAL"`
 }
 
-// ActivityLogListRequest represents query parameters for listing activity logs
+// ActivityLogListRequest represents query parameters for listing activity logs.
+// Offset pagination (page/limit) is the default; set pagination=cursor or pass a
+// cursor from a previous response to use keyset pagination instead.
 type ActivityLogListRequest struct {
-	Page      int    `form:"page" binding:"omitempty,min=1"`
-	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
-	EventType string `form:"event_type" binding:"omitempty"`
-	StartDate string `form:"start_date" binding:"omitempty"` // Format: 2006-01-02
-	EndDate   string `form:"end_date" binding:"omitempty"`   // Format: 2006-01-02
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	Limit      int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	EventType  string `form:"event_type" binding:"omitempty"`
+	StartDate  string `form:"start_date" binding:"omitempty"` // Format: 2006-01-02
+	EndDate    string `form:"end_date" binding:"omitempty"`   // Format: 2006-01-02
+	Pagination string `form:"pagination" binding:"omitempty,oneof=offset cursor"`
+	Cursor     string `form:"cursor" binding:"omitempty"` // Opaque cursor from next_cursor / prev_cursor
+	// Q is a full-text query; matches are ranked by relevance (offset pagination only)
+	Q string `form:"q" binding:"omitempty,max=200"`
+}
+
+// UsesCursor reports whether the request asks for keyset pagination.
+func (r ActivityLogListRequest) UsesCursor() bool {
+	return r.Pagination == "cursor" || r.Cursor != ""
 }
 
 // ActivityLogListResponse represents the paginated response for activity logs
@@ -28,6 +41,12 @@ type ActivityLogListResponse struct {
 	Pagination PaginationResponse    `json:"pagination"`
 }
 
+// ActivityLogCursorListResponse represents a keyset-paginated response for activity logs
+type ActivityLogCursorListResponse struct {
+	Data       []ActivityLogResponse `json:"data"`
+	Pagination pagination.Page       `json:"pagination"`
+}
+
 // PaginationResponse represents pagination metadata
 type PaginationResponse struct {
 	Page         int   `json:"page"`
@@ -53,3 +72,116 @@ type ActivityLogExportResponse struct {
 	Truncated  bool                  `json:"truncated"`
 	ExportedAt string                `json:"exported_at"`
 }
+
+// ActivityEventDefinition describes an activity log event type in the event catalog
+type ActivityEventDefinition struct {
+	Type           string `json:"type" example:"LOGIN"`
+	Category       string `json:"category" example:"authentication"`
+	Severity       string `json:"severity" example:"CRITICAL"`
+	RetentionClass string `json:"retention_class" example:"long"`
+	RetentionDays  int    `json:"retention_days" example:"365"`
+	Enabled        bool   `json:"enabled"`      // Logged with the current configuration
+	UserVisible    bool   `json:"user_visible"` // Shown in GET /profile/security-events
+	Description    string `json:"description" example:"Signed in with email and password"`
+}
+
+// ActivityEventCatalogResponse lists all activity log event types
+type ActivityEventCatalogResponse struct {
+	Events []ActivityEventDefinition `json:"events"`
+}
+
+// SecurityEventListRequest represents query parameters for the user's security events feed
+type SecurityEventListRequest struct {
+	Page      int    `form:"page" binding:"omitempty,min=1"`
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	EventType string `form:"event_type" binding:"omitempty"`
+	StartDate string `form:"start_date" binding:"omitempty"` // Format: 2006-01-02
+	EndDate   string `form:"end_date" binding:"omitempty"`   // Format: 2006-01-02
+}
+
+// SecurityEventResponse represents a single entry of the user's security timeline.
+// MessageKey is a stable translation key; Description is its English text.
+type SecurityEventResponse struct {
+	ID          string `json:"id"`
+	EventType   string `json:"event_type" example:"PASSWORD_CHANGE"`
+	Category    string `json:"category" example:"password"`
+	MessageKey  string `json:"message_key" example:"security_event.password_change"`
+	Description string `json:"description" example:"Password changed"`
+	Timestamp   string `json:"timestamp"`
+	IPAddress   string `json:"ip_address"`
+	UserAgent   string `json:"user_agent"`
+	NewDevice   bool   `json:"new_device"`   // First use of this IP address or browser
+	NewLocation bool   `json:"new_location"` // First use from this country
+}
+
+// SecurityEventListResponse represents the paginated security events feed
+type SecurityEventListResponse struct {
+	Data       []SecurityEventResponse `json:"data"`
+	Pagination PaginationResponse      `json:"pagination"`
+}
+
+// ActivityLogArchiveListRequest represents query parameters for listing the
+// files of the cold activity log archive
+type ActivityLogArchiveListRequest struct {
+	AppID     string `form:"app_id" binding:"omitempty,uuid"`
+	StartDate string `form:"start_date" binding:"omitempty"` // Format: 2006-01-02
+	EndDate   string `form:"end_date" binding:"omitempty"`   // Format: 2006-01-02
+}
+
+// ActivityLogArchiveResponse describes one archived file: one part of an
+// application's activity logs for one UTC day
+type ActivityLogArchiveResponse struct {
+	AppID         string  `json:"app_id"`
+	Day           string  `json:"day" example:"2026-08-01"`
+	Part          int     `json:"part" example:"1"`
+	Key           string  `json:"key"`
+	Backend       string  `json:"backend" example:"s3"`
+	RowCount      int64   `json:"row_count"`
+	SizeBytes     int64   `json:"size_bytes"`
+	ArchivedAt    string  `json:"archived_at"`
+	RestoredUntil *string `json:"restored_until,omitempty"`
+}
+
+// ActivityLogArchiveListResponse lists archived files, newest day first
+type ActivityLogArchiveListResponse struct {
+	Data      []ActivityLogArchiveResponse `json:"data"`
+	Count     int                          `json:"count"`
+	Truncated bool                         `json:"truncated"`
+}
+
+// ActivityLogArchiveQueryRequest represents query parameters for reading
+// archived activity logs of one application without restoring them
+type ActivityLogArchiveQueryRequest struct {
+	AppID     string `form:"app_id" binding:"required,uuid"`
+	StartDate string `form:"start_date" binding:"required"` // Format: 2006-01-02
+	EndDate   string `form:"end_date" binding:"required"`   // Format: 2006-01-02
+	UserID    string `form:"user_id" binding:"omitempty,uuid"`
+	EventType string `form:"event_type" binding:"omitempty"`
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// ActivityLogArchiveQueryResponse holds archived logs matching a query
+type ActivityLogArchiveQueryResponse struct {
+	Data         []ActivityLogResponse `json:"data"`
+	Count        int                   `json:"count"`
+	Truncated    bool                  `json:"truncated"`
+	FilesScanned int                   `json:"files_scanned"`
+	RowsScanned  int64                 `json:"rows_scanned"`
+}
+
+// ActivityLogArchiveRestoreRequest is the body of an archive restore: the
+// archived logs of one application in a date range are copied back into
+// activity_logs for Days days (default LOG_ARCHIVE_RESTORE_DAYS)
+type ActivityLogArchiveRestoreRequest struct {
+	AppID     string `json:"app_id" binding:"required,uuid"`
+	StartDate string `json:"start_date" binding:"required"` // Format: 2006-01-02
+	EndDate   string `json:"end_date" binding:"required"`   // Format: 2006-01-02
+	Days      int    `json:"days" binding:"omitempty,min=1,max=365"`
+}
+
+// ActivityLogArchiveRestoreResult is the result of an activity_log_restore job
+type ActivityLogArchiveRestoreResult struct {
+	Files         int    `json:"files"`
+	Restored      int64  `json:"restored"`
+	RestoredUntil string `json:"restored_until"`
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,15 +9,20 @@ import (
 
 // CreateTenantRequest represents the payload for creating a new tenant
 type CreateTenantRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name              string `json:"name" binding:"required"`
+	BillingCustomerID string `json:"billing_customer_id,omitempty"`      // Optional billing customer for usage reporting
+	MaxApps           int    `json:"max_apps,omitempty" binding:"min=0"` // Application quota (0 = use QUOTA_MAX_APPS_PER_TENANT)
 }
 
 // TenantResponse represents the tenant data returned to clients
 type TenantResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	BillingCustomerID string    `json:"billing_customer_id,omitempty"`
+	MaxApps           int       `json:"max_apps"`
+	ExternalID        *string   `json:"external_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 // CreateAppRequest represents the payload for creating a new application
@@ -26,10 +32,26 @@ type CreateAppRequest struct {
 	Description      string `json:"description"`
 	FrontendURL      string `json:"frontend_url"`
 	MagicLinkEnabled bool   `json:"magic_link_enabled"`
+	// Passkey-only mode: password registration/login disabled (optional; default false)
+	PasswordlessOnly bool `json:"passwordless_only"`
 	// Email Action Link Paths (optional; empty = use system defaults)
 	ResetPasswordPath string `json:"reset_password_path"`
 	MagicLinkPath     string `json:"magic_link_path"`
 	VerifyEmailPath   string `json:"verify_email_path"`
+	// Redirect allowlist (optional; empty = use ALLOWED_REDIRECT_DOMAINS)
+	AllowedRedirectURLs string `json:"allowed_redirect_urls"`
+	// Blocked email domains, in addition to the disposable email blocklist (optional)
+	BlockedEmailDomains string `json:"blocked_email_domains"`
+	// Verify email addresses with a 6-digit code instead of a link (optional; default false)
+	EmailVerificationCode bool `json:"email_verification_code"`
+	// Hold new registrations for admin approval (optional; default false)
+	RegistrationApprovalRequired bool `json:"registration_approval_required"`
+	// Social login callback mode (optional; default "query")
+	SocialCallbackMode string `json:"social_callback_mode" binding:"omitempty,oneof=query fragment json post_message"`
+	// Concurrent sessions per user (optional; 0 = unlimited) and what a sign-in beyond the
+	// limit does: "reject" or "evict_oldest" (default)
+	MaxSessionsPerUser int    `json:"max_sessions_per_user" binding:"min=0"`
+	SessionLimitPolicy string `json:"session_limit_policy" binding:"omitempty,oneof=reject evict_oldest"`
 }
 
 // AppResponse represents the application data returned to clients
@@ -39,12 +61,80 @@ type AppResponse struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	FrontendURL string    `json:"frontend_url"`
+	// Passwordless login via email magic link
+	MagicLinkEnabled bool `json:"magic_link_enabled"`
+	// Passkey-only mode: passwords are disabled, users sign in with passkeys or magic links
+	PasswordlessOnly bool `json:"passwordless_only"`
 	// Email Action Link Paths (empty = system defaults apply)
-	ResetPasswordPath string    `json:"reset_password_path"`
-	MagicLinkPath     string    `json:"magic_link_path"`
-	VerifyEmailPath   string    `json:"verify_email_path"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ResetPasswordPath string `json:"reset_password_path"`
+	MagicLinkPath     string `json:"magic_link_path"`
+	VerifyEmailPath   string `json:"verify_email_path"`
+	// Redirect allowlist (empty = ALLOWED_REDIRECT_DOMAINS applies)
+	AllowedRedirectURLs string `json:"allowed_redirect_urls"`
+	// Blocked email domains, in addition to the disposable email blocklist
+	BlockedEmailDomains string `json:"blocked_email_domains"`
+	// Email verification by 6-digit code (POST /verify-email/code) instead of a link
+	EmailVerificationCode bool `json:"email_verification_code"`
+	// New registrations are held for admin approval and cannot sign in until approved
+	RegistrationApprovalRequired bool `json:"registration_approval_required"`
+	// Social login callback mode: "query", "fragment", "json" or "post_message"
+	SocialCallbackMode string `json:"social_callback_mode"`
+	// Concurrent sessions per user (0 = unlimited) and the policy beyond the limit
+	MaxSessionsPerUser int    `json:"max_sessions_per_user"`
+	SessionLimitPolicy string `json:"session_limit_policy"`
+	// Environment (parent_app_id is omitted for top-level applications)
+	ParentAppID *uuid.UUID `json:"parent_app_id,omitempty"`
+	Environment string     `json:"environment"`
+	ShareUsers  bool       `json:"share_users"`
+	ExternalID  *string    `json:"external_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// OAuth provider configs (set by GET /admin/apps/:id and the external ID endpoints)
+	OAuthConfigs []OAuthConfigResponse `json:"oauth_configs,omitempty"`
+}
+
+// CloneAppRequest represents the payload for cloning an application's configuration
+type CloneAppRequest struct {
+	Name        string `json:"name" binding:"required"`
+	TenantID    string `json:"tenant_id"`   // Optional (default: the source application's tenant)
+	Description string `json:"description"` // Optional (default: the source application's description)
+}
+
+// CloneAppResponse is the new application along with what was copied into it.
+// Cloned OAuth configs have no client secret and are disabled; cloned SMTP
+// configs have no password.
+type CloneAppResponse struct {
+	AppResponse
+	ClonedOAuthConfigs   int `json:"cloned_oauth_configs"`
+	ClonedSMTPConfigs    int `json:"cloned_smtp_configs"`
+	ClonedEmailTemplates int `json:"cloned_email_templates"`
+}
+
+// ForcePasswordRotationResponse is the response for
+// POST /admin/apps/:id/force-password-rotation.
+type ForcePasswordRotationResponse struct {
+	AppID              string    `json:"app_id"`
+	RotationRequiredAt time.Time `json:"rotation_required_at"` // Passwords set before this must be changed at the next login
+	UsersWithPassword  int64     `json:"users_with_password"`  // Users of the application who must change their password
+}
+
+// RevokeAllAppSessionsResponse is the response for
+// POST /admin/apps/:id/revoke-all-sessions.
+type RevokeAllAppSessionsResponse struct {
+	AppID                 string    `json:"app_id"`
+	AuthEpoch             time.Time `json:"auth_epoch"`              // Tokens issued at or before this time are rejected
+	RevokedSessions       int64     `json:"revoked_sessions"`        // Sessions deleted from Redis
+	RevokedTrustedDevices int64     `json:"revoked_trusted_devices"` // "Remember this device" entries deleted
+}
+
+// CreateEnvironmentRequest represents the payload for creating an application environment.
+// Non-secret settings are copied from the parent application; OAuth, SMTP and API keys
+// are configured per environment afterwards.
+type CreateEnvironmentRequest struct {
+	Environment string `json:"environment" binding:"required,oneof=development staging production"`
+	Name        string `json:"name"`         // Optional (default: "<parent name> (<environment>)")
+	FrontendURL string `json:"frontend_url"` // Optional (default: parent's frontend URL)
+	ShareUsers  bool   `json:"share_users"`  // Share the parent's user base instead of keeping an isolated one
 }
 
 // UpsertOAuthConfigRequest represents the payload for setting OAuth credentials
@@ -53,6 +143,16 @@ type UpsertOAuthConfigRequest struct {
 	ClientID     string `json:"client_id" binding:"required"`
 	ClientSecret string `json:"client_secret" binding:"required"` // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
 	RedirectURL  string `json:"redirect_url" binding:"required"`
+	// Optional rules mapping the provider's claims to user fields, roles and groups (omit or null = none)
+	ClaimMappings json.RawMessage `json:"claim_mappings,omitempty" swaggertype:"array,object"`
+	// Just-in-time provisioning of first-time users: "auto" (default) or "existing_only"
+	ProvisioningMode    string `json:"provisioning_mode" binding:"omitempty,oneof=auto existing_only"`
+	AllowedEmailDomains string `json:"allowed_email_domains"` // Optional: only users of these domains are provisioned (comma or newline separated)
+	// Generic OpenID Connect providers (any provider other than google, facebook
+	// and github): the issuer whose discovery document gives the endpoints
+	// (required), and the space-separated scopes (default: "openid email profile")
+	IssuerURL string `json:"issuer_url,omitempty" example:"https://example.okta.com"`
+	Scopes    string `json:"scopes,omitempty"`
 }
 
 // OAuthConfigResponse represents the OAuth config data returned (excluding secret)
@@ -63,8 +163,125 @@ type OAuthConfigResponse struct {
 	ClientID    string    `json:"client_id"`
 	RedirectURL string    `json:"redirect_url"`
 	IsEnabled   bool      `json:"is_enabled"`
+	ExternalID  *string   `json:"external_id,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// Claim mapping rules (see UpsertOAuthConfigRequest)
+	ClaimMappings json.RawMessage `json:"claim_mappings,omitempty" swaggertype:"array,object"`
+	// Just-in-time provisioning controls (see UpsertOAuthConfigRequest)
+	ProvisioningMode    string `json:"provisioning_mode"`
+	AllowedEmailDomains string `json:"allowed_email_domains"`
+	// Generic OpenID Connect settings (see UpsertOAuthConfigRequest)
+	IssuerURL string `json:"issuer_url,omitempty"`
+	Scopes    string `json:"scopes,omitempty"`
+}
+
+// UpsertOAuthConfigByExternalIDRequest is the payload for
+// PUT /admin/oauth-configs/by-external-id/:external_id. app_id and provider
+// cannot change once the config exists.
+type UpsertOAuthConfigByExternalIDRequest struct {
+	AppID        string `json:"app_id" binding:"required"`
+	Provider     string `json:"provider" binding:"required"`
+	ClientID     string `json:"client_id" binding:"required"`
+	ClientSecret string `json:"client_secret"` // #nosec G101,G117 -- DTO field. Required on create; empty keeps the stored secret
+	RedirectURL  string `json:"redirect_url" binding:"required"`
+	IsEnabled    *bool  `json:"is_enabled"` // Optional (default: true)
+	// Optional claim mapping rules (see UpsertOAuthConfigRequest); omit or null to remove them
+	ClaimMappings json.RawMessage `json:"claim_mappings,omitempty" swaggertype:"array,object"`
+	// Just-in-time provisioning controls (see UpsertOAuthConfigRequest)
+	ProvisioningMode    string `json:"provisioning_mode" binding:"omitempty,oneof=auto existing_only"`
+	AllowedEmailDomains string `json:"allowed_email_domains"`
+	// Generic OpenID Connect settings (see UpsertOAuthConfigRequest)
+	IssuerURL string `json:"issuer_url,omitempty"`
+	Scopes    string `json:"scopes,omitempty"`
+}
+
+// OAuthConfigExportRequest is the payload for POST /admin/oauth-configs/export.
+type OAuthConfigExportRequest struct {
+	AppIDs   []string `json:"app_ids"`   // Optional: only these applications (default: all)
+	TenantID string   `json:"tenant_id"` // Optional: only the applications of this tenant
+	// How client secrets are exported: "redact" (default, omitted) or "encrypt" (with transport_key)
+	Secrets      string `json:"secrets" binding:"omitempty,oneof=redact encrypt"`
+	TransportKey string `json:"transport_key"` // #nosec G101,G117 -- DTO field. Required with secrets=encrypt, at least 16 characters
+}
+
+// OAuthConfigExport is an OAuth config export document, as returned by
+// POST /admin/oauth-configs/export and accepted by POST /admin/oauth-configs/import.
+type OAuthConfigExport struct {
+	Version    int       `json:"version" example:"1"`
+	ExportedAt time.Time `json:"exported_at"`
+	// "redacted" (no secrets), "encrypted" (sealed with a transport key) or
+	// "plain" (hand-written import documents only; never exported)
+	Secrets string                  `json:"secrets" example:"encrypted"`
+	Configs []OAuthConfigExportItem `json:"configs"`
+}
+
+// OAuthConfigExportItem is one OAuth provider config of an export document.
+type OAuthConfigExportItem struct {
+	AppID         string  `json:"app_id"`
+	AppName       string  `json:"app_name,omitempty"`
+	AppExternalID *string `json:"app_external_id,omitempty"` // Used to find the app on import when app_id is not mapped
+	Provider      string  `json:"provider"`
+	ClientID      string  `json:"client_id"`
+	ClientSecret  string  `json:"client_secret,omitempty"` // #nosec G101,G117 -- DTO field. Omitted when redacted
+	RedirectURL   string  `json:"redirect_url"`
+	IsEnabled     bool    `json:"is_enabled"`
+	ExternalID    *string `json:"external_id,omitempty"`
+	// Claim mapping rules and just-in-time provisioning controls (see UpsertOAuthConfigRequest)
+	ClaimMappings       json.RawMessage `json:"claim_mappings,omitempty" swaggertype:"array,object"`
+	ProvisioningMode    string          `json:"provisioning_mode"`
+	AllowedEmailDomains string          `json:"allowed_email_domains"`
+	// Generic OpenID Connect settings (see UpsertOAuthConfigRequest)
+	IssuerURL string `json:"issuer_url,omitempty"`
+	Scopes    string `json:"scopes,omitempty"`
+}
+
+// OAuthConfigImportRequest is the payload for POST /admin/oauth-configs/import:
+// an export document plus how to apply it.
+type OAuthConfigImportRequest struct {
+	Version      int                     `json:"version"`
+	Secrets      string                  `json:"secrets" binding:"required,oneof=redacted encrypted plain"`
+	Configs      []OAuthConfigExportItem `json:"configs" binding:"required"`
+	TransportKey string                  `json:"transport_key"` // #nosec G101,G117 -- DTO field. Required for encrypted secrets
+	// Optional source app ID -> target app ID; unmapped configs go to the app with
+	// the same external ID, or else the same ID
+	AppMap map[string]string `json:"app_map"`
+	DryRun bool              `json:"dry_run"` // Report what would change without saving
+}
+
+// OAuthConfigImportResult is the outcome of importing one config.
+type OAuthConfigImportResult struct {
+	SourceAppID string `json:"source_app_id"`
+	AppID       string `json:"app_id,omitempty"` // Target application
+	Provider    string `json:"provider"`
+	Status      string `json:"status" example:"created"` // created, updated or failed
+	Error       string `json:"error,omitempty"`
+}
+
+// OAuthConfigImportResponse is the response of POST /admin/oauth-configs/import.
+type OAuthConfigImportResponse struct {
+	DryRun  bool                      `json:"dry_run"`
+	Created int                       `json:"created"`
+	Updated int                       `json:"updated"`
+	Failed  int                       `json:"failed"`
+	Results []OAuthConfigImportResult `json:"results"`
+}
+
+// UpsertEmailTemplateByExternalIDRequest is the payload for
+// PUT /admin/email-templates/by-external-id/:external_id. app_id and
+// email_type_id cannot change once the template exists.
+type UpsertEmailTemplateByExternalIDRequest struct {
+	AppID          *string `json:"app_id"` // Omit for a global default template
+	EmailTypeID    string  `json:"email_type_id" binding:"required"`
+	Name           string  `json:"name" binding:"required,min=2,max=100"`
+	Subject        string  `json:"subject" binding:"required,min=2,max=255"`
+	BodyHTML       string  `json:"body_html"`
+	BodyText       string  `json:"body_text"`
+	TemplateEngine string  `json:"template_engine" binding:"required,oneof=go_template placeholder raw_html"`
+	FromEmail      string  `json:"from_email"`
+	FromName       string  `json:"from_name"`
+	ServerConfigID *string `json:"server_config_id"`
+	IsActive       *bool   `json:"is_active"` // Optional (default: true)
 }
 
 // AppLoginConfigResponse is the public response for GET /app-config/:app_id.
@@ -76,10 +293,17 @@ type AppLoginConfigResponse struct {
 	HasOIDCClients         bool     `json:"has_oidc_clients"`
 	MagicLinkEnabled       bool     `json:"magic_link_enabled"`
 	PasskeyLoginEnabled    bool     `json:"passkey_login_enabled"`
+	PasswordlessOnly       bool     `json:"passwordless_only"`      // passkey-only mode: hide password fields and register via /passkey/register-account
 	TwoFAEnabled           bool     `json:"two_fa_enabled"`         // whether 2FA is allowed for this app
 	TwoFARequired          bool     `json:"two_fa_required"`        // whether every user must set up 2FA before accessing the app
 	SMS2FAEnabled          bool     `json:"sms_2fa_enabled"`        // whether SMS is available as a 2FA method
 	TrustedDeviceEnabled   bool     `json:"trusted_device_enabled"` // whether "remember this device" is available
+	// EmailVerificationCode: registration sends a 6-digit code to enter (POST /verify-email/code) instead of a link
+	EmailVerificationCode bool `json:"email_verification_code"`
+	// RegistrationApprovalRequired: new accounts wait for admin approval before they can sign in
+	RegistrationApprovalRequired bool `json:"registration_approval_required"`
+	// AllowedAuthMethods: sign-in methods permitted by the app's auth method policy, e.g. ["password","passkey"]
+	AllowedAuthMethods []string `json:"allowed_auth_methods"`
 	// Login Page Branding
 	LoginLogoURL        string `json:"login_logo_url,omitempty"`        // URL to the app logo shown on login pages
 	LoginPrimaryColor   string `json:"login_primary_color,omitempty"`   // Primary brand color (e.g. "#4f46e5")
@@ -97,3 +321,554 @@ type AppLoginConfigResponse struct {
 	PwRequireDigit  bool `json:"pw_require_digit"`  // Require at least one digit
 	PwRequireSymbol bool `json:"pw_require_symbol"` // Require at least one special character
 }
+
+// AppStatsResponse is the response for GET /admin/apps/:id/stats.
+// Login, activity and provider figures are computed from activity logs, so they
+// only reflect events whose logging is enabled; registration and 2FA adoption
+// figures come from the users table.
+type AppStatsResponse struct {
+	AppID         string           `json:"app_id"`
+	From          string           `json:"from"` // Inclusive, YYYY-MM-DD (UTC)
+	To            string           `json:"to"`   // Inclusive, YYYY-MM-DD (UTC)
+	Registrations int64            `json:"registrations"`
+	Logins        AppLoginStats    `json:"logins"`
+	ActiveUsers   AppActiveStats   `json:"active_users"`
+	Providers     map[string]int64 `json:"providers"` // Successful logins by method, e.g. {"password": 10, "google": 4}
+	TwoFA         AppTwoFAStats    `json:"two_fa"`
+	EmailsSent    int64            `json:"emails_sent"`
+	Daily         []AppDailyStats  `json:"daily"`
+	GeneratedAt   time.Time        `json:"generated_at"`
+	Cached        bool             `json:"cached"`
+}
+
+// AppLoginStats summarises login outcomes over the requested range.
+type AppLoginStats struct {
+	Successful   int64   `json:"successful"`
+	Failed       int64   `json:"failed"`
+	SuccessRatio float64 `json:"success_ratio"` // successful / (successful + failed); 0 when there were no attempts
+}
+
+// AppActiveStats holds daily and monthly active user figures.
+type AppActiveStats struct {
+	AverageDAU float64 `json:"average_dau"`
+	MAU        int64   `json:"mau"`        // Distinct active users in the 30 days ending on To
+	Stickiness float64 `json:"stickiness"` // average_dau / mau
+}
+
+// AppTwoFAStats reports current 2FA adoption across the application's users.
+type AppTwoFAStats struct {
+	TotalUsers    int64   `json:"total_users"`
+	EnabledUsers  int64   `json:"enabled_users"`
+	AdoptionRatio float64 `json:"adoption_ratio"`
+}
+
+// AppDailyStats is one UTC day of the per-app statistics series.
+type AppDailyStats struct {
+	Date          string `json:"date"` // YYYY-MM-DD
+	Registrations int64  `json:"registrations"`
+	ActiveUsers   int64  `json:"active_users"`
+	Logins        int64  `json:"logins"`
+	FailedLogins  int64  `json:"failed_logins"`
+	EmailsSent    int64  `json:"emails_sent"`
+}
+
+// TenantUsageResponse is the response for GET /admin/tenants/:id/usage.
+// API call and email send counts are exact up to the last flush plus any
+// not-yet-flushed in-memory counts; active users are computed from activity logs.
+type TenantUsageResponse struct {
+	TenantID          string          `json:"tenant_id"`
+	BillingCustomerID string          `json:"billing_customer_id,omitempty"`
+	Period            string          `json:"period"` // YYYY-MM (UTC)
+	Totals            UsageQuantities `json:"totals"`
+	Apps              []AppUsage      `json:"apps"`
+	GeneratedAt       time.Time       `json:"generated_at"`
+}
+
+// AppUsage is one application's usage within a tenant usage report.
+type AppUsage struct {
+	AppID    string          `json:"app_id"`
+	AppName  string          `json:"app_name"`
+	Usage    UsageQuantities `json:"usage"`
+	Reported UsageQuantities `json:"reported"` // Quantities already pushed to the billing reporter
+}
+
+// UsageQuantities holds the billable metric values for a period.
+type UsageQuantities struct {
+	APICalls    int64 `json:"api_calls"`
+	EmailSends  int64 `json:"email_sends"`
+	ActiveUsers int64 `json:"active_users"` // Distinct users with activity in the period
+}
+
+// AdminNotificationResponse is one entry of the admin notification feed.
+type AdminNotificationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`     // "tenant_created", "smtp_failure", "anomaly_spike" or "api_key_expiring"
+	Severity  string     `json:"severity"` // "info", "warning" or "critical"
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	Link      string     `json:"link"` // Admin GUI path with details (may be empty)
+	AppID     *uuid.UUID `json:"app_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AdminNotificationFeedResponse is the response for GET /admin/notifications.
+type AdminNotificationFeedResponse struct {
+	Notifications []AdminNotificationResponse `json:"notifications"`
+	Total         int64                       `json:"total" example:"12"`
+	Page          int                         `json:"page" example:"1"`
+	PageSize      int                         `json:"page_size" example:"50"`
+	TotalPages    int64                       `json:"total_pages" example:"1"`
+	Sort          string                      `json:"sort" example:"created_at"`
+	Order         string                      `json:"order" example:"desc"`
+}
+
+// BackgroundJobResponse describes a background job (see GET /admin/jobs).
+type BackgroundJobResponse struct {
+	ID              uuid.UUID       `json:"id"`
+	Type            string          `json:"type"`   // e.g. "user_import"
+	Status          string          `json:"status"` // "queued", "running", "succeeded", "failed" or "cancelled"
+	Progress        int             `json:"progress"`
+	ProgressMessage string          `json:"progress_message"`
+	Result          json.RawMessage `json:"result,omitempty" swaggertype:"object"` // Job-type specific output of a succeeded job
+	Error           string          `json:"error,omitempty"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"max_attempts"`
+	CancelRequested bool            `json:"cancel_requested"`
+	CreatedBy       string          `json:"created_by"`
+	CreatedAt       time.Time       `json:"created_at"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
+
+// BackgroundJobListResponse is the response for GET /admin/jobs.
+type BackgroundJobListResponse struct {
+	Jobs       []BackgroundJobResponse `json:"jobs"`
+	Total      int64                   `json:"total" example:"8"`
+	Page       int                     `json:"page" example:"1"`
+	PageSize   int                     `json:"page_size" example:"50"`
+	TotalPages int64                   `json:"total_pages" example:"1"`
+	Sort       string                  `json:"sort" example:"created_at"`
+	Order      string                  `json:"order" example:"desc"`
+}
+
+// OAuthConfigListItemResponse is an OAuth config in GET /admin/oauth-configs,
+// with the names of its application and tenant.
+type OAuthConfigListItemResponse struct {
+	OAuthConfigResponse
+	AppName    string `json:"app_name"`
+	TenantName string `json:"tenant_name"`
+}
+
+// ToggleOAuthConfigRequest enables or disables an OAuth config.
+type ToggleOAuthConfigRequest struct {
+	IsEnabled *bool `json:"is_enabled" binding:"required" example:"false"`
+}
+
+// ToggleUserActiveRequest activates or deactivates a user.
+type ToggleUserActiveRequest struct {
+	IsActive *bool `json:"is_active" binding:"required" example:"false"`
+}
+
+// UserActiveResponse is the response for PUT /admin/users/{id}/toggle.
+type UserActiveResponse struct {
+	ID       uuid.UUID `json:"id"`
+	IsActive bool      `json:"is_active"`
+}
+
+// DashboardStatsResponse holds the aggregate counts shown on the admin dashboard.
+type DashboardStatsResponse struct {
+	TotalUsers         int64 `json:"total_users"`
+	ActiveUsers        int64 `json:"active_users"`
+	InactiveUsers      int64 `json:"inactive_users"`
+	TotalTenants       int64 `json:"total_tenants"`
+	TotalApps          int64 `json:"total_apps"`
+	RecentEventsCount  int64 `json:"recent_events_count"` // Activity log entries in the last 24 hours
+	ActiveSessions     int64 `json:"active_sessions"`
+	TrustedDeviceCount int64 `json:"trusted_device_count"` // Non-expired trusted devices
+	VerifiedPhoneCount int64 `json:"verified_phone_count"`
+	// GeneratedAt is when the counts were computed (they are refreshed every minute)
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// EditConflictResponse is returned with 409 when a conditional save
+// (If-Unmodified-Since) finds the resource modified after the given date. It
+// carries the current resource so the client can merge its changes and retry
+// with If-Unmodified-Since set to current_updated_at.
+type EditConflictResponse struct {
+	Error            string      `json:"error"`
+	CurrentUpdatedAt time.Time   `json:"current_updated_at"`
+	Current          interface{} `json:"current"`
+}
+
+// BootstrapApiKeyRequest is the optional payload for bootstrapping the first
+// admin API key
+type BootstrapApiKeyRequest struct {
+	Name        string `json:"name,omitempty"` // Defaults to "Bootstrap key"
+	Description string `json:"description,omitempty"`
+}
+
+// BootstrapApiKeyResponse carries the bootstrapped admin API key. Key is the
+// raw value, returned only once.
+type BootstrapApiKeyResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Key       string    `json:"key"`
+	KeyPrefix string    `json:"key_prefix"`
+	Method    string    `json:"method"` // "bootstrap_token" or "mtls"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminAuditLogResponse is a captured Admin API request. The bodies are
+// included only by GET /admin/audit-logs/{id}.
+type AdminAuditLogResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	Method            string     `json:"method"`
+	Route             string     `json:"route"`
+	Path              string     `json:"path"`
+	StatusCode        int        `json:"status_code"`
+	ApiKeyID          *uuid.UUID `json:"api_key_id,omitempty"` // Absent for the static ADMIN_API_KEY
+	IPAddress         string     `json:"ip_address"`
+	UserAgent         string     `json:"user_agent"`
+	RequestBody       string     `json:"request_body,omitempty"`
+	ResponseBody      string     `json:"response_body,omitempty"`
+	RequestTruncated  bool       `json:"request_truncated"`
+	ResponseTruncated bool       `json:"response_truncated"`
+	DurationMs        int64      `json:"duration_ms"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// DisposableEmailCheckResponse is the result of testing a domain against the
+// disposable email blocklist.
+type DisposableEmailCheckResponse struct {
+	Domain        string                    `json:"domain"`
+	Disposable    bool                      `json:"disposable"`
+	MatchedDomain string                    `json:"matched_domain,omitempty"` // Listed domain that matched (the domain or a parent)
+	Source        string                    `json:"source,omitempty"`         // "bundled", "remote" or "application"
+	List          DisposableEmailListStatus `json:"list"`
+}
+
+// DisposableEmailListStatus describes the loaded disposable email lists.
+type DisposableEmailListStatus struct {
+	Enabled      bool       `json:"enabled"`
+	ListURL      string     `json:"list_url,omitempty"`
+	BundledCount int        `json:"bundled_count"`
+	RemoteCount  int        `json:"remote_count"`
+	RefreshedAt  *time.Time `json:"refreshed_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// EraseUserRequest is the payload for POST /admin/users/:id/erase.
+type EraseUserRequest struct {
+	Mode    string `json:"mode" binding:"required,oneof=anonymize delete" example:"anonymize"` // anonymize keeps the user row without personal data; delete removes it
+	Reason  string `json:"reason" binding:"max=255" example:"GDPR request #1234"`              // Stored on the certificate; must not contain personal data
+	Confirm bool   `json:"confirm" example:"true"`                                             // Must be true: the erasure cannot be undone
+}
+
+// ErasureCounts is the number of rows erased per table by a right-to-erasure request.
+type ErasureCounts struct {
+	Users             int64 `json:"users"`                        // User rows deleted or anonymized
+	SocialAccounts    int64 `json:"social_accounts"`              // Linked social accounts deleted
+	Passkeys          int64 `json:"passkeys"`                     // WebAuthn credentials deleted
+	TrustedDevices    int64 `json:"trusted_devices"`              // "Remember this device" entries deleted
+	UserRoles         int64 `json:"user_roles"`                   // Role assignments deleted (delete mode)
+	OIDCAuthCodes     int64 `json:"oidc_auth_codes"`              // Pending OIDC authorization codes deleted
+	ActivityLogs      int64 `json:"activity_logs"`                // Activity logs deleted (delete mode) or anonymized
+	EmailSuppressions int64 `json:"email_suppressions"`           // Suppression list entries of the address deleted
+	AdminAuditLogs    int64 `json:"admin_audit_logs"`             // Captured Admin API requests on the user with their bodies cleared
+	WebhookDeliveries int64 `json:"webhook_deliveries,omitempty"` // Webhook deliveries naming the user's ID or email deleted (omitted when 0, so older certificates still verify)
+	Sessions          int64 `json:"sessions"`                     // Active sessions revoked
+}
+
+// ErasureCertificateResponse is a signed record of a right-to-erasure request.
+type ErasureCertificateResponse struct {
+	ID             uuid.UUID     `json:"id"`
+	AppID          uuid.UUID     `json:"app_id"`
+	UserID         uuid.UUID     `json:"user_id"`
+	Mode           string        `json:"mode"`
+	SubjectHash    string        `json:"subject_hash"` // HMAC-SHA256 of the erased email address (see GET /admin/erasure-certificates?email=)
+	Reason         string        `json:"reason,omitempty"`
+	ErasedBy       string        `json:"erased_by"`
+	Counts         ErasureCounts `json:"counts"`
+	ErasedAt       time.Time     `json:"erased_at"`
+	Signature      string        `json:"signature"`
+	SignatureValid bool          `json:"signature_valid"` // False if the certificate was altered or JWT_SECRET changed since
+}
+
+// ImpersonateUserRequest is the payload for POST /app/:id/users/:user_id/impersonate.
+type ImpersonateUserRequest struct {
+	ImpersonatedBy string `json:"impersonated_by" binding:"required,max=255" example:"agent@example.com"` // Support agent who will act as the user
+	Reason         string `json:"reason" binding:"required,max=255" example:"Support ticket #4711"`       // Shown in the user's activity log
+	TTLMinutes     int    `json:"ttl_minutes" binding:"min=0" example:"10"`                               // 0 = the maximum (IMPERSONATION_TOKEN_MAX_TTL_MINUTES); longer lifetimes are capped
+}
+
+// ImpersonationTokenResponse is an access token for signing in as a user.
+type ImpersonationTokenResponse struct {
+	AccessToken    string    `json:"access_token"`
+	TokenType      string    `json:"token_type" example:"Bearer"`
+	ExpiresIn      int64     `json:"expires_in" example:"600"` // Seconds; the token cannot be refreshed
+	ExpiresAt      time.Time `json:"expires_at"`
+	UserID         uuid.UUID `json:"user_id"`
+	ImpersonatedBy string    `json:"impersonated_by"`
+}
+
+// CreateServiceAccountRequest is the payload for POST /admin/apps/:id/service-accounts.
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" binding:"required,max=255" example:"billing-sync"`
+}
+
+// ServiceAccountResponse is a service account of an application.
+type ServiceAccountResponse struct {
+	ID           uuid.UUID `json:"id"`
+	AppID        uuid.UUID `json:"app_id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"` // Placeholder address; service accounts receive no email
+	IsActive     bool      `json:"is_active"`
+	ActiveTokens int64     `json:"active_tokens"` // Tokens neither revoked nor expired
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ServiceAccountListResponse lists the service accounts of an application.
+type ServiceAccountListResponse struct {
+	ServiceAccounts []ServiceAccountResponse `json:"service_accounts"`
+	Total           int64                    `json:"total" example:"3"`
+	Page            int                      `json:"page" example:"1"`
+	PageSize        int                      `json:"page_size" example:"20"`
+	TotalPages      int64                    `json:"total_pages" example:"1"`
+	Sort            string                   `json:"sort" example:"created_at"`
+	Order           string                   `json:"order" example:"asc"`
+}
+
+// CreateServiceAccountTokenRequest is the payload for POST /admin/apps/:id/service-accounts/:account_id/tokens.
+type CreateServiceAccountTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100" example:"production"`
+	Scopes        []string `json:"scopes" example:"invoices:read,invoices:write"` // Put in the token's space-separated "scope" claim
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0" example:"90"`  // 0 = the maximum (SERVICE_ACCOUNT_TOKEN_MAX_TTL_DAYS); longer lifetimes are capped
+}
+
+// ServiceAccountTokenResponse describes a token of a service account.
+type ServiceAccountTokenResponse struct {
+	ID        uuid.UUID  `json:"id"` // The token's jti
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ServiceAccountTokenCreatedResponse is a new service account token. The
+// access token is only returned once.
+type ServiceAccountTokenCreatedResponse struct {
+	ServiceAccountTokenResponse
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+}
+
+// ServiceAccountTokenListResponse lists the tokens of a service account.
+type ServiceAccountTokenListResponse struct {
+	Tokens []ServiceAccountTokenResponse `json:"tokens"`
+}
+
+// SecretAuditFinding is one problem found by the secrets audit. Secret values
+// are never included.
+type SecretAuditFinding struct {
+	Check       string   `json:"check" example:"jwt_secret"`
+	Severity    string   `json:"severity" example:"high"` // critical, high, medium or low
+	Title       string   `json:"title"`
+	Detail      string   `json:"detail"`
+	Remediation string   `json:"remediation"`
+	Targets     []string `json:"targets,omitempty"` // Affected records (e.g. "Shop / google")
+}
+
+// SecretAuditResponse is the report of GET /admin/diagnostics/secrets.
+type SecretAuditResponse struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Checks      []string             `json:"checks"`
+	Counts      map[string]int       `json:"counts"`   // Findings per severity
+	Findings    []SecretAuditFinding `json:"findings"` // Most severe first
+}
+
+// SupportBundle is the self-diagnostic report of GET /admin/diagnostics, to
+// attach to bug reports. Secrets are redacted.
+type SupportBundle struct {
+	GeneratedAt time.Time                  `json:"generated_at"`
+	Build       SupportBundleBuild         `json:"build"`
+	Runtime     SupportBundleRuntime       `json:"runtime"`
+	Config      []SupportBundleSetting     `json:"config"`     // Environment and config file, secrets redacted
+	Settings    []SupportBundleSetting     `json:"settings"`   // System settings stored in the database, sensitive ones redacted
+	Datastores  map[string]ComponentStatus `json:"datastores"` // database and redis, with their round-trip latency
+	Migrations  SupportBundleMigrations    `json:"migrations"`
+	Queues      SupportBundleQueues        `json:"queues"`
+	ErrorRates  SupportBundleErrorRates    `json:"error_rates"`
+	Errors      []string                   `json:"errors,omitempty"` // Sections that could not be collected
+}
+
+// SupportBundleBuild describes the binary and the versions of its dependencies.
+type SupportBundleBuild struct {
+	GoVersion       string                    `json:"go_version"`
+	Module          string                    `json:"module"`
+	Version         string                    `json:"version"` // Module version, "(devel)" for local builds
+	VCSRevision     string                    `json:"vcs_revision,omitempty"`
+	VCSTime         string                    `json:"vcs_time,omitempty"`
+	VCSModified     bool                      `json:"vcs_modified,omitempty"` // Built with uncommitted changes
+	DatabaseVersion string                    `json:"database_version,omitempty"`
+	RedisVersion    string                    `json:"redis_version,omitempty"`
+	Dependencies    []SupportBundleDependency `json:"dependencies"`
+}
+
+// SupportBundleDependency is a Go module compiled into the binary.
+type SupportBundleDependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Replace string `json:"replace,omitempty"` // Replacement module, if any
+}
+
+// SupportBundleRuntime describes the running process.
+type SupportBundleRuntime struct {
+	Instance      string `json:"instance"` // Hostname
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	NumCPU        int    `json:"num_cpu"`
+	Goroutines    int    `json:"goroutines"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	GinMode       string `json:"gin_mode"`
+	DBOpenConns   int    `json:"db_open_connections"`
+	DBInUseConns  int    `json:"db_in_use_connections"`
+}
+
+// SupportBundleSetting is a configuration value.
+type SupportBundleSetting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source,omitempty"` // Settings: db, or env when the environment overrides the stored value
+}
+
+// SupportBundleMigrations is the state of the SQL migrations.
+type SupportBundleMigrations struct {
+	Applied         int        `json:"applied"`
+	Latest          string     `json:"latest,omitempty"`
+	LatestAppliedAt *time.Time `json:"latest_applied_at,omitempty"`
+	Failed          []string   `json:"failed"`  // Recorded as unsuccessful
+	Pending         []string   `json:"pending"` // Files in the migrations directory not applied yet
+	// PendingChecked is false when the migrations directory is not available
+	// to the server, so Pending could not be determined.
+	PendingChecked bool `json:"pending_checked"`
+}
+
+// SupportBundleQueues are the depths of the background queues.
+type SupportBundleQueues struct {
+	Jobs           []SupportBundleQueueDepth `json:"jobs"`            // Queued and running background jobs by type
+	WebhookRetries int64                     `json:"webhook_retries"` // Failed webhook deliveries awaiting a retry
+}
+
+// SupportBundleQueueDepth counts the background jobs of a type in a status.
+type SupportBundleQueueDepth struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// SupportBundleErrorRates are the recent error figures.
+type SupportBundleErrorRates struct {
+	// HTTP 5xx responses since the process started, overall and by route group
+	HTTPRequests  float64                      `json:"http_requests"`
+	HTTPErrors    float64                      `json:"http_errors"`
+	HTTPErrorRate float64                      `json:"http_error_rate"` // Percent
+	HTTPGroups    []SupportBundleHTTPErrorRate `json:"http_groups"`
+	// Failures in the last 24 hours
+	JobsFailed24h              int64 `json:"jobs_failed_24h"`
+	WebhookDeliveriesFailed24h int64 `json:"webhook_deliveries_failed_24h"`
+}
+
+// SupportBundleHTTPErrorRate is the 5xx rate of a route group.
+type SupportBundleHTTPErrorRate struct {
+	Group     string  `json:"group"`
+	Requests  float64 `json:"requests"`
+	Errors    float64 `json:"errors"`
+	ErrorRate float64 `json:"error_rate"` // Percent
+}
+
+// JWTSigningKeyResponse is a token signing key in GET /admin/jwt-keys and
+// GET /admin/apps/:id/keys.
+type JWTSigningKeyResponse struct {
+	ID          string     `json:"id"`               // The "kid" of the tokens it signs
+	AppID       string     `json:"app_id,omitempty"` // Application whose tokens it signs; omitted for shared keys
+	Algorithm   string     `json:"algorithm"`
+	State       string     `json:"state"` // pending, active or retiring
+	ActivatesAt time.Time  `json:"activates_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // When it leaves the JWKS (retiring keys)
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// JWTSigningKeyListResponse is the response of GET /admin/jwt-keys and
+// GET /admin/apps/:id/keys.
+type JWTSigningKeyListResponse struct {
+	Algorithm string                  `json:"algorithm"` // Algorithm of the keys (JWT_SIGNING_ALGORITHM; RS256 for application keys under HS256)
+	Keys      []JWTSigningKeyResponse `json:"keys"`
+	// Set on application keys while none is active: the application's
+	// tokens are signed with the shared key (or JWT_SECRET)
+	UsesSharedKey bool `json:"uses_shared_key,omitempty"`
+}
+
+// JWTKeyRotateRequest is the body of POST /admin/jwt-keys/rotate and
+// POST /admin/apps/:id/keys/rotate.
+type JWTKeyRotateRequest struct {
+	// Sign with the new key right away instead of publishing it for an hour
+	// first; services with a cached JWKS reject its tokens until they refetch
+	Immediate bool `json:"immediate"`
+}
+
+// ConfigReloadStep is the outcome of one part of a configuration reload.
+type ConfigReloadStep struct {
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// ConfigReloadResponse is the response of POST /admin/config/reload: the
+// reload of the instance that handled the request.
+type ConfigReloadResponse struct {
+	OK         bool               `json:"ok"`
+	Instance   string             `json:"instance"`
+	StartedAt  time.Time          `json:"started_at"`
+	DurationMs int64              `json:"duration_ms"`
+	Changed    []string           `json:"changed"` // Environment variables changed by the config file
+	Steps      []ConfigReloadStep `json:"steps"`
+	// Set when the other instances could not be told to reload
+	Warning string `json:"warning,omitempty"`
+}
+
+// MetricsHistorySeries is the history of one metric. Values line up with
+// MetricsHistoryResponse.Timestamps; null marks a bucket without data.
+type MetricsHistorySeries struct {
+	Metric string     `json:"metric"`
+	Label  string     `json:"label"`
+	Kind   string     `json:"kind"` // "counter" (increase within the bucket) or "gauge"
+	Values []*float64 `json:"values"`
+}
+
+// MetricsHistoryResponse is the response of GET /admin/metrics/history.
+type MetricsHistoryResponse struct {
+	Range             string                 `json:"range"`
+	ResolutionSeconds int                    `json:"resolution_seconds"`
+	Timestamps        []time.Time            `json:"timestamps"` // Bucket starts, oldest first
+	Series            []MetricsHistorySeries `json:"series"`
+}
+
+// UserSyncStatusResponse is the response of GET /admin/apps/{id}/user-sync.
+type UserSyncStatusResponse struct {
+	AppID   string `json:"app_id"`
+	Pending int64  `json:"pending"` // Change events of the app waiting to be relayed
+}
+
+// UserSyncBackfillResponse is the response of POST /admin/apps/{id}/user-sync/backfill.
+type UserSyncBackfillResponse struct {
+	Message string `json:"message"`
+	Queued  int64  `json:"queued"` // user.snapshot events queued, one per user
+}
+
+// SocialRawDataRedactResponse is the response of POST /admin/apps/{id}/social-raw-data/redact.
+type SocialRawDataRedactResponse struct {
+	Message  string `json:"message"`
+	Policy   string `json:"policy"`   // The application's raw data retention policy that was applied
+	Checked  int64  `json:"checked"`  // Social accounts with stored raw data
+	Redacted int64  `json:"redacted"` // Social accounts whose raw data was reduced or removed
+}
@@ -4,6 +4,15 @@ package dto
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8,max=128"` // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
+	// Bot detection (REGISTRATION_BOT_DETECTION_ENABLED)
+	Website      string `json:"website,omitempty"`       // Honeypot: render hidden and leave empty; bots that fill it are rejected
+	FormToken    string `json:"form_token,omitempty"`    // Token from GET /register/form-token, fetched when the form is shown
+	CaptchaToken string `json:"captcha_token,omitempty"` // Google reCAPTCHA response token (required when the registration is challenged)
+}
+
+// RegistrationFormTokenResponse carries the token used for the time-to-submit check
+type RegistrationFormTokenResponse struct {
+	FormToken string `json:"form_token"`
 }
 
 // LoginRequest represents the request payload for user login
@@ -34,6 +43,12 @@ type ResendVerificationRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
+// VerifyEmailCodeRequest represents the request payload for verifying an email address with a code
+type VerifyEmailCodeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
+
 // ResetPasswordRequest represents the request payload for password reset
 type ResetPasswordRequest struct {
 	Token       string `json:"token" validate:"required"` // #nosec G101 -- This is a DTO field, not a hardcoded credential
@@ -42,9 +57,26 @@ type ResetPasswordRequest struct {
 
 // LoginResponse represents the response payload for successful login
 type LoginResponse struct {
-	AccessToken     string `json:"access_token"`               // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
-	RefreshToken    string `json:"refresh_token"`              // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
-	PasswordExpired bool   `json:"password_expired,omitempty"` // true when the password has expired; no tokens are issued in this case
+	AccessToken  string `json:"access_token"`  // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
+	RefreshToken string `json:"refresh_token"` // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
+}
+
+// PasswordChangeRequiredResponse is returned by login instead of tokens when the
+// password has expired or the application forced a rotation. The client sends
+// the token with a new password to POST /change-expired-password.
+type PasswordChangeRequiredResponse struct {
+	PasswordExpired        bool   `json:"password_expired"`         // Always true; kept for clients of the earlier expiry response
+	PasswordChangeRequired bool   `json:"password_change_required"` // Always true
+	PasswordChangeToken    string `json:"password_change_token"`    // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
+	ExpiresIn              int    `json:"expires_in"`               // Seconds the token stays valid
+	Message                string `json:"message"`
+}
+
+// ChangeExpiredPasswordRequest represents the request payload for answering a
+// password change challenge from login
+type ChangeExpiredPasswordRequest struct {
+	Token       string `json:"token" validate:"required"` // #nosec G101 -- This is a DTO field, not a hardcoded credential
+	NewPassword string `json:"new_password" validate:"required,min=8,max=128"`
 }
 
 // TwoFARequiredResponse represents response when 2FA is required during login
@@ -106,6 +138,7 @@ type SocialAccountResponse struct {
 	ProfilePicture string `json:"profile_picture,omitempty"`
 	Username       string `json:"username,omitempty"`
 	Locale         string `json:"locale,omitempty"`
+	LastSyncedAt   string `json:"last_synced_at,omitempty"`
 	CreatedAt      string `json:"created_at"`
 	UpdatedAt      string `json:"updated_at"`
 }
@@ -148,6 +181,26 @@ type UpdateProfileRequest struct {
 	Locale         string `json:"locale,omitempty" validate:"omitempty,min=2,max=10" example:"en-US"`
 }
 
+// AvatarResponse is returned after a profile picture upload
+type AvatarResponse struct {
+	ProfilePicture string `json:"profile_picture" example:"https://auth.example.com/avatars/00000000-0000-0000-0000-000000000001/4f1c...-9a2b3c4d5e6f7a8b.png"`
+}
+
+// NotificationPreferencesResponse is the user's opt-in state for
+// non-transactional email. Transactional email (verification, password
+// reset, 2FA codes) is always sent.
+type NotificationPreferencesResponse struct {
+	SecurityAlerts bool `json:"security_alerts" example:"true"` // New device logins, suspicious activity
+	ProductEmails  bool `json:"product_emails" example:"false"` // Announcements and other product email
+}
+
+// UpdateNotificationPreferencesRequest represents the request payload for a
+// notification preferences update. Omitted fields are left unchanged.
+type UpdateNotificationPreferencesRequest struct {
+	SecurityAlerts *bool `json:"security_alerts,omitempty" example:"true"`
+	ProductEmails  *bool `json:"product_emails,omitempty" example:"false"`
+}
+
 // UpdateEmailRequest represents the request payload for email update
 type UpdateEmailRequest struct {
 	Email    string `json:"email" validate:"required,email" example:"newemail@example.com"`
@@ -177,6 +230,12 @@ type LinkSocialAccountResponse struct {
 	Account SocialAccountResponse `json:"account"`
 }
 
+// SyncSocialAccountResponse represents the response when a social profile is synced
+type SyncSocialAccountResponse struct {
+	Message string                `json:"message"`
+	Account SocialAccountResponse `json:"account"`
+}
+
 // UnlinkSocialAccountResponse represents the response when a social account is unlinked
 type UnlinkSocialAccountResponse struct {
 	Message string `json:"message"`
@@ -200,6 +259,13 @@ type AccountLockedResponse struct {
 	RetryAfter int    `json:"retry_after,omitempty"`  // Seconds until the lockout expires
 }
 
+// LoginThrottledResponse represents the response when login attempts for an
+// account are delayed after repeated failures
+type LoginThrottledResponse struct {
+	Error      string `json:"error"`
+	RetryAfter int    `json:"retry_after"` // Seconds until the account accepts another attempt
+}
+
 // CaptchaRequiredResponse represents the response when CAPTCHA verification is needed
 type CaptchaRequiredResponse struct {
 	Error           string `json:"error"`
@@ -184,3 +184,23 @@ func TestDeleteAccountRequest_Valid(t *testing.T) {
 		t.Errorf("expected valid, got error: %v", err)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// VerifyEmailCodeRequest tests
+// ---------------------------------------------------------------------------
+
+func TestVerifyEmailCodeRequest_Valid(t *testing.T) {
+	req := VerifyEmailCodeRequest{Email: "user@example.com", Code: "042917"}
+	if err := validate.Struct(req); err != nil {
+		t.Errorf("expected valid, got error: %v", err)
+	}
+}
+
+func TestVerifyEmailCodeRequest_InvalidCode(t *testing.T) {
+	for _, code := range []string{"", "12345", "1234567", "12a456"} {
+		req := VerifyEmailCodeRequest{Email: "user@example.com", Code: code}
+		if err := validate.Struct(req); err == nil {
+			t.Errorf("expected validation error for code %q", code)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // ============================================================================
 // Email Server Configuration DTOs
 // ============================================================================
@@ -46,6 +48,7 @@ type EmailTypeResponse struct {
 	Name           string                      `json:"name"`
 	Description    string                      `json:"description"`
 	DefaultSubject string                      `json:"default_subject"`
+	Category       string                      `json:"category"` // transactional, security or product
 	Variables      []EmailTypeVariableResponse `json:"variables"`
 	IsSystem       bool                        `json:"is_system"`
 	IsActive       bool                        `json:"is_active"`
@@ -62,12 +65,36 @@ type EmailTypeVariableResponse struct {
 	Source       string `json:"source,omitempty"`
 }
 
+// EmailTemplateVariableResponse represents a variable available to templates
+// of an email type, with its reference syntax and a sample value
+type EmailTemplateVariableResponse struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Required          bool   `json:"required"`
+	DefaultValue      string `json:"default_value,omitempty"`
+	Source            string `json:"source,omitempty"`
+	Declared          bool   `json:"declared"`
+	WellKnown         bool   `json:"well_known"`
+	SampleValue       string `json:"sample_value"`
+	GoTemplateSyntax  string `json:"go_template_syntax"`
+	PlaceholderSyntax string `json:"placeholder_syntax"`
+}
+
+// EmailTypeVariablesResponse lists the variables templates of an email type
+// can reference: its declared variables followed by the remaining well-known ones
+type EmailTypeVariablesResponse struct {
+	EmailTypeID   string                          `json:"email_type_id"`
+	EmailTypeCode string                          `json:"email_type_code"`
+	Variables     []EmailTemplateVariableResponse `json:"variables"`
+}
+
 // CreateEmailTypeRequest represents the request payload for creating a custom email type
 type CreateEmailTypeRequest struct {
 	Code           string                      `json:"code" validate:"required,min=2,max=50"`
 	Name           string                      `json:"name" validate:"required,min=2,max=100"`
 	Description    string                      `json:"description,omitempty"`
 	DefaultSubject string                      `json:"default_subject,omitempty"`
+	Category       string                      `json:"category,omitempty" validate:"omitempty,oneof=transactional security product"` // Default: transactional
 	Variables      []EmailTypeVariableResponse `json:"variables,omitempty"`
 }
 
@@ -76,6 +103,7 @@ type UpdateEmailTypeRequest struct {
 	Name           string                      `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
 	Description    string                      `json:"description,omitempty"`
 	DefaultSubject string                      `json:"default_subject,omitempty"`
+	Category       string                      `json:"category,omitempty" validate:"omitempty,oneof=transactional security product"`
 	Variables      []EmailTypeVariableResponse `json:"variables,omitempty"`
 	IsActive       *bool                       `json:"is_active,omitempty"`
 }
@@ -128,6 +156,9 @@ type EmailPreviewRequest struct {
 	BodyText       string            `json:"body_text,omitempty"`
 	TemplateEngine string            `json:"template_engine" validate:"required,oneof=go_template placeholder raw_html"`
 	Variables      map[string]string `json:"variables"`
+	// UserID renders the template with this user's profile data and their
+	// application's settings instead of only Variables. The access is logged.
+	UserID string `json:"user_id,omitempty"`
 }
 
 // EmailPreviewResponse represents the rendered preview result
@@ -137,6 +168,65 @@ type EmailPreviewResponse struct {
 	BodyText string `json:"body_text"`
 }
 
+// EmailTemplateWarning is a problem found when linting an email template.
+// Code is parse_error, unknown_variable or unsupported_action.
+type EmailTemplateWarning struct {
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Variable string `json:"variable,omitempty"`
+	Message  string `json:"message"`
+}
+
+// EmailTemplateSaveResponse is returned after saving an email template.
+// Warnings do not prevent the save.
+type EmailTemplateSaveResponse struct {
+	Message  string                 `json:"message"`
+	Warnings []EmailTemplateWarning `json:"warnings"`
+}
+
+// EmailTemplateValidationResult lists the warnings of one broken template
+type EmailTemplateValidationResult struct {
+	TemplateID     string                 `json:"template_id"`
+	Name           string                 `json:"name"`
+	AppID          *string                `json:"app_id"` // null = global default
+	EmailTypeCode  string                 `json:"email_type_code"`
+	TemplateEngine string                 `json:"template_engine"`
+	Warnings       []EmailTemplateWarning `json:"warnings"`
+}
+
+// EmailTemplateValidationResponse reports the templates that have warnings
+type EmailTemplateValidationResponse struct {
+	Checked   int                             `json:"checked"`
+	Broken    int                             `json:"broken"`
+	Templates []EmailTemplateValidationResult `json:"templates"`
+}
+
+// EmailTemplateTestResult is the outcome of rendering one template of an
+// application's test matrix. Source is app, global, builtin or none (no
+// template); Effective marks the template the app's emails are sent with.
+type EmailTemplateTestResult struct {
+	EmailTypeCode    string                 `json:"email_type_code"`
+	EmailTypeName    string                 `json:"email_type_name"`
+	Source           string                 `json:"source"`
+	Effective        bool                   `json:"effective"`
+	TemplateID       *string                `json:"template_id"` // null for builtin and none
+	Name             string                 `json:"name,omitempty"`
+	TemplateEngine   string                 `json:"template_engine,omitempty"`
+	OK               bool                   `json:"ok"`
+	Subject          string                 `json:"subject,omitempty"` // Rendered with sample variables
+	RenderError      string                 `json:"render_error,omitempty"`
+	MissingVariables []string               `json:"missing_variables"`
+	Warnings         []EmailTemplateWarning `json:"warnings"`
+}
+
+// EmailTemplateTestMatrixResponse reports the test matrix of an application
+type EmailTemplateTestMatrixResponse struct {
+	AppID   string                    `json:"app_id"`
+	Checked int                       `json:"checked"`
+	Failed  int                       `json:"failed"`
+	Results []EmailTemplateTestResult `json:"results"`
+}
+
 // EmailTestRequest represents a request to send a test email
 type EmailTestRequest struct {
 	ToEmail string `json:"to_email" validate:"required,email"`
@@ -151,13 +241,187 @@ type SendEmailRequest struct {
 	TypeCode  string            `json:"type_code" validate:"required"`
 	ToEmail   string            `json:"to_email" validate:"required,email"`
 	Variables map[string]string `json:"variables,omitempty"`
+	// IdempotencyKey prevents a retried request from sending the email again
+	// within EMAIL_IDEMPOTENCY_WINDOW_HOURS (alternatively the Idempotency-Key header)
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 // SendEmailResponse represents the response after sending an email
 type SendEmailResponse struct {
-	Message  string `json:"message"`
-	TypeCode string `json:"type_code"`
-	ToEmail  string `json:"to_email"`
+	Message   string `json:"message"`
+	TypeCode  string `json:"type_code"`
+	ToEmail   string `json:"to_email"`
+	Duplicate bool   `json:"duplicate,omitempty"` // Not sent: the idempotency key already sent this email
+	// DeferredUntil is set when the email was queued until the app's quiet hours end
+	DeferredUntil *time.Time `json:"deferred_until,omitempty"`
+}
+
+// EmailBatchRecipient is one recipient of a batch send.
+type EmailBatchRecipient struct {
+	ToEmail   string            `json:"to_email"`
+	Variables map[string]string `json:"variables,omitempty"` // Override the batch variables for this recipient
+}
+
+// SendEmailBatchRequest represents a request to send an email of a specific type
+// to many recipients through the background job queue.
+type SendEmailBatchRequest struct {
+	TypeCode      string                `json:"type_code" validate:"required"`
+	Variables     map[string]string     `json:"variables,omitempty"` // Shared by all recipients
+	Recipients    []EmailBatchRecipient `json:"recipients" validate:"required"`
+	RatePerSecond float64               `json:"rate_per_second,omitempty"` // Sends per second; 0 or above the server limit = the server limit
+}
+
+// EmailBatchRecipientStatus is the outcome of a batch send for one recipient.
+type EmailBatchRecipientStatus struct {
+	ToEmail string `json:"to_email"`
+	Status  string `json:"status"` // "queued", "sent", "failed", "suppressed", "duplicate" or "invalid"
+	Error   string `json:"error,omitempty"`
+}
+
+// SendEmailBatchResponse is returned when a batch send has been queued. The
+// job's result (GET /admin/jobs/{id}) is an EmailBatchResult.
+type SendEmailBatchResponse struct {
+	Job           BackgroundJobResponse       `json:"job"`
+	Queued        int                         `json:"queued"`
+	Skipped       []EmailBatchRecipientStatus `json:"skipped"` // Duplicate, suppressed and invalid recipients; not sent
+	RatePerSecond float64                     `json:"rate_per_second"`
+	DeferredUntil *time.Time                  `json:"deferred_until,omitempty"` // Start of the batch when queued during the app's quiet hours
+}
+
+// EmailBatchResult is the result of an email_batch background job.
+type EmailBatchResult struct {
+	Total      int                         `json:"total"`
+	Sent       int                         `json:"sent"`
+	Failed     int                         `json:"failed"`
+	Skipped    int                         `json:"skipped"`
+	Recipients []EmailBatchRecipientStatus `json:"recipients"` // In request order, skipped recipients last
+}
+
+// EmailSuppressionRequest adds an address to an application's suppression list.
+type EmailSuppressionRequest struct {
+	Email  string `json:"email" validate:"required,email"`
+	Reason string `json:"reason,omitempty"` // "manual" (default), "bounce", "complaint" or "unsubscribe"
+	Note   string `json:"note,omitempty"`
+}
+
+// EmailSuppressionResponse is a suppressed address of an application.
+type EmailSuppressionResponse struct {
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EmailSuppressionListResponse is the response for GET /admin/apps/{id}/email-suppressions.
+type EmailSuppressionListResponse struct {
+	Suppressions []EmailSuppressionResponse `json:"suppressions"`
+	Total        int64                      `json:"total" example:"42"`
+	Page         int                        `json:"page" example:"1"`
+	PageSize     int                        `json:"page_size" example:"20"`
+	TotalPages   int64                      `json:"total_pages" example:"3"`
+	Sort         string                     `json:"sort" example:"created_at"`
+	Order        string                     `json:"order" example:"desc"`
+}
+
+// TenantEmailDomainRequest registers a sending domain for a tenant.
+type TenantEmailDomainRequest struct {
+	Domain string `json:"domain" validate:"required"`
+}
+
+// TenantEmailDomainResponse is a sending domain of a tenant, with the DNS
+// record that verifies it.
+type TenantEmailDomainResponse struct {
+	ID            string     `json:"id"`
+	TenantID      string     `json:"tenant_id"`
+	Domain        string     `json:"domain"`
+	Status        string     `json:"status"`      // pending, verified or failed
+	RecordName    string     `json:"record_name"` // Where to publish the TXT record
+	RecordValue   string     `json:"record_value"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TenantEmailDomainListResponse is the response for GET /admin/tenants/{id}/email-domains.
+type TenantEmailDomainListResponse struct {
+	Domains []TenantEmailDomainResponse `json:"domains"`
+}
+
+// TenantEmailDomainVerifyResponse is the outcome of checking a sending
+// domain's verification record.
+type TenantEmailDomainVerifyResponse struct {
+	Domain TenantEmailDomainResponse `json:"domain"`
+	Check  string                    `json:"check"` // pass, fail or error (DNS lookup failed; status unchanged)
+	Detail string                    `json:"detail"`
+	Hint   string                    `json:"hint,omitempty"`
+}
+
+// EmailTemplateVariantRequest creates or updates an A/B variant of an email
+// type's template for an application.
+type EmailTemplateVariantRequest struct {
+	EmailTypeID    string `json:"email_type_id"` // Required on create; the type of a variant cannot change
+	Name           string `json:"name"`          // Unique per app and email type; "control" is reserved
+	Subject        string `json:"subject"`
+	BodyHTML       string `json:"body_html,omitempty"`
+	BodyText       string `json:"body_text,omitempty"`
+	TemplateEngine string `json:"template_engine,omitempty"` // go_template (default), placeholder or raw_html
+	Weight         int    `json:"weight"`                    // Percentage of the type's emails (1-100)
+	IsActive       *bool  `json:"is_active,omitempty"`       // Default true
+}
+
+// EmailTemplateVariantResponse is an A/B variant of an email type's template.
+type EmailTemplateVariantResponse struct {
+	ID             string    `json:"id"`
+	EmailTypeID    string    `json:"email_type_id"`
+	Name           string    `json:"name"`
+	Subject        string    `json:"subject"`
+	BodyHTML       string    `json:"body_html"`
+	BodyText       string    `json:"body_text"`
+	TemplateEngine string    `json:"template_engine"`
+	Weight         int       `json:"weight"`
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// EmailTemplateVariantSaveResponse is returned after saving a variant.
+// Warnings do not prevent the save.
+type EmailTemplateVariantSaveResponse struct {
+	Variant  EmailTemplateVariantResponse `json:"variant"`
+	Warnings []EmailTemplateWarning       `json:"warnings"`
+}
+
+// EmailTemplateVariantListResponse is the response for GET /admin/apps/{id}/email-variants.
+type EmailTemplateVariantListResponse struct {
+	Variants   []EmailTemplateVariantResponse `json:"variants"`
+	Total      int64                          `json:"total" example:"2"`
+	Page       int                            `json:"page" example:"1"`
+	PageSize   int                            `json:"page_size" example:"20"`
+	TotalPages int64                          `json:"total_pages" example:"1"`
+	Sort       string                         `json:"sort" example:"created_at"`
+	Order      string                         `json:"order" example:"asc"`
+}
+
+// EmailVariantStatsEntry is the send statistics of one variant, or of the
+// regular template ("control").
+type EmailVariantStatsEntry struct {
+	VariantID   string     `json:"variant_id,omitempty"` // Empty for the control
+	Name        string     `json:"name"`
+	Weight      int        `json:"weight"` // Configured percentage; for the control, what the active variants leave
+	IsActive    bool       `json:"is_active"`
+	Sent        int64      `json:"sent"`
+	Failed      int64      `json:"failed"`
+	FailureRate float64    `json:"failure_rate"` // Failed / (sent + failed)
+	Share       float64    `json:"share"`        // Share of all sent emails of the type, to compare with the weight
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+}
+
+// EmailVariantStatsResponse is the response for GET /admin/apps/{id}/email-variant-stats.
+type EmailVariantStatsResponse struct {
+	EmailTypeID string                   `json:"email_type_id"`
+	TotalSent   int64                    `json:"total_sent"`
+	Variants    []EmailVariantStatsEntry `json:"variants"`
 }
 
 // ============================================================================
@@ -108,6 +108,11 @@ type OIDCTokenRequest struct {
 	RefreshToken string `form:"refresh_token"` // #nosec G101 -- DTO field
 	// Client credentials grant
 	Scope string `form:"scope"`
+	// Token exchange grant (RFC 8693)
+	SubjectToken       string `form:"subject_token"` // #nosec G101 -- DTO field
+	SubjectTokenType   string `form:"subject_token_type"`
+	RequestedTokenType string `form:"requested_token_type"`
+	Audience           string `form:"audience"`
 }
 
 // OIDCTokenResponse is returned by the token endpoint.
@@ -118,6 +123,8 @@ type OIDCTokenResponse struct {
 	IDToken      string `json:"id_token,omitempty"`      // #nosec G101 -- DTO field
 	RefreshToken string `json:"refresh_token,omitempty"` // #nosec G101 -- DTO field
 	Scope        string `json:"scope,omitempty"`
+	// IssuedTokenType is set only for token exchange responses (RFC 8693 §2.2.1).
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
 }
 
 // OIDCTokenErrorResponse is the RFC 6749 error object for the token endpoint.
@@ -181,3 +188,21 @@ type OIDCConsentSubmitRequest struct {
 	ConsentToken string `form:"consent_token" validate:"required"` // #nosec G101 -- CSRF-like token
 	Action       string `form:"action"`                            // "approve" or "deny"
 }
+
+// ─── End-user authorizations ───────────────────────────────────────────────────
+
+// OIDCAuthorizationResponse is an OIDC client the user has authorized.
+type OIDCAuthorizationResponse struct {
+	ClientID    string   `json:"client_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	LogoURL     string   `json:"logo_url,omitempty"`
+	Scopes      []string `json:"scopes"`
+	GrantedAt   string   `json:"granted_at"`
+	LastUsedAt  string   `json:"last_used_at"`
+}
+
+// OIDCAuthorizationListResponse is returned by GET /profile/authorizations
+type OIDCAuthorizationListResponse struct {
+	Authorizations []OIDCAuthorizationResponse `json:"authorizations"`
+}
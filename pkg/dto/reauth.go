@@ -0,0 +1,65 @@
+package dto
+
+import "encoding/json"
+
+// ============================================================================
+// Re-authentication Challenge DTOs
+// ============================================================================
+
+// ReauthChallengeRequest asks for a re-authentication challenge for the
+// current user. Purpose names the sensitive action being confirmed and is
+// echoed in the proof, so a proof for one action is not accepted for another.
+type ReauthChallengeRequest struct {
+	Method  string `json:"method" validate:"required,oneof=password totp webauthn email_otp" example:"totp"`
+	Purpose string `json:"purpose,omitempty" validate:"max=100" example:"delete_project"`
+}
+
+// ReauthChallengeResponse describes a started challenge. Options is set for
+// webauthn challenges and must be passed to navigator.credentials.get().
+type ReauthChallengeResponse struct {
+	ChallengeID string          `json:"challenge_id"`
+	Method      string          `json:"method"`
+	ExpiresIn   int             `json:"expires_in"` // Seconds
+	Options     json.RawMessage `json:"options,omitempty" swaggertype:"object"`
+}
+
+// ReauthMethodsResponse lists the challenge methods available to the current user.
+type ReauthMethodsResponse struct {
+	Methods []string `json:"methods"`
+}
+
+// ReauthVerifyRequest answers a challenge. Exactly one of Password (password),
+// Code (totp and email_otp) or Credential (webauthn) is used, per method.
+type ReauthVerifyRequest struct {
+	ChallengeID string          `json:"challenge_id" validate:"required"`
+	Password    string          `json:"password,omitempty" validate:"max=128"` // #nosec G101,G117 -- This is a DTO field, not a hardcoded credential
+	Code        string          `json:"code,omitempty"`
+	Credential  json.RawMessage `json:"credential,omitempty" swaggertype:"object"`
+}
+
+// ReauthProofResponse carries the signed proof of a passed challenge.
+type ReauthProofResponse struct {
+	Proof           string `json:"proof"`
+	Method          string `json:"method"`
+	Purpose         string `json:"purpose,omitempty"`
+	AuthenticatedAt string `json:"authenticated_at"`
+	ExpiresIn       int    `json:"expires_in"` // Seconds
+}
+
+// ReauthProofCheckRequest checks a proof before a sensitive action. When
+// Purpose is set the proof must have been issued for it; MaxAgeSeconds
+// additionally limits how long ago the user re-authenticated.
+type ReauthProofCheckRequest struct {
+	Proof         string `json:"proof" validate:"required"`
+	Purpose       string `json:"purpose,omitempty"`
+	MaxAgeSeconds int    `json:"max_age_seconds,omitempty" validate:"min=0"`
+}
+
+// ReauthProofCheckResponse describes an accepted proof. A proof is accepted once.
+type ReauthProofCheckResponse struct {
+	Valid           bool   `json:"valid"`
+	UserID          string `json:"user_id"`
+	Method          string `json:"method"`
+	Purpose         string `json:"purpose,omitempty"`
+	AuthenticatedAt string `json:"authenticated_at"`
+}
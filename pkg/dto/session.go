@@ -13,4 +13,8 @@ type SessionResponse struct {
 // SessionListResponse wraps the list of active sessions.
 type SessionListResponse struct {
 	Sessions []SessionResponse `json:"sessions"`
+	// Concurrent session limit of the application (omitted when unlimited) and what a
+	// sign-in beyond it does: "reject" or "evict_oldest"
+	MaxSessions int    `json:"max_sessions,omitempty" example:"3"`
+	LimitPolicy string `json:"limit_policy,omitempty" example:"evict_oldest"`
 }
@@ -36,6 +36,17 @@ type UserExportRequest struct {
 	Search string `form:"search"`
 }
 
+// ExportArchiveResult is the result of a background export job: the export
+// file written to the file storage backend.
+type ExportArchiveResult struct {
+	Key         string `json:"key"`          // Storage key of the file
+	Format      string `json:"format"`       // csv or json
+	Count       int    `json:"count"`        // Exported rows
+	Truncated   bool   `json:"truncated"`    // Capped at the export row limit
+	Size        int64  `json:"size"`         // File size in bytes
+	DownloadURL string `json:"download_url"` // Admin API path serving the file
+}
+
 // UserImportRow is one parsed record from an uploaded CSV or JSON file.
 type UserImportRow struct {
 	Email     string `json:"email"`
@@ -59,3 +70,27 @@ type UserImportResult struct {
 	Skipped  int                  `json:"skipped"`
 	Errors   []UserImportRowError `json:"errors,omitempty"`
 }
+
+// GenerateUsersRequest asks for Count synthetic users for load testing. Users
+// get the email <email_prefix><n>@<email_domain> for n = start_index ..
+// start_index+count-1 and all share the same password, so load-test scripts
+// can derive every credential.
+type GenerateUsersRequest struct {
+	AppID       string `json:"app_id" binding:"required,uuid"`
+	Count       int    `json:"count" binding:"required,min=1"`
+	StartIndex  int    `json:"start_index" binding:"min=0"`
+	BatchSize   int    `json:"batch_size" binding:"min=0"`         // Rows per INSERT (default 1000)
+	EmailPrefix string `json:"email_prefix"`                       // Default "loadtest-user-"
+	EmailDomain string `json:"email_domain"`                       // Default "loadtest.invalid"
+	Password    string `json:"password" binding:"omitempty,min=8"` // Default "LoadTest-Password-1"
+}
+
+// GenerateUsersResult is the response DTO after generating synthetic users.
+// Emails that already exist in the application are skipped.
+type GenerateUsersResult struct {
+	Requested  int    `json:"requested"`
+	Created    int    `json:"created"`
+	Skipped    int    `json:"skipped"`
+	FirstEmail string `json:"first_email"`
+	LastEmail  string `json:"last_email"`
+}
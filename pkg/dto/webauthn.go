@@ -57,6 +57,32 @@ type PasskeyLoginFinishRequest struct {
 	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
 }
 
+// ============================================================================
+// Passkey-only Account Registration DTOs
+// ============================================================================
+
+// PasskeyAccountBeginRequest starts the registration of a new account with a
+// passkey instead of a password (passkey-only applications).
+type PasskeyAccountBeginRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Name  string `json:"name" validate:"max=255"`
+}
+
+// PasskeyAccountBeginResponse contains the PublicKeyCredentialCreationOptions
+// to be passed to navigator.credentials.create() and the registration session ID.
+type PasskeyAccountBeginResponse struct {
+	Options   json.RawMessage `json:"options" swaggertype:"object"`
+	SessionID string          `json:"session_id"`
+}
+
+// PasskeyAccountFinishRequest contains the client's attestation response for
+// a passkey-only account registration and an optional name for the passkey.
+type PasskeyAccountFinishRequest struct {
+	SessionID  string          `json:"session_id" validate:"required"`
+	Name       string          `json:"name" validate:"max=100"`
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
 // ============================================================================
 // Passkey Management DTOs
 // ============================================================================
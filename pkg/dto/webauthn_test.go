@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Passkey-only account registration tests
+// ---------------------------------------------------------------------------
+
+func TestPasskeyAccountBeginRequest_Valid(t *testing.T) {
+	req := PasskeyAccountBeginRequest{Email: "user@example.com", Name: "Jane Doe"}
+	if err := validate.Struct(req); err != nil {
+		t.Errorf("expected valid, got error: %v", err)
+	}
+}
+
+func TestPasskeyAccountBeginRequest_NameOptional(t *testing.T) {
+	req := PasskeyAccountBeginRequest{Email: "user@example.com"}
+	if err := validate.Struct(req); err != nil {
+		t.Errorf("expected valid without name, got error: %v", err)
+	}
+}
+
+func TestPasskeyAccountBeginRequest_InvalidEmail(t *testing.T) {
+	req := PasskeyAccountBeginRequest{Email: "not-an-email"}
+	if err := validate.Struct(req); err == nil {
+		t.Error("expected validation error for invalid email")
+	}
+}
+
+func TestPasskeyAccountFinishRequest_MissingSession(t *testing.T) {
+	req := PasskeyAccountFinishRequest{Credential: json.RawMessage(`{}`)}
+	if err := validate.Struct(req); err == nil {
+		t.Error("expected validation error for missing session_id")
+	}
+}
+
+func TestPasskeyAccountFinishRequest_NameTooLong(t *testing.T) {
+	req := PasskeyAccountFinishRequest{
+		SessionID:  "session",
+		Name:       strings.Repeat("a", 101), // 101 > max=100
+		Credential: json.RawMessage(`{}`),
+	}
+	if err := validate.Struct(req); err == nil {
+		t.Error("expected validation error for passkey name > 100 chars")
+	}
+}
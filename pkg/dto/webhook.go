@@ -32,10 +32,13 @@ type CreateWebhookResponse struct {
 
 // WebhookEndpointListResponse is the paginated list response for webhook endpoints.
 type WebhookEndpointListResponse struct {
-	Endpoints []WebhookEndpointResponse `json:"endpoints"`
-	Total     int64                     `json:"total" example:"5"`
-	Page      int                       `json:"page" example:"1"`
-	PageSize  int                       `json:"page_size" example:"20"`
+	Endpoints  []WebhookEndpointResponse `json:"endpoints"`
+	Total      int64                     `json:"total" example:"5"`
+	Page       int                       `json:"page" example:"1"`
+	PageSize   int                       `json:"page_size" example:"20"`
+	TotalPages int64                     `json:"total_pages,omitempty" example:"1"`
+	Sort       string                    `json:"sort,omitempty" example:"created_at"`
+	Order      string                    `json:"order,omitempty" example:"desc"`
 }
 
 // ToggleWebhookRequest enables or disables a webhook endpoint.
@@ -69,4 +72,7 @@ type WebhookDeliveryListResponse struct {
 	Total      int64                     `json:"total" example:"100"`
 	Page       int                       `json:"page" example:"1"`
 	PageSize   int                       `json:"page_size" example:"20"`
+	TotalPages int64                     `json:"total_pages,omitempty" example:"5"`
+	Sort       string                    `json:"sort,omitempty" example:"created_at"`
+	Order      string                    `json:"order,omitempty" example:"desc"`
 }
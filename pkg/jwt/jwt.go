@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
@@ -19,6 +20,10 @@ const (
 
 	// TokenTypeRefresh identifies a refresh token.
 	TokenTypeRefresh = "refresh"
+
+	// TokenTypeReauth identifies a re-authentication proof (POST /auth/challenge/verify).
+	// Proofs are rejected wherever an access or refresh token is expected.
+	TokenTypeReauth = "reauth"
 )
 
 var (
@@ -53,81 +58,304 @@ type Claims struct {
 	SessionID string   `json:"session_id,omitempty"` // Session identifier for multi-device session management
 	TokenType string   `json:"token_type,omitempty"` // "access" or "refresh"; empty for legacy tokens
 	Roles     []string `json:"roles,omitempty"`      // User's role names in the application
+	Scope     string   `json:"scope,omitempty"`      // Space-separated scopes; set on delegated (token-exchange) tokens
+	Actor     *Actor   `json:"act,omitempty"`        // Acting party for delegated tokens (RFC 8693 §4.1)
+	AMR       []string `json:"amr,omitempty"`        // Methods used for a re-authentication proof (RFC 8176 style)
+	Purpose   string   `json:"purpose,omitempty"`    // Action a re-authentication proof was requested for
+
+	// Impersonation marks a token issued to support staff acting as the user;
+	// clients show an "impersonating" banner while it is present
+	Impersonation *Impersonation `json:"imp,omitempty"`
+
+	// ServiceAccount marks a long-lived token of a service account, checked
+	// against the service account token revocations
+	ServiceAccount bool `json:"svc,omitempty"`
+
+	// Session token state for multi-region deployments (see internal/region)
+	TokenVersion int64  `json:"tv,omitempty"`  // Raised on every refresh of the session
+	Region       string `json:"rgn,omitempty"` // Region that issued the token (REGION)
+
 	jwt.RegisteredClaims
 }
 
+// IssuedAtTime returns the token's issue time, the zero time when it has none.
+func (c *Claims) IssuedAtTime() time.Time {
+	if c.IssuedAt == nil {
+		return time.Time{}
+	}
+	return c.IssuedAt.Time
+}
+
+// Actor identifies the party acting on behalf of the token subject (RFC 8693 "act" claim).
+// Nested actors record the full delegation chain, most recent actor outermost.
+type Actor struct {
+	Subject string `json:"sub"`
+	Actor   *Actor `json:"act,omitempty"`
+}
+
+// Impersonation identifies who is signed in as the user with an impersonation
+// token and why.
+type Impersonation struct {
+	By       string `json:"by"`                // Support agent named by the application backend
+	Reason   string `json:"reason"`            // Support case or justification
+	ApiKeyID string `json:"api_key,omitempty"` // App API key that issued the token
+}
+
 // DefaultAccessTokenTTL returns the configured global access token TTL.
 func DefaultAccessTokenTTL() time.Duration {
-	return time.Minute * time.Duration(viper.GetInt("ACCESS_TOKEN_EXPIRATION_MINUTES"))
+	return currentSettings().accessTTL
 }
 
 // DefaultRefreshTokenTTL returns the configured global refresh token TTL.
 func DefaultRefreshTokenTTL() time.Duration {
-	return time.Hour * time.Duration(viper.GetInt("REFRESH_TOKEN_EXPIRATION_HOURS"))
+	return currentSettings().refreshTTL
+}
+
+// Issuer returns the configured token issuer (JWT_ISSUER), empty when none.
+func Issuer() string {
+	return currentSettings().issuer
+}
+
+// sign stamps the configured issuer, and the configured audience unless the
+// token has its own, and signs the token with the application's own signing
+// key, else the shared one (see SetSigningKeys), or with JWT_SECRET when there
+// is none.
+func sign(claims *Claims) (string, error) {
+	s := currentSettings()
+	claims.Issuer = s.issuer
+	if len(claims.Audience) == 0 && len(s.audience) > 0 {
+		claims.Audience = s.audience
+	}
+	if key := currentSigningKey(claims.AppID); key != nil {
+		token := jwt.NewWithClaims(key.method(), claims)
+		token.Header["kid"] = key.ID
+		return token.SignedString(key.Private)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// TokenVersion is the session token version and issuing region stamped on
+// session tokens, so that regions can order the tokens of a session when
+// their Redis replicas lag behind each other.
+type TokenVersion struct {
+	Version int64
+	Region  string
 }
 
 // GenerateAccessToken generates a new access token with an explicit TTL.
 // Pass 0 (or DefaultAccessTokenTTL()) to use the global configured value.
+// Each token gets a unique ID (jti) used for revocation.
 func GenerateAccessToken(appID, userID, sessionID string, roles []string, ttl time.Duration) (string, error) {
+	return GenerateVersionedAccessToken(appID, userID, sessionID, roles, ttl, TokenVersion{})
+}
+
+// GenerateVersionedAccessToken generates an access token stamped with the
+// session's token version and region.
+func GenerateVersionedAccessToken(appID, userID, sessionID string, roles []string, ttl time.Duration, v TokenVersion) (string, error) {
 	loadSecret()
 	if ttl <= 0 {
 		ttl = DefaultAccessTokenTTL()
 	}
 	expirationTime := time.Now().Add(ttl)
 	claims := &Claims{
-		UserID:    userID,
-		AppID:     appID,
-		SessionID: sessionID,
-		TokenType: TokenTypeAccess,
-		Roles:     roles,
+		UserID:       userID,
+		AppID:        appID,
+		SessionID:    sessionID,
+		TokenType:    TokenTypeAccess,
+		Roles:        roles,
+		TokenVersion: v.Version,
+		Region:       v.Region,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return sign(claims)
 }
 
 // GenerateRefreshToken generates a new refresh token with an explicit TTL.
 // Pass 0 (or DefaultRefreshTokenTTL()) to use the global configured value.
 func GenerateRefreshToken(appID, userID, sessionID string, roles []string, ttl time.Duration) (string, error) {
+	return GenerateVersionedRefreshToken(appID, userID, sessionID, roles, ttl, TokenVersion{})
+}
+
+// GenerateVersionedRefreshToken generates a refresh token stamped with the
+// session's token version and region.
+func GenerateVersionedRefreshToken(appID, userID, sessionID string, roles []string, ttl time.Duration, v TokenVersion) (string, error) {
 	loadSecret()
 	if ttl <= 0 {
 		ttl = DefaultRefreshTokenTTL()
 	}
 	expirationTime := time.Now().Add(ttl)
+	claims := &Claims{
+		UserID:       userID,
+		AppID:        appID,
+		SessionID:    sessionID,
+		TokenType:    TokenTypeRefresh,
+		Roles:        roles,
+		TokenVersion: v.Version,
+		Region:       v.Region,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return sign(claims)
+}
+
+// GenerateDelegatedAccessToken generates an access token issued via token exchange.
+// The token keeps the subject's user and session so revoking the original session
+// also revokes every token derived from it, but carries a narrowed scope, an explicit
+// audience and the acting party in the "act" claim.
+func GenerateDelegatedAccessToken(appID, userID, sessionID string, roles, audience []string, scope string, actor *Actor, ttl time.Duration) (string, error) {
+	loadSecret()
+	if ttl <= 0 {
+		ttl = DefaultAccessTokenTTL()
+	}
+	expirationTime := time.Now().Add(ttl)
 	claims := &Claims{
 		UserID:    userID,
 		AppID:     appID,
 		SessionID: sessionID,
-		TokenType: TokenTypeRefresh,
+		TokenType: TokenTypeAccess,
 		Roles:     roles,
+		Scope:     scope,
+		Actor:     actor,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Audience:  audience,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return sign(claims)
+}
+
+// DefaultReauthProofTTL returns how long re-authentication proofs stay valid
+// (REAUTH_PROOF_TTL_SECONDS, default 5 minutes).
+func DefaultReauthProofTTL() time.Duration {
+	if s := viper.GetInt("REAUTH_PROOF_TTL_SECONDS"); s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// GenerateReauthProof generates a short-lived proof that the user of sessionID
+// just re-authenticated with method, for the action named by purpose. The
+// issue time is the authentication time; the token ID lets a proof be
+// accepted only once.
+func GenerateReauthProof(appID, userID, sessionID, method, purpose string, ttl time.Duration) (string, error) {
+	loadSecret()
+	if ttl <= 0 {
+		ttl = DefaultReauthProofTTL()
+	}
+	now := time.Now()
+	claims := &Claims{
+		UserID:    userID,
+		AppID:     appID,
+		SessionID: sessionID,
+		TokenType: TokenTypeReauth,
+		AMR:       []string{method},
+		Purpose:   purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return sign(claims)
+}
+
+// MaxImpersonationTokenTTL returns the longest lifetime of an impersonation
+// token (IMPERSONATION_TOKEN_MAX_TTL_MINUTES, default 15 minutes).
+func MaxImpersonationTokenTTL() time.Duration {
+	if m := viper.GetInt("IMPERSONATION_TOKEN_MAX_TTL_MINUTES"); m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+// GenerateImpersonationToken generates an access token for the user carrying
+// the impersonation claim. It belongs to no session and cannot be refreshed;
+// ttl is capped at MaxImpersonationTokenTTL (0 = the maximum). Roles are left
+// out so authorization checks resolve the user's current roles.
+func GenerateImpersonationToken(appID, userID string, imp *Impersonation, ttl time.Duration) (string, *Claims, error) {
+	loadSecret()
+	if limit := MaxImpersonationTokenTTL(); ttl <= 0 || ttl > limit {
+		ttl = limit
+	}
+	now := time.Now()
+	claims := &Claims{
+		UserID:        userID,
+		AppID:         appID,
+		TokenType:     TokenTypeAccess,
+		Impersonation: imp,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	signed, err := sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// GenerateServiceAccountToken generates a long-lived access token for a
+// service account with the space-separated scope. Like impersonation tokens it
+// belongs to no session and cannot be refreshed; tokenID becomes its jti.
+func GenerateServiceAccountToken(appID, userID, tokenID, scope string, expiresAt time.Time) (string, error) {
+	loadSecret()
+	claims := &Claims{
+		UserID:         userID,
+		AppID:          appID,
+		TokenType:      TokenTypeAccess,
+		Scope:          scope,
+		ServiceAccount: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return sign(claims)
+}
+
+// ParseReauthProof parses and validates a re-authentication proof. Access and
+// refresh tokens are rejected.
+func ParseReauthProof(tokenString string) (*Claims, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeReauth {
+		return nil, fmt.Errorf("not a re-authentication proof")
+	}
+	return claims, nil
 }
 
 // ParseToken parses and validates a JWT token
 func ParseToken(tokenString string) (*Claims, error) {
 	loadSecret()
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyFunc,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgES256}))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	// Tokens issued before an issuer was configured have none and stay valid
+	// until they expire; tokens of another issuer are rejected.
+	if issuer := currentSettings().issuer; issuer != "" && claims.Issuer != "" && claims.Issuer != issuer {
+		return nil, fmt.Errorf("token issuer mismatch")
 	}
-	return nil, fmt.Errorf("invalid token")
+	return claims, nil
 }
@@ -212,3 +212,119 @@ func TestTokenTypeDifferentiation(t *testing.T) {
 		t.Fatal("Access and refresh tokens should be different")
 	}
 }
+
+func TestGenerateDelegatedAccessToken(t *testing.T) {
+	appID := "00000000-0000-0000-0000-000000000001"
+	userID := "test-user-id"
+	actor := &Actor{Subject: "service-b", Actor: &Actor{Subject: "service-a"}}
+
+	token, err := GenerateDelegatedAccessToken(appID, userID, "session-1", []string{"member"}, []string{"orders-api"}, "openid profile", actor, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate delegated token: %v", err)
+	}
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("Failed to parse delegated token: %v", err)
+	}
+
+	if claims.TokenType != TokenTypeAccess {
+		t.Fatalf("Expected token type %s, got %s", TokenTypeAccess, claims.TokenType)
+	}
+	if claims.SessionID != "session-1" {
+		t.Fatalf("Expected session ID to be preserved, got %q", claims.SessionID)
+	}
+	if claims.Scope != "openid profile" {
+		t.Fatalf("Expected scope 'openid profile', got %q", claims.Scope)
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != "orders-api" {
+		t.Fatalf("Expected audience [orders-api], got %v", claims.Audience)
+	}
+	if claims.Actor == nil || claims.Actor.Subject != "service-b" {
+		t.Fatalf("Expected actor service-b, got %+v", claims.Actor)
+	}
+	if claims.Actor.Actor == nil || claims.Actor.Actor.Subject != "service-a" {
+		t.Fatalf("Expected nested actor service-a, got %+v", claims.Actor.Actor)
+	}
+	if time.Until(claims.ExpiresAt.Time) > 5*time.Minute {
+		t.Fatal("Delegated token outlives requested TTL")
+	}
+}
+
+func TestGenerateReauthProof(t *testing.T) {
+	appID := "00000000-0000-0000-0000-000000000001"
+	userID := "test-user-id"
+
+	proof, err := GenerateReauthProof(appID, userID, "session-1", "totp", "delete_project", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+
+	claims, err := ParseReauthProof(proof)
+	if err != nil {
+		t.Fatalf("Failed to parse proof: %v", err)
+	}
+	if claims.TokenType != TokenTypeReauth {
+		t.Fatalf("Expected token type %s, got %s", TokenTypeReauth, claims.TokenType)
+	}
+	if claims.SessionID != "session-1" || claims.Purpose != "delete_project" {
+		t.Fatalf("Expected session and purpose to be preserved, got %q and %q", claims.SessionID, claims.Purpose)
+	}
+	if len(claims.AMR) != 1 || claims.AMR[0] != "totp" {
+		t.Fatalf("Expected amr [totp], got %v", claims.AMR)
+	}
+	if claims.ID == "" {
+		t.Fatal("Expected proof to carry a token ID")
+	}
+
+	// Access tokens are not proofs
+	access, _ := GenerateAccessToken(appID, userID, "session-1", nil, 0)
+	if _, err := ParseReauthProof(access); err == nil {
+		t.Fatal("Expected access token to be rejected as a proof")
+	}
+}
+
+func TestGenerateImpersonationToken(t *testing.T) {
+	appID := "00000000-0000-0000-0000-000000000001"
+	userID := "test-user-id"
+	imp := &Impersonation{By: "agent@example.com", Reason: "ticket 4711", ApiKeyID: "key-1"}
+
+	token, issued, err := GenerateImpersonationToken(appID, userID, imp, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate impersonation token: %v", err)
+	}
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("Failed to parse impersonation token: %v", err)
+	}
+	if claims.TokenType != TokenTypeAccess || claims.SessionID != "" {
+		t.Fatalf("Expected a session-less access token, got type %q and session %q", claims.TokenType, claims.SessionID)
+	}
+	if claims.Impersonation == nil || *claims.Impersonation != *imp {
+		t.Fatalf("Expected impersonation claim %+v, got %+v", imp, claims.Impersonation)
+	}
+	if claims.ID == "" || claims.ID != issued.ID {
+		t.Fatalf("Expected returned claims to match the token, got IDs %q and %q", issued.ID, claims.ID)
+	}
+	if time.Until(claims.ExpiresAt.Time) > 5*time.Minute {
+		t.Fatal("Impersonation token outlives requested TTL")
+	}
+
+	// Longer lifetimes are capped
+	viper.Set("IMPERSONATION_TOKEN_MAX_TTL_MINUTES", 10)
+	defer viper.Set("IMPERSONATION_TOKEN_MAX_TTL_MINUTES", nil)
+	_, capped, err := GenerateImpersonationToken(appID, userID, imp, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to generate impersonation token: %v", err)
+	}
+	if ttl := capped.ExpiresAt.Sub(capped.IssuedAt.Time); ttl != 10*time.Minute {
+		t.Fatalf("Expected TTL to be capped at 10m, got %v", ttl)
+	}
+
+	// Regular access tokens carry no impersonation claim
+	access, _ := GenerateAccessToken(appID, userID, "session-1", nil, 0)
+	if claims, _ := ParseToken(access); claims.Impersonation != nil {
+		t.Fatalf("Expected no impersonation claim, got %+v", claims.Impersonation)
+	}
+}
@@ -0,0 +1,337 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+// Signing algorithms (JWT_SIGNING_ALGORITHM). HS256 signs with JWT_SECRET, so
+// only services that know the secret can verify tokens. RS256 and ES256 sign
+// with a private key whose public half is published at
+// /.well-known/jwks.json, so any service can verify tokens offline.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// SettingSigningAlgorithm selects the signing algorithm (read from the
+// configuration only; a change applies on restart).
+const SettingSigningAlgorithm = "JWT_SIGNING_ALGORITHM"
+
+// SigningAlgorithm returns the configured signing algorithm, HS256 when unset
+// or unknown.
+func SigningAlgorithm() string {
+	switch alg := strings.ToUpper(strings.TrimSpace(viper.GetString(SettingSigningAlgorithm))); alg {
+	case AlgRS256, AlgES256:
+		return alg
+	}
+	return AlgHS256
+}
+
+// acceptHS256 reports whether tokens signed with JWT_SECRET are accepted
+// while an asymmetric key signs new tokens (JWT_ACCEPT_HS256, default true),
+// so sessions started before the switch survive until their tokens expire.
+func acceptHS256() bool {
+	if !viper.IsSet("JWT_ACCEPT_HS256") {
+		return true
+	}
+	return viper.GetBool("JWT_ACCEPT_HS256")
+}
+
+// SigningKey is an asymmetric key tokens are signed or verified with.
+type SigningKey struct {
+	ID          string        // "kid" header of the tokens it signs
+	AppID       string        // Application whose tokens it signs; "" = every application without a key of its own
+	Algorithm   string        // AlgRS256 or AlgES256
+	Private     crypto.Signer // *rsa.PrivateKey or *ecdsa.PrivateKey
+	ActivatesAt time.Time     // Signs tokens from then on (published in the JWKS before)
+}
+
+// method returns the JWT signing method of the key's algorithm.
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgES256 {
+		return jwt.SigningMethodES256
+	}
+	return jwt.SigningMethodRS256
+}
+
+var (
+	keysMu         sync.RWMutex
+	signingKey     *SigningKey            // nil = HS256 with JWT_SECRET
+	appSigningKeys map[string]*SigningKey // Keys of applications that sign with their own, by app ID
+	appKeyedSince  map[string]time.Time   // When those applications started signing with their own keys
+	keysByID       map[string]*SigningKey // Keys accepted by ParseToken, by kid
+	onUnknown      func()                 // Reloads the keys when a token has an unknown kid
+)
+
+// keyGeneration counts the changes of what ParseToken accepts: the loaded key
+// set and the settings it reads, such as JWT_ACCEPT_HS256.
+var keyGeneration atomic.Uint64
+
+// KeyGeneration returns a number that changes whenever a token verified
+// earlier may no longer verify, e.g. because its signing key was deleted.
+// Caches of verified tokens compare it to drop stale results.
+func KeyGeneration() uint64 {
+	return keyGeneration.Load()
+}
+
+// SetSigningKeys makes the package sign new tokens with signing (nil = HS256
+// with JWT_SECRET), the tokens of the applications of appSigning with their
+// own key (by SigningKey.AppID) instead, and accept tokens signed with any of
+// verification. The signing keys are accepted even if they are not in
+// verification.
+func SetSigningKeys(signing *SigningKey, verification []*SigningKey, appSigning ...*SigningKey) {
+	byID := make(map[string]*SigningKey, len(verification)+len(appSigning)+1)
+	for _, k := range verification {
+		byID[k.ID] = k
+	}
+	byApp := make(map[string]*SigningKey, len(appSigning))
+	for _, k := range appSigning {
+		byID[k.ID] = k
+		byApp[k.AppID] = k
+	}
+	if signing != nil {
+		byID[signing.ID] = signing
+	}
+	// The oldest loaded key of the application marks the switch to own keys
+	keyedSince := make(map[string]time.Time, len(byApp))
+	for _, k := range byID {
+		if byApp[k.AppID] == nil {
+			continue
+		}
+		if since, ok := keyedSince[k.AppID]; !ok || k.ActivatesAt.Before(since) {
+			keyedSince[k.AppID] = k.ActivatesAt
+		}
+	}
+	keysMu.Lock()
+	signingKey = signing
+	appSigningKeys = byApp
+	appKeyedSince = keyedSince
+	keysByID = byID
+	keysMu.Unlock()
+	keyGeneration.Add(1)
+}
+
+// SetUnknownKeyHandler registers fn to be called when a token names a key
+// that is not loaded, before it is rejected; fn reloads the keys, e.g. after
+// another instance rotated them. fn is responsible for throttling itself.
+func SetUnknownKeyHandler(fn func()) {
+	keysMu.Lock()
+	onUnknown = fn
+	keysMu.Unlock()
+}
+
+// verificationKey returns the loaded key with id, reloading the keys once
+// when it is unknown.
+func verificationKey(id string) *SigningKey {
+	keysMu.RLock()
+	k, reload := keysByID[id], onUnknown
+	keysMu.RUnlock()
+	if k != nil || reload == nil {
+		return k
+	}
+	reload()
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return keysByID[id]
+}
+
+// currentSigningKey returns the key new tokens of appID are signed with: the
+// application's own key, else the shared one, nil for HS256.
+func currentSigningKey(appID string) *SigningKey {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if k := appSigningKeys[appID]; k != nil {
+		return k
+	}
+	return signingKey
+}
+
+// sharedKeyAllowed reports whether a token of claims.AppID signed with a key
+// shared by all applications (a shared kid or JWT_SECRET) is accepted. Once
+// the application signs with its own key, only tokens issued before that, and
+// expiring within a refresh token lifetime of it, are: holders of a shared key
+// cannot mint tokens for applications with keys of their own.
+func sharedKeyAllowed(claims *Claims) bool {
+	keysMu.RLock()
+	since, keyed := appKeyedSince[claims.AppID]
+	keysMu.RUnlock()
+	if !keyed {
+		return true
+	}
+	if claims.IssuedAt == nil || claims.ExpiresAt == nil || !claims.IssuedAt.Before(since) {
+		return false
+	}
+	grace := DefaultRefreshTokenTTL()
+	if access := DefaultAccessTokenTTL(); access > grace {
+		grace = access
+	}
+	return !claims.ExpiresAt.After(since.Add(grace))
+}
+
+// keyFunc returns the key to verify token with: JWT_SECRET for HS256 tokens
+// (unless an asymmetric key signs the tokens of the application and
+// JWT_ACCEPT_HS256 is false), the public key named by "kid" for RS256 and
+// ES256 tokens. A key of an application only verifies that application's
+// tokens, and shared keys only verify the tokens an application with its own
+// key was issued before it (see sharedKeyAllowed).
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	claims, _ := token.Claims.(*Claims)
+	if claims == nil {
+		claims = &Claims{}
+	}
+	appID := claims.AppID
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if currentSigningKey(appID) != nil && !acceptHS256() {
+			return nil, fmt.Errorf("HS256 tokens are no longer accepted")
+		}
+		if !sharedKeyAllowed(claims) {
+			return nil, fmt.Errorf("the application signs its tokens with its own key")
+		}
+		return jwtSecret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		kid, _ := token.Header["kid"].(string)
+		k := verificationKey(kid)
+		if kid == "" || k == nil {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if k.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("signing key %q is not a %s key", kid, token.Method.Alg())
+		}
+		if k.AppID != "" && k.AppID != appID {
+			return nil, fmt.Errorf("signing key %q belongs to another application", kid)
+		}
+		if k.AppID == "" && !sharedKeyAllowed(claims) {
+			return nil, fmt.Errorf("the application signs its tokens with its own key")
+		}
+		return k.Private.Public(), nil
+	}
+	return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+}
+
+// GenerateSigningKey generates a key for alg (RSA 2048-bit or ECDSA P-256).
+// The caller sets its ID.
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generate %s key: %w", alg, err)
+	}
+	return &SigningKey{Algorithm: alg, Private: private}, nil
+}
+
+// MarshalPrivateKey encodes a private key as a PKCS#8 PEM block.
+func MarshalPrivateKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("marshal private key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParsePrivateKey decodes a PKCS#8 PEM block holding an RSA or ECDSA key.
+func ParsePrivateKey(pemStr string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse pkcs8 private key: %w", err)
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	}
+	return nil, fmt.Errorf("PEM does not contain an RSA or ECDSA private key")
+}
+
+// JWKS is a JSON Web Key Set (RFC 7517).
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is the public half of a signing key: N and E for RSA keys, Crv, X and Y
+// for EC keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+
+	// AppID names the application whose tokens the key signs (not part of
+	// RFC 7517); omitted for keys shared by all applications
+	AppID string `json:"app_id,omitempty"`
+}
+
+// PublicJWK returns the public JWK of the key.
+func (k *SigningKey) PublicJWK() JWK {
+	jwk := JWK{Use: "sig", Alg: k.Algorithm, Kid: k.ID, AppID: k.AppID}
+	switch pub := k.Private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// Coordinates are fixed-length (32 bytes for P-256), RFC 7518 §6.2.1
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	}
+	return jwk
+}
+
+// PublicKeys returns the JWKS of every key tokens are accepted with, so
+// services can verify tokens without JWT_SECRET. It is empty while tokens
+// are signed with HS256 and no asymmetric key was used before.
+func PublicKeys() JWKS {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	keys := make([]*SigningKey, 0, len(keysByID))
+	for _, k := range keysByID {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActivatesAt.Before(keys[j].ActivatesAt) })
+	set := JWKS{Keys: make([]JWK, len(keys))}
+	for i, k := range keys {
+		set.Keys[i] = k.PublicJWK()
+	}
+	return set
+}
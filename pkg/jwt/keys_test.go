@@ -0,0 +1,230 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+// useSigningKey makes the package sign with a new key of alg for the rest of
+// the test.
+func useSigningKey(t *testing.T, alg, id string) *SigningKey {
+	t.Helper()
+	key, err := GenerateSigningKey(alg)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey(%s): %v", alg, err)
+	}
+	key.ID = id
+	SetSigningKeys(key, nil)
+	t.Cleanup(func() { SetSigningKeys(nil, nil) })
+	return key
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgES256} {
+		t.Run(alg, func(t *testing.T) {
+			useSigningKey(t, alg, "key-"+alg)
+			token, err := GenerateAccessToken("app", "user", "", nil, 0)
+			if err != nil {
+				t.Fatalf("GenerateAccessToken: %v", err)
+			}
+			claims, err := ParseToken(token)
+			if err != nil {
+				t.Fatalf("ParseToken: %v", err)
+			}
+			if claims.UserID != "user" {
+				t.Errorf("UserID = %q, want user", claims.UserID)
+			}
+			if jwks := PublicKeys(); len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "key-"+alg || jwks.Keys[0].Alg != alg {
+				t.Errorf("PublicKeys() = %+v", jwks)
+			}
+		})
+	}
+}
+
+func TestRotatedKeyStillVerifies(t *testing.T) {
+	old := useSigningKey(t, AlgES256, "old")
+	token, err := GenerateAccessToken("app", "user", "", nil, 0)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+
+	next, _ := GenerateSigningKey(AlgES256)
+	next.ID = "next"
+	SetSigningKeys(next, []*SigningKey{old})
+	if _, err := ParseToken(token); err != nil {
+		t.Errorf("token of the retiring key rejected: %v", err)
+	}
+
+	SetSigningKeys(next, nil)
+	if _, err := ParseToken(token); err == nil {
+		t.Error("token of a deleted key accepted")
+	}
+}
+
+func TestUnknownKeyReloads(t *testing.T) {
+	key := useSigningKey(t, AlgRS256, "rotated-elsewhere")
+	token, err := GenerateAccessToken("app", "user", "", nil, 0)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	SetSigningKeys(nil, nil)
+
+	reloads := 0
+	SetUnknownKeyHandler(func() {
+		reloads++
+		SetSigningKeys(nil, []*SigningKey{key})
+	})
+	t.Cleanup(func() { SetUnknownKeyHandler(nil) })
+
+	if _, err := ParseToken(token); err != nil {
+		t.Fatalf("ParseToken after reload: %v", err)
+	}
+	if reloads != 1 {
+		t.Errorf("reloads = %d, want 1", reloads)
+	}
+}
+
+func TestAppSigningKey(t *testing.T) {
+	shared := useSigningKey(t, AlgRS256, "shared")
+	own, _ := GenerateSigningKey(AlgES256)
+	own.ID, own.AppID = "own", "app-a"
+	SetSigningKeys(shared, nil, own)
+
+	token, err := GenerateAccessToken("app-a", "user", "", nil, 0)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	parsed, _, _ := new(jwt.Parser).ParseUnverified(token, &Claims{})
+	if kid := parsed.Header["kid"]; kid != "own" {
+		t.Errorf("app-a token signed with %v, want own", kid)
+	}
+	if _, err := ParseToken(token); err != nil {
+		t.Errorf("app-a token rejected: %v", err)
+	}
+	other, err := GenerateAccessToken("app-b", "user", "", nil, 0)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	parsed, _, _ = new(jwt.Parser).ParseUnverified(other, &Claims{})
+	if kid := parsed.Header["kid"]; kid != "shared" {
+		t.Errorf("app-b token signed with %v, want shared", kid)
+	}
+
+	// A token of app-b signed with the key of app-a is rejected
+	forged := jwt.NewWithClaims(jwt.SigningMethodES256, &Claims{UserID: "user", AppID: "app-b", TokenType: TokenTypeAccess})
+	forged.Header["kid"] = "own"
+	signed, err := forged.SignedString(own.Private)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	if _, err := ParseToken(signed); err == nil {
+		t.Error("token of another application accepted with an application key")
+	}
+	if jwks := PublicKeys(); len(jwks.Keys) != 2 {
+		t.Errorf("PublicKeys() has %d keys, want 2", len(jwks.Keys))
+	}
+}
+
+func TestSharedKeysDoNotVerifyTokensOfKeyedApps(t *testing.T) {
+	shared := useSigningKey(t, AlgRS256, "shared")
+	own, _ := GenerateSigningKey(AlgES256)
+	activatesAt := time.Now().Add(-time.Hour)
+	own.ID, own.AppID, own.ActivatesAt = "own", "app-a", activatesAt
+	SetSigningKeys(shared, nil, own)
+
+	// sign signs a token of app-a issued at iat with method and key
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, iat time.Time) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, &Claims{UserID: "user", AppID: "app-a", TokenType: TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(iat), ExpiresAt: jwt.NewNumericDate(iat.Add(2 * time.Hour))}})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+	before, after := activatesAt.Add(-time.Minute), activatesAt.Add(time.Minute)
+
+	if _, err := ParseToken(sign(jwt.SigningMethodRS256, "shared", shared.Private, after)); err == nil {
+		t.Error("app-a token signed with the shared key after app-a's key activated accepted")
+	}
+	if _, err := ParseToken(sign(jwt.SigningMethodHS256, "", jwtSecret, after)); err == nil {
+		t.Error("app-a token signed with JWT_SECRET after app-a's key activated accepted")
+	}
+	// Sessions started before the switch survive until their tokens expire
+	if _, err := ParseToken(sign(jwt.SigningMethodRS256, "shared", shared.Private, before)); err != nil {
+		t.Errorf("app-a token signed with the shared key before the switch rejected: %v", err)
+	}
+	if _, err := ParseToken(sign(jwt.SigningMethodHS256, "", jwtSecret, before)); err != nil {
+		t.Errorf("app-a token signed with JWT_SECRET before the switch rejected: %v", err)
+	}
+	// ... but a backdated token cannot outlive them
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "user", AppID: "app-a", TokenType: TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(before), ExpiresAt: jwt.NewNumericDate(activatesAt.Add(DefaultRefreshTokenTTL() + time.Hour))}})
+	signed, _ := forged.SignedString(jwtSecret)
+	if _, err := ParseToken(signed); err == nil {
+		t.Error("backdated app-a token outliving the switch accepted")
+	}
+}
+
+func TestAcceptHS256(t *testing.T) {
+	hs, err := GenerateAccessToken("app", "user", "", nil, 0)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	useSigningKey(t, AlgES256, "es")
+
+	if _, err := ParseToken(hs); err != nil {
+		t.Errorf("HS256 token rejected by default: %v", err)
+	}
+	viper.Set("JWT_ACCEPT_HS256", false)
+	t.Cleanup(func() { viper.Set("JWT_ACCEPT_HS256", true) })
+	if _, err := ParseToken(hs); err == nil {
+		t.Error("HS256 token accepted with JWT_ACCEPT_HS256=false")
+	}
+}
+
+func TestPrivateKeyRoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgES256} {
+		key, err := GenerateSigningKey(alg)
+		if err != nil {
+			t.Fatalf("GenerateSigningKey(%s): %v", alg, err)
+		}
+		pemStr, err := MarshalPrivateKey(key.Private)
+		if err != nil {
+			t.Fatalf("MarshalPrivateKey(%s): %v", alg, err)
+		}
+		parsed, err := ParsePrivateKey(pemStr)
+		if err != nil {
+			t.Fatalf("ParsePrivateKey(%s): %v", alg, err)
+		}
+		back := &SigningKey{ID: "k", Algorithm: alg, Private: parsed}
+		key.ID = "k"
+		if back.PublicJWK() != key.PublicJWK() {
+			t.Errorf("%s: public key changed in the round trip", alg)
+		}
+	}
+}
+
+func TestECJWKCoordinatesAreFixedLength(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		key, _ := GenerateSigningKey(AlgES256)
+		jwk := key.PublicJWK()
+		if jwk.Kty != "EC" || jwk.Crv != "P-256" {
+			t.Fatalf("jwk = %+v", jwk)
+		}
+		for _, c := range []string{jwk.X, jwk.Y} {
+			b, err := base64.RawURLEncoding.DecodeString(c)
+			if err != nil || len(b) != 32 {
+				t.Fatalf("coordinate %q decodes to %d bytes (%v), want 32", c, len(b), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package jwt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Setting keys read through the resolver set with SetSettingResolver.
+const (
+	SettingAccessTokenMinutes = "ACCESS_TOKEN_EXPIRATION_MINUTES"
+	SettingRefreshTokenHours  = "REFRESH_TOKEN_EXPIRATION_HOURS"
+	SettingIssuer             = "JWT_ISSUER"
+	SettingAudience           = "JWT_AUDIENCE"
+	// SettingSecret is only read from the environment; it is listed so that
+	// ValidateSetting can check it.
+	SettingSecret = "JWT_SECRET"
+)
+
+const (
+	// Limits of the token lifetimes accepted by ValidateSetting
+	maxAccessTokenMinutes = 24 * 60
+	maxRefreshTokenHours  = 365 * 24
+
+	defaultAccessTokenMinutes = 15
+	defaultRefreshTokenHours  = 720
+
+	// settingsTTL is how long resolved settings are reused before they are
+	// resolved again, so setting changes apply without a restart.
+	settingsTTL = time.Minute
+)
+
+// SettingResolverFunc resolves a system setting by key (env > DB > default).
+type SettingResolverFunc func(key string) string
+
+// settings are the token settings in effect.
+type settings struct {
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	issuer     string   // Stamped as "iss" and checked by ParseToken (empty = none)
+	audience   []string // Stamped as "aud" on tokens without their own audience
+}
+
+var (
+	settingsMu sync.Mutex
+	resolver   SettingResolverFunc
+	current    settings
+	resolvedAt time.Time
+)
+
+// SetSettingResolver makes the package read the token lifetimes, issuer and
+// audience from the system settings. Without a resolver they are read from
+// the configuration (viper) on every call.
+func SetSettingResolver(fn SettingResolverFunc) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	resolver = fn
+	resolvedAt = time.Time{}
+	keyGeneration.Add(1) // e.g. JWT_ACCEPT_HS256 changed
+}
+
+// ReloadSettings makes the next token resolve the settings again instead of
+// waiting for the cached ones to expire.
+func ReloadSettings() {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	resolvedAt = time.Time{}
+	keyGeneration.Add(1) // e.g. JWT_ACCEPT_HS256 changed
+}
+
+// currentSettings returns the token settings in effect.
+func currentSettings() settings {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if resolver == nil {
+		return resolveSettings(viper.GetString)
+	}
+	if time.Since(resolvedAt) > settingsTTL {
+		current = resolveSettings(resolver)
+		resolvedAt = time.Now()
+	}
+	return current
+}
+
+// resolveSettings reads the settings, falling back to the defaults for
+// lifetimes that are not integers.
+func resolveSettings(resolve SettingResolverFunc) settings {
+	s := settings{
+		accessTTL:  defaultAccessTokenMinutes * time.Minute,
+		refreshTTL: defaultRefreshTokenHours * time.Hour,
+		issuer:     strings.TrimSpace(resolve(SettingIssuer)),
+		audience:   splitAudience(resolve(SettingAudience)),
+	}
+	if v, err := strconv.Atoi(strings.TrimSpace(resolve(SettingAccessTokenMinutes))); err == nil {
+		s.accessTTL = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(strings.TrimSpace(resolve(SettingRefreshTokenHours))); err == nil {
+		s.refreshTTL = time.Duration(v) * time.Hour
+	}
+	return s
+}
+
+// ValidateSetting checks the value of a token setting. Other keys are accepted.
+func ValidateSetting(key, value string) error {
+	switch key {
+	case SettingAccessTokenMinutes:
+		return validateRange(value, 1, maxAccessTokenMinutes, "minutes")
+	case SettingRefreshTokenHours:
+		return validateRange(value, 1, maxRefreshTokenHours, "hours")
+	case SettingIssuer:
+		if strings.ContainsAny(value, " \t\r\n") {
+			return fmt.Errorf("must not contain whitespace")
+		}
+		if len(value) > 255 {
+			return fmt.Errorf("must be at most 255 characters")
+		}
+	case SettingAudience:
+		for _, aud := range strings.Split(value, ",") {
+			if strings.ContainsAny(strings.TrimSpace(aud), " \t\r\n") {
+				return fmt.Errorf("audience %q must not contain whitespace", strings.TrimSpace(aud))
+			}
+		}
+	case SettingSecret:
+		if len(value) < minJWTSecretLength {
+			return fmt.Errorf("must be at least %d bytes (got %d)", minJWTSecretLength, len(value))
+		}
+	}
+	return nil
+}
+
+func validateRange(value string, min, max int, unit string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("must be a valid integer")
+	}
+	if n < min || n > max {
+		return fmt.Errorf("must be between %d and %d %s", min, max, unit)
+	}
+	return nil
+}
+
+// splitAudience splits a comma-separated audience list, dropping empty entries.
+func splitAudience(value string) []string {
+	var audience []string
+	for _, aud := range strings.Split(value, ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			audience = append(audience, aud)
+		}
+	}
+	return audience
+}
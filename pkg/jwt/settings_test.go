@@ -0,0 +1,86 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidateSetting(t *testing.T) {
+	tests := []struct {
+		key, value string
+		ok         bool
+	}{
+		{SettingAccessTokenMinutes, "15", true},
+		{SettingAccessTokenMinutes, "0", false},
+		{SettingAccessTokenMinutes, "1441", false},
+		{SettingRefreshTokenHours, "8760", true},
+		{SettingRefreshTokenHours, "8761", false},
+		{SettingIssuer, "https://auth.example.com", true},
+		{SettingIssuer, "auth example", false},
+		{SettingAudience, "api.example.com, billing", true},
+		{SettingAudience, "api example", false},
+		{SettingSecret, "short-secret", false},
+		{SettingSecret, "a-secret-that-is-at-least-32-bytes", true},
+		{"OTHER", "anything", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSetting(tt.key, tt.value); (err == nil) != tt.ok {
+			t.Errorf("ValidateSetting(%s, %q) = %v, want ok=%v", tt.key, tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestSettingResolver(t *testing.T) {
+	values := map[string]string{
+		SettingAccessTokenMinutes: "5",
+		SettingRefreshTokenHours:  "invalid",
+		SettingIssuer:             "https://auth.example.com",
+		SettingAudience:           "api.example.com, ,billing",
+	}
+	SetSettingResolver(func(key string) string { return values[key] })
+	t.Cleanup(func() { SetSettingResolver(nil) })
+
+	if got := DefaultAccessTokenTTL(); got != 5*time.Minute {
+		t.Errorf("access TTL = %v, want 5m", got)
+	}
+	if got := DefaultRefreshTokenTTL(); got != defaultRefreshTokenHours*time.Hour {
+		t.Errorf("invalid refresh TTL: got %v, want the default", got)
+	}
+
+	token, err := GenerateAccessToken("app", "user", "", nil, 0)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims.Issuer != "https://auth.example.com" || len(claims.Audience) != 2 || claims.Audience[1] != "billing" {
+		t.Errorf("iss = %q, aud = %v", claims.Issuer, claims.Audience)
+	}
+
+	// Tokens of another issuer are rejected, tokens without one accepted
+	values[SettingIssuer] = "https://other.example.com"
+	SetSettingResolver(func(key string) string { return values[key] })
+	if _, err := ParseToken(token); err == nil {
+		t.Error("token of another issuer: expected an error")
+	}
+	values[SettingIssuer] = ""
+	SetSettingResolver(func(key string) string { return values[key] })
+	legacy, _ := GenerateAccessToken("app", "user", "", nil, 0)
+	values[SettingIssuer] = "https://auth.example.com"
+	SetSettingResolver(func(key string) string { return values[key] })
+	if _, err := ParseToken(legacy); err != nil {
+		t.Errorf("token without an issuer: %v", err)
+	}
+}
+
+func TestSettingsWithoutResolver(t *testing.T) {
+	viper.Set(SettingAccessTokenMinutes, 30)
+	t.Cleanup(func() { viper.Set(SettingAccessTokenMinutes, 15) })
+	if got := DefaultAccessTokenTTL(); got != 30*time.Minute {
+		t.Errorf("access TTL = %v, want 30m from the configuration", got)
+	}
+}
@@ -13,7 +13,7 @@ type ActivityLog struct {
 	AppID     uuid.UUID       `gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000001';index" json:"app_id"`
 	UserID    uuid.UUID       `gorm:"type:uuid;index:idx_user_timestamp;index:idx_cleanup" json:"user_id"` // Composite indexes for performance
 	EventType string          `gorm:"index;not null" json:"event_type"`
-	Timestamp time.Time       `gorm:"index:idx_user_timestamp;index:idx_cleanup;not null" json:"timestamp"`
+	Timestamp time.Time       `gorm:"index:idx_user_timestamp;index:idx_cleanup;not null" json:"timestamp"` // The keyset index with id is built concurrently by a migration, not AutoMigrate
 	IPAddress string          `json:"ip_address"`
 	UserAgent string          `json:"user_agent"`
 	Details   json.RawMessage `gorm:"type:jsonb" json:"details"` // Use json.RawMessage for flexible JSONB
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityLogArchive records one file of the cold activity log archive: a
+// batch of one application's logs from one UTC day, moved out of
+// activity_logs to the file storage backend by the activity_log_archive job.
+// A partition (app_id, day) is stored as one or more numbered parts.
+type ActivityLogArchive struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID         uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_activity_log_archives_partition,priority:1" json:"app_id"`
+	Day           time.Time  `gorm:"type:date;not null;uniqueIndex:idx_activity_log_archives_partition,priority:2;index" json:"day"` // UTC day bucket (YYYY-MM-DD)
+	Part          int        `gorm:"not null;uniqueIndex:idx_activity_log_archives_partition,priority:3" json:"part"`
+	Key           string     `gorm:"type:varchar(512);not null" json:"key"`    // Storage key of the gzipped NDJSON file
+	Backend       string     `gorm:"type:varchar(20);not null" json:"backend"` // Storage backend the file was written to
+	RowCount      int64      `gorm:"not null;default:0" json:"row_count"`
+	SizeBytes     int64      `gorm:"not null;default:0" json:"size_bytes"`
+	ArchivedAt    time.Time  `gorm:"not null" json:"archived_at"`
+	RestoredUntil *time.Time `json:"restored_until,omitempty"` // Set while the partition's logs are restored into activity_logs
+}
+
+// TableName specifies the table name for ActivityLogArchive.
+func (ActivityLogArchive) TableName() string {
+	return "activity_log_archives"
+}
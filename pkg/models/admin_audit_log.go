@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminAuditLog is a captured Admin API request and its response, kept for
+// forensic review when ADMIN_AUDIT_CAPTURE_ENABLED is set. Bodies are stored
+// with secrets redacted and are omitted when larger than the capture limit.
+type AdminAuditLog struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Method            string     `gorm:"type:varchar(10);not null" json:"method"`
+	Route             string     `gorm:"type:varchar(255);not null;index" json:"route"` // Route pattern, e.g. /admin/apps/:id
+	Path              string     `gorm:"type:text;not null" json:"path"`                // Request path and query (redacted)
+	StatusCode        int        `gorm:"not null;index" json:"status_code"`
+	ApiKeyID          *uuid.UUID `gorm:"type:uuid;index" json:"api_key_id,omitempty"` // Nil for the static ADMIN_API_KEY
+	IPAddress         string     `gorm:"type:varchar(45);not null;default:''" json:"ip_address"`
+	UserAgent         string     `gorm:"type:text;not null;default:''" json:"user_agent"`
+	RequestBody       string     `gorm:"type:text;not null;default:''" json:"request_body"`
+	ResponseBody      string     `gorm:"type:text;not null;default:''" json:"response_body"`
+	RequestTruncated  bool       `gorm:"not null;default:false" json:"request_truncated"`  // Body exceeded the limit and was not stored
+	ResponseTruncated bool       `gorm:"not null;default:false" json:"response_truncated"` // Body exceeded the limit and was not stored
+	DurationMs        int64      `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt         time.Time  `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for AdminAuditLog.
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
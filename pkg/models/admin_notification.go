@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminNotification is an important system event shown in the admin GUI
+// notification center (bell icon) and in the Admin API notification feed.
+// Notifications are shared by all admins; read state is tracked per admin in
+// AdminNotificationRead.
+type AdminNotification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Type      string     `gorm:"type:varchar(50);not null;index" json:"type"`              // e.g. "tenant_created", "smtp_failure"
+	Severity  string     `gorm:"type:varchar(20);not null;default:'info'" json:"severity"` // "info", "warning" or "critical"
+	Title     string     `gorm:"type:varchar(200);not null" json:"title"`                  // Short headline shown in the bell dropdown
+	Message   string     `gorm:"type:text;not null;default:''" json:"message"`             // Optional details
+	Link      string     `gorm:"type:varchar(500);not null;default:''" json:"link"`        // Optional GUI path with more details
+	AppID     *uuid.UUID `gorm:"type:uuid;index" json:"app_id,omitempty"`                  // Related application, if any
+	DedupKey  string     `gorm:"type:varchar(200);not null;default:'';index" json:"-"`     // Suppresses repeats of the same event (see notification.Service.NotifyOnce)
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for AdminNotification.
+func (AdminNotification) TableName() string {
+	return "admin_notifications"
+}
+
+// AdminNotificationRead records that an admin has read a notification.
+// An absent row means the notification is unread for that admin.
+type AdminNotificationRead struct {
+	NotificationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"notification_id"`
+	AdminID        uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"admin_id"`
+	ReadAt         time.Time `json:"read_at"`
+}
+
+// TableName specifies the table name for AdminNotificationRead.
+func (AdminNotificationRead) TableName() string {
+	return "admin_notification_reads"
+}
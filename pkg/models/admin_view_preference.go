@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminSavedFilter is a named filter combination saved by an admin for one of the
+// admin GUI list views (e.g. "failed logins last 24h" on the logs page).
+// Query holds the URL-encoded filter parameters understood by the list endpoint.
+type AdminSavedFilter struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AdminID   uuid.UUID `gorm:"type:uuid;not null;index:idx_admin_saved_filters_admin_page" json:"admin_id"`
+	Page      string    `gorm:"type:varchar(50);not null;index:idx_admin_saved_filters_admin_page" json:"page"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Query     string    `gorm:"type:text;not null;default:''" json:"query"`
+	IsDefault bool      `gorm:"not null;default:false" json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for AdminSavedFilter.
+func (AdminSavedFilter) TableName() string {
+	return "admin_saved_filters"
+}
+
+// AdminColumnPreference stores which columns an admin has hidden on a list view.
+// HiddenColumns is a comma-separated list of column keys; an absent row means all
+// columns are visible.
+type AdminColumnPreference struct {
+	AdminID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"admin_id"`
+	Page          string    `gorm:"type:varchar(50);primaryKey" json:"page"`
+	HiddenColumns string    `gorm:"type:text;not null;default:''" json:"hidden_columns"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for AdminColumnPreference.
+func (AdminColumnPreference) TableName() string {
+	return "admin_column_preferences"
+}
+
+// AdminPreference stores an admin's display settings for the GUI. Empty values
+// (and an absent row) keep the defaults: the theme cookie, each list's own page
+// size, server local time, US-style dates and English.
+type AdminPreference struct {
+	AdminID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"admin_id"`
+	Theme     string    `gorm:"type:varchar(10);not null;default:''" json:"theme"`
+	PageSize  int       `gorm:"not null;default:0" json:"page_size"`
+	Timezone  string    `gorm:"type:varchar(64);not null;default:''" json:"timezone"`
+	Locale    string    `gorm:"type:varchar(10);not null;default:''" json:"locale"`
+	Language  string    `gorm:"type:varchar(10);not null;default:''" json:"language"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for AdminPreference.
+func (AdminPreference) TableName() string {
+	return "admin_preferences"
+}
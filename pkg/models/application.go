@@ -19,6 +19,8 @@ type Application struct {
 	Passkey2FAEnabled         bool      `gorm:"default:false" json:"passkey_2fa_enabled"`               // Allow passkey as a 2FA method
 	PasskeyLoginEnabled       bool      `gorm:"default:false" json:"passkey_login_enabled"`             // Allow fully passwordless login via passkey
 	MagicLinkEnabled          bool      `gorm:"default:false" json:"magic_link_enabled"`                // Allow passwordless login via email magic link
+	PasswordlessOnly          bool      `gorm:"default:false" json:"passwordless_only"`                 // Passkey-only mode: password registration/login disabled (passkeys and magic links only)
+	EmailVerificationCode     bool      `gorm:"default:false" json:"email_verification_code"`           // Verify email addresses with a 6-digit code (POST /verify-email/code) instead of a link
 	TwoFAMethods              string    `gorm:"type:varchar(100);default:'totp'" json:"two_fa_methods"` // Comma-separated available methods: "totp", "email", "passkey", or combinations
 	LoginNotificationsEnabled bool      `gorm:"default:false" json:"login_notifications_enabled"`       // Send email notifications on new device/location logins
 	SuspiciousActivityAlerts  bool      `gorm:"default:false" json:"suspicious_activity_alerts"`        // Send email alerts for suspicious activity (brute force, etc.)
@@ -47,6 +49,43 @@ type Application struct {
 	// Falls back to the FRONTEND_URL environment variable when empty.
 	FrontendURL string `gorm:"type:varchar(500);default:''" json:"frontend_url"`
 
+	// Redirect allowlist — hosts/origins allowed as social login redirect targets and as the
+	// base of email links (comma or newline separated, e.g. "app.example.com, .example.com,
+	// https://admin.example.com"). Empty = the global ALLOWED_REDIRECT_DOMAINS apply.
+	AllowedRedirectURLs string `gorm:"type:text;default:''" json:"allowed_redirect_urls"`
+
+	// Social callback mode — how Google/Facebook/GitHub login callbacks deliver tokens:
+	// "query" (302 with query parameters), "fragment" (302 with URL fragment), "json", or
+	// "post_message" (HTML page posting the result to window.opener). See internal/social.
+	SocialCallbackMode string `gorm:"type:varchar(20);default:'query'" json:"social_callback_mode"`
+
+	// Social profile sync — when name and avatar of linked social accounts are refreshed from the
+	// provider: "login" (only on sign-in), "on_demand" (also POST /profile/social-accounts/:provider/sync)
+	// or "scheduled" (also by the social_profile_sync job every SocialProfileSyncIntervalHours).
+	SocialProfileSync              string `gorm:"type:varchar(20);default:'login'" json:"social_profile_sync"`
+	SocialProfileSyncIntervalHours int    `gorm:"default:24" json:"social_profile_sync_interval_hours"`
+
+	// Social raw data retention — how much of each provider's user payload is kept in
+	// SocialAccount.RawData: "full", "allowlist" (only the comma or newline separated
+	// SocialRawDataFields, dot paths allowed, e.g. "sub, email, address.country") or "none".
+	SocialRawData       string `gorm:"type:varchar(20);default:'full'" json:"social_raw_data"`
+	SocialRawDataFields string `gorm:"type:text;default:''" json:"social_raw_data_fields"`
+
+	// Blocked email domains — per-app additions to the disposable email blocklist, rejected at
+	// registration and email change (comma or newline separated, e.g. "example.net, spam.test").
+	// Applied even when DISPOSABLE_EMAIL_BLOCKING_ENABLED is false. See internal/disposable.
+	BlockedEmailDomains string `gorm:"type:text;default:''" json:"blocked_email_domains"`
+
+	// Auth method policy — the sign-in methods this application permits (comma-separated
+	// AuthMethod* constants, e.g. "password,google,passkey"). Empty = every method is allowed;
+	// each method still needs its own feature switch (MagicLinkEnabled, a provider config, ...).
+	AllowedAuthMethods string `gorm:"type:varchar(255);default:''" json:"allowed_auth_methods"`
+
+	// Registration approval — new sign-ups (password, passkey and social) are held as "pending
+	// approval" and cannot sign in until an admin approves them from the approval queue in the
+	// admin GUI. Approved and rejected users are notified by email.
+	RegistrationApprovalRequired bool `gorm:"default:false" json:"registration_approval_required"`
+
 	// Email link paths — per-app path suffixes appended to FrontendURL when building
 	// action links sent in transactional emails. Falls back to hardcoded defaults when empty.
 	// Examples: "/auth/reset-password", "/account/verify", "/login/magic"
@@ -67,6 +106,19 @@ type Application struct {
 	LoginSecondaryColor string `gorm:"type:varchar(20);default:''" json:"login_secondary_color"` // Secondary brand color (e.g. "#7c3aed")
 	LoginDisplayName    string `gorm:"type:varchar(200);default:''" json:"login_display_name"`   // Display name shown on login page (falls back to Name if empty)
 
+	// Email Branding — injected into every email of this application through the reserved
+	// brand_* template variables (see internal/email/branding.go)
+	EmailLogoURL       string `gorm:"type:varchar(500);default:''" json:"email_logo_url"`       // Logo shown above the email footer
+	EmailFooterText    string `gorm:"type:text;default:''" json:"email_footer_text"`            // Footer text (e.g. legal notice or support contact)
+	EmailPostalAddress string `gorm:"type:varchar(500);default:''" json:"email_postal_address"` // Physical postal address of the sender
+	EmailSocialLinks   string `gorm:"type:text;default:''" json:"email_social_links"`           // One link per line: "Label https://..." or just the URL
+
+	// Email Quiet Hours — product emails sent between start and end (local time in
+	// EmailTimeZone) are deferred until the window opens (see internal/email/quiet_hours.go)
+	EmailQuietHoursStart string `gorm:"type:varchar(5);default:''" json:"email_quiet_hours_start"` // "HH:MM"; empty = no quiet hours
+	EmailQuietHoursEnd   string `gorm:"type:varchar(5);default:''" json:"email_quiet_hours_end"`   // "HH:MM"; may be earlier than start (overnight)
+	EmailTimeZone        string `gorm:"type:varchar(64);default:''" json:"email_time_zone"`        // IANA time zone of the quiet hours; empty = UTC
+
 	// Password Policy — per-app overrides for password strength and rotation requirements
 	PwMinLength     int  `gorm:"default:8" json:"pw_min_length"`         // Minimum password length (default 8)
 	PwMaxLength     int  `gorm:"default:128" json:"pw_max_length"`       // Maximum password length (default 128)
@@ -76,11 +128,39 @@ type Application struct {
 	PwRequireSymbol bool `gorm:"default:false" json:"pw_require_symbol"` // Require at least one special character
 	PwHistoryCount  int  `gorm:"default:0" json:"pw_history_count"`      // Number of previous passwords to remember (0 = disabled)
 	PwMaxAgeDays    int  `gorm:"default:0" json:"pw_max_age_days"`       // Days before password expires (0 = never)
+	// PwRotationRequiredAt forces a password change at the next login of every user whose
+	// password was last set before it (set by POST /admin/apps/:id/force-password-rotation)
+	PwRotationRequiredAt *time.Time `json:"pw_rotation_required_at,omitempty"`
+
+	// AuthEpochAt is when the session kill switch last ran: tokens issued at or before it are
+	// rejected and its sessions and trusted devices were deleted (nil = never)
+	AuthEpochAt *time.Time `json:"auth_epoch_at,omitempty"`
 
 	// Token TTL overrides — per-app token lifetimes (0 = use global env var defaults)
 	AccessTokenTTLMinutes int `gorm:"default:0" json:"access_token_ttl_minutes"` // Access token lifetime in minutes (0 = use ACCESS_TOKEN_EXPIRATION_MINUTES)
 	RefreshTokenTTLHours  int `gorm:"default:0" json:"refresh_token_ttl_hours"`  // Refresh token lifetime in hours (0 = use REFRESH_TOKEN_EXPIRATION_HOURS)
 
+	// Session concurrency — maximum simultaneous sessions per user (0 = unlimited). When a new
+	// sign-in would exceed it, SessionLimitPolicy "reject" refuses the sign-in and "evict_oldest"
+	// revokes the user's oldest sessions. See internal/session.
+	MaxSessionsPerUser int    `gorm:"default:0" json:"max_sessions_per_user"`
+	SessionLimitPolicy string `gorm:"type:varchar(20);default:'evict_oldest'" json:"session_limit_policy"`
+
+	// Quotas — per-app resource limits (0 = use the global QUOTA_* env var default; see internal/quota)
+	MaxUsers        int `gorm:"default:0" json:"max_users"`          // Maximum users (0 = use QUOTA_MAX_USERS_PER_APP)
+	MaxApiKeys      int `gorm:"default:0" json:"max_api_keys"`       // Maximum active app API keys (0 = use QUOTA_MAX_API_KEYS_PER_APP)
+	MaxEmailsPerDay int `gorm:"default:0" json:"max_emails_per_day"` // Maximum emails sent per UTC day (0 = use QUOTA_MAX_EMAILS_PER_DAY)
+
+	// Environments — an environment is a child application of ParentAppID with its own
+	// OAuth/SMTP configs, redirect URLs, API keys and signing key (see internal/environment)
+	ParentAppID *uuid.UUID `gorm:"type:uuid;index;default:null" json:"parent_app_id,omitempty"` // Parent application (NULL = top-level application)
+	Environment string     `gorm:"type:varchar(20);default:'production'" json:"environment"`    // "development", "staging" or "production"
+	ShareUsers  bool       `gorm:"default:false" json:"share_users"`                            // Environment shares the parent's user base instead of keeping its own
+
+	// External ID — caller-assigned identifier (e.g. a Terraform resource ID) used by
+	// PUT /admin/apps/by-external-id/:external_id for idempotent upserts (NULL = not managed)
+	ExternalID *string `gorm:"type:varchar(255);uniqueIndex" json:"external_id,omitempty"`
+
 	CreatedAt            time.Time             `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt            time.Time             `gorm:"autoUpdateTime" json:"updated_at"`
 	OAuthProviderConfigs []OAuthProviderConfig `gorm:"foreignKey:AppID" json:"oauth_provider_configs"`
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Auth methods an application can permit in its auth method policy
+// (Application.AllowedAuthMethods).
+const (
+	AuthMethodPassword          = "password"
+	AuthMethodGoogle            = "google"
+	AuthMethodFacebook          = "facebook"
+	AuthMethodGithub            = "github"
+	AuthMethodOIDC              = "oidc" // Generic OpenID Connect providers (see OAuthProviderConfig.IsGenericOIDC)
+	AuthMethodMagicLink         = "magic_link"
+	AuthMethodPasskey           = "passkey"
+	AuthMethodClientCredentials = "client_credentials" // OIDC client_credentials grant
+)
+
+// AuthMethods lists every auth method in display order.
+var AuthMethods = []string{
+	AuthMethodPassword,
+	AuthMethodGoogle,
+	AuthMethodFacebook,
+	AuthMethodGithub,
+	AuthMethodOIDC,
+	AuthMethodMagicLink,
+	AuthMethodPasskey,
+	AuthMethodClientCredentials,
+}
+
+// IsValidAuthMethod reports whether method is one of the AuthMethod* constants.
+func IsValidAuthMethod(method string) bool {
+	for _, m := range AuthMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAuthMethods validates a list of auth methods and returns it as a
+// policy string in the order of AuthMethods. A list naming every method is
+// stored as "" (no restriction).
+func NormalizeAuthMethods(methods []string) (string, error) {
+	if len(methods) == 0 {
+		return "", fmt.Errorf("at least one sign-in method must be allowed")
+	}
+	selected := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		m = strings.TrimSpace(m)
+		if !IsValidAuthMethod(m) {
+			return "", fmt.Errorf("unknown sign-in method %q", m)
+		}
+		selected[m] = true
+	}
+	if len(selected) == len(AuthMethods) {
+		return "", nil
+	}
+	ordered := make([]string, 0, len(selected))
+	for _, m := range AuthMethods {
+		if selected[m] {
+			ordered = append(ordered, m)
+		}
+	}
+	return strings.Join(ordered, ","), nil
+}
+
+// AuthMethodAllowed reports whether the application's auth method policy
+// permits method. An empty policy permits every method.
+func (a *Application) AuthMethodAllowed(method string) bool {
+	policy := strings.TrimSpace(a.AllowedAuthMethods)
+	if policy == "" {
+		return true
+	}
+	for _, m := range strings.Split(policy, ",") {
+		if strings.TrimSpace(m) == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BackgroundJob is a long-running admin operation (bulk import, export, purge,
+// ...) queued for execution by the background job queue (see internal/jobqueue)
+// instead of running inside a request handler.
+type BackgroundJob struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Type            string     `gorm:"type:varchar(50);not null;index" json:"type"`                   // Registered job type, e.g. "user_import"
+	Status          string     `gorm:"type:varchar(20);not null;index" json:"status"`                 // "queued", "running", "succeeded", "failed" or "cancelled"
+	Payload         string     `gorm:"type:text;not null;default:''" json:"-"`                        // JSON-encoded job input
+	Result          string     `gorm:"type:text;not null;default:''" json:"result,omitempty"`         // JSON-encoded job output
+	Error           string     `gorm:"type:text;not null;default:''" json:"error,omitempty"`          // Last error (also kept while a retry is pending)
+	Progress        int        `gorm:"not null;default:0" json:"progress"`                            // Percent complete (0-100)
+	ProgressMessage string     `gorm:"type:varchar(255);not null;default:''" json:"progress_message"` // Human-readable progress or outcome summary
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`                            // Executions started so far
+	MaxAttempts     int        `gorm:"not null;default:3" json:"max_attempts"`                        // Failed executions are retried until this many attempts
+	RunAfter        time.Time  `gorm:"not null;index" json:"run_after"`                               // Earliest time the job may (re)start; used for retry backoff
+	CancelRequested bool       `gorm:"not null;default:false" json:"cancel_requested"`                // Set to stop a running job
+	CreatedBy       string     `gorm:"type:varchar(255);not null;default:''" json:"created_by"`       // Admin who queued the job
+	LockedBy        string     `gorm:"type:varchar(255);not null;default:''" json:"locked_by"`        // Hostname of the instance running the job
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for BackgroundJob.
+func (BackgroundJob) TableName() string {
+	return "background_jobs"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// DailyAppMetric is the nightly rollup of one application's activity on one
+// UTC day. Reports read these rows instead of scanning the raw activity logs;
+// one row is maintained per (app_id, day) using upsert semantics, so a day can
+// be rolled up again.
+type DailyAppMetric struct {
+	AppID        uuid.UUID      `gorm:"type:uuid;primaryKey" json:"app_id"`
+	Day          time.Time      `gorm:"type:date;primaryKey;index:idx_daily_app_metrics_day" json:"day"` // UTC day bucket (YYYY-MM-DD)
+	Signups      int64          `gorm:"not null;default:0" json:"signups"`
+	Logins       int64          `gorm:"not null;default:0" json:"logins"`
+	FailedLogins int64          `gorm:"not null;default:0" json:"failed_logins"`
+	EmailsSent   int64          `gorm:"not null;default:0" json:"emails_sent"`
+	ActiveUsers  int64          `gorm:"not null;default:0" json:"active_users"`                // Distinct users with activity that day
+	MonthlyUsers int64          `gorm:"not null;default:0" json:"monthly_users"`               // Distinct users with activity in the 30 days ending that day
+	LoginMethods datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"login_methods"` // Successful logins by method, e.g. {"password": 10, "google": 4}
+	RolledUpAt   time.Time      `gorm:"not null" json:"rolled_up_at"`
+}
+
+// TableName specifies the table name for DailyAppMetric.
+func (DailyAppMetric) TableName() string {
+	return "daily_app_metrics"
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email suppression reasons
+const (
+	SuppressionReasonManual      = "manual"      // Added by an admin
+	SuppressionReasonBounce      = "bounce"      // The address hard-bounced
+	SuppressionReasonComplaint   = "complaint"   // The recipient reported the mail as spam
+	SuppressionReasonUnsubscribe = "unsubscribe" // The recipient opted out
+)
+
+// EmailSuppression is an address that bulk sends of an application skip. One
+// row is kept per (app_id, email); emails are stored lowercased.
+type EmailSuppression struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_email_suppressions_app_email" json:"app_id"`
+	Email     string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_email_suppressions_app_email" json:"email"`
+	Reason    string    `gorm:"type:varchar(20);not null;default:'manual'" json:"reason"` // One of the SuppressionReason* values
+	Note      string    `gorm:"type:varchar(255);not null;default:''" json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for EmailSuppression.
+func (EmailSuppression) TableName() string {
+	return "email_suppressions"
+}
@@ -21,6 +21,7 @@ type EmailTemplate struct {
 	FromName       string     `gorm:"type:varchar(255);default:''" json:"from_name,omitempty"`                // Optional sender name override
 	ServerConfigID *uuid.UUID `gorm:"type:uuid" json:"server_config_id,omitempty"`                            // Optional link to specific SMTP config
 	IsActive       bool       `gorm:"default:true" json:"is_active"`
+	ExternalID     *string    `gorm:"type:varchar(255);uniqueIndex" json:"external_id,omitempty"` // Caller-assigned ID for declarative upserts (NULL = not managed)
 	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ControlVariantName names an application's regular template in variant
+// statistics and activity logs while variants of its email type are active.
+const ControlVariantName = "control"
+
+// EmailTemplateVariant is an alternative subject and body for one email type of
+// an application, sent instead of the regular template for Weight percent of
+// the emails of that type (A/B tests). The active variants of a type weigh at
+// most 100 in total; the remaining emails use the regular template, the
+// control. Sender overrides and the SMTP config come from the regular template.
+type EmailTemplateVariant struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID          uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_email_variants_app_type_name" json:"app_id"`
+	EmailTypeID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_email_variants_app_type_name" json:"email_type_id"`
+	Name           string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_email_variants_app_type_name" json:"name"`
+	Subject        string    `gorm:"type:varchar(255);not null" json:"subject"`
+	BodyHTML       string    `gorm:"type:text" json:"body_html"`
+	BodyText       string    `gorm:"type:text" json:"body_text"`
+	TemplateEngine string    `gorm:"type:varchar(20);not null;default:'go_template'" json:"template_engine"` // go_template | placeholder | raw_html
+	Weight         int       `gorm:"not null;default:0" json:"weight"`                                       // Percentage of the type's emails (1-100)
+	IsActive       bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for EmailTemplateVariant.
+func (EmailTemplateVariant) TableName() string {
+	return "email_template_variants"
+}
+
+// EmailVariantStat counts the emails of a type sent with one variant.
+// VariantID is uuid.Nil for the control (the regular template).
+type EmailVariantStat struct {
+	AppID       uuid.UUID  `gorm:"type:uuid;primaryKey" json:"app_id"`
+	EmailTypeID uuid.UUID  `gorm:"type:uuid;primaryKey" json:"email_type_id"`
+	VariantID   uuid.UUID  `gorm:"type:uuid;primaryKey" json:"variant_id"`
+	Sent        int64      `gorm:"not null;default:0" json:"sent"`
+	Failed      int64      `gorm:"not null;default:0" json:"failed"` // Rejected by the SMTP server or unreachable
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+}
+
+// TableName specifies the table name for EmailVariantStat.
+func (EmailVariantStat) TableName() string {
+	return "email_variant_stats"
+}
@@ -16,7 +16,8 @@ type EmailType struct {
 	Name           string         `gorm:"type:varchar(100);not null" json:"name"`
 	Description    string         `gorm:"type:text" json:"description"`
 	DefaultSubject string         `gorm:"type:varchar(255)" json:"default_subject"`
-	Variables      datatypes.JSON `gorm:"type:jsonb" json:"variables"` // [{name, description, required}]
+	Variables      datatypes.JSON `gorm:"type:jsonb" json:"variables"`                                       // [{name, description, required}]
+	Category       string         `gorm:"type:varchar(20);not null;default:'transactional'" json:"category"` // See the EmailCategory* constants
 	IsSystem       bool           `gorm:"default:true" json:"is_system"`
 	IsActive       bool           `gorm:"default:true" json:"is_active"`
 	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -28,6 +29,25 @@ func (EmailType) TableName() string {
 	return "email_types"
 }
 
+// Email categories decide whether recipients can opt out of an email type.
+// Transactional emails (verification, password reset, 2FA codes, ...) are
+// always sent; the others honour the user's notification preferences and the
+// app's suppression list, and carry an unsubscribe link.
+const (
+	EmailCategoryTransactional = "transactional"
+	EmailCategorySecurity      = "security" // Security alerts (new device login, suspicious activity)
+	EmailCategoryProduct       = "product"  // Product news, newsletters and other optional email
+)
+
+// IsValidEmailCategory reports whether category is one of the EmailCategory* constants.
+func IsValidEmailCategory(category string) bool {
+	switch category {
+	case EmailCategoryTransactional, EmailCategorySecurity, EmailCategoryProduct:
+		return true
+	}
+	return false
+}
+
 // Variable source constants indicate where a variable's value is automatically resolved from.
 const (
 	VarSourceUser     = "user"     // Auto-resolved from user profile fields
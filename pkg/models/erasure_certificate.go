@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Erasure modes (ErasureCertificate.Mode)
+const (
+	ErasureModeAnonymize = "anonymize" // The user row is kept with its personal data removed
+	ErasureModeDelete    = "delete"    // The user row and everything owned by it are deleted
+)
+
+// ErasureCertificate records a right-to-erasure (GDPR) request carried out by
+// an admin. It holds no personal data: the erased address is kept only as a
+// keyed hash, so the erasure can later be proven for a given address. The
+// signature covers every field and is keyed with JWT_SECRET.
+type ErasureCertificate struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID       uuid.UUID      `gorm:"type:uuid;not null;index" json:"app_id"`
+	UserID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`             // No foreign key: deleted users are gone
+	Mode        string         `gorm:"type:varchar(20);not null" json:"mode"`               // One of the ErasureMode* values
+	SubjectHash string         `gorm:"type:varchar(64);not null;index" json:"subject_hash"` // HMAC-SHA256 of the lowercased email
+	Reason      string         `gorm:"type:varchar(255);not null;default:''" json:"reason,omitempty"`
+	ErasedBy    string         `gorm:"type:varchar(255);not null" json:"erased_by"` // Admin username, or the admin API key
+	Counts      datatypes.JSON `gorm:"type:jsonb;not null" json:"counts"`           // Rows erased per table
+	ErasedAt    time.Time      `gorm:"not null;index" json:"erased_at"`
+	Signature   string         `gorm:"type:varchar(64);not null" json:"signature"` // Hex HMAC-SHA256 of the certificate
+}
+
+// TableName specifies the table name for ErasureCertificate.
+func (ErasureCertificate) TableName() string {
+	return "erasure_certificates"
+}
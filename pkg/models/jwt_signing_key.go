@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JWTSigningKey is an asymmetric key access and refresh tokens are signed
+// with when JWT_SIGNING_ALGORITHM is RS256 or ES256. Its ID is the "kid" of
+// the tokens it signs. The newest key whose activation time has passed signs
+// new tokens; keys are published in the JWKS from creation until ExpiresAt,
+// when the last token they signed has expired. Keys with an AppID sign and
+// verify only the tokens of that application, which then no longer uses the
+// shared keys (AppID NULL).
+type JWTSigningKey struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID       *uuid.UUID `gorm:"type:uuid;index" json:"app_id,omitempty"`    // NULL = shared by all applications without keys of their own
+	Algorithm   string     `gorm:"type:varchar(10);not null" json:"algorithm"` // RS256 or ES256
+	PrivateKey  string     `gorm:"type:text;not null" json:"-"`                // PKCS#8 PEM, encrypted with the settings encryption key
+	ActivatesAt time.Time  `gorm:"not null;index" json:"activates_at"`         // Signs new tokens from then on
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"`          // Set once a newer key took over; dropped after
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for JWTSigningKey.
+func (JWTSigningKey) TableName() string {
+	return "jwt_signing_keys"
+}
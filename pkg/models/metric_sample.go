@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// MetricSample is one bucket of the embedded metrics history the admin
+// dashboard charts read, so they work without Prometheus. Minute buckets are
+// kept for two days and downsampled into hourly buckets, which are kept for
+// METRICS_HISTORY_RETENTION_DAYS. Counters hold the increase within the
+// bucket, gauges the value sampled (minute) or the average (hour).
+type MetricSample struct {
+	Metric      string    `gorm:"type:varchar(50);primaryKey" json:"metric"`
+	Resolution  int       `gorm:"primaryKey;index:idx_metric_samples_resolution_bucket,priority:1" json:"resolution"`   // Bucket size in seconds (60 or 3600)
+	BucketStart time.Time `gorm:"primaryKey;index:idx_metric_samples_resolution_bucket,priority:2" json:"bucket_start"` // UTC start of the bucket
+	Value       float64   `gorm:"not null;default:0" json:"value"`
+}
+
+// TableName specifies the table name for MetricSample.
+func (MetricSample) TableName() string {
+	return "metric_samples"
+}
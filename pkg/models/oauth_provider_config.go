@@ -1,22 +1,123 @@
 package models
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 // OAuthProviderConfig stores OAuth credentials for a specific application and provider
 type OAuthProviderConfig struct {
 	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	AppID        uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_app_provider" json:"app_id"`
-	Provider     string    `gorm:"not null;uniqueIndex:idx_app_provider" json:"provider"` // google, facebook, github
+	Provider     string    `gorm:"not null;uniqueIndex:idx_app_provider" json:"provider"` // google, facebook, github, or the name of a generic OpenID Connect provider
 	ClientID     string    `gorm:"not null" json:"client_id"`
 	ClientSecret string    `gorm:"not null" json:"-"` // Stored encrypted, not exposed via JSON
 	RedirectURL  string    `gorm:"not null" json:"redirect_url"`
 	IsEnabled    bool      `gorm:"default:true" json:"is_enabled"`
+	ExternalID   *string   `gorm:"type:varchar(255);uniqueIndex" json:"external_id,omitempty"` // Caller-assigned ID for declarative upserts (NULL = not managed)
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	// Rules mapping the provider's claims to user fields, roles and groups on sign-in (see internal/claimmap; NULL = none)
+	ClaimMappings datatypes.JSON `gorm:"type:jsonb" json:"claim_mappings,omitempty"`
+	// Just-in-time provisioning of users signing in with the provider for the first time:
+	// ProvisioningModeAuto creates their account, ProvisioningModeExistingOnly requires it to exist.
+	ProvisioningMode string `gorm:"type:varchar(20);not null;default:'auto'" json:"provisioning_mode"`
+	// Email domains whose users may be provisioned (comma or newline separated, subdomains
+	// included, e.g. "company.com"; empty = any). Requires a verified email.
+	AllowedEmailDomains string `gorm:"type:text;not null;default:''" json:"allowed_email_domains"`
+	// Generic OpenID Connect providers (Okta, Auth0, Azure AD, Keycloak, ...): the issuer
+	// whose discovery document gives the endpoints, and the space-separated scopes to
+	// request (empty = DefaultOIDCScopes). Both are empty for the built-in providers.
+	IssuerURL string `gorm:"type:varchar(500);not null;default:''" json:"issuer_url,omitempty"`
+	Scopes    string `gorm:"type:varchar(500);not null;default:''" json:"scopes,omitempty"`
+}
+
+// Provisioning modes (OAuthProviderConfig.ProvisioningMode).
+const (
+	ProvisioningModeAuto         = "auto"
+	ProvisioningModeExistingOnly = "existing_only"
+)
+
+// BuiltinOAuthProviders are the providers whose endpoints are built in. Any
+// other provider is a generic OpenID Connect provider.
+var BuiltinOAuthProviders = []string{"google", "facebook", "github"}
+
+// DefaultOIDCScopes are the scopes requested from a generic OpenID Connect
+// provider without configured scopes.
+const DefaultOIDCScopes = "openid email profile"
+
+// oidcProviderName is the form of generic OpenID Connect provider names,
+// which appear in the sign-in URLs (/auth/oidc/:provider/login).
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,31}$`)
+
+// IsBuiltinOAuthProvider reports whether provider is one of BuiltinOAuthProviders.
+func IsBuiltinOAuthProvider(provider string) bool {
+	for _, p := range BuiltinOAuthProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOIDCProviderName reports whether name is a valid generic OpenID Connect
+// provider name. Built-in provider names match as well.
+func IsOIDCProviderName(name string) bool {
+	return oidcProviderName.MatchString(name)
+}
+
+// IsGenericOIDC reports whether the config is of a generic OpenID Connect provider.
+func (c *OAuthProviderConfig) IsGenericOIDC() bool {
+	return !IsBuiltinOAuthProvider(c.Provider)
+}
+
+// OIDCScopes returns the scopes to request from a generic OpenID Connect provider.
+func (c *OAuthProviderConfig) OIDCScopes() []string {
+	scopes := strings.Fields(c.Scopes)
+	if len(scopes) == 0 {
+		scopes = strings.Fields(DefaultOIDCScopes)
+	}
+	return scopes
+}
+
+// ValidateProvider checks the provider name and the generic OpenID Connect
+// settings. Built-in providers take no issuer URL or scopes; any other
+// provider is a generic OpenID Connect provider, named by a lowercase slug
+// (e.g. "okta"), whose issuer URL is required and must use https (http is
+// accepted for localhost). Scopes must include openid.
+func (c *OAuthProviderConfig) ValidateProvider() error {
+	if !c.IsGenericOIDC() {
+		if c.IssuerURL != "" || c.Scopes != "" {
+			return fmt.Errorf("issuer_url and scopes are only supported for generic OpenID Connect providers")
+		}
+		return nil
+	}
+	if !IsOIDCProviderName(c.Provider) {
+		return fmt.Errorf("provider must be google, facebook, github, or the name of an OpenID Connect provider: 2 to 32 lowercase letters, digits, '-' or '_'")
+	}
+	if c.IssuerURL == "" {
+		return fmt.Errorf("issuer_url is required for OpenID Connect provider %q", c.Provider)
+	}
+	u, err := url.Parse(c.IssuerURL)
+	if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("issuer_url must be an absolute URL without query or fragment")
+	}
+	local := u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1" || u.Hostname() == "::1"
+	if u.Scheme != "https" && !(u.Scheme == "http" && local) {
+		return fmt.Errorf("issuer_url must use https")
+	}
+	for _, scope := range c.OIDCScopes() {
+		if scope == "openid" {
+			return nil
+		}
+	}
+	return fmt.Errorf("scopes must include openid")
 }
 
 // TableName overrides the default table name
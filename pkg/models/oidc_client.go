@@ -28,7 +28,8 @@ type OIDCClient struct {
 	RedirectURIs string `gorm:"type:text;not null;default:'[]'" json:"redirect_uris"`
 
 	// Comma-separated list of allowed grant types
-	// Supported: "authorization_code", "client_credentials", "refresh_token"
+	// Supported: "authorization_code", "client_credentials", "refresh_token",
+	// "urn:ietf:params:oauth:grant-type:token-exchange"
 	AllowedGrantTypes string `gorm:"type:varchar(200);default:'authorization_code,refresh_token'" json:"allowed_grant_types"`
 
 	// Comma-separated list of allowed OIDC scopes
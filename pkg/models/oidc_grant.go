@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCGrant records an end user's authorization of an OIDC client: the scopes
+// the user granted it and when tokens were last issued to it. Grants are
+// listed on the user's authorizations page, where revoking one invalidates
+// the tokens issued to the client.
+type OIDCGrant struct {
+	ID    uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AppID uuid.UUID `gorm:"type:uuid;not null;index" json:"app_id"`
+
+	// UserID of the end user, ClientID of the authorized OIDC client
+	UserID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_oidc_grant_user_client" json:"user_id"`
+	ClientID string    `gorm:"not null;uniqueIndex:idx_oidc_grant_user_client" json:"client_id"`
+
+	// Scopes granted (space-separated), accumulated over authorizations since
+	// the grant was last revoked
+	Scopes string `gorm:"not null;default:''" json:"scopes"`
+
+	// GrantedAt is when the user last authorized the client
+	GrantedAt time.Time `gorm:"not null" json:"granted_at"`
+	// LastUsedAt is when tokens were last issued to the client
+	LastUsedAt time.Time `gorm:"not null" json:"last_used_at"`
+	// RevokedAt is when the user last revoked the grant; tokens issued
+	// before it are rejected even after the client is authorized again
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Active reports whether the client is authorized: the grant was never
+// revoked, or the user authorized the client again since.
+func (g *OIDCGrant) Active() bool {
+	return g.RevokedAt == nil || g.GrantedAt.After(*g.RevokedAt)
+}
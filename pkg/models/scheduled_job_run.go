@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduledJobRun records one execution of a recurring background job run by
+// the job scheduler (see internal/scheduler). It backs the run history shown on
+// the admin GUI Scheduled Jobs page.
+type ScheduledJobRun struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	JobName    string     `gorm:"type:varchar(100);not null;index" json:"job_name"`
+	Trigger    string     `gorm:"type:varchar(20);not null;default:'schedule'" json:"trigger"` // "schedule" or "manual"
+	Status     string     `gorm:"type:varchar(20);not null;index" json:"status"`               // "running", "success" or "failed"
+	Error      string     `gorm:"type:text;not null;default:''" json:"error,omitempty"`        // Error message of a failed run
+	Instance   string     `gorm:"type:varchar(255);not null;default:''" json:"instance"`       // Hostname of the server that ran the job
+	StartedAt  time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `gorm:"not null;default:0" json:"duration_ms"`
+}
+
+// TableName specifies the table name for ScheduledJobRun.
+func (ScheduledJobRun) TableName() string {
+	return "scheduled_job_runs"
+}
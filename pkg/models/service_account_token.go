@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceAccountToken records a long-lived access token issued to a service
+// account. The token itself is a JWT whose ID (jti) is the record's ID; only
+// its metadata is stored, so a token can be listed and revoked but not shown
+// again.
+type ServiceAccountToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AppID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"app_id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"` // The service account
+	Name      string     `gorm:"type:varchar(100);not null" json:"name"`
+	Scopes    string     `gorm:"type:text;not null;default:''" json:"scopes"` // Space-separated, in the token's "scope" claim
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for ServiceAccountToken.
+func (ServiceAccountToken) TableName() string {
+	return "service_account_tokens"
+}
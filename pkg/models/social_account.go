@@ -25,6 +25,7 @@ type SocialAccount struct {
 	AccessToken    string         `json:"-"`                                                                        // Stored encrypted, not exposed via JSON
 	RefreshToken   string         `json:"-"`                                                                        // Stored encrypted, not exposed via JSON
 	ExpiresAt      *time.Time     `json:"expires_at"`
+	LastSyncedAt   *time.Time     `json:"last_synced_at,omitempty"` // Last profile refresh with the stored access token (login, manual or scheduled sync)
 	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -8,9 +8,12 @@ import (
 
 // Tenant represents a customer or organization that owns applications
 type Tenant struct {
-	ID        uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Name      string        `gorm:"not null" json:"name"`
-	CreatedAt time.Time     `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
-	Apps      []Application `gorm:"foreignKey:TenantID" json:"apps"`
+	ID                uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name              string        `gorm:"not null" json:"name"`
+	BillingCustomerID string        `gorm:"type:varchar(255);not null;default:''" json:"billing_customer_id,omitempty"` // External billing customer (e.g. Stripe) usage is reported against
+	MaxApps           int           `gorm:"default:0" json:"max_apps"`                                                  // Application quota (0 = use QUOTA_MAX_APPS_PER_TENANT)
+	ExternalID        *string       `gorm:"type:varchar(255);uniqueIndex" json:"external_id,omitempty"`                 // Caller-assigned ID for declarative upserts (NULL = not managed)
+	CreatedAt         time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+	Apps              []Application `gorm:"foreignKey:TenantID" json:"apps"`
 }
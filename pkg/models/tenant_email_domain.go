@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sending domain verification statuses
+const (
+	EmailDomainStatusPending  = "pending"  // Registered, verification record not checked yet
+	EmailDomainStatusVerified = "verified" // The verification record was found
+	EmailDomainStatusFailed   = "failed"   // The last check did not find the verification record
+)
+
+// TenantEmailDomain is a domain a tenant's applications may send email from.
+// Once a tenant has registered a domain, the From addresses of its
+// applications' SMTP configs must use a verified one. Domains are stored
+// lowercased; subdomains of a verified domain are allowed too.
+type TenantEmailDomain struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID          uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_email_domains_tenant_domain" json:"tenant_id"`
+	Domain            string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_tenant_email_domains_tenant_domain" json:"domain"`
+	Status            string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"` // One of the EmailDomainStatus* values
+	VerificationToken string     `gorm:"type:varchar(64);not null" json:"verification_token"`       // Published as a TXT record to prove control
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	LastCheckedAt     *time.Time `json:"last_checked_at,omitempty"`
+	LastError         string     `gorm:"type:text;not null;default:''" json:"last_error,omitempty"` // Why the last check failed
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for TenantEmailDomain.
+func (TenantEmailDomain) TableName() string {
+	return "tenant_email_domains"
+}
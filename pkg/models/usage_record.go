@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UsageRecord holds a monthly usage aggregate for one billable metric of an
+// application. One row is maintained per (app_id, period, metric) using upsert
+// semantics; ReportedQuantity tracks how much of Quantity has already been
+// pushed to the billing reporter so only deltas are sent.
+type UsageRecord struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	AppID            uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_usage_records_app_period_metric" json:"app_id"`
+	TenantID         uuid.UUID `gorm:"type:uuid;not null;index:idx_usage_records_tenant_period" json:"tenant_id"`
+	Period           string    `gorm:"type:varchar(7);not null;uniqueIndex:idx_usage_records_app_period_metric;index:idx_usage_records_tenant_period" json:"period"` // Month bucket (YYYY-MM)
+	Metric           string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_usage_records_app_period_metric" json:"metric"`
+	Quantity         int64     `gorm:"not null;default:0" json:"quantity"`
+	ReportedQuantity int64     `gorm:"not null;default:0" json:"reported_quantity"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for UsageRecord.
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
@@ -37,9 +37,32 @@ type User struct {
 	LockReason    string     `gorm:"type:varchar(255);default:''" json:"lock_reason,omitempty"` // Reason for lockout (e.g., "Too many failed login attempts")
 	LockExpiresAt *time.Time `gorm:"" json:"lock_expires_at,omitempty"`                         // When the lockout expires (nil = permanent until admin unlock)
 	// Password history and expiry tracking
-	PasswordHistory   datatypes.JSON  `gorm:"type:jsonb;default:'[]'" json:"-"`      // Array of previous bcrypt hashes (for history enforcement)
-	PasswordChangedAt *time.Time      `gorm:"" json:"password_changed_at,omitempty"` // When the password was last changed (nil = never changed)
-	CreatedAt         time.Time       `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt         time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
-	SocialAccounts    []SocialAccount `gorm:"foreignKey:UserID" json:"social_accounts"` // One-to-many relationship
+	PasswordHistory   datatypes.JSON `gorm:"type:jsonb;default:'[]'" json:"-"`      // Array of previous bcrypt hashes (for history enforcement)
+	PasswordChangedAt *time.Time     `gorm:"" json:"password_changed_at,omitempty"` // When the password was last changed (nil = never changed)
+	// Notification preferences for optional email categories (transactional email is always sent)
+	NotifySecurityAlerts bool            `gorm:"not null;default:true" json:"notify_security_alerts"`
+	NotifyProductEmails  bool            `gorm:"not null;default:true" json:"notify_product_emails"`
+	CreatedAt            time.Time       `gorm:"autoCreateTime" json:"created_at"` // Keyset indexes with id (and app_id) are built concurrently by migrations, not AutoMigrate
+	UpdatedAt            time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	SocialAccounts       []SocialAccount `gorm:"foreignKey:UserID" json:"social_accounts"` // One-to-many relationship
+	// Sign-up approval for applications with RegistrationApprovalRequired: "" (not moderated), "pending", "approved" or "rejected"
+	ApprovalStatus string `gorm:"type:varchar(20);not null;default:'';index" json:"approval_status,omitempty"`
+	// Groups assigned by the claim mappings of the identity provider the user last signed in with
+	Groups datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"groups,omitempty"`
+	// Service accounts are non-human users: they cannot sign in interactively, only use
+	// the tokens issued to them, and are left out of user lists and active-user counts.
+	// Indexed by the partial index idx_users_service_accounts of the SQL migration
+	IsServiceAccount bool `gorm:"not null;default:false" json:"is_service_account,omitempty"`
 }
+
+// ServiceAccountIDsSQL selects the IDs of all service accounts, for excluding
+// them from active-user counts, e.g. "user_id NOT IN (" + ServiceAccountIDsSQL + ")".
+const ServiceAccountIDsSQL = "SELECT id FROM users WHERE is_service_account"
+
+// User approval statuses (User.ApprovalStatus). Pending and rejected users are
+// inactive and cannot sign in.
+const (
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved"
+	ApprovalStatusRejected = "rejected"
+)
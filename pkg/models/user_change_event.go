@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// UserChangeEvent is a pending user change in the outbox relayed to downstream
+// systems (USER_SYNC_ENABLED). Rows are written by a trigger on the users
+// table, or by a backfill, and deleted once relayed.
+type UserChangeEvent struct {
+	ID        int64          `gorm:"primaryKey;autoIncrement" json:"id"`         // Sequence number; increases with each change of a user
+	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`          //
+	AppID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"app_id"`     //
+	Operation string         `gorm:"type:varchar(10);not null" json:"operation"` // created | updated | deleted | snapshot
+	Snapshot  datatypes.JSON `gorm:"type:jsonb" json:"snapshot,omitempty"`       // IDs of a deleted user and when it was deleted
+	CreatedAt time.Time      `gorm:"not null;default:now()" json:"created_at"`   // When the change was made
+}
+
+// TableName specifies the table name for UserChangeEvent.
+func (UserChangeEvent) TableName() string {
+	return "user_change_events"
+}
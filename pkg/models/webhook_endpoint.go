@@ -36,4 +36,8 @@ var ValidEventTypes = []string{
 	"2fa.disabled",
 	"social.linked",
 	"social.unlinked",
+	"user.created",  // User sync (USER_SYNC_ENABLED): any user insert
+	"user.updated",  // User sync: any user update
+	"user.deleted",  // User sync: any user delete
+	"user.snapshot", // User sync: backfill of an application's users
 }
@@ -0,0 +1,54 @@
+package optlock
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGuardCheck(t *testing.T) {
+	updated := time.Date(2026, 10, 16, 9, 30, 15, 123456000, time.UTC)
+	before := updated.Add(-time.Minute)
+	sameSecond := updated.Truncate(time.Second)
+	sameInstantOtherZone := updated.In(time.FixedZone("CEST", 2*3600))
+
+	tests := []struct {
+		name     string
+		guard    Guard
+		conflict bool
+	}{
+		{"zero guard", Guard{}, false},
+		{"version matches", Guard{Version: &updated}, false},
+		{"version matches in another zone", Guard{Version: &sameInstantOtherZone}, false},
+		{"version is stale", Guard{Version: &before}, true},
+		{"unmodified since the same second", Guard{UnmodifiedSince: &sameSecond}, false},
+		{"modified after the date", Guard{UnmodifiedSince: &before}, true},
+	}
+	for _, tc := range tests {
+		err := tc.guard.Check(updated)
+		if tc.conflict != errors.Is(err, ErrConflict) {
+			t.Errorf("%s: err = %v, want conflict %v", tc.name, err, tc.conflict)
+		}
+	}
+}
+
+func TestVersionRoundTrip(t *testing.T) {
+	updated := time.Date(2026, 10, 16, 9, 30, 15, 123456000, time.FixedZone("CEST", 2*3600))
+	parsed, err := ParseVersion(FormatVersion(updated))
+	if err != nil {
+		t.Fatalf("ParseVersion: %v", err)
+	}
+	if parsed == nil || !parsed.Equal(updated) {
+		t.Fatalf("round trip = %v, want %v", parsed, updated)
+	}
+	if err := (Guard{Version: parsed}).Check(updated); err != nil {
+		t.Errorf("guard from a round-tripped version rejected the same row: %v", err)
+	}
+
+	if v, err := ParseVersion(""); v != nil || err != nil {
+		t.Errorf("ParseVersion(\"\") = %v, %v; want nil, nil", v, err)
+	}
+	if _, err := ParseVersion("yesterday"); err == nil {
+		t.Error("ParseVersion accepted an invalid version")
+	}
+}
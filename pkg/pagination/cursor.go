@@ -0,0 +1,116 @@
+// Package pagination implements opaque keyset (cursor) pagination for lists
+// ordered newest first by a timestamp column with a UUID tie-breaker, and the
+// page, sort and filter parameters of offset-paginated lists (see ListSpec).
+//
+// Unlike OFFSET pagination, the cost of fetching a page does not grow with its
+// depth, and rows inserted while a client is paging do not shift later pages.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned when a cursor string cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor identifies a position in a list ordered by (timestamp DESC, id DESC).
+// Backward cursors fetch the page before the position instead of after it.
+// Clients treat the encoded form as opaque.
+type Cursor struct {
+	Time     time.Time `json:"t"`
+	ID       uuid.UUID `json:"i"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// Page is the cursor metadata returned alongside a keyset-paginated result.
+type Page struct {
+	Limit       int    `json:"limit"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
+	HasNext     bool   `json:"has_next"`
+	HasPrevious bool   `json:"has_previous"`
+}
+
+// Encode returns the opaque, URL-safe form of the cursor.
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode parses a cursor produced by Encode. An empty string decodes to nil
+// (first page) without error.
+func Decode(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Time.IsZero() || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Apply adds the keyset condition, ordering and limit to q. One extra row is
+// requested so Finish can tell whether another page exists. timeCol and idCol
+// must be trusted column expressions (e.g. "activity_logs.timestamp").
+func Apply(q *gorm.DB, timeCol, idCol string, cur *Cursor, limit int) *gorm.DB {
+	if cur == nil {
+		return q.Order(timeCol + " DESC, " + idCol + " DESC").Limit(limit + 1)
+	}
+	if cur.Backward {
+		return q.Where("("+timeCol+", "+idCol+") > (?, ?)", cur.Time, cur.ID).
+			Order(timeCol + " ASC, " + idCol + " ASC").Limit(limit + 1)
+	}
+	return q.Where("("+timeCol+", "+idCol+") < (?, ?)", cur.Time, cur.ID).
+		Order(timeCol + " DESC, " + idCol + " DESC").Limit(limit + 1)
+}
+
+// Finish trims the look-ahead row fetched by Apply, restores newest-first
+// order for backward pages and builds the next/previous cursors. key returns
+// the sort timestamp and ID of a row.
+func Finish[T any](rows []T, cur *Cursor, limit int, key func(T) (time.Time, uuid.UUID)) ([]T, Page) {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+
+	backward := cur != nil && cur.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	// Walking forward, a previous page exists whenever we started from a cursor;
+	// walking backward, a next page always exists (the page we came from).
+	hasNext, hasPrev := more, cur != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+
+	page := Page{Limit: limit}
+	if len(rows) == 0 {
+		return rows, page
+	}
+	if hasNext {
+		t, id := key(rows[len(rows)-1])
+		page.NextCursor = Cursor{Time: t, ID: id}.Encode()
+		page.HasNext = true
+	}
+	if hasPrev {
+		t, id := key(rows[0])
+		page.PrevCursor = Cursor{Time: t, ID: id, Backward: true}.Encode()
+		page.HasPrevious = true
+	}
+	return rows, page
+}
@@ -0,0 +1,96 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type row struct {
+	ts time.Time
+	id uuid.UUID
+}
+
+func rowKey(r row) (time.Time, uuid.UUID) { return r.ts, r.id }
+
+func makeRows(n int) []row {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]row, n)
+	for i := range rows {
+		rows[i] = row{ts: base.Add(-time.Duration(i) * time.Minute), id: uuid.New()}
+	}
+	return rows
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{Time: time.Date(2026, 5, 1, 12, 0, 0, 123456000, time.UTC), ID: uuid.New(), Backward: true}
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !got.Time.Equal(c.Time) || got.ID != c.ID || !got.Backward {
+		t.Errorf("round trip mismatch: got %+v, want %+v", got, c)
+	}
+}
+
+func TestDecodeEmptyAndInvalid(t *testing.T) {
+	if c, err := Decode(""); c != nil || err != nil {
+		t.Errorf("Decode(\"\") = %v, %v; want nil, nil", c, err)
+	}
+	for _, s := range []string{"not base64!", "e30", Cursor{}.Encode()} {
+		if _, err := Decode(s); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestFinishFirstPage(t *testing.T) {
+	rows := makeRows(4) // limit 3 + look-ahead row
+	got, page := Finish(rows, nil, 3, rowKey)
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3", len(got))
+	}
+	if !page.HasNext || page.NextCursor == "" {
+		t.Error("first page with a look-ahead row should have a next cursor")
+	}
+	if page.HasPrevious || page.PrevCursor != "" {
+		t.Error("first page should not have a previous cursor")
+	}
+	next, _ := Decode(page.NextCursor)
+	if next.ID != rows[2].id || next.Backward {
+		t.Errorf("next cursor should point forward from the last row, got %+v", next)
+	}
+}
+
+func TestFinishLastPage(t *testing.T) {
+	rows := makeRows(2)
+	cur := &Cursor{Time: time.Now(), ID: uuid.New()}
+	_, page := Finish(rows, cur, 3, rowKey)
+	if page.HasNext {
+		t.Error("last page should not have a next cursor")
+	}
+	if !page.HasPrevious || page.PrevCursor == "" {
+		t.Error("page reached via a cursor should have a previous cursor")
+	}
+}
+
+func TestFinishBackwardRestoresOrder(t *testing.T) {
+	desc := makeRows(3)
+	// Backward queries return rows oldest first.
+	asc := []row{desc[2], desc[1], desc[0]}
+	cur := &Cursor{Time: time.Now(), ID: uuid.New(), Backward: true}
+
+	got, page := Finish(asc, cur, 3, rowKey)
+	for i := range desc {
+		if got[i].id != desc[i].id {
+			t.Fatalf("row %d out of order after backward fetch", i)
+		}
+	}
+	if !page.HasNext {
+		t.Error("backward page should always have a next cursor")
+	}
+	if page.HasPrevious {
+		t.Error("backward page without a look-ahead row is the first page")
+	}
+}
@@ -0,0 +1,262 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Offset pagination limits shared by the admin list endpoints.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Sort orders
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// FilterKind is how a filter value is matched against its column.
+type FilterKind int
+
+const (
+	FilterExact    FilterKind = iota // column = value
+	FilterContains                   // case-insensitive substring match
+	FilterUUID                       // column = value; the value must be a UUID
+	FilterBool                       // column = value; the value must be true or false
+	FilterInt                        // column = value; the value must be an integer
+)
+
+// Filter is a column a list can be filtered by.
+type Filter struct {
+	Column string // Trusted column expression, e.g. "tenants.name"
+	Kind   FilterKind
+}
+
+// ListSpec declares what an offset-paginated list can be sorted and filtered
+// by. Clients pick sort fields and filters by name only, so the column
+// expressions are never taken from the request.
+type ListSpec struct {
+	DefaultPageSize int               // 0 = DefaultPageSize
+	Sorts           map[string]string // Sort field name -> column expression
+	DefaultSort     string            // One of Sorts
+	DefaultOrder    string            // OrderAsc or OrderDesc
+	TieBreaker      string            // Columns appended to the ORDER BY to make it unique so pages are stable, e.g. "tenants.id"
+	Filters         map[string]Filter // Filter name -> column
+}
+
+// ListQuery is a validated page, sort and filter selection for a ListSpec.
+type ListQuery struct {
+	Page     int
+	PageSize int
+	Sort     string            // Sort field name
+	Order    string            // OrderAsc or OrderDesc
+	Filters  map[string]string // Filter name -> value
+	spec     *ListSpec
+}
+
+// Query returns page of the list in its default order, unfiltered.
+func (s *ListSpec) Query(page, pageSize int) ListQuery {
+	q := ListQuery{Page: page, PageSize: pageSize, Sort: s.DefaultSort, Order: s.DefaultOrder, spec: s}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = s.pageSize()
+	}
+	return q
+}
+
+func (s *ListSpec) pageSize() int {
+	if s.DefaultPageSize > 0 {
+		return s.DefaultPageSize
+	}
+	return DefaultPageSize
+}
+
+// ParseListQuery reads page, page_size, sort, order and filter[name] from the
+// query string. A missing or invalid page or page size falls back to the
+// default and page sizes above MaxPageSize are capped. Unknown sort fields and
+// filters and invalid filter values are errors. A filter may also be passed as
+// a plain parameter (e.g. app_id=... for filter[app_id]=...).
+func ParseListQuery(query url.Values, spec *ListSpec) (ListQuery, error) {
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	q := spec.Query(page, pageSize)
+
+	if field := query.Get("sort"); field != "" {
+		// "-field" is shorthand for sort=field&order=desc
+		if strings.HasPrefix(field, "-") {
+			field = field[1:]
+			q.Order = OrderDesc
+		} else if query.Get("order") == "" {
+			q.Order = OrderAsc
+		}
+		if _, ok := spec.Sorts[field]; !ok {
+			return ListQuery{}, fmt.Errorf("invalid sort field %q (allowed: %s)", field, strings.Join(names(spec.Sorts), ", "))
+		}
+		q.Sort = field
+	}
+	if order := strings.ToLower(query.Get("order")); order != "" {
+		if order != OrderAsc && order != OrderDesc {
+			return ListQuery{}, fmt.Errorf("invalid order %q (allowed: asc, desc)", order)
+		}
+		q.Order = order
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		name := key[len("filter[") : len(key)-1]
+		if _, ok := spec.Filters[name]; !ok {
+			return ListQuery{}, fmt.Errorf("invalid filter %q (allowed: %s)", name, strings.Join(names(spec.Filters), ", "))
+		}
+		if err := q.setFilter(name, values[0]); err != nil {
+			return ListQuery{}, err
+		}
+	}
+	for name := range spec.Filters {
+		if _, set := q.Filters[name]; set {
+			continue
+		}
+		if value := query.Get(name); value != "" {
+			if err := q.setFilter(name, value); err != nil {
+				return ListQuery{}, err
+			}
+		}
+	}
+	return q, nil
+}
+
+// ParseCursorListQuery reads the filter[name] parameters of a cursor-paginated
+// list. Its cursors fix the order to the spec's default, so a sort or order
+// selecting any other order is an error.
+func ParseCursorListQuery(query url.Values, spec *ListSpec) (ListQuery, error) {
+	q, err := ParseListQuery(query, spec)
+	if err != nil {
+		return ListQuery{}, err
+	}
+	if q.Sort != spec.DefaultSort || q.Order != spec.DefaultOrder {
+		return ListQuery{}, fmt.Errorf("cursor pagination is ordered by %s %s; sort and order cannot change it", spec.DefaultSort, spec.DefaultOrder)
+	}
+	return q, nil
+}
+
+func (q *ListQuery) setFilter(name, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	switch q.spec.Filters[name].Kind {
+	case FilterUUID:
+		if _, err := uuid.Parse(value); err != nil {
+			return fmt.Errorf("invalid filter %q: %q is not a UUID", name, value)
+		}
+	case FilterBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid filter %q: %q is not true or false", name, value)
+		}
+		value = strconv.FormatBool(b)
+	case FilterInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("invalid filter %q: %q is not an integer", name, value)
+		}
+	}
+	if q.Filters == nil {
+		q.Filters = map[string]string{}
+	}
+	q.Filters[name] = value
+	return nil
+}
+
+// WithFilter returns a copy of q with a filter set by the server, e.g. from a
+// path parameter. name must be one of the spec's filters.
+func (q ListQuery) WithFilter(name, value string) ListQuery {
+	filters := make(map[string]string, len(q.Filters)+1)
+	for k, v := range q.Filters {
+		filters[k] = v
+	}
+	filters[name] = value
+	q.Filters = filters
+	return q
+}
+
+// Filter returns the value of a filter ("" when it is not set).
+func (q ListQuery) Filter(name string) string {
+	return q.Filters[name]
+}
+
+// ApplyFilters adds the filter conditions to db.
+func (q ListQuery) ApplyFilters(db *gorm.DB) *gorm.DB {
+	for _, name := range names(q.Filters) {
+		f, value := q.spec.Filters[name], q.Filters[name]
+		switch f.Kind {
+		case FilterContains:
+			db = db.Where(f.Column+" ILIKE ?", "%"+likeEscaper.Replace(value)+"%")
+		case FilterBool:
+			db = db.Where(f.Column+" = ?", value == "true")
+		case FilterInt:
+			n, _ := strconv.Atoi(value)
+			db = db.Where(f.Column+" = ?", n)
+		default:
+			db = db.Where(f.Column+" = ?", value)
+		}
+	}
+	return db
+}
+
+// likeEscaper escapes the LIKE wildcards of a filter value.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ApplyPage adds the ordering, offset and limit of the page to db.
+func (q ListQuery) ApplyPage(db *gorm.DB) *gorm.DB {
+	dir := " " + strings.ToUpper(q.Order)
+	order := q.spec.Sorts[q.Sort] + dir
+	if q.spec.TieBreaker != "" {
+		for _, col := range strings.Split(q.spec.TieBreaker, ",") {
+			order += ", " + strings.TrimSpace(col) + dir
+		}
+	}
+	return db.Order(order).Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize)
+}
+
+// TotalPages returns the number of pages for total rows.
+func (q ListQuery) TotalPages(total int64) int64 {
+	return (total + int64(q.PageSize) - 1) / int64(q.PageSize)
+}
+
+// Response returns the body of a list response: the page of data with the
+// total count and the effective page, page size and sort.
+func (q ListQuery) Response(data interface{}, total int64) map[string]interface{} {
+	return map[string]interface{}{
+		"data":        data,
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"total_pages": q.TotalPages(total),
+		"sort":        q.Sort,
+		"order":       q.Order,
+	}
+}
+
+// names returns the sorted keys of m.
+func names[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
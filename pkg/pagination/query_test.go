@@ -0,0 +1,158 @@
+package pagination
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var testSpec = &ListSpec{
+	DefaultPageSize: 10,
+	Sorts:           map[string]string{"name": "items.name", "created_at": "items.created_at"},
+	DefaultSort:     "created_at",
+	DefaultOrder:    OrderDesc,
+	TieBreaker:      "items.id",
+	Filters: map[string]Filter{
+		"name":      {Column: "items.name", Kind: FilterContains},
+		"owner_id":  {Column: "items.owner_id", Kind: FilterUUID},
+		"is_active": {Column: "items.is_active", Kind: FilterBool},
+		"count":     {Column: "items.count", Kind: FilterInt},
+	},
+}
+
+func parse(t *testing.T, raw string) (ListQuery, error) {
+	t.Helper()
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q): %v", raw, err)
+	}
+	return ParseListQuery(values, testSpec)
+}
+
+func TestParseListQueryDefaults(t *testing.T) {
+	q, err := parse(t, "page=0&page_size=500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Page != 1 || q.PageSize != MaxPageSize || q.Sort != "created_at" || q.Order != OrderDesc || len(q.Filters) != 0 {
+		t.Errorf("got %+v", q)
+	}
+
+	q, _ = parse(t, "")
+	if q.PageSize != 10 {
+		t.Errorf("PageSize = %d, want the spec default 10", q.PageSize)
+	}
+}
+
+func TestParseListQuerySort(t *testing.T) {
+	tests := []struct {
+		raw       string
+		sort      string
+		order     string
+		wantError bool
+	}{
+		{"sort=name", "name", OrderAsc, false},
+		{"sort=-name", "name", OrderDesc, false},
+		{"sort=name&order=DESC", "name", OrderDesc, false},
+		{"order=asc", "created_at", OrderAsc, false},
+		{"sort=password", "", "", true},
+		{"sort=name&order=sideways", "", "", true},
+	}
+	for _, tt := range tests {
+		q, err := parse(t, tt.raw)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.raw, err)
+			continue
+		}
+		if q.Sort != tt.sort || q.Order != tt.order {
+			t.Errorf("%s: sort %s %s, want %s %s", tt.raw, q.Sort, q.Order, tt.sort, tt.order)
+		}
+	}
+}
+
+func TestParseListQueryFilters(t *testing.T) {
+	q, err := parse(t, "filter[name]=ja&filter[is_active]=1&count=3&owner_id=6f1c1a2e-8f43-4c7e-9a57-0c3f3c1b2a11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"name": "ja", "is_active": "true", "count": "3", "owner_id": "6f1c1a2e-8f43-4c7e-9a57-0c3f3c1b2a11"}
+	for k, v := range want {
+		if q.Filter(k) != v {
+			t.Errorf("filter %s = %q, want %q", k, q.Filter(k), v)
+		}
+	}
+
+	for _, raw := range []string{"filter[password]=x", "filter[owner_id]=nope", "filter[is_active]=maybe", "count=many"} {
+		if _, err := parse(t, raw); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
+
+func TestParseCursorListQuery(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"":                           true,
+		"sort=-created_at":           true,
+		"order=desc&filter[count]=3": true,
+		"sort=name":                  false,
+		"sort=created_at":            false, // ascending
+		"order=asc":                  false,
+		"filter[unknown]=x":          false,
+	} {
+		values, _ := url.ParseQuery(raw)
+		_, err := ParseCursorListQuery(values, testSpec)
+		if (err == nil) != ok {
+			t.Errorf("ParseCursorListQuery(%q) error = %v, want ok %v", raw, err, ok)
+		}
+	}
+}
+
+func TestWithFilterCopies(t *testing.T) {
+	q, _ := parse(t, "filter[name]=ja")
+	scoped := q.WithFilter("owner_id", "6f1c1a2e-8f43-4c7e-9a57-0c3f3c1b2a11")
+	if q.Filter("owner_id") != "" {
+		t.Error("WithFilter modified the original query")
+	}
+	if scoped.Filter("name") != "ja" || scoped.Filter("owner_id") == "" {
+		t.Errorf("got %v", scoped.Filters)
+	}
+}
+
+func TestListQuerySQL(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	q, _ := parse(t, "page=3&page_size=5&sort=name&filter[name]=50%25_off&filter[is_active]=false")
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return q.ApplyPage(q.ApplyFilters(tx.Table("items"))).Find(&[]map[string]interface{}{})
+	})
+	for _, want := range []string{
+		`items.is_active = false`,
+		`items.name ILIKE '%50\%\_off%'`,
+		`ORDER BY items.name ASC, items.id ASC`,
+		`LIMIT 5 OFFSET 10`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("query %s\nmissing %s", sql, want)
+		}
+	}
+}
+
+func TestResponse(t *testing.T) {
+	q := testSpec.Query(2, 10)
+	resp := q.Response([]string{}, 21)
+	if resp["total_pages"] != int64(3) || resp["page"] != 2 || resp["sort"] != "created_at" || resp["order"] != OrderDesc {
+		t.Errorf("got %v", resp)
+	}
+}
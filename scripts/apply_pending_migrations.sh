@@ -26,23 +26,51 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 # We use || true to handle cases where the table might not exist yet (though step 1 covers this)
 APPLIED=$(docker exec -i $DB_CONTAINER psql -U $DB_USER -d $DB_NAME -t -c "SELECT version FROM schema_migrations" 2>/dev/null || echo "")
 
-# 3. Iterate over all .sql files in migrations directory, sorted by name
+# 3. Collect the pending .sql files in the migrations directory, sorted by name
 # using sort to ensure 00_ runs before 2024_ runs before 2026_
+PENDING=()
 for file in $(ls migrations/*.sql | sort); do
     # Skip rollback files
     if [[ $file == *"_rollback.sql" ]]; then
         continue
     fi
     
-    filename=$(basename "$file" .sql)
-    version="$filename"
+    version=$(basename "$file" .sql)
     
-    # 4. Check if this version is in the APPLIED list
+    # Check if this version is in the APPLIED list
     if echo "$APPLIED" | grep -q "$version"; then
         # echo "Skipping $version (already applied)"
         continue
     fi
     
+    PENDING+=("$file")
+done
+
+if [ ${#PENDING[@]} -eq 0 ]; then
+    echo "All migrations up to date."
+    exit 0
+fi
+
+# 4. Check the pending migrations with the migration guard. Tables whose
+# planner row estimate reaches LARGE_TABLE_ROWS are passed as large, so
+# locking operations on them fail the check.
+LARGE_TABLES=$(docker exec -i $DB_CONTAINER psql -U $DB_USER -d $DB_NAME -t -A -c "
+    SELECT COALESCE(string_agg(c.relname, ','), '') FROM pg_class c
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE c.relkind IN ('r', 'p') AND n.nspname = 'public'
+      AND c.reltuples >= ${LARGE_TABLE_ROWS:-100000};")
+
+if ! go run ./cmd/setup -lint-migrations -large-tables "$LARGE_TABLES" "${PENDING[@]}"; then
+    if [ "$ALLOW_UNSAFE_MIGRATIONS" != "true" ]; then
+        echo "❌ Pending migrations would lock large tables; rewrite them or rerun with ALLOW_UNSAFE_MIGRATIONS=true"
+        exit 1
+    fi
+    echo "⚠️  Applying despite migration guard errors (ALLOW_UNSAFE_MIGRATIONS=true)"
+fi
+
+for file in "${PENDING[@]}"; do
+    version=$(basename "$file" .sql)
+    
     echo "Applying migration: $version"
     
     # 5. Run the migration
@@ -55,6 +55,30 @@ execute_sql_file() {
     echo ""
 }
 
+# Function to check a migration file with the migration guard before it is
+# applied. Tables whose planner row estimate reaches LARGE_TABLE_ROWS are
+# passed as large; set ALLOW_UNSAFE_MIGRATIONS=true to apply anyway.
+lint_migration_file() {
+    local file="$1"
+    local large_tables
+    
+    large_tables=$(PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" -t -A -c "
+        SELECT COALESCE(string_agg(c.relname, ','), '') FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        WHERE c.relkind IN ('r', 'p') AND n.nspname = 'public'
+          AND c.reltuples >= ${LARGE_TABLE_ROWS:-100000};")
+    
+    if go run ./cmd/setup -lint-migrations -large-tables "$large_tables" "$file"; then
+        return 0
+    fi
+    if [[ "$ALLOW_UNSAFE_MIGRATIONS" == "true" ]]; then
+        echo -e "${YELLOW}Applying despite migration guard errors (ALLOW_UNSAFE_MIGRATIONS=true)${NC}"
+        return 0
+    fi
+    echo -e "${RED}Migration would lock large tables; rewrite it or rerun with ALLOW_UNSAFE_MIGRATIONS=true${NC}"
+    return 1
+}
+
 # Function to confirm action
 confirm() {
     local prompt="$1"
@@ -91,6 +115,10 @@ apply_mt_migration() {
         return
     fi
     
+    if ! lint_migration_file "$migration_file"; then
+        return
+    fi
+    
     execute_sql_file "$migration_file" "Applying migration..."
 }
 
@@ -196,7 +224,7 @@ case $choice in
         echo "- Update existing logs with defaults"
         echo ""
         
-        if confirm "Apply migration?"; then
+        if confirm "Apply migration?" && lint_migration_file "migrations/20240103_add_activity_log_smart_fields.sql"; then
             echo -e "${GREEN}Creating backup first...${NC}"
             timestamp=$(date +%Y%m%d_%H%M%S)
             backup_file="backup_before_smart_logging_${timestamp}.sql"
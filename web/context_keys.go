@@ -28,6 +28,10 @@ const (
 	// GUISessionIDKey is the Gin context key for the current session ID.
 	GUISessionIDKey = "admin_session_id"
 
+	// GUIPreferencesKey is the Gin context key for the authenticated admin's
+	// display preferences (web.Preferences). Set by GUIPreferencesMiddleware.
+	GUIPreferencesKey = "admin_preferences"
+
 	// CSRFTokenKey is the Gin context key where the CSRF token is stored for templates.
 	CSRFTokenKey = "csrf_token"
 
@@ -49,6 +53,10 @@ const (
 	// ApiKeyScopesKey is the Gin context key for the scopes granted by the validated API key.
 	// Value is []string; set by AppApiKeyMiddleware and AdminAuthMiddleware after successful validation.
 	ApiKeyScopesKey = "api_key_scopes" // #nosec G101 -- context key string, not a credential
+
+	// ApiKeyIDKey is the Gin context key for the ID (uuid.UUID) of the database API key
+	// that authenticated the request. Not set for the static ADMIN_API_KEY.
+	ApiKeyIDKey = "api_key_id" // #nosec G101 -- context key string, not a credential
 )
 
 // SessionValidator is the interface used by GUI middleware to validate sessions
@@ -91,8 +99,16 @@ func ClearSessionCookie(c *gin.Context) {
 	})
 }
 
-// IsSecureCookie returns true if the request is over HTTPS (Secure flag for cookies).
+// ForceSecureCookies reports whether cookies always get the Secure flag
+// (COOKIE_FORCE_SECURE setting). It is set in main; nil means never forced.
+var ForceSecureCookies func() bool
+
+// IsSecureCookie returns true if the request is over HTTPS (Secure flag for
+// cookies), or if secure cookies are forced.
 func IsSecureCookie(c *gin.Context) bool {
+	if ForceSecureCookies != nil && ForceSecureCookies() {
+		return true
+	}
 	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
 }
 
@@ -104,9 +120,13 @@ func IsSecureCookie(c *gin.Context) bool {
 // The function is nil until the middleware package's init() registers it.
 var ClearRateLimitFallback func(keyPrefix, identifier string)
 
-// GetTheme reads the gui_theme cookie and returns "dark" or "light" (default).
+// GetTheme returns the admin's saved theme preference, falling back to the
+// gui_theme cookie, as "dark" or "light" (default).
 // Used by GUI handlers to populate TemplateData.Theme for server-side theme injection.
 func GetTheme(c *gin.Context) string {
+	if theme := GetPreferences(c).Theme; theme != "" {
+		return theme
+	}
 	theme, err := c.Cookie(ThemeCookieName)
 	if err != nil || theme != "dark" {
 		return "light"
@@ -114,6 +134,19 @@ func GetTheme(c *gin.Context) string {
 	return "dark"
 }
 
+// SetThemeCookie sets the gui_theme cookie, which the base layout reads before
+// CSS loads. It is not HttpOnly because the theme toggle updates it from JS.
+func SetThemeCookie(c *gin.Context, theme string) {
+	http.SetCookie(c.Writer, &http.Cookie{ // #nosec G124 -- Non-sensitive UI preference that must be readable by JS
+		Name:     ThemeCookieName,
+		Value:    theme,
+		Path:     "/gui",
+		MaxAge:   365 * 24 * 60 * 60,
+		Secure:   IsSecureCookie(c),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
 // ApiKeyValidator is the interface used by admin/app API key middleware to validate keys
 // against hashed keys stored in the database. Implemented by admin.Repository.
 type ApiKeyValidator interface {
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the burst of events an editor save produces
+// (write, chmod, rename of a swap file) into a single reload.
+const reloadDebounce = 100 * time.Millisecond
+
+// templateDirs are the directories under templates/ that hold .tmpl files.
+var templateDirs = []string{"layouts", "partials", "pages"}
+
+// templateWatcher reloads a Renderer whenever a template file changes on disk.
+type templateWatcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewDevRenderer creates a Renderer for GUI development (GUI_DEV_MODE). It
+// parses the templates from webDir/templates on disk instead of the embedded
+// copy, and re-parses them whenever a .tmpl file there changes, so template
+// edits show up on the next request without a restart. A reload that fails
+// to parse is logged and the previous templates stay in use.
+// Call Close to stop watching.
+func NewDevRenderer(webDir string) (*Renderer, error) {
+	r, err := newRenderer(os.DirFS(webDir))
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch templates: %w", err)
+	}
+	for _, dir := range templateDirs {
+		path := filepath.Join(webDir, "templates", dir)
+		if err := watcher.Add(path); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+	}
+
+	r.watcher = &templateWatcher{watcher: watcher, done: make(chan struct{})}
+	go r.watchTemplates()
+	return r, nil
+}
+
+// Close stops reloading templates. It is a no-op for the embedded renderer.
+func (r *Renderer) Close() error {
+	if r.watcher == nil {
+		return nil
+	}
+	err := r.watcher.watcher.Close()
+	<-r.watcher.done
+	return err
+}
+
+// watchTemplates re-parses the templates after each burst of .tmpl changes
+// until the watcher is closed.
+func (r *Renderer) watchTemplates() {
+	defer close(r.watcher.done)
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case event, ok := <-r.watcher.watcher.Events:
+			if !ok {
+				return
+			}
+			if strings.HasSuffix(event.Name, ".tmpl") && !event.Has(fsnotify.Chmod) {
+				pending = time.After(reloadDebounce)
+			}
+		case err, ok := <-r.watcher.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("GUI template watcher error: %v", err)
+		case <-pending:
+			pending = nil
+			if err := r.parseTemplates(); err != nil {
+				log.Printf("GUI templates not reloaded: %v", err)
+				continue
+			}
+			log.Println("GUI templates reloaded")
+		}
+	}
+}
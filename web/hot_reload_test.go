@@ -0,0 +1,86 @@
+package web
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTemplate(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func renderString(t *testing.T, r *Renderer, name string) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := r.Instance(name, nil).Render(w); err != nil {
+		t.Fatalf("render %s: %v", name, err)
+	}
+	return w.Body.String()
+}
+
+func TestDevRendererReloadsChangedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "templates", "layouts", "base.tmpl"), `{{define "base"}}base{{end}}`)
+	writeTemplate(t, filepath.Join(dir, "templates", "partials", "greeting.tmpl"), `{{define "greeting"}}hello{{end}}`)
+	writeTemplate(t, filepath.Join(dir, "templates", "pages", "home.tmpl"), `{{define "home"}}{{template "greeting"}} v1{{end}}`)
+	writeTemplate(t, filepath.Join(dir, "locales", "en.json"), `{}`)
+	writeTemplate(t, filepath.Join(dir, "locales", "de.json"), `{}`)
+
+	r, err := NewDevRenderer(dir)
+	if err != nil {
+		t.Fatalf("NewDevRenderer: %v", err)
+	}
+	defer r.Close()
+
+	if got := renderString(t, r, "home"); got != "hello v1" {
+		t.Fatalf("initial render = %q, want %q", got, "hello v1")
+	}
+
+	waitFor := func(name, want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			got := renderString(t, r, name)
+			if got == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("render %s = %q, want %q after reload", name, got, want)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	writeTemplate(t, filepath.Join(dir, "templates", "pages", "home.tmpl"), `{{define "home"}}{{template "greeting"}} v2{{end}}`)
+	waitFor("home", "hello v2")
+
+	// A partial change is picked up by the pages that include it
+	writeTemplate(t, filepath.Join(dir, "templates", "partials", "greeting.tmpl"), `{{define "greeting"}}hi{{end}}`)
+	waitFor("home", "hi v2")
+
+	// A template that does not parse keeps the previous set in use
+	writeTemplate(t, filepath.Join(dir, "templates", "pages", "home.tmpl"), `{{define "home"}}{{if}}{{end}}`)
+	time.Sleep(4 * reloadDebounce)
+	if got := renderString(t, r, "home"); got != "hi v2" {
+		t.Errorf("render after a broken edit = %q, want the previous %q", got, "hi v2")
+	}
+}
+
+func TestEmbeddedRendererCloseIsNoop(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Close = %v, want nil", err)
+	}
+}
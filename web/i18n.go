@@ -0,0 +1,74 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+)
+
+// DefaultLanguage is the GUI language used when an admin has not chosen one.
+// Its catalog is the fallback for messages missing from other catalogs.
+const DefaultLanguage = "en"
+
+// Language is a GUI language with a message catalog in locales/<Code>.json.
+type Language struct {
+	Code string
+	Name string // In the language itself, as shown in the language picker
+}
+
+// Languages are the GUI languages an admin can choose. Every language must
+// have a catalog; the renderer fails to start if one is missing or invalid.
+var Languages = []Language{
+	{Code: "en", Name: "English"},
+	{Code: "de", Name: "Deutsch"},
+}
+
+// ValidLanguage reports whether code can be saved as a preference ("" clears it).
+func ValidLanguage(code string) bool {
+	if code == "" {
+		return true
+	}
+	for _, l := range Languages {
+		if l.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// catalogs maps a language code to its messages, keyed by message ID.
+type catalogs map[string]map[string]string
+
+// loadCatalogs reads the message catalog of every language from the
+// locales/ directory of fsys.
+func loadCatalogs(fsys fs.FS) (catalogs, error) {
+	out := make(catalogs, len(Languages))
+	for _, l := range Languages {
+		path := "locales/" + l.Code + ".json"
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message catalog: %w", err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("failed to parse message catalog %s: %w", path, err)
+		}
+		out[l.Code] = messages
+	}
+	return out, nil
+}
+
+// translate returns the message for id in lang, falling back to the default
+// language and then to id itself. With args, the message is a fmt format.
+func (cs catalogs) translate(lang, id string, args ...interface{}) string {
+	msg, ok := cs[lang][id]
+	if !ok {
+		if msg, ok = cs[DefaultLanguage][id]; !ok {
+			msg = id
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"io/fs"
+	"regexp"
+	"testing"
+)
+
+func TestCatalogsHaveTheSameMessages(t *testing.T) {
+	cs, err := loadCatalogs(templateFS)
+	if err != nil {
+		t.Fatalf("loadCatalogs: %v", err)
+	}
+	for _, l := range Languages {
+		for id := range cs[DefaultLanguage] {
+			if _, ok := cs[l.Code][id]; !ok {
+				t.Errorf("locales/%s.json is missing %q", l.Code, id)
+			}
+		}
+		for id := range cs[l.Code] {
+			if _, ok := cs[DefaultLanguage][id]; !ok {
+				t.Errorf("locales/%s.json has %q, which is not in the %s catalog", l.Code, id, DefaultLanguage)
+			}
+		}
+	}
+}
+
+// templateMessageID matches the message ID of a {{t "..."}} call.
+var templateMessageID = regexp.MustCompile(`\{\{-?\s*t\s+"([^"]+)"`)
+
+func TestTemplateMessagesExist(t *testing.T) {
+	cs, err := loadCatalogs(templateFS)
+	if err != nil {
+		t.Fatalf("loadCatalogs: %v", err)
+	}
+	err = fs.WalkDir(templateFS, "templates", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(templateFS, path)
+		if err != nil {
+			return err
+		}
+		for _, m := range templateMessageID.FindAllStringSubmatch(string(data), -1) {
+			if _, ok := cs[DefaultLanguage][m[1]]; !ok {
+				t.Errorf("%s uses message %q, which is not in locales/%s.json", path, m[1], DefaultLanguage)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranslateFallsBack(t *testing.T) {
+	cs := catalogs{
+		"en": {"greeting": "Hello", "count": "%d users"},
+		"de": {"greeting": "Hallo"},
+	}
+	tests := []struct {
+		lang, id string
+		args     []interface{}
+		want     string
+	}{
+		{"de", "greeting", nil, "Hallo"},
+		{"de", "count", []interface{}{3}, "3 users"},
+		{"en", "missing", nil, "missing"},
+	}
+	for _, tt := range tests {
+		if got := cs.translate(tt.lang, tt.id, tt.args...); got != tt.want {
+			t.Errorf("translate(%s, %s) = %q, want %q", tt.lang, tt.id, got, tt.want)
+		}
+	}
+}
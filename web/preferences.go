@@ -0,0 +1,219 @@
+package web
+
+import (
+	"html/template"
+	"sync"
+	"time"
+	_ "time/tzdata" // Admin timezones must resolve on images without a zoneinfo database (e.g. alpine)
+
+	"github.com/gin-gonic/gin"
+)
+
+// Preferences are an admin's display settings for the GUI. The zero value
+// renders the GUI as it looks without any saved preferences.
+type Preferences struct {
+	Theme    string // "light" or "dark"; "" = follow the gui_theme cookie
+	PageSize int    // Rows per page in list views; 0 = each list's own default
+	Timezone string // IANA timezone for displayed timestamps; "" = server local time
+	Locale   string // Date format locale code (see Locales); "" = DefaultLocale
+	Language string // GUI language code (see Languages); "" = DefaultLanguage
+}
+
+// PreferencesLoader is the interface used by GUI middleware to load the
+// authenticated admin's preferences. Implemented by admin.ViewPreferenceRepository.
+type PreferencesLoader interface {
+	// LoadPreferences returns the saved preferences of an admin, or the zero
+	// value if none have been saved.
+	LoadPreferences(adminID string) (Preferences, error)
+}
+
+// DefaultLocale is the date format locale used when an admin has not chosen one.
+const DefaultLocale = "en-US"
+
+// PageSizes are the page sizes an admin can choose for list views.
+var PageSizes = []int{10, 25, 50, 100}
+
+// Locale is a selectable date format locale.
+type Locale struct {
+	Code string
+	Name string
+
+	dateLayout         string
+	dateTimeLayout     string
+	dateTimeFullLayout string
+}
+
+// Example formats a fixed date with the locale's date layout, for showing
+// the format next to the locale name.
+func (l Locale) Example() string {
+	return time.Date(2026, time.March, 14, 0, 0, 0, 0, time.UTC).Format(l.dateLayout)
+}
+
+// Locales are the date format locales an admin can choose. The first entry
+// is DefaultLocale and matches the formats used before preferences existed.
+var Locales = []Locale{
+	{Code: "en-US", Name: "English (United States)",
+		dateLayout: "Jan 02, 2006", dateTimeLayout: "Jan 02, 2006 15:04", dateTimeFullLayout: "Jan 02, 2006 15:04:05 MST"},
+	{Code: "en-GB", Name: "English (United Kingdom)",
+		dateLayout: "02 Jan 2006", dateTimeLayout: "02 Jan 2006 15:04", dateTimeFullLayout: "02 Jan 2006 15:04:05 MST"},
+	{Code: "de-DE", Name: "Deutsch (Deutschland)",
+		dateLayout: "02.01.2006", dateTimeLayout: "02.01.2006 15:04", dateTimeFullLayout: "02.01.2006 15:04:05 MST"},
+	{Code: "fr-FR", Name: "Français (France)",
+		dateLayout: "02/01/2006", dateTimeLayout: "02/01/2006 15:04", dateTimeFullLayout: "02/01/2006 15:04:05 MST"},
+	{Code: "ISO", Name: "ISO 8601",
+		dateLayout: "2006-01-02", dateTimeLayout: "2006-01-02 15:04", dateTimeFullLayout: "2006-01-02 15:04:05 MST"},
+}
+
+// Timezones are suggested in the preferences form. Any IANA name that
+// time.LoadLocation accepts can be saved.
+var Timezones = []string{
+	"UTC",
+	"America/Los_Angeles", "America/Denver", "America/Chicago", "America/New_York",
+	"America/Sao_Paulo", "Europe/London", "Europe/Lisbon", "Europe/Paris",
+	"Europe/Berlin", "Europe/Belgrade", "Europe/Athens", "Europe/Moscow",
+	"Africa/Johannesburg", "Asia/Dubai", "Asia/Kolkata", "Asia/Singapore",
+	"Asia/Shanghai", "Asia/Tokyo", "Australia/Sydney", "Pacific/Auckland",
+}
+
+// ValidTheme reports whether theme can be saved as a preference ("" clears it).
+func ValidTheme(theme string) bool {
+	return theme == "" || theme == "light" || theme == "dark"
+}
+
+// ValidPageSize reports whether n can be saved as a preference (0 clears it).
+func ValidPageSize(n int) bool {
+	if n == 0 {
+		return true
+	}
+	for _, size := range PageSizes {
+		if n == size {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidTimezone reports whether name can be saved as a preference ("" clears it).
+func ValidTimezone(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, err := loadLocation(name)
+	return err == nil
+}
+
+// ValidLocale reports whether code can be saved as a preference ("" clears it).
+func ValidLocale(code string) bool {
+	if code == "" {
+		return true
+	}
+	_, ok := findLocale(code)
+	return ok
+}
+
+// Location returns the timezone timestamps are displayed in.
+func (p Preferences) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.Local
+	}
+	loc, err := loadLocation(p.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// locale returns the date format locale, falling back to DefaultLocale.
+func (p Preferences) locale() Locale {
+	if l, ok := findLocale(p.Locale); ok {
+		return l
+	}
+	return Locales[0]
+}
+
+func findLocale(code string) (Locale, bool) {
+	for _, l := range Locales {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Locale{}, false
+}
+
+// locations caches time.LoadLocation results, which read the zoneinfo database.
+var locations sync.Map // name -> *time.Location
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// preferenceFuncs returns the template functions that depend on prefs:
+// date formatting in the admin's timezone and locale (overriding the
+// server-local versions in defaultFuncMap) and message translation.
+func (r *Renderer) preferenceFuncs(prefs Preferences) template.FuncMap {
+	loc := prefs.Location()
+	l := prefs.locale()
+	lang := prefs.Language
+	if lang == "" || !ValidLanguage(lang) {
+		lang = DefaultLanguage
+	}
+	return template.FuncMap{
+		"formatDate": func(t time.Time) string {
+			return t.In(loc).Format(l.dateLayout)
+		},
+		"formatDateTime": func(t time.Time) string {
+			return t.In(loc).Format(l.dateTimeLayout)
+		},
+		"formatDateTimeFull": func(t time.Time) string {
+			return t.In(loc).Format(l.dateTimeFullLayout)
+		},
+
+		// t translates a message ID from locales/<lang>.json; extra arguments
+		// fill the message's fmt verbs: {{t "users.count" .Total}}
+		"t": func(id string, args ...interface{}) string {
+			return r.catalogs.translate(lang, id, args...)
+		},
+		// lang is the current GUI language code, e.g. for <html lang>
+		"lang": func() string {
+			return lang
+		},
+	}
+}
+
+// preferencesWriter carries the admin's preferences from the request to
+// HTMLRender, which gin hands only the response writer.
+type preferencesWriter struct {
+	gin.ResponseWriter
+	prefs Preferences
+}
+
+// SetPreferences stores the admin's preferences for the rest of the request.
+// Templates rendered afterwards format dates in the admin's timezone and locale
+// and translate messages to the admin's language.
+func SetPreferences(c *gin.Context, prefs Preferences) {
+	c.Set(GUIPreferencesKey, prefs)
+	if pw, ok := c.Writer.(*preferencesWriter); ok {
+		pw.prefs = prefs
+		return
+	}
+	c.Writer = &preferencesWriter{ResponseWriter: c.Writer, prefs: prefs}
+}
+
+// GetPreferences returns the preferences set by GUIPreferencesMiddleware,
+// or the zero value if none were loaded.
+func GetPreferences(c *gin.Context) Preferences {
+	if v, ok := c.Get(GUIPreferencesKey); ok {
+		if prefs, ok := v.(Preferences); ok {
+			return prefs
+		}
+	}
+	return Preferences{}
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRenderFormatsDatesWithPreferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRenderer(fstest.MapFS{
+		"templates/pages/stamp.tmpl": {Data: []byte(`{{define "stamp"}}{{formatDateTimeFull .}}|{{formatDate .}}{{end}}`)},
+		"locales/en.json":            {Data: []byte(`{}`)},
+		"locales/de.json":            {Data: []byte(`{}`)},
+	})
+	if err != nil {
+		t.Fatalf("newRenderer: %v", err)
+	}
+	stamp := time.Date(2026, time.March, 14, 23, 30, 0, 0, time.UTC)
+
+	render := func(prefs *Preferences) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		c, engine := gin.CreateTestContext(w)
+		engine.HTMLRender = r
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if prefs != nil {
+			SetPreferences(c, *prefs)
+		}
+		c.HTML(http.StatusOK, "stamp", stamp)
+		return w.Body.String()
+	}
+
+	tests := []struct {
+		name  string
+		prefs *Preferences
+		want  string
+	}{
+		{"no preferences", nil, stamp.Local().Format("Jan 02, 2006 15:04:05 MST") + "|" + stamp.Local().Format("Jan 02, 2006")},
+		{"timezone and locale", &Preferences{Timezone: "Asia/Tokyo", Locale: "de-DE"}, "15.03.2026 08:30:00 JST|15.03.2026"},
+		{"timezone only", &Preferences{Timezone: "UTC"}, "Mar 14, 2026 23:30:00 UTC|Mar 14, 2026"},
+		{"unknown locale falls back", &Preferences{Timezone: "UTC", Locale: "xx"}, "Mar 14, 2026 23:30:00 UTC|Mar 14, 2026"},
+		// Rendering again with the first preferences reuses their cached clone
+		{"no preferences again", &Preferences{}, stamp.Local().Format("Jan 02, 2006 15:04:05 MST") + "|" + stamp.Local().Format("Jan 02, 2006")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(tt.prefs); got != tt.want {
+				t.Errorf("render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetThemePrefersSavedTheme(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.AddCookie(&http.Cookie{Name: ThemeCookieName, Value: "light"})
+
+	if got := GetTheme(c); got != "light" {
+		t.Fatalf("GetTheme without preferences = %q, want light", got)
+	}
+	SetPreferences(c, Preferences{Theme: "dark"})
+	if got := GetTheme(c); got != "dark" {
+		t.Errorf("GetTheme with saved theme = %q, want dark", got)
+	}
+}
+
+func TestPreferenceValidation(t *testing.T) {
+	if !ValidTimezone("") || !ValidTimezone("Europe/Belgrade") || ValidTimezone("Mars/Olympus") {
+		t.Error("ValidTimezone accepts the wrong names")
+	}
+	if !ValidPageSize(0) || !ValidPageSize(50) || ValidPageSize(7) {
+		t.Error("ValidPageSize accepts the wrong sizes")
+	}
+	if !ValidLocale("") || !ValidLocale("en-GB") || ValidLocale("xx") {
+		t.Error("ValidLocale accepts the wrong codes")
+	}
+	if !ValidTheme("") || !ValidTheme("dark") || ValidTheme("blue") {
+		t.Error("ValidTheme accepts the wrong themes")
+	}
+}
@@ -8,14 +8,16 @@ import (
 	"io/fs"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin/render"
+	"github.com/gjovanovicst/auth_api/internal/search"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
-//go:embed templates
+//go:embed templates locales
 var templateFS embed.FS
 
 // TemplateData is the standard data structure passed to all templates.
@@ -45,7 +47,7 @@ type TemplateData struct {
 	TwoFAMethod string // "totp" or "email" — which 2FA method is required
 
 	// Theme is the active UI theme: "light" or "dark".
-	// Read from the gui_theme cookie via web.GetTheme(c).
+	// Read from the admin's preferences or the gui_theme cookie via web.GetTheme(c).
 	Theme string
 
 	// Page-specific data (each page can put arbitrary data here)
@@ -54,17 +56,38 @@ type TemplateData struct {
 
 // Renderer implements gin's render.HTMLRender interface using embedded templates.
 type Renderer struct {
+	fsys     fs.FS // Holds the templates/ and locales/ directories (embedded, or web/ on disk in dev mode)
+	funcMap  template.FuncMap
+	catalogs catalogs // GUI message catalogs, loaded once at startup
+
+	mu        sync.RWMutex // Guards templates and formatted, which dev mode replaces on reload
 	templates map[string]*template.Template
-	funcMap   template.FuncMap
+	formatted map[Preferences]map[string]*template.Template // Per-preference clones of templates; see lookup
+
+	watcher *templateWatcher // nil = templates are never reloaded
 }
 
 // NewRenderer creates a Renderer by parsing all embedded templates.
 // Layout templates are combined with each page template so that
 // {{template "base" .}} works from page templates.
 func NewRenderer() (*Renderer, error) {
+	return newRenderer(templateFS)
+}
+
+// newRenderer creates a Renderer that parses the templates/ directory of fsys
+// and loads the message catalogs from its locales/ directory.
+func newRenderer(fsys fs.FS) (*Renderer, error) {
+	cs, err := loadCatalogs(fsys)
+	if err != nil {
+		return nil, err
+	}
 	r := &Renderer{
-		templates: make(map[string]*template.Template),
-		funcMap:   defaultFuncMap(),
+		fsys:     fsys,
+		funcMap:  defaultFuncMap(),
+		catalogs: cs,
+	}
+	for name, fn := range r.preferenceFuncs(Preferences{}) {
+		r.funcMap[name] = fn
 	}
 
 	if err := r.parseTemplates(); err != nil {
@@ -77,33 +100,62 @@ func NewRenderer() (*Renderer, error) {
 // Instance returns a render.Render for a specific template name and data.
 // This satisfies the render.HTMLRender interface.
 func (r *Renderer) Instance(name string, data interface{}) render.Render {
-	tmpl, ok := r.templates[name]
-	if !ok {
-		// Fallback: return an error render
-		return &HTMLRender{
-			Template: nil,
-			Name:     name,
-			Data:     data,
-		}
-	}
 	return &HTMLRender{
-		Template: tmpl,
 		Name:     name,
 		Data:     data,
+		renderer: r,
+	}
+}
+
+// lookup returns the named template with its date and translation functions
+// bound to the admin's timezone, locale and language. The parsed templates
+// are never executed themselves (html/template cannot clone an executed
+// template); each combination gets its own clone, created on first use.
+func (r *Renderer) lookup(name string, prefs Preferences) (*template.Template, error) {
+	key := Preferences{Timezone: prefs.Timezone, Locale: prefs.Locale, Language: prefs.Language}
+
+	r.mu.RLock()
+	tmpl, ok := r.formatted[key][name]
+	r.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tmpl, ok := r.formatted[key][name]; ok {
+		return tmpl, nil
+	}
+	base, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %q not found", name)
 	}
+	tmpl, err := base.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone template %q: %w", name, err)
+	}
+	tmpl.Funcs(r.preferenceFuncs(key))
+	if r.formatted[key] == nil {
+		r.formatted[key] = make(map[string]*template.Template)
+	}
+	r.formatted[key][name] = tmpl
+	return tmpl, nil
 }
 
-// parseTemplates reads layout and page templates from the embedded FS.
+// parseTemplates reads layout and page templates from the renderer's FS.
 // Each page template is cloned from the layout set so it can use {{template "base" .}}.
+// The parsed set replaces the current one only if every template parses.
 func (r *Renderer) parseTemplates() error {
+	templates := make(map[string]*template.Template)
+
 	// Parse all layout files
-	layoutFiles, err := fs.Glob(templateFS, "templates/layouts/*.tmpl")
+	layoutFiles, err := fs.Glob(r.fsys, "templates/layouts/*.tmpl")
 	if err != nil {
 		return fmt.Errorf("failed to glob layouts: %w", err)
 	}
 
 	// Parse all partial files
-	partialFiles, err := fs.Glob(templateFS, "templates/partials/*.tmpl")
+	partialFiles, err := fs.Glob(r.fsys, "templates/partials/*.tmpl")
 	if err != nil {
 		return fmt.Errorf("failed to glob partials: %w", err)
 	}
@@ -112,7 +164,7 @@ func (r *Renderer) parseTemplates() error {
 	baseFiles := append(layoutFiles, partialFiles...)
 
 	// Parse each page template individually, combined with layouts + partials
-	pageFiles, err := fs.Glob(templateFS, "templates/pages/*.tmpl")
+	pageFiles, err := fs.Glob(r.fsys, "templates/pages/*.tmpl")
 	if err != nil {
 		return fmt.Errorf("failed to glob pages: %w", err)
 	}
@@ -124,12 +176,12 @@ func (r *Renderer) parseTemplates() error {
 
 		// Create a new template set with functions, parse base files + this page file
 		files := append([]string{pageFile}, baseFiles...)
-		tmpl, err := template.New(name).Funcs(r.funcMap).ParseFS(templateFS, files...)
+		tmpl, err := template.New(name).Funcs(r.funcMap).ParseFS(r.fsys, files...)
 		if err != nil {
 			return fmt.Errorf("failed to parse template %q: %w", name, err)
 		}
 
-		r.templates[name] = tmpl
+		templates[name] = tmpl
 	}
 
 	// Register partials as standalone templates for HTMX fragment responses.
@@ -139,14 +191,18 @@ func (r *Renderer) parseTemplates() error {
 		name := strings.TrimPrefix(partialFile, "templates/partials/")
 		name = strings.TrimSuffix(name, ".tmpl")
 
-		tmpl, err := template.New(name).Funcs(r.funcMap).ParseFS(templateFS, partialFiles...)
+		tmpl, err := template.New(name).Funcs(r.funcMap).ParseFS(r.fsys, partialFiles...)
 		if err != nil {
 			return fmt.Errorf("failed to parse partial template %q: %w", name, err)
 		}
 
-		r.templates[name] = tmpl
+		templates[name] = tmpl
 	}
 
+	r.mu.Lock()
+	r.templates = templates
+	r.formatted = make(map[Preferences]map[string]*template.Template)
+	r.mu.Unlock()
 	return nil
 }
 
@@ -259,6 +315,15 @@ func defaultFuncMap() template.FuncMap {
 			return string(runes[:n]) + "…"
 		},
 
+		// highlight escapes s and marks the terms of a search query in it.
+		"highlight": search.Highlight,
+
+		// searchSnippet returns the highlighted part of s around the first
+		// search term it contains, or "" when there is none.
+		"searchSnippet": func(s, q string) template.HTML {
+			return search.Snippet(s, q, 80)
+		},
+
 		// toJSON marshals a value to a JSON string for use in inline <script> blocks.
 		"toJSON": func(v interface{}) template.JS {
 			b, err := json.Marshal(v)
@@ -272,18 +337,25 @@ func defaultFuncMap() template.FuncMap {
 
 // HTMLRender implements gin's render.Render interface for a single template execution.
 type HTMLRender struct {
-	Template *template.Template
-	Name     string
-	Data     interface{}
+	Name string
+	Data interface{}
+
+	renderer *Renderer
 }
 
-// Render writes the template to the response writer.
+// Render writes the template to the response writer. Dates are formatted with
+// the preferences set by SetPreferences, if any.
 func (h *HTMLRender) Render(w http.ResponseWriter) error {
 	h.WriteContentType(w)
-	if h.Template == nil {
-		return fmt.Errorf("template %q not found", h.Name)
+	var prefs Preferences
+	if pw, ok := w.(*preferencesWriter); ok {
+		prefs = pw.prefs
+	}
+	tmpl, err := h.renderer.lookup(h.Name, prefs)
+	if err != nil {
+		return err
 	}
-	return h.Template.ExecuteTemplate(w, h.Name, h.Data)
+	return tmpl.ExecuteTemplate(w, h.Name, h.Data)
 }
 
 // WriteContentType sets the Content-Type header.
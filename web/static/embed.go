@@ -4,18 +4,55 @@ import (
 	"embed"
 	"io/fs"
 	"net/http"
+	"os"
+	"strings"
 )
 
 //go:embed css js fonts
 var staticFiles embed.FS
 
+// assetDirs are the directories embedded above; DirHTTPFileSystem serves only these.
+var assetDirs = []string{"css", "js", "fonts"}
+
 // HTTPFileSystem returns an http.FileSystem rooted at the static directory.
 // Use with gin's StaticFS: router.StaticFS("/gui/static", static.HTTPFileSystem())
 func HTTPFileSystem() http.FileSystem {
 	return http.FS(staticFiles)
 }
 
+// DirHTTPFileSystem returns an http.FileSystem serving the same assets as
+// HTTPFileSystem from dir on disk (GUI_DEV_MODE), so CSS and JS edits apply
+// without rebuilding the binary. Files outside the asset directories, such as
+// this package's Go sources, are not served.
+func DirHTTPFileSystem(dir string) http.FileSystem {
+	return http.FS(assetFS{os.DirFS(dir)})
+}
+
 // FS returns the raw embed.FS for direct file access if needed.
 func FS() fs.FS {
 	return staticFiles
 }
+
+// assetFS limits an fs.FS to the asset directories.
+type assetFS struct {
+	fs.FS
+}
+
+// Open opens name if it is the root or lies inside one of the asset directories.
+func (a assetFS) Open(name string) (fs.File, error) {
+	if name != "." && !isAssetPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return a.FS.Open(name)
+}
+
+// isAssetPath reports whether the slash-separated path name is inside an asset directory.
+func isAssetPath(name string) bool {
+	top, _, _ := strings.Cut(name, "/")
+	for _, dir := range assetDirs {
+		if top == dir {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package static
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestHTTPFileSystemServesEmbeddedAssets(t *testing.T) {
+	for _, name := range []string{"/js/htmx.min.js", "/css/bootstrap.min.css"} {
+		f, err := HTTPFileSystem().Open(name)
+		if err != nil {
+			t.Errorf("Open(%s): %v", name, err)
+			continue
+		}
+		_ = f.Close()
+	}
+}
+
+func TestDirHTTPFileSystemServesOnlyAssetDirs(t *testing.T) {
+	// The package directory is the on-disk copy of the embedded assets
+	files := DirHTTPFileSystem(".")
+
+	f, err := files.Open("/js/htmx.min.js")
+	if err != nil {
+		t.Fatalf("Open(/js/htmx.min.js): %v", err)
+	}
+	_ = f.Close()
+
+	for _, name := range []string{"/embed.go", "/embed_test.go", "/../static/embed.go"} {
+		if _, err := files.Open(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(%s) error = %v, want not exist", name, err)
+		}
+	}
+}